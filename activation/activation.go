@@ -181,6 +181,10 @@ func (b *Builder) loop() {
 	if err := b.waitOrStop(b.layerClock.AwaitLayer(1)); err != nil {
 		return
 	}
+	events.Publish(events.NodeEvent{Kind: events.NodeEventSmeshingStarted, Layer: b.layerClock.GetCurrentLayer()})
+	defer func() {
+		events.Publish(events.NodeEvent{Kind: events.NodeEventSmeshingStopped, Layer: b.layerClock.GetCurrentLayer()})
+	}()
 	for {
 		select {
 		case <-b.stop:
@@ -425,6 +429,7 @@ func (b *Builder) PublishActivationTx() error {
 
 	b.log.Event().Info("atx published!", atx.Fields(size)...)
 	events.Publish(events.AtxCreated{Created: true, ID: atx.ShortString(), Layer: uint64(b.currentEpoch())})
+	events.Publish(events.NodeEvent{Kind: events.NodeEventAtxPublished, Layer: atx.PubLayerID, Details: atx.ShortString()})
 
 	select {
 	case <-atxReceived:
@@ -508,8 +513,12 @@ func (b *Builder) discardChallengeIfStale() bool {
 }
 
 // ExtractPublicKey extracts public key from message and verifies public key exists in idStore, this is how we validate
-// ATX signature. If this is the first ATX it is considered valid anyways and ATX syntactic validation will determine ATX validity
+// ATX signature. If this is the first ATX it is considered valid anyways and ATX syntactic validation will determine ATX validity.
+// The public key cached by types.ExtractSigners is used if there is one.
 func ExtractPublicKey(signedAtx *types.ActivationTx) (*signing.PublicKey, error) {
+	if pub := signedAtx.Signer(); pub != nil {
+		return pub, nil
+	}
 	bts, err := signedAtx.InnerBytes()
 	if err != nil {
 		return nil, err
@@ -5,6 +5,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/database"
+	"github.com/spacemeshos/go-spacemesh/events"
 	"github.com/spacemeshos/go-spacemesh/log"
 	"github.com/spacemeshos/go-spacemesh/mesh"
 	"github.com/spacemeshos/go-spacemesh/rand"
@@ -61,6 +62,10 @@ func (MockState) LoadState(types.LayerID) error {
 	panic("implement me")
 }
 
+func (MockState) PruneLayerStateRoot(types.LayerID) error {
+	panic("implement me")
+}
+
 func (MockState) GetStateRoot() types.Hash32 {
 	panic("implement me")
 }
@@ -687,6 +692,78 @@ func TestActivationDb_ProcessAtx(t *testing.T) {
 	r.Equal(idx1, res)
 }
 
+func TestActivationDb_ProcessAtx_MultipleAtxs(t *testing.T) {
+	r := require.New(t)
+
+	atxdb, _, _ := getAtxDb("t8")
+	idx1 := types.NodeID{Key: uuid.New().String(), VRFPublicKey: []byte("anton")}
+	coinbase := types.HexToAddress("aaaa")
+	atx1 := newActivationTx(idx1, 0, *types.EmptyATXID, 100, 0, *types.EmptyATXID, coinbase, 3, []types.BlockID{}, &types.NIPST{})
+	atx2 := newActivationTx(idx1, 0, *types.EmptyATXID, 101, 0, *types.EmptyATXID, coinbase, 3, []types.BlockID{}, &types.NIPST{})
+	atx3 := newActivationTx(idx1, 1, atx2.ID(), 1100, 0, atx2.ID(), coinbase, 3, []types.BlockID{}, &types.NIPST{})
+
+	sub := events.Subscribe(events.EventMalfeasance)
+	defer sub.Close()
+
+	r.NoError(atxdb.ProcessAtx(atx1))
+	_, err := atxdb.GetMalfeasanceProof(idx1)
+	r.Equal(database.ErrNotFound, err)
+
+	// processing the same atx again is fine
+	r.NoError(atxdb.ProcessAtx(atx1))
+	_, err = atxdb.GetMalfeasanceProof(idx1)
+	r.Equal(database.ErrNotFound, err)
+
+	// a second atx in the same epoch is not
+	r.NoError(atxdb.ProcessAtx(atx2))
+	proof, err := atxdb.GetMalfeasanceProof(types.NodeID{Key: idx1.Key})
+	r.NoError(err)
+	r.Equal(idx1, proof.NodeID)
+	r.Equal(types.MultipleAtxs, proof.Type)
+	r.Equal(types.LayerID(101), proof.Layer)
+	r.Len(proof.Messages, 2)
+	first, err := types.BytesToAtx(proof.Messages[0])
+	r.NoError(err)
+	first.CalcAndSetID()
+	r.Equal(atx1.ID(), first.ID())
+
+	ev := <-sub.Events()
+	r.Equal(proof, ev.(events.MalfeasanceDetected).Proof)
+
+	// atxs in a later epoch are not malfeasance
+	r.NoError(atxdb.ProcessAtx(atx3))
+	r.Len(sub.Events(), 0)
+}
+
+func TestActivationDb_AddMalfeasanceProof(t *testing.T) {
+	r := require.New(t)
+
+	atxdb, _, _ := getAtxDb("t9")
+	idx1 := types.NodeID{Key: uuid.New().String(), VRFPublicKey: []byte("anton")}
+	proof := &types.MalfeasanceProof{NodeID: idx1, Layer: 10, Type: types.HareEquivocation, Messages: [][]byte{{1}, {2}}}
+
+	sub := events.Subscribe(events.EventMalfeasance)
+	defer sub.Close()
+
+	added, err := atxdb.AddMalfeasanceProof(proof)
+	r.NoError(err)
+	r.True(added)
+	stored, err := atxdb.GetMalfeasanceProof(idx1)
+	r.NoError(err)
+	r.Equal(proof, stored)
+	ev := <-sub.Events()
+	r.Equal(proof, ev.(events.MalfeasanceDetected).Proof)
+
+	// only the first proof of a smesher is kept
+	added, err = atxdb.AddMalfeasanceProof(&types.MalfeasanceProof{NodeID: idx1, Layer: 11, Type: types.MultipleAtxs})
+	r.NoError(err)
+	r.False(added)
+	stored, err = atxdb.GetMalfeasanceProof(idx1)
+	r.NoError(err)
+	r.Equal(proof, stored)
+	r.Len(sub.Events(), 0)
+}
+
 func BenchmarkActivationDb_SyntacticallyValidateAtx(b *testing.B) {
 	r := require.New(b)
 	nopLogger := log.NewDefault("").WithOptions(log.Nop)
@@ -42,6 +42,10 @@ func getAtxBodyKey(atxID types.ATXID) []byte {
 	return []byte(fmt.Sprintf("b_%v", atxID.Bytes()))
 }
 
+func getMalfeasanceKey(nodeID types.NodeID) []byte {
+	return []byte(fmt.Sprintf("m_%v", nodeID.Key))
+}
+
 var errInvalidSig = fmt.Errorf("identity not found when validating signature, invalid atx")
 
 type atxChan struct {
@@ -66,6 +70,7 @@ type DB struct {
 	processAtxMutex   sync.Mutex
 	assLock           sync.Mutex
 	atxChannels       map[types.ATXID]*atxChan
+	malfeasanceMutex  sync.Mutex
 }
 
 // NewDB creates a new struct of type DB, this struct will hold the atxs received from all nodes and
@@ -170,10 +175,14 @@ func (db *DB) ProcessAtx(atx *types.ActivationTx) error {
 	} else {
 		db.log.With().Info("ATX is valid", atx.ID())
 	}
+	if err := db.detectMultipleAtxs(atx); err != nil {
+		db.log.With().Error("failed to check atx for malfeasance", atx.ID(), log.Err(err))
+	}
 	err = db.StoreAtx(epoch, atx)
 	if err != nil {
 		return fmt.Errorf("cannot store atx %s: %v", atx.ShortString(), err)
 	}
+	events.Publish(events.AtxInMesh{Atx: atx})
 
 	err = db.StoreNodeIdentity(atx.NodeID)
 	if err != nil {
@@ -601,6 +610,71 @@ func (db *DB) addNodeAtxToEpoch(epoch types.EpochID, nodeID types.NodeID, atx *t
 	return nil
 }
 
+// detectMultipleAtxs checks whether the smesher that published atx already published a different ATX in the same epoch.
+// If so, a malfeasance proof is stored and published.
+func (db *DB) detectMultipleAtxs(atx *types.ActivationTx) error {
+	prevID, err := db.GetNodeAtxIDForEpoch(atx.NodeID, atx.PubLayerID.GetEpoch())
+	if err != nil || prevID == atx.ID() {
+		// no other atx in this epoch
+		return nil
+	}
+	prev, err := db.GetFullAtx(prevID)
+	if err != nil {
+		return fmt.Errorf("failed to get atx %v: %v", prevID.ShortString(), err)
+	}
+	prevBytes, err := types.InterfaceToBytes(prev)
+	if err != nil {
+		return err
+	}
+	atxBytes, err := types.InterfaceToBytes(atx)
+	if err != nil {
+		return err
+	}
+	proof := &types.MalfeasanceProof{
+		NodeID:   atx.NodeID,
+		Layer:    atx.PubLayerID,
+		Type:     types.MultipleAtxs,
+		Messages: [][]byte{prevBytes, atxBytes},
+	}
+	db.log.With().Warning("smesher published multiple atxs in the same epoch",
+		append(proof.Fields(), log.FieldNamed("first_atx", prevID), log.FieldNamed("second_atx", atx.ID()))...)
+	_, err = db.AddMalfeasanceProof(proof)
+	return err
+}
+
+// AddMalfeasanceProof stores and publishes the malfeasance proof of a smesher. A single proof is enough, so only the
+// first proof found for a smesher is kept. It returns true if the proof was added.
+func (db *DB) AddMalfeasanceProof(proof *types.MalfeasanceProof) (bool, error) {
+	db.malfeasanceMutex.Lock()
+	defer db.malfeasanceMutex.Unlock()
+	if _, err := db.GetMalfeasanceProof(proof.NodeID); err == nil {
+		return false, nil
+	}
+	proofBytes, err := types.InterfaceToBytes(proof)
+	if err != nil {
+		return false, err
+	}
+	if err := db.atxs.Put(getMalfeasanceKey(proof.NodeID), proofBytes); err != nil {
+		return false, fmt.Errorf("failed to store malfeasance proof: %v", err)
+	}
+	events.Publish(events.MalfeasanceDetected{Proof: proof})
+	return true, nil
+}
+
+// GetMalfeasanceProof returns the malfeasance proof stored for the smesher with the given node ID. Only the Key of the
+// node ID is used for the lookup. It returns database.ErrNotFound if there's no proof for the smesher.
+func (db *DB) GetMalfeasanceProof(nodeID types.NodeID) (*types.MalfeasanceProof, error) {
+	proofBytes, err := db.atxs.Get(getMalfeasanceKey(nodeID))
+	if err != nil {
+		return nil, err
+	}
+	var proof types.MalfeasanceProof
+	if err := types.BytesToInterface(proofBytes, &proof); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal malfeasance proof: %v", err)
+	}
+	return &proof, nil
+}
+
 // ErrAtxNotFound is a specific error returned when no atx was found in DB
 type ErrAtxNotFound error
 
@@ -738,6 +812,12 @@ func (db *DB) HandleGossipAtx(data service.GossipMessage, syncer service.Syncer)
 		return
 	}
 	atx.CalcAndSetID()
+	// the signer is extracted before the references of the ATX are fetched, so a malformed signature doesn't cost a
+	// fetch. Gossip ATXs are handled by several workers of the gossip listener, which spreads the extraction over CPUs
+	if err := types.ExtractSigners([]*types.ActivationTx{atx}); err != nil {
+		db.log.With().Warning("received ATX with invalid signature", atx.ID(), log.Err(err))
+		return
+	}
 
 	db.log.With().Info("got new ATX", atx.Fields(len(data.Bytes()))...)
 
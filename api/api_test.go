@@ -181,6 +181,42 @@ func (t *TxAPIMock) AddressExists(types.Address) bool {
 	return true
 }
 
+func (t *TxAPIMock) ProcessedLayer() types.LayerID {
+	return ValidatedLayerID
+}
+
+func (t *TxAPIMock) GetLayer(i types.LayerID) (*types.Layer, error) {
+	return types.NewLayer(i), nil
+}
+
+func (t *TxAPIMock) GetBlock(id types.BlockID) (*types.Block, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (t *TxAPIMock) GetATXs(atxIds []types.ATXID) (map[types.ATXID]*types.ActivationTx, []types.ATXID) {
+	return nil, atxIds
+}
+
+func (t *TxAPIMock) GetTransactions(txIds []types.TransactionID) (txs []*types.Transaction, missing map[types.TransactionID]struct{}) {
+	missing = make(map[types.TransactionID]struct{})
+	for _, id := range txIds {
+		if tx, ok := t.returnTx[id]; ok {
+			txs = append(txs, tx)
+		} else {
+			missing[id] = struct{}{}
+		}
+	}
+	return
+}
+
+func (t *TxAPIMock) GetCoinbaseRewards(types.Address, types.LayerID, types.LayerID) ([]mesh.CoinbaseReward, error) {
+	return nil, nil
+}
+
+func (t *TxAPIMock) GetAccountTransactions(types.Address, mesh.TxDirection, types.LayerID, types.LayerID, []byte, int) ([]mesh.AccountTx, []byte, error) {
+	return nil, nil, nil
+}
+
 // MiningAPIMock is a mock for mining API
 type MiningAPIMock struct{}
 
@@ -376,7 +412,7 @@ func TestJsonWalletApi(t *testing.T) {
 
 	r.Zero(nodeStatus.Peers)
 	r.Equal(uint64(5), nodeStatus.MinPeers)
-	r.Equal(uint64(105), nodeStatus.MaxPeers)
+	r.Equal(uint64(120), nodeStatus.MaxPeers)
 	r.False(nodeStatus.Synced)
 	r.Equal(uint64(10), nodeStatus.SyncedLayer)
 	r.Equal(uint64(1), nodeStatus.CurrentLayer)
@@ -6,15 +6,32 @@ import (
 )
 
 const (
-	defaultStartGRPCServer    = false
-	defaultGRPCServerPort     = 9091
-	defaultNewGRPCServerPort  = 9092
-	defaultStartJSONServer    = false
-	defaultStartNewJSONServer = false
-	defaultJSONServerPort     = 9090
-	defaultNewJSONServerPort  = 9093
-	defaultStartNodeService   = false
-	defaultStartMeshService   = false
+	defaultStartGRPCServer         = false
+	defaultGRPCServerPort          = 9091
+	defaultNewGRPCServerPort       = 9092
+	defaultStartJSONServer         = false
+	defaultStartNewJSONServer      = false
+	defaultJSONServerPort          = 9090
+	defaultNewJSONServerPort       = 9093
+	defaultSwaggerUI               = false
+	defaultStartGraphQLServer      = false
+	defaultGraphQLServerPort       = 9094
+	defaultStartJSONRPCServer      = false
+	defaultJSONRPCServerPort       = 9095
+	defaultStartRosettaServer      = false
+	defaultRosettaServerPort       = 9096
+	defaultStartNodeService        = false
+	defaultStartMeshService        = false
+	defaultStartSmesherService     = false
+	defaultStartTxService          = false
+	defaultStartGlobalStateService = false
+	defaultStartDebugService       = false
+	defaultStartAdminService       = false
+	defaultStartEventService       = false
+	defaultMinTxFee                = 0
+	defaultStateHistoryLayers      = 0
+	defaultCheckpointDir           = ""
+	defaultBackupDir               = ""
 )
 
 // Config defines the api config params
@@ -27,9 +44,26 @@ type Config struct {
 	StartNewJSONServer bool     `mapstructure:"json-server-new"`
 	JSONServerPort     int      `mapstructure:"json-port"`
 	NewJSONServerPort  int      `mapstructure:"json-port-new"`
+	SwaggerUI          bool     `mapstructure:"json-swagger-ui"`
+	StartGraphQLServer bool     `mapstructure:"graphql-server"`
+	GraphQLServerPort  int      `mapstructure:"graphql-port"`
+	StartJSONRPCServer bool     `mapstructure:"jsonrpc-server"`
+	JSONRPCServerPort  int      `mapstructure:"jsonrpc-port"`
+	StartRosettaServer bool     `mapstructure:"rosetta-server"`
+	RosettaServerPort  int      `mapstructure:"rosetta-port"`
+	MinTxFee           uint64   `mapstructure:"min-tx-fee"`
+	StateHistoryLayers uint64   `mapstructure:"state-history-layers"`
+	CheckpointDir      string   `mapstructure:"checkpoint-dir"`
+	BackupDir          string   `mapstructure:"backup-dir"`
 	// no direct command line flags for these
-	StartNodeService bool
-	StartMeshService bool
+	StartNodeService        bool
+	StartMeshService        bool
+	StartSmesherService     bool
+	StartTxService          bool
+	StartGlobalStateService bool
+	StartDebugService       bool
+	StartAdminService       bool
+	StartEventService       bool
 }
 
 func init() {
@@ -39,16 +73,33 @@ func init() {
 // DefaultConfig defines the default configuration options for api
 func DefaultConfig() Config {
 	return Config{
-		StartGrpcServer:    defaultStartGRPCServer, // note: all bool flags default to false so don't set one of these to true here
-		StartGrpcServices:  nil,                    // note: cannot configure an array as a const
-		GrpcServerPort:     defaultGRPCServerPort,
-		NewGrpcServerPort:  defaultNewGRPCServerPort,
-		StartJSONServer:    defaultStartJSONServer,
-		StartNewJSONServer: defaultStartNewJSONServer,
-		JSONServerPort:     defaultJSONServerPort,
-		NewJSONServerPort:  defaultNewJSONServerPort,
-		StartNodeService:   defaultStartNodeService,
-		StartMeshService:   defaultStartMeshService,
+		StartGrpcServer:         defaultStartGRPCServer, // note: all bool flags default to false so don't set one of these to true here
+		StartGrpcServices:       nil,                    // note: cannot configure an array as a const
+		GrpcServerPort:          defaultGRPCServerPort,
+		NewGrpcServerPort:       defaultNewGRPCServerPort,
+		StartJSONServer:         defaultStartJSONServer,
+		StartNewJSONServer:      defaultStartNewJSONServer,
+		JSONServerPort:          defaultJSONServerPort,
+		NewJSONServerPort:       defaultNewJSONServerPort,
+		SwaggerUI:               defaultSwaggerUI,
+		StartGraphQLServer:      defaultStartGraphQLServer,
+		GraphQLServerPort:       defaultGraphQLServerPort,
+		StartJSONRPCServer:      defaultStartJSONRPCServer,
+		JSONRPCServerPort:       defaultJSONRPCServerPort,
+		StartRosettaServer:      defaultStartRosettaServer,
+		RosettaServerPort:       defaultRosettaServerPort,
+		MinTxFee:                defaultMinTxFee,
+		StateHistoryLayers:      defaultStateHistoryLayers,
+		CheckpointDir:           defaultCheckpointDir,
+		BackupDir:               defaultBackupDir,
+		StartNodeService:        defaultStartNodeService,
+		StartMeshService:        defaultStartMeshService,
+		StartSmesherService:     defaultStartSmesherService,
+		StartTxService:          defaultStartTxService,
+		StartGlobalStateService: defaultStartGlobalStateService,
+		StartDebugService:       defaultStartDebugService,
+		StartAdminService:       defaultStartAdminService,
+		StartEventService:       defaultStartEventService,
 	}
 }
 
@@ -61,6 +112,18 @@ func (s *Config) ParseServicesList() error {
 			s.StartMeshService = true
 		case "node":
 			s.StartNodeService = true
+		case "smesher":
+			s.StartSmesherService = true
+		case "transaction":
+			s.StartTxService = true
+		case "globalstate":
+			s.StartGlobalStateService = true
+		case "debug":
+			s.StartDebugService = true
+		case "admin":
+			s.StartAdminService = true
+		case "events":
+			s.StartEventService = true
 		default:
 			return errors.New("unrecognized GRPC service requested: " + svc)
 		}
@@ -68,9 +131,25 @@ func (s *Config) ParseServicesList() error {
 
 	// If JSON gateway server is enabled, make sure at least one
 	// GRPC service is also enabled
-	if s.StartNewJSONServer && !s.StartNodeService {
+	if s.StartNewJSONServer && !s.anyServiceEnabled() {
 		return errors.New("must enable at least one GRPC service along with JSON gateway service")
 	}
 
+	// The JSON-RPC server forwards its methods to the GRPC services as well
+	if s.StartJSONRPCServer && !s.anyServiceEnabled() {
+		return errors.New("must enable at least one GRPC service along with JSON-RPC server")
+	}
+
+	// So does the Rosetta server
+	if s.StartRosettaServer && !s.anyServiceEnabled() {
+		return errors.New("must enable at least one GRPC service along with Rosetta server")
+	}
+
 	return nil
 }
+
+func (s *Config) anyServiceEnabled() bool {
+	return s.StartNodeService || s.StartMeshService || s.StartSmesherService || s.StartTxService ||
+		s.StartGlobalStateService || s.StartDebugService || s.StartAdminService ||
+		s.StartEventService
+}
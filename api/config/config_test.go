@@ -0,0 +1,43 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_ParseServicesList(t *testing.T) {
+	r := require.New(t)
+
+	conf := DefaultConfig()
+	conf.StartGrpcServices = []string{"mesh", "transaction", "globalstate", "events"}
+	r.NoError(conf.ParseServicesList())
+	r.True(conf.StartEventService)
+	r.True(conf.StartMeshService)
+	r.True(conf.StartTxService)
+	r.True(conf.StartGlobalStateService)
+	r.False(conf.StartNodeService)
+
+	conf = DefaultConfig()
+	conf.StartGrpcServices = []string{"mesh", "unknown"}
+	r.Error(conf.ParseServicesList())
+
+	// the json gateway needs at least one service, but it doesn't have to be the node service
+	conf = DefaultConfig()
+	conf.StartNewJSONServer = true
+	r.Error(conf.ParseServicesList())
+	conf.StartGrpcServices = []string{"debug"}
+	r.NoError(conf.ParseServicesList())
+
+	conf = DefaultConfig()
+	conf.StartJSONRPCServer = true
+	r.Error(conf.ParseServicesList())
+	conf.StartGrpcServices = []string{"globalstate"}
+	r.NoError(conf.ParseServicesList())
+
+	conf = DefaultConfig()
+	conf.StartRosettaServer = true
+	r.Error(conf.ParseServicesList())
+	conf.StartGrpcServices = []string{"mesh", "globalstate"}
+	r.NoError(conf.ParseServicesList())
+}
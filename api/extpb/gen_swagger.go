@@ -0,0 +1,53 @@
+// +build ignore
+
+// gen_swagger embeds the swagger definitions generated by protoc-gen-swagger for the local api extensions in
+// swagger_definitions.go, so they can be served by a node without the definition files. Run it with go generate after
+// generating the definitions (see scripts/genproto.sh).
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const output = "swagger_definitions.go"
+
+func main() {
+	files, err := filepath.Glob("*.swagger.json")
+	if err != nil {
+		log.Fatal(err)
+	}
+	sort.Strings(files)
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by gen_swagger.go. DO NOT EDIT.\n\n")
+	buf.WriteString("package extpb\n\n")
+	buf.WriteString("var swaggerDefinitions = map[string][]byte{\n")
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			log.Fatal(err)
+		}
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, data); err != nil {
+			log.Fatalf("invalid swagger definition %v: %v", file, err)
+		}
+		fmt.Fprintf(&buf, "\t%q: []byte(%q),\n", strings.TrimSuffix(file, ".swagger.json"), compact.String())
+	}
+	buf.WriteString("}\n")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile(output, src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
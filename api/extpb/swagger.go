@@ -0,0 +1,10 @@
+package extpb
+
+//go:generate go run gen_swagger.go
+
+// SwaggerDefinition returns the swagger (OpenAPI v2) definition of the api extensions declared in the given proto file,
+// e.g. "mesh" for mesh.proto, and whether such a definition exists
+func SwaggerDefinition(name string) ([]byte, bool) {
+	def, ok := swaggerDefinitions[name]
+	return def, ok
+}
@@ -0,0 +1,15 @@
+// Code generated by gen_swagger.go. DO NOT EDIT.
+
+package extpb
+
+var swaggerDefinitions = map[string][]byte{
+	"admin":       []byte("{\"swagger\":\"2.0\",\"info\":{\"title\":\"api/extpb/admin.proto\",\"version\":\"version not set\"},\"consumes\":[\"application/json\"],\"produces\":[\"application/json\"],\"paths\":{\"/v1/admin/backup\":{\"post\":{\"summary\":\"Backs up the databases of the node while it runs, from snapshots of all databases taken together. The backup is\\nstreamed back as a tarball in chunks, or written to the backup directory of the node.\",\"operationId\":\"AdminService_Backup\",\"responses\":{\"200\":{\"description\":\"A successful response.(streaming responses)\",\"schema\":{\"type\":\"object\",\"properties\":{\"result\":{\"$ref\":\"#/definitions/extBackupResponse\"},\"error\":{\"$ref\":\"#/definitions/runtimeStreamError\"}},\"title\":\"Stream result of extBackupResponse\"}},\"default\":{\"description\":\"An unexpected error response\",\"schema\":{\"$ref\":\"#/definitions/runtimeError\"}}},\"parameters\":[{\"name\":\"body\",\"in\":\"body\",\"required\":true,\"schema\":{\"$ref\":\"#/definitions/extBackupRequest\"}}],\"tags\":[\"AdminService\"]}},\"/v1/admin/ban\":{\"post\":{\"summary\":\"Bans a peer ID or an IP address, optionally until the ban expires. Connections to banned peers are closed, and\\nthey can't connect or be connected to. The banlist persists across restarts.\",\"operationId\":\"AdminService_Ban\",\"responses\":{\"200\":{\"description\":\"A successful response.\",\"schema\":{\"$ref\":\"#/definitions/extBanResponse\"}},\"default\":{\"description\":\"An unexpected error response\",\"schema\":{\"$ref\":\"#/definitions/runtimeError\"}}},\"parameters\":[{\"name\":\"body\",\"in\":\"body\",\"required\":true,\"schema\":{\"$ref\":\"#/definitions/extBanRequest\"}}],\"tags\":[\"AdminService\"]}},\"/v1/admin/checkdatabases\":{\"post\":{\"summary\":\"Checks that the blocks of the validated layers are stored along with the transactions and ATXs they reference,\\nand that the state of the latest layer applied to the state is complete. With repair, the missing blocks,\\ntransactions and ATXs are fetched from peers.\",\"operationId\":\"AdminService_CheckDatabases\",\"responses\":{\"200\":{\"description\":\"A successful response.\",\"schema\":{\"$ref\":\"#/definitions/extCheckDatabasesResponse\"}},\"default\":{\"description\":\"An unexpected error response\",\"schema\":{\"$ref\":\"#/definitions/runtimeError\"}}},\"parameters\":[{\"name\":\"body\",\"in\":\"body\",\"required\":true,\"schema\":{\"$ref\":\"#/definitions/extCheckDatabasesRequest\"}}],\"tags\":[\"AdminService\"]}},\"/v1/admin/checkpoint\":{\"post\":{\"summary\":\"Creates a checkpoint of the accounts and recent ATXs at the latest layer applied to the global state. The\\ncheckpoint is streamed back in chunks, or written to the checkpoint directory of the node.\",\"operationId\":\"AdminService_Checkpoint\",\"responses\":{\"200\":{\"description\":\"A successful response.(streaming responses)\",\"schema\":{\"type\":\"object\",\"properties\":{\"result\":{\"$ref\":\"#/definitions/extCheckpointResponse\"},\"error\":{\"$ref\":\"#/definitions/runtimeStreamError\"}},\"title\":\"Stream result of extCheckpointResponse\"}},\"default\":{\"description\":\"An unexpected error response\",\"schema\":{\"$ref\":\"#/definitions/runtimeError\"}}},\"parameters\":[{\"name\":\"body\",\"in\":\"body\",\"required\":true,\"schema\":{\"$ref\":\"#/definitions/extCheckpointRequest\"}}],\"tags\":[\"AdminService\"]}},\"/v1/admin/compactdatabases\":{\"post\":{\"summary\":\"Compacts the databases of the node one after the other while it runs, to reclaim the space of deleted data.\\nReturns when all databases are compacted.\",\"operationId\":\"AdminService_CompactDatabases\",\"responses\":{\"200\":{\"description\":\"A successful response.\",\"schema\":{\"$ref\":\"#/definitions/extCompactDatabasesResponse\"}},\"default\":{\"description\":\"An unexpected error response\",\"schema\":{\"$ref\":\"#/definitions/runtimeError\"}}},\"parameters\":[{\"name\":\"body\",\"in\":\"body\",\"required\":true,\"schema\":{\"$ref\":\"#/definitions/extCompactDatabasesRequest\"}}],\"tags\":[\"AdminService\"]}},\"/v1/admin/eventsstream\":{\"post\":{\"summary\":\"Streams high level events of the node lifecycle\",\"operationId\":\"AdminService_EventsStream\",\"responses\":{\"200\":{\"description\":\"A successful response.(streaming responses)\",\"schema\":{\"type\":\"object\",\"properties\":{\"result\":{\"$ref\":\"#/definitions/extEventsStreamResponse\"},\"error\":{\"$ref\":\"#/definitions/runtimeStreamError\"}},\"title\":\"Stream result of extEventsStreamResponse\"}},\"default\":{\"description\":\"An unexpected error response\",\"schema\":{\"$ref\":\"#/definitions/runtimeError\"}}},\"parameters\":[{\"name\":\"body\",\"in\":\"body\",\"required\":true,\"schema\":{\"$ref\":\"#/definitions/extEventsStreamRequest\"}}],\"tags\":[\"AdminService\"]}},\"/v1/admin/export\":{\"post\":{\"summary\":\"Exports the transactions, rewards or ATXs of a range of layers applied to the global state, for offline\\nanalytics and accounting. The rows are streamed a layer at a time, or an epoch at a time for ATXs.\",\"operationId\":\"AdminService_Export\",\"responses\":{\"200\":{\"description\":\"A successful response.(streaming responses)\",\"schema\":{\"type\":\"object\",\"properties\":{\"result\":{\"$ref\":\"#/definitions/extExportResponse\"},\"error\":{\"$ref\":\"#/definitions/runtimeStreamError\"}},\"title\":\"Stream result of extExportResponse\"}},\"default\":{\"description\":\"An unexpected error response\",\"schema\":{\"$ref\":\"#/definitions/runtimeError\"}}},\"parameters\":[{\"name\":\"body\",\"in\":\"body\",\"required\":true,\"schema\":{\"$ref\":\"#/definitions/extExportRequest\"}}],\"tags\":[\"AdminService\"]}},\"/v1/admin/listbans\":{\"post\":{\"summary\":\"Lists the banned peer IDs and IP addresses, the oldest ban first\",\"operationId\":\"AdminService_ListBans\",\"responses\":{\"200\":{\"description\":\"A successful response.\",\"schema\":{\"$ref\":\"#/definitions/extListBansResponse\"}},\"default\":{\"description\":\"An unexpected error response\",\"schema\":{\"$ref\":\"#/definitions/runtimeError\"}}},\"parameters\":[{\"name\":\"body\",\"in\":\"body\",\"required\":true,\"schema\":{\"$ref\":\"#/definitions/extListBansRequest\"}}],\"tags\":[\"AdminService\"]}},\"/v1/admin/recover\":{\"post\":{\"summary\":\"Downloads and verifies a checkpoint and stages it for recovery. When the node restarts it wipes its mesh and\\nglobal state, restores the checkpoint and syncs forward from the checkpoint layer.\",\"operationId\":\"AdminService_Recover\",\"responses\":{\"200\":{\"description\":\"A successful response.\",\"schema\":{\"$ref\":\"#/definitions/extRecoverResponse\"}},\"default\":{\"description\":\"An unexpected error response\",\"schema\":{\"$ref\":\"#/definitions/runtimeError\"}}},\"parameters\":[{\"name\":\"body\",\"in\":\"body\",\"required\":true,\"schema\":{\"$ref\":\"#/definitions/extRecoverRequest\"}}],\"tags\":[\"AdminService\"]}},\"/v1/admin/setsynclimits\":{\"post\":{\"summary\":\"Changes the bandwidth and request concurrency limits of sync while the node runs, to sync in the background\\nwithout saturating the connection of the node, or to lift the limits\",\"operationId\":\"AdminService_SetSyncLimits\",\"responses\":{\"200\":{\"description\":\"A successful response.\",\"schema\":{\"$ref\":\"#/definitions/extSetSyncLimitsResponse\"}},\"default\":{\"description\":\"An unexpected error response\",\"schema\":{\"$ref\":\"#/definitions/runtimeError\"}}},\"parameters\":[{\"name\":\"body\",\"in\":\"body\",\"required\":true,\"schema\":{\"$ref\":\"#/definitions/extSetSyncLimitsRequest\"}}],\"tags\":[\"AdminService\"]}},\"/v1/admin/synclimits\":{\"post\":{\"summary\":\"Returns the bandwidth and request concurrency limits of sync\",\"operationId\":\"AdminService_SyncLimits\",\"responses\":{\"200\":{\"description\":\"A successful response.\",\"schema\":{\"$ref\":\"#/definitions/extSyncLimitsResponse\"}},\"default\":{\"description\":\"An unexpected error response\",\"schema\":{\"$ref\":\"#/definitions/runtimeError\"}}},\"parameters\":[{\"name\":\"body\",\"in\":\"body\",\"required\":true,\"schema\":{\"$ref\":\"#/definitions/extSyncLimitsRequest\"}}],\"tags\":[\"AdminService\"]}},\"/v1/admin/unban\":{\"post\":{\"summary\":\"Lifts the ban of a peer ID or an IP address\",\"operationId\":\"AdminService_Unban\",\"responses\":{\"200\":{\"description\":\"A successful response.\",\"schema\":{\"$ref\":\"#/definitions/extUnbanResponse\"}},\"default\":{\"description\":\"An unexpected error response\",\"schema\":{\"$ref\":\"#/definitions/runtimeError\"}}},\"parameters\":[{\"name\":\"body\",\"in\":\"body\",\"required\":true,\"schema\":{\"$ref\":\"#/definitions/extUnbanRequest\"}}],\"tags\":[\"AdminService\"]}}},\"definitions\":{\"extBackupDatabase\":{\"type\":\"object\",\"properties\":{\"name\":{\"type\":\"string\"},\"keys\":{\"type\":\"string\",\"format\":\"uint64\"},\"size\":{\"type\":\"string\",\"format\":\"uint64\"},\"hash\":{\"type\":\"string\",\"format\":\"byte\"}}},\"extBackupRequest\":{\"type\":\"object\",\"properties\":{\"write_to_dir\":{\"type\":\"boolean\",\"format\":\"boolean\"}}},\"extBackupResponse\":{\"type\":\"object\",\"properties\":{\"created\":{\"type\":\"string\",\"format\":\"uint64\"},\"databases\":{\"type\":\"array\",\"items\":{\"$ref\":\"#/definitions/extBackupDatabase\"}},\"size\":{\"type\":\"string\",\"format\":\"uint64\"},\"path\":{\"type\":\"string\"},\"data\":{\"type\":\"string\",\"format\":\"byte\"}},\"title\":\"The first response describes the backup, the following responses carry the tarball unless it was written to a\\ndirectory\"},\"extBanEntry\":{\"type\":\"object\",\"properties\":{\"target\":{\"$ref\":\"#/definitions/extBanTarget\"},\"created\":{\"type\":\"string\",\"format\":\"uint64\"},\"expires\":{\"type\":\"string\",\"format\":\"uint64\"}}},\"extBanRequest\":{\"type\":\"object\",\"properties\":{\"target\":{\"$ref\":\"#/definitions/extBanTarget\"},\"duration\":{\"type\":\"string\",\"format\":\"uint64\"}}},\"extBanResponse\":{\"type\":\"object\"},\"extBanTarget\":{\"type\":\"object\",\"properties\":{\"peer_id\":{\"type\":\"string\",\"format\":\"byte\"},\"ip\":{\"type\":\"string\"}},\"title\":\"BanTarget is a peer ID or an IP address, exactly one of them must be set\"},\"extCheckDatabasesRequest\":{\"type\":\"object\",\"properties\":{\"repair\":{\"type\":\"boolean\",\"format\":\"boolean\"}}},\"extCheckDatabasesResponse\":{\"type\":\"object\",\"properties\":{\"from_layer\":{\"type\":\"string\",\"format\":\"uint64\"},\"to_layer\":{\"type\":\"string\",\"format\":\"uint64\"},\"blocks\":{\"type\":\"string\",\"format\":\"uint64\"},\"transactions\":{\"type\":\"string\",\"format\":\"uint64\"},\"atxs\":{\"type\":\"string\",\"format\":\"uint64\"},\"state_layer\":{\"type\":\"string\",\"format\":\"uint64\"},\"issues\":{\"type\":\"array\",\"items\":{\"$ref\":\"#/definitions/extDatabaseIssue\"}},\"duration_ms\":{\"type\":\"string\",\"format\":\"uint64\"}}},\"extCheckpointRequest\":{\"type\":\"object\",\"properties\":{\"write_to_file\":{\"type\":\"boolean\",\"format\":\"boolean\"}}},\"extCheckpointResponse\":{\"type\":\"object\",\"properties\":{\"layer\":{\"type\":\"string\",\"format\":\"uint64\"},\"state_root\":{\"type\":\"string\",\"format\":\"byte\"},\"accounts\":{\"type\":\"string\",\"format\":\"uint64\"},\"atxs\":{\"type\":\"string\",\"format\":\"uint64\"},\"hash\":{\"type\":\"string\",\"format\":\"byte\"},\"size\":{\"type\":\"string\",\"format\":\"uint64\"},\"path\":{\"type\":\"string\"},\"data\":{\"type\":\"string\",\"format\":\"byte\"}},\"title\":\"The first response describes the checkpoint, the following responses carry its data unless it was written to a file\"},\"extCompactDatabasesRequest\":{\"type\":\"object\"},\"extCompactDatabasesResponse\":{\"type\":\"object\",\"properties\":{\"databases\":{\"type\":\"array\",\"items\":{\"$ref\":\"#/definitions/extDatabaseCompaction\"}},\"reclaimed\":{\"type\":\"string\",\"format\":\"int64\"},\"duration_ms\":{\"type\":\"string\",\"format\":\"uint64\"}}},\"extDatabaseCompaction\":{\"type\":\"object\",\"properties\":{\"name\":{\"type\":\"string\"},\"size_before\":{\"type\":\"string\",\"format\":\"uint64\"},\"size_after\":{\"type\":\"string\",\"format\":\"uint64\"},\"reclaimed\":{\"type\":\"string\",\"format\":\"int64\"},\"duration_ms\":{\"type\":\"string\",\"format\":\"uint64\"},\"error\":{\"type\":\"string\"}}},\"extDatabaseIssue\":{\"type\":\"object\",\"properties\":{\"kind\":{\"type\":\"string\"},\"layer\":{\"type\":\"string\",\"format\":\"uint64\"},\"id\":{\"type\":\"string\",\"format\":\"byte\"},\"repaired\":{\"type\":\"boolean\",\"format\":\"boolean\"},\"details\":{\"type\":\"string\"}}},\"extEventsStreamRequest\":{\"type\":\"object\"},\"extEventsStreamResponse\":{\"type\":\"object\",\"properties\":{\"kind\":{\"$ref\":\"#/definitions/extNodeEventKind\"},\"layer\":{\"type\":\"string\",\"format\":\"uint64\"},\"details\":{\"type\":\"string\"},\"timestamp\":{\"type\":\"string\",\"format\":\"uint64\"}}},\"extExportDataset\":{\"type\":\"string\",\"enum\":[\"EXPORT_DATASET_UNSPECIFIED\",\"EXPORT_DATASET_TRANSACTIONS\",\"EXPORT_DATASET_REWARDS\",\"EXPORT_DATASET_ATXS\"],\"default\":\"EXPORT_DATASET_UNSPECIFIED\"},\"extExportRequest\":{\"type\":\"object\",\"properties\":{\"dataset\":{\"$ref\":\"#/definitions/extExportDataset\"},\"start_layer\":{\"type\":\"string\",\"format\":\"uint64\"},\"end_layer\":{\"type\":\"string\",\"format\":\"uint64\"}}},\"extExportResponse\":{\"type\":\"object\",\"properties\":{\"transactions\":{\"type\":\"array\",\"items\":{\"$ref\":\"#/definitions/extExportedTransaction\"}},\"rewards\":{\"type\":\"array\",\"items\":{\"$ref\":\"#/definitions/extExportedReward\"}},\"atxs\":{\"type\":\"array\",\"items\":{\"$ref\":\"#/definitions/extExportedAtx\"}}},\"title\":\"Every response carries the rows of a single dataset\"},\"extExportedAtx\":{\"type\":\"object\",\"properties\":{\"id\":{\"type\":\"string\",\"format\":\"byte\"},\"layer\":{\"type\":\"string\",\"format\":\"uint64\"},\"smesher_id\":{\"type\":\"string\",\"format\":\"byte\"},\"coinbase\":{\"type\":\"string\",\"format\":\"byte\"},\"sequence\":{\"type\":\"string\",\"format\":\"uint64\"},\"prev_atx\":{\"type\":\"string\",\"format\":\"byte\"},\"positioning_atx\":{\"type\":\"string\",\"format\":\"byte\"},\"space\":{\"type\":\"string\",\"format\":\"uint64\"}}},\"extExportedReward\":{\"type\":\"object\",\"properties\":{\"layer\":{\"type\":\"string\",\"format\":\"uint64\"},\"coinbase\":{\"type\":\"string\",\"format\":\"byte\"},\"blocks\":{\"type\":\"string\",\"format\":\"uint64\"},\"total\":{\"type\":\"string\",\"format\":\"uint64\"},\"layer_reward\":{\"type\":\"string\",\"format\":\"uint64\"}}},\"extExportedTransaction\":{\"type\":\"object\",\"properties\":{\"id\":{\"type\":\"string\",\"format\":\"byte\"},\"layer\":{\"type\":\"string\",\"format\":\"uint64\"},\"origin\":{\"type\":\"string\",\"format\":\"byte\"},\"recipient\":{\"type\":\"string\",\"format\":\"byte\"},\"amount\":{\"type\":\"string\",\"format\":\"uint64\"},\"fee\":{\"type\":\"string\",\"format\":\"uint64\"},\"nonce\":{\"type\":\"string\",\"format\":\"uint64\"},\"gas_limit\":{\"type\":\"string\",\"format\":\"uint64\"}}},\"extListBansRequest\":{\"type\":\"object\"},\"extListBansResponse\":{\"type\":\"object\",\"properties\":{\"bans\":{\"type\":\"array\",\"items\":{\"$ref\":\"#/definitions/extBanEntry\"}}}},\"extNodeEventKind\":{\"type\":\"string\",\"enum\":[\"NODE_EVENT_KIND_UNSPECIFIED\",\"NODE_EVENT_KIND_SMESHING_STARTED\",\"NODE_EVENT_KIND_SMESHING_STOPPED\",\"NODE_EVENT_KIND_ATX_PUBLISHED\",\"NODE_EVENT_KIND_BEACON_COMPUTED\",\"NODE_EVENT_KIND_SYNC_STARTED\",\"NODE_EVENT_KIND_SYNC_COMPLETED\",\"NODE_EVENT_KIND_BEACON_FALLBACK\",\"NODE_EVENT_KIND_TORTOISE_DIVERGED\",\"NODE_EVENT_KIND_REORG_REJECTED\"],\"default\":\"NODE_EVENT_KIND_UNSPECIFIED\",\"title\":\"NodeEventKind is the kind of a high level event in the lifecycle of the node\"},\"extRecoverRequest\":{\"type\":\"object\",\"properties\":{\"uri\":{\"type\":\"string\"},\"hash\":{\"type\":\"string\",\"format\":\"byte\"}}},\"extRecoverResponse\":{\"type\":\"object\",\"properties\":{\"layer\":{\"type\":\"string\",\"format\":\"uint64\"},\"state_root\":{\"type\":\"string\",\"format\":\"byte\"},\"hash\":{\"type\":\"string\",\"format\":\"byte\"}}},\"extSetSyncLimitsRequest\":{\"type\":\"object\",\"properties\":{\"limits\":{\"$ref\":\"#/definitions/extSyncLimits\"}}},\"extSetSyncLimitsResponse\":{\"type\":\"object\"},\"extSyncLimits\":{\"type\":\"object\",\"properties\":{\"bandwidth\":{\"type\":\"string\",\"format\":\"uint64\"},\"requests\":{\"type\":\"integer\",\"format\":\"int64\"}}},\"extSyncLimitsRequest\":{\"type\":\"object\"},\"extSyncLimitsResponse\":{\"type\":\"object\",\"properties\":{\"limits\":{\"$ref\":\"#/definitions/extSyncLimits\"}}},\"extUnbanRequest\":{\"type\":\"object\",\"properties\":{\"target\":{\"$ref\":\"#/definitions/extBanTarget\"}}},\"extUnbanResponse\":{\"type\":\"object\",\"properties\":{\"found\":{\"type\":\"boolean\",\"format\":\"boolean\"}}},\"protobufAny\":{\"type\":\"object\",\"properties\":{\"type_url\":{\"type\":\"string\"},\"value\":{\"type\":\"string\",\"format\":\"byte\"}}},\"runtimeError\":{\"type\":\"object\",\"properties\":{\"error\":{\"type\":\"string\"},\"code\":{\"type\":\"integer\",\"format\":\"int32\"},\"message\":{\"type\":\"string\"},\"details\":{\"type\":\"array\",\"items\":{\"$ref\":\"#/definitions/protobufAny\"}}}},\"runtimeStreamError\":{\"type\":\"object\",\"properties\":{\"grpc_code\":{\"type\":\"integer\",\"format\":\"int32\"},\"http_code\":{\"type\":\"integer\",\"format\":\"int32\"},\"message\":{\"type\":\"string\"},\"http_status\":{\"type\":\"string\"},\"details\":{\"type\":\"array\",\"items\":{\"$ref\":\"#/definitions/protobufAny\"}}}}}}"),
+	"debug":       []byte("{\"swagger\":\"2.0\",\"info\":{\"title\":\"api/extpb/debug.proto\",\"version\":\"version not set\"},\"consumes\":[\"application/json\"],\"produces\":[\"application/json\"],\"paths\":{\"/v1/debug/accounts\":{\"post\":{\"summary\":\"Streams all accounts of the current global state in chunks\",\"operationId\":\"DebugService_Accounts\",\"responses\":{\"200\":{\"description\":\"A successful response.(streaming responses)\",\"schema\":{\"type\":\"object\",\"properties\":{\"result\":{\"$ref\":\"#/definitions/extAccountsResponse\"},\"error\":{\"$ref\":\"#/definitions/runtimeStreamError\"}},\"title\":\"Stream result of extAccountsResponse\"}},\"default\":{\"description\":\"An unexpected error response\",\"schema\":{\"$ref\":\"#/definitions/runtimeError\"}}},\"parameters\":[{\"name\":\"body\",\"in\":\"body\",\"required\":true,\"schema\":{\"$ref\":\"#/definitions/extAccountsRequest\"}}],\"tags\":[\"DebugService\"]}},\"/v1/debug/beaconstatus\":{\"post\":{\"summary\":\"Returns how the hare beacon values of the layers of an epoch were computed, including the layers whose value fell\\nback to a value derived from the layer below because they had no contextually valid blocks\",\"operationId\":\"DebugService_BeaconStatus\",\"responses\":{\"200\":{\"description\":\"A successful response.\",\"schema\":{\"$ref\":\"#/definitions/extBeaconStatusResponse\"}},\"default\":{\"description\":\"An unexpected error response\",\"schema\":{\"$ref\":\"#/definitions/runtimeError\"}}},\"parameters\":[{\"name\":\"body\",\"in\":\"body\",\"required\":true,\"schema\":{\"$ref\":\"#/definitions/extBeaconStatusRequest\"}}],\"tags\":[\"DebugService\"]}},\"/v1/debug/dataaudit\":{\"post\":{\"summary\":\"Returns the report of the latest audit of the data of the recent layers, which finds the blocks, transactions\\nand ATXs the node is missing and fetches them from peers again. Runs an audit first if asked to.\",\"operationId\":\"DebugService_DataAudit\",\"responses\":{\"200\":{\"description\":\"A successful response.\",\"schema\":{\"$ref\":\"#/definitions/extDataAuditResponse\"}},\"default\":{\"description\":\"An unexpected error response\",\"schema\":{\"$ref\":\"#/definitions/runtimeError\"}}},\"parameters\":[{\"name\":\"body\",\"in\":\"body\",\"required\":true,\"schema\":{\"$ref\":\"#/definitions/extDataAuditRequest\"}}],\"tags\":[\"DebugService\"]}},\"/v1/debug/fetch\":{\"post\":{\"summary\":\"Requests a block, transaction or ATX from all peers by its ID and reports which peers served it, to diagnose and\\nunblock a layer that waits for it. The object is validated and stored like the objects sync fetches.\",\"operationId\":\"DebugService_Fetch\",\"responses\":{\"200\":{\"description\":\"A successful response.\",\"schema\":{\"$ref\":\"#/definitions/extFetchResponse\"}},\"default\":{\"description\":\"An unexpected error response\",\"schema\":{\"$ref\":\"#/definitions/runtimeError\"}}},\"parameters\":[{\"name\":\"body\",\"in\":\"body\",\"required\":true,\"schema\":{\"$ref\":\"#/definitions/extFetchRequest\"}}],\"tags\":[\"DebugService\"]}},\"/v1/debug/layerinternals\":{\"post\":{\"summary\":\"Returns the votes, hare output and tortoise opinion on the blocks of a layer\",\"operationId\":\"DebugService_LayerInternals\",\"responses\":{\"200\":{\"description\":\"A successful response.\",\"schema\":{\"$ref\":\"#/definitions/extLayerInternalsResponse\"}},\"default\":{\"description\":\"An unexpected error response\",\"schema\":{\"$ref\":\"#/definitions/runtimeError\"}}},\"parameters\":[{\"name\":\"body\",\"in\":\"body\",\"required\":true,\"schema\":{\"$ref\":\"#/definitions/extLayerInternalsRequest\"}}],\"tags\":[\"DebugService\"]}},\"/v1/debug/networkinfo\":{\"post\":{\"summary\":\"Returns the addresses, peers and routing table of the node\",\"operationId\":\"DebugService_NetworkInfo\",\"responses\":{\"200\":{\"description\":\"A successful response.\",\"schema\":{\"$ref\":\"#/definitions/extNetworkInfoResponse\"}},\"default\":{\"description\":\"An unexpected error response\",\"schema\":{\"$ref\":\"#/definitions/runtimeError\"}}},\"parameters\":[{\"name\":\"body\",\"in\":\"body\",\"required\":true,\"schema\":{\"$ref\":\"#/definitions/extNetworkInfoRequest\"}}],\"tags\":[\"DebugService\"]}},\"/v1/debug/peerevents\":{\"post\":{\"summary\":\"Streams gossip peers connecting and disconnecting as it happens\",\"operationId\":\"DebugService_PeerEvents\",\"responses\":{\"200\":{\"description\":\"A successful response.(streaming responses)\",\"schema\":{\"type\":\"object\",\"properties\":{\"result\":{\"$ref\":\"#/definitions/extPeerEvent\"},\"error\":{\"$ref\":\"#/definitions/runtimeStreamError\"}},\"title\":\"Stream result of extPeerEvent\"}},\"default\":{\"description\":\"An unexpected error response\",\"schema\":{\"$ref\":\"#/definitions/runtimeError\"}}},\"parameters\":[{\"name\":\"body\",\"in\":\"body\",\"required\":true,\"schema\":{\"$ref\":\"#/definitions/extPeerEventsRequest\"}}],\"tags\":[\"DebugService\"]}},\"/v1/debug/peers\":{\"post\":{\"summary\":\"Returns the connected gossip peers with the details of their connections, the longest connected first\",\"operationId\":\"DebugService_Peers\",\"responses\":{\"200\":{\"description\":\"A successful response.\",\"schema\":{\"$ref\":\"#/definitions/extPeersResponse\"}},\"default\":{\"description\":\"An unexpected error response\",\"schema\":{\"$ref\":\"#/definitions/runtimeError\"}}},\"parameters\":[{\"name\":\"body\",\"in\":\"body\",\"required\":true,\"schema\":{\"$ref\":\"#/definitions/extPeersRequest\"}}],\"tags\":[\"DebugService\"]}}},\"definitions\":{\"extAccount\":{\"type\":\"object\",\"properties\":{\"account_id\":{\"type\":\"string\",\"format\":\"byte\"},\"counter\":{\"type\":\"string\",\"format\":\"uint64\"},\"balance\":{\"type\":\"string\",\"format\":\"uint64\"}}},\"extAccountsRequest\":{\"type\":\"object\",\"properties\":{\"chunk_size\":{\"type\":\"integer\",\"format\":\"int64\"}}},\"extAccountsResponse\":{\"type\":\"object\",\"properties\":{\"root_hash\":{\"type\":\"string\",\"format\":\"byte\"},\"layer\":{\"type\":\"string\",\"format\":\"uint64\"},\"accounts\":{\"type\":\"array\",\"items\":{\"$ref\":\"#/definitions/extAccount\"}}}},\"extBeaconStatusRequest\":{\"type\":\"object\",\"properties\":{\"epoch\":{\"type\":\"string\",\"format\":\"uint64\"}}},\"extBeaconStatusResponse\":{\"type\":\"object\",\"properties\":{\"epoch\":{\"type\":\"string\",\"format\":\"uint64\"},\"computed\":{\"type\":\"integer\",\"format\":\"int64\"},\"fallbacks\":{\"type\":\"integer\",\"format\":\"int64\"},\"value\":{\"type\":\"integer\",\"format\":\"int64\"},\"valid_blocks\":{\"type\":\"integer\",\"format\":\"int64\"},\"fallback\":{\"type\":\"boolean\",\"format\":\"boolean\"},\"proposals\":{\"type\":\"integer\",\"format\":\"int64\"}}},\"extBlockInternals\":{\"type\":\"object\",\"properties\":{\"id\":{\"type\":\"string\",\"format\":\"byte\"},\"atx_id\":{\"type\":\"string\",\"format\":\"byte\"},\"block_votes\":{\"type\":\"array\",\"items\":{\"type\":\"string\",\"format\":\"byte\"}},\"view_edges\":{\"type\":\"array\",\"items\":{\"type\":\"string\",\"format\":\"byte\"}},\"in_hare_output\":{\"type\":\"boolean\",\"format\":\"boolean\"},\"opinion\":{\"$ref\":\"#/definitions/extTortoiseOpinion\"},\"support\":{\"type\":\"string\",\"format\":\"int64\"},\"against\":{\"type\":\"string\",\"format\":\"int64\"},\"validity\":{\"$ref\":\"#/definitions/extContextualValidity\"}}},\"extContextualValidity\":{\"type\":\"string\",\"enum\":[\"CONTEXTUAL_VALIDITY_UNKNOWN\",\"CONTEXTUAL_VALIDITY_VALID\",\"CONTEXTUAL_VALIDITY_INVALID\"],\"default\":\"CONTEXTUAL_VALIDITY_UNKNOWN\",\"title\":\"ContextualValidity is the persisted verdict of the tortoise on a block\"},\"extDataAuditRequest\":{\"type\":\"object\",\"properties\":{\"run\":{\"type\":\"boolean\",\"format\":\"boolean\"}}},\"extDataAuditResponse\":{\"type\":\"object\",\"properties\":{\"started\":{\"type\":\"string\",\"format\":\"uint64\"},\"duration_ms\":{\"type\":\"string\",\"format\":\"uint64\"},\"from_layer\":{\"type\":\"string\",\"format\":\"uint64\"},\"to_layer\":{\"type\":\"string\",\"format\":\"uint64\"},\"missing_blocks\":{\"type\":\"integer\",\"format\":\"int64\"},\"missing_txs\":{\"type\":\"integer\",\"format\":\"int64\"},\"missing_atxs\":{\"type\":\"integer\",\"format\":\"int64\"},\"refetched_blocks\":{\"type\":\"integer\",\"format\":\"int64\"},\"refetched_txs\":{\"type\":\"integer\",\"format\":\"int64\"},\"refetched_atxs\":{\"type\":\"integer\",\"format\":\"int64\"}}},\"extExternalAddress\":{\"type\":\"object\",\"properties\":{\"address\":{\"type\":\"string\"},\"reports\":{\"type\":\"string\",\"format\":\"uint64\"},\"last_seen\":{\"type\":\"string\",\"format\":\"uint64\"}}},\"extFetchRequest\":{\"type\":\"object\",\"properties\":{\"kind\":{\"$ref\":\"#/definitions/extMeshObjectKind\"},\"id\":{\"type\":\"string\",\"format\":\"byte\"}}},\"extFetchResponse\":{\"type\":\"object\",\"properties\":{\"local\":{\"type\":\"boolean\",\"format\":\"boolean\"},\"peers\":{\"type\":\"array\",\"items\":{\"type\":\"string\",\"format\":\"byte\"}},\"stored\":{\"type\":\"boolean\",\"format\":\"boolean\"},\"error\":{\"type\":\"string\"},\"layer\":{\"type\":\"string\",\"format\":\"uint64\"}}},\"extGossipCacheInfo\":{\"type\":\"object\",\"properties\":{\"size\":{\"type\":\"string\",\"format\":\"uint64\"},\"capacity\":{\"type\":\"string\",\"format\":\"uint64\"},\"ttl\":{\"type\":\"string\",\"format\":\"uint64\"},\"hits\":{\"type\":\"string\",\"format\":\"uint64\"},\"misses\":{\"type\":\"string\",\"format\":\"uint64\"},\"evictions\":{\"type\":\"string\",\"format\":\"uint64\"}},\"title\":\"The cache of seen gossip messages, which aren't processed or relayed again\"},\"extGossipPeer\":{\"type\":\"object\",\"properties\":{\"id\":{\"type\":\"string\",\"format\":\"byte\"},\"address\":{\"type\":\"string\"},\"outbound\":{\"type\":\"boolean\",\"format\":\"boolean\"},\"score\":{\"type\":\"number\",\"format\":\"double\"},\"connected_since\":{\"type\":\"string\",\"format\":\"uint64\"},\"last_activity\":{\"type\":\"string\",\"format\":\"uint64\"},\"protocols\":{\"type\":\"array\",\"items\":{\"type\":\"string\"}},\"reputation\":{\"type\":\"number\",\"format\":\"double\"}}},\"extLayerInternalsRequest\":{\"type\":\"object\",\"properties\":{\"layer\":{\"type\":\"string\",\"format\":\"uint64\"}}},\"extLayerInternalsResponse\":{\"type\":\"object\",\"properties\":{\"layer\":{\"type\":\"string\",\"format\":\"uint64\"},\"hare_output_known\":{\"type\":\"boolean\",\"format\":\"boolean\"},\"hare_output\":{\"type\":\"array\",\"items\":{\"type\":\"string\",\"format\":\"byte\"}},\"verified_layer\":{\"type\":\"string\",\"format\":\"uint64\"},\"blocks\":{\"type\":\"array\",\"items\":{\"$ref\":\"#/definitions/extBlockInternals\"}}}},\"extMeshObjectKind\":{\"type\":\"string\",\"enum\":[\"MESH_OBJECT_KIND_UNSPECIFIED\",\"MESH_OBJECT_KIND_BLOCK\",\"MESH_OBJECT_KIND_TRANSACTION\",\"MESH_OBJECT_KIND_ATX\"],\"default\":\"MESH_OBJECT_KIND_UNSPECIFIED\",\"description\":\"MeshObjectKind is the kind of a mesh object to fetch. A block carries the votes of its miner, there are no separate\\nballots.\"},\"extNATStatus\":{\"type\":\"string\",\"enum\":[\"NAT_STATUS_DISABLED\",\"NAT_STATUS_NO_GATEWAY\",\"NAT_STATUS_PORT_FAILED\",\"NAT_STATUS_PORT_MAPPED\"],\"default\":\"NAT_STATUS_DISABLED\",\"title\":\"NATStatus describes whether the node mapped its port on a NAT gateway using UPnP or NAT-PMP\"},\"extNetworkInfoRequest\":{\"type\":\"object\"},\"extNetworkInfoResponse\":{\"type\":\"object\",\"properties\":{\"id\":{\"type\":\"string\",\"format\":\"byte\"},\"tcp_address\":{\"type\":\"string\"},\"udp_address\":{\"type\":\"string\"},\"external_addresses\":{\"type\":\"array\",\"items\":{\"$ref\":\"#/definitions/extExternalAddress\"}},\"nat\":{\"$ref\":\"#/definitions/extNATStatus\"},\"gossip_protocols\":{\"type\":\"array\",\"items\":{\"type\":\"string\"}},\"peers\":{\"type\":\"array\",\"items\":{\"$ref\":\"#/definitions/extGossipPeer\"}},\"routing_table\":{\"type\":\"array\",\"items\":{\"$ref\":\"#/definitions/extRoutingTableEntry\"}},\"mapped_address\":{\"type\":\"string\"},\"gossip_cache\":{\"$ref\":\"#/definitions/extGossipCacheInfo\"}}},\"extPeerEvent\":{\"type\":\"object\",\"properties\":{\"kind\":{\"$ref\":\"#/definitions/extPeerEventKind\"},\"id\":{\"type\":\"string\",\"format\":\"byte\"},\"address\":{\"type\":\"string\"},\"outbound\":{\"type\":\"boolean\",\"format\":\"boolean\"},\"reputation\":{\"type\":\"number\",\"format\":\"double\"},\"inbound_peers\":{\"type\":\"string\",\"format\":\"uint64\"},\"outbound_peers\":{\"type\":\"string\",\"format\":\"uint64\"},\"time\":{\"type\":\"string\",\"format\":\"uint64\"}}},\"extPeerEventKind\":{\"type\":\"string\",\"enum\":[\"PEER_EVENT_KIND_UNSPECIFIED\",\"PEER_EVENT_KIND_CONNECTED\",\"PEER_EVENT_KIND_DISCONNECTED\"],\"default\":\"PEER_EVENT_KIND_UNSPECIFIED\",\"title\":\"PeerEventKind is whether a gossip peer connected or disconnected\"},\"extPeerEventsRequest\":{\"type\":\"object\"},\"extPeersRequest\":{\"type\":\"object\"},\"extPeersResponse\":{\"type\":\"object\",\"properties\":{\"peers\":{\"type\":\"array\",\"items\":{\"$ref\":\"#/definitions/extGossipPeer\"}}}},\"extRoutingTableEntry\":{\"type\":\"object\",\"properties\":{\"id\":{\"type\":\"string\",\"format\":\"byte\"},\"address\":{\"type\":\"string\"},\"discovery_port\":{\"type\":\"integer\",\"format\":\"int64\"},\"tried\":{\"type\":\"boolean\",\"format\":\"boolean\"},\"attempts\":{\"type\":\"string\",\"format\":\"uint64\"},\"last_seen\":{\"type\":\"string\",\"format\":\"uint64\"},\"last_attempt\":{\"type\":\"string\",\"format\":\"uint64\"},\"last_success\":{\"type\":\"string\",\"format\":\"uint64\"},\"score\":{\"type\":\"number\",\"format\":\"double\"}}},\"extTortoiseOpinion\":{\"type\":\"string\",\"enum\":[\"TORTOISE_OPINION_UNSPECIFIED\",\"TORTOISE_OPINION_ABSTAIN\",\"TORTOISE_OPINION_SUPPORT\",\"TORTOISE_OPINION_AGAINST\"],\"default\":\"TORTOISE_OPINION_UNSPECIFIED\",\"title\":\"TortoiseOpinion is the vote of the tortoise on the contextual validity of a block\"},\"protobufAny\":{\"type\":\"object\",\"properties\":{\"type_url\":{\"type\":\"string\"},\"value\":{\"type\":\"string\",\"format\":\"byte\"}}},\"runtimeError\":{\"type\":\"object\",\"properties\":{\"error\":{\"type\":\"string\"},\"code\":{\"type\":\"integer\",\"format\":\"int32\"},\"message\":{\"type\":\"string\"},\"details\":{\"type\":\"array\",\"items\":{\"$ref\":\"#/definitions/protobufAny\"}}}},\"runtimeStreamError\":{\"type\":\"object\",\"properties\":{\"grpc_code\":{\"type\":\"integer\",\"format\":\"int32\"},\"http_code\":{\"type\":\"integer\",\"format\":\"int32\"},\"message\":{\"type\":\"string\"},\"http_status\":{\"type\":\"string\"},\"details\":{\"type\":\"array\",\"items\":{\"$ref\":\"#/definitions/protobufAny\"}}}}}}"),
+	"events":      []byte("{\"swagger\":\"2.0\",\"info\":{\"title\":\"api/extpb/events.proto\",\"version\":\"version not set\"},\"consumes\":[\"application/json\"],\"produces\":[\"application/json\"],\"paths\":{\"/v1/events/subscribeevents\":{\"post\":{\"summary\":\"Streams the events matching the filter of the last request sent by the client. The filter can be replaced at\\nany time by sending another request on the stream, no events match until the first filter is received.\",\"operationId\":\"EventService_SubscribeEvents\",\"responses\":{\"200\":{\"description\":\"A successful response.(streaming responses)\",\"schema\":{\"type\":\"object\",\"properties\":{\"result\":{\"$ref\":\"#/definitions/extSubscribeEventsResponse\"},\"error\":{\"$ref\":\"#/definitions/runtimeStreamError\"}},\"title\":\"Stream result of extSubscribeEventsResponse\"}},\"default\":{\"description\":\"An unexpected error response\",\"schema\":{\"$ref\":\"#/definitions/runtimeError\"}}},\"parameters\":[{\"name\":\"body\",\"description\":\" (streaming inputs)\",\"in\":\"body\",\"required\":true,\"schema\":{\"$ref\":\"#/definitions/extSubscribeEventsRequest\"}}],\"tags\":[\"EventService\"]}}},\"definitions\":{\"LayerLayerStatus\":{\"type\":\"string\",\"enum\":[\"LAYER_STATUS_UNSPECIFIED\",\"LAYER_STATUS_APPROVED\",\"LAYER_STATUS_CONFIRMED\"],\"default\":\"LAYER_STATUS_UNSPECIFIED\"},\"MalfeasanceProofMalfeasanceType\":{\"type\":\"string\",\"enum\":[\"MALFEASANCE_TYPE_UNSPECIFIED\",\"MALFEASANCE_TYPE_MULTIPLE_ATXS\",\"MALFEASANCE_TYPE_HARE_EQUIVOCATION\"],\"default\":\"MALFEASANCE_TYPE_UNSPECIFIED\"},\"TransactionEventTransactionState\":{\"type\":\"string\",\"enum\":[\"TRANSACTION_STATE_UNSPECIFIED\",\"TRANSACTION_STATE_MEMPOOL\",\"TRANSACTION_STATE_MESH\",\"TRANSACTION_STATE_APPLIED\"],\"default\":\"TRANSACTION_STATE_UNSPECIFIED\"},\"TransactionReceiptTransactionResult\":{\"type\":\"string\",\"enum\":[\"TRANSACTION_RESULT_UNSPECIFIED\",\"TRANSACTION_RESULT_APPLIED\",\"TRANSACTION_RESULT_UNKNOWN_ORIGIN\",\"TRANSACTION_RESULT_BAD_COUNTER\",\"TRANSACTION_RESULT_INSUFFICIENT_FUNDS\"],\"default\":\"TRANSACTION_RESULT_UNSPECIFIED\"},\"extAccount\":{\"type\":\"object\",\"properties\":{\"account_id\":{\"type\":\"string\",\"format\":\"byte\"},\"counter\":{\"type\":\"string\",\"format\":\"uint64\"},\"balance\":{\"type\":\"string\",\"format\":\"uint64\"}}},\"extActivation\":{\"type\":\"object\",\"properties\":{\"id\":{\"type\":\"string\",\"format\":\"byte\"},\"layer\":{\"type\":\"string\",\"format\":\"uint64\"},\"smesher_id\":{\"type\":\"string\",\"format\":\"byte\"},\"coinbase\":{\"type\":\"string\",\"format\":\"byte\"},\"prev_atx\":{\"type\":\"string\",\"format\":\"byte\"},\"sequence\":{\"type\":\"string\",\"format\":\"uint64\"}}},\"extBlock\":{\"type\":\"object\",\"properties\":{\"id\":{\"type\":\"string\",\"format\":\"byte\"},\"atx_id\":{\"type\":\"string\",\"format\":\"byte\"},\"transaction_ids\":{\"type\":\"array\",\"items\":{\"type\":\"string\",\"format\":\"byte\"}},\"transactions\":{\"type\":\"array\",\"items\":{\"$ref\":\"#/definitions/extTransaction\"}},\"layer\":{\"type\":\"string\",\"format\":\"uint64\"},\"smesher_id\":{\"type\":\"string\",\"format\":\"byte\"},\"timestamp\":{\"type\":\"string\",\"format\":\"int64\"}}},\"extEventFilter\":{\"type\":\"object\",\"properties\":{\"accounts\":{\"type\":\"array\",\"items\":{\"type\":\"string\",\"format\":\"byte\"},\"title\":\"account updates, rewards, transactions sent or received and activations with the account as their coinbase\"},\"transaction_ids\":{\"type\":\"array\",\"items\":{\"type\":\"string\",\"format\":\"byte\"},\"title\":\"the mempool, mesh and receipt events of the transactions\"},\"layers\":{\"type\":\"boolean\",\"format\":\"boolean\",\"title\":\"layer status updates\"},\"smesher_ids\":{\"type\":\"array\",\"items\":{\"type\":\"string\",\"format\":\"byte\"},\"title\":\"activations, rewards and malfeasance proofs of the smeshers\"}},\"title\":\"EventFilter selects the events sent on a stream, an event is sent if it matches any part of the filter\"},\"extLayer\":{\"type\":\"object\",\"properties\":{\"number\":{\"type\":\"string\",\"format\":\"uint64\"},\"status\":{\"$ref\":\"#/definitions/LayerLayerStatus\"},\"hash\":{\"type\":\"string\",\"format\":\"byte\"},\"blocks\":{\"type\":\"array\",\"items\":{\"$ref\":\"#/definitions/extBlock\"}},\"activations\":{\"type\":\"array\",\"items\":{\"$ref\":\"#/definitions/extActivation\"}},\"certified\":{\"type\":\"boolean\",\"format\":\"boolean\"}}},\"extMalfeasanceProof\":{\"type\":\"object\",\"properties\":{\"smesher_id\":{\"type\":\"string\",\"format\":\"byte\"},\"layer\":{\"type\":\"string\",\"format\":\"uint64\"},\"type\":{\"$ref\":\"#/definitions/MalfeasanceProofMalfeasanceType\"},\"messages\":{\"type\":\"array\",\"items\":{\"type\":\"string\",\"format\":\"byte\"}}}},\"extReward\":{\"type\":\"object\",\"properties\":{\"layer\":{\"type\":\"string\",\"format\":\"uint64\"},\"total\":{\"type\":\"string\",\"format\":\"uint64\"},\"layer_reward\":{\"type\":\"string\",\"format\":\"uint64\"},\"coinbase\":{\"type\":\"string\",\"format\":\"byte\"},\"smesher_id\":{\"type\":\"string\",\"format\":\"byte\"}}},\"extSubscribeEventsRequest\":{\"type\":\"object\",\"properties\":{\"filter\":{\"$ref\":\"#/definitions/extEventFilter\"}}},\"extSubscribeEventsResponse\":{\"type\":\"object\",\"properties\":{\"account\":{\"$ref\":\"#/definitions/extAccount\"},\"reward\":{\"$ref\":\"#/definitions/extReward\"},\"transaction\":{\"$ref\":\"#/definitions/extTransactionEvent\"},\"layer\":{\"$ref\":\"#/definitions/extLayer\"},\"activation\":{\"$ref\":\"#/definitions/extActivation\"},\"malfeasance\":{\"$ref\":\"#/definitions/extMalfeasanceProof\"}}},\"extTransaction\":{\"type\":\"object\",\"properties\":{\"id\":{\"type\":\"string\",\"format\":\"byte\"},\"sender\":{\"type\":\"string\",\"format\":\"byte\"},\"recipient\":{\"type\":\"string\",\"format\":\"byte\"},\"amount\":{\"type\":\"string\",\"format\":\"uint64\"},\"counter\":{\"type\":\"string\",\"format\":\"uint64\"},\"gas_limit\":{\"type\":\"string\",\"format\":\"uint64\"},\"fee\":{\"type\":\"string\",\"format\":\"uint64\"},\"signature\":{\"type\":\"string\",\"format\":\"byte\"}}},\"extTransactionEvent\":{\"type\":\"object\",\"properties\":{\"state\":{\"$ref\":\"#/definitions/TransactionEventTransactionState\"},\"transaction\":{\"$ref\":\"#/definitions/extTransaction\"},\"layer\":{\"type\":\"string\",\"format\":\"uint64\"},\"receipt\":{\"$ref\":\"#/definitions/extTransactionReceipt\"}}},\"extTransactionReceipt\":{\"type\":\"object\",\"properties\":{\"id\":{\"type\":\"string\",\"format\":\"byte\"},\"layer\":{\"type\":\"string\",\"format\":\"uint64\"},\"result\":{\"$ref\":\"#/definitions/TransactionReceiptTransactionResult\"},\"gas_used\":{\"type\":\"string\",\"format\":\"uint64\"},\"fee\":{\"type\":\"string\",\"format\":\"uint64\"},\"error\":{\"type\":\"string\"}},\"title\":\"TransactionReceipt is the outcome of applying a transaction of a confirmed layer to the global state\"},\"protobufAny\":{\"type\":\"object\",\"properties\":{\"type_url\":{\"type\":\"string\"},\"value\":{\"type\":\"string\",\"format\":\"byte\"}}},\"runtimeError\":{\"type\":\"object\",\"properties\":{\"error\":{\"type\":\"string\"},\"code\":{\"type\":\"integer\",\"format\":\"int32\"},\"message\":{\"type\":\"string\"},\"details\":{\"type\":\"array\",\"items\":{\"$ref\":\"#/definitions/protobufAny\"}}}},\"runtimeStreamError\":{\"type\":\"object\",\"properties\":{\"grpc_code\":{\"type\":\"integer\",\"format\":\"int32\"},\"http_code\":{\"type\":\"integer\",\"format\":\"int32\"},\"message\":{\"type\":\"string\"},\"http_status\":{\"type\":\"string\"},\"details\":{\"type\":\"array\",\"items\":{\"$ref\":\"#/definitions/protobufAny\"}}}}}}"),
+	"globalstate": []byte("{\"swagger\":\"2.0\",\"info\":{\"title\":\"api/extpb/globalstate.proto\",\"version\":\"version not set\"},\"consumes\":[\"application/json\"],\"produces\":[\"application/json\"],\"paths\":{\"/v1/globalstate/accountatlayer\":{\"post\":{\"summary\":\"Returns the counter and balance of an account in the global state after applying the requested layer\",\"operationId\":\"GlobalStateService_AccountAtLayer\",\"responses\":{\"200\":{\"description\":\"A successful response.\",\"schema\":{\"$ref\":\"#/definitions/extAccountAtLayerResponse\"}},\"default\":{\"description\":\"An unexpected error response\",\"schema\":{\"$ref\":\"#/definitions/runtimeError\"}}},\"parameters\":[{\"name\":\"body\",\"in\":\"body\",\"required\":true,\"schema\":{\"$ref\":\"#/definitions/extAccountAtLayerRequest\"}}],\"tags\":[\"GlobalStateService\"]}},\"/v1/globalstate/accountproof\":{\"post\":{\"summary\":\"Returns a merkle proof of the counter and balance of an account against a global state root\",\"operationId\":\"GlobalStateService_AccountProof\",\"responses\":{\"200\":{\"description\":\"A successful response.\",\"schema\":{\"$ref\":\"#/definitions/extAccountProofResponse\"}},\"default\":{\"description\":\"An unexpected error response\",\"schema\":{\"$ref\":\"#/definitions/runtimeError\"}}},\"parameters\":[{\"name\":\"body\",\"in\":\"body\",\"required\":true,\"schema\":{\"$ref\":\"#/definitions/extAccountProofRequest\"}}],\"tags\":[\"GlobalStateService\"]}},\"/v1/globalstate/coinbaserewards\":{\"post\":{\"summary\":\"Returns the rewards paid to a coinbase aggregated per epoch\",\"operationId\":\"GlobalStateService_CoinbaseRewards\",\"responses\":{\"200\":{\"description\":\"A successful response.\",\"schema\":{\"$ref\":\"#/definitions/extCoinbaseRewardsResponse\"}},\"default\":{\"description\":\"An unexpected error response\",\"schema\":{\"$ref\":\"#/definitions/runtimeError\"}}},\"parameters\":[{\"name\":\"body\",\"in\":\"body\",\"required\":true,\"schema\":{\"$ref\":\"#/definitions/extCoinbaseRewardsRequest\"}}],\"tags\":[\"GlobalStateService\"]}},\"/v1/globalstate/globalstatehashatlayer\":{\"post\":{\"summary\":\"Returns the global state root after applying the requested layer\",\"operationId\":\"GlobalStateService_GlobalStateHashAtLayer\",\"responses\":{\"200\":{\"description\":\"A successful response.\",\"schema\":{\"$ref\":\"#/definitions/extGlobalStateHashAtLayerResponse\"}},\"default\":{\"description\":\"An unexpected error response\",\"schema\":{\"$ref\":\"#/definitions/runtimeError\"}}},\"parameters\":[{\"name\":\"body\",\"in\":\"body\",\"required\":true,\"schema\":{\"$ref\":\"#/definitions/extGlobalStateHashAtLayerRequest\"}}],\"tags\":[\"GlobalStateService\"]}},\"/v1/globalstate/rewardstream\":{\"post\":{\"summary\":\"Streams the rewards paid to a coinbase or earned by a smesher as layers are applied to the global state\",\"operationId\":\"GlobalStateService_RewardStream\",\"responses\":{\"200\":{\"description\":\"A successful response.(streaming responses)\",\"schema\":{\"type\":\"object\",\"properties\":{\"result\":{\"$ref\":\"#/definitions/extRewardStreamResponse\"},\"error\":{\"$ref\":\"#/definitions/runtimeStreamError\"}},\"title\":\"Stream result of extRewardStreamResponse\"}},\"default\":{\"description\":\"An unexpected error response\",\"schema\":{\"$ref\":\"#/definitions/runtimeError\"}}},\"parameters\":[{\"name\":\"body\",\"in\":\"body\",\"required\":true,\"schema\":{\"$ref\":\"#/definitions/extRewardStreamRequest\"}}],\"tags\":[\"GlobalStateService\"]}},\"/v1/globalstate/supply\":{\"post\":{\"summary\":\"Returns the total and estimated circulating supply of coins in the global state after applying the requested layer\",\"operationId\":\"GlobalStateService_Supply\",\"responses\":{\"200\":{\"description\":\"A successful response.\",\"schema\":{\"$ref\":\"#/definitions/extSupplyResponse\"}},\"default\":{\"description\":\"An unexpected error response\",\"schema\":{\"$ref\":\"#/definitions/runtimeError\"}}},\"parameters\":[{\"name\":\"body\",\"in\":\"body\",\"required\":true,\"schema\":{\"$ref\":\"#/definitions/extSupplyRequest\"}}],\"tags\":[\"GlobalStateService\"]}}},\"definitions\":{\"extAccountAtLayerRequest\":{\"type\":\"object\",\"properties\":{\"account_id\":{\"type\":\"string\",\"format\":\"byte\"},\"layer\":{\"type\":\"string\",\"format\":\"uint64\"}}},\"extAccountAtLayerResponse\":{\"type\":\"object\",\"properties\":{\"account_id\":{\"type\":\"string\",\"format\":\"byte\"},\"layer\":{\"type\":\"string\",\"format\":\"uint64\"},\"root_hash\":{\"type\":\"string\",\"format\":\"byte\"},\"exists\":{\"type\":\"boolean\",\"format\":\"boolean\"},\"counter\":{\"type\":\"string\",\"format\":\"uint64\"},\"balance\":{\"type\":\"string\",\"format\":\"uint64\"}}},\"extAccountProofRequest\":{\"type\":\"object\",\"properties\":{\"account_id\":{\"type\":\"string\",\"format\":\"byte\"},\"root_hash\":{\"type\":\"string\",\"format\":\"byte\"},\"field_mask\":{\"$ref\":\"#/definitions/protobufFieldMask\"}}},\"extAccountProofResponse\":{\"type\":\"object\",\"properties\":{\"root_hash\":{\"type\":\"string\",\"format\":\"byte\"},\"account_id\":{\"type\":\"string\",\"format\":\"byte\"},\"exists\":{\"type\":\"boolean\",\"format\":\"boolean\"},\"counter\":{\"type\":\"string\",\"format\":\"uint64\"},\"balance\":{\"type\":\"string\",\"format\":\"uint64\"},\"nodes\":{\"type\":\"array\",\"items\":{\"type\":\"string\",\"format\":\"byte\"},\"title\":\"the encoded global state trie nodes on the path from the root to the account, keyed by the keccak256 hash of the\\naddress\"}}},\"extCoinbaseRewardsRequest\":{\"type\":\"object\",\"properties\":{\"coinbase\":{\"type\":\"string\",\"format\":\"byte\"},\"min_epoch\":{\"type\":\"string\",\"format\":\"uint64\"},\"max_epoch\":{\"type\":\"string\",\"format\":\"uint64\"},\"include_layers\":{\"type\":\"boolean\",\"format\":\"boolean\"},\"field_mask\":{\"$ref\":\"#/definitions/protobufFieldMask\"}}},\"extCoinbaseRewardsResponse\":{\"type\":\"object\",\"properties\":{\"epochs\":{\"type\":\"array\",\"items\":{\"$ref\":\"#/definitions/extEpochRewards\"},\"title\":\"epochs in which the coinbase received rewards, ordered by epoch\"}}},\"extEpochRewards\":{\"type\":\"object\",\"properties\":{\"epoch\":{\"type\":\"string\",\"format\":\"uint64\"},\"blocks\":{\"type\":\"string\",\"format\":\"uint64\"},\"total\":{\"type\":\"string\",\"format\":\"uint64\"},\"layer_reward\":{\"type\":\"string\",\"format\":\"uint64\"},\"layers\":{\"type\":\"array\",\"items\":{\"$ref\":\"#/definitions/extLayerRewards\"}}},\"title\":\"EpochRewards are the rewards a coinbase received in a single epoch\"},\"extGlobalStateHashAtLayerRequest\":{\"type\":\"object\",\"properties\":{\"layer\":{\"type\":\"string\",\"format\":\"uint64\"}}},\"extGlobalStateHashAtLayerResponse\":{\"type\":\"object\",\"properties\":{\"root_hash\":{\"type\":\"string\",\"format\":\"byte\"},\"layer\":{\"type\":\"string\",\"format\":\"uint64\"}}},\"extLayerRewards\":{\"type\":\"object\",\"properties\":{\"layer\":{\"type\":\"string\",\"format\":\"uint64\"},\"blocks\":{\"type\":\"string\",\"format\":\"uint64\"},\"total\":{\"type\":\"string\",\"format\":\"uint64\"},\"layer_reward\":{\"type\":\"string\",\"format\":\"uint64\"}},\"title\":\"LayerRewards are the rewards a coinbase received in a single layer\"},\"extReward\":{\"type\":\"object\",\"properties\":{\"layer\":{\"type\":\"string\",\"format\":\"uint64\"},\"total\":{\"type\":\"string\",\"format\":\"uint64\"},\"layer_reward\":{\"type\":\"string\",\"format\":\"uint64\"},\"coinbase\":{\"type\":\"string\",\"format\":\"byte\"},\"smesher_id\":{\"type\":\"string\",\"format\":\"byte\"}}},\"extRewardStreamRequest\":{\"type\":\"object\",\"properties\":{\"smesher_id\":{\"type\":\"string\",\"format\":\"byte\"},\"coinbase\":{\"type\":\"string\",\"format\":\"byte\"}},\"title\":\"at least one filter must be set, rewards must match all filters that are set\"},\"extRewardStreamResponse\":{\"type\":\"object\",\"properties\":{\"reward\":{\"$ref\":\"#/definitions/extReward\"}}},\"extSupplyRequest\":{\"type\":\"object\",\"properties\":{\"layer\":{\"type\":\"string\",\"format\":\"uint64\"}}},\"extSupplyResponse\":{\"type\":\"object\",\"properties\":{\"layer\":{\"type\":\"string\",\"format\":\"uint64\"},\"root_hash\":{\"type\":\"string\",\"format\":\"byte\"},\"total\":{\"type\":\"string\",\"format\":\"uint64\"},\"genesis\":{\"type\":\"string\",\"format\":\"uint64\"},\"rewards\":{\"type\":\"string\",\"format\":\"uint64\"},\"circulating\":{\"type\":\"string\",\"format\":\"uint64\",\"title\":\"an estimate of the coins in circulation: the total supply without the coins that are still held by the genesis\\naccounts\"}}},\"protobufAny\":{\"type\":\"object\",\"properties\":{\"type_url\":{\"type\":\"string\"},\"value\":{\"type\":\"string\",\"format\":\"byte\"}}},\"protobufFieldMask\":{\"type\":\"object\",\"properties\":{\"paths\":{\"type\":\"array\",\"items\":{\"type\":\"string\"}}}},\"runtimeError\":{\"type\":\"object\",\"properties\":{\"error\":{\"type\":\"string\"},\"code\":{\"type\":\"integer\",\"format\":\"int32\"},\"message\":{\"type\":\"string\"},\"details\":{\"type\":\"array\",\"items\":{\"$ref\":\"#/definitions/protobufAny\"}}}},\"runtimeStreamError\":{\"type\":\"object\",\"properties\":{\"grpc_code\":{\"type\":\"integer\",\"format\":\"int32\"},\"http_code\":{\"type\":\"integer\",\"format\":\"int32\"},\"message\":{\"type\":\"string\"},\"http_status\":{\"type\":\"string\"},\"details\":{\"type\":\"array\",\"items\":{\"$ref\":\"#/definitions/protobufAny\"}}}}}}"),
+	"mesh":        []byte("{\"swagger\":\"2.0\",\"info\":{\"title\":\"api/extpb/mesh.proto\",\"version\":\"version not set\"},\"consumes\":[\"application/json\"],\"produces\":[\"application/json\"],\"paths\":{\"/v1/mesh/blockquery\":{\"get\":{\"summary\":\"Returns a block by its id, optionally along with its transactions\",\"operationId\":\"MeshService_BlockQuery2\",\"responses\":{\"200\":{\"description\":\"A successful response.\",\"schema\":{\"$ref\":\"#/definitions/extBlockQueryResponse\"}},\"default\":{\"description\":\"An unexpected error response\",\"schema\":{\"$ref\":\"#/definitions/runtimeError\"}}},\"parameters\":[{\"name\":\"id\",\"in\":\"query\",\"required\":false,\"type\":\"string\",\"format\":\"byte\"},{\"name\":\"include_transactions\",\"in\":\"query\",\"required\":false,\"type\":\"boolean\",\"format\":\"boolean\"},{\"name\":\"field_mask.paths\",\"in\":\"query\",\"required\":false,\"type\":\"array\",\"items\":{\"type\":\"string\"},\"collectionFormat\":\"multi\"}],\"tags\":[\"MeshService\"]},\"post\":{\"summary\":\"Returns a block by its id, optionally along with its transactions\",\"operationId\":\"MeshService_BlockQuery\",\"responses\":{\"200\":{\"description\":\"A successful response.\",\"schema\":{\"$ref\":\"#/definitions/extBlockQueryResponse\"}},\"default\":{\"description\":\"An unexpected error response\",\"schema\":{\"$ref\":\"#/definitions/runtimeError\"}}},\"parameters\":[{\"name\":\"body\",\"in\":\"body\",\"required\":true,\"schema\":{\"$ref\":\"#/definitions/extBlockQueryRequest\"}}],\"tags\":[\"MeshService\"]}},\"/v1/mesh/epochstream\":{\"post\":{\"summary\":\"Returns a stream of epoch summaries, one when the node enters each new epoch\",\"operationId\":\"MeshService_EpochStream\",\"responses\":{\"200\":{\"description\":\"A successful response.(streaming responses)\",\"schema\":{\"type\":\"object\",\"properties\":{\"result\":{\"$ref\":\"#/definitions/extEpochStreamResponse\"},\"error\":{\"$ref\":\"#/definitions/runtimeStreamError\"}},\"title\":\"Stream result of extEpochStreamResponse\"}},\"default\":{\"description\":\"An unexpected error response\",\"schema\":{\"$ref\":\"#/definitions/runtimeError\"}}},\"parameters\":[{\"name\":\"body\",\"in\":\"body\",\"required\":true,\"schema\":{\"$ref\":\"#/definitions/extEpochStreamRequest\"}}],\"tags\":[\"MeshService\"]}},\"/v1/mesh/malfeasancequery\":{\"post\":{\"summary\":\"Returns the malfeasance proof of a smesher, if there is one\",\"operationId\":\"MeshService_MalfeasanceQuery\",\"responses\":{\"200\":{\"description\":\"A successful response.\",\"schema\":{\"$ref\":\"#/definitions/extMalfeasanceQueryResponse\"}},\"default\":{\"description\":\"An unexpected error response\",\"schema\":{\"$ref\":\"#/definitions/runtimeError\"}}},\"parameters\":[{\"name\":\"body\",\"in\":\"body\",\"required\":true,\"schema\":{\"$ref\":\"#/definitions/extMalfeasanceQueryRequest\"}}],\"tags\":[\"MeshService\"]}},\"/v1/mesh/malfeasancestream\":{\"post\":{\"summary\":\"Returns a stream of newly detected malfeasance proofs\",\"operationId\":\"MeshService_MalfeasanceStream\",\"responses\":{\"200\":{\"description\":\"A successful response.(streaming responses)\",\"schema\":{\"type\":\"object\",\"properties\":{\"result\":{\"$ref\":\"#/definitions/extMalfeasanceStreamResponse\"},\"error\":{\"$ref\":\"#/definitions/runtimeStreamError\"}},\"title\":\"Stream result of extMalfeasanceStreamResponse\"}},\"default\":{\"description\":\"An unexpected error response\",\"schema\":{\"$ref\":\"#/definitions/runtimeError\"}}},\"parameters\":[{\"name\":\"body\",\"in\":\"body\",\"required\":true,\"schema\":{\"$ref\":\"#/definitions/extMalfeasanceStreamRequest\"}}],\"tags\":[\"MeshService\"]}},\"/v1/mesh/pagedlayersquery\":{\"get\":{\"summary\":\"Returns mesh data for a range of layers, one page at a time\",\"operationId\":\"MeshService_PagedLayersQuery2\",\"responses\":{\"200\":{\"description\":\"A successful response.\",\"schema\":{\"$ref\":\"#/definitions/extPagedLayersQueryResponse\"}},\"default\":{\"description\":\"An unexpected error response\",\"schema\":{\"$ref\":\"#/definitions/runtimeError\"}}},\"parameters\":[{\"name\":\"start_layer\",\"in\":\"query\",\"required\":false,\"type\":\"string\",\"format\":\"uint64\"},{\"name\":\"end_layer\",\"in\":\"query\",\"required\":false,\"type\":\"string\",\"format\":\"uint64\"},{\"name\":\"include_transactions\",\"in\":\"query\",\"required\":false,\"type\":\"boolean\",\"format\":\"boolean\"},{\"name\":\"include_activations\",\"in\":\"query\",\"required\":false,\"type\":\"boolean\",\"format\":\"boolean\"},{\"name\":\"page_size\",\"in\":\"query\",\"required\":false,\"type\":\"integer\",\"format\":\"int64\"},{\"name\":\"page_token\",\"in\":\"query\",\"required\":false,\"type\":\"string\"},{\"name\":\"field_mask.paths\",\"in\":\"query\",\"required\":false,\"type\":\"array\",\"items\":{\"type\":\"string\"},\"collectionFormat\":\"multi\"}],\"tags\":[\"MeshService\"]},\"post\":{\"summary\":\"Returns mesh data for a range of layers, one page at a time\",\"operationId\":\"MeshService_PagedLayersQuery\",\"responses\":{\"200\":{\"description\":\"A successful response.\",\"schema\":{\"$ref\":\"#/definitions/extPagedLayersQueryResponse\"}},\"default\":{\"description\":\"An unexpected error response\",\"schema\":{\"$ref\":\"#/definitions/runtimeError\"}}},\"parameters\":[{\"name\":\"body\",\"in\":\"body\",\"required\":true,\"schema\":{\"$ref\":\"#/definitions/extPagedLayersQueryRequest\"}}],\"tags\":[\"MeshService\"]}}},\"definitions\":{\"LayerLayerStatus\":{\"type\":\"string\",\"enum\":[\"LAYER_STATUS_UNSPECIFIED\",\"LAYER_STATUS_APPROVED\",\"LAYER_STATUS_CONFIRMED\"],\"default\":\"LAYER_STATUS_UNSPECIFIED\"},\"MalfeasanceProofMalfeasanceType\":{\"type\":\"string\",\"enum\":[\"MALFEASANCE_TYPE_UNSPECIFIED\",\"MALFEASANCE_TYPE_MULTIPLE_ATXS\",\"MALFEASANCE_TYPE_HARE_EQUIVOCATION\"],\"default\":\"MALFEASANCE_TYPE_UNSPECIFIED\"},\"extActivation\":{\"type\":\"object\",\"properties\":{\"id\":{\"type\":\"string\",\"format\":\"byte\"},\"layer\":{\"type\":\"string\",\"format\":\"uint64\"},\"smesher_id\":{\"type\":\"string\",\"format\":\"byte\"},\"coinbase\":{\"type\":\"string\",\"format\":\"byte\"},\"prev_atx\":{\"type\":\"string\",\"format\":\"byte\"},\"sequence\":{\"type\":\"string\",\"format\":\"uint64\"}}},\"extBlock\":{\"type\":\"object\",\"properties\":{\"id\":{\"type\":\"string\",\"format\":\"byte\"},\"atx_id\":{\"type\":\"string\",\"format\":\"byte\"},\"transaction_ids\":{\"type\":\"array\",\"items\":{\"type\":\"string\",\"format\":\"byte\"}},\"transactions\":{\"type\":\"array\",\"items\":{\"$ref\":\"#/definitions/extTransaction\"}},\"layer\":{\"type\":\"string\",\"format\":\"uint64\"},\"smesher_id\":{\"type\":\"string\",\"format\":\"byte\"},\"timestamp\":{\"type\":\"string\",\"format\":\"int64\"}}},\"extBlockQueryRequest\":{\"type\":\"object\",\"properties\":{\"id\":{\"type\":\"string\",\"format\":\"byte\"},\"include_transactions\":{\"type\":\"boolean\",\"format\":\"boolean\"},\"field_mask\":{\"$ref\":\"#/definitions/protobufFieldMask\"}}},\"extBlockQueryResponse\":{\"type\":\"object\",\"properties\":{\"block\":{\"$ref\":\"#/definitions/extBlock\"},\"layer_status\":{\"$ref\":\"#/definitions/LayerLayerStatus\"}}},\"extEpochStreamRequest\":{\"type\":\"object\"},\"extEpochStreamResponse\":{\"type\":\"object\",\"properties\":{\"epoch\":{\"type\":\"string\",\"format\":\"uint64\"},\"total_weight\":{\"type\":\"string\",\"format\":\"uint64\"},\"active_smeshers\":{\"type\":\"string\",\"format\":\"uint64\"},\"beacon\":{\"type\":\"string\",\"format\":\"byte\"}}},\"extLayer\":{\"type\":\"object\",\"properties\":{\"number\":{\"type\":\"string\",\"format\":\"uint64\"},\"status\":{\"$ref\":\"#/definitions/LayerLayerStatus\"},\"hash\":{\"type\":\"string\",\"format\":\"byte\"},\"blocks\":{\"type\":\"array\",\"items\":{\"$ref\":\"#/definitions/extBlock\"}},\"activations\":{\"type\":\"array\",\"items\":{\"$ref\":\"#/definitions/extActivation\"}},\"certified\":{\"type\":\"boolean\",\"format\":\"boolean\"}}},\"extMalfeasanceProof\":{\"type\":\"object\",\"properties\":{\"smesher_id\":{\"type\":\"string\",\"format\":\"byte\"},\"layer\":{\"type\":\"string\",\"format\":\"uint64\"},\"type\":{\"$ref\":\"#/definitions/MalfeasanceProofMalfeasanceType\"},\"messages\":{\"type\":\"array\",\"items\":{\"type\":\"string\",\"format\":\"byte\"}}}},\"extMalfeasanceQueryRequest\":{\"type\":\"object\",\"properties\":{\"smesher_id\":{\"type\":\"string\",\"format\":\"byte\"}}},\"extMalfeasanceQueryResponse\":{\"type\":\"object\",\"properties\":{\"proof\":{\"$ref\":\"#/definitions/extMalfeasanceProof\"}}},\"extMalfeasanceStreamRequest\":{\"type\":\"object\"},\"extMalfeasanceStreamResponse\":{\"type\":\"object\",\"properties\":{\"proof\":{\"$ref\":\"#/definitions/extMalfeasanceProof\"}}},\"extPagedLayersQueryRequest\":{\"type\":\"object\",\"properties\":{\"start_layer\":{\"type\":\"string\",\"format\":\"uint64\"},\"end_layer\":{\"type\":\"string\",\"format\":\"uint64\"},\"include_transactions\":{\"type\":\"boolean\",\"format\":\"boolean\"},\"include_activations\":{\"type\":\"boolean\",\"format\":\"boolean\"},\"page_size\":{\"type\":\"integer\",\"format\":\"int64\"},\"page_token\":{\"type\":\"string\"},\"field_mask\":{\"$ref\":\"#/definitions/protobufFieldMask\",\"description\":\"the fields of the response to return, e.g. layers.number and layers.hash, all fields if not set. Masking out\\nnext_page_token ends paging after the first page.\"}}},\"extPagedLayersQueryResponse\":{\"type\":\"object\",\"properties\":{\"layers\":{\"type\":\"array\",\"items\":{\"$ref\":\"#/definitions/extLayer\"}},\"next_page_token\":{\"type\":\"string\"}}},\"extTransaction\":{\"type\":\"object\",\"properties\":{\"id\":{\"type\":\"string\",\"format\":\"byte\"},\"sender\":{\"type\":\"string\",\"format\":\"byte\"},\"recipient\":{\"type\":\"string\",\"format\":\"byte\"},\"amount\":{\"type\":\"string\",\"format\":\"uint64\"},\"counter\":{\"type\":\"string\",\"format\":\"uint64\"},\"gas_limit\":{\"type\":\"string\",\"format\":\"uint64\"},\"fee\":{\"type\":\"string\",\"format\":\"uint64\"},\"signature\":{\"type\":\"string\",\"format\":\"byte\"}}},\"protobufAny\":{\"type\":\"object\",\"properties\":{\"type_url\":{\"type\":\"string\"},\"value\":{\"type\":\"string\",\"format\":\"byte\"}}},\"protobufFieldMask\":{\"type\":\"object\",\"properties\":{\"paths\":{\"type\":\"array\",\"items\":{\"type\":\"string\"}}}},\"runtimeError\":{\"type\":\"object\",\"properties\":{\"error\":{\"type\":\"string\"},\"code\":{\"type\":\"integer\",\"format\":\"int32\"},\"message\":{\"type\":\"string\"},\"details\":{\"type\":\"array\",\"items\":{\"$ref\":\"#/definitions/protobufAny\"}}}},\"runtimeStreamError\":{\"type\":\"object\",\"properties\":{\"grpc_code\":{\"type\":\"integer\",\"format\":\"int32\"},\"http_code\":{\"type\":\"integer\",\"format\":\"int32\"},\"message\":{\"type\":\"string\"},\"http_status\":{\"type\":\"string\"},\"details\":{\"type\":\"array\",\"items\":{\"$ref\":\"#/definitions/protobufAny\"}}}}}}"),
+	"node":        []byte("{\"swagger\":\"2.0\",\"info\":{\"title\":\"api/extpb/node.proto\",\"version\":\"version not set\"},\"consumes\":[\"application/json\"],\"produces\":[\"application/json\"],\"paths\":{\"/v1/node/finality\":{\"post\":{\"summary\":\"Returns the finalized layer of the node: the last layer such that the valid blocks of it and of all the layers\\nbelow it can no longer change\",\"operationId\":\"NodeService_Finality\",\"responses\":{\"200\":{\"description\":\"A successful response.\",\"schema\":{\"$ref\":\"#/definitions/extFinalityResponse\"}},\"default\":{\"description\":\"An unexpected error response\",\"schema\":{\"$ref\":\"#/definitions/runtimeError\"}}},\"parameters\":[{\"name\":\"body\",\"in\":\"body\",\"required\":true,\"schema\":{\"$ref\":\"#/definitions/extFinalityRequest\"}}],\"tags\":[\"NodeService\"]}},\"/v1/node/finalitystream\":{\"post\":{\"summary\":\"Streams the layers as they become final, in order, starting with the first layer finalized after the request\",\"operationId\":\"NodeService_FinalityStream\",\"responses\":{\"200\":{\"description\":\"A successful response.(streaming responses)\",\"schema\":{\"type\":\"object\",\"properties\":{\"result\":{\"$ref\":\"#/definitions/extFinalityStreamResponse\"},\"error\":{\"$ref\":\"#/definitions/runtimeStreamError\"}},\"title\":\"Stream result of extFinalityStreamResponse\"}},\"default\":{\"description\":\"An unexpected error response\",\"schema\":{\"$ref\":\"#/definitions/runtimeError\"}}},\"parameters\":[{\"name\":\"body\",\"in\":\"body\",\"required\":true,\"schema\":{\"$ref\":\"#/definitions/extFinalityStreamRequest\"}}],\"tags\":[\"NodeService\"]}},\"/v1/node/syncfrontier\":{\"post\":{\"summary\":\"Returns the persisted sync frontier of the node: its last validated layer, and the layers it is fetching,\\nwhich it syncs again if it restarts\",\"operationId\":\"NodeService_SyncFrontier\",\"responses\":{\"200\":{\"description\":\"A successful response.\",\"schema\":{\"$ref\":\"#/definitions/extSyncFrontierResponse\"}},\"default\":{\"description\":\"An unexpected error response\",\"schema\":{\"$ref\":\"#/definitions/runtimeError\"}}},\"parameters\":[{\"name\":\"body\",\"in\":\"body\",\"required\":true,\"schema\":{\"$ref\":\"#/definitions/extSyncFrontierRequest\"}}],\"tags\":[\"NodeService\"]}},\"/v1/node/syncprogressstream\":{\"post\":{\"summary\":\"Streams the progress of the sync of the node: its phase, the layers it synced and has left to sync, its\\ndownload rate and the estimated time until it's synced. The progress is sent once a second.\",\"operationId\":\"NodeService_SyncProgressStream\",\"responses\":{\"200\":{\"description\":\"A successful response.(streaming responses)\",\"schema\":{\"type\":\"object\",\"properties\":{\"result\":{\"$ref\":\"#/definitions/extSyncProgressStreamResponse\"},\"error\":{\"$ref\":\"#/definitions/runtimeStreamError\"}},\"title\":\"Stream result of extSyncProgressStreamResponse\"}},\"default\":{\"description\":\"An unexpected error response\",\"schema\":{\"$ref\":\"#/definitions/runtimeError\"}}},\"parameters\":[{\"name\":\"body\",\"in\":\"body\",\"required\":true,\"schema\":{\"$ref\":\"#/definitions/extSyncProgressStreamRequest\"}}],\"tags\":[\"NodeService\"]}}},\"definitions\":{\"LayerFinalityFinalitySource\":{\"type\":\"string\",\"enum\":[\"FINALITY_SOURCE_UNSPECIFIED\",\"FINALITY_SOURCE_TORTOISE\",\"FINALITY_SOURCE_HARE\"],\"default\":\"FINALITY_SOURCE_UNSPECIFIED\"},\"SyncProgressSyncPhase\":{\"type\":\"string\",\"enum\":[\"SYNC_PHASE_UNSPECIFIED\",\"SYNC_PHASE_STARTING\",\"SYNC_PHASE_SNAPSHOT\",\"SYNC_PHASE_LAYERS\",\"SYNC_PHASE_GOSSIP\",\"SYNC_PHASE_SYNCED\",\"SYNC_PHASE_HEADERS\"],\"default\":\"SYNC_PHASE_UNSPECIFIED\"},\"extFinalityRequest\":{\"type\":\"object\"},\"extFinalityResponse\":{\"type\":\"object\",\"properties\":{\"finalized_layer\":{\"type\":\"string\",\"format\":\"uint64\"},\"verified_layer\":{\"type\":\"string\",\"format\":\"uint64\"}}},\"extFinalityStreamRequest\":{\"type\":\"object\"},\"extFinalityStreamResponse\":{\"type\":\"object\",\"properties\":{\"finality\":{\"$ref\":\"#/definitions/extLayerFinality\"}}},\"extLayerFinality\":{\"type\":\"object\",\"properties\":{\"layer\":{\"type\":\"string\",\"format\":\"uint64\"},\"source\":{\"$ref\":\"#/definitions/LayerFinalityFinalitySource\"}}},\"extSyncFrontierRequest\":{\"type\":\"object\"},\"extSyncFrontierResponse\":{\"type\":\"object\",\"properties\":{\"validated_layer\":{\"type\":\"string\",\"format\":\"uint64\"},\"in_flight_layers\":{\"type\":\"array\",\"items\":{\"type\":\"string\",\"format\":\"uint64\"}}}},\"extSyncProgress\":{\"type\":\"object\",\"properties\":{\"phase\":{\"$ref\":\"#/definitions/SyncProgressSyncPhase\"},\"processed_layer\":{\"type\":\"string\",\"format\":\"uint64\"},\"current_layer\":{\"type\":\"string\",\"format\":\"uint64\"},\"layers_completed\":{\"type\":\"string\",\"format\":\"uint64\"},\"layers_remaining\":{\"type\":\"string\",\"format\":\"uint64\"},\"layers_per_second\":{\"type\":\"number\",\"format\":\"double\"},\"bytes_per_second\":{\"type\":\"number\",\"format\":\"double\"},\"eta_seconds\":{\"type\":\"string\",\"format\":\"uint64\"}}},\"extSyncProgressStreamRequest\":{\"type\":\"object\"},\"extSyncProgressStreamResponse\":{\"type\":\"object\",\"properties\":{\"progress\":{\"$ref\":\"#/definitions/extSyncProgress\"}}},\"protobufAny\":{\"type\":\"object\",\"properties\":{\"type_url\":{\"type\":\"string\"},\"value\":{\"type\":\"string\",\"format\":\"byte\"}}},\"runtimeError\":{\"type\":\"object\",\"properties\":{\"error\":{\"type\":\"string\"},\"code\":{\"type\":\"integer\",\"format\":\"int32\"},\"message\":{\"type\":\"string\"},\"details\":{\"type\":\"array\",\"items\":{\"$ref\":\"#/definitions/protobufAny\"}}}},\"runtimeStreamError\":{\"type\":\"object\",\"properties\":{\"grpc_code\":{\"type\":\"integer\",\"format\":\"int32\"},\"http_code\":{\"type\":\"integer\",\"format\":\"int32\"},\"message\":{\"type\":\"string\"},\"http_status\":{\"type\":\"string\"},\"details\":{\"type\":\"array\",\"items\":{\"$ref\":\"#/definitions/protobufAny\"}}}}}}"),
+	"smesher":     []byte("{\"swagger\":\"2.0\",\"info\":{\"title\":\"api/extpb/smesher.proto\",\"version\":\"version not set\"},\"consumes\":[\"application/json\"],\"produces\":[\"application/json\"],\"paths\":{\"/v1/smesher/eligibilityreport\":{\"post\":{\"summary\":\"Returns the block and hare eligibilities of this smesher in the current and next epoch\",\"operationId\":\"SmesherService_EligibilityReport\",\"responses\":{\"200\":{\"description\":\"A successful response.\",\"schema\":{\"$ref\":\"#/definitions/extEligibilityReportResponse\"}},\"default\":{\"description\":\"An unexpected error response\",\"schema\":{\"$ref\":\"#/definitions/runtimeError\"}}},\"parameters\":[{\"name\":\"body\",\"in\":\"body\",\"required\":true,\"schema\":{\"$ref\":\"#/definitions/extEligibilityReportRequest\"}}],\"tags\":[\"SmesherService\"]}}},\"definitions\":{\"extEligibilityReportRequest\":{\"type\":\"object\"},\"extEligibilityReportResponse\":{\"type\":\"object\",\"properties\":{\"current\":{\"$ref\":\"#/definitions/extEpochEligibility\"},\"next\":{\"$ref\":\"#/definitions/extEpochEligibility\"}}},\"extEpochEligibility\":{\"type\":\"object\",\"properties\":{\"epoch\":{\"type\":\"string\",\"format\":\"uint64\"},\"atx_id\":{\"type\":\"string\",\"format\":\"byte\"},\"active_set_size\":{\"type\":\"integer\",\"format\":\"int64\"},\"num_blocks\":{\"type\":\"integer\",\"format\":\"int64\"},\"block_layers\":{\"type\":\"array\",\"items\":{\"$ref\":\"#/definitions/extLayerEligibility\"}},\"hare_layers\":{\"type\":\"array\",\"items\":{\"type\":\"string\",\"format\":\"uint64\"}}}},\"extLayerEligibility\":{\"type\":\"object\",\"properties\":{\"layer\":{\"type\":\"string\",\"format\":\"uint64\"},\"num_blocks\":{\"type\":\"integer\",\"format\":\"int64\"}}},\"protobufAny\":{\"type\":\"object\",\"properties\":{\"type_url\":{\"type\":\"string\"},\"value\":{\"type\":\"string\",\"format\":\"byte\"}}},\"runtimeError\":{\"type\":\"object\",\"properties\":{\"error\":{\"type\":\"string\"},\"code\":{\"type\":\"integer\",\"format\":\"int32\"},\"message\":{\"type\":\"string\"},\"details\":{\"type\":\"array\",\"items\":{\"$ref\":\"#/definitions/protobufAny\"}}}}}}"),
+	"tx":          []byte("{\"swagger\":\"2.0\",\"info\":{\"title\":\"api/extpb/tx.proto\",\"version\":\"version not set\"},\"consumes\":[\"application/json\"],\"produces\":[\"application/json\"],\"paths\":{\"/v1/tx/accounttransactions\":{\"get\":{\"summary\":\"Returns the transactions in the mesh that involve an account, ordered by layer\",\"operationId\":\"TransactionService_AccountTransactions2\",\"responses\":{\"200\":{\"description\":\"A successful response.\",\"schema\":{\"$ref\":\"#/definitions/extAccountTransactionsResponse\"}},\"default\":{\"description\":\"An unexpected error response\",\"schema\":{\"$ref\":\"#/definitions/runtimeError\"}}},\"parameters\":[{\"name\":\"account_id\",\"in\":\"query\",\"required\":false,\"type\":\"string\",\"format\":\"byte\"},{\"name\":\"direction\",\"in\":\"query\",\"required\":false,\"type\":\"string\",\"enum\":[\"TRANSACTION_DIRECTION_ANY\",\"TRANSACTION_DIRECTION_SENT\",\"TRANSACTION_DIRECTION_RECEIVED\"],\"default\":\"TRANSACTION_DIRECTION_ANY\"},{\"name\":\"min_layer\",\"in\":\"query\",\"required\":false,\"type\":\"string\",\"format\":\"uint64\"},{\"name\":\"max_layer\",\"in\":\"query\",\"required\":false,\"type\":\"string\",\"format\":\"uint64\"},{\"name\":\"max_results\",\"in\":\"query\",\"required\":false,\"type\":\"integer\",\"format\":\"int64\"},{\"name\":\"page_token\",\"in\":\"query\",\"required\":false,\"type\":\"string\",\"format\":\"byte\"}],\"tags\":[\"TransactionService\"]},\"post\":{\"summary\":\"Returns the transactions in the mesh that involve an account, ordered by layer\",\"operationId\":\"TransactionService_AccountTransactions\",\"responses\":{\"200\":{\"description\":\"A successful response.\",\"schema\":{\"$ref\":\"#/definitions/extAccountTransactionsResponse\"}},\"default\":{\"description\":\"An unexpected error response\",\"schema\":{\"$ref\":\"#/definitions/runtimeError\"}}},\"parameters\":[{\"name\":\"body\",\"in\":\"body\",\"required\":true,\"schema\":{\"$ref\":\"#/definitions/extAccountTransactionsRequest\"}}],\"tags\":[\"TransactionService\"]}},\"/v1/tx/decodetransaction\":{\"post\":{\"summary\":\"Decodes a signed transaction without validating or submitting it\",\"operationId\":\"TransactionService_DecodeTransaction\",\"responses\":{\"200\":{\"description\":\"A successful response.\",\"schema\":{\"$ref\":\"#/definitions/extDecodeTransactionResponse\"}},\"default\":{\"description\":\"An unexpected error response\",\"schema\":{\"$ref\":\"#/definitions/runtimeError\"}}},\"parameters\":[{\"name\":\"body\",\"in\":\"body\",\"required\":true,\"schema\":{\"$ref\":\"#/definitions/extDecodeTransactionRequest\"}}],\"tags\":[\"TransactionService\"]}},\"/v1/tx/estimatefee\":{\"post\":{\"summary\":\"Recommends fees based on recent blocks, the mempool and the minimal fee of this node\",\"operationId\":\"TransactionService_EstimateFee\",\"responses\":{\"200\":{\"description\":\"A successful response.\",\"schema\":{\"$ref\":\"#/definitions/extEstimateFeeResponse\"}},\"default\":{\"description\":\"An unexpected error response\",\"schema\":{\"$ref\":\"#/definitions/runtimeError\"}}},\"parameters\":[{\"name\":\"body\",\"in\":\"body\",\"required\":true,\"schema\":{\"$ref\":\"#/definitions/extEstimateFeeRequest\"}}],\"tags\":[\"TransactionService\"]}},\"/v1/tx/submittransactions\":{\"post\":{\"summary\":\"Validates a batch of signed transactions and broadcasts the valid ones, unless dry_run is set\",\"operationId\":\"TransactionService_SubmitTransactions\",\"responses\":{\"200\":{\"description\":\"A successful response.\",\"schema\":{\"$ref\":\"#/definitions/extSubmitTransactionsResponse\"}},\"default\":{\"description\":\"An unexpected error response\",\"schema\":{\"$ref\":\"#/definitions/runtimeError\"}}},\"parameters\":[{\"name\":\"body\",\"in\":\"body\",\"required\":true,\"schema\":{\"$ref\":\"#/definitions/extSubmitTransactionsRequest\"}}],\"tags\":[\"TransactionService\"]}},\"/v1/tx/submittransactionwithoptions\":{\"post\":{\"summary\":\"Validates a signed transaction against the projected global state and, unless dry_run is set, broadcasts it\",\"operationId\":\"TransactionService_SubmitTransactionWithOptions\",\"responses\":{\"200\":{\"description\":\"A successful response.\",\"schema\":{\"$ref\":\"#/definitions/extSubmitTransactionWithOptionsResponse\"}},\"default\":{\"description\":\"An unexpected error response\",\"schema\":{\"$ref\":\"#/definitions/runtimeError\"}}},\"parameters\":[{\"name\":\"body\",\"in\":\"body\",\"required\":true,\"schema\":{\"$ref\":\"#/definitions/extSubmitTransactionWithOptionsRequest\"}}],\"tags\":[\"TransactionService\"]}},\"/v1/tx/transactionreceipt\":{\"get\":{\"summary\":\"Returns the receipt of a transaction that was applied to the global state, including the ones that failed\",\"operationId\":\"TransactionService_TransactionReceipt2\",\"responses\":{\"200\":{\"description\":\"A successful response.\",\"schema\":{\"$ref\":\"#/definitions/extTransactionReceiptResponse\"}},\"default\":{\"description\":\"An unexpected error response\",\"schema\":{\"$ref\":\"#/definitions/runtimeError\"}}},\"parameters\":[{\"name\":\"id\",\"in\":\"query\",\"required\":false,\"type\":\"string\",\"format\":\"byte\"}],\"tags\":[\"TransactionService\"]},\"post\":{\"summary\":\"Returns the receipt of a transaction that was applied to the global state, including the ones that failed\",\"operationId\":\"TransactionService_TransactionReceipt\",\"responses\":{\"200\":{\"description\":\"A successful response.\",\"schema\":{\"$ref\":\"#/definitions/extTransactionReceiptResponse\"}},\"default\":{\"description\":\"An unexpected error response\",\"schema\":{\"$ref\":\"#/definitions/runtimeError\"}}},\"parameters\":[{\"name\":\"body\",\"in\":\"body\",\"required\":true,\"schema\":{\"$ref\":\"#/definitions/extTransactionReceiptRequest\"}}],\"tags\":[\"TransactionService\"]}},\"/v1/tx/transactionreceiptstream\":{\"post\":{\"summary\":\"Streams the receipts of transactions as layers are applied to the global state\",\"operationId\":\"TransactionService_TransactionReceiptStream\",\"responses\":{\"200\":{\"description\":\"A successful response.(streaming responses)\",\"schema\":{\"type\":\"object\",\"properties\":{\"result\":{\"$ref\":\"#/definitions/extTransactionReceiptStreamResponse\"},\"error\":{\"$ref\":\"#/definitions/runtimeStreamError\"}},\"title\":\"Stream result of extTransactionReceiptStreamResponse\"}},\"default\":{\"description\":\"An unexpected error response\",\"schema\":{\"$ref\":\"#/definitions/runtimeError\"}}},\"parameters\":[{\"name\":\"body\",\"in\":\"body\",\"required\":true,\"schema\":{\"$ref\":\"#/definitions/extTransactionReceiptStreamRequest\"}}],\"tags\":[\"TransactionService\"]}}},\"definitions\":{\"TransactionReceiptTransactionResult\":{\"type\":\"string\",\"enum\":[\"TRANSACTION_RESULT_UNSPECIFIED\",\"TRANSACTION_RESULT_APPLIED\",\"TRANSACTION_RESULT_UNKNOWN_ORIGIN\",\"TRANSACTION_RESULT_BAD_COUNTER\",\"TRANSACTION_RESULT_INSUFFICIENT_FUNDS\"],\"default\":\"TRANSACTION_RESULT_UNSPECIFIED\"},\"extAccountTransaction\":{\"type\":\"object\",\"properties\":{\"transaction\":{\"$ref\":\"#/definitions/extTransaction\"},\"layer\":{\"type\":\"string\",\"format\":\"uint64\"},\"sent\":{\"type\":\"boolean\",\"format\":\"boolean\"},\"received\":{\"type\":\"boolean\",\"format\":\"boolean\"}},\"description\":\"AccountTransaction is a transaction in the history of an account. A transaction included in blocks of several layers\\nappears once for every layer.\"},\"extAccountTransactionsRequest\":{\"type\":\"object\",\"properties\":{\"account_id\":{\"type\":\"string\",\"format\":\"byte\"},\"direction\":{\"$ref\":\"#/definitions/extTransactionDirection\"},\"min_layer\":{\"type\":\"string\",\"format\":\"uint64\"},\"max_layer\":{\"type\":\"string\",\"format\":\"uint64\"},\"max_results\":{\"type\":\"integer\",\"format\":\"int64\"},\"page_token\":{\"type\":\"string\",\"format\":\"byte\"}}},\"extAccountTransactionsResponse\":{\"type\":\"object\",\"properties\":{\"transactions\":{\"type\":\"array\",\"items\":{\"$ref\":\"#/definitions/extAccountTransaction\"}},\"next_page_token\":{\"type\":\"string\",\"format\":\"byte\"}}},\"extDecodeTransactionRequest\":{\"type\":\"object\",\"properties\":{\"transaction\":{\"type\":\"string\",\"format\":\"byte\"}}},\"extDecodeTransactionResponse\":{\"type\":\"object\",\"properties\":{\"transaction\":{\"$ref\":\"#/definitions/extTransaction\"}}},\"extEstimateFeeRequest\":{\"type\":\"object\"},\"extEstimateFeeResponse\":{\"type\":\"object\",\"properties\":{\"low_fee\":{\"type\":\"string\",\"format\":\"uint64\"},\"medium_fee\":{\"type\":\"string\",\"format\":\"uint64\"},\"high_fee\":{\"type\":\"string\",\"format\":\"uint64\"},\"min_fee\":{\"type\":\"string\",\"format\":\"uint64\"},\"mempool_size\":{\"type\":\"string\",\"format\":\"uint64\"},\"congested\":{\"type\":\"boolean\",\"format\":\"boolean\"},\"sampled_layers\":{\"type\":\"string\",\"format\":\"uint64\"},\"sampled_transactions\":{\"type\":\"string\",\"format\":\"uint64\"}}},\"extSubmitTransactionWithOptionsRequest\":{\"type\":\"object\",\"properties\":{\"transaction\":{\"type\":\"string\",\"format\":\"byte\"},\"dry_run\":{\"type\":\"boolean\",\"format\":\"boolean\"},\"origin\":{\"type\":\"string\",\"format\":\"byte\"}}},\"extSubmitTransactionWithOptionsResponse\":{\"type\":\"object\",\"properties\":{\"id\":{\"type\":\"string\",\"format\":\"byte\"},\"validity\":{\"$ref\":\"#/definitions/extTransactionValidity\"},\"message\":{\"type\":\"string\"},\"projected_nonce\":{\"type\":\"string\",\"format\":\"uint64\"},\"projected_balance\":{\"type\":\"string\",\"format\":\"uint64\"},\"broadcast\":{\"type\":\"boolean\",\"format\":\"boolean\"}}},\"extSubmitTransactionsRequest\":{\"type\":\"object\",\"properties\":{\"transactions\":{\"type\":\"array\",\"items\":{\"type\":\"string\",\"format\":\"byte\"}},\"dry_run\":{\"type\":\"boolean\",\"format\":\"boolean\"},\"origins\":{\"type\":\"array\",\"items\":{\"type\":\"string\",\"format\":\"byte\"}}}},\"extSubmitTransactionsResponse\":{\"type\":\"object\",\"properties\":{\"results\":{\"type\":\"array\",\"items\":{\"$ref\":\"#/definitions/extSubmitTransactionWithOptionsResponse\"},\"description\":\"one result for every submitted transaction, in order. The projected state of a transaction includes the valid\\ntransactions of the same sender that precede it in the batch.\"}}},\"extTransaction\":{\"type\":\"object\",\"properties\":{\"id\":{\"type\":\"string\",\"format\":\"byte\"},\"sender\":{\"type\":\"string\",\"format\":\"byte\"},\"recipient\":{\"type\":\"string\",\"format\":\"byte\"},\"amount\":{\"type\":\"string\",\"format\":\"uint64\"},\"counter\":{\"type\":\"string\",\"format\":\"uint64\"},\"gas_limit\":{\"type\":\"string\",\"format\":\"uint64\"},\"fee\":{\"type\":\"string\",\"format\":\"uint64\"},\"signature\":{\"type\":\"string\",\"format\":\"byte\"}}},\"extTransactionDirection\":{\"type\":\"string\",\"enum\":[\"TRANSACTION_DIRECTION_ANY\",\"TRANSACTION_DIRECTION_SENT\",\"TRANSACTION_DIRECTION_RECEIVED\"],\"default\":\"TRANSACTION_DIRECTION_ANY\",\"title\":\"TransactionDirection filters the transactions of an account by how they involve it\"},\"extTransactionReceipt\":{\"type\":\"object\",\"properties\":{\"id\":{\"type\":\"string\",\"format\":\"byte\"},\"layer\":{\"type\":\"string\",\"format\":\"uint64\"},\"result\":{\"$ref\":\"#/definitions/TransactionReceiptTransactionResult\"},\"gas_used\":{\"type\":\"string\",\"format\":\"uint64\"},\"fee\":{\"type\":\"string\",\"format\":\"uint64\"},\"error\":{\"type\":\"string\"}},\"title\":\"TransactionReceipt is the outcome of applying a transaction of a confirmed layer to the global state\"},\"extTransactionReceiptRequest\":{\"type\":\"object\",\"properties\":{\"id\":{\"type\":\"string\",\"format\":\"byte\"}}},\"extTransactionReceiptResponse\":{\"type\":\"object\",\"properties\":{\"receipt\":{\"$ref\":\"#/definitions/extTransactionReceipt\"}}},\"extTransactionReceiptStreamRequest\":{\"type\":\"object\",\"properties\":{\"id\":{\"type\":\"array\",\"items\":{\"type\":\"string\",\"format\":\"byte\"}}}},\"extTransactionReceiptStreamResponse\":{\"type\":\"object\",\"properties\":{\"receipt\":{\"$ref\":\"#/definitions/extTransactionReceipt\"}}},\"extTransactionValidity\":{\"type\":\"string\",\"enum\":[\"TRANSACTION_VALIDITY_VALID\",\"TRANSACTION_VALIDITY_MALFORMED\",\"TRANSACTION_VALIDITY_INVALID_SIGNATURE\",\"TRANSACTION_VALIDITY_UNKNOWN_ORIGIN\",\"TRANSACTION_VALIDITY_BAD_NONCE\",\"TRANSACTION_VALIDITY_INSUFFICIENT_BALANCE\",\"TRANSACTION_VALIDITY_FEE_TOO_LOW\"],\"default\":\"TRANSACTION_VALIDITY_VALID\",\"description\":\"- TRANSACTION_VALIDITY_INVALID_SIGNATURE: the signer could not be recovered from the signature, it isn't the given origin or, if no origin was given, it\\nhas no account in the global state. A bad signature recovers a random signer, which doesn't have an account.\",\"title\":\"TransactionValidity is the result of validating a submitted transaction\"},\"protobufAny\":{\"type\":\"object\",\"properties\":{\"type_url\":{\"type\":\"string\"},\"value\":{\"type\":\"string\",\"format\":\"byte\"}}},\"runtimeError\":{\"type\":\"object\",\"properties\":{\"error\":{\"type\":\"string\"},\"code\":{\"type\":\"integer\",\"format\":\"int32\"},\"message\":{\"type\":\"string\"},\"details\":{\"type\":\"array\",\"items\":{\"$ref\":\"#/definitions/protobufAny\"}}}},\"runtimeStreamError\":{\"type\":\"object\",\"properties\":{\"grpc_code\":{\"type\":\"integer\",\"format\":\"int32\"},\"http_code\":{\"type\":\"integer\",\"format\":\"int32\"},\"message\":{\"type\":\"string\"},\"http_status\":{\"type\":\"string\"},\"details\":{\"type\":\"array\",\"items\":{\"$ref\":\"#/definitions/protobufAny\"}}}}}}"),
+	"types":       []byte("{\"swagger\":\"2.0\",\"info\":{\"title\":\"api/extpb/types.proto\",\"version\":\"version not set\"},\"consumes\":[\"application/json\"],\"produces\":[\"application/json\"],\"paths\":{},\"definitions\":{\"protobufAny\":{\"type\":\"object\",\"properties\":{\"type_url\":{\"type\":\"string\"},\"value\":{\"type\":\"string\",\"format\":\"byte\"}}},\"runtimeError\":{\"type\":\"object\",\"properties\":{\"error\":{\"type\":\"string\"},\"code\":{\"type\":\"integer\",\"format\":\"int32\"},\"message\":{\"type\":\"string\"},\"details\":{\"type\":\"array\",\"items\":{\"$ref\":\"#/definitions/protobufAny\"}}}}}}"),
+}
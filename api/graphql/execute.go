@@ -0,0 +1,482 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+)
+
+const (
+	// maxDepth limits the nesting of the fields of a request, relations between objects are cyclic and a deep request
+	// can make the node read a large part of its database
+	maxDepth = 10
+	// maxComplexity limits the number of fields a request resolves, counting every item of list fields. Lists are
+	// counted at the size their arguments limit them to, or at the size estimated by the schema.
+	maxComplexity = 50000
+)
+
+// object is a GraphQL object type whose fields are resolved from a source value
+type object struct {
+	name   string
+	fields map[string]*field
+}
+
+// resolver returns the value of a field of the given source. Fields of object types return the source of the nested
+// object, or a slice of sources for lists. A nil value is returned as null.
+type resolver func(source interface{}, args arguments) (interface{}, error)
+
+type field struct {
+	// typ is the object type of the field, it's nil for scalar fields
+	typ  *object
+	args []string
+	// items returns the number of items a list field is expected to return given the literal values of its arguments,
+	// it's nil for fields that aren't lists
+	items   func(args map[string]interface{}) int
+	resolve resolver
+}
+
+// Error is an error that occurred while processing a request, path is set for errors of specific fields
+type Error struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path,omitempty"`
+}
+
+// Response is the result of a GraphQL request. Data is only omitted if the request wasn't executed at all.
+type Response struct {
+	Data   *orderedMap `json:"data,omitempty"`
+	Errors []*Error    `json:"errors,omitempty"`
+}
+
+func errorResponse(err error) *Response {
+	return &Response{Errors: []*Error{{Message: err.Error()}}}
+}
+
+// orderedMap keeps the fields of a result in the order they were requested in
+type orderedMap struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func newOrderedMap() *orderedMap {
+	return &orderedMap{values: make(map[string]interface{})}
+}
+
+func (m *orderedMap) set(key string, value interface{}) {
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+// MarshalJSON implements json.Marshaler
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		v, err := json.Marshal(m.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// execute runs a query against the schema with the given root query type
+func execute(query *object, request string, operationName string, variables map[string]interface{}) *Response {
+	doc, err := parse(request)
+	if err != nil {
+		return errorResponse(err)
+	}
+	op, err := doc.operation(operationName)
+	if err != nil {
+		return errorResponse(err)
+	}
+	e := &executor{doc: doc}
+	if e.variables, err = op.coerceVariables(variables); err != nil {
+		return errorResponse(err)
+	}
+	if _, err := e.validate(query, op.selections, 1, make(map[string]bool)); err != nil {
+		return errorResponse(err)
+	}
+	data := e.selectionSet(query, nil, op.selections, nil)
+	return &Response{Data: data, Errors: e.errors}
+}
+
+func (d *document) operation(name string) (*operation, error) {
+	if name == "" {
+		if len(d.operations) > 1 {
+			return nil, fmt.Errorf("the operation name must be provided when the request contains several operations")
+		}
+		return d.operations[0], nil
+	}
+	for _, op := range d.operations {
+		if op.name == name {
+			return op, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown operation %v", name)
+}
+
+func (op *operation) coerceVariables(values map[string]interface{}) (map[string]interface{}, error) {
+	vars := make(map[string]interface{})
+	for _, def := range op.variables {
+		v, ok := values[def.name]
+		switch {
+		case ok:
+			vars[def.name] = normalize(v)
+		case def.defaultValue != nil:
+			vars[def.name] = def.defaultValue
+		case def.required:
+			return nil, fmt.Errorf("variable $%v is required", def.name)
+		}
+		if def.required && vars[def.name] == nil {
+			return nil, fmt.Errorf("variable $%v can't be null", def.name)
+		}
+	}
+	return vars, nil
+}
+
+// normalize converts the numbers of a decoded JSON value to the types the parser uses for literals
+func normalize(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return int64(v)
+		}
+		return v
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i := range v {
+			list[i] = normalize(v[i])
+		}
+		return list
+	case map[string]interface{}:
+		obj := make(map[string]interface{}, len(v))
+		for k := range v {
+			obj[k] = normalize(v[k])
+		}
+		return obj
+	}
+	return v
+}
+
+type executor struct {
+	doc       *document
+	variables map[string]interface{}
+	errors    []*Error
+}
+
+// validate checks that the selections only request existing fields with known arguments, that object fields select
+// sub-fields and that the request isn't nested too deep or too complex. It returns the complexity of the selections,
+// and stops as soon as it exceeds maxComplexity so that fragments spread many times don't make it slow.
+func (e *executor) validate(obj *object, sels []*selection, depth int, fragments map[string]bool) (int, error) {
+	if depth > maxDepth {
+		return 0, fmt.Errorf("the request is nested deeper than %d levels", maxDepth)
+	}
+	complexity := 0
+	for _, sel := range sels {
+		cost, err := e.validateSelection(obj, sel, depth, fragments)
+		if err != nil {
+			return 0, err
+		}
+		if complexity += cost; complexity > maxComplexity {
+			return 0, fmt.Errorf("the request is more complex than %d fields", maxComplexity)
+		}
+	}
+	return complexity, nil
+}
+
+func (e *executor) validateSelection(obj *object, sel *selection, depth int, fragments map[string]bool) (int, error) {
+	if sel.fragment != "" {
+		f, ok := e.doc.fragments[sel.fragment]
+		if !ok {
+			return 0, fmt.Errorf("unknown fragment %v", sel.fragment)
+		}
+		if fragments[sel.fragment] {
+			return 0, fmt.Errorf("fragment %v spreads itself", sel.fragment)
+		}
+		fragments[sel.fragment] = true
+		defer delete(fragments, sel.fragment)
+		return e.validate(obj, f.selections, depth, fragments)
+	}
+	if sel.name == "" {
+		return e.validate(obj, sel.selections, depth, fragments)
+	}
+	if sel.name == "__typename" {
+		if len(sel.selections) > 0 {
+			return 0, fmt.Errorf("field __typename of %v can't have a selection", obj.name)
+		}
+		return 1, nil
+	}
+	f, ok := obj.fields[sel.name]
+	if !ok {
+		return 0, fmt.Errorf("unknown field %v of %v", sel.name, obj.name)
+	}
+	for name := range sel.arguments {
+		if !contains(f.args, name) {
+			return 0, fmt.Errorf("unknown argument %v of field %v of %v", name, sel.name, obj.name)
+		}
+	}
+	if f.typ == nil {
+		if len(sel.selections) > 0 {
+			return 0, fmt.Errorf("field %v of %v is a scalar and can't have a selection", sel.name, obj.name)
+		}
+		return 1, nil
+	}
+	if len(sel.selections) == 0 {
+		return 0, fmt.Errorf("field %v of %v must have a selection of subfields", sel.name, obj.name)
+	}
+	complexity, err := e.validate(f.typ, sel.selections, depth+1, fragments)
+	if err != nil {
+		return 0, err
+	}
+	if f.items != nil {
+		complexity *= f.items(sel.arguments)
+	}
+	return 1 + complexity, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// collectFields groups the fields of the selections by the key they are returned under, in the order they were first
+// requested in, following fragments and leaving out skipped fields
+func (e *executor) collectFields(sels []*selection, keys *[]string, fields map[string][]*selection) error {
+	for _, sel := range sels {
+		include, err := e.included(sel)
+		if err != nil {
+			return err
+		}
+		if !include {
+			continue
+		}
+		switch {
+		case sel.fragment != "":
+			if err := e.collectFields(e.doc.fragments[sel.fragment].selections, keys, fields); err != nil {
+				return err
+			}
+		case sel.name == "":
+			if err := e.collectFields(sel.selections, keys, fields); err != nil {
+				return err
+			}
+		default:
+			if _, ok := fields[sel.alias]; !ok {
+				*keys = append(*keys, sel.alias)
+			}
+			fields[sel.alias] = append(fields[sel.alias], sel)
+		}
+	}
+	return nil
+}
+
+func (e *executor) included(sel *selection) (bool, error) {
+	for _, dir := range sel.directives {
+		if dir.name != "include" && dir.name != "skip" {
+			return false, fmt.Errorf("unknown directive @%v", dir.name)
+		}
+		args, err := e.arguments(dir.arguments)
+		if err != nil {
+			return false, err
+		}
+		cond, ok := args["if"].(bool)
+		if !ok {
+			return false, fmt.Errorf("argument if of directive @%v must be a boolean", dir.name)
+		}
+		if cond != (dir.name == "include") {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// arguments replaces the variables in the argument values with their values
+func (e *executor) arguments(args map[string]interface{}) (arguments, error) {
+	res := make(arguments, len(args))
+	for name, v := range args {
+		v, err := e.substitute(v)
+		if err != nil {
+			return nil, err
+		}
+		res[name] = v
+	}
+	return res, nil
+}
+
+func (e *executor) substitute(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case variable:
+		value, ok := e.variables[string(v)]
+		if !ok {
+			return nil, fmt.Errorf("variable $%v is not defined", v)
+		}
+		return value, nil
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i := range v {
+			item, err := e.substitute(v[i])
+			if err != nil {
+				return nil, err
+			}
+			list[i] = item
+		}
+		return list, nil
+	case map[string]interface{}:
+		obj := make(map[string]interface{}, len(v))
+		for k := range v {
+			item, err := e.substitute(v[k])
+			if err != nil {
+				return nil, err
+			}
+			obj[k] = item
+		}
+		return obj, nil
+	}
+	return v, nil
+}
+
+func (e *executor) addError(path []interface{}, err error) {
+	e.errors = append(e.errors, &Error{Message: err.Error(), Path: append([]interface{}{}, path...)})
+}
+
+func (e *executor) selectionSet(obj *object, source interface{}, sels []*selection, path []interface{}) *orderedMap {
+	var keys []string
+	fields := make(map[string][]*selection)
+	if err := e.collectFields(sels, &keys, fields); err != nil {
+		e.addError(path, err)
+		return nil
+	}
+	res := newOrderedMap()
+	for _, key := range keys {
+		res.set(key, e.field(obj, source, fields[key], append(path, key)))
+	}
+	return res
+}
+
+func (e *executor) field(obj *object, source interface{}, sels []*selection, path []interface{}) interface{} {
+	name := sels[0].name
+	if name == "__typename" {
+		return obj.name
+	}
+	f := obj.fields[name]
+	args, err := e.arguments(sels[0].arguments)
+	if err != nil {
+		e.addError(path, err)
+		return nil
+	}
+	value, err := f.resolve(source, args)
+	if err != nil {
+		e.addError(path, err)
+		return nil
+	}
+	if f.typ == nil || isNull(value) {
+		return value
+	}
+
+	var subs []*selection
+	for _, sel := range sels {
+		subs = append(subs, sel.selections...)
+	}
+	list := reflect.ValueOf(value)
+	if list.Kind() != reflect.Slice {
+		return e.selectionSet(f.typ, value, subs, path)
+	}
+	items := make([]interface{}, list.Len())
+	for i := range items {
+		items[i] = e.selectionSet(f.typ, list.Index(i).Interface(), subs, append(path, i))
+	}
+	return items
+}
+
+// isNull tells if the value of an object field is null, resolvers may return nil pointers of the source type
+func isNull(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	return v.Kind() == reflect.Ptr && v.IsNil()
+}
+
+// arguments are the values of the arguments of a field, after replacing variables
+type arguments map[string]interface{}
+
+// uint64 returns the value of an unsigned integer argument and whether it was set
+func (a arguments) uint64(name string) (uint64, bool, error) {
+	v, ok := a[name]
+	if !ok || v == nil {
+		return 0, false, nil
+	}
+	i, ok := v.(int64)
+	if !ok || i < 0 {
+		return 0, false, fmt.Errorf("argument %v must be a non negative integer", name)
+	}
+	return uint64(i), true, nil
+}
+
+// requiredUint64 returns the value of an unsigned integer argument that must be set
+func (a arguments) requiredUint64(name string) (uint64, error) {
+	v, ok, err := a.uint64(name)
+	if err == nil && !ok {
+		err = fmt.Errorf("argument %v is required", name)
+	}
+	return v, err
+}
+
+// string returns the value of a string argument and whether it was set
+func (a arguments) string(name string) (string, bool, error) {
+	v, ok := a[name]
+	if !ok || v == nil {
+		return "", false, nil
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", false, fmt.Errorf("argument %v must be a string", name)
+	}
+	return s, true, nil
+}
+
+// requiredString returns the value of a string argument that must be set
+func (a arguments) requiredString(name string) (string, error) {
+	v, ok, err := a.string(name)
+	if err == nil && !ok {
+		err = fmt.Errorf("argument %v is required", name)
+	}
+	return v, err
+}
+
+// enum returns the value of an enum argument and whether it was set, enum values in variables are strings
+func (a arguments) enum(name string) (string, bool, error) {
+	switch v := a[name].(type) {
+	case nil:
+		return "", false, nil
+	case enumValue:
+		return string(v), true, nil
+	case string:
+		return v, true, nil
+	}
+	return "", false, fmt.Errorf("argument %v must be an enum value", name)
+}
@@ -0,0 +1,428 @@
+package graphql
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/spacemeshos/go-spacemesh/api"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/common/util"
+	"github.com/spacemeshos/go-spacemesh/database"
+	"github.com/spacemeshos/go-spacemesh/events"
+	"github.com/spacemeshos/go-spacemesh/mesh"
+	"github.com/spacemeshos/go-spacemesh/signing"
+	"github.com/spacemeshos/go-spacemesh/state"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	signer    = signing.NewEdSigner()
+	recipient = types.HexToAddress("0xaaaa")
+	nodeID    = types.NodeID{Key: signer.PublicKey().String()}
+)
+
+func newTx(t *testing.T, nonce, amount uint64) *types.Transaction {
+	tx, err := mesh.NewSignedTx(nonce, recipient, amount, 3, 1, signer)
+	require.NoError(t, err)
+	return tx
+}
+
+// meshMock implements the parts of the TxAPI the schema uses
+type meshMock struct {
+	api.TxAPI
+	layers  map[types.LayerID]*types.Layer
+	txs     map[types.TransactionID]*types.Transaction
+	history []mesh.AccountTx
+	rewards []types.Reward
+}
+
+func (m *meshMock) LatestLayer() types.LayerID { return 10 }
+
+func (m *meshMock) GetLayer(i types.LayerID) (*types.Layer, error) {
+	if l, ok := m.layers[i]; ok {
+		return l, nil
+	}
+	return nil, database.ErrNotFound
+}
+
+func (m *meshMock) GetBlock(id types.BlockID) (*types.Block, error) {
+	for _, l := range m.layers {
+		for _, b := range l.Blocks() {
+			if b.ID() == id {
+				return b, nil
+			}
+		}
+	}
+	return nil, database.ErrNotFound
+}
+
+func (m *meshMock) GetTransaction(id types.TransactionID) (*types.Transaction, error) {
+	if tx, ok := m.txs[id]; ok {
+		return tx, nil
+	}
+	return nil, errors.New("not found")
+}
+
+func (m *meshMock) GetTransactions(ids []types.TransactionID) ([]*types.Transaction, map[types.TransactionID]struct{}) {
+	var txs []*types.Transaction
+	missing := make(map[types.TransactionID]struct{})
+	for _, id := range ids {
+		if tx, ok := m.txs[id]; ok {
+			txs = append(txs, tx)
+		} else {
+			missing[id] = struct{}{}
+		}
+	}
+	return txs, missing
+}
+
+func (m *meshMock) GetLayerApplied(id types.TransactionID) *types.LayerID {
+	for _, h := range m.history {
+		if h.ID == id {
+			layer := h.Layer
+			return &layer
+		}
+	}
+	return nil
+}
+
+func (m *meshMock) GetAccountTransactions(account types.Address, direction mesh.TxDirection, minLayer, maxLayer types.LayerID,
+	cursor []byte, limit int) ([]mesh.AccountTx, []byte, error) {
+	var res []mesh.AccountTx
+	for _, h := range m.history {
+		if h.Layer >= minLayer && h.Layer <= maxLayer && h.Direction&direction != 0 && len(res) < limit {
+			res = append(res, h)
+		}
+	}
+	return res, nil, nil
+}
+
+func (m *meshMock) GetRewards(account types.Address) ([]types.Reward, error) {
+	return m.rewards, nil
+}
+
+type stateMock map[types.Address]uint64
+
+func (s stateMock) GetBalance(addr types.Address) uint64 { return s[addr] }
+func (s stateMock) GetNonce(addr types.Address) uint64   { return 1 }
+func (s stateMock) Exist(addr types.Address) bool        { _, ok := s[addr]; return ok }
+
+type receiptsMock map[types.TransactionID]*state.Receipt
+
+func (r receiptsMock) Get(id types.TransactionID) (*state.Receipt, error) {
+	if receipt, ok := r[id]; ok {
+		return receipt, nil
+	}
+	return nil, database.ErrNotFound
+}
+
+type atxsMock map[types.EpochID]*types.ActivationTx
+
+func (a atxsMock) GetNodeLastAtxID(nodeID types.NodeID) (types.ATXID, error) {
+	var last *types.ActivationTx
+	for _, atx := range a {
+		if atx.NodeID.Key == nodeID.Key && (last == nil || atx.PubLayerID > last.PubLayerID) {
+			last = atx
+		}
+	}
+	if last == nil {
+		return types.ATXID{}, errors.New("not found")
+	}
+	return last.ID(), nil
+}
+
+func (a atxsMock) GetNodeAtxIDForEpoch(nodeID types.NodeID, epoch types.EpochID) (types.ATXID, error) {
+	if atx, ok := a[epoch]; ok && atx.NodeID.Key == nodeID.Key {
+		return atx.ID(), nil
+	}
+	return types.ATXID{}, errors.New("not found")
+}
+
+func (a atxsMock) GetFullAtx(id types.ATXID) (*types.ActivationTx, error) {
+	for _, atx := range a {
+		if atx.ID() == id {
+			return atx, nil
+		}
+	}
+	return nil, errors.New("not found")
+}
+
+type fixture struct {
+	server *Server
+	sender types.Address
+	tx1    *types.Transaction
+	tx2    *types.Transaction
+	block  *types.Block
+	atx    *types.ActivationTx
+}
+
+func newFixture(t *testing.T) *fixture {
+	types.SetLayersPerEpoch(3)
+	f := &fixture{tx1: newTx(t, 1, 10), tx2: newTx(t, 2, 20)}
+	f.sender = f.tx1.Origin()
+
+	f.atx = types.NewActivationTx(types.NIPSTChallenge{NodeID: nodeID, PubLayerID: 4}, recipient, &types.NIPST{Space: 1024}, nil)
+	f.block = types.NewExistingBlock(7, []byte("data"))
+	f.block.ATXID = f.atx.ID()
+	f.block.TxIDs = []types.TransactionID{f.tx1.ID(), f.tx2.ID()}
+	f.block.Signature = signer.Sign(f.block.Bytes())
+	f.block.Initialize()
+	layer := types.NewLayer(7)
+	layer.AddBlock(f.block)
+
+	msh := &meshMock{
+		layers: map[types.LayerID]*types.Layer{7: layer, 8: types.NewLayer(8)},
+		txs:    map[types.TransactionID]*types.Transaction{f.tx1.ID(): f.tx1, f.tx2.ID(): f.tx2},
+		history: []mesh.AccountTx{
+			{ID: f.tx1.ID(), Layer: 7, Direction: mesh.TxSent},
+			{ID: f.tx2.ID(), Layer: 9, Direction: mesh.TxSent},
+		},
+		rewards: []types.Reward{{Layer: 5, TotalReward: 100, LayerRewardEstimate: 90}, {Layer: 7, TotalReward: 50, LayerRewardEstimate: 40}},
+	}
+	receipts := receiptsMock{
+		f.tx1.ID(): {ID: f.tx1.ID(), Layer: 7, Result: events.TxResultApplied, GasUsed: 3, Fee: 1},
+		f.tx2.ID(): {ID: f.tx2.ID(), Layer: 9, Result: events.TxResultInsufficientFunds, Error: "insufficient funds"},
+	}
+	f.server = NewServer(0, msh, stateMock{f.sender: 1000, recipient: 30}, receipts, atxsMock{2: f.atx})
+	return f
+}
+
+// query runs a request against the server and returns the decoded data and errors
+func (f *fixture) query(t *testing.T, query string, variables map[string]interface{}) (map[string]interface{}, []interface{}) {
+	body, err := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	require.NoError(t, err)
+	rec := httptest.NewRecorder()
+	f.server.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body))))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	var res struct {
+		Data   map[string]interface{} `json:"data"`
+		Errors []interface{}          `json:"errors"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	return res.Data, res.Errors
+}
+
+func TestServer_AccountTransactionsWithReceipts(t *testing.T) {
+	f := newFixture(t)
+	data, errs := f.query(t, `query History($address: String!, $epoch: Int) {
+		account(address: $address) {
+			balance
+			transactions(epoch: $epoch, direction: SENT) {
+				id
+				amount
+				recipient { address balance }
+				receipt { result fee error }
+			}
+		}
+	}`, map[string]interface{}{"address": f.sender.String(), "epoch": 2})
+	require.Empty(t, errs)
+
+	account := data["account"].(map[string]interface{})
+	require.Equal(t, float64(1000), account["balance"])
+	// only the transaction of layer 7 is in epoch 2
+	txs := account["transactions"].([]interface{})
+	require.Len(t, txs, 1)
+	tx := txs[0].(map[string]interface{})
+	require.Equal(t, util.Encode(f.tx1.ID().Bytes()), tx["id"])
+	require.Equal(t, float64(10), tx["amount"])
+	require.Equal(t, map[string]interface{}{"address": recipient.String(), "balance": float64(30)}, tx["recipient"])
+	require.Equal(t, map[string]interface{}{"result": "APPLIED", "fee": float64(1), "error": nil}, tx["receipt"])
+
+	data, errs = f.query(t, `{ account(address: "`+f.sender.String()+`") { transactions { receipt { result error } } rewards(minLayer: 6) { layer total } } }`, nil)
+	require.Empty(t, errs)
+	account = data["account"].(map[string]interface{})
+	txs = account["transactions"].([]interface{})
+	require.Len(t, txs, 2)
+	require.Equal(t, map[string]interface{}{"result": "INSUFFICIENT_FUNDS", "error": "insufficient funds"},
+		txs[1].(map[string]interface{})["receipt"])
+	require.Equal(t, []interface{}{map[string]interface{}{"layer": float64(7), "total": float64(50)}}, account["rewards"])
+}
+
+func TestServer_LayersAndBlocks(t *testing.T) {
+	f := newFixture(t)
+	data, errs := f.query(t, `{
+		latestLayer
+		layers(from: 6, to: 8) { number epoch blocks { ...blockFields } }
+		missing: layer(number: 20) { number }
+	}
+	fragment blockFields on Block {
+		id
+		smesher { id latestActivation { layer space coinbase { address } } }
+		transactions { id layer { number } }
+	}`, nil)
+	require.Empty(t, errs)
+	require.Equal(t, float64(10), data["latestLayer"])
+	require.Nil(t, data["missing"])
+
+	layers := data["layers"].([]interface{})
+	require.Len(t, layers, 2)
+	layer := layers[0].(map[string]interface{})
+	require.Equal(t, float64(7), layer["number"])
+	require.Equal(t, float64(2), layer["epoch"])
+	block := layer["blocks"].([]interface{})[0].(map[string]interface{})
+	require.Equal(t, util.Encode(f.block.ID().Bytes()), block["id"])
+	require.Equal(t, map[string]interface{}{
+		"id": nodeID.Key,
+		"latestActivation": map[string]interface{}{
+			"layer":    float64(4),
+			"space":    float64(1024),
+			"coinbase": map[string]interface{}{"address": recipient.String()},
+		},
+	}, block["smesher"])
+	txs := block["transactions"].([]interface{})
+	require.Len(t, txs, 2)
+	require.Equal(t, map[string]interface{}{"number": float64(7)}, txs[0].(map[string]interface{})["layer"])
+	// layer 9 isn't in the mesh
+	require.Nil(t, txs[1].(map[string]interface{})["layer"])
+	require.Empty(t, layers[1].(map[string]interface{})["blocks"])
+
+	data, errs = f.query(t, `query ($id: String!) { block(id: $id) { transactions @skip(if: true) { id } layer { number } } }`,
+		map[string]interface{}{"id": util.Encode(f.block.ID().Bytes())})
+	require.Empty(t, errs)
+	require.Equal(t, map[string]interface{}{"layer": map[string]interface{}{"number": float64(7)}}, data["block"])
+}
+
+func TestServer_Errors(t *testing.T) {
+	f := newFixture(t)
+
+	// requests that don't match the schema aren't executed
+	for _, query := range []string{
+		`{ account(address: "0x1") { unknown } }`,
+		`{ account(address: "0x1") }`,
+		`{ latestLayer { number } }`,
+		`{ layer(number: 1, other: 2) { number } }`,
+		`{ ...missing }`,
+		`{ latestLayer`,
+		`mutation { latestLayer }`,
+		`query ($n: Int!) { layer(number: $n) { number } }`,
+		`{ a: account(address: "0x1") { transactions { sender { transactions { sender { transactions { sender {
+			transactions { sender { transactions { sender { address } } } } } } } } } } }`,
+	} {
+		data, errs := f.query(t, query, nil)
+		require.Nil(t, data, query)
+		require.Len(t, errs, 1, query)
+	}
+
+	// field errors only null the fields they occur in
+	data, errs := f.query(t, `{ latestLayer account(address: "not an address") { balance } }`, nil)
+	require.Equal(t, float64(10), data["latestLayer"])
+	require.Nil(t, data["account"])
+	require.Len(t, errs, 1)
+	require.Equal(t, []interface{}{"account"}, errs[0].(map[string]interface{})["path"])
+
+	rec := httptest.NewRecorder()
+	f.server.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader("not json")))
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	rec = httptest.NewRecorder()
+	f.server.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/graphql", nil))
+	require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestServer_Get(t *testing.T) {
+	f := newFixture(t)
+	rec := httptest.NewRecorder()
+	f.server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/graphql?query="+url.QueryEscape("{ latestLayer }"), nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.JSONEq(t, `{"data":{"latestLayer":10}}`, rec.Body.String())
+}
+
+func TestParse(t *testing.T) {
+	r := require.New(t)
+	doc, err := parse(`
+		# a named query
+		query Q($a: [String!]! = ["x"], $b: Int) @dir {
+			alias: field(s: "a\"bA", i: -3, f: 1.5e2, l: [1, 2], o: {k: $b}, e: ENUM, n: null, t: true) {
+				... on Type { x }
+				...frag @include(if: $b)
+			}
+		}
+		fragment frag on Type { y }`)
+	r.NoError(err)
+	r.Len(doc.operations, 1)
+	op := doc.operations[0]
+	r.Equal("Q", op.name)
+	r.Equal([]variableDefinition{{name: "a", required: true, defaultValue: []interface{}{"x"}}, {name: "b"}}, op.variables)
+	sel := op.selections[0]
+	r.Equal("alias", sel.alias)
+	r.Equal("field", sel.name)
+	r.Equal(map[string]interface{}{
+		"s": "a\"bA",
+		"i": int64(-3),
+		"f": 150.0,
+		"l": []interface{}{int64(1), int64(2)},
+		"o": map[string]interface{}{"k": variable("b")},
+		"e": enumValue("ENUM"),
+		"n": nil,
+		"t": true,
+	}, sel.arguments)
+	r.Len(sel.selections, 2)
+	r.Equal("x", sel.selections[0].selections[0].name)
+	r.Equal("frag", sel.selections[1].fragment)
+	r.Equal("include", sel.selections[1].directives[0].name)
+	r.Contains(doc.fragments, "frag")
+
+	for _, tc := range []struct {
+		query string
+		err   string
+	}{
+		{``, "the request doesn't contain any operation"},
+		{`{}`, "syntax error at 1: empty selection set"},
+		{`{ a(b: ) }`, `syntax error at 7: unexpected ")"`},
+		{`{ a(b: "x) }`, "syntax error at 7: unterminated string"},
+		{"{ a(b: \"x\ny\") }", "syntax error at 7: unterminated string"},
+		{`{ a(b: "\q") }`, `syntax error at 9: invalid escape sequence \q`},
+		{`{ a(b: "\u12g4") }`, "syntax error at 9: invalid unicode escape"},
+		{`{ a(b: 1.) }`, "syntax error at 7: invalid number"},
+		{`{ a(b: 1e) }`, "syntax error at 7: invalid number"},
+		{`{ a .. }`, "syntax error at 4: unexpected '.'"},
+		{`{ a % }`, "syntax error at 4: unexpected character '%'"},
+		{`{ a`, "syntax error at 3: unexpected end of request"},
+		{`mutation { a }`, "syntax error at 0: mutation operations are not supported"},
+		{`fragment on on T { a } { a }`, `syntax error at 9: invalid fragment name "on"`},
+		{`fragment f on T { a } fragment f on T { b } { a }`, "fragment f is defined more than once"},
+		{`{ a(b: ` + strings.Repeat("[", 1000), "syntax error at 56: the request is nested deeper than 50 levels"},
+		{strings.Repeat("{ a ", 100), "syntax error at 200: the request is nested deeper than 50 levels"},
+		{`query ($a: ` + strings.Repeat("[", 1000) + `Int` + strings.Repeat("]", 1000) + `) { a }`,
+			"syntax error at 61: the request is nested deeper than 50 levels"},
+	} {
+		_, err := parse(tc.query)
+		r.EqualError(err, tc.err, tc.query)
+	}
+}
+
+func TestServer_Complexity(t *testing.T) {
+	f := newFixture(t)
+
+	// lists are estimated from the range of layers and the number of transactions that are requested
+	data, errs := f.query(t, `{ layers(from: 0, to: 1) { blocks { id transactions { id } } } }`, nil)
+	require.Empty(t, errs)
+	require.NotNil(t, data["layers"])
+	for _, query := range []string{
+		`{ layers(from: 0, to: 99) { blocks { transactions { id sender { address } } } } }`,
+		`query ($to: Int!) { layers(from: 0, to: $to) { blocks { transactions { id } } } }`,
+		`{ account(address: "0x1") { transactions(first: 1000) { sender { transactions(first: 1000) { id } } } } }`,
+	} {
+		data, errs := f.query(t, query, map[string]interface{}{"to": 1})
+		require.Nil(t, data, query)
+		require.Len(t, errs, 1, query)
+		require.Equal(t, "the request is more complex than 50000 fields", errs[0].(map[string]interface{})["message"], query)
+	}
+
+	// fragments that spread each other several times are counted at every spread
+	query := `{ ...f0 } fragment f0 on Query { latestLayer }`
+	for i := 1; i < 20; i++ {
+		query += fmt.Sprintf(` fragment f%d on Query { ...f%d ...f%d }`, i, i-1, i-1)
+	}
+	query = strings.Replace(query, `{ ...f0 }`, `{ ...f19 }`, 1)
+	data, errs = f.query(t, query, nil)
+	require.Nil(t, data)
+	require.Len(t, errs, 1)
+	require.Equal(t, "the request is more complex than 50000 fields", errs[0].(map[string]interface{})["message"])
+}
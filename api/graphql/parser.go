@@ -0,0 +1,637 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// the parser supports the executable part of the GraphQL query language: queries with variables, aliases, arguments,
+// fragments and the @include and @skip directives. Type conditions of fragments are accepted but not checked, there
+// are no interfaces or unions in the schema.
+
+// maxNesting limits the nesting of selection sets, values and types in a request, the parser is recursive and deeply
+// nested requests would exhaust its stack. Executed requests are limited further by maxDepth.
+const maxNesting = 50
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunctuator
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+type lexer struct {
+	src string
+	pos int
+}
+
+func (l *lexer) errorf(pos int, format string, args ...interface{}) error {
+	return fmt.Errorf("syntax error at %d: %v", pos, fmt.Sprintf(format, args...))
+}
+
+func (l *lexer) skipIgnored() {
+	for l.pos < len(l.src) {
+		switch c := l.src[l.pos]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			l.pos++
+		case c == '#':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' && l.src[l.pos] != '\r' {
+				l.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (l *lexer) next() (token, error) {
+	l.skipIgnored()
+	start := l.pos
+	if l.pos >= len(l.src) {
+		return token{kind: tokenEOF, pos: start}, nil
+	}
+	c := l.src[l.pos]
+	switch {
+	case strings.IndexByte("!$():=@[]{}|", c) >= 0:
+		l.pos++
+		return token{kind: tokenPunctuator, value: string(c), pos: start}, nil
+	case c == '.':
+		if !strings.HasPrefix(l.src[l.pos:], "...") {
+			return token{}, l.errorf(start, "unexpected '.'")
+		}
+		l.pos += 3
+		return token{kind: tokenPunctuator, value: "...", pos: start}, nil
+	case c == '_' || isLetter(c):
+		for l.pos < len(l.src) && (l.src[l.pos] == '_' || isLetter(l.src[l.pos]) || isDigit(l.src[l.pos])) {
+			l.pos++
+		}
+		return token{kind: tokenName, value: l.src[start:l.pos], pos: start}, nil
+	case c == '-' || isDigit(c):
+		return l.number()
+	case c == '"':
+		return l.string()
+	}
+	return token{}, l.errorf(start, "unexpected character %q", c)
+}
+
+func (l *lexer) number() (token, error) {
+	start := l.pos
+	kind := tokenInt
+	if l.src[l.pos] == '-' {
+		l.pos++
+	}
+	digits := func() int {
+		n := 0
+		for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+			l.pos++
+			n++
+		}
+		return n
+	}
+	if digits() == 0 {
+		return token{}, l.errorf(start, "invalid number")
+	}
+	if l.pos < len(l.src) && l.src[l.pos] == '.' {
+		kind = tokenFloat
+		l.pos++
+		if digits() == 0 {
+			return token{}, l.errorf(start, "invalid number")
+		}
+	}
+	if l.pos < len(l.src) && (l.src[l.pos] == 'e' || l.src[l.pos] == 'E') {
+		kind = tokenFloat
+		l.pos++
+		if l.pos < len(l.src) && (l.src[l.pos] == '+' || l.src[l.pos] == '-') {
+			l.pos++
+		}
+		if digits() == 0 {
+			return token{}, l.errorf(start, "invalid number")
+		}
+	}
+	return token{kind: kind, value: l.src[start:l.pos], pos: start}, nil
+}
+
+func (l *lexer) string() (token, error) {
+	start := l.pos
+	l.pos++
+	var sb strings.Builder
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '"':
+			l.pos++
+			return token{kind: tokenString, value: sb.String(), pos: start}, nil
+		case c == '\n' || c == '\r':
+			return token{}, l.errorf(start, "unterminated string")
+		case c == '\\':
+			if l.pos+1 >= len(l.src) {
+				return token{}, l.errorf(start, "unterminated string")
+			}
+			l.pos++
+			switch esc := l.src[l.pos]; esc {
+			case '"', '\\', '/':
+				sb.WriteByte(esc)
+			case 'b':
+				sb.WriteByte('\b')
+			case 'f':
+				sb.WriteByte('\f')
+			case 'n':
+				sb.WriteByte('\n')
+			case 'r':
+				sb.WriteByte('\r')
+			case 't':
+				sb.WriteByte('\t')
+			case 'u':
+				if l.pos+5 > len(l.src) {
+					return token{}, l.errorf(l.pos, "invalid unicode escape")
+				}
+				r, err := strconv.ParseUint(l.src[l.pos+1:l.pos+5], 16, 32)
+				if err != nil {
+					return token{}, l.errorf(l.pos, "invalid unicode escape")
+				}
+				sb.WriteRune(rune(r))
+				l.pos += 4
+			default:
+				return token{}, l.errorf(l.pos, "invalid escape sequence \\%c", esc)
+			}
+			l.pos++
+		default:
+			r, size := utf8.DecodeRuneInString(l.src[l.pos:])
+			sb.WriteRune(r)
+			l.pos += size
+		}
+	}
+	return token{}, l.errorf(start, "unterminated string")
+}
+
+func isLetter(c byte) bool { return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' }
+func isDigit(c byte) bool  { return c >= '0' && c <= '9' }
+
+// document is a parsed GraphQL request
+type document struct {
+	operations []*operation
+	fragments  map[string]*fragment
+}
+
+type operation struct {
+	name       string
+	variables  []variableDefinition
+	selections []*selection
+}
+
+type variableDefinition struct {
+	name         string
+	required     bool
+	defaultValue interface{}
+}
+
+type fragment struct {
+	name       string
+	selections []*selection
+}
+
+// selection is a field, or a fragment spread when fragment is set, or an inline fragment when only selections are set
+type selection struct {
+	alias      string
+	name       string
+	arguments  map[string]interface{}
+	directives []directive
+	selections []*selection
+	fragment   string
+	pos        int
+}
+
+type directive struct {
+	name      string
+	arguments map[string]interface{}
+}
+
+// variable is a reference to a variable in an argument value
+type variable string
+
+// enumValue is an enum literal in an argument value
+type enumValue string
+
+type parser struct {
+	lex   *lexer
+	tok   token
+	depth int // the nesting of the current selection set, value or type
+}
+
+func parse(query string) (*document, error) {
+	p := &parser{lex: &lexer{src: query}}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	doc := &document{fragments: make(map[string]*fragment)}
+	for p.tok.kind != tokenEOF {
+		switch {
+		case p.peek(tokenPunctuator, "{"):
+			sels, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, &operation{selections: sels})
+		case p.peek(tokenName, "query"):
+			op, err := p.operation()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, op)
+		case p.peek(tokenName, "mutation"), p.peek(tokenName, "subscription"):
+			return nil, p.lex.errorf(p.tok.pos, "%v operations are not supported", p.tok.value)
+		case p.peek(tokenName, "fragment"):
+			f, err := p.fragment()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := doc.fragments[f.name]; ok {
+				return nil, fmt.Errorf("fragment %v is defined more than once", f.name)
+			}
+			doc.fragments[f.name] = f
+		default:
+			return nil, p.unexpected()
+		}
+	}
+	if len(doc.operations) == 0 {
+		return nil, fmt.Errorf("the request doesn't contain any operation")
+	}
+	return doc, nil
+}
+
+func (p *parser) advance() error {
+	tok, err := p.lex.next()
+	if err != nil {
+		return err
+	}
+	p.tok = tok
+	return nil
+}
+
+func (p *parser) peek(kind tokenKind, value string) bool {
+	return p.tok.kind == kind && p.tok.value == value
+}
+
+func (p *parser) unexpected() error {
+	if p.tok.kind == tokenEOF {
+		return p.lex.errorf(p.tok.pos, "unexpected end of request")
+	}
+	return p.lex.errorf(p.tok.pos, "unexpected %q", p.tok.value)
+}
+
+func (p *parser) expect(kind tokenKind, value string) error {
+	if !p.peek(kind, value) {
+		return p.unexpected()
+	}
+	return p.advance()
+}
+
+// nest enters a nested selection set, value or type, the returned function leaves it
+func (p *parser) nest() (func(), error) {
+	if p.depth >= maxNesting {
+		return nil, p.lex.errorf(p.tok.pos, "the request is nested deeper than %d levels", maxNesting)
+	}
+	p.depth++
+	return func() { p.depth-- }, nil
+}
+
+func (p *parser) name() (string, error) {
+	if p.tok.kind != tokenName {
+		return "", p.unexpected()
+	}
+	name := p.tok.value
+	return name, p.advance()
+}
+
+func (p *parser) operation() (*operation, error) {
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	op := &operation{}
+	if p.tok.kind == tokenName {
+		op.name = p.tok.value
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+	if p.peek(tokenPunctuator, "(") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		for !p.peek(tokenPunctuator, ")") {
+			def, err := p.variableDefinition()
+			if err != nil {
+				return nil, err
+			}
+			op.variables = append(op.variables, def)
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := p.directives(); err != nil {
+		return nil, err
+	}
+	sels, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	op.selections = sels
+	return op, nil
+}
+
+func (p *parser) variableDefinition() (variableDefinition, error) {
+	var def variableDefinition
+	if err := p.expect(tokenPunctuator, "$"); err != nil {
+		return def, err
+	}
+	name, err := p.name()
+	if err != nil {
+		return def, err
+	}
+	def.name = name
+	if err := p.expect(tokenPunctuator, ":"); err != nil {
+		return def, err
+	}
+	// the variable types are only used to tell if a variable is required, the resolvers check the values they get
+	if def.required, err = p.typeReference(); err != nil {
+		return def, err
+	}
+	if p.peek(tokenPunctuator, "=") {
+		if err := p.advance(); err != nil {
+			return def, err
+		}
+		if def.defaultValue, err = p.value(true); err != nil {
+			return def, err
+		}
+	}
+	return def, nil
+}
+
+func (p *parser) typeReference() (required bool, err error) {
+	if p.peek(tokenPunctuator, "[") {
+		leave, err := p.nest()
+		if err != nil {
+			return false, err
+		}
+		defer leave()
+		if err := p.advance(); err != nil {
+			return false, err
+		}
+		if _, err := p.typeReference(); err != nil {
+			return false, err
+		}
+		if err := p.expect(tokenPunctuator, "]"); err != nil {
+			return false, err
+		}
+	} else if _, err := p.name(); err != nil {
+		return false, err
+	}
+	if p.peek(tokenPunctuator, "!") {
+		return true, p.advance()
+	}
+	return false, nil
+}
+
+func (p *parser) fragment() (*fragment, error) {
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	pos := p.tok.pos
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if name == "on" {
+		return nil, p.lex.errorf(pos, "invalid fragment name %q", name)
+	}
+	if err := p.expect(tokenName, "on"); err != nil {
+		return nil, err
+	}
+	if _, err := p.name(); err != nil {
+		return nil, err
+	}
+	if _, err := p.directives(); err != nil {
+		return nil, err
+	}
+	sels, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	return &fragment{name: name, selections: sels}, nil
+}
+
+func (p *parser) selectionSet() ([]*selection, error) {
+	leave, err := p.nest()
+	if err != nil {
+		return nil, err
+	}
+	defer leave()
+	if err := p.expect(tokenPunctuator, "{"); err != nil {
+		return nil, err
+	}
+	var sels []*selection
+	for !p.peek(tokenPunctuator, "}") {
+		sel, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		sels = append(sels, sel)
+	}
+	if len(sels) == 0 {
+		return nil, p.lex.errorf(p.tok.pos, "empty selection set")
+	}
+	return sels, p.advance()
+}
+
+func (p *parser) selection() (*selection, error) {
+	sel := &selection{pos: p.tok.pos}
+	var err error
+	if p.peek(tokenPunctuator, "...") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		if p.tok.kind == tokenName && p.tok.value != "on" {
+			sel.fragment = p.tok.value
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			sel.directives, err = p.directives()
+			return sel, err
+		}
+		if p.peek(tokenName, "on") {
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			if _, err := p.name(); err != nil {
+				return nil, err
+			}
+		}
+		if sel.directives, err = p.directives(); err != nil {
+			return nil, err
+		}
+		sel.selections, err = p.selectionSet()
+		return sel, err
+	}
+
+	if sel.name, err = p.name(); err != nil {
+		return nil, err
+	}
+	sel.alias = sel.name
+	if p.peek(tokenPunctuator, ":") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		if sel.name, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+	if sel.arguments, err = p.arguments(); err != nil {
+		return nil, err
+	}
+	if sel.directives, err = p.directives(); err != nil {
+		return nil, err
+	}
+	if p.peek(tokenPunctuator, "{") {
+		if sel.selections, err = p.selectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return sel, nil
+}
+
+func (p *parser) arguments() (map[string]interface{}, error) {
+	args := make(map[string]interface{})
+	if !p.peek(tokenPunctuator, "(") {
+		return args, nil
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	for !p.peek(tokenPunctuator, ")") {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokenPunctuator, ":"); err != nil {
+			return nil, err
+		}
+		if args[name], err = p.value(false); err != nil {
+			return nil, err
+		}
+	}
+	return args, p.advance()
+}
+
+func (p *parser) directives() ([]directive, error) {
+	var dirs []directive
+	for p.peek(tokenPunctuator, "@") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		args, err := p.arguments()
+		if err != nil {
+			return nil, err
+		}
+		dirs = append(dirs, directive{name: name, arguments: args})
+	}
+	return dirs, nil
+}
+
+// value parses an argument value, constant values can't reference variables
+func (p *parser) value(constant bool) (interface{}, error) {
+	tok := p.tok
+	switch {
+	case p.peek(tokenPunctuator, "$") && !constant:
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		return variable(name), err
+	case p.peek(tokenPunctuator, "["):
+		leave, err := p.nest()
+		if err != nil {
+			return nil, err
+		}
+		defer leave()
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		list := []interface{}{}
+		for !p.peek(tokenPunctuator, "]") {
+			v, err := p.value(constant)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, p.advance()
+	case p.peek(tokenPunctuator, "{"):
+		leave, err := p.nest()
+		if err != nil {
+			return nil, err
+		}
+		defer leave()
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		obj := make(map[string]interface{})
+		for !p.peek(tokenPunctuator, "}") {
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(tokenPunctuator, ":"); err != nil {
+				return nil, err
+			}
+			if obj[name], err = p.value(constant); err != nil {
+				return nil, err
+			}
+		}
+		return obj, p.advance()
+	case tok.kind == tokenInt:
+		v, err := strconv.ParseInt(tok.value, 10, 64)
+		if err != nil {
+			return nil, p.lex.errorf(tok.pos, "invalid integer %v", tok.value)
+		}
+		return v, p.advance()
+	case tok.kind == tokenFloat:
+		v, err := strconv.ParseFloat(tok.value, 64)
+		if err != nil {
+			return nil, p.lex.errorf(tok.pos, "invalid float %v", tok.value)
+		}
+		return v, p.advance()
+	case tok.kind == tokenString:
+		return tok.value, p.advance()
+	case tok.kind == tokenName:
+		var v interface{}
+		switch tok.value {
+		case "true":
+			v = true
+		case "false":
+			v = false
+		case "null":
+			v = nil
+		default:
+			v = enumValue(tok.value)
+		}
+		return v, p.advance()
+	}
+	return nil, p.unexpected()
+}
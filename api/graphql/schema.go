@@ -0,0 +1,516 @@
+package graphql
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spacemeshos/go-spacemesh/api"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/common/util"
+	"github.com/spacemeshos/go-spacemesh/database"
+	"github.com/spacemeshos/go-spacemesh/events"
+	"github.com/spacemeshos/go-spacemesh/mesh"
+	"github.com/spacemeshos/go-spacemesh/state"
+)
+
+const (
+	// maxLayers limits the number of layers returned by a single layers field
+	maxLayers = 100
+	// defaultTransactions is the number of transactions of an account returned when first isn't set
+	defaultTransactions = 100
+	// maxTransactions limits the number of transactions of an account returned by a single transactions field
+	maxTransactions = 1000
+	// estimatedItems is the number of items the complexity of a request counts for lists that their arguments don't
+	// limit, like the blocks of a layer
+	estimatedItems = 50
+)
+
+// the objects of the schema and the sources their fields are resolved from:
+//
+//	Query       the root of all requests
+//	Account     types.Address
+//	Transaction *types.Transaction
+//	Receipt     *state.Receipt
+//	Reward      types.Reward
+//	Layer       *types.Layer
+//	Block       *types.Block
+//	Smesher     types.NodeID
+//	Activation  *types.ActivationTx
+//
+// Ids, hashes and addresses are 0x prefixed hex strings, except for smesher ids which are the hex of their public key
+// as used across the node. Amounts and other 64 bit values are returned as plain JSON numbers.
+type schema struct {
+	mesh     api.TxAPI
+	state    api.StateAPI
+	receipts api.ReceiptAPI
+	atxs     api.NodeAtxAPI
+}
+
+// query builds the object types of the schema and returns the root query type
+func (s *schema) query() *object {
+	var (
+		query       = &object{name: "Query"}
+		account     = &object{name: "Account"}
+		transaction = &object{name: "Transaction"}
+		receipt     = &object{name: "Receipt"}
+		reward      = &object{name: "Reward"}
+		layer       = &object{name: "Layer"}
+		block       = &object{name: "Block"}
+		smesher     = &object{name: "Smesher"}
+		activation  = &object{name: "Activation"}
+	)
+
+	query.fields = map[string]*field{
+		"latestLayer": {resolve: func(interface{}, arguments) (interface{}, error) {
+			return s.mesh.LatestLayer().Uint64(), nil
+		}},
+		"account":     {typ: account, args: []string{"address"}, resolve: s.account},
+		"layer":       {typ: layer, args: []string{"number"}, resolve: s.layer},
+		"layers":      {typ: layer, args: []string{"from", "to"}, items: layerItems, resolve: s.layers},
+		"block":       {typ: block, args: []string{"id"}, resolve: s.block},
+		"transaction": {typ: transaction, args: []string{"id"}, resolve: s.transaction},
+		"smesher":     {typ: smesher, args: []string{"id"}, resolve: s.smesher},
+	}
+
+	account.fields = map[string]*field{
+		"address": {resolve: func(src interface{}, _ arguments) (interface{}, error) {
+			return src.(types.Address).String(), nil
+		}},
+		"balance": {resolve: func(src interface{}, _ arguments) (interface{}, error) {
+			return s.state.GetBalance(src.(types.Address)), nil
+		}},
+		"nonce": {resolve: func(src interface{}, _ arguments) (interface{}, error) {
+			return s.state.GetNonce(src.(types.Address)), nil
+		}},
+		"transactions": {typ: transaction, args: []string{"epoch", "minLayer", "maxLayer", "direction", "first"},
+			items: transactionItems, resolve: s.accountTransactions},
+		"rewards": {typ: reward, args: []string{"epoch", "minLayer", "maxLayer"}, items: estimated,
+			resolve: s.accountRewards},
+	}
+
+	transaction.fields = map[string]*field{
+		"id": {resolve: func(src interface{}, _ arguments) (interface{}, error) {
+			return util.Encode(src.(*types.Transaction).ID().Bytes()), nil
+		}},
+		"sender": {typ: account, resolve: func(src interface{}, _ arguments) (interface{}, error) {
+			return src.(*types.Transaction).Origin(), nil
+		}},
+		"recipient": {typ: account, resolve: func(src interface{}, _ arguments) (interface{}, error) {
+			return src.(*types.Transaction).Recipient, nil
+		}},
+		"amount": {resolve: func(src interface{}, _ arguments) (interface{}, error) {
+			return src.(*types.Transaction).Amount, nil
+		}},
+		"fee": {resolve: func(src interface{}, _ arguments) (interface{}, error) {
+			return src.(*types.Transaction).Fee, nil
+		}},
+		"gasLimit": {resolve: func(src interface{}, _ arguments) (interface{}, error) {
+			return src.(*types.Transaction).GasLimit, nil
+		}},
+		"counter": {resolve: func(src interface{}, _ arguments) (interface{}, error) {
+			return src.(*types.Transaction).AccountNonce, nil
+		}},
+		"layer": {typ: layer, resolve: func(src interface{}, _ arguments) (interface{}, error) {
+			applied := s.mesh.GetLayerApplied(src.(*types.Transaction).ID())
+			if applied == nil {
+				return nil, nil
+			}
+			return s.getLayer(*applied)
+		}},
+		"receipt": {typ: receipt, resolve: func(src interface{}, _ arguments) (interface{}, error) {
+			r, err := s.receipts.Get(src.(*types.Transaction).ID())
+			if err == database.ErrNotFound {
+				return nil, nil
+			}
+			return r, err
+		}},
+	}
+
+	receipt.fields = map[string]*field{
+		"result": {resolve: func(src interface{}, _ arguments) (interface{}, error) {
+			return receiptResult(src.(*state.Receipt).Result), nil
+		}},
+		"layer": {resolve: func(src interface{}, _ arguments) (interface{}, error) {
+			return src.(*state.Receipt).Layer.Uint64(), nil
+		}},
+		"gasUsed": {resolve: func(src interface{}, _ arguments) (interface{}, error) {
+			return src.(*state.Receipt).GasUsed, nil
+		}},
+		"fee": {resolve: func(src interface{}, _ arguments) (interface{}, error) {
+			return src.(*state.Receipt).Fee, nil
+		}},
+		"error": {resolve: func(src interface{}, _ arguments) (interface{}, error) {
+			if e := src.(*state.Receipt).Error; e != "" {
+				return e, nil
+			}
+			return nil, nil
+		}},
+	}
+
+	reward.fields = map[string]*field{
+		"layer": {resolve: func(src interface{}, _ arguments) (interface{}, error) {
+			return src.(types.Reward).Layer.Uint64(), nil
+		}},
+		"total": {resolve: func(src interface{}, _ arguments) (interface{}, error) {
+			return src.(types.Reward).TotalReward, nil
+		}},
+		"layerReward": {resolve: func(src interface{}, _ arguments) (interface{}, error) {
+			return src.(types.Reward).LayerRewardEstimate, nil
+		}},
+	}
+
+	layer.fields = map[string]*field{
+		"number": {resolve: func(src interface{}, _ arguments) (interface{}, error) {
+			return src.(*types.Layer).Index().Uint64(), nil
+		}},
+		"epoch": {resolve: func(src interface{}, _ arguments) (interface{}, error) {
+			return uint64(src.(*types.Layer).Index().GetEpoch()), nil
+		}},
+		"blocks": {typ: block, items: estimated, resolve: func(src interface{}, _ arguments) (interface{}, error) {
+			return src.(*types.Layer).Blocks(), nil
+		}},
+	}
+
+	block.fields = map[string]*field{
+		"id": {resolve: func(src interface{}, _ arguments) (interface{}, error) {
+			return util.Encode(src.(*types.Block).ID().Bytes()), nil
+		}},
+		"layer": {typ: layer, resolve: func(src interface{}, _ arguments) (interface{}, error) {
+			return s.getLayer(src.(*types.Block).Layer())
+		}},
+		"timestamp": {resolve: func(src interface{}, _ arguments) (interface{}, error) {
+			return src.(*types.Block).Timestamp, nil
+		}},
+		"smesher": {typ: smesher, resolve: func(src interface{}, _ arguments) (interface{}, error) {
+			miner := src.(*types.Block).MinerID()
+			if miner == nil {
+				return nil, nil
+			}
+			return types.NodeID{Key: miner.String()}, nil
+		}},
+		"activation": {typ: activation, resolve: func(src interface{}, _ arguments) (interface{}, error) {
+			return s.atxs.GetFullAtx(src.(*types.Block).ATXID)
+		}},
+		"transactions": {typ: transaction, items: estimated, resolve: func(src interface{}, _ arguments) (interface{}, error) {
+			return s.getTransactions(src.(*types.Block).TxIDs), nil
+		}},
+	}
+
+	smesher.fields = map[string]*field{
+		"id": {resolve: func(src interface{}, _ arguments) (interface{}, error) {
+			return src.(types.NodeID).Key, nil
+		}},
+		// the atx lookups by smesher fail when the smesher didn't publish the requested atx, the errors don't tell
+		// missing atxs from database failures so both are returned as null
+		"latestActivation": {typ: activation, resolve: func(src interface{}, _ arguments) (interface{}, error) {
+			id, err := s.atxs.GetNodeLastAtxID(src.(types.NodeID))
+			if err != nil {
+				return nil, nil
+			}
+			return s.atxs.GetFullAtx(id)
+		}},
+		"activation": {typ: activation, args: []string{"epoch"}, resolve: func(src interface{}, args arguments) (interface{}, error) {
+			epoch, err := args.requiredUint64("epoch")
+			if err != nil {
+				return nil, err
+			}
+			id, err := s.atxs.GetNodeAtxIDForEpoch(src.(types.NodeID), types.EpochID(epoch))
+			if err != nil {
+				return nil, nil
+			}
+			return s.atxs.GetFullAtx(id)
+		}},
+	}
+
+	activation.fields = map[string]*field{
+		"id": {resolve: func(src interface{}, _ arguments) (interface{}, error) {
+			return util.Encode(src.(*types.ActivationTx).ID().Bytes()), nil
+		}},
+		"layer": {resolve: func(src interface{}, _ arguments) (interface{}, error) {
+			return src.(*types.ActivationTx).PubLayerID.Uint64(), nil
+		}},
+		"targetEpoch": {resolve: func(src interface{}, _ arguments) (interface{}, error) {
+			return uint64(src.(*types.ActivationTx).TargetEpoch()), nil
+		}},
+		"smesher": {typ: smesher, resolve: func(src interface{}, _ arguments) (interface{}, error) {
+			return types.NodeID{Key: src.(*types.ActivationTx).NodeID.Key}, nil
+		}},
+		"coinbase": {typ: account, resolve: func(src interface{}, _ arguments) (interface{}, error) {
+			return src.(*types.ActivationTx).Coinbase, nil
+		}},
+		"space": {resolve: func(src interface{}, _ arguments) (interface{}, error) {
+			if nipst := src.(*types.ActivationTx).Nipst; nipst != nil {
+				return nipst.Space, nil
+			}
+			return uint64(0), nil
+		}},
+	}
+
+	return query
+}
+
+func (s *schema) account(_ interface{}, args arguments) (interface{}, error) {
+	address, err := args.requiredString("address")
+	if err != nil {
+		return nil, err
+	}
+	b, err := util.Decode(address)
+	if err != nil || len(b) != types.AddressLength {
+		return nil, fmt.Errorf("invalid address %v", address)
+	}
+	return types.BytesToAddress(b), nil
+}
+
+func (s *schema) getLayer(number types.LayerID) (interface{}, error) {
+	if number > s.mesh.LatestLayer() {
+		return nil, nil
+	}
+	l, err := s.mesh.GetLayer(number)
+	if err == database.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read layer %v: %v", number, err)
+	}
+	return l, nil
+}
+
+func (s *schema) layer(_ interface{}, args arguments) (interface{}, error) {
+	number, err := args.requiredUint64("number")
+	if err != nil {
+		return nil, err
+	}
+	return s.getLayer(types.LayerID(number))
+}
+
+// literalUint64 returns the value of an argument given as a non negative integer literal
+func literalUint64(args map[string]interface{}, name string) (uint64, bool) {
+	v, ok := args[name].(int64)
+	return uint64(v), ok && v >= 0
+}
+
+func estimated(map[string]interface{}) int {
+	return estimatedItems
+}
+
+// layerItems is the number of layers in the range of a layers field, or the most it can return if the range is given
+// in variables
+func layerItems(args map[string]interface{}) int {
+	from, fromOk := literalUint64(args, "from")
+	to, toOk := literalUint64(args, "to")
+	if fromOk && toOk && to >= from && to-from < maxLayers {
+		return int(to-from) + 1
+	}
+	return maxLayers
+}
+
+// transactionItems is the number of transactions the first argument of a transactions field requests, or the most it
+// can return if it's given in a variable
+func transactionItems(args map[string]interface{}) int {
+	if _, ok := args["first"]; !ok {
+		return defaultTransactions
+	}
+	if first, ok := literalUint64(args, "first"); ok && first < maxTransactions {
+		return int(first)
+	}
+	return maxTransactions
+}
+
+func (s *schema) layers(_ interface{}, args arguments) (interface{}, error) {
+	from, err := args.requiredUint64("from")
+	if err != nil {
+		return nil, err
+	}
+	to, err := args.requiredUint64("to")
+	if err != nil {
+		return nil, err
+	}
+	if to < from {
+		return nil, fmt.Errorf("to must not be smaller than from")
+	}
+	if to-from >= maxLayers {
+		return nil, fmt.Errorf("at most %d layers can be requested at once", maxLayers)
+	}
+	if latest := s.mesh.LatestLayer().Uint64(); to > latest {
+		to = latest
+	}
+	var layers []*types.Layer
+	for i := from; i <= to; i++ {
+		l, err := s.getLayer(types.LayerID(i))
+		if err != nil {
+			return nil, err
+		}
+		if l != nil {
+			layers = append(layers, l.(*types.Layer))
+		}
+	}
+	return layers, nil
+}
+
+func (s *schema) block(_ interface{}, args arguments) (interface{}, error) {
+	id, err := args.requiredString("id")
+	if err != nil {
+		return nil, err
+	}
+	// block ids are returned in their 32 bytes form, like in the rest of the api
+	b, err := util.Decode(id)
+	if err != nil || len(b) != types.Hash32Length {
+		return nil, fmt.Errorf("invalid block id %v", id)
+	}
+	blk, err := s.mesh.GetBlock(types.BlockID(types.BytesToHash(b).ToHash20()))
+	if err == database.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read block %v: %v", id, err)
+	}
+	return blk, nil
+}
+
+func (s *schema) transaction(_ interface{}, args arguments) (interface{}, error) {
+	id, err := args.requiredString("id")
+	if err != nil {
+		return nil, err
+	}
+	b, err := util.Decode(id)
+	if err != nil || len(b) != types.Hash32Length {
+		return nil, fmt.Errorf("invalid transaction id %v", id)
+	}
+	// the mesh doesn't tell missing transactions from failures to read them
+	tx, err := s.mesh.GetTransaction(types.TransactionID(types.BytesToHash(b)))
+	if err != nil {
+		return nil, nil
+	}
+	return tx, nil
+}
+
+func (s *schema) smesher(_ interface{}, args arguments) (interface{}, error) {
+	id, err := args.requiredString("id")
+	if err != nil {
+		return nil, err
+	}
+	key := strings.ToLower(strings.TrimPrefix(id, "0x"))
+	if len(util.Hex2Bytes(key)) == 0 {
+		return nil, fmt.Errorf("invalid smesher id %v", id)
+	}
+	return types.NodeID{Key: key}, nil
+}
+
+// getTransactions returns the transactions with the given ids that are in the mesh, in the order of the ids
+func (s *schema) getTransactions(ids []types.TransactionID) []*types.Transaction {
+	txs, _ := s.mesh.GetTransactions(ids)
+	byID := make(map[types.TransactionID]*types.Transaction, len(txs))
+	for _, tx := range txs {
+		byID[tx.ID()] = tx
+	}
+	res := make([]*types.Transaction, 0, len(txs))
+	for _, id := range ids {
+		if tx, ok := byID[id]; ok {
+			res = append(res, tx)
+		}
+	}
+	return res
+}
+
+// layerRange returns the layers selected by the epoch, minLayer and maxLayer arguments, an epoch limits the range to
+// its layers
+func layerRange(args arguments) (min, max types.LayerID, err error) {
+	max = types.LayerID(^uint64(0))
+	if v, ok, err := args.uint64("minLayer"); err != nil {
+		return 0, 0, err
+	} else if ok {
+		min = types.LayerID(v)
+	}
+	if v, ok, err := args.uint64("maxLayer"); err != nil {
+		return 0, 0, err
+	} else if ok {
+		max = types.LayerID(v)
+	}
+	if epoch, ok, err := args.uint64("epoch"); err != nil {
+		return 0, 0, err
+	} else if ok {
+		first := types.EpochID(epoch).FirstLayer()
+		last := types.EpochID(epoch+1).FirstLayer() - 1
+		if first > min {
+			min = first
+		}
+		if last < max {
+			max = last
+		}
+	}
+	return min, max, nil
+}
+
+func (s *schema) accountTransactions(src interface{}, args arguments) (interface{}, error) {
+	min, max, err := layerRange(args)
+	if err != nil {
+		return nil, err
+	}
+	direction := mesh.TxAnyDirection
+	if d, ok, err := args.enum("direction"); err != nil {
+		return nil, err
+	} else if ok {
+		switch d {
+		case "SENT":
+			direction = mesh.TxSent
+		case "RECEIVED":
+			direction = mesh.TxReceived
+		case "ANY":
+		default:
+			return nil, fmt.Errorf("direction must be one of SENT, RECEIVED or ANY")
+		}
+	}
+	first := uint64(defaultTransactions)
+	if v, ok, err := args.uint64("first"); err != nil {
+		return nil, err
+	} else if ok {
+		first = v
+	}
+	if first > maxTransactions {
+		return nil, fmt.Errorf("at most %d transactions can be requested at once", maxTransactions)
+	}
+	if first == 0 || min > max {
+		return []*types.Transaction{}, nil
+	}
+	history, _, err := s.mesh.GetAccountTransactions(src.(types.Address), direction, min, max, nil, int(first))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read account transactions: %v", err)
+	}
+	ids := make([]types.TransactionID, 0, len(history))
+	for _, h := range history {
+		ids = append(ids, h.ID)
+	}
+	return s.getTransactions(ids), nil
+}
+
+func (s *schema) accountRewards(src interface{}, args arguments) (interface{}, error) {
+	min, max, err := layerRange(args)
+	if err != nil {
+		return nil, err
+	}
+	rewards, err := s.mesh.GetRewards(src.(types.Address))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read account rewards: %v", err)
+	}
+	res := make([]types.Reward, 0, len(rewards))
+	for _, r := range rewards {
+		if r.Layer >= min && r.Layer <= max {
+			res = append(res, r)
+		}
+	}
+	return res, nil
+}
+
+func receiptResult(result events.TxResult) string {
+	switch result {
+	case events.TxResultApplied:
+		return "APPLIED"
+	case events.TxResultUnknownOrigin:
+		return "UNKNOWN_ORIGIN"
+	case events.TxResultBadNonce:
+		return "BAD_COUNTER"
+	case events.TxResultInsufficientFunds:
+		return "INSUFFICIENT_FUNDS"
+	}
+	return "UNKNOWN"
+}
@@ -0,0 +1,104 @@
+// Package graphql provides a GraphQL server over the data of the node, it lets clients follow the relations between
+// accounts, transactions, layers, blocks and smeshers in a single request
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/spacemeshos/go-spacemesh/api"
+	"github.com/spacemeshos/go-spacemesh/log"
+	"golang.org/x/net/context"
+)
+
+// maxRequestSize limits the size of the body of a request
+const maxRequestSize = 1 << 20
+
+// Server is an http server that serves GraphQL queries at /graphql
+type Server struct {
+	Port   int
+	query  *object
+	server *http.Server
+}
+
+type request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// NewServer returns a new GraphQL server listening on the given port
+func NewServer(port int, mesh api.TxAPI, state api.StateAPI, receipts api.ReceiptAPI, atxs api.NodeAtxAPI) *Server {
+	s := &schema{mesh: mesh, state: state, receipts: receipts, atxs: atxs}
+	return &Server{Port: port, query: s.query()}
+}
+
+// Start starts serving requests in the background
+func (s *Server) Start() {
+	mux := http.NewServeMux()
+	mux.Handle("/graphql", s)
+	s.server = &http.Server{
+		Addr:    fmt.Sprintf(":%d", s.Port),
+		Handler: mux,
+	}
+	log.Info("starting graphql server on port %d", s.Port)
+	go func() {
+		if err := s.server.ListenAndServe(); err != http.ErrServerClosed {
+			log.Error("error from graphql listener: %v", err)
+		}
+	}()
+}
+
+// Close stops the server
+func (s *Server) Close() error {
+	log.Debug("Stopping graphql server...")
+	if s.server != nil {
+		return s.server.Shutdown(context.TODO())
+	}
+	return nil
+}
+
+// ServeHTTP serves GraphQL requests sent as a JSON body of a POST request, or as the query string of a GET request
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req request
+	switch r.Method {
+	case http.MethodGet:
+		q := r.URL.Query()
+		req.Query = q.Get("query")
+		req.OperationName = q.Get("operationName")
+		if vars := q.Get("variables"); vars != "" {
+			if err := decode(strings.NewReader(vars), &req.Variables); err != nil {
+				http.Error(w, "invalid variables", http.StatusBadRequest)
+				return
+			}
+		}
+	case http.MethodPost:
+		if err := decode(http.MaxBytesReader(w, r.Body, maxRequestSize), &req); err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if req.Query == "" {
+		http.Error(w, "query must be provided", http.StatusBadRequest)
+		return
+	}
+
+	res := execute(s.query, req.Query, req.OperationName, req.Variables)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		log.Error("error writing graphql response: %v", err)
+	}
+}
+
+func decode(r io.Reader, v interface{}) error {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	return dec.Decode(v)
+}
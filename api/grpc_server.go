@@ -333,7 +333,7 @@ func (s SpacemeshGrpcService) GetNodeStatus(context.Context, *empty.Empty) (*pb.
 	return &pb.NodeStatus{
 		Peers:         s.PeerCounter.PeerCount(),
 		MinPeers:      uint64(s.Config.P2P.SwarmConfig.RandomConnections),
-		MaxPeers:      uint64(s.Config.P2P.MaxInboundPeers + s.Config.P2P.SwarmConfig.RandomConnections),
+		MaxPeers:      uint64(s.Config.P2P.MaxInboundPeers + s.Config.P2P.MaxOutboundPeers),
 		Synced:        s.Syncer.IsSynced(),
 		SyncedLayer:   s.Tx.LatestLayer().Uint64(),
 		CurrentLayer:  s.GenTime.GetCurrentLayer().Uint64(),
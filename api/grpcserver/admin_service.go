@@ -0,0 +1,651 @@
+package grpcserver
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/api"
+	"github.com/spacemeshos/go-spacemesh/api/extpb"
+	"github.com/spacemeshos/go-spacemesh/checkpoint"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/common/util"
+	"github.com/spacemeshos/go-spacemesh/database"
+	"github.com/spacemeshos/go-spacemesh/events"
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/p2p/p2pcrypto"
+	"github.com/spacemeshos/go-spacemesh/sync"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// checkpointChunkSize is the maximal size of the checkpoint data sent in a single Checkpoint response
+const checkpointChunkSize = 1 << 20
+
+// AdminService is a grpc server providing the AdminService, which contains endpoints for node operators
+type AdminService struct {
+	Tx            api.TxAPI          // Mesh
+	State         api.GlobalStateAPI // Global state
+	Atxs          api.ActivationAPI
+	Bans          api.PeerBanAPI       // nil if the network doesn't support banning peers
+	Limits        api.SyncLimitsAPI    // nil if the bandwidth of sync can't be limited
+	Databases     api.DatabaseAPI      // nil if the databases can't be maintained while the node runs
+	Checker       api.DatabaseCheckAPI // nil if the databases can't be checked while the node runs
+	CheckpointDir string               // checkpoints are only written to files if it's set
+	BackupDir     string               // backups are only written to directories if it's set
+	RecoveryFile  string               // where checkpoints are staged until the node restarts and restores them
+}
+
+// RegisterService registers this service with a grpc server instance
+func (s AdminService) RegisterService(server *Server) {
+	extpb.RegisterAdminServiceServer(server.GrpcServer, s)
+}
+
+// NewAdminService creates a new grpc service using config data.
+func NewAdminService(tx api.TxAPI, state api.GlobalStateAPI, atxs api.ActivationAPI, bans api.PeerBanAPI, limits api.SyncLimitsAPI, dbs api.DatabaseAPI, checker api.DatabaseCheckAPI, checkpointDir, backupDir, recoveryFile string) *AdminService {
+	return &AdminService{
+		Tx:            tx,
+		State:         state,
+		Atxs:          atxs,
+		Bans:          bans,
+		Limits:        limits,
+		Databases:     dbs,
+		Checker:       checker,
+		CheckpointDir: checkpointDir,
+		BackupDir:     backupDir,
+		RecoveryFile:  recoveryFile,
+	}
+}
+
+// EventsStream streams the high level events of the node lifecycle until the client disconnects
+func (s AdminService) EventsStream(in *extpb.EventsStreamRequest, stream extpb.AdminService_EventsStreamServer) error {
+	log.Info("GRPC AdminService.EventsStream")
+
+	sub := events.Subscribe(events.EventNode)
+	defer sub.Close()
+	for {
+		select {
+		case <-stream.Context().Done():
+			log.Info("EventsStream closing stream, client disconnected")
+			return nil
+		case ev := <-sub.Events():
+			event := ev.(events.NodeEvent)
+			res := &extpb.EventsStreamResponse{
+				Kind:      convertNodeEventKind(event.Kind),
+				Layer:     event.Layer.Uint64(),
+				Details:   event.Details,
+				Timestamp: uint64(time.Now().Unix()),
+			}
+			if err := stream.Send(res); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Checkpoint creates a checkpoint at the latest layer applied to the global state. The first response describes the
+// checkpoint, it is followed by the checkpoint data in chunks unless the checkpoint is written to a file.
+func (s AdminService) Checkpoint(in *extpb.CheckpointRequest, stream extpb.AdminService_CheckpointServer) error {
+	log.Info("GRPC AdminService.Checkpoint")
+
+	if in.WriteToFile && s.CheckpointDir == "" {
+		return status.Errorf(codes.FailedPrecondition, "the node has no checkpoint directory")
+	}
+	layer := s.Tx.LatestLayerInState()
+	cp, err := checkpoint.Generate(layer, s.State, s.Atxs)
+	if err != nil {
+		log.Error("error creating checkpoint at layer %v: %v", layer, err)
+		return status.Errorf(codes.Internal, "error creating checkpoint")
+	}
+	data, err := cp.Encode()
+	if err != nil {
+		log.Error("error encoding checkpoint at layer %v: %v", layer, err)
+		return status.Errorf(codes.Internal, "error encoding checkpoint")
+	}
+	hash := types.CalcHash32(data)
+	res := &extpb.CheckpointResponse{
+		Layer:     layer.Uint64(),
+		StateRoot: cp.StateRoot.Bytes(),
+		Accounts:  uint64(len(cp.Accounts)),
+		Atxs:      uint64(len(cp.Atxs)),
+		Hash:      hash.Bytes(),
+		Size:      uint64(len(data)),
+	}
+
+	if in.WriteToFile {
+		path := filepath.Join(s.CheckpointDir, fmt.Sprintf("checkpoint-%d", layer))
+		if err := writeFile(path, data); err != nil {
+			log.Error("error writing checkpoint to %v: %v", path, err)
+			return status.Errorf(codes.Internal, "error writing checkpoint")
+		}
+		log.Info("checkpoint of layer %v written to %v", layer, path)
+		res.Path = path
+		return stream.Send(res)
+	}
+
+	if err := stream.Send(res); err != nil {
+		return err
+	}
+	for len(data) > 0 {
+		n := checkpointChunkSize
+		if n > len(data) {
+			n = len(data)
+		}
+		if err := stream.Send(&extpb.CheckpointResponse{Data: data[:n]}); err != nil {
+			return err
+		}
+		data = data[n:]
+	}
+	return nil
+}
+
+// Recover loads and verifies a checkpoint and stages it, so that the node restores it when it restarts
+func (s AdminService) Recover(ctx context.Context, in *extpb.RecoverRequest) (*extpb.RecoverResponse, error) {
+	log.Info("GRPC AdminService.Recover")
+
+	if in.Uri == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "`Uri` must be provided")
+	}
+	if len(in.Hash) != 0 && len(in.Hash) != types.Hash32Length {
+		return nil, status.Errorf(codes.InvalidArgument, "`Hash` must be %d bytes", types.Hash32Length)
+	}
+	if s.RecoveryFile == "" {
+		return nil, status.Errorf(codes.FailedPrecondition, "the node can't stage checkpoints")
+	}
+	cp, hash, err := checkpoint.Load(in.Uri, in.Hash)
+	if err != nil {
+		log.Error("error loading checkpoint from %v: %v", in.Uri, err)
+		return nil, status.Errorf(codes.InvalidArgument, "invalid checkpoint: %v", err)
+	}
+	data, err := cp.Encode()
+	if err != nil {
+		log.Error("error encoding checkpoint from %v: %v", in.Uri, err)
+		return nil, status.Errorf(codes.Internal, "error encoding checkpoint")
+	}
+	if err := writeFile(s.RecoveryFile, data); err != nil {
+		log.Error("error staging checkpoint at %v: %v", s.RecoveryFile, err)
+		return nil, status.Errorf(codes.Internal, "error staging checkpoint")
+	}
+	log.Info("checkpoint of layer %v staged for recovery, it will be restored when the node restarts", cp.Layer)
+	return &extpb.RecoverResponse{
+		Layer:     cp.Layer.Uint64(),
+		StateRoot: cp.StateRoot.Bytes(),
+		Hash:      hash.Bytes(),
+	}, nil
+}
+
+// Export streams the transactions, rewards or ATXs of a range of layers applied to the global state. Transactions and
+// rewards are sent a layer at a time and ATXs an epoch at a time, so the export is never held in memory.
+func (s AdminService) Export(in *extpb.ExportRequest, stream extpb.AdminService_ExportServer) error {
+	log.Info("GRPC AdminService.Export")
+
+	latest := s.Tx.LatestLayerInState()
+	start, end := types.LayerID(in.StartLayer), types.LayerID(in.EndLayer)
+	if end == 0 {
+		end = latest
+	}
+	if end > latest {
+		return status.Errorf(codes.InvalidArgument, "`EndLayer` must not be greater than the latest layer in state %v", latest)
+	}
+	if start > end {
+		return status.Errorf(codes.InvalidArgument, "`StartLayer` must not be greater than `EndLayer`")
+	}
+
+	switch in.Dataset {
+	case extpb.ExportDataset_EXPORT_DATASET_TRANSACTIONS, extpb.ExportDataset_EXPORT_DATASET_REWARDS:
+		for layerID := start; layerID <= end; layerID++ {
+			if err := stream.Context().Err(); err != nil {
+				return err
+			}
+			layer, err := s.Tx.GetLayer(layerID)
+			if err == database.ErrNotFound {
+				continue
+			}
+			if err == database.ErrPruned {
+				return status.Errorf(codes.NotFound, "layer %v was pruned", layerID)
+			}
+			if err != nil {
+				log.Error("error reading layer %v: %v", layerID, err)
+				return status.Errorf(codes.Internal, "error reading layer data")
+			}
+			res := &extpb.ExportResponse{}
+			if in.Dataset == extpb.ExportDataset_EXPORT_DATASET_TRANSACTIONS {
+				res.Transactions = s.exportTransactions(layer)
+			} else if res.Rewards, err = s.exportRewards(layer); err != nil {
+				log.Error("error reading rewards of layer %v: %v", layerID, err)
+				return status.Errorf(codes.Internal, "error reading rewards")
+			}
+			if len(res.Transactions)+len(res.Rewards) == 0 {
+				continue
+			}
+			if err := stream.Send(res); err != nil {
+				return err
+			}
+		}
+	case extpb.ExportDataset_EXPORT_DATASET_ATXS:
+		for epoch := start.GetEpoch(); epoch <= end.GetEpoch(); epoch++ {
+			if err := stream.Context().Err(); err != nil {
+				return err
+			}
+			res := &extpb.ExportResponse{}
+			for _, id := range s.Atxs.GetEpochAtxs(epoch) {
+				atx, err := s.Atxs.GetFullAtx(id)
+				if err != nil {
+					log.Error("error reading atx %v: %v", id.ShortString(), err)
+					return status.Errorf(codes.Internal, "error reading atx data")
+				}
+				if atx.PubLayerID < start || atx.PubLayerID > end {
+					continue
+				}
+				res.Atxs = append(res.Atxs, convertExportedAtx(atx))
+			}
+			if len(res.Atxs) == 0 {
+				continue
+			}
+			if err := stream.Send(res); err != nil {
+				return err
+			}
+		}
+	default:
+		return status.Errorf(codes.InvalidArgument, "`Dataset` must be provided")
+	}
+	return nil
+}
+
+// exportTransactions returns the transactions of the blocks of the layer, a transaction included in several blocks is
+// exported once
+func (s AdminService) exportTransactions(layer *types.Layer) []*extpb.ExportedTransaction {
+	var ids []types.TransactionID
+	seen := make(map[types.TransactionID]struct{})
+	for _, b := range layer.Blocks() {
+		for _, id := range b.TxIDs {
+			if _, ok := seen[id]; !ok {
+				seen[id] = struct{}{}
+				ids = append(ids, id)
+			}
+		}
+	}
+	txs, missing := s.Tx.GetTransactions(ids)
+	if len(missing) > 0 {
+		log.Warning("%v transactions of layer %v are missing from the export", len(missing), layer.Index())
+	}
+	res := make([]*extpb.ExportedTransaction, 0, len(txs))
+	for _, tx := range txs {
+		res = append(res, &extpb.ExportedTransaction{
+			Id:        tx.ID().Bytes(),
+			Layer:     layer.Index().Uint64(),
+			Origin:    tx.Origin().Bytes(),
+			Recipient: tx.Recipient.Bytes(),
+			Amount:    tx.Amount,
+			Fee:       tx.Fee,
+			Nonce:     tx.AccountNonce,
+			GasLimit:  tx.GasLimit,
+		})
+	}
+	return res
+}
+
+// exportRewards returns the rewards the coinbases of the blocks of the layer received in the layer
+func (s AdminService) exportRewards(layer *types.Layer) ([]*extpb.ExportedReward, error) {
+	var atxIDs []types.ATXID
+	seenAtxs := make(map[types.ATXID]struct{})
+	for _, b := range layer.Blocks() {
+		if _, ok := seenAtxs[b.ATXID]; !ok {
+			seenAtxs[b.ATXID] = struct{}{}
+			atxIDs = append(atxIDs, b.ATXID)
+		}
+	}
+	atxs, _ := s.Tx.GetATXs(atxIDs)
+	var res []*extpb.ExportedReward
+	seenCoinbases := make(map[types.Address]struct{})
+	for _, id := range atxIDs {
+		atx, ok := atxs[id]
+		if !ok {
+			continue
+		}
+		if _, ok := seenCoinbases[atx.Coinbase]; ok {
+			continue
+		}
+		seenCoinbases[atx.Coinbase] = struct{}{}
+		rewards, err := s.Tx.GetCoinbaseRewards(atx.Coinbase, layer.Index(), layer.Index())
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range rewards {
+			res = append(res, &extpb.ExportedReward{
+				Layer:       r.Layer.Uint64(),
+				Coinbase:    atx.Coinbase.Bytes(),
+				Blocks:      r.Blocks,
+				Total:       r.TotalReward,
+				LayerReward: r.LayerRewardEstimate,
+			})
+		}
+	}
+	return res, nil
+}
+
+// Ban bans a peer ID or an IP address. Connections to the banned peers are closed.
+func (s AdminService) Ban(ctx context.Context, in *extpb.BanRequest) (*extpb.BanResponse, error) {
+	log.Info("GRPC AdminService.Ban")
+
+	if s.Bans == nil {
+		return nil, status.Errorf(codes.Unavailable, "the network doesn't support banning peers")
+	}
+	peer, ip, err := parseBanTarget(in.Target)
+	if err != nil {
+		return nil, err
+	}
+	duration := time.Duration(in.Duration) * time.Second
+	if peer != nil {
+		err = s.Bans.BanPeer(peer, duration)
+	} else {
+		err = s.Bans.BanIP(ip, duration)
+	}
+	if err != nil {
+		log.Error("error banning peer: %v", err)
+		return nil, status.Errorf(codes.Internal, "error persisting banlist")
+	}
+	return &extpb.BanResponse{}, nil
+}
+
+// Unban lifts the ban of a peer ID or an IP address
+func (s AdminService) Unban(ctx context.Context, in *extpb.UnbanRequest) (*extpb.UnbanResponse, error) {
+	log.Info("GRPC AdminService.Unban")
+
+	if s.Bans == nil {
+		return nil, status.Errorf(codes.Unavailable, "the network doesn't support banning peers")
+	}
+	peer, ip, err := parseBanTarget(in.Target)
+	if err != nil {
+		return nil, err
+	}
+	var found bool
+	if peer != nil {
+		found, err = s.Bans.UnbanPeer(peer)
+	} else {
+		found, err = s.Bans.UnbanIP(ip)
+	}
+	if err != nil {
+		log.Error("error unbanning peer: %v", err)
+		return nil, status.Errorf(codes.Internal, "error persisting banlist")
+	}
+	return &extpb.UnbanResponse{Found: found}, nil
+}
+
+// ListBans returns the banned peer IDs and IP addresses, the oldest ban first
+func (s AdminService) ListBans(ctx context.Context, in *extpb.ListBansRequest) (*extpb.ListBansResponse, error) {
+	log.Info("GRPC AdminService.ListBans")
+
+	if s.Bans == nil {
+		return nil, status.Errorf(codes.Unavailable, "the network doesn't support banning peers")
+	}
+	res := &extpb.ListBansResponse{}
+	for _, ban := range s.Bans.Bans() {
+		entry := &extpb.BanEntry{
+			Target:  &extpb.BanTarget{},
+			Created: unixSeconds(ban.Created),
+			Expires: unixSeconds(ban.Expires),
+		}
+		if ban.Peer != nil {
+			entry.Target.PeerId = ban.Peer.Bytes()
+		} else {
+			entry.Target.Ip = ban.IP.String()
+		}
+		res.Bans = append(res.Bans, entry)
+	}
+	return res, nil
+}
+
+// SetSyncLimits changes the bandwidth and request concurrency limits of sync
+func (s AdminService) SetSyncLimits(ctx context.Context, in *extpb.SetSyncLimitsRequest) (*extpb.SetSyncLimitsResponse, error) {
+	log.Info("GRPC AdminService.SetSyncLimits")
+
+	if s.Limits == nil {
+		return nil, status.Errorf(codes.Unavailable, "the syncer doesn't support sync limits")
+	}
+	if in.Limits == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "`Limits` must be provided")
+	}
+	if in.Limits.Bandwidth > math.MaxInt32 {
+		return nil, status.Errorf(codes.InvalidArgument, "`Limits.Bandwidth` must be at most %v", math.MaxInt32)
+	}
+	s.Limits.SetSyncLimits(sync.Limits{Bandwidth: int(in.Limits.Bandwidth), Requests: int(in.Limits.Requests)})
+	return &extpb.SetSyncLimitsResponse{}, nil
+}
+
+// SyncLimits returns the bandwidth and request concurrency limits of sync
+func (s AdminService) SyncLimits(ctx context.Context, in *extpb.SyncLimitsRequest) (*extpb.SyncLimitsResponse, error) {
+	log.Info("GRPC AdminService.SyncLimits")
+
+	if s.Limits == nil {
+		return nil, status.Errorf(codes.Unavailable, "the syncer doesn't support sync limits")
+	}
+	limits := s.Limits.SyncLimits()
+	return &extpb.SyncLimitsResponse{Limits: &extpb.SyncLimits{
+		Bandwidth: uint64(limits.Bandwidth),
+		Requests:  uint32(limits.Requests),
+	}}, nil
+}
+
+// CompactDatabases compacts the databases of the node one after the other, and reports the space each compaction
+// reclaimed
+func (s AdminService) CompactDatabases(ctx context.Context, in *extpb.CompactDatabasesRequest) (*extpb.CompactDatabasesResponse, error) {
+	log.Info("GRPC AdminService.CompactDatabases")
+
+	if s.Databases == nil {
+		return nil, status.Errorf(codes.Unavailable, "the databases can't be compacted")
+	}
+	start := time.Now()
+	results, err := s.Databases.Compact()
+	if err == database.ErrCompactionRunning {
+		return nil, status.Errorf(codes.FailedPrecondition, "%v", err)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "could not compact the databases: %v", err)
+	}
+	res := &extpb.CompactDatabasesResponse{DurationMs: uint64(time.Since(start).Milliseconds())}
+	for _, r := range results {
+		db := &extpb.DatabaseCompaction{
+			Name:       r.Name,
+			SizeBefore: uint64(r.SizeBefore),
+			SizeAfter:  uint64(r.SizeAfter),
+			DurationMs: uint64(r.Duration.Milliseconds()),
+		}
+		if r.Err != nil {
+			db.Error = r.Err.Error()
+		} else {
+			db.Reclaimed = r.Reclaimed()
+			res.Reclaimed += db.Reclaimed
+		}
+		res.Databases = append(res.Databases, db)
+	}
+	return res, nil
+}
+
+// CheckDatabases checks the references between the databases of the node, and fetches the missing blocks, transactions
+// and ATXs from peers if asked to
+func (s AdminService) CheckDatabases(ctx context.Context, in *extpb.CheckDatabasesRequest) (*extpb.CheckDatabasesResponse, error) {
+	log.Info("GRPC AdminService.CheckDatabases")
+
+	if s.Checker == nil {
+		return nil, status.Errorf(codes.Unavailable, "the databases can't be checked")
+	}
+	report := s.Checker.Check(in.Repair)
+	res := &extpb.CheckDatabasesResponse{
+		FromLayer:    report.FromLayer.Uint64(),
+		ToLayer:      report.ToLayer.Uint64(),
+		Blocks:       uint64(report.Blocks),
+		Transactions: uint64(report.Transactions),
+		Atxs:         uint64(report.Atxs),
+		StateLayer:   report.StateLayer.Uint64(),
+		DurationMs:   uint64(report.Duration.Milliseconds()),
+	}
+	for _, issue := range report.Issues {
+		res.Issues = append(res.Issues, &extpb.DatabaseIssue{
+			Kind:     issue.Kind.String(),
+			Layer:    issue.Layer.Uint64(),
+			Id:       issue.ID.Bytes(),
+			Repaired: issue.Repaired,
+			Details:  issue.Details,
+		})
+	}
+	return res, nil
+}
+
+// Backup backs up the databases of the node from a consistent snapshot, and streams the backup as a tarball or writes it
+// to the backup directory
+func (s AdminService) Backup(in *extpb.BackupRequest, stream extpb.AdminService_BackupServer) error {
+	log.Info("GRPC AdminService.Backup")
+
+	if s.Databases == nil {
+		return status.Errorf(codes.Unavailable, "the databases can't be backed up")
+	}
+	if in.WriteToDir && s.BackupDir == "" {
+		return status.Errorf(codes.FailedPrecondition, "the node has no backup directory")
+	}
+	snap, err := s.Databases.Snapshot()
+	if err != nil {
+		log.Error("error taking snapshot of the databases: %v", err)
+		return status.Errorf(codes.Internal, "error taking snapshot of the databases")
+	}
+	defer snap.Release()
+	manifest := snap.Manifest()
+	res := &extpb.BackupResponse{
+		Created: uint64(manifest.Created),
+		Size:    uint64(manifest.Size()),
+	}
+	for _, db := range manifest.Databases {
+		hash, _ := hex.DecodeString(db.Hash)
+		res.Databases = append(res.Databases, &extpb.BackupDatabase{
+			Name: db.Name,
+			Keys: uint64(db.Keys),
+			Size: uint64(db.Size),
+			Hash: hash,
+		})
+	}
+
+	if in.WriteToDir {
+		path := filepath.Join(s.BackupDir, fmt.Sprintf("backup-%d", manifest.Created))
+		if err := snap.WriteDir(path); err != nil {
+			log.Error("error writing backup to %v: %v", path, err)
+			return status.Errorf(codes.Internal, "error writing backup")
+		}
+		log.Info("backup of %d databases written to %v", len(manifest.Databases), path)
+		res.Path = path
+		return stream.Send(res)
+	}
+
+	if err := stream.Send(res); err != nil {
+		return err
+	}
+	w := &backupStreamWriter{stream: stream}
+	if err := snap.WriteTar(w); err != nil {
+		return err
+	}
+	return w.flush()
+}
+
+// backupStreamWriter sends the tarball of a backup in chunks of checkpointChunkSize
+type backupStreamWriter struct {
+	stream extpb.AdminService_BackupServer
+	buf    []byte
+}
+
+func (w *backupStreamWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for len(w.buf) >= checkpointChunkSize {
+		if err := w.stream.Send(&extpb.BackupResponse{Data: w.buf[:checkpointChunkSize]}); err != nil {
+			return 0, err
+		}
+		w.buf = append([]byte(nil), w.buf[checkpointChunkSize:]...)
+	}
+	return len(p), nil
+}
+
+func (w *backupStreamWriter) flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	err := w.stream.Send(&extpb.BackupResponse{Data: w.buf})
+	w.buf = nil
+	return err
+}
+
+// parseBanTarget returns the peer ID or the IP address of the target, exactly one of them is set
+func parseBanTarget(target *extpb.BanTarget) (p2pcrypto.PublicKey, net.IP, error) {
+	if target == nil || (len(target.PeerId) == 0) == (target.Ip == "") {
+		return nil, nil, status.Errorf(codes.InvalidArgument, "exactly one of `Target.PeerId` and `Target.Ip` must be provided")
+	}
+	if target.Ip != "" {
+		ip := net.ParseIP(target.Ip)
+		if ip == nil {
+			return nil, nil, status.Errorf(codes.InvalidArgument, "`Target.Ip` must be an IP address")
+		}
+		return nil, ip, nil
+	}
+	peer, err := p2pcrypto.NewPubkeyFromBytes(target.PeerId)
+	if err != nil {
+		return nil, nil, status.Errorf(codes.InvalidArgument, "`Target.PeerId` must be a public key")
+	}
+	return peer, nil, nil
+}
+
+func convertExportedAtx(atx *types.ActivationTx) *extpb.ExportedAtx {
+	res := &extpb.ExportedAtx{
+		Id:             atx.ID().Bytes(),
+		Layer:          atx.PubLayerID.Uint64(),
+		SmesherId:      util.Hex2Bytes(atx.NodeID.Key),
+		Coinbase:       atx.Coinbase.Bytes(),
+		Sequence:       atx.Sequence,
+		PrevAtx:        atx.PrevATXID.Bytes(),
+		PositioningAtx: atx.PositioningATX.Bytes(),
+	}
+	if atx.Nipst != nil {
+		res.Space = atx.Nipst.Space
+	}
+	return res
+}
+
+// writeFile writes the data to a temporary file and renames it, so that a partially written file never has the
+// given path
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func convertNodeEventKind(kind events.NodeEventKind) extpb.NodeEventKind {
+	switch kind {
+	case events.NodeEventSmeshingStarted:
+		return extpb.NodeEventKind_NODE_EVENT_KIND_SMESHING_STARTED
+	case events.NodeEventSmeshingStopped:
+		return extpb.NodeEventKind_NODE_EVENT_KIND_SMESHING_STOPPED
+	case events.NodeEventAtxPublished:
+		return extpb.NodeEventKind_NODE_EVENT_KIND_ATX_PUBLISHED
+	case events.NodeEventBeaconComputed:
+		return extpb.NodeEventKind_NODE_EVENT_KIND_BEACON_COMPUTED
+	case events.NodeEventSyncStarted:
+		return extpb.NodeEventKind_NODE_EVENT_KIND_SYNC_STARTED
+	case events.NodeEventSyncCompleted:
+		return extpb.NodeEventKind_NODE_EVENT_KIND_SYNC_COMPLETED
+	case events.NodeEventBeaconFallback:
+		return extpb.NodeEventKind_NODE_EVENT_KIND_BEACON_FALLBACK
+	case events.NodeEventTortoiseDiverged:
+		return extpb.NodeEventKind_NODE_EVENT_KIND_TORTOISE_DIVERGED
+	case events.NodeEventReorgRejected:
+		return extpb.NodeEventKind_NODE_EVENT_KIND_REORG_REJECTED
+	default:
+		return extpb.NodeEventKind_NODE_EVENT_KIND_UNSPECIFIED
+	}
+}
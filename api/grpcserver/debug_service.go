@@ -0,0 +1,427 @@
+package grpcserver
+
+import (
+	"net"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/api"
+	"github.com/spacemeshos/go-spacemesh/api/extpb"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/database"
+	"github.com/spacemeshos/go-spacemesh/events"
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/p2p"
+	"github.com/spacemeshos/go-spacemesh/sync"
+	"github.com/spacemeshos/go-spacemesh/tortoise"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// defaultAccountsChunkSize is the number of accounts sent in a single Accounts response unless the client asks for
+// another chunk size
+const defaultAccountsChunkSize = 1000
+
+// DebugService is a grpc server providing the DebugService, which exposes internal node data for debugging and testing
+type DebugService struct {
+	Tx       api.TxAPI             // Mesh
+	State    api.GlobalStateAPI    // Global state
+	Mesh     api.LayerInternalsAPI // Consensus data of the mesh
+	Tortoise api.TortoiseAPI       // Vote counting
+	Network  api.NetworkInfoAPI    // P2P connectivity, nil if the network can't describe itself
+	Fetcher  api.FetchAPI          // On demand fetch of mesh objects, nil if the node can't fetch
+	Beacon   api.BeaconAPI         // Hare beacon, nil if the hare oracle doesn't use one
+	Auditor  api.AuditAPI          // Audits of the mesh data, nil if the fetcher doesn't audit
+}
+
+// RegisterService registers this service with a grpc server instance
+func (s DebugService) RegisterService(server *Server) {
+	extpb.RegisterDebugServiceServer(server.GrpcServer, s)
+}
+
+// NewDebugService creates a new grpc service using config data.
+func NewDebugService(tx api.TxAPI, state api.GlobalStateAPI, msh api.LayerInternalsAPI, trtl api.TortoiseAPI, net api.NetworkInfoAPI, fetcher api.FetchAPI, beacon api.BeaconAPI) *DebugService {
+	auditor, _ := fetcher.(api.AuditAPI)
+	return &DebugService{
+		Tx:       tx,
+		State:    state,
+		Mesh:     msh,
+		Tortoise: trtl,
+		Network:  net,
+		Fetcher:  fetcher,
+		Beacon:   beacon,
+		Auditor:  auditor,
+	}
+}
+
+// Accounts streams all accounts of the current global state in chunks. All chunks are read from the same state root,
+// so nodes with the same root return the same accounts in the same order.
+func (s DebugService) Accounts(in *extpb.AccountsRequest, stream extpb.DebugService_AccountsServer) error {
+	log.Info("GRPC DebugService.Accounts")
+
+	chunkSize := int(in.ChunkSize)
+	if chunkSize == 0 {
+		chunkSize = defaultAccountsChunkSize
+	}
+	layer := s.Tx.LatestLayerInState()
+	root := s.Tx.GetStateRoot()
+
+	chunk := make([]*extpb.Account, 0, chunkSize)
+	send := func() error {
+		res := &extpb.AccountsResponse{RootHash: root.Bytes(), Layer: layer.Uint64(), Accounts: chunk}
+		chunk = make([]*extpb.Account, 0, chunkSize)
+		return stream.Send(res)
+	}
+	sent := false
+	var sendErr error
+	err := s.State.IterateAccounts(root, func(addr types.Address, nonce, balance uint64) error {
+		chunk = append(chunk, &extpb.Account{AccountId: addr.Bytes(), Counter: nonce, Balance: balance})
+		if len(chunk) < chunkSize {
+			return nil
+		}
+		sent = true
+		sendErr = send()
+		return sendErr
+	})
+	if sendErr != nil {
+		return sendErr
+	}
+	if err != nil {
+		log.Error("error reading accounts of global state %v: %v", root.ShortString(), err)
+		return status.Errorf(codes.Internal, "error reading accounts")
+	}
+	// the last chunk is sent even if it's empty so that the client always learns the root
+	if len(chunk) > 0 || !sent {
+		return send()
+	}
+	return nil
+}
+
+// LayerInternals returns the internal voting view of a layer: the votes of its blocks, the hare output, and the
+// opinion of the tortoise on every block.
+func (s DebugService) LayerInternals(ctx context.Context, in *extpb.LayerInternalsRequest) (*extpb.LayerInternalsResponse, error) {
+	log.Info("GRPC DebugService.LayerInternals")
+
+	layerID := types.LayerID(in.Layer)
+	layer, err := s.Mesh.GetLayer(layerID)
+	if err == database.ErrNotFound {
+		return nil, status.Errorf(codes.NotFound, "layer %d not found", in.Layer)
+	}
+	if err == database.ErrPruned {
+		return nil, status.Errorf(codes.NotFound, "layer %d was pruned", in.Layer)
+	}
+	if err != nil {
+		log.Error("error reading layer %v: %v", layerID, err)
+		return nil, status.Errorf(codes.Internal, "error reading layer data")
+	}
+
+	res := &extpb.LayerInternalsResponse{
+		Layer:         in.Layer,
+		VerifiedLayer: s.Tortoise.LatestComplete().Uint64(),
+	}
+	inHareOutput := make(map[types.BlockID]struct{})
+	hareOutput, err := s.Mesh.GetLayerInputVector(layerID)
+	switch err {
+	case nil:
+		res.HareOutputKnown = true
+		for _, id := range hareOutput {
+			inHareOutput[id] = struct{}{}
+			res.HareOutput = append(res.HareOutput, id.Bytes())
+		}
+	case database.ErrNotFound, database.ErrPruned:
+	default:
+		log.Error("error reading hare output of layer %v: %v", layerID, err)
+		return nil, status.Errorf(codes.Internal, "error reading hare output")
+	}
+
+	opinions := s.Tortoise.LayerOpinion(layerID)
+	for _, b := range layer.Blocks() {
+		blk := &extpb.BlockInternals{
+			Id:         b.ID().Bytes(),
+			AtxId:      b.ATXID.Bytes(),
+			BlockVotes: blockIDsToBytes(b.BlockVotes),
+			ViewEdges:  blockIDsToBytes(b.ViewEdges),
+		}
+		_, blk.InHareOutput = inHareOutput[b.ID()]
+		if opinion, ok := opinions[b.ID()]; ok {
+			blk.Opinion = convertOpinion(opinion.Opinion)
+			blk.Support = int64(opinion.Support)
+			blk.Against = int64(opinion.Against)
+		}
+		valid, err := s.Mesh.ContextualValidity(b.ID())
+		switch err {
+		case nil:
+			blk.Validity = extpb.ContextualValidity_CONTEXTUAL_VALIDITY_INVALID
+			if valid {
+				blk.Validity = extpb.ContextualValidity_CONTEXTUAL_VALIDITY_VALID
+			}
+		case database.ErrNotFound:
+		default:
+			log.Error("error reading contextual validity of block %v: %v", b.ID(), err)
+			return nil, status.Errorf(codes.Internal, "error reading contextual validity")
+		}
+		res.Blocks = append(res.Blocks, blk)
+	}
+	return res, nil
+}
+
+func blockIDsToBytes(ids []types.BlockID) [][]byte {
+	res := make([][]byte, 0, len(ids))
+	for _, id := range ids {
+		res = append(res, id.Bytes())
+	}
+	return res
+}
+
+func convertOpinion(opinion tortoise.Opinion) extpb.TortoiseOpinion {
+	switch opinion {
+	case tortoise.Support:
+		return extpb.TortoiseOpinion_TORTOISE_OPINION_SUPPORT
+	case tortoise.Against:
+		return extpb.TortoiseOpinion_TORTOISE_OPINION_AGAINST
+	default:
+		return extpb.TortoiseOpinion_TORTOISE_OPINION_ABSTAIN
+	}
+}
+
+// NetworkInfo returns the addresses, peers and routing table of the node, for diagnosing connectivity issues
+func (s DebugService) NetworkInfo(ctx context.Context, in *extpb.NetworkInfoRequest) (*extpb.NetworkInfoResponse, error) {
+	log.Info("GRPC DebugService.NetworkInfo")
+
+	if s.Network == nil {
+		return nil, status.Errorf(codes.Unavailable, "network info isn't available")
+	}
+	info := s.Network.NetworkInfo()
+	res := &extpb.NetworkInfoResponse{
+		Id:              info.ID.Bytes(),
+		TcpAddress:      info.TCPAddress,
+		UdpAddress:      info.UDPAddress,
+		Nat:             convertNATStatus(info.NAT),
+		MappedAddress:   info.MappedAddress,
+		GossipProtocols: info.GossipProtocols,
+		GossipCache: &extpb.GossipCacheInfo{
+			Size:      uint64(info.GossipCache.Size),
+			Capacity:  uint64(info.GossipCache.Capacity),
+			Ttl:       uint64(info.GossipCache.TTL / time.Second),
+			Hits:      info.GossipCache.Hits,
+			Misses:    info.GossipCache.Misses,
+			Evictions: info.GossipCache.Evictions,
+		},
+	}
+	for _, ext := range info.ExternalAddresses {
+		res.ExternalAddresses = append(res.ExternalAddresses, &extpb.ExternalAddress{
+			Address:  ext.Address,
+			Reports:  uint64(ext.Reports),
+			LastSeen: unixSeconds(ext.LastSeen),
+		})
+	}
+	for _, peer := range info.Peers {
+		res.Peers = append(res.Peers, convertPeer(peer))
+	}
+	for _, addr := range info.RoutingTable {
+		res.RoutingTable = append(res.RoutingTable, &extpb.RoutingTableEntry{
+			Id:            addr.Info.ID.Bytes(),
+			Address:       net.JoinHostPort(addr.Info.IP.String(), strconv.Itoa(int(addr.Info.ProtocolPort))),
+			DiscoveryPort: uint32(addr.Info.DiscoveryPort),
+			Tried:         addr.Tried,
+			Attempts:      uint64(addr.Attempts),
+			LastSeen:      unixSeconds(addr.LastSeen),
+			LastAttempt:   unixSeconds(addr.LastAttempt),
+			LastSuccess:   unixSeconds(addr.LastSuccess),
+			Score:         addr.Score,
+		})
+	}
+	return res, nil
+}
+
+// Peers returns the connected gossip peers with the details of their connections, the longest connected first
+func (s DebugService) Peers(ctx context.Context, in *extpb.PeersRequest) (*extpb.PeersResponse, error) {
+	log.Info("GRPC DebugService.Peers")
+
+	if s.Network == nil {
+		return nil, status.Errorf(codes.Unavailable, "network info isn't available")
+	}
+	peers := s.Network.NetworkInfo().Peers
+	// peers without an open connection have a zero connection time and are listed last
+	sort.SliceStable(peers, func(i, j int) bool {
+		if peers[i].ConnectedSince.IsZero() || peers[j].ConnectedSince.IsZero() {
+			return !peers[i].ConnectedSince.IsZero()
+		}
+		return peers[i].ConnectedSince.Before(peers[j].ConnectedSince)
+	})
+	res := &extpb.PeersResponse{}
+	for _, peer := range peers {
+		res.Peers = append(res.Peers, convertPeer(peer))
+	}
+	return res, nil
+}
+
+// PeerEvents streams gossip peers connecting and disconnecting as it happens
+func (s DebugService) PeerEvents(in *extpb.PeerEventsRequest, stream extpb.DebugService_PeerEventsServer) error {
+	log.Info("GRPC DebugService.PeerEvents")
+
+	peers := events.Subscribe(events.EventPeer)
+	defer peers.Close()
+	for {
+		select {
+		case <-stream.Context().Done():
+			log.Info("PeerEvents closing stream, client disconnected")
+			return nil
+		case ev := <-peers.Events():
+			if err := stream.Send(convertPeerUpdate(ev.(events.PeerUpdate))); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Fetch requests a block, transaction or ATX from all peers by its ID and reports which peers served it. The object
+// is validated and stored like the objects sync fetches.
+func (s DebugService) Fetch(ctx context.Context, in *extpb.FetchRequest) (*extpb.FetchResponse, error) {
+	log.Info("GRPC DebugService.Fetch")
+
+	if s.Fetcher == nil {
+		return nil, status.Errorf(codes.Unavailable, "fetching isn't available")
+	}
+	var kind sync.ObjectKind
+	switch in.Kind {
+	case extpb.MeshObjectKind_MESH_OBJECT_KIND_BLOCK:
+		kind = sync.BlockObject
+	case extpb.MeshObjectKind_MESH_OBJECT_KIND_TRANSACTION:
+		kind = sync.TransactionObject
+	case extpb.MeshObjectKind_MESH_OBJECT_KIND_ATX:
+		kind = sync.AtxObject
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "`Kind` must be a block, a transaction or an ATX")
+	}
+	if len(in.Id) != types.Hash32Length {
+		return nil, status.Errorf(codes.InvalidArgument, "`Id` must be %d bytes", types.Hash32Length)
+	}
+	id := types.BytesToHash(in.Id)
+	if kind == sync.BlockObject {
+		id = types.BlockID(id.ToHash20()).AsHash32()
+	}
+
+	res, err := s.Fetcher.Fetch(kind, id)
+	if err != nil {
+		log.Error("error fetching %v %v: %v", kind, id.ShortString(), err)
+		return nil, status.Errorf(codes.Unavailable, "error fetching %v: %v", kind, err)
+	}
+	out := &extpb.FetchResponse{
+		Local:  res.Local,
+		Stored: res.Stored,
+		Layer:  res.Layer.Uint64(),
+	}
+	for _, peer := range res.Peers {
+		out.Peers = append(out.Peers, peer.Bytes())
+	}
+	if res.StoreErr != nil {
+		out.Error = res.StoreErr.Error()
+	}
+	return out, nil
+}
+
+// BeaconStatus returns how the hare beacon values of the layers of an epoch were computed
+func (s DebugService) BeaconStatus(ctx context.Context, in *extpb.BeaconStatusRequest) (*extpb.BeaconStatusResponse, error) {
+	log.Info("GRPC DebugService.BeaconStatus")
+
+	if s.Beacon == nil {
+		return nil, status.Errorf(codes.Unavailable, "the hare beacon isn't available")
+	}
+	res, err := s.Beacon.BeaconStatus(types.EpochID(in.Epoch))
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "%v", err)
+	}
+	return &extpb.BeaconStatusResponse{
+		Epoch:       uint64(res.Epoch),
+		Computed:    res.Computed,
+		Fallbacks:   res.Fallbacks,
+		Value:       res.Value,
+		ValidBlocks: res.ValidBlocks,
+		Fallback:    res.Fallback,
+		Proposals:   res.Proposals,
+	}, nil
+}
+
+// DataAudit returns the report of the latest audit of the data of the recent layers, running an audit first if asked to
+func (s DebugService) DataAudit(ctx context.Context, in *extpb.DataAuditRequest) (*extpb.DataAuditResponse, error) {
+	log.Info("GRPC DebugService.DataAudit")
+
+	if s.Auditor == nil {
+		return nil, status.Errorf(codes.Unavailable, "data audits aren't available")
+	}
+	var report *sync.AuditReport
+	if in.Run {
+		report = s.Auditor.Audit()
+	} else {
+		report = s.Auditor.LastAudit()
+	}
+	if report == nil {
+		return nil, status.Errorf(codes.NotFound, "the node didn't audit its data yet")
+	}
+	return &extpb.DataAuditResponse{
+		Started:         unixSeconds(report.Started),
+		DurationMs:      uint64(report.Duration.Milliseconds()),
+		FromLayer:       report.FromLayer.Uint64(),
+		ToLayer:         report.ToLayer.Uint64(),
+		MissingBlocks:   uint32(report.Missing[sync.BlockObject]),
+		MissingTxs:      uint32(report.Missing[sync.TransactionObject]),
+		MissingAtxs:     uint32(report.Missing[sync.AtxObject]),
+		RefetchedBlocks: uint32(report.Refetched[sync.BlockObject]),
+		RefetchedTxs:    uint32(report.Refetched[sync.TransactionObject]),
+		RefetchedAtxs:   uint32(report.Refetched[sync.AtxObject]),
+	}, nil
+}
+
+func convertPeerUpdate(update events.PeerUpdate) *extpb.PeerEvent {
+	kind := extpb.PeerEventKind_PEER_EVENT_KIND_DISCONNECTED
+	if update.Connected {
+		kind = extpb.PeerEventKind_PEER_EVENT_KIND_CONNECTED
+	}
+	return &extpb.PeerEvent{
+		Kind:          kind,
+		Id:            update.ID,
+		Address:       update.Address,
+		Outbound:      update.Outbound,
+		Reputation:    update.Reputation,
+		InboundPeers:  update.InboundPeers,
+		OutboundPeers: update.OutboundPeers,
+		Time:          unixSeconds(update.Time),
+	}
+}
+
+func convertPeer(peer p2p.PeerInfo) *extpb.GossipPeer {
+	return &extpb.GossipPeer{
+		Id:             peer.ID.Bytes(),
+		Address:        peer.Address,
+		Outbound:       peer.Outbound,
+		Score:          peer.Score,
+		ConnectedSince: unixSeconds(peer.ConnectedSince),
+		LastActivity:   unixSeconds(peer.LastActivity),
+		Protocols:      peer.Protocols,
+		Reputation:     peer.Reputation,
+	}
+}
+
+func convertNATStatus(nat p2p.NATStatus) extpb.NATStatus {
+	switch nat {
+	case p2p.NATNoGateway:
+		return extpb.NATStatus_NAT_STATUS_NO_GATEWAY
+	case p2p.NATPortFailed:
+		return extpb.NATStatus_NAT_STATUS_PORT_FAILED
+	case p2p.NATPortMapped:
+		return extpb.NATStatus_NAT_STATUS_PORT_MAPPED
+	default:
+		return extpb.NATStatus_NAT_STATUS_DISABLED
+	}
+}
+
+// unixSeconds returns 0 for the zero time, which means never
+func unixSeconds(t time.Time) uint64 {
+	if t.IsZero() {
+		return 0
+	}
+	return uint64(t.Unix())
+}
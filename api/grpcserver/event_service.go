@@ -0,0 +1,264 @@
+package grpcserver
+
+import (
+	"io"
+
+	"github.com/spacemeshos/go-spacemesh/api"
+	"github.com/spacemeshos/go-spacemesh/api/extpb"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/common/util"
+	"github.com/spacemeshos/go-spacemesh/events"
+	"github.com/spacemeshos/go-spacemesh/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// EventService is a grpc server providing the EventService, which multiplexes the events a client is interested in
+// over a single stream instead of a stream per account, transaction or smesher
+type EventService struct {
+	Tx api.TxAPI // Mesh
+}
+
+// RegisterService registers this service with a grpc server instance
+func (s EventService) RegisterService(server *Server) {
+	extpb.RegisterEventServiceServer(server.GrpcServer, s)
+}
+
+// NewEventService creates a new grpc service using config data.
+func NewEventService(tx api.TxAPI) *EventService {
+	return &EventService{
+		Tx: tx,
+	}
+}
+
+// eventFilter is the parsed filter of a SubscribeEvents stream
+type eventFilter struct {
+	accounts map[types.Address]struct{}
+	txs      map[types.TransactionID]struct{}
+	layers   bool
+	smeshers map[string]struct{}
+}
+
+func newEventFilter(in *extpb.EventFilter) (*eventFilter, error) {
+	f := &eventFilter{
+		accounts: make(map[types.Address]struct{}),
+		txs:      make(map[types.TransactionID]struct{}),
+		smeshers: make(map[string]struct{}),
+	}
+	if in == nil {
+		return f, nil
+	}
+	for _, a := range in.Accounts {
+		if len(a) != types.AddressLength {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid account %x", a)
+		}
+		f.accounts[types.BytesToAddress(a)] = struct{}{}
+	}
+	for _, id := range in.TransactionIds {
+		if len(id) != types.Hash32Length {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid transaction id %x", id)
+		}
+		f.txs[types.TransactionID(types.BytesToHash(id))] = struct{}{}
+	}
+	for _, id := range in.SmesherIds {
+		if len(id) == 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "empty smesher id")
+		}
+		f.smeshers[util.Bytes2Hex(id)] = struct{}{}
+	}
+	f.layers = in.Layers
+	return f, nil
+}
+
+func (f *eventFilter) hasAccount(addresses ...types.Address) bool {
+	for _, a := range addresses {
+		if _, ok := f.accounts[a]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *eventFilter) hasTx(id types.TransactionID) bool {
+	_, ok := f.txs[id]
+	return ok
+}
+
+func (f *eventFilter) hasSmesher(key string) bool {
+	_, ok := f.smeshers[key]
+	return ok
+}
+
+func (f *eventFilter) matchTx(tx *types.Transaction) bool {
+	return f.hasTx(tx.ID()) || f.hasAccount(tx.Origin(), tx.Recipient)
+}
+
+// SubscribeEvents streams the events matching the last filter sent by the client. Filters are read concurrently with
+// the events, so a client can replace its filter without reopening the stream.
+func (s EventService) SubscribeEvents(stream extpb.EventService_SubscribeEventsServer) error {
+	log.Info("GRPC EventService.SubscribeEvents")
+
+	filters := make(chan *eventFilter)
+	recvErr := make(chan error, 1)
+	go func() {
+		for {
+			in, err := stream.Recv()
+			if err == io.EOF {
+				// the client is done updating its filter, events are sent until it disconnects
+				return
+			}
+			if err == nil {
+				var f *eventFilter
+				if f, err = newEventFilter(in.Filter); err == nil {
+					select {
+					case filters <- f:
+						continue
+					case <-stream.Context().Done():
+						return
+					}
+				}
+			}
+			recvErr <- err
+			return
+		}
+	}()
+
+	accounts := events.Subscribe(events.EventAccountUpdate)
+	defer accounts.Close()
+	rewards := events.Subscribe(events.EventRewardReceived)
+	defer rewards.Close()
+	mempool := events.Subscribe(events.EventTxInMempool)
+	defer mempool.Close()
+	txs := events.Subscribe(events.EventTxInMesh)
+	defer txs.Close()
+	applied := events.Subscribe(events.EventTxApplied)
+	defer applied.Close()
+	layers := events.Subscribe(events.EventLayerUpdate)
+	defer layers.Close()
+	atxs := events.Subscribe(events.EventAtxInMesh)
+	defer atxs.Close()
+	malfeasance := events.Subscribe(events.EventMalfeasance)
+	defer malfeasance.Close()
+
+	// nothing matches until the client sends its first filter
+	filter, _ := newEventFilter(nil)
+	for {
+		var res *extpb.SubscribeEventsResponse
+		select {
+		case <-stream.Context().Done():
+			log.Info("SubscribeEvents closing stream, client disconnected")
+			return nil
+		case err := <-recvErr:
+			return err
+		case filter = <-filters:
+		case ev := <-accounts.Events():
+			update := ev.(events.AccountUpdate)
+			if filter.hasAccount(update.Address) {
+				res = &extpb.SubscribeEventsResponse{Event: &extpb.SubscribeEventsResponse_Account{Account: &extpb.Account{
+					AccountId: update.Address.Bytes(),
+					Counter:   update.Nonce,
+					Balance:   update.Balance,
+				}}}
+			}
+		case ev := <-rewards.Events():
+			reward := ev.(events.RewardReceived)
+			if filter.hasAccount(types.HexToAddress(reward.Coinbase)) || filter.hasSmesher(reward.Smesher) {
+				res = &extpb.SubscribeEventsResponse{Event: &extpb.SubscribeEventsResponse_Reward{
+					Reward: convertExtRewardReceived(reward),
+				}}
+			}
+		case ev := <-mempool.Events():
+			tx := ev.(events.TxInMempool).Transaction
+			if filter.matchTx(tx) {
+				res = transactionEvent(&extpb.TransactionEvent{
+					State:       extpb.TransactionEvent_TRANSACTION_STATE_MEMPOOL,
+					Transaction: convertExtTransaction(tx),
+				})
+			}
+		case ev := <-txs.Events():
+			inMesh := ev.(events.TxInMesh)
+			if filter.matchTx(inMesh.Transaction) {
+				res = transactionEvent(&extpb.TransactionEvent{
+					State:       extpb.TransactionEvent_TRANSACTION_STATE_MESH,
+					Transaction: convertExtTransaction(inMesh.Transaction),
+					Layer:       inMesh.LayerID.Uint64(),
+				})
+			}
+		case ev := <-applied.Events():
+			res = s.appliedEvent(filter, ev.(events.TxApplied))
+		case ev := <-layers.Events():
+			if filter.layers {
+				res = &extpb.SubscribeEventsResponse{Event: &extpb.SubscribeEventsResponse_Layer{
+					Layer: convertExtLayerUpdate(ev.(events.LayerUpdate)),
+				}}
+			}
+		case ev := <-atxs.Events():
+			atx := ev.(events.AtxInMesh).Atx
+			if filter.hasSmesher(atx.NodeID.Key) || filter.hasAccount(atx.Coinbase) {
+				res = &extpb.SubscribeEventsResponse{Event: &extpb.SubscribeEventsResponse_Activation{
+					Activation: convertExtActivation(atx),
+				}}
+			}
+		case ev := <-malfeasance.Events():
+			proof := ev.(events.MalfeasanceDetected).Proof
+			if filter.hasSmesher(proof.NodeID.Key) {
+				res = &extpb.SubscribeEventsResponse{Event: &extpb.SubscribeEventsResponse_Malfeasance{
+					Malfeasance: convertMalfeasanceProof(proof),
+				}}
+			}
+		}
+		if res == nil {
+			continue
+		}
+		if err := stream.Send(res); err != nil {
+			return err
+		}
+	}
+}
+
+// appliedEvent returns the event of an applied transaction if it matches the filter. Receipts only carry the id of
+// the transaction, it's read from the mesh to match its accounts.
+func (s EventService) appliedEvent(filter *eventFilter, applied events.TxApplied) *extpb.SubscribeEventsResponse {
+	if !filter.hasTx(applied.ID) && len(filter.accounts) == 0 {
+		return nil
+	}
+	tx, err := s.Tx.GetTransaction(applied.ID)
+	if err != nil {
+		tx = nil
+	}
+	if !filter.hasTx(applied.ID) && (tx == nil || !filter.hasAccount(tx.Origin(), tx.Recipient)) {
+		return nil
+	}
+	event := &extpb.TransactionEvent{
+		State:   extpb.TransactionEvent_TRANSACTION_STATE_APPLIED,
+		Layer:   applied.LayerID.Uint64(),
+		Receipt: convertExtReceipt(appliedReceipt(applied)),
+	}
+	if tx != nil {
+		event.Transaction = convertExtTransaction(tx)
+	}
+	return transactionEvent(event)
+}
+
+func transactionEvent(event *extpb.TransactionEvent) *extpb.SubscribeEventsResponse {
+	return &extpb.SubscribeEventsResponse{Event: &extpb.SubscribeEventsResponse_Transaction{Transaction: event}}
+}
+
+func convertExtLayerUpdate(layer events.LayerUpdate) *extpb.Layer {
+	res := &extpb.Layer{Number: layer.LayerID.Uint64()}
+	switch layer.Status {
+	case events.LayerStatusApproved:
+		res.Status = extpb.Layer_LAYER_STATUS_APPROVED
+	case events.LayerStatusConfirmed:
+		res.Status = extpb.Layer_LAYER_STATUS_CONFIRMED
+	default:
+		res.Status = extpb.Layer_LAYER_STATUS_UNSPECIFIED
+	}
+	if layer.Status != events.LayerStatusCreated {
+		res.Hash = types.CalcBlocksHash32(layer.Blocks, nil).Bytes()
+	}
+	for _, id := range layer.Blocks {
+		res.Blocks = append(res.Blocks, &extpb.Block{Id: id.Bytes(), Layer: layer.LayerID.Uint64()})
+	}
+	return res
+}
@@ -0,0 +1,106 @@
+package grpcserver
+
+import (
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// fieldMask is a parsed google.protobuf.FieldMask: a tree of the names of the fields of a response to return. A nil
+// fieldMask, or a nil subtree, selects all fields. The fields of a repeated message field are masked in every element,
+// map fields can only be selected as a whole.
+type fieldMask map[string]fieldMask
+
+// newFieldMask validates the paths of mask against the fields of the response message res, and returns the parsed mask.
+// A missing or empty mask selects all fields.
+func newFieldMask(mask *fieldmaskpb.FieldMask, res protoreflect.ProtoMessage) (fieldMask, error) {
+	if len(mask.GetPaths()) == 0 {
+		return nil, nil
+	}
+	m := make(fieldMask)
+	for _, path := range mask.Paths {
+		desc := res.ProtoReflect().Descriptor()
+		node := m
+		names := strings.Split(path, ".")
+		for i, name := range names {
+			if desc == nil {
+				return nil, status.Errorf(codes.InvalidArgument, "invalid field mask path %v: %v has no fields", path, names[i-1])
+			}
+			field := desc.Fields().ByName(protoreflect.Name(name))
+			if field == nil {
+				return nil, status.Errorf(codes.InvalidArgument, "invalid field mask path %v: unknown field %v", path, name)
+			}
+			desc = field.Message()
+			if field.IsMap() {
+				// map entries can't be masked
+				desc = nil
+			}
+			sub, ok := node[name]
+			if ok && sub == nil {
+				// a shorter path already selected the whole field
+				break
+			}
+			if i == len(names)-1 {
+				node[name] = nil
+				break
+			}
+			if !ok {
+				sub = make(fieldMask)
+				node[name] = sub
+			}
+			node = sub
+		}
+	}
+	return m, nil
+}
+
+// includes returns true if the mask selects the field at path, or any of its fields. It's used to skip reading data
+// that would be masked out anyway.
+func (m fieldMask) includes(path ...string) bool {
+	for _, name := range path {
+		if m == nil {
+			return true
+		}
+		sub, ok := m[name]
+		if !ok {
+			return false
+		}
+		m = sub
+	}
+	return true
+}
+
+// apply clears the fields of res that the mask doesn't select
+func (m fieldMask) apply(res protoreflect.ProtoMessage) {
+	if m == nil {
+		return
+	}
+	m.applyMessage(res.ProtoReflect())
+}
+
+func (m fieldMask) applyMessage(msg protoreflect.Message) {
+	var clear []protoreflect.FieldDescriptor
+	msg.Range(func(field protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		sub, ok := m[string(field.Name())]
+		switch {
+		case !ok:
+			clear = append(clear, field)
+		case sub == nil:
+			// the whole field is selected
+		case field.IsList():
+			list := v.List()
+			for i := 0; i < list.Len(); i++ {
+				sub.applyMessage(list.Get(i).Message())
+			}
+		default:
+			sub.applyMessage(v.Message())
+		}
+		return true
+	})
+	for _, field := range clear {
+		msg.Clear(field)
+	}
+}
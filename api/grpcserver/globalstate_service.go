@@ -0,0 +1,590 @@
+package grpcserver
+
+import (
+	"sort"
+
+	pb "github.com/spacemeshos/api/release/go/spacemesh/v1"
+	"github.com/spacemeshos/go-spacemesh/api"
+	"github.com/spacemeshos/go-spacemesh/api/extpb"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/common/util"
+	"github.com/spacemeshos/go-spacemesh/database"
+	"github.com/spacemeshos/go-spacemesh/events"
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/mesh"
+	"github.com/spacemeshos/go-spacemesh/state"
+	"github.com/spacemeshos/go-spacemesh/trie"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GlobalStateService is a grpc server providing the GlobalStateService, which exposes the accounts, rewards and
+// transaction receipts of the global state
+type GlobalStateService struct {
+	Tx    api.TxAPI          // Mesh
+	State api.GlobalStateAPI // Global state
+	// HistoryLayers is the number of recent layers whose global state can be queried, 0 for all layers
+	HistoryLayers uint64
+	// GenesisAccounts are the balances allocated to accounts at genesis
+	GenesisAccounts map[types.Address]uint64
+	Receipts        api.ReceiptAPI
+}
+
+// RegisterService registers this service with a grpc server instance
+func (s GlobalStateService) RegisterService(server *Server) {
+	pb.RegisterGlobalStateServiceServer(server.GrpcServer, s)
+	extpb.RegisterGlobalStateServiceServer(server.GrpcServer, s)
+}
+
+// NewGlobalStateService creates a new grpc service using config data.
+func NewGlobalStateService(tx api.TxAPI, state api.GlobalStateAPI, receipts api.ReceiptAPI, historyLayers uint64, genesisAccounts map[types.Address]uint64) *GlobalStateService {
+	return &GlobalStateService{
+		Tx:              tx,
+		State:           state,
+		HistoryLayers:   historyLayers,
+		GenesisAccounts: genesisAccounts,
+		Receipts:        receipts,
+	}
+}
+
+// GlobalStateHash returns the latest global state root along with the layer it was computed for
+func (s GlobalStateService) GlobalStateHash(ctx context.Context, in *pb.GlobalStateHashRequest) (*pb.GlobalStateHashResponse, error) {
+	log.Info("GRPC GlobalStateService.GlobalStateHash")
+	return &pb.GlobalStateHashResponse{Response: &pb.GlobalStateHash{
+		RootHash:    s.Tx.GetStateRoot().Bytes(),
+		LayerNumber: s.Tx.LatestLayerInState().Uint64(),
+	}}, nil
+}
+
+// GlobalStateHashAtLayer returns the global state root after applying a layer. Roots are only available for layers
+// that were applied to the current global state.
+func (s GlobalStateService) GlobalStateHashAtLayer(ctx context.Context, in *extpb.GlobalStateHashAtLayerRequest) (*extpb.GlobalStateHashAtLayerResponse, error) {
+	log.Info("GRPC GlobalStateService.GlobalStateHashAtLayer")
+
+	layer := types.LayerID(in.Layer)
+	root, err := s.layerStateRoot(layer)
+	if err != nil {
+		return nil, err
+	}
+	return &extpb.GlobalStateHashAtLayerResponse{RootHash: root.Bytes(), Layer: layer.Uint64()}, nil
+}
+
+// AccountAtLayer returns the counter and balance of an account in the global state after applying a layer
+func (s GlobalStateService) AccountAtLayer(ctx context.Context, in *extpb.AccountAtLayerRequest) (*extpb.AccountAtLayerResponse, error) {
+	log.Info("GRPC GlobalStateService.AccountAtLayer")
+
+	if len(in.AccountId) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "`AccountId` must be provided")
+	}
+	layer := types.LayerID(in.Layer)
+	root, err := s.layerStateRoot(layer)
+	if err != nil {
+		return nil, err
+	}
+	addr := types.BytesToAddress(in.AccountId)
+	exists, nonce, balance, err := s.State.GetAccountAt(root, addr)
+	if err != nil {
+		log.Error("error reading account %v at layer %v: %v", addr.Short(), layer, err)
+		return nil, status.Errorf(codes.Internal, "error reading account state")
+	}
+	return &extpb.AccountAtLayerResponse{
+		AccountId: addr.Bytes(),
+		Layer:     layer.Uint64(),
+		RootHash:  root.Bytes(),
+		Exists:    exists,
+		Counter:   nonce,
+		Balance:   balance,
+	}, nil
+}
+
+// layerStateRoot returns the global state root after applying a layer, or a grpc error if the state of the layer
+// can't be queried
+func (s GlobalStateService) layerStateRoot(layer types.LayerID) (types.Hash32, error) {
+	latest := s.Tx.LatestLayerInState()
+	// roots of layers after a reverted state are stale until the layers are applied again
+	if layer > latest {
+		return types.Hash32{}, status.Errorf(codes.NotFound, "layer %v was not applied to the global state", layer)
+	}
+	if s.HistoryLayers > 0 && latest.Uint64()-layer.Uint64() >= s.HistoryLayers {
+		return types.Hash32{}, status.Errorf(codes.OutOfRange, "the global state of layer %v is older than the last %v layers", layer, s.HistoryLayers)
+	}
+	root, err := s.State.GetLayerStateRoot(layer)
+	if err == database.ErrNotFound {
+		return types.Hash32{}, status.Errorf(codes.NotFound, "no global state root for layer %v", layer)
+	}
+	if err == database.ErrPruned {
+		return types.Hash32{}, status.Errorf(codes.NotFound, "global state root of layer %v was pruned", layer)
+	}
+	if err != nil {
+		log.Error("error reading global state root of layer %v: %v", layer, err)
+		return types.Hash32{}, status.Errorf(codes.Internal, "error reading global state root")
+	}
+	return root, nil
+}
+
+// AccountProof returns a merkle proof of the state of an account against a global state root, which can be verified
+// with types.VerifyAccountProof without trusting this node
+func (s GlobalStateService) AccountProof(ctx context.Context, in *extpb.AccountProofRequest) (*extpb.AccountProofResponse, error) {
+	log.Info("GRPC GlobalStateService.AccountProof")
+
+	if len(in.AccountId) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "`AccountId` must be provided")
+	}
+	mask, err := newFieldMask(in.FieldMask, (*extpb.AccountProofResponse)(nil))
+	if err != nil {
+		return nil, err
+	}
+	root := s.Tx.GetStateRoot()
+	if len(in.RootHash) > 0 {
+		root = types.BytesToHash(in.RootHash)
+	}
+	addr := types.BytesToAddress(in.AccountId)
+	proof, err := s.State.GetProof(root, addr)
+	if _, ok := err.(*trie.MissingNodeError); ok {
+		return nil, status.Errorf(codes.NotFound, "global state root %v is not available", root.ShortString())
+	}
+	if err != nil {
+		log.Error("error proving account %v: %v", addr.Short(), err)
+		return nil, status.Errorf(codes.Internal, "error proving account state")
+	}
+	res := &extpb.AccountProofResponse{
+		RootHash:  root.Bytes(),
+		AccountId: addr.Bytes(),
+		Exists:    proof.Exists,
+		Counter:   proof.Nonce,
+		Balance:   proof.Balance,
+		Nodes:     proof.Nodes,
+	}
+	mask.apply(res)
+	return res, nil
+}
+
+// Supply returns the total supply of coins after applying a layer, which is the sum of the balances of all accounts.
+// Since fees are only moved between accounts, everything that wasn't allocated at genesis was issued as layer rewards.
+// The circulating supply is estimated as the coins that are not held by the genesis accounts anymore.
+func (s GlobalStateService) Supply(ctx context.Context, in *extpb.SupplyRequest) (*extpb.SupplyResponse, error) {
+	log.Info("GRPC GlobalStateService.Supply")
+
+	layer := types.LayerID(in.Layer)
+	if layer == 0 {
+		layer = s.Tx.LatestLayerInState()
+	}
+	root, err := s.layerStateRoot(layer)
+	if err != nil {
+		return nil, err
+	}
+
+	var total, held uint64
+	err = s.State.IterateAccounts(root, func(addr types.Address, nonce, balance uint64) error {
+		total += balance
+		if _, ok := s.GenesisAccounts[addr]; ok {
+			held += balance
+		}
+		return nil
+	})
+	if err != nil {
+		log.Error("error reading accounts of layer %v: %v", layer, err)
+		return nil, status.Errorf(codes.Internal, "error reading accounts")
+	}
+	var genesis uint64
+	for _, balance := range s.GenesisAccounts {
+		genesis += balance
+	}
+	res := &extpb.SupplyResponse{
+		Layer:       layer.Uint64(),
+		RootHash:    root.Bytes(),
+		Total:       total,
+		Genesis:     genesis,
+		Circulating: total - held,
+	}
+	if total > genesis {
+		res.Rewards = total - genesis
+	}
+	return res, nil
+}
+
+// CoinbaseRewards returns the rewards paid to a coinbase in a range of epochs, aggregated per epoch. Epochs in which the
+// coinbase wasn't rewarded are omitted.
+func (s GlobalStateService) CoinbaseRewards(ctx context.Context, in *extpb.CoinbaseRewardsRequest) (*extpb.CoinbaseRewardsResponse, error) {
+	log.Info("GRPC GlobalStateService.CoinbaseRewards")
+
+	if len(in.Coinbase) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "`Coinbase` must be provided")
+	}
+	mask, err := newFieldMask(in.FieldMask, (*extpb.CoinbaseRewardsResponse)(nil))
+	if err != nil {
+		return nil, err
+	}
+	maxEpoch := types.EpochID(in.MaxEpoch)
+	if maxEpoch == 0 {
+		maxEpoch = s.Tx.LatestLayerInState().GetEpoch()
+	}
+	if types.EpochID(in.MinEpoch) > maxEpoch {
+		return nil, status.Errorf(codes.InvalidArgument, "`MinEpoch` must not be greater than `MaxEpoch`")
+	}
+
+	coinbase := types.BytesToAddress(in.Coinbase)
+	rewards, err := s.Tx.GetCoinbaseRewards(coinbase, types.EpochID(in.MinEpoch).FirstLayer(), (maxEpoch+1).FirstLayer()-1)
+	if err != nil {
+		log.Error("error reading rewards of coinbase %v: %v", coinbase.Short(), err)
+		return nil, status.Errorf(codes.Internal, "error reading rewards")
+	}
+	includeLayers := in.IncludeLayers && mask.includes("epochs", "layers")
+	res := &extpb.CoinbaseRewardsResponse{}
+	var epoch *extpb.EpochRewards
+	for _, r := range rewards {
+		if epoch == nil || epoch.Epoch != uint64(r.Layer.GetEpoch()) {
+			epoch = &extpb.EpochRewards{Epoch: uint64(r.Layer.GetEpoch())}
+			res.Epochs = append(res.Epochs, epoch)
+		}
+		epoch.Blocks += r.Blocks
+		epoch.Total += r.TotalReward
+		epoch.LayerReward += r.LayerRewardEstimate
+		if includeLayers {
+			epoch.Layers = append(epoch.Layers, &extpb.LayerRewards{
+				Layer:       r.Layer.Uint64(),
+				Blocks:      r.Blocks,
+				Total:       r.TotalReward,
+				LayerReward: r.LayerRewardEstimate,
+			})
+		}
+	}
+	mask.apply(res)
+	return res, nil
+}
+
+// Account returns the current counter and balance of an account
+func (s GlobalStateService) Account(ctx context.Context, in *pb.AccountRequest) (*pb.AccountResponse, error) {
+	log.Info("GRPC GlobalStateService.Account")
+
+	if in.AccountId == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "`AccountId` must be provided")
+	}
+	return &pb.AccountResponse{Account: s.readAccount(types.BytesToAddress(in.AccountId.Address))}, nil
+}
+
+// AccountDataQuery returns the current account state, the rewards and the transaction receipts of an account, as
+// selected by the filter flags. All results are returned in this order, rewards and receipts ordered by layer, and
+// max_results and offset page through them.
+func (s GlobalStateService) AccountDataQuery(ctx context.Context, in *pb.AccountDataQueryRequest) (*pb.AccountDataQueryResponse, error) {
+	log.Info("GRPC GlobalStateService.AccountDataQuery")
+
+	if in.Filter == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "`Filter` must be provided")
+	}
+	if in.Filter.AccountId == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "`Filter.AccountId` must be provided")
+	}
+	if in.Filter.AccountDataFlags == uint32(pb.AccountDataFlag_ACCOUNT_DATA_FLAG_UNSPECIFIED) {
+		return nil, status.Errorf(codes.InvalidArgument, "`Filter.AccountDataFlags` must set at least one bitfield")
+	}
+
+	addr := types.BytesToAddress(in.Filter.AccountId.Address)
+	flags := in.Filter.AccountDataFlags
+	var items []*pb.AccountData
+
+	if flags&uint32(pb.AccountDataFlag_ACCOUNT_DATA_FLAG_ACCOUNT) != 0 {
+		items = append(items, &pb.AccountData{Item: &pb.AccountData_Account{Account: s.readAccount(addr)}})
+	}
+	if flags&uint32(pb.AccountDataFlag_ACCOUNT_DATA_FLAG_REWARD) != 0 {
+		rewards, err := s.Tx.GetRewards(addr)
+		if err != nil {
+			log.Error("error getting rewards of account %v: %v", addr.Short(), err)
+			return nil, status.Errorf(codes.Internal, "error getting rewards data")
+		}
+		for _, r := range rewards {
+			items = append(items, &pb.AccountData{Item: &pb.AccountData_Reward{Reward: convertReward(r, addr)}})
+		}
+	}
+	if flags&uint32(pb.AccountDataFlag_ACCOUNT_DATA_FLAG_TRANSACTION_RECEIPT) != 0 {
+		receipts, err := s.accountReceipts(addr)
+		if err != nil {
+			log.Error("error getting transaction receipts of account %v: %v", addr.Short(), err)
+			return nil, status.Errorf(codes.Internal, "error getting transaction receipts")
+		}
+		for _, receipt := range receipts {
+			items = append(items, &pb.AccountData{Item: &pb.AccountData_Receipt{Receipt: receipt}})
+		}
+	}
+
+	res := &pb.AccountDataQueryResponse{TotalResults: uint32(len(items))}
+	if int(in.Offset) >= len(items) {
+		return res, nil
+	}
+	items = items[in.Offset:]
+	if in.MaxResults > 0 && int(in.MaxResults) < len(items) {
+		items = items[:in.MaxResults]
+	}
+	res.AccountItem = items
+	return res, nil
+}
+
+// SmesherDataQuery returns the rewards of a smesher
+func (s GlobalStateService) SmesherDataQuery(ctx context.Context, in *pb.SmesherDataQueryRequest) (*pb.SmesherDataQueryResponse, error) {
+	log.Info("GRPC GlobalStateService.SmesherDataQuery")
+	return nil, status.Errorf(codes.Unimplemented, "this endpoint is not implemented")
+}
+
+// STREAMS
+
+// AccountDataStream returns a stream of updates to the state of an account
+func (s GlobalStateService) AccountDataStream(in *pb.AccountDataStreamRequest, stream pb.GlobalStateService_AccountDataStreamServer) error {
+	log.Info("GRPC GlobalStateService.AccountDataStream")
+	return status.Errorf(codes.Unimplemented, "this endpoint is not implemented")
+}
+
+// SmesherRewardStream returns a stream of the rewards earned by a smesher as layers are applied to the global state
+func (s GlobalStateService) SmesherRewardStream(in *pb.SmesherRewardStreamRequest, stream pb.GlobalStateService_SmesherRewardStreamServer) error {
+	log.Info("GRPC GlobalStateService.SmesherRewardStream")
+
+	if in.Id == nil {
+		return status.Errorf(codes.InvalidArgument, "`Id` must be provided")
+	}
+	smesher := util.Bytes2Hex(in.Id.Id)
+	return streamRewards(stream.Context(), func(r events.RewardReceived) bool {
+		return r.Smesher == smesher
+	}, func(r events.RewardReceived) error {
+		return stream.Send(&pb.SmesherRewardStreamResponse{Reward: convertRewardReceived(r)})
+	})
+}
+
+// RewardStream returns a stream of the rewards paid to a coinbase or earned by a smesher as layers are applied to the
+// global state
+func (s GlobalStateService) RewardStream(in *extpb.RewardStreamRequest, stream extpb.GlobalStateService_RewardStreamServer) error {
+	log.Info("GRPC GlobalStateService.RewardStream")
+
+	if len(in.SmesherId) == 0 && len(in.Coinbase) == 0 {
+		return status.Errorf(codes.InvalidArgument, "`SmesherId` or `Coinbase` must be provided")
+	}
+	smesher := util.Bytes2Hex(in.SmesherId)
+	coinbase := types.BytesToAddress(in.Coinbase)
+	return streamRewards(stream.Context(), func(r events.RewardReceived) bool {
+		if len(in.SmesherId) > 0 && r.Smesher != smesher {
+			return false
+		}
+		return len(in.Coinbase) == 0 || types.HexToAddress(r.Coinbase) == coinbase
+	}, func(r events.RewardReceived) error {
+		return stream.Send(&extpb.RewardStreamResponse{Reward: convertExtRewardReceived(r)})
+	})
+}
+
+// streamRewards sends the published rewards that match a filter until the client disconnects or sending fails
+func streamRewards(ctx context.Context, match func(events.RewardReceived) bool, send func(events.RewardReceived) error) error {
+	sub := events.Subscribe(events.EventRewardReceived)
+	defer sub.Close()
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info("reward stream closing stream, client disconnected")
+			return nil
+		case ev := <-sub.Events():
+			reward := ev.(events.RewardReceived)
+			if !match(reward) {
+				continue
+			}
+			if err := send(reward); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// AppEventStream returns a stream of the events emitted by apps
+func (s GlobalStateService) AppEventStream(in *pb.AppEventStreamRequest, stream pb.GlobalStateService_AppEventStreamServer) error {
+	log.Info("GRPC GlobalStateService.AppEventStream")
+	return status.Errorf(codes.Unimplemented, "this endpoint is not implemented")
+}
+
+// GlobalStateStream returns a stream of changes to the global state as layers are applied to it: transaction
+// receipts, rewards, updated accounts and the new global state hash, as selected by the flags
+func (s GlobalStateService) GlobalStateStream(in *pb.GlobalStateStreamRequest, stream pb.GlobalStateService_GlobalStateStreamServer) error {
+	log.Info("GRPC GlobalStateService.GlobalStateStream")
+
+	flags := in.GlobalStateDataItemFlags
+	if flags == uint32(pb.GlobalStateDataItemFlag_GLOBAL_STATE_DATA_ITEM_FLAG_UNSPECIFIED) {
+		return status.Errorf(codes.InvalidArgument, "`GlobalStateDataItemFlags` must set at least one bitfield")
+	}
+
+	// a nil channel blocks forever, so we only receive the requested data
+	var receiptCh, rewardCh, accountCh, layerCh <-chan events.Event
+	if flags&uint32(pb.GlobalStateDataItemFlag_GLOBAL_STATE_DATA_ITEM_FLAG_TRANSACTION_RECEIPT) != 0 {
+		sub := events.Subscribe(events.EventTxApplied)
+		defer sub.Close()
+		receiptCh = sub.Events()
+	}
+	if flags&uint32(pb.GlobalStateDataItemFlag_GLOBAL_STATE_DATA_ITEM_FLAG_REWARD) != 0 {
+		sub := events.Subscribe(events.EventRewardReceived)
+		defer sub.Close()
+		rewardCh = sub.Events()
+	}
+	if flags&uint32(pb.GlobalStateDataItemFlag_GLOBAL_STATE_DATA_ITEM_FLAG_ACCOUNT) != 0 {
+		sub := events.Subscribe(events.EventAccountUpdate)
+		defer sub.Close()
+		accountCh = sub.Events()
+	}
+	if flags&uint32(pb.GlobalStateDataItemFlag_GLOBAL_STATE_DATA_ITEM_FLAG_GLOBAL_STATE_HASH) != 0 {
+		sub := events.Subscribe(events.EventLayerUpdate)
+		defer sub.Close()
+		layerCh = sub.Events()
+	}
+
+	for {
+		var item *pb.GlobalStateDataItem
+		select {
+		case <-stream.Context().Done():
+			log.Info("GlobalStateStream closing stream, client disconnected")
+			return nil
+		case ev := <-receiptCh:
+			item = &pb.GlobalStateDataItem{Data: &pb.GlobalStateDataItem_Receipt{Receipt: convertReceipt(appliedReceipt(ev.(events.TxApplied)))}}
+		case ev := <-rewardCh:
+			item = &pb.GlobalStateDataItem{Data: &pb.GlobalStateDataItem_Reward{Reward: convertRewardReceived(ev.(events.RewardReceived))}}
+		case ev := <-accountCh:
+			account := ev.(events.AccountUpdate)
+			item = &pb.GlobalStateDataItem{Data: &pb.GlobalStateDataItem_Account{Account: &pb.Account{
+				Address: &pb.AccountId{Address: account.Address.Bytes()},
+				Counter: account.Nonce,
+				Balance: &pb.Amount{Value: account.Balance},
+			}}}
+		case ev := <-layerCh:
+			layer := ev.(events.LayerUpdate)
+			if layer.Status != events.LayerStatusConfirmed {
+				continue
+			}
+			item = &pb.GlobalStateDataItem{Data: &pb.GlobalStateDataItem_GlobalState{GlobalState: &pb.GlobalStateHash{
+				RootHash:    layer.StateRoot.Bytes(),
+				LayerNumber: layer.LayerID.Uint64(),
+			}}}
+		}
+		if err := stream.Send(&pb.GlobalStateStreamResponse{DataItem: []*pb.GlobalStateDataItem{item}}); err != nil {
+			return err
+		}
+	}
+}
+
+func (s GlobalStateService) readAccount(addr types.Address) *pb.Account {
+	return &pb.Account{
+		Address: &pb.AccountId{Address: addr.Bytes()},
+		Counter: s.State.GetNonce(addr),
+		Balance: &pb.Amount{Value: s.State.GetBalance(addr)},
+	}
+}
+
+// accountReceipts returns the receipts of the transactions sent or received by an account that were applied to the
+// global state, ordered by layer. The transactions are read from the transaction history of the account, so only
+// transactions that were stored in the mesh after the history was indexed are found. Transactions that were applied
+// before receipts were recorded only have a receipt if they were applied successfully.
+func (s GlobalStateService) accountReceipts(addr types.Address) ([]*pb.TransactionReceipt, error) {
+	var receipts []*pb.TransactionReceipt
+	seen := make(map[types.TransactionID]struct{})
+	latest := s.Tx.LatestLayerInState()
+	var cursor []byte
+	for {
+		history, next, err := s.Tx.GetAccountTransactions(addr, mesh.TxAnyDirection, 0, latest, cursor, maxAccountTransactions)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range history {
+			id := entry.ID
+			// a transaction appears in the history once for every layer it was included in
+			if _, ok := seen[id]; ok {
+				continue
+			}
+			seen[id] = struct{}{}
+			receipt, err := s.Receipts.Get(id)
+			if err == nil {
+				receipts = append(receipts, convertReceipt(receipt))
+				continue
+			}
+			if err != database.ErrNotFound {
+				log.With().Warning("could not read transaction receipt", id, log.Err(err))
+			}
+			applied := s.Tx.GetLayerApplied(id)
+			if applied == nil {
+				continue
+			}
+			tx, err := s.Tx.GetTransaction(id)
+			if err != nil || tx == nil {
+				log.With().Warning("could not read applied transaction", id, log.Err(err))
+				continue
+			}
+			receipts = append(receipts, &pb.TransactionReceipt{
+				Id:          &pb.TransactionId{Id: id.Bytes()},
+				Result:      pb.TransactionReceipt_TRANSACTION_RESULT_EXECUTED,
+				Fee:         &pb.Amount{Value: tx.Fee},
+				LayerNumber: applied.Uint64(),
+			})
+		}
+		if next == nil {
+			break
+		}
+		cursor = next
+	}
+	sort.SliceStable(receipts, func(i, j int) bool { return receipts[i].LayerNumber < receipts[j].LayerNumber })
+	return receipts, nil
+}
+
+func convertReward(r types.Reward, coinbase types.Address) *pb.Reward {
+	return &pb.Reward{
+		Layer:         r.Layer.Uint64(),
+		Total:         &pb.Amount{Value: r.TotalReward},
+		LayerReward:   &pb.Amount{Value: r.LayerRewardEstimate},
+		LayerComputed: r.Layer.Uint64(),
+		Coinbase:      &pb.AccountId{Address: coinbase.Bytes()},
+	}
+}
+
+func convertRewardReceived(r events.RewardReceived) *pb.Reward {
+	return &pb.Reward{
+		Layer:         r.Layer,
+		Total:         &pb.Amount{Value: r.Amount},
+		LayerReward:   &pb.Amount{Value: r.LayerReward},
+		LayerComputed: r.Layer,
+		Coinbase:      &pb.AccountId{Address: types.HexToAddress(r.Coinbase).Bytes()},
+		Smesher:       &pb.SmesherId{Id: util.Hex2Bytes(r.Smesher)},
+	}
+}
+
+func convertExtRewardReceived(r events.RewardReceived) *extpb.Reward {
+	return &extpb.Reward{
+		Layer:       r.Layer,
+		Total:       r.Amount,
+		LayerReward: r.LayerReward,
+		Coinbase:    types.HexToAddress(r.Coinbase).Bytes(),
+		SmesherId:   util.Hex2Bytes(r.Smesher),
+	}
+}
+
+// appliedReceipt returns the receipt reported by a TxApplied event
+func appliedReceipt(tx events.TxApplied) *state.Receipt {
+	receipt := &state.Receipt{
+		ID:      tx.ID,
+		Layer:   tx.LayerID,
+		Result:  tx.Result,
+		GasUsed: tx.GasUsed,
+		Error:   tx.Error,
+	}
+	// failed transactions are dropped and no fee is charged
+	if tx.Result == events.TxResultApplied {
+		receipt.Fee = tx.Fee
+	}
+	return receipt
+}
+
+func convertReceipt(r *state.Receipt) *pb.TransactionReceipt {
+	receipt := &pb.TransactionReceipt{
+		Id:          &pb.TransactionId{Id: r.ID.Bytes()},
+		GasUsed:     r.GasUsed,
+		LayerNumber: r.Layer.Uint64(),
+	}
+	switch r.Result {
+	case events.TxResultApplied:
+		receipt.Result = pb.TransactionReceipt_TRANSACTION_RESULT_EXECUTED
+		receipt.Fee = &pb.Amount{Value: r.Fee}
+	case events.TxResultBadNonce:
+		receipt.Result = pb.TransactionReceipt_TRANSACTION_RESULT_BAD_COUNTER
+	case events.TxResultInsufficientFunds:
+		receipt.Result = pb.TransactionReceipt_TRANSACTION_RESULT_INSUFFICIENT_FUNDS
+	}
+	return receipt
+}
@@ -1,20 +1,41 @@
 package grpcserver
 
 import (
+	"archive/tar"
 	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/golang/protobuf/jsonpb"
 	"github.com/golang/protobuf/proto"
 	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/spacemeshos/go-spacemesh/checkpoint"
 	"github.com/spacemeshos/go-spacemesh/cmd"
+	"github.com/spacemeshos/go-spacemesh/database"
+	"github.com/spacemeshos/go-spacemesh/dbcheck"
+	"github.com/spacemeshos/go-spacemesh/events"
+	"github.com/spacemeshos/go-spacemesh/hare/eligibility"
+	"github.com/spacemeshos/go-spacemesh/log"
 	"github.com/spacemeshos/go-spacemesh/state"
+	spacesync "github.com/spacemeshos/go-spacemesh/sync"
+	"github.com/spacemeshos/go-spacemesh/tortoise"
+	"github.com/spacemeshos/go-spacemesh/trie"
 	"google.golang.org/genproto/googleapis/rpc/code"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"io"
 	"io/ioutil"
 	"math/big"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -22,11 +43,20 @@ import (
 	"time"
 
 	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/common/util"
 	"github.com/spacemeshos/go-spacemesh/p2p/p2pcrypto"
+	p2ppeers "github.com/spacemeshos/go-spacemesh/p2p/peers"
+	"github.com/spacemeshos/go-spacemesh/signing"
 	"github.com/stretchr/testify/require"
 
 	pb "github.com/spacemeshos/api/release/go/spacemesh/v1"
 	"github.com/spacemeshos/go-spacemesh/api/config"
+	"github.com/spacemeshos/go-spacemesh/api/extpb"
+	"github.com/spacemeshos/go-spacemesh/mesh"
+	"github.com/spacemeshos/go-spacemesh/miner"
+	"github.com/spacemeshos/go-spacemesh/p2p"
+	"github.com/spacemeshos/go-spacemesh/p2p/discovery"
+	"github.com/spacemeshos/go-spacemesh/p2p/gossip"
 	"github.com/spacemeshos/go-spacemesh/p2p/node"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
@@ -37,6 +67,9 @@ import (
 type NodeAPIMock struct {
 	balances map[types.Address]*big.Int
 	nonces   map[types.Address]uint64
+	roots    map[types.LayerID]types.Hash32
+	proofs   map[types.Hash32]*types.AccountProof
+	pruned   types.LayerID // roots of layers below it were pruned
 }
 
 type NetworkMock struct {
@@ -81,6 +114,8 @@ func NewNodeAPIMock() NodeAPIMock {
 	return NodeAPIMock{
 		balances: make(map[types.Address]*big.Int),
 		nonces:   make(map[types.Address]uint64),
+		roots:    make(map[types.LayerID]types.Hash32),
+		proofs:   make(map[types.Hash32]*types.AccountProof),
 	}
 }
 
@@ -97,11 +132,57 @@ func (n NodeAPIMock) Exist(address types.Address) bool {
 	return ok
 }
 
+func (n NodeAPIMock) GetProof(root types.Hash32, addr types.Address) (*types.AccountProof, error) {
+	proof, ok := n.proofs[root]
+	if !ok {
+		return nil, &trie.MissingNodeError{NodeHash: root}
+	}
+	return proof, nil
+}
+
+// GetAccountAt returns the current state of the account, the mock keeps no history
+func (n NodeAPIMock) GetAccountAt(_ types.Hash32, addr types.Address) (exists bool, nonce, balance uint64, err error) {
+	if !n.Exist(addr) {
+		return false, 0, 0, nil
+	}
+	return true, n.GetNonce(addr), n.GetBalance(addr), nil
+}
+
+// IterateAccounts iterates the current accounts ordered by address, the mock keeps no history
+func (n NodeAPIMock) IterateAccounts(_ types.Hash32, fn func(addr types.Address, nonce, balance uint64) error) error {
+	addrs := make([]types.Address, 0, len(n.nonces))
+	for addr := range n.nonces {
+		addrs = append(addrs, addr)
+	}
+	sort.Slice(addrs, func(i, j int) bool { return bytes.Compare(addrs[i].Bytes(), addrs[j].Bytes()) < 0 })
+	for _, addr := range addrs {
+		if err := fn(addr, n.GetNonce(addr), n.GetBalance(addr)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (n NodeAPIMock) GetLayerStateRoot(layer types.LayerID) (types.Hash32, error) {
+	if layer < n.pruned {
+		return types.Hash32{}, database.ErrPruned
+	}
+	root, ok := n.roots[layer]
+	if !ok {
+		return types.Hash32{}, database.ErrNotFound
+	}
+	return root, nil
+}
+
 type TxAPIMock struct {
-	mockOrigin   types.Address
-	returnTx     map[types.TransactionID]*types.Transaction
-	layerApplied map[types.TransactionID]*types.LayerID
-	err          error
+	mockOrigin      types.Address
+	returnTx        map[types.TransactionID]*types.Transaction
+	layerApplied    map[types.TransactionID]*types.LayerID
+	rewards         map[types.Address][]types.Reward
+	accountTxs      []mesh.AccountTx
+	coinbaseRewards map[types.Address][]mesh.CoinbaseReward
+	missing         map[types.Address]struct{} // accounts that don't exist, all others do
+	err             error
 }
 
 func (t *TxAPIMock) GetStateRoot() types.Hash32 {
@@ -134,8 +215,8 @@ func (t *TxAPIMock) LatestLayer() types.LayerID {
 	return 10
 }
 
-func (t *TxAPIMock) GetRewards(types.Address) (rewards []types.Reward, err error) {
-	return
+func (t *TxAPIMock) GetRewards(account types.Address) (rewards []types.Reward, err error) {
+	return t.rewards[account], nil
 }
 
 func (t *TxAPIMock) GetTransactionsByDestination(l types.LayerID, account types.Address) (txs []types.TransactionID) {
@@ -166,8 +247,97 @@ func (t *TxAPIMock) setMockOrigin(orig types.Address) {
 	t.mockOrigin = orig
 }
 
-func (t *TxAPIMock) AddressExists(types.Address) bool {
-	return true
+func (t *TxAPIMock) AddressExists(addr types.Address) bool {
+	_, ok := t.missing[addr]
+	return !ok
+}
+
+func (t *TxAPIMock) ProcessedLayer() types.LayerID {
+	return ValidatedLayerID + 1
+}
+
+// GetLayer returns a layer with a single block containing globalTx, layers past the latest one are not found
+func (t *TxAPIMock) GetLayer(i types.LayerID) (*types.Layer, error) {
+	if i > t.LatestLayer() {
+		return nil, database.ErrNotFound
+	}
+	block := types.NewExistingBlock(i, []byte("data"))
+	block.ATXID = globalAtx.ID()
+	block.TxIDs = []types.TransactionID{globalTx.ID()}
+	block.Signature = blockSigner.Sign(block.Bytes())
+	block.Initialize()
+	l := types.NewLayer(i)
+	l.AddBlock(block)
+	return l, nil
+}
+
+func (t *TxAPIMock) GetBlock(id types.BlockID) (*types.Block, error) {
+	for i := types.LayerID(0); i <= t.LatestLayer(); i++ {
+		l, _ := t.GetLayer(i)
+		for _, b := range l.Blocks() {
+			if b.ID() == id {
+				return b, nil
+			}
+		}
+	}
+	return nil, database.ErrNotFound
+}
+
+func (t *TxAPIMock) GetATXs(atxIds []types.ATXID) (map[types.ATXID]*types.ActivationTx, []types.ATXID) {
+	atxs := make(map[types.ATXID]*types.ActivationTx)
+	var missing []types.ATXID
+	for _, id := range atxIds {
+		if id == globalAtx.ID() {
+			atxs[id] = globalAtx
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	return atxs, missing
+}
+
+func (t *TxAPIMock) GetTransactions(txIds []types.TransactionID) (txs []*types.Transaction, missing map[types.TransactionID]struct{}) {
+	missing = make(map[types.TransactionID]struct{})
+	for _, id := range txIds {
+		if id == globalTx.ID() {
+			txs = append(txs, globalTx)
+		} else {
+			missing[id] = struct{}{}
+		}
+	}
+	return
+}
+
+func (t *TxAPIMock) GetCoinbaseRewards(coinbase types.Address, minLayer, maxLayer types.LayerID) (rewards []mesh.CoinbaseReward, err error) {
+	for _, r := range t.coinbaseRewards[coinbase] {
+		if r.Layer >= minLayer && r.Layer <= maxLayer {
+			rewards = append(rewards, r)
+		}
+	}
+	return rewards, nil
+}
+
+// GetAccountTransactions returns the entries of accountTxs that match the filters, the cursor is the index of the next
+// entry as a single byte
+func (t *TxAPIMock) GetAccountTransactions(_ types.Address, direction mesh.TxDirection, minLayer, maxLayer types.LayerID, cursor []byte, limit int) (txs []mesh.AccountTx, next []byte, err error) {
+	start := 0
+	if len(cursor) > 0 {
+		if len(cursor) != 1 {
+			return nil, nil, mesh.ErrBadCursor
+		}
+		start = int(cursor[0])
+	}
+	for i := start; i < len(t.accountTxs); i++ {
+		entry := t.accountTxs[i]
+		if entry.Layer < minLayer || entry.Layer > maxLayer || entry.Direction&direction == 0 {
+			continue
+		}
+		if len(txs) == limit {
+			return txs, []byte{byte(i)}, nil
+		}
+		txs = append(txs, entry)
+	}
+	return txs, nil, nil
 }
 
 // MiningAPIMock is a mock for mining API
@@ -206,6 +376,147 @@ func (t GenesisTimeMock) GetGenesisTime() time.Time {
 	return t.t
 }
 
+type EligibilityMock struct{}
+
+func (EligibilityMock) EpochEligibility(epoch types.EpochID) (*miner.EpochEligibility, error) {
+	if epoch > 1 {
+		return &miner.EpochEligibility{Epoch: epoch}, nil
+	}
+	return &miner.EpochEligibility{
+		Epoch:         epoch,
+		AtxID:         types.ATXID(types.HexToHash32("55555")),
+		ActiveSetSize: 10,
+		NumBlocks:     3,
+		BlockLayers:   []miner.LayerEligibility{{Layer: 12, NumBlocks: 2}, {Layer: 14, NumBlocks: 1}},
+		HareLayers:    []types.LayerID{13},
+	}, nil
+}
+
+type MalfeasanceMock struct {
+	proofs map[string]*types.MalfeasanceProof
+}
+
+func (m MalfeasanceMock) GetMalfeasanceProof(nodeID types.NodeID) (*types.MalfeasanceProof, error) {
+	if proof, ok := m.proofs[nodeID.Key]; ok {
+		return proof, nil
+	}
+	return nil, database.ErrNotFound
+}
+
+type ProjectorMock struct {
+	nonces   map[types.Address]uint64
+	balances map[types.Address]uint64
+}
+
+func (p ProjectorMock) GetProjection(addr types.Address) (nonce, balance uint64, err error) {
+	return p.nonces[addr], p.balances[addr], nil
+}
+
+type LayerInternalsMock struct {
+	layers      map[types.LayerID]*types.Layer
+	hareOutputs map[types.LayerID][]types.BlockID
+	validity    map[types.BlockID]bool
+	opinions    map[types.LayerID]map[types.BlockID]tortoise.BlockOpinion
+	verified    types.LayerID
+}
+
+func (m LayerInternalsMock) GetLayer(i types.LayerID) (*types.Layer, error) {
+	if l, ok := m.layers[i]; ok {
+		return l, nil
+	}
+	return nil, database.ErrNotFound
+}
+
+func (m LayerInternalsMock) GetLayerInputVector(layer types.LayerID) ([]types.BlockID, error) {
+	if ids, ok := m.hareOutputs[layer]; ok {
+		return ids, nil
+	}
+	return nil, database.ErrNotFound
+}
+
+func (m LayerInternalsMock) ContextualValidity(id types.BlockID) (bool, error) {
+	if valid, ok := m.validity[id]; ok {
+		return valid, nil
+	}
+	return false, database.ErrNotFound
+}
+
+func (m LayerInternalsMock) LatestComplete() types.LayerID {
+	return m.verified
+}
+
+func (m LayerInternalsMock) LayerOpinion(layer types.LayerID) map[types.BlockID]tortoise.BlockOpinion {
+	return m.opinions[layer]
+}
+
+type NetworkInfoMock struct {
+	info p2p.NetworkInfo
+}
+
+func (m NetworkInfoMock) NetworkInfo() p2p.NetworkInfo {
+	return m.info
+}
+
+type PeerBanMock struct {
+	bans []p2p.Ban
+}
+
+func (m *PeerBanMock) BanPeer(peer p2pcrypto.PublicKey, duration time.Duration) error {
+	m.bans = append(m.bans, p2p.Ban{Peer: peer, Created: time.Unix(1600000000, 0), Expires: time.Unix(1600000000, 0).Add(duration)})
+	return nil
+}
+
+func (m *PeerBanMock) BanIP(ip net.IP, duration time.Duration) error {
+	m.bans = append(m.bans, p2p.Ban{IP: ip, Created: time.Unix(1600000000, 0)})
+	return nil
+}
+
+func (m *PeerBanMock) UnbanPeer(peer p2pcrypto.PublicKey) (bool, error) {
+	for i, ban := range m.bans {
+		if ban.Peer != nil && ban.Peer.String() == peer.String() {
+			m.bans = append(m.bans[:i], m.bans[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *PeerBanMock) UnbanIP(ip net.IP) (bool, error) {
+	for i, ban := range m.bans {
+		if ip.Equal(ban.IP) {
+			m.bans = append(m.bans[:i], m.bans[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *PeerBanMock) Bans() []p2p.Ban {
+	return m.bans
+}
+
+type ActivationMock struct {
+	atxs map[types.EpochID][]*types.ActivationTx
+}
+
+func (m ActivationMock) GetEpochAtxs(epochID types.EpochID) (ids []types.ATXID) {
+	for _, atx := range m.atxs[epochID] {
+		ids = append(ids, atx.ID())
+	}
+	return ids
+}
+
+func (m ActivationMock) GetFullAtx(id types.ATXID) (*types.ActivationTx, error) {
+	for _, atxs := range m.atxs {
+		for _, atx := range atxs {
+			if atx.ID() == id {
+				return atx, nil
+			}
+		}
+	}
+	return nil, database.ErrNotFound
+}
+
 type PostMock struct {
 }
 
@@ -216,23 +527,46 @@ func (PostMock) Reset() error {
 const (
 	genTimeUnix      = 1000000
 	layerDuration    = 10
+	layersPerEpoch   = 5
+	networkID        = 120
+	layerAvgSize     = 10
+	txsPerBlock      = 99
 	ValidatedLayerID = 8
 	TxReturnLayer    = 1
 )
 
 var (
-	ap          = NewNodeAPIMock()
-	networkMock = NetworkMock{}
-	mining      = MiningAPIMock{}
-	oracle      = OracleMock{}
-	genTime     = GenesisTimeMock{time.Unix(genTimeUnix, 0)}
-	txMempool   = state.NewTxMemPool()
-	txAPI       = &TxAPIMock{
+	ap              = NewNodeAPIMock()
+	networkMock     = NetworkMock{}
+	mining          = MiningAPIMock{}
+	oracle          = OracleMock{}
+	genTime         = GenesisTimeMock{time.Unix(genTimeUnix, 0)}
+	txMempool       = state.NewTxMemPool()
+	receiptStore    = state.NewReceiptStore(database.NewMemDatabase())
+	globalAtx       = types.NewActivationTx(types.NIPSTChallenge{NodeID: types.NodeID{Key: "11111"}, PubLayerID: 7}, types.HexToAddress("22222"), nil, nil)
+	globalTx        = newTx(1, types.HexToAddress("33333"), types.HexToAddress("44444"), 100)
+	blockSigner     = signing.NewEdSigner() // signs the blocks of the mesh mock, so they read the same every time
+	malfeasanceMock = MalfeasanceMock{proofs: map[string]*types.MalfeasanceProof{
+		"5555": {NodeID: types.NodeID{Key: "5555"}, Layer: 7, Type: types.MultipleAtxs, Messages: [][]byte{{1}, {2}}},
+	}}
+	txAPI = &TxAPIMock{
 		returnTx:     make(map[types.TransactionID]*types.Transaction),
 		layerApplied: make(map[types.TransactionID]*types.LayerID),
 	}
 )
 
+func newTx(nonce uint64, origin, recipient types.Address, amount uint64) *types.Transaction {
+	tx := &types.Transaction{InnerTransaction: types.InnerTransaction{
+		AccountNonce: nonce,
+		Recipient:    recipient,
+		Amount:       amount,
+		GasLimit:     10,
+		Fee:          1,
+	}}
+	tx.SetOrigin(origin)
+	return tx
+}
+
 func marshalProto(t *testing.T, msg proto.Message) string {
 	var buf bytes.Buffer
 	var m jsonpb.Marshaler
@@ -249,6 +583,27 @@ type SyncerMock struct {
 func (SyncerMock) IsSynced() bool { return false }
 func (s *SyncerMock) Start()      { s.startCalled = true }
 
+type SyncProgressMock struct {
+	SyncerMock
+	mu       sync.Mutex
+	progress spacesync.Progress
+	frontier mesh.SyncFrontier
+}
+
+func (s *SyncProgressMock) SyncFrontier() mesh.SyncFrontier { return s.frontier }
+
+func (s *SyncProgressMock) SyncProgress() spacesync.Progress {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.progress
+}
+
+func (s *SyncProgressMock) setProgress(p spacesync.Progress) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.progress = p
+}
+
 func launchServer(t *testing.T, services ...ServiceAPI) func() {
 	networkMock.Broadcast("", []byte{0x00})
 	grpcService := NewServer(cfg.NewGrpcServerPort)
@@ -261,7 +616,7 @@ func launchServer(t *testing.T, services ...ServiceAPI) func() {
 
 	// start gRPC and json servers
 	grpcService.Start()
-	jsonService.StartService(cfg.StartNodeService, cfg.StartMeshService)
+	jsonService.StartService(cfg)
 	time.Sleep(3 * time.Second) // wait for server to be ready (critical on Travis)
 
 	return func() {
@@ -389,7 +744,7 @@ func TestNodeService(t *testing.T) {
 }
 
 func TestMeshService(t *testing.T) {
-	grpcService := NewMeshService(&networkMock, txAPI, &genTime, &SyncerMock{})
+	grpcService := NewMeshService(&networkMock, txAPI, &genTime, &SyncerMock{}, malfeasanceMock, layersPerEpoch, networkID, layerDuration, layerAvgSize, txsPerBlock)
 	shutDown := launchServer(t, grpcService)
 	defer shutDown()
 
@@ -408,12 +763,31 @@ func TestMeshService(t *testing.T) {
 	response, err := c.GenesisTime(context.Background(), &pb.GenesisTimeRequest{})
 	require.NoError(t, err)
 	require.Equal(t, uint64(genTime.GetGenesisTime().Unix()), response.Unixtime.Value)
+
+	types.SetLayersPerEpoch(layersPerEpoch)
+	currentLayer, err := c.CurrentLayer(context.Background(), &pb.CurrentLayerRequest{})
+	require.NoError(t, err)
+	require.Equal(t, uint64(12), currentLayer.Layernum.Value)
+	currentEpoch, err := c.CurrentEpoch(context.Background(), &pb.CurrentEpochRequest{})
+	require.NoError(t, err)
+	require.Equal(t, uint64(12/layersPerEpoch), currentEpoch.Epochnum.Value)
+	netID, err := c.NetID(context.Background(), &pb.NetIDRequest{})
+	require.NoError(t, err)
+	require.Equal(t, uint64(networkID), netID.Netid.Value)
+	numLayers, err := c.EpochNumLayers(context.Background(), &pb.EpochNumLayersRequest{})
+	require.NoError(t, err)
+	require.Equal(t, uint64(layersPerEpoch), numLayers.Numlayers.Value)
+	duration, err := c.LayerDuration(context.Background(), &pb.LayerDurationRequest{})
+	require.NoError(t, err)
+	require.Equal(t, uint64(layerDuration), duration.Duration.Value)
+	maxTx, err := c.MaxTransactionsPerSecond(context.Background(), &pb.MaxTransactionsPerSecondRequest{})
+	require.NoError(t, err)
+	require.Equal(t, uint64(layerAvgSize*txsPerBlock/layerDuration), maxTx.Maxtxpersecond.Value)
 }
 
-func TestMultiService(t *testing.T) {
-	svc1 := NewNodeService(&networkMock, txAPI, &genTime, &SyncerMock{})
-	svc2 := NewMeshService(&networkMock, txAPI, &genTime, &SyncerMock{})
-	shutDown := launchServer(t, svc1, svc2)
+func TestMeshService_PagedLayersQuery(t *testing.T) {
+	grpcService := NewMeshService(&networkMock, txAPI, &genTime, &SyncerMock{}, malfeasanceMock, layersPerEpoch, networkID, layerDuration, layerAvgSize, txsPerBlock)
+	shutDown := launchServer(t, grpcService)
 	defer shutDown()
 
 	// start a client
@@ -425,71 +799,3026 @@ func TestMultiService(t *testing.T) {
 	defer func() {
 		require.NoError(t, conn.Close())
 	}()
-	c1 := pb.NewNodeServiceClient(conn)
-	c2 := pb.NewMeshServiceClient(conn)
+	c := extpb.NewMeshServiceClient(conn)
 
-	// call endpoints and validate results
-	const message = "Hello World"
-	res1, err1 := c1.Echo(context.Background(), &pb.EchoRequest{
-		Msg: &pb.SimpleString{Value: message}})
-	require.NoError(t, err1)
-	require.Equal(t, message, res1.Msg.Value)
-	res2, err2 := c2.GenesisTime(context.Background(), &pb.GenesisTimeRequest{})
-	require.NoError(t, err2)
-	require.Equal(t, uint64(genTime.GetGenesisTime().Unix()), res2.Unixtime.Value)
+	testCases := []struct {
+		name string
+		run  func(*testing.T)
+	}{
+		{"start after end", func(t *testing.T) {
+			_, err := c.PagedLayersQuery(context.Background(), &extpb.PagedLayersQueryRequest{StartLayer: 5, EndLayer: 4})
+			require.Error(t, err)
+			require.Equal(t, codes.InvalidArgument, status.Code(err))
+		}},
+		{"bad page token", func(t *testing.T) {
+			_, err := c.PagedLayersQuery(context.Background(), &extpb.PagedLayersQueryRequest{PageToken: "not a token"})
+			require.Error(t, err)
+			require.Equal(t, codes.InvalidArgument, status.Code(err))
+		}},
+		{"without txs and atxs", func(t *testing.T) {
+			res, err := c.PagedLayersQuery(context.Background(), &extpb.PagedLayersQueryRequest{StartLayer: 7, EndLayer: 9})
+			require.NoError(t, err)
+			require.Len(t, res.Layers, 3)
+			require.Empty(t, res.NextPageToken)
+			require.Equal(t, extpb.Layer_LAYER_STATUS_CONFIRMED, res.Layers[0].Status)
+			require.Equal(t, extpb.Layer_LAYER_STATUS_CONFIRMED, res.Layers[1].Status)
+			require.Equal(t, extpb.Layer_LAYER_STATUS_APPROVED, res.Layers[2].Status)
+			for i, l := range res.Layers {
+				require.Equal(t, uint64(7+i), l.Number)
+				require.Len(t, l.Blocks, 1)
+				require.Equal(t, globalAtx.ID().Bytes(), l.Blocks[0].AtxId)
+				require.Equal(t, [][]byte{globalTx.ID().Bytes()}, l.Blocks[0].TransactionIds)
+				require.Empty(t, l.Blocks[0].Transactions)
+				require.Empty(t, l.Activations)
+			}
+		}},
+		{"with txs and atxs", func(t *testing.T) {
+			res, err := c.PagedLayersQuery(context.Background(), &extpb.PagedLayersQueryRequest{
+				StartLayer:          10,
+				IncludeTransactions: true,
+				IncludeActivations:  true,
+			})
+			require.NoError(t, err)
+			require.Len(t, res.Layers, 1)
+			l := res.Layers[0]
+			require.Equal(t, extpb.Layer_LAYER_STATUS_UNSPECIFIED, l.Status)
+			require.Len(t, l.Blocks[0].Transactions, 1)
+			tx := l.Blocks[0].Transactions[0]
+			require.Equal(t, globalTx.ID().Bytes(), tx.Id)
+			require.Equal(t, globalTx.Origin().Bytes(), tx.Sender)
+			require.Equal(t, globalTx.Recipient.Bytes(), tx.Recipient)
+			require.Equal(t, globalTx.Amount, tx.Amount)
+			require.Len(t, l.Activations, 1)
+			atx := l.Activations[0]
+			require.Equal(t, globalAtx.ID().Bytes(), atx.Id)
+			require.Equal(t, uint64(7), atx.Layer)
+			require.Equal(t, globalAtx.Coinbase.Bytes(), atx.Coinbase)
+		}},
+		{"pages", func(t *testing.T) {
+			req := &extpb.PagedLayersQueryRequest{StartLayer: 1, PageSize: 4}
+			var layers []uint64
+			for pages := 0; pages < 10; pages++ {
+				res, err := c.PagedLayersQuery(context.Background(), req)
+				require.NoError(t, err)
+				require.True(t, len(res.Layers) <= 4)
+				for _, l := range res.Layers {
+					layers = append(layers, l.Number)
+				}
+				if res.NextPageToken == "" {
+					break
+				}
+				req.PageToken = res.NextPageToken
+			}
+			require.Equal(t, []uint64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, layers)
+		}},
+		{"bad field mask", func(t *testing.T) {
+			for _, path := range []string{"layers.unknown", "layers.number.value", ""} {
+				_, err := c.PagedLayersQuery(context.Background(), &extpb.PagedLayersQueryRequest{
+					FieldMask: &fieldmaskpb.FieldMask{Paths: []string{path}},
+				})
+				require.Equal(t, codes.InvalidArgument, status.Code(err), path)
+			}
+		}},
+		{"field mask", func(t *testing.T) {
+			res, err := c.PagedLayersQuery(context.Background(), &extpb.PagedLayersQueryRequest{
+				StartLayer:          8,
+				PageSize:            2,
+				IncludeTransactions: true,
+				FieldMask:           &fieldmaskpb.FieldMask{Paths: []string{"layers.number", "layers.hash", "layers.blocks.id"}},
+			})
+			require.NoError(t, err)
+			require.Empty(t, res.NextPageToken)
+			require.Len(t, res.Layers, 2)
+			for i, l := range res.Layers {
+				require.Equal(t, uint64(8+i), l.Number)
+				require.NotEmpty(t, l.Hash)
+				require.Equal(t, extpb.Layer_LAYER_STATUS_UNSPECIFIED, l.Status)
+				require.Len(t, l.Blocks, 1)
+				require.NotEmpty(t, l.Blocks[0].Id)
+				require.Empty(t, l.Blocks[0].AtxId)
+				require.Empty(t, l.Blocks[0].TransactionIds)
+				require.Empty(t, l.Blocks[0].Transactions)
+			}
+
+			// a shorter path selects the whole field
+			res, err = c.PagedLayersQuery(context.Background(), &extpb.PagedLayersQueryRequest{
+				StartLayer: 8,
+				PageSize:   1,
+				FieldMask:  &fieldmaskpb.FieldMask{Paths: []string{"layers.blocks.id", "layers", "next_page_token"}},
+			})
+			require.NoError(t, err)
+			require.NotEmpty(t, res.NextPageToken)
+			require.Equal(t, extpb.Layer_LAYER_STATUS_CONFIRMED, res.Layers[0].Status)
+			require.NotEmpty(t, res.Layers[0].Blocks[0].AtxId)
+		}},
+	}
 
-	// Make sure that shutting down the grpc service shuts them both down
-	shutDown()
+	for _, tc := range testCases {
+		t.Run(tc.name, tc.run)
+	}
+}
 
-	// Make sure NodeService is off
-	res1, err1 = c1.Echo(context.Background(), &pb.EchoRequest{
-		Msg: &pb.SimpleString{Value: message}})
-	require.Error(t, err1)
-	require.Contains(t, err1.Error(), "rpc error: code = Unavailable")
+func TestMeshService_LayersQuery(t *testing.T) {
+	grpcService := NewMeshService(&networkMock, txAPI, &genTime, &SyncerMock{}, malfeasanceMock, layersPerEpoch, networkID, layerDuration, layerAvgSize, txsPerBlock)
+	shutDown := launchServer(t, grpcService)
+	defer shutDown()
 
-	// Make sure MeshService is off
-	res2, err2 = c2.GenesisTime(context.Background(), &pb.GenesisTimeRequest{})
-	require.Error(t, err2)
-	require.Contains(t, err2.Error(), "rpc error: code = Unavailable")
+	// start a client
+	addr := "localhost:" + strconv.Itoa(cfg.NewGrpcServerPort)
+
+	// Set up a connection to the server.
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, conn.Close())
+	}()
+	c := pb.NewMeshServiceClient(conn)
+
+	_, err = c.LayersQuery(context.Background(), &pb.LayersQueryRequest{StartLayer: 5, EndLayer: 4})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	res, err := c.LayersQuery(context.Background(), &pb.LayersQueryRequest{StartLayer: 8, EndLayer: 10})
+	require.NoError(t, err)
+	require.Len(t, res.Layer, 3)
+	require.Equal(t, pb.Layer_LAYER_STATUS_CONFIRMED, res.Layer[0].Status)
+	require.Equal(t, pb.Layer_LAYER_STATUS_APPROVED, res.Layer[1].Status)
+	require.Equal(t, pb.Layer_LAYER_STATUS_UNSPECIFIED, res.Layer[2].Status)
+	for i, l := range res.Layer {
+		require.Equal(t, uint64(8+i), l.Number)
+		require.Len(t, l.Blocks, 1)
+		require.Len(t, l.Blocks[0].Transactions, 1)
+		tx := l.Blocks[0].Transactions[0]
+		require.Equal(t, globalTx.ID().Bytes(), tx.Id.Id)
+		require.Equal(t, globalTx.Origin().Bytes(), tx.Sender.Address)
+		require.Equal(t, globalTx.Recipient.Bytes(), tx.GetCoinTransfer().Receiver.Address)
+		require.Equal(t, globalTx.Amount, tx.Amount.Value)
+		require.Len(t, l.Activations, 1)
+		require.Equal(t, globalAtx.ID().Bytes(), l.Activations[0].Id.Id)
+		require.Equal(t, globalAtx.Coinbase.Bytes(), l.Activations[0].Coinbase.Address)
+	}
 }
 
-func TestJsonApi(t *testing.T) {
-	const message = "hello world!"
+func TestMeshService_BlockQuery(t *testing.T) {
+	grpcService := NewMeshService(&networkMock, txAPI, &genTime, &SyncerMock{}, malfeasanceMock, layersPerEpoch, networkID, layerDuration, layerAvgSize, txsPerBlock)
+	shutDown := launchServer(t, grpcService)
+	defer shutDown()
 
-	// we cannot start the gateway service without enabling at least one service
-	require.Equal(t, cfg.StartNodeService, false)
-	require.Equal(t, cfg.StartMeshService, false)
-	shutDown := launchServer(t)
-	payload := marshalProto(t, &pb.EchoRequest{Msg: &pb.SimpleString{Value: message}})
-	url := fmt.Sprintf("http://127.0.0.1:%d/%s", cfg.NewJSONServerPort, "v1/node/echo")
-	t.Log("sending POST request to", url)
-	_, err := http.Post(url, "application/json", strings.NewReader(payload))
-	require.Error(t, err)
-	require.Contains(t, err.Error(), fmt.Sprintf(
-		"dial tcp 127.0.0.1:%d: connect: connection refused",
-		cfg.NewJSONServerPort))
-	shutDown()
+	// start a client
+	addr := "localhost:" + strconv.Itoa(cfg.NewGrpcServerPort)
 
-	// enable services and try again
-	svc1 := NewNodeService(&networkMock, txAPI, &genTime, &SyncerMock{})
-	svc2 := NewMeshService(&networkMock, txAPI, &genTime, &SyncerMock{})
-	cfg.StartNodeService = true
-	cfg.StartMeshService = true
-	shutDown = launchServer(t, svc1, svc2)
+	// Set up a connection to the server.
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, conn.Close())
+	}()
+	c := extpb.NewMeshServiceClient(conn)
+
+	layer, err := txAPI.GetLayer(8)
+	require.NoError(t, err)
+	block := layer.Blocks()[0]
+
+	_, err = c.BlockQuery(context.Background(), &extpb.BlockQueryRequest{})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = c.BlockQuery(context.Background(), &extpb.BlockQueryRequest{Id: types.HexToHash32("1234").Bytes()})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	res, err := c.BlockQuery(context.Background(), &extpb.BlockQueryRequest{Id: block.ID().Bytes()})
+	require.NoError(t, err)
+	require.Equal(t, block.ID().Bytes(), res.Block.Id)
+	require.Equal(t, uint64(8), res.Block.Layer)
+	require.Equal(t, block.Timestamp, res.Block.Timestamp)
+	require.Equal(t, globalAtx.ID().Bytes(), res.Block.AtxId)
+	require.Equal(t, [][]byte{globalTx.ID().Bytes()}, res.Block.TransactionIds)
+	require.Empty(t, res.Block.Transactions)
+	require.Equal(t, extpb.Layer_LAYER_STATUS_CONFIRMED, res.LayerStatus)
+
+	res, err = c.BlockQuery(context.Background(), &extpb.BlockQueryRequest{Id: block.ID().Bytes(), IncludeTransactions: true})
+	require.NoError(t, err)
+	require.Len(t, res.Block.Transactions, 1)
+	require.Equal(t, globalTx.ID().Bytes(), res.Block.Transactions[0].Id)
+
+	res, err = c.BlockQuery(context.Background(), &extpb.BlockQueryRequest{
+		Id:                  block.ID().Bytes(),
+		IncludeTransactions: true,
+		FieldMask:           &fieldmaskpb.FieldMask{Paths: []string{"block.transaction_ids"}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{globalTx.ID().Bytes()}, res.Block.TransactionIds)
+	require.Empty(t, res.Block.Id)
+	require.Empty(t, res.Block.Transactions)
+	require.Equal(t, extpb.Layer_LAYER_STATUS_UNSPECIFIED, res.LayerStatus)
+}
+
+func TestMeshService_LayerStream(t *testing.T) {
+	grpcService := NewMeshService(&networkMock, txAPI, &genTime, &SyncerMock{}, malfeasanceMock, layersPerEpoch, networkID, layerDuration, layerAvgSize, txsPerBlock)
+	shutDown := launchServer(t, grpcService)
 	defer shutDown()
 
-	// generate request payload (api input params)
-	payload = marshalProto(t, &pb.EchoRequest{Msg: &pb.SimpleString{Value: message}})
-	respBody, respStatus := callEndpoint(t, "v1/node/echo", payload)
-	require.Equal(t, http.StatusOK, respStatus)
-	var msg pb.EchoResponse
-	require.NoError(t, jsonpb.UnmarshalString(respBody, &msg))
-	require.Equal(t, message, msg.Msg.Value)
+	// start a client
+	addr := "localhost:" + strconv.Itoa(cfg.NewGrpcServerPort)
 
-	// Test MeshService
-	respBody2, respStatus2 := callEndpoint(t, "v1/mesh/genesistime", "")
-	require.Equal(t, http.StatusOK, respStatus2)
-	var msg2 pb.GenesisTimeResponse
-	require.NoError(t, jsonpb.UnmarshalString(respBody2, &msg2))
-	require.Equal(t, uint64(genTime.GetGenesisTime().Unix()), msg2.Unixtime.Value)
+	// Set up a connection to the server.
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, conn.Close())
+	}()
+	c := pb.NewMeshServiceClient(conn)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := c.LayerStream(ctx, &pb.LayerStreamRequest{})
+	require.NoError(t, err)
+	time.Sleep(time.Second) // wait for the server to subscribe
+
+	blocks := []types.BlockID{{1}, {2}}
+	var root types.Hash32
+	root.SetBytes([]byte("11111"))
+	events.Publish(events.LayerUpdate{LayerID: 11, Status: events.LayerStatusCreated})
+	events.Publish(events.LayerUpdate{LayerID: 11, Status: events.LayerStatusApproved, Blocks: blocks})
+	events.Publish(events.LayerUpdate{LayerID: 11, Status: events.LayerStatusConfirmed, Blocks: blocks, StateRoot: root})
+
+	res, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, uint64(11), res.Layer.Number)
+	require.Equal(t, pb.Layer_LAYER_STATUS_UNSPECIFIED, res.Layer.Status)
+	require.Empty(t, res.Layer.Blocks)
+
+	res, err = stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, pb.Layer_LAYER_STATUS_APPROVED, res.Layer.Status)
+	require.Equal(t, types.CalcBlocksHash32(blocks, nil).Bytes(), res.Layer.Hash)
+	require.Len(t, res.Layer.Blocks, 2)
+	require.Equal(t, blocks[0].Bytes(), res.Layer.Blocks[0].Id)
+	require.Empty(t, res.Layer.RootStateHash)
+
+	res, err = stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, pb.Layer_LAYER_STATUS_CONFIRMED, res.Layer.Status)
+	require.Equal(t, root.Bytes(), res.Layer.RootStateHash)
+}
+
+func TestMeshService_AccountMeshDataStream(t *testing.T) {
+	grpcService := NewMeshService(&networkMock, txAPI, &genTime, &SyncerMock{}, malfeasanceMock, layersPerEpoch, networkID, layerDuration, layerAvgSize, txsPerBlock)
+	shutDown := launchServer(t, grpcService)
+	defer shutDown()
+
+	// start a client
+	addr := "localhost:" + strconv.Itoa(cfg.NewGrpcServerPort)
+
+	// Set up a connection to the server.
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, conn.Close())
+	}()
+	c := pb.NewMeshServiceClient(conn)
+
+	openStream := func(t *testing.T, filter *pb.AccountMeshDataFilter) (pb.MeshService_AccountMeshDataStreamClient, func()) {
+		ctx, cancel := context.WithCancel(context.Background())
+		stream, err := c.AccountMeshDataStream(ctx, &pb.AccountMeshDataStreamRequest{Filter: filter})
+		require.NoError(t, err)
+		return stream, cancel
+	}
+
+	testCases := []struct {
+		name string
+		run  func(*testing.T)
+	}{
+		{"missing filter", func(t *testing.T) {
+			stream, cancel := openStream(t, nil)
+			defer cancel()
+			_, err := stream.Recv()
+			require.Equal(t, codes.InvalidArgument, status.Code(err))
+		}},
+		{"missing flags", func(t *testing.T) {
+			stream, cancel := openStream(t, &pb.AccountMeshDataFilter{AccountId: &pb.AccountId{Address: globalTx.Recipient.Bytes()}})
+			defer cancel()
+			_, err := stream.Recv()
+			require.Equal(t, codes.InvalidArgument, status.Code(err))
+		}},
+		{"transactions and activations", func(t *testing.T) {
+			// the coinbase of globalAtx is the sender of the second tx
+			stream, cancel := openStream(t, &pb.AccountMeshDataFilter{
+				AccountId: &pb.AccountId{Address: globalAtx.Coinbase.Bytes()},
+				AccountMeshDataFlags: uint32(pb.AccountMeshDataFlag_ACCOUNT_MESH_DATA_FLAG_TRANSACTIONS |
+					pb.AccountMeshDataFlag_ACCOUNT_MESH_DATA_FLAG_ACTIVATIONS),
+			})
+			defer cancel()
+			time.Sleep(time.Second) // wait for the server to subscribe
+
+			ownTx := newTx(2, globalAtx.Coinbase, globalTx.Recipient, 5)
+			events.Publish(events.TxInMesh{Transaction: globalTx, LayerID: 3})
+			events.Publish(events.TxInMesh{Transaction: ownTx, LayerID: 3})
+			events.Publish(events.AtxInMesh{Atx: globalAtx})
+
+			// transactions and activations are delivered independently of each other
+			var tx *pb.Transaction
+			var atx *pb.Activation
+			for i := 0; i < 2; i++ {
+				res, err := stream.Recv()
+				require.NoError(t, err)
+				if res.Data.GetTransaction() != nil {
+					tx = res.Data.GetTransaction()
+				} else {
+					atx = res.Data.GetActivation()
+				}
+			}
+			require.NotNil(t, tx)
+			require.Equal(t, ownTx.ID().Bytes(), tx.Id.Id)
+			require.Equal(t, globalAtx.Coinbase.Bytes(), tx.Sender.Address)
+			require.Equal(t, globalTx.Recipient.Bytes(), tx.GetCoinTransfer().Receiver.Address)
+			require.Equal(t, uint64(5), tx.Amount.Value)
+			require.Equal(t, uint64(2), tx.Counter)
+			require.NotNil(t, atx)
+			require.Equal(t, globalAtx.ID().Bytes(), atx.Id.Id)
+			require.Equal(t, uint64(7), atx.Layer)
+		}},
+		{"activations only", func(t *testing.T) {
+			stream, cancel := openStream(t, &pb.AccountMeshDataFilter{
+				AccountId:            &pb.AccountId{Address: globalAtx.Coinbase.Bytes()},
+				AccountMeshDataFlags: uint32(pb.AccountMeshDataFlag_ACCOUNT_MESH_DATA_FLAG_ACTIVATIONS),
+			})
+			defer cancel()
+			time.Sleep(time.Second) // wait for the server to subscribe
+
+			events.Publish(events.TxInMesh{Transaction: newTx(3, globalAtx.Coinbase, globalTx.Recipient, 5), LayerID: 3})
+			events.Publish(events.AtxInMesh{Atx: globalAtx})
+
+			res, err := stream.Recv()
+			require.NoError(t, err)
+			require.Nil(t, res.Data.GetTransaction())
+			require.Equal(t, globalAtx.ID().Bytes(), res.Data.GetActivation().Id.Id)
+		}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, tc.run)
+	}
+}
+
+func TestMeshService_Malfeasance(t *testing.T) {
+	grpcService := NewMeshService(&networkMock, txAPI, &genTime, &SyncerMock{}, malfeasanceMock, layersPerEpoch, networkID, layerDuration, layerAvgSize, txsPerBlock)
+	shutDown := launchServer(t, grpcService)
+	defer shutDown()
+
+	// start a client
+	addr := "localhost:" + strconv.Itoa(cfg.NewGrpcServerPort)
+
+	// Set up a connection to the server.
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, conn.Close())
+	}()
+	c := extpb.NewMeshServiceClient(conn)
+
+	_, err = c.MalfeasanceQuery(context.Background(), &extpb.MalfeasanceQueryRequest{})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	res, err := c.MalfeasanceQuery(context.Background(), &extpb.MalfeasanceQueryRequest{SmesherId: []byte{0x55, 0x55}})
+	require.NoError(t, err)
+	require.NotNil(t, res.Proof)
+	require.Equal(t, []byte{0x55, 0x55}, res.Proof.SmesherId)
+	require.Equal(t, uint64(7), res.Proof.Layer)
+	require.Equal(t, extpb.MalfeasanceProof_MALFEASANCE_TYPE_MULTIPLE_ATXS, res.Proof.Type)
+	require.Equal(t, [][]byte{{1}, {2}}, res.Proof.Messages)
+
+	res, err = c.MalfeasanceQuery(context.Background(), &extpb.MalfeasanceQueryRequest{SmesherId: []byte{0x66}})
+	require.NoError(t, err)
+	require.Nil(t, res.Proof)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := c.MalfeasanceStream(ctx, &extpb.MalfeasanceStreamRequest{})
+	require.NoError(t, err)
+	time.Sleep(time.Second) // wait for the server to subscribe
+
+	events.Publish(events.MalfeasanceDetected{Proof: &types.MalfeasanceProof{NodeID: types.NodeID{Key: "6666"}, Layer: 9, Type: types.MultipleAtxs}})
+	streamed, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x66, 0x66}, streamed.Proof.SmesherId)
+	require.Equal(t, uint64(9), streamed.Proof.Layer)
+}
+
+func TestMeshService_EpochStream(t *testing.T) {
+	grpcService := NewMeshService(&networkMock, txAPI, &genTime, &SyncerMock{}, malfeasanceMock, layersPerEpoch, networkID, layerDuration, layerAvgSize, txsPerBlock)
+	shutDown := launchServer(t, grpcService)
+	defer shutDown()
+
+	// start a client
+	addr := "localhost:" + strconv.Itoa(cfg.NewGrpcServerPort)
+
+	// Set up a connection to the server.
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, conn.Close())
+	}()
+	c := extpb.NewMeshServiceClient(conn)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := c.EpochStream(ctx, &extpb.EpochStreamRequest{})
+	require.NoError(t, err)
+	time.Sleep(time.Second) // wait for the server to subscribe
+
+	events.Publish(events.EpochUpdate{Epoch: 3, TotalWeight: 3072, ActiveSmeshers: 3, Beacon: []byte{3, 0, 0, 0}})
+	res, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), res.Epoch)
+	require.Equal(t, uint64(3072), res.TotalWeight)
+	require.Equal(t, uint64(3), res.ActiveSmeshers)
+	require.Equal(t, []byte{3, 0, 0, 0}, res.Beacon)
+}
+
+func TestSmesherService(t *testing.T) {
+	types.SetLayersPerEpoch(10)
+	grpcService := NewSmesherService(&genTime, EligibilityMock{})
+	shutDown := launchServer(t, grpcService)
+	defer shutDown()
+
+	// start a client
+	addr := "localhost:" + strconv.Itoa(cfg.NewGrpcServerPort)
+
+	// Set up a connection to the server.
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, conn.Close())
+	}()
+	c := extpb.NewSmesherServiceClient(conn)
+
+	// current layer is 12, i.e. epoch 1
+	res, err := c.EligibilityReport(context.Background(), &extpb.EligibilityReportRequest{})
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), res.Current.Epoch)
+	require.Equal(t, uint32(3), res.Current.NumBlocks)
+	require.Equal(t, uint32(10), res.Current.ActiveSetSize)
+	require.Equal(t, types.HexToHash32("55555").Bytes(), res.Current.AtxId)
+	require.Len(t, res.Current.BlockLayers, 2)
+	require.Equal(t, uint64(12), res.Current.BlockLayers[0].Layer)
+	require.Equal(t, uint32(2), res.Current.BlockLayers[0].NumBlocks)
+	require.Equal(t, []uint64{13}, res.Current.HareLayers)
+
+	// no atx for the next epoch yet
+	require.Equal(t, uint64(2), res.Next.Epoch)
+	require.Equal(t, uint32(0), res.Next.NumBlocks)
+	require.Empty(t, res.Next.AtxId)
+	require.Empty(t, res.Next.BlockLayers)
+}
+
+func TestTransactionService_SubmitTransaction(t *testing.T) {
+	signer := signing.NewEdSigner()
+	origin := types.BytesToAddress(signer.PublicKey().Bytes())
+	projector := ProjectorMock{
+		nonces:   map[types.Address]uint64{origin: 3},
+		balances: map[types.Address]uint64{origin: 100},
+	}
+	grpcService := NewTransactionService(&networkMock, txAPI, txMempool, projector, receiptStore, 2, layerAvgSize*txsPerBlock)
+	shutDown := launchServer(t, grpcService)
+	defer shutDown()
+
+	// start a client
+	addr := "localhost:" + strconv.Itoa(cfg.NewGrpcServerPort)
+
+	// Set up a connection to the server.
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, conn.Close())
+	}()
+	c := extpb.NewTransactionServiceClient(conn)
+	c1 := pb.NewTransactionServiceClient(conn)
+
+	serialize := func(nonce, amount, fee uint64) []byte {
+		tx, err := mesh.NewSignedTx(nonce, types.HexToAddress("33333"), amount, 10, fee, signer)
+		require.NoError(t, err)
+		buf, err := types.InterfaceToBytes(tx)
+		require.NoError(t, err)
+		return buf
+	}
+
+	_, err = c.SubmitTransactionWithOptions(context.Background(), &extpb.SubmitTransactionWithOptionsRequest{})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	res, err := c.SubmitTransactionWithOptions(context.Background(), &extpb.SubmitTransactionWithOptionsRequest{Transaction: []byte{1, 2, 3}})
+	require.NoError(t, err)
+	require.Equal(t, extpb.TransactionValidity_TRANSACTION_VALIDITY_MALFORMED, res.Validity)
+	require.Empty(t, res.Id)
+
+	tests := []struct {
+		name               string
+		nonce, amount, fee uint64
+		validity           extpb.TransactionValidity
+	}{
+		{"valid", 3, 90, 10, extpb.TransactionValidity_TRANSACTION_VALIDITY_VALID},
+		{"bad nonce", 2, 90, 10, extpb.TransactionValidity_TRANSACTION_VALIDITY_BAD_NONCE},
+		{"insufficient balance", 3, 91, 10, extpb.TransactionValidity_TRANSACTION_VALIDITY_INSUFFICIENT_BALANCE},
+		{"fee too low", 3, 90, 1, extpb.TransactionValidity_TRANSACTION_VALIDITY_FEE_TOO_LOW},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := c.SubmitTransactionWithOptions(context.Background(), &extpb.SubmitTransactionWithOptionsRequest{
+				Transaction: serialize(tc.nonce, tc.amount, tc.fee),
+				DryRun:      true,
+			})
+			require.NoError(t, err)
+			require.Equal(t, tc.validity, res.Validity, res.Message)
+			require.NotEmpty(t, res.Id)
+			require.Equal(t, uint64(3), res.ProjectedNonce)
+			require.Equal(t, uint64(100), res.ProjectedBalance)
+			require.False(t, res.Broadcast)
+		})
+	}
+
+	// a valid transaction is broadcast unless it's a dry run
+	raw := serialize(3, 50, 5)
+	res, err = c.SubmitTransactionWithOptions(context.Background(), &extpb.SubmitTransactionWithOptionsRequest{Transaction: raw})
+	require.NoError(t, err)
+	require.True(t, res.Broadcast)
+	require.Eventually(t, func() bool { return bytes.Equal(raw, networkMock.GetBroadcast()) }, time.Second, 10*time.Millisecond)
+
+	// the v1 endpoint reports the reason as the transaction state
+	_, err = c1.SubmitTransaction(context.Background(), &pb.SubmitTransactionRequest{Transaction: []byte{1, 2, 3}})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	res1, err := c1.SubmitTransaction(context.Background(), &pb.SubmitTransactionRequest{Transaction: serialize(3, 100, 10)})
+	require.NoError(t, err)
+	require.Equal(t, int32(code.Code_FAILED_PRECONDITION), res1.Status.Code)
+	require.Equal(t, pb.TransactionState_TRANSACTION_STATE_INSUFFICIENT_FUNDS, res1.Txstate.State)
+
+	raw = serialize(3, 60, 5)
+	res1, err = c1.SubmitTransaction(context.Background(), &pb.SubmitTransactionRequest{Transaction: raw})
+	require.NoError(t, err)
+	require.Equal(t, int32(code.Code_OK), res1.Status.Code)
+	require.Equal(t, pb.TransactionState_TRANSACTION_STATE_MEMPOOL, res1.Txstate.State)
+	require.NotEmpty(t, res1.Txstate.Id.Id)
+	require.Eventually(t, func() bool { return bytes.Equal(raw, networkMock.GetBroadcast()) }, time.Second, 10*time.Millisecond)
+}
+
+func TestTransactionService_SubmitTransactionSigner(t *testing.T) {
+	signer := signing.NewEdSigner()
+	origin := types.BytesToAddress(signer.PublicKey().Bytes())
+	unknown := signing.NewEdSigner()
+	unknownOrigin := types.BytesToAddress(unknown.PublicKey().Bytes())
+	projector := ProjectorMock{
+		nonces:   map[types.Address]uint64{origin: 3},
+		balances: map[types.Address]uint64{origin: 100},
+	}
+	tx := &TxAPIMock{missing: map[types.Address]struct{}{unknownOrigin: {}}}
+	grpcService := NewTransactionService(&networkMock, tx, txMempool, projector, receiptStore, 2, layerAvgSize*txsPerBlock)
+	shutDown := launchServer(t, grpcService)
+	defer shutDown()
+
+	// start a client
+	addr := "localhost:" + strconv.Itoa(cfg.NewGrpcServerPort)
+
+	// Set up a connection to the server.
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, conn.Close())
+	}()
+	c := extpb.NewTransactionServiceClient(conn)
+
+	serialize := func(signer *signing.EdSigner, tamper bool) []byte {
+		tx, err := mesh.NewSignedTx(3, types.HexToAddress("33333"), 50, 10, 5, signer)
+		require.NoError(t, err)
+		if tamper {
+			tx.Signature[0] ^= 1
+		}
+		buf, err := types.InterfaceToBytes(tx)
+		require.NoError(t, err)
+		return buf
+	}
+
+	_, err = c.SubmitTransactionWithOptions(context.Background(), &extpb.SubmitTransactionWithOptionsRequest{
+		Transaction: serialize(signer, false),
+		Origin:      []byte{1, 2, 3},
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = c.SubmitTransactions(context.Background(), &extpb.SubmitTransactionsRequest{
+		Transactions: [][]byte{serialize(signer, false), serialize(signer, false)},
+		Origins:      [][]byte{origin.Bytes()},
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// a bad signature recovers a signer without an account, it's told from an unknown signer by the given origin
+	tests := []struct {
+		name     string
+		raw      []byte
+		origin   []byte
+		validity extpb.TransactionValidity
+	}{
+		{"valid", serialize(signer, false), origin.Bytes(), extpb.TransactionValidity_TRANSACTION_VALIDITY_VALID},
+		{"bad signature", serialize(signer, true), origin.Bytes(), extpb.TransactionValidity_TRANSACTION_VALIDITY_INVALID_SIGNATURE},
+		{"other signer", serialize(signer, false), unknownOrigin.Bytes(), extpb.TransactionValidity_TRANSACTION_VALIDITY_INVALID_SIGNATURE},
+		{"unknown signer", serialize(unknown, false), unknownOrigin.Bytes(), extpb.TransactionValidity_TRANSACTION_VALIDITY_UNKNOWN_ORIGIN},
+		{"unknown signer without origin", serialize(unknown, false), nil, extpb.TransactionValidity_TRANSACTION_VALIDITY_INVALID_SIGNATURE},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := c.SubmitTransactionWithOptions(context.Background(), &extpb.SubmitTransactionWithOptionsRequest{
+				Transaction: tc.raw,
+				Origin:      tc.origin,
+				DryRun:      true,
+			})
+			require.NoError(t, err)
+			require.Equal(t, tc.validity, res.Validity, res.Message)
+
+			batch, err := c.SubmitTransactions(context.Background(), &extpb.SubmitTransactionsRequest{
+				Transactions: [][]byte{tc.raw},
+				Origins:      [][]byte{tc.origin},
+				DryRun:       true,
+			})
+			require.NoError(t, err)
+			require.Equal(t, tc.validity, batch.Results[0].Validity, batch.Results[0].Message)
+		})
+	}
+}
+
+func TestTransactionService_SubmitTransactions(t *testing.T) {
+	signer := signing.NewEdSigner()
+	origin := types.BytesToAddress(signer.PublicKey().Bytes())
+	projector := ProjectorMock{
+		nonces:   map[types.Address]uint64{origin: 3},
+		balances: map[types.Address]uint64{origin: 100},
+	}
+	grpcService := NewTransactionService(&networkMock, txAPI, txMempool, projector, receiptStore, 2, layerAvgSize*txsPerBlock)
+	shutDown := launchServer(t, grpcService)
+	defer shutDown()
+
+	// start a client
+	addr := "localhost:" + strconv.Itoa(cfg.NewGrpcServerPort)
+
+	// Set up a connection to the server.
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, conn.Close())
+	}()
+	c := extpb.NewTransactionServiceClient(conn)
+
+	serialize := func(nonce, amount, fee uint64) []byte {
+		tx, err := mesh.NewSignedTx(nonce, types.HexToAddress("33333"), amount, 10, fee, signer)
+		require.NoError(t, err)
+		buf, err := types.InterfaceToBytes(tx)
+		require.NoError(t, err)
+		return buf
+	}
+
+	_, err = c.SubmitTransactions(context.Background(), &extpb.SubmitTransactionsRequest{})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = c.SubmitTransactions(context.Background(), &extpb.SubmitTransactionsRequest{
+		Transactions: make([][]byte, maxBatchTransactions+1),
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// transactions of the same sender are validated on top of each other
+	batch := [][]byte{
+		serialize(3, 40, 5),
+		serialize(4, 40, 5),
+		serialize(5, 40, 5),
+		{1, 2, 3},
+		serialize(5, 5, 5),
+	}
+	for _, dryRun := range []bool{true, false} {
+		res, err := c.SubmitTransactions(context.Background(), &extpb.SubmitTransactionsRequest{
+			Transactions: batch,
+			DryRun:       dryRun,
+		})
+		require.NoError(t, err)
+		require.Len(t, res.Results, len(batch))
+		expected := []struct {
+			validity       extpb.TransactionValidity
+			nonce, balance uint64
+		}{
+			{extpb.TransactionValidity_TRANSACTION_VALIDITY_VALID, 3, 100},
+			{extpb.TransactionValidity_TRANSACTION_VALIDITY_VALID, 4, 55},
+			{extpb.TransactionValidity_TRANSACTION_VALIDITY_INSUFFICIENT_BALANCE, 5, 10},
+			{extpb.TransactionValidity_TRANSACTION_VALIDITY_MALFORMED, 0, 0},
+			{extpb.TransactionValidity_TRANSACTION_VALIDITY_VALID, 5, 10},
+		}
+		for i, exp := range expected {
+			require.Equal(t, exp.validity, res.Results[i].Validity, res.Results[i].Message)
+			require.Equal(t, exp.nonce, res.Results[i].ProjectedNonce)
+			require.Equal(t, exp.balance, res.Results[i].ProjectedBalance)
+			valid := exp.validity == extpb.TransactionValidity_TRANSACTION_VALIDITY_VALID
+			require.Equal(t, valid && !dryRun, res.Results[i].Broadcast)
+		}
+	}
+
+	// valid transactions are broadcast in order
+	require.Eventually(t, func() bool { return bytes.Equal(batch[4], networkMock.GetBroadcast()) }, time.Second, 10*time.Millisecond)
+}
+
+func TestTransactionService_EstimateFee(t *testing.T) {
+	mempool := state.NewTxMemPool()
+	grpcService := NewTransactionService(&networkMock, txAPI, mempool, ProjectorMock{}, receiptStore, 0, 1)
+	shutDown := launchServer(t, grpcService)
+	defer shutDown()
+
+	// start a client
+	addr := "localhost:" + strconv.Itoa(cfg.NewGrpcServerPort)
+
+	// Set up a connection to the server.
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, conn.Close())
+	}()
+	c := extpb.NewTransactionServiceClient(conn)
+
+	// every layer of the mock contains the same transaction, which is only sampled once
+	res, err := c.EstimateFee(context.Background(), &extpb.EstimateFeeRequest{})
+	require.NoError(t, err)
+	require.Equal(t, uint64(feeEstimationLayers), res.SampledLayers)
+	require.Equal(t, uint64(1), res.SampledTransactions)
+	require.Equal(t, globalTx.Fee, res.LowFee)
+	require.Equal(t, globalTx.Fee, res.MediumFee)
+	require.Equal(t, globalTx.Fee, res.HighFee)
+	require.False(t, res.Congested)
+
+	// more transactions are waiting than fit in a layer
+	for i := uint64(0); i < 2; i++ {
+		tx := newTx(i, types.HexToAddress("33333"), types.HexToAddress("44444"), 10)
+		mempool.Put(tx.ID(), tx)
+	}
+	res, err = c.EstimateFee(context.Background(), &extpb.EstimateFeeRequest{})
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), res.MempoolSize)
+	require.True(t, res.Congested)
+	require.Equal(t, globalTx.Fee, res.MediumFee)
+	require.Equal(t, globalTx.Fee+1, res.HighFee)
+}
+
+func TestEstimateFees(t *testing.T) {
+	var fees []uint64
+	for i := uint64(1); i <= 100; i++ {
+		fees = append(fees, 101-i)
+	}
+
+	low, medium, high := estimateFees(fees, 0, false)
+	require.Equal(t, []uint64{25, 50, 90}, []uint64{low, medium, high})
+
+	low, medium, high = estimateFees(fees, 0, true)
+	require.Equal(t, []uint64{50, 90, 101}, []uint64{low, medium, high})
+
+	low, medium, high = estimateFees(fees, 60, false)
+	require.Equal(t, []uint64{60, 60, 90}, []uint64{low, medium, high})
+
+	low, medium, high = estimateFees(nil, 7, true)
+	require.Equal(t, []uint64{7, 7, 7}, []uint64{low, medium, high})
+}
+
+func TestTransactionService_DecodeTransaction(t *testing.T) {
+	grpcService := NewTransactionService(&networkMock, txAPI, txMempool, ProjectorMock{}, receiptStore, 0, layerAvgSize*txsPerBlock)
+	shutDown := launchServer(t, grpcService)
+	defer shutDown()
+
+	// start a client
+	addr := "localhost:" + strconv.Itoa(cfg.NewGrpcServerPort)
+
+	// Set up a connection to the server.
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, conn.Close())
+	}()
+	c := extpb.NewTransactionServiceClient(conn)
+
+	_, err = c.DecodeTransaction(context.Background(), &extpb.DecodeTransactionRequest{})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = c.DecodeTransaction(context.Background(), &extpb.DecodeTransactionRequest{Transaction: []byte{1, 2, 3}})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	signer := signing.NewEdSigner()
+	tx, err := mesh.NewSignedTx(7, types.HexToAddress("33333"), 50, 10, 3, signer)
+	require.NoError(t, err)
+	raw, err := types.InterfaceToBytes(tx)
+	require.NoError(t, err)
+
+	res, err := c.DecodeTransaction(context.Background(), &extpb.DecodeTransactionRequest{Transaction: raw})
+	require.NoError(t, err)
+	require.Equal(t, tx.ID().Bytes(), res.Transaction.Id)
+	require.Equal(t, types.BytesToAddress(signer.PublicKey().Bytes()).Bytes(), res.Transaction.Sender)
+	require.Equal(t, types.HexToAddress("33333").Bytes(), res.Transaction.Recipient)
+	require.Equal(t, uint64(50), res.Transaction.Amount)
+	require.Equal(t, uint64(7), res.Transaction.Counter)
+	require.Equal(t, uint64(10), res.Transaction.GasLimit)
+	require.Equal(t, uint64(3), res.Transaction.Fee)
+	require.Equal(t, tx.Signature[:], res.Transaction.Signature)
+}
+
+func TestTransactionService_TransactionReceipt(t *testing.T) {
+	failed := newTx(7, types.HexToAddress("33333"), types.HexToAddress("44444"), 1000)
+	receipts := state.NewReceiptStore(database.NewMemDatabase())
+	require.NoError(t, receipts.Put(&state.Receipt{ID: failed.ID(), Layer: 4, Result: events.TxResultInsufficientFunds, Error: "insufficient funds"}))
+	grpcService := NewTransactionService(&networkMock, txAPI, txMempool, ProjectorMock{}, receipts, 0, layerAvgSize*txsPerBlock)
+	shutDown := launchServer(t, grpcService)
+	defer shutDown()
+
+	// start a client
+	addr := "localhost:" + strconv.Itoa(cfg.NewGrpcServerPort)
+
+	// Set up a connection to the server.
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, conn.Close())
+	}()
+	c := extpb.NewTransactionServiceClient(conn)
+
+	_, err = c.TransactionReceipt(context.Background(), &extpb.TransactionReceiptRequest{})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = c.TransactionReceipt(context.Background(), &extpb.TransactionReceiptRequest{Id: globalTx.ID().Bytes()})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	res, err := c.TransactionReceipt(context.Background(), &extpb.TransactionReceiptRequest{Id: failed.ID().Bytes()})
+	require.NoError(t, err)
+	require.Equal(t, failed.ID().Bytes(), res.Receipt.Id)
+	require.Equal(t, uint64(4), res.Receipt.Layer)
+	require.Equal(t, extpb.TransactionReceipt_TRANSACTION_RESULT_INSUFFICIENT_FUNDS, res.Receipt.Result)
+	require.Equal(t, "insufficient funds", res.Receipt.Error)
+	require.Zero(t, res.Receipt.Fee)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := c.TransactionReceiptStream(ctx, &extpb.TransactionReceiptStreamRequest{Id: [][]byte{globalTx.ID().Bytes()}})
+	require.NoError(t, err)
+	time.Sleep(time.Second) // wait for the server to subscribe
+
+	// only receipts of the requested transactions are streamed
+	events.Publish(events.TxApplied{ID: failed.ID(), LayerID: 9, Fee: 1, Result: events.TxResultBadNonce, Error: "incorrect nonce"})
+	events.Publish(events.TxApplied{ID: globalTx.ID(), LayerID: 9, Fee: 1, Result: events.TxResultApplied, GasUsed: 10})
+	streamed, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, globalTx.ID().Bytes(), streamed.Receipt.Id)
+	require.Equal(t, extpb.TransactionReceipt_TRANSACTION_RESULT_APPLIED, streamed.Receipt.Result)
+	require.Equal(t, uint64(9), streamed.Receipt.Layer)
+	require.Equal(t, uint64(10), streamed.Receipt.GasUsed)
+	require.Equal(t, uint64(1), streamed.Receipt.Fee)
+	require.Empty(t, streamed.Receipt.Error)
+}
+
+func TestTransactionService_AccountTransactions(t *testing.T) {
+	tx := &TxAPIMock{accountTxs: []mesh.AccountTx{
+		{ID: globalTx.ID(), Layer: 2, Direction: mesh.TxSent},
+		{ID: globalTx.ID(), Layer: 3, Direction: mesh.TxReceived},
+		{ID: globalTx.ID(), Layer: 5, Direction: mesh.TxSent | mesh.TxReceived},
+		{ID: globalTx.ID(), Layer: 8, Direction: mesh.TxReceived},
+	}}
+	grpcService := NewTransactionService(&networkMock, tx, txMempool, ProjectorMock{}, receiptStore, 0, layerAvgSize*txsPerBlock)
+	shutDown := launchServer(t, grpcService)
+	defer shutDown()
+
+	// start a client
+	addr := "localhost:" + strconv.Itoa(cfg.NewGrpcServerPort)
+
+	// Set up a connection to the server.
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, conn.Close())
+	}()
+	c := extpb.NewTransactionServiceClient(conn)
+	account := types.HexToAddress("33333").Bytes()
+
+	for _, in := range []*extpb.AccountTransactionsRequest{
+		{},
+		{AccountId: account, MaxResults: maxAccountTransactions + 1},
+		{AccountId: account, MinLayer: 6, MaxLayer: 4},
+		{AccountId: account, PageToken: []byte{1, 2}},
+	} {
+		_, err = c.AccountTransactions(context.Background(), in)
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	}
+
+	res, err := c.AccountTransactions(context.Background(), &extpb.AccountTransactionsRequest{AccountId: account})
+	require.NoError(t, err)
+	require.Len(t, res.Transactions, 4)
+	require.Empty(t, res.NextPageToken)
+	require.Equal(t, globalTx.ID().Bytes(), res.Transactions[0].Transaction.Id)
+	require.Equal(t, uint64(5), res.Transactions[2].Layer)
+	require.True(t, res.Transactions[2].Sent)
+	require.True(t, res.Transactions[2].Received)
+
+	res, err = c.AccountTransactions(context.Background(), &extpb.AccountTransactionsRequest{
+		AccountId: account,
+		Direction: extpb.TransactionDirection_TRANSACTION_DIRECTION_RECEIVED,
+		MaxLayer:  6,
+	})
+	require.NoError(t, err)
+	require.Len(t, res.Transactions, 2)
+	require.Equal(t, uint64(3), res.Transactions[0].Layer)
+	require.False(t, res.Transactions[0].Sent)
+	require.Equal(t, uint64(5), res.Transactions[1].Layer)
+
+	// page through the sent transactions one at a time
+	var layers []uint64
+	var token []byte
+	for {
+		res, err = c.AccountTransactions(context.Background(), &extpb.AccountTransactionsRequest{
+			AccountId:  account,
+			Direction:  extpb.TransactionDirection_TRANSACTION_DIRECTION_SENT,
+			MaxResults: 1,
+			PageToken:  token,
+		})
+		require.NoError(t, err)
+		for _, tx := range res.Transactions {
+			layers = append(layers, tx.Layer)
+		}
+		if len(res.NextPageToken) == 0 {
+			break
+		}
+		token = res.NextPageToken
+	}
+	require.Equal(t, []uint64{2, 5}, layers)
+}
+
+func TestTransactionService_TransactionsState(t *testing.T) {
+	applied := types.LayerID(ValidatedLayerID)
+	processedTx := newTx(1, types.HexToAddress("33333"), types.HexToAddress("44444"), 10)
+	meshTx := newTx(2, types.HexToAddress("33333"), types.HexToAddress("44444"), 20)
+	mempoolTx := newTx(3, types.HexToAddress("33333"), types.HexToAddress("44444"), 30)
+	streamedTx := newTx(4, types.HexToAddress("33333"), types.HexToAddress("44444"), 40)
+	mempool := state.NewTxMemPool()
+	mempool.Put(mempoolTx.ID(), mempoolTx)
+	tx := &TxAPIMock{
+		returnTx: map[types.TransactionID]*types.Transaction{
+			processedTx.ID(): processedTx,
+			meshTx.ID():      meshTx,
+		},
+		layerApplied: map[types.TransactionID]*types.LayerID{processedTx.ID(): &applied},
+	}
+	grpcService := NewTransactionService(&networkMock, tx, mempool, ProjectorMock{}, receiptStore, 0, layerAvgSize*txsPerBlock)
+	shutDown := launchServer(t, grpcService)
+	defer shutDown()
+
+	// start a client
+	addr := "localhost:" + strconv.Itoa(cfg.NewGrpcServerPort)
+
+	// Set up a connection to the server.
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, conn.Close())
+	}()
+	c := pb.NewTransactionServiceClient(conn)
+
+	_, err = c.TransactionsState(context.Background(), &pb.TransactionsStateRequest{})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	res, err := c.TransactionsState(context.Background(), &pb.TransactionsStateRequest{
+		TransactionId: []*pb.TransactionId{
+			{Id: processedTx.ID().Bytes()},
+			{Id: meshTx.ID().Bytes()},
+			{Id: mempoolTx.ID().Bytes()},
+			{Id: streamedTx.ID().Bytes()},
+		},
+		IncludeTransactions: true,
+	})
+	require.NoError(t, err)
+	require.Len(t, res.TransactionsState, 4)
+	require.Equal(t, pb.TransactionState_TRANSACTION_STATE_PROCESSED, res.TransactionsState[0].State)
+	require.Equal(t, pb.TransactionState_TRANSACTION_STATE_MESH, res.TransactionsState[1].State)
+	require.Equal(t, pb.TransactionState_TRANSACTION_STATE_MEMPOOL, res.TransactionsState[2].State)
+	require.Equal(t, pb.TransactionState_TRANSACTION_STATE_UNSPECIFIED, res.TransactionsState[3].State)
+	require.Len(t, res.Transactions, 3)
+	require.Equal(t, mempoolTx.ID().Bytes(), res.Transactions[2].Id.Id)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := c.TransactionsStateStream(ctx, &pb.TransactionsStateStreamRequest{
+		TransactionId:       []*pb.TransactionId{{Id: streamedTx.ID().Bytes()}},
+		IncludeTransactions: true,
+	})
+	require.NoError(t, err)
+
+	// the current state is sent first
+	streamed, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, pb.TransactionState_TRANSACTION_STATE_UNSPECIFIED, streamed.TransactionsState[0].State)
+	require.Empty(t, streamed.Transactions)
+
+	expectState := func(txState pb.TransactionState_TransactionState) {
+		streamed, err := stream.Recv()
+		require.NoError(t, err)
+		require.Len(t, streamed.TransactionsState, 1)
+		require.Equal(t, streamedTx.ID().Bytes(), streamed.TransactionsState[0].Id.Id)
+		require.Equal(t, txState, streamed.TransactionsState[0].State)
+	}
+
+	// other transactions aren't reported
+	events.Publish(events.TxInMempool{Transaction: mempoolTx})
+	events.Publish(events.TxInMempool{Transaction: streamedTx})
+	expectState(pb.TransactionState_TRANSACTION_STATE_MEMPOOL)
+	events.Publish(events.TxInMesh{Transaction: streamedTx, LayerID: 9})
+	expectState(pb.TransactionState_TRANSACTION_STATE_MESH)
+
+	// a transaction never moves back to an earlier state
+	events.Publish(events.TxInMempool{Transaction: streamedTx})
+	events.Publish(events.TxApplied{ID: streamedTx.ID(), LayerID: 9, Result: events.TxResultBadNonce})
+	expectState(pb.TransactionState_TRANSACTION_STATE_REJECTED)
+}
+
+func TestGlobalStateService_AccountDataQuery(t *testing.T) {
+	account := types.HexToAddress("33333")
+	other := types.HexToAddress("44444")
+	applied := types.LayerID(TxReturnLayer)
+	sent := newTx(1, account, other, 10)
+	received := newTx(2, other, account, 20)
+	pending := newTx(2, account, other, 30)
+	tx := &TxAPIMock{
+		returnTx: map[types.TransactionID]*types.Transaction{
+			sent.ID():     sent,
+			received.ID(): received,
+			pending.ID():  pending,
+		},
+		layerApplied: map[types.TransactionID]*types.LayerID{sent.ID(): &applied, received.ID(): &applied},
+		rewards: map[types.Address][]types.Reward{
+			account: {{Layer: 3, TotalReward: 50, LayerRewardEstimate: 40}, {Layer: 5, TotalReward: 60, LayerRewardEstimate: 40}},
+		},
+		// sent was included in two layers, pending is in a layer that wasn't applied yet
+		accountTxs: []mesh.AccountTx{
+			{ID: sent.ID(), Layer: applied, Direction: mesh.TxSent},
+			{ID: received.ID(), Layer: applied, Direction: mesh.TxReceived},
+			{ID: sent.ID(), Layer: applied + 1, Direction: mesh.TxSent},
+			{ID: pending.ID(), Layer: ValidatedLayerID + 1, Direction: mesh.TxSent},
+		},
+	}
+	stateAPI := NewNodeAPIMock()
+	stateAPI.balances[account] = big.NewInt(1000)
+	stateAPI.nonces[account] = 2
+	// sent has a recorded receipt, received was applied before receipts were recorded
+	receipts := state.NewReceiptStore(database.NewMemDatabase())
+	require.NoError(t, receipts.Put(&state.Receipt{ID: sent.ID(), Layer: applied, Result: events.TxResultApplied, GasUsed: 100, Fee: 1}))
+	grpcService := NewGlobalStateService(tx, stateAPI, receipts, 0, nil)
+	shutDown := launchServer(t, grpcService)
+	defer shutDown()
+
+	// start a client
+	addr := "localhost:" + strconv.Itoa(cfg.NewGrpcServerPort)
+
+	// Set up a connection to the server.
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, conn.Close())
+	}()
+	c := pb.NewGlobalStateServiceClient(conn)
+
+	_, err = c.AccountDataQuery(context.Background(), &pb.AccountDataQueryRequest{})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = c.AccountDataQuery(context.Background(), &pb.AccountDataQueryRequest{
+		Filter: &pb.AccountDataFilter{AccountId: &pb.AccountId{Address: account.Bytes()}},
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	allFlags := uint32(pb.AccountDataFlag_ACCOUNT_DATA_FLAG_ACCOUNT |
+		pb.AccountDataFlag_ACCOUNT_DATA_FLAG_REWARD |
+		pb.AccountDataFlag_ACCOUNT_DATA_FLAG_TRANSACTION_RECEIPT)
+	query := func(flags, maxResults, offset uint32) *pb.AccountDataQueryResponse {
+		res, err := c.AccountDataQuery(context.Background(), &pb.AccountDataQueryRequest{
+			Filter: &pb.AccountDataFilter{
+				AccountId:        &pb.AccountId{Address: account.Bytes()},
+				AccountDataFlags: flags,
+			},
+			MaxResults: maxResults,
+			Offset:     offset,
+		})
+		require.NoError(t, err)
+		return res
+	}
+
+	// the account comes first, then rewards and the receipts of applied transactions
+	res := query(allFlags, 0, 0)
+	require.Equal(t, uint32(5), res.TotalResults)
+	require.Len(t, res.AccountItem, 5)
+	acc := res.AccountItem[0].GetAccount()
+	require.NotNil(t, acc)
+	require.Equal(t, account.Bytes(), acc.Address.Address)
+	require.Equal(t, uint64(2), acc.Counter)
+	require.Equal(t, uint64(1000), acc.Balance.Value)
+	reward := res.AccountItem[1].GetReward()
+	require.NotNil(t, reward)
+	require.Equal(t, uint64(3), reward.Layer)
+	require.Equal(t, uint64(50), reward.Total.Value)
+	require.Equal(t, uint64(40), reward.LayerReward.Value)
+	require.Equal(t, account.Bytes(), reward.Coinbase.Address)
+	require.Equal(t, uint64(5), res.AccountItem[2].GetReward().Layer)
+	var receiptIds [][]byte
+	for _, item := range res.AccountItem[3:] {
+		receipt := item.GetReceipt()
+		require.NotNil(t, receipt)
+		require.Equal(t, pb.TransactionReceipt_TRANSACTION_RESULT_EXECUTED, receipt.Result)
+		require.Equal(t, uint64(TxReturnLayer), receipt.LayerNumber)
+		if bytes.Equal(sent.ID().Bytes(), receipt.Id.Id) {
+			require.Equal(t, uint64(100), receipt.GasUsed)
+		}
+		receiptIds = append(receiptIds, receipt.Id.Id)
+	}
+	require.ElementsMatch(t, [][]byte{sent.ID().Bytes(), received.ID().Bytes()}, receiptIds)
+
+	// paging
+	res = query(allFlags, 2, 1)
+	require.Equal(t, uint32(5), res.TotalResults)
+	require.Len(t, res.AccountItem, 2)
+	require.Equal(t, uint64(3), res.AccountItem[0].GetReward().Layer)
+	require.Equal(t, uint64(5), res.AccountItem[1].GetReward().Layer)
+	res = query(allFlags, 0, 5)
+	require.Equal(t, uint32(5), res.TotalResults)
+	require.Empty(t, res.AccountItem)
+
+	// filtering
+	res = query(uint32(pb.AccountDataFlag_ACCOUNT_DATA_FLAG_TRANSACTION_RECEIPT), 0, 0)
+	require.Equal(t, uint32(2), res.TotalResults)
+	require.NotNil(t, res.AccountItem[0].GetReceipt())
+}
+
+func TestGlobalStateService_GlobalStateHashAtLayer(t *testing.T) {
+	stateAPI := NewNodeAPIMock()
+	root := types.BytesToHash([]byte("root"))
+	stateAPI.roots[3] = root
+	stateAPI.roots[ValidatedLayerID+1] = types.BytesToHash([]byte("stale"))
+	stateAPI.pruned = 3
+	grpcService := NewGlobalStateService(&TxAPIMock{}, stateAPI, receiptStore, 0, nil)
+	shutDown := launchServer(t, grpcService)
+	defer shutDown()
+
+	// start a client
+	addr := "localhost:" + strconv.Itoa(cfg.NewGrpcServerPort)
+
+	// Set up a connection to the server.
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, conn.Close())
+	}()
+	c := extpb.NewGlobalStateServiceClient(conn)
+
+	res, err := c.GlobalStateHashAtLayer(context.Background(), &extpb.GlobalStateHashAtLayerRequest{Layer: 3})
+	require.NoError(t, err)
+	require.Equal(t, root.Bytes(), res.RootHash)
+	require.Equal(t, uint64(3), res.Layer)
+
+	_, err = c.GlobalStateHashAtLayer(context.Background(), &extpb.GlobalStateHashAtLayerRequest{Layer: 4})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	// layers that weren't applied to the current state aren't reported
+	_, err = c.GlobalStateHashAtLayer(context.Background(), &extpb.GlobalStateHashAtLayerRequest{Layer: ValidatedLayerID + 1})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = c.GlobalStateHashAtLayer(context.Background(), &extpb.GlobalStateHashAtLayerRequest{Layer: 2})
+	require.Equal(t, codes.NotFound, status.Code(err))
+	require.Contains(t, status.Convert(err).Message(), "pruned")
+}
+
+func TestGlobalStateService_Supply(t *testing.T) {
+	genesisAccount := types.HexToAddress("11111")
+	account := types.HexToAddress("33333")
+	stateAPI := NewNodeAPIMock()
+	stateAPI.balances[genesisAccount] = big.NewInt(700)
+	stateAPI.nonces[genesisAccount] = 1
+	stateAPI.balances[account] = big.NewInt(500)
+	stateAPI.nonces[account] = 0
+	root := types.BytesToHash([]byte("root"))
+	stateAPI.roots[ValidatedLayerID] = root
+	stateAPI.roots[3] = types.BytesToHash([]byte("old root"))
+	genesis := map[types.Address]uint64{genesisAccount: 1000}
+	grpcService := NewGlobalStateService(&TxAPIMock{}, stateAPI, receiptStore, 0, genesis)
+	shutDown := launchServer(t, grpcService)
+	defer shutDown()
+
+	// start a client
+	addr := "localhost:" + strconv.Itoa(cfg.NewGrpcServerPort)
+
+	// Set up a connection to the server.
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, conn.Close())
+	}()
+	c := extpb.NewGlobalStateServiceClient(conn)
+
+	// the latest layer in state is used by default
+	res, err := c.Supply(context.Background(), &extpb.SupplyRequest{})
+	require.NoError(t, err)
+	require.Equal(t, uint64(ValidatedLayerID), res.Layer)
+	require.Equal(t, root.Bytes(), res.RootHash)
+	require.Equal(t, uint64(1200), res.Total)
+	require.Equal(t, uint64(1000), res.Genesis)
+	require.Equal(t, uint64(200), res.Rewards)
+	require.Equal(t, uint64(500), res.Circulating)
+
+	res, err = c.Supply(context.Background(), &extpb.SupplyRequest{Layer: 3})
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), res.Layer)
+
+	_, err = c.Supply(context.Background(), &extpb.SupplyRequest{Layer: 4})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestGlobalStateService_CoinbaseRewards(t *testing.T) {
+	types.SetLayersPerEpoch(layersPerEpoch)
+	coinbase := types.HexToAddress("33333")
+	epochLayer := func(epoch types.EpochID, i int) types.LayerID {
+		return epoch.FirstLayer() + types.LayerID(i)
+	}
+	tx := &TxAPIMock{coinbaseRewards: map[types.Address][]mesh.CoinbaseReward{coinbase: {
+		{Layer: epochLayer(0, 1), Blocks: 1, TotalReward: 110, LayerRewardEstimate: 100},
+		{Layer: epochLayer(0, 2), Blocks: 2, TotalReward: 230, LayerRewardEstimate: 200},
+		{Layer: epochLayer(2, 0), Blocks: 1, TotalReward: 105, LayerRewardEstimate: 100},
+	}}}
+	grpcService := NewGlobalStateService(tx, NewNodeAPIMock(), receiptStore, 0, nil)
+	shutDown := launchServer(t, grpcService)
+	defer shutDown()
+
+	// start a client
+	addr := "localhost:" + strconv.Itoa(cfg.NewGrpcServerPort)
+
+	// Set up a connection to the server.
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, conn.Close())
+	}()
+	c := extpb.NewGlobalStateServiceClient(conn)
+
+	_, err = c.CoinbaseRewards(context.Background(), &extpb.CoinbaseRewardsRequest{})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = c.CoinbaseRewards(context.Background(), &extpb.CoinbaseRewardsRequest{Coinbase: coinbase.Bytes(), MinEpoch: 3, MaxEpoch: 2})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	res, err := c.CoinbaseRewards(context.Background(), &extpb.CoinbaseRewardsRequest{Coinbase: coinbase.Bytes(), MaxEpoch: 5})
+	require.NoError(t, err)
+	require.Len(t, res.Epochs, 2)
+	require.Equal(t, &extpb.EpochRewards{Epoch: 0, Blocks: 3, Total: 340, LayerReward: 300}, res.Epochs[0])
+	require.Equal(t, &extpb.EpochRewards{Epoch: 2, Blocks: 1, Total: 105, LayerReward: 100}, res.Epochs[1])
+
+	res, err = c.CoinbaseRewards(context.Background(), &extpb.CoinbaseRewardsRequest{Coinbase: coinbase.Bytes(), MinEpoch: 1, MaxEpoch: 2, IncludeLayers: true})
+	require.NoError(t, err)
+	require.Len(t, res.Epochs, 1)
+	require.Len(t, res.Epochs[0].Layers, 1)
+	require.Equal(t, epochLayer(2, 0).Uint64(), res.Epochs[0].Layers[0].Layer)
+	require.Equal(t, uint64(105), res.Epochs[0].Layers[0].Total)
+
+	res, err = c.CoinbaseRewards(context.Background(), &extpb.CoinbaseRewardsRequest{
+		Coinbase:      coinbase.Bytes(),
+		MaxEpoch:      5,
+		IncludeLayers: true,
+		FieldMask:     &fieldmaskpb.FieldMask{Paths: []string{"epochs.epoch", "epochs.total", "epochs.layers.layer"}},
+	})
+	require.NoError(t, err)
+	require.Len(t, res.Epochs, 2)
+	require.Equal(t, &extpb.EpochRewards{Epoch: 0, Total: 340, Layers: []*extpb.LayerRewards{
+		{Layer: epochLayer(0, 1).Uint64()},
+		{Layer: epochLayer(0, 2).Uint64()},
+	}}, res.Epochs[0])
+}
+
+func TestGlobalStateService_AccountAtLayer(t *testing.T) {
+	account := types.HexToAddress("33333")
+	stateAPI := NewNodeAPIMock()
+	stateAPI.balances[account] = big.NewInt(1000)
+	stateAPI.nonces[account] = 2
+	root := types.BytesToHash([]byte("root"))
+	stateAPI.roots[ValidatedLayerID-1] = root
+	stateAPI.roots[ValidatedLayerID-3] = types.BytesToHash([]byte("old root"))
+	grpcService := NewGlobalStateService(&TxAPIMock{}, stateAPI, receiptStore, 3, nil)
+	shutDown := launchServer(t, grpcService)
+	defer shutDown()
+
+	// start a client
+	addr := "localhost:" + strconv.Itoa(cfg.NewGrpcServerPort)
+
+	// Set up a connection to the server.
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, conn.Close())
+	}()
+	c := extpb.NewGlobalStateServiceClient(conn)
+
+	_, err = c.AccountAtLayer(context.Background(), &extpb.AccountAtLayerRequest{Layer: ValidatedLayerID - 1})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	res, err := c.AccountAtLayer(context.Background(), &extpb.AccountAtLayerRequest{AccountId: account.Bytes(), Layer: ValidatedLayerID - 1})
+	require.NoError(t, err)
+	require.Equal(t, account.Bytes(), res.AccountId)
+	require.Equal(t, uint64(ValidatedLayerID-1), res.Layer)
+	require.Equal(t, root.Bytes(), res.RootHash)
+	require.True(t, res.Exists)
+	require.Equal(t, uint64(2), res.Counter)
+	require.Equal(t, uint64(1000), res.Balance)
+
+	res, err = c.AccountAtLayer(context.Background(), &extpb.AccountAtLayerRequest{AccountId: types.HexToAddress("44444").Bytes(), Layer: ValidatedLayerID - 1})
+	require.NoError(t, err)
+	require.False(t, res.Exists)
+
+	_, err = c.AccountAtLayer(context.Background(), &extpb.AccountAtLayerRequest{AccountId: account.Bytes(), Layer: ValidatedLayerID})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	// only the state of the last 3 layers can be queried
+	_, err = c.AccountAtLayer(context.Background(), &extpb.AccountAtLayerRequest{AccountId: account.Bytes(), Layer: ValidatedLayerID - 3})
+	require.Equal(t, codes.OutOfRange, status.Code(err))
+	_, err = c.GlobalStateHashAtLayer(context.Background(), &extpb.GlobalStateHashAtLayerRequest{Layer: ValidatedLayerID - 3})
+	require.Equal(t, codes.OutOfRange, status.Code(err))
+}
+
+func TestGlobalStateService_AccountProof(t *testing.T) {
+	account := types.HexToAddress("33333")
+	globalState, err := state.New(types.Hash32{}, state.NewDatabase(database.NewMemDatabase()))
+	require.NoError(t, err)
+	globalState.SetNonce(account, 3)
+	globalState.AddBalance(account, big.NewInt(1000))
+	globalState.AddBalance(types.HexToAddress("44444"), big.NewInt(10))
+	root, err := globalState.Commit()
+	require.NoError(t, err)
+	proof, err := globalState.GetProof(root, account)
+	require.NoError(t, err)
+
+	stateAPI := NewNodeAPIMock()
+	stateAPI.proofs[root] = proof
+	grpcService := NewGlobalStateService(&TxAPIMock{}, stateAPI, receiptStore, 0, nil)
+	shutDown := launchServer(t, grpcService)
+	defer shutDown()
+
+	// start a client
+	addr := "localhost:" + strconv.Itoa(cfg.NewGrpcServerPort)
+
+	// Set up a connection to the server.
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, conn.Close())
+	}()
+	c := extpb.NewGlobalStateServiceClient(conn)
+
+	_, err = c.AccountProof(context.Background(), &extpb.AccountProofRequest{RootHash: root.Bytes()})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// the current root of the mock isn't available
+	_, err = c.AccountProof(context.Background(), &extpb.AccountProofRequest{AccountId: account.Bytes()})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	res, err := c.AccountProof(context.Background(), &extpb.AccountProofRequest{AccountId: account.Bytes(), RootHash: root.Bytes()})
+	require.NoError(t, err)
+	require.Equal(t, root.Bytes(), res.RootHash)
+	require.Equal(t, account.Bytes(), res.AccountId)
+	require.True(t, res.Exists)
+	require.Equal(t, uint64(3), res.Counter)
+	require.Equal(t, uint64(1000), res.Balance)
+
+	// the proof can be verified by the client
+	exists, nonce, balance, err := types.VerifyAccountProof(root, account, res.Nodes)
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.Equal(t, uint64(3), nonce)
+	require.Equal(t, uint64(1000), balance)
+
+	res, err = c.AccountProof(context.Background(), &extpb.AccountProofRequest{
+		AccountId: account.Bytes(),
+		RootHash:  root.Bytes(),
+		FieldMask: &fieldmaskpb.FieldMask{Paths: []string{"balance"}},
+	})
+	require.NoError(t, err)
+	require.True(t, proto.Equal(&extpb.AccountProofResponse{Balance: 1000}, res), res.String())
+}
+
+func TestGlobalStateService_GlobalStateStream(t *testing.T) {
+	grpcService := NewGlobalStateService(&TxAPIMock{}, NewNodeAPIMock(), receiptStore, 0, nil)
+	shutDown := launchServer(t, grpcService)
+	defer shutDown()
+
+	// start a client
+	addr := "localhost:" + strconv.Itoa(cfg.NewGrpcServerPort)
+
+	// Set up a connection to the server.
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, conn.Close())
+	}()
+	c := pb.NewGlobalStateServiceClient(conn)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := c.GlobalStateStream(ctx, &pb.GlobalStateStreamRequest{})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	stream, err = c.GlobalStateStream(ctx, &pb.GlobalStateStreamRequest{
+		GlobalStateDataItemFlags: uint32(pb.GlobalStateDataItemFlag_GLOBAL_STATE_DATA_ITEM_FLAG_TRANSACTION_RECEIPT |
+			pb.GlobalStateDataItemFlag_GLOBAL_STATE_DATA_ITEM_FLAG_GLOBAL_STATE_HASH),
+	})
+	require.NoError(t, err)
+	time.Sleep(time.Second) // wait for the server to subscribe
+
+	account := types.HexToAddress("33333")
+	tx := newTx(1, account, types.HexToAddress("44444"), 10)
+	root := types.BytesToHash([]byte("root"))
+	events.Publish(events.AccountUpdate{Address: account, Nonce: 2, Balance: 100, LayerID: 9})
+	events.Publish(events.RewardReceived{Coinbase: account.String(), Amount: 50, Layer: 9, LayerReward: 40})
+	events.Publish(events.TxApplied{ID: tx.ID(), LayerID: 9, Fee: 1, Result: events.TxResultApplied})
+	events.Publish(events.LayerUpdate{LayerID: 9, Status: events.LayerStatusApproved})
+	events.Publish(events.LayerUpdate{LayerID: 9, Status: events.LayerStatusConfirmed, StateRoot: root})
+
+	// receipts and hashes are delivered independently of each other
+	var receipt *pb.TransactionReceipt
+	var hash *pb.GlobalStateHash
+	for i := 0; i < 2; i++ {
+		res, err := stream.Recv()
+		require.NoError(t, err)
+		require.Len(t, res.DataItem, 1)
+		require.Nil(t, res.DataItem[0].GetAccount())
+		require.Nil(t, res.DataItem[0].GetReward())
+		if res.DataItem[0].GetReceipt() != nil {
+			receipt = res.DataItem[0].GetReceipt()
+		} else {
+			hash = res.DataItem[0].GetGlobalState()
+		}
+	}
+	require.NotNil(t, receipt)
+	require.Equal(t, tx.ID().Bytes(), receipt.Id.Id)
+	require.Equal(t, pb.TransactionReceipt_TRANSACTION_RESULT_EXECUTED, receipt.Result)
+	require.Equal(t, uint64(1), receipt.Fee.Value)
+	require.Equal(t, uint64(9), receipt.LayerNumber)
+	require.NotNil(t, hash)
+	require.Equal(t, root.Bytes(), hash.RootHash)
+	require.Equal(t, uint64(9), hash.LayerNumber)
+
+	stream, err = c.GlobalStateStream(ctx, &pb.GlobalStateStreamRequest{
+		GlobalStateDataItemFlags: uint32(pb.GlobalStateDataItemFlag_GLOBAL_STATE_DATA_ITEM_FLAG_ACCOUNT |
+			pb.GlobalStateDataItemFlag_GLOBAL_STATE_DATA_ITEM_FLAG_REWARD),
+	})
+	require.NoError(t, err)
+	time.Sleep(time.Second) // wait for the server to subscribe
+
+	events.Publish(events.TxApplied{ID: tx.ID(), LayerID: 10, Result: events.TxResultBadNonce})
+	events.Publish(events.AccountUpdate{Address: account, Nonce: 2, Balance: 100, LayerID: 10})
+	res, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, account.Bytes(), res.DataItem[0].GetAccount().Address.Address)
+	require.Equal(t, uint64(2), res.DataItem[0].GetAccount().Counter)
+	require.Equal(t, uint64(100), res.DataItem[0].GetAccount().Balance.Value)
+
+	events.Publish(events.RewardReceived{Coinbase: account.String(), Amount: 50, Layer: 10, LayerReward: 40, Smesher: "abcd"})
+	res, err = stream.Recv()
+	require.NoError(t, err)
+	reward := res.DataItem[0].GetReward()
+	require.Equal(t, account.Bytes(), reward.Coinbase.Address)
+	require.Equal(t, []byte{0xab, 0xcd}, reward.Smesher.Id)
+	require.Equal(t, uint64(10), reward.Layer)
+	require.Equal(t, uint64(50), reward.Total.Value)
+	require.Equal(t, uint64(40), reward.LayerReward.Value)
+}
+
+func TestGlobalStateService_RewardStreams(t *testing.T) {
+	grpcService := NewGlobalStateService(&TxAPIMock{}, NewNodeAPIMock(), receiptStore, 0, nil)
+	shutDown := launchServer(t, grpcService)
+	defer shutDown()
+
+	// start a client
+	addr := "localhost:" + strconv.Itoa(cfg.NewGrpcServerPort)
+
+	// Set up a connection to the server.
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, conn.Close())
+	}()
+	c := pb.NewGlobalStateServiceClient(conn)
+	ext := extpb.NewGlobalStateServiceClient(conn)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	smesherStream, err := c.SmesherRewardStream(ctx, &pb.SmesherRewardStreamRequest{})
+	require.NoError(t, err)
+	_, err = smesherStream.Recv()
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	extStream, err := ext.RewardStream(ctx, &extpb.RewardStreamRequest{})
+	require.NoError(t, err)
+	_, err = extStream.Recv()
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	coinbase := types.HexToAddress("33333")
+	other := types.HexToAddress("44444")
+	smesherStream, err = c.SmesherRewardStream(ctx, &pb.SmesherRewardStreamRequest{Id: &pb.SmesherId{Id: []byte{0xab, 0xcd}}})
+	require.NoError(t, err)
+	coinbaseStream, err := ext.RewardStream(ctx, &extpb.RewardStreamRequest{Coinbase: coinbase.Bytes()})
+	require.NoError(t, err)
+	bothStream, err := ext.RewardStream(ctx, &extpb.RewardStreamRequest{SmesherId: []byte{0xab, 0xcd}, Coinbase: other.Bytes()})
+	require.NoError(t, err)
+	time.Sleep(time.Second) // wait for the server to subscribe
+
+	events.Publish(events.RewardReceived{Coinbase: other.String(), Amount: 10, Layer: 5, LayerReward: 8, Smesher: "1234"})
+	events.Publish(events.RewardReceived{Coinbase: coinbase.String(), Amount: 20, Layer: 5, LayerReward: 8, Smesher: "abcd"})
+	events.Publish(events.RewardReceived{Coinbase: other.String(), Amount: 30, Layer: 6, LayerReward: 8, Smesher: "abcd"})
+
+	res, err := smesherStream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, uint64(20), res.Reward.Total.Value)
+	res, err = smesherStream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, uint64(30), res.Reward.Total.Value)
+	require.Equal(t, other.Bytes(), res.Reward.Coinbase.Address)
+
+	extRes, err := coinbaseStream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, uint64(20), extRes.Reward.Total)
+	require.Equal(t, uint64(8), extRes.Reward.LayerReward)
+	require.Equal(t, uint64(5), extRes.Reward.Layer)
+	require.Equal(t, coinbase.Bytes(), extRes.Reward.Coinbase)
+	require.Equal(t, []byte{0xab, 0xcd}, extRes.Reward.SmesherId)
+
+	extRes, err = bothStream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, uint64(30), extRes.Reward.Total)
+}
+
+func TestDebugService_Accounts(t *testing.T) {
+	stateAPI := NewNodeAPIMock()
+	for i := 1; i <= 5; i++ {
+		addr := types.BytesToAddress([]byte{byte(i)})
+		stateAPI.nonces[addr] = uint64(i)
+		stateAPI.balances[addr] = big.NewInt(int64(i * 100))
+	}
+	tx := &TxAPIMock{}
+	grpcService := NewDebugService(tx, stateAPI, LayerInternalsMock{}, LayerInternalsMock{}, nil, nil, nil)
+	shutDown := launchServer(t, grpcService)
+	defer shutDown()
+
+	// start a client
+	addr := "localhost:" + strconv.Itoa(cfg.NewGrpcServerPort)
+
+	// Set up a connection to the server.
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, conn.Close())
+	}()
+	c := extpb.NewDebugServiceClient(conn)
+
+	stream, err := c.Accounts(context.Background(), &extpb.AccountsRequest{ChunkSize: 2})
+	require.NoError(t, err)
+	var accounts []*extpb.Account
+	chunks := 0
+	for {
+		res, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		require.Equal(t, tx.GetStateRoot().Bytes(), res.RootHash)
+		require.Equal(t, uint64(ValidatedLayerID), res.Layer)
+		require.True(t, len(res.Accounts) <= 2)
+		accounts = append(accounts, res.Accounts...)
+		chunks++
+	}
+	require.Equal(t, 3, chunks)
+	require.Len(t, accounts, 5)
+	for i, account := range accounts {
+		require.Equal(t, types.BytesToAddress([]byte{byte(i + 1)}).Bytes(), account.AccountId)
+		require.Equal(t, uint64(i+1), account.Counter)
+		require.Equal(t, uint64((i+1)*100), account.Balance)
+	}
+
+	// an empty state is reported in a single empty chunk
+	for addr := range stateAPI.nonces {
+		delete(stateAPI.nonces, addr)
+	}
+	stream, err = c.Accounts(context.Background(), &extpb.AccountsRequest{})
+	require.NoError(t, err)
+	res, err := stream.Recv()
+	require.NoError(t, err)
+	require.Empty(t, res.Accounts)
+	_, err = stream.Recv()
+	require.Equal(t, io.EOF, err)
+}
+
+func TestDebugService_LayerInternals(t *testing.T) {
+	blocks := make([]*types.Block, 3)
+	for i := range blocks {
+		blocks[i] = types.NewExistingBlock(5, []byte{byte(i)})
+		blocks[i].ATXID = globalAtx.ID()
+		blocks[i].BlockVotes = []types.BlockID{{1}}
+		blocks[i].ViewEdges = []types.BlockID{{1}, {2}}
+	}
+	mock := LayerInternalsMock{
+		layers: map[types.LayerID]*types.Layer{
+			5: types.NewExistingLayer(5, blocks),
+			6: types.NewExistingLayer(6, nil),
+		},
+		hareOutputs: map[types.LayerID][]types.BlockID{
+			5: {blocks[0].ID(), blocks[1].ID()},
+		},
+		validity: map[types.BlockID]bool{
+			blocks[0].ID(): true,
+			blocks[2].ID(): false,
+		},
+		opinions: map[types.LayerID]map[types.BlockID]tortoise.BlockOpinion{
+			5: {
+				blocks[0].ID(): {Opinion: tortoise.Support, Support: 9},
+				blocks[1].ID(): {Opinion: tortoise.Abstain, Support: 4, Against: 5},
+				blocks[2].ID(): {Opinion: tortoise.Against, Against: 9},
+			},
+		},
+		verified: 7,
+	}
+	grpcService := NewDebugService(&TxAPIMock{}, NewNodeAPIMock(), mock, mock, nil, nil, nil)
+	shutDown := launchServer(t, grpcService)
+	defer shutDown()
+
+	// start a client
+	addr := "localhost:" + strconv.Itoa(cfg.NewGrpcServerPort)
+
+	// Set up a connection to the server.
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, conn.Close())
+	}()
+	c := extpb.NewDebugServiceClient(conn)
+
+	res, err := c.LayerInternals(context.Background(), &extpb.LayerInternalsRequest{Layer: 5})
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), res.Layer)
+	require.Equal(t, uint64(7), res.VerifiedLayer)
+	require.True(t, res.HareOutputKnown)
+	require.Equal(t, [][]byte{blocks[0].ID().Bytes(), blocks[1].ID().Bytes()}, res.HareOutput)
+	require.Len(t, res.Blocks, 3)
+	for i, blk := range res.Blocks {
+		require.Equal(t, blocks[i].ID().Bytes(), blk.Id)
+		require.Equal(t, globalAtx.ID().Bytes(), blk.AtxId)
+		require.Equal(t, [][]byte{types.BlockID{1}.Bytes()}, blk.BlockVotes)
+		require.Len(t, blk.ViewEdges, 2)
+	}
+	require.True(t, res.Blocks[0].InHareOutput)
+	require.Equal(t, extpb.TortoiseOpinion_TORTOISE_OPINION_SUPPORT, res.Blocks[0].Opinion)
+	require.Equal(t, int64(9), res.Blocks[0].Support)
+	require.Equal(t, extpb.ContextualValidity_CONTEXTUAL_VALIDITY_VALID, res.Blocks[0].Validity)
+
+	require.True(t, res.Blocks[1].InHareOutput)
+	require.Equal(t, extpb.TortoiseOpinion_TORTOISE_OPINION_ABSTAIN, res.Blocks[1].Opinion)
+	require.Equal(t, int64(4), res.Blocks[1].Support)
+	require.Equal(t, int64(5), res.Blocks[1].Against)
+	require.Equal(t, extpb.ContextualValidity_CONTEXTUAL_VALIDITY_UNKNOWN, res.Blocks[1].Validity)
+
+	require.False(t, res.Blocks[2].InHareOutput)
+	require.Equal(t, extpb.TortoiseOpinion_TORTOISE_OPINION_AGAINST, res.Blocks[2].Opinion)
+	require.Equal(t, extpb.ContextualValidity_CONTEXTUAL_VALIDITY_INVALID, res.Blocks[2].Validity)
+
+	// a layer without hare output or tortoise opinion
+	res, err = c.LayerInternals(context.Background(), &extpb.LayerInternalsRequest{Layer: 6})
+	require.NoError(t, err)
+	require.False(t, res.HareOutputKnown)
+	require.Empty(t, res.HareOutput)
+	require.Empty(t, res.Blocks)
+
+	_, err = c.LayerInternals(context.Background(), &extpb.LayerInternalsRequest{Layer: 8})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestDebugService_NetworkInfo(t *testing.T) {
+	local := node.GenerateRandomNodeData()
+	out := node.GenerateRandomNodeData()
+	in := node.GenerateRandomNodeData()
+	seen := time.Unix(1600000000, 0)
+	netInfo := NetworkInfoMock{info: p2p.NetworkInfo{
+		ID:                local.PublicKey(),
+		TCPAddress:        "0.0.0.0:7513",
+		UDPAddress:        "0.0.0.0:7513",
+		ExternalAddresses: []discovery.ExternalAddress{{Address: "1.2.3.4:7513", Reports: 3, LastSeen: seen}},
+		NAT:               p2p.NATPortMapped,
+		MappedAddress:     "1.2.3.4:7513",
+		GossipProtocols:   []string{"a", "b"},
+		GossipCache:       gossip.CacheStats{Size: 10, Capacity: 100, TTL: time.Hour, Hits: 3, Misses: 12, Evictions: 2},
+		Peers: []p2p.PeerInfo{
+			{ID: out.PublicKey(), Address: "5.6.7.8:7513", Outbound: true, Score: 0.5},
+			{ID: in.PublicKey()},
+		},
+		RoutingTable: []discovery.AddressInfo{
+			{Info: out, Tried: true, Attempts: 1, LastSeen: seen, Score: 0.5},
+		},
+	}}
+
+	grpcService := NewDebugService(&TxAPIMock{}, NewNodeAPIMock(), LayerInternalsMock{}, LayerInternalsMock{}, netInfo, nil, nil)
+	shutDown := launchServer(t, grpcService)
+	defer shutDown()
+
+	// start a client
+	addr := "localhost:" + strconv.Itoa(cfg.NewGrpcServerPort)
+
+	// Set up a connection to the server.
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, conn.Close())
+	}()
+	c := extpb.NewDebugServiceClient(conn)
+
+	res, err := c.NetworkInfo(context.Background(), &extpb.NetworkInfoRequest{})
+	require.NoError(t, err)
+	require.Equal(t, local.PublicKey().Bytes(), res.Id)
+	require.Equal(t, "0.0.0.0:7513", res.TcpAddress)
+	require.Equal(t, "0.0.0.0:7513", res.UdpAddress)
+	require.Equal(t, extpb.NATStatus_NAT_STATUS_PORT_MAPPED, res.Nat)
+	require.Equal(t, "1.2.3.4:7513", res.MappedAddress)
+	require.Equal(t, []string{"a", "b"}, res.GossipProtocols)
+	require.Equal(t, &extpb.GossipCacheInfo{Size: 10, Capacity: 100, Ttl: 3600, Hits: 3, Misses: 12, Evictions: 2}, res.GossipCache)
+	require.Len(t, res.ExternalAddresses, 1)
+	require.Equal(t, "1.2.3.4:7513", res.ExternalAddresses[0].Address)
+	require.Equal(t, uint64(3), res.ExternalAddresses[0].Reports)
+	require.Equal(t, uint64(seen.Unix()), res.ExternalAddresses[0].LastSeen)
+
+	require.Len(t, res.Peers, 2)
+	require.Equal(t, out.PublicKey().Bytes(), res.Peers[0].Id)
+	require.Equal(t, "5.6.7.8:7513", res.Peers[0].Address)
+	require.True(t, res.Peers[0].Outbound)
+	require.Equal(t, 0.5, res.Peers[0].Score)
+	require.Equal(t, in.PublicKey().Bytes(), res.Peers[1].Id)
+	require.False(t, res.Peers[1].Outbound)
+
+	require.Len(t, res.RoutingTable, 1)
+	entry := res.RoutingTable[0]
+	require.Equal(t, out.ID.Bytes(), entry.Id)
+	require.Equal(t, fmt.Sprintf("%v:%d", out.IP, out.ProtocolPort), entry.Address)
+	require.Equal(t, uint32(out.DiscoveryPort), entry.DiscoveryPort)
+	require.True(t, entry.Tried)
+	require.Equal(t, uint64(1), entry.Attempts)
+	require.Equal(t, uint64(seen.Unix()), entry.LastSeen)
+	require.Zero(t, entry.LastAttempt)
+	require.Zero(t, entry.LastSuccess)
+	require.Equal(t, 0.5, entry.Score)
+
+	// the network of the node may not describe itself
+	_, err = DebugService{}.NetworkInfo(context.Background(), &extpb.NetworkInfoRequest{})
+	require.Equal(t, codes.Unavailable, status.Code(err))
+}
+
+func TestDebugService_Peers(t *testing.T) {
+	early := node.GenerateRandomNodeData()
+	late := node.GenerateRandomNodeData()
+	unconnected := node.GenerateRandomNodeData()
+	connected := time.Unix(1600000000, 0)
+	active := time.Unix(1600000100, 0)
+	netInfo := NetworkInfoMock{info: p2p.NetworkInfo{
+		Peers: []p2p.PeerInfo{
+			{ID: unconnected.PublicKey()},
+			{ID: late.PublicKey(), Address: "1.2.3.4:7513", ConnectedSince: connected.Add(time.Minute), LastActivity: active},
+			{
+				ID:             early.PublicKey(),
+				Address:        "5.6.7.8:7513",
+				Outbound:       true,
+				Score:          0.5,
+				ConnectedSince: connected,
+				LastActivity:   active,
+				Protocols:      []string{"a", "b"},
+				Reputation:     -2.5,
+			},
+		},
+	}}
+
+	grpcService := NewDebugService(&TxAPIMock{}, NewNodeAPIMock(), LayerInternalsMock{}, LayerInternalsMock{}, netInfo, nil, nil)
+	shutDown := launchServer(t, grpcService)
+	defer shutDown()
+
+	// start a client
+	addr := "localhost:" + strconv.Itoa(cfg.NewGrpcServerPort)
+
+	// Set up a connection to the server.
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, conn.Close())
+	}()
+	c := extpb.NewDebugServiceClient(conn)
+
+	res, err := c.Peers(context.Background(), &extpb.PeersRequest{})
+	require.NoError(t, err)
+	require.Len(t, res.Peers, 3)
+
+	// the longest connected peer comes first
+	require.Equal(t, early.PublicKey().Bytes(), res.Peers[0].Id)
+	require.Equal(t, "5.6.7.8:7513", res.Peers[0].Address)
+	require.True(t, res.Peers[0].Outbound)
+	require.Equal(t, 0.5, res.Peers[0].Score)
+	require.Equal(t, uint64(connected.Unix()), res.Peers[0].ConnectedSince)
+	require.Equal(t, uint64(active.Unix()), res.Peers[0].LastActivity)
+	require.Equal(t, []string{"a", "b"}, res.Peers[0].Protocols)
+	require.Equal(t, -2.5, res.Peers[0].Reputation)
+
+	require.Equal(t, late.PublicKey().Bytes(), res.Peers[1].Id)
+	require.False(t, res.Peers[1].Outbound)
+	require.Equal(t, uint64(connected.Add(time.Minute).Unix()), res.Peers[1].ConnectedSince)
+	require.Empty(t, res.Peers[1].Protocols)
+
+	// peers without an open connection come last
+	require.Equal(t, unconnected.PublicKey().Bytes(), res.Peers[2].Id)
+	require.Empty(t, res.Peers[2].Address)
+	require.Zero(t, res.Peers[2].ConnectedSince)
+	require.Zero(t, res.Peers[2].LastActivity)
+
+	// the network of the node may not describe itself
+	_, err = DebugService{}.Peers(context.Background(), &extpb.PeersRequest{})
+	require.Equal(t, codes.Unavailable, status.Code(err))
+}
+
+func TestDebugService_PeerEvents(t *testing.T) {
+	grpcService := NewDebugService(&TxAPIMock{}, NewNodeAPIMock(), LayerInternalsMock{}, LayerInternalsMock{}, nil, nil, nil)
+	shutDown := launchServer(t, grpcService)
+	defer shutDown()
+
+	// start a client
+	addr := "localhost:" + strconv.Itoa(cfg.NewGrpcServerPort)
+
+	// Set up a connection to the server.
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, conn.Close())
+	}()
+	c := extpb.NewDebugServiceClient(conn)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := c.PeerEvents(ctx, &extpb.PeerEventsRequest{})
+	require.NoError(t, err)
+	time.Sleep(time.Second) // wait for the server to subscribe
+
+	peer := node.GenerateRandomNodeData()
+	now := time.Unix(1600000000, 0)
+	events.Publish(events.PeerUpdate{ID: peer.PublicKey().Bytes(), Connected: true, Address: "1.2.3.4:7513",
+		Outbound: true, InboundPeers: 3, OutboundPeers: 5, Time: now})
+	events.Publish(events.PeerUpdate{ID: peer.PublicKey().Bytes(), Outbound: true, Reputation: -2.5,
+		InboundPeers: 3, OutboundPeers: 4, Time: now.Add(time.Minute)})
+
+	res, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, extpb.PeerEventKind_PEER_EVENT_KIND_CONNECTED, res.Kind)
+	require.Equal(t, peer.PublicKey().Bytes(), res.Id)
+	require.Equal(t, "1.2.3.4:7513", res.Address)
+	require.True(t, res.Outbound)
+	require.Equal(t, uint64(3), res.InboundPeers)
+	require.Equal(t, uint64(5), res.OutboundPeers)
+	require.Equal(t, uint64(now.Unix()), res.Time)
+
+	res, err = stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, extpb.PeerEventKind_PEER_EVENT_KIND_DISCONNECTED, res.Kind)
+	require.Empty(t, res.Address)
+	require.Equal(t, -2.5, res.Reputation)
+	require.Equal(t, uint64(4), res.OutboundPeers)
+	require.Equal(t, uint64(now.Add(time.Minute).Unix()), res.Time)
+}
+
+type FetchMock struct {
+	kind spacesync.ObjectKind
+	id   types.Hash32
+	res  *spacesync.FetchResult
+}
+
+func (f *FetchMock) Fetch(kind spacesync.ObjectKind, id types.Hash32) (*spacesync.FetchResult, error) {
+	f.kind, f.id = kind, id
+	if f.res == nil {
+		return nil, errors.New("no peers")
+	}
+	return f.res, nil
+}
+
+func TestDebugService_Fetch(t *testing.T) {
+	peer := node.GenerateRandomNodeData().PublicKey()
+	fetcher := &FetchMock{res: &spacesync.FetchResult{Peers: []p2ppeers.Peer{peer}, Stored: true, Layer: 7}}
+	grpcService := NewDebugService(&TxAPIMock{}, NewNodeAPIMock(), LayerInternalsMock{}, LayerInternalsMock{}, nil, fetcher, nil)
+	shutDown := launchServer(t, grpcService)
+	defer shutDown()
+
+	// start a client
+	addr := "localhost:" + strconv.Itoa(cfg.NewGrpcServerPort)
+
+	// Set up a connection to the server.
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, conn.Close())
+	}()
+	c := extpb.NewDebugServiceClient(conn)
+	ctx := context.Background()
+
+	blockID := types.NewExistingBlock(7, []byte("data")).ID()
+	res, err := c.Fetch(ctx, &extpb.FetchRequest{Kind: extpb.MeshObjectKind_MESH_OBJECT_KIND_BLOCK, Id: blockID.Bytes()})
+	require.NoError(t, err)
+	require.Equal(t, spacesync.BlockObject, fetcher.kind)
+	require.Equal(t, blockID.AsHash32(), fetcher.id)
+	require.Equal(t, [][]byte{peer.Bytes()}, res.Peers)
+	require.True(t, res.Stored)
+	require.False(t, res.Local)
+	require.Equal(t, uint64(7), res.Layer)
+
+	fetcher.res = &spacesync.FetchResult{Peers: []p2ppeers.Peer{peer}, StoreErr: errors.New("invalid atx")}
+	res, err = c.Fetch(ctx, &extpb.FetchRequest{Kind: extpb.MeshObjectKind_MESH_OBJECT_KIND_ATX, Id: globalAtx.ID().Bytes()})
+	require.NoError(t, err)
+	require.Equal(t, spacesync.AtxObject, fetcher.kind)
+	require.Equal(t, globalAtx.ID().Hash32(), fetcher.id)
+	require.False(t, res.Stored)
+	require.Equal(t, "invalid atx", res.Error)
+
+	fetcher.res = nil
+	_, err = c.Fetch(ctx, &extpb.FetchRequest{Kind: extpb.MeshObjectKind_MESH_OBJECT_KIND_TRANSACTION, Id: globalTx.ID().Bytes()})
+	require.Equal(t, codes.Unavailable, status.Code(err))
+	require.Equal(t, spacesync.TransactionObject, fetcher.kind)
+
+	_, err = c.Fetch(ctx, &extpb.FetchRequest{Kind: extpb.MeshObjectKind_MESH_OBJECT_KIND_BLOCK, Id: blockID.Bytes()[:20]})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = c.Fetch(ctx, &extpb.FetchRequest{Id: globalTx.ID().Bytes()})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = DebugService{}.Fetch(ctx, &extpb.FetchRequest{Kind: extpb.MeshObjectKind_MESH_OBJECT_KIND_TRANSACTION, Id: globalTx.ID().Bytes()})
+	require.Equal(t, codes.Unavailable, status.Code(err))
+}
+
+type BeaconMock struct {
+	statuses map[types.EpochID]*eligibility.BeaconStatus
+}
+
+func (b BeaconMock) BeaconStatus(epoch types.EpochID) (*eligibility.BeaconStatus, error) {
+	s, ok := b.statuses[epoch]
+	if !ok {
+		return nil, errors.New("unknown epoch")
+	}
+	return s, nil
+}
+
+func TestDebugService_BeaconStatus(t *testing.T) {
+	beacon := BeaconMock{statuses: map[types.EpochID]*eligibility.BeaconStatus{
+		3: {Epoch: 3, Computed: 5, Fallbacks: 2, Value: 1234, Proposals: 4, ValidBlocks: 0, Fallback: true},
+	}}
+	grpcService := NewDebugService(&TxAPIMock{}, NewNodeAPIMock(), LayerInternalsMock{}, LayerInternalsMock{}, nil, nil, beacon)
+	ctx := context.Background()
+
+	res, err := grpcService.BeaconStatus(ctx, &extpb.BeaconStatusRequest{Epoch: 3})
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), res.Epoch)
+	require.Equal(t, uint32(5), res.Computed)
+	require.Equal(t, uint32(2), res.Fallbacks)
+	require.Equal(t, uint32(1234), res.Value)
+	require.Equal(t, uint32(4), res.Proposals)
+	require.True(t, res.Fallback)
+
+	_, err = grpcService.BeaconStatus(ctx, &extpb.BeaconStatusRequest{Epoch: 4})
+	require.Equal(t, codes.NotFound, status.Code(err))
+	_, err = DebugService{}.BeaconStatus(ctx, &extpb.BeaconStatusRequest{Epoch: 3})
+	require.Equal(t, codes.Unavailable, status.Code(err))
+}
+
+type AuditMock struct {
+	FetchMock
+	runs int
+	last *spacesync.AuditReport
+}
+
+func (a *AuditMock) Audit() *spacesync.AuditReport {
+	a.runs++
+	a.last = &spacesync.AuditReport{
+		Started:   time.Unix(1000, 0),
+		Duration:  1500 * time.Millisecond,
+		FromLayer: 3,
+		ToLayer:   12,
+		Missing:   map[spacesync.ObjectKind]int{spacesync.BlockObject: 1, spacesync.TransactionObject: 4},
+		Refetched: map[spacesync.ObjectKind]int{spacesync.TransactionObject: 3},
+	}
+	return a.last
+}
+
+func (a *AuditMock) LastAudit() *spacesync.AuditReport {
+	return a.last
+}
+
+func TestDebugService_DataAudit(t *testing.T) {
+	auditor := &AuditMock{}
+	grpcService := NewDebugService(&TxAPIMock{}, NewNodeAPIMock(), LayerInternalsMock{}, LayerInternalsMock{}, nil, auditor, nil)
+	ctx := context.Background()
+
+	_, err := grpcService.DataAudit(ctx, &extpb.DataAuditRequest{})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	res, err := grpcService.DataAudit(ctx, &extpb.DataAuditRequest{Run: true})
+	require.NoError(t, err)
+	require.Equal(t, 1, auditor.runs)
+	require.Equal(t, &extpb.DataAuditResponse{
+		Started:       1000,
+		DurationMs:    1500,
+		FromLayer:     3,
+		ToLayer:       12,
+		MissingBlocks: 1,
+		MissingTxs:    4,
+		RefetchedTxs:  3,
+	}, res)
+
+	// the latest report is returned without running another audit
+	res, err = grpcService.DataAudit(ctx, &extpb.DataAuditRequest{})
+	require.NoError(t, err)
+	require.Equal(t, 1, auditor.runs)
+	require.Equal(t, uint32(4), res.MissingTxs)
+
+	_, err = NewDebugService(&TxAPIMock{}, NewNodeAPIMock(), LayerInternalsMock{}, LayerInternalsMock{}, nil, &FetchMock{}, nil).DataAudit(ctx, &extpb.DataAuditRequest{Run: true})
+	require.Equal(t, codes.Unavailable, status.Code(err))
+}
+
+func TestAdminService_EventsStream(t *testing.T) {
+	grpcService := NewAdminService(&TxAPIMock{}, NewNodeAPIMock(), ActivationMock{}, nil, nil, nil, nil, "", "", "")
+	shutDown := launchServer(t, grpcService)
+	defer shutDown()
+
+	// start a client
+	addr := "localhost:" + strconv.Itoa(cfg.NewGrpcServerPort)
+
+	// Set up a connection to the server.
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, conn.Close())
+	}()
+	c := extpb.NewAdminServiceClient(conn)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := c.EventsStream(ctx, &extpb.EventsStreamRequest{})
+	require.NoError(t, err)
+	time.Sleep(time.Second) // wait for the server to subscribe
+
+	before := uint64(time.Now().Unix())
+	events.Publish(events.NodeEvent{Kind: events.NodeEventSyncStarted, Layer: 3})
+	// events of other channels aren't streamed
+	events.Publish(events.ValidBlock{ID: "abcd", Valid: true})
+	events.Publish(events.NodeEvent{Kind: events.NodeEventAtxPublished, Layer: 10, Details: "1234"})
+
+	res, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, extpb.NodeEventKind_NODE_EVENT_KIND_SYNC_STARTED, res.Kind)
+	require.Equal(t, uint64(3), res.Layer)
+	require.True(t, res.Timestamp >= before)
+
+	res, err = stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, extpb.NodeEventKind_NODE_EVENT_KIND_ATX_PUBLISHED, res.Kind)
+	require.Equal(t, uint64(10), res.Layer)
+	require.Equal(t, "1234", res.Details)
+}
+
+func TestAdminService_Checkpoint(t *testing.T) {
+	types.SetLayersPerEpoch(layersPerEpoch)
+	stateAPI := NewNodeAPIMock()
+	for i := 1; i <= 3; i++ {
+		addr := types.BytesToAddress([]byte{byte(i)})
+		stateAPI.nonces[addr] = uint64(i)
+		stateAPI.balances[addr] = big.NewInt(int64(i * 100))
+	}
+	root := types.HexToHash32("1234")
+	stateAPI.roots[ValidatedLayerID] = root
+	epoch := types.LayerID(ValidatedLayerID).GetEpoch()
+	atxs := ActivationMock{atxs: map[types.EpochID][]*types.ActivationTx{
+		epoch - 1: {types.NewActivationTx(types.NIPSTChallenge{NodeID: types.NodeID{Key: "a"}}, types.HexToAddress("22222"), &types.NIPST{}, nil)},
+		epoch:     {types.NewActivationTx(types.NIPSTChallenge{NodeID: types.NodeID{Key: "b"}}, types.HexToAddress("22222"), &types.NIPST{}, nil)},
+	}}
+	dir, err := ioutil.TempDir("", "checkpoint")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	grpcService := NewAdminService(&TxAPIMock{}, stateAPI, atxs, nil, nil, nil, nil, dir, "", "")
+	shutDown := launchServer(t, grpcService)
+	defer shutDown()
+
+	// start a client
+	addr := "localhost:" + strconv.Itoa(cfg.NewGrpcServerPort)
+
+	// Set up a connection to the server.
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, conn.Close())
+	}()
+	c := extpb.NewAdminServiceClient(conn)
+
+	// the checkpoint is streamed back
+	stream, err := c.Checkpoint(context.Background(), &extpb.CheckpointRequest{})
+	require.NoError(t, err)
+	info, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, uint64(ValidatedLayerID), info.Layer)
+	require.Equal(t, root.Bytes(), info.StateRoot)
+	require.Equal(t, uint64(3), info.Accounts)
+	require.Equal(t, uint64(2), info.Atxs)
+	require.Empty(t, info.Path)
+	var data []byte
+	for {
+		res, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		data = append(data, res.Data...)
+	}
+	require.Equal(t, info.Size, uint64(len(data)))
+	require.Equal(t, info.Hash, types.CalcHash32(data).Bytes())
+	cp, err := checkpoint.Decode(data)
+	require.NoError(t, err)
+	require.Equal(t, types.LayerID(ValidatedLayerID), cp.Layer)
+	require.Len(t, cp.Accounts, 3)
+	require.Equal(t, atxs.atxs[epoch-1][0].ID(), cp.Atxs[0].ID())
+
+	// or written to the checkpoint directory
+	stream, err = c.Checkpoint(context.Background(), &extpb.CheckpointRequest{WriteToFile: true})
+	require.NoError(t, err)
+	res, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, fmt.Sprintf("checkpoint-%d", ValidatedLayerID)), res.Path)
+	require.Empty(t, res.Data)
+	_, err = stream.Recv()
+	require.Equal(t, io.EOF, err)
+	written, err := ioutil.ReadFile(res.Path)
+	require.NoError(t, err)
+	require.Equal(t, data, written)
+
+	// a node without a checkpoint directory can only stream checkpoints
+	err = AdminService{}.Checkpoint(&extpb.CheckpointRequest{WriteToFile: true}, nil)
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+func TestAdminService_Recover(t *testing.T) {
+	cp := &checkpoint.Checkpoint{
+		Layer:     10,
+		StateRoot: types.HexToHash32("1234"),
+		Accounts:  []checkpoint.Account{{Address: types.HexToAddress("1"), Nonce: 1, Balance: 100}},
+	}
+	data, err := cp.Encode()
+	require.NoError(t, err)
+	hash := types.CalcHash32(data)
+	dir, err := ioutil.TempDir("", "recover")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "checkpoint")
+	require.NoError(t, ioutil.WriteFile(path, data, 0600))
+
+	recoveryFile := filepath.Join(dir, "node", "recovery-checkpoint")
+	grpcService := NewAdminService(&TxAPIMock{}, NewNodeAPIMock(), ActivationMock{}, nil, nil, nil, nil, "", "", recoveryFile)
+	shutDown := launchServer(t, grpcService)
+	defer shutDown()
+
+	// start a client
+	addr := "localhost:" + strconv.Itoa(cfg.NewGrpcServerPort)
+
+	// Set up a connection to the server.
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, conn.Close())
+	}()
+	c := extpb.NewAdminServiceClient(conn)
+
+	t.Run("missing uri", func(t *testing.T) {
+		_, err := c.Recover(context.Background(), &extpb.RecoverRequest{})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+	t.Run("hash mismatch", func(t *testing.T) {
+		_, err := c.Recover(context.Background(), &extpb.RecoverRequest{Uri: path, Hash: types.HexToHash32("12").Bytes()})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+		_, err = os.Stat(recoveryFile)
+		require.True(t, os.IsNotExist(err))
+	})
+	t.Run("staged", func(t *testing.T) {
+		res, err := c.Recover(context.Background(), &extpb.RecoverRequest{Uri: path, Hash: hash.Bytes()})
+		require.NoError(t, err)
+		require.Equal(t, uint64(10), res.Layer)
+		require.Equal(t, cp.StateRoot.Bytes(), res.StateRoot)
+		require.Equal(t, hash.Bytes(), res.Hash)
+		staged, err := ioutil.ReadFile(recoveryFile)
+		require.NoError(t, err)
+		require.Equal(t, data, staged)
+	})
+}
+
+func TestAdminService_Export(t *testing.T) {
+	types.SetLayersPerEpoch(layersPerEpoch)
+	coinbase := globalAtx.Coinbase
+	tx := &TxAPIMock{coinbaseRewards: map[types.Address][]mesh.CoinbaseReward{coinbase: {
+		{Layer: 3, Blocks: 1, TotalReward: 110, LayerRewardEstimate: 100},
+		{Layer: 5, Blocks: 2, TotalReward: 230, LayerRewardEstimate: 200},
+	}}}
+	atxs := ActivationMock{atxs: map[types.EpochID][]*types.ActivationTx{
+		0: {types.NewActivationTx(types.NIPSTChallenge{NodeID: types.NodeID{Key: "aa"}, PubLayerID: 2}, coinbase, &types.NIPST{Space: 1024}, nil)},
+		1: {
+			types.NewActivationTx(types.NIPSTChallenge{NodeID: types.NodeID{Key: "bb"}, PubLayerID: 6}, coinbase, nil, nil),
+			types.NewActivationTx(types.NIPSTChallenge{NodeID: types.NodeID{Key: "cc"}, PubLayerID: 9}, coinbase, nil, nil),
+		},
+	}}
+	grpcService := NewAdminService(tx, NewNodeAPIMock(), atxs, nil, nil, nil, nil, "", "", "")
+	shutDown := launchServer(t, grpcService)
+	defer shutDown()
+
+	conn, err := grpc.Dial("localhost:"+strconv.Itoa(cfg.NewGrpcServerPort), grpc.WithInsecure())
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, conn.Close())
+	}()
+	c := extpb.NewAdminServiceClient(conn)
+
+	export := func(req *extpb.ExportRequest) ([]*extpb.ExportResponse, error) {
+		stream, err := c.Export(context.Background(), req)
+		require.NoError(t, err)
+		var responses []*extpb.ExportResponse
+		for {
+			res, err := stream.Recv()
+			if err == io.EOF {
+				return responses, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			responses = append(responses, res)
+		}
+	}
+
+	t.Run("Transactions", func(t *testing.T) {
+		// a response for every layer, up to the latest layer in state
+		responses, err := export(&extpb.ExportRequest{Dataset: extpb.ExportDataset_EXPORT_DATASET_TRANSACTIONS, StartLayer: 6})
+		require.NoError(t, err)
+		require.Len(t, responses, ValidatedLayerID-6+1)
+		for i, res := range responses {
+			require.Len(t, res.Transactions, 1)
+			require.Equal(t, uint64(6+i), res.Transactions[0].Layer)
+			require.Equal(t, globalTx.ID().Bytes(), res.Transactions[0].Id)
+			require.Equal(t, globalTx.Origin().Bytes(), res.Transactions[0].Origin)
+			require.Equal(t, globalTx.Amount, res.Transactions[0].Amount)
+		}
+	})
+
+	t.Run("Rewards", func(t *testing.T) {
+		responses, err := export(&extpb.ExportRequest{Dataset: extpb.ExportDataset_EXPORT_DATASET_REWARDS, StartLayer: 1, EndLayer: 4})
+		require.NoError(t, err)
+		require.Len(t, responses, 1)
+		require.Len(t, responses[0].Rewards, 1)
+		require.True(t, proto.Equal(&extpb.ExportedReward{
+			Layer:       3,
+			Coinbase:    coinbase.Bytes(),
+			Blocks:      1,
+			Total:       110,
+			LayerReward: 100,
+		}, responses[0].Rewards[0]))
+	})
+
+	t.Run("Atxs", func(t *testing.T) {
+		// ATXs published after the end layer aren't exported
+		responses, err := export(&extpb.ExportRequest{Dataset: extpb.ExportDataset_EXPORT_DATASET_ATXS})
+		require.NoError(t, err)
+		require.Len(t, responses, 2)
+		require.Len(t, responses[0].Atxs, 1)
+		require.Equal(t, atxs.atxs[0][0].ID().Bytes(), responses[0].Atxs[0].Id)
+		require.Equal(t, uint64(1024), responses[0].Atxs[0].Space)
+		require.Equal(t, util.Hex2Bytes("aa"), responses[0].Atxs[0].SmesherId)
+		require.Len(t, responses[1].Atxs, 1)
+		require.Equal(t, uint64(6), responses[1].Atxs[0].Layer)
+	})
+
+	t.Run("InvalidArguments", func(t *testing.T) {
+		for _, req := range []*extpb.ExportRequest{
+			{},
+			{Dataset: extpb.ExportDataset_EXPORT_DATASET_ATXS, EndLayer: ValidatedLayerID + 1},
+			{Dataset: extpb.ExportDataset_EXPORT_DATASET_ATXS, StartLayer: 5, EndLayer: 4},
+		} {
+			_, err := export(req)
+			require.Equal(t, codes.InvalidArgument, status.Code(err), req)
+		}
+	})
+}
+
+func TestAdminService_Ban(t *testing.T) {
+	bans := &PeerBanMock{}
+	grpcService := NewAdminService(&TxAPIMock{}, NewNodeAPIMock(), ActivationMock{}, bans, nil, nil, nil, "", "", "")
+	shutDown := launchServer(t, grpcService)
+	defer shutDown()
+
+	// start a client
+	addr := "localhost:" + strconv.Itoa(cfg.NewGrpcServerPort)
+
+	// Set up a connection to the server.
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, conn.Close())
+	}()
+	c := extpb.NewAdminServiceClient(conn)
+	ctx := context.Background()
+
+	peer := p2pcrypto.NewRandomPubkey()
+	_, err = c.Ban(ctx, &extpb.BanRequest{Target: &extpb.BanTarget{PeerId: peer.Bytes()}, Duration: 60})
+	require.NoError(t, err)
+	_, err = c.Ban(ctx, &extpb.BanRequest{Target: &extpb.BanTarget{Ip: "1.2.3.4"}})
+	require.NoError(t, err)
+
+	res, err := c.ListBans(ctx, &extpb.ListBansRequest{})
+	require.NoError(t, err)
+	require.Len(t, res.Bans, 2)
+	require.Equal(t, peer.Bytes(), res.Bans[0].Target.PeerId)
+	require.Empty(t, res.Bans[0].Target.Ip)
+	require.Equal(t, uint64(1600000000), res.Bans[0].Created)
+	require.Equal(t, uint64(1600000060), res.Bans[0].Expires)
+	require.Equal(t, "1.2.3.4", res.Bans[1].Target.Ip)
+	require.Empty(t, res.Bans[1].Target.PeerId)
+	require.Zero(t, res.Bans[1].Expires)
+
+	unbanned, err := c.Unban(ctx, &extpb.UnbanRequest{Target: &extpb.BanTarget{Ip: "1.2.3.4"}})
+	require.NoError(t, err)
+	require.True(t, unbanned.Found)
+	unbanned, err = c.Unban(ctx, &extpb.UnbanRequest{Target: &extpb.BanTarget{Ip: "1.2.3.4"}})
+	require.NoError(t, err)
+	require.False(t, unbanned.Found)
+	unbanned, err = c.Unban(ctx, &extpb.UnbanRequest{Target: &extpb.BanTarget{PeerId: peer.Bytes()}})
+	require.NoError(t, err)
+	require.True(t, unbanned.Found)
+	res, err = c.ListBans(ctx, &extpb.ListBansRequest{})
+	require.NoError(t, err)
+	require.Empty(t, res.Bans)
+
+	// exactly one of the peer id and the ip must be a valid target
+	for _, target := range []*extpb.BanTarget{
+		nil,
+		{},
+		{PeerId: peer.Bytes(), Ip: "1.2.3.4"},
+		{Ip: "1.2.3"},
+		{PeerId: []byte{1, 2, 3}},
+	} {
+		_, err = c.Ban(ctx, &extpb.BanRequest{Target: target})
+		require.Equal(t, codes.InvalidArgument, status.Code(err), "%v", target)
+		_, err = c.Unban(ctx, &extpb.UnbanRequest{Target: target})
+		require.Equal(t, codes.InvalidArgument, status.Code(err), "%v", target)
+	}
+
+	// simulated networks don't support banning
+	_, err = AdminService{}.Ban(ctx, &extpb.BanRequest{Target: &extpb.BanTarget{Ip: "1.2.3.4"}})
+	require.Equal(t, codes.Unavailable, status.Code(err))
+	_, err = AdminService{}.ListBans(ctx, &extpb.ListBansRequest{})
+	require.Equal(t, codes.Unavailable, status.Code(err))
+}
+
+type SyncLimitsMock struct {
+	limits spacesync.Limits
+}
+
+func (s *SyncLimitsMock) SetSyncLimits(limits spacesync.Limits) {
+	s.limits = limits
+}
+
+func (s *SyncLimitsMock) SyncLimits() spacesync.Limits {
+	return s.limits
+}
+
+func TestAdminService_SyncLimits(t *testing.T) {
+	limits := &SyncLimitsMock{limits: spacesync.Limits{Bandwidth: 1 << 20}}
+	grpcService := NewAdminService(&TxAPIMock{}, NewNodeAPIMock(), ActivationMock{}, nil, limits, nil, nil, "", "", "")
+	shutDown := launchServer(t, grpcService)
+	defer shutDown()
+
+	// start a client
+	addr := "localhost:" + strconv.Itoa(cfg.NewGrpcServerPort)
+
+	// Set up a connection to the server.
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, conn.Close())
+	}()
+	c := extpb.NewAdminServiceClient(conn)
+	ctx := context.Background()
+
+	res, err := c.SyncLimits(ctx, &extpb.SyncLimitsRequest{})
+	require.NoError(t, err)
+	require.Equal(t, uint64(1<<20), res.Limits.Bandwidth)
+	require.Zero(t, res.Limits.Requests)
+
+	_, err = c.SetSyncLimits(ctx, &extpb.SetSyncLimitsRequest{Limits: &extpb.SyncLimits{Bandwidth: 1000, Requests: 2}})
+	require.NoError(t, err)
+	require.Equal(t, spacesync.Limits{Bandwidth: 1000, Requests: 2}, limits.limits)
+	res, err = c.SyncLimits(ctx, &extpb.SyncLimitsRequest{})
+	require.NoError(t, err)
+	require.Equal(t, uint64(1000), res.Limits.Bandwidth)
+	require.Equal(t, uint32(2), res.Limits.Requests)
+
+	_, err = c.SetSyncLimits(ctx, &extpb.SetSyncLimitsRequest{})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = c.SetSyncLimits(ctx, &extpb.SetSyncLimitsRequest{Limits: &extpb.SyncLimits{Bandwidth: 1 << 40}})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = AdminService{}.SyncLimits(ctx, &extpb.SyncLimitsRequest{})
+	require.Equal(t, codes.Unavailable, status.Code(err))
+}
+
+type DatabaseMock struct {
+	results  []database.CompactionResult
+	err      error
+	registry *database.Registry
+}
+
+func (d DatabaseMock) Compact() ([]database.CompactionResult, error) {
+	return d.results, d.err
+}
+
+func (d DatabaseMock) Snapshot() (*database.Snapshot, error) {
+	if d.registry == nil {
+		return nil, errors.New("no databases")
+	}
+	return d.registry.Snapshot()
+}
+
+func TestAdminService_CompactDatabases(t *testing.T) {
+	dbs := DatabaseMock{results: []database.CompactionResult{
+		{Name: "mesh/blocks", SizeBefore: 5000, SizeAfter: 3000, Duration: 2 * time.Second},
+		{Name: "state", SizeBefore: 1000, SizeAfter: 1200, Duration: time.Second},
+		{Name: "store", SizeBefore: 700, Err: errors.New("disk failure")},
+	}}
+	grpcService := NewAdminService(&TxAPIMock{}, NewNodeAPIMock(), ActivationMock{}, nil, nil, dbs, nil, "", "", "")
+	ctx := context.Background()
+
+	res, err := grpcService.CompactDatabases(ctx, &extpb.CompactDatabasesRequest{})
+	require.NoError(t, err)
+	require.Len(t, res.Databases, 3)
+	require.Equal(t, &extpb.DatabaseCompaction{Name: "mesh/blocks", SizeBefore: 5000, SizeAfter: 3000, Reclaimed: 2000, DurationMs: 2000}, res.Databases[0])
+	require.Equal(t, int64(-200), res.Databases[1].Reclaimed)
+	require.Equal(t, "disk failure", res.Databases[2].Error)
+	require.Zero(t, res.Databases[2].Reclaimed)
+	require.Equal(t, int64(1800), res.Reclaimed)
+
+	grpcService.Databases = DatabaseMock{err: database.ErrCompactionRunning}
+	_, err = grpcService.CompactDatabases(ctx, &extpb.CompactDatabasesRequest{})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	_, err = AdminService{}.CompactDatabases(ctx, &extpb.CompactDatabasesRequest{})
+	require.Equal(t, codes.Unavailable, status.Code(err))
+}
+
+func TestAdminService_Backup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "backup")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	db, err := database.NewLDBDatabase(filepath.Join(dir, "data"), 0, 0, log.NewDefault(t.Name()))
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, db.Put([]byte("key"), []byte("value")))
+	registry := database.NewRegistry(log.NewDefault(t.Name()))
+	registry.Register("state", db)
+
+	backupDir := filepath.Join(dir, "backups")
+	grpcService := NewAdminService(&TxAPIMock{}, NewNodeAPIMock(), ActivationMock{}, nil, nil, DatabaseMock{registry: registry}, nil, "", backupDir, "")
+	shutDown := launchServer(t, grpcService)
+	defer shutDown()
+
+	// start a client
+	addr := "localhost:" + strconv.Itoa(cfg.NewGrpcServerPort)
+
+	// Set up a connection to the server.
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, conn.Close())
+	}()
+	c := extpb.NewAdminServiceClient(conn)
+
+	// the tarball is streamed back
+	stream, err := c.Backup(context.Background(), &extpb.BackupRequest{})
+	require.NoError(t, err)
+	info, err := stream.Recv()
+	require.NoError(t, err)
+	require.Len(t, info.Databases, 1)
+	require.Equal(t, "state", info.Databases[0].Name)
+	require.Equal(t, uint64(1), info.Databases[0].Keys)
+	require.Equal(t, info.Databases[0].Size, info.Size)
+	require.Empty(t, info.Path)
+	var data []byte
+	for {
+		res, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		data = append(data, res.Data...)
+	}
+	tr := tar.NewReader(bytes.NewReader(data))
+	hdr, err := tr.Next()
+	require.NoError(t, err)
+	require.Equal(t, database.ManifestFile, hdr.Name)
+	hdr, err = tr.Next()
+	require.NoError(t, err)
+	require.Equal(t, "state.kv", hdr.Name)
+	dump, err := ioutil.ReadAll(tr)
+	require.NoError(t, err)
+	hash := sha256.Sum256(dump)
+	require.Equal(t, hash[:], info.Databases[0].Hash)
+
+	// or written to the backup directory
+	stream, err = c.Backup(context.Background(), &extpb.BackupRequest{WriteToDir: true})
+	require.NoError(t, err)
+	res, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(backupDir, fmt.Sprintf("backup-%d", res.Created)), res.Path)
+	require.Empty(t, res.Data)
+	_, err = stream.Recv()
+	require.Equal(t, io.EOF, err)
+	written, err := ioutil.ReadFile(filepath.Join(res.Path, "state.kv"))
+	require.NoError(t, err)
+	require.Equal(t, dump, written)
+
+	// a node without a backup directory can only stream backups
+	err = AdminService{Databases: DatabaseMock{}}.Backup(&extpb.BackupRequest{WriteToDir: true}, nil)
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+	err = AdminService{}.Backup(&extpb.BackupRequest{}, nil)
+	require.Equal(t, codes.Unavailable, status.Code(err))
+}
+
+type CheckerMock struct {
+	report  *dbcheck.Report
+	repairs []bool
+}
+
+func (c *CheckerMock) Check(repair bool) *dbcheck.Report {
+	c.repairs = append(c.repairs, repair)
+	return c.report
+}
+
+func TestAdminService_CheckDatabases(t *testing.T) {
+	checker := &CheckerMock{report: &dbcheck.Report{
+		FromLayer:    1,
+		ToLayer:      10,
+		Blocks:       30,
+		Transactions: 100,
+		Atxs:         60,
+		StateLayer:   9,
+		Issues:       []dbcheck.Issue{{Kind: dbcheck.MissingTransaction, Layer: 4, ID: types.Hash32{1}, Repaired: true, Details: "block 1"}},
+		Duration:     3 * time.Second,
+	}}
+	grpcService := NewAdminService(&TxAPIMock{}, NewNodeAPIMock(), ActivationMock{}, nil, nil, nil, checker, "", "", "")
+	ctx := context.Background()
+
+	res, err := grpcService.CheckDatabases(ctx, &extpb.CheckDatabasesRequest{Repair: true})
+	require.NoError(t, err)
+	require.Equal(t, []bool{true}, checker.repairs)
+	require.Equal(t, uint64(1), res.FromLayer)
+	require.Equal(t, uint64(10), res.ToLayer)
+	require.Equal(t, uint64(30), res.Blocks)
+	require.Equal(t, uint64(100), res.Transactions)
+	require.Equal(t, uint64(60), res.Atxs)
+	require.Equal(t, uint64(9), res.StateLayer)
+	require.Equal(t, uint64(3000), res.DurationMs)
+	require.Len(t, res.Issues, 1)
+	require.Equal(t, &extpb.DatabaseIssue{Kind: "missing transaction", Layer: 4, Id: types.Hash32{1}.Bytes(), Repaired: true, Details: "block 1"}, res.Issues[0])
+
+	_, err = AdminService{}.CheckDatabases(ctx, &extpb.CheckDatabasesRequest{})
+	require.Equal(t, codes.Unavailable, status.Code(err))
+}
+
+func TestEventService_SubscribeEvents(t *testing.T) {
+	// other tests publish events of the global transaction, the stream follows accounts of its own
+	appliedTx := newTx(1, types.HexToAddress("66666"), types.HexToAddress("77777"), 10)
+	txs := &TxAPIMock{returnTx: map[types.TransactionID]*types.Transaction{appliedTx.ID(): appliedTx}}
+	grpcService := NewEventService(txs)
+	shutDown := launchServer(t, grpcService)
+	defer shutDown()
+
+	// start a client
+	addr := "localhost:" + strconv.Itoa(cfg.NewGrpcServerPort)
+
+	// Set up a connection to the server.
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, conn.Close())
+	}()
+	c := extpb.NewEventServiceClient(conn)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := c.SubscribeEvents(ctx)
+	require.NoError(t, err)
+	sender := appliedTx.Origin()
+	require.NoError(t, stream.Send(&extpb.SubscribeEventsRequest{Filter: &extpb.EventFilter{Accounts: [][]byte{sender.Bytes()}}}))
+	time.Sleep(time.Second) // wait for the server to subscribe
+
+	events.Publish(events.AccountUpdate{Address: types.HexToAddress("55555"), Nonce: 1, Balance: 10})
+	events.Publish(events.AccountUpdate{Address: sender, Nonce: 2, Balance: 20})
+	events.Publish(events.RewardReceived{Coinbase: types.HexToAddress("55555").String(), Amount: 10, Layer: 5, Smesher: "abcd"})
+	events.Publish(events.TxApplied{ID: appliedTx.ID(), LayerID: 6, Fee: 1, Result: events.TxResultApplied})
+
+	// events of different kinds aren't ordered
+	recv := func(n int) map[string]*extpb.SubscribeEventsResponse {
+		received := make(map[string]*extpb.SubscribeEventsResponse)
+		for i := 0; i < n; i++ {
+			res, err := stream.Recv()
+			require.NoError(t, err)
+			received[fmt.Sprintf("%T", res.Event)] = res
+		}
+		return received
+	}
+
+	received := recv(2)
+	account := received["*extpb.SubscribeEventsResponse_Account"].GetAccount()
+	require.NotNil(t, account)
+	require.Equal(t, sender.Bytes(), account.AccountId)
+	require.Equal(t, uint64(2), account.Counter)
+	require.Equal(t, uint64(20), account.Balance)
+	tx := received["*extpb.SubscribeEventsResponse_Transaction"].GetTransaction()
+	require.NotNil(t, tx)
+	require.Equal(t, extpb.TransactionEvent_TRANSACTION_STATE_APPLIED, tx.State)
+	require.Equal(t, appliedTx.ID().Bytes(), tx.Transaction.Id)
+	require.Equal(t, uint64(6), tx.Layer)
+	require.Equal(t, extpb.TransactionReceipt_TRANSACTION_RESULT_APPLIED, tx.Receipt.Result)
+
+	// replace the filter on the same stream
+	require.NoError(t, stream.Send(&extpb.SubscribeEventsRequest{Filter: &extpb.EventFilter{SmesherIds: [][]byte{{0xab, 0xcd}}, Layers: true}}))
+	time.Sleep(100 * time.Millisecond) // wait for the server to read the filter
+
+	events.Publish(events.AccountUpdate{Address: sender, Nonce: 3, Balance: 30})
+	events.Publish(events.RewardReceived{Coinbase: types.HexToAddress("55555").String(), Amount: 10, Layer: 5, Smesher: "abcd"})
+	events.Publish(events.LayerUpdate{LayerID: 7, Status: events.LayerStatusConfirmed})
+
+	received = recv(2)
+	reward := received["*extpb.SubscribeEventsResponse_Reward"].GetReward()
+	require.NotNil(t, reward)
+	require.Equal(t, uint64(10), reward.Total)
+	require.Equal(t, []byte{0xab, 0xcd}, reward.SmesherId)
+	layer := received["*extpb.SubscribeEventsResponse_Layer"].GetLayer()
+	require.NotNil(t, layer)
+	require.Equal(t, uint64(7), layer.Number)
+	require.Equal(t, extpb.Layer_LAYER_STATUS_CONFIRMED, layer.Status)
+
+	// malformed filters end the stream
+	require.NoError(t, stream.Send(&extpb.SubscribeEventsRequest{Filter: &extpb.EventFilter{Accounts: [][]byte{{1, 2}}}}))
+	_, err = stream.Recv()
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestNodeService_SyncProgressStream(t *testing.T) {
+	syncer := &SyncProgressMock{progress: spacesync.Progress{
+		Phase:           spacesync.PhaseLayers,
+		ProcessedLayer:  70,
+		CurrentLayer:    100,
+		LayersCompleted: 20,
+		LayersRemaining: 30,
+		LayersPerSecond: 2,
+		BytesPerSecond:  1000,
+		ETA:             15 * time.Second,
+	}}
+	shutDown := launchServer(t, NewNodeService(&networkMock, txAPI, &genTime, syncer))
+	defer shutDown()
+
+	addr := "localhost:" + strconv.Itoa(cfg.NewGrpcServerPort)
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, conn.Close())
+	}()
+	c := extpb.NewNodeServiceClient(conn)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := c.SyncProgressStream(ctx, &extpb.SyncProgressStreamRequest{})
+	require.NoError(t, err)
+	res, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, &extpb.SyncProgress{
+		Phase:           extpb.SyncProgress_SYNC_PHASE_LAYERS,
+		ProcessedLayer:  70,
+		CurrentLayer:    100,
+		LayersCompleted: 20,
+		LayersRemaining: 30,
+		LayersPerSecond: 2,
+		BytesPerSecond:  1000,
+		EtaSeconds:      15,
+	}, res.Progress)
+
+	// the progress is sent periodically
+	syncer.setProgress(spacesync.Progress{Phase: spacesync.PhaseSynced, ProcessedLayer: 100, CurrentLayer: 100})
+	res, err = stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, extpb.SyncProgress_SYNC_PHASE_SYNCED, res.Progress.Phase)
+	require.Equal(t, uint64(100), res.Progress.ProcessedLayer)
+	require.Zero(t, res.Progress.LayersRemaining)
+}
+
+func TestNodeService_SyncFrontier(t *testing.T) {
+	syncer := &SyncProgressMock{frontier: mesh.SyncFrontier{Validated: 70, InFlight: []types.LayerID{71, 72, 74}}}
+	shutDown := launchServer(t, NewNodeService(&networkMock, txAPI, &genTime, syncer))
+	defer shutDown()
+
+	addr := "localhost:" + strconv.Itoa(cfg.NewGrpcServerPort)
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, conn.Close())
+	}()
+	c := extpb.NewNodeServiceClient(conn)
+
+	res, err := c.SyncFrontier(context.Background(), &extpb.SyncFrontierRequest{})
+	require.NoError(t, err)
+	require.Equal(t, uint64(70), res.ValidatedLayer)
+	require.Equal(t, []uint64{71, 72, 74}, res.InFlightLayers)
+
+	// a node that never synced has an empty frontier
+	syncer.frontier = mesh.SyncFrontier{}
+	res, err = c.SyncFrontier(context.Background(), &extpb.SyncFrontierRequest{})
+	require.NoError(t, err)
+	require.Zero(t, res.ValidatedLayer)
+	require.Empty(t, res.InFlightLayers)
+}
+
+type finalityTxAPIMock struct {
+	*TxAPIMock
+	finalized types.LayerID
+}
+
+func (t *finalityTxAPIMock) FinalizedLayer() types.LayerID {
+	return t.finalized
+}
+
+func TestNodeService_Finality(t *testing.T) {
+	tx := &finalityTxAPIMock{TxAPIMock: txAPI, finalized: types.LayerID(ValidatedLayerID - 3)}
+	shutDown := launchServer(t, NewNodeService(&networkMock, tx, &genTime, &SyncerMock{}))
+	defer shutDown()
+
+	addr := "localhost:" + strconv.Itoa(cfg.NewGrpcServerPort)
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, conn.Close())
+	}()
+	c := extpb.NewNodeServiceClient(conn)
+
+	res, err := c.Finality(context.Background(), &extpb.FinalityRequest{})
+	require.NoError(t, err)
+	require.Equal(t, uint64(ValidatedLayerID-3), res.FinalizedLayer)
+	require.Equal(t, uint64(ValidatedLayerID), res.VerifiedLayer)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := c.FinalityStream(ctx, &extpb.FinalityStreamRequest{})
+	require.NoError(t, err)
+	time.Sleep(time.Second) // wait for the server to subscribe
+
+	events.Publish(events.LayerFinalized{LayerID: types.LayerID(ValidatedLayerID - 2), ByHare: false})
+	events.Publish(events.LayerFinalized{LayerID: types.LayerID(ValidatedLayerID - 1), ByHare: true})
+	update, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, uint64(ValidatedLayerID-2), update.Finality.Layer)
+	require.Equal(t, extpb.LayerFinality_FINALITY_SOURCE_TORTOISE, update.Finality.Source)
+	update, err = stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, uint64(ValidatedLayerID-1), update.Finality.Layer)
+	require.Equal(t, extpb.LayerFinality_FINALITY_SOURCE_HARE, update.Finality.Source)
+}
+
+func TestNodeService_SyncProgressStreamUnavailable(t *testing.T) {
+	shutDown := launchServer(t, NewNodeService(&networkMock, txAPI, &genTime, &SyncerMock{}))
+	defer shutDown()
+
+	addr := "localhost:" + strconv.Itoa(cfg.NewGrpcServerPort)
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, conn.Close())
+	}()
+	c := extpb.NewNodeServiceClient(conn)
+
+	stream, err := c.SyncProgressStream(context.Background(), &extpb.SyncProgressStreamRequest{})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.Equal(t, codes.Unavailable, status.Code(err))
+	_, err = c.SyncFrontier(context.Background(), &extpb.SyncFrontierRequest{})
+	require.Equal(t, codes.Unavailable, status.Code(err))
+	_, err = c.Finality(context.Background(), &extpb.FinalityRequest{})
+	require.Equal(t, codes.Unavailable, status.Code(err))
+}
+
+func TestMultiService(t *testing.T) {
+	svc1 := NewNodeService(&networkMock, txAPI, &genTime, &SyncerMock{})
+	svc2 := NewMeshService(&networkMock, txAPI, &genTime, &SyncerMock{}, malfeasanceMock, layersPerEpoch, networkID, layerDuration, layerAvgSize, txsPerBlock)
+	shutDown := launchServer(t, svc1, svc2)
+	defer shutDown()
+
+	// start a client
+	addr := "localhost:" + strconv.Itoa(cfg.NewGrpcServerPort)
+
+	// Set up a connection to the server.
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, conn.Close())
+	}()
+	c1 := pb.NewNodeServiceClient(conn)
+	c2 := pb.NewMeshServiceClient(conn)
+
+	// call endpoints and validate results
+	const message = "Hello World"
+	res1, err1 := c1.Echo(context.Background(), &pb.EchoRequest{
+		Msg: &pb.SimpleString{Value: message}})
+	require.NoError(t, err1)
+	require.Equal(t, message, res1.Msg.Value)
+	res2, err2 := c2.GenesisTime(context.Background(), &pb.GenesisTimeRequest{})
+	require.NoError(t, err2)
+	require.Equal(t, uint64(genTime.GetGenesisTime().Unix()), res2.Unixtime.Value)
+
+	// Make sure that shutting down the grpc service shuts them both down
+	shutDown()
+
+	// Make sure NodeService is off
+	res1, err1 = c1.Echo(context.Background(), &pb.EchoRequest{
+		Msg: &pb.SimpleString{Value: message}})
+	require.Error(t, err1)
+	require.Contains(t, err1.Error(), "rpc error: code = Unavailable")
+
+	// Make sure MeshService is off
+	res2, err2 = c2.GenesisTime(context.Background(), &pb.GenesisTimeRequest{})
+	require.Error(t, err2)
+	require.Contains(t, err2.Error(), "rpc error: code = Unavailable")
+}
+
+func TestJsonApi(t *testing.T) {
+	const message = "hello world!"
+
+	// we cannot start the gateway service without enabling at least one service
+	require.Equal(t, cfg.StartNodeService, false)
+	require.Equal(t, cfg.StartMeshService, false)
+	shutDown := launchServer(t)
+	payload := marshalProto(t, &pb.EchoRequest{Msg: &pb.SimpleString{Value: message}})
+	url := fmt.Sprintf("http://127.0.0.1:%d/%s", cfg.NewJSONServerPort, "v1/node/echo")
+	t.Log("sending POST request to", url)
+	_, err := http.Post(url, "application/json", strings.NewReader(payload))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), fmt.Sprintf(
+		"dial tcp 127.0.0.1:%d: connect: connection refused",
+		cfg.NewJSONServerPort))
+	shutDown()
+
+	// enable services and try again
+	svc1 := NewNodeService(&networkMock, txAPI, &genTime, &SyncerMock{})
+	svc2 := NewMeshService(&networkMock, txAPI, &genTime, &SyncerMock{}, malfeasanceMock, layersPerEpoch, networkID, layerDuration, layerAvgSize, txsPerBlock)
+	cfg.StartNodeService = true
+	cfg.StartMeshService = true
+	shutDown = launchServer(t, svc1, svc2)
+	defer shutDown()
+
+	// generate request payload (api input params)
+	payload = marshalProto(t, &pb.EchoRequest{Msg: &pb.SimpleString{Value: message}})
+	respBody, respStatus := callEndpoint(t, "v1/node/echo", payload)
+	require.Equal(t, http.StatusOK, respStatus)
+	var msg pb.EchoResponse
+	require.NoError(t, jsonpb.UnmarshalString(respBody, &msg))
+	require.Equal(t, message, msg.Msg.Value)
+
+	// Test MeshService
+	respBody2, respStatus2 := callEndpoint(t, "v1/mesh/genesistime", "")
+	require.Equal(t, http.StatusOK, respStatus2)
+	var msg2 pb.GenesisTimeResponse
+	require.NoError(t, jsonpb.UnmarshalString(respBody2, &msg2))
+	require.Equal(t, uint64(genTime.GetGenesisTime().Unix()), msg2.Unixtime.Value)
+
+	// the local extensions of a service are served along with the upstream api
+	_, respStatus3 := callEndpoint(t, "v1/mesh/blockquery", "{}")
+	require.Equal(t, http.StatusBadRequest, respStatus3)
+}
+
+func TestJsonApi_Swagger(t *testing.T) {
+	conf := cfg
+	defer func() { cfg = conf }()
+	cfg.StartMeshService = true
+	cfg.StartTxService = false
+	cfg.SwaggerUI = true
+	svc := NewMeshService(&networkMock, txAPI, &genTime, &SyncerMock{}, malfeasanceMock, layersPerEpoch, networkID, layerDuration, layerAvgSize, txsPerBlock)
+	shutDown := launchServer(t, svc)
+	defer shutDown()
+
+	get := func(path string) (string, *http.Response) {
+		resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d%s", cfg.NewJSONServerPort, path))
+		require.NoError(t, err)
+		buf, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		return string(buf), resp
+	}
+
+	body, resp := get("/swagger/mesh.swagger.json")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	var def map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(body), &def))
+	require.Contains(t, def["paths"], "/v1/mesh/blockquery")
+
+	// only the definitions of enabled services are served
+	_, resp = get("/swagger/tx.swagger.json")
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	_, resp = get("/swagger/unknown.swagger.json")
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	body, resp = get("/swagger-ui/")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Contains(t, body, "mesh.swagger.json")
+	require.NotContains(t, body, "tx.swagger.json")
+}
+
+func TestJsonApi_Headers(t *testing.T) {
+	conf := cfg
+	defer func() { cfg = conf }()
+	cfg.StartMeshService = true
+	cfg.StartTxService = true
+	tx := &TxAPIMock{accountTxs: []mesh.AccountTx{
+		{ID: globalTx.ID(), Layer: 2, Direction: mesh.TxSent},
+		{ID: globalTx.ID(), Layer: 3, Direction: mesh.TxSent},
+	}}
+	svc1 := NewMeshService(&networkMock, txAPI, &genTime, &SyncerMock{}, malfeasanceMock, layersPerEpoch, networkID, layerDuration, layerAvgSize, txsPerBlock)
+	svc2 := NewTransactionService(&networkMock, tx, txMempool, ProjectorMock{}, receiptStore, 0, layerAvgSize*txsPerBlock)
+	shutDown := launchServer(t, svc1, svc2)
+	defer shutDown()
+
+	get := func(path string, header ...string) (string, *http.Response) {
+		req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("http://127.0.0.1:%d%s", cfg.NewJSONServerPort, path), nil)
+		require.NoError(t, err)
+		for i := 0; i < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		buf, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		return string(buf), resp
+	}
+
+	// a page of confirmed layers links to the next page and can be cached
+	body, resp := get("/v1/mesh/pagedlayersquery?start_layer=1&page_size=2")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var layers extpb.PagedLayersQueryResponse
+	require.NoError(t, jsonpb.UnmarshalString(body, &layers))
+	require.Len(t, layers.Layers, 2)
+	require.Equal(t, fmt.Sprintf("</v1/mesh/pagedlayersquery?page_size=2&page_token=%s&start_layer=1>; rel=\"next\"",
+		url.QueryEscape(layers.NextPageToken)), resp.Header.Get("Link"))
+	etag := resp.Header.Get("ETag")
+	require.NotEmpty(t, etag)
+	require.Contains(t, resp.Header.Get("Cache-Control"), "max-age=")
+
+	body, resp = get("/v1/mesh/pagedlayersquery?start_layer=1&page_size=2", "If-None-Match", etag)
+	require.Equal(t, http.StatusNotModified, resp.StatusCode)
+	require.Empty(t, body)
+	_, resp = get("/v1/mesh/pagedlayersquery?start_layer=1&page_size=2", "If-None-Match", `"other"`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// the last page is only cacheable if it ends at the requested end layer, and all its layers are confirmed
+	_, resp = get("/v1/mesh/pagedlayersquery?start_layer=7&end_layer=8")
+	require.Empty(t, resp.Header.Get("Link"))
+	require.NotEmpty(t, resp.Header.Get("ETag"))
+	_, resp = get("/v1/mesh/pagedlayersquery?start_layer=7&end_layer=9")
+	require.Empty(t, resp.Header.Get("ETag"))
+	_, resp = get("/v1/mesh/pagedlayersquery?start_layer=8&page_size=2")
+	require.Empty(t, resp.Header.Get("ETag"))
+
+	layer, err := txAPI.GetLayer(8)
+	require.NoError(t, err)
+	blockID := base64.URLEncoding.EncodeToString(layer.Blocks()[0].ID().Bytes())
+	_, resp = get("/v1/mesh/blockquery?id=" + blockID)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	etag = resp.Header.Get("ETag")
+	require.NotEmpty(t, etag)
+	_, resp = get("/v1/mesh/blockquery?id="+blockID, "If-None-Match", "W/"+etag)
+	require.Equal(t, http.StatusNotModified, resp.StatusCode)
+
+	// POST requests are neither linked nor cached
+	resp, err = http.Post(fmt.Sprintf("http://127.0.0.1:%d/v1/mesh/blockquery", cfg.NewJSONServerPort), "application/json",
+		strings.NewReader(fmt.Sprintf(`{"id":"%s"}`, base64.StdEncoding.EncodeToString(layer.Blocks()[0].ID().Bytes()))))
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Empty(t, resp.Header.Get("ETag"))
+
+	account := base64.URLEncoding.EncodeToString(types.HexToAddress("33333").Bytes())
+	body, resp = get("/v1/tx/accounttransactions?max_results=1&account_id=" + account)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	link := resp.Header.Get("Link")
+	require.True(t, strings.HasPrefix(link, "</v1/tx/accounttransactions?"), link)
+	require.True(t, strings.HasSuffix(link, ">; rel=\"next\""), link)
+	body, resp = get(strings.TrimSuffix(strings.TrimPrefix(link, "<"), ">; rel=\"next\""))
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var txs extpb.AccountTransactionsResponse
+	require.NoError(t, jsonpb.UnmarshalString(body, &txs))
+	require.Len(t, txs.Transactions, 1)
+	require.Equal(t, uint64(3), txs.Transactions[0].Layer)
+	require.Empty(t, resp.Header.Get("Link"))
+
+	// Last-Modified validates requests without an etag
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("If-Modified-Since", "Tue, 20 Oct 2020 10:00:00 GMT")
+	require.True(t, notModified(req, http.Header{"Last-Modified": {"Tue, 20 Oct 2020 09:00:00 GMT"}}))
+	require.False(t, notModified(req, http.Header{"Last-Modified": {"Tue, 20 Oct 2020 11:00:00 GMT"}}))
+	req.Header.Set("If-None-Match", `"a"`)
+	require.False(t, notModified(req, http.Header{"Last-Modified": {"Tue, 20 Oct 2020 09:00:00 GMT"}, "Etag": {`"b"`}}))
 }
@@ -0,0 +1,176 @@
+package grpcserver
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/spacemeshos/go-spacemesh/api/extpb"
+	"github.com/spacemeshos/go-spacemesh/log"
+	"golang.org/x/net/context"
+)
+
+// immutableMaxAge is how long http caches may keep responses that only hold finalized data
+const immutableMaxAge = 24 * time.Hour
+
+// requestKey is the context key of the http request a gateway response is forwarded for
+type requestKey struct{}
+
+// withGatewayHeaders makes the http request available to forwardHeaders, and answers conditional GET requests for
+// cacheable responses that didn't change with 304 Not Modified
+func withGatewayHeaders(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w = &conditionalWriter{ResponseWriter: w, req: r}
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestKey{}, r)))
+	})
+}
+
+// forwardHeaders is a forward response option of the gateway. It adds an RFC 5988 Link header pointing at the next
+// page to paginated responses, and ETag, Last-Modified and Cache-Control headers to responses that hold finalized
+// layers, blocks or receipts. Only GET requests get these headers, since a POST request can't be linked to or cached.
+func forwardHeaders(ctx context.Context, w http.ResponseWriter, msg proto.Message) error {
+	r, ok := ctx.Value(requestKey{}).(*http.Request)
+	if !ok || r.Method != http.MethodGet {
+		return nil
+	}
+	switch res := msg.(type) {
+	case *extpb.PagedLayersQueryResponse:
+		setNextLink(w, r, res.NextPageToken)
+		if finalLayers(res, r) {
+			var modified int64
+			for _, l := range res.Layers {
+				for _, b := range l.Blocks {
+					if b.Timestamp > modified {
+						modified = b.Timestamp
+					}
+				}
+			}
+			setCacheHeaders(w, msg, modified)
+		}
+	case *extpb.AccountTransactionsResponse:
+		if len(res.NextPageToken) > 0 {
+			setNextLink(w, r, base64.URLEncoding.EncodeToString(res.NextPageToken))
+		}
+	case *extpb.BlockQueryResponse:
+		if res.LayerStatus == extpb.Layer_LAYER_STATUS_CONFIRMED {
+			setCacheHeaders(w, msg, res.Block.Timestamp)
+		}
+	case *extpb.TransactionReceiptResponse:
+		// receipts are only stored once the layer of their transaction was applied to the global state
+		setCacheHeaders(w, msg, 0)
+	}
+	return nil
+}
+
+// finalLayers returns true if the page of layers can't change anymore: all its layers are confirmed, and it either
+// isn't the last page or it ends at the end layer of the request, rather than at the latest layer
+func finalLayers(res *extpb.PagedLayersQueryResponse, r *http.Request) bool {
+	if len(res.Layers) == 0 {
+		return false
+	}
+	for _, l := range res.Layers {
+		if l.Status != extpb.Layer_LAYER_STATUS_CONFIRMED {
+			return false
+		}
+	}
+	if res.NextPageToken != "" {
+		return true
+	}
+	end, err := strconv.ParseUint(r.URL.Query().Get("end_layer"), 10, 64)
+	return err == nil && end == res.Layers[len(res.Layers)-1].Number
+}
+
+// setNextLink sets a Link header to the request url with its page token replaced by token, if there's a next page
+func setNextLink(w http.ResponseWriter, r *http.Request, token string) {
+	if token == "" {
+		return
+	}
+	query := r.URL.Query()
+	query.Set("page_token", token)
+	w.Header().Set("Link", fmt.Sprintf("<%s?%s>; rel=\"next\"", r.URL.Path, query.Encode()))
+}
+
+// setCacheHeaders sets the headers of an immutable response, modified is its unix time in nanoseconds, or 0 if unknown
+func setCacheHeaders(w http.ResponseWriter, msg proto.Message, modified int64) {
+	buf, err := proto.Marshal(msg)
+	if err != nil {
+		log.Error("error computing etag of gateway response: %v", err)
+		return
+	}
+	h := w.Header()
+	h.Set("ETag", fmt.Sprintf("\"%x\"", sha256.Sum256(buf)))
+	if modified > 0 {
+		h.Set("Last-Modified", time.Unix(0, modified).UTC().Format(http.TimeFormat))
+	}
+	h.Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(immutableMaxAge.Seconds())))
+}
+
+// conditionalWriter replaces a successful response with 304 Not Modified if it matches the validators of the request
+type conditionalWriter struct {
+	http.ResponseWriter
+	req         *http.Request
+	wroteHeader bool
+	notModified bool
+}
+
+func (w *conditionalWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	if code == http.StatusOK && notModified(w.req, w.Header()) {
+		w.notModified = true
+		w.Header().Del("Content-Type")
+		w.Header().Del("Content-Length")
+		code = http.StatusNotModified
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *conditionalWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.notModified {
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher, which the gateway requires to forward streams
+func (w *conditionalWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// notModified evaluates the If-None-Match and If-Modified-Since headers of a request against the headers of its
+// response, as specified by RFC 7232. If-Modified-Since is ignored when If-None-Match is present.
+func notModified(r *http.Request, h http.Header) bool {
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		etag := h.Get("ETag")
+		if etag == "" {
+			return false
+		}
+		for _, tag := range strings.Split(match, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == "*" || tag == etag {
+				return true
+			}
+		}
+		return false
+	}
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	modified, err := http.ParseTime(h.Get("Last-Modified"))
+	return err == nil && !modified.After(since)
+}
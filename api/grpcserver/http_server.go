@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"github.com/grpc-ecosystem/grpc-gateway/runtime"
 	gw "github.com/spacemeshos/api/release/go/spacemesh/v1"
+	"github.com/spacemeshos/go-spacemesh/api/config"
+	"github.com/spacemeshos/go-spacemesh/api/extpb"
 	cmdp "github.com/spacemeshos/go-spacemesh/cmd"
 	"github.com/spacemeshos/go-spacemesh/log"
 	"golang.org/x/net/context"
@@ -19,6 +21,37 @@ type JSONHTTPServer struct {
 	server   *http.Server
 }
 
+// gatewayHandler registers the http handlers of a grpc service, that forward requests to endpoint, on mux
+type gatewayHandler func(ctx context.Context, mux *runtime.ServeMux, endpoint string, opts []grpc.DialOption) error
+
+// gatewayService is a grpc service exposed by the gateway. Services that extend an upstream api service have a
+// handler for the upstream api as well as for the local extensions, swagger names the swagger definition of the
+// local extensions, if there are any.
+type gatewayService struct {
+	name     string
+	enabled  bool
+	swagger  string
+	handlers []gatewayHandler
+}
+
+// gatewayServices lists the services the gateway serves for the given config, in the order they are registered
+func gatewayServices(conf config.Config) []gatewayService {
+	return []gatewayService{
+		{"NodeService", conf.StartNodeService, "node", []gatewayHandler{gw.RegisterNodeServiceHandlerFromEndpoint,
+			extpb.RegisterNodeServiceHandlerFromEndpoint}},
+		{"MeshService", conf.StartMeshService, "mesh", []gatewayHandler{gw.RegisterMeshServiceHandlerFromEndpoint,
+			extpb.RegisterMeshServiceHandlerFromEndpoint}},
+		{"SmesherService", conf.StartSmesherService, "smesher", []gatewayHandler{extpb.RegisterSmesherServiceHandlerFromEndpoint}},
+		{"TransactionService", conf.StartTxService, "tx", []gatewayHandler{gw.RegisterTransactionServiceHandlerFromEndpoint,
+			extpb.RegisterTransactionServiceHandlerFromEndpoint}},
+		{"GlobalStateService", conf.StartGlobalStateService, "globalstate", []gatewayHandler{gw.RegisterGlobalStateServiceHandlerFromEndpoint,
+			extpb.RegisterGlobalStateServiceHandlerFromEndpoint}},
+		{"DebugService", conf.StartDebugService, "debug", []gatewayHandler{extpb.RegisterDebugServiceHandlerFromEndpoint}},
+		{"AdminService", conf.StartAdminService, "admin", []gatewayHandler{extpb.RegisterAdminServiceHandlerFromEndpoint}},
+		{"EventService", conf.StartEventService, "events", []gatewayHandler{extpb.RegisterEventServiceHandlerFromEndpoint}},
+	}
+}
+
 // NewJSONHTTPServer creates a new json http server.
 func NewJSONHTTPServer(port int, grpcPort int) *JSONHTTPServer {
 	return &JSONHTTPServer{Port: port, GrpcPort: grpcPort}
@@ -35,15 +68,16 @@ func (s *JSONHTTPServer) Close() error {
 	return nil
 }
 
-// StartService starts the json api server and listens for status (started, stopped).
-func (s *JSONHTTPServer) StartService(startNodeService bool, startMeshService bool) {
-	go s.startInternal(startNodeService, startMeshService)
+// StartService starts the json api server, serving every grpc service that is enabled in conf along with its swagger
+// definition, and listens for status (started, stopped).
+func (s *JSONHTTPServer) StartService(conf config.Config) {
+	go s.startInternal(gatewayServices(conf), conf.SwaggerUI)
 }
 
-func (s *JSONHTTPServer) startInternal(startNodeService bool, startMeshService bool) {
+func (s *JSONHTTPServer) startInternal(services []gatewayService, swaggerUI bool) {
 	ctx, cancel := context.WithCancel(cmdp.Ctx)
 	defer cancel()
-	mux := runtime.NewServeMux()
+	mux := runtime.NewServeMux(runtime.WithForwardResponseOption(forwardHeaders))
 	opts := []grpc.DialOption{grpc.WithInsecure()}
 
 	// register the http server on the local grpc server
@@ -51,20 +85,20 @@ func (s *JSONHTTPServer) startInternal(startNodeService bool, startMeshService b
 
 	// register each individual, enabled service
 	serviceCount := 0
-	if startNodeService {
-		if err := gw.RegisterNodeServiceHandlerFromEndpoint(ctx, mux, jsonEndpoint, opts); err != nil {
-			log.Error("error registering NodeService with grpc gateway", err)
-		} else {
-			serviceCount++
-			log.Info("registered NodeService with grpc gateway server")
+	for _, svc := range services {
+		if !svc.enabled {
+			continue
 		}
-	}
-	if startMeshService {
-		if err := gw.RegisterMeshServiceHandlerFromEndpoint(ctx, mux, jsonEndpoint, opts); err != nil {
-			log.Error("error registering MeshService with grpc gateway", err)
-		} else {
+		registered := true
+		for _, register := range svc.handlers {
+			if err := register(ctx, mux, jsonEndpoint, opts); err != nil {
+				log.Error("error registering %v with grpc gateway: %v", svc.name, err)
+				registered = false
+			}
+		}
+		if registered {
 			serviceCount++
-			log.Info("registered MeshService with grpc gateway server")
+			log.Info("registered %v with grpc gateway server", svc.name)
 		}
 	}
 
@@ -74,10 +108,19 @@ func (s *JSONHTTPServer) startInternal(startNodeService bool, startMeshService b
 		return
 	}
 
+	swagger := swaggerHandler{services: services}
+	handler := http.NewServeMux()
+	handler.Handle("/", withGatewayHeaders(mux))
+	handler.Handle(swaggerPath, swagger)
+	if swaggerUI {
+		handler.HandleFunc(swaggerUIPath, swagger.serveUI)
+		log.Info("serving swagger ui at %v", swaggerUIPath)
+	}
+
 	log.Info("starting grpc gateway server on port %d connected to grpc service at %s", s.Port, jsonEndpoint)
 	s.server = &http.Server{
 		Addr:    fmt.Sprintf(":%d", s.Port),
-		Handler: mux,
+		Handler: handler,
 	}
 
 	// This call is blocking, and only returns an error
@@ -1,37 +1,70 @@
 package grpcserver
 
 import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+
 	pb "github.com/spacemeshos/api/release/go/spacemesh/v1"
 	"github.com/spacemeshos/go-spacemesh/api"
+	"github.com/spacemeshos/go-spacemesh/api/extpb"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/common/util"
+	"github.com/spacemeshos/go-spacemesh/database"
+	"github.com/spacemeshos/go-spacemesh/events"
 	"github.com/spacemeshos/go-spacemesh/log"
 	"github.com/spacemeshos/go-spacemesh/p2p/peers"
 	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultLayersPageSize = 100
+	maxLayersPageSize     = 1000
 )
 
-// MeshService is a grpc server providing the MeshService
+// MeshService is a grpc server providing the MeshService, along with the node-local extensions to it
 type MeshService struct {
-	Network     api.NetworkAPI // P2P Swarm
-	Tx          api.TxAPI      // Mesh
-	GenTime     api.GenesisTimeAPI
-	PeerCounter api.PeerCounter
-	Syncer      api.Syncer
+	Network          api.NetworkAPI // P2P Swarm
+	Tx               api.TxAPI      // Mesh
+	GenTime          api.GenesisTimeAPI
+	PeerCounter      api.PeerCounter
+	Syncer           api.Syncer
+	Malfeasance      api.MalfeasanceAPI
+	Certificates     api.CertificateAPI // nil if the mesh doesn't store hare certificates
+	LayersPerEpoch   int
+	NetworkID        int8
+	LayerDurationSec int
+	LayerAvgSize     int
+	TxsPerBlock      int
 }
 
 // RegisterService registers this service with a grpc server instance
 func (s MeshService) RegisterService(server *Server) {
 	pb.RegisterMeshServiceServer(server.GrpcServer, s)
+	extpb.RegisterMeshServiceServer(server.GrpcServer, s)
 }
 
 // NewMeshService creates a new grpc service using config data.
 func NewMeshService(
 	net api.NetworkAPI, tx api.TxAPI, genTime api.GenesisTimeAPI,
-	syncer api.Syncer) *MeshService {
+	syncer api.Syncer, malfeasance api.MalfeasanceAPI, layersPerEpoch int, networkID int8, layerDurationSec int,
+	layerAvgSize int, txsPerBlock int) *MeshService {
+	certificates, _ := tx.(api.CertificateAPI)
 	return &MeshService{
-		Network:     net,
-		Tx:          tx,
-		GenTime:     genTime,
-		PeerCounter: peers.NewPeers(net, log.NewDefault("grpc_server.MeshService")),
-		Syncer:      syncer,
+		Network:          net,
+		Tx:               tx,
+		GenTime:          genTime,
+		PeerCounter:      peers.NewPeers(net, log.NewDefault("grpc_server.MeshService")),
+		Syncer:           syncer,
+		Malfeasance:      malfeasance,
+		Certificates:     certificates,
+		LayersPerEpoch:   layersPerEpoch,
+		NetworkID:        networkID,
+		LayerDurationSec: layerDurationSec,
+		LayerAvgSize:     layerAvgSize,
+		TxsPerBlock:      txsPerBlock,
 	}
 }
 
@@ -46,37 +79,53 @@ func (s MeshService) GenesisTime(ctx context.Context, in *pb.GenesisTimeRequest)
 // CurrentLayer returns the current layer number
 func (s MeshService) CurrentLayer(ctx context.Context, in *pb.CurrentLayerRequest) (*pb.CurrentLayerResponse, error) {
 	log.Info("GRPC MeshService.CurrentLayer")
-	return nil, nil
+	return &pb.CurrentLayerResponse{Layernum: &pb.SimpleInt{
+		Value: s.GenTime.GetCurrentLayer().Uint64(),
+	}}, nil
 }
 
 // CurrentEpoch returns the current epoch number
 func (s MeshService) CurrentEpoch(ctx context.Context, in *pb.CurrentEpochRequest) (*pb.CurrentEpochResponse, error) {
 	log.Info("GRPC MeshService.CurrentEpoch")
-	return nil, nil
+	return &pb.CurrentEpochResponse{Epochnum: &pb.SimpleInt{
+		Value: uint64(s.GenTime.GetCurrentLayer().GetEpoch()),
+	}}, nil
 }
 
 // NetID returns the network ID
 func (s MeshService) NetID(ctx context.Context, in *pb.NetIDRequest) (*pb.NetIDResponse, error) {
 	log.Info("GRPC MeshService.NetId")
-	return nil, nil
+	return &pb.NetIDResponse{Netid: &pb.SimpleInt{
+		Value: uint64(s.NetworkID),
+	}}, nil
 }
 
 // EpochNumLayers returns the number of layers per epoch (a network parameter)
 func (s MeshService) EpochNumLayers(ctx context.Context, in *pb.EpochNumLayersRequest) (*pb.EpochNumLayersResponse, error) {
 	log.Info("GRPC MeshService.EpochNumLayers")
-	return nil, nil
+	return &pb.EpochNumLayersResponse{Numlayers: &pb.SimpleInt{
+		Value: uint64(s.LayersPerEpoch),
+	}}, nil
 }
 
 // LayerDuration returns the layer duration in seconds (a network parameter)
 func (s MeshService) LayerDuration(ctx context.Context, in *pb.LayerDurationRequest) (*pb.LayerDurationResponse, error) {
 	log.Info("GRPC MeshService.LayerDuration")
-	return nil, nil
+	return &pb.LayerDurationResponse{Duration: &pb.SimpleInt{
+		Value: uint64(s.LayerDurationSec),
+	}}, nil
 }
 
-// MaxTransactionsPerSecond returns the max number of tx per sec (a network parameter)
+// MaxTransactionsPerSecond returns the max number of tx per sec (a network parameter). It's derived from the
+// average number of blocks in a layer, the max number of transactions in a block and the layer duration.
 func (s MeshService) MaxTransactionsPerSecond(ctx context.Context, in *pb.MaxTransactionsPerSecondRequest) (*pb.MaxTransactionsPerSecondResponse, error) {
 	log.Info("GRPC MeshService.MaxTransactionsPerSecond")
-	return nil, nil
+	if s.LayerDurationSec <= 0 {
+		return nil, status.Errorf(codes.Internal, "layer duration is not configured")
+	}
+	return &pb.MaxTransactionsPerSecondResponse{Maxtxpersecond: &pb.SimpleInt{
+		Value: uint64(s.LayerAvgSize * s.TxsPerBlock / s.LayerDurationSec),
+	}}, nil
 }
 
 // QUERIES
@@ -87,22 +136,496 @@ func (s MeshService) AccountMeshDataQuery(ctx context.Context, in *pb.AccountMes
 	return nil, nil
 }
 
-// LayersQuery returns all mesh data, layer by layer
+// LayersQuery returns all mesh data, layer by layer. The layers are read by the pager of PagedLayersQuery, and since the
+// response can't be paged, ranges of more than a page of layers are rejected.
 func (s MeshService) LayersQuery(ctx context.Context, in *pb.LayersQueryRequest) (*pb.LayersQueryResponse, error) {
 	log.Info("GRPC MeshService.LayersQuery")
-	return nil, nil
+
+	page, err := s.PagedLayersQuery(ctx, &extpb.PagedLayersQueryRequest{
+		StartLayer:          uint64(in.StartLayer),
+		EndLayer:            uint64(in.EndLayer),
+		IncludeTransactions: true,
+		IncludeActivations:  true,
+		PageSize:            maxLayersPageSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if page.NextPageToken != "" {
+		return nil, status.Errorf(codes.OutOfRange, "at most %d layers are returned, use PagedLayersQuery for larger ranges", maxLayersPageSize)
+	}
+	res := &pb.LayersQueryResponse{}
+	for _, layer := range page.Layers {
+		res.Layer = append(res.Layer, convertLayerFromExt(layer))
+	}
+	return res, nil
+}
+
+// PagedLayersQuery returns mesh data for a range of layers. At most page_size layers are returned per call, the
+// returned page token can be used to request the rest of the range.
+func (s MeshService) PagedLayersQuery(ctx context.Context, in *extpb.PagedLayersQueryRequest) (*extpb.PagedLayersQueryResponse, error) {
+	log.Info("GRPC MeshService.PagedLayersQuery")
+
+	if in.EndLayer != 0 && in.StartLayer > in.EndLayer {
+		return nil, status.Errorf(codes.InvalidArgument, "`StartLayer` must not be greater than `EndLayer`")
+	}
+	mask, err := newFieldMask(in.FieldMask, (*extpb.PagedLayersQueryResponse)(nil))
+	if err != nil {
+		return nil, err
+	}
+	start := types.LayerID(in.StartLayer)
+	if in.PageToken != "" {
+		next, err := decodeLayerPageToken(in.PageToken)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid page token")
+		}
+		start = next
+	}
+
+	latest := s.Tx.LatestLayer()
+	end := types.LayerID(in.EndLayer)
+	if end == 0 || end > latest {
+		end = latest
+	}
+	if start > end {
+		// nothing (more) to return
+		return &extpb.PagedLayersQueryResponse{}, nil
+	}
+
+	pageSize := uint64(in.PageSize)
+	if pageSize == 0 {
+		pageSize = defaultLayersPageSize
+	}
+	if pageSize > maxLayersPageSize {
+		pageSize = maxLayersPageSize
+	}
+	last := end
+	if uint64(end-start) >= pageSize {
+		last = start + types.LayerID(pageSize) - 1
+	}
+
+	// don't read transactions and activations that are masked out
+	includeTxs := in.IncludeTransactions && mask.includes("layers", "blocks", "transactions")
+	includeAtxs := in.IncludeActivations && mask.includes("layers", "activations")
+	res := &extpb.PagedLayersQueryResponse{}
+	for l := start; l <= last; l++ {
+		layer, err := s.readLayer(l, includeTxs, includeAtxs)
+		if err == database.ErrPruned {
+			return nil, status.Errorf(codes.NotFound, "layer %v was pruned", l)
+		}
+		if err != nil {
+			log.With().Error("could not read layer from database", l, log.Err(err))
+			return nil, status.Errorf(codes.Internal, "error reading layer data")
+		}
+		res.Layers = append(res.Layers, layer)
+	}
+	if last < end {
+		res.NextPageToken = encodeLayerPageToken(last + 1)
+	}
+	mask.apply(res)
+	return res, nil
+}
+
+// readLayer reads a single layer from the mesh. Layers which are not in the database yet are returned without blocks.
+func (s MeshService) readLayer(layerID types.LayerID, includeTxs, includeAtxs bool) (*extpb.Layer, error) {
+	res := &extpb.Layer{
+		Number: layerID.Uint64(),
+		Status: s.layerStatus(layerID),
+	}
+	if s.Certificates != nil {
+		_, err := s.Certificates.GetCertificate(layerID)
+		res.Certified = err == nil
+	}
+	layer, err := s.Tx.GetLayer(layerID)
+	if err == database.ErrNotFound {
+		return res, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	res.Hash = layer.Hash().Bytes()
+
+	var atxIDs []types.ATXID
+	seenAtxs := make(map[types.ATXID]struct{})
+	for _, b := range layer.Blocks() {
+		res.Blocks = append(res.Blocks, s.readBlock(b, includeTxs))
+
+		if _, ok := seenAtxs[b.ATXID]; !ok {
+			seenAtxs[b.ATXID] = struct{}{}
+			atxIDs = append(atxIDs, b.ATXID)
+		}
+	}
+
+	// the activations of a layer are the ones its blocks were produced with
+	if includeAtxs && len(atxIDs) > 0 {
+		atxs, missing := s.Tx.GetATXs(atxIDs)
+		if len(missing) > 0 {
+			log.With().Warning("could not find all layer activations in database", layerID, log.Int("missing", len(missing)))
+		}
+		for _, id := range atxIDs {
+			if atx, ok := atxs[id]; ok {
+				res.Activations = append(res.Activations, convertExtActivation(atx))
+			}
+		}
+	}
+	return res, nil
+}
+
+func (s MeshService) readBlock(b *types.Block, includeTxs bool) *extpb.Block {
+	block := &extpb.Block{
+		Id:        b.ID().Bytes(),
+		AtxId:     b.ATXID.Bytes(),
+		Layer:     b.Layer().Uint64(),
+		Timestamp: b.Timestamp,
+	}
+	if b.MinerID() != nil {
+		block.SmesherId = b.MinerID().Bytes()
+	}
+	for _, id := range b.TxIDs {
+		block.TransactionIds = append(block.TransactionIds, id.Bytes())
+	}
+	if includeTxs {
+		txs, missing := s.Tx.GetTransactions(b.TxIDs)
+		if len(missing) > 0 {
+			log.With().Warning("could not find all block transactions in database", b.ID(), log.Int("missing", len(missing)))
+		}
+		for _, tx := range txs {
+			block.Transactions = append(block.Transactions, convertExtTransaction(tx))
+		}
+	}
+	return block
+}
+
+// BlockQuery returns a block by its id, along with the status of its layer. Transaction bodies are only included when
+// requested.
+func (s MeshService) BlockQuery(ctx context.Context, in *extpb.BlockQueryRequest) (*extpb.BlockQueryResponse, error) {
+	log.Info("GRPC MeshService.BlockQuery")
+
+	if len(in.Id) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "`Id` must be provided")
+	}
+	mask, err := newFieldMask(in.FieldMask, (*extpb.BlockQueryResponse)(nil))
+	if err != nil {
+		return nil, err
+	}
+	id := types.BlockID(types.BytesToHash(in.Id).ToHash20())
+	block, err := s.Tx.GetBlock(id)
+	if err == database.ErrNotFound {
+		return nil, status.Errorf(codes.NotFound, "block %v not found", id)
+	}
+	if err == database.ErrPruned {
+		return nil, status.Errorf(codes.NotFound, "block %v was pruned", id)
+	}
+	if err != nil {
+		log.With().Error("error reading block", id, log.Err(err))
+		return nil, status.Errorf(codes.Internal, "error reading block")
+	}
+	res := &extpb.BlockQueryResponse{
+		Block:       s.readBlock(block, in.IncludeTransactions && mask.includes("block", "transactions")),
+		LayerStatus: s.layerStatus(block.Layer()),
+	}
+	mask.apply(res)
+	return res, nil
+}
+
+func (s MeshService) layerStatus(layerID types.LayerID) extpb.Layer_LayerStatus {
+	if layerID <= s.Tx.LatestLayerInState() {
+		return extpb.Layer_LAYER_STATUS_CONFIRMED
+	}
+	if layerID <= s.Tx.ProcessedLayer() {
+		return extpb.Layer_LAYER_STATUS_APPROVED
+	}
+	return extpb.Layer_LAYER_STATUS_UNSPECIFIED
+}
+
+func convertTransaction(tx *types.Transaction) *pb.Transaction {
+	return &pb.Transaction{
+		Id: &pb.TransactionId{Id: tx.ID().Bytes()},
+		Data: &pb.Transaction_CoinTransfer{CoinTransfer: &pb.CoinTransferTransaction{
+			Receiver: &pb.AccountId{Address: tx.Recipient.Bytes()},
+		}},
+		Sender: &pb.AccountId{Address: tx.Origin().Bytes()},
+		GasOffered: &pb.GasOffered{
+			GasProvided: tx.GasLimit,
+			GasPrice:    tx.Fee,
+		},
+		Amount:  &pb.Amount{Value: tx.Amount},
+		Counter: tx.AccountNonce,
+		Signature: &pb.Signature{
+			Scheme:    pb.Signature_SCHEME_ED25519_PLUS_PLUS,
+			Signature: tx.Signature[:],
+		},
+	}
+}
+
+func convertActivation(atx *types.ActivationTx) *pb.Activation {
+	return &pb.Activation{
+		Id:        &pb.ActivationId{Id: atx.ID().Bytes()},
+		Layer:     atx.PubLayerID.Uint64(),
+		SmesherId: &pb.SmesherId{Id: util.Hex2Bytes(atx.NodeID.Key)},
+		Coinbase:  &pb.AccountId{Address: atx.Coinbase.Bytes()},
+		PrevAtx:   &pb.ActivationId{Id: atx.PrevATXID.Bytes()},
+	}
+}
+
+func convertExtTransaction(tx *types.Transaction) *extpb.Transaction {
+	return &extpb.Transaction{
+		Id:        tx.ID().Bytes(),
+		Sender:    tx.Origin().Bytes(),
+		Recipient: tx.Recipient.Bytes(),
+		Amount:    tx.Amount,
+		Counter:   tx.AccountNonce,
+		GasLimit:  tx.GasLimit,
+		Fee:       tx.Fee,
+		Signature: tx.Signature[:],
+	}
+}
+
+func convertExtActivation(atx *types.ActivationTx) *extpb.Activation {
+	return &extpb.Activation{
+		Id:        atx.ID().Bytes(),
+		Layer:     atx.PubLayerID.Uint64(),
+		SmesherId: util.Hex2Bytes(atx.NodeID.Key),
+		Coinbase:  atx.Coinbase.Bytes(),
+		PrevAtx:   atx.PrevATXID.Bytes(),
+		Sequence:  atx.Sequence,
+	}
+}
+
+func convertLayerFromExt(layer *extpb.Layer) *pb.Layer {
+	res := &pb.Layer{
+		Number: layer.Number,
+		Status: pb.Layer_LayerStatus(layer.Status),
+		Hash:   layer.Hash,
+	}
+	for _, b := range layer.Blocks {
+		block := &pb.Block{Id: b.Id}
+		for _, tx := range b.Transactions {
+			block.Transactions = append(block.Transactions, &pb.Transaction{
+				Id: &pb.TransactionId{Id: tx.Id},
+				Data: &pb.Transaction_CoinTransfer{CoinTransfer: &pb.CoinTransferTransaction{
+					Receiver: &pb.AccountId{Address: tx.Recipient},
+				}},
+				Sender: &pb.AccountId{Address: tx.Sender},
+				GasOffered: &pb.GasOffered{
+					GasProvided: tx.GasLimit,
+					GasPrice:    tx.Fee,
+				},
+				Amount:  &pb.Amount{Value: tx.Amount},
+				Counter: tx.Counter,
+				Signature: &pb.Signature{
+					Scheme:    pb.Signature_SCHEME_ED25519_PLUS_PLUS,
+					Signature: tx.Signature,
+				},
+			})
+		}
+		res.Blocks = append(res.Blocks, block)
+	}
+	for _, atx := range layer.Activations {
+		res.Activations = append(res.Activations, &pb.Activation{
+			Id:        &pb.ActivationId{Id: atx.Id},
+			Layer:     atx.Layer,
+			SmesherId: &pb.SmesherId{Id: atx.SmesherId},
+			Coinbase:  &pb.AccountId{Address: atx.Coinbase},
+			PrevAtx:   &pb.ActivationId{Id: atx.PrevAtx},
+		})
+	}
+	return res
+}
+
+// layer page tokens are opaque to clients, they encode the first layer of the next page
+func encodeLayerPageToken(next types.LayerID) string {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, next.Uint64())
+	return base64.URLEncoding.EncodeToString(buf)
+}
+
+func decodeLayerPageToken(token string) (types.LayerID, error) {
+	buf, err := base64.URLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, err
+	}
+	if len(buf) != 8 {
+		return 0, errors.New("malformed page token")
+	}
+	return types.LayerID(binary.BigEndian.Uint64(buf)), nil
+}
+
+// MalfeasanceQuery returns the malfeasance proof of a smesher, if there is one
+func (s MeshService) MalfeasanceQuery(ctx context.Context, in *extpb.MalfeasanceQueryRequest) (*extpb.MalfeasanceQueryResponse, error) {
+	log.Info("GRPC MeshService.MalfeasanceQuery")
+
+	if len(in.SmesherId) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "`SmesherId` must be provided")
+	}
+	proof, err := s.Malfeasance.GetMalfeasanceProof(types.NodeID{Key: util.Bytes2Hex(in.SmesherId)})
+	if err == database.ErrNotFound {
+		return &extpb.MalfeasanceQueryResponse{}, nil
+	}
+	if err != nil {
+		log.Error("error reading malfeasance proof: %v", err)
+		return nil, status.Errorf(codes.Internal, "error reading malfeasance proof")
+	}
+	return &extpb.MalfeasanceQueryResponse{Proof: convertMalfeasanceProof(proof)}, nil
+}
+
+func convertMalfeasanceProof(proof *types.MalfeasanceProof) *extpb.MalfeasanceProof {
+	res := &extpb.MalfeasanceProof{
+		SmesherId: util.Hex2Bytes(proof.NodeID.Key),
+		Layer:     proof.Layer.Uint64(),
+		Messages:  proof.Messages,
+	}
+	switch proof.Type {
+	case types.MultipleAtxs:
+		res.Type = extpb.MalfeasanceProof_MALFEASANCE_TYPE_MULTIPLE_ATXS
+	case types.HareEquivocation:
+		res.Type = extpb.MalfeasanceProof_MALFEASANCE_TYPE_HARE_EQUIVOCATION
+	default:
+		res.Type = extpb.MalfeasanceProof_MALFEASANCE_TYPE_UNSPECIFIED
+	}
+	return res
 }
 
 // STREAMS
 
-// AccountMeshDataStream returns a stream of transactions and activations for an account
+// MalfeasanceStream returns a stream of malfeasance proofs as they are detected
+func (s MeshService) MalfeasanceStream(request *extpb.MalfeasanceStreamRequest, stream extpb.MeshService_MalfeasanceStreamServer) error {
+	log.Info("GRPC MeshService.MalfeasanceStream")
+
+	sub := events.Subscribe(events.EventMalfeasance)
+	defer sub.Close()
+	for {
+		select {
+		case <-stream.Context().Done():
+			log.Info("MalfeasanceStream closing stream, client disconnected")
+			return nil
+		case ev := <-sub.Events():
+			proof := ev.(events.MalfeasanceDetected).Proof
+			if err := stream.Send(&extpb.MalfeasanceStreamResponse{Proof: convertMalfeasanceProof(proof)}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// EpochStream returns a stream of epoch summaries, reported when the node enters a new epoch
+func (s MeshService) EpochStream(request *extpb.EpochStreamRequest, stream extpb.MeshService_EpochStreamServer) error {
+	log.Info("GRPC MeshService.EpochStream")
+
+	sub := events.Subscribe(events.EventEpochUpdate)
+	defer sub.Close()
+	for {
+		select {
+		case <-stream.Context().Done():
+			log.Info("EpochStream closing stream, client disconnected")
+			return nil
+		case ev := <-sub.Events():
+			update := ev.(events.EpochUpdate)
+			if err := stream.Send(&extpb.EpochStreamResponse{
+				Epoch:          uint64(update.Epoch),
+				TotalWeight:    update.TotalWeight,
+				ActiveSmeshers: update.ActiveSmeshers,
+				Beacon:         update.Beacon,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// AccountMeshDataStream returns a stream of transactions and activations for an account, as they are added to the
+// mesh. Transactions are reported when the account is their sender or recipient, activations when it's their coinbase.
 func (s MeshService) AccountMeshDataStream(request *pb.AccountMeshDataStreamRequest, stream pb.MeshService_AccountMeshDataStreamServer) error {
 	log.Info("GRPC MeshService.AccountMeshDataStream")
-	return nil
+
+	if request.Filter == nil {
+		return status.Errorf(codes.InvalidArgument, "`Filter` must be provided")
+	}
+	if request.Filter.AccountId == nil {
+		return status.Errorf(codes.InvalidArgument, "`Filter.AccountId` must be provided")
+	}
+	if request.Filter.AccountMeshDataFlags == uint32(pb.AccountMeshDataFlag_ACCOUNT_MESH_DATA_FLAG_UNSPECIFIED) {
+		return status.Errorf(codes.InvalidArgument, "`Filter.AccountMeshDataFlags` must set at least one bitfield")
+	}
+	addr := types.BytesToAddress(request.Filter.AccountId.Address)
+	filterTx := request.Filter.AccountMeshDataFlags&uint32(pb.AccountMeshDataFlag_ACCOUNT_MESH_DATA_FLAG_TRANSACTIONS) != 0
+	filterActivations := request.Filter.AccountMeshDataFlags&uint32(pb.AccountMeshDataFlag_ACCOUNT_MESH_DATA_FLAG_ACTIVATIONS) != 0
+
+	// a nil channel blocks forever, so we only receive the requested data
+	var txCh, atxCh <-chan events.Event
+	if filterTx {
+		sub := events.Subscribe(events.EventTxInMesh)
+		defer sub.Close()
+		txCh = sub.Events()
+	}
+	if filterActivations {
+		sub := events.Subscribe(events.EventAtxInMesh)
+		defer sub.Close()
+		atxCh = sub.Events()
+	}
+
+	for {
+		var data *pb.AccountMeshData
+		select {
+		case <-stream.Context().Done():
+			log.Info("AccountMeshDataStream closing stream, client disconnected")
+			return nil
+		case ev := <-txCh:
+			tx := ev.(events.TxInMesh).Transaction
+			if tx.Origin() != addr && tx.Recipient != addr {
+				continue
+			}
+			data = &pb.AccountMeshData{DataItem: &pb.AccountMeshData_Transaction{Transaction: convertTransaction(tx)}}
+		case ev := <-atxCh:
+			atx := ev.(events.AtxInMesh).Atx
+			if atx.Coinbase != addr {
+				continue
+			}
+			data = &pb.AccountMeshData{DataItem: &pb.AccountMeshData_Activation{Activation: convertActivation(atx)}}
+		}
+		if err := stream.Send(&pb.AccountMeshDataStreamResponse{Data: data}); err != nil {
+			return err
+		}
+	}
 }
 
-// LayerStream returns a stream of all mesh data per layer
+// LayerStream returns a stream of layer status updates: a layer is reported when it's first seen, when the hare
+// approved its blocks and when it was applied to the global state
 func (s MeshService) LayerStream(request *pb.LayerStreamRequest, stream pb.MeshService_LayerStreamServer) error {
 	log.Info("GRPC MeshService.LayerStream")
-	return nil
+
+	sub := events.Subscribe(events.EventLayerUpdate)
+	defer sub.Close()
+	for {
+		select {
+		case <-stream.Context().Done():
+			log.Info("LayerStream closing stream, client disconnected")
+			return nil
+		case ev := <-sub.Events():
+			layer := ev.(events.LayerUpdate)
+			if err := stream.Send(&pb.LayerStreamResponse{Layer: convertLayerUpdate(layer)}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func convertLayerUpdate(layer events.LayerUpdate) *pb.Layer {
+	res := &pb.Layer{Number: layer.LayerID.Uint64()}
+	switch layer.Status {
+	case events.LayerStatusApproved:
+		res.Status = pb.Layer_LAYER_STATUS_APPROVED
+	case events.LayerStatusConfirmed:
+		res.Status = pb.Layer_LAYER_STATUS_CONFIRMED
+		res.RootStateHash = layer.StateRoot.Bytes()
+	default:
+		res.Status = pb.Layer_LAYER_STATUS_UNSPECIFIED
+	}
+	if layer.Status != events.LayerStatusCreated {
+		res.Hash = types.CalcBlocksHash32(layer.Blocks, nil).Bytes()
+	}
+	for _, id := range layer.Blocks {
+		res.Blocks = append(res.Blocks, &pb.Block{Id: id.Bytes()})
+	}
+	return res
 }
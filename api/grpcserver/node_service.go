@@ -4,16 +4,23 @@ import (
 	"github.com/golang/protobuf/ptypes/empty"
 	pb "github.com/spacemeshos/api/release/go/spacemesh/v1"
 	"github.com/spacemeshos/go-spacemesh/api"
+	"github.com/spacemeshos/go-spacemesh/api/extpb"
 	"github.com/spacemeshos/go-spacemesh/cmd"
+	"github.com/spacemeshos/go-spacemesh/events"
 	"github.com/spacemeshos/go-spacemesh/log"
 	"github.com/spacemeshos/go-spacemesh/p2p/peers"
+	"github.com/spacemeshos/go-spacemesh/sync"
 	"golang.org/x/net/context"
 	"google.golang.org/genproto/googleapis/rpc/code"
 	rpcstatus "google.golang.org/genproto/googleapis/rpc/status"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"time"
 )
 
+// syncProgressInterval is how often SyncProgressStream sends the progress of the sync
+var syncProgressInterval = time.Second
+
 // NodeService is a grpc server that provides the NodeService, which exposes node-related
 // data such as node status, software version, errors, etc. It can also be used to start
 // the sync process, or to shut down the node.
@@ -23,23 +30,33 @@ type NodeService struct {
 	GenTime     api.GenesisTimeAPI
 	PeerCounter api.PeerCounter
 	Syncer      api.Syncer
+	Progress    api.SyncProgressAPI // nil if the syncer doesn't report its progress
+	Frontier    api.SyncFrontierAPI // nil if the syncer doesn't persist its progress
+	Finalized   api.FinalityAPI     // nil if the mesh doesn't track finality
 }
 
 // RegisterService registers this service with a grpc server instance
 func (s NodeService) RegisterService(server *Server) {
 	pb.RegisterNodeServiceServer(server.GrpcServer, s)
+	extpb.RegisterNodeServiceServer(server.GrpcServer, s)
 }
 
 // NewNodeService creates a new grpc service using config data.
 func NewNodeService(
 	net api.NetworkAPI, tx api.TxAPI, genTime api.GenesisTimeAPI,
 	syncer api.Syncer) *NodeService {
+	progress, _ := syncer.(api.SyncProgressAPI)
+	frontier, _ := syncer.(api.SyncFrontierAPI)
+	finalized, _ := tx.(api.FinalityAPI)
 	return &NodeService{
 		Network:     net,
 		Tx:          tx,
 		GenTime:     genTime,
 		PeerCounter: peers.NewPeers(net, log.NewDefault("grpc_server.NodeService")),
 		Syncer:      syncer,
+		Progress:    progress,
+		Frontier:    frontier,
+		Finalized:   finalized,
 	}
 }
 
@@ -101,6 +118,20 @@ func (s NodeService) Shutdown(ctx context.Context, request *pb.ShutdownRequest)
 	}, nil
 }
 
+// SyncFrontier returns the persisted progress of the sync: the last validated layer, and the layers being fetched
+func (s NodeService) SyncFrontier(ctx context.Context, in *extpb.SyncFrontierRequest) (*extpb.SyncFrontierResponse, error) {
+	log.Info("GRPC NodeService.SyncFrontier")
+	if s.Frontier == nil {
+		return nil, status.Errorf(codes.Unavailable, "sync frontier isn't available")
+	}
+	frontier := s.Frontier.SyncFrontier()
+	res := &extpb.SyncFrontierResponse{ValidatedLayer: frontier.Validated.Uint64()}
+	for _, layer := range frontier.InFlight {
+		res.InFlightLayers = append(res.InFlightLayers, layer.Uint64())
+	}
+	return res, nil
+}
+
 // STREAMS
 
 // StatusStream is a stub for a future server-side streaming RPC endpoint
@@ -114,3 +145,101 @@ func (s NodeService) ErrorStream(request *pb.ErrorStreamRequest, stream pb.NodeS
 	log.Info("GRPC NodeService.ErrorStream")
 	return nil
 }
+
+// SyncProgressStream streams the progress of the sync of the node, so clients can show how far it got and how long
+// it's expected to take
+func (s NodeService) SyncProgressStream(request *extpb.SyncProgressStreamRequest, stream extpb.NodeService_SyncProgressStreamServer) error {
+	log.Info("GRPC NodeService.SyncProgressStream")
+	if s.Progress == nil {
+		return status.Errorf(codes.Unavailable, "sync progress isn't available")
+	}
+	ticker := time.NewTicker(syncProgressInterval)
+	defer ticker.Stop()
+	for {
+		res := &extpb.SyncProgressStreamResponse{Progress: convertSyncProgress(s.Progress.SyncProgress())}
+		if err := stream.Send(res); err != nil {
+			return err
+		}
+		select {
+		case <-stream.Context().Done():
+			log.Info("SyncProgressStream closing stream, client disconnected")
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Finality returns the finalized layer of the node, so clients can wait for a transaction's layer to be final instead
+// of for an arbitrary number of confirmations
+func (s NodeService) Finality(ctx context.Context, in *extpb.FinalityRequest) (*extpb.FinalityResponse, error) {
+	log.Info("GRPC NodeService.Finality")
+	if s.Finalized == nil {
+		return nil, status.Errorf(codes.Unavailable, "finality isn't available")
+	}
+	return &extpb.FinalityResponse{
+		FinalizedLayer: s.Finalized.FinalizedLayer().Uint64(),
+		VerifiedLayer:  s.Tx.LatestLayerInState().Uint64(),
+	}, nil
+}
+
+// FinalityStream streams the layers as they become final
+func (s NodeService) FinalityStream(in *extpb.FinalityStreamRequest, stream extpb.NodeService_FinalityStreamServer) error {
+	log.Info("GRPC NodeService.FinalityStream")
+	if s.Finalized == nil {
+		return status.Errorf(codes.Unavailable, "finality isn't available")
+	}
+
+	sub := events.Subscribe(events.EventLayerFinalized)
+	defer sub.Close()
+	for {
+		select {
+		case <-stream.Context().Done():
+			log.Info("FinalityStream closing stream, client disconnected")
+			return nil
+		case ev := <-sub.Events():
+			if err := stream.Send(&extpb.FinalityStreamResponse{Finality: convertLayerFinalized(ev.(events.LayerFinalized))}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func convertLayerFinalized(ev events.LayerFinalized) *extpb.LayerFinality {
+	res := &extpb.LayerFinality{
+		Layer:  ev.LayerID.Uint64(),
+		Source: extpb.LayerFinality_FINALITY_SOURCE_TORTOISE,
+	}
+	if ev.ByHare {
+		res.Source = extpb.LayerFinality_FINALITY_SOURCE_HARE
+	}
+	return res
+}
+
+func convertSyncProgress(p sync.Progress) *extpb.SyncProgress {
+	res := &extpb.SyncProgress{
+		ProcessedLayer:  p.ProcessedLayer.Uint64(),
+		CurrentLayer:    p.CurrentLayer.Uint64(),
+		LayersCompleted: p.LayersCompleted,
+		LayersRemaining: p.LayersRemaining,
+		LayersPerSecond: p.LayersPerSecond,
+		BytesPerSecond:  p.BytesPerSecond,
+		EtaSeconds:      uint64(p.ETA.Seconds()),
+	}
+	switch p.Phase {
+	case sync.PhaseStarting:
+		res.Phase = extpb.SyncProgress_SYNC_PHASE_STARTING
+	case sync.PhaseSnapshot:
+		res.Phase = extpb.SyncProgress_SYNC_PHASE_SNAPSHOT
+	case sync.PhaseHeaders:
+		res.Phase = extpb.SyncProgress_SYNC_PHASE_HEADERS
+	case sync.PhaseLayers:
+		res.Phase = extpb.SyncProgress_SYNC_PHASE_LAYERS
+	case sync.PhaseGossip:
+		res.Phase = extpb.SyncProgress_SYNC_PHASE_GOSSIP
+	case sync.PhaseSynced:
+		res.Phase = extpb.SyncProgress_SYNC_PHASE_SYNCED
+	default:
+		res.Phase = extpb.SyncProgress_SYNC_PHASE_UNSPECIFIED
+	}
+	return res
+}
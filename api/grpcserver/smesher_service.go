@@ -0,0 +1,75 @@
+package grpcserver
+
+import (
+	"github.com/spacemeshos/go-spacemesh/api"
+	"github.com/spacemeshos/go-spacemesh/api/extpb"
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/miner"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SmesherService is a grpc server providing the node-local SmesherService endpoints, which expose
+// data about this node's role as a smesher
+type SmesherService struct {
+	GenTime     api.GenesisTimeAPI
+	Eligibility api.EligibilityAPI
+}
+
+// RegisterService registers this service with a grpc server instance
+func (s SmesherService) RegisterService(server *Server) {
+	extpb.RegisterSmesherServiceServer(server.GrpcServer, s)
+}
+
+// NewSmesherService creates a new grpc service using config data.
+func NewSmesherService(genTime api.GenesisTimeAPI, eligibility api.EligibilityAPI) *SmesherService {
+	return &SmesherService{
+		GenTime:     genTime,
+		Eligibility: eligibility,
+	}
+}
+
+// EligibilityReport returns the number of block and hare eligibilities this smesher has in the
+// current and the next epoch
+func (s SmesherService) EligibilityReport(ctx context.Context, in *extpb.EligibilityReportRequest) (*extpb.EligibilityReportResponse, error) {
+	log.Info("GRPC SmesherService.EligibilityReport")
+
+	currentEpoch := s.GenTime.GetCurrentLayer().GetEpoch()
+	current, err := s.Eligibility.EpochEligibility(currentEpoch)
+	if err != nil {
+		log.Error("error calculating eligibility for epoch %v: %v", currentEpoch, err)
+		return nil, status.Errorf(codes.Internal, "error calculating eligibility for current epoch")
+	}
+	next, err := s.Eligibility.EpochEligibility(currentEpoch + 1)
+	if err != nil {
+		log.Error("error calculating eligibility for epoch %v: %v", currentEpoch+1, err)
+		return nil, status.Errorf(codes.Internal, "error calculating eligibility for next epoch")
+	}
+
+	return &extpb.EligibilityReportResponse{
+		Current: convertEpochEligibility(current),
+		Next:    convertEpochEligibility(next),
+	}, nil
+}
+
+func convertEpochEligibility(e *miner.EpochEligibility) *extpb.EpochEligibility {
+	res := &extpb.EpochEligibility{
+		Epoch:         uint64(e.Epoch),
+		ActiveSetSize: e.ActiveSetSize,
+		NumBlocks:     e.NumBlocks,
+	}
+	if e.NumBlocks > 0 {
+		res.AtxId = e.AtxID.Bytes()
+	}
+	for _, l := range e.BlockLayers {
+		res.BlockLayers = append(res.BlockLayers, &extpb.LayerEligibility{
+			Layer:     l.Layer.Uint64(),
+			NumBlocks: l.NumBlocks,
+		})
+	}
+	for _, l := range e.HareLayers {
+		res.HareLayers = append(res.HareLayers, l.Uint64())
+	}
+	return res
+}
@@ -0,0 +1,102 @@
+package grpcserver
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/spacemeshos/go-spacemesh/api/extpb"
+	"github.com/spacemeshos/go-spacemesh/log"
+)
+
+const (
+	swaggerPath   = "/swagger/"
+	swaggerSuffix = ".swagger.json"
+	swaggerUIPath = "/swagger-ui/"
+)
+
+// swaggerUI loads the swagger ui assets from a CDN, so they don't have to be shipped with the node
+var swaggerUI = template.Must(template.New("swagger-ui").Parse(`<!DOCTYPE html>
+<html>
+<head>
+  <title>Spacemesh API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@3/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@3/swagger-ui-bundle.js"></script>
+  <script src="https://unpkg.com/swagger-ui-dist@3/swagger-ui-standalone-preset.js"></script>
+  <script>
+    window.onload = function() {
+      window.ui = SwaggerUIBundle({
+        urls: [{{range .}}{url: {{.URL}}, name: {{.Name}}},{{end}}],
+        dom_id: "#swagger-ui",
+        presets: [SwaggerUIBundle.presets.apis, SwaggerUIStandalonePreset],
+        layout: "StandaloneLayout"
+      });
+    };
+  </script>
+</body>
+</html>
+`))
+
+type swaggerURL struct {
+	URL  string
+	Name string
+}
+
+// swaggerHandler serves the swagger definitions of the given services at /swagger/<definition>.swagger.json. Only the
+// local api extensions have definitions, the upstream api publishes its own.
+type swaggerHandler struct {
+	services []gatewayService
+}
+
+func (h swaggerHandler) enabled(definition string) bool {
+	for _, svc := range h.services {
+		if svc.enabled && svc.swagger == definition {
+			return true
+		}
+	}
+	return false
+}
+
+func (h swaggerHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, swaggerPath)
+	if !strings.HasSuffix(name, swaggerSuffix) {
+		http.NotFound(w, r)
+		return
+	}
+	name = strings.TrimSuffix(name, swaggerSuffix)
+	if name == "" || !h.enabled(name) {
+		http.NotFound(w, r)
+		return
+	}
+	def, ok := extpb.SwaggerDefinition(name)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(def); err != nil {
+		log.Error("error writing swagger definition %v: %v", name, err)
+	}
+}
+
+// serveUI serves a swagger ui page that lists the definitions of all the enabled services
+func (h swaggerHandler) serveUI(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != swaggerUIPath {
+		http.NotFound(w, r)
+		return
+	}
+	var urls []swaggerURL
+	for _, svc := range h.services {
+		if svc.enabled && svc.swagger != "" {
+			urls = append(urls, swaggerURL{URL: fmt.Sprintf("%v%v%v", swaggerPath, svc.swagger, swaggerSuffix), Name: svc.name})
+		}
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := swaggerUI.Execute(w, urls); err != nil {
+		log.Error("error rendering swagger ui: %v", err)
+	}
+}
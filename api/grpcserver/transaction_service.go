@@ -0,0 +1,665 @@
+package grpcserver
+
+import (
+	"fmt"
+	"sort"
+
+	pb "github.com/spacemeshos/api/release/go/spacemesh/v1"
+	"github.com/spacemeshos/go-spacemesh/api"
+	"github.com/spacemeshos/go-spacemesh/api/extpb"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/database"
+	"github.com/spacemeshos/go-spacemesh/events"
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/mesh"
+	"github.com/spacemeshos/go-spacemesh/state"
+	"golang.org/x/net/context"
+	rpcstatus "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TransactionService is a grpc server providing the TransactionService, along with the node-local extensions to it
+type TransactionService struct {
+	Network     api.NetworkAPI // P2P Swarm
+	Tx          api.TxAPI      // Mesh
+	Mempool     api.MempoolAPI
+	Projector   api.StateProjector
+	Receipts    api.ReceiptAPI
+	MinTxFee    uint64
+	TxsPerLayer int
+}
+
+// RegisterService registers this service with a grpc server instance
+func (s TransactionService) RegisterService(server *Server) {
+	pb.RegisterTransactionServiceServer(server.GrpcServer, s)
+	extpb.RegisterTransactionServiceServer(server.GrpcServer, s)
+}
+
+// NewTransactionService creates a new grpc service using config data.
+func NewTransactionService(
+	net api.NetworkAPI, tx api.TxAPI, mempool api.MempoolAPI, projector api.StateProjector, receipts api.ReceiptAPI,
+	minTxFee uint64, txsPerLayer int) *TransactionService {
+	return &TransactionService{
+		Network:     net,
+		Tx:          tx,
+		Mempool:     mempool,
+		Projector:   projector,
+		Receipts:    receipts,
+		MinTxFee:    minTxFee,
+		TxsPerLayer: txsPerLayer,
+	}
+}
+
+const (
+	// maxBatchTransactions is the max number of transactions that can be submitted in a single batch
+	maxBatchTransactions = 1000
+	// feeEstimationLayers is the number of recent layers whose transaction fees are sampled to estimate fees
+	feeEstimationLayers = 10
+	// defaultAccountTransactions is the number of transactions returned by AccountTransactions unless the client
+	// asks for another number
+	defaultAccountTransactions = 100
+	// maxAccountTransactions is the max number of transactions returned by AccountTransactions
+	maxAccountTransactions = 1000
+)
+
+// txValidation is the outcome of validating a submitted transaction against the projected global state
+type txValidation struct {
+	tx       *types.Transaction
+	validity extpb.TransactionValidity
+	message  string
+	nonce    uint64
+	balance  uint64
+}
+
+// accountProjection is the projected nonce and balance of an account after applying the valid transactions of a batch
+type accountProjection struct {
+	nonce   uint64
+	balance uint64
+}
+
+// validateTransaction decodes a signed transaction and checks its signature, nonce, balance and fee. The signer is
+// recovered from the signature, so a bad signature recovers a random signer: it's told from an unknown signer by
+// comparing the signer to origin, if the client gave it, and reported as a bad signature otherwise. The reason a
+// transaction is invalid is returned as part of the result, an error is only returned if the state couldn't be projected.
+// When validating a batch, batch holds the projections of the accounts seen so far and is updated with the transaction
+// if it's valid, so that transactions of the same account can be validated on top of each other. It's nil otherwise.
+func (s TransactionService) validateTransaction(raw, origin []byte, batch map[types.Address]*accountProjection) (*txValidation, error) {
+	res := &txValidation{}
+	tx, err := types.BytesToTransaction(raw)
+	if err != nil {
+		res.validity = extpb.TransactionValidity_TRANSACTION_VALIDITY_MALFORMED
+		res.message = fmt.Sprintf("failed to deserialize transaction: %v", err)
+		return res, nil
+	}
+	res.tx = tx
+	if err := tx.CalcAndSetOrigin(); err != nil {
+		res.validity = extpb.TransactionValidity_TRANSACTION_VALIDITY_INVALID_SIGNATURE
+		res.message = fmt.Sprintf("failed to recover signer: %v", err)
+		return res, nil
+	}
+	if len(origin) > 0 && types.BytesToAddress(origin) != tx.Origin() {
+		res.validity = extpb.TransactionValidity_TRANSACTION_VALIDITY_INVALID_SIGNATURE
+		res.message = fmt.Sprintf("transaction is signed by %v instead of origin %v",
+			tx.Origin().Short(), types.BytesToAddress(origin).Short())
+		return res, nil
+	}
+	if !s.Tx.AddressExists(tx.Origin()) {
+		if len(origin) == 0 {
+			res.validity = extpb.TransactionValidity_TRANSACTION_VALIDITY_INVALID_SIGNATURE
+			res.message = fmt.Sprintf("transaction signer %v not found in global state, the signature is likely invalid",
+				tx.Origin().Short())
+			return res, nil
+		}
+		res.validity = extpb.TransactionValidity_TRANSACTION_VALIDITY_UNKNOWN_ORIGIN
+		res.message = fmt.Sprintf("transaction origin %v not found in global state", tx.Origin().Short())
+		return res, nil
+	}
+	projection, ok := batch[tx.Origin()]
+	if !ok {
+		nonce, balance, err := s.Projector.GetProjection(tx.Origin())
+		if err != nil {
+			return nil, fmt.Errorf("failed to project state of origin %v: %v", tx.Origin().Short(), err)
+		}
+		projection = &accountProjection{nonce: nonce, balance: balance}
+		if batch != nil {
+			batch[tx.Origin()] = projection
+		}
+	}
+	nonce, balance := projection.nonce, projection.balance
+	res.nonce, res.balance = nonce, balance
+	switch {
+	case tx.Fee < s.MinTxFee:
+		res.validity = extpb.TransactionValidity_TRANSACTION_VALIDITY_FEE_TOO_LOW
+		res.message = fmt.Sprintf("fee too low, minimum: %d, actual: %d", s.MinTxFee, tx.Fee)
+	case tx.AccountNonce != nonce:
+		res.validity = extpb.TransactionValidity_TRANSACTION_VALIDITY_BAD_NONCE
+		res.message = fmt.Sprintf("incorrect account nonce, expected: %d, actual: %d", nonce, tx.AccountNonce)
+	case tx.Amount+tx.Fee < tx.Amount || tx.Amount+tx.Fee > balance:
+		res.validity = extpb.TransactionValidity_TRANSACTION_VALIDITY_INSUFFICIENT_BALANCE
+		res.message = fmt.Sprintf("insufficient balance, available: %d, attempting to spend: %d[amount]+%d[fee]",
+			balance, tx.Amount, tx.Fee)
+	default:
+		projection.nonce++
+		projection.balance -= tx.Amount + tx.Fee
+	}
+	return res, nil
+}
+
+// broadcast sends validated transactions to the network, in order, where they are also picked up by this node's mempool
+func (s TransactionService) broadcast(txs []*types.Transaction, raws [][]byte) {
+	for _, tx := range txs {
+		log.With().Info("GRPC TransactionService broadcasting tx", tx.ID(),
+			log.String("origin", tx.Origin().Short()),
+			log.String("recipient", tx.Recipient.Short()),
+			log.Uint64("nonce", tx.AccountNonce),
+			log.Uint64("amount", tx.Amount),
+			log.Uint64("fee", tx.Fee))
+	}
+	go func() {
+		for i, raw := range raws {
+			if err := s.Network.Broadcast(state.IncomingTxProtocol, raw); err != nil {
+				log.With().Error("failed to broadcast tx", txs[i].ID(), log.Err(err))
+			}
+		}
+	}()
+}
+
+// SubmitTransaction validates a signed transaction against the projected global state and broadcasts it if it's valid
+func (s TransactionService) SubmitTransaction(ctx context.Context, in *pb.SubmitTransactionRequest) (*pb.SubmitTransactionResponse, error) {
+	log.Info("GRPC TransactionService.SubmitTransaction")
+
+	if len(in.Transaction) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "`Transaction` payload empty")
+	}
+	res, err := s.validateTransaction(in.Transaction, nil, nil)
+	if err != nil {
+		log.Error("error validating transaction: %v", err)
+		return nil, status.Errorf(codes.Internal, "error projecting account state")
+	}
+	if res.validity == extpb.TransactionValidity_TRANSACTION_VALIDITY_MALFORMED {
+		return nil, status.Errorf(codes.InvalidArgument, "`Transaction` must contain a valid, serialized transaction")
+	}
+
+	txState := &pb.TransactionState{Id: &pb.TransactionId{Id: res.tx.ID().Bytes()}}
+	if res.validity != extpb.TransactionValidity_TRANSACTION_VALIDITY_VALID {
+		log.With().Info("GRPC TransactionService.SubmitTransaction rejected tx", res.tx.ID(),
+			log.String("reason", res.validity.String()), log.String("message", res.message))
+		txState.State = convertTxValidity(res.validity)
+		return &pb.SubmitTransactionResponse{
+			Status:  &rpcstatus.Status{Code: int32(codes.FailedPrecondition), Message: res.message},
+			Txstate: txState,
+		}, nil
+	}
+
+	s.broadcast([]*types.Transaction{res.tx}, [][]byte{in.Transaction})
+	txState.State = pb.TransactionState_TRANSACTION_STATE_MEMPOOL
+	return &pb.SubmitTransactionResponse{
+		Status:  &rpcstatus.Status{Code: int32(codes.OK)},
+		Txstate: txState,
+	}, nil
+}
+
+// SubmitTransactionWithOptions validates a signed transaction against the projected global state and returns the
+// reason it's invalid, if it is. Valid transactions are broadcast unless a dry run was requested.
+func (s TransactionService) SubmitTransactionWithOptions(ctx context.Context, in *extpb.SubmitTransactionWithOptionsRequest) (*extpb.SubmitTransactionWithOptionsResponse, error) {
+	log.Info("GRPC TransactionService.SubmitTransactionWithOptions")
+
+	if len(in.Transaction) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "`Transaction` payload empty")
+	}
+	if len(in.Origin) != 0 && len(in.Origin) != types.AddressLength {
+		return nil, status.Errorf(codes.InvalidArgument, "`Origin` must be an address of %d bytes", types.AddressLength)
+	}
+	res, err := s.validateTransaction(in.Transaction, in.Origin, nil)
+	if err != nil {
+		log.Error("error validating transaction: %v", err)
+		return nil, status.Errorf(codes.Internal, "error projecting account state")
+	}
+	resp := convertTxValidation(res)
+	if res.validity == extpb.TransactionValidity_TRANSACTION_VALIDITY_VALID && !in.DryRun {
+		s.broadcast([]*types.Transaction{res.tx}, [][]byte{in.Transaction})
+		resp.Broadcast = true
+	}
+	return resp, nil
+}
+
+// SubmitTransactions validates a batch of signed transactions and returns a result for each of them, in order.
+// Transactions of the same account are validated on top of the valid ones that precede them in the batch. Valid
+// transactions are broadcast together unless a dry run was requested.
+func (s TransactionService) SubmitTransactions(ctx context.Context, in *extpb.SubmitTransactionsRequest) (*extpb.SubmitTransactionsResponse, error) {
+	log.Info("GRPC TransactionService.SubmitTransactions")
+
+	if len(in.Transactions) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "`Transactions` must include one or more transactions")
+	}
+	if len(in.Transactions) > maxBatchTransactions {
+		return nil, status.Errorf(codes.InvalidArgument, "`Transactions` must include at most %d transactions", maxBatchTransactions)
+	}
+	if len(in.Origins) != 0 && len(in.Origins) != len(in.Transactions) {
+		return nil, status.Errorf(codes.InvalidArgument, "`Origins` must include an origin for every transaction")
+	}
+	for _, origin := range in.Origins {
+		if len(origin) != 0 && len(origin) != types.AddressLength {
+			return nil, status.Errorf(codes.InvalidArgument, "`Origins` must be addresses of %d bytes", types.AddressLength)
+		}
+	}
+
+	resp := &extpb.SubmitTransactionsResponse{}
+	batch := make(map[types.Address]*accountProjection)
+	var valid []*types.Transaction
+	var raws [][]byte
+	for i, raw := range in.Transactions {
+		var origin []byte
+		if len(in.Origins) != 0 {
+			origin = in.Origins[i]
+		}
+		res, err := s.validateTransaction(raw, origin, batch)
+		if err != nil {
+			log.Error("error validating transaction: %v", err)
+			return nil, status.Errorf(codes.Internal, "error projecting account state")
+		}
+		result := convertTxValidation(res)
+		if res.validity == extpb.TransactionValidity_TRANSACTION_VALIDITY_VALID && !in.DryRun {
+			valid = append(valid, res.tx)
+			raws = append(raws, raw)
+			result.Broadcast = true
+		}
+		resp.Results = append(resp.Results, result)
+	}
+	if len(valid) > 0 {
+		s.broadcast(valid, raws)
+	}
+	return resp, nil
+}
+
+// DecodeTransaction returns the fields of a signed transaction, including the sender recovered from its signature,
+// without validating or submitting it
+func (s TransactionService) DecodeTransaction(ctx context.Context, in *extpb.DecodeTransactionRequest) (*extpb.DecodeTransactionResponse, error) {
+	log.Info("GRPC TransactionService.DecodeTransaction")
+
+	if len(in.Transaction) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "`Transaction` payload empty")
+	}
+	tx, err := types.BytesToTransaction(in.Transaction)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "`Transaction` must contain a valid, serialized transaction")
+	}
+	if err := tx.CalcAndSetOrigin(); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to recover the sender from the `Transaction` signature")
+	}
+	return &extpb.DecodeTransactionResponse{Transaction: convertExtTransaction(tx)}, nil
+}
+
+// AccountTransactions returns the transactions in the mesh that involve an account, ordered by layer. Results are paged,
+// the next page starts after the last transaction of the previous one.
+func (s TransactionService) AccountTransactions(ctx context.Context, in *extpb.AccountTransactionsRequest) (*extpb.AccountTransactionsResponse, error) {
+	log.Info("GRPC TransactionService.AccountTransactions")
+
+	if len(in.AccountId) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "`AccountId` must be provided")
+	}
+	limit := int(in.MaxResults)
+	if limit == 0 {
+		limit = defaultAccountTransactions
+	}
+	if limit > maxAccountTransactions {
+		return nil, status.Errorf(codes.InvalidArgument, "`MaxResults` must be at most %d", maxAccountTransactions)
+	}
+	maxLayer := types.LayerID(in.MaxLayer)
+	if maxLayer == 0 {
+		maxLayer = s.Tx.LatestLayer()
+	}
+	if types.LayerID(in.MinLayer) > maxLayer {
+		return nil, status.Errorf(codes.InvalidArgument, "`MinLayer` must not be greater than `MaxLayer`")
+	}
+	var direction mesh.TxDirection
+	switch in.Direction {
+	case extpb.TransactionDirection_TRANSACTION_DIRECTION_ANY:
+		direction = mesh.TxAnyDirection
+	case extpb.TransactionDirection_TRANSACTION_DIRECTION_SENT:
+		direction = mesh.TxSent
+	case extpb.TransactionDirection_TRANSACTION_DIRECTION_RECEIVED:
+		direction = mesh.TxReceived
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unknown `Direction`")
+	}
+
+	addr := types.BytesToAddress(in.AccountId)
+	history, next, err := s.Tx.GetAccountTransactions(addr, direction, types.LayerID(in.MinLayer), maxLayer, in.PageToken, limit)
+	if err == mesh.ErrBadCursor {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid `PageToken`")
+	}
+	if err != nil {
+		log.Error("error reading transactions of account %v: %v", addr.Short(), err)
+		return nil, status.Errorf(codes.Internal, "error reading transactions")
+	}
+
+	ids := make([]types.TransactionID, 0, len(history))
+	for _, entry := range history {
+		ids = append(ids, entry.ID)
+	}
+	txs, missing := s.Tx.GetTransactions(ids)
+	if len(missing) > 0 {
+		log.Error("could not find %d transactions of account %v", len(missing), addr.Short())
+		return nil, status.Errorf(codes.Internal, "error reading transactions")
+	}
+	byID := make(map[types.TransactionID]*types.Transaction, len(txs))
+	for _, tx := range txs {
+		byID[tx.ID()] = tx
+	}
+	res := &extpb.AccountTransactionsResponse{NextPageToken: next}
+	for _, entry := range history {
+		res.Transactions = append(res.Transactions, &extpb.AccountTransaction{
+			Transaction: convertExtTransaction(byID[entry.ID]),
+			Layer:       entry.Layer.Uint64(),
+			Sent:        entry.Direction&mesh.TxSent != 0,
+			Received:    entry.Direction&mesh.TxReceived != 0,
+		})
+	}
+	return res, nil
+}
+
+// TransactionReceipt returns the receipt of a transaction that was applied to the global state, including the reason
+// it failed for transactions that couldn't be applied
+func (s TransactionService) TransactionReceipt(ctx context.Context, in *extpb.TransactionReceiptRequest) (*extpb.TransactionReceiptResponse, error) {
+	log.Info("GRPC TransactionService.TransactionReceipt")
+
+	if len(in.Id) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "`Id` must be provided")
+	}
+	id := types.TransactionID(types.BytesToHash(in.Id))
+	receipt, err := s.Receipts.Get(id)
+	if err == database.ErrNotFound {
+		return nil, status.Errorf(codes.NotFound, "no receipt for transaction %v", id.ShortString())
+	}
+	if err != nil {
+		log.Error("error reading receipt of transaction %v: %v", id.ShortString(), err)
+		return nil, status.Errorf(codes.Internal, "error reading transaction receipt")
+	}
+	return &extpb.TransactionReceiptResponse{Receipt: convertExtReceipt(receipt)}, nil
+}
+
+// TransactionReceiptStream returns a stream of the receipts of transactions as they are applied to the global state,
+// optionally only of the requested transactions
+func (s TransactionService) TransactionReceiptStream(in *extpb.TransactionReceiptStreamRequest, stream extpb.TransactionService_TransactionReceiptStreamServer) error {
+	log.Info("GRPC TransactionService.TransactionReceiptStream")
+
+	ids := make(map[types.TransactionID]struct{}, len(in.Id))
+	for _, id := range in.Id {
+		ids[types.TransactionID(types.BytesToHash(id))] = struct{}{}
+	}
+	sub := events.Subscribe(events.EventTxApplied)
+	defer sub.Close()
+	for {
+		select {
+		case <-stream.Context().Done():
+			log.Info("TransactionReceiptStream closing stream, client disconnected")
+			return nil
+		case ev := <-sub.Events():
+			applied := ev.(events.TxApplied)
+			if _, ok := ids[applied.ID]; len(ids) > 0 && !ok {
+				continue
+			}
+			receipt := appliedReceipt(applied)
+			if err := stream.Send(&extpb.TransactionReceiptStreamResponse{Receipt: convertExtReceipt(receipt)}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func convertExtReceipt(receipt *state.Receipt) *extpb.TransactionReceipt {
+	res := &extpb.TransactionReceipt{
+		Id:      receipt.ID.Bytes(),
+		Layer:   receipt.Layer.Uint64(),
+		GasUsed: receipt.GasUsed,
+		Fee:     receipt.Fee,
+		Error:   receipt.Error,
+	}
+	switch receipt.Result {
+	case events.TxResultApplied:
+		res.Result = extpb.TransactionReceipt_TRANSACTION_RESULT_APPLIED
+	case events.TxResultUnknownOrigin:
+		res.Result = extpb.TransactionReceipt_TRANSACTION_RESULT_UNKNOWN_ORIGIN
+	case events.TxResultBadNonce:
+		res.Result = extpb.TransactionReceipt_TRANSACTION_RESULT_BAD_COUNTER
+	case events.TxResultInsufficientFunds:
+		res.Result = extpb.TransactionReceipt_TRANSACTION_RESULT_INSUFFICIENT_FUNDS
+	}
+	return res
+}
+
+// EstimateFee recommends low, medium and high fees based on the fees of the transactions included in recent layers,
+// the number of transactions waiting in the mempool and the minimal fee this node accepts
+func (s TransactionService) EstimateFee(ctx context.Context, in *extpb.EstimateFeeRequest) (*extpb.EstimateFeeResponse, error) {
+	log.Info("GRPC TransactionService.EstimateFee")
+
+	last := s.Tx.LatestLayer()
+	first := types.LayerID(0)
+	if last >= feeEstimationLayers {
+		first = last - feeEstimationLayers + 1
+	}
+	var fees []uint64
+	seen := make(map[types.TransactionID]struct{})
+	for l := first; l <= last; l++ {
+		layer, err := s.Tx.GetLayer(l)
+		if err == database.ErrNotFound || err == database.ErrPruned {
+			continue
+		}
+		if err != nil {
+			log.Error("error reading layer %v: %v", l, err)
+			return nil, status.Errorf(codes.Internal, "error reading layer data")
+		}
+		var ids []types.TransactionID
+		for _, b := range layer.Blocks() {
+			for _, id := range b.TxIDs {
+				if _, ok := seen[id]; !ok {
+					seen[id] = struct{}{}
+					ids = append(ids, id)
+				}
+			}
+		}
+		txs, _ := s.Tx.GetTransactions(ids)
+		for _, tx := range txs {
+			fees = append(fees, tx.Fee)
+		}
+	}
+
+	pending := s.Mempool.Len()
+	congested := pending > s.TxsPerLayer
+	low, medium, high := estimateFees(fees, s.MinTxFee, congested)
+	return &extpb.EstimateFeeResponse{
+		LowFee:              low,
+		MediumFee:           medium,
+		HighFee:             high,
+		MinFee:              s.MinTxFee,
+		MempoolSize:         uint64(pending),
+		Congested:           congested,
+		SampledLayers:       uint64(last - first + 1),
+		SampledTransactions: uint64(len(fees)),
+	}, nil
+}
+
+// estimateFees returns fee tiers from the fees of recently included transactions: the 25th, 50th and 90th percentile.
+// When the mempool holds more transactions than fit in a layer each tier moves up a step, the high tier outbidding every
+// sampled fee. No tier is ever below the minimal fee.
+func estimateFees(fees []uint64, minFee uint64, congested bool) (low, medium, high uint64) {
+	if len(fees) > 0 {
+		sorted := make([]uint64, len(fees))
+		copy(sorted, fees)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		percentile := func(p int) uint64 {
+			return sorted[(len(sorted)-1)*p/100]
+		}
+		low, medium, high = percentile(25), percentile(50), percentile(90)
+		if congested {
+			low, medium, high = medium, high, sorted[len(sorted)-1]+1
+		}
+	}
+	return maxUint64(low, minFee), maxUint64(medium, minFee), maxUint64(high, minFee)
+}
+
+func maxUint64(a, b uint64) uint64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// TransactionsState returns the current state of one or more transactions
+func (s TransactionService) TransactionsState(ctx context.Context, in *pb.TransactionsStateRequest) (*pb.TransactionsStateResponse, error) {
+	log.Info("GRPC TransactionService.TransactionsState")
+
+	if len(in.TransactionId) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "`TransactionId` must include one or more transaction IDs")
+	}
+	res := &pb.TransactionsStateResponse{}
+	for _, pbID := range in.TransactionId {
+		id := types.TransactionID(types.BytesToHash(pbID.Id))
+		txState, tx := s.transactionState(id)
+		res.TransactionsState = append(res.TransactionsState, &pb.TransactionState{Id: pbID, State: txState})
+		if in.IncludeTransactions && tx != nil {
+			res.Transactions = append(res.Transactions, convertTransaction(tx))
+		}
+	}
+	return res, nil
+}
+
+// transactionState returns the state of a transaction along with the transaction itself, if it's known
+func (s TransactionService) transactionState(id types.TransactionID) (pb.TransactionState_TransactionState, *types.Transaction) {
+	if tx, err := s.Tx.GetTransaction(id); err == nil && tx != nil {
+		if s.Tx.GetLayerApplied(id) != nil {
+			return pb.TransactionState_TRANSACTION_STATE_PROCESSED, tx
+		}
+		return pb.TransactionState_TRANSACTION_STATE_MESH, tx
+	}
+	if tx, err := s.Mempool.Get(id); err == nil {
+		return pb.TransactionState_TRANSACTION_STATE_MEMPOOL, tx
+	}
+	return pb.TransactionState_TRANSACTION_STATE_UNSPECIFIED, nil
+}
+
+// STREAMS
+
+// TransactionsStateStream returns the current state of one or more transactions, followed by a stream of their state
+// changes as they move from the mempool into a block and are then applied to the global state
+func (s TransactionService) TransactionsStateStream(in *pb.TransactionsStateStreamRequest, stream pb.TransactionService_TransactionsStateStreamServer) error {
+	log.Info("GRPC TransactionService.TransactionsStateStream")
+
+	if len(in.TransactionId) == 0 {
+		return status.Errorf(codes.InvalidArgument, "`TransactionId` must include one or more transaction IDs")
+	}
+
+	// subscribe before reading the current states so that no transition is missed
+	mempoolSub := events.Subscribe(events.EventTxInMempool)
+	defer mempoolSub.Close()
+	meshSub := events.Subscribe(events.EventTxInMesh)
+	defer meshSub.Close()
+	appliedSub := events.Subscribe(events.EventTxApplied)
+	defer appliedSub.Close()
+
+	// the last state sent for each transaction. Events of different channels may be received out of order, so a
+	// transaction never moves back to an earlier state.
+	states := make(map[types.TransactionID]pb.TransactionState_TransactionState)
+	send := func(id types.TransactionID, txState pb.TransactionState_TransactionState, tx *types.Transaction) error {
+		if prev, ok := states[id]; ok && txStateRank(prev) >= txStateRank(txState) {
+			return nil
+		}
+		states[id] = txState
+		res := &pb.TransactionsStateStreamResponse{
+			TransactionsState: []*pb.TransactionState{{Id: &pb.TransactionId{Id: id.Bytes()}, State: txState}},
+		}
+		if in.IncludeTransactions && tx != nil {
+			res.Transactions = []*pb.Transaction{convertTransaction(tx)}
+		}
+		return stream.Send(res)
+	}
+
+	for _, pbID := range in.TransactionId {
+		id := types.TransactionID(types.BytesToHash(pbID.Id))
+		txState, tx := s.transactionState(id)
+		if err := send(id, txState, tx); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			log.Info("TransactionsStateStream closing stream, client disconnected")
+			return nil
+		case ev := <-mempoolSub.Events():
+			tx := ev.(events.TxInMempool).Transaction
+			if _, ok := states[tx.ID()]; ok {
+				if err := send(tx.ID(), pb.TransactionState_TRANSACTION_STATE_MEMPOOL, tx); err != nil {
+					return err
+				}
+			}
+		case ev := <-meshSub.Events():
+			tx := ev.(events.TxInMesh).Transaction
+			if _, ok := states[tx.ID()]; ok {
+				if err := send(tx.ID(), pb.TransactionState_TRANSACTION_STATE_MESH, tx); err != nil {
+					return err
+				}
+			}
+		case ev := <-appliedSub.Events():
+			applied := ev.(events.TxApplied)
+			if _, ok := states[applied.ID]; !ok {
+				continue
+			}
+			// transactions that fail to apply are dropped from the mesh and will never be processed
+			txState := pb.TransactionState_TRANSACTION_STATE_PROCESSED
+			if applied.Result != events.TxResultApplied {
+				txState = pb.TransactionState_TRANSACTION_STATE_REJECTED
+			}
+			var tx *types.Transaction
+			if in.IncludeTransactions {
+				tx, _ = s.Tx.GetTransaction(applied.ID)
+			}
+			if err := send(applied.ID, txState, tx); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// txStateRank orders the states a transaction goes through, the rejection of an applied transaction is final
+func txStateRank(txState pb.TransactionState_TransactionState) int {
+	switch txState {
+	case pb.TransactionState_TRANSACTION_STATE_MEMPOOL:
+		return 1
+	case pb.TransactionState_TRANSACTION_STATE_MESH:
+		return 2
+	case pb.TransactionState_TRANSACTION_STATE_PROCESSED, pb.TransactionState_TRANSACTION_STATE_REJECTED:
+		return 3
+	default:
+		return 0
+	}
+}
+
+func convertTxValidation(res *txValidation) *extpb.SubmitTransactionWithOptionsResponse {
+	resp := &extpb.SubmitTransactionWithOptionsResponse{
+		Validity:         res.validity,
+		Message:          res.message,
+		ProjectedNonce:   res.nonce,
+		ProjectedBalance: res.balance,
+	}
+	if res.tx != nil {
+		resp.Id = res.tx.ID().Bytes()
+	}
+	return resp
+}
+
+func convertTxValidity(validity extpb.TransactionValidity) pb.TransactionState_TransactionState {
+	switch validity {
+	case extpb.TransactionValidity_TRANSACTION_VALIDITY_VALID:
+		return pb.TransactionState_TRANSACTION_STATE_MEMPOOL
+	case extpb.TransactionValidity_TRANSACTION_VALIDITY_BAD_NONCE:
+		return pb.TransactionState_TRANSACTION_STATE_CONFLICTING
+	case extpb.TransactionValidity_TRANSACTION_VALIDITY_INSUFFICIENT_BALANCE:
+		return pb.TransactionState_TRANSACTION_STATE_INSUFFICIENT_FUNDS
+	default:
+		return pb.TransactionState_TRANSACTION_STATE_REJECTED
+	}
+}
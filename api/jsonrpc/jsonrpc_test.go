@@ -0,0 +1,195 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	pb "github.com/spacemeshos/api/release/go/spacemesh/v1"
+	"github.com/spacemeshos/go-spacemesh/api/extpb"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	rpcstatus "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type globalStateMock struct {
+	pb.UnimplementedGlobalStateServiceServer
+}
+
+func (globalStateMock) Account(ctx context.Context, in *pb.AccountRequest) (*pb.AccountResponse, error) {
+	return &pb.AccountResponse{Account: &pb.Account{
+		Address: in.AccountId,
+		Counter: 3,
+		Balance: &pb.Amount{Value: 100},
+	}}, nil
+}
+
+type txMock struct {
+	pb.UnimplementedTransactionServiceServer
+}
+
+func (txMock) SubmitTransaction(ctx context.Context, in *pb.SubmitTransactionRequest) (*pb.SubmitTransactionResponse, error) {
+	if in.Transaction[0] == 0 {
+		return &pb.SubmitTransactionResponse{
+			Status:  &rpcstatus.Status{Code: int32(codes.FailedPrecondition), Message: "insufficient funds"},
+			Txstate: &pb.TransactionState{Id: &pb.TransactionId{Id: []byte{0xaa}}, State: pb.TransactionState_TRANSACTION_STATE_INSUFFICIENT_FUNDS},
+		}, nil
+	}
+	return &pb.SubmitTransactionResponse{
+		Status:  &rpcstatus.Status{Code: int32(codes.OK)},
+		Txstate: &pb.TransactionState{Id: &pb.TransactionId{Id: []byte{0xbb}}, State: pb.TransactionState_TRANSACTION_STATE_MEMPOOL},
+	}, nil
+}
+
+type meshMock struct {
+	extpb.UnimplementedMeshServiceServer
+}
+
+func (meshMock) BlockQuery(ctx context.Context, in *extpb.BlockQueryRequest) (*extpb.BlockQueryResponse, error) {
+	if in.Id[0] != 1 {
+		return nil, status.Errorf(codes.NotFound, "block not found")
+	}
+	return &extpb.BlockQueryResponse{Block: &extpb.Block{
+		Id:             in.Id,
+		Layer:          5,
+		TransactionIds: [][]byte{{0xcc}},
+	}}, nil
+}
+
+// launchServer starts a grpc server with the mocked services and a json-rpc server forwarding to it
+func launchServer(t *testing.T) (*Server, func()) {
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	grpcServer := grpc.NewServer()
+	pb.RegisterGlobalStateServiceServer(grpcServer, &globalStateMock{})
+	pb.RegisterTransactionServiceServer(grpcServer, &txMock{})
+	extpb.RegisterMeshServiceServer(grpcServer, &meshMock{})
+	go grpcServer.Serve(lis)
+
+	srv := NewServer(0, lis.Addr().(*net.TCPAddr).Port)
+	srv.Start()
+	return srv, func() {
+		require.NoError(t, srv.Close())
+		grpcServer.Stop()
+	}
+}
+
+func post(t *testing.T, srv *Server, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+	return rec
+}
+
+type testResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result"`
+	Error   *Error          `json:"error"`
+	ID      json.RawMessage `json:"id"`
+}
+
+func call(t *testing.T, srv *Server, body string) testResponse {
+	rec := post(t, srv, body)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var res testResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	require.Equal(t, "2.0", res.JSONRPC)
+	return res
+}
+
+func TestServer_Methods(t *testing.T) {
+	srv, shutDown := launchServer(t)
+	defer shutDown()
+
+	res := call(t, srv, `{"jsonrpc":"2.0","method":"getBalance","params":["0x0102"],"id":1}`)
+	require.Nil(t, res.Error)
+	require.JSONEq(t, `100`, string(res.Result))
+	require.JSONEq(t, `1`, string(res.ID))
+
+	res = call(t, srv, `{"jsonrpc":"2.0","method":"getAccount","params":{"address":"0102"},"id":"a"}`)
+	require.Nil(t, res.Error)
+	require.JSONEq(t, `{"address":"0x0102","counter":3,"balance":100}`, string(res.Result))
+	require.JSONEq(t, `"a"`, string(res.ID))
+
+	res = call(t, srv, `{"jsonrpc":"2.0","method":"sendRawTransaction","params":["0x01"],"id":2}`)
+	require.Nil(t, res.Error)
+	require.JSONEq(t, `"0xbb"`, string(res.Result))
+
+	res = call(t, srv, `{"jsonrpc":"2.0","method":"sendRawTransaction","params":["0x00"],"id":3}`)
+	require.NotNil(t, res.Error)
+	require.Equal(t, codeTxRejected, res.Error.Code)
+	require.Equal(t, "INSUFFICIENT_FUNDS", res.Error.Data)
+
+	res = call(t, srv, `{"jsonrpc":"2.0","method":"getBlock","params":["0x01"],"id":4}`)
+	require.Nil(t, res.Error)
+	require.JSONEq(t, `{"id":"0x01","layer":5,"timestamp":0,"transactions":["0xcc"]}`, string(res.Result))
+
+	// missing blocks are null results
+	res = call(t, srv, `{"jsonrpc":"2.0","method":"getBlock","params":["0x02"],"id":5}`)
+	require.Nil(t, res.Error)
+	require.Equal(t, `null`, string(res.Result))
+}
+
+func TestServer_Errors(t *testing.T) {
+	srv, shutDown := launchServer(t)
+	defer shutDown()
+
+	for _, tc := range []struct {
+		name, body string
+		code       int
+	}{
+		{"parse error", `{"jsonrpc":"2.0",`, codeParseError},
+		{"missing version", `{"method":"getBalance","params":["0x01"],"id":1}`, codeInvalidRequest},
+		{"empty batch", `[]`, codeInvalidRequest},
+		{"unknown method", `{"jsonrpc":"2.0","method":"eth_call","id":1}`, codeMethodNotFound},
+		{"disabled service", `{"jsonrpc":"2.0","method":"getCurrentLayer","id":1}`, codeMethodNotFound},
+		{"missing param", `{"jsonrpc":"2.0","method":"getBalance","params":[],"id":1}`, codeInvalidParams},
+		{"too many params", `{"jsonrpc":"2.0","method":"getBalance","params":["0x01","0x02"],"id":1}`, codeInvalidParams},
+		{"unknown param", `{"jsonrpc":"2.0","method":"getBalance","params":{"account":"0x01"},"id":1}`, codeInvalidParams},
+		{"invalid hex", `{"jsonrpc":"2.0","method":"getBalance","params":["0xzz"],"id":1}`, codeInvalidParams},
+		{"invalid bool", `{"jsonrpc":"2.0","method":"getBlock","params":["0x01","yes"],"id":1}`, codeInvalidParams},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			res := call(t, srv, tc.body)
+			require.NotNil(t, res.Error)
+			require.Equal(t, tc.code, res.Error.Code)
+			require.Nil(t, res.Result)
+		})
+	}
+}
+
+func TestServer_Batch(t *testing.T) {
+	srv, shutDown := launchServer(t)
+	defer shutDown()
+
+	// notifications aren't answered
+	rec := post(t, srv, `[
+		{"jsonrpc":"2.0","method":"getBalance","params":["0x01"],"id":1},
+		{"jsonrpc":"2.0","method":"getBalance","params":["0x01"]},
+		{"jsonrpc":"2.0","method":"unknown","id":2},
+		1
+	]`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var res []testResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	require.Len(t, res, 3)
+	require.JSONEq(t, `100`, string(res[0].Result))
+	require.Equal(t, codeMethodNotFound, res[1].Error.Code)
+	require.Equal(t, codeInvalidRequest, res[2].Error.Code)
+	require.Equal(t, `null`, string(res[2].ID))
+
+	rec = post(t, srv, `{"jsonrpc":"2.0","method":"getBalance","params":["0x01"]}`)
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	require.Empty(t, rec.Body.Bytes())
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
@@ -0,0 +1,418 @@
+package jsonrpc
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	pb "github.com/spacemeshos/api/release/go/spacemesh/v1"
+	"github.com/spacemeshos/go-spacemesh/api/extpb"
+	"github.com/spacemeshos/go-spacemesh/common/util"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+)
+
+// codeTxRejected is the server error returned by sendRawTransaction when the node rejects the transaction
+const codeTxRejected = -32001
+
+// method maps a JSON-RPC method onto the grpc api. Parameters can be passed by position or by name.
+type method struct {
+	params   []string // the names of the parameters, in order
+	required int      // the number of leading parameters that must be provided
+	call     func(ctx context.Context, c *clients, p params) (interface{}, error)
+}
+
+// methods are the methods served by the JSON-RPC server. Ids, hashes, addresses and transactions are 0x prefixed hex
+// strings, amounts and layer numbers are plain JSON numbers.
+var methods = map[string]method{
+	"getStatus":             {call: getStatus},
+	"getCurrentLayer":       {call: getCurrentLayer},
+	"getBalance":            {params: []string{"address"}, required: 1, call: getBalance},
+	"getAccount":            {params: []string{"address"}, required: 1, call: getAccount},
+	"getTransaction":        {params: []string{"id"}, required: 1, call: getTransaction},
+	"getTransactionReceipt": {params: []string{"id"}, required: 1, call: getTransactionReceipt},
+	"sendRawTransaction":    {params: []string{"transaction"}, required: 1, call: sendRawTransaction},
+	"estimateFee":           {call: estimateFee},
+	"getLayerByNumber":      {params: []string{"number", "includeTransactions"}, required: 1, call: getLayerByNumber},
+	"getBlock":              {params: []string{"id", "includeTransactions"}, required: 1, call: getBlock},
+}
+
+// params are the parameters of a request in the order of the method's parameter names, missing ones are nil
+type params []json.RawMessage
+
+func (m method) parseParams(raw json.RawMessage) (params, error) {
+	p := make(params, len(m.params))
+	switch trimmed := strings.TrimSpace(string(raw)); {
+	case trimmed == "" || trimmed == "null":
+	case trimmed[0] == '[':
+		var list []json.RawMessage
+		if err := json.Unmarshal(raw, &list); err != nil {
+			return nil, fmt.Errorf("invalid params")
+		}
+		if len(list) > len(m.params) {
+			return nil, fmt.Errorf("too many params, expected at most %d", len(m.params))
+		}
+		copy(p, list)
+	case trimmed[0] == '{':
+		var named map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &named); err != nil {
+			return nil, fmt.Errorf("invalid params")
+		}
+		for i, name := range m.params {
+			p[i] = named[name]
+			delete(named, name)
+		}
+		for name := range named {
+			return nil, fmt.Errorf("unknown param %v", name)
+		}
+	default:
+		return nil, fmt.Errorf("params must be an array or an object")
+	}
+	for i := 0; i < m.required; i++ {
+		if p.missing(i) {
+			return nil, fmt.Errorf("missing param %v", m.params[i])
+		}
+	}
+	return p, nil
+}
+
+func (p params) missing(i int) bool {
+	return p[i] == nil || string(p[i]) == "null"
+}
+
+func invalidParam(format string, args ...interface{}) *Error {
+	return &Error{Code: codeInvalidParams, Message: fmt.Sprintf(format, args...)}
+}
+
+// bytes decodes a hex param, the 0x prefix is optional
+func (p params) bytes(i int, name string) ([]byte, error) {
+	var s string
+	if err := json.Unmarshal(p[i], &s); err != nil {
+		return nil, invalidParam("%v must be a hex string", name)
+	}
+	b, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	if err != nil || len(b) == 0 {
+		return nil, invalidParam("%v must be a hex string", name)
+	}
+	return b, nil
+}
+
+func (p params) uint64(i int, name string) (uint64, error) {
+	var v uint64
+	if err := json.Unmarshal(p[i], &v); err != nil {
+		return 0, invalidParam("%v must be a non negative integer", name)
+	}
+	return v, nil
+}
+
+// bool decodes an optional boolean param, which is false when it's missing
+func (p params) bool(i int, name string) (bool, error) {
+	var v bool
+	if p.missing(i) {
+		return false, nil
+	}
+	if err := json.Unmarshal(p[i], &v); err != nil {
+		return false, invalidParam("%v must be a boolean", name)
+	}
+	return v, nil
+}
+
+type statusResult struct {
+	ConnectedPeers uint64 `json:"connectedPeers"`
+	IsSynced       bool   `json:"isSynced"`
+	SyncedLayer    uint64 `json:"syncedLayer"`
+	TopLayer       uint64 `json:"topLayer"`
+	VerifiedLayer  uint64 `json:"verifiedLayer"`
+	FinalizedLayer uint64 `json:"finalizedLayer"`
+}
+
+func getStatus(ctx context.Context, c *clients, _ params) (interface{}, error) {
+	res, err := c.node.Status(ctx, &pb.StatusRequest{})
+	if err != nil {
+		return nil, err
+	}
+	finality, err := c.extNode.Finality(ctx, &extpb.FinalityRequest{})
+	if err != nil {
+		return nil, err
+	}
+	return &statusResult{
+		ConnectedPeers: res.Status.GetConnectedPeers(),
+		IsSynced:       res.Status.GetIsSynced(),
+		SyncedLayer:    res.Status.GetSyncedLayer(),
+		TopLayer:       res.Status.GetTopLayer(),
+		VerifiedLayer:  res.Status.GetVerifiedLayer(),
+		FinalizedLayer: finality.GetFinalizedLayer(),
+	}, nil
+}
+
+func getCurrentLayer(ctx context.Context, c *clients, _ params) (interface{}, error) {
+	res, err := c.mesh.CurrentLayer(ctx, &pb.CurrentLayerRequest{})
+	if err != nil {
+		return nil, err
+	}
+	return res.Layernum.GetValue(), nil
+}
+
+type accountResult struct {
+	Address string `json:"address"`
+	Counter uint64 `json:"counter"`
+	Balance uint64 `json:"balance"`
+}
+
+func readAccount(ctx context.Context, c *clients, p params) (*pb.Account, error) {
+	address, err := p.bytes(0, "address")
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.globalState.Account(ctx, &pb.AccountRequest{AccountId: &pb.AccountId{Address: address}})
+	if err != nil {
+		return nil, err
+	}
+	return res.Account, nil
+}
+
+func getBalance(ctx context.Context, c *clients, p params) (interface{}, error) {
+	account, err := readAccount(ctx, c, p)
+	if err != nil {
+		return nil, err
+	}
+	return account.Balance.GetValue(), nil
+}
+
+func getAccount(ctx context.Context, c *clients, p params) (interface{}, error) {
+	account, err := readAccount(ctx, c, p)
+	if err != nil {
+		return nil, err
+	}
+	return &accountResult{
+		Address: util.Encode(account.Address.GetAddress()),
+		Counter: account.Counter,
+		Balance: account.Balance.GetValue(),
+	}, nil
+}
+
+type transactionResult struct {
+	ID        string `json:"id"`
+	State     string `json:"state"`
+	Sender    string `json:"sender,omitempty"`
+	Recipient string `json:"recipient,omitempty"`
+	Amount    uint64 `json:"amount"`
+	Counter   uint64 `json:"counter"`
+	GasLimit  uint64 `json:"gasLimit"`
+	Fee       uint64 `json:"fee"`
+}
+
+func transactionState(state pb.TransactionState_TransactionState) string {
+	return strings.TrimPrefix(state.String(), "TRANSACTION_STATE_")
+}
+
+func getTransaction(ctx context.Context, c *clients, p params) (interface{}, error) {
+	id, err := p.bytes(0, "id")
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.tx.TransactionsState(ctx, &pb.TransactionsStateRequest{
+		TransactionId:       []*pb.TransactionId{{Id: id}},
+		IncludeTransactions: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// transactions the node doesn't know are returned without a body
+	if len(res.Transactions) == 0 {
+		return nil, nil
+	}
+	tx := res.Transactions[0]
+	result := &transactionResult{
+		ID:       util.Encode(tx.Id.GetId()),
+		State:    transactionState(res.TransactionsState[0].State),
+		Sender:   util.Encode(tx.Sender.GetAddress()),
+		Amount:   tx.Amount.GetValue(),
+		Counter:  tx.Counter,
+		GasLimit: tx.GasOffered.GetGasProvided(),
+		Fee:      tx.GasOffered.GetGasPrice(),
+	}
+	if transfer := tx.GetCoinTransfer(); transfer != nil {
+		result.Recipient = util.Encode(transfer.Receiver.GetAddress())
+	}
+	return result, nil
+}
+
+type receiptResult struct {
+	ID      string `json:"id"`
+	Layer   uint64 `json:"layer"`
+	Result  string `json:"result"`
+	GasUsed uint64 `json:"gasUsed"`
+	Fee     uint64 `json:"fee"`
+	Error   string `json:"error,omitempty"`
+}
+
+func getTransactionReceipt(ctx context.Context, c *clients, p params) (interface{}, error) {
+	id, err := p.bytes(0, "id")
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.extTx.TransactionReceipt(ctx, &extpb.TransactionReceiptRequest{Id: id})
+	if err != nil {
+		return nil, err
+	}
+	return &receiptResult{
+		ID:      util.Encode(res.Receipt.Id),
+		Layer:   res.Receipt.Layer,
+		Result:  strings.TrimPrefix(res.Receipt.Result.String(), "TRANSACTION_RESULT_"),
+		GasUsed: res.Receipt.GasUsed,
+		Fee:     res.Receipt.Fee,
+		Error:   res.Receipt.Error,
+	}, nil
+}
+
+// sendRawTransaction submits a signed transaction and returns its id. Transactions the node rejects are reported
+// as errors that carry the state of the transaction.
+func sendRawTransaction(ctx context.Context, c *clients, p params) (interface{}, error) {
+	raw, err := p.bytes(0, "transaction")
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.tx.SubmitTransaction(ctx, &pb.SubmitTransactionRequest{Transaction: raw})
+	if err != nil {
+		return nil, err
+	}
+	if codes.Code(res.Status.GetCode()) != codes.OK {
+		return nil, &Error{
+			Code:    codeTxRejected,
+			Message: fmt.Sprintf("transaction rejected: %v", res.Status.GetMessage()),
+			Data:    transactionState(res.Txstate.GetState()),
+		}
+	}
+	return util.Encode(res.Txstate.GetId().GetId()), nil
+}
+
+type feeResult struct {
+	Low         uint64 `json:"low"`
+	Medium      uint64 `json:"medium"`
+	High        uint64 `json:"high"`
+	Min         uint64 `json:"min"`
+	MempoolSize uint64 `json:"mempoolSize"`
+	Congested   bool   `json:"congested"`
+}
+
+func estimateFee(ctx context.Context, c *clients, _ params) (interface{}, error) {
+	res, err := c.extTx.EstimateFee(ctx, &extpb.EstimateFeeRequest{})
+	if err != nil {
+		return nil, err
+	}
+	return &feeResult{
+		Low:         res.LowFee,
+		Medium:      res.MediumFee,
+		High:        res.HighFee,
+		Min:         res.MinFee,
+		MempoolSize: res.MempoolSize,
+		Congested:   res.Congested,
+	}, nil
+}
+
+type blockResult struct {
+	ID           string               `json:"id"`
+	Layer        uint64               `json:"layer"`
+	Smesher      string               `json:"smesher,omitempty"`
+	Activation   string               `json:"activation,omitempty"`
+	Timestamp    int64                `json:"timestamp"`
+	Transactions []string             `json:"transactions"`
+	Bodies       []*transactionResult `json:"transactionBodies,omitempty"`
+}
+
+type layerResult struct {
+	Number uint64         `json:"number"`
+	Status string         `json:"status"`
+	Hash   string         `json:"hash,omitempty"`
+	Blocks []*blockResult `json:"blocks"`
+}
+
+func layerStatus(status extpb.Layer_LayerStatus) string {
+	return strings.TrimPrefix(status.String(), "LAYER_STATUS_")
+}
+
+func convertBlock(b *extpb.Block) *blockResult {
+	res := &blockResult{
+		ID:           util.Encode(b.Id),
+		Layer:        b.Layer,
+		Timestamp:    b.Timestamp,
+		Transactions: make([]string, 0, len(b.TransactionIds)),
+	}
+	if len(b.AtxId) > 0 {
+		res.Activation = util.Encode(b.AtxId)
+	}
+	if len(b.SmesherId) > 0 {
+		res.Smesher = util.Encode(b.SmesherId)
+	}
+	for _, id := range b.TransactionIds {
+		res.Transactions = append(res.Transactions, util.Encode(id))
+	}
+	for _, tx := range b.Transactions {
+		res.Bodies = append(res.Bodies, &transactionResult{
+			ID:        util.Encode(tx.Id),
+			State:     transactionState(pb.TransactionState_TRANSACTION_STATE_MESH),
+			Sender:    util.Encode(tx.Sender),
+			Recipient: util.Encode(tx.Recipient),
+			Amount:    tx.Amount,
+			Counter:   tx.Counter,
+			GasLimit:  tx.GasLimit,
+			Fee:       tx.Fee,
+		})
+	}
+	return res
+}
+
+func getLayerByNumber(ctx context.Context, c *clients, p params) (interface{}, error) {
+	number, err := p.uint64(0, "number")
+	if err != nil {
+		return nil, err
+	}
+	includeTxs, err := p.bool(1, "includeTransactions")
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.extMesh.PagedLayersQuery(ctx, &extpb.PagedLayersQueryRequest{
+		StartLayer:          number,
+		EndLayer:            number,
+		IncludeTransactions: includeTxs,
+		PageSize:            1,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// layers past the latest known one aren't returned
+	if len(res.Layers) == 0 {
+		return nil, nil
+	}
+	layer := res.Layers[0]
+	result := &layerResult{
+		Number: layer.Number,
+		Status: layerStatus(layer.Status),
+		Blocks: make([]*blockResult, 0, len(layer.Blocks)),
+	}
+	if len(layer.Hash) > 0 {
+		result.Hash = util.Encode(layer.Hash)
+	}
+	for _, b := range layer.Blocks {
+		result.Blocks = append(result.Blocks, convertBlock(b))
+	}
+	return result, nil
+}
+
+func getBlock(ctx context.Context, c *clients, p params) (interface{}, error) {
+	id, err := p.bytes(0, "id")
+	if err != nil {
+		return nil, err
+	}
+	includeTxs, err := p.bool(1, "includeTransactions")
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.extMesh.BlockQuery(ctx, &extpb.BlockQueryRequest{Id: id, IncludeTransactions: includeTxs})
+	if err != nil {
+		return nil, err
+	}
+	return convertBlock(res.Block), nil
+}
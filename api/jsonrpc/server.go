@@ -0,0 +1,249 @@
+// Package jsonrpc provides a JSON-RPC 2.0 server that maps a curated set of methods onto the grpc api of the node, for
+// integrations that are built around JSON-RPC rather than grpc or the json gateway
+package jsonrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	pb "github.com/spacemeshos/api/release/go/spacemesh/v1"
+	"github.com/spacemeshos/go-spacemesh/api/extpb"
+	"github.com/spacemeshos/go-spacemesh/log"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxRequestSize limits the size of the body of a request, batches included
+const maxRequestSize = 1 << 20
+
+// error codes defined by the JSON-RPC 2.0 specification, and the server error the failures of the grpc api are
+// reported with
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeInternalError  = -32603
+	codeServerError    = -32000
+)
+
+// Server is an http server that serves JSON-RPC requests by forwarding them to the grpc server of the node. Like the
+// json gateway, a method is only available when the grpc service it's mapped onto is enabled.
+type Server struct {
+	Port     int
+	GrpcPort int
+	server   *http.Server
+	conn     *grpc.ClientConn
+	clients  *clients
+}
+
+// Error is a JSON-RPC error object
+type Error struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%v (%d)", e.Message, e.Code)
+}
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+	ID      json.RawMessage `json:"id"` // nil for notifications, which aren't answered
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+var null = json.RawMessage("null")
+
+// NewServer returns a new JSON-RPC server listening on port, that forwards requests to the grpc server on grpcPort
+func NewServer(port int, grpcPort int) *Server {
+	return &Server{Port: port, GrpcPort: grpcPort}
+}
+
+// Start connects to the grpc server and starts serving requests in the background
+func (s *Server) Start() {
+	endpoint := fmt.Sprintf("localhost:%d", s.GrpcPort)
+	conn, err := grpc.Dial(endpoint, grpc.WithInsecure())
+	if err != nil {
+		log.Error("not starting json-rpc server; failed to connect to grpc service at %s: %v", endpoint, err)
+		return
+	}
+	s.conn = conn
+	s.clients = newClients(conn)
+
+	mux := http.NewServeMux()
+	mux.Handle("/", s)
+	s.server = &http.Server{
+		Addr:    fmt.Sprintf(":%d", s.Port),
+		Handler: mux,
+	}
+	log.Info("starting json-rpc server on port %d connected to grpc service at %s", s.Port, endpoint)
+	go func() {
+		if err := s.server.ListenAndServe(); err != http.ErrServerClosed {
+			log.Error("error from json-rpc listener: %v", err)
+		}
+	}()
+}
+
+// Close stops the server and closes its connection to the grpc server
+func (s *Server) Close() error {
+	log.Debug("Stopping json-rpc server...")
+	if s.server != nil {
+		if err := s.server.Shutdown(context.TODO()); err != nil {
+			return err
+		}
+	}
+	if s.conn != nil {
+		return s.conn.Close()
+	}
+	return nil
+}
+
+// ServeHTTP serves a single JSON-RPC request or a batch of requests sent as the body of a POST request
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	if err != nil {
+		http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	ctx := r.Context()
+	var res interface{}
+	body = bytes.TrimSpace(body)
+	switch {
+	case !json.Valid(body):
+		res = errorResponse(null, &Error{Code: codeParseError, Message: "parse error"})
+	case body[0] == '[':
+		var batch []json.RawMessage
+		if err := json.Unmarshal(body, &batch); err != nil || len(batch) == 0 {
+			res = errorResponse(null, &Error{Code: codeInvalidRequest, Message: "invalid request"})
+			break
+		}
+		responses := make([]*response, 0, len(batch))
+		for _, raw := range batch {
+			if resp := s.handle(ctx, raw); resp != nil {
+				responses = append(responses, resp)
+			}
+		}
+		if len(responses) > 0 {
+			res = responses
+		}
+	default:
+		if resp := s.handle(ctx, body); resp != nil {
+			res = resp
+		}
+	}
+
+	// a request that only holds notifications isn't answered
+	if res == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		log.Error("error writing json-rpc response: %v", err)
+	}
+}
+
+// handle calls the method of a single request and returns its response, or nil if the request is a notification
+func (s *Server) handle(ctx context.Context, raw json.RawMessage) *response {
+	var req request
+	if err := json.Unmarshal(raw, &req); err != nil || req.JSONRPC != "2.0" || req.Method == "" {
+		id := req.ID
+		if id == nil {
+			id = null
+		}
+		return errorResponse(id, &Error{Code: codeInvalidRequest, Message: "invalid request"})
+	}
+
+	result, rpcErr := s.call(ctx, req)
+	if req.ID == nil {
+		return nil
+	}
+	if rpcErr != nil {
+		return errorResponse(req.ID, rpcErr)
+	}
+	return &response{JSONRPC: "2.0", Result: result, ID: req.ID}
+}
+
+func (s *Server) call(ctx context.Context, req request) (json.RawMessage, *Error) {
+	m, ok := methods[req.Method]
+	if !ok {
+		return nil, &Error{Code: codeMethodNotFound, Message: fmt.Sprintf("method %v not found", req.Method)}
+	}
+	p, err := m.parseParams(req.Params)
+	if err != nil {
+		return nil, &Error{Code: codeInvalidParams, Message: err.Error()}
+	}
+	log.Info("JSON-RPC %v", req.Method)
+
+	result, err := m.call(ctx, s.clients, p)
+	if err != nil {
+		if rpcErr, ok := err.(*Error); ok {
+			return nil, rpcErr
+		}
+		st := status.Convert(err)
+		switch st.Code() {
+		case codes.NotFound:
+			// missing layers, blocks and receipts are returned as null results
+			return null, nil
+		case codes.InvalidArgument:
+			return nil, &Error{Code: codeInvalidParams, Message: st.Message()}
+		case codes.Unimplemented:
+			return nil, &Error{Code: codeMethodNotFound, Message: fmt.Sprintf("method %v is not available, the service it uses isn't enabled", req.Method)}
+		}
+		return nil, &Error{Code: codeServerError, Message: st.Message()}
+	}
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		log.Error("error encoding json-rpc result of %v: %v", req.Method, err)
+		return nil, &Error{Code: codeInternalError, Message: "internal error"}
+	}
+	return encoded, nil
+}
+
+func errorResponse(id json.RawMessage, err *Error) *response {
+	return &response{JSONRPC: "2.0", Error: err, ID: id}
+}
+
+// clients are the grpc clients of the services the methods are mapped onto
+type clients struct {
+	node        pb.NodeServiceClient
+	extNode     extpb.NodeServiceClient
+	mesh        pb.MeshServiceClient
+	extMesh     extpb.MeshServiceClient
+	globalState pb.GlobalStateServiceClient
+	tx          pb.TransactionServiceClient
+	extTx       extpb.TransactionServiceClient
+}
+
+func newClients(conn *grpc.ClientConn) *clients {
+	return &clients{
+		node:        pb.NewNodeServiceClient(conn),
+		extNode:     extpb.NewNodeServiceClient(conn),
+		mesh:        pb.NewMeshServiceClient(conn),
+		extMesh:     extpb.NewMeshServiceClient(conn),
+		globalState: pb.NewGlobalStateServiceClient(conn),
+		tx:          pb.NewTransactionServiceClient(conn),
+		extTx:       extpb.NewTransactionServiceClient(conn),
+	}
+}
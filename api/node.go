@@ -2,9 +2,19 @@ package api
 
 import (
 	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/database"
+	"github.com/spacemeshos/go-spacemesh/dbcheck"
+	"github.com/spacemeshos/go-spacemesh/hare/eligibility"
+	"github.com/spacemeshos/go-spacemesh/mesh"
+	"github.com/spacemeshos/go-spacemesh/miner"
+	"github.com/spacemeshos/go-spacemesh/p2p"
 	"github.com/spacemeshos/go-spacemesh/p2p/p2pcrypto"
 	"github.com/spacemeshos/go-spacemesh/p2p/service"
 	"github.com/spacemeshos/go-spacemesh/priorityq"
+	"github.com/spacemeshos/go-spacemesh/state"
+	"github.com/spacemeshos/go-spacemesh/sync"
+	"github.com/spacemeshos/go-spacemesh/tortoise"
+	"net"
 	"time"
 )
 
@@ -20,12 +30,47 @@ type StateAPI interface {
 	Exist(address types.Address) bool
 }
 
+// GlobalStateAPI is an API to the global state and its history
+type GlobalStateAPI interface {
+	StateAPI
+	GetLayerStateRoot(layer types.LayerID) (types.Hash32, error)
+	GetProof(root types.Hash32, addr types.Address) (*types.AccountProof, error)
+	GetAccountAt(root types.Hash32, addr types.Address) (exists bool, nonce, balance uint64, err error)
+	IterateAccounts(root types.Hash32, fn func(addr types.Address, nonce, balance uint64) error) error
+}
+
+// StateProjector is an API to get the nonce and balance of an account after applying its pending transactions on top
+// of the global state
+type StateProjector interface {
+	GetProjection(addr types.Address) (nonce, balance uint64, err error)
+}
+
+// MempoolAPI is an API to get transactions that were received but aren't in the mesh yet
+type MempoolAPI interface {
+	Get(id types.TransactionID) (*types.Transaction, error)
+	Len() int
+}
+
 // NetworkAPI is an API to nodes gossip network
 type NetworkAPI interface {
 	Broadcast(channel string, data []byte) error
 	SubscribePeerEvents() (conn, disc chan p2pcrypto.PublicKey)
 }
 
+// NetworkInfoAPI is an API to the connectivity of the node: its addresses, peers and routing table
+type NetworkInfoAPI interface {
+	NetworkInfo() p2p.NetworkInfo
+}
+
+// PeerBanAPI is an API to the banlist of the p2p network, bans of peer IDs and IP addresses with an optional expiry
+type PeerBanAPI interface {
+	BanPeer(peer p2pcrypto.PublicKey, duration time.Duration) error
+	BanIP(ip net.IP, duration time.Duration) error
+	UnbanPeer(peer p2pcrypto.PublicKey) (bool, error)
+	UnbanIP(ip net.IP) (bool, error)
+	Bans() []p2p.Ban
+}
+
 // MiningAPI is an API for controlling Post, setting coinbase account and getting mining stats
 type MiningAPI interface {
 	StartPost(address types.Address, datadir string, space uint64) error
@@ -39,6 +84,39 @@ type OracleAPI interface {
 	GetEligibleLayers() []types.LayerID
 }
 
+// EligibilityAPI is an API to get the block and hare eligibilities of this smesher in a given epoch
+type EligibilityAPI interface {
+	EpochEligibility(epoch types.EpochID) (*miner.EpochEligibility, error)
+}
+
+// BeaconAPI is an API to the status of the hare beacon in a given epoch
+type BeaconAPI interface {
+	BeaconStatus(epoch types.EpochID) (*eligibility.BeaconStatus, error)
+}
+
+// MalfeasanceAPI is an API to get the proofs of smeshers that broke the protocol
+type MalfeasanceAPI interface {
+	GetMalfeasanceProof(nodeID types.NodeID) (*types.MalfeasanceProof, error)
+}
+
+// ActivationAPI is an API to the ATXs the node knows about
+type ActivationAPI interface {
+	GetEpochAtxs(epochID types.EpochID) []types.ATXID
+	GetFullAtx(id types.ATXID) (*types.ActivationTx, error)
+}
+
+// NodeAtxAPI is an API to the ATXs published by specific smeshers
+type NodeAtxAPI interface {
+	GetNodeLastAtxID(nodeID types.NodeID) (types.ATXID, error)
+	GetNodeAtxIDForEpoch(nodeID types.NodeID, targetEpoch types.EpochID) (types.ATXID, error)
+	GetFullAtx(id types.ATXID) (*types.ActivationTx, error)
+}
+
+// ReceiptAPI is an API to the receipts of the transactions that were applied to the global state
+type ReceiptAPI interface {
+	Get(id types.TransactionID) (*state.Receipt, error)
+}
+
 // GenesisTimeAPI is an API to get genesis time and current layer of the system
 type GenesisTimeAPI interface {
 	GetGenesisTime() time.Time
@@ -61,6 +139,49 @@ type Syncer interface {
 	Start()
 }
 
+// SyncProgressAPI reports the progress of the sync of the node
+type SyncProgressAPI interface {
+	SyncProgress() sync.Progress
+}
+
+// SyncFrontierAPI reports the persisted progress of the sync of the node
+type SyncFrontierAPI interface {
+	SyncFrontier() mesh.SyncFrontier
+}
+
+// FinalityAPI reports the layers of the mesh that are final
+type FinalityAPI interface {
+	FinalizedLayer() types.LayerID
+}
+
+// SyncLimitsAPI controls the bandwidth and concurrency limits of the sync of the node
+type SyncLimitsAPI interface {
+	SetSyncLimits(limits sync.Limits)
+	SyncLimits() sync.Limits
+}
+
+// FetchAPI fetches mesh objects from peers on demand
+type FetchAPI interface {
+	Fetch(kind sync.ObjectKind, id types.Hash32) (*sync.FetchResult, error)
+}
+
+// AuditAPI audits the data of the recent layers of the mesh and fetches the missing data again
+type AuditAPI interface {
+	Audit() *sync.AuditReport
+	LastAudit() *sync.AuditReport
+}
+
+// DatabaseAPI maintains the databases of the node while it runs
+type DatabaseAPI interface {
+	Compact() ([]database.CompactionResult, error)
+	Snapshot() (*database.Snapshot, error)
+}
+
+// DatabaseCheckAPI checks the references between the databases of the node
+type DatabaseCheckAPI interface {
+	Check(repair bool) *dbcheck.Report
+}
+
 // TxAPI is an api for getting transaction status
 type TxAPI interface {
 	AddressExists(addr types.Address) bool
@@ -68,12 +189,38 @@ type TxAPI interface {
 	GetRewards(account types.Address) (rewards []types.Reward, err error)
 	GetTransactionsByDestination(l types.LayerID, account types.Address) (txs []types.TransactionID)
 	GetTransactionsByOrigin(l types.LayerID, account types.Address) (txs []types.TransactionID)
+	GetAccountTransactions(account types.Address, direction mesh.TxDirection, minLayer, maxLayer types.LayerID,
+		cursor []byte, limit int) (txs []mesh.AccountTx, next []byte, err error)
+	GetCoinbaseRewards(coinbase types.Address, minLayer, maxLayer types.LayerID) ([]mesh.CoinbaseReward, error)
 	LatestLayer() types.LayerID
 	GetLayerApplied(txID types.TransactionID) *types.LayerID
 	GetTransaction(id types.TransactionID) (*types.Transaction, error)
 	GetProjection(addr types.Address, prevNonce, prevBalance uint64) (nonce, balance uint64, err error)
 	LatestLayerInState() types.LayerID
 	GetStateRoot() types.Hash32
+	ProcessedLayer() types.LayerID
+	GetLayer(i types.LayerID) (*types.Layer, error)
+	GetBlock(id types.BlockID) (*types.Block, error)
+	GetATXs(atxIds []types.ATXID) (map[types.ATXID]*types.ActivationTx, []types.ATXID)
+	GetTransactions(transactions []types.TransactionID) ([]*types.Transaction, map[types.TransactionID]struct{})
+}
+
+// CertificateAPI is an API to the certificates of the hare outputs of layers
+type CertificateAPI interface {
+	GetCertificate(layer types.LayerID) ([]byte, error)
+}
+
+// LayerInternalsAPI is an API to the consensus data the mesh keeps for a layer
+type LayerInternalsAPI interface {
+	GetLayer(i types.LayerID) (*types.Layer, error)
+	GetLayerInputVector(layer types.LayerID) ([]types.BlockID, error)
+	ContextualValidity(id types.BlockID) (bool, error)
+}
+
+// TortoiseAPI is an API to the opinion of the vote counting algorithm
+type TortoiseAPI interface {
+	LatestComplete() types.LayerID
+	LayerOpinion(layer types.LayerID) map[types.BlockID]tortoise.BlockOpinion
 }
 
 // PeerCounter is an api to get amount of connected peers
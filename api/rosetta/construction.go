@@ -0,0 +1,285 @@
+package rosetta
+
+import (
+	"encoding/hex"
+
+	pb "github.com/spacemeshos/api/release/go/spacemesh/v1"
+	"github.com/spacemeshos/go-spacemesh/api/extpb"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/common/util"
+	"github.com/spacemeshos/go-spacemesh/signing"
+	"golang.org/x/net/context"
+)
+
+// Construction API
+//
+// A transaction is constructed from the two TRANSFER operations of a coin transfer, the nonce and the fee come from the
+// metadata. The unsigned transaction is the address of the sender followed by the encoded inner transaction, which is
+// the payload the sender signs. The signed transaction is the encoded transaction, as submitted to the node.
+
+const (
+	curveType     = "edwards25519"
+	signatureType = "ed25519"
+)
+
+// defaultGasLimit is the gas limit of constructed transactions, coin transfers don't use more
+const defaultGasLimit = 10
+
+func constructionDerive(ctx context.Context, s *Server, body []byte) (interface{}, error) {
+	var req deriveRequest
+	if err := s.decode(ctx, body, &req, func() *NetworkIdentifier { return req.NetworkIdentifier }); err != nil {
+		return nil, err
+	}
+	if req.PublicKey == nil || req.PublicKey.CurveType != curveType {
+		return nil, errInvalidRequest.withReason("public keys must be %v keys", curveType)
+	}
+	pub, err := decodeHex(req.PublicKey.HexBytes)
+	if err != nil || len(pub) != 32 {
+		return nil, errInvalidRequest.withReason("invalid public key %q", req.PublicKey.HexBytes)
+	}
+	return &deriveResponse{AccountIdentifier: &AccountIdentifier{Address: util.Encode(types.BytesToAddress(pub).Bytes())}}, nil
+}
+
+func constructionPreprocess(ctx context.Context, s *Server, body []byte) (interface{}, error) {
+	var req preprocessRequest
+	if err := s.decode(ctx, body, &req, func() *NetworkIdentifier { return req.NetworkIdentifier }); err != nil {
+		return nil, err
+	}
+	sender, _, _, err := parseOperations(req.Operations)
+	if err != nil {
+		return nil, err
+	}
+	account := &AccountIdentifier{Address: util.Encode(sender.Bytes())}
+	return &preprocessResponse{
+		Options:            map[string]interface{}{"sender": account.Address},
+		RequiredPublicKeys: []*AccountIdentifier{account},
+	}, nil
+}
+
+// constructionMetadataEndpoint returns the nonce of the sender and the fee estimated by the node. The nonce is the
+// one of the global state, it doesn't count the transactions of the sender that are still pending.
+func constructionMetadataEndpoint(ctx context.Context, s *Server, body []byte) (interface{}, error) {
+	var req metadataRequest
+	if err := s.decode(ctx, body, &req, func() *NetworkIdentifier { return req.NetworkIdentifier }); err != nil {
+		return nil, err
+	}
+	sender, err := accountAddress(&AccountIdentifier{Address: req.Options.Sender})
+	if err != nil {
+		return nil, err
+	}
+	account, err := s.clients.globalState.Account(ctx, &pb.AccountRequest{AccountId: &pb.AccountId{Address: sender.Bytes()}})
+	if err != nil {
+		return nil, err
+	}
+	fee, err := s.clients.extTx.EstimateFee(ctx, &extpb.EstimateFeeRequest{})
+	if err != nil {
+		return nil, err
+	}
+	return &metadataResponse{
+		Metadata: &constructionMetadata{
+			Nonce:    account.Account.GetCounter(),
+			Fee:      fee.MediumFee,
+			GasLimit: defaultGasLimit,
+		},
+		SuggestedFee: []*Amount{amount(fee.MediumFee, false)},
+	}, nil
+}
+
+func constructionPayloads(ctx context.Context, s *Server, body []byte) (interface{}, error) {
+	var req payloadsRequest
+	if err := s.decode(ctx, body, &req, func() *NetworkIdentifier { return req.NetworkIdentifier }); err != nil {
+		return nil, err
+	}
+	if req.Metadata == nil {
+		return nil, errInvalidRequest.withReason("metadata must be provided")
+	}
+	sender, recipient, value, err := parseOperations(req.Operations)
+	if err != nil {
+		return nil, err
+	}
+	inner := types.InnerTransaction{
+		AccountNonce: req.Metadata.Nonce,
+		Recipient:    recipient,
+		GasLimit:     req.Metadata.GasLimit,
+		Fee:          req.Metadata.Fee,
+		Amount:       value,
+	}
+	payload, err := types.InterfaceToBytes(&inner)
+	if err != nil {
+		return nil, err
+	}
+	return &payloadsResponse{
+		UnsignedTransaction: hex.EncodeToString(append(sender.Bytes(), payload...)),
+		Payloads: []*SigningPayload{{
+			AccountIdentifier: &AccountIdentifier{Address: util.Encode(sender.Bytes())},
+			HexBytes:          hex.EncodeToString(payload),
+			SignatureType:     signatureType,
+		}},
+	}, nil
+}
+
+func constructionCombine(ctx context.Context, s *Server, body []byte) (interface{}, error) {
+	var req combineRequest
+	if err := s.decode(ctx, body, &req, func() *NetworkIdentifier { return req.NetworkIdentifier }); err != nil {
+		return nil, err
+	}
+	sender, inner, err := decodeUnsigned(req.UnsignedTransaction)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.Signatures) != 1 {
+		return nil, errInvalidSignature.withReason("transactions must have a single signature")
+	}
+	sig := req.Signatures[0]
+	if sig.SignatureType != signatureType || sig.PublicKey == nil || sig.PublicKey.CurveType != curveType {
+		return nil, errInvalidSignature.withReason("signatures must be %v signatures of %v keys", signatureType, curveType)
+	}
+	pub, err := decodeHex(sig.PublicKey.HexBytes)
+	if err != nil || len(pub) != 32 {
+		return nil, errInvalidSignature.withReason("invalid public key %q", sig.PublicKey.HexBytes)
+	}
+	if types.BytesToAddress(pub) != sender {
+		return nil, errInvalidSignature.withReason("the public key isn't the key of the sender")
+	}
+	signature, err := decodeHex(sig.HexBytes)
+	if err != nil || len(signature) != len(types.Transaction{}.Signature) {
+		return nil, errInvalidSignature.withReason("invalid signature %q", sig.HexBytes)
+	}
+	payload, err := types.InterfaceToBytes(inner)
+	if err != nil {
+		return nil, err
+	}
+	if !signing.Verify(signing.NewPublicKey(pub), payload, signature) {
+		return nil, errInvalidSignature.withReason("the signature doesn't match the transaction")
+	}
+	tx := &types.Transaction{InnerTransaction: *inner}
+	copy(tx.Signature[:], signature)
+	signed, err := types.InterfaceToBytes(tx)
+	if err != nil {
+		return nil, err
+	}
+	return &combineResponse{SignedTransaction: hex.EncodeToString(signed)}, nil
+}
+
+func constructionParse(ctx context.Context, s *Server, body []byte) (interface{}, error) {
+	var req parseRequest
+	if err := s.decode(ctx, body, &req, func() *NetworkIdentifier { return req.NetworkIdentifier }); err != nil {
+		return nil, err
+	}
+	if !req.Signed {
+		sender, inner, err := decodeUnsigned(req.Transaction)
+		if err != nil {
+			return nil, err
+		}
+		return &parseResponse{
+			Operations:               transferOperations(sender.Bytes(), inner.Recipient.Bytes(), inner.Amount, 0, nil),
+			AccountIdentifierSigners: []*AccountIdentifier{},
+		}, nil
+	}
+	tx, err := decodeSigned(req.Transaction)
+	if err != nil {
+		return nil, err
+	}
+	sender := &AccountIdentifier{Address: util.Encode(tx.Origin().Bytes())}
+	return &parseResponse{
+		Operations:               transferOperations(tx.Origin().Bytes(), tx.Recipient.Bytes(), tx.Amount, 0, nil),
+		AccountIdentifierSigners: []*AccountIdentifier{sender},
+	}, nil
+}
+
+func constructionHash(ctx context.Context, s *Server, body []byte) (interface{}, error) {
+	var req signedTransactionRequest
+	if err := s.decode(ctx, body, &req, func() *NetworkIdentifier { return req.NetworkIdentifier }); err != nil {
+		return nil, err
+	}
+	tx, err := decodeSigned(req.SignedTransaction)
+	if err != nil {
+		return nil, err
+	}
+	return &transactionIdentifierResponse{TransactionIdentifier: &TransactionIdentifier{Hash: util.Encode(tx.ID().Bytes())}}, nil
+}
+
+func constructionSubmit(ctx context.Context, s *Server, body []byte) (interface{}, error) {
+	var req signedTransactionRequest
+	if err := s.decode(ctx, body, &req, func() *NetworkIdentifier { return req.NetworkIdentifier }); err != nil {
+		return nil, err
+	}
+	raw, err := decodeHex(req.SignedTransaction)
+	if err != nil {
+		return nil, errInvalidTransaction.withReason("%v", err)
+	}
+	res, err := s.clients.extTx.SubmitTransactionWithOptions(ctx, &extpb.SubmitTransactionWithOptionsRequest{Transaction: raw})
+	if err != nil {
+		return nil, err
+	}
+	switch res.Validity {
+	case extpb.TransactionValidity_TRANSACTION_VALIDITY_VALID:
+	case extpb.TransactionValidity_TRANSACTION_VALIDITY_MALFORMED, extpb.TransactionValidity_TRANSACTION_VALIDITY_INVALID_SIGNATURE:
+		return nil, errInvalidTransaction.withReason("%v", res.Message)
+	default:
+		rejected := errTxRejected.withReason("%v", res.Message)
+		rejected.Details["validity"] = res.Validity.String()
+		return nil, rejected
+	}
+	return &transactionIdentifierResponse{TransactionIdentifier: &TransactionIdentifier{Hash: util.Encode(res.Id)}}, nil
+}
+
+// parseOperations returns the sender, recipient and amount of the operations of a coin transfer
+func parseOperations(ops []*Operation) (sender, recipient types.Address, value uint64, err error) {
+	if len(ops) != 2 {
+		return sender, recipient, 0, errInvalidOperations.withReason("a transfer must have 2 %v operations", opTransfer)
+	}
+	var debited, credited bool
+	var debit, credit uint64
+	for _, op := range ops {
+		if op == nil || op.Type != opTransfer {
+			return sender, recipient, 0, errInvalidOperations.withReason("only %v operations are supported", opTransfer)
+		}
+		v, isDebit, err := parseAmount(op.Amount)
+		if err != nil {
+			return sender, recipient, 0, errInvalidOperations.withReason("%v", err)
+		}
+		addr, err := accountAddress(op.Account)
+		if err != nil {
+			return sender, recipient, 0, errInvalidOperations.withReason("operations must have the address of an account")
+		}
+		if isDebit {
+			sender, debit, debited = addr, v, true
+		} else {
+			recipient, credit, credited = addr, v, true
+		}
+	}
+	if !debited || !credited || debit != credit {
+		return sender, recipient, 0, errInvalidOperations.withReason("a transfer must debit the sender with the amount it credits the recipient")
+	}
+	return sender, recipient, credit, nil
+}
+
+// decodeUnsigned decodes an unsigned transaction into its sender and inner transaction
+func decodeUnsigned(s string) (types.Address, *types.InnerTransaction, error) {
+	b, err := decodeHex(s)
+	if err != nil || len(b) <= types.AddressLength {
+		return types.Address{}, nil, errInvalidTransaction.withReason("invalid unsigned transaction")
+	}
+	var inner types.InnerTransaction
+	if err := types.BytesToInterface(b[types.AddressLength:], &inner); err != nil {
+		return types.Address{}, nil, errInvalidTransaction.withReason("%v", err)
+	}
+	return types.BytesToAddress(b[:types.AddressLength]), &inner, nil
+}
+
+// decodeSigned decodes a signed transaction and recovers its sender from the signature
+func decodeSigned(s string) (*types.Transaction, error) {
+	b, err := decodeHex(s)
+	if err != nil {
+		return nil, errInvalidTransaction.withReason("%v", err)
+	}
+	tx, err := types.BytesToTransaction(b)
+	if err != nil {
+		return nil, errInvalidTransaction.withReason("%v", err)
+	}
+	if err := tx.CalcAndSetOrigin(); err != nil {
+		return nil, errInvalidSignature.withReason("%v", err)
+	}
+	return tx, nil
+}
@@ -0,0 +1,493 @@
+package rosetta
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	pb "github.com/spacemeshos/api/release/go/spacemesh/v1"
+	"github.com/spacemeshos/go-spacemesh/api/extpb"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/common/util"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// operation types
+const (
+	opTransfer = "TRANSFER"
+	opFee      = "FEE"
+	opReward   = "REWARD"
+)
+
+// operation statuses
+const (
+	statusSuccess = "SUCCESS"
+	statusFailure = "FAILURE"
+)
+
+// genesisIndex is the index of the first block
+const genesisIndex = 0
+
+// Data API
+
+func networkList(ctx context.Context, s *Server, body []byte) (interface{}, error) {
+	params, err := s.networkParams(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &networkListResponse{NetworkIdentifiers: []*NetworkIdentifier{{Blockchain: Blockchain, Network: params.network}}}, nil
+}
+
+func networkOptions(ctx context.Context, s *Server, body []byte) (interface{}, error) {
+	var req networkRequest
+	if err := s.decode(ctx, body, &req, func() *NetworkIdentifier { return req.NetworkIdentifier }); err != nil {
+		return nil, err
+	}
+	version, err := s.clients.node.Version(ctx, &empty.Empty{})
+	if err != nil {
+		return nil, err
+	}
+	return &networkOptionsResponse{
+		Version: &Version{RosettaVersion: rosettaVersion, NodeVersion: version.VersionString.GetValue()},
+		Allow: &Allow{
+			OperationStatuses: []*OperationStatus{
+				{Status: statusSuccess, Successful: true},
+				{Status: statusFailure, Successful: false},
+			},
+			OperationTypes:          []string{opTransfer, opFee, opReward},
+			Errors:                  allErrors,
+			HistoricalBalanceLookup: true,
+		},
+	}, nil
+}
+
+func networkStatus(ctx context.Context, s *Server, body []byte) (interface{}, error) {
+	var req networkRequest
+	if err := s.decode(ctx, body, &req, func() *NetworkIdentifier { return req.NetworkIdentifier }); err != nil {
+		return nil, err
+	}
+	res, err := s.clients.node.Status(ctx, &pb.StatusRequest{})
+	if err != nil {
+		return nil, err
+	}
+	params, err := s.networkParams(ctx)
+	if err != nil {
+		return nil, err
+	}
+	current, err := s.layer(ctx, res.Status.GetVerifiedLayer(), false)
+	if err != nil {
+		return nil, err
+	}
+	genesis, err := s.layer(ctx, genesisIndex, false)
+	if err != nil {
+		return nil, err
+	}
+	return &networkStatusResponse{
+		CurrentBlockIdentifier: blockIdentifier(current),
+		CurrentBlockTimestamp:  params.timestamp(current.Number),
+		GenesisBlockIdentifier: blockIdentifier(genesis),
+		SyncStatus: &SyncStatus{
+			CurrentIndex: int64(res.Status.GetVerifiedLayer()),
+			TargetIndex:  int64(res.Status.GetTopLayer()),
+			Synced:       res.Status.GetIsSynced(),
+		},
+		// the api only reports the number of peers
+		Peers: []*Peer{},
+	}, nil
+}
+
+func accountBalance(ctx context.Context, s *Server, body []byte) (interface{}, error) {
+	var req accountBalanceRequest
+	if err := s.decode(ctx, body, &req, func() *NetworkIdentifier { return req.NetworkIdentifier }); err != nil {
+		return nil, err
+	}
+	addr, err := accountAddress(req.AccountIdentifier)
+	if err != nil {
+		return nil, err
+	}
+	layer, err := s.blockLayer(ctx, req.BlockIdentifier)
+	if err != nil {
+		return nil, err
+	}
+	account, err := s.clients.extGlobalState.AccountAtLayer(ctx, &extpb.AccountAtLayerRequest{
+		AccountId: addr.Bytes(),
+		Layer:     layer.Number,
+	})
+	if status.Code(err) == codes.NotFound || status.Code(err) == codes.OutOfRange {
+		return nil, errAccountState.withReason("%v", status.Convert(err).Message())
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &accountBalanceResponse{
+		BlockIdentifier: blockIdentifier(layer),
+		Balances:        []*Amount{amount(account.Balance, false)},
+		Metadata:        map[string]interface{}{"nonce": account.Counter},
+	}, nil
+}
+
+func block(ctx context.Context, s *Server, body []byte) (interface{}, error) {
+	var req blockRequest
+	if err := s.decode(ctx, body, &req, func() *NetworkIdentifier { return req.NetworkIdentifier }); err != nil {
+		return nil, err
+	}
+	if req.BlockIdentifier == nil {
+		return nil, errInvalidRequest.withReason("block_identifier must be provided")
+	}
+	layer, err := s.blockLayer(ctx, req.BlockIdentifier)
+	if err != nil {
+		return nil, err
+	}
+	// fetch the layer again, with the data of its transactions
+	layer, err = s.layer(ctx, layer.Number, true)
+	if err != nil {
+		return nil, err
+	}
+	params, err := s.networkParams(ctx)
+	if err != nil {
+		return nil, err
+	}
+	txs, err := s.blockTransactions(ctx, layer)
+	if err != nil {
+		return nil, err
+	}
+	parent := blockIdentifier(layer)
+	if layer.Number > genesisIndex {
+		parentLayer, err := s.layer(ctx, layer.Number-1, false)
+		if err != nil {
+			return nil, err
+		}
+		parent = blockIdentifier(parentLayer)
+	}
+	return &blockResponse{Block: &Block{
+		BlockIdentifier:       blockIdentifier(layer),
+		ParentBlockIdentifier: parent,
+		Timestamp:             params.timestamp(layer.Number),
+		Transactions:          txs,
+	}}, nil
+}
+
+func blockTransaction(ctx context.Context, s *Server, body []byte) (interface{}, error) {
+	var req blockTransactionRequest
+	if err := s.decode(ctx, body, &req, func() *NetworkIdentifier { return req.NetworkIdentifier }); err != nil {
+		return nil, err
+	}
+	if req.BlockIdentifier == nil || req.TransactionIdentifier == nil {
+		return nil, errInvalidRequest.withReason("block_identifier and transaction_identifier must be provided")
+	}
+	layer, err := s.layer(ctx, uint64(req.BlockIdentifier.Index), true)
+	if err != nil {
+		return nil, err
+	}
+	if blockIdentifier(layer).Hash != req.BlockIdentifier.Hash {
+		return nil, errBlockNotFound.withReason("the hash of block %d is %v", layer.Number, blockIdentifier(layer).Hash)
+	}
+	txs, err := s.blockTransactions(ctx, layer)
+	if err != nil {
+		return nil, err
+	}
+	for _, tx := range txs {
+		if tx.TransactionIdentifier.Hash == req.TransactionIdentifier.Hash {
+			return &transactionResponse{Transaction: tx}, nil
+		}
+	}
+	return nil, errTxNotFound
+}
+
+// mempool returns no transactions, the api doesn't list the transactions of the mempool. Their state can be queried by
+// their id with /mempool/transaction.
+func mempool(ctx context.Context, s *Server, body []byte) (interface{}, error) {
+	var req networkRequest
+	if err := s.decode(ctx, body, &req, func() *NetworkIdentifier { return req.NetworkIdentifier }); err != nil {
+		return nil, err
+	}
+	return &mempoolResponse{TransactionIdentifiers: []*TransactionIdentifier{}}, nil
+}
+
+func mempoolTransaction(ctx context.Context, s *Server, body []byte) (interface{}, error) {
+	var req mempoolTransactionRequest
+	if err := s.decode(ctx, body, &req, func() *NetworkIdentifier { return req.NetworkIdentifier }); err != nil {
+		return nil, err
+	}
+	if req.TransactionIdentifier == nil {
+		return nil, errInvalidRequest.withReason("transaction_identifier must be provided")
+	}
+	id, err := decodeHex(req.TransactionIdentifier.Hash)
+	if err != nil {
+		return nil, errInvalidRequest.withReason("invalid transaction hash: %v", err)
+	}
+	res, err := s.clients.tx.TransactionsState(ctx, &pb.TransactionsStateRequest{
+		TransactionId:       []*pb.TransactionId{{Id: id}},
+		IncludeTransactions: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(res.TransactionsState) == 0 || len(res.Transactions) == 0 ||
+		res.TransactionsState[0].State != pb.TransactionState_TRANSACTION_STATE_MEMPOOL {
+		return nil, errTxNotFound
+	}
+	tx := res.Transactions[0]
+	var recipient []byte
+	if transfer, ok := tx.Data.(*pb.Transaction_CoinTransfer); ok {
+		recipient = transfer.CoinTransfer.GetReceiver().GetAddress()
+	}
+	return &transactionResponse{Transaction: &Transaction{
+		TransactionIdentifier: &TransactionIdentifier{Hash: util.Encode(tx.Id.GetId())},
+		Operations: transferOperations(tx.Sender.GetAddress(), recipient, tx.Amount.GetValue(),
+			tx.GasOffered.GetGasPrice(), nil),
+	}}, nil
+}
+
+// Blocks
+
+// timestamp returns the start time of a layer, in unix milliseconds
+func (p *networkParams) timestamp(layer uint64) int64 {
+	return (p.genesisTime + int64(layer)*p.layerDuration) * 1000
+}
+
+// layer returns a layer that was applied to the global state, along with the data of its transactions and the
+// activations of its blocks if withData is set
+func (s *Server) layer(ctx context.Context, index uint64, withData bool) (*extpb.Layer, error) {
+	res, err := s.clients.extMesh.PagedLayersQuery(ctx, &extpb.PagedLayersQueryRequest{
+		StartLayer:          index,
+		EndLayer:            index,
+		IncludeTransactions: withData,
+		IncludeActivations:  withData,
+		PageSize:            1,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Layers) == 0 || res.Layers[0].Number != index {
+		return nil, errBlockNotFound.withReason("layer %d is unknown", index)
+	}
+	layer := res.Layers[0]
+	if index != genesisIndex && layer.Status != extpb.Layer_LAYER_STATUS_CONFIRMED {
+		return nil, errBlockNotFound.withReason("layer %d wasn't applied to the global state yet", index)
+	}
+	return layer, nil
+}
+
+// blockLayer returns the layer of a block identifier, the last layer applied to the global state if it's not set
+func (s *Server) blockLayer(ctx context.Context, id *PartialBlockIdentifier) (*extpb.Layer, error) {
+	if id != nil && id.Index == nil && id.Hash != nil {
+		return nil, errHashLookup
+	}
+	if id == nil || id.Index == nil {
+		res, err := s.clients.node.Status(ctx, &pb.StatusRequest{})
+		if err != nil {
+			return nil, err
+		}
+		return s.layer(ctx, res.Status.GetVerifiedLayer(), false)
+	}
+	if *id.Index < 0 {
+		return nil, errInvalidRequest.withReason("block index must not be negative")
+	}
+	layer, err := s.layer(ctx, uint64(*id.Index), false)
+	if err != nil {
+		return nil, err
+	}
+	if id.Hash != nil && *id.Hash != blockIdentifier(layer).Hash {
+		return nil, errBlockNotFound.withReason("the hash of block %d is %v", layer.Number, blockIdentifier(layer).Hash)
+	}
+	return layer, nil
+}
+
+// blockIdentifier returns the identifier of the block of a layer. Its hash is the hash of the layer, except for layers
+// without blocks, which all have the same hash: their hash is the hash of their number.
+func blockIdentifier(layer *extpb.Layer) *BlockIdentifier {
+	hash := layer.Hash
+	if len(layer.Blocks) == 0 {
+		var b [8]byte
+		binary.BigEndian.PutUint64(b[:], layer.Number)
+		hash = types.CalcHash32(b[:]).Bytes()
+	}
+	return &BlockIdentifier{Index: int64(layer.Number), Hash: util.Encode(hash)}
+}
+
+// blockTransactions returns the transactions that were applied in a layer, followed by a transaction paying the
+// rewards of the layer if there were any. A transaction may be included in several layers, but it's only applied in
+// the first one that's applied to the global state.
+func (s *Server) blockTransactions(ctx context.Context, layer *extpb.Layer) ([]*Transaction, error) {
+	txs := []*Transaction{}
+	seen := make(map[string]bool)
+	for _, b := range layer.Blocks {
+		for _, tx := range b.Transactions {
+			if seen[string(tx.Id)] {
+				continue
+			}
+			seen[string(tx.Id)] = true
+			res, err := s.clients.extTx.TransactionReceipt(ctx, &extpb.TransactionReceiptRequest{Id: tx.Id})
+			if status.Code(err) == codes.NotFound {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			if res.Receipt.GetLayer() != layer.Number {
+				continue
+			}
+			opStatus := statusFailure
+			if res.Receipt.Result == extpb.TransactionReceipt_TRANSACTION_RESULT_APPLIED {
+				opStatus = statusSuccess
+			}
+			txs = append(txs, &Transaction{
+				TransactionIdentifier: &TransactionIdentifier{Hash: util.Encode(tx.Id)},
+				Operations:            transferOperations(tx.Sender, tx.Recipient, tx.Amount, res.Receipt.Fee, &opStatus),
+			})
+		}
+	}
+	rewards, err := s.rewardsTransaction(ctx, layer)
+	if err != nil {
+		return nil, err
+	}
+	if rewards != nil {
+		txs = append(txs, rewards)
+	}
+	return txs, nil
+}
+
+// rewardsTransaction returns a transaction with an operation for every coinbase that was rewarded in a layer, or nil
+// if there were no rewards. Its hash is made of the index of the block, so that it can't collide with the hash of a
+// transaction.
+func (s *Server) rewardsTransaction(ctx context.Context, layer *extpb.Layer) (*Transaction, error) {
+	params, err := s.networkParams(ctx)
+	if err != nil {
+		return nil, err
+	}
+	epoch := layer.Number / params.layersPerEpoch
+	var coinbases [][]byte
+	seen := make(map[string]bool)
+	for _, atx := range layer.Activations {
+		if !seen[string(atx.Coinbase)] {
+			seen[string(atx.Coinbase)] = true
+			coinbases = append(coinbases, atx.Coinbase)
+		}
+	}
+	var ops []*Operation
+	for _, cb := range coinbases {
+		res, err := s.clients.extGlobalState.CoinbaseRewards(ctx, &extpb.CoinbaseRewardsRequest{
+			Coinbase:      cb,
+			MinEpoch:      epoch,
+			MaxEpoch:      epoch,
+			IncludeLayers: true,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range res.Epochs {
+			for _, l := range e.Layers {
+				if l.Layer == layer.Number && l.Total > 0 {
+					ops = append(ops, operation(len(ops), opReward, cb, amount(l.Total, false), statusSuccess))
+				}
+			}
+		}
+	}
+	if len(ops) == 0 {
+		return nil, nil
+	}
+	return &Transaction{
+		TransactionIdentifier: &TransactionIdentifier{Hash: fmt.Sprintf("rewards-%d", layer.Number)},
+		Operations:            ops,
+	}, nil
+}
+
+// Operations
+
+// transferOperations returns the operations of a transaction: the amount that's moved from the sender to the
+// recipient, and the fee the sender pays. Statuses are only set for transactions that were applied.
+func transferOperations(sender, recipient []byte, value, fee uint64, opStatus *string) []*Operation {
+	ops := []*Operation{
+		{
+			OperationIdentifier: &OperationIdentifier{Index: 0},
+			Type:                opTransfer,
+			Status:              opStatus,
+			Account:             &AccountIdentifier{Address: util.Encode(sender)},
+			Amount:              amount(value, true),
+		},
+		{
+			OperationIdentifier: &OperationIdentifier{Index: 1},
+			RelatedOperations:   []*OperationIdentifier{{Index: 0}},
+			Type:                opTransfer,
+			Status:              opStatus,
+			Account:             &AccountIdentifier{Address: util.Encode(recipient)},
+			Amount:              amount(value, false),
+		},
+	}
+	if fee > 0 {
+		ops = append(ops, &Operation{
+			OperationIdentifier: &OperationIdentifier{Index: 2},
+			Type:                opFee,
+			Status:              opStatus,
+			Account:             &AccountIdentifier{Address: util.Encode(sender)},
+			Amount:              amount(fee, true),
+		})
+	}
+	return ops
+}
+
+func operation(index int, typ string, account []byte, value *Amount, opStatus string) *Operation {
+	return &Operation{
+		OperationIdentifier: &OperationIdentifier{Index: int64(index)},
+		Type:                typ,
+		Status:              &opStatus,
+		Account:             &AccountIdentifier{Address: util.Encode(account)},
+		Amount:              value,
+	}
+}
+
+func amount(value uint64, debit bool) *Amount {
+	s := strconv.FormatUint(value, 10)
+	if debit && value > 0 {
+		s = "-" + s
+	}
+	return &Amount{Value: s, Currency: currency}
+}
+
+// parseAmount returns the value of an amount and whether it's a debit
+func parseAmount(a *Amount) (uint64, bool, error) {
+	if a == nil || a.Currency == nil || *a.Currency != *currency {
+		return 0, false, fmt.Errorf("amounts must be in %v with %d decimals", currency.Symbol, currency.Decimals)
+	}
+	debit := strings.HasPrefix(a.Value, "-")
+	value, err := strconv.ParseUint(strings.TrimPrefix(a.Value, "-"), 10, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid amount %q", a.Value)
+	}
+	return value, debit, nil
+}
+
+// Encoding
+
+// decodeHex decodes a hex string, with or without the 0x prefix
+func decodeHex(s string) ([]byte, error) {
+	return hex.DecodeString(strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X"))
+}
+
+// accountAddress returns the address of an account, accounts don't have sub accounts
+func accountAddress(id *AccountIdentifier) (types.Address, error) {
+	if id == nil {
+		return types.Address{}, errInvalidRequest.withReason("account_identifier must be provided")
+	}
+	if id.SubAccount != nil {
+		return types.Address{}, errInvalidRequest.withReason("sub accounts aren't supported")
+	}
+	b, err := decodeHex(id.Address)
+	if err != nil || len(b) != types.AddressLength {
+		return types.Address{}, errInvalidRequest.withReason("invalid address %q", id.Address)
+	}
+	return types.BytesToAddress(b), nil
+}
+
+// sameAddress returns whether an account identifier is the given address
+func sameAddress(id *AccountIdentifier, addr types.Address) bool {
+	other, err := accountAddress(id)
+	return err == nil && bytes.Equal(other.Bytes(), addr.Bytes())
+}
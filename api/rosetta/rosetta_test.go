@@ -0,0 +1,455 @@
+package rosetta
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	pb "github.com/spacemeshos/api/release/go/spacemesh/v1"
+	"github.com/spacemeshos/go-spacemesh/api/extpb"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/common/util"
+	"github.com/spacemeshos/go-spacemesh/signing"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	sender    = types.BytesToAddress([]byte{0xaa})
+	recipient = types.BytesToAddress([]byte{0xbb})
+	coinbase  = types.BytesToAddress([]byte{0xcc})
+	tx1       = []byte{0x01}
+	tx2       = []byte{0x02}
+)
+
+type nodeMock struct {
+	pb.UnimplementedNodeServiceServer
+}
+
+func (nodeMock) Version(ctx context.Context, in *empty.Empty) (*pb.VersionResponse, error) {
+	return &pb.VersionResponse{VersionString: &pb.SimpleString{Value: "v0.1.0"}}, nil
+}
+
+func (nodeMock) Status(ctx context.Context, in *pb.StatusRequest) (*pb.StatusResponse, error) {
+	return &pb.StatusResponse{Status: &pb.NodeStatus{VerifiedLayer: 3, TopLayer: 5, IsSynced: true}}, nil
+}
+
+type meshMock struct {
+	pb.UnimplementedMeshServiceServer
+}
+
+func (meshMock) NetID(ctx context.Context, in *pb.NetIDRequest) (*pb.NetIDResponse, error) {
+	return &pb.NetIDResponse{Netid: &pb.SimpleInt{Value: 1}}, nil
+}
+
+func (meshMock) GenesisTime(ctx context.Context, in *pb.GenesisTimeRequest) (*pb.GenesisTimeResponse, error) {
+	return &pb.GenesisTimeResponse{Unixtime: &pb.SimpleInt{Value: 1000}}, nil
+}
+
+func (meshMock) LayerDuration(ctx context.Context, in *pb.LayerDurationRequest) (*pb.LayerDurationResponse, error) {
+	return &pb.LayerDurationResponse{Duration: &pb.SimpleInt{Value: 10}}, nil
+}
+
+func (meshMock) EpochNumLayers(ctx context.Context, in *pb.EpochNumLayersRequest) (*pb.EpochNumLayersResponse, error) {
+	return &pb.EpochNumLayersResponse{Numlayers: &pb.SimpleInt{Value: 5}}, nil
+}
+
+type extMeshMock struct {
+	extpb.UnimplementedMeshServiceServer
+}
+
+// PagedLayersQuery returns layers 0 to 5, of which 0 to 3 are confirmed. Layer 3 has a block with two transactions,
+// the second one was applied in layer 2.
+func (extMeshMock) PagedLayersQuery(ctx context.Context, in *extpb.PagedLayersQueryRequest) (*extpb.PagedLayersQueryResponse, error) {
+	if in.StartLayer > 5 {
+		return &extpb.PagedLayersQueryResponse{}, nil
+	}
+	layer := &extpb.Layer{Number: in.StartLayer, Status: extpb.Layer_LAYER_STATUS_CONFIRMED}
+	if in.StartLayer > 3 {
+		layer.Status = extpb.Layer_LAYER_STATUS_APPROVED
+	}
+	if in.StartLayer == 3 {
+		layer.Hash = []byte{0x33}
+		block := &extpb.Block{Id: []byte{0x03}, Layer: 3, TransactionIds: [][]byte{tx1, tx2}}
+		if in.IncludeTransactions {
+			block.Transactions = []*extpb.Transaction{
+				{Id: tx1, Sender: sender.Bytes(), Recipient: recipient.Bytes(), Amount: 10, Fee: 1},
+				{Id: tx2, Sender: sender.Bytes(), Recipient: recipient.Bytes(), Amount: 20, Fee: 1},
+			}
+		}
+		layer.Blocks = []*extpb.Block{block}
+		if in.IncludeActivations {
+			layer.Activations = []*extpb.Activation{{Id: []byte{0x04}, Coinbase: coinbase.Bytes()}}
+		}
+	}
+	return &extpb.PagedLayersQueryResponse{Layers: []*extpb.Layer{layer}}, nil
+}
+
+type extTxMock struct {
+	extpb.UnimplementedTransactionServiceServer
+}
+
+func (extTxMock) TransactionReceipt(ctx context.Context, in *extpb.TransactionReceiptRequest) (*extpb.TransactionReceiptResponse, error) {
+	switch {
+	case bytes.Equal(in.Id, tx1):
+		return &extpb.TransactionReceiptResponse{Receipt: &extpb.TransactionReceipt{
+			Id: tx1, Layer: 3, Result: extpb.TransactionReceipt_TRANSACTION_RESULT_APPLIED, Fee: 1,
+		}}, nil
+	case bytes.Equal(in.Id, tx2):
+		return &extpb.TransactionReceiptResponse{Receipt: &extpb.TransactionReceipt{
+			Id: tx2, Layer: 2, Result: extpb.TransactionReceipt_TRANSACTION_RESULT_APPLIED, Fee: 1,
+		}}, nil
+	}
+	return nil, status.Errorf(codes.NotFound, "transaction receipt not found")
+}
+
+func (extTxMock) EstimateFee(ctx context.Context, in *extpb.EstimateFeeRequest) (*extpb.EstimateFeeResponse, error) {
+	return &extpb.EstimateFeeResponse{LowFee: 1, MediumFee: 2, HighFee: 3}, nil
+}
+
+func (extTxMock) SubmitTransactionWithOptions(ctx context.Context, in *extpb.SubmitTransactionWithOptionsRequest) (*extpb.SubmitTransactionWithOptionsResponse, error) {
+	tx, err := types.BytesToTransaction(in.Transaction)
+	if err != nil {
+		return &extpb.SubmitTransactionWithOptionsResponse{Validity: extpb.TransactionValidity_TRANSACTION_VALIDITY_MALFORMED}, nil
+	}
+	if tx.AccountNonce != 7 {
+		return &extpb.SubmitTransactionWithOptionsResponse{
+			Id:       tx.ID().Bytes(),
+			Validity: extpb.TransactionValidity_TRANSACTION_VALIDITY_BAD_NONCE,
+			Message:  "bad nonce",
+		}, nil
+	}
+	return &extpb.SubmitTransactionWithOptionsResponse{Id: tx.ID().Bytes(), Broadcast: true}, nil
+}
+
+type txMock struct {
+	pb.UnimplementedTransactionServiceServer
+}
+
+func (txMock) TransactionsState(ctx context.Context, in *pb.TransactionsStateRequest) (*pb.TransactionsStateResponse, error) {
+	id := in.TransactionId[0]
+	if id.Id[0] != 0xdd {
+		return &pb.TransactionsStateResponse{TransactionsState: []*pb.TransactionState{{Id: id}}}, nil
+	}
+	return &pb.TransactionsStateResponse{
+		TransactionsState: []*pb.TransactionState{{Id: id, State: pb.TransactionState_TRANSACTION_STATE_MEMPOOL}},
+		Transactions: []*pb.Transaction{{
+			Id:         id,
+			Data:       &pb.Transaction_CoinTransfer{CoinTransfer: &pb.CoinTransferTransaction{Receiver: &pb.AccountId{Address: recipient.Bytes()}}},
+			Sender:     &pb.AccountId{Address: sender.Bytes()},
+			GasOffered: &pb.GasOffered{GasPrice: 2},
+			Amount:     &pb.Amount{Value: 5},
+		}},
+	}, nil
+}
+
+type globalStateMock struct {
+	pb.UnimplementedGlobalStateServiceServer
+}
+
+func (globalStateMock) Account(ctx context.Context, in *pb.AccountRequest) (*pb.AccountResponse, error) {
+	return &pb.AccountResponse{Account: &pb.Account{Address: in.AccountId, Counter: 7, Balance: &pb.Amount{Value: 100}}}, nil
+}
+
+type extGlobalStateMock struct {
+	extpb.UnimplementedGlobalStateServiceServer
+}
+
+func (extGlobalStateMock) AccountAtLayer(ctx context.Context, in *extpb.AccountAtLayerRequest) (*extpb.AccountAtLayerResponse, error) {
+	if in.Layer < 1 {
+		return nil, status.Errorf(codes.OutOfRange, "the global state of layer %v is too old", in.Layer)
+	}
+	return &extpb.AccountAtLayerResponse{AccountId: in.AccountId, Layer: in.Layer, Exists: true, Counter: 7, Balance: 100 + in.Layer}, nil
+}
+
+func (extGlobalStateMock) CoinbaseRewards(ctx context.Context, in *extpb.CoinbaseRewardsRequest) (*extpb.CoinbaseRewardsResponse, error) {
+	return &extpb.CoinbaseRewardsResponse{Epochs: []*extpb.EpochRewards{{
+		Epoch:  0,
+		Total:  50,
+		Layers: []*extpb.LayerRewards{{Layer: 3, Blocks: 1, Total: 50, LayerReward: 49}},
+	}}}, nil
+}
+
+// launchServer starts a grpc server with the mocked services and a rosetta server reading from it
+func launchServer(t *testing.T) (*Server, func()) {
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	grpcServer := grpc.NewServer()
+	pb.RegisterNodeServiceServer(grpcServer, &nodeMock{})
+	pb.RegisterMeshServiceServer(grpcServer, &meshMock{})
+	extpb.RegisterMeshServiceServer(grpcServer, &extMeshMock{})
+	pb.RegisterTransactionServiceServer(grpcServer, &txMock{})
+	extpb.RegisterTransactionServiceServer(grpcServer, &extTxMock{})
+	pb.RegisterGlobalStateServiceServer(grpcServer, &globalStateMock{})
+	extpb.RegisterGlobalStateServiceServer(grpcServer, &extGlobalStateMock{})
+	go grpcServer.Serve(lis)
+
+	srv := NewServer(0, lis.Addr().(*net.TCPAddr).Port)
+	srv.Start()
+	return srv, func() {
+		require.NoError(t, srv.Close())
+		grpcServer.Stop()
+	}
+}
+
+const network = `"network_identifier":{"blockchain":"Spacemesh","network":"testnet"}`
+
+func post(t *testing.T, srv *Server, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(body))
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+	return rec
+}
+
+// call posts a request and decodes its successful response into res
+func call(t *testing.T, srv *Server, path, body string, res interface{}) {
+	rec := post(t, srv, path, body)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), res))
+}
+
+// callError posts a request that fails and returns its error
+func callError(t *testing.T, srv *Server, path, body string) *Error {
+	rec := post(t, srv, path, body)
+	require.Equal(t, http.StatusInternalServerError, rec.Code, rec.Body.String())
+	var res Error
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	return &res
+}
+
+func TestServer_Network(t *testing.T) {
+	srv, shutDown := launchServer(t)
+	defer shutDown()
+
+	var list networkListResponse
+	call(t, srv, "/network/list", `{}`, &list)
+	require.Equal(t, []*NetworkIdentifier{{Blockchain: "Spacemesh", Network: "testnet"}}, list.NetworkIdentifiers)
+
+	var options networkOptionsResponse
+	call(t, srv, "/network/options", `{`+network+`}`, &options)
+	require.Equal(t, "v0.1.0", options.Version.NodeVersion)
+	require.Equal(t, []string{opTransfer, opFee, opReward}, options.Allow.OperationTypes)
+	require.Len(t, options.Allow.Errors, len(allErrors))
+	require.True(t, options.Allow.HistoricalBalanceLookup)
+
+	var st networkStatusResponse
+	call(t, srv, "/network/status", `{`+network+`}`, &st)
+	require.Equal(t, &BlockIdentifier{Index: 3, Hash: "0x33"}, st.CurrentBlockIdentifier)
+	require.Equal(t, int64(1030000), st.CurrentBlockTimestamp)
+	require.Equal(t, int64(0), st.GenesisBlockIdentifier.Index)
+	require.Equal(t, &SyncStatus{CurrentIndex: 3, TargetIndex: 5, Synced: true}, st.SyncStatus)
+
+	err := callError(t, srv, "/network/status", `{"network_identifier":{"blockchain":"Spacemesh","network":"mainnet"}}`)
+	require.Equal(t, errNetworkUnsupported.Code, err.Code)
+	err = callError(t, srv, "/network/status", `{}`)
+	require.Equal(t, errInvalidRequest.Code, err.Code)
+}
+
+func TestServer_Blocks(t *testing.T) {
+	srv, shutDown := launchServer(t)
+	defer shutDown()
+
+	var res blockResponse
+	call(t, srv, "/block", `{`+network+`,"block_identifier":{"index":3}}`, &res)
+	b := res.Block
+	require.Equal(t, &BlockIdentifier{Index: 3, Hash: "0x33"}, b.BlockIdentifier)
+	require.Equal(t, int64(2), b.ParentBlockIdentifier.Index)
+	require.NotEqual(t, b.BlockIdentifier.Hash, b.ParentBlockIdentifier.Hash)
+	require.Equal(t, int64(1030000), b.Timestamp)
+
+	// the second transaction was applied in layer 2
+	require.Len(t, b.Transactions, 2)
+	tx := b.Transactions[0]
+	require.Equal(t, util.Encode(tx1), tx.TransactionIdentifier.Hash)
+	require.Len(t, tx.Operations, 3)
+	require.Equal(t, "-10", tx.Operations[0].Amount.Value)
+	require.Equal(t, util.Encode(sender.Bytes()), tx.Operations[0].Account.Address)
+	require.Equal(t, "10", tx.Operations[1].Amount.Value)
+	require.Equal(t, util.Encode(recipient.Bytes()), tx.Operations[1].Account.Address)
+	require.Equal(t, opFee, tx.Operations[2].Type)
+	require.Equal(t, "-1", tx.Operations[2].Amount.Value)
+	for _, op := range tx.Operations {
+		require.Equal(t, statusSuccess, *op.Status)
+	}
+	rewards := b.Transactions[1]
+	require.Equal(t, "rewards-3", rewards.TransactionIdentifier.Hash)
+	require.Len(t, rewards.Operations, 1)
+	require.Equal(t, opReward, rewards.Operations[0].Type)
+	require.Equal(t, "50", rewards.Operations[0].Amount.Value)
+	require.Equal(t, util.Encode(coinbase.Bytes()), rewards.Operations[0].Account.Address)
+
+	// the latest block is the last layer applied to the global state
+	call(t, srv, "/block", `{`+network+`,"block_identifier":{}}`, &res)
+	require.Equal(t, int64(3), res.Block.BlockIdentifier.Index)
+
+	var txRes transactionResponse
+	call(t, srv, "/block/transaction", `{`+network+`,"block_identifier":{"index":3,"hash":"0x33"},"transaction_identifier":{"hash":"rewards-3"}}`, &txRes)
+	require.Equal(t, rewards, txRes.Transaction)
+
+	for _, tc := range []struct {
+		name, path, body string
+		code             int32
+	}{
+		{"not applied", "/block", `"block_identifier":{"index":4}`, errBlockNotFound.Code},
+		{"unknown", "/block", `"block_identifier":{"index":6}`, errBlockNotFound.Code},
+		{"hash lookup", "/block", `"block_identifier":{"hash":"0x33"}`, errHashLookup.Code},
+		{"wrong hash", "/block", `"block_identifier":{"index":3,"hash":"0x34"}`, errBlockNotFound.Code},
+		{"unknown tx", "/block/transaction", `"block_identifier":{"index":3,"hash":"0x33"},"transaction_identifier":{"hash":"0x02"}`, errTxNotFound.Code},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.code, callError(t, srv, tc.path, `{`+network+`,`+tc.body+`}`).Code)
+		})
+	}
+}
+
+func TestServer_Accounts(t *testing.T) {
+	srv, shutDown := launchServer(t)
+	defer shutDown()
+
+	account := `"account_identifier":{"address":"` + util.Encode(sender.Bytes()) + `"}`
+	var res accountBalanceResponse
+	call(t, srv, "/account/balance", `{`+network+`,`+account+`}`, &res)
+	require.Equal(t, int64(3), res.BlockIdentifier.Index)
+	require.Equal(t, "103", res.Balances[0].Value)
+	require.Equal(t, currency, res.Balances[0].Currency)
+	require.Equal(t, 7.0, res.Metadata["nonce"])
+
+	call(t, srv, "/account/balance", `{`+network+`,`+account+`,"block_identifier":{"index":2}}`, &res)
+	require.Equal(t, int64(2), res.BlockIdentifier.Index)
+	require.Equal(t, "102", res.Balances[0].Value)
+
+	err := callError(t, srv, "/account/balance", `{`+network+`,`+account+`,"block_identifier":{"index":0}}`)
+	require.Equal(t, errAccountState.Code, err.Code)
+	err = callError(t, srv, "/account/balance", `{`+network+`,"account_identifier":{"address":"0x01"}}`)
+	require.Equal(t, errInvalidRequest.Code, err.Code)
+}
+
+func TestServer_Mempool(t *testing.T) {
+	srv, shutDown := launchServer(t)
+	defer shutDown()
+
+	var res mempoolResponse
+	call(t, srv, "/mempool", `{`+network+`}`, &res)
+	require.Empty(t, res.TransactionIdentifiers)
+
+	var txRes transactionResponse
+	call(t, srv, "/mempool/transaction", `{`+network+`,"transaction_identifier":{"hash":"0xdd"}}`, &txRes)
+	require.Equal(t, "0xdd", txRes.Transaction.TransactionIdentifier.Hash)
+	require.Len(t, txRes.Transaction.Operations, 3)
+	require.Nil(t, txRes.Transaction.Operations[0].Status)
+
+	err := callError(t, srv, "/mempool/transaction", `{`+network+`,"transaction_identifier":{"hash":"0xee"}}`)
+	require.Equal(t, errTxNotFound.Code, err.Code)
+}
+
+func TestServer_Construction(t *testing.T) {
+	srv, shutDown := launchServer(t)
+	defer shutDown()
+
+	signer := signing.NewEdSigner()
+	pub := signer.PublicKey().Bytes()
+	from := util.Encode(types.BytesToAddress(pub).Bytes())
+	to := util.Encode(recipient.Bytes())
+
+	var derived deriveResponse
+	call(t, srv, "/construction/derive", `{`+network+`,"public_key":{"hex_bytes":"`+hex.EncodeToString(pub)+`","curve_type":"edwards25519"}}`, &derived)
+	require.Equal(t, from, derived.AccountIdentifier.Address)
+
+	ops := `"operations":[
+		{"operation_identifier":{"index":0},"type":"TRANSFER","account":{"address":"` + from + `"},"amount":{"value":"-10","currency":{"symbol":"SMH","decimals":12}}},
+		{"operation_identifier":{"index":1},"type":"TRANSFER","account":{"address":"` + to + `"},"amount":{"value":"10","currency":{"symbol":"SMH","decimals":12}}}
+	]`
+	var preprocessed preprocessResponse
+	call(t, srv, "/construction/preprocess", `{`+network+`,`+ops+`}`, &preprocessed)
+	require.Equal(t, from, preprocessed.Options["sender"])
+	options, err := json.Marshal(preprocessed.Options)
+	require.NoError(t, err)
+
+	var meta metadataResponse
+	call(t, srv, "/construction/metadata", `{`+network+`,"options":`+string(options)+`}`, &meta)
+	require.Equal(t, &constructionMetadata{Nonce: 7, Fee: 2, GasLimit: defaultGasLimit}, meta.Metadata)
+	metadata, err := json.Marshal(meta.Metadata)
+	require.NoError(t, err)
+
+	var payloads payloadsResponse
+	call(t, srv, "/construction/payloads", `{`+network+`,`+ops+`,"metadata":`+string(metadata)+`}`, &payloads)
+	require.Len(t, payloads.Payloads, 1)
+	require.Equal(t, from, payloads.Payloads[0].AccountIdentifier.Address)
+
+	var parsed parseResponse
+	call(t, srv, "/construction/parse", `{`+network+`,"signed":false,"transaction":"`+payloads.UnsignedTransaction+`"}`, &parsed)
+	require.Len(t, parsed.Operations, 2)
+	require.Equal(t, "-10", parsed.Operations[0].Amount.Value)
+	require.Empty(t, parsed.AccountIdentifierSigners)
+
+	payload, err := hex.DecodeString(payloads.Payloads[0].HexBytes)
+	require.NoError(t, err)
+	sig := hex.EncodeToString(signer.Sign(payload))
+	payloadJSON, err := json.Marshal(payloads.Payloads[0])
+	require.NoError(t, err)
+	signatures := `"signatures":[{"signing_payload":` + string(payloadJSON) + `,"public_key":{"hex_bytes":"` +
+		hex.EncodeToString(pub) + `","curve_type":"edwards25519"},"signature_type":"ed25519","hex_bytes":"` + sig + `"}]`
+	var combined combineResponse
+	call(t, srv, "/construction/combine", `{`+network+`,"unsigned_transaction":"`+payloads.UnsignedTransaction+`",`+signatures+`}`, &combined)
+
+	call(t, srv, "/construction/parse", `{`+network+`,"signed":true,"transaction":"`+combined.SignedTransaction+`"}`, &parsed)
+	require.Equal(t, []*AccountIdentifier{{Address: from}}, parsed.AccountIdentifierSigners)
+	require.Equal(t, to, parsed.Operations[1].Account.Address)
+
+	var hashed, submitted transactionIdentifierResponse
+	call(t, srv, "/construction/hash", `{`+network+`,"signed_transaction":"`+combined.SignedTransaction+`"}`, &hashed)
+	call(t, srv, "/construction/submit", `{`+network+`,"signed_transaction":"`+combined.SignedTransaction+`"}`, &submitted)
+	require.Equal(t, hashed, submitted)
+
+	// signatures of other keys and transactions are rejected
+	other := signing.NewEdSigner()
+	otherSig := hex.EncodeToString(other.Sign(payload))
+	e := callError(t, srv, "/construction/combine", `{`+network+`,"unsigned_transaction":"`+payloads.UnsignedTransaction+`",`+
+		`"signatures":[{"public_key":{"hex_bytes":"`+hex.EncodeToString(other.PublicKey().Bytes())+`","curve_type":"edwards25519"},"signature_type":"ed25519","hex_bytes":"`+otherSig+`"}]}`)
+	require.Equal(t, errInvalidSignature.Code, e.Code)
+	e = callError(t, srv, "/construction/combine", `{`+network+`,"unsigned_transaction":"`+payloads.UnsignedTransaction+`",`+
+		`"signatures":[{"public_key":{"hex_bytes":"`+hex.EncodeToString(pub)+`","curve_type":"edwards25519"},"signature_type":"ed25519","hex_bytes":"`+otherSig+`"}]}`)
+	require.Equal(t, errInvalidSignature.Code, e.Code)
+
+	// the node rejects transactions with a stale nonce
+	call(t, srv, "/construction/payloads", `{`+network+`,`+ops+`,"metadata":{"nonce":6,"fee":2,"gas_limit":10}}`, &payloads)
+	payload, err = hex.DecodeString(payloads.Payloads[0].HexBytes)
+	require.NoError(t, err)
+	sig = hex.EncodeToString(signer.Sign(payload))
+	signatures = `"signatures":[{"public_key":{"hex_bytes":"` + hex.EncodeToString(pub) +
+		`","curve_type":"edwards25519"},"signature_type":"ed25519","hex_bytes":"` + sig + `"}]`
+	call(t, srv, "/construction/combine", `{`+network+`,"unsigned_transaction":"`+payloads.UnsignedTransaction+`",`+signatures+`}`, &combined)
+	e = callError(t, srv, "/construction/submit", `{`+network+`,"signed_transaction":"`+combined.SignedTransaction+`"}`)
+	require.Equal(t, errTxRejected.Code, e.Code)
+	require.Equal(t, "TRANSACTION_VALIDITY_BAD_NONCE", e.Details["validity"])
+
+	// transfers must balance
+	unbalanced := `"operations":[
+		{"operation_identifier":{"index":0},"type":"TRANSFER","account":{"address":"` + from + `"},"amount":{"value":"-10","currency":{"symbol":"SMH","decimals":12}}},
+		{"operation_identifier":{"index":1},"type":"TRANSFER","account":{"address":"` + to + `"},"amount":{"value":"9","currency":{"symbol":"SMH","decimals":12}}}
+	]`
+	e = callError(t, srv, "/construction/preprocess", `{`+network+`,`+unbalanced+`}`)
+	require.Equal(t, errInvalidOperations.Code, e.Code)
+}
+
+func TestServer_HTTP(t *testing.T) {
+	srv, shutDown := launchServer(t)
+	defer shutDown()
+
+	require.Equal(t, http.StatusNotFound, post(t, srv, "/unknown", `{}`).Code)
+	req := httptest.NewRequest(http.MethodGet, "/network/list", nil)
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+
+	err := callError(t, srv, "/network/status", `{`)
+	require.Equal(t, errInvalidRequest.Code, err.Code)
+}
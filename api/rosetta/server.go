@@ -0,0 +1,292 @@
+// Package rosetta provides an http server implementing the Data and Construction APIs of the Rosetta specification,
+// so that exchanges and custodians can integrate with the node using standard Rosetta tooling. Like the JSON-RPC
+// server, it's backed by the grpc api of the node.
+//
+// Blocks are the layers that were applied to the global state, a block holds the transactions that were applied in its
+// layer and a transaction that pays the rewards of the layer. Transactions must be signed with the ed25519 variant of
+// the node, which lets the sender be recovered from the signature.
+package rosetta
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+
+	pb "github.com/spacemeshos/api/release/go/spacemesh/v1"
+	"github.com/spacemeshos/go-spacemesh/api/extpb"
+	"github.com/spacemeshos/go-spacemesh/log"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// The version of the specification the server implements
+const rosettaVersion = "1.4.10"
+
+// Blockchain is the name of the blockchain in network identifiers
+const Blockchain = "Spacemesh"
+
+// maxRequestSize limits the size of the body of a request
+const maxRequestSize = 1 << 20
+
+// currency is the currency of all amounts, values are in its smallest unit
+var currency = &Currency{Symbol: "SMH", Decimals: 12}
+
+// Error is a Rosetta error object. All the errors the server returns are listed by /network/options.
+type Error struct {
+	Code      int32                  `json:"code"`
+	Message   string                 `json:"message"`
+	Retriable bool                   `json:"retriable"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%v (%d)", e.Message, e.Code)
+}
+
+// withReason returns a copy of the error with the reason in its details
+func (e *Error) withReason(format string, args ...interface{}) *Error {
+	res := *e
+	res.Details = map[string]interface{}{"reason": fmt.Sprintf(format, args...)}
+	return &res
+}
+
+var (
+	errInvalidRequest     = &Error{Code: 1, Message: "invalid request"}
+	errNetworkUnsupported = &Error{Code: 2, Message: "network not supported"}
+	errUnavailable        = &Error{Code: 3, Message: "endpoint unavailable, the grpc service it uses isn't enabled"}
+	errNode               = &Error{Code: 4, Message: "node error", Retriable: true}
+	errBlockNotFound      = &Error{Code: 5, Message: "block not found", Retriable: true}
+	errHashLookup         = &Error{Code: 6, Message: "blocks can only be looked up by index"}
+	errTxNotFound         = &Error{Code: 7, Message: "transaction not found"}
+	errAccountState       = &Error{Code: 8, Message: "account state not available"}
+	errInvalidOperations  = &Error{Code: 9, Message: "invalid operations"}
+	errInvalidTransaction = &Error{Code: 10, Message: "invalid transaction"}
+	errInvalidSignature   = &Error{Code: 11, Message: "invalid signature"}
+	errTxRejected         = &Error{Code: 12, Message: "transaction rejected"}
+)
+
+var allErrors = []*Error{
+	errInvalidRequest, errNetworkUnsupported, errUnavailable, errNode, errBlockNotFound, errHashLookup, errTxNotFound,
+	errAccountState, errInvalidOperations, errInvalidTransaction, errInvalidSignature, errTxRejected,
+}
+
+// endpoint serves the requests of a path, body is the raw json request
+type endpoint func(ctx context.Context, s *Server, body []byte) (interface{}, error)
+
+var endpoints = map[string]endpoint{
+	"/network/list":            networkList,
+	"/network/options":         networkOptions,
+	"/network/status":          networkStatus,
+	"/account/balance":         accountBalance,
+	"/block":                   block,
+	"/block/transaction":       blockTransaction,
+	"/mempool":                 mempool,
+	"/mempool/transaction":     mempoolTransaction,
+	"/construction/derive":     constructionDerive,
+	"/construction/preprocess": constructionPreprocess,
+	"/construction/metadata":   constructionMetadataEndpoint,
+	"/construction/payloads":   constructionPayloads,
+	"/construction/combine":    constructionCombine,
+	"/construction/parse":      constructionParse,
+	"/construction/hash":       constructionHash,
+	"/construction/submit":     constructionSubmit,
+}
+
+// Server is an http server that serves Rosetta requests from the grpc server of the node. An endpoint is only
+// available when the grpc services it uses are enabled.
+type Server struct {
+	Port     int
+	GrpcPort int
+	server   *http.Server
+	conn     *grpc.ClientConn
+	clients  *clients
+
+	// the network parameters are read from the node once
+	paramsMu sync.Mutex
+	params   *networkParams
+}
+
+// NewServer returns a new Rosetta server listening on port, that reads from the grpc server on grpcPort
+func NewServer(port int, grpcPort int) *Server {
+	return &Server{Port: port, GrpcPort: grpcPort}
+}
+
+// Start connects to the grpc server and starts serving requests in the background
+func (s *Server) Start() {
+	endpoint := fmt.Sprintf("localhost:%d", s.GrpcPort)
+	conn, err := grpc.Dial(endpoint, grpc.WithInsecure())
+	if err != nil {
+		log.Error("not starting rosetta server; failed to connect to grpc service at %s: %v", endpoint, err)
+		return
+	}
+	s.conn = conn
+	s.clients = newClients(conn)
+
+	s.server = &http.Server{
+		Addr:    fmt.Sprintf(":%d", s.Port),
+		Handler: s,
+	}
+	log.Info("starting rosetta server on port %d connected to grpc service at %s", s.Port, endpoint)
+	go func() {
+		if err := s.server.ListenAndServe(); err != http.ErrServerClosed {
+			log.Error("error from rosetta listener: %v", err)
+		}
+	}()
+}
+
+// Close stops the server and closes its connection to the grpc server
+func (s *Server) Close() error {
+	log.Debug("Stopping rosetta server...")
+	if s.server != nil {
+		if err := s.server.Shutdown(context.TODO()); err != nil {
+			return err
+		}
+	}
+	if s.conn != nil {
+		return s.conn.Close()
+	}
+	return nil
+}
+
+// ServeHTTP serves a Rosetta request sent as the body of a POST request. Errors are returned with status 500, as the
+// specification requires.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	handle, ok := endpoints[r.URL.Path]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	if err != nil {
+		http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	log.Info("Rosetta %v", r.URL.Path)
+
+	res, err := handle(r.Context(), s, body)
+	w.Header().Set("Content-Type", "application/json")
+	if err != nil {
+		res = s.convertError(r.URL.Path, err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		log.Error("error writing rosetta response: %v", err)
+	}
+}
+
+// convertError converts the errors of the grpc api to Rosetta errors
+func (s *Server) convertError(path string, err error) *Error {
+	if rosettaErr, ok := err.(*Error); ok {
+		return rosettaErr
+	}
+	st := status.Convert(err)
+	switch st.Code() {
+	case codes.Unimplemented:
+		return errUnavailable.withReason("%v", st.Message())
+	case codes.InvalidArgument:
+		return errInvalidRequest.withReason("%v", st.Message())
+	}
+	log.Error("error serving rosetta %v: %v", path, err)
+	return errNode.withReason("%v", st.Message())
+}
+
+// decode decodes the json request into req, and checks that it's sent to the network of the node
+func (s *Server) decode(ctx context.Context, body []byte, req interface{}, network func() *NetworkIdentifier) error {
+	if err := json.Unmarshal(body, req); err != nil {
+		return errInvalidRequest.withReason("%v", err)
+	}
+	id := network()
+	if id == nil {
+		return errInvalidRequest.withReason("network_identifier must be provided")
+	}
+	params, err := s.networkParams(ctx)
+	if err != nil {
+		return err
+	}
+	if id.Blockchain != Blockchain || id.Network != params.network {
+		return errNetworkUnsupported.withReason("the node serves %v %v", Blockchain, params.network)
+	}
+	return nil
+}
+
+// networkParams are the parameters of the network the node belongs to
+type networkParams struct {
+	network        string
+	genesisTime    int64 // unix time in seconds
+	layerDuration  int64 // in seconds
+	layersPerEpoch uint64
+}
+
+func (s *Server) networkParams(ctx context.Context) (*networkParams, error) {
+	s.paramsMu.Lock()
+	defer s.paramsMu.Unlock()
+	if s.params != nil {
+		return s.params, nil
+	}
+	netID, err := s.clients.mesh.NetID(ctx, &pb.NetIDRequest{})
+	if err != nil {
+		return nil, err
+	}
+	genesis, err := s.clients.mesh.GenesisTime(ctx, &pb.GenesisTimeRequest{})
+	if err != nil {
+		return nil, err
+	}
+	duration, err := s.clients.mesh.LayerDuration(ctx, &pb.LayerDurationRequest{})
+	if err != nil {
+		return nil, err
+	}
+	epochLayers, err := s.clients.mesh.EpochNumLayers(ctx, &pb.EpochNumLayersRequest{})
+	if err != nil {
+		return nil, err
+	}
+	s.params = &networkParams{
+		network:        networkName(netID.Netid.GetValue()),
+		genesisTime:    int64(genesis.Unixtime.GetValue()),
+		layerDuration:  int64(duration.Duration.GetValue()),
+		layersPerEpoch: epochLayers.Numlayers.GetValue(),
+	}
+	return s.params, nil
+}
+
+// networkName returns the name of the network with the given id in network identifiers
+func networkName(id uint64) string {
+	switch id {
+	case 0:
+		return "mainnet"
+	case 1:
+		return "testnet"
+	}
+	return fmt.Sprintf("network-%d", id)
+}
+
+// clients are the grpc clients of the services the endpoints use
+type clients struct {
+	node           pb.NodeServiceClient
+	mesh           pb.MeshServiceClient
+	extMesh        extpb.MeshServiceClient
+	globalState    pb.GlobalStateServiceClient
+	extGlobalState extpb.GlobalStateServiceClient
+	tx             pb.TransactionServiceClient
+	extTx          extpb.TransactionServiceClient
+}
+
+func newClients(conn *grpc.ClientConn) *clients {
+	return &clients{
+		node:           pb.NewNodeServiceClient(conn),
+		mesh:           pb.NewMeshServiceClient(conn),
+		extMesh:        extpb.NewMeshServiceClient(conn),
+		globalState:    pb.NewGlobalStateServiceClient(conn),
+		extGlobalState: extpb.NewGlobalStateServiceClient(conn),
+		tx:             pb.NewTransactionServiceClient(conn),
+		extTx:          extpb.NewTransactionServiceClient(conn),
+	}
+}
@@ -0,0 +1,267 @@
+package rosetta
+
+// The objects of the Rosetta API specification used by this server, see https://www.rosetta-api.org/docs/Reference.html.
+// Fields the server doesn't support are left out, they're ignored in requests.
+
+// NetworkIdentifier identifies the network a request is sent to
+type NetworkIdentifier struct {
+	Blockchain string `json:"blockchain"`
+	Network    string `json:"network"`
+}
+
+// BlockIdentifier identifies a block, blocks are the layers of the mesh
+type BlockIdentifier struct {
+	Index int64  `json:"index"`
+	Hash  string `json:"hash"`
+}
+
+// PartialBlockIdentifier identifies a block by index or hash, the latest block if neither is set
+type PartialBlockIdentifier struct {
+	Index *int64  `json:"index,omitempty"`
+	Hash  *string `json:"hash,omitempty"`
+}
+
+// TransactionIdentifier identifies a transaction
+type TransactionIdentifier struct {
+	Hash string `json:"hash"`
+}
+
+// AccountIdentifier identifies an account by its address
+type AccountIdentifier struct {
+	Address    string      `json:"address"`
+	SubAccount interface{} `json:"sub_account,omitempty"`
+}
+
+// Currency is the currency of amounts, always SMH
+type Currency struct {
+	Symbol   string `json:"symbol"`
+	Decimals int32  `json:"decimals"`
+}
+
+// Amount is a signed amount of a currency, in its smallest unit
+type Amount struct {
+	Value    string    `json:"value"`
+	Currency *Currency `json:"currency"`
+}
+
+// OperationIdentifier identifies an operation within a transaction
+type OperationIdentifier struct {
+	Index int64 `json:"index"`
+}
+
+// Operation is a change of the balance of a single account
+type Operation struct {
+	OperationIdentifier *OperationIdentifier   `json:"operation_identifier"`
+	RelatedOperations   []*OperationIdentifier `json:"related_operations,omitempty"`
+	Type                string                 `json:"type"`
+	Status              *string                `json:"status,omitempty"`
+	Account             *AccountIdentifier     `json:"account,omitempty"`
+	Amount              *Amount                `json:"amount,omitempty"`
+}
+
+// Transaction is a set of operations that are applied together
+type Transaction struct {
+	TransactionIdentifier *TransactionIdentifier `json:"transaction_identifier"`
+	Operations            []*Operation           `json:"operations"`
+}
+
+// Block is a layer of the mesh that was applied to the global state
+type Block struct {
+	BlockIdentifier       *BlockIdentifier `json:"block_identifier"`
+	ParentBlockIdentifier *BlockIdentifier `json:"parent_block_identifier"`
+	Timestamp             int64            `json:"timestamp"` // unix time in milliseconds
+	Transactions          []*Transaction   `json:"transactions"`
+}
+
+// PublicKey is a public key of a curve
+type PublicKey struct {
+	HexBytes  string `json:"hex_bytes"`
+	CurveType string `json:"curve_type"`
+}
+
+// SigningPayload is a payload that must be signed by an account
+type SigningPayload struct {
+	AccountIdentifier *AccountIdentifier `json:"account_identifier"`
+	HexBytes          string             `json:"hex_bytes"`
+	SignatureType     string             `json:"signature_type"`
+}
+
+// Signature is a signature of a payload
+type Signature struct {
+	SigningPayload *SigningPayload `json:"signing_payload"`
+	PublicKey      *PublicKey      `json:"public_key"`
+	SignatureType  string          `json:"signature_type"`
+	HexBytes       string          `json:"hex_bytes"`
+}
+
+// Version describes the versions of the api and the node
+type Version struct {
+	RosettaVersion string `json:"rosetta_version"`
+	NodeVersion    string `json:"node_version"`
+}
+
+// OperationStatus is a status of operations, and whether it changes balances
+type OperationStatus struct {
+	Status     string `json:"status"`
+	Successful bool   `json:"successful"`
+}
+
+// Allow describes the statuses, types and errors of the server
+type Allow struct {
+	OperationStatuses       []*OperationStatus `json:"operation_statuses"`
+	OperationTypes          []string           `json:"operation_types"`
+	Errors                  []*Error           `json:"errors"`
+	HistoricalBalanceLookup bool               `json:"historical_balance_lookup"`
+}
+
+// SyncStatus describes the progress of the node syncing the mesh
+type SyncStatus struct {
+	CurrentIndex int64 `json:"current_index"`
+	TargetIndex  int64 `json:"target_index"`
+	Synced       bool  `json:"synced"`
+}
+
+// Peer is a peer of the node
+type Peer struct {
+	PeerID string `json:"peer_id"`
+}
+
+type networkRequest struct {
+	NetworkIdentifier *NetworkIdentifier `json:"network_identifier"`
+}
+
+type networkListResponse struct {
+	NetworkIdentifiers []*NetworkIdentifier `json:"network_identifiers"`
+}
+
+type networkOptionsResponse struct {
+	Version *Version `json:"version"`
+	Allow   *Allow   `json:"allow"`
+}
+
+type networkStatusResponse struct {
+	CurrentBlockIdentifier *BlockIdentifier `json:"current_block_identifier"`
+	CurrentBlockTimestamp  int64            `json:"current_block_timestamp"`
+	GenesisBlockIdentifier *BlockIdentifier `json:"genesis_block_identifier"`
+	SyncStatus             *SyncStatus      `json:"sync_status"`
+	Peers                  []*Peer          `json:"peers"`
+}
+
+type accountBalanceRequest struct {
+	NetworkIdentifier *NetworkIdentifier      `json:"network_identifier"`
+	AccountIdentifier *AccountIdentifier      `json:"account_identifier"`
+	BlockIdentifier   *PartialBlockIdentifier `json:"block_identifier"`
+}
+
+type accountBalanceResponse struct {
+	BlockIdentifier *BlockIdentifier       `json:"block_identifier"`
+	Balances        []*Amount              `json:"balances"`
+	Metadata        map[string]interface{} `json:"metadata"`
+}
+
+type blockRequest struct {
+	NetworkIdentifier *NetworkIdentifier      `json:"network_identifier"`
+	BlockIdentifier   *PartialBlockIdentifier `json:"block_identifier"`
+}
+
+type blockResponse struct {
+	Block *Block `json:"block"`
+}
+
+type blockTransactionRequest struct {
+	NetworkIdentifier     *NetworkIdentifier     `json:"network_identifier"`
+	BlockIdentifier       *BlockIdentifier       `json:"block_identifier"`
+	TransactionIdentifier *TransactionIdentifier `json:"transaction_identifier"`
+}
+
+type transactionResponse struct {
+	Transaction *Transaction `json:"transaction"`
+}
+
+type mempoolResponse struct {
+	TransactionIdentifiers []*TransactionIdentifier `json:"transaction_identifiers"`
+}
+
+type mempoolTransactionRequest struct {
+	NetworkIdentifier     *NetworkIdentifier     `json:"network_identifier"`
+	TransactionIdentifier *TransactionIdentifier `json:"transaction_identifier"`
+}
+
+type deriveRequest struct {
+	NetworkIdentifier *NetworkIdentifier `json:"network_identifier"`
+	PublicKey         *PublicKey         `json:"public_key"`
+}
+
+type deriveResponse struct {
+	AccountIdentifier *AccountIdentifier `json:"account_identifier"`
+}
+
+type preprocessRequest struct {
+	NetworkIdentifier *NetworkIdentifier `json:"network_identifier"`
+	Operations        []*Operation       `json:"operations"`
+}
+
+type preprocessResponse struct {
+	Options            map[string]interface{} `json:"options"`
+	RequiredPublicKeys []*AccountIdentifier   `json:"required_public_keys"`
+}
+
+type metadataRequest struct {
+	NetworkIdentifier *NetworkIdentifier `json:"network_identifier"`
+	Options           struct {
+		Sender string `json:"sender"`
+	} `json:"options"`
+}
+
+// constructionMetadata is the metadata returned by /construction/metadata and passed to /construction/payloads
+type constructionMetadata struct {
+	Nonce    uint64 `json:"nonce"`
+	Fee      uint64 `json:"fee"`
+	GasLimit uint64 `json:"gas_limit"`
+}
+
+type metadataResponse struct {
+	Metadata     *constructionMetadata `json:"metadata"`
+	SuggestedFee []*Amount             `json:"suggested_fee"`
+}
+
+type payloadsRequest struct {
+	NetworkIdentifier *NetworkIdentifier    `json:"network_identifier"`
+	Operations        []*Operation          `json:"operations"`
+	Metadata          *constructionMetadata `json:"metadata"`
+}
+
+type payloadsResponse struct {
+	UnsignedTransaction string            `json:"unsigned_transaction"`
+	Payloads            []*SigningPayload `json:"payloads"`
+}
+
+type combineRequest struct {
+	NetworkIdentifier   *NetworkIdentifier `json:"network_identifier"`
+	UnsignedTransaction string             `json:"unsigned_transaction"`
+	Signatures          []*Signature       `json:"signatures"`
+}
+
+type combineResponse struct {
+	SignedTransaction string `json:"signed_transaction"`
+}
+
+type parseRequest struct {
+	NetworkIdentifier *NetworkIdentifier `json:"network_identifier"`
+	Signed            bool               `json:"signed"`
+	Transaction       string             `json:"transaction"`
+}
+
+type parseResponse struct {
+	Operations               []*Operation         `json:"operations"`
+	AccountIdentifierSigners []*AccountIdentifier `json:"account_identifier_signers"`
+}
+
+type signedTransactionRequest struct {
+	NetworkIdentifier *NetworkIdentifier `json:"network_identifier"`
+	SignedTransaction string             `json:"signed_transaction"`
+}
+
+type transactionIdentifierResponse struct {
+	TransactionIdentifier *TransactionIdentifier `json:"transaction_identifier"`
+}
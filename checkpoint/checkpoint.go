@@ -0,0 +1,101 @@
+// Package checkpoint creates snapshots of the accounts and recent ATXs of a node at a verified layer, which let a node
+// recover or bootstrap without replaying the mesh from genesis.
+package checkpoint
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+// ErrHashMismatch is returned when decoding a checkpoint whose content doesn't match its hash
+var ErrHashMismatch = errors.New("checkpoint hash mismatch")
+
+type stateReader interface {
+	GetLayerStateRoot(layer types.LayerID) (types.Hash32, error)
+	IterateAccounts(root types.Hash32, fn func(addr types.Address, nonce, balance uint64) error) error
+}
+
+type atxProvider interface {
+	GetEpochAtxs(epochID types.EpochID) []types.ATXID
+	GetFullAtx(id types.ATXID) (*types.ActivationTx, error)
+}
+
+// Account is the state of a single account in a checkpoint
+type Account struct {
+	Address types.Address
+	Nonce   uint64
+	Balance uint64
+}
+
+// Checkpoint is a snapshot of the global state after applying a verified layer, along with the ATXs of the epoch of
+// that layer and of the previous epoch, which are needed to validate the blocks and ATXs that follow it
+type Checkpoint struct {
+	Layer     types.LayerID
+	StateRoot types.Hash32
+	Accounts  []Account
+	Atxs      []*types.ActivationTx
+}
+
+// Generate creates a checkpoint of the global state and recent ATXs at the given layer. The layer must already be
+// applied to the global state.
+func Generate(layer types.LayerID, state stateReader, atxs atxProvider) (*Checkpoint, error) {
+	root, err := state.GetLayerStateRoot(layer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get state root of layer %v: %v", layer, err)
+	}
+	cp := &Checkpoint{Layer: layer, StateRoot: root}
+	err = state.IterateAccounts(root, func(addr types.Address, nonce, balance uint64) error {
+		cp.Accounts = append(cp.Accounts, Account{Address: addr, Nonce: nonce, Balance: balance})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read accounts of state root %v: %v", root.ShortString(), err)
+	}
+
+	epoch := layer.GetEpoch()
+	epochs := []types.EpochID{epoch}
+	if epoch > 0 {
+		epochs = []types.EpochID{epoch - 1, epoch}
+	}
+	for _, e := range epochs {
+		for _, id := range atxs.GetEpochAtxs(e) {
+			atx, err := atxs.GetFullAtx(id)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get atx %v: %v", id.ShortString(), err)
+			}
+			cp.Atxs = append(cp.Atxs, atx)
+		}
+	}
+	return cp, nil
+}
+
+// Encode serializes the checkpoint followed by the hash of the serialized data
+func (c *Checkpoint) Encode() ([]byte, error) {
+	data, err := types.InterfaceToBytes(c)
+	if err != nil {
+		return nil, err
+	}
+	hash := types.CalcHash32(data)
+	return append(data, hash.Bytes()...), nil
+}
+
+// Decode deserializes a checkpoint created by Encode and verifies its hash
+func Decode(b []byte) (*Checkpoint, error) {
+	if len(b) < types.Hash32Length {
+		return nil, errors.New("checkpoint too short")
+	}
+	data, hash := b[:len(b)-types.Hash32Length], types.BytesToHash(b[len(b)-types.Hash32Length:])
+	if types.CalcHash32(data) != hash {
+		return nil, ErrHashMismatch
+	}
+	var c Checkpoint
+	if err := types.BytesToInterface(data, &c); err != nil {
+		return nil, fmt.Errorf("malformed checkpoint: %v", err)
+	}
+	for _, atx := range c.Atxs {
+		atx.CalcAndSetID()
+	}
+	return &c, nil
+}
@@ -0,0 +1,122 @@
+package checkpoint
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/stretchr/testify/require"
+)
+
+type stateMock struct {
+	roots    map[types.LayerID]types.Hash32
+	accounts []Account
+}
+
+func (s *stateMock) GetLayerStateRoot(layer types.LayerID) (types.Hash32, error) {
+	root, ok := s.roots[layer]
+	if !ok {
+		return types.Hash32{}, errors.New("unknown layer")
+	}
+	return root, nil
+}
+
+func (s *stateMock) IterateAccounts(root types.Hash32, fn func(addr types.Address, nonce, balance uint64) error) error {
+	for _, a := range s.accounts {
+		if err := fn(a.Address, a.Nonce, a.Balance); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type atxMock map[types.EpochID][]*types.ActivationTx
+
+func (m atxMock) GetEpochAtxs(epochID types.EpochID) (ids []types.ATXID) {
+	for _, atx := range m[epochID] {
+		ids = append(ids, atx.ID())
+	}
+	return ids
+}
+
+func (m atxMock) GetFullAtx(id types.ATXID) (*types.ActivationTx, error) {
+	for _, atxs := range m {
+		for _, atx := range atxs {
+			if atx.ID() == id {
+				return atx, nil
+			}
+		}
+	}
+	return nil, errors.New("atx not found")
+}
+
+func newAtx(nodeID string, pubLayer types.LayerID) *types.ActivationTx {
+	challenge := types.NIPSTChallenge{NodeID: types.NodeID{Key: nodeID}, PubLayerID: pubLayer}
+	atx := types.NewActivationTx(challenge, types.HexToAddress("aaaa"), &types.NIPST{}, nil)
+	atx.Sig = []byte("sig")
+	return atx
+}
+
+func TestGenerate(t *testing.T) {
+	r := require.New(t)
+	types.SetLayersPerEpoch(3)
+
+	state := &stateMock{
+		roots: map[types.LayerID]types.Hash32{7: types.HexToHash32("1234")},
+		accounts: []Account{
+			{Address: types.HexToAddress("1"), Nonce: 1, Balance: 100},
+			{Address: types.HexToAddress("2"), Nonce: 0, Balance: 5},
+		},
+	}
+	atxs := atxMock{
+		0: {newAtx("a", 1)},
+		1: {newAtx("b", 3), newAtx("c", 4)},
+		2: {newAtx("d", 6)},
+	}
+
+	cp, err := Generate(7, state, atxs)
+	r.NoError(err)
+	r.Equal(types.LayerID(7), cp.Layer)
+	r.Equal(types.HexToHash32("1234"), cp.StateRoot)
+	r.Equal(state.accounts, cp.Accounts)
+	// only the atxs of the epoch of the layer and of the previous epoch are included
+	r.Len(cp.Atxs, 3)
+	r.Equal(atxs[1][0].ID(), cp.Atxs[0].ID())
+	r.Equal(atxs[1][1].ID(), cp.Atxs[1].ID())
+	r.Equal(atxs[2][0].ID(), cp.Atxs[2].ID())
+
+	_, err = Generate(8, state, atxs)
+	r.Error(err)
+}
+
+func TestCheckpoint_EncodeDecode(t *testing.T) {
+	r := require.New(t)
+
+	cp := &Checkpoint{
+		Layer:     12,
+		StateRoot: types.HexToHash32("abcd"),
+		Accounts:  []Account{{Address: types.HexToAddress("1"), Nonce: 3, Balance: 1000}},
+		Atxs:      []*types.ActivationTx{newAtx("a", 10), newAtx("b", 11)},
+	}
+	b, err := cp.Encode()
+	r.NoError(err)
+
+	decoded, err := Decode(b)
+	r.NoError(err)
+	r.Equal(cp.Layer, decoded.Layer)
+	r.Equal(cp.StateRoot, decoded.StateRoot)
+	r.Equal(cp.Accounts, decoded.Accounts)
+	r.Len(decoded.Atxs, 2)
+	for i, atx := range decoded.Atxs {
+		r.Equal(cp.Atxs[i].ID(), atx.ID())
+		r.Equal(cp.Atxs[i].Sig, atx.Sig)
+	}
+
+	// any change of the content is detected
+	b[10]++
+	_, err = Decode(b)
+	r.Equal(ErrHashMismatch, err)
+
+	_, err = Decode(b[:types.Hash32Length-1])
+	r.Error(err)
+}
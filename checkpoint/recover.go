@@ -0,0 +1,91 @@
+package checkpoint
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+// ErrStateRootMismatch is returned when the restored accounts don't add up to the state root of the checkpoint
+var ErrStateRootMismatch = errors.New("restored state root doesn't match the checkpoint")
+
+// downloadTimeout limits the time it takes to download a checkpoint
+const downloadTimeout = 10 * time.Minute
+
+type stateWriter interface {
+	SetNonce(addr types.Address, nonce uint64)
+	SetBalance(addr types.Address, amount *big.Int)
+	CommitLayer(layer types.LayerID) (types.Hash32, error)
+}
+
+type atxStore interface {
+	StoreAtx(ech types.EpochID, atx *types.ActivationTx) error
+}
+
+type layerRestorer interface {
+	RestoreCheckpointLayer(layer types.LayerID)
+}
+
+// Load reads a checkpoint file from a local path or an http(s) URL and decodes it. If hash isn't empty the sha256 of
+// the file must match it. It returns the sha256 of the file along with the checkpoint.
+func Load(uri string, hash []byte) (*Checkpoint, types.Hash32, error) {
+	data, err := read(uri)
+	if err != nil {
+		return nil, types.Hash32{}, fmt.Errorf("failed to read checkpoint from %v: %v", uri, err)
+	}
+	fileHash := types.CalcHash32(data)
+	if len(hash) > 0 && !bytes.Equal(hash, fileHash.Bytes()) {
+		return nil, types.Hash32{}, fmt.Errorf("checkpoint from %v has hash %x, expected %x", uri, fileHash.Bytes(), hash)
+	}
+	cp, err := Decode(data)
+	if err != nil {
+		return nil, types.Hash32{}, err
+	}
+	return cp, fileHash, nil
+}
+
+func read(uri string) ([]byte, error) {
+	if !strings.HasPrefix(uri, "http://") && !strings.HasPrefix(uri, "https://") {
+		return ioutil.ReadFile(strings.TrimPrefix(uri, "file://"))
+	}
+	client := http.Client{Timeout: downloadTimeout}
+	res, err := client.Get(uri)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %v", res.Status)
+	}
+	return ioutil.ReadAll(res.Body)
+}
+
+// Restore writes the accounts and ATXs of the checkpoint to an empty global state and ATX database, verifies that the
+// accounts add up to the state root of the checkpoint and sets the checkpoint layer as the latest layer of the mesh
+func Restore(cp *Checkpoint, state stateWriter, atxs atxStore, msh layerRestorer) error {
+	for _, acc := range cp.Accounts {
+		state.SetNonce(acc.Address, acc.Nonce)
+		state.SetBalance(acc.Address, new(big.Int).SetUint64(acc.Balance))
+	}
+	root, err := state.CommitLayer(cp.Layer)
+	if err != nil {
+		return fmt.Errorf("failed to commit restored state: %v", err)
+	}
+	if root != cp.StateRoot {
+		return ErrStateRootMismatch
+	}
+	for _, atx := range cp.Atxs {
+		if err := atxs.StoreAtx(atx.PubLayerID.GetEpoch(), atx); err != nil {
+			return fmt.Errorf("failed to store atx %v: %v", atx.ShortString(), err)
+		}
+	}
+	msh.RestoreCheckpointLayer(cp.Layer)
+	return nil
+}
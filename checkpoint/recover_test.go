@@ -0,0 +1,119 @@
+package checkpoint
+
+import (
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/database"
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/state"
+	"github.com/stretchr/testify/require"
+)
+
+type atxStoreMock map[types.ATXID]*types.ActivationTx
+
+func (m atxStoreMock) StoreAtx(_ types.EpochID, atx *types.ActivationTx) error {
+	m[atx.ID()] = atx
+	return nil
+}
+
+type meshMock struct {
+	layer types.LayerID
+}
+
+func (m *meshMock) RestoreCheckpointLayer(layer types.LayerID) {
+	m.layer = layer
+}
+
+func newProcessor() *state.TransactionProcessor {
+	return state.NewTransactionProcessor(database.NewMemDatabase(), database.NewMemDatabase(), nil, state.NewTxMemPool(), log.New("checkpoint", "", ""))
+}
+
+func TestLoad(t *testing.T) {
+	r := require.New(t)
+
+	cp := &Checkpoint{Layer: 5, StateRoot: types.HexToHash32("ab"), Accounts: []Account{{Address: types.HexToAddress("1"), Balance: 10}}}
+	data, err := cp.Encode()
+	r.NoError(err)
+	hash := types.CalcHash32(data)
+
+	dir, err := ioutil.TempDir("", "checkpoint")
+	r.NoError(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "checkpoint")
+	r.NoError(ioutil.WriteFile(path, data, 0600))
+
+	loaded, loadedHash, err := Load(path, hash.Bytes())
+	r.NoError(err)
+	r.Equal(hash, loadedHash)
+	r.Equal(cp.Accounts, loaded.Accounts)
+
+	_, _, err = Load("file://"+path, nil)
+	r.NoError(err)
+
+	// the checkpoint must have the expected hash
+	_, _, err = Load(path, types.HexToHash32("12").Bytes())
+	r.Error(err)
+
+	_, _, err = Load(filepath.Join(dir, "missing"), nil)
+	r.Error(err)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/checkpoint" {
+			http.NotFound(w, req)
+			return
+		}
+		_, _ = w.Write(data)
+	}))
+	defer srv.Close()
+
+	loaded, loadedHash, err = Load(srv.URL+"/checkpoint", hash.Bytes())
+	r.NoError(err)
+	r.Equal(hash, loadedHash)
+	r.Equal(cp.Layer, loaded.Layer)
+
+	_, _, err = Load(srv.URL+"/missing", nil)
+	r.Error(err)
+}
+
+func TestRestore(t *testing.T) {
+	r := require.New(t)
+	types.SetLayersPerEpoch(3)
+
+	src := newProcessor()
+	for i := 1; i <= 10; i++ {
+		addr := types.BytesToAddress([]byte{byte(i)})
+		src.SetNonce(addr, uint64(i))
+		src.SetBalance(addr, big.NewInt(int64(i*1000)))
+	}
+	root, err := src.CommitLayer(7)
+	r.NoError(err)
+	atxs := atxMock{2: {newAtx("a", 6)}}
+	cp, err := Generate(7, src, atxs)
+	r.NoError(err)
+
+	dst := newProcessor()
+	stored := atxStoreMock{}
+	msh := &meshMock{}
+	r.NoError(Restore(cp, dst, stored, msh))
+	r.Equal(root, dst.GetStateRoot())
+	layerRoot, err := dst.GetLayerStateRoot(7)
+	r.NoError(err)
+	r.Equal(root, layerRoot)
+	r.Equal(uint64(5000), dst.GetBalance(types.BytesToAddress([]byte{5})))
+	r.Contains(stored, atxs[2][0].ID())
+	r.Equal(types.LayerID(7), msh.layer)
+
+	// accounts that don't add up to the state root are rejected
+	cp.Accounts[0].Balance++
+	msh = &meshMock{}
+	err = Restore(cp, newProcessor(), atxStoreMock{}, msh)
+	r.Equal(ErrStateRootMismatch, err)
+	r.Equal(types.LayerID(0), msh.layer)
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	pb "github.com/spacemeshos/api/release/go/spacemesh/v1"
+	"github.com/spacemeshos/go-spacemesh/api/extpb"
+	apipb "github.com/spacemeshos/go-spacemesh/api/pb"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+type nodeMock struct {
+	pb.UnimplementedNodeServiceServer
+}
+
+func (nodeMock) Status(ctx context.Context, in *pb.StatusRequest) (*pb.StatusResponse, error) {
+	return &pb.StatusResponse{Status: &pb.NodeStatus{ConnectedPeers: 3, TopLayer: 10}}, nil
+}
+
+type globalStateMock struct {
+	pb.UnimplementedGlobalStateServiceServer
+}
+
+func (globalStateMock) Account(ctx context.Context, in *pb.AccountRequest) (*pb.AccountResponse, error) {
+	return &pb.AccountResponse{Account: &pb.Account{
+		Address: in.AccountId,
+		Counter: 2,
+		Balance: &pb.Amount{Value: 100},
+	}}, nil
+}
+
+type legacyMock struct {
+	apipb.UnimplementedSpacemeshServiceServer
+	coinbase string
+}
+
+func (m *legacyMock) SetAwardsAddress(ctx context.Context, in *apipb.AccountId) (*apipb.SimpleMessage, error) {
+	m.coinbase = in.Address
+	return &apipb.SimpleMessage{Value: "ok"}, nil
+}
+
+type eventsMock struct {
+	extpb.UnimplementedEventServiceServer
+}
+
+// SubscribeEvents sends an account event for every account of the first filter, and closes the stream
+func (eventsMock) SubscribeEvents(stream extpb.EventService_SubscribeEventsServer) error {
+	in, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	for _, account := range in.Filter.Accounts {
+		err := stream.Send(&extpb.SubscribeEventsResponse{Event: &extpb.SubscribeEventsResponse_Account{
+			Account: &extpb.Account{AccountId: account, Balance: 5},
+		}})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type adminMock struct {
+	extpb.UnimplementedAdminServiceServer
+	bans []*extpb.BanEntry
+}
+
+func (m *adminMock) Ban(ctx context.Context, in *extpb.BanRequest) (*extpb.BanResponse, error) {
+	m.bans = append(m.bans, &extpb.BanEntry{Target: in.Target, Expires: in.Duration})
+	return &extpb.BanResponse{}, nil
+}
+
+func (m *adminMock) Unban(ctx context.Context, in *extpb.UnbanRequest) (*extpb.UnbanResponse, error) {
+	for i, ban := range m.bans {
+		if proto.Equal(ban.Target, in.Target) {
+			m.bans = append(m.bans[:i], m.bans[i+1:]...)
+			return &extpb.UnbanResponse{Found: true}, nil
+		}
+	}
+	return &extpb.UnbanResponse{}, nil
+}
+
+func (m *adminMock) ListBans(ctx context.Context, in *extpb.ListBansRequest) (*extpb.ListBansResponse, error) {
+	return &extpb.ListBansResponse{Bans: m.bans}, nil
+}
+
+// Export sends a transaction of every layer in the range, in a response per layer
+func (*adminMock) Export(in *extpb.ExportRequest, stream extpb.AdminService_ExportServer) error {
+	for layer := in.StartLayer; layer <= in.EndLayer; layer++ {
+		err := stream.Send(&extpb.ExportResponse{Transactions: []*extpb.ExportedTransaction{
+			{Id: []byte{byte(layer)}, Layer: layer, Amount: 10 * layer},
+		}})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func launchServer(t *testing.T, legacy *legacyMock) (string, func()) {
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	server := grpc.NewServer()
+	pb.RegisterNodeServiceServer(server, &nodeMock{})
+	pb.RegisterGlobalStateServiceServer(server, &globalStateMock{})
+	apipb.RegisterSpacemeshServiceServer(server, legacy)
+	extpb.RegisterEventServiceServer(server, &eventsMock{})
+	extpb.RegisterAdminServiceServer(server, &adminMock{})
+	go server.Serve(lis)
+	return lis.Addr().String(), server.Stop
+}
+
+// resetFlags restores the flags of c and its subcommands to their defaults, since they keep their values between runs
+func resetFlags(t *testing.T, c *cobra.Command) {
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		if v, ok := f.Value.(pflag.SliceValue); ok {
+			require.NoError(t, v.Replace(nil))
+		} else {
+			require.NoError(t, f.Value.Set(f.DefValue))
+		}
+		f.Changed = false
+	})
+	for _, sub := range c.Commands() {
+		resetFlags(t, sub)
+	}
+}
+
+func run(t *testing.T, addr string, args ...string) (string, error) {
+	resetFlags(t, cmd)
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetArgs(append(args, "--grpc-server", addr, "--legacy-grpc-server", addr))
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func TestCli(t *testing.T) {
+	legacy := &legacyMock{}
+	addr, shutDown := launchServer(t, legacy)
+	defer shutDown()
+
+	out, err := run(t, addr, "status")
+	require.NoError(t, err)
+	require.JSONEq(t, `{"status":{"connectedPeers":3,"isSynced":false,"syncedLayer":0,"topLayer":10,"verifiedLayer":0}}`, out)
+
+	out, err = run(t, addr, "balance", "0x0102")
+	require.NoError(t, err)
+	require.JSONEq(t, `{"address":{"address":"0x0102"},"counter":2,"balance":{"value":100}}`, out)
+
+	out, err = run(t, addr, "smeshing", "set-coinbase", "0x0a0b")
+	require.NoError(t, err)
+	require.Equal(t, "coinbase set to 0x0a0b\n", out)
+	require.Equal(t, "0x0a0b", legacy.coinbase)
+
+	out, err = run(t, addr, "watch", "--account", "0x01", "--account", "02")
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	require.Len(t, lines, 2)
+	require.JSONEq(t, `{"account":{"accountId":"0x01","counter":0,"balance":5}}`, lines[0])
+	require.JSONEq(t, `{"account":{"accountId":"0x02","counter":0,"balance":5}}`, lines[1])
+
+	out, err = run(t, addr, "export", "transactions", "--start-layer", "3", "--end-layer", "4")
+	require.NoError(t, err)
+	require.Equal(t, "id,layer,origin,recipient,amount,fee,nonce,gas_limit\n"+
+		"0x03,3,0x,0x,30,0,0,0\n"+
+		"0x04,4,0x,0x,40,0,0,0\n", out)
+
+	out, err = run(t, addr, "ban", "1.2.3.4", "--duration", "1h")
+	require.NoError(t, err)
+	require.Equal(t, "banned 1.2.3.4\n", out)
+	_, err = run(t, addr, "ban", "0x0a0b")
+	require.NoError(t, err)
+	out, err = run(t, addr, "bans")
+	require.NoError(t, err)
+	require.JSONEq(t, `{"bans":[{"target":{"peerId":"0x","ip":"1.2.3.4"},"created":0,"expires":3600},`+
+		`{"target":{"peerId":"0x0a0b","ip":""},"created":0,"expires":0}]}`, out)
+	out, err = run(t, addr, "unban", "1.2.3.4")
+	require.NoError(t, err)
+	require.Equal(t, "unbanned 1.2.3.4\n", out)
+	_, err = run(t, addr, "unban", "1.2.3.4")
+	require.Error(t, err)
+
+	// services that aren't served fail
+	_, err = run(t, addr, "peers")
+	require.Error(t, err)
+	_, err = run(t, addr, "network")
+	require.Error(t, err)
+}
+
+func TestCli_InvalidArgs(t *testing.T) {
+	for _, args := range [][]string{
+		{"balance", "0xzz"},
+		{"balance"},
+		{"tx", "submit", ""},
+		{"watch"},
+		{"watch", "--smesher", "xyz"},
+		{"export", "blocks"},
+		{"ban", "1.2.3"},
+		{"ban", "1.2.3.4", "--duration", "1ms"},
+		{"unban"},
+		{"smeshing", "start", "--datadir", "/tmp", "--space", "1024"},
+	} {
+		// nothing is listening on the address, invalid arguments must fail before connecting
+		_, err := run(t, "localhost:0", args...)
+		require.Error(t, err, args)
+		require.NotContains(t, err.Error(), "failed to connect", args)
+	}
+}
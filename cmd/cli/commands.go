@@ -0,0 +1,376 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	pb "github.com/spacemeshos/api/release/go/spacemesh/v1"
+	"github.com/spacemeshos/go-spacemesh/api/extpb"
+	apipb "github.com/spacemeshos/go-spacemesh/api/pb"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the sync status, peers and layers of the node",
+	Args:  cobra.NoArgs,
+	RunE: func(c *cobra.Command, args []string) error {
+		return call(grpcServer, func(ctx context.Context, conn *grpc.ClientConn) error {
+			res, err := pb.NewNodeServiceClient(conn).Status(ctx, &pb.StatusRequest{})
+			if err != nil {
+				return err
+			}
+			return printMessage(c, res)
+		})
+	},
+}
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Show the version and build of the node",
+	Args:  cobra.NoArgs,
+	RunE: func(c *cobra.Command, args []string) error {
+		return call(grpcServer, func(ctx context.Context, conn *grpc.ClientConn) error {
+			client := pb.NewNodeServiceClient(conn)
+			version, err := client.Version(ctx, &empty.Empty{})
+			if err != nil {
+				return err
+			}
+			build, err := client.Build(ctx, &empty.Empty{})
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintf(c.OutOrStdout(), "%v (%v)\n", version.VersionString.GetValue(), build.BuildString.GetValue())
+			return err
+		})
+	},
+}
+
+var networkCmd = &cobra.Command{
+	Use:   "network",
+	Short: "Show the addresses, peers and routing table of the node (requires the debug service)",
+	Args:  cobra.NoArgs,
+	RunE: func(c *cobra.Command, args []string) error {
+		return call(grpcServer, func(ctx context.Context, conn *grpc.ClientConn) error {
+			res, err := extpb.NewDebugServiceClient(conn).NetworkInfo(ctx, &extpb.NetworkInfoRequest{})
+			if err != nil {
+				return err
+			}
+			return printMessage(c, res)
+		})
+	},
+}
+
+var peersCmd = &cobra.Command{
+	Use:   "peers",
+	Short: "Show the connected peers and the details of their connections (requires the debug service)",
+	Args:  cobra.NoArgs,
+	RunE: func(c *cobra.Command, args []string) error {
+		return call(grpcServer, func(ctx context.Context, conn *grpc.ClientConn) error {
+			res, err := extpb.NewDebugServiceClient(conn).Peers(ctx, &extpb.PeersRequest{})
+			if err != nil {
+				return err
+			}
+			return printMessage(c, res)
+		})
+	},
+}
+
+var banCmd = &cobra.Command{
+	Use:   "ban <peer-id|ip>",
+	Short: "Ban a peer ID (in hex) or an IP address and close the connections to it (requires the admin service)",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(c *cobra.Command, args []string) error {
+		target, err := parseBanTarget(args[0])
+		if err != nil {
+			return err
+		}
+		duration, _ := c.Flags().GetDuration("duration")
+		if duration < 0 || (duration > 0 && duration < time.Second) {
+			return fmt.Errorf("invalid duration %v, expected at least a second or 0 to ban forever", duration)
+		}
+		return call(grpcServer, func(ctx context.Context, conn *grpc.ClientConn) error {
+			req := &extpb.BanRequest{Target: target, Duration: uint64(duration / time.Second)}
+			if _, err := extpb.NewAdminServiceClient(conn).Ban(ctx, req); err != nil {
+				return err
+			}
+			_, err := fmt.Fprintln(c.OutOrStdout(), "banned", args[0])
+			return err
+		})
+	},
+}
+
+var unbanCmd = &cobra.Command{
+	Use:   "unban <peer-id|ip>",
+	Short: "Lift the ban of a peer ID (in hex) or an IP address (requires the admin service)",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(c *cobra.Command, args []string) error {
+		target, err := parseBanTarget(args[0])
+		if err != nil {
+			return err
+		}
+		return call(grpcServer, func(ctx context.Context, conn *grpc.ClientConn) error {
+			res, err := extpb.NewAdminServiceClient(conn).Unban(ctx, &extpb.UnbanRequest{Target: target})
+			if err != nil {
+				return err
+			}
+			if !res.Found {
+				return fmt.Errorf("%v isn't banned", args[0])
+			}
+			_, err = fmt.Fprintln(c.OutOrStdout(), "unbanned", args[0])
+			return err
+		})
+	},
+}
+
+var bansCmd = &cobra.Command{
+	Use:   "bans",
+	Short: "Show the banned peer IDs and IP addresses (requires the admin service)",
+	Args:  cobra.NoArgs,
+	RunE: func(c *cobra.Command, args []string) error {
+		return call(grpcServer, func(ctx context.Context, conn *grpc.ClientConn) error {
+			res, err := extpb.NewAdminServiceClient(conn).ListBans(ctx, &extpb.ListBansRequest{})
+			if err != nil {
+				return err
+			}
+			return printMessage(c, res)
+		})
+	},
+}
+
+// parseBanTarget parses an IP address or a peer ID in hex
+func parseBanTarget(arg string) (*extpb.BanTarget, error) {
+	if ip := net.ParseIP(arg); ip != nil {
+		return &extpb.BanTarget{Ip: ip.String()}, nil
+	}
+	id, err := parseHex("peer id or ip", arg)
+	if err != nil {
+		return nil, err
+	}
+	return &extpb.BanTarget{PeerId: id}, nil
+}
+
+var balanceCmd = &cobra.Command{
+	Use:   "balance <address>",
+	Short: "Show the balance and counter of an account",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(c *cobra.Command, args []string) error {
+		address, err := parseHex("address", args[0])
+		if err != nil {
+			return err
+		}
+		return call(grpcServer, func(ctx context.Context, conn *grpc.ClientConn) error {
+			res, err := pb.NewGlobalStateServiceClient(conn).Account(ctx, &pb.AccountRequest{
+				AccountId: &pb.AccountId{Address: address},
+			})
+			if err != nil {
+				return err
+			}
+			return printMessage(c, res.Account)
+		})
+	},
+}
+
+var txCmd = &cobra.Command{
+	Use:   "tx",
+	Short: "Submit transactions and query their receipts",
+}
+
+var txSubmitCmd = &cobra.Command{
+	Use:   "submit <transaction>",
+	Short: "Submit a signed binary transaction, given in hex",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(c *cobra.Command, args []string) error {
+		tx, err := parseHex("transaction", args[0])
+		if err != nil {
+			return err
+		}
+		return call(grpcServer, func(ctx context.Context, conn *grpc.ClientConn) error {
+			res, err := pb.NewTransactionServiceClient(conn).SubmitTransaction(ctx, &pb.SubmitTransactionRequest{Transaction: tx})
+			if err != nil {
+				return err
+			}
+			return printMessage(c, res)
+		})
+	},
+}
+
+var txReceiptCmd = &cobra.Command{
+	Use:   "receipt <id>",
+	Short: "Show the receipt of a transaction that was applied to the global state",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(c *cobra.Command, args []string) error {
+		id, err := parseHex("transaction id", args[0])
+		if err != nil {
+			return err
+		}
+		return call(grpcServer, func(ctx context.Context, conn *grpc.ClientConn) error {
+			res, err := extpb.NewTransactionServiceClient(conn).TransactionReceipt(ctx, &extpb.TransactionReceiptRequest{Id: id})
+			if err != nil {
+				return err
+			}
+			return printMessage(c, res.Receipt)
+		})
+	},
+}
+
+var smeshingCmd = &cobra.Command{
+	Use:   "smeshing",
+	Short: "Manage smeshing",
+	Long:  "Manage smeshing. Smeshing can't be stopped once it started, the node doesn't support it yet.",
+}
+
+var smeshingStartCmd = &cobra.Command{
+	Use:   "start",
+	Short: "Initialize the PoST data of the node, if needed, and start smeshing",
+	Args:  cobra.NoArgs,
+	RunE: func(c *cobra.Command, args []string) error {
+		dataDir, _ := c.Flags().GetString("datadir")
+		space, _ := c.Flags().GetUint64("space")
+		coinbase, _ := c.Flags().GetString("coinbase")
+		if _, err := parseHex("coinbase", coinbase); err != nil {
+			return err
+		}
+		return call(legacyGrpcServer, func(ctx context.Context, conn *grpc.ClientConn) error {
+			_, err := apipb.NewSpacemeshServiceClient(conn).StartMining(ctx, &apipb.InitPost{
+				LogicalDrive:   dataDir,
+				CommitmentSize: space,
+				Coinbase:       coinbase,
+			})
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintln(c.OutOrStdout(), "started smeshing")
+			return err
+		})
+	},
+}
+
+var smeshingStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the PoST initialization status, coinbase and data directory of the node",
+	Args:  cobra.NoArgs,
+	RunE: func(c *cobra.Command, args []string) error {
+		return call(legacyGrpcServer, func(ctx context.Context, conn *grpc.ClientConn) error {
+			res, err := apipb.NewSpacemeshServiceClient(conn).GetMiningStats(ctx, &empty.Empty{})
+			if err != nil {
+				return err
+			}
+			return printMessage(c, res)
+		})
+	},
+}
+
+var smeshingEligibilityCmd = &cobra.Command{
+	Use:   "eligibility",
+	Short: "Show the block and hare eligibilities of the node in the current and next epoch",
+	Args:  cobra.NoArgs,
+	RunE: func(c *cobra.Command, args []string) error {
+		return call(grpcServer, func(ctx context.Context, conn *grpc.ClientConn) error {
+			res, err := extpb.NewSmesherServiceClient(conn).EligibilityReport(ctx, &extpb.EligibilityReportRequest{})
+			if err != nil {
+				return err
+			}
+			return printMessage(c, res)
+		})
+	},
+}
+
+var setCoinbaseCmd = &cobra.Command{
+	Use:   "set-coinbase <address>",
+	Short: "Set the account the rewards of the node are paid to",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(c *cobra.Command, args []string) error {
+		if _, err := parseHex("address", args[0]); err != nil {
+			return err
+		}
+		return call(legacyGrpcServer, func(ctx context.Context, conn *grpc.ClientConn) error {
+			if _, err := apipb.NewSpacemeshServiceClient(conn).SetAwardsAddress(ctx, &apipb.AccountId{Address: args[0]}); err != nil {
+				return err
+			}
+			_, err := fmt.Fprintln(c.OutOrStdout(), "coinbase set to", args[0])
+			return err
+		})
+	},
+}
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Print the events of accounts, transactions, smeshers and layers as they happen, one json object per line",
+	Args:  cobra.NoArgs,
+	RunE: func(c *cobra.Command, args []string) error {
+		filter := &extpb.EventFilter{}
+		filter.Layers, _ = c.Flags().GetBool("layers")
+		for _, f := range []struct {
+			flag   string
+			values *[][]byte
+		}{
+			{"account", &filter.Accounts},
+			{"tx", &filter.TransactionIds},
+			{"smesher", &filter.SmesherIds},
+		} {
+			args, _ := c.Flags().GetStringSlice(f.flag)
+			for _, arg := range args {
+				b, err := parseHex(f.flag, arg)
+				if err != nil {
+					return err
+				}
+				*f.values = append(*f.values, b)
+			}
+		}
+		if !filter.Layers && len(filter.Accounts)+len(filter.TransactionIds)+len(filter.SmesherIds) == 0 {
+			return fmt.Errorf("nothing to watch, at least one of --account, --tx, --smesher or --layers must be set")
+		}
+
+		return watch(grpcServer, func(ctx context.Context, conn *grpc.ClientConn) error {
+			stream, err := extpb.NewEventServiceClient(conn).SubscribeEvents(ctx)
+			if err != nil {
+				return err
+			}
+			if err := stream.Send(&extpb.SubscribeEventsRequest{Filter: filter}); err != nil {
+				return err
+			}
+			if err := stream.CloseSend(); err != nil {
+				return err
+			}
+			for {
+				res, err := stream.Recv()
+				if err == io.EOF || ctx.Err() != nil {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+				if err := printLine(c, res); err != nil {
+					return err
+				}
+			}
+		})
+	},
+}
+
+func init() {
+	smeshingStartCmd.Flags().String("datadir", "", "directory of the PoST data")
+	smeshingStartCmd.Flags().Uint64("space", 0, "size of the PoST data in bytes")
+	smeshingStartCmd.Flags().String("coinbase", "", "account the rewards of the node are paid to")
+	_ = smeshingStartCmd.MarkFlagRequired("datadir")
+	_ = smeshingStartCmd.MarkFlagRequired("space")
+	_ = smeshingStartCmd.MarkFlagRequired("coinbase")
+	smeshingCmd.AddCommand(smeshingStartCmd, smeshingStatusCmd, smeshingEligibilityCmd, setCoinbaseCmd)
+
+	txCmd.AddCommand(txSubmitCmd, txReceiptCmd)
+
+	watchCmd.Flags().StringSlice("account", nil, "watch the balance, rewards and transactions of an account")
+	watchCmd.Flags().StringSlice("tx", nil, "watch the state of a transaction")
+	watchCmd.Flags().StringSlice("smesher", nil, "watch the activations, rewards and malfeasance of a smesher")
+	watchCmd.Flags().Bool("layers", false, "watch the status of layers")
+
+	banCmd.Flags().Duration("duration", 0, "how long the ban lasts, e.g. 24h, the ban doesn't expire if 0")
+
+	cmd.AddCommand(statusCmd, versionCmd, networkCmd, peersCmd, banCmd, unbanCmd, bansCmd, balanceCmd, txCmd, smeshingCmd, watchCmd)
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/spacemeshos/go-spacemesh/api/extpb"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+var exportDatasets = map[string]extpb.ExportDataset{
+	"transactions": extpb.ExportDataset_EXPORT_DATASET_TRANSACTIONS,
+	"rewards":      extpb.ExportDataset_EXPORT_DATASET_REWARDS,
+	"atxs":         extpb.ExportDataset_EXPORT_DATASET_ATXS,
+}
+
+var exportCmd = &cobra.Command{
+	Use:   "export <transactions|rewards|atxs>",
+	Short: "Export the transactions, rewards or ATXs of a range of layers to CSV (requires the admin service)",
+	Long: "Export the transactions, rewards or ATXs of a range of layers applied to the global state to CSV, with a " +
+		"header row. Ids, addresses and keys are written in hex. The rows are written as they're streamed from the " +
+		"node, so exports of any size can be interrupted and resumed from a later start layer.",
+	Args:      cobra.ExactValidArgs(1),
+	ValidArgs: []string{"transactions", "rewards", "atxs"},
+	RunE: func(c *cobra.Command, args []string) error {
+		req := &extpb.ExportRequest{Dataset: exportDatasets[args[0]]}
+		req.StartLayer, _ = c.Flags().GetUint64("start-layer")
+		req.EndLayer, _ = c.Flags().GetUint64("end-layer")
+		output, _ := c.Flags().GetString("output")
+
+		return watch(grpcServer, func(ctx context.Context, conn *grpc.ClientConn) error {
+			stream, err := extpb.NewAdminServiceClient(conn).Export(ctx, req)
+			if err != nil {
+				return err
+			}
+			// the first response is received before creating the output, so that invalid requests don't leave files
+			res, err := stream.Recv()
+			if err != nil && err != io.EOF {
+				return err
+			}
+
+			out := c.OutOrStdout()
+			if output != "" {
+				f, err := os.Create(output)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				out = f
+			}
+			w := csv.NewWriter(out)
+			if err := w.Write(csvHeader(exportRowDescriptor(req.Dataset))); err != nil {
+				return err
+			}
+			for err != io.EOF {
+				if ctx.Err() != nil {
+					break
+				}
+				if err != nil {
+					return err
+				}
+				for _, row := range exportRows(res) {
+					if err := w.Write(csvRecord(proto.MessageReflect(row))); err != nil {
+						return err
+					}
+				}
+				res, err = stream.Recv()
+			}
+			w.Flush()
+			return w.Error()
+		})
+	},
+}
+
+// exportRowDescriptor returns the descriptor of the rows of the dataset, which defines the columns of the export
+func exportRowDescriptor(dataset extpb.ExportDataset) protoreflect.MessageDescriptor {
+	switch dataset {
+	case extpb.ExportDataset_EXPORT_DATASET_TRANSACTIONS:
+		return (&extpb.ExportedTransaction{}).ProtoReflect().Descriptor()
+	case extpb.ExportDataset_EXPORT_DATASET_REWARDS:
+		return (&extpb.ExportedReward{}).ProtoReflect().Descriptor()
+	}
+	return (&extpb.ExportedAtx{}).ProtoReflect().Descriptor()
+}
+
+func exportRows(res *extpb.ExportResponse) []proto.Message {
+	var rows []proto.Message
+	for _, tx := range res.Transactions {
+		rows = append(rows, tx)
+	}
+	for _, r := range res.Rewards {
+		rows = append(rows, r)
+	}
+	for _, atx := range res.Atxs {
+		rows = append(rows, atx)
+	}
+	return rows
+}
+
+// csvHeader returns the names of the fields of a message, in the order of their declaration
+func csvHeader(desc protoreflect.MessageDescriptor) []string {
+	fields := desc.Fields()
+	header := make([]string, fields.Len())
+	for i := range header {
+		header[i] = string(fields.Get(i).Name())
+	}
+	return header
+}
+
+// csvRecord returns the values of the scalar fields of a message, formatted like the cli prints them
+func csvRecord(m protoreflect.Message) []string {
+	fields := m.Descriptor().Fields()
+	record := make([]string, fields.Len())
+	for i := range record {
+		field := fields.Get(i)
+		record[i] = fmt.Sprint(singularValue(field, m.Get(field)))
+	}
+	return record
+}
+
+func init() {
+	exportCmd.Flags().Uint64("start-layer", 0, "first exported layer")
+	exportCmd.Flags().Uint64("end-layer", 0, "last exported layer, the latest layer applied to the global state if zero")
+	exportCmd.Flags().StringP("output", "o", "", "file the csv is written to, instead of the standard output")
+	cmd.AddCommand(exportCmd)
+}
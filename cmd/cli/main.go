@@ -0,0 +1,122 @@
+// package cli cmd is spacemesh-cli, a command line client of the grpc api of a running node
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/spacemeshos/go-spacemesh/api/config"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+)
+
+var (
+	grpcServer       string
+	legacyGrpcServer string
+	timeout          time.Duration
+)
+
+// cmd is the root command of the cli
+var cmd = &cobra.Command{
+	Use:          "spacemesh-cli",
+	Short:        "Command line client of the spacemesh node api",
+	SilenceUsage: true,
+}
+
+func init() {
+	conf := config.DefaultConfig()
+	cmd.PersistentFlags().StringVar(&grpcServer, "grpc-server", fmt.Sprintf("localhost:%d", conf.NewGrpcServerPort),
+		"address of the grpc api of the node")
+	cmd.PersistentFlags().StringVar(&legacyGrpcServer, "legacy-grpc-server", fmt.Sprintf("localhost:%d", conf.GrpcServerPort),
+		"address of the legacy grpc api of the node, which manages smeshing")
+	cmd.PersistentFlags().DurationVar(&timeout, "timeout", 10*time.Second,
+		"timeout of requests, watching a stream is only limited by the timeout of connecting to the node")
+}
+
+func main() {
+	if err := cmd.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+func dial(ctx context.Context, addr string) (*grpc.ClientConn, error) {
+	conn, err := grpc.DialContext(ctx, addr, grpc.WithInsecure(), grpc.WithBlock())
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %v: %v", addr, err)
+	}
+	return conn, nil
+}
+
+// call connects to the grpc server at addr and calls f with the connection, within the request timeout
+func call(addr string, f func(ctx context.Context, conn *grpc.ClientConn) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	conn, err := dial(ctx, addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return f(ctx, conn)
+}
+
+// watch connects to the grpc server at addr and calls f with the connection and a context that's canceled when the
+// process is interrupted
+func watch(addr string, f func(ctx context.Context, conn *grpc.ClientConn) error) error {
+	dialCtx, cancelDial := context.WithTimeout(context.Background(), timeout)
+	defer cancelDial()
+	conn, err := dial(dialCtx, addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+	go func() {
+		select {
+		case <-interrupt:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return f(ctx, conn)
+}
+
+// printMessage writes msg to the output of c as indented json
+func printMessage(c *cobra.Command, msg proto.Message) error {
+	buf, err := json.MarshalIndent(messageValue(proto.MessageReflect(msg)), "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(c.OutOrStdout(), string(buf))
+	return err
+}
+
+// printLine writes msg to the output of c as json on a single line, for streams
+func printLine(c *cobra.Command, msg proto.Message) error {
+	buf, err := json.Marshal(messageValue(proto.MessageReflect(msg)))
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(c.OutOrStdout(), string(buf))
+	return err
+}
+
+// parseHex decodes a hex argument, with or without a 0x prefix
+func parseHex(name, arg string) ([]byte, error) {
+	b, err := hex.DecodeString(strings.TrimPrefix(arg, "0x"))
+	if err != nil || len(b) == 0 {
+		return nil, fmt.Errorf("invalid %v %q, expected a hex string", name, arg)
+	}
+	return b, nil
+}
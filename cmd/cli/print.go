@@ -0,0 +1,57 @@
+package main
+
+import (
+	"github.com/spacemeshos/go-spacemesh/common/util"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// messageValue converts a message to a value that encodes to json like the json gateway encodes it, except that bytes
+// are encoded in hex rather than base64, since that's how ids and addresses are displayed everywhere else, and that
+// scalar fields are included even if they hold their default value
+func messageValue(m protoreflect.Message) map[string]interface{} {
+	res := make(map[string]interface{})
+	fields := m.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		if (field.Message() != nil || field.ContainingOneof() != nil) && !m.Has(field) {
+			continue
+		}
+		res[field.JSONName()] = fieldValue(field, m.Get(field))
+	}
+	return res
+}
+
+func fieldValue(field protoreflect.FieldDescriptor, v protoreflect.Value) interface{} {
+	switch {
+	case field.IsList():
+		list := v.List()
+		res := make([]interface{}, list.Len())
+		for i := range res {
+			res[i] = singularValue(field, list.Get(i))
+		}
+		return res
+	case field.IsMap():
+		res := make(map[string]interface{})
+		v.Map().Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+			res[k.String()] = singularValue(field.MapValue(), v)
+			return true
+		})
+		return res
+	}
+	return singularValue(field, v)
+}
+
+func singularValue(field protoreflect.FieldDescriptor, v protoreflect.Value) interface{} {
+	switch field.Kind() {
+	case protoreflect.BytesKind:
+		return util.Encode(v.Bytes())
+	case protoreflect.EnumKind:
+		if value := field.Enum().Values().ByNumber(v.Enum()); value != nil {
+			return string(value.Name())
+		}
+		return v.Enum()
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return messageValue(v.Message())
+	}
+	return v.Interface()
+}
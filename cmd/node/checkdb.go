@@ -0,0 +1,137 @@
+package node
+
+import (
+	"fmt"
+
+	"github.com/spacemeshos/go-spacemesh/activation"
+	cmdp "github.com/spacemeshos/go-spacemesh/cmd"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/database"
+	"github.com/spacemeshos/go-spacemesh/dbcheck"
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/mesh"
+	"github.com/spacemeshos/go-spacemesh/state"
+	"github.com/spf13/cobra"
+)
+
+// CheckDBCmd checks the databases of a stopped node
+var CheckDBCmd = &cobra.Command{
+	Use:   "check-db",
+	Short: "Check the databases of a stopped node",
+	Long: `Check that the blocks of the validated layers are stored along with the transactions and ATXs they
+reference, and that the state of the latest layer applied to the state is complete. With --repair, an incomplete
+state is rewound to the latest layer whose state is complete, and the node applies the later layers again when it
+starts. Missing blocks, transactions and ATXs can only be fetched while the node runs, via the CheckDatabases API.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		app := NewSpacemeshApp()
+		if err := app.ParseConfig(); err != nil {
+			log.Error(fmt.Sprintf("couldn't parse the config err=%v", err))
+		}
+		if err := cmdp.EnsureCLIFlags(cmd, app.Config); err != nil {
+			return err
+		}
+		repair, err := cmd.Flags().GetBool("repair")
+		if err != nil {
+			return err
+		}
+		report, err := checkDatabases(app, repair)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("checked layers %v to %v: %v blocks, %v transactions, %v atxs, state at layer %v in %v\n",
+			report.FromLayer, report.ToLayer, report.Blocks, report.Transactions, report.Atxs, report.StateLayer,
+			report.Duration)
+		for _, issue := range report.Issues {
+			fmt.Println(issue)
+		}
+		if len(report.Issues) == 0 {
+			fmt.Println("no issues found")
+		}
+		return nil
+	},
+}
+
+func init() {
+	CheckDBCmd.Flags().Bool("repair", false, "rewind an incomplete state to the latest layer whose state is complete")
+	Cmd.AddCommand(CheckDBCmd)
+}
+
+// offlineMesh provides the layers the mesh of a stopped node recovers when it starts
+type offlineMesh struct {
+	*mesh.DB
+	processed  types.LayerID
+	inState    types.LayerID
+	checkpoint types.LayerID
+}
+
+func (m *offlineMesh) ProcessedLayer() types.LayerID {
+	return m.processed
+}
+
+func (m *offlineMesh) LatestLayerInState() types.LayerID {
+	return m.inState
+}
+
+func (m *offlineMesh) CheckpointLayer() types.LayerID {
+	return m.checkpoint
+}
+
+// checkDatabases checks the databases in the data dir of a stopped node
+func checkDatabases(app *SpacemeshApp, repair bool) (*dbcheck.Report, error) {
+	types.SetLayersPerEpoch(int32(app.Config.LayersPerEpoch))
+	dbStorepath := app.Config.DataDir()
+	lg := log.NewDefault("check-db")
+
+	var closers []interface{ Close() }
+	defer func() {
+		for _, c := range closers {
+			c.Close()
+		}
+	}()
+	open := func(name string) (*database.LDBDatabase, error) {
+		db, err := database.NewTunedLDBDatabase(app.Config.DBPath(dbStorepath, name), app.Config.DBTuning(name), lg.WithName(name))
+		if err != nil {
+			return nil, fmt.Errorf("could not open %v database: %v", name, err)
+		}
+		closers = append(closers, db)
+		return db, nil
+	}
+
+	mdb, err := mesh.NewTunedPersistentMeshDB(app.Config.DBPath(dbStorepath, "mesh"), app.Config.BlockCacheSize, app.meshDBTuning, lg.WithName("meshDb"))
+	if err != nil {
+		return nil, err
+	}
+	closers = append(closers, mdb)
+	stateDb, err := open("state")
+	if err != nil {
+		return nil, err
+	}
+	appliedTxs, err := open("appliedTxs")
+	if err != nil {
+		return nil, err
+	}
+	atxdbstore, err := open("atx")
+	if err != nil {
+		return nil, err
+	}
+	iddbstore, err := open("ids")
+	if err != nil {
+		return nil, err
+	}
+
+	msh := &offlineMesh{DB: mdb, checkpoint: mdb.RecoverCheckpointLayer()}
+	if msh.processed, err = mdb.RecoverProcessedLayer(); err != nil {
+		return nil, fmt.Errorf("could not read the validated layer: %v", err)
+	}
+	if msh.inState, err = mdb.RecoverLatestLayerInState(); err != nil {
+		return nil, fmt.Errorf("could not read the latest layer in state: %v", err)
+	}
+	processor := state.NewTransactionProcessor(stateDb, appliedTxs, nil, state.NewTxMemPool(), lg.WithName("state"))
+	atxdb := activation.NewDB(atxdbstore, activation.NewIdentityStore(iddbstore), mdb, uint16(app.Config.LayersPerEpoch), nil, lg.WithName("atxDb"))
+
+	checker := dbcheck.New(msh, atxdb, processor, lg)
+	if repair {
+		checker.SetRewinder(mdb)
+	}
+	return checker.Check(repair), nil
+}
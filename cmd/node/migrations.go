@@ -0,0 +1,8 @@
+package node
+
+import "github.com/spacemeshos/go-spacemesh/database"
+
+// migrations are the schema migrations of the databases of the node, by the name the databases are registered under.
+// A change to the layout of the data a component stores appends a migration to the list of its database, with the
+// next version, so that nodes migrate their data when they're upgraded instead of resyncing from scratch.
+var migrations = map[string][]database.Migration{}
@@ -3,17 +3,23 @@ package node
 
 import "C"
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
 	"fmt"
 	"github.com/spacemeshos/amcl"
 	"github.com/spacemeshos/amcl/BLS381"
 	"github.com/spacemeshos/go-spacemesh/activation"
 	apiCfg "github.com/spacemeshos/go-spacemesh/api/config"
+	"github.com/spacemeshos/go-spacemesh/checkpoint"
 	cmdp "github.com/spacemeshos/go-spacemesh/cmd"
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/common/util"
 	"github.com/spacemeshos/go-spacemesh/database"
+	"github.com/spacemeshos/go-spacemesh/dbcheck"
 	"github.com/spacemeshos/go-spacemesh/events"
+	"github.com/spacemeshos/go-spacemesh/events/broker"
+	"github.com/spacemeshos/go-spacemesh/events/webhook"
 	"github.com/spacemeshos/go-spacemesh/hare"
 	"github.com/spacemeshos/go-spacemesh/hare/eligibility"
 	"github.com/spacemeshos/go-spacemesh/mesh"
@@ -30,16 +36,22 @@ import (
 	"github.com/spacemeshos/post/shared"
 	"go.uber.org/zap"
 	"io/ioutil"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"runtime"
 	"runtime/pprof"
+	"sort"
 	"time"
 
 	"github.com/spacemeshos/go-spacemesh/api"
+	"github.com/spacemeshos/go-spacemesh/api/graphql"
 	"github.com/spacemeshos/go-spacemesh/api/grpcserver"
+	"github.com/spacemeshos/go-spacemesh/api/jsonrpc"
+	"github.com/spacemeshos/go-spacemesh/api/rosetta"
 	cfg "github.com/spacemeshos/go-spacemesh/config"
 	"github.com/spacemeshos/go-spacemesh/filesystem"
 	"github.com/spacemeshos/go-spacemesh/log"
@@ -54,6 +66,9 @@ import _ "net/http/pprof" // import for memory and network profiling
 
 const edKeyFileName = "key.bin"
 
+// dbMetricsInterval is the interval between exports of the size and the statistics of the databases
+const dbMetricsInterval = 30 * time.Second
+
 // Logger names
 const (
 	AppLogger            = "app"
@@ -81,6 +96,9 @@ const (
 	NipstBuilderLogger   = "nipstBuilder"
 	AtxBuilderLogger     = "atxBuilder"
 	GossipListener       = "gossipListener"
+	EpochReporterLogger  = "epochReporter"
+	WebhookLogger        = webhook.LoggerName
+	BrokerLogger         = "broker"
 )
 
 // Cmd is the cobra wrapper for the node, that allows adding parameters to it
@@ -146,32 +164,46 @@ type TickProvider interface {
 // SpacemeshApp is the cli app singleton
 type SpacemeshApp struct {
 	*cobra.Command
-	nodeID            types.NodeID
-	P2P               p2p.Service
-	Config            *cfg.Config
-	grpcAPIService    *api.SpacemeshGrpcService
-	jsonAPIService    *api.JSONHTTPServer
-	newgrpcAPIService *grpcserver.Server
-	newjsonAPIService *grpcserver.JSONHTTPServer
-	syncer            *sync.Syncer
-	blockListener     *sync.BlockListener
-	state             *state.TransactionProcessor
-	blockProducer     *miner.BlockBuilder
-	oracle            *miner.Oracle
-	txProcessor       *state.TransactionProcessor
-	mesh              *mesh.Mesh
-	gossipListener    *service.Listener
-	clock             TickProvider
-	hare              HareService
-	atxBuilder        *activation.Builder
-	atxDb             *activation.DB
-	poetListener      *activation.PoetListener
-	edSgn             *signing.EdSigner
-	closers           []interface{ Close() }
-	log               log.Log
-	txPool            *state.TxMempool
-	loggers           map[string]*zap.AtomicLevel
-	term              chan struct{} // this channel is closed when closing services, goroutines should wait on this channel in order to terminate
+	nodeID              types.NodeID
+	P2P                 p2p.Service
+	Config              *cfg.Config
+	grpcAPIService      *api.SpacemeshGrpcService
+	jsonAPIService      *api.JSONHTTPServer
+	newgrpcAPIService   *grpcserver.Server
+	newjsonAPIService   *grpcserver.JSONHTTPServer
+	graphQLService      *graphql.Server
+	jsonRPCService      *jsonrpc.Server
+	rosettaService      *rosetta.Server
+	syncer              *sync.Syncer
+	blockListener       *sync.BlockListener
+	state               *state.TransactionProcessor
+	receipts            *state.ReceiptStore
+	blockProducer       *miner.BlockBuilder
+	oracle              *miner.Oracle
+	eligibilityReporter *miner.EligibilityReporter
+	epochReporter       *miner.EpochReporter
+	webhooks            *webhook.Dispatcher
+	broker              *broker.Bridge
+	txProcessor         *state.TransactionProcessor
+	mesh                *mesh.Mesh
+	tortoise            tortoise.Tortoise
+	gossipListener      *service.Listener
+	clock               TickProvider
+	hare                HareService
+	hareBeacon          *eligibility.Beacon // nil if the hare oracle is fixed
+	atxBuilder          *activation.Builder
+	atxDb               *activation.DB
+	poetListener        *activation.PoetListener
+	edSgn               *signing.EdSigner
+	closers             []interface{ Close() }
+	databases           *database.Registry
+	log                 log.Log
+	txPool              *state.TxMempool
+	loggers             map[string]*zap.AtomicLevel
+	recovery            *checkpoint.Checkpoint // the checkpoint to restore the node from, if any
+	recoveryHash        types.Hash32
+	rerunInterval       time.Duration // interval of the tortoise reruns, 0 if they are disabled
+	term                chan struct{} // this channel is closed when closing services, goroutines should wait on this channel in order to terminate
 }
 
 // LoadConfigFromFile tries to load configuration file if the config parameter was specified
@@ -251,12 +283,15 @@ func (app *SpacemeshApp) Initialize(cmd *cobra.Command, args []string) (err erro
 		return err
 	}
 
+	if err := proxyHTTP(app.Config.P2P.Proxy); err != nil {
+		return err
+	}
+
 	// override default config in timesync since timesync is using TimeCongigValues
 	timeCfg.TimeConfigValues = app.Config.TIME
 
 	// ensure all data folders exist
-	err = filesystem.ExistOrCreate(app.Config.DataDir())
-	if err != nil {
+	if err := app.Config.PrepareStorage(); err != nil {
 		return err
 	}
 
@@ -273,6 +308,32 @@ func (app *SpacemeshApp) Initialize(cmd *cobra.Command, args []string) (err erro
 	return nil
 }
 
+// proxyHTTP routes the http requests of the node, e.g. to the PoET server, through the SOCKS5 proxy at proxyURL.
+// Requests to loopback addresses aren't proxied.
+func proxyHTTP(proxyURL string) error {
+	if proxyURL == "" {
+		return nil
+	}
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return fmt.Errorf("invalid proxy url: %v", err)
+	}
+	if u.Scheme != "socks5" && u.Scheme != "socks5h" {
+		return fmt.Errorf("unsupported proxy scheme %q, only socks5 proxies are supported", u.Scheme)
+	}
+	// net/http always lets socks5 proxies resolve host names
+	u.Scheme = "socks5"
+	http.DefaultTransport.(*http.Transport).Proxy = func(req *http.Request) (*url.URL, error) {
+		host := req.URL.Hostname()
+		if ip := net.ParseIP(host); host == "localhost" || (ip != nil && ip.IsLoopback()) {
+			return nil, nil
+		}
+		return u, nil
+	}
+	log.Info("Proxying http requests through %v", u.Host)
+	return nil
+}
+
 // setupLogging configured the app logging system.
 func (app *SpacemeshApp) setupLogging() {
 
@@ -306,17 +367,112 @@ func (app *SpacemeshApp) Cleanup(cmd *cobra.Command, args []string) (err error)
 	return nil
 }
 
-func (app *SpacemeshApp) setupGenesis(state *state.TransactionProcessor, msh *mesh.Mesh) {
+// A node can start from three kinds of data besides empty databases:
+//
+//   - a backup: the Backup method of the AdminService writes the databases of a running node from snapshots taken
+//     together, and the restore command validates the backup and restores its databases into their configured paths.
+//     The node then starts as if it was restarted at the time of the backup: the mesh recovers its validated layer and
+//     the global state of the latest layer applied to it, replays the layers the tortoise already verified, and the
+//     syncer fetches the layers that followed the backup.
+//   - a checkpoint staged through the Recover method of the AdminService or given in the config: the mesh and the
+//     global state are wiped and replaced by the accounts of the checkpoint, and the node syncs from its layer.
+//   - a trusted checkpoint that a new node syncs from instead of from genesis.
+
+const (
+	// recoveryFile is where a checkpoint received through the api is kept until the node restarts and restores it
+	recoveryFile = "recovery-checkpoint"
+	// recoveredFile holds the hash of the last checkpoint the node was restored from
+	recoveredFile = "recovered-checkpoint"
+)
+
+// prepareRecovery loads the checkpoint the node should be restored from and wipes the databases that the checkpoint
+// replaces. A checkpoint received through the api takes precedence over the one in the config, which is only restored
+// once.
+func (app *SpacemeshApp) prepareRecovery(dbStorepath string) error {
+	uri, hash := app.Config.RecoverFrom, util.FromHex(app.Config.RecoverHash)
+	staged := filepath.Join(dbStorepath, recoveryFile)
+	if _, err := os.Stat(staged); err == nil {
+		uri, hash = staged, nil
+	} else if uri == "" {
+		return app.prepareCheckpointSync(dbStorepath)
+	}
+	cp, cpHash, err := checkpoint.Load(uri, hash)
+	if err != nil {
+		return err
+	}
+	if uri != staged {
+		if recovered, err := ioutil.ReadFile(filepath.Join(dbStorepath, recoveredFile)); err == nil && bytes.Equal(recovered, cpHash.Bytes()) {
+			log.Info("node was already restored from checkpoint %v", uri)
+			return nil
+		}
+	}
+	log.Info("wiping mesh and global state to restore checkpoint of layer %v from %v", cp.Layer, uri)
+	for _, db := range []string{"mesh", "state", "atx", "appliedTxs", "receipts"} {
+		if err := os.RemoveAll(app.Config.DBPath(dbStorepath, db)); err != nil {
+			return err
+		}
+	}
+	app.recovery, app.recoveryHash = cp, cpHash
+	return nil
+}
+
+// prepareCheckpointSync loads the trusted checkpoint that a new node syncs from instead of from genesis. The checkpoint
+// must match the configured hash. Nodes that already have a mesh keep syncing it.
+func (app *SpacemeshApp) prepareCheckpointSync(dbStorepath string) error {
+	uri := app.Config.CheckpointSync
+	if uri == "" {
+		return nil
+	}
+	hash := util.FromHex(app.Config.CheckpointSyncHash)
+	if len(hash) != types.Hash32Length {
+		return fmt.Errorf("checkpoint-sync-hash must be the sha256 of the checkpoint, got %q", app.Config.CheckpointSyncHash)
+	}
+	if _, err := os.Stat(app.Config.DBPath(dbStorepath, "mesh")); err == nil {
+		log.Info("node already has a mesh, ignoring checkpoint %v", uri)
+		return nil
+	}
+	cp, cpHash, err := checkpoint.Load(uri, hash)
+	if err != nil {
+		return err
+	}
+	log.Info("syncing new node from checkpoint of layer %v from %v", cp.Layer, uri)
+	app.recovery, app.recoveryHash = cp, cpHash
+	return nil
+}
+
+// restoreCheckpoint restores the checkpoint loaded by prepareRecovery into the empty mesh and global state
+func (app *SpacemeshApp) restoreCheckpoint(dbStorepath string, processor *state.TransactionProcessor, atxdb *activation.DB, msh *mesh.Mesh, trtl tortoise.Tortoise) error {
+	if err := checkpoint.Restore(app.recovery, processor, atxdb, msh); err != nil {
+		return err
+	}
+	if err := trtl.Snapshot(); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dbStorepath, recoveredFile), app.recoveryHash.Bytes(), 0600); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(filepath.Join(dbStorepath, recoveryFile)); err != nil {
+		return err
+	}
+	app.log.Info("restored checkpoint of layer %v with %d accounts and %d atxs, syncing from layer %v",
+		app.recovery.Layer, len(app.recovery.Accounts), len(app.recovery.Atxs), app.recovery.Layer+1)
+	return nil
+}
+
+// genesisAccounts returns the accounts created at genesis, as set by the genesis config file or the default config
+func (app *SpacemeshApp) genesisAccounts() map[types.Address]apiCfg.GenesisAccount {
 	var conf *apiCfg.GenesisConfig
 	if app.Config.GenesisConfPath != "" {
 		var err error
 		conf, err = apiCfg.LoadGenesisConfig(app.Config.GenesisConfPath)
 		if err != nil {
 			app.log.Error("cannot load genesis config from file")
+			return nil
 		}
 	} else {
 		conf = apiCfg.DefaultGenesisConfig()
 	}
+	accounts := make(map[types.Address]apiCfg.GenesisAccount, len(conf.InitialAccounts))
 	for id, acc := range conf.InitialAccounts {
 		bytes := util.FromHex(id)
 		if len(bytes) == 0 {
@@ -324,12 +480,35 @@ func (app *SpacemeshApp) setupGenesis(state *state.TransactionProcessor, msh *me
 			log.Error("cannot read config entry for :%s", id)
 			continue
 		}
+		accounts[types.BytesToAddress(bytes)] = acc
+	}
+	return accounts
+}
+
+// genesisID identifies the network of the node by a hash of its genesis config, so nodes of other networks, e.g. of
+// testnets and devnets that share a network id, refuse to peer with it
+func (app *SpacemeshApp) genesisID() []byte {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s/%d/%d/%d", app.Config.GenesisTime, app.Config.LayerDurationSec, app.Config.LayersPerEpoch,
+		app.Config.GenesisActiveSet)
+	accounts := app.genesisAccounts()
+	addrs := make([]types.Address, 0, len(accounts))
+	for addr := range accounts {
+		addrs = append(addrs, addr)
+	}
+	sort.Slice(addrs, func(i, j int) bool { return bytes.Compare(addrs[i].Bytes(), addrs[j].Bytes()) < 0 })
+	for _, addr := range addrs {
+		fmt.Fprintf(h, "/%x:%v:%d", addr.Bytes(), accounts[addr].Balance, accounts[addr].Nonce)
+	}
+	return h.Sum(nil)[:8]
+}
 
-		addr := types.BytesToAddress(bytes)
+func (app *SpacemeshApp) setupGenesis(state *state.TransactionProcessor, msh *mesh.Mesh) {
+	for addr, acc := range app.genesisAccounts() {
 		state.CreateAccount(addr)
 		state.AddBalance(addr, acc.Balance)
 		state.SetNonce(addr, acc.Nonce)
-		app.log.Info("Genesis account created: %s, Balance: %s", id, acc.Balance.Uint64())
+		app.log.Info("Genesis account created: %s, Balance: %s", addr.String(), acc.Balance.Uint64())
 	}
 
 	_, err := state.Commit()
@@ -351,6 +530,34 @@ func (weakCoinStub) GetResult() bool {
 	return true
 }
 
+// migrateDatabases applies the schema migrations of the registered databases. A dry run only reports the pending
+// migrations, and fails if there are any so that the node doesn't start with databases it can't read.
+func (app *SpacemeshApp) migrateDatabases(lg log.Log) error {
+	migrator := database.NewMigrator(lg.WithName("migrations"))
+	for name, migs := range migrations {
+		migrator.Register(name, migs...)
+	}
+	pending, err := migrator.Run(app.databases, app.Config.DBMigrationDryRun, app.Config.DBMigrationBackupDir)
+	if err != nil {
+		return err
+	}
+	if app.Config.DBMigrationDryRun {
+		for _, p := range pending {
+			fmt.Println("pending migration of", p)
+		}
+		return fmt.Errorf("dry run of the database migrations, %d migrations pending", len(pending))
+	}
+	return nil
+}
+
+// compactionWindow returns the maintenance window of the scheduled database compactions, nil if they're disabled
+func (app *SpacemeshApp) compactionWindow() (*database.MaintenanceWindow, error) {
+	if app.Config.DBCompactionWindow == "" {
+		return nil, nil
+	}
+	return database.ParseMaintenanceWindow(app.Config.DBCompactionWindow)
+}
+
 func (app *SpacemeshApp) addLogger(name string, logger log.Log) log.Log {
 	log.Level()
 	lvl := zap.NewAtomicLevel()
@@ -430,6 +637,11 @@ func (app *SpacemeshApp) SetLogLevel(name, loglevel string) error {
 	return nil
 }
 
+// meshDBTuning returns the tuning of the named database of the mesh
+func (app *SpacemeshApp) meshDBTuning(name string) database.Tuning {
+	return app.Config.DBTuning("mesh/" + name)
+}
+
 func (app *SpacemeshApp) initServices(nodeID types.NodeID,
 	swarm service.Service,
 	dbStorepath string,
@@ -452,71 +664,156 @@ func (app *SpacemeshApp) initServices(nodeID types.NodeID,
 
 	app.log = app.addLogger(AppLogger, lg)
 
+	if !app.Config.HARE.SuperHare {
+		if err := hare.ValidateConfig(app.Config.HARE, time.Duration(app.Config.LayerDurationSec)*time.Second); err != nil {
+			return fmt.Errorf("invalid hare config: %v", err)
+		}
+	}
+
 	postClient.SetLogger(app.addLogger(PostLogger, lg))
 
-	db, err := database.NewLDBDatabase(filepath.Join(dbStorepath, "state"), 0, 0, app.addLogger(StateDbLogger, lg))
+	compactionWindow, err := app.compactionWindow()
+	if err != nil {
+		return err
+	}
+	app.databases = database.NewRegistry(lg.WithName("databases"))
+	app.closers = append(app.closers, app.databases)
+	if app.Config.CollectMetrics {
+		app.databases.CollectMetrics(dbMetricsInterval)
+	}
+
+	db, err := database.NewTunedLDBDatabase(app.Config.DBPath(dbStorepath, "state"), app.Config.DBTuning("state"), app.addLogger(StateDbLogger, lg))
 	if err != nil {
 		return err
 	}
 	app.closers = append(app.closers, db)
+	app.databases.Register("state", db)
 
 	coinToss := weakCoinStub{}
 
-	atxdbstore, err := database.NewLDBDatabase(filepath.Join(dbStorepath, "atx"), 0, 0, app.addLogger(AtxDbStoreLogger, lg))
+	atxdbstore, err := database.NewTunedLDBDatabase(app.Config.DBPath(dbStorepath, "atx"), app.Config.DBTuning("atx"), app.addLogger(AtxDbStoreLogger, lg))
 	if err != nil {
 		return err
 	}
 	app.closers = append(app.closers, atxdbstore)
+	app.databases.Register("atx", atxdbstore)
 
-	poetDbStore, err := database.NewLDBDatabase(filepath.Join(dbStorepath, "poet"), 0, 0, app.addLogger(PoetDbStoreLogger, lg))
+	poetDbStore, err := database.NewTunedLDBDatabase(app.Config.DBPath(dbStorepath, "poet"), app.Config.DBTuning("poet"), app.addLogger(PoetDbStoreLogger, lg))
 	if err != nil {
 		return err
 	}
 	app.closers = append(app.closers, poetDbStore)
+	app.databases.Register("poet", poetDbStore)
 
-	iddbstore, err := database.NewLDBDatabase(filepath.Join(dbStorepath, "ids"), 0, 0, app.addLogger(StateDbLogger, lg))
+	iddbstore, err := database.NewTunedLDBDatabase(app.Config.DBPath(dbStorepath, "ids"), app.Config.DBTuning("ids"), app.addLogger(StateDbLogger, lg))
 	if err != nil {
 		return err
 	}
 	app.closers = append(app.closers, iddbstore)
+	app.databases.Register("ids", iddbstore)
 
-	store, err := database.NewLDBDatabase(filepath.Join(dbStorepath, "store"), 0, 0, app.addLogger(StoreLogger, lg))
+	store, err := database.NewTunedLDBDatabase(app.Config.DBPath(dbStorepath, "store"), app.Config.DBTuning("store"), app.addLogger(StoreLogger, lg))
 	if err != nil {
 		return err
 	}
 	app.closers = append(app.closers, store)
+	app.databases.Register("store", store)
 
 	idStore := activation.NewIdentityStore(iddbstore)
 	poetDb := activation.NewPoetDb(poetDbStore, app.addLogger(PoetDbLogger, lg))
 	validator := activation.NewValidator(&app.Config.POST, poetDb)
-	mdb, err := mesh.NewPersistentMeshDB(filepath.Join(dbStorepath, "mesh"), app.Config.BlockCacheSize, app.addLogger(MeshDBLogger, lg))
+	mdb, err := mesh.NewTunedPersistentMeshDB(app.Config.DBPath(dbStorepath, "mesh"), app.Config.BlockCacheSize, app.meshDBTuning, app.addLogger(MeshDBLogger, lg))
 	if err != nil {
 		return err
 	}
 
+	for name, mdbStore := range mdb.Databases() {
+		app.databases.Register(filepath.Join("mesh", name), mdbStore)
+	}
+	if compactionWindow != nil {
+		app.databases.ScheduleCompaction(compactionWindow)
+	}
+
 	app.txPool = state.NewTxMemPool()
 	meshAndPoolProjector := pendingtxs.NewMeshAndPoolProjector(mdb, app.txPool)
 
-	appliedTxs, err := database.NewLDBDatabase(filepath.Join(dbStorepath, "appliedTxs"), 0, 0, lg.WithName("appliedTxs"))
+	appliedTxs, err := database.NewTunedLDBDatabase(app.Config.DBPath(dbStorepath, "appliedTxs"), app.Config.DBTuning("appliedTxs"), lg.WithName("appliedTxs"))
 	if err != nil {
 		return err
 	}
 	app.closers = append(app.closers, appliedTxs)
+	app.databases.Register("appliedTxs", appliedTxs)
 	processor := state.NewTransactionProcessor(db, appliedTxs, meshAndPoolProjector, app.txPool, lg.WithName("state"))
 
+	receiptsDb, err := database.NewTunedLDBDatabase(app.Config.DBPath(dbStorepath, "receipts"), app.Config.DBTuning("receipts"), lg.WithName("receipts"))
+	if err != nil {
+		return err
+	}
+	app.closers = append(app.closers, receiptsDb)
+	app.databases.Register("receipts", receiptsDb)
+	app.receipts = state.NewReceiptStore(receiptsDb)
+	processor.SetReceiptStore(app.receipts)
+
+	// all databases are open, their data is migrated before any component reads it
+	if err := app.migrateDatabases(lg); err != nil {
+		return err
+	}
+
 	atxdb := activation.NewDB(atxdbstore, idStore, mdb, layersPerEpoch, validator, app.addLogger(AtxDbLogger, lg))
 	beaconProvider := &miner.EpochBeaconProvider{}
 
+	if err := tortoise.ValidateWindow(app.Config.TortoiseWindow, app.Config.Hdist); err != nil {
+		return err
+	}
+	if err := tortoise.ValidateReorgWindow(app.Config.ReorgWindow, app.Config.Hdist); err != nil {
+		return err
+	}
 	var msh *mesh.Mesh
 	var trtl tortoise.Tortoise
 	if mdb.PersistentData() {
-		trtl = tortoise.NewRecoveredTortoise(mdb, app.addLogger(TrtlLogger, lg))
+		trtl = tortoise.NewRecoveredTortoise(mdb, app.Config.TortoiseWindow, app.addLogger(TrtlLogger, lg))
 		msh = mesh.NewRecoveredMesh(mdb, atxdb, app.Config.REWARD, trtl, app.txPool, processor, app.addLogger(MeshLogger, lg))
 		go msh.CacheWarmUp(app.Config.LayerAvgSize)
 	} else {
-		trtl = tortoise.NewTortoise(int(layerSize), mdb, app.Config.Hdist, app.addLogger(TrtlLogger, lg))
+		trtl = tortoise.NewTortoise(int(layerSize), mdb, app.Config.Hdist, app.Config.TortoiseWindow, app.addLogger(TrtlLogger, lg))
 		msh = mesh.NewMesh(mdb, atxdb, app.Config.REWARD, trtl, app.txPool, processor, app.addLogger(MeshLogger, lg))
-		app.setupGenesis(processor, msh)
+		if app.recovery != nil {
+			if err := app.restoreCheckpoint(dbStorepath, processor, atxdb, msh, trtl); err != nil {
+				return err
+			}
+		} else {
+			app.setupGenesis(processor, msh)
+		}
+	}
+	// backups are taken between the layers applied to the mesh and the state
+	app.databases.SetWriteLock(msh.ApplyLock())
+	// the tortoise may still revise its opinion on the verified layers within the reorg window
+	msh.SetReorgDistance(types.LayerID(app.Config.ReorgWindow))
+	mode, err := mesh.ParseMode(app.Config.NodeMode)
+	if err != nil {
+		return err
+	}
+	if mode == mesh.Pruned {
+		// the tortoise may still vote on the blocks of the last hdist layers
+		if app.Config.PruneWindow <= app.Config.Hdist {
+			return fmt.Errorf("prune-window must be greater than hdist (%d), got %d", app.Config.Hdist, app.Config.PruneWindow)
+		}
+		msh.SetPruning(types.LayerID(app.Config.PruneWindow))
+	}
+	app.log.Info("running as %v node", mode)
+	msh.SetRetention(types.LayerID(app.Config.RetentionLayers), app.Config.RetentionInterval)
+	app.rerunInterval = app.Config.TortoiseRerunInterval
+	if app.rerunInterval > 0 && (mode == mesh.Pruned || msh.CheckpointLayer() != 0) {
+		app.log.Warning("tortoise reruns need the whole history of the mesh, disabling them")
+		app.rerunInterval = 0
+	}
+	trustedLayers, err := sync.ParseTrustedLayers(app.Config.TrustedLayers)
+	if err != nil {
+		return err
+	}
+	snapshotRoots, err := sync.ParseTrustedLayers(app.Config.SnapshotRoots)
+	if err != nil {
+		return err
 	}
 	eValidator := miner.NewBlockEligibilityValidator(layerSize, uint32(app.Config.GenesisActiveSet), layersPerEpoch, atxdb, beaconProvider, BLS381.Verify2, msh, app.addLogger(BlkEligibilityLogger, lg))
 
@@ -527,7 +824,17 @@ func (app *SpacemeshApp) initServices(nodeID types.NodeID,
 		SyncInterval:    time.Duration(app.Config.SyncInterval) * time.Second,
 		ValidationDelta: time.Duration(app.Config.SyncValidationDelta) * time.Second,
 		Hdist:           app.Config.Hdist,
-		AtxsLimit:       app.Config.AtxsPerBlock}
+		AtxsLimit:       app.Config.AtxsPerBlock,
+		SnapshotSync:    app.Config.SnapshotSync,
+		FetchLayers:     app.Config.SyncFetchLayers,
+		PeerRequests:    app.Config.SyncPeerRequests,
+		Bandwidth:       app.Config.SyncBandwidth,
+		Requests:        app.Config.SyncRequests,
+		HeadersFirst:    app.Config.SyncHeadersFirst,
+		TrustedLayers:   trustedLayers,
+		SnapshotRoots:   snapshotRoots,
+		AuditInterval:   app.Config.SyncAuditInterval,
+		AuditLayers:     app.Config.SyncAuditLayers}
 
 	if app.Config.AtxsPerBlock > miner.AtxsPerBlockLimit { // validate limit
 		app.log.Panic("Number of atxs per block required is bigger than the limit atxsPerBlock=%v limit=%v", app.Config.AtxsPerBlock, miner.AtxsPerBlockLimit)
@@ -540,6 +847,7 @@ func (app *SpacemeshApp) initServices(nodeID types.NodeID,
 	}
 
 	syncer := sync.NewSync(swarm, msh, app.txPool, atxdb, eValidator, poetDb, syncConf, clock, app.addLogger(SyncLogger, lg))
+	syncer.SetSnapshotSource(processor, atxdb)
 	blockOracle := miner.NewMinerBlockOracle(layerSize, uint32(app.Config.GenesisActiveSet), layersPerEpoch, atxdb, beaconProvider, vrfSigner, nodeID, syncer.ListenToGossip, app.addLogger(BlockOracle, lg))
 
 	// TODO: we should probably decouple the apptest and the node (and duplicate as necessary) (#1926)
@@ -547,12 +855,12 @@ func (app *SpacemeshApp) initServices(nodeID types.NodeID,
 	if isFixedOracle { // fixed rolacle, take the provided rolacle
 		hOracle = rolacle
 	} else { // regular oracle, build and use it
-		beacon := eligibility.NewBeacon(mdb, app.Config.HareEligibility.ConfidenceParam, app.addLogger(HareBeaconLogger, lg))
-		hOracle = eligibility.New(beacon, atxdb.CalcActiveSetSize, BLS381.Verify2, vrfSigner, uint16(app.Config.LayersPerEpoch), app.Config.GenesisActiveSet, mdb, app.Config.HareEligibility, app.addLogger(HareOracleLogger, lg))
+		app.hareBeacon = eligibility.NewBeacon(mdb, app.Config.HareEligibility.ConfidenceParam, app.addLogger(HareBeaconLogger, lg))
+		hOracle = eligibility.New(app.hareBeacon, atxdb.CalcActiveSetSize, BLS381.Verify2, vrfSigner, uint16(app.Config.LayersPerEpoch), app.Config.GenesisActiveSet, mdb, app.Config.HareEligibility, app.addLogger(HareOracleLogger, lg))
 	}
 
-	gossipListener := service.NewListener(swarm, syncer, app.addLogger(GossipListener, lg))
-	ha := app.HareFactory(mdb, swarm, sgn, nodeID, syncer, msh, hOracle, idStore, clock, lg)
+	gossipListener := service.NewListener(swarm, syncer, app.Config.P2P.SwarmConfig.GossipValidators, app.addLogger(GossipListener, lg))
+	ha := app.HareFactory(mdb, swarm, sgn, nodeID, syncer, msh, hOracle, idStore, atxdb, clock, lg)
 
 	stateAndMeshProjector := pendingtxs.NewStateAndMeshProjector(processor, msh)
 	cfg := miner.Config{
@@ -561,10 +869,13 @@ func (app *SpacemeshApp) initServices(nodeID types.NodeID,
 		AtxsPerBlock:   app.Config.AtxsPerBlock,
 		LayersPerEpoch: layersPerEpoch,
 		TxsPerBlock:    app.Config.TxsPerBlock,
+		// blocks must reach the peers before they start the hare for the layer
+		Deadline:      time.Duration(app.Config.HARE.WakeupDelta) * time.Second,
+		SigningBudget: time.Duration(app.Config.BlockSigningBudget) * time.Millisecond,
 	}
 
 	blockProducer := miner.NewBlockBuilder(cfg, sgn, swarm, clock.Subscribe(), coinToss, msh, ha, blockOracle, syncer, stateAndMeshProjector, app.txPool, atxdb, app.addLogger(BlockBuilderLogger, lg))
-	blockListener := sync.NewBlockListener(swarm, syncer, 4, app.addLogger(BlockListenerLogger, lg))
+	blockListener := sync.NewBlockListener(swarm, syncer, app.Config.P2P.SwarmConfig.GossipBlockValidators, app.addLogger(BlockListenerLogger, lg))
 
 	poetListener := activation.NewPoetListener(swarm, poetDb, app.addLogger(PoetListenerLogger, lg))
 
@@ -577,13 +888,14 @@ func (app *SpacemeshApp) initServices(nodeID types.NodeID,
 	}
 	atxBuilder := activation.NewBuilder(nodeID, coinBase, sgn, atxdb, swarm, msh, layersPerEpoch, nipstBuilder, postClient, clock, syncer, store, app.addLogger("atxBuilder", lg))
 
-	gossipListener.AddListener(state.IncomingTxProtocol, priorityq.Low, processor.HandleTxData)
+	gossipListener.AddListener(state.IncomingTxProtocol, priorityq.Bulk, processor.HandleTxData)
 	gossipListener.AddListener(activation.AtxProtocol, priorityq.Low, atxdb.HandleGossipAtx)
 
 	app.blockProducer = blockProducer
 	app.blockListener = blockListener
 	app.gossipListener = gossipListener
 	app.mesh = msh
+	app.tortoise = trtl
 	app.syncer = syncer
 	app.clock = clock
 	app.state = processor
@@ -592,12 +904,48 @@ func (app *SpacemeshApp) initServices(nodeID types.NodeID,
 	app.poetListener = poetListener
 	app.atxBuilder = atxBuilder
 	app.oracle = blockOracle
+	app.eligibilityReporter = miner.NewEligibilityReporter(blockOracle, hOracle, app.Config.HARE.N, layersPerEpoch, nodeID, app.addLogger(BlockOracle, lg))
+	app.epochReporter = miner.NewEpochReporter(atxdb, beaconProvider, clock.Subscribe(), app.addLogger(EpochReporterLogger, lg))
 	app.txProcessor = processor
 	app.atxDb = atxdb
 
+	if len(app.Config.WEBHOOKS.Hooks) > 0 {
+		webhooks, err := webhook.NewDispatcher(app.Config.WEBHOOKS, nodeID.Key, app.addLogger(WebhookLogger, lg))
+		if err != nil {
+			return err
+		}
+		app.webhooks = webhooks
+	}
+
+	if app.Config.BROKER.URL != "" {
+		bridge, err := broker.NewBridge(app.Config.BROKER, app.addLogger(BrokerLogger, lg))
+		if err != nil {
+			return err
+		}
+		app.broker = bridge
+	}
+
 	return nil
 }
 
+// periodically reruns the tortoise from genesis and switches to its opinion if it diverges
+func (app *SpacemeshApp) rerunTortoise() {
+	ticker := time.NewTicker(app.rerunInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-app.term:
+			return
+
+		case <-ticker.C:
+			if _, err := app.tortoise.Rerun(); err != nil {
+				app.log.With().Error("tortoise rerun failed", log.Err(err))
+			}
+		}
+	}
+}
+
 // periodically checks that our clock is sync
 func (app *SpacemeshApp) checkTimeDrifts() {
 	checkTimeSync := time.NewTicker(app.Config.TIME.RefreshNtpInterval)
@@ -620,7 +968,7 @@ func (app *SpacemeshApp) checkTimeDrifts() {
 }
 
 // HareFactory returns a hare consensus algorithm according to the parameters is app.Config.Hare.SuperHare
-func (app *SpacemeshApp) HareFactory(mdb *mesh.DB, swarm service.Service, sgn hare.Signer, nodeID types.NodeID, syncer *sync.Syncer, msh *mesh.Mesh, hOracle hare.Rolacle, idStore *activation.IdentityStore, clock TickProvider, lg log.Log) HareService {
+func (app *SpacemeshApp) HareFactory(mdb *mesh.DB, swarm service.Service, sgn hare.Signer, nodeID types.NodeID, syncer *sync.Syncer, msh *mesh.Mesh, hOracle hare.Rolacle, idStore *activation.IdentityStore, atxdb *activation.DB, clock TickProvider, lg log.Log) HareService {
 	if app.Config.HARE.SuperHare {
 		return turbohare.New(msh)
 	}
@@ -642,7 +990,9 @@ func (app *SpacemeshApp) HareFactory(mdb *mesh.DB, swarm service.Service, sgn ha
 
 		return true
 	}
-	ha := hare.New(app.Config.HARE, swarm, sgn, nodeID, validationFunc, syncer.IsSynced, msh, hOracle, uint16(app.Config.LayersPerEpoch), idStore, hOracle, clock.Subscribe(), app.addLogger(HareLogger, lg))
+	ha := hare.New(app.Config.HARE, swarm, sgn, nodeID, validationFunc, syncer.IsSynced, msh, hOracle, uint16(app.Config.LayersPerEpoch), idStore, hOracle, atxdb, clock.Subscribe(), app.addLogger(HareLogger, lg))
+	ha.SetCertificateStore(msh)
+	syncer.SetCertificateHandler(ha)
 	return ha
 }
 
@@ -657,6 +1007,13 @@ func (app *SpacemeshApp) startServices() {
 	if err != nil {
 		log.Panic("cannot start block producer")
 	}
+	app.epochReporter.Start()
+	if app.webhooks != nil {
+		app.webhooks.Start()
+	}
+	if app.broker != nil {
+		app.broker.Start()
+	}
 
 	app.poetListener.Start()
 
@@ -673,6 +1030,9 @@ func (app *SpacemeshApp) startServices() {
 	app.atxBuilder.Start()
 	app.clock.StartNotifying()
 	go app.checkTimeDrifts()
+	if app.rerunInterval > 0 {
+		go app.rerunTortoise()
+	}
 }
 
 func (app *SpacemeshApp) startAPIServices(postClient api.PostAPI, net api.NetworkAPI) {
@@ -712,7 +1072,45 @@ func (app *SpacemeshApp) startAPIServices(postClient api.PostAPI, net api.Networ
 		startService(grpcserver.NewNodeService(net, app.mesh, app.clock, app.syncer))
 	}
 	if apiConf.StartMeshService {
-		startService(grpcserver.NewMeshService(net, app.mesh, app.clock, app.syncer))
+		startService(grpcserver.NewMeshService(net, app.mesh, app.clock, app.syncer, app.atxDb,
+			app.Config.LayersPerEpoch, app.Config.P2P.NetworkID, app.Config.LayerDurationSec,
+			app.Config.LayerAvgSize, app.Config.TxsPerBlock))
+	}
+	if apiConf.StartSmesherService {
+		startService(grpcserver.NewSmesherService(app.clock, app.eligibilityReporter))
+	}
+	if apiConf.StartTxService {
+		projector := pendingtxs.NewStateAndMeshProjector(app.state, pendingtxs.NewMeshAndPoolProjector(app.mesh, app.txPool))
+		startService(grpcserver.NewTransactionService(net, app.mesh, app.txPool, projector, app.receipts,
+			apiConf.MinTxFee, app.Config.LayerAvgSize*app.Config.TxsPerBlock))
+	}
+	if apiConf.StartGlobalStateService {
+		genesis := make(map[types.Address]uint64)
+		for addr, acc := range app.genesisAccounts() {
+			genesis[addr] = acc.Balance.Uint64()
+		}
+		startService(grpcserver.NewGlobalStateService(app.mesh, app.state, app.receipts, apiConf.StateHistoryLayers, genesis))
+	}
+	if apiConf.StartDebugService {
+		// only the p2p switch describes its connectivity, simulated networks don't
+		netInfo, _ := net.(api.NetworkInfoAPI)
+		var beacon api.BeaconAPI
+		if app.hareBeacon != nil {
+			beacon = app.hareBeacon
+		}
+		startService(grpcserver.NewDebugService(app.mesh, app.state, app.mesh, app.tortoise, netInfo, app.syncer, beacon))
+	}
+	if apiConf.StartAdminService {
+		// simulated networks have no banlist
+		bans, _ := net.(api.PeerBanAPI)
+		// the state isn't rewound while the node runs, the missing objects are fetched from peers instead
+		checker := dbcheck.New(app.mesh, app.atxDb, app.state, app.log.WithName("dbcheck"))
+		checker.SetFetcher(app.syncer)
+		startService(grpcserver.NewAdminService(app.mesh, app.state, app.atxDb, bans, app.syncer, app.databases, checker, apiConf.CheckpointDir,
+			apiConf.BackupDir, filepath.Join(app.Config.DataDir(), recoveryFile)))
+	}
+	if apiConf.StartEventService {
+		startService(grpcserver.NewEventService(app.mesh))
 	}
 
 	if apiConf.StartNewJSONServer {
@@ -723,7 +1121,34 @@ func (app *SpacemeshApp) startAPIServices(postClient api.PostAPI, net api.Networ
 			return
 		}
 		app.newjsonAPIService = grpcserver.NewJSONHTTPServer(apiConf.NewJSONServerPort, apiConf.NewGrpcServerPort)
-		app.newjsonAPIService.StartService(apiConf.StartNodeService, apiConf.StartMeshService)
+		app.newjsonAPIService.StartService(*apiConf)
+	}
+
+	if apiConf.StartGraphQLServer {
+		app.graphQLService = graphql.NewServer(apiConf.GraphQLServerPort, app.mesh, app.state, app.receipts, app.atxDb)
+		app.graphQLService.Start()
+	}
+
+	if apiConf.StartJSONRPCServer {
+		if app.newgrpcAPIService == nil {
+			// This panics because it should not happen.
+			// It should be caught inside apiConf.
+			log.Panic("one or more new GRPC services must be enabled with the JSON-RPC server.")
+			return
+		}
+		app.jsonRPCService = jsonrpc.NewServer(apiConf.JSONRPCServerPort, apiConf.NewGrpcServerPort)
+		app.jsonRPCService.Start()
+	}
+
+	if apiConf.StartRosettaServer {
+		if app.newgrpcAPIService == nil {
+			// This panics because it should not happen.
+			// It should be caught inside apiConf.
+			log.Panic("one or more new GRPC services must be enabled with the Rosetta server.")
+			return
+		}
+		app.rosettaService = rosetta.NewServer(apiConf.RosettaServerPort, apiConf.NewGrpcServerPort)
+		app.rosettaService.Start()
 	}
 }
 
@@ -747,6 +1172,27 @@ func (app *SpacemeshApp) stopServices() {
 		app.newjsonAPIService.Close()
 	}
 
+	if app.graphQLService != nil {
+		log.Info("Stopping graphql service...")
+		if err := app.graphQLService.Close(); err != nil {
+			log.Error("error stopping graphql service: %v", err)
+		}
+	}
+
+	if app.jsonRPCService != nil {
+		log.Info("Stopping JSON-RPC service...")
+		if err := app.jsonRPCService.Close(); err != nil {
+			log.Error("error stopping JSON-RPC service: %v", err)
+		}
+	}
+
+	if app.rosettaService != nil {
+		log.Info("Stopping Rosetta service...")
+		if err := app.rosettaService.Close(); err != nil {
+			log.Error("error stopping Rosetta service: %v", err)
+		}
+	}
+
 	if app.newgrpcAPIService != nil {
 		log.Info("Stopping new grpc service...")
 		app.newgrpcAPIService.Close()
@@ -759,6 +1205,21 @@ func (app *SpacemeshApp) stopServices() {
 		}
 	}
 
+	if app.epochReporter != nil {
+		app.log.Info("closing epoch reporter")
+		app.epochReporter.Close()
+	}
+
+	if app.webhooks != nil {
+		app.log.Info("closing webhooks")
+		app.webhooks.Close()
+	}
+
+	if app.broker != nil {
+		app.log.Info("closing broker bridge")
+		app.broker.Close()
+	}
+
 	if app.clock != nil {
 		app.log.Info("%v closing clock", app.nodeID.Key)
 		app.clock.Close()
@@ -789,6 +1250,13 @@ func (app *SpacemeshApp) stopServices() {
 		app.P2P.Shutdown()
 	}
 
+	if app.tortoise != nil {
+		app.log.Info("%v persisting tortoise state", app.nodeID.Key)
+		if err := app.tortoise.Snapshot(); err != nil {
+			app.log.With().Error("could not persist tortoise state", log.Err(err))
+		}
+	}
+
 	if app.mesh != nil {
 		app.log.Info("%v closing mesh", app.nodeID.Key)
 		app.mesh.Close()
@@ -870,7 +1338,11 @@ func (app *SpacemeshApp) getIdentityFile() (string, error) {
 
 // Start starts the Spacemesh node and initializes all relevant services according to command line arguments provided.
 func (app *SpacemeshApp) Start(cmd *cobra.Command, args []string) {
-	log.With().Info("Starting Spacemesh", log.String("data-dir", app.Config.DataDir()), log.String("post-dir", app.Config.POST.DataDir))
+	dataDir := app.Config.DataDir()
+	log.With().Info("Starting Spacemesh", log.String("data-dir", dataDir), log.String("post-dir", app.Config.POST.DataDir),
+		log.String("state-db", app.Config.DBPath(dataDir, "state")),
+		log.String("mesh-db", app.Config.DBPath(dataDir, "mesh")),
+		log.String("atx-db", app.Config.DBPath(dataDir, "atx")))
 
 	err := filesystem.ExistOrCreate(app.Config.DataDir())
 	if err != nil {
@@ -951,11 +1423,18 @@ func (app *SpacemeshApp) Start(cmd *cobra.Command, args []string) {
 	clock := timesync.NewClock(timesync.RealClock{}, ld, gTime, log.NewDefault("clock"))
 
 	log.Info("Initializing P2P services")
+	app.Config.P2P.GenesisID = app.genesisID()
+	log.Info("Genesis id %x", app.Config.P2P.GenesisID)
 	swarm, err := p2p.New(cmdp.Ctx, app.Config.P2P, app.addLogger(P2PLogger, lg), dbStorepath)
 	if err != nil {
 		log.Panic("Error starting p2p services. err: %v", err)
 	}
 
+	if err := app.prepareRecovery(dbStorepath); err != nil {
+		log.Error("cannot recover from checkpoint: %v", err)
+		return
+	}
+
 	err = app.initServices(nodeID, swarm, dbStorepath, app.edSgn, false, nil, uint32(app.Config.LayerAvgSize), postClient, poetClient, vrfSigner, uint16(app.Config.LayersPerEpoch), clock)
 	if err != nil {
 		log.Error("cannot start services %v", err.Error())
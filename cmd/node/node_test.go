@@ -8,7 +8,9 @@ import (
 	"github.com/golang/protobuf/proto"
 	pb "github.com/spacemeshos/api/release/go/spacemesh/v1"
 	"github.com/spacemeshos/go-spacemesh/api/config"
+	"github.com/spacemeshos/go-spacemesh/checkpoint"
 	cmdp "github.com/spacemeshos/go-spacemesh/cmd"
+	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/log"
 	"github.com/spacemeshos/go-spacemesh/p2p"
 	"github.com/spacemeshos/go-spacemesh/p2p/net"
@@ -20,6 +22,7 @@ import (
 	"io/ioutil"
 	inet "net"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
@@ -499,10 +502,146 @@ func TestSpacemeshApp_P2PInterface(t *testing.T) {
 	r.NoError(swarm.Start())
 	defer swarm.Shutdown()
 
-	// Try to connect again: this should succeed
-	conn, err := p2pnet.Dial(cmdp.Ctx, &tcpAddr, l.PublicKey())
+	// Try to connect again: the node closes the connection unless we dial its key
+	wrong, err := p2pnet.Dial(cmdp.Ctx, &tcpAddr, l.PublicKey())
+	r.NoError(err)
+	r.Eventually(wrong.Closed, 5*time.Second, 10*time.Millisecond)
+	conn, err := p2pnet.Dial(cmdp.Ctx, &tcpAddr, swarm.LocalNode().PublicKey())
 	r.NoError(err)
 	defer conn.Close()
 	r.Equal(fmt.Sprintf("%s:%d", addr, app.Config.P2P.TCPPort), conn.RemoteAddr().String())
-	r.Equal(l.PublicKey(), conn.RemotePublicKey())
+	r.Equal(swarm.LocalNode().PublicKey(), conn.RemotePublicKey())
+}
+
+func TestSpacemeshApp_PrepareRecovery(t *testing.T) {
+	r := require.New(t)
+
+	dir, err := ioutil.TempDir("", "recovery")
+	r.NoError(err)
+	defer os.RemoveAll(dir)
+	cp := &checkpoint.Checkpoint{Layer: 10}
+	data, err := cp.Encode()
+	r.NoError(err)
+	cpPath := filepath.Join(dir, "checkpoint")
+	r.NoError(ioutil.WriteFile(cpPath, data, 0600))
+	dbPath := filepath.Join(dir, "data")
+	r.NoError(os.MkdirAll(filepath.Join(dbPath, "mesh"), 0700))
+	r.NoError(os.MkdirAll(filepath.Join(dbPath, "ids"), 0700))
+
+	// nothing to recover from
+	app := NewSpacemeshApp()
+	r.NoError(app.prepareRecovery(dbPath))
+	r.Nil(app.recovery)
+
+	// the checkpoint must have the expected hash
+	app.Config.RecoverFrom = cpPath
+	app.Config.RecoverHash = "1234"
+	r.Error(app.prepareRecovery(dbPath))
+	r.Nil(app.recovery)
+	r.DirExists(filepath.Join(dbPath, "mesh"))
+
+	// the databases the checkpoint replaces are wiped from their paths, the identity is kept
+	app.Config.StateDBPath = filepath.Join(dir, "ssd")
+	r.NoError(os.MkdirAll(filepath.Join(dir, "ssd", "state"), 0700))
+	app.Config.RecoverHash = types.CalcHash32(data).String()
+	r.NoError(app.prepareRecovery(dbPath))
+	r.NotNil(app.recovery)
+	r.Equal(types.LayerID(10), app.recovery.Layer)
+	_, err = os.Stat(filepath.Join(dbPath, "mesh"))
+	r.True(os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(dir, "ssd", "state"))
+	r.True(os.IsNotExist(err))
+	r.DirExists(filepath.Join(dbPath, "ids"))
+
+	// a checkpoint is only restored once
+	r.NoError(ioutil.WriteFile(filepath.Join(dbPath, recoveredFile), app.recoveryHash.Bytes(), 0600))
+	app = NewSpacemeshApp()
+	app.Config.RecoverFrom = cpPath
+	r.NoError(app.prepareRecovery(dbPath))
+	r.Nil(app.recovery)
+
+	// unless it was staged through the api
+	r.NoError(ioutil.WriteFile(filepath.Join(dbPath, recoveryFile), data, 0600))
+	r.NoError(app.prepareRecovery(dbPath))
+	r.NotNil(app.recovery)
+}
+
+func TestSpacemeshApp_PrepareCheckpointSync(t *testing.T) {
+	r := require.New(t)
+
+	dir, err := ioutil.TempDir("", "checkpoint-sync")
+	r.NoError(err)
+	defer os.RemoveAll(dir)
+	cp := &checkpoint.Checkpoint{Layer: 10}
+	data, err := cp.Encode()
+	r.NoError(err)
+	cpPath := filepath.Join(dir, "checkpoint")
+	r.NoError(ioutil.WriteFile(cpPath, data, 0600))
+	dbPath := filepath.Join(dir, "data")
+
+	// the hash of the checkpoint must be configured
+	app := NewSpacemeshApp()
+	app.Config.CheckpointSync = cpPath
+	r.Error(app.prepareRecovery(dbPath))
+	app.Config.CheckpointSyncHash = types.CalcHash32([]byte("other")).String()
+	r.Error(app.prepareRecovery(dbPath))
+	r.Nil(app.recovery)
+
+	app.Config.CheckpointSyncHash = types.CalcHash32(data).String()
+	r.NoError(app.prepareRecovery(dbPath))
+	r.NotNil(app.recovery)
+	r.Equal(types.LayerID(10), app.recovery.Layer)
+
+	// nodes that already have a mesh aren't synced from the checkpoint
+	r.NoError(os.MkdirAll(filepath.Join(dbPath, "mesh"), 0700))
+	app = NewSpacemeshApp()
+	app.Config.CheckpointSync = cpPath
+	app.Config.CheckpointSyncHash = types.CalcHash32(data).String()
+	r.NoError(app.prepareRecovery(dbPath))
+	r.Nil(app.recovery)
+	r.DirExists(filepath.Join(dbPath, "mesh"))
+}
+
+func TestProxyHTTP(t *testing.T) {
+	transport := http.DefaultTransport.(*http.Transport)
+	defer func(proxy func(*http.Request) (*url.URL, error)) { transport.Proxy = proxy }(transport.Proxy)
+
+	require.NoError(t, proxyHTTP("socks5h://127.0.0.1:9050"))
+	for _, tc := range []struct {
+		url     string
+		proxied bool
+	}{
+		{"http://poet.spacemesh.io/v1/info", true},
+		{"http://1.2.3.4:8080/v1/info", true},
+		{"http://127.0.0.1:8080/v1/info", false},
+		{"http://localhost:8080/v1/info", false},
+		{"http://[::1]:8080/v1/info", false},
+	} {
+		req, err := http.NewRequest("GET", tc.url, nil)
+		require.NoError(t, err)
+		proxy, err := transport.Proxy(req)
+		require.NoError(t, err)
+		if tc.proxied {
+			require.Equal(t, "socks5://127.0.0.1:9050", proxy.String(), tc.url)
+		} else {
+			require.Nil(t, proxy, tc.url)
+		}
+	}
+
+	require.Error(t, proxyHTTP("http://127.0.0.1:8080"))
+}
+
+func TestSpacemeshApp_GenesisID(t *testing.T) {
+	app := NewSpacemeshApp()
+	app.Config.GenesisTime = "2020-06-01T00:00:00+00:00"
+	id := app.genesisID()
+	require.Len(t, id, 8)
+	require.Equal(t, id, app.genesisID())
+
+	// nodes of other genesis configs are on other networks
+	app.Config.GenesisTime = "2020-06-02T00:00:00+00:00"
+	require.NotEqual(t, id, app.genesisID())
+	app.Config.GenesisTime = "2020-06-01T00:00:00+00:00"
+	app.Config.LayersPerEpoch++
+	require.NotEqual(t, id, app.genesisID())
 }
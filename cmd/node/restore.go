@@ -0,0 +1,48 @@
+package node
+
+import (
+	"fmt"
+
+	cmdp "github.com/spacemeshos/go-spacemesh/cmd"
+	"github.com/spacemeshos/go-spacemesh/database"
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spf13/cobra"
+)
+
+// RestoreCmd restores the databases of a node from a backup
+var RestoreCmd = &cobra.Command{
+	Use:   "restore <backup>",
+	Short: "Restore the databases of a node from a backup",
+	Long: `Restore the databases of a node from a backup taken with the Backup method of the GRPC AdminService, either the
+directory the node wrote it to or the streamed tarball. The manifest of the backup and the hashes of the databases are
+validated, and the databases are restored into the data directory or their configured paths, which must not hold them
+yet. When the node starts it recovers the mesh and the global state from the restored databases, and syncs the layers
+that followed the backup.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		app := NewSpacemeshApp()
+		if err := app.ParseConfig(); err != nil {
+			log.Error(fmt.Sprintf("couldn't parse the config err=%v", err))
+		}
+		if err := cmdp.EnsureCLIFlags(cmd, app.Config); err != nil {
+			return err
+		}
+		if err := app.Config.PrepareStorage(); err != nil {
+			return err
+		}
+		dataDir := app.Config.DataDir()
+		manifest, err := database.RestoreBackup(args[0], func(name string) string {
+			return app.Config.DBPath(dataDir, name)
+		}, log.NewDefault("restore"))
+		if err != nil {
+			return err
+		}
+		fmt.Printf("restored %v databases with %v bytes of data into %v\n", len(manifest.Databases), manifest.Size(), dataDir)
+		fmt.Println("the node syncs the layers that followed the backup when it starts")
+		return nil
+	},
+}
+
+func init() {
+	Cmd.AddCommand(RestoreCmd)
+}
@@ -58,6 +58,8 @@ func AddCommands(cmd *cobra.Command) {
 		config.BlockCacheSize, "size in layers of meshdb block cache")
 	cmd.PersistentFlags().StringVar(&config.PublishEventsURL, "events-url",
 		config.PublishEventsURL, "publish events to this url; if no url specified no events will be published")
+	cmd.PersistentFlags().StringVar(&config.BROKER.URL, "broker-url",
+		config.BROKER.URL, "publish layer, transaction and account events to the message broker at this url, e.g. nats://localhost:4222 or mqtt://localhost:1883")
 
 	cmd.PersistentFlags().IntVar(&config.SyncRequestTimeout, "sync-request-timeout",
 		config.SyncRequestTimeout, "the timeout in ms for direct requests in the sync")
@@ -65,6 +67,70 @@ func AddCommands(cmd *cobra.Command) {
 		config.AtxsPerBlock, "the number of atxs to select per block on block creation")
 	cmd.PersistentFlags().IntVar(&config.TxsPerBlock, "txs-per-block",
 		config.TxsPerBlock, "the number of transactions to select per block on block creation")
+	cmd.PersistentFlags().IntVar(&config.BlockSigningBudget, "block-signing-budget",
+		config.BlockSigningBudget, "the time in ms reserved for signing and storing a block; tx selection stops when only this much is left before the hare starts")
+	cmd.PersistentFlags().StringVar(&config.RecoverFrom, "recover-from",
+		config.RecoverFrom, "wipe the mesh and global state and restore them from the checkpoint at this path or url")
+	cmd.PersistentFlags().StringVar(&config.RecoverHash, "recover-hash",
+		config.RecoverHash, "expected sha256 of the checkpoint given by --recover-from, in hex")
+	cmd.PersistentFlags().StringVar(&config.CheckpointSync, "checkpoint-sync",
+		config.CheckpointSync, "sync a new node from the trusted checkpoint at this path or url instead of from genesis")
+	cmd.PersistentFlags().StringVar(&config.CheckpointSyncHash, "checkpoint-sync-hash",
+		config.CheckpointSyncHash, "sha256 of the checkpoint given by --checkpoint-sync, in hex")
+	cmd.PersistentFlags().BoolVar(&config.SnapshotSync, "snapshot-sync",
+		config.SnapshotSync, "sync a new node from a snapshot of the state served by peers instead of applying every layer")
+	cmd.PersistentFlags().StringSliceVar(&config.SnapshotRoots, "snapshot-roots",
+		config.SnapshotRoots, "trusted state roots as layer:root, the hex state root of the layer. A snapshot is only restored if its state root is trusted")
+	cmd.PersistentFlags().IntVar(&config.SyncFetchLayers, "sync-fetch-layers",
+		config.SyncFetchLayers, "the number of layers fetched concurrently from peers while the node is out of sync")
+	cmd.PersistentFlags().IntVar(&config.SyncPeerRequests, "sync-peer-requests",
+		config.SyncPeerRequests, "the max number of sync requests in flight to a single peer, 0 for no limit")
+	cmd.PersistentFlags().IntVar(&config.SyncBandwidth, "sync-bandwidth",
+		config.SyncBandwidth, "sync in the background: the max bytes per second received by sync, 0 for no limit")
+	cmd.PersistentFlags().IntVar(&config.SyncRequests, "sync-requests",
+		config.SyncRequests, "sync in the background: the max number of sync requests in flight to all peers, 0 for no limit")
+	cmd.PersistentFlags().BoolVar(&config.SyncHeadersFirst, "sync-headers-first",
+		config.SyncHeadersFirst, "fetch and validate the blocks of all missing layers before fetching their transactions")
+	cmd.PersistentFlags().DurationVar(&config.SyncAuditInterval, "sync-audit-interval",
+		config.SyncAuditInterval, "interval between audits that scan the recent layers for missing blocks, transactions and atxs and fetch them again, 0 disables them")
+	cmd.PersistentFlags().IntVar(&config.SyncAuditLayers, "sync-audit-layers",
+		config.SyncAuditLayers, "the number of recent validated layers scanned by an audit, 0 for all")
+	cmd.PersistentFlags().StringSliceVar(&config.TrustedLayers, "trusted-layers",
+		config.TrustedLayers, "known good layers as layer:hash, the hex hash of the block ids of the layer. Sync rejects peers and blocks that conflict with them")
+	cmd.PersistentFlags().StringVar(&config.NodeMode, "node-mode",
+		config.NodeMode, "archival nodes keep all blocks, transactions and state roots, pruned nodes only those of the last prune-window layers")
+	cmd.PersistentFlags().IntVar(&config.PruneWindow, "prune-window",
+		config.PruneWindow, "the number of layers a pruned node keeps below the latest layer applied to the state")
+	cmd.PersistentFlags().DurationVar(&config.TortoiseRerunInterval, "tortoise-rerun-interval",
+		config.TortoiseRerunInterval, "interval of the background tortoise reruns from genesis, which switch to the recomputed opinion if it diverges within the reorg window. 0 disables them")
+	cmd.PersistentFlags().IntVar(&config.TortoiseWindow, "tortoise-window",
+		config.TortoiseWindow, "the number of layers the tortoise keeps in memory below the latest layer if the verified layer lags behind, it never evicts the layers above the verified layer. 0 for no bound")
+	cmd.PersistentFlags().IntVar(&config.ReorgWindow, "reorg-window",
+		config.ReorgWindow, "the number of layers below the verified layer whose validity the tortoise may revise, at least hdist. Deeper revisions are refused and stop automatic reorgs until the node restarts")
+	cmd.PersistentFlags().IntVar(&config.RetentionLayers, "retention-layers",
+		config.RetentionLayers, "the number of layers below the finalized layer whose consensus data, e.g. the hare output, is kept. The data of older layers is deleted in the background. 0 keeps the data of all layers")
+	cmd.PersistentFlags().DurationVar(&config.RetentionInterval, "retention-interval",
+		config.RetentionInterval, "interval of the background deletion of the consensus data of the layers out of the retention horizon")
+	cmd.PersistentFlags().StringVar(&config.DBCompactionWindow, "db-compaction-window",
+		config.DBCompactionWindow, "daily maintenance window as HH:MM-HH:MM in UTC in which the databases are compacted to reclaim the space of deleted data, empty disables scheduled compactions")
+	cmd.PersistentFlags().BoolVar(&config.DBMigrationDryRun, "db-migration-dry-run",
+		config.DBMigrationDryRun, "report the schema migrations the databases need and exit without applying them")
+	cmd.PersistentFlags().StringVar(&config.DBMigrationBackupDir, "db-migration-backup-dir",
+		config.DBMigrationBackupDir, "directory to which the databases are backed up before schema migrations are applied to them, empty to skip the backup")
+	cmd.PersistentFlags().StringVar(&config.StateDBPath, "state-db-path",
+		config.StateDBPath, "directory to keep the state database in, instead of the data directory")
+	cmd.PersistentFlags().StringVar(&config.MeshDBPath, "mesh-db-path",
+		config.MeshDBPath, "directory to keep the mesh databases in, instead of the data directory")
+	cmd.PersistentFlags().StringVar(&config.AtxDBPath, "atx-db-path",
+		config.AtxDBPath, "directory to keep the atx database in, instead of the data directory")
+	cmd.PersistentFlags().IntVar(&config.DBCache, "db-cache",
+		config.DBCache, "MiB of the block cache of each database")
+	cmd.PersistentFlags().IntVar(&config.DBWriteBuffer, "db-write-buffer",
+		config.DBWriteBuffer, "MiB of the write buffer of each database, two of which may be in use")
+	cmd.PersistentFlags().IntVar(&config.DBOpenFiles, "db-open-files",
+		config.DBOpenFiles, "max number of files each database keeps open")
+	cmd.PersistentFlags().IntVar(&config.DBMemory, "db-memory",
+		config.DBMemory, "MiB of memory shared by all the databases, derives their caches, write buffers and open files instead of db-cache, db-write-buffer and db-open-files (0 to disable)")
 
 	/** ======================== P2P Flags ========================== **/
 
@@ -74,12 +140,19 @@ func AddCommands(cmd *cobra.Command) {
 		config.P2P.TCPInterface, "inet interface for P2P listener, specify as IP address")
 	cmd.PersistentFlags().BoolVar(&config.P2P.AcquirePort, "acquire-port",
 		config.P2P.AcquirePort, "Should the node attempt to forward the port to this machine on a NAT?")
+	cmd.PersistentFlags().BoolVar(&config.P2P.NATPMP, "nat-pmp",
+		config.P2P.NATPMP, "Forward the port using NAT-PMP when no UPnP gateway is found")
+	cmd.PersistentFlags().StringVar(&config.P2P.Proxy, "proxy",
+		config.P2P.Proxy, "Dial peers and http services through a SOCKS5 proxy, e.g. socks5://127.0.0.1:9050 for Tor. "+
+			"The node then only listens on loopback, doesn't forward its port and doesn't bootstrap, use static-peers")
 	cmd.PersistentFlags().DurationVar(&config.P2P.DialTimeout, "dial-timeout",
 		config.P2P.DialTimeout, "Network dial timeout duration")
 	cmd.PersistentFlags().DurationVar(&config.P2P.ConnKeepAlive, "conn-keepalive",
 		config.P2P.ConnKeepAlive, "Network connection keep alive")
 	cmd.PersistentFlags().Int8Var(&config.P2P.NetworkID, "network-id",
 		config.P2P.NetworkID, "NetworkID to run on (0 - mainnet, 1 - testnet)")
+	cmd.PersistentFlags().StringVar(&config.P2P.NetworkKey, "network-key",
+		config.P2P.NetworkKey, "Hex encoded 32 byte pre-shared key of a private network, nodes without it can't connect")
 	cmd.PersistentFlags().DurationVar(&config.P2P.ResponseTimeout, "response-timeout",
 		config.P2P.ResponseTimeout, "Timeout for waiting on resposne message")
 	cmd.PersistentFlags().DurationVar(&config.P2P.SessionTimeout, "session-timeout",
@@ -94,6 +167,34 @@ func AddCommands(cmd *cobra.Command) {
 		config.P2P.OutboundPeersTarget, "The outbound peer target we're trying to connect")
 	cmd.PersistentFlags().IntVar(&config.P2P.MaxInboundPeers, "max-inbound",
 		config.P2P.MaxInboundPeers, "The maximum number of inbound peers ")
+	cmd.PersistentFlags().IntVar(&config.P2P.MaxOutboundPeers, "max-outbound",
+		config.P2P.MaxOutboundPeers, "The maximum number of outbound peers")
+	cmd.PersistentFlags().IntVar(&config.P2P.ReservedPeerSlots, "reserved-peer-slots",
+		config.P2P.ReservedPeerSlots, "Slots of the inbound and outbound peer limits reserved for static and protected peers")
+	cmd.PersistentFlags().IntVar(&config.P2P.MaxInboundPerGroup, "max-inbound-per-group",
+		config.P2P.MaxInboundPerGroup, "The maximum number of inbound peers from the same address group, 0 for unlimited")
+	cmd.PersistentFlags().IntVar(&config.P2P.IPv4GroupPrefix, "ipv4-group-prefix",
+		config.P2P.IPv4GroupPrefix, "The prefix length of the subnets IPv4 addresses are grouped by, 0 to not group them")
+	cmd.PersistentFlags().IntVar(&config.P2P.IPv6GroupPrefix, "ipv6-group-prefix",
+		config.P2P.IPv6GroupPrefix, "The prefix length of the subnets IPv6 addresses are grouped by, 0 to not group them")
+	cmd.PersistentFlags().IntVar(&config.P2P.CompressThreshold, "compress-threshold",
+		config.P2P.CompressThreshold, "The size in bytes from which messages are compressed for peers that support it, 0 to not compress")
+	cmd.PersistentFlags().BoolVar(&config.P2P.HolePunch, "hole-punch",
+		config.P2P.HolePunch, "Connect to peers behind NATs through the NAT by connecting at the same time, coordinated by relay peers")
+	cmd.PersistentFlags().BoolVar(&config.P2P.Relay, "relay",
+		config.P2P.Relay, "Coordinate hole punching between peers, for nodes that peers can dial directly")
+	cmd.PersistentFlags().StringVar(&config.P2P.Transport, "transport",
+		config.P2P.Transport, "The transport of p2p connections: tcp, or quic to also accept QUIC connections and dial peers that support it over QUIC")
+	cmd.PersistentFlags().IntVar(&config.P2P.QUICPort, "quic-port",
+		config.P2P.QUICPort, "udp port for QUIC connections when the transport is quic")
+	cmd.PersistentFlags().IntVar(&config.P2P.SendRate, "send-rate",
+		config.P2P.SendRate, "The maximal upload rate over all connections in bytes per second, 0 for unlimited")
+	cmd.PersistentFlags().IntVar(&config.P2P.RecvRate, "recv-rate",
+		config.P2P.RecvRate, "The maximal download rate over all connections in bytes per second, 0 for unlimited")
+	cmd.PersistentFlags().IntVar(&config.P2P.PeerSendRate, "peer-send-rate",
+		config.P2P.PeerSendRate, "The maximal upload rate to each peer in bytes per second, 0 for unlimited")
+	cmd.PersistentFlags().IntVar(&config.P2P.PeerRecvRate, "peer-recv-rate",
+		config.P2P.PeerRecvRate, "The maximal download rate from each peer in bytes per second, 0 for unlimited")
 	cmd.PersistentFlags().BoolVar(&config.P2P.SwarmConfig.Gossip, "gossip",
 		config.P2P.SwarmConfig.Gossip, "should we start a gossiping node?")
 	cmd.PersistentFlags().BoolVar(&config.P2P.SwarmConfig.Bootstrap, "bootstrap",
@@ -106,6 +207,34 @@ func AddCommands(cmd *cobra.Command) {
 		config.P2P.SwarmConfig.RoutingTableAlpha, "Number of random connections")
 	cmd.PersistentFlags().StringSliceVar(&config.P2P.SwarmConfig.BootstrapNodes, "bootnodes",
 		config.P2P.SwarmConfig.BootstrapNodes, "Number of random connections")
+	cmd.PersistentFlags().StringSliceVar(&config.P2P.SwarmConfig.DNSSeeds, "dns-seeds",
+		config.P2P.SwarmConfig.DNSSeeds, "DNS names whose TXT records are bootstrap nodes")
+	cmd.PersistentFlags().DurationVar(&config.P2P.SwarmConfig.DNSSeedRefresh, "dns-seed-refresh",
+		config.P2P.SwarmConfig.DNSSeedRefresh, "How often the dns seeds are resolved again")
+	cmd.PersistentFlags().IntVar(&config.P2P.SwarmConfig.GossipCacheSize, "gossip-cache-size",
+		config.P2P.SwarmConfig.GossipCacheSize, "The number of seen gossip messages remembered so they aren't relayed again")
+	cmd.PersistentFlags().DurationVar(&config.P2P.SwarmConfig.GossipCacheTTL, "gossip-cache-ttl",
+		config.P2P.SwarmConfig.GossipCacheTTL, "How long seen gossip messages are remembered, 0 for as long as there's room")
+	cmd.PersistentFlags().StringSliceVar(&config.P2P.SwarmConfig.StaticPeers, "static-peers",
+		config.P2P.SwarmConfig.StaticPeers, "Nodes the node always keeps connected, redialing them when they disconnect")
+	cmd.PersistentFlags().StringSliceVar(&config.P2P.SwarmConfig.ProtectedPeers, "protected-peers",
+		config.P2P.SwarmConfig.ProtectedPeers, "IDs of peers that are never dropped or refused by connection management")
+	cmd.PersistentFlags().BoolVar(&config.P2P.SwarmConfig.PrivateMesh, "private-mesh",
+		config.P2P.SwarmConfig.PrivateMesh, "Only connect to allowed and static peers, reject all other peers and don't bootstrap")
+	cmd.PersistentFlags().StringSliceVar(&config.P2P.SwarmConfig.AllowedPeers, "allowed-peers",
+		config.P2P.SwarmConfig.AllowedPeers, "IDs of the peers of the private mesh")
+	cmd.PersistentFlags().BoolVar(&config.P2P.SwarmConfig.MDNS, "mdns",
+		config.P2P.SwarmConfig.MDNS, "Find nodes on the local network with multicast DNS")
+	cmd.PersistentFlags().DurationVar(&config.P2P.SwarmConfig.MDNSInterval, "mdns-interval",
+		config.P2P.SwarmConfig.MDNSInterval, "How often the local network is queried for nodes with multicast DNS")
+	cmd.PersistentFlags().IntVar(&config.P2P.SwarmConfig.GossipFanout, "gossip-fanout",
+		config.P2P.SwarmConfig.GossipFanout, "The number of random peers gossip messages are propagated to, 0 for all peers")
+	cmd.PersistentFlags().IntVar(&config.P2P.SwarmConfig.GossipForwarders, "gossip-forwarders",
+		config.P2P.SwarmConfig.GossipForwarders, "The number of gossip messages propagated at the same time")
+	cmd.PersistentFlags().IntVar(&config.P2P.SwarmConfig.GossipValidators, "gossip-validators",
+		config.P2P.SwarmConfig.GossipValidators, "The number of gossip messages of each protocol validated at the same time")
+	cmd.PersistentFlags().IntVar(&config.P2P.SwarmConfig.GossipBlockValidators, "gossip-block-validators",
+		config.P2P.SwarmConfig.GossipBlockValidators, "The number of gossip blocks validated at the same time")
 	cmd.PersistentFlags().DurationVar(&config.TIME.MaxAllowedDrift, "max-allowed-time-drift",
 		config.TIME.MaxAllowedDrift, "When to close the app until user resolves time sync problems")
 	cmd.PersistentFlags().StringVar(&config.P2P.SwarmConfig.PeersFile, "peers-file",
@@ -137,6 +266,29 @@ func AddCommands(cmd *cobra.Command) {
 	// NewJSONServerPortFlag determines the json api server local listening port (for new server)
 	cmd.PersistentFlags().IntVar(&config.API.NewJSONServerPort, "json-port-new",
 		config.API.NewJSONServerPort, "New JSON api server port")
+	// SwaggerUIFlag determines if the new json api server should serve a swagger ui for the enabled services
+	cmd.PersistentFlags().BoolVar(&config.API.SwaggerUI, "json-swagger-ui",
+		config.API.SwaggerUI, "Serve a swagger ui for the enabled services from the new JSON api server")
+	// StartGraphQLServerFlag determines if the graphql server should be started
+	cmd.PersistentFlags().BoolVar(&config.API.StartGraphQLServer, "graphql-server",
+		config.API.StartGraphQLServer, "Start the GraphQL server")
+	// GraphQLServerPortFlag determines the graphql server local listening port
+	cmd.PersistentFlags().IntVar(&config.API.GraphQLServerPort, "graphql-port",
+		config.API.GraphQLServerPort, "GraphQL server port")
+	// StartJSONRPCServerFlag determines if the json-rpc server should be started
+	cmd.PersistentFlags().BoolVar(&config.API.StartJSONRPCServer, "jsonrpc-server",
+		config.API.StartJSONRPCServer, "Start the JSON-RPC 2.0 server. "+
+			"Its methods are forwarded to the grpc services enabled with --grpc")
+	// JSONRPCServerPortFlag determines the json-rpc server local listening port
+	cmd.PersistentFlags().IntVar(&config.API.JSONRPCServerPort, "jsonrpc-port",
+		config.API.JSONRPCServerPort, "JSON-RPC server port")
+	// StartRosettaServerFlag determines if the rosetta server should be started
+	cmd.PersistentFlags().BoolVar(&config.API.StartRosettaServer, "rosetta-server",
+		config.API.StartRosettaServer, "Start the Rosetta Data and Construction API server. "+
+			"It reads from the grpc services enabled with --grpc, and needs node, mesh, transaction and globalstate")
+	// RosettaServerPortFlag determines the rosetta server local listening port
+	cmd.PersistentFlags().IntVar(&config.API.RosettaServerPort, "rosetta-port",
+		config.API.RosettaServerPort, "Rosetta server port")
 	// StartGrpcAPIServerFlag determines if the grpc server should be started
 	cmd.PersistentFlags().BoolVar(&config.API.StartGrpcServer, "grpc-server",
 		config.API.StartGrpcServer, "StartService the grpc server. "+
@@ -149,6 +301,18 @@ func AddCommands(cmd *cobra.Command) {
 	// NewGrpcServerFlag determines the grpc server local listening port (for new server)
 	cmd.PersistentFlags().IntVar(&config.API.NewGrpcServerPort, "grpc-port-new",
 		config.API.NewGrpcServerPort, "New GRPC api server port")
+	// MinTxFee determines the minimal fee of transactions submitted through the api
+	cmd.PersistentFlags().Uint64Var(&config.API.MinTxFee, "min-tx-fee",
+		config.API.MinTxFee, "Minimal fee of transactions submitted through the GRPC TransactionService")
+	// StateHistoryLayers limits how far back the global state can be queried through the api
+	cmd.PersistentFlags().Uint64Var(&config.API.StateHistoryLayers, "state-history-layers",
+		config.API.StateHistoryLayers, "Number of recent layers whose global state can be queried through the GRPC GlobalStateService, 0 for all layers")
+	// CheckpointDir is where checkpoints requested through the api are written
+	cmd.PersistentFlags().StringVar(&config.API.CheckpointDir, "checkpoint-dir",
+		config.API.CheckpointDir, "Directory to which the GRPC AdminService writes checkpoints, they can only be streamed if it's not set")
+	// BackupDir is where backups requested through the api are written
+	cmd.PersistentFlags().StringVar(&config.API.BackupDir, "backup-dir",
+		config.API.BackupDir, "Directory to which the GRPC AdminService writes backups of the databases, they can only be streamed if it's not set")
 
 	/**======================== Hare Flags ========================== **/
 
@@ -160,9 +324,9 @@ func AddCommands(cmd *cobra.Command) {
 		config.HARE.F, "Max number of adversaries in the Hare committee")
 	// RoundDuration determines the duration of a round in the Hare protocol
 	cmd.PersistentFlags().IntVar(&config.HARE.RoundDuration, "hare-round-duration-sec",
-		config.HARE.RoundDuration, "Duration of round in the Hare protocol")
+		config.HARE.RoundDuration, "Duration of a round in the Hare protocol in seconds, the wakeup delta and 5 rounds must fit in a layer")
 	cmd.PersistentFlags().IntVar(&config.HARE.WakeupDelta, "hare-wakeup-delta",
-		config.HARE.WakeupDelta, "Wakeup delta after tick for hare protocol")
+		config.HARE.WakeupDelta, "Seconds to wait after the layer tick before starting the Hare protocol")
 	cmd.PersistentFlags().IntVar(&config.HARE.ExpectedLeaders, "hare-exp-leaders",
 		config.HARE.ExpectedLeaders, "The expected number of leaders in the hare protocol")
 	cmd.PersistentFlags().IntVar(&config.HARE.LimitIterations, "hare-limit-iterations",
@@ -102,6 +102,12 @@ func (app *syncApp) start(cmd *cobra.Command, args []string) {
 		panic("something got fudged while creating p2p service ")
 	}
 
+	trustedLayers, err := sync.ParseTrustedLayers(app.Config.TrustedLayers)
+	if err != nil {
+		lg.Error("invalid trusted layers: ", err)
+		return
+	}
+
 	conf := sync.Configuration{
 		Concurrency:     4,
 		AtxsLimit:       200,
@@ -111,6 +117,12 @@ func (app *syncApp) start(cmd *cobra.Command, args []string) {
 		Hdist:           app.Config.Hdist,
 		ValidationDelta: 30 * time.Second,
 		LayersPerEpoch:  uint16(app.Config.LayersPerEpoch),
+		FetchLayers:     app.Config.SyncFetchLayers,
+		PeerRequests:    app.Config.SyncPeerRequests,
+		Bandwidth:       app.Config.SyncBandwidth,
+		Requests:        app.Config.SyncRequests,
+		HeadersFirst:    app.Config.SyncHeadersFirst,
+		TrustedLayers:   trustedLayers,
 	}
 	types.SetLayersPerEpoch(int32(app.Config.LayersPerEpoch))
 	lg.Info("local db path: %v layers per epoch %v", path, app.Config.LayersPerEpoch)
@@ -0,0 +1,181 @@
+package types
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/spacemeshos/go-spacemesh/crypto/sha3"
+	"github.com/spacemeshos/go-spacemesh/rlp"
+)
+
+// emptyStateRoot is the root of a global state without any accounts.
+var emptyStateRoot = HexToHash32("56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")
+
+// AccountProof is a merkle proof of the state of an account in the global state with a given root. It can be verified
+// without access to the global state. If the account doesn't exist the proof shows its absence.
+type AccountProof struct {
+	Address Address
+	Exists  bool
+	Nonce   uint64
+	Balance uint64
+	// Nodes are the encoded global state trie nodes on the path from the root to the account.
+	Nodes [][]byte
+}
+
+// Verify checks that the proof is valid for the given global state root and that it proves the nonce and balance of
+// the account, or its absence.
+func (p *AccountProof) Verify(root Hash32) error {
+	exists, nonce, balance, err := VerifyAccountProof(root, p.Address, p.Nodes)
+	if err != nil {
+		return err
+	}
+	if exists != p.Exists || nonce != p.Nonce || balance != p.Balance {
+		return fmt.Errorf("proof doesn't match the account state: exists %v nonce %v balance %v", exists, nonce, balance)
+	}
+	return nil
+}
+
+// VerifyAccountProof walks the trie nodes of a proof from the given global state root to an account and returns the
+// proven state of the account. It returns an error if a node is missing or malformed.
+func VerifyAccountProof(root Hash32, addr Address, nodes [][]byte) (exists bool, nonce, balance uint64, err error) {
+	byHash := make(map[Hash32][]byte, len(nodes))
+	for _, n := range nodes {
+		byHash[keccak256(n)] = n
+	}
+	// accounts are keyed by the hash of their address
+	key := keyToNibbles(keccak256(addr.Bytes()).Bytes())
+	hash := root
+	for {
+		enc, ok := byHash[hash]
+		if !ok {
+			if hash == emptyStateRoot {
+				return false, 0, 0, nil
+			}
+			return false, 0, 0, fmt.Errorf("proof node %v missing", hash.ShortString())
+		}
+		// nodes shorter than a hash are embedded in their parent, so the walk continues until a referenced node
+		var value, child []byte
+		for child == nil && value == nil {
+			value, child, key, err = walkNode(enc, key)
+			if err != nil {
+				return false, 0, 0, err
+			}
+			if value == nil && child == nil {
+				return false, 0, 0, nil
+			}
+			if len(child) > 0 && len(child) < len(hash) {
+				enc, child = child, nil
+			}
+		}
+		if value != nil {
+			var account struct {
+				Nonce   uint64
+				Balance *big.Int
+			}
+			if err := rlp.DecodeBytes(value, &account); err != nil {
+				return false, 0, 0, fmt.Errorf("malformed account: %v", err)
+			}
+			return true, account.Nonce, account.Balance.Uint64(), nil
+		}
+		hash = BytesToHash(child)
+	}
+}
+
+// walkNode follows key through a single encoded trie node. It returns the value if the key ends in the node, or the
+// child to continue with, which is either a hash or an embedded node, along with the rest of the key. It returns
+// neither if the node proves that the key is absent.
+func walkNode(enc []byte, key []byte) (value, child, rest []byte, err error) {
+	elems, _, err := rlp.SplitList(enc)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("malformed proof node: %v", err)
+	}
+	count, err := rlp.CountValues(elems)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("malformed proof node: %v", err)
+	}
+	switch count {
+	case 2:
+		encKey, rest, err := splitElem(elems)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		nodeKey, isLeaf := compactToNibbles(encKey)
+		if !bytes.HasPrefix(key, nodeKey) {
+			return nil, nil, nil, nil
+		}
+		key = key[len(nodeKey):]
+		val, _, err := splitElem(rest)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if isLeaf {
+			if len(key) > 0 {
+				return nil, nil, nil, nil
+			}
+			return val, nil, nil, nil
+		}
+		return nil, val, key, nil
+	case 17:
+		if len(key) == 0 {
+			return nil, nil, nil, errors.New("malformed proof: key ends in a branch")
+		}
+		for i := byte(0); i < key[0]; i++ {
+			if _, elems, err = splitElem(elems); err != nil {
+				return nil, nil, nil, err
+			}
+		}
+		child, _, err := splitElem(elems)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if len(child) == 0 {
+			return nil, nil, nil, nil
+		}
+		return nil, child, key[1:], nil
+	default:
+		return nil, nil, nil, fmt.Errorf("malformed proof node with %d elements", count)
+	}
+}
+
+// splitElem returns the first element of an encoded list, the content of strings and the encoding of lists, which
+// are embedded nodes
+func splitElem(elems []byte) (elem, rest []byte, err error) {
+	kind, content, rest, err := rlp.Split(elems)
+	if err != nil {
+		return nil, nil, fmt.Errorf("malformed proof node: %v", err)
+	}
+	if kind == rlp.List {
+		return elems[:len(elems)-len(rest)], rest, nil
+	}
+	return content, rest, nil
+}
+
+// compactToNibbles decodes the hex-prefix encoding of a trie node key
+func compactToNibbles(compact []byte) (nibbles []byte, isLeaf bool) {
+	if len(compact) == 0 {
+		return nil, false
+	}
+	nibbles = keyToNibbles(compact)
+	isLeaf = nibbles[0] >= 2
+	// odd length keys keep their first nibble in the flags byte
+	if nibbles[0]&1 == 1 {
+		return nibbles[1:], isLeaf
+	}
+	return nibbles[2:], isLeaf
+}
+
+func keyToNibbles(key []byte) []byte {
+	nibbles := make([]byte, 0, len(key)*2)
+	for _, b := range key {
+		nibbles = append(nibbles, b/16, b%16)
+	}
+	return nibbles
+}
+
+func keccak256(data []byte) Hash32 {
+	d := sha3.NewKeccak256()
+	d.Write(data)
+	return BytesToHash(d.Sum(nil))
+}
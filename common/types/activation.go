@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"github.com/spacemeshos/go-spacemesh/common/util"
 	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/signing"
 	"github.com/spacemeshos/poet/shared"
 	"github.com/spacemeshos/post/proving"
 	"github.com/spacemeshos/sha256-simd"
@@ -164,7 +165,8 @@ type InnerActivationTx struct {
 // they are eligible to actively participate in the Spacemesh protocol in the next epoch.
 type ActivationTx struct {
 	*InnerActivationTx
-	Sig []byte
+	Sig    []byte
+	signer *signing.PublicKey // non-exported cache of the public key extracted from Sig
 }
 
 // NewActivationTx returns a new activation transaction. The ATXID is calculated and cached.
@@ -231,6 +233,34 @@ func (atx *ActivationTx) CalcAndSetID() {
 	atx.SetID(&id)
 }
 
+// Signer returns the public key extracted from the signature by ExtractSigners, or nil if it wasn't extracted.
+func (atx *ActivationTx) Signer() *signing.PublicKey {
+	return atx.signer
+}
+
+// ExtractSigners extracts the public keys from the signatures of the ATXs in parallel and caches them, so that they
+// aren't extracted again when the ATXs are validated. It returns an error if any of the signatures is malformed.
+func ExtractSigners(atxs []*ActivationTx) error {
+	extractor := signing.NewKeyExtractor(len(atxs))
+	for _, atx := range atxs {
+		bts, err := atx.InnerBytes()
+		if err != nil {
+			return fmt.Errorf("failed to marshal atx: %v", err)
+		}
+		extractor.Add(bts, atx.Sig)
+	}
+	keys, errs := extractor.ExtractParallel()
+	for i, err := range errs {
+		if err != nil {
+			return fmt.Errorf("failed to extract public key of atx %v: %v", atxs[i].ShortString(), err)
+		}
+	}
+	for i, atx := range atxs {
+		atx.signer = keys[i]
+	}
+	return nil
+}
+
 // GetPoetProofRef returns the reference to the PoET proof.
 func (atx *ActivationTx) GetPoetProofRef() []byte {
 	return atx.Nipst.PostProof.Challenge
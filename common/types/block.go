@@ -209,6 +209,31 @@ func (b *Block) Initialize() {
 	b.minerID = signing.NewPublicKey(pubkey)
 }
 
+// InitializeBlocks initializes the blocks like Initialize does, but extracts the MinerIDs from the signatures in
+// parallel. Unlike Initialize it doesn't panic on a bad signature, it returns an error and leaves the blocks
+// uninitialized.
+func InitializeBlocks(blocks []*Block) error {
+	extractor := signing.NewKeyExtractor(len(blocks))
+	for _, b := range blocks {
+		blockBytes, err := InterfaceToBytes(b.MiniBlock)
+		if err != nil {
+			return fmt.Errorf("failed to marshal block: %v", err)
+		}
+		b.id = BlockID(CalcHash32(blockBytes).ToHash20())
+		extractor.Add(blockBytes, b.Signature)
+	}
+	keys, errs := extractor.ExtractParallel()
+	for i, err := range errs {
+		if err != nil {
+			return fmt.Errorf("failed to extract public key of block %v: %v", blocks[i].id, err)
+		}
+	}
+	for i, b := range blocks {
+		b.minerID = keys[i]
+	}
+	return nil
+}
+
 // Hash32 returns a Hash32 whose first 20 bytes are the bytes of this BlockID, it is right-padded with zeros.
 // This implements the sync.item interface.
 func (b Block) Hash32() Hash32 {
@@ -3,6 +3,8 @@ package types
 import (
 	"github.com/spacemeshos/go-spacemesh/log"
 	"github.com/spacemeshos/go-spacemesh/rand"
+	"github.com/spacemeshos/go-spacemesh/signing"
+	"github.com/stretchr/testify/require"
 	"testing"
 	"time"
 )
@@ -36,3 +38,24 @@ func TestFields(t *testing.T) {
 	b.ActiveSet = &[]ATXID{atx1, atx2, atx3}
 	log.With().Info("got new block", b.Fields()...)
 }
+
+func TestInitializeBlocks(t *testing.T) {
+	signer := signing.NewEdSigner()
+	blocks := make([]*Block, 0, 3)
+	for i := 0; i < 3; i++ {
+		b := &Block{MiniBlock: MiniBlock{BlockHeader: BlockHeader{LayerIndex: LayerID(i)}}}
+		b.Signature = signer.Sign(b.Bytes())
+		blocks = append(blocks, b)
+	}
+	require.NoError(t, InitializeBlocks(blocks))
+	for _, b := range blocks {
+		expected := &Block{MiniBlock: b.MiniBlock, Signature: b.Signature}
+		expected.Initialize()
+		require.Equal(t, expected.ID(), b.ID())
+		require.Equal(t, signer.PublicKey().Bytes(), b.MinerID().Bytes())
+	}
+
+	// a malformed signature fails the batch
+	blocks[1].Signature = blocks[1].Signature[:10]
+	require.Error(t, InitializeBlocks(blocks))
+}
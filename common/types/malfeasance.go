@@ -0,0 +1,49 @@
+package types
+
+import (
+	"fmt"
+
+	"github.com/spacemeshos/go-spacemesh/log"
+)
+
+// MalfeasanceType is the kind of protocol violation a MalfeasanceProof proves.
+type MalfeasanceType uint8
+
+const (
+	// MultipleAtxs means that the smesher published more than one ATX in the same epoch.
+	MultipleAtxs MalfeasanceType = iota + 1
+	// HareEquivocation means that the smesher sent conflicting hare messages in the same round of a layer.
+	HareEquivocation
+)
+
+// String returns a human readable name of the malfeasance type.
+func (t MalfeasanceType) String() string {
+	switch t {
+	case MultipleAtxs:
+		return "multiple atxs"
+	case HareEquivocation:
+		return "hare equivocation"
+	default:
+		return fmt.Sprintf("unknown (%d)", uint8(t))
+	}
+}
+
+// MalfeasanceProof proves that a smesher broke the protocol. It holds the conflicting messages signed by the smesher,
+// which anyone can verify independently.
+type MalfeasanceProof struct {
+	NodeID NodeID
+	// Layer is the layer in which the conflicting messages were published.
+	Layer LayerID
+	Type  MalfeasanceType
+	// Messages are the serialized conflicting messages.
+	Messages [][]byte
+}
+
+// Fields returns an array of LoggableFields for logging
+func (p *MalfeasanceProof) Fields() []log.LoggableField {
+	return []log.LoggableField{
+		p.NodeID,
+		p.Layer,
+		log.String("malfeasance_type", p.Type.String()),
+	}
+}
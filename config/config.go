@@ -8,6 +8,9 @@ import (
 
 	"github.com/spacemeshos/go-spacemesh/activation"
 	apiConfig "github.com/spacemeshos/go-spacemesh/api/config"
+	"github.com/spacemeshos/go-spacemesh/database"
+	"github.com/spacemeshos/go-spacemesh/events/broker"
+	"github.com/spacemeshos/go-spacemesh/events/webhook"
 	"github.com/spacemeshos/go-spacemesh/filesystem"
 	hareConfig "github.com/spacemeshos/go-spacemesh/hare/config"
 	eligConfig "github.com/spacemeshos/go-spacemesh/hare/eligibility/config"
@@ -43,6 +46,8 @@ type Config struct {
 	REWARD          mesh.Config           `mapstructure:"reward"`
 	POST            postConfig.Config     `mapstructure:"post"`
 	LOGGING         LoggerConfig          `mapstructure:"logging"`
+	WEBHOOKS        webhook.Config        `mapstructure:"webhooks"`
+	BROKER          broker.Config         `mapstructure:"broker"`
 }
 
 // DataDir returns the absolute path to use for the node's data. This is the tilde-expanded path given in the config
@@ -99,7 +104,71 @@ type BaseConfig struct {
 
 	TxsPerBlock int `mapstructure:"txs-per-block"`
 
+	BlockSigningBudget int `mapstructure:"block-signing-budget"` // time in ms reserved for signing and storing a block after selecting its txs
+
 	BlockCacheSize int `mapstructure:"block-cache-size"`
+
+	RecoverFrom string `mapstructure:"recover-from"` // path or url of a checkpoint to restore the node from
+
+	RecoverHash string `mapstructure:"recover-hash"` // expected sha256 of the checkpoint, in hex
+
+	CheckpointSync string `mapstructure:"checkpoint-sync"` // path or url of a trusted checkpoint a new node syncs from
+
+	CheckpointSyncHash string `mapstructure:"checkpoint-sync-hash"` // sha256 of the trusted checkpoint, in hex
+
+	SnapshotSync bool `mapstructure:"snapshot-sync"` // a new node syncs a snapshot of the state from peers
+
+	SnapshotRoots []string `mapstructure:"snapshot-roots"` // layer:root of the trusted state roots of the snapshots a new node may restore
+
+	SyncFetchLayers int `mapstructure:"sync-fetch-layers"` // number of layers fetched concurrently while out of sync
+
+	SyncPeerRequests int `mapstructure:"sync-peer-requests"` // max sync requests in flight to a single peer, 0 for no limit
+
+	SyncBandwidth int `mapstructure:"sync-bandwidth"` // max bytes per second received by sync, 0 for no limit
+
+	SyncRequests int `mapstructure:"sync-requests"` // max sync requests in flight to all peers, 0 for no limit
+
+	SyncHeadersFirst bool `mapstructure:"sync-headers-first"` // fetch the blocks of all missing layers before their transactions
+
+	SyncAuditInterval time.Duration `mapstructure:"sync-audit-interval"` // interval between audits of the recent layers for missing data, 0 disables them
+
+	SyncAuditLayers int `mapstructure:"sync-audit-layers"` // number of recent validated layers scanned by an audit
+
+	TrustedLayers []string `mapstructure:"trusted-layers"` // layer:hash of layers whose history sync must pass through
+
+	NodeMode string `mapstructure:"node-mode"` // archival keeps the whole history of the mesh, pruned only the recent layers
+
+	PruneWindow int `mapstructure:"prune-window"` // layers a pruned node keeps below the latest layer applied to the state
+
+	TortoiseRerunInterval time.Duration `mapstructure:"tortoise-rerun-interval"` // interval of tortoise reruns from genesis, 0 disables them
+
+	TortoiseWindow int `mapstructure:"tortoise-window"` // max layers the tortoise keeps in memory below the latest layer, above the verified layer it keeps all of them, 0 for no bound
+
+	ReorgWindow int `mapstructure:"reorg-window"` // layers below the verified layer whose validity the tortoise may revise, at least hdist
+
+	RetentionLayers int `mapstructure:"retention-layers"` // layers below the finalized layer whose consensus data is kept, 0 for all
+
+	RetentionInterval time.Duration `mapstructure:"retention-interval"` // interval of the deletion of the data out of the retention horizon
+
+	DBCompactionWindow string `mapstructure:"db-compaction-window"` // daily HH:MM-HH:MM UTC window of database compactions, empty to disable
+
+	DBMigrationDryRun bool `mapstructure:"db-migration-dry-run"` // only report the pending database migrations, and don't start the node
+
+	DBMigrationBackupDir string `mapstructure:"db-migration-backup-dir"` // where the databases are backed up before they're migrated, empty to skip the backup
+
+	StateDBPath string `mapstructure:"state-db-path"` // directory the state database is kept in, the data directory if empty
+
+	MeshDBPath string `mapstructure:"mesh-db-path"` // directory the mesh databases are kept in, the data directory if empty
+
+	AtxDBPath string `mapstructure:"atx-db-path"` // directory the atx database is kept in, the data directory if empty
+
+	DBCache int `mapstructure:"db-cache"` // MiB of the block cache of each database
+
+	DBWriteBuffer int `mapstructure:"db-write-buffer"` // MiB of the write buffer of each database, two of which may be in use
+
+	DBOpenFiles int `mapstructure:"db-open-files"` // max files each database keeps open
+
+	DBMemory int `mapstructure:"db-memory"` // MiB shared by all the databases, sets their caches, write buffers and open files, 0 to disable
 }
 
 // LoggerConfig holds the logging level for each module.
@@ -141,31 +210,49 @@ func DefaultConfig() Config {
 		TIME:            timeConfig.DefaultConfig(),
 		REWARD:          mesh.DefaultMeshConfig(),
 		POST:            activation.DefaultConfig(),
+		WEBHOOKS:        webhook.DefaultConfig(),
+		BROKER:          broker.DefaultConfig(),
 	}
 }
 
 // DefaultBaseConfig returns a default configuration for spacemesh
 func defaultBaseConfig() BaseConfig {
 	return BaseConfig{
-		DataDirParent:       defaultDataDir,
-		ConfigFile:          defaultConfigFileName,
-		TestMode:            defaultTestMode,
-		CollectMetrics:      false,
-		MetricsPort:         1010,
-		OracleServer:        "http://localhost:3030",
-		OracleServerWorldID: 0,
-		GenesisTime:         time.Now().Format(time.RFC3339),
-		LayerDurationSec:    30,
-		LayersPerEpoch:      3,
-		PoETServer:          "127.0.0.1",
-		Hdist:               5,
-		GenesisActiveSet:    5,
-		BlockCacheSize:      20,
-		SyncRequestTimeout:  2000,
-		SyncInterval:        10,
-		SyncValidationDelta: 30,
-		AtxsPerBlock:        100,
-		TxsPerBlock:         200,
+		DataDirParent:         defaultDataDir,
+		ConfigFile:            defaultConfigFileName,
+		TestMode:              defaultTestMode,
+		CollectMetrics:        false,
+		MetricsPort:           1010,
+		OracleServer:          "http://localhost:3030",
+		OracleServerWorldID:   0,
+		GenesisTime:           time.Now().Format(time.RFC3339),
+		LayerDurationSec:      30,
+		LayersPerEpoch:        3,
+		PoETServer:            "127.0.0.1",
+		Hdist:                 5,
+		GenesisActiveSet:      5,
+		BlockCacheSize:        20,
+		SyncRequestTimeout:    2000,
+		SyncInterval:          10,
+		SyncValidationDelta:   30,
+		SyncFetchLayers:       4,
+		SyncPeerRequests:      8,
+		SyncHeadersFirst:      true,
+		SyncAuditInterval:     time.Hour,
+		SyncAuditLayers:       100,
+		NodeMode:              "archival",
+		PruneWindow:           1000,
+		TortoiseRerunInterval: 24 * time.Hour,
+		TortoiseWindow:        100,
+		ReorgWindow:           10,
+		RetentionLayers:       1000,
+		RetentionInterval:     10 * time.Minute,
+		AtxsPerBlock:          100,
+		TxsPerBlock:           200,
+		BlockSigningBudget:    1000,
+		DBCache:               database.DefaultTuning.Cache,
+		DBWriteBuffer:         database.DefaultTuning.WriteBuffer,
+		DBOpenFiles:           database.DefaultTuning.Handles,
 	}
 }
 
@@ -0,0 +1,149 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spacemeshos/go-spacemesh/database"
+	"github.com/spacemeshos/go-spacemesh/filesystem"
+)
+
+// The databases of the node are kept in its data directory, unless the state, mesh or atx databases are given a path
+// of their own. This lets operators keep the small databases that are accessed on every layer on fast storage, and the
+// bulk of the data on larger and slower storage. The POST data is kept in its own directory.
+
+// DBPath returns the directory of the named database of a node that keeps its data in dataDir. Databases with a
+// configured path are kept in a directory of their name under it, e.g. the blocks database of the mesh is kept in
+// <mesh-db-path>/mesh/blocks.
+func (cfg *BaseConfig) DBPath(dataDir, name string) string {
+	base := dataDir
+	switch strings.SplitN(name, "/", 2)[0] {
+	case "state":
+		base = canonicalOr(cfg.StateDBPath, dataDir)
+	case "mesh":
+		base = canonicalOr(cfg.MeshDBPath, dataDir)
+	case "atx":
+		base = canonicalOr(cfg.AtxDBPath, dataDir)
+	}
+	return filepath.Join(base, filepath.FromSlash(name))
+}
+
+// StorageLayout returns the directories the node keeps its data in, by the option that sets them
+func (cfg *Config) StorageLayout() map[string]string {
+	dataDir := cfg.DataDir()
+	return map[string]string{
+		"data-folder":   dataDir,
+		"state-db-path": cfg.DBPath(dataDir, "state"),
+		"mesh-db-path":  cfg.DBPath(dataDir, "mesh"),
+		"atx-db-path":   cfg.DBPath(dataDir, "atx"),
+		"post-datadir":  filesystem.GetCanonicalPath(cfg.POST.DataDir),
+	}
+}
+
+// PrepareStorage validates the storage layout and creates its directories. The databases and the POST data may not
+// be kept inside one another, and the directories that hold them must be writable.
+func (cfg *Config) PrepareStorage() error {
+	layout := cfg.StorageLayout()
+	opts := make([]string, 0, len(layout))
+	for opt := range layout {
+		opts = append(opts, opt)
+	}
+	sort.Strings(opts)
+	for _, opt := range opts {
+		if opt == "data-folder" {
+			continue
+		}
+		for _, other := range opts {
+			if other == opt || other == "data-folder" || other == "post-datadir" {
+				continue
+			}
+			if isWithin(layout[opt], layout[other]) {
+				return fmt.Errorf("%v %v is inside the database of %v %v", opt, layout[opt], other, layout[other])
+			}
+		}
+	}
+	for _, opt := range opts {
+		dir := layout[opt]
+		if opt != "data-folder" && opt != "post-datadir" {
+			// the databases create their own directories
+			dir = filepath.Dir(dir)
+		}
+		if err := filesystem.ExistOrCreate(dir); err != nil {
+			return fmt.Errorf("could not create %v %v: %v", opt, dir, err)
+		}
+		if err := checkWritable(dir); err != nil {
+			return fmt.Errorf("%v %v isn't writable: %v", opt, dir, err)
+		}
+	}
+	return nil
+}
+
+// canonicalOr returns the canonical path of p, or def if p is empty
+func canonicalOr(p, def string) string {
+	if p == "" {
+		return def
+	}
+	return filesystem.GetCanonicalPath(p)
+}
+
+// isWithin returns true if path is dir or inside it
+func isWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// checkWritable checks that a file can be created in dir
+func checkWritable(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("not a directory")
+	}
+	f, err := ioutil.TempFile(dir, ".write-check")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// dbMemoryShares are the shares of the databases in the db-memory budget. The state and the blocks are read on every
+// layer, the databases missing here get a single share.
+var dbMemoryShares = map[string]int{
+	"state":             4,
+	"atx":               2,
+	"poet":              1,
+	"ids":               1,
+	"store":             1,
+	"mesh/blocks":       4,
+	"mesh/layers":       2,
+	"mesh/validity":     1,
+	"mesh/transactions": 2,
+	"mesh/general":      1,
+	"mesh/unappliedTxs": 1,
+	"appliedTxs":        1,
+	"receipts":          1,
+}
+
+// DBTuning returns the tuning of the named database. With a db-memory budget, the database gets its share of the
+// budget, otherwise the sizes set for every database.
+func (cfg *BaseConfig) DBTuning(name string) database.Tuning {
+	if cfg.DBMemory <= 0 {
+		return database.Tuning{Cache: cfg.DBCache, WriteBuffer: cfg.DBWriteBuffer, Handles: cfg.DBOpenFiles}
+	}
+	total := 0
+	for _, share := range dbMemoryShares {
+		total += share
+	}
+	share, ok := dbMemoryShares[name]
+	if !ok {
+		share = 1
+	}
+	return database.MemoryTuning(cfg.DBMemory * share / total)
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spacemeshos/go-spacemesh/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_DBPath(t *testing.T) {
+	config := DefaultConfig()
+	dataDir := filepath.Join("data", "1")
+	assert.Equal(t, filepath.Join(dataDir, "state"), config.DBPath(dataDir, "state"))
+	assert.Equal(t, filepath.Join(dataDir, "mesh", "blocks"), config.DBPath(dataDir, "mesh/blocks"))
+
+	config.StateDBPath = "ssd"
+	config.MeshDBPath = "hdd"
+	assert.Equal(t, filepath.Join("ssd", "state"), config.DBPath(dataDir, "state"))
+	assert.Equal(t, filepath.Join("hdd", "mesh"), config.DBPath(dataDir, "mesh"))
+	assert.Equal(t, filepath.Join("hdd", "mesh", "blocks"), config.DBPath(dataDir, "mesh/blocks"))
+	assert.Equal(t, filepath.Join(dataDir, "atx"), config.DBPath(dataDir, "atx"))
+	assert.Equal(t, filepath.Join(dataDir, "receipts"), config.DBPath(dataDir, "receipts"))
+}
+
+func TestConfig_PrepareStorage(t *testing.T) {
+	r := require.New(t)
+	dir, err := ioutil.TempDir("", "storage_test")
+	r.NoError(err)
+	defer os.RemoveAll(dir)
+
+	config := DefaultConfig()
+	config.DataDirParent = filepath.Join(dir, "data")
+	config.StateDBPath = filepath.Join(dir, "ssd")
+	config.AtxDBPath = filepath.Join(dir, "ssd")
+	config.MeshDBPath = filepath.Join(dir, "hdd")
+	config.POST.DataDir = filepath.Join(dir, "post")
+	r.NoError(config.PrepareStorage())
+	for _, p := range []string{config.DataDir(), config.StateDBPath, config.MeshDBPath, config.POST.DataDir} {
+		r.DirExists(p)
+	}
+	// the databases create their own directories
+	_, err = os.Stat(filepath.Join(dir, "ssd", "state"))
+	r.True(os.IsNotExist(err))
+
+	// databases may not be kept inside other databases
+	config.MeshDBPath = filepath.Join(dir, "ssd", "state")
+	r.Error(config.PrepareStorage())
+	config.MeshDBPath = filepath.Join(dir, "hdd")
+	config.POST.DataDir = filepath.Join(dir, "hdd", "mesh", "post")
+	r.Error(config.PrepareStorage())
+	config.POST.DataDir = filepath.Join(dir, "post")
+
+	// and the paths must be directories
+	r.NoError(ioutil.WriteFile(filepath.Join(dir, "file"), nil, 0600))
+	config.AtxDBPath = filepath.Join(dir, "file")
+	r.Error(config.PrepareStorage())
+}
+
+func TestConfig_DBTuning(t *testing.T) {
+	config := DefaultConfig()
+	assert.Equal(t, database.DefaultTuning, config.DBTuning("state"))
+	config.DBCache = 64
+	assert.Equal(t, 64, config.DBTuning("mesh/blocks").Cache)
+
+	// the budget is shared by the databases, and the hot ones get more of it
+	config.DBMemory = 1100
+	assert.Equal(t, database.Tuning{Cache: 100, WriteBuffer: 50, Handles: 800}, config.DBTuning("state"))
+	assert.Equal(t, database.Tuning{Cache: 25, WriteBuffer: 12, Handles: 200}, config.DBTuning("receipts"))
+	total := 0
+	for name := range dbMemoryShares {
+		tuning := config.DBTuning(name)
+		total += tuning.Cache + 2*tuning.WriteBuffer
+	}
+	assert.LessOrEqual(t, total, config.DBMemory)
+
+	// low budgets are raised to the minimums a database works with
+	config.DBMemory = 10
+	assert.Equal(t, database.Tuning{Cache: 1, WriteBuffer: 1, Handles: 16}, config.DBTuning("receipts"))
+}
@@ -0,0 +1,231 @@
+package database
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// A backup holds the content of every registered database as of a snapshot taken while the node runs. The snapshots of
+// all databases are taken together, before any of them is read, so the backup doesn't see writes made while it's
+// written. The snapshots are taken under the write lock of the registry, which the mesh holds while it applies a layer
+// to the mesh and the state, so a backup never holds half of a layer. Other writes, such as gossiped blocks and atxs,
+// aren't paused, so the backup is only consistent per database for them. Every database is dumped to its own file as a sequence of records, a uvarint length prefixed key followed by
+// a uvarint length prefixed value, in key order. The manifest lists the files along with their sizes and hashes, and is
+// written first, so that a streamed backup can be validated while it's read.
+
+// BackupVersion is the version of the backup format
+const BackupVersion = 1
+
+// ManifestFile is the name of the manifest file of a backup
+const ManifestFile = "manifest.json"
+
+// BackupManifest describes the content of a backup
+type BackupManifest struct {
+	Version   int              `json:"version"`
+	Created   int64            `json:"created"` // unix time of the snapshot
+	Databases []BackupDatabase `json:"databases"`
+}
+
+// BackupDatabase describes the dump of a single database in a backup
+type BackupDatabase struct {
+	Name string `json:"name"`
+	File string `json:"file"` // slash separated path in the backup
+	Keys int64  `json:"keys"`
+	Size int64  `json:"size"` // bytes
+	Hash string `json:"hash"` // hex encoded sha256 of the file
+}
+
+// Size returns the total size of the dumps of the databases in the backup
+func (m *BackupManifest) Size() int64 {
+	var size int64
+	for _, db := range m.Databases {
+		size += db.Size
+	}
+	return size
+}
+
+// Snapshot is a consistent view of all registered databases, from which a backup is written. It must be released.
+type Snapshot struct {
+	manifest *BackupManifest
+	snaps    []*leveldb.Snapshot // in the order of the manifest
+}
+
+// Snapshot takes a snapshot of all registered databases, and hashes their content
+func (r *Registry) Snapshot() (*Snapshot, error) {
+	s := &Snapshot{manifest: &BackupManifest{Version: BackupVersion, Created: time.Now().Unix()}}
+	if err := r.takeSnapshots(s); err != nil {
+		s.Release()
+		return nil, err
+	}
+
+	for i, snap := range s.snaps {
+		db := &s.manifest.Databases[i]
+		h := sha256.New()
+		cw := &countingWriter{w: h}
+		keys, err := dump(snap, cw)
+		if err != nil {
+			s.Release()
+			return nil, fmt.Errorf("could not read snapshot of %v: %v", db.Name, err)
+		}
+		db.Keys = keys
+		db.Size = cw.n
+		db.Hash = hex.EncodeToString(h.Sum(nil))
+	}
+	r.log.With().Info("took snapshot of databases",
+		log.Int("databases", len(s.snaps)),
+		log.Int64("size", s.manifest.Size()))
+	return s, nil
+}
+
+// takeSnapshots takes the snapshots of all registered databases while their writes are paused
+func (r *Registry) takeSnapshots(s *Snapshot) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.writes != nil {
+		r.writes.Lock()
+		defer r.writes.Unlock()
+	}
+	for _, name := range r.names() {
+		snap, err := r.dbs[name].db.GetSnapshot()
+		if err != nil {
+			return fmt.Errorf("could not take snapshot of %v: %v", name, err)
+		}
+		s.snaps = append(s.snaps, snap)
+		s.manifest.Databases = append(s.manifest.Databases, BackupDatabase{Name: name, File: name + ".kv"})
+	}
+	return nil
+}
+
+// Manifest returns the manifest of the backup written from the snapshot
+func (s *Snapshot) Manifest() *BackupManifest {
+	return s.manifest
+}
+
+// Release releases the snapshots of the databases
+func (s *Snapshot) Release() {
+	for _, snap := range s.snaps {
+		snap.Release()
+	}
+	s.snaps = nil
+}
+
+// WriteDir writes the backup to the directory, which must not exist or be empty
+func (s *Snapshot) WriteDir(dir string) error {
+	if files, err := ioutil.ReadDir(dir); err == nil && len(files) > 0 {
+		return fmt.Errorf("backup directory %v isn't empty", dir)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	manifest, err := json.MarshalIndent(s.manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, ManifestFile), manifest, 0600); err != nil {
+		return err
+	}
+	for i, snap := range s.snaps {
+		file := filepath.Join(dir, filepath.FromSlash(s.manifest.Databases[i].File))
+		if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+			return err
+		}
+		if err := writeDump(file, snap); err != nil {
+			return fmt.Errorf("could not write %v: %v", file, err)
+		}
+	}
+	return nil
+}
+
+// WriteTar writes the backup as a tarball
+func (s *Snapshot) WriteTar(w io.Writer) error {
+	tw := tar.NewWriter(w)
+	dirs := make(map[string]bool)
+	manifest, err := json.MarshalIndent(s.manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeTarHeader(tw, dirs, ManifestFile, int64(len(manifest))); err != nil {
+		return err
+	}
+	if _, err := tw.Write(manifest); err != nil {
+		return err
+	}
+	for i, snap := range s.snaps {
+		db := s.manifest.Databases[i]
+		if err := writeTarHeader(tw, dirs, db.File, db.Size); err != nil {
+			return err
+		}
+		if _, err := dump(snap, tw); err != nil {
+			return fmt.Errorf("could not write %v: %v", db.File, err)
+		}
+	}
+	return tw.Close()
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// writeTarHeader writes the header of a file, preceded by the header of its directory unless it was already written
+func writeTarHeader(tw *tar.Writer, dirs map[string]bool, name string, size int64) error {
+	if dir := path.Dir(name); dir != "." && !dirs[dir] {
+		if err := tw.WriteHeader(&tar.Header{Typeflag: tar.TypeDir, Name: dir + "/", Mode: 0700}); err != nil {
+			return err
+		}
+		dirs[dir] = true
+	}
+	return tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: name, Size: size, Mode: 0600})
+}
+
+func writeDump(file string, snap *leveldb.Snapshot) error {
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := dump(snap, f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// dump writes all records of the snapshot to w, and returns the number of keys
+func dump(snap *leveldb.Snapshot, w io.Writer) (int64, error) {
+	it := snap.NewIterator(nil, nil)
+	defer it.Release()
+	var keys int64
+	buf := make([]byte, binary.MaxVarintLen64)
+	for it.Next() {
+		for _, b := range [][]byte{it.Key(), it.Value()} {
+			n := binary.PutUvarint(buf, uint64(len(b)))
+			if _, err := w.Write(buf[:n]); err != nil {
+				return keys, err
+			}
+			if _, err := w.Write(b); err != nil {
+				return keys, err
+			}
+		}
+		keys++
+	}
+	return keys, it.Error()
+}
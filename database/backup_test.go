@@ -0,0 +1,248 @@
+package database
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/stretchr/testify/require"
+)
+
+// newBackupRegistry returns a registry of a flat and a nested database holding keys
+func newBackupRegistry(t *testing.T, dir string) (*Registry, []*LDBDatabase) {
+	r := require.New(t)
+	registry := NewRegistry(log.NewDefault(t.Name()))
+	var dbs []*LDBDatabase
+	for i, name := range []string{"state", "mesh/blocks"} {
+		db, err := NewLDBDatabase(filepath.Join(dir, name), 0, 0, log.NewDefault(t.Name()))
+		r.NoError(err)
+		for k := 0; k < 10*(i+1); k++ {
+			r.NoError(db.Put([]byte(fmt.Sprintf("key%d", k)), []byte(fmt.Sprintf("%v-%d", name, k))))
+		}
+		registry.Register(name, db)
+		dbs = append(dbs, db)
+	}
+	return registry, dbs
+}
+
+// inDir returns the paths of databases kept in dir
+func inDir(dir string) func(string) string {
+	return func(name string) string {
+		return filepath.Join(dir, filepath.FromSlash(name))
+	}
+}
+
+func TestSnapshot_Write(t *testing.T) {
+	r := require.New(t)
+	dir, err := ioutil.TempDir("", "backup_test")
+	r.NoError(err)
+	defer os.RemoveAll(dir)
+	registry, dbs := newBackupRegistry(t, filepath.Join(dir, "data"))
+	defer func() {
+		for _, db := range dbs {
+			db.Close()
+		}
+	}()
+
+	snap, err := registry.Snapshot()
+	r.NoError(err)
+	defer snap.Release()
+	// writes after the snapshot aren't backed up
+	r.NoError(dbs[0].Put([]byte("later"), []byte("value")))
+
+	manifest := snap.Manifest()
+	r.Equal(BackupVersion, manifest.Version)
+	r.Len(manifest.Databases, 2)
+	r.Equal("mesh/blocks", manifest.Databases[0].Name)
+	r.Equal("mesh/blocks.kv", manifest.Databases[0].File)
+	r.Equal(int64(20), manifest.Databases[0].Keys)
+	r.Equal("state", manifest.Databases[1].Name)
+	r.Equal(int64(10), manifest.Databases[1].Keys)
+
+	// the files written to a directory match the manifest
+	backupDir := filepath.Join(dir, "backup")
+	r.NoError(snap.WriteDir(backupDir))
+	var written BackupManifest
+	data, err := ioutil.ReadFile(filepath.Join(backupDir, ManifestFile))
+	r.NoError(err)
+	r.NoError(json.Unmarshal(data, &written))
+	r.Equal(*manifest, written)
+	for _, db := range manifest.Databases {
+		data, err := ioutil.ReadFile(filepath.Join(backupDir, filepath.FromSlash(db.File)))
+		r.NoError(err)
+		r.Equal(db.Size, int64(len(data)))
+		hash := sha256.Sum256(data)
+		r.Equal(db.Hash, hex.EncodeToString(hash[:]))
+	}
+	r.Error(snap.WriteDir(backupDir), "the backup directory isn't empty")
+
+	// and so do the files of the tarball
+	var buf bytes.Buffer
+	r.NoError(snap.WriteTar(&buf))
+	tr := tar.NewReader(&buf)
+	hdr, err := tr.Next()
+	r.NoError(err)
+	r.Equal(ManifestFile, hdr.Name)
+	files := make(map[string][]byte)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		r.NoError(err)
+		if hdr.Typeflag == tar.TypeDir {
+			continue
+		}
+		files[hdr.Name], err = ioutil.ReadAll(tr)
+		r.NoError(err)
+	}
+	r.Len(files, 2)
+	for _, db := range manifest.Databases {
+		hash := sha256.Sum256(files[db.File])
+		r.Equal(db.Hash, hex.EncodeToString(hash[:]))
+	}
+}
+
+func TestSnapshot_WriteLock(t *testing.T) {
+	r := require.New(t)
+	dir, err := ioutil.TempDir("", "backup_test")
+	r.NoError(err)
+	defer os.RemoveAll(dir)
+	registry, dbs := newBackupRegistry(t, dir)
+	defer func() {
+		for _, db := range dbs {
+			db.Close()
+		}
+	}()
+	var writes sync.Mutex
+	registry.SetWriteLock(&writes)
+
+	// a writer spanning both databases holds the lock between its writes
+	writes.Lock()
+	r.NoError(dbs[0].Put([]byte("layer"), []byte("value")))
+	snaps := make(chan *Snapshot, 1)
+	go func() {
+		snap, err := registry.Snapshot()
+		r.NoError(err)
+		snaps <- snap
+	}()
+	select {
+	case <-snaps:
+		r.Fail("took a snapshot in the middle of the writes")
+	case <-time.After(100 * time.Millisecond):
+	}
+	r.NoError(dbs[1].Put([]byte("layer"), []byte("value")))
+	writes.Unlock()
+
+	snap := <-snaps
+	defer snap.Release()
+	manifest := snap.Manifest()
+	r.Equal(int64(21), manifest.Databases[0].Keys)
+	r.Equal(int64(11), manifest.Databases[1].Keys)
+}
+
+func TestRestoreBackup(t *testing.T) {
+	r := require.New(t)
+	dir, err := ioutil.TempDir("", "restore_test")
+	r.NoError(err)
+	defer os.RemoveAll(dir)
+	registry, dbs := newBackupRegistry(t, filepath.Join(dir, "data"))
+	snap, err := registry.Snapshot()
+	r.NoError(err)
+	backupDir := filepath.Join(dir, "backup")
+	r.NoError(snap.WriteDir(backupDir))
+	tarball := filepath.Join(dir, "backup.tar")
+	f, err := os.Create(tarball)
+	r.NoError(err)
+	r.NoError(snap.WriteTar(f))
+	r.NoError(f.Close())
+	snap.Release()
+	for _, db := range dbs {
+		db.Close()
+	}
+
+	lg := log.NewDefault(t.Name())
+	for _, src := range []string{backupDir, tarball} {
+		restoreDir := filepath.Join(dir, "restored-"+filepath.Base(src))
+		manifest, err := RestoreBackup(src, inDir(restoreDir), lg)
+		r.NoError(err)
+		r.Len(manifest.Databases, 2)
+		db, err := NewLDBDatabase(filepath.Join(restoreDir, "mesh", "blocks"), 0, 0, lg)
+		r.NoError(err)
+		value, err := db.Get([]byte("key19"))
+		r.NoError(err)
+		r.Equal([]byte("mesh/blocks-19"), value)
+		db.Close()
+
+		// databases aren't restored over existing ones
+		_, err = RestoreBackup(src, inDir(restoreDir), lg)
+		r.Error(err)
+	}
+
+	// databases are restored into their own paths
+	statePath := filepath.Join(dir, "ssd")
+	restoreDir := filepath.Join(dir, "hdd")
+	_, err = RestoreBackup(tarball, func(name string) string {
+		if name == "state" {
+			return filepath.Join(statePath, name)
+		}
+		return inDir(restoreDir)(name)
+	}, lg)
+	r.NoError(err)
+	r.DirExists(filepath.Join(statePath, "state"))
+	r.DirExists(filepath.Join(restoreDir, "mesh", "blocks"))
+	_, err = os.Stat(filepath.Join(restoreDir, "state"))
+	r.True(os.IsNotExist(err))
+
+	// a corrupted dump fails the restore, and no database is left behind
+	file := filepath.Join(backupDir, "state.kv")
+	data, err := ioutil.ReadFile(file)
+	r.NoError(err)
+	data[len(data)-1]++
+	r.NoError(ioutil.WriteFile(file, data, 0600))
+	restoreDir = filepath.Join(dir, "corrupted")
+	_, err = RestoreBackup(backupDir, inDir(restoreDir), lg)
+	r.Error(err)
+	r.Contains(err.Error(), "hash mismatch")
+	_, err = os.Stat(filepath.Join(restoreDir, "mesh", "blocks"))
+	r.True(os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(restoreDir, "state"))
+	r.True(os.IsNotExist(err))
+}
+
+func TestBackupManifest_Validate(t *testing.T) {
+	hash := hex.EncodeToString(make([]byte, sha256.Size))
+	for _, tc := range []struct {
+		name     string
+		manifest BackupManifest
+		valid    bool
+	}{
+		{"valid", BackupManifest{Version: BackupVersion, Databases: []BackupDatabase{{Name: "mesh/blocks", File: "mesh/blocks.kv", Hash: hash}}}, true},
+		{"version", BackupManifest{Version: BackupVersion + 1, Databases: []BackupDatabase{{Name: "state", File: "state.kv", Hash: hash}}}, false},
+		{"empty", BackupManifest{Version: BackupVersion}, false},
+		{"escaping name", BackupManifest{Version: BackupVersion, Databases: []BackupDatabase{{Name: "../state", File: "state.kv", Hash: hash}}}, false},
+		{"absolute file", BackupManifest{Version: BackupVersion, Databases: []BackupDatabase{{Name: "state", File: "/state.kv", Hash: hash}}}, false},
+		{"duplicate file", BackupManifest{Version: BackupVersion, Databases: []BackupDatabase{{Name: "a", File: "a.kv", Hash: hash}, {Name: "b", File: "a.kv", Hash: hash}}}, false},
+		{"hash", BackupManifest{Version: BackupVersion, Databases: []BackupDatabase{{Name: "state", File: "state.kv", Hash: "12"}}}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.manifest.validate()
+			if tc.valid {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
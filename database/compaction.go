@@ -0,0 +1,182 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// Deleted and overwritten keys keep taking space on disk until leveldb compacts the tables that hold them, which it
+// only does as a side effect of writes. Nodes that prune or rewrite a lot of data compact their databases explicitly,
+// on demand or every day in a maintenance window, when the extra disk reads don't compete with syncing and mining.
+
+// ErrCompactionRunning is returned when a compaction is requested while another one runs
+var ErrCompactionRunning = errors.New("a compaction is already running")
+
+// CompactionResult describes the compaction of a single database
+type CompactionResult struct {
+	Name       string
+	SizeBefore int64 // bytes on disk
+	SizeAfter  int64
+	Duration   time.Duration
+	Err        error
+}
+
+// Reclaimed returns the number of bytes the compaction freed on disk, negative if the database grew meanwhile
+func (r CompactionResult) Reclaimed() int64 {
+	return r.SizeBefore - r.SizeAfter
+}
+
+// Compact compacts the whole key range of the database, while it keeps serving reads and writes
+func (db *LDBDatabase) Compact() error {
+	return db.db.CompactRange(util.Range{})
+}
+
+// DiskSize returns the total size of the files of the database
+func (db *LDBDatabase) DiskSize() (int64, error) {
+	var size int64
+	err := filepath.Walk(db.fn, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
+
+// Compact compacts all registered databases one after the other
+func (r *Registry) Compact() ([]CompactionResult, error) {
+	return r.compact(time.Time{})
+}
+
+// compact compacts the registered databases one after the other. Databases aren't compacted after the deadline,
+// unless it's zero.
+func (r *Registry) compact(deadline time.Time) ([]CompactionResult, error) {
+	r.compactMu.Lock()
+	if r.compacting {
+		r.compactMu.Unlock()
+		return nil, ErrCompactionRunning
+	}
+	r.compacting = true
+	r.compactMu.Unlock()
+	defer func() {
+		r.compactMu.Lock()
+		r.compacting = false
+		r.compactMu.Unlock()
+	}()
+
+	var results []CompactionResult
+	for _, name := range r.Names() {
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			r.log.With().Warning("maintenance window ended before all databases were compacted",
+				log.String("next_db", name))
+			break
+		}
+		db := r.Get(name)
+		if db == nil {
+			continue
+		}
+		res := CompactionResult{Name: name}
+		start := time.Now()
+		res.SizeBefore, res.Err = db.DiskSize()
+		if res.Err == nil {
+			res.Err = db.Compact()
+		}
+		if res.Err == nil {
+			res.SizeAfter, res.Err = db.DiskSize()
+		}
+		res.Duration = time.Since(start)
+		if res.Err != nil {
+			r.log.With().Error("could not compact database", log.String("db", name), log.Err(res.Err))
+		} else {
+			observeCompaction(res)
+			r.log.With().Info("compacted database", log.String("db", name),
+				log.Int64("size_before", res.SizeBefore),
+				log.Int64("reclaimed", res.Reclaimed()),
+				log.String("duration", res.Duration.String()))
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// MaintenanceWindow is a daily period of time, in UTC, in which the node runs its maintenance jobs
+type MaintenanceWindow struct {
+	Start time.Duration // since midnight
+	End   time.Duration // since midnight, before the start if the window spans midnight
+}
+
+// ParseMaintenanceWindow parses a window given as HH:MM-HH:MM in UTC
+func ParseMaintenanceWindow(s string) (*MaintenanceWindow, error) {
+	var sh, sm, eh, em int
+	if n, err := fmt.Sscanf(s, "%d:%d-%d:%d", &sh, &sm, &eh, &em); err != nil || n != 4 {
+		return nil, fmt.Errorf("maintenance window must be HH:MM-HH:MM, got %q", s)
+	}
+	for _, v := range []struct{ h, m int }{{sh, sm}, {eh, em}} {
+		if v.h < 0 || v.h > 23 || v.m < 0 || v.m > 59 {
+			return nil, fmt.Errorf("invalid time of day in maintenance window %q", s)
+		}
+	}
+	w := &MaintenanceWindow{
+		Start: time.Duration(sh)*time.Hour + time.Duration(sm)*time.Minute,
+		End:   time.Duration(eh)*time.Hour + time.Duration(em)*time.Minute,
+	}
+	if w.Start == w.End {
+		return nil, fmt.Errorf("empty maintenance window %q", s)
+	}
+	return w, nil
+}
+
+// next returns the start and the end of the first window that ends after t
+func (w *MaintenanceWindow) next(t time.Time) (time.Time, time.Time) {
+	t = t.UTC()
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	length := w.End - w.Start
+	if length < 0 {
+		length += 24 * time.Hour
+	}
+	// the window that started yesterday may span midnight and still be open
+	for start := midnight.Add(w.Start - 24*time.Hour); ; start = start.Add(24 * time.Hour) {
+		if end := start.Add(length); end.After(t) {
+			return start, end
+		}
+	}
+}
+
+// ScheduleCompaction compacts the registered databases once in every maintenance window, until the registry is closed
+func (r *Registry) ScheduleCompaction(window *MaintenanceWindow) {
+	go func() {
+		for {
+			start, end := window.next(time.Now())
+			wait := time.Until(start)
+			if wait < 0 {
+				wait = 0
+			}
+			r.log.With().Info("scheduled database compaction", log.String("start", start.String()))
+			timer := time.NewTimer(wait)
+			select {
+			case <-r.exit:
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+			if _, err := r.compact(end); err != nil {
+				r.log.With().Warning("skipped scheduled database compaction", log.Err(err))
+			}
+			// don't compact again in the same window
+			select {
+			case <-r.exit:
+				return
+			case <-time.After(time.Until(end)):
+			}
+		}
+	}()
+}
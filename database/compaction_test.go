@@ -0,0 +1,97 @@
+package database
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_Compact(t *testing.T) {
+	r := require.New(t)
+	dir, err := ioutil.TempDir("", "compaction_test")
+	r.NoError(err)
+	defer os.RemoveAll(dir)
+	db, err := NewLDBDatabase(dir, 0, 0, log.NewDefault(t.Name()))
+	r.NoError(err)
+	defer db.Close()
+
+	registry := NewRegistry(log.NewDefault(t.Name()))
+	registry.Register("data", db)
+	registry.Register("memory", NewMemDatabase())
+	r.Equal([]string{"data"}, registry.Names())
+
+	value := bytes.Repeat([]byte{1}, 1024)
+	for i := 0; i < 10000; i++ {
+		r.NoError(db.Put([]byte(fmt.Sprintf("key%d", i)), value))
+	}
+	for i := 0; i < 10000; i++ {
+		r.NoError(db.Delete([]byte(fmt.Sprintf("key%d", i))))
+	}
+
+	results, err := registry.Compact()
+	r.NoError(err)
+	r.Len(results, 1)
+	r.Equal("data", results[0].Name)
+	r.NoError(results[0].Err)
+	r.True(results[0].Reclaimed() > 0)
+	r.Equal(results[0].SizeBefore-results[0].SizeAfter, results[0].Reclaimed())
+
+	// compactions don't run concurrently
+	registry.compacting = true
+	_, err = registry.Compact()
+	r.Equal(ErrCompactionRunning, err)
+	registry.compacting = false
+
+	// no database is compacted after the end of the maintenance window
+	results, err = registry.compact(time.Now().Add(-time.Second))
+	r.NoError(err)
+	r.Empty(results)
+}
+
+func TestParseMaintenanceWindow(t *testing.T) {
+	r := require.New(t)
+	w, err := ParseMaintenanceWindow("03:30-05:00")
+	r.NoError(err)
+	r.Equal(&MaintenanceWindow{Start: 3*time.Hour + 30*time.Minute, End: 5 * time.Hour}, w)
+
+	for _, s := range []string{"", "3-5", "03:30", "24:00-01:00", "03:60-04:00", "03:00-03:00"} {
+		_, err := ParseMaintenanceWindow(s)
+		r.Error(err, s)
+	}
+}
+
+func TestMaintenanceWindow_Next(t *testing.T) {
+	r := require.New(t)
+	day := time.Date(2021, 3, 10, 0, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) time.Time { return day.Add(d) }
+
+	w, err := ParseMaintenanceWindow("03:00-05:00")
+	r.NoError(err)
+	start, end := w.next(at(time.Hour))
+	r.Equal(at(3*time.Hour), start)
+	r.Equal(at(5*time.Hour), end)
+	// the window is open
+	start, end = w.next(at(4 * time.Hour))
+	r.Equal(at(3*time.Hour), start)
+	r.Equal(at(5*time.Hour), end)
+	// the window of the next day
+	start, end = w.next(at(6 * time.Hour))
+	r.Equal(at(27*time.Hour), start)
+	r.Equal(at(29*time.Hour), end)
+
+	// a window that spans midnight is still open after midnight
+	w, err = ParseMaintenanceWindow("23:00-01:00")
+	r.NoError(err)
+	start, end = w.next(at(30 * time.Minute))
+	r.Equal(at(-time.Hour), start)
+	r.Equal(at(time.Hour), end)
+	start, end = w.next(at(2 * time.Hour))
+	r.Equal(at(23*time.Hour), start)
+	r.Equal(at(25*time.Hour), end)
+}
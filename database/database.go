@@ -30,6 +30,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -40,6 +41,9 @@ const (
 // ErrNotFound is special type error for not found in DB
 var ErrNotFound = errors.ErrNotFound
 
+// ErrPruned is returned for data that a pruned node deleted because it's older than the history it keeps
+var ErrPruned = errors.New("pruned")
+
 // LDBDatabase  is a wrapper for leveldb database with concurrent access
 type LDBDatabase struct {
 	fn string      // filename for reporting
@@ -49,6 +53,8 @@ type LDBDatabase struct {
 	quitChan chan chan error // Quit channel to stop the metrics collection before closing the database
 
 	log log.Log // Contextual logger tracking the database path
+
+	metrics atomic.Value // *dbMetrics, set when the database is registered in a metered registry
 }
 
 // NewLDBDatabase returns a LevelDB wrapped object.
@@ -57,18 +63,27 @@ func NewLDBDatabase(file string, cache int, handles int, logger log.Log) (*LDBDa
 	if cache < 16 {
 		cache = 16
 	}
-	if handles < 16 {
-		handles = 16
-	}
+	return NewTunedLDBDatabase(file, Tuning{Cache: cache / 2, WriteBuffer: cache / 4, Handles: handles}, logger)
+}
+
+// NewTunedLDBDatabase returns a LevelDB wrapped object that uses the memory and the files of the tuning.
+func NewTunedLDBDatabase(file string, tuning Tuning, logger log.Log) (*LDBDatabase, error) {
+	tuning = tuning.withMinimums()
 	logger.With().Info("Allocated cache and file handles",
-		log.Int("cache_size", cache),
-		log.Int("num_handles", handles))
+		log.Int("cache_size", tuning.Cache),
+		log.Int("write_buffer", tuning.WriteBuffer),
+		log.Int("num_handles", tuning.Handles))
 
+	ldb := &LDBDatabase{
+		fn:  file,
+		log: logger,
+	}
 	// Open the db and recover any potential corruptions
 	db, err := leveldb.OpenFile(file, &opt.Options{
-		OpenFilesCacheCapacity: handles,
-		BlockCacheCapacity:     cache / 2 * opt.MiB,
-		WriteBuffer:            cache / 4 * opt.MiB, // Two of these are used internally
+		OpenFilesCacheCapacity: tuning.Handles,
+		BlockCacheCapacity:     tuning.Cache * opt.MiB,
+		BlockCacher:            newMeteredCacher(ldb),
+		WriteBuffer:            tuning.WriteBuffer * opt.MiB, // Two of these are used internally
 		Filter:                 filter.NewBloomFilter(10),
 	})
 	if _, corrupted := err.(*errors.ErrCorrupted); corrupted {
@@ -78,11 +93,8 @@ func NewLDBDatabase(file string, cache int, handles int, logger log.Log) (*LDBDa
 	if err != nil {
 		return nil, err
 	}
-	return &LDBDatabase{
-		fn:  file,
-		db:  db,
-		log: logger,
-	}, nil
+	ldb.db = db
+	return ldb, nil
 }
 
 // Path returns the path to the database directory.
@@ -92,16 +104,19 @@ func (db *LDBDatabase) Path() string {
 
 // Put puts the given key / value to the queue
 func (db *LDBDatabase) Put(key []byte, value []byte) error {
+	defer db.observe("put", time.Now())
 	return db.db.Put(key, value, nil)
 }
 
 // Has returns whether the db contains the key
 func (db *LDBDatabase) Has(key []byte) (bool, error) {
+	defer db.observe("has", time.Now())
 	return db.db.Has(key, nil)
 }
 
 // Get returns the given key if it's present.
 func (db *LDBDatabase) Get(key []byte) ([]byte, error) {
+	defer db.observe("get", time.Now())
 	dat, err := db.db.Get(key, nil)
 	if err != nil {
 		return nil, err
@@ -111,6 +126,7 @@ func (db *LDBDatabase) Get(key []byte) ([]byte, error) {
 
 // Delete deletes the key from the queue and database
 func (db *LDBDatabase) Delete(key []byte) error {
+	defer db.observe("delete", time.Now())
 	return db.db.Delete(key, nil)
 }
 
@@ -344,11 +360,11 @@ func (db *LDBDatabase) meter(refresh time.Duration) {
 
 //NewBatch creates a new batch write struct, able to add multiple values in a single operation
 func (db *LDBDatabase) NewBatch() Batch {
-	return &ldbBatch{db: db.db, b: new(leveldb.Batch)}
+	return &ldbBatch{db: db, b: new(leveldb.Batch)}
 }
 
 type ldbBatch struct {
-	db   *leveldb.DB
+	db   *LDBDatabase
 	b    *leveldb.Batch
 	size int
 }
@@ -366,7 +382,8 @@ func (b *ldbBatch) Delete(key []byte) error {
 }
 
 func (b *ldbBatch) Write() error {
-	return b.db.Write(b.b, nil)
+	defer b.db.observe("batch", time.Now())
+	return b.db.db.Write(b.b, nil)
 }
 
 func (b *ldbBatch) ValueSize() int {
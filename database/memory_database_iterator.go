@@ -1,7 +1,8 @@
 package database
 
 import (
-	"reflect"
+	"bytes"
+	"sort"
 )
 
 // MemDatabaseIterator is an iterator for memory database
@@ -25,7 +26,7 @@ func (iter *MemDatabaseIterator) Value() []byte {
 
 // Next advances iterator to next item
 func (iter *MemDatabaseIterator) Next() bool {
-	if iter.index == len(iter.keys)-1 {
+	if iter.index >= len(iter.keys)-1 {
 		return false
 	}
 
@@ -67,20 +68,13 @@ func (iter *MemDatabaseIterator) Prev() bool {
 	return true
 }
 
-// Seek returns true if key is found in iterator object
+// Seek moves the iterator to the first key that is greater or equal to the given key, it returns false if there is
+// no such key
 func (iter *MemDatabaseIterator) Seek(key []byte) bool {
-	size := len(iter.keys)
-	if size == 0 {
-		iter.index = 0
-		return false
-	}
-	for k := range iter.db {
-		if reflect.DeepEqual(k, key) {
-			return true
-		}
-	}
-
-	return false
+	iter.index = sort.Search(len(iter.keys), func(i int) bool {
+		return bytes.Compare(iter.keys[i], key) >= 0
+	})
+	return iter.index < len(iter.keys)
 }
 
 // Release is a stub to comply with DB interface
@@ -27,3 +27,19 @@ func TestMemoryDB_Iterator(t *testing.T) {
 	iter.Next()
 	checkRow(secondKey, secondValue, iter, t)
 }
+
+func TestMemoryDB_IteratorSeek(t *testing.T) {
+	db := NewMemDatabase()
+	db.Put([]byte("a1"), []byte("1"))
+	db.Put([]byte("a3"), []byte("3"))
+	db.Put([]byte("a5"), []byte("5"))
+
+	iter := db.Find([]byte("a")).(*MemDatabaseIterator)
+	assert.True(t, iter.Seek([]byte("a3")))
+	checkRow([]byte("a3"), []byte("3"), iter, t)
+	assert.True(t, iter.Seek([]byte("a4")))
+	checkRow([]byte("a5"), []byte("5"), iter, t)
+	assert.False(t, iter.Next())
+	assert.False(t, iter.Seek([]byte("a6")))
+	assert.False(t, iter.Next())
+}
@@ -0,0 +1,197 @@
+package database
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/metrics"
+	prmkit "github.com/go-kit/kit/metrics/prometheus"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/cache"
+)
+
+const (
+	namespace = "spacemesh"
+	subsystem = "database"
+)
+
+func newCounter(name, help string, labels []string) metrics.Counter {
+	return prmkit.NewCounterFrom(prometheus.CounterOpts{Namespace: namespace, Subsystem: subsystem, Name: name, Help: help}, labels)
+}
+
+func newGauge(name, help string, labels []string) metrics.Gauge {
+	return prmkit.NewGaugeFrom(prometheus.GaugeOpts{Namespace: namespace, Subsystem: subsystem, Name: name, Help: help}, labels)
+}
+
+func newHistogram(name, help string, buckets []float64, labels []string) metrics.Histogram {
+	return prmkit.NewHistogramFrom(prometheus.HistogramOpts{Namespace: namespace, Subsystem: subsystem, Name: name, Help: help, Buckets: buckets}, labels)
+}
+
+var (
+	diskSize             = newGauge("size_bytes", "size of the files of the database on disk", []string{"db"})
+	opDuration           = newHistogram("operation_duration_seconds", "duration of reads and writes of the database", prometheus.ExponentialBuckets(0.00001, 4, 10), []string{"db", "op"})
+	blockCacheQueries    = newCounter("block_cache_queries", "lookups of table blocks in the block cache of the database", []string{"db", "result"})
+	compactions          = newCounter("compactions", "number of compactions of the whole database", []string{"db"})
+	compactionDuration   = newHistogram("compaction_duration_seconds", "duration of compactions of the whole database", prometheus.ExponentialBuckets(0.1, 4, 8), []string{"db"})
+	backgroundCompaction = newCounter("background_compaction_seconds", "time leveldb spent compacting the tables of the database after writes", []string{"db"})
+	writeDelays          = newCounter("write_delays", "number of writes delayed while the database compacted", []string{"db"})
+	writeDelay           = newCounter("write_delay_seconds", "time writes were delayed while the database compacted", []string{"db"})
+)
+
+// dbMetrics are the metrics of a database, labeled with its name in the registry
+type dbMetrics struct {
+	ops                    map[string]metrics.Histogram // by operation
+	cacheHits, cacheMisses metrics.Counter
+}
+
+func newDBMetrics(name string) *dbMetrics {
+	m := &dbMetrics{
+		ops:         make(map[string]metrics.Histogram),
+		cacheHits:   blockCacheQueries.With("db", name, "result", "hit"),
+		cacheMisses: blockCacheQueries.With("db", name, "result", "miss"),
+	}
+	for _, op := range []string{"get", "has", "put", "delete", "batch"} {
+		m.ops[op] = opDuration.With("db", name, "op", op)
+	}
+	return m
+}
+
+// observe records the duration of an operation that started at start, if the database is metered
+func (db *LDBDatabase) observe(op string, start time.Time) {
+	if m, ok := db.metrics.Load().(*dbMetrics); ok {
+		m.ops[op].Observe(time.Since(start).Seconds())
+	}
+}
+
+// meteredCacher counts the lookups in the block cache of a database. leveldb promotes every block it looks up in the
+// cache, and a block that the cacher doesn't hold yet was read from disk.
+type meteredCacher struct {
+	mu sync.Mutex // guards the cache data of the nodes, which the cacher changes
+	cache.Cacher
+	db *LDBDatabase
+}
+
+func newMeteredCacher(db *LDBDatabase) *meteredCacher {
+	return &meteredCacher{db: db}
+}
+
+// New returns the cache of the capacity that leveldb opens the database with
+func (c *meteredCacher) New(capacity int) cache.Cacher {
+	c.Cacher = cache.NewLRU(capacity)
+	return c
+}
+
+func (c *meteredCacher) Promote(n *cache.Node) {
+	c.mu.Lock()
+	hit := n.CacheData != nil
+	c.Cacher.Promote(n)
+	c.mu.Unlock()
+	if m, ok := c.db.metrics.Load().(*dbMetrics); ok {
+		if hit {
+			m.cacheHits.Add(1)
+		} else {
+			m.cacheMisses.Add(1)
+		}
+	}
+}
+
+func (c *meteredCacher) SetCapacity(capacity int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Cacher.SetCapacity(capacity)
+}
+
+func (c *meteredCacher) Ban(n *cache.Node) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Cacher.Ban(n)
+}
+
+func (c *meteredCacher) Evict(n *cache.Node) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Cacher.Evict(n)
+}
+
+func (c *meteredCacher) EvictNS(ns uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Cacher.EvictNS(ns)
+}
+
+func (c *meteredCacher) EvictAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Cacher.EvictAll()
+}
+
+// CollectMetrics meters the registered databases, and exports their size on disk and the statistics of leveldb every
+// interval until the registry is closed. Only the databases registered after it's called are metered.
+func (r *Registry) CollectMetrics(interval time.Duration) {
+	r.mu.Lock()
+	r.metered = true
+	r.mu.Unlock()
+	go func() {
+		prev := make(map[string]*leveldb.DBStats)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			r.collectMetrics(prev)
+			select {
+			case <-r.exit:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// collectMetrics exports the size and the statistics of the registered databases. The statistics are counted from
+// the previous ones of each database.
+func (r *Registry) collectMetrics(prev map[string]*leveldb.DBStats) {
+	for _, name := range r.Names() {
+		db := r.Get(name)
+		if db == nil {
+			continue
+		}
+		if size, err := db.DiskSize(); err == nil {
+			diskSize.With("db", name).Set(float64(size))
+		}
+		stats := &leveldb.DBStats{}
+		if err := db.db.Stats(stats); err != nil {
+			r.log.With().Warning("could not read database stats", log.String("db", name), log.Err(err))
+			continue
+		}
+		last, ok := prev[name]
+		if !ok {
+			last = &leveldb.DBStats{}
+		}
+		addDelta(backgroundCompaction.With("db", name), (sumDurations(stats.LevelDurations) - sumDurations(last.LevelDurations)).Seconds())
+		addDelta(writeDelays.With("db", name), float64(stats.WriteDelayCount-last.WriteDelayCount))
+		addDelta(writeDelay.With("db", name), (stats.WriteDelayDuration - last.WriteDelayDuration).Seconds())
+		prev[name] = stats
+	}
+}
+
+// observeCompaction exports a compaction of a whole database
+func observeCompaction(res CompactionResult) {
+	compactions.With("db", res.Name).Add(1)
+	compactionDuration.With("db", res.Name).Observe(res.Duration.Seconds())
+}
+
+// addDelta adds the growth of a statistic to its counter. Statistics restart when a database is reopened.
+func addDelta(c metrics.Counter, delta float64) {
+	if delta > 0 {
+		c.Add(delta)
+	}
+}
+
+func sumDurations(durations []time.Duration) time.Duration {
+	var sum time.Duration
+	for _, d := range durations {
+		sum += d
+	}
+	return sum
+}
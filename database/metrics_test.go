@@ -0,0 +1,98 @@
+package database
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/stretchr/testify/require"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// metricValue returns the value of the metric of the database with the labels, the number of samples of histograms
+func metricValue(t *testing.T, metric, db string, labels map[string]string) float64 {
+	families, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() != namespace+"_"+subsystem+"_"+metric {
+			continue
+		}
+	next:
+		for _, m := range family.GetMetric() {
+			values := map[string]string{}
+			for _, l := range m.GetLabel() {
+				values[l.GetName()] = l.GetValue()
+			}
+			if values["db"] != db {
+				continue
+			}
+			for k, v := range labels {
+				if values[k] != v {
+					continue next
+				}
+			}
+			switch {
+			case m.Counter != nil:
+				return m.GetCounter().GetValue()
+			case m.Gauge != nil:
+				return m.GetGauge().GetValue()
+			case m.Histogram != nil:
+				return float64(m.GetHistogram().GetSampleCount())
+			}
+		}
+	}
+	return 0
+}
+
+func TestRegistry_Metrics(t *testing.T) {
+	r := require.New(t)
+	dir, err := ioutil.TempDir("", "metrics_test")
+	r.NoError(err)
+	defer os.RemoveAll(dir)
+	lg := log.NewDefault(t.Name())
+	db, err := NewLDBDatabase(filepath.Join(dir, "metered"), 0, 0, lg)
+	r.NoError(err)
+	defer db.Close()
+	unmetered, err := NewLDBDatabase(filepath.Join(dir, "unmetered"), 0, 0, lg)
+	r.NoError(err)
+	defer unmetered.Close()
+
+	registry := NewRegistry(lg)
+	defer registry.Close()
+	registry.Register("metrics-test-unmetered", unmetered)
+	registry.CollectMetrics(time.Hour)
+	registry.Register("metrics-test", db)
+
+	for i := 0; i < 100; i++ {
+		r.NoError(db.Put([]byte(fmt.Sprintf("key%d", i)), []byte("value")))
+		r.NoError(unmetered.Put([]byte(fmt.Sprintf("key%d", i)), []byte("value")))
+	}
+	results, err := registry.Compact()
+	r.NoError(err)
+	r.Len(results, 2)
+	// a repeated read finds the blocks in the cache
+	_, err = db.Get([]byte("key1"))
+	r.NoError(err)
+	hits := metricValue(t, "block_cache_queries", "metrics-test", map[string]string{"result": "hit"})
+	misses := metricValue(t, "block_cache_queries", "metrics-test", map[string]string{"result": "miss"})
+	r.NotZero(misses)
+	_, err = db.Get([]byte("key1"))
+	r.NoError(err)
+	r.Greater(metricValue(t, "block_cache_queries", "metrics-test", map[string]string{"result": "hit"}), hits)
+	r.Equal(misses, metricValue(t, "block_cache_queries", "metrics-test", map[string]string{"result": "miss"}))
+	registry.collectMetrics(make(map[string]*leveldb.DBStats))
+
+	r.Equal(100.0, metricValue(t, "operation_duration_seconds", "metrics-test", map[string]string{"op": "put"}))
+	r.Equal(2.0, metricValue(t, "operation_duration_seconds", "metrics-test", map[string]string{"op": "get"}))
+	r.Equal(0.0, metricValue(t, "operation_duration_seconds", "metrics-test-unmetered", map[string]string{"op": "put"}))
+	r.Equal(1.0, metricValue(t, "compactions", "metrics-test", nil))
+	r.Equal(1.0, metricValue(t, "compaction_duration_seconds", "metrics-test", nil))
+	size, err := db.DiskSize()
+	r.NoError(err)
+	r.Equal(float64(size), metricValue(t, "size_bytes", "metrics-test", nil))
+}
@@ -0,0 +1,167 @@
+package database
+
+import (
+	"encoding/binary"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/log"
+)
+
+// Every database of the node is stamped with the version of its schema. When the node starts, the migrations that
+// upgrade each database from its version to the latest one are applied in order, and the stamp is updated after every
+// migration, so a node that stops in the middle resumes from the first migration that wasn't applied. A new database
+// is stamped with the latest version, and a database created before the stamps were introduced has version 0.
+
+// schemaVersionKey holds the schema version of a database. It sorts before the keys of all components.
+var schemaVersionKey = []byte("\x00schema-version")
+
+// Migration upgrades the schema of a database from the previous version to Version
+type Migration struct {
+	Version     int
+	Description string
+	Migrate     func(db Database) error
+}
+
+// PendingMigration is a migration that a database needs to reach the latest version of its schema
+type PendingMigration struct {
+	Database string
+	Migration
+}
+
+func (p PendingMigration) String() string {
+	return fmt.Sprintf("%v to version %d: %v", p.Database, p.Version, p.Description)
+}
+
+// SchemaVersion returns the schema version of the database, 0 if it isn't stamped
+func SchemaVersion(db Database) (int, error) {
+	val, err := db.Get(schemaVersionKey)
+	if err == ErrNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if len(val) != 8 {
+		return 0, fmt.Errorf("invalid schema version %x", val)
+	}
+	return int(binary.BigEndian.Uint64(val)), nil
+}
+
+// SetSchemaVersion stamps the database with the schema version
+func SetSchemaVersion(db Database, version int) error {
+	val := make([]byte, 8)
+	binary.BigEndian.PutUint64(val, uint64(version))
+	return db.Put(schemaVersionKey, val)
+}
+
+// Migrator applies the migrations of the registered databases
+type Migrator struct {
+	log        log.Log
+	migrations map[string][]Migration // by database name, in version order
+}
+
+// NewMigrator returns a migrator without migrations
+func NewMigrator(logger log.Log) *Migrator {
+	return &Migrator{log: logger, migrations: make(map[string][]Migration)}
+}
+
+// Register adds the migrations of the named database. Their versions must follow the latest registered version of the
+// database without gaps.
+func (m *Migrator) Register(name string, migrations ...Migration) {
+	for _, mig := range migrations {
+		if latest := m.Latest(name); mig.Version != latest+1 {
+			panic(fmt.Sprintf("migration of %v to version %d registered after version %d", name, mig.Version, latest))
+		}
+		m.migrations[name] = append(m.migrations[name], mig)
+	}
+}
+
+// Latest returns the latest schema version of the named database
+func (m *Migrator) Latest(name string) int {
+	return len(m.migrations[name])
+}
+
+// Pending returns the migrations the registered databases need, in the order they are applied. Unstamped empty
+// databases are new, and are stamped with the latest version.
+func (m *Migrator) Pending(registry *Registry) ([]PendingMigration, error) {
+	var pending []PendingMigration
+	for _, name := range registry.Names() {
+		db := registry.Get(name)
+		version, err := SchemaVersion(db)
+		if err != nil {
+			return nil, fmt.Errorf("could not read schema version of %v: %v", name, err)
+		}
+		latest := m.Latest(name)
+		if version == 0 && latest > 0 && isEmpty(db) {
+			if err := SetSchemaVersion(db, latest); err != nil {
+				return nil, err
+			}
+			version = latest
+		}
+		if version > latest {
+			return nil, fmt.Errorf("database %v has schema version %d, the node only supports up to %d", name, version, latest)
+		}
+		for _, mig := range m.migrations[name][version:] {
+			pending = append(pending, PendingMigration{Database: name, Migration: mig})
+		}
+	}
+	sort.SliceStable(pending, func(i, j int) bool { return pending[i].Database < pending[j].Database })
+	return pending, nil
+}
+
+// Run applies the pending migrations of the registered databases. If backupDir is set, the databases are backed up to
+// a new directory in it before the first migration is applied. With dryRun, the pending migrations are only returned.
+func (m *Migrator) Run(registry *Registry, dryRun bool, backupDir string) ([]PendingMigration, error) {
+	pending, err := m.Pending(registry)
+	if err != nil {
+		return nil, err
+	}
+	if len(pending) == 0 {
+		return nil, nil
+	}
+	for _, p := range pending {
+		m.log.With().Info("pending database migration", log.String("migration", p.String()))
+	}
+	if dryRun {
+		return pending, nil
+	}
+
+	if backupDir != "" {
+		snap, err := registry.Snapshot()
+		if err != nil {
+			return nil, err
+		}
+		dir := filepath.Join(backupDir, fmt.Sprintf("pre-migration-%d", snap.Manifest().Created))
+		err = snap.WriteDir(dir)
+		snap.Release()
+		if err != nil {
+			return nil, fmt.Errorf("could not back up the databases before migrating them: %v", err)
+		}
+		m.log.With().Info("backed up the databases before migrating them", log.String("path", dir))
+	}
+
+	for _, p := range pending {
+		db := registry.Get(p.Database)
+		start := time.Now()
+		if err := p.Migrate(db); err != nil {
+			return nil, fmt.Errorf("migration of %v failed: %v", p, err)
+		}
+		if err := SetSchemaVersion(db, p.Version); err != nil {
+			return nil, err
+		}
+		m.log.With().Info("migrated database",
+			log.String("migration", p.String()),
+			log.String("duration", time.Since(start).String()))
+	}
+	return pending, nil
+}
+
+// isEmpty returns true if the database holds no keys
+func isEmpty(db *LDBDatabase) bool {
+	it := db.Iterator()
+	defer it.Release()
+	return !it.Next()
+}
@@ -0,0 +1,95 @@
+package database
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrator_Run(t *testing.T) {
+	r := require.New(t)
+	dir, err := ioutil.TempDir("", "migration_test")
+	r.NoError(err)
+	defer os.RemoveAll(dir)
+	lg := log.NewDefault(t.Name())
+	old, err := NewLDBDatabase(filepath.Join(dir, "old"), 0, 0, lg)
+	r.NoError(err)
+	defer old.Close()
+	r.NoError(old.Put([]byte("key"), []byte("v0")))
+	fresh, err := NewLDBDatabase(filepath.Join(dir, "new"), 0, 0, lg)
+	r.NoError(err)
+	defer fresh.Close()
+	registry := NewRegistry(lg)
+	registry.Register("old", old)
+	registry.Register("new", fresh)
+
+	var applied []string
+	migrator := NewMigrator(lg)
+	for _, name := range []string{"old", "new"} {
+		name := name
+		migrator.Register(name,
+			Migration{Version: 1, Description: "rename", Migrate: func(db Database) error {
+				applied = append(applied, name+"/1")
+				return db.Put([]byte("key"), []byte("v1"))
+			}},
+			Migration{Version: 2, Description: "rewrite", Migrate: func(db Database) error {
+				applied = append(applied, name+"/2")
+				return db.Put([]byte("key"), []byte("v2"))
+			}})
+	}
+	r.Panics(func() { migrator.Register("old", Migration{Version: 4}) })
+
+	// a dry run only reports the migrations, the new database is stamped with the latest version
+	pending, err := migrator.Run(registry, true, "")
+	r.NoError(err)
+	r.Len(pending, 2)
+	r.Equal("old", pending[0].Database)
+	r.Equal(1, pending[0].Version)
+	r.Equal(2, pending[1].Version)
+	r.Empty(applied)
+	version, err := SchemaVersion(fresh)
+	r.NoError(err)
+	r.Equal(2, version)
+	version, err = SchemaVersion(old)
+	r.NoError(err)
+	r.Equal(0, version)
+
+	// the databases are backed up before they're migrated
+	backupDir := filepath.Join(dir, "backups")
+	pending, err = migrator.Run(registry, false, backupDir)
+	r.NoError(err)
+	r.Len(pending, 2)
+	r.Equal([]string{"old/1", "old/2"}, applied)
+	val, err := old.Get([]byte("key"))
+	r.NoError(err)
+	r.Equal([]byte("v2"), val)
+	version, err = SchemaVersion(old)
+	r.NoError(err)
+	r.Equal(2, version)
+	backups, err := ioutil.ReadDir(backupDir)
+	r.NoError(err)
+	r.Len(backups, 1)
+	restored := filepath.Join(dir, "restored")
+	_, err = RestoreBackup(filepath.Join(backupDir, backups[0].Name()), inDir(restored), lg)
+	r.NoError(err)
+	backup, err := NewLDBDatabase(filepath.Join(restored, "old"), 0, 0, lg)
+	r.NoError(err)
+	val, err = backup.Get([]byte("key"))
+	r.NoError(err)
+	r.Equal([]byte("v0"), val)
+	backup.Close()
+
+	// migrated databases have nothing left to migrate
+	pending, err = migrator.Run(registry, false, backupDir)
+	r.NoError(err)
+	r.Empty(pending)
+
+	// a node doesn't start with a database of a newer schema
+	r.NoError(SetSchemaVersion(old, 3))
+	_, err = migrator.Run(registry, false, "")
+	r.Error(err)
+}
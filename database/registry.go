@@ -0,0 +1,82 @@
+package database
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/spacemeshos/go-spacemesh/log"
+)
+
+// Registry holds the named databases of the node, for the maintenance jobs that run on all of them
+type Registry struct {
+	log log.Log
+
+	mu      sync.RWMutex
+	dbs     map[string]*LDBDatabase
+	metered bool        // databases are metered when they're registered
+	writes  sync.Locker // held while the databases are snapshotted, nil if writes aren't paused
+
+	compactMu  sync.Mutex
+	compacting bool // compactions don't run concurrently
+	exit       chan struct{}
+	closeOnce  sync.Once
+}
+
+// NewRegistry returns an empty registry of databases
+func NewRegistry(logger log.Log) *Registry {
+	return &Registry{
+		log:  logger,
+		dbs:  make(map[string]*LDBDatabase),
+		exit: make(chan struct{}),
+	}
+}
+
+// Register adds a database to the registry under the name. Databases that aren't persisted on disk are ignored.
+func (r *Registry) Register(name string, db Database) {
+	ldb, ok := db.(*LDBDatabase)
+	if !ok {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.dbs[name] = ldb
+	if r.metered {
+		ldb.metrics.Store(newDBMetrics(name))
+	}
+}
+
+// SetWriteLock sets the lock that writers spanning several databases hold, so that snapshots of the databases are
+// taken between their writes
+func (r *Registry) SetWriteLock(l sync.Locker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.writes = l
+}
+
+// Names returns the names of the registered databases in lexicographic order
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.names()
+}
+
+func (r *Registry) names() []string {
+	names := make([]string, 0, len(r.dbs))
+	for name := range r.dbs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get returns the database registered under the name, nil if there is none
+func (r *Registry) Get(name string) *LDBDatabase {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.dbs[name]
+}
+
+// Close stops the maintenance jobs of the registry. It doesn't close the databases.
+func (r *Registry) Close() {
+	r.closeOnce.Do(func() { close(r.exit) })
+}
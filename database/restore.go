@@ -0,0 +1,286 @@
+package database
+
+import (
+	"archive/tar"
+	"bufio"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/spacemeshos/go-spacemesh/filesystem"
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// restoreBatchSize is the number of keys written to a restored database at once
+const restoreBatchSize = 1000
+
+// RestoreBackup restores the databases of the backup at src, a directory written by Snapshot.WriteDir or a tarball
+// written by Snapshot.WriteTar, into the directories dbPath returns for their names. None of the databases of the
+// backup may exist yet. The content of every database is checked against the hash in the manifest while it's restored,
+// and the restored databases are deleted if any of them doesn't match.
+func RestoreBackup(src string, dbPath func(name string) string, logger log.Log) (*BackupManifest, error) {
+	info, err := os.Stat(src)
+	if err != nil {
+		return nil, err
+	}
+	var r backupReader
+	if info.IsDir() {
+		r = &dirBackupReader{dir: src}
+	} else {
+		f, err := os.Open(src)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = &tarBackupReader{tr: tar.NewReader(bufio.NewReader(f))}
+	}
+
+	manifest, err := r.manifest()
+	if err != nil {
+		return nil, fmt.Errorf("invalid manifest: %v", err)
+	}
+	if err := manifest.validate(); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %v", err)
+	}
+	for _, db := range manifest.Databases {
+		if dir := dbPath(db.Name); filesystem.PathExists(dir) {
+			return nil, fmt.Errorf("database %v already exists in %v", db.Name, dir)
+		}
+	}
+
+	restored := make([]string, 0, len(manifest.Databases))
+	cleanup := func() {
+		for _, dir := range restored {
+			if err := os.RemoveAll(dir); err != nil {
+				logger.With().Error("could not delete restored database", log.String("path", dir), log.Err(err))
+			}
+		}
+	}
+	for range manifest.Databases {
+		file, data, err := r.next()
+		if err != nil {
+			cleanup()
+			return nil, err
+		}
+		db := manifest.database(file)
+		if db == nil {
+			cleanup()
+			return nil, fmt.Errorf("file %v isn't in the manifest", file)
+		}
+		dir := dbPath(db.Name)
+		for _, d := range restored {
+			if d == dir {
+				cleanup()
+				return nil, fmt.Errorf("file %v is in the backup twice", file)
+			}
+		}
+		restored = append(restored, dir)
+		err = restoreDatabase(dir, db, data)
+		if c, ok := data.(io.Closer); ok {
+			c.Close()
+		}
+		if err != nil {
+			cleanup()
+			return nil, fmt.Errorf("could not restore %v: %v", db.Name, err)
+		}
+		logger.With().Info("restored database", log.String("db", db.Name), log.Int64("keys", db.Keys))
+	}
+	return manifest, nil
+}
+
+// validate checks the version of the manifest and that the files of the databases stay within the backup
+func (m *BackupManifest) validate() error {
+	if m.Version != BackupVersion {
+		return fmt.Errorf("unsupported backup version %d", m.Version)
+	}
+	if len(m.Databases) == 0 {
+		return fmt.Errorf("no databases")
+	}
+	files := make(map[string]bool)
+	for _, db := range m.Databases {
+		for _, p := range []string{db.Name, db.File} {
+			if p == "" || path.IsAbs(p) || path.Clean(p) != p || p == ".." || strings.HasPrefix(p, "../") {
+				return fmt.Errorf("invalid path %q", p)
+			}
+		}
+		if files[db.File] {
+			return fmt.Errorf("duplicate file %v", db.File)
+		}
+		files[db.File] = true
+		if _, err := hex.DecodeString(db.Hash); err != nil || len(db.Hash) != 2*sha256.Size {
+			return fmt.Errorf("invalid hash of %v", db.Name)
+		}
+	}
+	return nil
+}
+
+// database returns the database whose dump is the file, nil if there is none
+func (m *BackupManifest) database(file string) *BackupDatabase {
+	for i := range m.Databases {
+		if m.Databases[i].File == file {
+			return &m.Databases[i]
+		}
+	}
+	return nil
+}
+
+// restoreDatabase writes the records of the dump into a new database in dir, and checks the dump against the manifest
+func restoreDatabase(dir string, db *BackupDatabase, data io.Reader) error {
+	ldb, err := leveldb.OpenFile(dir, nil)
+	if err != nil {
+		return err
+	}
+	defer ldb.Close()
+
+	h := sha256.New()
+	cr := &countingReader{r: io.TeeReader(data, h)}
+	br := bufio.NewReader(cr)
+	batch := new(leveldb.Batch)
+	var keys int64
+	for {
+		key, err := readRecord(br)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		value, err := readRecord(br)
+		if err != nil {
+			return fmt.Errorf("truncated record: %v", err)
+		}
+		batch.Put(key, value)
+		keys++
+		if batch.Len() >= restoreBatchSize {
+			if err := ldb.Write(batch, nil); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	if err := ldb.Write(batch, nil); err != nil {
+		return err
+	}
+	if hash := hex.EncodeToString(h.Sum(nil)); hash != db.Hash {
+		return fmt.Errorf("hash mismatch, expected %v got %v", db.Hash, hash)
+	}
+	if cr.n != db.Size || keys != db.Keys {
+		return fmt.Errorf("expected %d keys in %d bytes, got %d keys in %d bytes", db.Keys, db.Size, keys, cr.n)
+	}
+	return nil
+}
+
+// readRecord reads a uvarint length prefixed record
+func readRecord(br *bufio.Reader) ([]byte, error) {
+	n, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, err
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(br, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// backupReader reads the manifest of a backup, then the dumps of its databases one after the other
+type backupReader interface {
+	manifest() (*BackupManifest, error)
+	next() (string, io.Reader, error)
+}
+
+type dirBackupReader struct {
+	dir   string
+	files []string // the files of the manifest that weren't read yet
+}
+
+func (r *dirBackupReader) manifest() (*BackupManifest, error) {
+	data, err := ioutil.ReadFile(filepath.Join(r.dir, ManifestFile))
+	if err != nil {
+		return nil, err
+	}
+	var m BackupManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	for _, db := range m.Databases {
+		r.files = append(r.files, db.File)
+	}
+	return &m, nil
+}
+
+func (r *dirBackupReader) next() (string, io.Reader, error) {
+	if len(r.files) == 0 {
+		return "", nil, io.EOF
+	}
+	file := r.files[0]
+	r.files = r.files[1:]
+	f, err := os.Open(filepath.Join(r.dir, filepath.FromSlash(file)))
+	if err != nil {
+		return "", nil, err
+	}
+	return file, f, nil
+}
+
+type tarBackupReader struct {
+	tr *tar.Reader
+}
+
+// nextFile skips the directories of the tarball
+func (r *tarBackupReader) nextFile() (*tar.Header, error) {
+	for {
+		hdr, err := r.tr.Next()
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag == tar.TypeReg {
+			return hdr, nil
+		}
+	}
+}
+
+func (r *tarBackupReader) manifest() (*BackupManifest, error) {
+	hdr, err := r.nextFile()
+	if err != nil {
+		return nil, err
+	}
+	if hdr.Name != ManifestFile {
+		return nil, fmt.Errorf("the tarball doesn't start with %v", ManifestFile)
+	}
+	var m BackupManifest
+	if err := json.NewDecoder(r.tr).Decode(&m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (r *tarBackupReader) next() (string, io.Reader, error) {
+	hdr, err := r.nextFile()
+	if err == io.EOF {
+		return "", nil, fmt.Errorf("the tarball is missing databases")
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	return hdr.Name, r.tr, nil
+}
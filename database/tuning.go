@@ -0,0 +1,32 @@
+package database
+
+// Tuning sets the memory and the files a leveldb database uses
+type Tuning struct {
+	Cache       int // MiB of the block cache
+	WriteBuffer int // MiB of the write buffer, two of which are used while one is flushed
+	Handles     int // max number of files kept open
+}
+
+// DefaultTuning is the tuning of databases opened without one
+var DefaultTuning = Tuning{Cache: 8, WriteBuffer: 4, Handles: 16}
+
+// MemoryTuning returns the tuning of a database that may use memory MiB: half of it for the block cache, and a
+// quarter for each of the two write buffers. Every open table holds its index and filter in memory, so the database
+// keeps 4 files open for each MiB.
+func MemoryTuning(memory int) Tuning {
+	return Tuning{Cache: memory / 2, WriteBuffer: memory / 4, Handles: memory * 4}.withMinimums()
+}
+
+// withMinimums returns the tuning raised to the smallest sizes a database works with
+func (t Tuning) withMinimums() Tuning {
+	if t.Cache < 1 {
+		t.Cache = 1
+	}
+	if t.WriteBuffer < 1 {
+		t.WriteBuffer = 1
+	}
+	if t.Handles < 16 {
+		t.Handles = 16
+	}
+	return t
+}
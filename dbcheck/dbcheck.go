@@ -0,0 +1,285 @@
+// Package dbcheck verifies the references between the mesh, ATX and global state databases of a node: that the blocks
+// of the validated layers are stored along with the transactions and ATXs they reference, and that the state of the
+// latest layer applied to the state is complete. It reports the inconsistencies and repairs those it can.
+package dbcheck
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/database"
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/sync"
+)
+
+type meshDB interface {
+	ProcessedLayer() types.LayerID
+	LatestLayerInState() types.LayerID
+	PrunedLayer() types.LayerID
+	CheckpointLayer() types.LayerID
+	LayerBlockIds(layer types.LayerID) ([]types.BlockID, error)
+	GetBlock(id types.BlockID) (*types.Block, error)
+	GetTransactions(ids []types.TransactionID) ([]*types.Transaction, map[types.TransactionID]struct{})
+}
+
+type atxDB interface {
+	GetAtxHeader(id types.ATXID) (*types.ActivationTxHeader, error)
+}
+
+type stateDB interface {
+	GetLayerStateRoot(layer types.LayerID) (types.Hash32, error)
+	IterateAccounts(root types.Hash32, fn func(addr types.Address, nonce, balance uint64) error) error
+}
+
+// fetcher fetches the missing blocks, transactions and ATXs from peers, while the node runs
+type fetcher interface {
+	Fetch(kind sync.ObjectKind, id types.Hash32) (*sync.FetchResult, error)
+}
+
+// rewinder makes the node apply the layers after the given layer to the state again when it starts
+type rewinder interface {
+	RewindLatestLayerInState(layer types.LayerID) error
+}
+
+// IssueKind is the kind of an inconsistency between the databases
+type IssueKind int
+
+const (
+	// MissingBlock is a block of a validated layer that isn't stored
+	MissingBlock IssueKind = iota
+	// MissingTransaction is a transaction referenced by a stored block that isn't stored
+	MissingTransaction
+	// MissingAtx is an ATX referenced by a stored block that isn't stored
+	MissingAtx
+	// MissingStateRoot is a layer applied to the state whose state root isn't stored
+	MissingStateRoot
+	// IncompleteState is a state root whose accounts can't all be read
+	IncompleteState
+)
+
+func (k IssueKind) String() string {
+	switch k {
+	case MissingBlock:
+		return "missing block"
+	case MissingTransaction:
+		return "missing transaction"
+	case MissingAtx:
+		return "missing atx"
+	case MissingStateRoot:
+		return "missing state root"
+	case IncompleteState:
+		return "incomplete state"
+	default:
+		return "unknown"
+	}
+}
+
+// Issue is an inconsistency between the databases
+type Issue struct {
+	Kind     IssueKind
+	Layer    types.LayerID
+	ID       types.Hash32 // the missing object, or the state root
+	Repaired bool
+	Details  string
+}
+
+func (i Issue) String() string {
+	s := fmt.Sprintf("%v %v in layer %v", i.Kind, i.ID.ShortString(), i.Layer)
+	if i.Details != "" {
+		s += ": " + i.Details
+	}
+	if i.Repaired {
+		s += " (repaired)"
+	}
+	return s
+}
+
+// Report describes a check of the databases
+type Report struct {
+	FromLayer    types.LayerID
+	ToLayer      types.LayerID
+	Blocks       int           // blocks checked
+	Transactions int           // references to transactions checked
+	Atxs         int           // references to ATXs checked
+	StateLayer   types.LayerID // the latest layer applied to the state whose state is complete, after repairs
+	Issues       []Issue
+	Duration     time.Duration
+}
+
+// Checker checks the references between the databases of a node
+type Checker struct {
+	log.Log
+	mesh     meshDB
+	atxs     atxDB
+	state    stateDB
+	fetcher  fetcher  // nil if missing objects can't be fetched
+	rewinder rewinder // nil if the state can't be rewound
+}
+
+// New returns a checker of the given databases
+func New(mesh meshDB, atxs atxDB, state stateDB, logger log.Log) *Checker {
+	return &Checker{Log: logger, mesh: mesh, atxs: atxs, state: state}
+}
+
+// SetFetcher makes the checker repair the missing blocks, transactions and ATXs by fetching them from peers
+func (c *Checker) SetFetcher(f fetcher) {
+	c.fetcher = f
+}
+
+// SetRewinder makes the checker repair an incomplete state by rewinding the state to the latest layer whose state is
+// complete. The node applies the later layers again when it starts, so it must only be set while the node is stopped.
+func (c *Checker) SetRewinder(r rewinder) {
+	c.rewinder = r
+}
+
+// Check checks the blocks of the validated layers and the state of the latest layer applied to the state, and repairs
+// the issues it finds if asked to
+func (c *Checker) Check(repair bool) *Report {
+	start := time.Now()
+	report := &Report{
+		FromLayer: types.GetEffectiveGenesis() + 1,
+		ToLayer:   c.mesh.ProcessedLayer(),
+	}
+	if pruned := c.mesh.PrunedLayer(); pruned > report.FromLayer {
+		report.FromLayer = pruned
+	}
+	// a node restored from a checkpoint has neither the blocks nor the state roots of the layers before it
+	if cp := c.mesh.CheckpointLayer(); cp >= report.FromLayer {
+		report.FromLayer = cp + 1
+	}
+	for layer := report.FromLayer; layer <= report.ToLayer; layer++ {
+		c.checkLayer(layer, report, repair)
+	}
+	c.checkState(report, repair)
+	report.Duration = time.Since(start)
+
+	repaired := 0
+	for _, issue := range report.Issues {
+		if issue.Repaired {
+			repaired++
+		}
+	}
+	c.With().Info("checked databases",
+		log.FieldNamed("from_layer", report.FromLayer),
+		log.FieldNamed("to_layer", report.ToLayer),
+		log.Int("blocks", report.Blocks),
+		log.Int("issues", len(report.Issues)),
+		log.Int("repaired", repaired),
+		log.String("duration", report.Duration.String()))
+	return report
+}
+
+func (c *Checker) addIssue(report *Report, issue Issue) {
+	c.With().Warning("database inconsistency", log.String("issue", issue.String()))
+	report.Issues = append(report.Issues, issue)
+}
+
+// fetch fetches a missing object from peers, and returns true if it was stored
+func (c *Checker) fetch(kind sync.ObjectKind, id types.Hash32) bool {
+	res, err := c.fetcher.Fetch(kind, id)
+	if err != nil {
+		c.With().Warning("could not fetch missing object", log.String("kind", kind.String()), log.Err(err))
+		return false
+	}
+	return res.Stored
+}
+
+func (c *Checker) checkLayer(layer types.LayerID, report *Report, repair bool) {
+	ids, err := c.mesh.LayerBlockIds(layer)
+	if err != nil {
+		if err != database.ErrNotFound {
+			c.With().Error("could not read the blocks of layer", layer, log.Err(err))
+		}
+		return
+	}
+	for _, id := range ids {
+		report.Blocks++
+		blk, err := c.mesh.GetBlock(id)
+		if err == database.ErrPruned {
+			continue
+		}
+		if err != nil {
+			issue := Issue{Kind: MissingBlock, Layer: layer, ID: id.AsHash32()}
+			issue.Repaired = repair && c.fetcher != nil && c.fetch(sync.BlockObject, issue.ID)
+			c.addIssue(report, issue)
+			continue
+		}
+
+		report.Transactions += len(blk.TxIDs)
+		if _, missing := c.mesh.GetTransactions(blk.TxIDs); len(missing) > 0 {
+			for txID := range missing {
+				issue := Issue{Kind: MissingTransaction, Layer: layer, ID: txID.Hash32(), Details: "block " + blk.ID().String()}
+				issue.Repaired = repair && c.fetcher != nil && c.fetch(sync.TransactionObject, issue.ID)
+				c.addIssue(report, issue)
+			}
+		}
+
+		atxs := []types.ATXID{blk.ATXID}
+		if blk.ActiveSet != nil {
+			atxs = append(atxs, *blk.ActiveSet...)
+		}
+		report.Atxs += len(atxs)
+		for _, atxID := range atxs {
+			if atxID == *types.EmptyATXID {
+				continue
+			}
+			if _, err := c.atxs.GetAtxHeader(atxID); err == nil {
+				continue
+			}
+			issue := Issue{Kind: MissingAtx, Layer: layer, ID: atxID.Hash32(), Details: "block " + blk.ID().String()}
+			issue.Repaired = repair && c.fetcher != nil && c.fetch(sync.AtxObject, issue.ID)
+			c.addIssue(report, issue)
+		}
+	}
+}
+
+// checkState checks that the state roots of the layers applied to the state are stored, and that all the accounts of
+// the state of the latest layer can be read, so that the node can load it when it starts
+func (c *Checker) checkState(report *Report, repair bool) {
+	inState := c.mesh.LatestLayerInState()
+	for layer := report.FromLayer; layer < inState; layer++ {
+		if _, err := c.state.GetLayerStateRoot(layer); err == database.ErrNotFound {
+			c.addIssue(report, Issue{Kind: MissingStateRoot, Layer: layer})
+		}
+	}
+	report.StateLayer = inState
+	if inState <= types.GetEffectiveGenesis() {
+		return
+	}
+	if err := c.checkStateOf(inState); err == nil {
+		return
+	} else if err == database.ErrNotFound {
+		c.addIssue(report, Issue{Kind: MissingStateRoot, Layer: inState})
+	} else {
+		root, _ := c.state.GetLayerStateRoot(inState)
+		c.addIssue(report, Issue{Kind: IncompleteState, Layer: inState, ID: root, Details: err.Error()})
+	}
+	if !repair || c.rewinder == nil {
+		return
+	}
+	issue := &report.Issues[len(report.Issues)-1]
+	for layer := inState - 1; layer >= report.FromLayer && layer > types.GetEffectiveGenesis(); layer-- {
+		if c.checkStateOf(layer) != nil {
+			continue
+		}
+		if err := c.rewinder.RewindLatestLayerInState(layer); err != nil {
+			c.With().Error("could not rewind the state", layer, log.Err(err))
+			return
+		}
+		c.With().Warning("rewound the state, the node applies the later layers again when it starts", layer)
+		issue.Repaired = true
+		issue.Details = fmt.Sprintf("rewound the state to layer %v", layer)
+		report.StateLayer = layer
+		return
+	}
+}
+
+// checkStateOf reads all accounts of the state root of the layer
+func (c *Checker) checkStateOf(layer types.LayerID) error {
+	root, err := c.state.GetLayerStateRoot(layer)
+	if err != nil {
+		return err
+	}
+	return c.state.IterateAccounts(root, func(types.Address, uint64, uint64) error { return nil })
+}
@@ -0,0 +1,171 @@
+package dbcheck
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/database"
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/sync"
+	"github.com/stretchr/testify/require"
+)
+
+type meshMock struct {
+	processed, inState types.LayerID
+	layers             map[types.LayerID][]types.BlockID
+	blocks             map[types.BlockID]*types.Block
+	txs                map[types.TransactionID]struct{}
+}
+
+func (m *meshMock) ProcessedLayer() types.LayerID     { return m.processed }
+func (m *meshMock) LatestLayerInState() types.LayerID { return m.inState }
+func (m *meshMock) PrunedLayer() types.LayerID        { return 0 }
+func (m *meshMock) CheckpointLayer() types.LayerID    { return 0 }
+
+func (m *meshMock) LayerBlockIds(layer types.LayerID) ([]types.BlockID, error) {
+	ids, ok := m.layers[layer]
+	if !ok {
+		return nil, database.ErrNotFound
+	}
+	return ids, nil
+}
+
+func (m *meshMock) GetBlock(id types.BlockID) (*types.Block, error) {
+	blk, ok := m.blocks[id]
+	if !ok {
+		return nil, database.ErrNotFound
+	}
+	return blk, nil
+}
+
+func (m *meshMock) GetTransactions(ids []types.TransactionID) ([]*types.Transaction, map[types.TransactionID]struct{}) {
+	missing := make(map[types.TransactionID]struct{})
+	for _, id := range ids {
+		if _, ok := m.txs[id]; !ok {
+			missing[id] = struct{}{}
+		}
+	}
+	return nil, missing
+}
+
+type atxMock map[types.ATXID]struct{}
+
+func (a atxMock) GetAtxHeader(id types.ATXID) (*types.ActivationTxHeader, error) {
+	if _, ok := a[id]; !ok {
+		return nil, database.ErrNotFound
+	}
+	return &types.ActivationTxHeader{}, nil
+}
+
+type stateMock struct {
+	roots      map[types.LayerID]types.Hash32
+	incomplete map[types.Hash32]struct{}
+}
+
+func (s *stateMock) GetLayerStateRoot(layer types.LayerID) (types.Hash32, error) {
+	root, ok := s.roots[layer]
+	if !ok {
+		return types.Hash32{}, database.ErrNotFound
+	}
+	return root, nil
+}
+
+func (s *stateMock) IterateAccounts(root types.Hash32, fn func(addr types.Address, nonce, balance uint64) error) error {
+	if _, ok := s.incomplete[root]; ok {
+		return errors.New("missing trie node")
+	}
+	return fn(types.Address{}, 0, 0)
+}
+
+type fetcherMock struct {
+	fetched []types.Hash32
+}
+
+func (f *fetcherMock) Fetch(kind sync.ObjectKind, id types.Hash32) (*sync.FetchResult, error) {
+	f.fetched = append(f.fetched, id)
+	return &sync.FetchResult{Stored: true}, nil
+}
+
+type rewinderMock struct {
+	layer types.LayerID
+}
+
+func (r *rewinderMock) RewindLatestLayerInState(layer types.LayerID) error {
+	r.layer = layer
+	return nil
+}
+
+func TestChecker_Check(t *testing.T) {
+	r := require.New(t)
+	genesis := types.GetEffectiveGenesis()
+	atx := types.ATXID{1}
+	missingAtx := types.ATXID{2}
+	tx := types.TransactionID{3}
+	missingTx := types.TransactionID{4}
+
+	blk := types.NewExistingBlock(genesis+1, []byte("data"))
+	blk.ATXID = atx
+	blk.ActiveSet = &[]types.ATXID{atx, missingAtx}
+	blk.TxIDs = []types.TransactionID{tx, missingTx}
+	blk.Initialize()
+	missingBlk := types.NewExistingBlock(genesis+2, []byte("missing"))
+
+	msh := &meshMock{
+		processed: genesis + 2,
+		inState:   genesis + 2,
+		layers:    map[types.LayerID][]types.BlockID{genesis + 1: {blk.ID()}, genesis + 2: {missingBlk.ID()}},
+		blocks:    map[types.BlockID]*types.Block{blk.ID(): blk},
+		txs:       map[types.TransactionID]struct{}{tx: {}},
+	}
+	state := &stateMock{
+		roots:      map[types.LayerID]types.Hash32{genesis + 1: {5}, genesis + 2: {6}},
+		incomplete: map[types.Hash32]struct{}{{6}: {}},
+	}
+	checker := New(msh, atxMock{atx: {}}, state, log.NewDefault(t.Name()))
+
+	report := checker.Check(false)
+	r.Equal(genesis+1, report.FromLayer)
+	r.Equal(genesis+2, report.ToLayer)
+	r.Equal(2, report.Blocks)
+	r.Equal(2, report.Transactions)
+	r.Equal(3, report.Atxs)
+	r.Equal(genesis+2, report.StateLayer)
+	r.Equal([]Issue{
+		{Kind: MissingTransaction, Layer: genesis + 1, ID: missingTx.Hash32(), Details: "block " + blk.ID().String()},
+		{Kind: MissingAtx, Layer: genesis + 1, ID: missingAtx.Hash32(), Details: "block " + blk.ID().String()},
+		{Kind: MissingBlock, Layer: genesis + 2, ID: missingBlk.ID().AsHash32()},
+		{Kind: IncompleteState, Layer: genesis + 2, ID: types.Hash32{6}, Details: "missing trie node"},
+	}, report.Issues)
+
+	// without a fetcher and a rewinder, nothing is repaired
+	report = checker.Check(true)
+	for _, issue := range report.Issues {
+		r.False(issue.Repaired)
+	}
+
+	fetcher := &fetcherMock{}
+	rewinder := &rewinderMock{}
+	checker.SetFetcher(fetcher)
+	checker.SetRewinder(rewinder)
+	report = checker.Check(true)
+	r.Len(report.Issues, 4)
+	for _, issue := range report.Issues {
+		r.True(issue.Repaired, issue.String())
+	}
+	r.Equal([]types.Hash32{missingTx.Hash32(), missingAtx.Hash32(), missingBlk.ID().AsHash32()}, fetcher.fetched)
+	r.Equal(genesis+1, rewinder.layer)
+	r.Equal(genesis+1, report.StateLayer)
+}
+
+func TestChecker_MissingStateRoot(t *testing.T) {
+	r := require.New(t)
+	genesis := types.GetEffectiveGenesis()
+	msh := &meshMock{processed: genesis + 3, inState: genesis + 3}
+	state := &stateMock{roots: map[types.LayerID]types.Hash32{genesis + 1: {1}, genesis + 3: {3}}}
+	checker := New(msh, atxMock{}, state, log.NewDefault(t.Name()))
+
+	report := checker.Check(false)
+	r.Equal([]Issue{{Kind: MissingStateRoot, Layer: genesis + 2}}, report.Issues)
+	r.Equal(genesis+3, report.StateLayer)
+}
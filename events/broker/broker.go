@@ -0,0 +1,252 @@
+// Package broker forwards layer, transaction and account events of the node to a message broker, so that indexers can
+// consume them without a grpc client. Brokers are supported by drivers selected by the scheme of the broker url, nats
+// and mqtt drivers are built in, drivers for other brokers can be added with RegisterDriver.
+package broker
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sync"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/common/util"
+	"github.com/spacemeshos/go-spacemesh/events"
+	"github.com/spacemeshos/go-spacemesh/log"
+)
+
+// The topics events are published on, under the topic prefix
+const (
+	// TopicLayers receives the status changes of layers
+	TopicLayers = "layers"
+	// TopicTransactions receives transactions added to the mempool and the mesh, and the results of applying them
+	TopicTransactions = "transactions"
+	// TopicAccounts receives the updates of the balances and nonces of accounts, and the rewards paid to them
+	TopicAccounts = "accounts"
+)
+
+var topicChannels = map[string][]events.ChannelID{
+	TopicLayers:       {events.EventLayerUpdate},
+	TopicTransactions: {events.EventTxInMempool, events.EventTxInMesh, events.EventTxApplied},
+	TopicAccounts:     {events.EventAccountUpdate, events.EventRewardReceived},
+}
+
+// Config is the configuration of the broker integration
+type Config struct {
+	// URL is the url of the broker, e.g. nats://localhost:4222 or mqtt://localhost:1883, events aren't published if
+	// it's empty
+	URL string `mapstructure:"url"`
+	// TopicPrefix is the first element of the topics, e.g. spacemesh.layers in nats or spacemesh/layers in mqtt
+	TopicPrefix string `mapstructure:"topic-prefix"`
+	// Topics are the published topics
+	Topics []string `mapstructure:"topics"`
+}
+
+// DefaultConfig returns the default configuration, which doesn't publish events
+func DefaultConfig() Config {
+	return Config{
+		TopicPrefix: "spacemesh",
+		Topics:      []string{TopicLayers, TopicTransactions, TopicAccounts},
+	}
+}
+
+// Driver publishes messages to a broker
+type Driver interface {
+	// Publish publishes payload on the topic, given as its hierarchy of names which the driver joins like its broker
+	// does. Drivers connect lazily, and reconnect on the next message after a failure.
+	Publish(topic []string, payload []byte) error
+	Close() error
+}
+
+// OpenFunc returns a driver of a broker given its url
+type OpenFunc func(u *url.URL) (Driver, error)
+
+var drivers = struct {
+	sync.RWMutex
+	open map[string]OpenFunc
+}{open: map[string]OpenFunc{
+	"nats": openNATS,
+	"mqtt": openMQTT,
+}}
+
+// RegisterDriver registers the driver of the brokers with urls of the given scheme
+func RegisterDriver(scheme string, open OpenFunc) {
+	drivers.Lock()
+	defer drivers.Unlock()
+	drivers.open[scheme] = open
+}
+
+// Message is the json payload of the published messages
+type Message struct {
+	// Type is the type of the event, e.g. tx-applied
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+}
+
+// Bridge publishes the events of the node to a broker. Messages are published at most once: events are dropped if the
+// broker is unreachable, or if it's slower than the node.
+type Bridge struct {
+	conf   Config
+	driver Driver
+	log    log.Log
+	// topics maps the channels of the published events to their topics
+	topics map[events.ChannelID]string
+
+	subs []*events.Subscription
+	exit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewBridge validates the configuration and returns a bridge to the broker
+func NewBridge(conf Config, logger log.Log) (*Bridge, error) {
+	u, err := url.Parse(conf.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid broker url %q: %v", conf.URL, err)
+	}
+	drivers.RLock()
+	open, ok := drivers.open[u.Scheme]
+	drivers.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("no driver for broker url %q", conf.URL)
+	}
+	b := &Bridge{
+		conf:   conf,
+		log:    logger,
+		topics: make(map[events.ChannelID]string),
+		exit:   make(chan struct{}),
+	}
+	for _, topic := range conf.Topics {
+		channels, ok := topicChannels[topic]
+		if !ok {
+			return nil, fmt.Errorf("unknown broker topic %q", topic)
+		}
+		for _, channel := range channels {
+			b.topics[channel] = topic
+		}
+	}
+	if b.driver, err = open(u); err != nil {
+		return nil, fmt.Errorf("failed to open broker at %v: %v", u.Host, err)
+	}
+	return b, nil
+}
+
+// Start subscribes to the events of the configured topics and starts publishing them
+func (b *Bridge) Start() {
+	for channel, topic := range b.topics {
+		sub := events.Subscribe(channel)
+		b.subs = append(b.subs, sub)
+		b.wg.Add(1)
+		go b.forward(sub, topic)
+	}
+}
+
+// Close stops publishing events and closes the driver
+func (b *Bridge) Close() {
+	for _, sub := range b.subs {
+		sub.Close()
+	}
+	close(b.exit)
+	b.wg.Wait()
+	if err := b.driver.Close(); err != nil {
+		b.log.Warning("failed to close broker driver: %v", err)
+	}
+}
+
+func (b *Bridge) forward(sub *events.Subscription, topic string) {
+	defer b.wg.Done()
+	for {
+		select {
+		case <-b.exit:
+			return
+		case e, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+			payload, err := json.Marshal(message(e))
+			if err != nil {
+				b.log.Error("failed to encode event for broker: %v", err)
+				continue
+			}
+			if err := b.driver.Publish([]string{b.conf.TopicPrefix, topic}, payload); err != nil {
+				b.log.Warning("failed to publish event on broker topic %v: %v", topic, err)
+			}
+		}
+	}
+}
+
+var layerStatuses = map[events.LayerStatus]string{
+	events.LayerStatusCreated:   "created",
+	events.LayerStatusApproved:  "approved",
+	events.LayerStatusConfirmed: "confirmed",
+}
+
+var txResults = map[events.TxResult]string{
+	events.TxResultApplied:           "applied",
+	events.TxResultUnknownOrigin:     "unknown-origin",
+	events.TxResultBadNonce:          "bad-nonce",
+	events.TxResultInsufficientFunds: "insufficient-funds",
+}
+
+func transaction(tx *types.Transaction) map[string]interface{} {
+	id := tx.ID()
+	return map[string]interface{}{
+		"id":        util.Encode(id[:]),
+		"origin":    util.Encode(tx.Origin().Bytes()),
+		"recipient": util.Encode(tx.Recipient.Bytes()),
+		"amount":    tx.Amount,
+		"fee":       tx.Fee,
+		"gasLimit":  tx.GasLimit,
+		"nonce":     tx.AccountNonce,
+	}
+}
+
+// message converts an event to the message published on the broker, binary ids are encoded in hex
+func message(e events.Event) Message {
+	switch e := e.(type) {
+	case events.LayerUpdate:
+		blocks := make([]string, len(e.Blocks))
+		for i, id := range e.Blocks {
+			blocks[i] = util.Encode(id.Bytes())
+		}
+		data := map[string]interface{}{
+			"layer":  e.LayerID.Uint64(),
+			"status": layerStatuses[e.Status],
+			"blocks": blocks,
+		}
+		if e.Status == events.LayerStatusConfirmed {
+			data["stateRoot"] = util.Encode(e.StateRoot.Bytes())
+		}
+		return Message{"layer-update", data}
+	case events.TxInMempool:
+		return Message{"tx-mempool", transaction(e.Transaction)}
+	case events.TxInMesh:
+		data := transaction(e.Transaction)
+		data["layer"] = e.LayerID.Uint64()
+		return Message{"tx-mesh", data}
+	case events.TxApplied:
+		return Message{"tx-applied", map[string]interface{}{
+			"id":      util.Encode(e.ID.Bytes()),
+			"layer":   e.LayerID.Uint64(),
+			"fee":     e.Fee,
+			"result":  txResults[e.Result],
+			"gasUsed": e.GasUsed,
+			"error":   e.Error,
+		}}
+	case events.AccountUpdate:
+		return Message{"account-update", map[string]interface{}{
+			"address": util.Encode(e.Address.Bytes()),
+			"nonce":   e.Nonce,
+			"balance": e.Balance,
+			"layer":   e.LayerID.Uint64(),
+		}}
+	case events.RewardReceived:
+		return Message{"reward", map[string]interface{}{
+			"coinbase":    e.Coinbase,
+			"smesher":     e.Smesher,
+			"amount":      e.Amount,
+			"layerReward": e.LayerReward,
+			"layer":       e.Layer,
+		}}
+	}
+	return Message{fmt.Sprintf("%T", e), e}
+}
@@ -0,0 +1,286 @@
+package broker
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/events"
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/stretchr/testify/require"
+)
+
+type published struct {
+	topic   string
+	payload []byte
+}
+
+// a fake broker, it sends the messages it receives on messages, and signals on pings when a client answers its ping
+// or pings it
+type fakeBroker struct {
+	addr     string
+	messages chan published
+	pings    chan struct{}
+	lis      net.Listener
+}
+
+func (b *fakeBroker) shutDown() {
+	b.lis.Close()
+}
+
+func (b *fakeBroker) ping() {
+	select {
+	case b.pings <- struct{}{}:
+	default:
+	}
+}
+
+func listen(t *testing.T, scheme string) *fakeBroker {
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	return &fakeBroker{
+		addr:     scheme + "://" + lis.Addr().String(),
+		messages: make(chan published, 100),
+		pings:    make(chan struct{}, 1),
+		lis:      lis,
+	}
+}
+
+// launchNATS starts a fake nats server that pings every client once it's connected, and answers their pings
+func launchNATS(t *testing.T) *fakeBroker {
+	b := listen(t, "nats")
+	go func() {
+		for {
+			conn, err := b.lis.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				if _, err := fmt.Fprint(conn, "INFO {\"max_payload\":1048576,\"proto\":1}\r\n"); err != nil {
+					return
+				}
+				connected := false
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					var subject string
+					var size int
+					switch {
+					case line == "PING\r\n":
+						reply := "PONG\r\n"
+						if !connected {
+							// the client pings to complete its handshake
+							connected = true
+							reply += "PING\r\n"
+						} else {
+							b.ping()
+						}
+						if _, err := fmt.Fprint(conn, reply); err != nil {
+							return
+						}
+					case line == "PONG\r\n":
+						b.ping()
+					case strings.HasPrefix(line, "PUB "):
+						if _, err := fmt.Sscanf(line, "PUB %s %d\r\n", &subject, &size); err != nil {
+							return
+						}
+						payload := make([]byte, size+2)
+						if _, err := io.ReadFull(r, payload); err != nil {
+							return
+						}
+						b.messages <- published{subject, payload[:size]}
+					}
+				}
+			}()
+		}
+	}()
+	return b
+}
+
+// mqtt 3.1.1 control packet types, in the high nibble of the first byte of a packet
+const (
+	mqttConnect  = 1 << 4
+	mqttConnAck  = 2 << 4
+	mqttPublish  = 3 << 4
+	mqttPingReq  = 12 << 4
+	mqttPingResp = 13 << 4
+)
+
+func readPacket(r *bufio.Reader) (byte, []byte, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	size, err := binary.ReadUvarint(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	body := make([]byte, size)
+	_, err = io.ReadFull(r, body)
+	return header, body, err
+}
+
+// launchMQTT starts a fake mqtt broker that answers the pings of its clients
+func launchMQTT(t *testing.T) *fakeBroker {
+	b := listen(t, "mqtt")
+	go func() {
+		for {
+			conn, err := b.lis.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				header, body, err := readPacket(r)
+				if err != nil || header != mqttConnect || !strings.Contains(string(body), "MQTT") {
+					return
+				}
+				if _, err := conn.Write([]byte{mqttConnAck, 2, 0, 0}); err != nil {
+					return
+				}
+				for {
+					header, body, err := readPacket(r)
+					if err != nil {
+						return
+					}
+					switch header {
+					case mqttPublish:
+						size := binary.BigEndian.Uint16(body)
+						b.messages <- published{string(body[2 : 2+size]), body[2+size:]}
+					case mqttPingReq:
+						b.ping()
+						if _, err := conn.Write([]byte{mqttPingResp, 0}); err != nil {
+							return
+						}
+					}
+				}
+			}()
+		}
+	}()
+	return b
+}
+
+func receive(t *testing.T, messages chan published) (string, Message) {
+	select {
+	case p := <-messages:
+		var msg Message
+		require.NoError(t, json.Unmarshal(p.payload, &msg))
+		return p.topic, msg
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "message wasn't published")
+	}
+	return "", Message{}
+}
+
+func TestBridge(t *testing.T) {
+	broker := launchNATS(t)
+	defer broker.shutDown()
+	messages := broker.messages
+
+	conf := DefaultConfig()
+	conf.URL = broker.addr
+	conf.Topics = []string{TopicLayers, TopicAccounts}
+	b, err := NewBridge(conf, log.NewDefault("broker"))
+	require.NoError(t, err)
+	b.Start()
+	defer b.Close()
+
+	events.Publish(events.LayerUpdate{LayerID: 5, Status: events.LayerStatusConfirmed, Blocks: []types.BlockID{{1}}})
+	topic, msg := receive(t, messages)
+	require.Equal(t, "spacemesh.layers", topic)
+	require.Equal(t, Message{"layer-update", map[string]interface{}{
+		"layer":     5.0,
+		"status":    "confirmed",
+		"blocks":    []interface{}{"0x01" + strings.Repeat("00", 31)},
+		"stateRoot": "0x" + strings.Repeat("00", 32),
+	}}, msg)
+
+	// transactions aren't published
+	events.Publish(events.TxApplied{ID: types.TransactionID{1}, LayerID: 5})
+	events.Publish(events.AccountUpdate{Address: types.BytesToAddress([]byte{2}), Nonce: 1, Balance: 10, LayerID: 5})
+	topic, msg = receive(t, messages)
+	require.Equal(t, "spacemesh.accounts", topic)
+	require.Equal(t, "account-update", msg.Type)
+	require.Equal(t, 10.0, msg.Data.(map[string]interface{})["balance"])
+	require.Len(t, messages, 0)
+}
+
+func TestDrivers(t *testing.T) {
+	for _, launch := range []func(t *testing.T) *fakeBroker{launchNATS, launchMQTT} {
+		broker := launch(t)
+		u, err := url.Parse(broker.addr)
+		require.NoError(t, err)
+		drivers.RLock()
+		d, err := drivers.open[u.Scheme](u)
+		drivers.RUnlock()
+		require.NoError(t, err)
+
+		require.NoError(t, d.Publish([]string{"a", "b"}, []byte(`{"type":"1"}`)))
+		require.NoError(t, d.Publish([]string{"a", "b"}, []byte(`{"type":"2"}`)))
+		topic, msg := receive(t, broker.messages)
+		require.Equal(t, u.Scheme == "nats", topic == "a.b", topic)
+		require.Equal(t, u.Scheme == "mqtt", topic == "a/b", topic)
+		require.Equal(t, "1", msg.Type)
+		_, msg = receive(t, broker.messages)
+		require.Equal(t, "2", msg.Type)
+
+		broker.shutDown()
+		require.NoError(t, d.Close())
+		// the driver reconnects lazily, and fails when the broker is down
+		require.Error(t, d.Publish([]string{"a"}, []byte("{}")), u.Scheme)
+	}
+}
+
+func TestDrivers_KeepAlive(t *testing.T) {
+	defer func(interval time.Duration) { keepAlive = interval }(keepAlive)
+	keepAlive = time.Second
+	for _, launch := range []func(t *testing.T) *fakeBroker{launchNATS, launchMQTT} {
+		broker := launch(t)
+		u, err := url.Parse(broker.addr)
+		require.NoError(t, err)
+		drivers.RLock()
+		d, err := drivers.open[u.Scheme](u)
+		drivers.RUnlock()
+		require.NoError(t, err)
+		require.NoError(t, d.Publish([]string{"a"}, []byte(`{"type":"1"}`)))
+		receive(t, broker.messages)
+
+		// the idle connection is kept alive with pings, and stays open
+		for i := 0; i < 2; i++ {
+			select {
+			case <-broker.pings:
+			case <-time.After(5 * keepAlive):
+				require.Fail(t, "no ping", u.Scheme)
+			}
+		}
+		require.NoError(t, d.Publish([]string{"a"}, []byte(`{"type":"2"}`)))
+		_, msg := receive(t, broker.messages)
+		require.Equal(t, "2", msg.Type)
+		require.NoError(t, d.Close())
+		broker.shutDown()
+	}
+}
+
+func TestNewBridge_InvalidConfig(t *testing.T) {
+	for _, conf := range []Config{
+		{URL: "kafka://localhost:9092"},
+		{URL: "nats://localhost", Topics: []string{"blocks"}},
+		{URL: "://"},
+	} {
+		_, err := NewBridge(conf, log.NewDefault("broker"))
+		require.Error(t, err, conf)
+	}
+}
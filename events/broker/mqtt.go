@@ -0,0 +1,86 @@
+package broker
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+var errTimeout = errors.New("timed out waiting for the mqtt broker")
+
+// mqttDriver publishes to an mqtt 3.1.1 broker with qos 0, with the paho client. The client pings the broker on idle
+// connections, and the driver connects again on the next message if the connection is lost. The client id is taken
+// from the client-id query parameter of the url, the broker assigns one if it's missing.
+type mqttDriver struct {
+	opts *mqtt.ClientOptions
+
+	mu     sync.Mutex
+	client mqtt.Client
+}
+
+func openMQTT(u *url.URL) (Driver, error) {
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), "1883")
+	}
+	opts := mqtt.NewClientOptions().
+		AddBroker("tcp://" + addr).
+		SetClientID(u.Query().Get("client-id")).
+		SetProtocolVersion(4).
+		SetCleanSession(true).
+		SetKeepAlive(keepAlive).
+		SetConnectTimeout(dialTimeout).
+		SetWriteTimeout(dialTimeout).
+		SetAutoReconnect(false)
+	if u.User != nil {
+		opts.SetUsername(u.User.Username())
+		if pass, ok := u.User.Password(); ok {
+			opts.SetPassword(pass)
+		}
+	}
+	return &mqttDriver{opts: opts}, nil
+}
+
+// connect connects a new client to the broker. Must be called with the lock held.
+func (d *mqttDriver) connect() error {
+	client := mqtt.NewClient(d.opts)
+	if err := wait(client.Connect()); err != nil {
+		return err
+	}
+	d.client = client
+	return nil
+}
+
+// wait waits for the completion of the operation of the token, up to dialTimeout
+func wait(token mqtt.Token) error {
+	if !token.WaitTimeout(dialTimeout) {
+		return errTimeout
+	}
+	return token.Error()
+}
+
+func (d *mqttDriver) Publish(topic []string, payload []byte) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.client == nil || !d.client.IsConnectionOpen() {
+		if err := d.connect(); err != nil {
+			return err
+		}
+	}
+	return wait(d.client.Publish(strings.Join(topic, "/"), 0, false, payload))
+}
+
+func (d *mqttDriver) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.client != nil {
+		d.client.Disconnect(uint(time.Second / time.Millisecond))
+		d.client = nil
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package broker
+
+import (
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+const (
+	// dialTimeout limits connecting and handshaking with a broker
+	dialTimeout = 10 * time.Second
+	// maxPingsOut is the number of pings the broker may leave unanswered before the connection is considered lost
+	maxPingsOut = 2
+)
+
+// keepAlive is the interval of the pings that check that an idle connection to a broker is alive
+var keepAlive = 30 * time.Second
+
+// natsDriver publishes to a nats server with the nats client. Messages are published without acknowledgement, like
+// nats core does. The client answers the pings of the server, pings it on idle connections and reconnects after a
+// failure, and the driver connects again on the next message once the client gives up.
+type natsDriver struct {
+	url  string
+	opts []nats.Option
+
+	mu   sync.Mutex
+	conn *nats.Conn
+}
+
+func openNATS(u *url.URL) (Driver, error) {
+	server := *u
+	if u.Port() == "" {
+		server.Host = net.JoinHostPort(u.Hostname(), "4222")
+	}
+	return &natsDriver{
+		url: server.String(),
+		opts: []nats.Option{
+			nats.Name("go-spacemesh"),
+			nats.Timeout(dialTimeout),
+			nats.PingInterval(keepAlive),
+			nats.MaxPingsOutstanding(maxPingsOut),
+			nats.NoCallbacksAfterClientClose(),
+		},
+	}, nil
+}
+
+func (d *natsDriver) Publish(topic []string, payload []byte) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.conn == nil || d.conn.IsClosed() {
+		conn, err := nats.Connect(d.url, d.opts...)
+		if err != nil {
+			return err
+		}
+		d.conn = conn
+	}
+	return d.conn.Publish(strings.Join(topic, "."), payload)
+}
+
+func (d *natsDriver) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.conn != nil {
+		d.conn.Close()
+		d.conn = nil
+	}
+	return nil
+}
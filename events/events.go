@@ -2,6 +2,8 @@
 package events
 
 import (
+	"time"
+
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/log"
 )
@@ -17,13 +19,25 @@ const (
 	EventRewardReceived
 	EventCreatedBlock
 	EventCreatedAtx
+	EventLayerUpdate
+	EventTxInMesh
+	EventAtxInMesh
+	EventMalfeasance
+	EventTxInMempool
+	EventTxApplied
+	EventAccountUpdate
+	EventNode
+	EventEpochUpdate
+	EventPeer
+	EventLayerFinalized
 )
 
 // publisher is the event publisher singleton.
 var publisher *EventPublisher
 
-// Publish publishes an event on the pubsub singleton.
+// Publish publishes an event on the pubsub singleton and delivers it to in-process subscribers.
 func Publish(event Event) {
+	report(event)
 	if publisher != nil {
 		err := publisher.PublishEvent(event)
 		if err != nil {
@@ -161,10 +175,14 @@ func (ValidTx) GetChannel() ChannelID {
 	return EventTxValid
 }
 
-// RewardReceived signals reward has been received
+// RewardReceived signals reward has been received. It's published once for every block of a layer, Amount includes
+// the block's share of the layer reward and of the transaction fees.
 type RewardReceived struct {
-	Coinbase string
-	Amount   uint64
+	Coinbase    string
+	Amount      uint64
+	Layer       uint64
+	LayerReward uint64
+	Smesher     string
 }
 
 // GetChannel gets the message type which means on which this message should be sent
@@ -183,3 +201,208 @@ type AtxCreated struct {
 func (AtxCreated) GetChannel() ChannelID {
 	return EventCreatedAtx
 }
+
+// LayerStatus is the status of a layer reported by LayerUpdate
+type LayerStatus int
+
+// These are the statuses a layer goes through, in order
+const (
+	// LayerStatusCreated means that the first block of the layer was received
+	LayerStatusCreated LayerStatus = iota
+	// LayerStatusApproved means that the hare agreed on the layer's blocks
+	LayerStatusApproved
+	// LayerStatusConfirmed means that the layer was applied to the global state
+	LayerStatusConfirmed
+)
+
+// LayerUpdate signals that a layer changed its status
+type LayerUpdate struct {
+	LayerID types.LayerID
+	Status  LayerStatus
+	// Blocks are the valid blocks of the layer, not set for created layers
+	Blocks []types.BlockID
+	// StateRoot is the global state root after applying the layer, only set for confirmed layers
+	StateRoot types.Hash32
+}
+
+// GetChannel gets the message type which means on which this message should be sent
+func (LayerUpdate) GetChannel() ChannelID {
+	return EventLayerUpdate
+}
+
+// TxInMesh signals that a transaction was added to the mesh as part of a block. It's published once for every
+// transaction, with the layer of the first block that included it.
+type TxInMesh struct {
+	Transaction *types.Transaction
+	LayerID     types.LayerID
+}
+
+// GetChannel gets the message type which means on which this message should be sent
+func (TxInMesh) GetChannel() ChannelID {
+	return EventTxInMesh
+}
+
+// AtxInMesh signals that an activation transaction was processed and stored
+type AtxInMesh struct {
+	Atx *types.ActivationTx
+}
+
+// GetChannel gets the message type which means on which this message should be sent
+func (AtxInMesh) GetChannel() ChannelID {
+	return EventAtxInMesh
+}
+
+// MalfeasanceDetected signals that a smesher was found breaking the protocol. It's published once for every smesher.
+type MalfeasanceDetected struct {
+	Proof *types.MalfeasanceProof
+}
+
+// GetChannel gets the message type which means on which this message should be sent
+func (MalfeasanceDetected) GetChannel() ChannelID {
+	return EventMalfeasance
+}
+
+// TxInMempool signals that a transaction passed validation and was added to the mempool
+type TxInMempool struct {
+	Transaction *types.Transaction
+}
+
+// GetChannel gets the message type which means on which this message should be sent
+func (TxInMempool) GetChannel() ChannelID {
+	return EventTxInMempool
+}
+
+// TxResult is the outcome of applying a transaction to the global state
+type TxResult int
+
+// These are the possible outcomes of applying a transaction
+const (
+	// TxResultApplied means that the transaction was applied
+	TxResultApplied TxResult = iota
+	// TxResultUnknownOrigin means that the origin of the transaction has no account
+	TxResultUnknownOrigin
+	// TxResultBadNonce means that the nonce of the transaction didn't match the nonce of the origin
+	TxResultBadNonce
+	// TxResultInsufficientFunds means that the origin couldn't pay for the amount and fee of the transaction
+	TxResultInsufficientFunds
+)
+
+// TxApplied signals that a transaction of a confirmed layer was applied to the global state, or failed to apply and was
+// dropped
+type TxApplied struct {
+	ID      types.TransactionID
+	LayerID types.LayerID
+	Fee     uint64
+	Result  TxResult
+	GasUsed uint64
+	// Error describes why the transaction failed, it's empty for applied transactions
+	Error string
+}
+
+// GetChannel gets the message type which means on which this message should be sent
+func (TxApplied) GetChannel() ChannelID {
+	return EventTxApplied
+}
+
+// AccountUpdate signals that the balance or nonce of an account changed when applying a layer to the global state
+type AccountUpdate struct {
+	Address types.Address
+	Nonce   uint64
+	Balance uint64
+	LayerID types.LayerID
+}
+
+// GetChannel gets the message type which means on which this message should be sent
+func (AccountUpdate) GetChannel() ChannelID {
+	return EventAccountUpdate
+}
+
+// NodeEventKind is the kind of a high level event in the lifecycle of the node
+type NodeEventKind int
+
+// These are the kinds of node events
+const (
+	// NodeEventSmeshingStarted means that the post is initialized and the node started to build ATXs
+	NodeEventSmeshingStarted NodeEventKind = iota
+	// NodeEventSmeshingStopped means that the node stopped building ATXs
+	NodeEventSmeshingStopped
+	// NodeEventAtxPublished means that the node broadcast an ATX
+	NodeEventAtxPublished
+	// NodeEventBeaconComputed means that the beacon of a new epoch was computed
+	NodeEventBeaconComputed
+	// NodeEventSyncStarted means that the node fell out of sync and started syncing
+	NodeEventSyncStarted
+	// NodeEventSyncCompleted means that the node is synced and listens to gossip
+	NodeEventSyncCompleted
+	// NodeEventBeaconFallback means that the hare beacon value of a layer fell back to a value derived from the layer
+	// below it because the layer had no contextually valid blocks
+	NodeEventBeaconFallback
+	// NodeEventTortoiseDiverged means that a rerun of the tortoise from genesis diverged from the opinion of the node.
+	// It's critical: the node may be on a different history than the network.
+	NodeEventTortoiseDiverged
+	// NodeEventReorgRejected means that the tortoise refused to revise the validity of blocks deeper than the reorg
+	// window, and stopped revising validity automatically until the node restarts. It's critical: the node may be on a
+	// different history than the network.
+	NodeEventReorgRejected
+)
+
+// NodeEvent signals a change in what the node is doing, it lets operators follow the node with a single subscription
+type NodeEvent struct {
+	Kind NodeEventKind
+	// Layer is the layer the event refers to, it is zero for events that don't refer to a layer
+	Layer   types.LayerID
+	Details string
+}
+
+// GetChannel gets the message type which means on which this message should be sent
+func (NodeEvent) GetChannel() ChannelID {
+	return EventNode
+}
+
+// EpochUpdate signals that the layer clock entered a new epoch. It summarizes the smeshers that are active in the epoch,
+// which are the ones that published an ATX in the previous epoch.
+type EpochUpdate struct {
+	Epoch types.EpochID
+	// TotalWeight is the total space committed by the active smeshers
+	TotalWeight    uint64
+	ActiveSmeshers uint64
+	Beacon         []byte
+}
+
+// GetChannel gets the message type which means on which this message should be sent
+func (EpochUpdate) GetChannel() ChannelID {
+	return EventEpochUpdate
+}
+
+// PeerUpdate signals that a gossip peer connected or disconnected
+type PeerUpdate struct {
+	ID        []byte // the public key of the peer
+	Connected bool   // false if the peer disconnected
+	// Address is the remote address of the connection, it is empty when the peer disconnected
+	Address    string
+	Outbound   bool
+	Reputation float64
+	// InboundPeers and OutboundPeers are the number of peers of each direction after the event
+	InboundPeers  uint64
+	OutboundPeers uint64
+	Time          time.Time
+}
+
+// GetChannel gets the message type which means on which this message should be sent
+func (PeerUpdate) GetChannel() ChannelID {
+	return EventPeer
+}
+
+// LayerFinalized signals that a layer became final: its valid blocks, as verified by the tortoise, can no longer change.
+// Layers are finalized in order.
+type LayerFinalized struct {
+	LayerID types.LayerID
+	// ByHare is true if the valid blocks of the layer are the blocks the hare approved for it, and false if the tortoise
+	// overrode the hare
+	ByHare bool
+}
+
+// GetChannel gets the message type which means on which this message should be sent
+func (LayerFinalized) GetChannel() ChannelID {
+	return EventLayerFinalized
+}
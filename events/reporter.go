@@ -0,0 +1,68 @@
+package events
+
+import (
+	"sync"
+
+	"github.com/spacemeshos/go-spacemesh/log"
+)
+
+// subscriptionBufferSize is the number of events buffered for every in-process subscription. Events published while the
+// buffer of a subscription is full are dropped for that subscription, so a slow subscriber never blocks the publisher.
+const subscriptionBufferSize = 1024
+
+// reporter delivers published events to in-process subscribers, e.g. streaming API endpoints.
+var reporter = struct {
+	sync.RWMutex
+	subs map[ChannelID]map[*Subscription]struct{}
+}{subs: make(map[ChannelID]map[*Subscription]struct{})}
+
+// Subscription receives the events that are published on a single channel within this process.
+type Subscription struct {
+	channel ChannelID
+	events  chan Event
+}
+
+// Subscribe returns a new subscription to the events published on channel. In-process subscriptions don't depend on
+// the pubsub publisher and receive events whether or not it was initialized. Close must be called to release it.
+func Subscribe(channel ChannelID) *Subscription {
+	sub := &Subscription{
+		channel: channel,
+		events:  make(chan Event, subscriptionBufferSize),
+	}
+	reporter.Lock()
+	defer reporter.Unlock()
+	if reporter.subs[channel] == nil {
+		reporter.subs[channel] = make(map[*Subscription]struct{})
+	}
+	reporter.subs[channel][sub] = struct{}{}
+	return sub
+}
+
+// Events returns the channel on which events are delivered. It is closed when the subscription is closed.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Close stops the delivery of events to the subscription. It is safe to call Close more than once.
+func (s *Subscription) Close() {
+	reporter.Lock()
+	defer reporter.Unlock()
+	if _, ok := reporter.subs[s.channel][s]; !ok {
+		return
+	}
+	delete(reporter.subs[s.channel], s)
+	close(s.events)
+}
+
+// report delivers the event to all in-process subscriptions of its channel
+func report(event Event) {
+	reporter.RLock()
+	defer reporter.RUnlock()
+	for sub := range reporter.subs[event.GetChannel()] {
+		select {
+		case sub.events <- event:
+		default:
+			log.Warning("in-process subscription to channel %v is full, dropping event", event.GetChannel())
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscribe(t *testing.T) {
+	sub1 := Subscribe(EventLayerUpdate)
+	defer sub1.Close()
+	sub2 := Subscribe(EventLayerUpdate)
+	other := Subscribe(EventNewBlock)
+	defer other.Close()
+
+	orig := LayerUpdate{LayerID: 3, Status: LayerStatusApproved, Blocks: []types.BlockID{{1}, {2}}}
+	Publish(orig)
+
+	for _, sub := range []*Subscription{sub1, sub2} {
+		select {
+		case ev := <-sub.Events():
+			require.Equal(t, orig, ev)
+		case <-time.After(time.Second):
+			require.Fail(t, "didn't receive event")
+		}
+	}
+	require.Len(t, other.Events(), 0)
+
+	// closed subscriptions don't receive events anymore
+	sub2.Close()
+	sub2.Close()
+	_, ok := <-sub2.Events()
+	require.False(t, ok)
+	Publish(LayerUpdate{LayerID: 4})
+	require.Equal(t, types.LayerID(4), (<-sub1.Events()).(LayerUpdate).LayerID)
+}
+
+func TestSubscribe_Full(t *testing.T) {
+	sub := Subscribe(EventLayerUpdate)
+	defer sub.Close()
+
+	// publishing never blocks, events beyond the buffer size are dropped
+	for i := 0; i < subscriptionBufferSize+10; i++ {
+		Publish(LayerUpdate{LayerID: types.LayerID(i)})
+	}
+	require.Len(t, sub.Events(), subscriptionBufferSize)
+	require.Equal(t, types.LayerID(0), (<-sub.Events()).(LayerUpdate).LayerID)
+}
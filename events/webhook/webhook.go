@@ -0,0 +1,342 @@
+// Package webhook posts node events to http endpoints configured by the operator
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/events"
+	"github.com/spacemeshos/go-spacemesh/log"
+	"go.uber.org/zap/zapcore"
+)
+
+// The events that hooks can subscribe to
+const (
+	// EventAtxPublished is sent when the node broadcast an ATX
+	EventAtxPublished = "atx-published"
+	// EventOutOfSync is sent when the node fell out of sync and started syncing
+	EventOutOfSync = "out-of-sync"
+	// EventSynced is sent when the node is synced again
+	EventSynced = "synced"
+	// EventReward is sent for every reward paid to the node
+	EventReward = "reward"
+	// EventError is sent for every log entry at or above the error level of the hook
+	EventError = "error"
+)
+
+var allEvents = map[string]struct{}{
+	EventAtxPublished: {},
+	EventOutOfSync:    {},
+	EventSynced:       {},
+	EventReward:       {},
+	EventError:        {},
+}
+
+// LoggerName is the name of the logger of the dispatcher, entries of this logger are never sent to hooks, so that a
+// failing hook doesn't report its own failures
+const LoggerName = "webhook"
+
+// SignatureHeader holds the hex encoded HMAC-SHA256 of the body, keyed with the secret of the hook, if it has one
+const SignatureHeader = "X-Spacemesh-Signature"
+
+// EventHeader holds the name of the event
+const EventHeader = "X-Spacemesh-Event"
+
+// Config is the configuration of the webhooks of the node
+type Config struct {
+	Hooks []HookConfig `mapstructure:"hooks"`
+	// MaxRetries is the number of times a failed delivery is retried
+	MaxRetries int `mapstructure:"max-retries"`
+	// RetryInterval is the delay before the first retry, it's doubled for every following retry
+	RetryInterval time.Duration `mapstructure:"retry-interval"`
+	// Timeout is the timeout of a single delivery attempt
+	Timeout time.Duration `mapstructure:"timeout"`
+	// QueueSize is the number of undelivered events kept for every hook, events are dropped when it's full
+	QueueSize int `mapstructure:"queue-size"`
+}
+
+// HookConfig is the configuration of a single webhook
+type HookConfig struct {
+	URL string `mapstructure:"url"`
+	// Secret keys the signature of the payloads, payloads aren't signed if it's empty
+	Secret string `mapstructure:"secret"`
+	// Events are the names of the events posted to the hook, all events are posted if it's empty
+	Events []string `mapstructure:"events"`
+	// ErrorLevel is the lowest level of the log entries sent as error events, "warn" or "error"
+	ErrorLevel string `mapstructure:"error-level"`
+}
+
+// DefaultConfig returns the default configuration, without any hooks
+func DefaultConfig() Config {
+	return Config{
+		MaxRetries:    5,
+		RetryInterval: time.Second,
+		Timeout:       10 * time.Second,
+		QueueSize:     1000,
+	}
+}
+
+// Payload is the json body posted to hooks
+type Payload struct {
+	Event string      `json:"event"`
+	Time  time.Time   `json:"time"`
+	Node  string      `json:"node"`
+	Data  interface{} `json:"data"`
+}
+
+type hook struct {
+	conf       HookConfig
+	events     map[string]struct{}
+	errorLevel zapcore.Level
+	queue      chan Payload
+}
+
+func (h *hook) subscribed(event string) bool {
+	if len(h.events) == 0 {
+		return true
+	}
+	_, ok := h.events[event]
+	return ok
+}
+
+// Dispatcher posts the events of the node to the configured hooks. Every hook has its own queue and worker, so a slow
+// or unreachable endpoint doesn't delay the others.
+type Dispatcher struct {
+	conf   Config
+	nodeID string
+	log    log.Log
+	client *http.Client
+	hooks  []*hook
+
+	subs       []*events.Subscription
+	removeHook func()
+	closeOnce  sync.Once
+	exit       chan struct{}
+	wg         sync.WaitGroup
+}
+
+// NewDispatcher validates the configuration and returns a dispatcher for the events of the node with id nodeID
+func NewDispatcher(conf Config, nodeID string, logger log.Log) (*Dispatcher, error) {
+	d := &Dispatcher{
+		conf:   conf,
+		nodeID: nodeID,
+		log:    logger,
+		client: &http.Client{Timeout: conf.Timeout},
+		exit:   make(chan struct{}),
+	}
+	for _, hc := range conf.Hooks {
+		u, err := url.Parse(hc.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid webhook url %q", hc.URL)
+		}
+		h := &hook{
+			conf:       hc,
+			events:     make(map[string]struct{}),
+			errorLevel: zapcore.ErrorLevel,
+			queue:      make(chan Payload, conf.QueueSize),
+		}
+		for _, e := range hc.Events {
+			if _, ok := allEvents[e]; !ok {
+				return nil, fmt.Errorf("unknown event %q of webhook %v", e, hc.URL)
+			}
+			h.events[e] = struct{}{}
+		}
+		if hc.ErrorLevel != "" {
+			if err := h.errorLevel.UnmarshalText([]byte(hc.ErrorLevel)); err != nil || h.errorLevel < zapcore.WarnLevel {
+				return nil, fmt.Errorf("invalid error level %q of webhook %v, expected warn or above", hc.ErrorLevel, hc.URL)
+			}
+		}
+		d.hooks = append(d.hooks, h)
+	}
+	return d, nil
+}
+
+// Start subscribes to the events of the node and starts delivering them
+func (d *Dispatcher) Start() {
+	for _, h := range d.hooks {
+		d.wg.Add(1)
+		go d.deliver(h)
+	}
+	node := events.Subscribe(events.EventNode)
+	rewards := events.Subscribe(events.EventRewardReceived)
+	d.subs = []*events.Subscription{node, rewards}
+	d.removeHook = log.AddHook(d.logged)
+	d.wg.Add(1)
+	go d.listen(node, rewards)
+}
+
+// Close stops the dispatcher, events that weren't delivered yet are dropped
+func (d *Dispatcher) Close() {
+	d.closeOnce.Do(func() {
+		if d.removeHook != nil {
+			d.removeHook()
+		}
+		for _, sub := range d.subs {
+			sub.Close()
+		}
+		close(d.exit)
+		d.wg.Wait()
+	})
+}
+
+func (d *Dispatcher) listen(node, rewards *events.Subscription) {
+	defer d.wg.Done()
+	for {
+		select {
+		case <-d.exit:
+			return
+		case e, ok := <-node.Events():
+			if !ok {
+				return
+			}
+			ev := e.(events.NodeEvent)
+			data := map[string]interface{}{"layer": ev.Layer.Uint64(), "details": ev.Details}
+			switch ev.Kind {
+			case events.NodeEventAtxPublished:
+				d.dispatch(EventAtxPublished, data)
+			case events.NodeEventSyncStarted:
+				d.dispatch(EventOutOfSync, data)
+			case events.NodeEventSyncCompleted:
+				d.dispatch(EventSynced, data)
+			}
+		case e, ok := <-rewards.Events():
+			if !ok {
+				return
+			}
+			reward := e.(events.RewardReceived)
+			if reward.Smesher != d.nodeID {
+				continue
+			}
+			d.dispatch(EventReward, map[string]interface{}{
+				"coinbase":    reward.Coinbase,
+				"amount":      reward.Amount,
+				"layer":       reward.Layer,
+				"layerReward": reward.LayerReward,
+			})
+		}
+	}
+}
+
+// logged is the log hook that sends warnings and errors as error events
+func (d *Dispatcher) logged(entry zapcore.Entry, fields map[string]interface{}) {
+	if strings.HasSuffix(strings.TrimSpace(entry.LoggerName), LoggerName) {
+		return
+	}
+	p := Payload{
+		Event: EventError,
+		Time:  entry.Time,
+		Node:  d.nodeID,
+		Data: map[string]interface{}{
+			"level":   entry.Level.String(),
+			"logger":  strings.TrimSpace(entry.LoggerName),
+			"message": entry.Message,
+			"fields":  fields,
+		},
+	}
+	for _, h := range d.hooks {
+		if entry.Level >= h.errorLevel && h.subscribed(EventError) {
+			d.enqueue(h, p)
+		}
+	}
+}
+
+func (d *Dispatcher) dispatch(event string, data interface{}) {
+	p := Payload{Event: event, Time: time.Now(), Node: d.nodeID, Data: data}
+	for _, h := range d.hooks {
+		if h.subscribed(event) {
+			d.enqueue(h, p)
+		}
+	}
+}
+
+// enqueue never blocks, it's called by the log hook
+func (d *Dispatcher) enqueue(h *hook, p Payload) {
+	select {
+	case h.queue <- p:
+	default:
+		d.log.Warning("queue of webhook %v is full, dropping %v event", h.conf.URL, p.Event)
+	}
+}
+
+func (d *Dispatcher) deliver(h *hook) {
+	defer d.wg.Done()
+	for {
+		select {
+		case <-d.exit:
+			return
+		case p := <-h.queue:
+			body, err := json.Marshal(p)
+			if err != nil {
+				d.log.Error("failed to encode %v event for webhook %v: %v", p.Event, h.conf.URL, err)
+				continue
+			}
+			d.post(h, p.Event, body)
+		}
+	}
+}
+
+// post delivers a payload, retrying with an exponential backoff when the request fails, the server fails or the
+// server is rate limiting
+func (d *Dispatcher) post(h *hook, event string, body []byte) {
+	interval := d.conf.RetryInterval
+	for attempt := 0; ; attempt++ {
+		err := d.send(h, event, body)
+		if err == nil {
+			return
+		}
+		if _, ok := err.(permanentError); ok || attempt >= d.conf.MaxRetries {
+			d.log.Warning("failed to deliver %v event to webhook %v: %v", event, h.conf.URL, err)
+			return
+		}
+		select {
+		case <-d.exit:
+			return
+		case <-time.After(interval):
+		}
+		interval *= 2
+	}
+}
+
+type permanentError struct {
+	error
+}
+
+func (d *Dispatcher) send(h *hook, event string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, h.conf.URL, bytes.NewReader(body))
+	if err != nil {
+		return permanentError{err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, event)
+	if h.conf.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign([]byte(h.conf.Secret), body))
+	}
+	res, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	switch {
+	case res.StatusCode >= 200 && res.StatusCode < 300:
+		return nil
+	case res.StatusCode >= 500 || res.StatusCode == http.StatusTooManyRequests:
+		return fmt.Errorf("server responded %v", res.Status)
+	}
+	return permanentError{fmt.Errorf("server responded %v", res.Status)}
+}
+
+// Sign returns the value of the signature header of body: "sha256=" followed by the hex encoded HMAC-SHA256 of body
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
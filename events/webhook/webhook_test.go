@@ -0,0 +1,137 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/events"
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/stretchr/testify/require"
+)
+
+type request struct {
+	header  http.Header
+	body    []byte
+	payload Payload
+}
+
+// launchServer returns a server that fails the first failures requests, and sends the others on the returned channel
+func launchServer(t *testing.T, failures int32) (*httptest.Server, chan request) {
+	requests := make(chan request, 100)
+	var count int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&count, 1) <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		req := request{header: r.Header, body: body}
+		require.NoError(t, json.Unmarshal(body, &req.payload))
+		requests <- req
+	}))
+	return server, requests
+}
+
+func receive(t *testing.T, requests chan request) request {
+	select {
+	case req := <-requests:
+		return req
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "webhook wasn't called")
+	}
+	return request{}
+}
+
+func testConfig(hooks ...HookConfig) Config {
+	conf := DefaultConfig()
+	conf.Hooks = hooks
+	conf.RetryInterval = 10 * time.Millisecond
+	return conf
+}
+
+func TestDispatcher(t *testing.T) {
+	server, requests := launchServer(t, 2)
+	defer server.Close()
+
+	d, err := NewDispatcher(testConfig(HookConfig{
+		URL:    server.URL,
+		Secret: "secret",
+		Events: []string{EventAtxPublished, EventReward, EventError},
+	}), "abcd", log.NewDefault(LoggerName))
+	require.NoError(t, err)
+	d.Start()
+	defer d.Close()
+
+	// failed deliveries are retried
+	events.Publish(events.NodeEvent{Kind: events.NodeEventAtxPublished, Layer: 7, Details: "atx"})
+	req := receive(t, requests)
+	require.Equal(t, EventAtxPublished, req.payload.Event)
+	require.Equal(t, "abcd", req.payload.Node)
+	require.Equal(t, map[string]interface{}{"layer": 7.0, "details": "atx"}, req.payload.Data)
+	require.Equal(t, EventAtxPublished, req.header.Get(EventHeader))
+	require.Equal(t, "application/json", req.header.Get("Content-Type"))
+	require.Equal(t, Sign([]byte("secret"), req.body), req.header.Get(SignatureHeader))
+
+	// events that aren't subscribed, and rewards of other smeshers, aren't sent
+	events.Publish(events.NodeEvent{Kind: events.NodeEventSyncStarted})
+	events.Publish(events.RewardReceived{Coinbase: "cb", Amount: 5, Layer: 3, Smesher: "other"})
+	events.Publish(events.RewardReceived{Coinbase: "cb", Amount: 10, Layer: 3, LayerReward: 50, Smesher: "abcd"})
+	req = receive(t, requests)
+	require.Equal(t, EventReward, req.payload.Event)
+	require.Equal(t, map[string]interface{}{"coinbase": "cb", "amount": 10.0, "layer": 3.0, "layerReward": 50.0}, req.payload.Data)
+
+	// warnings are below the default error level, and the dispatcher doesn't report its own errors
+	lg := log.NewDefault("test")
+	lg.Warning("warning")
+	log.NewDefault(LoggerName).Error("own error")
+	lg.With().Error("failure", log.String("reason", "test"))
+	req = receive(t, requests)
+	require.Equal(t, EventError, req.payload.Event)
+	require.Equal(t, map[string]interface{}{
+		"level":   "error",
+		"logger":  "test",
+		"message": "failure",
+		"fields":  map[string]interface{}{"reason": "test"},
+	}, req.payload.Data)
+
+	require.Len(t, requests, 0)
+}
+
+func TestDispatcher_PermanentFailure(t *testing.T) {
+	var count int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	d, err := NewDispatcher(testConfig(HookConfig{URL: server.URL}), "abcd", log.NewDefault(LoggerName))
+	require.NoError(t, err)
+	d.Start()
+	defer d.Close()
+
+	// client errors aren't retried
+	events.Publish(events.NodeEvent{Kind: events.NodeEventSyncCompleted})
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&count) == 1 }, time.Second, 10*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	require.EqualValues(t, 1, atomic.LoadInt32(&count))
+}
+
+func TestNewDispatcher_InvalidConfig(t *testing.T) {
+	for _, hc := range []HookConfig{
+		{URL: "localhost:8080"},
+		{URL: "ftp://localhost"},
+		{URL: "http://localhost", Events: []string{"unknown"}},
+		{URL: "http://localhost", ErrorLevel: "info"},
+		{URL: "http://localhost", ErrorLevel: "loud"},
+	} {
+		_, err := NewDispatcher(testConfig(hc), "abcd", log.NewDefault(LoggerName))
+		require.Error(t, err, hc)
+	}
+}
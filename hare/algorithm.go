@@ -9,6 +9,7 @@ import (
 	"github.com/spacemeshos/ed25519"
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/hare/config"
+	"github.com/spacemeshos/go-spacemesh/hare/metrics"
 	"github.com/spacemeshos/go-spacemesh/log"
 	"github.com/spacemeshos/go-spacemesh/p2p/service"
 	"github.com/spacemeshos/go-spacemesh/priorityq"
@@ -51,11 +52,13 @@ const (
 )
 
 // procReport is the termination report of the CP.
-// It consists of the layer id, the set we agreed on (if available) and a flag to indicate if the CP completed.
+// It consists of the layer id, the set we agreed on (if available), its certificate (if available) and a flag to
+// indicate if the CP completed.
 type procReport struct {
-	id        instanceID
-	set       *Set
-	completed bool
+	id          instanceID
+	set         *Set
+	completed   bool
+	certificate *certificate
 }
 
 func (cpo procReport) ID() instanceID {
@@ -70,8 +73,17 @@ func (cpo procReport) Completed() bool {
 	return cpo.completed
 }
 
+// Certificate returns the certificate of the set, nil if the CP didn't complete
+func (cpo procReport) Certificate() *certificate {
+	return cpo.certificate
+}
+
 func (proc *consensusProcess) report(completed bool) {
-	proc.terminationReport <- procReport{proc.instanceID, proc.s, completed}
+	var cert *certificate
+	if completed {
+		cert = proc.certificate
+	}
+	proc.terminationReport <- procReport{proc.instanceID, proc.s, completed, cert}
 }
 
 var _ TerminationOutput = (*procReport)(nil)
@@ -169,7 +181,11 @@ type consensusProcess struct {
 	pending           map[string]*Msg // buffer for early messages that are pending process
 	notifySent        bool            // flag to set in case a notification had already been sent by this instance
 	mTracker          *msgsTracker    // tracks valid messages
+	eTracker          *equivocationTracker
+	onEquivocation    func(proof *types.MalfeasanceProof) // called with the proofs of detected equivocations, may be nil
 	terminating       bool
+	startTime         time.Time   // when the event loop started
+	trace             *roundTrace // what was observed during the current round
 }
 
 // newConsensusProcess creates a new consensus process instance.
@@ -192,6 +208,7 @@ func newConsensusProcess(cfg config.Config, instanceID instanceID, s *Set, oracl
 		pending:           make(map[string]*Msg, cfg.N),
 		Log:               logger,
 		mTracker:          msgsTracker,
+		eTracker:          newEquivocationTracker(),
 	}
 	proc.validator = newSyntaxContextValidator(signing, cfg.F+1, proc.statusValidator(), stateQuerier, layersPerEpoch, ev, msgsTracker, logger)
 
@@ -253,6 +270,8 @@ func (proc *consensusProcess) eventLoop() {
 
 	// start the timer
 	timer := time.NewTimer(time.Duration(proc.cfg.RoundDuration) * time.Second)
+	proc.startTime = time.Now()
+	proc.trace = newRoundTrace(proc.k)
 
 	// check participation and send message
 	go func() {
@@ -280,6 +299,7 @@ PreRound:
 		case <-timer.C:
 			break PreRound
 		case <-proc.CloseChannel():
+			proc.traceTermination(resultCancelled)
 			return
 		}
 	}
@@ -289,6 +309,7 @@ PreRound:
 	} else {
 		proc.Info("PreRound ended")
 	}
+	proc.endRoundTrace()
 	proc.advanceToNextRound() // K was initialized to -1, K should be 0
 
 	// start first iteration
@@ -310,6 +331,7 @@ PreRound:
 			// exit if we reached the limit on number of iterations
 			if proc.k/4 >= int32(proc.cfg.LimitIterations) {
 				proc.Warning("terminating: reached iterations limit")
+				proc.traceTermination(resultIterationsLimit)
 				proc.report(notCompleted)
 				return
 			}
@@ -317,6 +339,7 @@ PreRound:
 			proc.onRoundBegin()
 		case <-proc.CloseChannel(): // close event
 			proc.Info("terminating: received termination signal")
+			proc.traceTermination(resultCancelled)
 			proc.report(notCompleted)
 			return
 		}
@@ -398,6 +421,16 @@ func (proc *consensusProcess) handleMessage(m *Msg) {
 		return
 	}
 
+	// an equivocator's second message isn't processed
+	if prev := proc.eTracker.Track(m); prev != nil {
+		proof := newEquivocationProof(prev, m)
+		proc.With().Warning("equivocation detected", append(proof.Fields(), log.Int32("msg_k", m.InnerMsg.K))...)
+		if proc.onEquivocation != nil {
+			proc.onEquivocation(proof)
+		}
+		return
+	}
+
 	// warn on late pre-round msgs
 	if m.InnerMsg.Type == pre && proc.k != -1 {
 		proc.With().Warning("encountered late PreRound message", log.String("sender_id", m.PubKey.ShortString()), types.LayerID(proc.instanceID))
@@ -410,8 +443,8 @@ func (proc *consensusProcess) handleMessage(m *Msg) {
 // process the message by its type
 func (proc *consensusProcess) processMsg(m *Msg) {
 	proc.Debug("Processing message of type %v", m.InnerMsg.Type.String())
-	// TODO: fix metrics
-	// metrics.MessageTypeCounter.With("type_id", m.InnerMsg.Type.String(), "layer", strconv.FormatUint(uint64(m.InnerMsg.InstanceID), 10), "reporter", "processMsg").Add(1)
+	metrics.MessageTypeCounter.With("type_id", m.InnerMsg.Type.String(), "reporter", "processMsg").Add(1)
+	proc.trace.onMessage(m)
 
 	switch m.InnerMsg.Type {
 	case pre:
@@ -471,6 +504,7 @@ func (proc *consensusProcess) onRoundEnd() {
 	case commitRound:
 		proc.With().Info("commit round ended", types.LayerID(proc.instanceID))
 	}
+	proc.endRoundTrace()
 }
 
 // advances the state to the next round
@@ -561,19 +595,23 @@ func (proc *consensusProcess) beginNotifyRound() {
 
 	if proc.proposalTracker.IsConflicting() {
 		proc.Warning("Begin notify round: proposal is conflicting")
+		metrics.Certificates.With(metrics.ResultLabel, "conflicting_proposal").Add(1)
 		return
 	}
 
 	if !proc.commitTracker.HasEnoughCommits() {
 		proc.With().Warning("Begin notify round: not enough commits", log.Int("expected", proc.cfg.F+1), log.Int("actual", proc.commitTracker.CommitCount()))
+		metrics.Certificates.With(metrics.ResultLabel, "not_enough_commits").Add(1)
 		return
 	}
 
 	cert := proc.commitTracker.BuildCertificate()
 	if cert == nil {
 		proc.Error("Begin notify round: Build certificate returned nil")
+		metrics.Certificates.With(metrics.ResultLabel, "failed").Add(1)
 		return
 	}
+	metrics.Certificates.With(metrics.ResultLabel, "built").Add(1)
 
 	s := proc.proposalTracker.ProposedSet()
 	if s == nil {
@@ -614,6 +652,8 @@ func (proc *consensusProcess) handlePending(pending map[string]*Msg) {
 // runs the logic of the beginning of a round by its type
 // pending messages are passed for handling
 func (proc *consensusProcess) onRoundBegin() {
+	proc.trace = newRoundTrace(proc.k)
+
 	// reset trackers
 	switch proc.currentRound() {
 	case statusRound:
@@ -704,6 +744,7 @@ func (proc *consensusProcess) processNotifyMsg(msg *Msg) {
 	proc.s = s // update to the agreed set
 	proc.Event().Info("Consensus process terminated", log.String("current_set", proc.s.String()),
 		types.LayerID(proc.instanceID), log.Int("set_size", proc.s.Size()))
+	proc.traceTermination(resultCompleted)
 	proc.report(completed)
 	close(proc.CloseChannel())
 	proc.terminating = true
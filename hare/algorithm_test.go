@@ -245,6 +245,28 @@ func TestConsensusProcess_handleMessage(t *testing.T) {
 	r.Equal(1, len(proc.pending))
 }
 
+func TestConsensusProcess_handleMessage_Equivocation(t *testing.T) {
+	r := require.New(t)
+	proc := generateConsensusProcess(t)
+	proc.validator = &mockMessageValidator{syntaxValid: true}
+	var proofs []*types.MalfeasanceProof
+	proc.onEquivocation = func(proof *types.MalfeasanceProof) {
+		proofs = append(proofs, proof)
+	}
+	signer := generateSigning(t)
+	m1 := BuildPreRoundMsg(signer, NewSetFromValues(value1))
+	m2 := BuildPreRoundMsg(signer, NewSetFromValues(value2))
+	proc.handleMessage(m1)
+	proc.handleMessage(m1)
+	r.Empty(proofs)
+	proc.handleMessage(m2)
+	r.Len(proofs, 1)
+	r.Equal(signer.PublicKey().String(), proofs[0].NodeID.Key)
+	r.NoError(validateEquivocationProof(proofs[0]))
+	// the conflicting message isn't processed
+	r.False(proc.preRoundTracker.preRound[signer.PublicKey().String()].Contains(value2))
+}
+
 func TestConsensusProcess_nextRound(t *testing.T) {
 	sim := service.NewSimulator()
 	n1 := sim.NewNode()
@@ -478,13 +500,13 @@ func TestConsensusProcess_onEarlyMessage(t *testing.T) {
 }
 
 func TestProcOutput_Id(t *testing.T) {
-	po := procReport{instanceID1, nil, false}
+	po := procReport{instanceID1, nil, false, nil}
 	assert.Equal(t, po.ID(), instanceID1)
 }
 
 func TestProcOutput_Set(t *testing.T) {
 	es := NewDefaultEmptySet()
-	po := procReport{instanceID1, es, false}
+	po := procReport{instanceID1, es, false, nil}
 	assert.True(t, es.Equals(po.Set()))
 }
 
@@ -3,6 +3,7 @@ package hare
 import (
 	"errors"
 	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/hare/metrics"
 	"github.com/spacemeshos/go-spacemesh/log"
 	"github.com/spacemeshos/go-spacemesh/p2p/service"
 	"github.com/spacemeshos/go-spacemesh/priorityq"
@@ -89,7 +90,7 @@ func (b *Broker) Start() error {
 
 	b.isStarted = true
 
-	b.inbox = b.network.RegisterGossipProtocol(protoName, priorityq.Mid)
+	b.inbox = b.network.RegisterGossipProtocol(protoName, priorityq.High)
 	go b.eventLoop()
 
 	return nil
@@ -164,8 +165,7 @@ func (b *Broker) eventLoop() {
 			}
 
 			msgInstID := hareMsg.InnerMsg.InstanceID
-			// TODO: fix metrics
-			// metrics.MessageTypeCounter.With("type_id", hareMsg.InnerMsg.Type.String(), "layer", strconv.FormatUint(uint64(msgInstID), 10), "reporter", "brokerHandler").Add(1)
+			metrics.MessageTypeCounter.With("type_id", hareMsg.InnerMsg.Type.String(), "reporter", "brokerHandler").Add(1)
 			isEarly := false
 			if err := b.validate(hareMsg); err != nil {
 				if err != errEarlyMsg {
@@ -254,6 +254,14 @@ func (b *Broker) cleanOldLayers() {
 	}
 }
 
+// dropPending drops the early messages buffered for a layer that no consensus process will run for
+func (b *Broker) dropPending(id instanceID) {
+	if msgs, exist := b.pending[id]; exist {
+		metrics.PendingMessagesDropped.Add(float64(len(msgs)))
+		delete(b.pending, id)
+	}
+}
+
 func (b *Broker) updateSynchronicity(id instanceID) {
 	if _, ok := b.syncState[id]; ok { // already has result
 		return
@@ -288,8 +296,14 @@ func (b *Broker) Register(id instanceID) (chan *Msg, error) {
 	resCh := make(chan chan *Msg, 1)
 	regRequest := func() {
 		b.updateLatestLayer(id)
+		for pid := range b.pending {
+			if pid < id { // the layer was skipped
+				b.dropPending(pid)
+			}
+		}
 
 		if len(b.outbox) >= b.limit {
+			b.dropPending(id)
 			resErr <- errTooMany
 			resCh <- nil
 			return
@@ -311,6 +325,7 @@ func (b *Broker) Register(id instanceID) (chan *Msg, error) {
 			return
 		}
 
+		b.dropPending(id)
 		resErr <- errInstanceNotSynced
 		resCh <- nil
 	}
@@ -249,6 +249,38 @@ func TestBroker_Register(t *testing.T) {
 	assert.Equal(t, 0, len(broker.pending[instanceID1]))
 }
 
+func TestBroker_DropPending(t *testing.T) {
+	r := require.New(t)
+	b := buildBroker(service.NewSimulator().NewNode(), t.Name())
+	b.Start()
+	msg := BuildPreRoundMsg(signing.NewEdSigner(), NewSetFromValues(value1))
+
+	// the messages of a layer whose process didn't start are dropped
+	b.isNodeSynced = falseFunc
+	b.tasks <- func() { b.pending[instanceID1] = []*Msg{msg} }
+	_, err := b.Register(instanceID1)
+	r.Equal(errInstanceNotSynced, err)
+
+	// and so are the messages of skipped layers
+	b.isNodeSynced = trueFunc
+	b.tasks <- func() {
+		b.pending[instanceID2] = []*Msg{msg}
+		b.pending[instanceID4] = []*Msg{msg, msg}
+	}
+	_, err = b.Register(instanceID3)
+	r.NoError(err)
+	c, err := b.Register(instanceID4)
+	r.NoError(err)
+	r.Len(c, 2)
+
+	done := make(chan struct{})
+	b.tasks <- func() {
+		r.Empty(b.pending)
+		close(done)
+	}
+	<-done
+}
+
 func assertMsg(t *testing.T, msg *mockGossipMessage) {
 	tm := time.NewTimer(2 * time.Second)
 	select {
@@ -0,0 +1,163 @@
+package hare
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/nullstyle/go-xdr/xdr3"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/database"
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/p2p/service"
+)
+
+// The output of a consensus process is certified by the f+1 commit messages for its set, signed by eligible committee
+// members. The certificate is stored and gossiped when the process terminates, and served to syncing peers, so that
+// nodes that were offline during a layer verify its hare output without running the protocol: the certificate is
+// valid only if it would be a valid certificate of a notify message of the layer.
+
+const certificateProtoName = "HARE_CERTIFICATE"
+
+// certificateStore stores the encoded certificates of the hare outputs of layers
+type certificateStore interface {
+	SaveCertificate(layer types.LayerID, cert []byte) error
+	GetCertificate(layer types.LayerID) ([]byte, error)
+	GetLayerInputVector(layer types.LayerID) ([]types.BlockID, error)
+}
+
+// layerCertificate is the certificate of the hare output of a layer as it's stored and gossiped
+type layerCertificate struct {
+	Layer types.LayerID
+	Cert  *certificate
+}
+
+func encodeLayerCertificate(lc *layerCertificate) ([]byte, error) {
+	var w bytes.Buffer
+	if _, err := xdr.Marshal(&w, lc); err != nil {
+		return nil, err
+	}
+	return w.Bytes(), nil
+}
+
+func decodeLayerCertificate(buf []byte) (*layerCertificate, error) {
+	lc := &layerCertificate{}
+	if _, err := xdr.Unmarshal(bytes.NewReader(buf), lc); err != nil {
+		return nil, err
+	}
+	return lc, nil
+}
+
+// SetCertificateStore sets the store of the certificates of the hare outputs. Certificates aren't stored nor gossiped
+// until it's set. It must be called before Start.
+func (h *Hare) SetCertificateStore(store certificateStore) {
+	h.certificates = store
+}
+
+// validateLayerCertificate checks that the certificate holds f+1 commit messages of the layer for its set, signed by
+// distinct eligible committee members in the same round
+func (h *Hare) validateLayerCertificate(lc *layerCertificate) error {
+	if lc.Cert == nil || lc.Cert.AggMsgs == nil || len(lc.Cert.AggMsgs.Messages) == 0 {
+		return errors.New("empty certificate")
+	}
+	for _, commit := range lc.Cert.AggMsgs.Messages {
+		if commit.InnerMsg == nil {
+			return errNilInner
+		}
+		if types.LayerID(commit.InnerMsg.InstanceID) != lc.Layer {
+			return fmt.Errorf("commit message of layer %v", commit.InnerMsg.InstanceID)
+		}
+	}
+	v := newSyntaxContextValidator(h.sign, h.config.F+1, nil, h.stateQuerier, h.layersPerEpoch, h.eligibility,
+		newMsgsTracker(), h.Log)
+	if !v.validateCertificate(lc.Cert) {
+		return errors.New("invalid certificate")
+	}
+	return nil
+}
+
+// certify stores and gossips the certificate of the output of a consensus process
+func (h *Hare) certify(layer types.LayerID, cert *certificate) {
+	if h.certificates == nil || cert == nil {
+		return
+	}
+	buf, err := encodeLayerCertificate(&layerCertificate{Layer: layer, Cert: cert})
+	if err != nil {
+		h.With().Error("could not serialize hare certificate", layer, log.Err(err))
+		return
+	}
+	if err := h.certificates.SaveCertificate(layer, buf); err != nil {
+		h.With().Error("could not store hare certificate", layer, log.Err(err))
+		return
+	}
+	if err := h.network.Broadcast(certificateProtoName, buf); err != nil {
+		h.With().Error("could not gossip hare certificate", layer, log.Err(err))
+	}
+}
+
+// HandleCertificate validates and stores the encoded certificate of the hare output of the layer, received from a
+// peer. If the node has no hare output for a past layer, the certified set becomes its output. The output of the
+// current layer is left to the consensus process that may still run for it, and certificates of layers that fell out
+// of the retention horizon are ignored.
+func (h *Hare) HandleCertificate(layer types.LayerID, buf []byte) error {
+	if h.certificates == nil {
+		return errors.New("hare certificates aren't stored")
+	}
+	lc, err := decodeLayerCertificate(buf)
+	if err != nil {
+		return fmt.Errorf("could not decode certificate: %v", err)
+	}
+	if lc.Layer != layer {
+		return fmt.Errorf("certificate of layer %v", lc.Layer)
+	}
+	if _, err := h.certificates.GetCertificate(layer); err == nil || err == database.ErrPruned {
+		return nil
+	}
+	if err := h.validateLayerCertificate(lc); err != nil {
+		return err
+	}
+	if err := h.certificates.SaveCertificate(layer, buf); err != nil {
+		return fmt.Errorf("could not store certificate: %v", err)
+	}
+	if layer >= h.getLastLayer() {
+		return nil
+	}
+	if _, err := h.certificates.GetLayerInputVector(layer); err != database.ErrNotFound {
+		return nil
+	}
+	h.With().Info("applying certified hare output", layer, log.Int("blocks", len(lc.Cert.Values)))
+	h.msh.HandleValidatedLayer(layer, lc.Cert.Values)
+	return nil
+}
+
+// listens to the certificates gossiped by peers
+func (h *Hare) certificateLoop(inbox chan service.GossipMessage) {
+	for {
+		select {
+		case msg := <-inbox:
+			if msg == nil {
+				h.Error("certificate inbox closed")
+				return
+			}
+			h.handleCertificateMsg(msg)
+		case <-h.CloseChannel():
+			return
+		}
+	}
+}
+
+func (h *Hare) handleCertificateMsg(msg service.GossipMessage) {
+	lc, err := decodeLayerCertificate(msg.Bytes())
+	if err != nil {
+		h.With().Warning("could not decode gossiped hare certificate", log.Err(err))
+		return
+	}
+	if _, err := h.certificates.GetCertificate(lc.Layer); err == nil || err == database.ErrPruned {
+		return // already known or obsolete, don't relay it again
+	}
+	if err := h.HandleCertificate(lc.Layer, msg.Bytes()); err != nil {
+		h.With().Warning("invalid gossiped hare certificate", lc.Layer, log.Err(err))
+		return
+	}
+	msg.ReportValidation(certificateProtoName)
+}
@@ -0,0 +1,118 @@
+package hare
+
+import (
+	"testing"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/database"
+	"github.com/spacemeshos/go-spacemesh/hare/config"
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/p2p/service"
+	"github.com/spacemeshos/go-spacemesh/signing"
+	"github.com/stretchr/testify/require"
+)
+
+type certStoreMock struct {
+	certs    map[types.LayerID][]byte
+	vectors  map[types.LayerID][]types.BlockID
+	retained types.LayerID
+}
+
+func newCertStoreMock() *certStoreMock {
+	return &certStoreMock{certs: make(map[types.LayerID][]byte), vectors: make(map[types.LayerID][]types.BlockID)}
+}
+
+func (m *certStoreMock) SaveCertificate(layer types.LayerID, cert []byte) error {
+	m.certs[layer] = cert
+	return nil
+}
+
+func (m *certStoreMock) GetCertificate(layer types.LayerID) ([]byte, error) {
+	if layer < m.retained {
+		return nil, database.ErrPruned
+	}
+	cert, ok := m.certs[layer]
+	if !ok {
+		return nil, database.ErrNotFound
+	}
+	return cert, nil
+}
+
+func (m *certStoreMock) GetLayerInputVector(layer types.LayerID) ([]types.BlockID, error) {
+	vector, ok := m.vectors[layer]
+	if !ok {
+		return nil, database.ErrNotFound
+	}
+	return vector, nil
+}
+
+func (m *certStoreMock) HandleValidatedLayer(layer types.LayerID, blocks []types.BlockID) {
+	m.vectors[layer] = blocks
+}
+
+func (m *certStoreMock) LayerBlockIds(types.LayerID) ([]types.BlockID, error) {
+	return nil, nil
+}
+
+func buildTestCertificate(t *testing.T, commits int) *certificate {
+	s := NewSetFromValues(value1, value2)
+	cert := &certificate{Values: s.ToSlice(), AggMsgs: &aggregatedMessages{}}
+	for i := 0; i < commits; i++ {
+		cert.AggMsgs.Messages = append(cert.AggMsgs.Messages, BuildCommitMsg(generateSigning(t), s).Message)
+	}
+	return cert
+}
+
+func TestHare_HandleCertificate(t *testing.T) {
+	r := require.New(t)
+	types.SetLayersPerEpoch(4)
+	defer func() {
+		// leave the zero genesis to the tests that run the protocol for the first layers
+		types.SetLayersPerEpoch(0)
+		types.EffectiveGenesis = 0
+	}()
+	cfg := config.Config{N: 3, F: 1, RoundDuration: 1, LimitIterations: 1, LimitConcurrent: 1}
+	store := newCertStoreMock()
+	h := New(cfg, service.NewSimulator().NewNode(), signing.NewEdSigner(), types.NodeID{}, validateBlocks,
+		(&mockSyncer{true}).IsSynced, store, newMockHashOracle(numOfClients), 10, &mockIDProvider{},
+		NewMockStateQuerier(), nil, make(chan types.LayerID), log.NewDefault(t.Name()))
+	h.lastLayer = 5
+
+	// certificates aren't handled until they're stored
+	buf, err := encodeLayerCertificate(&layerCertificate{Layer: 1, Cert: buildTestCertificate(t, cfg.F+1)})
+	r.NoError(err)
+	r.Error(h.HandleCertificate(1, buf))
+	h.SetCertificateStore(store)
+
+	// a certificate of another layer
+	r.Error(h.HandleCertificate(2, buf))
+	_, err = store.GetCertificate(2)
+	r.Equal(database.ErrNotFound, err)
+
+	// a certificate with less than f+1 commits
+	invalid, err := encodeLayerCertificate(&layerCertificate{Layer: 1, Cert: buildTestCertificate(t, cfg.F)})
+	r.NoError(err)
+	r.Error(h.HandleCertificate(1, invalid))
+	_, err = store.GetCertificate(1)
+	r.Equal(database.ErrNotFound, err)
+
+	// a valid certificate is stored and its set becomes the hare output of the layer
+	r.NoError(h.HandleCertificate(1, buf))
+	stored, err := store.GetCertificate(1)
+	r.NoError(err)
+	r.Equal(buf, stored)
+	r.ElementsMatch([]types.BlockID{value1, value2}, store.vectors[1])
+
+	lc, err := decodeLayerCertificate(stored)
+	r.NoError(err)
+	r.Equal(types.LayerID(1), lc.Layer)
+	r.Len(lc.Cert.AggMsgs.Messages, cfg.F+1)
+
+	// certificates of layers out of the retention horizon are ignored
+	store.retained = 3
+	buf, err = encodeLayerCertificate(&layerCertificate{Layer: 2, Cert: buildTestCertificate(t, cfg.F+1)})
+	r.NoError(err)
+	r.NoError(h.HandleCertificate(2, buf))
+	r.NotContains(store.certs, types.LayerID(2))
+	r.NotContains(store.vectors, types.LayerID(2))
+}
@@ -2,18 +2,27 @@ package eligibility
 
 import (
 	"errors"
+	"fmt"
 	lru "github.com/hashicorp/golang-lru"
 	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/common/util"
+	"github.com/spacemeshos/go-spacemesh/database"
+	"github.com/spacemeshos/go-spacemesh/events"
 	"github.com/spacemeshos/go-spacemesh/log"
 	"hash/fnv"
+	"sync"
 )
 
 const nilVal = 0
 
+// statusEpochs is the number of recent epochs the Beacon keeps the status of
+const statusEpochs = 10
+
 type patternProvider interface {
 	// GetPatternId returns the pattern ID of the given Layer
 	// the pattern ID is defined to be the hash of blocks in a Layer
 	ContextuallyValidBlock(layer types.LayerID) (map[types.BlockID]struct{}, error)
+	LayerBlockIds(layer types.LayerID) ([]types.BlockID, error)
 }
 
 type addGet interface {
@@ -28,6 +37,28 @@ type Beacon struct {
 	confidenceParam uint64
 	cache           addGet
 	log.Log
+
+	mu       sync.Mutex
+	statuses map[types.EpochID]*BeaconStatus
+}
+
+// BeaconStatus describes how the Beacon values of the layers of an epoch were computed
+type BeaconStatus struct {
+	Epoch types.EpochID
+	// Computed is the number of values computed from the contextually valid blocks of a safe layer
+	Computed uint32
+	// Fallbacks is the number of safe layers without contextually valid blocks, whose value fell back to a value
+	// derived from the value of the layer below them
+	Fallbacks uint32
+	// Value is the last value returned for a layer of the epoch
+	Value uint32
+	// Proposals is the number of blocks proposed in the safe layer of the last computed value
+	Proposals uint32
+	// ValidBlocks is the number of proposals of the safe layer of the last computed value that the tortoise votes
+	// made contextually valid, the value is based on them
+	ValidBlocks uint32
+	// Fallback is true if the last value computed for a layer of the epoch is a fallback value
+	Fallback bool
 }
 
 // NewBeacon returns a new Beacon.
@@ -43,16 +74,21 @@ func NewBeacon(patternProvider patternProvider, confidenceParam uint64, lg log.L
 		confidenceParam: confidenceParam,
 		cache:           c,
 		Log:             lg,
+		statuses:        make(map[types.EpochID]*BeaconStatus),
 	}
 }
 
-// Value returns the unpredictable and agreed value for the given Layer
+// Value returns the unpredictable and agreed value for the given Layer. If the safe layer has no contextually valid
+// blocks, which all the nodes agree on, the value falls back to a value derived from the value of the layer below it.
 // Note: Value is concurrency-safe but not concurrency-optimized
 func (b *Beacon) Value(layer types.LayerID) (uint32, error) {
 	sl := safeLayer(layer, types.LayerID(b.confidenceParam))
 
 	// check cache
 	if val, exist := b.cache.Get(sl); exist {
+		b.updateStatus(layer, func(s *BeaconStatus) {
+			s.Value = val.(uint32)
+		})
 		return val.(uint32), nil
 	}
 
@@ -64,22 +100,109 @@ func (b *Beacon) Value(layer types.LayerID) (uint32, error) {
 			log.Err(err), layer, log.FieldNamed("sl_id", sl))
 		return nilVal, errors.New("could not calc Beacon value")
 	}
+	proposals, err := b.patternProvider.LayerBlockIds(sl)
+	if err != nil && err != database.ErrNotFound {
+		b.Log.With().Warning("hare Beacon: could not get the blocks of the safe layer",
+			layer, log.FieldNamed("sl_id", sl), log.Err(err))
+	}
 
-	// notify if there are no contextually valid blocks
-	if len(v) == 0 {
-		b.Log.With().Warning("hare Beacon: zero contextually valid blocks (ignore if genesis first layers)",
+	var value uint32
+	fallback := len(v) == 0
+	if fallback {
+		if value, err = b.fallbackValue(sl); err != nil {
+			b.Log.With().Error("Could not calc Beacon fallback value",
+				log.Err(err), layer, log.FieldNamed("sl_id", sl))
+			return nilVal, errors.New("could not calc Beacon value")
+		}
+		b.Log.With().Warning("hare Beacon: zero contextually valid blocks, falling back to the value of the layer below",
 			layer, log.FieldNamed("sl_id", sl))
+		events.Publish(events.NodeEvent{Kind: events.NodeEventBeaconFallback, Layer: sl,
+			Details: fmt.Sprintf("hare beacon of layer %v: no contextually valid blocks in layer %v", layer, sl)})
+	} else {
+		value = calcValue(v)
 	}
 
-	// calculate
-	value := calcValue(v)
-
 	// update
 	b.cache.Add(sl, value)
+	b.updateStatus(layer, func(s *BeaconStatus) {
+		if fallback {
+			s.Fallbacks++
+		} else {
+			s.Computed++
+		}
+		s.Value = value
+		s.Proposals = uint32(len(proposals))
+		s.ValidBlocks = uint32(len(v))
+		s.Fallback = fallback
+	})
 
 	return value, nil
 }
 
+// fallbackValue derives the value of a safe layer without contextually valid blocks from the value of the closest layer
+// below it with contextually valid blocks, so that it isn't known before the blocks of that layer are
+func (b *Beacon) fallbackValue(sl types.LayerID) (uint32, error) {
+	// find the closest layer below with a known value or with contextually valid blocks
+	var value uint32
+	layer := sl
+	for layer > 0 {
+		layer--
+		if val, exist := b.cache.Get(layer); exist {
+			value = val.(uint32)
+			break
+		}
+		v, err := b.patternProvider.ContextuallyValidBlock(layer)
+		if err != nil {
+			return nilVal, err
+		}
+		if len(v) > 0 {
+			value = calcValue(v)
+			b.cache.Add(layer, value)
+			break
+		}
+	}
+	// derive the values of the layers without contextually valid blocks above it
+	for layer++; layer <= sl; layer++ {
+		h := fnv.New32()
+		if _, err := h.Write(append(util.Uint32ToBytes(value), layer.Bytes()...)); err != nil {
+			log.Panic("Could not calculate Beacon fallback value. Hash write error=%v", err)
+		}
+		value = h.Sum32()
+	}
+	return value, nil
+}
+
+// updateStatus applies the update to the status of the epoch of the layer, and forgets the statuses of old epochs
+func (b *Beacon) updateStatus(layer types.LayerID, update func(s *BeaconStatus)) {
+	epoch := layer.GetEpoch()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s, ok := b.statuses[epoch]
+	if !ok {
+		s = &BeaconStatus{Epoch: epoch}
+		b.statuses[epoch] = s
+		for e := range b.statuses {
+			if e+statusEpochs <= epoch {
+				delete(b.statuses, e)
+			}
+		}
+	}
+	update(s)
+}
+
+// BeaconStatus returns how the values of the layers of the epoch were computed. Only the statuses of the last
+// statusEpochs epochs are kept.
+func (b *Beacon) BeaconStatus(epoch types.EpochID) (*BeaconStatus, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s, ok := b.statuses[epoch]
+	if !ok {
+		return nil, fmt.Errorf("no beacon values were computed for epoch %v", epoch)
+	}
+	res := *s
+	return &res, nil
+}
+
 // calculates the Beacon value from the set of ids
 func calcValue(bids map[types.BlockID]struct{}) uint32 {
 	keys := make([]types.BlockID, 0, len(bids))
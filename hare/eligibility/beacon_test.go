@@ -2,6 +2,7 @@ package eligibility
 
 import (
 	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/events"
 	"github.com/spacemeshos/go-spacemesh/log"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -12,16 +13,31 @@ type mockPatternProvider struct {
 	val        map[types.BlockID]struct{}
 	valGenesis map[types.BlockID]struct{}
 	err        error
+	layers     map[types.LayerID]map[types.BlockID]struct{} // overrides the valid blocks of the layers
 }
 
 func (mpp *mockPatternProvider) ContextuallyValidBlock(layer types.LayerID) (map[types.BlockID]struct{}, error) {
+	if v, ok := mpp.layers[layer]; ok {
+		return v, mpp.err
+	}
 	if layer == types.GetEffectiveGenesis() {
 		return mpp.valGenesis, mpp.err
 	}
 	return mpp.val, mpp.err
 }
 
+func (mpp *mockPatternProvider) LayerBlockIds(layer types.LayerID) ([]types.BlockID, error) {
+	v, err := mpp.ContextuallyValidBlock(layer)
+	ids := make([]types.BlockID, 0, len(v)+1)
+	for id := range v {
+		ids = append(ids, id)
+	}
+	// an invalid block
+	return append(ids, types.BlockID{}), err
+}
+
 func TestBeacon_Value(t *testing.T) {
+	types.SetLayersPerEpoch(10)
 	genesisBlock := types.NewExistingBlock(types.GetEffectiveGenesis(), []byte("genesis"))
 
 	block1 := types.NewExistingBlock(0, []byte("asghsfgdhn"))
@@ -42,11 +58,11 @@ func TestBeacon_Value(t *testing.T) {
 	valGoodPtrn[block2.ID()] = struct{}{}
 	valGoodPtrn[block3.ID()] = struct{}{}
 
-	b.patternProvider = &mockPatternProvider{valGoodPtrn, genesisGoodPtrn, errFoo}
+	b.patternProvider = &mockPatternProvider{val: valGoodPtrn, valGenesis: genesisGoodPtrn, err: errFoo}
 	b.confidenceParam = cfg.ConfidenceParam
 	_, err := b.Value(100)
 	r.NotNil(err)
-	b.patternProvider = &mockPatternProvider{valGoodPtrn, genesisGoodPtrn, nil}
+	b.patternProvider = &mockPatternProvider{val: valGoodPtrn, valGenesis: genesisGoodPtrn}
 	val, err := b.Value(100)
 	r.Nil(err)
 	r.Equal(calcValue(valGoodPtrn), val)
@@ -65,6 +81,72 @@ func TestBeacon_Value(t *testing.T) {
 	assert.Equal(t, calcValue(genesisGoodPtrn), val)
 }
 
+func TestBeacon_Fallback(t *testing.T) {
+	types.SetLayersPerEpoch(10)
+	r := require.New(t)
+	block := types.NewExistingBlock(0, []byte("asghsfgdhn"))
+	valid := map[types.BlockID]struct{}{block.ID(): {}}
+	empty := map[types.BlockID]struct{}{}
+	layer := types.LayerID(100)
+	p := &mockPatternProvider{val: valid, layers: map[types.LayerID]map[types.BlockID]struct{}{
+		layer: empty, layer - 1: empty}}
+	b := NewBeacon(p, 0, log.NewDefault(t.Name()))
+	sub := events.Subscribe(events.EventNode)
+	defer sub.Close()
+
+	// the fallback value is derived from the value of the closest layer below with contextually valid blocks
+	val, err := b.Value(layer)
+	r.NoError(err)
+	r.NotEqual(calcValue(empty), val)
+	r.NotEqual(calcValue(valid), val)
+	ev := <-sub.Events()
+	r.Equal(events.NodeEventBeaconFallback, ev.(events.NodeEvent).Kind)
+	r.Equal(layer, ev.(events.NodeEvent).Layer)
+
+	status, err := b.BeaconStatus(layer.GetEpoch())
+	r.NoError(err)
+	r.Equal(uint32(0), status.Computed)
+	r.Equal(uint32(1), status.Fallbacks)
+	r.Equal(val, status.Value)
+	r.Equal(uint32(1), status.Proposals)
+	r.Equal(uint32(0), status.ValidBlocks)
+	r.True(status.Fallback)
+
+	// it depends on the blocks of that layer
+	other := NewBeacon(&mockPatternProvider{val: map[types.BlockID]struct{}{{1}: {}}, layers: p.layers}, 0,
+		log.NewDefault(t.Name()))
+	otherVal, err := other.Value(layer)
+	r.NoError(err)
+	r.NotEqual(val, otherVal)
+	<-sub.Events()
+
+	// the fallback value is cached and reported once
+	p.layers[layer] = valid
+	cached, err := b.Value(layer)
+	r.NoError(err)
+	r.Equal(val, cached)
+	r.Len(sub.Events(), 0)
+
+	val, err = b.Value(layer + 1)
+	r.NoError(err)
+	r.Equal(calcValue(valid), val)
+	status, err = b.BeaconStatus(layer.GetEpoch())
+	r.NoError(err)
+	r.Equal(uint32(1), status.Computed)
+	r.Equal(uint32(1), status.Fallbacks)
+	r.Equal(uint32(2), status.Proposals)
+	r.Equal(uint32(1), status.ValidBlocks)
+	r.False(status.Fallback)
+
+	// the layers of an unavailable safe layer have no value
+	p.err = errFoo
+	_, err = b.Value(layer + 2)
+	r.Error(err)
+
+	_, err = b.BeaconStatus(layer.GetEpoch() + 1)
+	r.Error(err)
+}
+
 func TestNewBeacon(t *testing.T) {
 	r := require.New(t)
 	p := &mockPatternProvider{}
@@ -0,0 +1,109 @@
+package eligibility
+
+import (
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/hare/metrics"
+)
+
+// The oracle caches in layers: the active set of a safe epoch is shared by all the layers that derive their consensus
+// view from it, and the proofs and eligibility results of a layer are kept while its hare runs. When the contextually
+// valid blocks of a safe layer change, its active set is recomputed and the results of all layers are dropped.
+
+const layersCacheSize = 10 // the hare of a layer runs for a few layers at most, typically two run concurrently
+
+// Names of the oracle caches, as reported to the metrics
+const (
+	activeSetCache   = "active_set"
+	eligibilityCache = "eligibility"
+	proofCache       = "proof"
+)
+
+// activeSet is the cached active set of a safe epoch
+type activeSet struct {
+	blocks  types.Hash32 // the hash of the contextually valid blocks of the safe layer it was computed from
+	actives map[string]struct{}
+	checked types.LayerID // the latest layer for which the valid blocks of the safe layer were checked
+}
+
+// eligibilityKey identifies the eligibility of a role proof in a round of a layer
+type eligibilityKey struct {
+	round         int32
+	committeeSize int
+	vrfPub        string
+	sig           string
+}
+
+// layerCache holds the proofs and eligibility results of a layer
+type layerCache struct {
+	proofs   map[int32][]byte
+	eligible map[eligibilityKey]bool
+}
+
+func newLayerCache() *layerCache {
+	return &layerCache{
+		proofs:   make(map[int32][]byte),
+		eligible: make(map[eligibilityKey]bool),
+	}
+}
+
+// calcBlocksHash returns the hash of a set of contextually valid blocks
+func calcBlocksHash(blocks map[types.BlockID]struct{}) types.Hash32 {
+	ids := make([]types.BlockID, 0, len(blocks))
+	for id := range blocks {
+		ids = append(ids, id)
+	}
+	return types.CalcBlocksHash32(ids, nil)
+}
+
+func reportCacheQuery(cache string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	metrics.OracleCacheQueries.With(metrics.CacheLabel, cache, metrics.ResultLabel, result).Add(1)
+}
+
+// layerCacheOf returns the cache of the layer, o.layersLock must be held
+func (o *Oracle) layerCacheOf(layer types.LayerID) *layerCache {
+	if lc, ok := o.layersCache.Get(layer); ok {
+		return lc.(*layerCache)
+	}
+	lc := newLayerCache()
+	o.layersCache.Add(layer, lc)
+	return lc
+}
+
+func (o *Oracle) cachedEligibility(layer types.LayerID, key eligibilityKey) (eligible bool, ok bool) {
+	o.layersLock.Lock()
+	defer o.layersLock.Unlock()
+	eligible, ok = o.layerCacheOf(layer).eligible[key]
+	reportCacheQuery(eligibilityCache, ok)
+	return eligible, ok
+}
+
+func (o *Oracle) cacheEligibility(layer types.LayerID, key eligibilityKey, eligible bool) {
+	o.layersLock.Lock()
+	defer o.layersLock.Unlock()
+	o.layerCacheOf(layer).eligible[key] = eligible
+}
+
+func (o *Oracle) cachedProof(layer types.LayerID, round int32) ([]byte, bool) {
+	o.layersLock.Lock()
+	defer o.layersLock.Unlock()
+	proof, ok := o.layerCacheOf(layer).proofs[round]
+	reportCacheQuery(proofCache, ok)
+	return proof, ok
+}
+
+func (o *Oracle) cacheProof(layer types.LayerID, round int32, proof []byte) {
+	o.layersLock.Lock()
+	defer o.layersLock.Unlock()
+	o.layerCacheOf(layer).proofs[round] = proof
+}
+
+// purgeLayers drops the cached results of all layers once an active set they may depend on changed
+func (o *Oracle) purgeLayers() {
+	o.layersLock.Lock()
+	defer o.layersLock.Unlock()
+	o.layersCache.Purge()
+}
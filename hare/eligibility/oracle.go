@@ -9,6 +9,7 @@ import (
 	"github.com/nullstyle/go-xdr/xdr3"
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	eCfg "github.com/spacemeshos/go-spacemesh/hare/eligibility/config"
+	"github.com/spacemeshos/go-spacemesh/hare/metrics"
 	"github.com/spacemeshos/go-spacemesh/log"
 	"math"
 	"sync"
@@ -50,7 +51,9 @@ type Oracle struct {
 	vrfVerifier          verifierFunc
 	layersPerEpoch       uint16
 	vrfMsgCache          addGet
-	activesCache         addGet
+	activesCache         addGet     // active sets by safe epoch
+	layersLock           sync.Mutex // guards layersCache
+	layersCache          *lru.Cache // proofs and eligibility results by layer
 	genesisActiveSetSize int
 	blocksProvider       goodBlocksProvider
 	cfg                  eCfg.Config
@@ -104,6 +107,11 @@ func New(beacon valueProvider, activeSetFunc activeSetFunc, vrfVerifier verifier
 		log.Panic("Could not create lru cache err=%v", e)
 	}
 
+	lc, e := lru.New(layersCacheSize)
+	if e != nil {
+		log.Panic("Could not create lru cache err=%v", e)
+	}
+
 	return &Oracle{
 		beacon:               beacon,
 		getActiveSet:         activeSetFunc,
@@ -112,6 +120,7 @@ func New(beacon valueProvider, activeSetFunc activeSetFunc, vrfVerifier verifier
 		layersPerEpoch:       layersPerEpoch,
 		vrfMsgCache:          vmc,
 		activesCache:         ac,
+		layersCache:          lc,
 		genesisActiveSetSize: genesisActiveSet,
 		blocksProvider:       goodBlocksProvider,
 		cfg:                  cfg,
@@ -182,6 +191,21 @@ func (o *Oracle) activeSetSize(layer types.LayerID) (uint32, error) {
 
 // Eligible checks if ID is eligible on the given Layer where msg is the VRF message, sig is the role proof and assuming commSize as the expected committee size
 func (o *Oracle) Eligible(layer types.LayerID, round int32, committeeSize int, id types.NodeID, sig []byte) (bool, error) {
+	key := eligibilityKey{round: round, committeeSize: committeeSize, vrfPub: string(id.VRFPublicKey), sig: string(sig)}
+	if eligible, ok := o.cachedEligibility(layer, key); ok {
+		return eligible, nil
+	}
+
+	eligible, err := o.calcEligibility(layer, round, committeeSize, id, sig)
+	if err != nil {
+		return false, err
+	}
+	o.cacheEligibility(layer, key, eligible)
+	return eligible, nil
+}
+
+// calcEligibility verifies the role proof and checks it against the eligibility threshold of the round
+func (o *Oracle) calcEligibility(layer types.LayerID, round int32, committeeSize int, id types.NodeID, sig []byte) (bool, error) {
 	msg, err := o.buildVRFMessage(layer, round)
 	if err != nil {
 		o.Error("eligibility: could not build VRF message")
@@ -233,6 +257,10 @@ func (o *Oracle) Eligible(layer types.LayerID, round int32, committeeSize int, i
 
 // Proof returns the role proof for the current Layer & Round
 func (o *Oracle) Proof(layer types.LayerID, round int32) ([]byte, error) {
+	if proof, ok := o.cachedProof(layer, round); ok {
+		return proof, nil
+	}
+
 	msg, err := o.buildVRFMessage(layer, round)
 	if err != nil {
 		o.Error("Proof: could not build VRF message err=%v", err)
@@ -245,6 +273,7 @@ func (o *Oracle) Proof(layer types.LayerID, round int32) ([]byte, error) {
 		return nil, err
 	}
 
+	o.cacheProof(layer, round, sig)
 	return sig, nil
 }
 
@@ -263,17 +292,21 @@ func (o *Oracle) actives(layer types.LayerID) (map[string]struct{}, error) {
 	// lock until any return
 	// note: no need to lock per safeEp - we do not expect many concurrent requests per safeEp (max two)
 	o.lock.Lock()
+	defer o.lock.Unlock()
 
-	// check cache
+	// check cache, the valid blocks of the safe layer are checked again once per layer
+	var cached *activeSet
 	if val, exist := o.activesCache.Get(safeEp); exist {
-		o.lock.Unlock()
-		return val.(map[string]struct{}), nil
+		cached = val.(*activeSet)
+		if layer <= cached.checked {
+			reportCacheQuery(activeSetCache, true)
+			return cached.actives, nil
+		}
 	}
 
 	// build a map of all blocks on the current layer
 	mp, err := o.blocksProvider.ContextuallyValidBlock(sl)
 	if err != nil {
-		o.lock.Unlock()
 		return nil, err
 	}
 
@@ -282,22 +315,34 @@ func (o *Oracle) actives(layer types.LayerID) (map[string]struct{}, error) {
 		o.With().Error("Could not calculate hare active set size: no contextually valid blocks",
 			layer, layer.GetEpoch(),
 			log.FieldNamed("safe_layer_id", sl), log.FieldNamed("safe_epoch_id", safeEp))
-		o.lock.Unlock()
 		return nil, errNoContextualBlocks
 	}
 
+	blocksHash := calcBlocksHash(mp)
+	if cached != nil {
+		if cached.blocks == blocksHash {
+			cached.checked = layer
+			reportCacheQuery(activeSetCache, true)
+			return cached.actives, nil
+		}
+		// the tortoise changed its opinion on the safe layer
+		o.With().Warning("contextually valid blocks of safe layer changed, recomputing active set",
+			layer, log.FieldNamed("safe_layer_id", sl), log.FieldNamed("safe_epoch_id", safeEp))
+		metrics.OracleActiveSetInvalidations.Add(1)
+		o.purgeLayers()
+	}
+	reportCacheQuery(activeSetCache, false)
+
 	activeMap, err := o.getActiveSet(safeEp-1, mp)
 	if err != nil {
 		o.With().Error("Could not retrieve active set size", log.Err(err), layer, layer.GetEpoch(),
 			log.FieldNamed("safe_layer_id", sl), log.FieldNamed("safe_epoch_id", safeEp))
-		o.lock.Unlock()
 		return nil, err
 	}
 
 	// update
-	o.activesCache.Add(safeEp, activeMap)
+	o.activesCache.Add(safeEp, &activeSet{blocks: blocksHash, actives: activeMap, checked: layer})
 
-	o.lock.Unlock()
 	return activeMap, nil
 }
 
@@ -502,3 +502,106 @@ func TestOracle_Eligible2(t *testing.T) {
 	_, err := o.Eligible(100, 1, 1, types.NodeID{}, []byte{})
 	assert.Equal(t, errFoo, err)
 }
+
+func TestOracle_EligibleCache(t *testing.T) {
+	r := require.New(t)
+	o := New(&mockValueProvider{1, nil}, (&mockActiveSetProvider{10}).ActiveSet, nil, &mockSigner{[]byte{1, 2, 3}, nil}, 10, genActive, mockBlocksProvider{}, cfg, log.NewDefault(t.Name()))
+	verified := 0
+	o.vrfVerifier = func(msg, sig, pub []byte) (bool, error) {
+		verified++
+		return true, nil
+	}
+
+	res, err := o.Eligible(100, 1, 10, types.NodeID{}, []byte{1})
+	r.NoError(err)
+	r.True(res)
+	res, err = o.Eligible(100, 1, 10, types.NodeID{}, []byte{1})
+	r.NoError(err)
+	r.True(res)
+	r.Equal(1, verified)
+
+	// another committee size, round or proof is verified again
+	_, err = o.Eligible(100, 1, 0, types.NodeID{}, []byte{1})
+	r.NoError(err)
+	_, err = o.Eligible(100, 2, 10, types.NodeID{}, []byte{1})
+	r.NoError(err)
+	_, err = o.Eligible(100, 1, 10, types.NodeID{}, []byte{2})
+	r.NoError(err)
+	r.Equal(4, verified)
+
+	// errors aren't cached
+	o.getActiveSet = func(epoch types.EpochID, blocks map[types.BlockID]struct{}) (map[string]struct{}, error) {
+		return nil, errFoo
+	}
+	_, err = o.Eligible(200, 1, 10, types.NodeID{}, []byte{1})
+	r.Equal(errFoo, err)
+	o.getActiveSet = (&mockActiveSetProvider{10}).ActiveSet
+	res, err = o.Eligible(200, 1, 10, types.NodeID{}, []byte{1})
+	r.NoError(err)
+	r.True(res)
+}
+
+type countingSigner struct {
+	signed int
+}
+
+func (s *countingSigner) Sign(msg []byte) ([]byte, error) {
+	s.signed++
+	return msg, nil
+}
+
+func TestOracle_ProofCache(t *testing.T) {
+	r := require.New(t)
+	signer := &countingSigner{}
+	o := New(&mockValueProvider{1, nil}, (&mockActiveSetProvider{10}).ActiveSet, buildVerifier(true, nil), signer, 10, genActive, mockBlocksProvider{}, cfg, log.NewDefault(t.Name()))
+
+	p1, err := o.Proof(5, 1)
+	r.NoError(err)
+	p2, err := o.Proof(5, 1)
+	r.NoError(err)
+	r.Equal(p1, p2)
+	r.Equal(1, signer.signed)
+
+	_, err = o.Proof(5, 2)
+	r.NoError(err)
+	r.Equal(2, signer.signed)
+}
+
+func TestOracle_activesInvalidation(t *testing.T) {
+	r := require.New(t)
+	types.SetLayersPerEpoch(defLayersPerEpoch)
+	o := New(&mockValueProvider{1, nil}, nil, buildVerifier(true, nil), nil, defLayersPerEpoch, genActive, mockBlocksProvider{}, cfg, log.NewDefault(t.Name()))
+	block1 := types.NewExistingBlock(0, []byte("some data"))
+	block2 := types.NewExistingBlock(0, []byte("other data"))
+	blocks := map[types.BlockID]struct{}{block1.ID(): {}}
+	o.blocksProvider = mockBlocksProvider{mp: blocks}
+	computed := 0
+	o.getActiveSet = func(epoch types.EpochID, blocks map[types.BlockID]struct{}) (map[string]struct{}, error) {
+		computed++
+		return createMapWithSize(computed), nil
+	}
+
+	v, err := o.actives(100)
+	r.NoError(err)
+	r.Len(v, 1)
+	_, err = o.Eligible(100, 1, 10, types.NodeID{}, []byte{1})
+	r.NoError(err)
+
+	// the active set isn't recomputed while the valid blocks of the safe layer don't change
+	v, err = o.actives(101)
+	r.NoError(err)
+	r.Len(v, 1)
+	r.Equal(1, computed)
+
+	// the tortoise changed its opinion on the safe layer
+	blocks[block2.ID()] = struct{}{}
+	v, err = o.actives(100)
+	r.NoError(err)
+	r.Len(v, 1, "layers that were already checked use the cached active set")
+	v, err = o.actives(102)
+	r.NoError(err)
+	r.Len(v, 2)
+	r.Equal(2, computed)
+	_, ok := o.cachedEligibility(100, eligibilityKey{round: 1, committeeSize: 10, sig: string([]byte{1})})
+	r.False(ok)
+}
@@ -231,7 +231,7 @@ func createMaatuf(tcfg config.Config, rng *amcl.RAND, layersCh chan types.LayerI
 	// vrfSigner := BLS381.NewBlsSigner(vrfPriv)
 	nodeID := types.NodeID{Key: pub.String(), VRFPublicKey: vrfPub}
 	hare := New(tcfg, p2p, ed, nodeID, validateBlock, isSynced, &mockBlockProvider{}, rolacle, 10, &mockIdentityP{nid: nodeID},
-		&MockStateQuerier{true, nil}, layersCh, log.NewDefault(name+"_"+ed.PublicKey().ShortString()))
+		&MockStateQuerier{true, nil}, nil, layersCh, log.NewDefault(name+"_"+ed.PublicKey().ShortString()))
 
 	return hare
 }
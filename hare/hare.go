@@ -2,9 +2,12 @@ package hare
 
 import (
 	"errors"
+	"fmt"
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/hare/config"
+	"github.com/spacemeshos/go-spacemesh/hare/metrics"
 	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/priorityq"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -32,6 +35,11 @@ type TerminationOutput interface {
 	Completed() bool
 }
 
+// certifiedOutput is an output of a consensus process that carries the certificate of its set
+type certifiedOutput interface {
+	Certificate() *certificate
+}
+
 type layers interface {
 	LayerBlockIds(layerID types.LayerID) ([]types.BlockID, error)
 	HandleValidatedLayer(validatedLayer types.LayerID, layer []types.BlockID)
@@ -73,13 +81,44 @@ type Hare struct {
 
 	nid types.NodeID
 
+	malfeasance malfeasanceStore
+
+	certificates   certificateStore // nil if certificates aren't stored
+	stateQuerier   StateQuerier
+	eligibility    *eligibilityValidator
+	layersPerEpoch uint16
+
 	totalCPs int32
 }
 
+// roundsPerLayer is the number of rounds that must fit in a layer: the pre-round and the first iteration's status,
+// proposal, commit and notify rounds, so that the hare of a layer can complete before the next one begins.
+const roundsPerLayer = 5
+
+// ValidateConfig checks that the hare rounds, started the wakeup delta after the layer tick, fit in a layer.
+func ValidateConfig(conf config.Config, layerDuration time.Duration) error {
+	if conf.RoundDuration < 1 {
+		return fmt.Errorf("hare round duration (%ds) must be at least 1s", conf.RoundDuration)
+	}
+	if conf.WakeupDelta < 0 {
+		return fmt.Errorf("hare wakeup delta (%ds) must not be negative", conf.WakeupDelta)
+	}
+	if conf.LimitIterations < 1 {
+		return fmt.Errorf("hare iterations limit (%d) must be at least 1", conf.LimitIterations)
+	}
+	wakeup := time.Duration(conf.WakeupDelta) * time.Second
+	rounds := roundsPerLayer * time.Duration(conf.RoundDuration) * time.Second
+	if wakeup+rounds > layerDuration {
+		return fmt.Errorf("hare wakeup delta (%v) and %d rounds of %ds (%v) don't fit in a layer of %v",
+			wakeup, roundsPerLayer, conf.RoundDuration, rounds, layerDuration)
+	}
+	return nil
+}
+
 // New returns a new Hare struct.
 func New(conf config.Config, p2p NetworkService, sign Signer, nid types.NodeID, validate outputValidationFunc,
 	syncState syncStateFunc, obp layers, rolacle Rolacle,
-	layersPerEpoch uint16, idProvider identityProvider, stateQ StateQuerier,
+	layersPerEpoch uint16, idProvider identityProvider, stateQ StateQuerier, malfeasance malfeasanceStore,
 	beginLayer chan types.LayerID, logger log.Log) *Hare {
 	h := new(Hare)
 
@@ -93,6 +132,7 @@ func New(conf config.Config, p2p NetworkService, sign Signer, nid types.NodeID,
 	h.beginLayer = beginLayer
 
 	ev := newEligibilityValidator(rolacle, layersPerEpoch, idProvider, conf.N, conf.ExpectedLeaders, logger)
+	ev.malfeasance = malfeasance
 	h.broker = newBroker(p2p, ev, stateQ, syncState, layersPerEpoch, conf.LimitConcurrent, h.Closer, logger)
 
 	h.sign = sign
@@ -110,13 +150,21 @@ func New(conf config.Config, p2p NetworkService, sign Signer, nid types.NodeID,
 	h.outputs = make(map[types.LayerID][]types.BlockID, h.bufferSize) //  we keep results about LayerBuffer past layers
 
 	h.factory = func(conf config.Config, instanceId instanceID, s *Set, oracle Rolacle, signing Signer, p2p NetworkService, terminationReport chan TerminationOutput) Consensus {
-		return newConsensusProcess(conf, instanceId, s, oracle, stateQ, layersPerEpoch, signing, nid, p2p, terminationReport, ev, logger)
+		cp := newConsensusProcess(conf, instanceId, s, oracle, stateQ, layersPerEpoch, signing, nid, p2p, terminationReport, ev, logger)
+		cp.onEquivocation = h.onEquivocation
+		return cp
 	}
 
 	h.validate = validate
 
 	h.nid = nid
 
+	h.malfeasance = malfeasance
+
+	h.stateQuerier = stateQ
+	h.eligibility = ev
+	h.layersPerEpoch = layersPerEpoch
+
 	return h
 }
 
@@ -174,6 +222,9 @@ func (h *Hare) collectOutput(output TerminationOutput) error {
 	id := output.ID()
 
 	h.msh.HandleValidatedLayer(types.LayerID(id), blocks)
+	if certified, ok := output.(certifiedOutput); ok {
+		h.certify(types.LayerID(id), certified.Certificate())
+	}
 
 	if h.outOfBufferRange(id) {
 		return ErrTooLate
@@ -251,9 +302,9 @@ func (h *Hare) onTick(id types.LayerID) {
 		h.broker.Unregister(cp.ID())
 		return
 	}
-	h.With().Info("number of consensus processes", log.Int32("count", atomic.AddInt32(&h.totalCPs, 1)))
-	// TODO: fix metrics
-	//metrics.TotalConsensusProcesses.With("layer", strconv.FormatUint(uint64(id), 10)).Add(1)
+	count := atomic.AddInt32(&h.totalCPs, 1)
+	h.With().Info("number of consensus processes", log.Int32("count", count))
+	metrics.TotalConsensusProcesses.Set(float64(count))
 }
 
 var (
@@ -294,9 +345,9 @@ func (h *Hare) outputCollectionLoop() {
 
 			// anyway, unregister from broker
 			h.broker.Unregister(out.ID()) // unregister from broker after termination
-			h.With().Info("number of consensus processes", log.Int32("count", atomic.AddInt32(&h.totalCPs, -1)))
-			// TODO: fix metrics
-			//metrics.TotalConsensusProcesses.With("layer", strconv.FormatUint(uint64(out.ID()), 10)).Add(-1)
+			count := atomic.AddInt32(&h.totalCPs, -1)
+			h.With().Info("number of consensus processes", log.Int32("count", count))
+			metrics.TotalConsensusProcesses.Set(float64(count))
 		case <-h.CloseChannel():
 			return
 		}
@@ -325,6 +376,12 @@ func (h *Hare) Start() error {
 
 	go h.tickLoop()
 	go h.outputCollectionLoop()
+	if h.malfeasance != nil {
+		go h.malfeasanceLoop(h.network.RegisterGossipProtocol(malfeasanceProtoName, priorityq.Low))
+	}
+	if h.certificates != nil {
+		go h.certificateLoop(h.network.RegisterGossipProtocol(certificateProtoName, priorityq.Low))
+	}
 
 	return nil
 }
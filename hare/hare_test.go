@@ -80,7 +80,7 @@ func newMockConsensusProcess(cfg config.Config, instanceID instanceID, s *Set, o
 }
 
 func createHare(n1 p2p.Service, logger log.Log) *Hare {
-	return New(cfg, n1, signing2.NewEdSigner(), types.NodeID{}, validateBlocks, (&mockSyncer{true}).IsSynced, new(orphanMock), eligibility.New(), 10, &mockIDProvider{}, NewMockStateQuerier(), make(chan types.LayerID), logger)
+	return New(cfg, n1, signing2.NewEdSigner(), types.NodeID{}, validateBlocks, (&mockSyncer{true}).IsSynced, new(orphanMock), eligibility.New(), 10, &mockIDProvider{}, NewMockStateQuerier(), nil, make(chan types.LayerID), logger)
 }
 
 var _ Consensus = (*mockConsensusProcess)(nil)
@@ -96,6 +96,25 @@ func TestNew(t *testing.T) {
 	}
 }
 
+func TestValidateConfig(t *testing.T) {
+	r := require.New(t)
+	conf := config.DefaultConfig()
+	r.NoError(ValidateConfig(conf, 30*time.Second))
+	// the wakeup delta and 5 rounds of 2s take exactly 20s
+	r.NoError(ValidateConfig(conf, 20*time.Second))
+	r.EqualError(ValidateConfig(conf, 19*time.Second),
+		"hare wakeup delta (10s) and 5 rounds of 2s (10s) don't fit in a layer of 19s")
+
+	conf.RoundDuration = 0
+	r.EqualError(ValidateConfig(conf, 30*time.Second), "hare round duration (0s) must be at least 1s")
+	conf = config.DefaultConfig()
+	conf.WakeupDelta = -1
+	r.Error(ValidateConfig(conf, 30*time.Second))
+	conf = config.DefaultConfig()
+	conf.LimitIterations = 0
+	r.Error(ValidateConfig(conf, 30*time.Second))
+}
+
 func TestHare_Start(t *testing.T) {
 	sim := service.NewSimulator()
 	n1 := sim.NewNode()
@@ -281,7 +300,7 @@ func TestHare_onTick(t *testing.T) {
 		return blockset
 	}
 
-	h := New(cfg, n1, signing, types.NodeID{}, validateBlocks, (&mockSyncer{true}).IsSynced, om, oracle, 10, &mockIDProvider{}, NewMockStateQuerier(), layerTicker, log.NewDefault("Hare"))
+	h := New(cfg, n1, signing, types.NodeID{}, validateBlocks, (&mockSyncer{true}).IsSynced, om, oracle, 10, &mockIDProvider{}, NewMockStateQuerier(), nil, layerTicker, log.NewDefault("Hare"))
 	h.networkDelta = 0
 	h.bufferSize = 1
 
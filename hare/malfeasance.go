@@ -0,0 +1,159 @@
+package hare
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/spacemeshos/ed25519"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/p2p/service"
+	"github.com/spacemeshos/go-spacemesh/signing"
+)
+
+// An honest participant sends a single message in every round. Two different messages signed by the same identity in
+// the same round of a layer prove that it equivocated: the proof is stored, gossiped, and the equivocator is no
+// longer eligible to participate in the hare.
+
+const malfeasanceProtoName = "HARE_MALFEASANCE"
+
+// malfeasanceStore stores the malfeasance proofs of smeshers
+type malfeasanceStore interface {
+	GetMalfeasanceProof(nodeID types.NodeID) (*types.MalfeasanceProof, error)
+	AddMalfeasanceProof(proof *types.MalfeasanceProof) (bool, error)
+}
+
+// equivocationTracker tracks the messages of every sender in every round of a consensus process
+type equivocationTracker struct {
+	msgs map[int32]map[string]*Msg // round counter -> sender -> message
+}
+
+func newEquivocationTracker() *equivocationTracker {
+	return &equivocationTracker{msgs: make(map[int32]map[string]*Msg)}
+}
+
+// Track tracks the message and returns the message its sender sent earlier in the same round, if it's different
+func (et *equivocationTracker) Track(m *Msg) *Msg {
+	round, ok := et.msgs[m.InnerMsg.K]
+	if !ok {
+		round = make(map[string]*Msg)
+		et.msgs[m.InnerMsg.K] = round
+	}
+	sender := m.PubKey.String()
+	prev, ok := round[sender]
+	if !ok {
+		round[sender] = m
+		return nil
+	}
+	if bytes.Equal(prev.InnerMsg.Bytes(), m.InnerMsg.Bytes()) {
+		return nil
+	}
+	return prev
+}
+
+// newEquivocationProof builds the malfeasance proof of the sender of two conflicting messages
+func newEquivocationProof(first, second *Msg) *types.MalfeasanceProof {
+	return &types.MalfeasanceProof{
+		NodeID:   types.NodeID{Key: first.PubKey.String()},
+		Layer:    types.LayerID(first.InnerMsg.InstanceID),
+		Type:     types.HareEquivocation,
+		Messages: [][]byte{first.Bytes(), second.Bytes()},
+	}
+}
+
+// validateEquivocationProof checks that the proof holds two different messages signed by the smesher in the same
+// round of the layer
+func validateEquivocationProof(proof *types.MalfeasanceProof) error {
+	if proof.Type != types.HareEquivocation {
+		return fmt.Errorf("not a hare equivocation proof: %v", proof.Type)
+	}
+	if len(proof.Messages) != 2 {
+		return fmt.Errorf("equivocation proof must hold 2 messages, got %d", len(proof.Messages))
+	}
+	var inner [2]*innerMessage
+	for i, buf := range proof.Messages {
+		m, err := MessageFromBuffer(buf)
+		if err != nil {
+			return err
+		}
+		if m.InnerMsg == nil {
+			return errNilInner
+		}
+		pub, err := ed25519.ExtractPublicKey(m.InnerMsg.Bytes(), m.Sig)
+		if err != nil {
+			return fmt.Errorf("invalid signature: %v", err)
+		}
+		if signing.NewPublicKey(pub).String() != proof.NodeID.Key {
+			return errors.New("message wasn't signed by the smesher")
+		}
+		if types.LayerID(m.InnerMsg.InstanceID) != proof.Layer {
+			return errors.New("message isn't of the layer of the proof")
+		}
+		inner[i] = m.InnerMsg
+	}
+	if inner[0].K != inner[1].K {
+		return errors.New("messages are of different rounds")
+	}
+	if bytes.Equal(inner[0].Bytes(), inner[1].Bytes()) {
+		return errors.New("messages don't conflict")
+	}
+	return nil
+}
+
+// onEquivocation stores and gossips the proof of an equivocation detected by a consensus process
+func (h *Hare) onEquivocation(proof *types.MalfeasanceProof) {
+	h.With().Warning("smesher sent conflicting hare messages", proof.Fields()...)
+	if h.malfeasance == nil {
+		return
+	}
+	added, err := h.malfeasance.AddMalfeasanceProof(proof)
+	if err != nil {
+		h.With().Error("could not store malfeasance proof", append(proof.Fields(), log.Err(err))...)
+		return
+	}
+	if !added {
+		return
+	}
+	buf, err := types.InterfaceToBytes(proof)
+	if err != nil {
+		h.With().Error("could not serialize malfeasance proof", log.Err(err))
+		return
+	}
+	if err := h.network.Broadcast(malfeasanceProtoName, buf); err != nil {
+		h.With().Error("could not gossip malfeasance proof", log.Err(err))
+	}
+}
+
+// listens to the equivocation proofs gossiped by peers
+func (h *Hare) malfeasanceLoop(inbox chan service.GossipMessage) {
+	for {
+		select {
+		case msg := <-inbox:
+			if msg == nil {
+				h.Error("malfeasance inbox closed")
+				return
+			}
+			h.handleMalfeasanceMsg(msg)
+		case <-h.CloseChannel():
+			return
+		}
+	}
+}
+
+func (h *Hare) handleMalfeasanceMsg(msg service.GossipMessage) {
+	proof := &types.MalfeasanceProof{}
+	if err := types.BytesToInterface(msg.Bytes(), proof); err != nil {
+		h.With().Warning("could not decode gossiped malfeasance proof", log.Err(err))
+		return
+	}
+	if err := validateEquivocationProof(proof); err != nil {
+		h.With().Warning("invalid gossiped malfeasance proof", append(proof.Fields(), log.Err(err))...)
+		return
+	}
+	if _, err := h.malfeasance.AddMalfeasanceProof(proof); err != nil {
+		h.With().Error("could not store gossiped malfeasance proof", append(proof.Fields(), log.Err(err))...)
+		return
+	}
+	msg.ReportValidation(malfeasanceProtoName)
+}
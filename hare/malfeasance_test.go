@@ -0,0 +1,109 @@
+package hare
+
+import (
+	"testing"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/database"
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/stretchr/testify/require"
+)
+
+type mockMalfeasanceStore struct {
+	proofs map[string]*types.MalfeasanceProof
+}
+
+func newMockMalfeasanceStore() *mockMalfeasanceStore {
+	return &mockMalfeasanceStore{proofs: make(map[string]*types.MalfeasanceProof)}
+}
+
+func (ms *mockMalfeasanceStore) GetMalfeasanceProof(nodeID types.NodeID) (*types.MalfeasanceProof, error) {
+	if proof, ok := ms.proofs[nodeID.Key]; ok {
+		return proof, nil
+	}
+	return nil, database.ErrNotFound
+}
+
+func (ms *mockMalfeasanceStore) AddMalfeasanceProof(proof *types.MalfeasanceProof) (bool, error) {
+	if _, ok := ms.proofs[proof.NodeID.Key]; ok {
+		return false, nil
+	}
+	ms.proofs[proof.NodeID.Key] = proof
+	return true, nil
+}
+
+func TestEquivocationTracker_Track(t *testing.T) {
+	r := require.New(t)
+	tracker := newEquivocationTracker()
+	signer := generateSigning(t)
+	m1 := BuildCommitMsg(signer, NewSetFromValues(value1))
+	m2 := BuildCommitMsg(signer, NewSetFromValues(value2))
+
+	r.Nil(tracker.Track(m1))
+	// the same message may be received more than once
+	r.Nil(tracker.Track(m1))
+	// messages of other rounds and other senders aren't conflicting
+	r.Nil(tracker.Track(BuildNotifyMsg(signer, NewSetFromValues(value2))))
+	r.Nil(tracker.Track(BuildCommitMsg(generateSigning(t), NewSetFromValues(value2))))
+
+	r.Equal(m1, tracker.Track(m2))
+}
+
+func TestValidateEquivocationProof(t *testing.T) {
+	r := require.New(t)
+	signer := generateSigning(t)
+	m1 := BuildCommitMsg(signer, NewSetFromValues(value1))
+	m2 := BuildCommitMsg(signer, NewSetFromValues(value2))
+
+	proof := newEquivocationProof(m1, m2)
+	r.Equal(signer.PublicKey().String(), proof.NodeID.Key)
+	r.Equal(types.LayerID(instanceID1), proof.Layer)
+	r.NoError(validateEquivocationProof(proof))
+
+	// the same message twice
+	proof = newEquivocationProof(m1, m1)
+	r.Error(validateEquivocationProof(proof))
+
+	// messages of different rounds
+	proof = newEquivocationProof(m1, BuildNotifyMsg(signer, NewSetFromValues(value2)))
+	r.Error(validateEquivocationProof(proof))
+
+	// messages of different senders
+	proof = newEquivocationProof(m1, BuildCommitMsg(generateSigning(t), NewSetFromValues(value2)))
+	r.Error(validateEquivocationProof(proof))
+
+	// a different layer
+	proof = newEquivocationProof(m1, m2)
+	proof.Layer++
+	r.Error(validateEquivocationProof(proof))
+
+	proof = newEquivocationProof(m1, m2)
+	proof.Messages = proof.Messages[:1]
+	r.Error(validateEquivocationProof(proof))
+
+	proof = newEquivocationProof(m1, m2)
+	proof.Type = types.MultipleAtxs
+	r.Error(validateEquivocationProof(proof))
+}
+
+func TestEligibilityValidator_validateRole_Malfeasance(t *testing.T) {
+	r := require.New(t)
+	types.SetLayersPerEpoch(4)
+	oracle := &mockRolacle{isEligible: true}
+	ev := newEligibilityValidator(oracle, 10, &mockIDProvider{}, 1, 5, log.NewDefault(""))
+	store := newMockMalfeasanceStore()
+	ev.malfeasance = store
+
+	signer := generateSigning(t)
+	m := BuildCommitMsg(signer, NewSetFromValues(value1))
+	m.InnerMsg.InstanceID = 111 // not in genesis
+	res, err := ev.validateRole(m)
+	r.NoError(err)
+	r.True(res)
+
+	_, err = store.AddMalfeasanceProof(newEquivocationProof(m, BuildCommitMsg(signer, NewSetFromValues(value2))))
+	r.NoError(err)
+	res, err = ev.validateRole(m)
+	r.NoError(err)
+	r.False(res)
+}
@@ -21,13 +21,14 @@ type eligibilityValidator struct {
 	oracle           Rolacle
 	layersPerEpoch   uint16
 	identityProvider identityProvider
-	maxExpActives    int // the maximal expected committee size
-	expLeaders       int // the expected number of leaders
+	maxExpActives    int              // the maximal expected committee size
+	expLeaders       int              // the expected number of leaders
+	malfeasance      malfeasanceStore // identities with a malfeasance proof aren't eligible, may be nil
 	log.Log
 }
 
 func newEligibilityValidator(oracle Rolacle, layersPerEpoch uint16, idProvider identityProvider, maxExpActives, expLeaders int, logger log.Log) *eligibilityValidator {
-	return &eligibilityValidator{oracle, layersPerEpoch, idProvider, maxExpActives, expLeaders, logger}
+	return &eligibilityValidator{oracle, layersPerEpoch, idProvider, maxExpActives, expLeaders, nil, logger}
 }
 
 // check eligibility of the provided message by the oracle.
@@ -54,6 +55,14 @@ func (ev *eligibilityValidator) validateRole(m *Msg) (bool, error) {
 		return false, err
 	}
 
+	// equivocators are excluded from all committees
+	if ev.malfeasance != nil {
+		if _, err := ev.malfeasance.GetMalfeasanceProof(nID); err == nil {
+			ev.With().Warning("Eligibility validator: sender has a malfeasance proof", log.String("sender_id", pub.ShortString()))
+			return false, nil
+		}
+	}
+
 	// validate role
 	res, err := ev.oracle.Eligible(layer, m.InnerMsg.K, expectedCommitteeSize(m.InnerMsg.K, ev.maxExpActives, ev.expLeaders), nID, m.InnerMsg.RoleProof)
 	if err != nil {
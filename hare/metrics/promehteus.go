@@ -10,6 +10,13 @@ const (
 	Namespace = "spacemesh"
 	// Subsystem is a subsystem shared by all metrics exposed by this package.
 	Subsystem = "hare"
+
+	// RoundLabel is the label of the round a metric was measured in, the type of the messages of the round
+	RoundLabel = "round"
+	// ResultLabel is the label of the outcome of a consensus process or a certificate
+	ResultLabel = "result"
+	// CacheLabel is the label of the eligibility oracle cache that was queried
+	CacheLabel = "cache"
 )
 
 var (
@@ -19,7 +26,7 @@ var (
 		Subsystem: Subsystem,
 		Name:      "message_type_counter",
 		Help:      "Number of valid messages sent to processing for each type",
-	}, []string{"type_id", "layer", "reporter"})
+	}, []string{"type_id", "reporter"})
 
 	// TotalConsensusProcesses is the total number of current consensus processes.
 	TotalConsensusProcesses = prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
@@ -27,5 +34,73 @@ var (
 		Subsystem: Subsystem,
 		Name:      "total_consensus_processes",
 		Help:      "The total number of current consensus processes running",
-	}, []string{"layer"})
+	}, nil)
+
+	// RoundCommitteeSize is the number of distinct participants whose messages of the round's type were processed.
+	RoundCommitteeSize = prometheus.NewHistogramFrom(stdprometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: Subsystem,
+		Name:      "round_committee_size",
+		Help:      "Number of distinct participants that sent a valid message of the round's type",
+		Buckets:   stdprometheus.ExponentialBuckets(1, 2, 12),
+	}, []string{RoundLabel})
+
+	// RoundDuration is the time in seconds from the beginning to the end of a round.
+	RoundDuration = prometheus.NewHistogramFrom(stdprometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: Subsystem,
+		Name:      "round_duration_seconds",
+		Help:      "Duration of a round, from its beginning until its end was handled",
+		Buckets:   stdprometheus.ExponentialBuckets(0.5, 2, 8),
+	}, []string{RoundLabel})
+
+	// Certificates is the number of notify rounds by whether a certificate could be built for the proposed set.
+	Certificates = prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: Subsystem,
+		Name:      "certificates",
+		Help:      "Number of notify rounds by whether a certificate was built or why it wasn't",
+	}, []string{ResultLabel})
+
+	// ConsensusResults is the number of terminated consensus processes by their outcome.
+	ConsensusResults = prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: Subsystem,
+		Name:      "consensus_results",
+		Help:      "Number of terminated consensus processes by whether they reached agreement",
+	}, []string{ResultLabel})
+
+	// ConsensusDuration is the time in seconds from the start to the termination of a consensus process.
+	ConsensusDuration = prometheus.NewHistogramFrom(stdprometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: Subsystem,
+		Name:      "consensus_duration_seconds",
+		Help:      "Duration of a consensus process, from its start until it terminated",
+		Buckets:   stdprometheus.ExponentialBuckets(1, 2, 10),
+	}, []string{ResultLabel})
+
+	// OracleCacheQueries is the number of queries to the caches of the eligibility oracle, by whether they were hits.
+	OracleCacheQueries = prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: Subsystem,
+		Name:      "oracle_cache_queries",
+		Help:      "Number of queries to the eligibility oracle caches by cache and result (hit or miss)",
+	}, []string{CacheLabel, ResultLabel})
+
+	// OracleActiveSetInvalidations is the number of cached active sets that were recomputed since the contextually
+	// valid blocks they were computed from changed.
+	OracleActiveSetInvalidations = prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: Subsystem,
+		Name:      "oracle_active_set_invalidations",
+		Help:      "Number of cached active sets invalidated since the valid blocks of their safe layer changed",
+	}, nil)
+
+	// PendingMessagesDropped is the number of early messages dropped since no consensus process ran for their layer.
+	PendingMessagesDropped = prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: Subsystem,
+		Name:      "pending_messages_dropped",
+		Help:      "Number of buffered early messages dropped since their layer was skipped or its process didn't start",
+	}, nil)
 )
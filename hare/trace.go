@@ -0,0 +1,100 @@
+package hare
+
+import (
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/hare/metrics"
+	"github.com/spacemeshos/go-spacemesh/log"
+)
+
+// Outcomes of a consensus process, as reported to the metrics and the trace log
+const (
+	resultCompleted       = "completed"
+	resultIterationsLimit = "iterations_limit"
+	resultCancelled       = "cancelled"
+)
+
+// roundTrace collects what a consensus process observed during a single round. It's logged, keyed by the layer, and
+// reported to the metrics when the round ends, so that layers that missed agreement can be analyzed after the fact.
+type roundTrace struct {
+	k        int32
+	start    time.Time
+	received map[messageType]int
+	senders  map[string]struct{} // senders of messages of the round's type
+}
+
+func newRoundTrace(k int32) *roundTrace {
+	return &roundTrace{
+		k:        k,
+		start:    time.Now(),
+		received: make(map[messageType]int),
+		senders:  make(map[string]struct{}),
+	}
+}
+
+// roundType returns the type of the messages sent in the round with the given counter
+func roundType(k int32) messageType {
+	if k < 0 {
+		return pre
+	}
+	return messageType(k % 4)
+}
+
+// onMessage records a valid message that was processed during the round
+func (rt *roundTrace) onMessage(m *Msg) {
+	if rt == nil {
+		return
+	}
+	rt.received[m.InnerMsg.Type]++
+	if m.InnerMsg.Type == roundType(rt.k) {
+		rt.senders[m.PubKey.String()] = struct{}{}
+	}
+}
+
+// CommitteeSize returns the number of distinct participants that sent a message of the round's type
+func (rt *roundTrace) CommitteeSize() int {
+	return len(rt.senders)
+}
+
+// endRoundTrace logs and reports the trace of the current round, if there is one
+func (proc *consensusProcess) endRoundTrace() {
+	rt := proc.trace
+	if rt == nil {
+		return
+	}
+	proc.trace = nil
+	round := roundType(rt.k).String()
+	duration := time.Since(rt.start)
+	metrics.RoundDuration.With(metrics.RoundLabel, round).Observe(duration.Seconds())
+	metrics.RoundCommitteeSize.With(metrics.RoundLabel, round).Observe(float64(rt.CommitteeSize()))
+	proc.With().Info("hare round trace",
+		types.LayerID(proc.instanceID),
+		log.String("round", round),
+		log.Int32("round_counter", rt.k),
+		log.Int32("iteration", iterationFromCounter(rt.k)),
+		log.Int("committee_size", rt.CommitteeSize()),
+		log.Int("expected_committee_size", expectedCommitteeSize(rt.k, proc.cfg.N, proc.cfg.ExpectedLeaders)),
+		log.Int("pre_msgs", rt.received[pre]),
+		log.Int("status_msgs", rt.received[status]),
+		log.Int("proposal_msgs", rt.received[proposal]),
+		log.Int("commit_msgs", rt.received[commit]),
+		log.Int("notify_msgs", rt.received[notify]),
+		log.String("duration", duration.String()))
+}
+
+// traceTermination logs and reports the outcome of the consensus process once it terminates
+func (proc *consensusProcess) traceTermination(result string) {
+	proc.endRoundTrace()
+	duration := time.Since(proc.startTime)
+	metrics.ConsensusResults.With(metrics.ResultLabel, result).Add(1)
+	metrics.ConsensusDuration.With(metrics.ResultLabel, result).Observe(duration.Seconds())
+	proc.With().Info("hare consensus trace",
+		types.LayerID(proc.instanceID),
+		log.String("result", result),
+		log.Int32("round_counter", proc.k),
+		log.Int32("iterations", iterationFromCounter(proc.k)+1),
+		log.Int("set_size", proc.s.Size()),
+		log.Bool("has_certificate", proc.certificate != nil),
+		log.String("duration", duration.String()))
+}
@@ -0,0 +1,50 @@
+package hare
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRoundType(t *testing.T) {
+	assert.Equal(t, pre, roundType(preRound))
+	assert.Equal(t, status, roundType(4))
+	assert.Equal(t, proposal, roundType(5))
+	assert.Equal(t, commit, roundType(6))
+	assert.Equal(t, notify, roundType(7))
+}
+
+func TestRoundTrace_OnMessage(t *testing.T) {
+	s := NewSetFromValues(value1)
+	rt := newRoundTrace(commitRound)
+	signer := generateSigning(t)
+	rt.onMessage(BuildCommitMsg(signer, s))
+	rt.onMessage(BuildCommitMsg(signer, s))
+	rt.onMessage(BuildCommitMsg(generateSigning(t), s))
+	// late messages of other rounds are counted but aren't part of the committee of the round
+	rt.onMessage(BuildProposalMsg(generateSigning(t), s))
+
+	assert.Equal(t, 3, rt.received[commit])
+	assert.Equal(t, 1, rt.received[proposal])
+	assert.Equal(t, 2, rt.CommitteeSize())
+
+	// processes that don't trace their rounds ignore messages
+	var none *roundTrace
+	none.onMessage(BuildCommitMsg(signer, s))
+}
+
+func TestConsensusProcess_RoundTrace(t *testing.T) {
+	proc := generateConsensusProcess(t)
+	proc.advanceToNextRound()
+	proc.advanceToNextRound()
+	proc.statusesTracker = newStatusTracker(proc.cfg.F+1, proc.cfg.N)
+	proc.onRoundBegin()
+	assert.NotNil(t, proc.trace)
+	assert.Equal(t, proc.k, proc.trace.k)
+
+	proc.processMsg(BuildProposalMsg(generateSigning(t), NewSetFromValues(value1)))
+	assert.Equal(t, 1, proc.trace.received[proposal])
+
+	proc.onRoundEnd()
+	assert.Nil(t, proc.trace)
+	proc.traceTermination(resultCancelled)
+}
@@ -0,0 +1,83 @@
+package log
+
+import (
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Hook is called with every warning or error logged by a logger created with New, and with the fields of the entry
+type Hook func(entry zapcore.Entry, fields map[string]interface{})
+
+var hooks = struct {
+	sync.RWMutex
+	next  int
+	hooks map[int]Hook
+}{hooks: make(map[int]Hook)}
+
+// AddHook registers a hook that's called with every warning or error logged by the node, e.g. to report errors to an
+// external service. Hooks are called synchronously by the logging goroutine and must not block. The returned function
+// removes the hook.
+func AddHook(hook Hook) (remove func()) {
+	hooks.Lock()
+	defer hooks.Unlock()
+	id := hooks.next
+	hooks.next++
+	hooks.hooks[id] = hook
+	return func() {
+		hooks.Lock()
+		defer hooks.Unlock()
+		delete(hooks.hooks, id)
+	}
+}
+
+// hookCore is a zapcore.Core that passes warnings and errors to the registered hooks
+type hookCore struct {
+	fields []zapcore.Field
+}
+
+func (c hookCore) Enabled(level zapcore.Level) bool {
+	return level >= zapcore.WarnLevel
+}
+
+func (c hookCore) With(fields []zapcore.Field) zapcore.Core {
+	return hookCore{fields: append(c.fields[:len(c.fields):len(c.fields)], fields...)}
+}
+
+func (c hookCore) Check(e zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(e.Level) {
+		return ce
+	}
+	hooks.RLock()
+	n := len(hooks.hooks)
+	hooks.RUnlock()
+	if n == 0 {
+		return ce
+	}
+	return ce.AddCore(e, c)
+}
+
+func (c hookCore) Write(e zapcore.Entry, fields []zapcore.Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range c.fields {
+		f.AddTo(enc)
+	}
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	// hooks may log themselves, so they're called without holding the lock
+	hooks.RLock()
+	registered := make([]Hook, 0, len(hooks.hooks))
+	for _, hook := range hooks.hooks {
+		registered = append(registered, hook)
+	}
+	hooks.RUnlock()
+	for _, hook := range registered {
+		hook(e, enc.Fields)
+	}
+	return nil
+}
+
+func (c hookCore) Sync() error {
+	return nil
+}
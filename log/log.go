@@ -94,6 +94,7 @@ func New(module string, dataFolderPath string, logFileName string) Log {
 		fs := zapcore.AddSync(wr)
 		cores = append(cores, zapcore.NewCore(enc, fs, debugLevel))
 	}
+	cores = append(cores, hookCore{})
 
 	core := zapcore.NewTee(cores...)
 
@@ -77,6 +77,11 @@ func Int32(name string, val int32) Field {
 	return Field(zap.Int32(name, val))
 }
 
+// Int64 returns an int64 Field.
+func Int64(name string, val int64) Field {
+	return Field(zap.Int64(name, val))
+}
+
 // Uint32 returns an uint32 Field.
 func Uint32(name string, val uint32) Field {
 	return Field(zap.Uint32(name, val))
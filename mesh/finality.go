@@ -0,0 +1,107 @@
+package mesh
+
+import (
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/common/util"
+	"github.com/spacemeshos/go-spacemesh/database"
+	"github.com/spacemeshos/go-spacemesh/events"
+	"github.com/spacemeshos/go-spacemesh/log"
+)
+
+var constFINALIZED = []byte("finalized")
+
+// A layer is final when its valid blocks can no longer change. The tortoise may still revise its opinion on a layer it
+// verified, e.g. when late blocks arrive, as long as the layer is within the reorg distance of the last layer it
+// verified. A verified layer is therefore final once the reorg distance of layers were verified after it, even if the
+// tortoise agrees with the blocks the hare approved for it. Layers become final in order: the finalized layer is the
+// last layer such that it and all the layers below it are final. The tortoise refuses to revise the validity of the
+// blocks of final layers, and then stops revising validity until the node restarts.
+
+// SetReorgDistance sets the number of verified layers after which a verified layer becomes final, it's the reorg window
+// of the tortoise. 0 makes layers final as soon as they're verified.
+func (msh *Mesh) SetReorgDistance(distance types.LayerID) {
+	msh.finalityMutex.Lock()
+	defer msh.finalityMutex.Unlock()
+	msh.reorgDistance = distance
+}
+
+// FinalizedLayer returns the last layer such that it and all the layers below it are final
+func (m *DB) FinalizedLayer() types.LayerID {
+	m.finalityMutex.RLock()
+	defer m.finalityMutex.RUnlock()
+	return m.finalizedLayer
+}
+
+func (msh *Mesh) recoverFinalizedLayer() {
+	finalized, err := msh.general.Get(constFINALIZED)
+	if err != nil {
+		return
+	}
+	msh.finalizedLayer = types.LayerID(util.BytesToUint64(finalized))
+}
+
+// setFinalizedLayer makes the layer final without checking it, for layers whose blocks are trusted, e.g. a checkpoint
+func (msh *Mesh) setFinalizedLayer(layer types.LayerID) {
+	msh.finalityMutex.Lock()
+	defer msh.finalityMutex.Unlock()
+	if layer <= msh.finalizedLayer {
+		return
+	}
+	msh.finalizedLayer = layer
+	if err := msh.general.Put(constFINALIZED, layer.Bytes()); err != nil {
+		msh.With().Error("could not persist finalized layer", layer, log.Err(err))
+	}
+}
+
+// updateFinality finalizes the layers that became final now that the tortoise verified the layers below pbase
+func (msh *Mesh) updateFinality(pbase types.LayerID) {
+	msh.finalityMutex.Lock()
+	defer msh.finalityMutex.Unlock()
+	finalized := msh.finalizedLayer
+	for layer := finalized + 1; layer+msh.reorgDistance < pbase; layer++ {
+		finalized = layer
+		events.Publish(events.LayerFinalized{LayerID: layer, ByHare: msh.hareAgrees(layer)})
+	}
+	if finalized == msh.finalizedLayer {
+		return
+	}
+	msh.With().Info("layers finalized",
+		log.FieldNamed("from_layer", msh.finalizedLayer+1),
+		log.FieldNamed("to_layer", finalized),
+		log.FieldNamed("pbase", pbase))
+	msh.finalizedLayer = finalized
+	if err := msh.general.Put(constFINALIZED, finalized.Bytes()); err != nil {
+		msh.With().Error("could not persist finalized layer", finalized, log.Err(err))
+	}
+}
+
+// hareAgrees returns true if the valid blocks of the layer are the blocks the hare approved for it
+func (msh *Mesh) hareAgrees(layer types.LayerID) bool {
+	approved, err := msh.GetLayerInputVector(layer)
+	if err != nil {
+		return false
+	}
+	ids, err := msh.LayerBlockIds(layer)
+	if err != nil && err != database.ErrNotFound {
+		return false
+	}
+	valid := make(map[types.BlockID]struct{}, len(ids))
+	for _, id := range ids {
+		v, err := msh.ContextualValidity(id)
+		if err != nil {
+			return false
+		}
+		if v {
+			valid[id] = struct{}{}
+		}
+	}
+	if len(valid) != len(approved) {
+		return false
+	}
+	for _, id := range approved {
+		if _, ok := valid[id]; !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,84 @@
+package mesh
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/events"
+	"github.com/stretchr/testify/require"
+)
+
+func addBlockWithValidity(r *require.Assertions, msh *Mesh, layer types.LayerID, data []byte, valid bool) *types.Block {
+	blk := types.NewExistingBlock(layer, data)
+	r.NoError(msh.SaveContextualValidity(blk.ID(), valid))
+	r.NoError(msh.AddBlock(blk))
+	return blk
+}
+
+func TestMesh_Finality(t *testing.T) {
+	r := require.New(t)
+	msh := getMesh("finality")
+	msh.SetReorgDistance(3)
+	sub := events.Subscribe(events.EventLayerFinalized)
+	defer sub.Close()
+
+	gen := types.GetEffectiveGenesis()
+	r.Equal(gen, msh.FinalizedLayer())
+	for layer := gen + 1; layer <= gen+6; layer++ {
+		valid := addBlockWithValidity(r, msh, layer, []byte("valid"), true)
+		invalid := addBlockWithValidity(r, msh, layer, []byte("invalid"), false)
+		// the hare approved the blocks the tortoise considers valid, except in the second layer
+		approved := []types.BlockID{valid.ID()}
+		if layer == gen+2 {
+			approved = append(approved, invalid.ID())
+		}
+		r.NoError(msh.SaveLayerInputVector(layer, approved))
+	}
+
+	// verified layers aren't final until they're beyond the reorg distance, even if the tortoise agrees with the hare
+	msh.updateFinality(gen + 2)
+	r.Equal(gen, msh.FinalizedLayer())
+	msh.updateFinality(gen + 5)
+	r.Equal(gen+1, msh.FinalizedLayer())
+	msh.updateFinality(gen + 7)
+	r.Equal(gen+3, msh.FinalizedLayer())
+
+	expected := []events.LayerFinalized{
+		{LayerID: gen + 1, ByHare: true},
+		{LayerID: gen + 2, ByHare: false},
+		{LayerID: gen + 3, ByHare: true},
+	}
+	for _, ev := range expected {
+		select {
+		case got := <-sub.Events():
+			r.Equal(ev, got)
+		case <-time.After(time.Second):
+			r.FailNow("finalization event wasn't published", ev.LayerID)
+		}
+	}
+
+	// the finalized layer never goes back
+	msh.updateFinality(gen + 2)
+	r.Equal(gen+3, msh.FinalizedLayer())
+}
+
+func TestMesh_FinalityRecovery(t *testing.T) {
+	r := require.New(t)
+	msh := getMesh("finality_recovery")
+	gen := types.GetEffectiveGenesis()
+	for layer := gen + 1; layer <= gen+3; layer++ {
+		addBlockWithTxs(r, msh, layer, true)
+	}
+	// without hare results, verified layers are final immediately if the reorg distance is 0
+	msh.updateFinality(gen + 4)
+	r.Equal(gen+3, msh.FinalizedLayer())
+
+	recovered := NewMesh(msh.DB, NewAtxDbMock(), ConfigTst(), &MeshValidatorMock{mdb: msh.DB}, MockTxMemPool{}, &MockState{}, msh.Log)
+	recovered.recoverFinalizedLayer()
+	r.Equal(gen+3, recovered.FinalizedLayer())
+
+	// a checkpoint is final
+	recovered.setFinalizedLayer(gen + 10)
+	r.Equal(gen+10, recovered.FinalizedLayer())
+}
@@ -29,6 +29,9 @@ var constFalse = []byte{0}
 var constLATEST = []byte("latest")
 var constLAYERHASH = []byte("layer hash")
 var constPROCESSED = []byte("processed")
+var constCHECKPOINT = []byte("checkpoint")
+var constSYNCFRONTIER = []byte("sync frontier")
+var constPRUNED = []byte("pruned")
 
 // TORTOISE key for tortoise persistence in database
 var TORTOISE = []byte("tortoise")
@@ -60,6 +63,7 @@ type txProcessor interface {
 	GetStateRoot() types.Hash32
 	LoadState(layer types.LayerID) error
 	ValidateAndAddTxToPool(tx *types.Transaction) error
+	PruneLayerStateRoot(layer types.LayerID) error
 }
 
 type txMemPoolInValidator interface {
@@ -101,6 +105,20 @@ type Mesh struct {
 	nextValidLayers    map[types.LayerID]*types.Layer
 	maxValidatedLayer  types.LayerID
 	txMutex            sync.Mutex
+	checkpointLayer    types.LayerID
+	frontierMutex      sync.RWMutex
+	applyMutex         sync.Mutex // held while a layer is applied to the mesh and the state
+	syncFrontier       SyncFrontier
+	pruneWindow        types.LayerID // layers kept below the latest layer in state, 0 to keep all layers
+	reorgDistance      types.LayerID // verified layers after which a verified layer is final
+	retention          retention
+}
+
+// SyncFrontier is the progress of the sync of the mesh, persisted so a node that restarts in the middle of the sync
+// resumes from it: the last validated layer, and the layers that were being fetched, whose data may be partial
+type SyncFrontier struct {
+	Validated types.LayerID
+	InFlight  []types.LayerID
 }
 
 // NewMesh creates a new instant of a mesh
@@ -118,6 +136,7 @@ func NewMesh(db *DB, atxDb AtxDB, rewardConfig Config, mesh tortoise, txInvalida
 		latestLayer:        types.GetEffectiveGenesis(),
 		latestLayerInState: types.GetEffectiveGenesis(),
 	}
+	ll.finalizedLayer = types.GetEffectiveGenesis()
 
 	ll.Validator = &validator{ll, 0}
 
@@ -134,12 +153,12 @@ func NewRecoveredMesh(db *DB, atxDb AtxDB, rewardConfig Config, mesh tortoise, t
 	}
 	msh.latestLayer = types.LayerID(util.BytesToUint64(latest))
 
-	processed, err := db.general.Get(constPROCESSED)
+	processed, err := db.RecoverProcessedLayer()
 	if err != nil {
 		logger.Panic("could not recover processed layer: %v", err)
 	}
 
-	msh.SetProcessedLayer(types.LayerID(util.BytesToUint64(processed)))
+	msh.SetProcessedLayer(processed)
 
 	if msh.layerHash, err = db.general.Get(constLAYERHASH); err != nil {
 		logger.With().Error("could not recover latest layer hash", log.Err(err))
@@ -151,6 +170,18 @@ func NewRecoveredMesh(db *DB, atxDb AtxDB, rewardConfig Config, mesh tortoise, t
 	}
 	msh.latestLayerInState = types.LayerID(util.BytesToUint64(verified))
 
+	if checkpoint, err := db.general.Get(constCHECKPOINT); err == nil {
+		msh.checkpointLayer = types.LayerID(util.BytesToUint64(checkpoint))
+	}
+
+	msh.recoverFinalizedLayer()
+
+	if frontier, err := db.general.Get(constSYNCFRONTIER); err == nil {
+		if err := types.BytesToInterface(frontier, &msh.syncFrontier); err != nil {
+			logger.With().Error("could not recover sync frontier", log.Err(err))
+		}
+	}
+
 	err = pr.LoadState(msh.LatestLayerInState())
 	if err != nil {
 		logger.Panic("cannot load state for layer %v, message: %v", msh.LatestLayerInState(), err)
@@ -165,6 +196,8 @@ func NewRecoveredMesh(db *DB, atxDb AtxDB, rewardConfig Config, mesh tortoise, t
 	msh.With().Info("recovered mesh from disc",
 		log.FieldNamed("latest_layer", msh.latestLayer),
 		log.FieldNamed("validated_layer", msh.ProcessedLayer()),
+		log.FieldNamed("finalized_layer", msh.finalizedLayer),
+		log.Int("in_flight_layers", len(msh.syncFrontier.InFlight)),
 		log.String("layer_hash", util.Bytes2Hex(msh.layerHash)),
 		log.String("root_hash", pr.GetStateRoot().String()))
 
@@ -209,6 +242,7 @@ func (msh *Mesh) SetLatestLayer(idx types.LayerID) {
 		if err := msh.general.Put(constLATEST, idx.Bytes()); err != nil {
 			msh.Error("could not persist Latest layer index")
 		}
+		events.Publish(events.LayerUpdate{LayerID: idx, Status: events.LayerStatusCreated})
 	}
 }
 
@@ -225,6 +259,12 @@ func (msh *Mesh) GetLayer(i types.LayerID) (*types.Layer, error) {
 	return l, nil
 }
 
+// ApplyLock returns the lock held while a layer is applied to the mesh and the state, for the jobs that must see either
+// none or all of the writes of a layer
+func (msh *Mesh) ApplyLock() sync.Locker {
+	return &msh.applyMutex
+}
+
 type validator struct {
 	*Mesh
 	processedLayer types.LayerID
@@ -245,11 +285,14 @@ func (vl *validator) SetProcessedLayer(lyr types.LayerID) {
 
 func (vl *validator) HandleLateBlock(b *types.Block) {
 	vl.Info("Validate late block %s", b.ID())
+	vl.applyMutex.Lock()
+	defer vl.applyMutex.Unlock()
 	oldPbase, newPbase := vl.trtl.HandleLateBlock(b)
 	if err := vl.trtl.Persist(); err != nil {
 		vl.Error("could not persist Tortoise on late block %s from layer index %d", b.ID(), b.Layer())
 	}
 	vl.pushLayersToState(oldPbase, newPbase)
+	vl.updateFinality(newPbase)
 }
 
 func (vl *validator) ValidateLayer(lyr *types.Layer) {
@@ -260,16 +303,19 @@ func (vl *validator) ValidateLayer(lyr *types.Layer) {
 		return
 	}
 
+	vl.applyMutex.Lock()
+	defer vl.applyMutex.Unlock()
 	oldPbase, newPbase := vl.trtl.HandleIncomingLayer(lyr)
 	vl.SetProcessedLayer(lyr.Index())
 
 	if err := vl.trtl.Persist(); err != nil {
 		vl.Error("could not persist tortoise layer index %d", lyr.Index())
 	}
-	if err := vl.general.Put(constPROCESSED, lyr.Index().Bytes()); err != nil {
+	if err := vl.PersistProcessedLayer(lyr.Index()); err != nil {
 		vl.Error("could not persist validated layer index %d", lyr.Index())
 	}
 	vl.pushLayersToState(oldPbase, newPbase)
+	vl.updateFinality(newPbase)
 	vl.Info("done validating layer %v", lyr.Index())
 }
 
@@ -311,6 +357,13 @@ func (msh *Mesh) applyState(l *types.Layer) {
 	msh.accumulateRewards(l, msh.config)
 	msh.pushTransactions(l)
 	msh.setLatestLayerInState(l.Index())
+	msh.prune(l.Index())
+	events.Publish(events.LayerUpdate{
+		LayerID:   l.Index(),
+		Status:    events.LayerStatusConfirmed,
+		Blocks:    types.BlockIDs(l.Blocks()),
+		StateRoot: msh.txProcessor.GetStateRoot(),
+	})
 }
 
 // HandleValidatedLayer handles layer valid blocks as decided by hare
@@ -326,7 +379,11 @@ func (msh *Mesh) HandleValidatedLayer(validatedLayer types.LayerID, layer []type
 		}
 		blocks = append(blocks, block)
 	}
+	if err := msh.SaveLayerInputVector(validatedLayer, layer); err != nil {
+		msh.With().Error("failed to save hare output", validatedLayer, log.Err(err))
+	}
 	lyr := types.NewExistingLayer(validatedLayer, blocks)
+	events.Publish(events.LayerUpdate{LayerID: validatedLayer, Status: events.LayerStatusApproved, Blocks: layer})
 	invalidBlocks := msh.getInvalidBlocksByHare(lyr)
 	msh.updateStateWithLayer(validatedLayer, lyr)
 	msh.reInsertTxsToPool(blocks, invalidBlocks, lyr.Index())
@@ -378,6 +435,61 @@ func (msh *Mesh) updateStateWithLayer(validatedLayer types.LayerID, layer *types
 	}
 }
 
+// RestoreCheckpointLayer sets the given layer as the latest, processed and applied layer of a mesh whose state was
+// restored from a checkpoint, so that syncing continues from the layer that follows it
+func (msh *Mesh) RestoreCheckpointLayer(layer types.LayerID) {
+	msh.SetLatestLayer(layer)
+	msh.SetProcessedLayer(layer)
+	if err := msh.PersistProcessedLayer(layer); err != nil {
+		msh.Error("could not persist validated layer index %d", layer)
+	}
+	msh.setLatestLayerInState(layer)
+	msh.checkpointLayer = layer
+	msh.setFinalizedLayer(layer)
+	if err := msh.general.Put(constCHECKPOINT, layer.Bytes()); err != nil {
+		msh.Error("could not persist checkpoint layer %d", layer)
+	}
+}
+
+// CheckpointLayer returns the layer of the checkpoint the mesh was restored from, or 0 if it was synced from genesis
+func (msh *Mesh) CheckpointLayer() types.LayerID {
+	return msh.checkpointLayer
+}
+
+// SetSyncFrontier persists the progress of the sync
+func (msh *Mesh) SetSyncFrontier(frontier SyncFrontier) error {
+	b, err := types.InterfaceToBytes(&frontier)
+	if err != nil {
+		return err
+	}
+	msh.frontierMutex.Lock()
+	defer msh.frontierMutex.Unlock()
+	if err := msh.general.Put(constSYNCFRONTIER, b); err != nil {
+		return err
+	}
+	msh.syncFrontier = frontier
+	return nil
+}
+
+// SyncFrontier returns the last persisted progress of the sync
+func (msh *Mesh) SyncFrontier() SyncFrontier {
+	msh.frontierMutex.RLock()
+	defer msh.frontierMutex.RUnlock()
+	return msh.syncFrontier
+}
+
+// AddCheckpointedBlock stores a block of a layer covered by the checkpoint the mesh was restored from. The block isn't
+// validated or applied, it's only kept so that the votes of the blocks that follow the checkpoint can be validated.
+func (msh *Mesh) AddCheckpointedBlock(blk *types.Block) error {
+	if blk.Layer() > msh.checkpointLayer {
+		return fmt.Errorf("block %v of layer %v isn't covered by the checkpoint of layer %v", blk.ID(), blk.Layer(), msh.checkpointLayer)
+	}
+	if err := msh.DB.AddBlock(blk); err != nil && err != ErrAlreadyExist {
+		return err
+	}
+	return nil
+}
+
 func (msh *Mesh) setLatestLayerInState(lyr types.LayerID) {
 	// update validated layer only after applying transactions since loading of state depends on processedLayer param.
 	msh.pMutex.Lock()
@@ -541,6 +653,23 @@ func (msh *Mesh) SetZeroBlockLayer(lyr types.LayerID) error {
 func (msh *Mesh) AddBlockWithTxs(blk *types.Block, txs []*types.Transaction, atxs []*types.ActivationTx) error {
 	msh.With().Debug("adding block", blk.Fields()...)
 
+	// transactions already added with another block were reported to be in the mesh with it
+	newTxs := txs
+	if len(txs) > 0 {
+		ids := make([]types.TransactionID, 0, len(txs))
+		for _, tx := range txs {
+			ids = append(ids, tx.ID())
+		}
+		if _, missing := msh.GetTransactions(ids); len(missing) < len(txs) {
+			newTxs = make([]*types.Transaction, 0, len(missing))
+			for _, tx := range txs {
+				if _, ok := missing[tx.ID()]; ok {
+					newTxs = append(newTxs, tx)
+				}
+			}
+		}
+	}
+
 	// Store transactions (doesn't have to be rolled back if other writes fail)
 	if len(txs) > 0 {
 		if err := msh.writeTransactions(blk.LayerIndex, txs); err != nil {
@@ -580,10 +709,45 @@ func (msh *Mesh) AddBlockWithTxs(blk *types.Block, txs []*types.Transaction, atx
 	msh.invalidateFromPools(&blk.MiniBlock)
 
 	events.Publish(events.NewBlock{ID: blk.ID().String(), Atx: blk.ATXID.ShortString(), Layer: uint64(blk.LayerIndex)})
+	for _, tx := range newTxs {
+		events.Publish(events.TxInMesh{Transaction: tx, LayerID: blk.LayerIndex})
+	}
 	msh.With().Info("added block to database", blk.Fields()...)
 	return nil
 }
 
+// AddBlockBody stores the transactions of a block that was added without them. The transactions of a block must be
+// stored before its layer is applied to the global state.
+func (msh *Mesh) AddBlockBody(blk *types.Block, txs []*types.Transaction) error {
+	ids := make([]types.TransactionID, 0, len(txs))
+	for _, tx := range txs {
+		ids = append(ids, tx.ID())
+	}
+	_, missing := msh.GetTransactions(ids)
+	if len(missing) == 0 {
+		return nil
+	}
+	newTxs := make([]*types.Transaction, 0, len(missing))
+	for _, tx := range txs {
+		if _, ok := missing[tx.ID()]; ok {
+			newTxs = append(newTxs, tx)
+		}
+	}
+	if err := msh.writeTransactions(blk.LayerIndex, newTxs); err != nil {
+		return fmt.Errorf("could not write transactions of block %v database: %v", blk.ID(), err)
+	}
+	if err := msh.addToUnappliedTxs(newTxs, blk.LayerIndex); err != nil {
+		return fmt.Errorf("failed to add to unappliedTxs: %v", err)
+	}
+	msh.invalidateFromPools(&blk.MiniBlock)
+
+	for _, tx := range newTxs {
+		events.Publish(events.TxInMesh{Transaction: tx, LayerID: blk.LayerIndex})
+	}
+	msh.With().Info("added block body to database", blk.ID(), log.Int("tx_count", len(newTxs)))
+	return nil
+}
+
 func (msh *Mesh) invalidateFromPools(blk *types.MiniBlock) {
 	for _, id := range blk.TxIDs {
 		msh.txInvalidator.Invalidate(id)
@@ -649,6 +813,7 @@ func (msh *Mesh) GetOrphanBlocksBefore(l types.LayerID) ([]types.BlockID, error)
 
 func (msh *Mesh) accumulateRewards(l *types.Layer, params Config) {
 	ids := make([]types.Address, 0, len(l.Blocks()))
+	smeshers := make([]types.NodeID, 0, len(l.Blocks()))
 	for _, bl := range l.Blocks() {
 		if bl.ATXID == *types.EmptyATXID {
 			msh.With().Info("skipping reward distribution for block with no ATX", bl.LayerIndex, bl.ID())
@@ -660,7 +825,7 @@ func (msh *Mesh) accumulateRewards(l *types.Layer, params Config) {
 			continue
 		}
 		ids = append(ids, atx.Coinbase)
-
+		smeshers = append(smeshers, atx.NodeID)
 	}
 
 	if len(ids) == 0 {
@@ -699,6 +864,15 @@ func (msh *Mesh) accumulateRewards(l *types.Layer, params Config) {
 	if err != nil {
 		msh.Error("cannot write reward to db")
 	}
+	for i, coinbase := range ids {
+		events.Publish(events.RewardReceived{
+			Coinbase:    coinbase.String(),
+			Amount:      blockTotalReward.Uint64(),
+			Layer:       l.Index().Uint64(),
+			LayerReward: blockLayerReward.Uint64(),
+			Smesher:     smeshers[i].Key,
+		})
+	}
 	// todo: should miner id be sorted in a deterministic order prior to applying rewards?
 
 }
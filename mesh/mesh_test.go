@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/database"
+	"github.com/spacemeshos/go-spacemesh/events"
 	"github.com/spacemeshos/go-spacemesh/log"
 	"github.com/spacemeshos/go-spacemesh/rand"
 	"github.com/spacemeshos/go-spacemesh/signing"
@@ -75,6 +76,10 @@ func (MockState) LoadState(types.LayerID) error {
 	panic("implement me")
 }
 
+func (MockState) PruneLayerStateRoot(types.LayerID) error {
+	return nil
+}
+
 func (MockState) GetStateRoot() types.Hash32 {
 	return [32]byte{}
 }
@@ -240,6 +245,57 @@ func TestLayers_LatestKnownLayer(t *testing.T) {
 	assert.True(t, layers.LatestLayer() == 10, "wrong layer")
 }
 
+func TestMesh_RestoreCheckpointLayer(t *testing.T) {
+	layers := getMesh("t_restore")
+	defer layers.Close()
+	layers.RestoreCheckpointLayer(12)
+	assert.Equal(t, types.LayerID(12), layers.LatestLayer())
+	assert.Equal(t, types.LayerID(12), layers.ProcessedLayer())
+	assert.Equal(t, types.LayerID(12), layers.LatestLayerInState())
+
+	// the layers are persisted so that the mesh can be recovered from disk
+	for _, key := range [][]byte{constLATEST, constPROCESSED, VERIFIED, constCHECKPOINT} {
+		b, err := layers.general.Get(key)
+		assert.NoError(t, err)
+		assert.Equal(t, types.LayerID(12).Bytes(), b)
+	}
+	assert.Equal(t, types.LayerID(12), layers.CheckpointLayer())
+}
+
+func TestMesh_AddCheckpointedBlock(t *testing.T) {
+	layers := getMesh("t_checkpointed")
+	defer layers.Close()
+	layers.RestoreCheckpointLayer(12)
+
+	blk := types.NewExistingBlock(11, []byte("data1"))
+	assert.NoError(t, layers.AddCheckpointedBlock(blk))
+	assert.NoError(t, layers.AddCheckpointedBlock(blk))
+	_, err := layers.GetBlock(blk.ID())
+	assert.NoError(t, err)
+	// the block isn't an orphan and doesn't move the latest layer
+	assert.Equal(t, types.LayerID(12), layers.LatestLayer())
+	assert.NotContains(t, layers.orphanBlocks[11], blk.ID())
+
+	assert.Error(t, layers.AddCheckpointedBlock(types.NewExistingBlock(13, []byte("data2"))))
+}
+
+func TestMesh_SyncFrontier(t *testing.T) {
+	layers := getMesh("t_frontier")
+	defer layers.Close()
+	assert.Equal(t, SyncFrontier{}, layers.SyncFrontier())
+
+	frontier := SyncFrontier{Validated: 5, InFlight: []types.LayerID{6, 8}}
+	assert.NoError(t, layers.SetSyncFrontier(frontier))
+	assert.Equal(t, frontier, layers.SyncFrontier())
+
+	// the frontier is persisted so that it can be recovered from disk
+	b, err := layers.general.Get(constSYNCFRONTIER)
+	assert.NoError(t, err)
+	var persisted SyncFrontier
+	assert.NoError(t, types.BytesToInterface(b, &persisted))
+	assert.Equal(t, frontier, persisted)
+}
+
 func TestLayers_WakeUp(t *testing.T) {
 	layers := getMesh("t1")
 	defer layers.Close()
@@ -413,6 +469,71 @@ func TestMesh_AddBlockWithTxs_PushTransactions_getInvalidBlocksByHare(t *testing
 
 }
 
+func TestMesh_AddBlockBody(t *testing.T) {
+	r := require.New(t)
+
+	msh := getMesh("mesh")
+	state := &MockMapState{}
+	msh.txProcessor = state
+
+	layerID := types.LayerID(types.GetEffectiveGenesis() + 1)
+	signer, origin := newSignerAndAddress(r, "origin")
+	tx1 := newTx(r, signer, 2468, 111)
+	tx2 := newTx(r, signer, 2469, 111)
+	blk := types.NewExistingBlock(layerID, []byte("data"))
+	blk.TxIDs = []types.TransactionID{tx1.ID(), tx2.ID()}
+	blk.Initialize()
+	r.NoError(msh.SaveContextualValidity(blk.ID(), true))
+
+	// the block is stored first, and its transactions later
+	r.NoError(msh.AddBlockWithTxs(blk, nil, nil))
+	_, missing := msh.GetTransactions(blk.TxIDs)
+	r.Len(missing, 2)
+	r.NoError(msh.AddBlockBody(blk, []*types.Transaction{tx1, tx2}))
+	r.NoError(msh.AddBlockBody(blk, []*types.Transaction{tx1, tx2}))
+	_, missing = msh.GetTransactions(blk.TxIDs)
+	r.Empty(missing)
+	r.Len(getTxns(r, msh.DB, origin), 2)
+
+	msh.pushLayersToState(layerID, layerID+1)
+	r.Len(state.Txs, 2)
+	r.Empty(getTxns(r, msh.DB, origin))
+}
+
+func TestMesh_AddBlockWithTxs_TxInMesh(t *testing.T) {
+	r := require.New(t)
+
+	msh := getMesh("mesh")
+	sub := events.Subscribe(events.EventTxInMesh)
+	defer sub.Close()
+	layerID := types.LayerID(types.GetEffectiveGenesis() + 1)
+	signer, _ := newSignerAndAddress(r, "origin")
+	tx1 := newTx(r, signer, 2468, 111)
+	tx2 := newTx(r, signer, 2469, 111)
+	tx3 := newTx(r, signer, 2470, 111)
+
+	// transactions included in several blocks are reported with the first one
+	addBlockWithTxs(r, msh, layerID, true, tx1, tx2)
+	addBlockWithTxs(r, msh, layerID+1, true, tx2, tx3)
+	var reported []types.TransactionID
+	for len(reported) < 3 {
+		select {
+		case ev := <-sub.Events():
+			tx := ev.(events.TxInMesh)
+			r.Equal(layerID+types.LayerID(len(reported)/2), tx.LayerID)
+			reported = append(reported, tx.Transaction.ID())
+		case <-time.After(time.Second):
+			r.Fail("transaction wasn't reported")
+		}
+	}
+	r.Equal(GetTransactionIds(tx1, tx2, tx3), reported)
+	select {
+	case ev := <-sub.Events():
+		r.Fail("transaction reported twice", ev.(events.TxInMesh).Transaction.ID())
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
 func TestMesh_ExtractUniqueOrderedTransactions(t *testing.T) {
 	r := require.New(t)
 
@@ -1,11 +1,14 @@
 package mesh
 
 import (
+	"bytes"
 	"container/list"
+	"encoding/binary"
 	"encoding/hex"
 	"errors"
 	"fmt"
 	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/common/util"
 	"github.com/spacemeshos/go-spacemesh/database"
 	"github.com/spacemeshos/go-spacemesh/log"
 	"github.com/spacemeshos/go-spacemesh/pendingtxs"
@@ -36,32 +39,46 @@ type DB struct {
 	orphanBlocks       map[types.LayerID]map[types.BlockID]struct{}
 	layerMutex         map[types.LayerID]*layerMutex
 	lhMutex            sync.Mutex
+	prunedMutex        sync.RWMutex
+	prunedLayer        types.LayerID // layers after the effective genesis and below it were pruned
+	retainedMutex      sync.RWMutex
+	retainedLayer      types.LayerID // the consensus data of layers after the effective genesis and below it was deleted
+	finalityMutex      sync.RWMutex
+	finalizedLayer     types.LayerID
 	exit               chan struct{}
 }
 
 // NewPersistentMeshDB creates an instance of a mesh database
 func NewPersistentMeshDB(path string, blockCacheSize int, log log.Log) (*DB, error) {
-	bdb, err := database.NewLDBDatabase(filepath.Join(path, "blocks"), 0, 0, log)
+	return NewTunedPersistentMeshDB(path, blockCacheSize, func(string) database.Tuning { return database.DefaultTuning }, log)
+}
+
+// NewTunedPersistentMeshDB creates a new persistent mesh db whose databases use the tuning returned for their names
+func NewTunedPersistentMeshDB(path string, blockCacheSize int, tuning func(name string) database.Tuning, log log.Log) (*DB, error) {
+	open := func(name string) (*database.LDBDatabase, error) {
+		return database.NewTunedLDBDatabase(filepath.Join(path, name), tuning(name), log)
+	}
+	bdb, err := open("blocks")
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize blocks db: %v", err)
 	}
-	ldb, err := database.NewLDBDatabase(filepath.Join(path, "layers"), 0, 0, log)
+	ldb, err := open("layers")
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize layers db: %v", err)
 	}
-	vdb, err := database.NewLDBDatabase(filepath.Join(path, "validity"), 0, 0, log)
+	vdb, err := open("validity")
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize validity db: %v", err)
 	}
-	tdb, err := database.NewLDBDatabase(filepath.Join(path, "transactions"), 0, 0, log)
+	tdb, err := open("transactions")
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize transactions db: %v", err)
 	}
-	gdb, err := database.NewLDBDatabase(filepath.Join(path, "general"), 0, 0, log)
+	gdb, err := open("general")
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize general db: %v", err)
 	}
-	utx, err := database.NewLDBDatabase(filepath.Join(path, "unappliedTxs"), 0, 0, log)
+	utx, err := open("unappliedTxs")
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize mesh unappliedTxs db: %v", err)
 	}
@@ -79,6 +96,12 @@ func NewPersistentMeshDB(path string, blockCacheSize int, log log.Log) (*DB, err
 		layerMutex:         make(map[types.LayerID]*layerMutex),
 		exit:               make(chan struct{}),
 	}
+	if pruned, err := gdb.Get(constPRUNED); err == nil {
+		ll.prunedLayer = types.LayerID(util.BytesToUint64(pruned))
+	}
+	if retained, err := gdb.Get(constRETAINED); err == nil {
+		ll.retainedLayer = types.LayerID(util.BytesToUint64(retained))
+	}
 	ll.AddBlock(GenesisBlock())
 	ll.SaveContextualValidity(GenesisBlock().ID(), true)
 	return ll, nil
@@ -94,6 +117,18 @@ func (m *DB) PersistentData() bool {
 	return false
 }
 
+// Databases returns the databases of the mesh by name
+func (m *DB) Databases() map[string]database.Database {
+	return map[string]database.Database{
+		"blocks":       m.blocks,
+		"layers":       m.layers,
+		"validity":     m.contextualValidity,
+		"transactions": m.transactions,
+		"general":      m.general,
+		"unappliedTxs": m.unappliedTxs,
+	}
+}
+
 // NewMemMeshDB is a mock used for testing
 func NewMemMeshDB(log log.Log) *DB {
 	ll := &DB{
@@ -152,6 +187,9 @@ func (m *DB) GetBlock(id types.BlockID) (*types.Block, error) {
 	if err != nil {
 		return nil, err
 	}
+	if len(b) == 0 {
+		return nil, database.ErrPruned
+	}
 	mbk := &types.Block{}
 	err = types.BytesToInterface(b, mbk)
 	mbk.Initialize()
@@ -160,6 +198,9 @@ func (m *DB) GetBlock(id types.BlockID) (*types.Block, error) {
 
 // LayerBlocks retrieves all blocks from a layer by layer index
 func (m *DB) LayerBlocks(index types.LayerID) ([]*types.Block, error) {
+	if m.isPruned(index) {
+		return nil, database.ErrPruned
+	}
 	ids, err := m.LayerBlockIds(index)
 	if err != nil {
 		return nil, err
@@ -280,6 +321,58 @@ func (m *DB) SaveContextualValidity(id types.BlockID, valid bool) error {
 	return m.contextualValidity.Put(id.Bytes(), v)
 }
 
+func getLayerInputVectorKey(layer types.LayerID) []byte {
+	return append([]byte("input vector"), layer.Bytes()...)
+}
+
+// SaveLayerInputVector persists the blocks of a layer that were approved by the hare
+func (m *DB) SaveLayerInputVector(layer types.LayerID, vector []types.BlockID) error {
+	bytes, err := types.InterfaceToBytes(vector)
+	if err != nil {
+		return err
+	}
+	return m.general.Put(getLayerInputVectorKey(layer), bytes)
+}
+
+// GetLayerInputVector returns the blocks of a layer that were approved by the hare, it returns database.ErrNotFound
+// if the hare didn't terminate for the layer, and database.ErrPruned if the layer fell out of the retention horizon
+func (m *DB) GetLayerInputVector(layer types.LayerID) ([]types.BlockID, error) {
+	if !m.isRetained(layer) {
+		return nil, database.ErrPruned
+	}
+	bytes, err := m.general.Get(getLayerInputVectorKey(layer))
+	if err != nil {
+		return nil, err
+	}
+	var vector []types.BlockID
+	if err := types.BytesToInterface(bytes, &vector); err != nil {
+		return nil, err
+	}
+	return vector, nil
+}
+
+func getCertificateKey(layer types.LayerID) []byte {
+	return append([]byte("certificate"), layer.Bytes()...)
+}
+
+// SaveCertificate persists the encoded certificate of the hare output of a layer, the mesh doesn't interpret it. It
+// returns database.ErrPruned if the layer fell out of the retention horizon.
+func (m *DB) SaveCertificate(layer types.LayerID, cert []byte) error {
+	if !m.isRetained(layer) {
+		return database.ErrPruned
+	}
+	return m.general.Put(getCertificateKey(layer), cert)
+}
+
+// GetCertificate returns the encoded certificate of the hare output of a layer, it returns database.ErrNotFound if
+// the node has no certificate for the layer, and database.ErrPruned if the layer fell out of the retention horizon
+func (m *DB) GetCertificate(layer types.LayerID) ([]byte, error) {
+	if !m.isRetained(layer) {
+		return nil, database.ErrPruned
+	}
+	return m.general.Get(getCertificateKey(layer))
+}
+
 func (m *DB) writeBlock(bl *types.Block) error {
 	bytes, err := types.InterfaceToBytes(bl)
 	if err != nil {
@@ -382,6 +475,96 @@ func getTransactionDestKeyPrefix(l types.LayerID, account types.Address) []byte
 	return []byte(str)
 }
 
+// TxDirection tells how a transaction involves an account
+type TxDirection uint8
+
+const (
+	// TxSent marks transactions sent by the account
+	TxSent TxDirection = 1 << iota
+	// TxReceived marks transactions received by the account
+	TxReceived
+	// TxAnyDirection matches both sent and received transactions
+	TxAnyDirection = TxSent | TxReceived
+)
+
+// AccountTx is an entry in the transaction history of an account
+type AccountTx struct {
+	ID        types.TransactionID
+	Layer     types.LayerID
+	Direction TxDirection
+}
+
+// accountTxCursorLength is the length of the layer and transaction id suffix of the account history keys, which is
+// used as cursor to continue the history from
+const accountTxCursorLength = 8 + types.Hash32Length
+
+// ErrBadCursor is returned when querying the history of an account with a malformed cursor
+var ErrBadCursor = errors.New("malformed account history cursor")
+
+// getAccountTxKeyPrefix returns the prefix of the history keys of an account. The keys continue with the big endian
+// layer and the transaction id, so iterating them returns the history ordered by layer.
+func getAccountTxKeyPrefix(account types.Address) []byte {
+	return append([]byte("h_"), account.Bytes()...)
+}
+
+func getAccountTxKey(account types.Address, l types.LayerID, id types.TransactionID) []byte {
+	return append(append(getAccountTxKeyPrefix(account), util.Uint64ToBytesBigEndian(l.Uint64())...), id.Bytes()...)
+}
+
+func writeAccountTxs(batch database.Putter, l types.LayerID, t *types.Transaction) error {
+	if t.Origin() == t.Recipient {
+		return batch.Put(getAccountTxKey(t.Origin(), l, t.ID()), []byte{byte(TxSent | TxReceived)})
+	}
+	if err := batch.Put(getAccountTxKey(t.Origin(), l, t.ID()), []byte{byte(TxSent)}); err != nil {
+		return err
+	}
+	return batch.Put(getAccountTxKey(t.Recipient, l, t.ID()), []byte{byte(TxReceived)})
+}
+
+// GetAccountTransactions returns up to limit transactions that involve the account in the given direction, in layers
+// minLayer to maxLayer, ordered by layer. A transaction appears once for every layer it was included in. If cursor
+// isn't empty the history continues after the entry it points to. The returned cursor points to the last returned
+// entry, it's nil if there are no more entries.
+func (m *DB) GetAccountTransactions(account types.Address, direction TxDirection, minLayer, maxLayer types.LayerID,
+	cursor []byte, limit int) (txs []AccountTx, next []byte, err error) {
+	prefix := getAccountTxKeyPrefix(account)
+	start := append(prefix, util.Uint64ToBytesBigEndian(minLayer.Uint64())...)
+	if len(cursor) > 0 {
+		if len(cursor) != accountTxCursorLength {
+			return nil, nil, ErrBadCursor
+		}
+		// the entry after the cursor is the first key that is greater than it
+		if after := append(append([]byte{}, prefix...), append(cursor, 0)...); bytes.Compare(after, start) > 0 {
+			start = after
+		}
+	}
+	it := m.transactions.Find(prefix)
+	for ok := it.Seek(start); ok; ok = it.Next() {
+		key := it.Key()
+		if len(key) != len(prefix)+accountTxCursorLength {
+			continue
+		}
+		suffix := key[len(prefix):]
+		layer := types.LayerID(binary.BigEndian.Uint64(suffix[:8]))
+		if layer > maxLayer {
+			break
+		}
+		if len(it.Value()) != 1 || TxDirection(it.Value()[0])&direction == 0 {
+			continue
+		}
+		if len(txs) == limit {
+			return txs, next, nil
+		}
+		txs = append(txs, AccountTx{
+			ID:        types.TransactionID(types.BytesToHash(suffix[8:])),
+			Layer:     layer,
+			Direction: TxDirection(it.Value()[0]),
+		})
+		next = append([]byte{}, suffix...)
+	}
+	return txs, nil, nil
+}
+
 type dbTransaction struct {
 	*types.Transaction
 	Origin types.Address
@@ -413,6 +596,9 @@ func (m *DB) writeTransactions(l types.LayerID, txs []*types.Transaction) error
 		if err := batch.Put(getTransactionDestKey(l, t), t.ID().Bytes()); err != nil {
 			return fmt.Errorf("could not write tx %v to database: %v", t.ID().ShortString(), err)
 		}
+		if err := writeAccountTxs(batch, l, t); err != nil {
+			return fmt.Errorf("could not write tx %v to database: %v", t.ID().ShortString(), err)
+		}
 		m.Debug("wrote tx %v to db", t.ID().ShortString())
 	}
 	err := batch.Write()
@@ -428,6 +614,24 @@ type dbReward struct {
 	// TotalReward - LayerRewardEstimate = FeesEstimate
 }
 
+// CoinbaseReward is the reward a coinbase received for the blocks it produced in a single layer
+type CoinbaseReward struct {
+	Layer               types.LayerID
+	Blocks              uint64
+	TotalReward         uint64
+	LayerRewardEstimate uint64
+}
+
+// getCoinbaseRewardKeyPrefix returns the prefix of the reward keys of a coinbase. The keys continue with the big endian
+// layer, so iterating them returns the rewards ordered by layer.
+func getCoinbaseRewardKeyPrefix(coinbase types.Address) []byte {
+	return append([]byte("cr_"), coinbase.Bytes()...)
+}
+
+func getCoinbaseRewardKey(coinbase types.Address, l types.LayerID) []byte {
+	return append(getCoinbaseRewardKeyPrefix(coinbase), util.Uint64ToBytesBigEndian(l.Uint64())...)
+}
+
 func (m *DB) writeTransactionRewards(l types.LayerID, accounts []types.Address, totalReward, layerReward *big.Int) error {
 	actBlockCnt := make(map[types.Address]uint64)
 	for _, account := range accounts {
@@ -442,10 +646,38 @@ func (m *DB) writeTransactionRewards(l types.LayerID, accounts []types.Address,
 		} else if err := batch.Put(getRewardKey(l, account), b); err != nil {
 			return fmt.Errorf("could not write reward to %v to database: %v", account.Short(), err)
 		}
+		coinbaseReward := CoinbaseReward{Layer: l, Blocks: cnt, TotalReward: reward.TotalReward, LayerRewardEstimate: reward.LayerRewardEstimate}
+		if b, err := types.InterfaceToBytes(&coinbaseReward); err != nil {
+			return fmt.Errorf("could not marshal reward for %v: %v", account.Short(), err)
+		} else if err := batch.Put(getCoinbaseRewardKey(account, l), b); err != nil {
+			return fmt.Errorf("could not write reward to %v to database: %v", account.Short(), err)
+		}
 	}
 	return batch.Write()
 }
 
+// GetCoinbaseRewards returns the rewards paid to a coinbase in the layers minLayer to maxLayer, ordered by layer. Only
+// rewards that were written since the coinbase index was added are returned.
+func (m *DB) GetCoinbaseRewards(coinbase types.Address, minLayer, maxLayer types.LayerID) ([]CoinbaseReward, error) {
+	prefix := getCoinbaseRewardKeyPrefix(coinbase)
+	it := m.transactions.Find(prefix)
+	var rewards []CoinbaseReward
+	for ok := it.Seek(getCoinbaseRewardKey(coinbase, minLayer)); ok; ok = it.Next() {
+		if len(it.Key()) != len(prefix)+8 {
+			continue
+		}
+		var reward CoinbaseReward
+		if err := types.BytesToInterface(it.Value(), &reward); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal reward: %v", err)
+		}
+		if reward.Layer > maxLayer {
+			break
+		}
+		rewards = append(rewards, reward)
+	}
+	return rewards, nil
+}
+
 // GetRewards retrieves account's rewards by address
 func (m *DB) GetRewards(account types.Address) (rewards []types.Reward, err error) {
 	it := m.transactions.Find(getRewardKeyPrefix(account))
@@ -631,6 +863,9 @@ func (m *DB) GetTransaction(id types.TransactionID) (*types.Transaction, error)
 	if err != nil {
 		return nil, fmt.Errorf("could not find transaction in database %v err=%v", hex.EncodeToString(id[:]), err)
 	}
+	if len(tBytes) == 0 {
+		return nil, database.ErrPruned
+	}
 	var dbTx dbTransaction
 	err = types.BytesToInterface(tBytes, &dbTx)
 	if err != nil {
@@ -733,6 +968,45 @@ func (m *DB) ContextuallyValidBlock(layer types.LayerID) (map[types.BlockID]stru
 	return validBlks, nil
 }
 
+// PersistProcessedLayer saves the latest layer the tortoise processed
+func (m *DB) PersistProcessedLayer(layer types.LayerID) error {
+	return m.general.Put(constPROCESSED, layer.Bytes())
+}
+
+// RecoverProcessedLayer returns the latest layer the tortoise processed before the node restarted
+func (m *DB) RecoverProcessedLayer() (types.LayerID, error) {
+	processed, err := m.general.Get(constPROCESSED)
+	if err != nil {
+		return 0, err
+	}
+	return types.LayerID(util.BytesToUint64(processed)), nil
+}
+
+// RecoverLatestLayerInState returns the latest layer applied to the state before the node restarted
+func (m *DB) RecoverLatestLayerInState() (types.LayerID, error) {
+	verified, err := m.general.Get(VERIFIED)
+	if err != nil {
+		return 0, err
+	}
+	return types.LayerID(util.BytesToUint64(verified)), nil
+}
+
+// RewindLatestLayerInState makes the node load the state of the layer and apply the later layers to the state again
+// when it starts. It must only be called while the node is stopped.
+func (m *DB) RewindLatestLayerInState(layer types.LayerID) error {
+	return m.general.Put(VERIFIED, layer.Bytes())
+}
+
+// RecoverCheckpointLayer returns the layer of the checkpoint the mesh was restored from, or 0 if it was synced from
+// genesis
+func (m *DB) RecoverCheckpointLayer() types.LayerID {
+	checkpoint, err := m.general.Get(constCHECKPOINT)
+	if err != nil {
+		return 0
+	}
+	return types.LayerID(util.BytesToUint64(checkpoint))
+}
+
 // Persist persists an item v into the database using key as its id
 func (m *DB) Persist(key []byte, v interface{}) error {
 	buf, err := types.InterfaceToBytes(v)
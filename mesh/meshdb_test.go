@@ -448,6 +448,80 @@ func TestMeshDB_testGetTransactions(t *testing.T) {
 	r.Equal(0, len(txs))
 }
 
+func TestMeshDB_GetAccountTransactions(t *testing.T) {
+	r := require.New(t)
+
+	mdb := NewMemMeshDB(log.New("TestMeshDB_GetAccountTransactions", "", ""))
+
+	signer1, addr1 := newSignerAndAddress(r, "thc")
+	signer2, addr2 := newSignerAndAddress(r, "cbd")
+	sent1 := newTxWithDest(r, signer1, addr2, 0, 100)
+	received1 := newTxWithDest(r, signer2, addr1, 0, 100)
+	self := newTxWithDest(r, signer1, addr1, 1, 100)
+	sent2 := newTxWithDest(r, signer1, addr2, 2, 100)
+	r.NoError(mdb.writeTransactions(1, []*types.Transaction{sent1}))
+	r.NoError(mdb.writeTransactions(2, []*types.Transaction{received1, self}))
+	// a layer whose decimal representation shares a prefix with an earlier one
+	r.NoError(mdb.writeTransactions(10, []*types.Transaction{sent2}))
+
+	ids := func(txs []AccountTx) (ids []types.TransactionID) {
+		for _, tx := range txs {
+			ids = append(ids, tx.ID)
+		}
+		return ids
+	}
+
+	txs, next, err := mdb.GetAccountTransactions(addr1, TxAnyDirection, 0, 100, nil, 10)
+	r.NoError(err)
+	r.Nil(next)
+	r.Len(txs, 4)
+	r.Equal(AccountTx{ID: sent1.ID(), Layer: 1, Direction: TxSent}, txs[0])
+	r.Equal(types.LayerID(2), txs[1].Layer)
+	r.Equal(types.LayerID(2), txs[2].Layer)
+	r.ElementsMatch([]types.TransactionID{received1.ID(), self.ID()}, ids(txs[1:3]))
+	r.Equal(AccountTx{ID: sent2.ID(), Layer: 10, Direction: TxSent}, txs[3])
+
+	// a transaction to self is both sent and received
+	txs, _, err = mdb.GetAccountTransactions(addr1, TxReceived, 0, 100, nil, 10)
+	r.NoError(err)
+	r.ElementsMatch([]types.TransactionID{received1.ID(), self.ID()}, ids(txs))
+	txs, _, err = mdb.GetAccountTransactions(addr1, TxSent, 0, 100, nil, 10)
+	r.NoError(err)
+	r.ElementsMatch([]types.TransactionID{sent1.ID(), self.ID(), sent2.ID()}, ids(txs))
+
+	// layer range
+	txs, _, err = mdb.GetAccountTransactions(addr1, TxAnyDirection, 2, 9, nil, 10)
+	r.NoError(err)
+	r.ElementsMatch([]types.TransactionID{received1.ID(), self.ID()}, ids(txs))
+
+	// paging
+	all, _, err := mdb.GetAccountTransactions(addr1, TxAnyDirection, 0, 100, nil, 10)
+	r.NoError(err)
+	var paged []AccountTx
+	var cursor []byte
+	for {
+		txs, next, err = mdb.GetAccountTransactions(addr1, TxAnyDirection, 0, 100, cursor, 3)
+		r.NoError(err)
+		r.True(len(txs) <= 3)
+		paged = append(paged, txs...)
+		if next == nil {
+			break
+		}
+		cursor = next
+	}
+	r.Equal(all, paged)
+
+	// the other side of the transactions
+	txs, _, err = mdb.GetAccountTransactions(addr2, TxAnyDirection, 0, 100, nil, 10)
+	r.NoError(err)
+	r.Equal([]types.TransactionID{sent1.ID(), received1.ID(), sent2.ID()}, ids(txs))
+	r.Equal(TxReceived, txs[0].Direction)
+	r.Equal(TxSent, txs[1].Direction)
+
+	_, _, err = mdb.GetAccountTransactions(addr1, TxAnyDirection, 0, 100, []byte{1, 2}, 10)
+	r.Equal(ErrBadCursor, err)
+}
+
 type TinyTx struct {
 	ID          types.TransactionID
 	Nonce       uint64
@@ -511,3 +585,66 @@ func TestMeshDB_testGetRewards(t *testing.T) {
 	r.NoError(err)
 	r.Nil(rewards)
 }
+
+func TestMeshDB_GetCoinbaseRewards(t *testing.T) {
+	r := require.New(t)
+	mdb := NewMemMeshDB(log.New("TestMeshDB_GetCoinbaseRewards", "", ""))
+	_, addr1 := newSignerAndAddress(r, "123")
+	_, addr2 := newSignerAndAddress(r, "456")
+
+	r.NoError(mdb.writeTransactionRewards(1, []types.Address{addr1, addr2}, big.NewInt(10000), big.NewInt(9000)))
+	r.NoError(mdb.writeTransactionRewards(2, []types.Address{addr2, addr2}, big.NewInt(15000), big.NewInt(14500)))
+	r.NoError(mdb.writeTransactionRewards(10, []types.Address{addr2}, big.NewInt(20000), big.NewInt(19000)))
+	r.NoError(mdb.writeTransactionRewards(256, []types.Address{addr2}, big.NewInt(5000), big.NewInt(4000)))
+
+	// rewards are ordered by layer, not by the decimal representation of the layer
+	rewards, err := mdb.GetCoinbaseRewards(addr2, 0, 1000)
+	r.NoError(err)
+	r.Equal([]CoinbaseReward{
+		{Layer: 1, Blocks: 1, TotalReward: 10000, LayerRewardEstimate: 9000},
+		{Layer: 2, Blocks: 2, TotalReward: 30000, LayerRewardEstimate: 29000},
+		{Layer: 10, Blocks: 1, TotalReward: 20000, LayerRewardEstimate: 19000},
+		{Layer: 256, Blocks: 1, TotalReward: 5000, LayerRewardEstimate: 4000},
+	}, rewards)
+
+	rewards, err = mdb.GetCoinbaseRewards(addr2, 2, 10)
+	r.NoError(err)
+	r.Len(rewards, 2)
+	r.Equal(types.LayerID(2), rewards[0].Layer)
+	r.Equal(types.LayerID(10), rewards[1].Layer)
+
+	rewards, err = mdb.GetCoinbaseRewards(addr1, 2, 1000)
+	r.NoError(err)
+	r.Empty(rewards)
+}
+
+func TestMeshDB_LayerInputVector(t *testing.T) {
+	r := require.New(t)
+	mdb := NewMemMeshDB(log.New("TestMeshDB_LayerInputVector", "", ""))
+	defer mdb.Close()
+
+	_, err := mdb.GetLayerInputVector(1)
+	r.Equal(database.ErrNotFound, err)
+
+	vector := []types.BlockID{types.NewExistingBlock(1, []byte("a")).ID(), types.NewExistingBlock(1, []byte("b")).ID()}
+	r.NoError(mdb.SaveLayerInputVector(1, vector))
+	saved, err := mdb.GetLayerInputVector(1)
+	r.NoError(err)
+	r.Equal(vector, saved)
+}
+
+func TestMeshDB_Certificate(t *testing.T) {
+	r := require.New(t)
+	mdb := NewMemMeshDB(log.New("TestMeshDB_Certificate", "", ""))
+	defer mdb.Close()
+
+	_, err := mdb.GetCertificate(1)
+	r.Equal(database.ErrNotFound, err)
+
+	r.NoError(mdb.SaveCertificate(1, []byte("certificate")))
+	saved, err := mdb.GetCertificate(1)
+	r.NoError(err)
+	r.Equal([]byte("certificate"), saved)
+	_, err = mdb.GetCertificate(2)
+	r.Equal(database.ErrNotFound, err)
+}
@@ -0,0 +1,22 @@
+package mesh
+
+import (
+	"github.com/go-kit/kit/metrics"
+	prmkit "github.com/go-kit/kit/metrics/prometheus"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	namespace = "spacemesh"
+	subsystem = "mesh"
+)
+
+func newCounter(name, help string, labels []string) metrics.Counter {
+	return prmkit.NewCounterFrom(prometheus.CounterOpts{Namespace: namespace, Subsystem: subsystem, Name: name, Help: help}, labels)
+}
+
+var (
+	retentionLayers    = newCounter("retention_layers", "number of layers whose consensus data was deleted", []string{})
+	retentionReclaimed = newCounter("retention_reclaimed_bytes", "bytes of layer data deleted out of the retention horizon", []string{"kind"})
+	retentionDeleted   = newCounter("retention_deleted", "number of items of layer data deleted out of the retention horizon", []string{"kind"})
+)
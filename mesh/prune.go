@@ -0,0 +1,123 @@
+package mesh
+
+import (
+	"fmt"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/database"
+	"github.com/spacemeshos/go-spacemesh/log"
+)
+
+// Mode is whether a node keeps the whole history of the mesh or only the recent layers that validation needs
+type Mode int
+
+const (
+	// Archival nodes keep all the blocks, transactions and state roots of the mesh
+	Archival Mode = iota
+	// Pruned nodes delete the blocks, applied transactions and state roots of layers older than the prune window
+	Pruned
+)
+
+// ParseMode parses the node mode, archival or pruned
+func ParseMode(mode string) (Mode, error) {
+	switch mode {
+	case "archival":
+		return Archival, nil
+	case "pruned":
+		return Pruned, nil
+	default:
+		return Archival, fmt.Errorf("node mode must be archival or pruned, got %q", mode)
+	}
+}
+
+func (m Mode) String() string {
+	if m == Pruned {
+		return "pruned"
+	}
+	return "archival"
+}
+
+// Pruned data is replaced with an empty value rather than deleted, so that reading it returns database.ErrPruned and
+// a pruned block can't be added to the mesh again.
+
+// SetPruning makes the mesh keep only the given number of layers below the latest layer applied to the state, and
+// prune older layers as layers are applied. 0 keeps all layers.
+func (msh *Mesh) SetPruning(window types.LayerID) {
+	msh.pruneWindow = window
+}
+
+// PrunedLayer returns the layer below which the history of the mesh was pruned, 0 if it wasn't
+func (m *DB) PrunedLayer() types.LayerID {
+	m.prunedMutex.RLock()
+	defer m.prunedMutex.RUnlock()
+	return m.prunedLayer
+}
+
+// isPruned returns true if the blocks of the layer were pruned
+func (m *DB) isPruned(layer types.LayerID) bool {
+	return layer > types.GetEffectiveGenesis() && layer < m.PrunedLayer()
+}
+
+// prune prunes the layers that fell out of the prune window once the layer was applied to the state
+func (msh *Mesh) prune(applied types.LayerID) {
+	if msh.pruneWindow == 0 || applied <= msh.pruneWindow {
+		return
+	}
+	from := msh.PrunedLayer()
+	if from <= types.GetEffectiveGenesis() {
+		from = types.GetEffectiveGenesis() + 1
+	}
+	for layer := from; layer < applied-msh.pruneWindow; layer++ {
+		if err := msh.pruneLayer(layer); err != nil {
+			msh.With().Error("failed to prune layer", layer, log.Err(err))
+			return
+		}
+		msh.prunedMutex.Lock()
+		msh.prunedLayer = layer + 1
+		msh.prunedMutex.Unlock()
+		if err := msh.general.Put(constPRUNED, (layer + 1).Bytes()); err != nil {
+			msh.With().Error("could not persist pruned layer", layer, log.Err(err))
+		}
+		msh.With().Debug("pruned layer", layer)
+	}
+}
+
+// pruneLayer deletes the blocks of the layer, the transactions that were applied up to the layer, and its state root.
+// The block IDs of the layer and the contextual validity of its blocks are kept.
+func (msh *Mesh) pruneLayer(layer types.LayerID) error {
+	ids, err := msh.LayerBlockIds(layer)
+	if err != nil && err != database.ErrNotFound {
+		return err
+	}
+	blockBatch := msh.blocks.NewBatch()
+	txBatch := msh.transactions.NewBatch()
+	for _, id := range ids {
+		blk, err := msh.GetBlock(id)
+		if err == database.ErrPruned {
+			// pruned before the node stopped
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		for _, txID := range blk.TxIDs {
+			// a transaction of an invalid block may be applied with a later layer
+			if applied := msh.GetLayerApplied(txID); applied != nil && *applied <= layer {
+				if err := txBatch.Put(txID.Bytes(), nil); err != nil {
+					return err
+				}
+			}
+		}
+		if err := blockBatch.Put(blk.ID().Bytes(), nil); err != nil {
+			return err
+		}
+		msh.blockCache.Remove(blk.ID())
+	}
+	if err := txBatch.Write(); err != nil {
+		return fmt.Errorf("failed to prune transactions: %v", err)
+	}
+	if err := blockBatch.Write(); err != nil {
+		return fmt.Errorf("failed to prune blocks: %v", err)
+	}
+	return msh.PruneLayerStateRoot(layer)
+}
@@ -0,0 +1,96 @@
+package mesh
+
+import (
+	"testing"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/database"
+	"github.com/stretchr/testify/require"
+)
+
+type pruneStateMock struct {
+	MockMapState
+	applied map[types.TransactionID]types.LayerID
+	pruned  []types.LayerID
+}
+
+func (s *pruneStateMock) GetLayerApplied(id types.TransactionID) *types.LayerID {
+	if l, ok := s.applied[id]; ok {
+		return &l
+	}
+	return nil
+}
+
+func (s *pruneStateMock) PruneLayerStateRoot(layer types.LayerID) error {
+	s.pruned = append(s.pruned, layer)
+	return nil
+}
+
+func TestParseMode(t *testing.T) {
+	r := require.New(t)
+	for _, mode := range []Mode{Archival, Pruned} {
+		parsed, err := ParseMode(mode.String())
+		r.NoError(err)
+		r.Equal(mode, parsed)
+	}
+	_, err := ParseMode("full")
+	r.Error(err)
+}
+
+func TestMesh_Prune(t *testing.T) {
+	r := require.New(t)
+	msh := getMesh("prune")
+	state := &pruneStateMock{applied: make(map[types.TransactionID]types.LayerID)}
+	msh.txProcessor = state
+
+	gen := types.GetEffectiveGenesis()
+	signer, _ := newSignerAndAddress(r, "origin")
+	tx1 := addTxToMesh(r, msh, signer, 1)
+	tx2 := addTxToMesh(r, msh, signer, 2)
+	tx3 := addTxToMesh(r, msh, signer, 3)
+	blk1 := addBlockWithTxs(r, msh, gen+1, true, tx1)
+	// the transaction of an invalid block was applied with a later layer
+	blk2 := addBlockWithTxs(r, msh, gen+1, false, tx2)
+	blk3 := addBlockWithTxs(r, msh, gen+2, true, tx3)
+	state.applied[tx1.ID()] = gen + 1
+	state.applied[tx2.ID()] = gen + 2
+	state.applied[tx3.ID()] = gen + 2
+
+	// archival nodes keep all layers
+	msh.prune(gen + 10)
+	r.Equal(types.LayerID(0), msh.PrunedLayer())
+
+	msh.SetPruning(2)
+	msh.prune(gen + 3)
+	r.Equal(types.LayerID(0), msh.PrunedLayer())
+	msh.prune(gen + 3 + 1)
+	r.Equal(gen+2, msh.PrunedLayer())
+	r.Equal([]types.LayerID{gen + 1}, state.pruned)
+
+	_, err := msh.GetBlock(blk1.ID())
+	r.Equal(database.ErrPruned, err)
+	_, err = msh.GetBlock(blk2.ID())
+	r.Equal(database.ErrPruned, err)
+	_, err = msh.GetLayer(gen + 1)
+	r.Equal(database.ErrPruned, err)
+	_, err = msh.GetTransaction(tx1.ID())
+	r.Equal(database.ErrPruned, err)
+	_, err = msh.GetTransaction(tx2.ID())
+	r.NoError(err)
+	// block IDs and contextual validity are kept for the tortoise
+	ids, err := msh.LayerBlockIds(gen + 1)
+	r.NoError(err)
+	r.ElementsMatch([]types.BlockID{blk1.ID(), blk2.ID()}, ids)
+	valid, err := msh.ContextualValidity(blk1.ID())
+	r.NoError(err)
+	r.True(valid)
+	// pruned blocks aren't added again
+	r.Equal(ErrAlreadyExist, msh.AddBlock(blk1))
+
+	_, err = msh.GetBlock(blk3.ID())
+	r.NoError(err)
+	_, err = msh.GetLayer(gen + 2)
+	r.NoError(err)
+	_, err = msh.GetTransaction(tx3.ID())
+	r.NoError(err)
+}
@@ -0,0 +1,134 @@
+package mesh
+
+import (
+	"sync"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/database"
+	"github.com/spacemeshos/go-spacemesh/log"
+)
+
+var constRETAINED = []byte("retained")
+
+// Besides the blocks and transactions, the mesh keeps consensus data for every layer that it only needs while the layer
+// may still change, e.g. the blocks the hare approved for the layer, which finality compares with the opinion of the
+// tortoise, and the certificate of the hare output, which syncing peers validate it with. The data of layers more than
+// the retention horizon below the finalized layer is deleted in the background, a few layers at a time, in archival and
+// pruned nodes alike. Reading deleted data returns database.ErrPruned, and certificates of such layers aren't stored
+// again. The opinions of the tortoise are kept as the validity of the blocks, which is overwritten when an opinion
+// changes and kept with the blocks.
+
+// retentionBatch is the max number of layers whose data is deleted at once
+const retentionBatch = 100
+
+// layerKeys are the keys of the consensus data the mesh keeps for every layer, by kind
+var layerKeys = []struct {
+	kind string
+	key  func(types.LayerID) []byte
+}{
+	{"hare_output", getLayerInputVectorKey},
+	{"certificate", getCertificateKey},
+}
+
+type retention struct {
+	mu      sync.Mutex
+	horizon types.LayerID // layers kept below the finalized layer, 0 to keep all layers
+}
+
+// SetRetention makes the mesh keep the consensus data of the given number of layers below the finalized layer, and
+// delete the data of older layers every interval. 0 keeps the data of all layers.
+func (msh *Mesh) SetRetention(horizon types.LayerID, interval time.Duration) {
+	msh.retention.mu.Lock()
+	msh.retention.horizon = horizon
+	msh.retention.mu.Unlock()
+	if horizon == 0 || interval == 0 {
+		return
+	}
+	go msh.retentionLoop(interval)
+}
+
+// RetainedLayer returns the layer below which the consensus data of the layers was deleted, 0 if none was
+func (m *DB) RetainedLayer() types.LayerID {
+	m.retainedMutex.RLock()
+	defer m.retainedMutex.RUnlock()
+	return m.retainedLayer
+}
+
+// isRetained returns false if the consensus data of the layer was deleted
+func (m *DB) isRetained(layer types.LayerID) bool {
+	return layer <= types.GetEffectiveGenesis() || layer >= m.RetainedLayer()
+}
+
+func (msh *Mesh) retentionLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-msh.exit:
+			return
+		case <-ticker.C:
+			msh.pruneLayerData(retentionBatch)
+		}
+	}
+}
+
+// pruneLayerData deletes the consensus data of at most max layers that fell out of the retention horizon, the oldest
+// first. It returns the number of layers whose data it deleted.
+func (msh *Mesh) pruneLayerData(max int) int {
+	msh.retention.mu.Lock()
+	defer msh.retention.mu.Unlock()
+	finalized := msh.FinalizedLayer()
+	if msh.retention.horizon == 0 || finalized <= msh.retention.horizon {
+		return 0
+	}
+	horizon := finalized - msh.retention.horizon
+	from := msh.RetainedLayer()
+	if from <= types.GetEffectiveGenesis() {
+		from = types.GetEffectiveGenesis() + 1
+	}
+	pruned := 0
+	for layer := from; layer < horizon && pruned < max; layer++ {
+		for _, lk := range layerKeys {
+			reclaimed, err := msh.deleteLayerKey(lk.key(layer))
+			if err != nil {
+				msh.With().Error("failed to delete layer data", layer, log.String("kind", lk.kind), log.Err(err))
+				return pruned
+			}
+			if reclaimed > 0 {
+				retentionDeleted.With("kind", lk.kind).Add(1)
+				retentionReclaimed.With("kind", lk.kind).Add(float64(reclaimed))
+			}
+		}
+		msh.retainedMutex.Lock()
+		msh.retainedLayer = layer + 1
+		msh.retainedMutex.Unlock()
+		if err := msh.general.Put(constRETAINED, (layer + 1).Bytes()); err != nil {
+			msh.With().Error("could not persist retained layer", layer, log.Err(err))
+		}
+		retentionLayers.Add(1)
+		pruned++
+	}
+	if pruned > 0 {
+		msh.With().Info("deleted the consensus data of layers out of the retention horizon",
+			log.FieldNamed("to_layer", msh.RetainedLayer()-1),
+			log.FieldNamed("finalized_layer", finalized),
+			log.Int("layers", pruned))
+	}
+	return pruned
+}
+
+// deleteLayerKey deletes the consensus data of a layer under the key, and returns the number of bytes it deleted
+func (m *DB) deleteLayerKey(key []byte) (int64, error) {
+	val, err := m.general.Get(key)
+	if err == database.ErrNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if err := m.general.Delete(key); err != nil {
+		return 0, err
+	}
+	return int64(len(key) + len(val)), nil
+}
@@ -0,0 +1,52 @@
+package mesh
+
+import (
+	"testing"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/database"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMesh_PruneLayerData(t *testing.T) {
+	r := require.New(t)
+	msh := getMesh("retention")
+	gen := types.GetEffectiveGenesis()
+	for layer := gen + 1; layer <= gen+10; layer++ {
+		r.NoError(msh.SaveLayerInputVector(layer, []types.BlockID{types.BlockID(types.CalcHash32(layer.Bytes()).ToHash20())}))
+		r.NoError(msh.SaveCertificate(layer, layer.Bytes()))
+	}
+	msh.setFinalizedLayer(gen + 8)
+
+	// the data of all layers is kept without a retention horizon
+	r.Zero(msh.pruneLayerData(retentionBatch))
+
+	// the data of the layers more than the horizon below the finalized layer is deleted, a batch at a time
+	msh.SetRetention(3, 0)
+	r.Equal(2, msh.pruneLayerData(2))
+	r.Equal(gen+3, msh.RetainedLayer())
+	r.Equal(2, msh.pruneLayerData(retentionBatch))
+	r.Equal(gen+5, msh.RetainedLayer())
+	r.Zero(msh.pruneLayerData(retentionBatch))
+
+	for layer := gen + 1; layer < gen+5; layer++ {
+		_, err := msh.GetLayerInputVector(layer)
+		r.Equal(database.ErrPruned, err)
+		_, err = msh.GetCertificate(layer)
+		r.Equal(database.ErrPruned, err)
+		_, err = msh.general.Get(getCertificateKey(layer))
+		r.Equal(database.ErrNotFound, err)
+	}
+	_, err := msh.GetLayerInputVector(gen + 5)
+	r.NoError(err)
+	cert, err := msh.GetCertificate(gen + 5)
+	r.NoError(err)
+	r.Equal((gen + 5).Bytes(), cert)
+
+	// certificates of pruned layers aren't stored again
+	r.Equal(database.ErrPruned, msh.SaveCertificate(gen+1, (gen+1).Bytes()))
+	_, err = msh.general.Get(getCertificateKey(gen + 1))
+	r.Equal(database.ErrNotFound, err)
+	_, err = msh.GetLayerInputVector(gen)
+	r.Equal(database.ErrNotFound, err)
+}
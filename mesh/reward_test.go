@@ -2,6 +2,7 @@ package mesh
 
 import (
 	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/events"
 	"github.com/spacemeshos/go-spacemesh/log"
 	"github.com/spacemeshos/go-spacemesh/rand"
 	"github.com/spacemeshos/go-spacemesh/signing"
@@ -24,6 +25,7 @@ func (s *MockMapState) ValidateAndAddTxToPool(tx *types.Transaction) error {
 }
 
 func (s MockMapState) LoadState(types.LayerID) error                    { panic("implement me") }
+func (MockMapState) PruneLayerStateRoot(types.LayerID) error            { return nil }
 func (MockMapState) GetStateRoot() types.Hash32                         { return [32]byte{} }
 func (MockMapState) ValidateNonceAndBalance(*types.Transaction) error   { panic("implement me") }
 func (MockMapState) GetLayerApplied(types.TransactionID) *types.LayerID { panic("implement me") }
@@ -130,6 +132,35 @@ func TestMesh_AccumulateRewards_happyFlow(t *testing.T) {
 
 }
 
+func TestMesh_AccumulateRewards_Events(t *testing.T) {
+	s := &MockMapState{Rewards: make(map[types.Address]*big.Int)}
+	layers, atxDB := getMeshWithMapState("t_events", s)
+	defer layers.Close()
+
+	sub := events.Subscribe(events.EventRewardReceived)
+	defer sub.Close()
+
+	params := NewTestRewardParams()
+	createLayer(t, layers, 1, 3, 1, atxDB)
+	l, err := layers.GetLayer(1)
+	assert.NoError(t, err)
+	layers.accumulateRewards(l, params)
+
+	smeshers := make(map[string]string)
+	for i := 0; i < 3; i++ {
+		ev := (<-sub.Events()).(events.RewardReceived)
+		assert.Equal(t, uint64(1), ev.Layer)
+		assert.Equal(t, params.BaseReward.Uint64()/3, ev.LayerReward)
+		assert.Equal(t, ev.LayerReward, ev.Amount)
+		smeshers[ev.Smesher] = ev.Coinbase
+	}
+	assert.Len(t, sub.Events(), 0)
+	for i := 0; i < 3; i++ {
+		key := strconv.Itoa(i)
+		assert.Equal(t, types.HexToAddress(key).String(), smeshers[key])
+	}
+}
+
 func NewTestRewardParams() Config {
 	return Config{
 		BaseReward: big.NewInt(5000),
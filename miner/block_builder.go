@@ -35,7 +35,7 @@ type syncer interface {
 }
 
 type txPool interface {
-	GetTxsForBlock(numOfTxs int, getState func(addr types.Address) (nonce, balance uint64, err error)) ([]types.TransactionID, []*types.Transaction, error)
+	SelectTxsForBlock(numOfTxs int, getState func(addr types.Address) (nonce, balance uint64, err error), done func() bool) ([]types.TransactionID, []*types.Transaction, error)
 }
 
 type projector interface {
@@ -82,6 +82,8 @@ type BlockBuilder struct {
 	projector       projector
 	db              database.Database
 	layerPerEpoch   uint16
+	deadline        time.Duration // time after the start of a layer by which its blocks must be broadcast
+	signingBudget   time.Duration // time reserved for building, signing and storing a block after selecting its txs
 }
 
 // Config is the block builders configuration struct
@@ -91,6 +93,8 @@ type Config struct {
 	AtxsPerBlock   int
 	LayersPerEpoch uint16
 	TxsPerBlock    int
+	Deadline       time.Duration // zero for no deadline
+	SigningBudget  time.Duration
 }
 
 // NewBlockBuilder creates a struct of block builder type.
@@ -126,6 +130,8 @@ func NewBlockBuilder(config Config, sgn signer, net p2p.Service, beginRoundEvent
 		TransactionPool: txPool,
 		db:              db,
 		layerPerEpoch:   config.LayersPerEpoch,
+		deadline:        config.Deadline,
+		signingBudget:   config.SigningBudget,
 	}
 
 }
@@ -350,6 +356,66 @@ func selectAtxs(atxs []types.ATXID, atxsPerBlock int) []types.ATXID {
 	return selected
 }
 
+// buildDeadline is the deadline of building the blocks of a layer
+type buildDeadline struct {
+	at     time.Time // zero for no deadline
+	budget time.Duration
+}
+
+func (t *BlockBuilder) newBuildDeadline(layerStart time.Time) buildDeadline {
+	if t.deadline == 0 {
+		return buildDeadline{}
+	}
+	return buildDeadline{at: layerStart.Add(t.deadline), budget: t.signingBudget}
+}
+
+// txsDone returns whether tx selection must stop so that the remaining blocks of the layer are built, signed and
+// stored before the deadline
+func (d buildDeadline) txsDone(remaining int) func() bool {
+	if d.at.IsZero() {
+		return func() bool { return false }
+	}
+	stop := d.at.Add(-time.Duration(remaining) * d.budget)
+	return func() bool { return !time.Now().Before(stop) }
+}
+
+func (d buildDeadline) missed() bool {
+	return !d.at.IsZero() && time.Now().After(d.at)
+}
+
+func observeStage(stage string, start time.Time) {
+	stageDuration.With("stage", stage).Observe(time.Since(start).Seconds())
+}
+
+// buildBlock selects txs for a block of the layer against the deadline, then builds, signs and stores it
+func (t *BlockBuilder) buildBlock(layerID types.LayerID, atxID types.ATXID, eligibilityProof types.BlockEligibilityProof, dl buildDeadline, remaining int) (*types.Block, error) {
+	start := time.Now()
+	done := dl.txsDone(remaining)
+	txList, txs, err := t.TransactionPool.SelectTxsForBlock(t.txsPerBlock, t.projector.GetProjection, done)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get txs for block: %v", err)
+	}
+	observeStage(stageTxs, start)
+	if done() {
+		txsCutShort.Add(1)
+		t.With().Warning("tx selection stopped by the block deadline", layerID, log.Int("tx_count", len(txList)))
+	}
+
+	start = time.Now()
+	blk, err := t.createBlock(layerID, atxID, eligibilityProof, txList)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create new block: %v", err)
+	}
+	observeStage(stageBlock, start)
+
+	start = time.Now()
+	if err := t.meshProvider.AddBlockWithTxs(blk, txs, nil); err != nil {
+		return nil, err
+	}
+	observeStage(stageStore, start)
+	return blk, nil
+}
+
 func (t *BlockBuilder) createBlockLoop() {
 	for {
 		select {
@@ -358,18 +424,22 @@ func (t *BlockBuilder) createBlockLoop() {
 			return
 
 		case layerID := <-t.beginRoundEvent:
+			// layers are notified when they start
+			dl := t.newBuildDeadline(time.Now())
 			if !t.syncer.IsSynced() {
 				t.Debug("builder got layer %v not synced yet", layerID)
 				continue
 			}
 
 			t.Debug("builder got layer %v", layerID)
+			start := time.Now()
 			atxID, proofs, err := t.blockOracle.BlockEligible(layerID)
 			if err != nil {
 				events.Publish(events.DoneCreatingBlock{Eligible: true, Layer: uint64(layerID), Error: "failed to check for block eligibility"})
 				t.With().Error("failed to check for block eligibility", layerID, log.Err(err))
 				continue
 			}
+			observeStage(stageEligibility, start)
 			if len(proofs) == 0 {
 				events.Publish(events.DoneCreatingBlock{Eligible: false, Layer: uint64(layerID), Error: ""})
 				t.With().Info("Notice: not eligible for blocks in layer", layerID)
@@ -378,26 +448,15 @@ func (t *BlockBuilder) createBlockLoop() {
 			// TODO: include multiple proofs in each block and weigh blocks where applicable
 
 			//reducedAtxList := selectAtxs(atxList, t.atxsPerBlock)
-			for _, eligibilityProof := range proofs {
-				txList, txs, err := t.TransactionPool.GetTxsForBlock(t.txsPerBlock, t.projector.GetProjection)
-				if err != nil {
-					events.Publish(events.DoneCreatingBlock{Eligible: true, Layer: uint64(layerID), Error: "failed to get txs for block"})
-					t.With().Error("failed to get txs for block", layerID, log.Err(err))
-					continue
-				}
-				blk, err := t.createBlock(layerID, atxID, eligibilityProof, txList)
-				if err != nil {
-					events.Publish(events.DoneCreatingBlock{Eligible: true, Layer: uint64(layerID), Error: "cannot create new block"})
-					t.Error("cannot create new block, %v ", err)
-					continue
-				}
-				err = t.meshProvider.AddBlockWithTxs(blk, txs, nil)
+			for i, eligibilityProof := range proofs {
+				blk, err := t.buildBlock(layerID, atxID, eligibilityProof, dl, len(proofs)-i)
 				if err != nil {
 					events.Publish(events.DoneCreatingBlock{Eligible: true, Layer: uint64(layerID), Error: err.Error()})
-					t.With().Error("failed to store block", blk.ID(), log.Err(err))
+					t.With().Error("failed to build block", layerID, log.Err(err))
 					continue
 				}
 				go func() {
+					start := time.Now()
 					bytes, err := types.InterfaceToBytes(blk)
 					if err != nil {
 						t.Log.Error("cannot serialize block %v", err)
@@ -408,6 +467,11 @@ func (t *BlockBuilder) createBlockLoop() {
 					if err != nil {
 						t.Log.Error("cannot send block %v", err)
 					}
+					observeStage(stageBroadcast, start)
+					if dl.missed() {
+						missedDeadline.Add(1)
+						t.With().Warning("block broadcast after the deadline", blk.ID(), layerID)
+					}
 					events.Publish(events.DoneCreatingBlock{Eligible: true, Layer: uint64(layerID), Error: ""})
 				}()
 			}
@@ -89,17 +89,36 @@ func (bo *Oracle) BlockEligible(layerID types.LayerID) (types.ATXID, []types.Blo
 }
 
 func (bo *Oracle) calcEligibilityProofs(epochNumber types.EpochID) error {
+	atxID, _, proofs, err := bo.computeEligibilityProofs(epochNumber)
+	if err != nil {
+		return err
+	}
+	if atxID != *types.EmptyATXID {
+		bo.atxID = atxID
+	}
+
+	bo.eligibilityMutex.Lock()
+	bo.eligibilityProofs = proofs
+	bo.eligibilityMutex.Unlock()
+	bo.proofsEpoch = epochNumber
+	return nil
+}
+
+// computeEligibilityProofs calculates the block eligibility proofs for the given epoch without touching the cached
+// proofs. It returns the ATX the proofs are based on (empty if none was found in genesis) and the active set size used.
+func (bo *Oracle) computeEligibilityProofs(epochNumber types.EpochID) (types.ATXID, uint32, map[types.LayerID][]types.BlockEligibilityProof, error) {
 	epochBeacon := bo.beaconProvider.GetBeacon(epochNumber)
 
 	// get the previous epochs total ATXs
 	activeSetSize := uint32(len(bo.atxDB.GetEpochAtxs(epochNumber - 1)))
+	atxID := *types.EmptyATXID
 	atx, err := bo.getValidAtxForEpoch(epochNumber)
 	if err != nil {
 		if !epochNumber.IsGenesis() {
-			return fmt.Errorf("failed to get latest ATX: %v", err)
+			return atxID, activeSetSize, nil, fmt.Errorf("failed to get latest ATX: %v", err)
 		}
 	} else {
-		bo.atxID = atx.ID()
+		atxID = atx.ID()
 	}
 	bo.log.Info("calculating eligibility for epoch %v, active set size %v", epochBeacon, activeSetSize)
 
@@ -110,35 +129,29 @@ func (bo *Oracle) calcEligibilityProofs(epochNumber types.EpochID) error {
 	numberOfEligibleBlocks, err := getNumberOfEligibleBlocks(activeSetSize, bo.committeeSize, bo.layersPerEpoch)
 	if err != nil {
 		bo.log.Error("failed to get number of eligible blocks: %v", err)
-		return err
+		return atxID, activeSetSize, nil, err
 	}
 
-	bo.eligibilityMutex.Lock()
-	bo.eligibilityProofs = map[types.LayerID][]types.BlockEligibilityProof{}
-	bo.eligibilityMutex.Unlock()
+	proofs := map[types.LayerID][]types.BlockEligibilityProof{}
 	for counter := uint32(0); counter < numberOfEligibleBlocks; counter++ {
 		message := serializeVRFMessage(epochBeacon, epochNumber, counter)
 		vrfSig, err := bo.vrfSigner.Sign(message)
 		if err != nil {
 			bo.log.Error("Could not sign message err=%v", err)
-			return err
+			return atxID, activeSetSize, nil, err
 		}
 		vrfHash := sha256.Sum256(vrfSig)
 		eligibleLayer := calcEligibleLayer(epochNumber, bo.layersPerEpoch, vrfHash)
-		bo.eligibilityMutex.Lock()
-		bo.eligibilityProofs[eligibleLayer] = append(bo.eligibilityProofs[eligibleLayer], types.BlockEligibilityProof{
+		proofs[eligibleLayer] = append(proofs[eligibleLayer], types.BlockEligibilityProof{
 			J:   counter,
 			Sig: vrfSig,
 		})
-		bo.eligibilityMutex.Unlock()
 	}
-	bo.proofsEpoch = epochNumber
-	bo.eligibilityMutex.RLock()
 
 	// Sort the layer map so we can print the layer data in order
-	keys := make([]types.LayerID, len(bo.eligibilityProofs))
+	keys := make([]types.LayerID, len(proofs))
 	i := 0
-	for k := range bo.eligibilityProofs {
+	for k := range proofs {
 		keys[i] = k
 		i++
 	}
@@ -149,17 +162,16 @@ func (bo *Oracle) calcEligibilityProofs(epochNumber types.EpochID) error {
 	// Pretty-print the number of blocks per eligible layer
 	var strs []string
 	for k := range keys {
-		strs = append(strs, fmt.Sprintf("Layer %d: %d", keys[k], len(bo.eligibilityProofs[keys[k]])))
+		strs = append(strs, fmt.Sprintf("Layer %d: %d", keys[k], len(proofs[keys[k]])))
 	}
 
 	bo.log.With().Info("eligibility for blocks in epoch",
 		bo.nodeID,
 		epochNumber,
 		log.Uint32("total_num_blocks", numberOfEligibleBlocks),
-		log.Int("num_layers_eligible", len(bo.eligibilityProofs)),
+		log.Int("num_layers_eligible", len(proofs)),
 		log.String("layers_and_num_blocks", strings.Join(strs, ", ")))
-	bo.eligibilityMutex.RUnlock()
-	return nil
+	return atxID, activeSetSize, proofs, nil
 }
 
 func (bo *Oracle) getValidAtxForEpoch(validForEpoch types.EpochID) (*types.ActivationTxHeader, error) {
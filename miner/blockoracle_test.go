@@ -342,3 +342,71 @@ func TestMinerBlockOracle_GetEligibleLayers(t *testing.T) {
 	r.Equal(eligibleLayers, len(blockOracle.GetEligibleLayers()))
 
 }
+
+type mockHareRolacle struct {
+	eligibleLayers map[types.LayerID]bool
+}
+
+func (m mockHareRolacle) Eligible(layer types.LayerID, round int32, committeeSize int, id types.NodeID, sig []byte) (bool, error) {
+	return m.eligibleLayers[layer], nil
+}
+
+func (m mockHareRolacle) Proof(layer types.LayerID, round int32) ([]byte, error) {
+	return []byte{}, nil
+}
+
+func TestEligibilityReporter_EpochEligibility(t *testing.T) {
+	r := require.New(t)
+	activeSetSize := uint32(5)
+	committeeSize := uint32(10)
+	layersPerEpoch := uint16(20)
+	types.SetLayersPerEpoch(int32(layersPerEpoch))
+
+	activationDB := &mockActivationDB{activeSetSize: activeSetSize, atxPublicationLayer: types.LayerID(0), atxs: map[string]map[types.LayerID]types.ATXID{}}
+	lg := log.NewDefault(nodeID.Key[:5])
+	blockOracle := NewMinerBlockOracle(committeeSize, activeSetSize, layersPerEpoch, activationDB, &EpochBeaconProvider{}, vrfsgn, nodeID, func() bool { return true }, lg.WithName("blockOracle"))
+	hareOracle := mockHareRolacle{eligibleLayers: map[types.LayerID]bool{41: true, 45: true, 80: true}}
+	reporter := NewEligibilityReporter(blockOracle, hareOracle, 10, layersPerEpoch, nodeID, lg.WithName("reporter"))
+
+	// genesis epoch has no eligibilities
+	report, err := reporter.EpochEligibility(0)
+	r.NoError(err)
+	r.Zero(report.NumBlocks)
+	r.Empty(report.HareLayers)
+
+	report, err = reporter.EpochEligibility(2)
+	r.NoError(err)
+	r.Equal(types.EpochID(2), report.Epoch)
+	r.Equal(atxID, report.AtxID)
+	r.Equal(uint32(len(activeSetAtxs)), report.ActiveSetSize)
+	r.Equal(committeeSize*uint32(layersPerEpoch)/activeSetSize, report.NumBlocks)
+	r.Equal([]types.LayerID{41, 45}, report.HareLayers)
+
+	// the report must match the proofs handed out to the block builder
+	total := uint32(0)
+	for _, l := range report.BlockLayers {
+		_, proofs, err := blockOracle.BlockEligible(l.Layer)
+		r.NoError(err)
+		r.Len(proofs, int(l.NumBlocks))
+		total += l.NumBlocks
+	}
+	r.Equal(report.NumBlocks, total)
+}
+
+func TestEligibilityReporter_NoAtx(t *testing.T) {
+	r := require.New(t)
+	layersPerEpoch := uint16(20)
+	types.SetLayersPerEpoch(int32(layersPerEpoch))
+
+	activationDB := &mockActivationDB{activeSetSize: 5, atxs: map[string]map[types.LayerID]types.ATXID{}}
+	otherID := types.NodeID{Key: "other", VRFPublicKey: vrfPubkey}
+	lg := log.NewDefault(t.Name())
+	blockOracle := NewMinerBlockOracle(10, 5, layersPerEpoch, activationDB, &EpochBeaconProvider{}, vrfsgn, otherID, func() bool { return true }, lg)
+	reporter := NewEligibilityReporter(blockOracle, nil, 10, layersPerEpoch, otherID, lg)
+
+	report, err := reporter.EpochEligibility(2)
+	r.NoError(err)
+	r.Zero(report.NumBlocks)
+	r.Empty(report.BlockLayers)
+	r.Equal(*types.EmptyATXID, report.AtxID)
+}
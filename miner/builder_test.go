@@ -276,6 +276,56 @@ func TestBlockBuilder_CreateBlockFlow(t *testing.T) {
 
 }
 
+func TestBlockBuilder_Deadline(t *testing.T) {
+	r := require.New(t)
+	net := service.NewSimulator()
+	beginRound := make(chan types.LayerID)
+	n := net.NewNode()
+	receiver := net.NewNode()
+
+	txPool := state.NewTxMemPool()
+	builder := createBlockBuilder("a", n, []*types.Block{block1, block2, block3})
+	builder.TransactionPool = txPool
+	builder.beginRoundEvent = beginRound
+	// the signing budget exceeds the deadline, so the block is built without selecting txs
+	builder.deadline = time.Second
+	builder.signingBudget = 2 * time.Second
+	r.NoError(builder.Start())
+	defer builder.Close()
+
+	tx := NewTx(t, 1, types.BytesToAddress([]byte{0x01}), signing.NewEdSigner())
+	txPool.Put(tx.ID(), tx)
+
+	go func() { beginRound <- types.GetEffectiveGenesis() + 1 }()
+	select {
+	case output := <-receiver.RegisterGossipProtocol(config.NewBlockProtocol, priorityq.High):
+		b := types.MiniBlock{}
+		_, err := xdr.Unmarshal(bytes.NewBuffer(output.Bytes()), &b)
+		r.NoError(err)
+		r.Empty(b.TxIDs)
+	case <-time.After(1 * time.Second):
+		r.Fail("timeout on receiving block")
+	}
+}
+
+func TestBuildDeadline_TxsDone(t *testing.T) {
+	r := require.New(t)
+	bb := &BlockBuilder{}
+	r.False(bb.newBuildDeadline(time.Now().Add(-time.Hour)).txsDone(1)())
+
+	bb.deadline = time.Minute
+	bb.signingBudget = 10 * time.Second
+	dl := bb.newBuildDeadline(time.Now())
+	r.False(dl.txsDone(1)())
+	r.False(dl.txsDone(5)())
+	r.True(dl.txsDone(6)())
+	r.False(dl.missed())
+
+	dl = bb.newBuildDeadline(time.Now().Add(-2 * time.Minute))
+	r.True(dl.txsDone(1)())
+	r.True(dl.missed())
+}
+
 func TestBlockBuilder_CreateBlockWithRef(t *testing.T) {
 	net := service.NewSimulator()
 	n := net.NewNode()
@@ -0,0 +1,112 @@
+package miner
+
+import (
+	"sort"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/log"
+)
+
+// harePreRound is the first round of every hare instance. Being eligible for it means that this smesher takes part in
+// the consensus of that layer.
+const harePreRound = -1
+
+type hareRolacle interface {
+	Eligible(layer types.LayerID, round int32, committeeSize int, id types.NodeID, sig []byte) (bool, error)
+	Proof(layer types.LayerID, round int32) ([]byte, error)
+}
+
+// LayerEligibility is the number of blocks this smesher may produce in a single layer.
+type LayerEligibility struct {
+	Layer     types.LayerID
+	NumBlocks uint32
+}
+
+// EpochEligibility summarizes the block and hare eligibilities this smesher has in a single epoch.
+type EpochEligibility struct {
+	Epoch         types.EpochID
+	AtxID         types.ATXID
+	ActiveSetSize uint32
+	NumBlocks     uint32
+	BlockLayers   []LayerEligibility
+	HareLayers    []types.LayerID
+}
+
+// EligibilityReporter calculates, for a given epoch, the layers in which this smesher is expected to produce blocks
+// and to take part in hare consensus. It is meant for operators that want to know whether their node is actually due to
+// produce anything.
+type EligibilityReporter struct {
+	blockOracle       *Oracle
+	hareOracle        hareRolacle
+	hareCommitteeSize int
+	layersPerEpoch    uint16
+	nodeID            types.NodeID
+	log               log.Log
+}
+
+// NewEligibilityReporter returns a new EligibilityReporter. hareOracle may be nil, in which case no hare eligibilities
+// are reported.
+func NewEligibilityReporter(blockOracle *Oracle, hareOracle hareRolacle, hareCommitteeSize int, layersPerEpoch uint16, nodeID types.NodeID, log log.Log) *EligibilityReporter {
+	return &EligibilityReporter{
+		blockOracle:       blockOracle,
+		hareOracle:        hareOracle,
+		hareCommitteeSize: hareCommitteeSize,
+		layersPerEpoch:    layersPerEpoch,
+		nodeID:            nodeID,
+		log:               log,
+	}
+}
+
+// EpochEligibility returns the eligibilities of this smesher for the given epoch. Block eligibility is based on the
+// smesher's ATX targeting the epoch and on the epoch beacon, hare eligibility is checked for the first round of each
+// layer in the epoch.
+func (r *EligibilityReporter) EpochEligibility(epoch types.EpochID) (*EpochEligibility, error) {
+	res := &EpochEligibility{Epoch: epoch}
+	if epoch.IsGenesis() {
+		return res, nil
+	}
+
+	// without an atx targeting the epoch this smesher cannot produce blocks in it, which isn't an error
+	if _, err := r.blockOracle.getValidAtxForEpoch(epoch); err != nil {
+		r.log.With().Info("no valid atx found, smesher is not eligible for blocks in epoch", epoch, log.Err(err))
+		return r.addHareEligibility(res), nil
+	}
+
+	atxID, activeSetSize, proofs, err := r.blockOracle.computeEligibilityProofs(epoch)
+	if err != nil {
+		return nil, err
+	}
+	res.AtxID = atxID
+	res.ActiveSetSize = activeSetSize
+	for layer, layerProofs := range proofs {
+		res.BlockLayers = append(res.BlockLayers, LayerEligibility{Layer: layer, NumBlocks: uint32(len(layerProofs))})
+		res.NumBlocks += uint32(len(layerProofs))
+	}
+	sort.Slice(res.BlockLayers, func(i, j int) bool {
+		return res.BlockLayers[i].Layer < res.BlockLayers[j].Layer
+	})
+	return r.addHareEligibility(res), nil
+}
+
+func (r *EligibilityReporter) addHareEligibility(res *EpochEligibility) *EpochEligibility {
+	if r.hareOracle == nil {
+		return res
+	}
+	first := res.Epoch.FirstLayer()
+	for layer := first; layer < first.Add(r.layersPerEpoch); layer++ {
+		proof, err := r.hareOracle.Proof(layer, harePreRound)
+		if err != nil {
+			r.log.With().Warning("could not calculate hare proof", layer, log.Err(err))
+			continue
+		}
+		eligible, err := r.hareOracle.Eligible(layer, harePreRound, r.hareCommitteeSize, r.nodeID, proof)
+		if err != nil {
+			r.log.With().Warning("could not calculate hare eligibility", layer, log.Err(err))
+			continue
+		}
+		if eligible {
+			res.HareLayers = append(res.HareLayers, layer)
+		}
+	}
+	return res
+}
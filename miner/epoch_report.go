@@ -0,0 +1,85 @@
+package miner
+
+import (
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/events"
+	"github.com/spacemeshos/go-spacemesh/log"
+)
+
+type epochAtxProvider interface {
+	GetEpochAtxs(epochID types.EpochID) []types.ATXID
+	GetFullAtx(id types.ATXID) (*types.ActivationTx, error)
+}
+
+type beaconProvider interface {
+	GetBeacon(epochNumber types.EpochID) []byte
+}
+
+// EpochReporter listens to the layer clock and publishes an EpochUpdate event every time a new epoch starts, so that
+// clients can follow the growth of the network without going over all the ATXs themselves.
+type EpochReporter struct {
+	atxs       epochAtxProvider
+	beacon     beaconProvider
+	layerTimer chan types.LayerID
+	stop       chan struct{}
+	lastEpoch  *types.EpochID
+	log        log.Log
+}
+
+// NewEpochReporter returns a new EpochReporter that reads layer ticks from layerTimer
+func NewEpochReporter(atxs epochAtxProvider, beacon beaconProvider, layerTimer chan types.LayerID, log log.Log) *EpochReporter {
+	return &EpochReporter{
+		atxs:       atxs,
+		beacon:     beacon,
+		layerTimer: layerTimer,
+		stop:       make(chan struct{}),
+		log:        log,
+	}
+}
+
+// Start starts listening to the layer clock
+func (r *EpochReporter) Start() {
+	go r.loop()
+}
+
+// Close stops listening to the layer clock
+func (r *EpochReporter) Close() {
+	close(r.stop)
+}
+
+func (r *EpochReporter) loop() {
+	for {
+		select {
+		case <-r.stop:
+			return
+		case layer := <-r.layerTimer:
+			epoch := layer.GetEpoch()
+			if r.lastEpoch != nil && epoch <= *r.lastEpoch {
+				continue
+			}
+			r.lastEpoch = &epoch
+			events.Publish(r.EpochUpdate(epoch))
+		}
+	}
+}
+
+// EpochUpdate summarizes the given epoch: the smeshers that are active in it are the ones that published an ATX in the
+// previous epoch, and the weight of each of them is the space it committed to
+func (r *EpochReporter) EpochUpdate(epoch types.EpochID) events.EpochUpdate {
+	update := events.EpochUpdate{Epoch: epoch, Beacon: r.beacon.GetBeacon(epoch)}
+	if epoch == 0 {
+		return update
+	}
+	for _, id := range r.atxs.GetEpochAtxs(epoch - 1) {
+		atx, err := r.atxs.GetFullAtx(id)
+		if err != nil {
+			r.log.With().Error("failed to get atx for epoch report", id, epoch, log.Err(err))
+			continue
+		}
+		update.ActiveSmeshers++
+		if atx.Nipst != nil {
+			update.TotalWeight += atx.Nipst.Space
+		}
+	}
+	return update
+}
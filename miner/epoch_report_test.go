@@ -0,0 +1,75 @@
+package miner
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/events"
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/stretchr/testify/require"
+)
+
+type epochAtxsMock map[types.EpochID][]*types.ActivationTx
+
+func (m epochAtxsMock) GetEpochAtxs(epochID types.EpochID) (ids []types.ATXID) {
+	for _, atx := range m[epochID] {
+		ids = append(ids, atx.ID())
+	}
+	return ids
+}
+
+func (m epochAtxsMock) GetFullAtx(id types.ATXID) (*types.ActivationTx, error) {
+	for _, atxs := range m {
+		for _, atx := range atxs {
+			if atx.ID() == id {
+				return atx, nil
+			}
+		}
+	}
+	return nil, errors.New("atx not found")
+}
+
+func newEpochAtx(nodeID string, pubLayer types.LayerID, space uint64) *types.ActivationTx {
+	challenge := types.NIPSTChallenge{NodeID: types.NodeID{Key: nodeID}, PubLayerID: pubLayer}
+	return types.NewActivationTx(challenge, types.Address{}, &types.NIPST{Space: space}, nil)
+}
+
+func TestEpochReporter(t *testing.T) {
+	types.SetLayersPerEpoch(3)
+	sub := events.Subscribe(events.EventEpochUpdate)
+	defer sub.Close()
+
+	atxs := epochAtxsMock{
+		0: {newEpochAtx("a", 1, 100)},
+		1: {newEpochAtx("b", 3, 200), newEpochAtx("c", 4, 300)},
+	}
+	layers := make(chan types.LayerID)
+	r := NewEpochReporter(atxs, &EpochBeaconProvider{}, layers, log.NewDefault("epochReporter"))
+	r.Start()
+	defer r.Close()
+
+	// only the first layer the clock reports in every epoch triggers an update
+	for _, layer := range []types.LayerID{2, 3, 4, 6} {
+		layers <- layer
+	}
+
+	var updates []events.EpochUpdate
+	for i := 0; i < 3; i++ {
+		select {
+		case ev := <-sub.Events():
+			updates = append(updates, ev.(events.EpochUpdate))
+		case <-time.After(time.Second):
+			require.FailNow(t, "timed out waiting for epoch update")
+		}
+	}
+	require.Equal(t, events.EpochUpdate{Epoch: 0, Beacon: (&EpochBeaconProvider{}).GetBeacon(0)}, updates[0])
+	require.Equal(t, types.EpochID(1), updates[1].Epoch)
+	require.Equal(t, uint64(1), updates[1].ActiveSmeshers)
+	require.Equal(t, uint64(100), updates[1].TotalWeight)
+	require.Equal(t, types.EpochID(2), updates[2].Epoch)
+	require.Equal(t, uint64(2), updates[2].ActiveSmeshers)
+	require.Equal(t, uint64(500), updates[2].TotalWeight)
+	require.Equal(t, (&EpochBeaconProvider{}).GetBeacon(2), updates[2].Beacon)
+}
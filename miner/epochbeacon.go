@@ -2,15 +2,34 @@ package miner
 
 import (
 	"encoding/binary"
+	"sync"
+
 	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/common/util"
+	"github.com/spacemeshos/go-spacemesh/events"
 )
 
 // EpochBeaconProvider holds all the dependencies for generating an epoch beacon. There are currently none.
-type EpochBeaconProvider struct{}
+type EpochBeaconProvider struct {
+	mu sync.Mutex
+	// lastEpoch is the latest epoch a beacon was computed for, it's used to report every new beacon once
+	lastEpoch *types.EpochID
+}
 
 // GetBeacon returns a beacon given an epoch ID. The current implementation returns the epoch ID in byte format.
 func (p *EpochBeaconProvider) GetBeacon(epochNumber types.EpochID) []byte {
 	ret := make([]byte, 32)
 	binary.LittleEndian.PutUint64(ret, uint64(epochNumber))
+
+	p.mu.Lock()
+	isNew := p.lastEpoch == nil || epochNumber > *p.lastEpoch
+	if isNew {
+		p.lastEpoch = &epochNumber
+	}
+	p.mu.Unlock()
+	if isNew {
+		events.Publish(events.NodeEvent{Kind: events.NodeEventBeaconComputed, Layer: epochNumber.FirstLayer(),
+			Details: util.Bytes2Hex(ret)})
+	}
 	return ret
 }
@@ -0,0 +1,29 @@
+package miner
+
+import (
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/common/util"
+	"github.com/spacemeshos/go-spacemesh/events"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestEpochBeaconProvider_BeaconComputedEvent(t *testing.T) {
+	sub := events.Subscribe(events.EventNode)
+	defer sub.Close()
+
+	p := &EpochBeaconProvider{}
+	beacon := p.GetBeacon(2)
+	p.GetBeacon(2)
+	p.GetBeacon(1)
+	p.GetBeacon(3)
+
+	// only beacons of new epochs are reported
+	ev := (<-sub.Events()).(events.NodeEvent)
+	require.Equal(t, events.NodeEventBeaconComputed, ev.Kind)
+	require.Equal(t, types.EpochID(2).FirstLayer(), ev.Layer)
+	require.Equal(t, util.Bytes2Hex(beacon), ev.Details)
+	ev = (<-sub.Events()).(events.NodeEvent)
+	require.Equal(t, types.EpochID(3).FirstLayer(), ev.Layer)
+	require.Empty(t, sub.Events())
+}
@@ -0,0 +1,35 @@
+package miner
+
+import (
+	"github.com/go-kit/kit/metrics"
+	prmkit "github.com/go-kit/kit/metrics/prometheus"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	namespace = "spacemesh"
+	subsystem = "miner"
+)
+
+func newCounter(name, help string, labels []string) metrics.Counter {
+	return prmkit.NewCounterFrom(prometheus.CounterOpts{Namespace: namespace, Subsystem: subsystem, Name: name, Help: help}, labels)
+}
+
+func newSummary(name, help string, labels []string) metrics.Histogram {
+	return prmkit.NewSummaryFrom(prometheus.SummaryOpts{Namespace: namespace, Subsystem: subsystem, Name: name, Help: help,
+		Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001}}, labels)
+}
+
+const (
+	stageEligibility = "eligibility"
+	stageTxs         = "txs"
+	stageBlock       = "block"
+	stageStore       = "store"
+	stageBroadcast   = "broadcast"
+)
+
+var (
+	stageDuration  = newSummary("block_build_stage_seconds", "time spent per block building stage", []string{"stage"})
+	txsCutShort    = newCounter("block_txs_cut_short", "blocks whose tx selection was stopped by the build deadline", []string{})
+	missedDeadline = newCounter("block_missed_deadline", "blocks broadcast after the build deadline", []string{})
+)
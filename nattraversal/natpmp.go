@@ -0,0 +1,11 @@
+package nattraversal
+
+import (
+	"github.com/spacemeshos/go-spacemesh/nattraversal/natpmp"
+)
+
+// DiscoverNATPMPGateway returns the default gateway of the host if it supports NAT-PMP. Unlike UPnP discovery it takes
+// a few seconds at most.
+func DiscoverNATPMPGateway() (UPNPGateway, error) {
+	return natpmp.Discover()
+}
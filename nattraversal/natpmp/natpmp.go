@@ -0,0 +1,249 @@
+// Package natpmp implements the client side of the NAT Port Mapping Protocol (RFC 6886), which is supported by many
+// consumer routers that don't support UPnP.
+//
+// Like the upnp package, forwarded ports are symmetric and TCP and UDP are forwarded together. NAT-PMP mappings are
+// leased, the gateway renews the lease of a forwarded port until it's cleared.
+package natpmp
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Port is the port NAT-PMP gateways listen on.
+const Port = 5351
+
+// Lifetime is the lifetime requested for port mappings, the lease is renewed when half of it passes.
+const Lifetime = 2 * time.Hour
+
+const (
+	opExternalAddress = 0
+	opMapUDP          = 1
+	opMapTCP          = 2
+
+	// responses have the opcode of the request plus 128
+	opResponse = 128
+
+	// the first retry is after initialTimeout, the timeout doubles on every retry
+	initialTimeout = 250 * time.Millisecond
+	maxTries       = 4
+)
+
+var resultErrors = map[uint16]string{
+	1: "unsupported version",
+	2: "not authorized",
+	3: "network failure",
+	4: "out of resources",
+	5: "unsupported opcode",
+}
+
+// Gateway is a NAT-PMP gateway.
+type Gateway struct {
+	addr *net.UDPAddr
+
+	mu       sync.Mutex // serializes requests and guards renewals
+	renewals map[uint16]chan struct{}
+}
+
+func newGateway(addr *net.UDPAddr) *Gateway {
+	return &Gateway{addr: addr, renewals: make(map[uint16]chan struct{})}
+}
+
+// Discover returns the default gateway of the host if it answers NAT-PMP requests.
+func Discover() (*Gateway, error) {
+	ip, err := defaultGateway()
+	if err != nil {
+		return nil, err
+	}
+	gw := newGateway(&net.UDPAddr{IP: ip, Port: Port})
+	if _, err := gw.ExternalIP(); err != nil {
+		return nil, fmt.Errorf("no NAT-PMP gateway found at %v: %v", ip, err)
+	}
+	return gw, nil
+}
+
+// ExternalIP returns the gateway's external IP.
+func (g *Gateway) ExternalIP() (string, error) {
+	res, err := g.request([]byte{0, opExternalAddress}, 12)
+	if err != nil {
+		return "", err
+	}
+	return net.IP(res[8:12]).String(), nil
+}
+
+// Forward forwards the specified port and keeps renewing its lease until it's cleared. The description is ignored,
+// NAT-PMP mappings have none.
+func (g *Gateway) Forward(port uint16, desc string) error {
+	if err := g.mapPort(port, Lifetime); err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	if _, ok := g.renewals[port]; !ok {
+		stop := make(chan struct{})
+		g.renewals[port] = stop
+		go g.renew(port, stop)
+	}
+	g.mu.Unlock()
+	return nil
+}
+
+// Clear un-forwards a port and stops renewing its lease.
+func (g *Gateway) Clear(port uint16) error {
+	g.mu.Lock()
+	if stop, ok := g.renewals[port]; ok {
+		close(stop)
+		delete(g.renewals, port)
+	}
+	g.mu.Unlock()
+	return g.mapPort(port, 0)
+}
+
+func (g *Gateway) renew(port uint16, stop chan struct{}) {
+	ticker := time.NewTicker(Lifetime / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			// a failed renewal is retried on the next tick, before the lease expires
+			_ = g.mapPort(port, Lifetime)
+		}
+	}
+}
+
+// mapPort maps the port for both TCP and UDP. A lifetime of zero deletes the mappings.
+func (g *Gateway) mapPort(port uint16, lifetime time.Duration) error {
+	var errs []string
+	for _, op := range []byte{opMapTCP, opMapUDP} {
+		if err := g.mapProtocol(op, port, lifetime); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if lifetime == 0 && len(errs) < 2 {
+		// like the upnp package, only fail clearing if both deletions failed
+		return nil
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+func (g *Gateway) mapProtocol(op byte, port uint16, lifetime time.Duration) error {
+	proto := "TCP"
+	if op == opMapUDP {
+		proto = "UDP"
+	}
+	req := make([]byte, 12)
+	req[1] = op
+	binary.BigEndian.PutUint16(req[4:6], port)
+	if lifetime > 0 {
+		binary.BigEndian.PutUint16(req[6:8], port)
+	}
+	binary.BigEndian.PutUint32(req[8:12], uint32(lifetime/time.Second))
+
+	res, err := g.request(req, 16)
+	if err != nil {
+		return fmt.Errorf("failed to map %v port %d: %v", proto, port, err)
+	}
+	if lifetime == 0 {
+		return nil
+	}
+	if external := binary.BigEndian.Uint16(res[10:12]); external != port {
+		// the mapping isn't symmetric, release it
+		binary.BigEndian.PutUint16(req[6:8], 0)
+		binary.BigEndian.PutUint32(req[8:12], 0)
+		_, _ = g.request(req, 16)
+		return fmt.Errorf("gateway mapped %v port %d to external port %d", proto, port, external)
+	}
+	return nil
+}
+
+// request sends a request to the gateway, retransmitting it until a response arrives, and returns the response.
+func (g *Gateway) request(req []byte, size int) ([]byte, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	conn, err := net.DialUDP("udp", nil, g.addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	buf := make([]byte, 16)
+	timeout := initialTimeout
+	for try := 0; try < maxTries; try++ {
+		if _, err := conn.Write(req); err != nil {
+			return nil, err
+		}
+		if err := conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+			return nil, err
+		}
+		timeout *= 2
+		for {
+			n, err := conn.Read(buf)
+			if err != nil {
+				if ne, ok := err.(net.Error); ok && ne.Timeout() {
+					break
+				}
+				return nil, err
+			}
+			if n < 4 || buf[0] != 0 || buf[1] != req[1]+opResponse {
+				// not a response to our request
+				continue
+			}
+			if result := binary.BigEndian.Uint16(buf[2:4]); result != 0 {
+				if msg, ok := resultErrors[result]; ok {
+					return nil, errors.New(msg)
+				}
+				return nil, fmt.Errorf("result code %d", result)
+			}
+			if n < size {
+				return nil, fmt.Errorf("response too short (%d bytes)", n)
+			}
+			return buf[:n], nil
+		}
+	}
+	return nil, errors.New("gateway didn't respond")
+}
+
+// defaultGateway returns the IPv4 address of the default gateway from the routing table of the kernel.
+func defaultGateway() (net.IP, error) {
+	f, err := os.Open("/proc/net/route")
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read the routing table: %v", err)
+	}
+	defer f.Close()
+	return parseRoutes(bufio.NewScanner(f))
+}
+
+// parseRoutes finds the default route in a routing table in the format of /proc/net/route.
+func parseRoutes(scanner *bufio.Scanner) (net.IP, error) {
+	scanner.Scan() // header
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 || fields[1] != "00000000" {
+			continue
+		}
+		b, err := hex.DecodeString(fields[2])
+		if err != nil || len(b) != 4 {
+			return nil, fmt.Errorf("malformed gateway address %v", fields[2])
+		}
+		// the address is in host byte order, which is little endian on the supported platforms
+		return net.IPv4(b[3], b[2], b[1], b[0]), nil
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, errors.New("no default gateway")
+}
@@ -0,0 +1,164 @@
+package natpmp
+
+import (
+	"bufio"
+	"encoding/binary"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// gatewayMock answers NAT-PMP requests, mapping ports to externalPort if it's set or to the requested port otherwise
+type gatewayMock struct {
+	conn         *net.UDPConn
+	externalPort uint16
+	result       uint16
+	drop         int // number of requests to ignore, to test retransmission
+
+	mu       sync.Mutex
+	mappings map[byte]map[uint16]uint32 // lifetimes by protocol and port
+}
+
+func newGatewayMock(t *testing.T) *gatewayMock {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	m := &gatewayMock{conn: conn, mappings: map[byte]map[uint16]uint32{opMapTCP: {}, opMapUDP: {}}}
+	go m.serve()
+	return m
+}
+
+func (m *gatewayMock) serve() {
+	buf := make([]byte, 16)
+	for {
+		n, addr, err := m.conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		m.mu.Lock()
+		if m.drop > 0 {
+			m.drop--
+			m.mu.Unlock()
+			continue
+		}
+		res := make([]byte, 16)
+		res[1] = buf[1] + opResponse
+		binary.BigEndian.PutUint16(res[2:4], m.result)
+		switch {
+		case n == 2 && buf[1] == opExternalAddress:
+			copy(res[8:12], net.IPv4(1, 2, 3, 4).To4())
+			res = res[:12]
+		case n == 12:
+			port := binary.BigEndian.Uint16(buf[4:6])
+			lifetime := binary.BigEndian.Uint32(buf[8:12])
+			external := binary.BigEndian.Uint16(buf[6:8])
+			if m.externalPort != 0 && lifetime != 0 {
+				external = m.externalPort
+			}
+			if lifetime == 0 {
+				delete(m.mappings[buf[1]], port)
+			} else if m.result == 0 {
+				m.mappings[buf[1]][port] = lifetime
+			}
+			copy(res[8:10], buf[4:6])
+			binary.BigEndian.PutUint16(res[10:12], external)
+			copy(res[12:16], buf[8:12])
+		}
+		m.mu.Unlock()
+		_, _ = m.conn.WriteToUDP(res, addr)
+	}
+}
+
+func (m *gatewayMock) lifetime(op byte, port uint16) (uint32, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	lifetime, ok := m.mappings[op][port]
+	return lifetime, ok
+}
+
+func (m *gatewayMock) gateway() *Gateway {
+	return newGateway(m.conn.LocalAddr().(*net.UDPAddr))
+}
+
+func TestGateway_ExternalIP(t *testing.T) {
+	m := newGatewayMock(t)
+	defer m.conn.Close()
+	m.mu.Lock()
+	m.drop = 1
+	m.mu.Unlock()
+
+	start := time.Now()
+	ip, err := m.gateway().ExternalIP()
+	require.NoError(t, err)
+	require.Equal(t, "1.2.3.4", ip)
+	require.True(t, time.Since(start) >= initialTimeout)
+}
+
+func TestGateway_Forward(t *testing.T) {
+	m := newGatewayMock(t)
+	defer m.conn.Close()
+	gw := m.gateway()
+
+	require.NoError(t, gw.Forward(7513, "spacemesh"))
+	for _, op := range []byte{opMapTCP, opMapUDP} {
+		lifetime, ok := m.lifetime(op, 7513)
+		require.True(t, ok)
+		require.Equal(t, uint32(Lifetime/time.Second), lifetime)
+	}
+	require.Len(t, gw.renewals, 1)
+
+	require.NoError(t, gw.Clear(7513))
+	for _, op := range []byte{opMapTCP, opMapUDP} {
+		_, ok := m.lifetime(op, 7513)
+		require.False(t, ok)
+	}
+	require.Empty(t, gw.renewals)
+}
+
+func TestGateway_ForwardFailure(t *testing.T) {
+	m := newGatewayMock(t)
+	defer m.conn.Close()
+	gw := m.gateway()
+
+	// the gateway maps a different external port, the mappings are released
+	m.mu.Lock()
+	m.externalPort = 7514
+	m.mu.Unlock()
+	require.EqualError(t, gw.Forward(7513, "spacemesh"),
+		"gateway mapped TCP port 7513 to external port 7514, gateway mapped UDP port 7513 to external port 7514")
+	_, ok := m.lifetime(opMapTCP, 7513)
+	require.False(t, ok)
+	require.Empty(t, gw.renewals)
+
+	m.mu.Lock()
+	m.externalPort = 0
+	m.result = 2
+	m.mu.Unlock()
+	require.EqualError(t, gw.Forward(7513, "spacemesh"),
+		"failed to map TCP port 7513: not authorized, failed to map UDP port 7513: not authorized")
+}
+
+func TestGateway_NoResponse(t *testing.T) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = newGateway(conn.LocalAddr().(*net.UDPAddr)).ExternalIP()
+	require.EqualError(t, err, "gateway didn't respond")
+}
+
+func TestParseRoutes(t *testing.T) {
+	routes := `Iface	Destination	Gateway 	Flags	RefCnt	Use	Metric	Mask		MTU	Window	IRTT
+eth0	0000A8C0	00000000	0001	0	0	0	00FFFFFF	0	0	0
+eth0	00000000	0100A8C0	0003	0	0	0	00000000	0	0	0
+`
+	ip, err := parseRoutes(bufio.NewScanner(strings.NewReader(routes)))
+	require.NoError(t, err)
+	require.Equal(t, "192.168.0.1", ip.String())
+
+	_, err = parseRoutes(bufio.NewScanner(strings.NewReader(strings.Join(strings.Split(routes, "\n")[:2], "\n"))))
+	require.EqualError(t, err, "no default gateway")
+}
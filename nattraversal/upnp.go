@@ -40,10 +40,12 @@ func getPortDesc() string {
 	return portDesc
 }
 
-// UPNPGateway is the interface of a UPnP gateway. It supports forwarding a port and clearing a forwarding rule.
+// UPNPGateway is the interface of a UPnP or NAT-PMP gateway. It supports forwarding a port, clearing a forwarding rule
+// and discovering the external IP of the gateway.
 type UPNPGateway interface {
 	Forward(port uint16, desc string) error
 	Clear(port uint16) error
+	ExternalIP() (string, error)
 }
 
 // DiscoverUPNPGateway returns a UPNPGateway if one is found on the network. This process is long - about 30 seconds and
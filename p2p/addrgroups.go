@@ -0,0 +1,161 @@
+package p2p
+
+import (
+	"fmt"
+	inet "net"
+	"sync"
+
+	"github.com/spacemeshos/go-spacemesh/p2p/node"
+	"github.com/spacemeshos/go-spacemesh/p2p/p2pcrypto"
+)
+
+// privateNets are the address ranges of private networks, which aren't grouped
+var privateNets = []*inet.IPNet{
+	{IP: inet.IP{10, 0, 0, 0}, Mask: inet.CIDRMask(8, 32)},
+	{IP: inet.IP{172, 16, 0, 0}, Mask: inet.CIDRMask(12, 32)},
+	{IP: inet.IP{192, 168, 0, 0}, Mask: inet.CIDRMask(16, 32)},
+	{IP: inet.IP{0xfc, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, Mask: inet.CIDRMask(7, 128)},
+}
+
+func isPrivateIP(ip inet.IP) bool {
+	for _, n := range privateNets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+type groupedPeer struct {
+	group   string
+	inbound bool
+}
+
+// addrGroups tracks the address groups, i.e. the subnets, of connected peers. An attacker usually controls addresses
+// in few subnets, so limiting the inbound peers of each group and dialing outbound peers of different groups makes it
+// harder to eclipse the node. Loopback, private and link local addresses aren't grouped, since local networks and
+// tests legitimately run many nodes in one subnet.
+type addrGroups struct {
+	mu       sync.Mutex
+	ipv4Mask inet.IPMask // nil if IPv4 addresses aren't grouped
+	ipv6Mask inet.IPMask // nil if IPv6 addresses aren't grouped
+	peers    map[p2pcrypto.PublicKey]groupedPeer
+	inbound  map[string]int
+	outbound map[string]int
+}
+
+// newAddrGroups creates address groups of the given prefix lengths, a prefix length of 0 disables grouping addresses
+// of the family
+func newAddrGroups(ipv4Prefix, ipv6Prefix int) (*addrGroups, error) {
+	if ipv4Prefix < 0 || ipv4Prefix > 32 {
+		return nil, fmt.Errorf("invalid ipv4 group prefix length %d", ipv4Prefix)
+	}
+	if ipv6Prefix < 0 || ipv6Prefix > 128 {
+		return nil, fmt.Errorf("invalid ipv6 group prefix length %d", ipv6Prefix)
+	}
+	ag := &addrGroups{
+		peers:    make(map[p2pcrypto.PublicKey]groupedPeer),
+		inbound:  make(map[string]int),
+		outbound: make(map[string]int),
+	}
+	if ipv4Prefix > 0 {
+		ag.ipv4Mask = inet.CIDRMask(ipv4Prefix, 32)
+	}
+	if ipv6Prefix > 0 {
+		ag.ipv6Mask = inet.CIDRMask(ipv6Prefix, 128)
+	}
+	return ag, nil
+}
+
+// group returns the address group of the ip, empty if the ip isn't grouped
+func (ag *addrGroups) group(ip inet.IP) string {
+	if ip == nil || ip.IsLoopback() || isPrivateIP(ip) || ip.IsLinkLocalUnicast() || ip.IsUnspecified() {
+		return ""
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		if ag.ipv4Mask == nil {
+			return ""
+		}
+		ones, _ := ag.ipv4Mask.Size()
+		return fmt.Sprintf("%v/%d", ip4.Mask(ag.ipv4Mask), ones)
+	}
+	if ag.ipv6Mask == nil {
+		return ""
+	}
+	ones, _ := ag.ipv6Mask.Size()
+	return fmt.Sprintf("%v/%d", ip.Mask(ag.ipv6Mask), ones)
+}
+
+// inboundCount returns the number of inbound peers in the address group of the ip
+func (ag *addrGroups) inboundCount(ip inet.IP) int {
+	g := ag.group(ip)
+	if g == "" {
+		return 0
+	}
+	ag.mu.Lock()
+	defer ag.mu.Unlock()
+	return ag.inbound[g]
+}
+
+// add records the address group of a connected peer
+func (ag *addrGroups) add(peer p2pcrypto.PublicKey, ip inet.IP, inbound bool) {
+	g := ag.group(ip)
+	if g == "" {
+		return
+	}
+	ag.mu.Lock()
+	defer ag.mu.Unlock()
+	if old, ok := ag.peers[peer]; ok {
+		ag.decrement(old)
+	}
+	ag.peers[peer] = groupedPeer{group: g, inbound: inbound}
+	if inbound {
+		ag.inbound[g]++
+	} else {
+		ag.outbound[g]++
+	}
+}
+
+// remove forgets the address group of a disconnected peer
+func (ag *addrGroups) remove(peer p2pcrypto.PublicKey) {
+	ag.mu.Lock()
+	defer ag.mu.Unlock()
+	if old, ok := ag.peers[peer]; ok {
+		ag.decrement(old)
+		delete(ag.peers, peer)
+	}
+}
+
+func (ag *addrGroups) decrement(gp groupedPeer) {
+	counts := ag.outbound
+	if gp.inbound {
+		counts = ag.inbound
+	}
+	if counts[gp.group]--; counts[gp.group] <= 0 {
+		delete(counts, gp.group)
+	}
+}
+
+// diverse returns the candidates for outbound peers in groups that have no outbound peer, one of each group, and
+// ungrouped candidates. It returns all the candidates if none of them is in a new group, so a node whose peers share
+// few subnets still connects.
+func (ag *addrGroups) diverse(nds []*node.Info) []*node.Info {
+	ag.mu.Lock()
+	defer ag.mu.Unlock()
+	selected := make([]*node.Info, 0, len(nds))
+	seen := make(map[string]struct{})
+	for _, nd := range nds {
+		g := ag.group(nd.IP)
+		if g != "" {
+			if _, ok := seen[g]; ok || ag.outbound[g] > 0 {
+				continue
+			}
+			seen[g] = struct{}{}
+		}
+		selected = append(selected, nd)
+	}
+	if len(selected) == 0 {
+		return nds
+	}
+	return selected
+}
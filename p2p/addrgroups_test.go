@@ -0,0 +1,82 @@
+package p2p
+
+import (
+	inet "net"
+	"testing"
+
+	"github.com/spacemeshos/go-spacemesh/p2p/node"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddrGroups_Group(t *testing.T) {
+	ag, err := newAddrGroups(24, 64)
+	require.NoError(t, err)
+	require.Equal(t, "1.2.3.0/24", ag.group(inet.ParseIP("1.2.3.4")))
+	require.Equal(t, ag.group(inet.ParseIP("1.2.3.4")), ag.group(inet.ParseIP("1.2.3.200")))
+	require.NotEqual(t, ag.group(inet.ParseIP("1.2.3.4")), ag.group(inet.ParseIP("1.2.4.4")))
+	require.Equal(t, "2001:db8:1:2::/64", ag.group(inet.ParseIP("2001:db8:1:2::1")))
+	require.Equal(t, ag.group(inet.ParseIP("2001:db8:1:2::1")), ag.group(inet.ParseIP("2001:db8:1:2:ffff::1")))
+
+	// local addresses aren't grouped
+	require.Empty(t, ag.group(inet.ParseIP("127.0.0.1")))
+	require.Empty(t, ag.group(inet.ParseIP("10.0.0.1")))
+	require.Empty(t, ag.group(inet.ParseIP("192.168.1.1")))
+	require.Empty(t, ag.group(inet.ParseIP("fe80::1")))
+	require.Empty(t, ag.group(inet.ParseIP("172.20.0.1")))
+	require.Empty(t, ag.group(inet.ParseIP("fd00::1")))
+	require.Empty(t, ag.group(nil))
+
+	ag, err = newAddrGroups(16, 0)
+	require.NoError(t, err)
+	require.Equal(t, "1.2.0.0/16", ag.group(inet.ParseIP("1.2.3.4")))
+	require.Empty(t, ag.group(inet.ParseIP("2001:db8:1:2::1")))
+
+	_, err = newAddrGroups(33, 64)
+	require.EqualError(t, err, "invalid ipv4 group prefix length 33")
+	_, err = newAddrGroups(24, -1)
+	require.EqualError(t, err, "invalid ipv6 group prefix length -1")
+}
+
+func TestAddrGroups_Counts(t *testing.T) {
+	ag, err := newAddrGroups(24, 64)
+	require.NoError(t, err)
+	ip := inet.ParseIP("1.2.3.4")
+	p1 := node.GenerateRandomNodeData().PublicKey()
+	p2 := node.GenerateRandomNodeData().PublicKey()
+	p3 := node.GenerateRandomNodeData().PublicKey()
+
+	ag.add(p1, ip, true)
+	ag.add(p2, inet.ParseIP("1.2.3.5"), true)
+	ag.add(p3, ip, false)
+	require.Equal(t, 2, ag.inboundCount(inet.ParseIP("1.2.3.100")))
+	require.Equal(t, 0, ag.inboundCount(inet.ParseIP("1.2.4.4")))
+
+	// adding a peer again doesn't count it twice
+	ag.add(p1, ip, true)
+	require.Equal(t, 2, ag.inboundCount(ip))
+
+	ag.remove(p1)
+	ag.remove(p1)
+	require.Equal(t, 1, ag.inboundCount(ip))
+	ag.remove(p2)
+	require.Equal(t, 0, ag.inboundCount(ip))
+	require.Empty(t, ag.inbound)
+	require.Len(t, ag.outbound, 1)
+}
+
+func TestAddrGroups_Diverse(t *testing.T) {
+	ag, err := newAddrGroups(24, 64)
+	require.NoError(t, err)
+	nds := node.GenerateRandomNodesData(5)
+	nds[0].IP = inet.ParseIP("1.2.3.4")
+	nds[1].IP = inet.ParseIP("1.2.3.5")
+	nds[2].IP = inet.ParseIP("5.6.7.8")
+	nds[3].IP = inet.ParseIP("9.9.9.9")
+	// nds[4] is local
+
+	ag.add(node.GenerateRandomNodeData().PublicKey(), inet.ParseIP("9.9.9.1"), false)
+	require.Equal(t, []*node.Info{nds[0], nds[2], nds[4]}, ag.diverse(nds))
+
+	// candidates are all returned if none is in a new group
+	require.Equal(t, nds[3:4], ag.diverse(nds[3:4]))
+}
@@ -0,0 +1,212 @@
+package p2p
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	inet "net"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/p2p/p2pcrypto"
+)
+
+const banListFileName = "banlist.json"
+
+// Ban is an entry of the banlist, it bans either a peer ID or an IP address
+type Ban struct {
+	Peer    p2pcrypto.PublicKey // nil if an IP address is banned
+	IP      inet.IP             // nil if a peer ID is banned
+	Created time.Time
+	Expires time.Time // zero if the ban doesn't expire
+}
+
+func (b Ban) key() string {
+	if b.Peer != nil {
+		return b.Peer.String()
+	}
+	return b.IP.String()
+}
+
+func (b Ban) expired(now time.Time) bool {
+	return !b.Expires.IsZero() && !now.Before(b.Expires)
+}
+
+type serializedBan struct {
+	Peer    string `json:",omitempty"` // base58
+	IP      string `json:",omitempty"`
+	Created int64
+	Expires int64 // 0 if the ban doesn't expire
+}
+
+// banList holds the banned peer IDs and IP addresses. It's written to its file after every change, so bans survive
+// restarts. Expired bans are dropped lazily.
+type banList struct {
+	mu     sync.Mutex
+	path   string // the banlist isn't persisted if it's empty
+	bans   map[string]Ban
+	logger log.Log
+}
+
+// newBanList creates a banlist persisted to path, and loads the bans that were persisted there. A missing or malformed
+// file results in an empty banlist.
+func newBanList(path string, logger log.Log) *banList {
+	bl := &banList{path: path, bans: make(map[string]Ban), logger: logger}
+	if path == "" {
+		return bl
+	}
+	if err := bl.load(); err != nil {
+		logger.Error("failed to load banlist from %v: %v", path, err)
+	}
+	return bl
+}
+
+func (bl *banList) load() error {
+	data, err := ioutil.ReadFile(bl.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var serialized []serializedBan
+	if err := json.Unmarshal(data, &serialized); err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, sb := range serialized {
+		ban := Ban{Created: time.Unix(sb.Created, 0)}
+		if sb.Expires != 0 {
+			ban.Expires = time.Unix(sb.Expires, 0)
+		}
+		if sb.Peer != "" {
+			if ban.Peer, err = p2pcrypto.NewPublicKeyFromBase58(sb.Peer); err != nil {
+				return fmt.Errorf("invalid peer id %v: %v", sb.Peer, err)
+			}
+		} else if ban.IP = inet.ParseIP(sb.IP); ban.IP == nil {
+			return fmt.Errorf("invalid ip address %q", sb.IP)
+		}
+		if !ban.expired(now) {
+			bl.bans[ban.key()] = ban
+		}
+	}
+	bl.logger.Info("loaded %d bans from %v", len(bl.bans), bl.path)
+	return nil
+}
+
+// save writes the banlist to its file, it must be called with the mutex held
+func (bl *banList) save() error {
+	if bl.path == "" {
+		return nil
+	}
+	serialized := make([]serializedBan, 0, len(bl.bans))
+	for _, ban := range bl.sorted() {
+		sb := serializedBan{Created: ban.Created.Unix()}
+		if !ban.Expires.IsZero() {
+			sb.Expires = ban.Expires.Unix()
+		}
+		if ban.Peer != nil {
+			sb.Peer = ban.Peer.String()
+		} else {
+			sb.IP = ban.IP.String()
+		}
+		serialized = append(serialized, sb)
+	}
+	data, err := json.MarshalIndent(serialized, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(bl.path), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(bl.path, data, 0600)
+}
+
+// add bans the peer or IP address of ban, replacing an existing ban of it
+func (bl *banList) add(ban Ban) error {
+	bl.mu.Lock()
+	defer bl.mu.Unlock()
+	bl.bans[ban.key()] = ban
+	return bl.save()
+}
+
+// remove lifts the ban with the given key, it returns false if there was no such ban
+func (bl *banList) remove(key string) (bool, error) {
+	bl.mu.Lock()
+	defer bl.mu.Unlock()
+	ban, ok := bl.bans[key]
+	if !ok {
+		return false, nil
+	}
+	delete(bl.bans, key)
+	return !ban.expired(time.Now()), bl.save()
+}
+
+// banned returns whether the peer or the IP address is banned, ip may be nil if it's unknown
+func (bl *banList) banned(peer p2pcrypto.PublicKey, ip inet.IP) bool {
+	bl.mu.Lock()
+	defer bl.mu.Unlock()
+	if len(bl.bans) == 0 {
+		return false
+	}
+	keys := []string{peer.String()}
+	if ip != nil {
+		keys = append(keys, ip.String())
+	}
+	now := time.Now()
+	for _, key := range keys {
+		if ban, ok := bl.bans[key]; ok {
+			if !ban.expired(now) {
+				return true
+			}
+			delete(bl.bans, key)
+		}
+	}
+	return false
+}
+
+// list returns the bans that didn't expire, the oldest first
+func (bl *banList) list() []Ban {
+	bl.mu.Lock()
+	defer bl.mu.Unlock()
+	now := time.Now()
+	for key, ban := range bl.bans {
+		if ban.expired(now) {
+			delete(bl.bans, key)
+		}
+	}
+	return bl.sorted()
+}
+
+func (bl *banList) sorted() []Ban {
+	res := make([]Ban, 0, len(bl.bans))
+	for _, ban := range bl.bans {
+		res = append(res, ban)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if !res[i].Created.Equal(res[j].Created) {
+			return res[i].Created.Before(res[j].Created)
+		}
+		return res[i].key() < res[j].key()
+	})
+	return res
+}
+
+// addrIP returns the IP address of a network address, or nil if it doesn't have one
+func addrIP(addr inet.Addr) inet.IP {
+	if addr == nil {
+		return nil
+	}
+	if tcp, ok := addr.(*inet.TCPAddr); ok {
+		return tcp.IP
+	}
+	host, _, err := inet.SplitHostPort(addr.String())
+	if err != nil {
+		return nil
+	}
+	return inet.ParseIP(host)
+}
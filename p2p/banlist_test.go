@@ -0,0 +1,93 @@
+package p2p
+
+import (
+	"io/ioutil"
+	inet "net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/p2p/node"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBanList(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "banlist")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+	path := filepath.Join(tempDir, "p2p", banListFileName)
+
+	peer := node.GenerateRandomNodeData().PublicKey()
+	other := node.GenerateRandomNodeData().PublicKey()
+	ip := inet.ParseIP("1.2.3.4")
+
+	bl := newBanList(path, log.NewDefault(t.Name()))
+	require.False(t, bl.banned(peer, ip))
+	require.NoError(t, bl.add(newBan(peer, nil, 0)))
+	require.NoError(t, bl.add(newBan(nil, ip, time.Hour)))
+	require.True(t, bl.banned(peer, nil))
+	require.True(t, bl.banned(other, ip))
+	require.True(t, bl.banned(other, inet.ParseIP("::ffff:1.2.3.4")))
+	require.False(t, bl.banned(other, inet.ParseIP("1.2.3.5")))
+
+	// the bans are persisted
+	loaded := newBanList(path, log.NewDefault(t.Name()))
+	bans := loaded.list()
+	require.Len(t, bans, 2)
+	// bans created in the same second are ordered by key once loaded
+	if bans[0].Peer == nil {
+		bans[0], bans[1] = bans[1], bans[0]
+	}
+	require.Equal(t, peer.String(), bans[0].Peer.String())
+	require.True(t, bans[0].Expires.IsZero())
+	require.True(t, ip.Equal(bans[1].IP))
+	require.Equal(t, bl.list()[1].Expires.Unix(), bans[1].Expires.Unix())
+
+	found, err := loaded.remove(peer.String())
+	require.NoError(t, err)
+	require.True(t, found)
+	found, err = loaded.remove(peer.String())
+	require.NoError(t, err)
+	require.False(t, found)
+	require.False(t, loaded.banned(peer, nil))
+	require.Len(t, newBanList(path, log.NewDefault(t.Name())).list(), 1)
+}
+
+func TestBanList_Expiry(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "banlist")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+	path := filepath.Join(tempDir, banListFileName)
+
+	peer := node.GenerateRandomNodeData().PublicKey()
+	bl := newBanList(path, log.NewDefault(t.Name()))
+	expired := newBan(peer, nil, time.Hour)
+	expired.Expires = time.Now().Add(-time.Second)
+	require.NoError(t, bl.add(expired))
+	require.False(t, bl.banned(peer, nil))
+	require.Empty(t, bl.list())
+
+	// expired bans aren't loaded
+	require.NoError(t, bl.add(expired))
+	require.Empty(t, newBanList(path, log.NewDefault(t.Name())).list())
+}
+
+func TestBanList_NotPersisted(t *testing.T) {
+	bl := newBanList("", log.NewDefault(t.Name()))
+	ip := inet.ParseIP("1.2.3.4")
+	require.NoError(t, bl.add(newBan(nil, ip, 0)))
+	require.True(t, bl.banned(node.GenerateRandomNodeData().PublicKey(), ip))
+}
+
+func TestBanList_Malformed(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "banlist")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+	path := filepath.Join(tempDir, banListFileName)
+	require.NoError(t, ioutil.WriteFile(path, []byte("not json"), 0600))
+
+	bl := newBanList(path, log.NewDefault(t.Name()))
+	require.Empty(t, bl.list())
+}
@@ -0,0 +1,118 @@
+package p2p
+
+import (
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/p2p/net"
+	"github.com/spacemeshos/go-spacemesh/p2p/p2pcrypto"
+	"github.com/spacemeshos/go-spacemesh/p2p/service"
+)
+
+// capabilitiesProtocol is the direct protocol the accepting side of a connection answers the handshake of the dialing
+// side with, only the dialing side sends a handshake.
+const capabilitiesProtocol = "/p2p/1.0/capabilities"
+
+// capabilitiesMessage is the answer to a handshake, it's only sent to nodes that sent a protocol version
+type capabilitiesMessage struct {
+	ProtocolVersion uint32
+	Capabilities    []string
+}
+
+// peerCapabilities are the p2p protocol version and the optional features a peer supports
+type peerCapabilities struct {
+	version      uint32
+	capabilities map[string]struct{}
+}
+
+// AddCapability advertises an optional feature, e.g. a new message format, to peers. Protocols check if a peer
+// supports a feature with PeerSupports before using it, and fall back to the old behavior otherwise. Capabilities
+// should be added before the Switch is started.
+func (s *Switch) AddCapability(capability string) {
+	s.network.AddCapability(capability)
+}
+
+// PeerProtocolVersion returns the p2p protocol version negotiated with a peer, the lower of the versions of the peer
+// and this node. It's 0 for peers that don't negotiate a version, and peers that aren't connected.
+func (s *Switch) PeerProtocolVersion(peer p2pcrypto.PublicKey) uint32 {
+	s.peerCapsMutex.RLock()
+	defer s.peerCapsMutex.RUnlock()
+	caps, ok := s.peerCaps[peer.String()]
+	if !ok {
+		return 0
+	}
+	if caps.version < net.ProtocolVersion {
+		return caps.version
+	}
+	return net.ProtocolVersion
+}
+
+// PeerSupports returns true if both this node and the peer support an optional feature.
+func (s *Switch) PeerSupports(peer p2pcrypto.PublicKey, capability string) bool {
+	supported := false
+	for _, c := range s.network.Capabilities() {
+		if c == capability {
+			supported = true
+			break
+		}
+	}
+	return supported && s.peerAdvertises(peer, capability)
+}
+
+// peerAdvertises returns true if the peer advertised the capability, whether this node supports it or not
+func (s *Switch) peerAdvertises(peer p2pcrypto.PublicKey, capability string) bool {
+	s.peerCapsMutex.RLock()
+	defer s.peerCapsMutex.RUnlock()
+	_, ok := s.peerCaps[peer.String()].capabilities[capability]
+	return ok
+}
+
+func (s *Switch) setPeerCapabilities(peer p2pcrypto.PublicKey, version uint32, capabilities []string) {
+	caps := peerCapabilities{version: version, capabilities: make(map[string]struct{}, len(capabilities))}
+	for _, c := range capabilities {
+		caps.capabilities[c] = struct{}{}
+		if port, ok := net.ParseQUICCapability(c); ok {
+			s.network.SetPeerQUICPort(peer, port)
+		}
+	}
+	s.peerCapsMutex.Lock()
+	s.peerCaps[peer.String()] = caps
+	s.peerCapsMutex.Unlock()
+}
+
+// acceptCapabilities records the capabilities a peer sent in its handshake, and answers with ours. Peers that don't
+// send a protocol version don't expect an answer.
+func (s *Switch) acceptCapabilities(nce net.NewConnectionEvent) {
+	peer := nce.Node.PublicKey()
+	if nce.ProtocolVersion == 0 {
+		return
+	}
+	s.setPeerCapabilities(peer, nce.ProtocolVersion, nce.Capabilities)
+	payload, err := types.InterfaceToBytes(&capabilitiesMessage{ProtocolVersion: net.ProtocolVersion, Capabilities: s.network.Capabilities()})
+	if err != nil {
+		s.logger.Error("failed to encode capabilities, err: %v", err)
+		return
+	}
+	// answered on the connection of the handshake, sending may block until the connection is processing messages
+	go func() {
+		if err := s.sendToConnection(nce.Conn, capabilitiesProtocol, service.DataBytes{Payload: payload}); err != nil {
+			s.logger.Warning("failed to send capabilities to %v, err: %v", peer, err)
+		}
+	}()
+}
+
+// capabilitiesLoop records the capabilities peers we dialed answer our handshakes with.
+func (s *Switch) capabilitiesLoop(messages chan service.DirectMessage) {
+	for {
+		select {
+		case msg := <-messages:
+			caps := &capabilitiesMessage{}
+			if err := types.BytesToInterface(msg.Bytes(), caps); err != nil {
+				s.logger.Warning("invalid capabilities from %v, err: %v", msg.Sender(), err)
+				s.ReportPeer(msg.Sender(), service.InvalidMessage)
+				continue
+			}
+			s.setPeerCapabilities(msg.Sender(), caps.ProtocolVersion, caps.Capabilities)
+		case <-s.shutdown:
+			return
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package p2p
+
+import (
+	"context"
+	inet "net"
+	"testing"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/p2p/config"
+	"github.com/spacemeshos/go-spacemesh/p2p/net"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSwarm_NegotiateCapabilities(t *testing.T) {
+	p1 := p2pTestNoStart(t, configWithPort(0))
+	p2 := p2pTestNoStart(t, configWithPort(0))
+	p1.AddCapability("compact-blocks")
+	p1.AddCapability("tx-batches")
+	p2.AddCapability("tx-batches")
+
+	require.NoError(t, p1.Start())
+	require.NoError(t, p2.Start())
+	defer p1.Shutdown()
+	defer p2.Shutdown()
+
+	_, err := p2.cPool.GetConnection(p1.network.LocalAddr(), p1.lNode.PublicKey())
+	require.NoError(t, err)
+
+	// the accepting side learns the capabilities from the handshake, the dialing side from the answer
+	deadline := time.Now().Add(5 * time.Second)
+	for p1.PeerProtocolVersion(p2.lNode.PublicKey()) != net.ProtocolVersion ||
+		p2.PeerProtocolVersion(p1.lNode.PublicKey()) != net.ProtocolVersion {
+		require.True(t, time.Now().Before(deadline), "capabilities weren't exchanged")
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.True(t, p1.PeerSupports(p2.lNode.PublicKey(), "tx-batches"))
+	require.True(t, p2.PeerSupports(p1.lNode.PublicKey(), "tx-batches"))
+	require.False(t, p1.PeerSupports(p2.lNode.PublicKey(), "compact-blocks"))
+	require.False(t, p2.PeerSupports(p1.lNode.PublicKey(), "compact-blocks"))
+
+	p1.Disconnect(p2.lNode.PublicKey())
+	require.Equal(t, uint32(0), p1.PeerProtocolVersion(p2.lNode.PublicKey()))
+	require.False(t, p1.PeerSupports(p2.lNode.PublicKey(), "tx-batches"))
+}
+
+func TestSwarm_QUIC(t *testing.T) {
+	quicConfig := func() config.Config {
+		cfg := configWithPort(0)
+		cfg.Transport = config.TransportQUIC
+		cfg.QUICPort = 0
+		return cfg
+	}
+	p1 := p2pTestInstance(t, quicConfig())
+	defer p1.Shutdown()
+	p2 := p2pTestInstance(t, quicConfig())
+	defer p2.Shutdown()
+
+	// the first connection is over TCP, the peers learn each other's QUIC port from the capabilities
+	conn, err := p2.cPool.GetConnection(p1.network.LocalAddr(), p1.lNode.PublicKey())
+	require.NoError(t, err)
+	require.IsType(t, &inet.TCPAddr{}, conn.RemoteAddr())
+	waitForCapabilities(t, p1, p2)
+
+	p2.cPool.CloseConnection(p1.lNode.PublicKey())
+	conn, err = p2.cPool.GetConnection(p1.network.LocalAddr(), p1.lNode.PublicKey())
+	require.NoError(t, err)
+	// the address of the QUIC connection is the QUIC port of the peer
+	require.IsType(t, &inet.UDPAddr{}, conn.RemoteAddr())
+	require.Equal(t, p1.network.QUICAddr().(*inet.UDPAddr).Port, conn.RemoteAddr().(*inet.UDPAddr).Port)
+}
+
+func TestSwarm_UnknownTransport(t *testing.T) {
+	cfg := configWithPort(0)
+	cfg.Transport = "sctp"
+	_, err := newSwarm(context.TODO(), cfg, log.NewDefault(t.Name()), "")
+	require.Error(t, err)
+}
@@ -0,0 +1,53 @@
+package p2p
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/golang/snappy"
+)
+
+// compressionCapability is advertised by nodes that accept compressed messages
+const compressionCapability = "compression/snappy"
+
+// compressedMessageMarker prefixes compressed messages. Encoded protocol messages never start with it, they start with
+// the presence flag of their metadata, 0 or 1.
+const compressedMessageMarker byte = 0xff
+
+// maxDecompressedSize bounds the size of decompressed messages when the message size is unlimited
+const maxDecompressedSize = 64 << 20
+
+// compressMessage compresses an encoded protocol message, it returns the message as is if compressing it doesn't
+// make it smaller
+func compressMessage(data []byte) []byte {
+	compressed := make([]byte, 1+snappy.MaxEncodedLen(len(data)))
+	compressed[0] = compressedMessageMarker
+	compressed = compressed[:1+len(snappy.Encode(compressed[1:], data))]
+	if len(compressed) >= len(data) {
+		return data
+	}
+	return compressed
+}
+
+// decompressMessage returns the encoded protocol message of a message that may be compressed. Messages that would
+// decompress beyond limit bytes are refused, a limit of 0 refuses messages beyond maxDecompressedSize.
+func decompressMessage(data []byte, limit int) ([]byte, error) {
+	if len(data) == 0 || data[0] != compressedMessageMarker {
+		return data, nil
+	}
+	if limit <= 0 {
+		limit = maxDecompressedSize
+	}
+	size, err := snappy.DecodedLen(data[1:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid compressed message: %v", err)
+	}
+	if size > limit {
+		return nil, errors.New("compressed message is too large")
+	}
+	decompressed, err := snappy.Decode(nil, data[1:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid compressed message: %v", err)
+	}
+	return decompressed, nil
+}
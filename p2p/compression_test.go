@@ -0,0 +1,31 @@
+package p2p
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompressMessage(t *testing.T) {
+	data := bytes.Repeat([]byte("block"), 1000)
+	compressed := compressMessage(data)
+	require.Equal(t, compressedMessageMarker, compressed[0])
+	require.Less(t, len(compressed), len(data))
+
+	decompressed, err := decompressMessage(compressed, 0)
+	require.NoError(t, err)
+	require.Equal(t, data, decompressed)
+
+	// messages that don't compress are sent as is
+	incompressible := []byte{0, 0, 0, 1, 2, 3}
+	require.Equal(t, incompressible, compressMessage(incompressible))
+	decompressed, err = decompressMessage(incompressible, 0)
+	require.NoError(t, err)
+	require.Equal(t, incompressible, decompressed)
+
+	_, err = decompressMessage(compressed, len(data)-1)
+	require.EqualError(t, err, "compressed message is too large")
+	_, err = decompressMessage([]byte{compressedMessageMarker, 0xff, 0xff}, 0)
+	require.Error(t, err)
+}
@@ -2,6 +2,8 @@
 package config
 
 import (
+	"encoding/hex"
+	"fmt"
 	"time"
 
 	"github.com/spacemeshos/go-spacemesh/log"
@@ -20,6 +22,16 @@ const (
 	defaultTCPPort = 7513
 	// defaultTCPInterface is the inet interface that P2P listens on by default
 	defaultTCPInterface = "0.0.0.0"
+	// defaultQUICPort is the udp port that P2P accepts QUIC connections on by default
+	defaultQUICPort = 7514
+)
+
+// Transport values
+const (
+	// TransportTCP connects to peers over TCP only
+	TransportTCP = "tcp"
+	// TransportQUIC also accepts QUIC connections, and dials peers that advertise QUIC over QUIC, falling back to TCP
+	TransportQUIC = "quic"
 )
 
 // Values specifies default values for node config params.
@@ -44,18 +56,73 @@ type Config struct {
 	TCPPort               int           `mapstructure:"tcp-port"`
 	TCPInterface          string        `mapstructure:"tcp-interface"`
 	AcquirePort           bool          `mapstructure:"acquire-port"`
+	NATPMP                bool          `mapstructure:"nat-pmp"`
+	Proxy                 string        `mapstructure:"proxy"`
 	NodeID                string        `mapstructure:"node-id"`
 	DialTimeout           time.Duration `mapstructure:"dial-timeout"`
 	ConnKeepAlive         time.Duration `mapstructure:"conn-keepalive"`
 	NetworkID             int8          `mapstructure:"network-id"`
+	NetworkKey            string        `mapstructure:"network-key"` // hex pre-shared key of a private network, see NetworkKeyBytes
+	GenesisID             []byte        `mapstructure:"-"`           // identifies the genesis of the network, derived from the genesis config
 	ResponseTimeout       time.Duration `mapstructure:"response-timeout"`
 	SessionTimeout        time.Duration `mapstructure:"session-timeout"`
 	MaxPendingConnections int           `mapstructure:"max-pending-connections"`
 	OutboundPeersTarget   int           `mapstructure:"outbound-target"`
 	MaxInboundPeers       int           `mapstructure:"max-inbound"`
+	MaxOutboundPeers      int           `mapstructure:"max-outbound"`
+	ReservedPeerSlots     int           `mapstructure:"reserved-peer-slots"` // slots of the limits only static and protected peers use
+	SendRate              int           `mapstructure:"send-rate"`           // bytes per second over all connections, 0 for unlimited
+	RecvRate              int           `mapstructure:"recv-rate"`           // bytes per second over all connections, 0 for unlimited
+	PeerSendRate          int           `mapstructure:"peer-send-rate"`      // bytes per second to each peer, 0 for unlimited
+	PeerRecvRate          int           `mapstructure:"peer-recv-rate"`      // bytes per second from each peer, 0 for unlimited
 	SwarmConfig           SwarmConfig   `mapstructure:"swarm"`
 	BufferSize            int           `mapstructure:"buffer-size"`
 	MsgSizeLimit          int           `mapstructure:"msg-size-limit"` // in bytes
+	// MaxInboundPerGroup limits the inbound peers of each address group, the IPv4 and IPv6 subnets of the prefix
+	// lengths IPv4GroupPrefix and IPv6GroupPrefix, 0 for unlimited. Outbound peers are dialed from different groups
+	// when possible. Loopback and private addresses aren't grouped.
+	MaxInboundPerGroup int `mapstructure:"max-inbound-per-group"`
+	IPv4GroupPrefix    int `mapstructure:"ipv4-group-prefix"` // 0 to not group IPv4 addresses
+	IPv6GroupPrefix    int `mapstructure:"ipv6-group-prefix"` // 0 to not group IPv6 addresses
+	// CompressThreshold is the size in bytes from which messages, e.g. blocks, ATXs and sync responses, are compressed
+	// for peers that negotiated compression in the handshake, 0 to not compress messages
+	CompressThreshold int `mapstructure:"compress-threshold"`
+	// HolePunch lets NATed peers connect directly: connections are dialed from the listening port, and peers that
+	// can't be dialed are connected to at the same time as they connect to the node, coordinated by a relay peer.
+	HolePunch bool `mapstructure:"hole-punch"`
+	// Relay coordinates hole punching between the peers of the node, for nodes that peers can dial directly
+	Relay bool `mapstructure:"relay"`
+	// Transport is TransportTCP or TransportQUIC. With QUIC, connections carry each message on its own stream, so a
+	// lost packet only delays the message it belongs to, and reconnecting to a peer sends the handshake in the first
+	// packet (0-RTT). QUIC connections are accepted on QUICPort, on the TCP interface. It isn't used with a proxy.
+	Transport string `mapstructure:"transport"`
+	QUICPort  int    `mapstructure:"quic-port"`
+}
+
+// ValidateTransport returns an error if the transport isn't supported
+func (cfg Config) ValidateTransport() error {
+	if cfg.Transport != TransportTCP && cfg.Transport != TransportQUIC {
+		return fmt.Errorf("unknown transport %q, use %q or %q", cfg.Transport, TransportTCP, TransportQUIC)
+	}
+	return nil
+}
+
+// NetworkKeySize is the size in bytes of the pre-shared key of private networks
+const NetworkKeySize = 32
+
+// NetworkKeyBytes returns the pre-shared key of the private network, nil if the network isn't private
+func (cfg Config) NetworkKeyBytes() ([]byte, error) {
+	if cfg.NetworkKey == "" {
+		return nil, nil
+	}
+	key, err := hex.DecodeString(cfg.NetworkKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid network key: %v", err)
+	}
+	if len(key) != NetworkKeySize {
+		return nil, fmt.Errorf("invalid network key size (got %d instead of %d bytes)", len(key), NetworkKeySize)
+	}
+	return key, nil
 }
 
 // SwarmConfig specifies swarm config params.
@@ -67,6 +134,39 @@ type SwarmConfig struct {
 	RandomConnections      int      `mapstructure:"randcon"`
 	BootstrapNodes         []string `mapstructure:"bootnodes"`
 	PeersFile              string   `mapstructure:"peers-file"`
+	// DNSSeeds are DNS names whose TXT records are bootstrap nodes, in the format of BootstrapNodes. They're resolved
+	// before bootstrapping and every DNSSeedRefresh, so the bootstrap nodes can change without editing configs.
+	DNSSeeds       []string      `mapstructure:"dns-seeds"`
+	DNSSeedRefresh time.Duration `mapstructure:"dns-seed-refresh"`
+	// GossipCacheSize is the number of seen gossip messages remembered so they aren't relayed again, and
+	// GossipCacheTTL is how long they're remembered, 0 for as long as there's room. A cache that's too small causes
+	// rebroadcast storms on large networks, one that remembers messages too long drops messages that are resent.
+	GossipCacheSize int           `mapstructure:"gossip-cache-size"`
+	GossipCacheTTL  time.Duration `mapstructure:"gossip-cache-ttl"`
+	// StaticPeers are nodes (in the format of BootstrapNodes) the node always keeps connected, redialing them when
+	// they disconnect. They count toward RandomConnections and are protected.
+	StaticPeers []string `mapstructure:"static-peers"`
+	// ProtectedPeers are IDs of peers, or nodes in the format of BootstrapNodes, that connection management never
+	// drops or refuses, e.g. when the node already has its maximal number of inbound peers
+	ProtectedPeers []string `mapstructure:"protected-peers"`
+	// PrivateMesh restricts the connectivity of the node to AllowedPeers and StaticPeers: connections and discovery
+	// messages of other peers are rejected, they aren't dialed, and the node doesn't bootstrap
+	PrivateMesh bool `mapstructure:"private-mesh"`
+	// AllowedPeers are IDs of peers, or nodes in the format of BootstrapNodes, that belong to the private mesh
+	AllowedPeers []string `mapstructure:"allowed-peers"`
+	// MDNS finds nodes on the local network with multicast DNS, so nodes of a LAN connect without bootstrap nodes.
+	// The node queries the network every MDNSInterval.
+	MDNS         bool          `mapstructure:"mdns"`
+	MDNSInterval time.Duration `mapstructure:"mdns-interval"`
+	// GossipFanout is the number of random peers gossip messages are propagated to, 0 for all peers. Relay nodes
+	// with many peers can propagate to fewer of them, small nodes keep propagating to all of their peers.
+	GossipFanout int `mapstructure:"gossip-fanout"`
+	// GossipForwarders is the number of gossip messages propagated at the same time
+	GossipForwarders int `mapstructure:"gossip-forwarders"`
+	// GossipValidators is the number of gossip messages of each protocol, e.g. transactions and ATXs, validated at
+	// the same time, and GossipBlockValidators is the number of gossip blocks validated at the same time
+	GossipValidators      int `mapstructure:"gossip-validators"`
+	GossipBlockValidators int `mapstructure:"gossip-block-validators"`
 }
 
 // DefaultConfig defines the default p2p configuration
@@ -81,23 +181,54 @@ func DefaultConfig() Config {
 		RandomConnections:      5,
 		BootstrapNodes:         []string{},   // these should be the spacemesh foundation bootstrap nodes
 		PeersFile:              "peers.json", // located under data-dir/<publickey>/<peer-file> not loaded or save if empty string is given.
+		DNSSeeds:               []string{},
+		DNSSeedRefresh:         time.Hour,
+		GossipCacheSize:        20000,
+		GossipCacheTTL:         time.Hour,
+		StaticPeers:            []string{},
+		ProtectedPeers:         []string{},
+		PrivateMesh:            false,
+		AllowedPeers:           []string{},
+		MDNS:                   false,
+		MDNSInterval:           10 * time.Second,
+		GossipFanout:           0,
+		GossipForwarders:       1,
+		GossipValidators:       1,
+		GossipBlockValidators:  4,
 	}
 
 	return Config{
 		TCPPort:               defaultTCPPort,
 		TCPInterface:          defaultTCPInterface,
 		AcquirePort:           true,
+		NATPMP:                true,
+		Proxy:                 "",
 		NodeID:                "",
 		DialTimeout:           duration("1m"),
 		ConnKeepAlive:         duration("48h"),
 		NetworkID:             TestNet,
+		NetworkKey:            "",
 		ResponseTimeout:       duration("60s"),
 		SessionTimeout:        duration("15s"),
 		MaxPendingConnections: 100,
 		OutboundPeersTarget:   10,
 		MaxInboundPeers:       100,
+		MaxOutboundPeers:      20,
+		ReservedPeerSlots:     0,
+		SendRate:              0,
+		RecvRate:              0,
+		PeerSendRate:          0,
+		PeerRecvRate:          0,
 		SwarmConfig:           SwarmConfigValues,
 		BufferSize:            10000,
 		MsgSizeLimit:          UnlimitedMsgSize,
+		MaxInboundPerGroup:    8,
+		IPv4GroupPrefix:       24,
+		IPv6GroupPrefix:       64,
+		CompressThreshold:     1024,
+		HolePunch:             true,
+		Relay:                 false,
+		Transport:             TransportTCP,
+		QUICPort:              defaultQUICPort,
 	}
 }
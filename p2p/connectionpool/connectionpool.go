@@ -70,6 +70,14 @@ func (cp *ConnectionPool) OnNewConnection(nce net.NewConnectionEvent) error {
 	return cp.handleNewConnection(nce.Conn.RemotePublicKey(), nce.Conn, net.Remote)
 }
 
+// OnDialedConnection handles connections the node dialed without the pool, e.g. through hole punching
+func (cp *ConnectionPool) OnDialedConnection(conn net.Connection) error {
+	if cp.isShuttingDown() {
+		return errors.New("shutting down")
+	}
+	return cp.handleNewConnection(conn.RemotePublicKey(), conn, net.Local)
+}
+
 // OnClosedConnection is an exported method used to handle new closing connections events
 func (cp *ConnectionPool) OnClosedConnection(cwe net.ConnectionWithErr) {
 	if cp.isShuttingDown() {
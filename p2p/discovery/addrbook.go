@@ -6,6 +6,7 @@ import (
 	"math/rand"
 	"net"
 	"path/filepath"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -76,6 +77,12 @@ const (
 	// getAddrPercent is the percentage of total addresses known that we
 	// will share with a call to AddressCache.
 	getAddrPercent = 23
+
+	// latencyWeight is the weight of a new latency sample in the moving average of the latency of an address.
+	latencyWeight = 0.25
+
+	// minRedialInterval is the time before an address is dialed again as one of the best addresses.
+	minRedialInterval = 10 * time.Minute
 )
 
 // addrBook provides a concurrency safe address manager for caching potential
@@ -335,10 +342,65 @@ func (a *addrBook) Good(addr p2pcrypto.PublicKey) {
 	ka.lastattempt = now
 	ka.lastSeen = now
 	ka.attempts = 0
+	ka.successes++
 
 	a.moveToTriedUnlocked(ka)
 }
 
+// Failed records a failed connection to the given address.
+func (a *addrBook) Failed(key p2pcrypto.PublicKey) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	if ka := a.lookup(key); ka != nil {
+		ka.failures++
+	}
+}
+
+// SetLatency records the time it took to connect to the given address.
+func (a *addrBook) SetLatency(key p2pcrypto.PublicKey, latency time.Duration) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	ka := a.lookup(key)
+	if ka == nil {
+		return
+	}
+	if ka.latency == 0 {
+		ka.latency = latency
+		return
+	}
+	ka.latency = time.Duration(latencyWeight*float64(latency) + (1-latencyWeight)*float64(ka.latency))
+}
+
+// BestAddresses returns up to n tried addresses that were connected to before, best quality first. Addresses that
+// were attempted recently are skipped, so callers that fail to connect move on to the next ones.
+func (a *addrBook) BestAddresses(n int) []*node.Info {
+	a.mtx.RLock()
+	defer a.mtx.RUnlock()
+
+	now := time.Now()
+	best := make([]*KnownAddress, 0, n)
+	for _, ka := range a.addrIndex {
+		if !ka.tried || ka.successes == 0 || now.Sub(ka.lastattempt) < minRedialInterval || ka.isBad() {
+			continue
+		}
+		best = append(best, ka)
+	}
+	sort.Slice(best, func(i, j int) bool {
+		return best[i].quality() > best[j].quality()
+	})
+	if len(best) > n {
+		best = best[:n]
+	}
+
+	addrs := make([]*node.Info, len(best))
+	for i, ka := range best {
+		addrs[i] = ka.na
+	}
+	return addrs
+}
+
 // moves a knownaddress to a tried bucket
 func (a *addrBook) moveToTriedUnlocked(ka *KnownAddress) {
 	// move to tried set, optionally evicting other addresses if neeed.
@@ -498,6 +560,45 @@ func (a *addrBook) getAddresses() []*node.Info {
 	return addrs
 }
 
+// AddressInfo is a snapshot of the state of an address in the address book.
+type AddressInfo struct {
+	Info        *node.Info
+	Tried       bool
+	Attempts    int
+	LastSeen    time.Time
+	LastAttempt time.Time
+	LastSuccess time.Time
+	Successes   int
+	Failures    int
+	Latency     time.Duration
+	// Score is the probability of the address to be selected when looking for new peers.
+	Score float64
+}
+
+// Addresses returns a snapshot of all the addresses in the address book.
+func (a *addrBook) Addresses() []AddressInfo {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	addrs := make([]AddressInfo, 0, len(a.addrIndex))
+	for _, ka := range a.addrIndex {
+		addrs = append(addrs, AddressInfo{
+			Info:        ka.na,
+			Tried:       ka.tried,
+			Attempts:    ka.attempts,
+			LastSeen:    ka.lastSeen,
+			LastAttempt: ka.lastattempt,
+			LastSuccess: ka.lastsuccess,
+			Successes:   ka.successes,
+			Failures:    ka.failures,
+			Latency:     ka.latency,
+			Score:       ka.chance(),
+		})
+	}
+
+	return addrs
+}
+
 // Start begins the core address handler which manages a pool of known
 // addresses, timeouts, and interval based writes.
 func (a *addrBook) Start() {
@@ -5,6 +5,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"testing"
+	"time"
 )
 
 func testAddrBook(name string) *addrBook {
@@ -150,3 +151,69 @@ func Test_LocalAddreses(t *testing.T) {
 	require.NotNil(t, nd)
 	require.Equal(t, nd.ID, addr3.ID)
 }
+
+func Test_Addresses(t *testing.T) {
+	n := testAddrBook(t.Name())
+	require.Empty(t, n.Addresses())
+
+	nodes := generateDiscNodes(3)
+	n.AddAddresses(nodes, n.localAddresses[0])
+	n.Good(nodes[0].PublicKey())
+	n.Attempt(nodes[1].PublicKey())
+
+	addrs := n.Addresses()
+	require.Len(t, addrs, 3)
+	byID := make(map[string]AddressInfo)
+	for _, a := range addrs {
+		byID[a.Info.String()] = a
+	}
+	good := byID[nodes[0].String()]
+	require.True(t, good.Tried)
+	require.False(t, good.LastSuccess.IsZero())
+	require.Equal(t, 0, good.Attempts)
+
+	attempted := byID[nodes[1].String()]
+	require.True(t, attempted.Tried)
+	require.True(t, attempted.LastSuccess.IsZero())
+	require.Equal(t, 1, attempted.Attempts)
+	require.True(t, attempted.Score < byID[nodes[2].String()].Score)
+}
+
+func Test_BestAddresses(t *testing.T) {
+	r := require.New(t)
+	n := testAddrBook(t.Name())
+
+	nodes := generateDiscNodes(4)
+	n.AddAddresses(nodes, n.localAddresses[0])
+	for _, nd := range nodes[:3] {
+		n.Good(nd.PublicKey())
+	}
+	n.Failed(nodes[1].PublicKey())
+	n.Failed(nodes[1].PublicKey())
+	n.SetLatency(nodes[1].PublicKey(), 100*time.Millisecond)
+	n.SetLatency(nodes[0].PublicKey(), 400*time.Millisecond)
+	n.SetLatency(nodes[0].PublicKey(), 800*time.Millisecond)
+	n.SetLatency(nodes[2].PublicKey(), 100*time.Millisecond)
+
+	addrs := n.Addresses()
+	byID := make(map[string]AddressInfo)
+	for _, a := range addrs {
+		byID[a.Info.String()] = a
+	}
+	r.Equal(1, byID[nodes[1].String()].Successes)
+	r.Equal(2, byID[nodes[1].String()].Failures)
+	r.Equal(500*time.Millisecond, byID[nodes[0].String()].Latency)
+
+	// addresses connected to in the last minutes aren't redialed
+	r.Empty(n.BestAddresses(10))
+
+	for _, nd := range nodes {
+		n.lookup(nd.PublicKey()).lastattempt = time.Now().Add(-time.Hour)
+	}
+	best := n.BestAddresses(10)
+	r.Len(best, 3)
+	r.Equal(nodes[2].ID, best[0].ID)
+	r.Equal(nodes[0].ID, best[1].ID)
+	r.Equal(nodes[1].ID, best[2].ID)
+	r.Len(n.BestAddresses(1), 1)
+}
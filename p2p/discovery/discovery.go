@@ -5,6 +5,9 @@ package discovery
 import (
 	"context"
 	"errors"
+	"net"
+	"sync"
+	"time"
 
 	"github.com/spacemeshos/go-spacemesh/log"
 	"github.com/spacemeshos/go-spacemesh/p2p/config"
@@ -30,6 +33,11 @@ type PeerStore interface {
 
 	Good(key p2pcrypto.PublicKey)
 	Attempt(key p2pcrypto.PublicKey)
+	Failed(key p2pcrypto.PublicKey)
+	SetLatency(key p2pcrypto.PublicKey, latency time.Duration)
+
+	Addresses() []AddressInfo
+	ExternalAddresses() []ExternalAddress
 }
 
 // Protocol is the API of node messages used to discover new nodes.
@@ -37,12 +45,15 @@ type Protocol interface {
 	Ping(p p2pcrypto.PublicKey) error
 	GetAddresses(server p2pcrypto.PublicKey) ([]*node.Info, error)
 	SetLocalAddresses(tcp, udp int)
+	ExternalAddresses() []ExternalAddress
 	Close()
 }
 
 type addressBook interface {
 	Good(key p2pcrypto.PublicKey)
 	Attempt(key p2pcrypto.PublicKey)
+	Failed(key p2pcrypto.PublicKey)
+	SetLatency(key p2pcrypto.PublicKey, latency time.Duration)
 
 	RemoveAddress(key p2pcrypto.PublicKey)
 	AddAddress(addr, srcAddr *node.Info)
@@ -51,8 +62,10 @@ type addressBook interface {
 	NeedNewAddresses() bool
 	Lookup(key p2pcrypto.PublicKey) (*node.Info, error)
 	AddressCache() []*node.Info
+	Addresses() []AddressInfo
 	NumAddresses() int
 	GetAddress() *KnownAddress
+	BestAddresses(n int) []*node.Info
 
 	AddLocalAddress(info *node.Info)
 	IsLocalAddress(info *node.Info) bool
@@ -63,6 +76,7 @@ type addressBook interface {
 
 type bootstrapper interface {
 	Bootstrap(ctx context.Context, minPeers int) error
+	setSeedNodes(nodes []*node.Info)
 }
 
 var (
@@ -82,6 +96,14 @@ type Discovery struct {
 	local        node.LocalNode
 	rt           addressBook
 	bootstrapper bootstrapper
+
+	resolver     txtResolver
+	shutdown     chan struct{}
+	shutdownOnce sync.Once
+
+	// finds nodes on the local network, nil unless MDNS is set in the config
+	mdnsOnce sync.Once
+	mdns     *mdns
 }
 
 // Size returns the size of addrBook.
@@ -99,6 +121,16 @@ func (d *Discovery) Attempt(key p2pcrypto.PublicKey) {
 	d.rt.Attempt(key)
 }
 
+// Failed records a failed connection to the node in the addrBook
+func (d *Discovery) Failed(key p2pcrypto.PublicKey) {
+	d.rt.Failed(key)
+}
+
+// SetLatency records the time it took to connect to the node in the addrBook
+func (d *Discovery) SetLatency(key p2pcrypto.PublicKey, latency time.Duration) {
+	d.rt.SetLatency(key, latency)
+}
+
 func (d *Discovery) refresh(ctx context.Context, peersToGet int) error {
 	err := d.bootstrapper.Bootstrap(ctx, peersToGet)
 	if err != nil {
@@ -108,19 +140,27 @@ func (d *Discovery) refresh(ctx context.Context, peersToGet int) error {
 	return nil
 }
 
-// SelectPeers asks routing table to randomly select a slice of nodes in size `qty`
+// SelectPeers asks routing table to randomly select a slice of nodes in size `qty`. Nodes that the node connected to
+// before, e.g. before a restart, are selected first.
 func (d *Discovery) SelectPeers(ctx context.Context, qty int) []*node.Info {
+	out := make([]*node.Info, 0, qty)
+	set := make(map[p2pcrypto.PublicKey]struct{})
+	for _, nd := range d.rt.BestAddresses(qty) {
+		out = append(out, nd)
+		set[nd.PublicKey()] = struct{}{}
+	}
+	if len(out) == qty {
+		return out
+	}
 
 	if d.rt.NeedNewAddresses() {
 		err := d.refresh(ctx, qty) // TODO: use ctx with timeout, check errors
 		if err == ErrBootAbort {
-			return nil
+			return out
 		}
 	}
 
-	out := make([]*node.Info, 0, qty)
-	set := make(map[p2pcrypto.PublicKey]struct{})
-	for i := 0; i < qty; i++ {
+	for i := len(out); i < qty; i++ {
 		add := d.rt.GetAddress()
 		if add == nil {
 			// addrbook is empty
@@ -154,6 +194,9 @@ func New(ln node.LocalNode, config config.SwarmConfig, service server.Service, p
 		logger: logger,
 		local:  ln,
 		rt:     newAddrBook(config, path, logger),
+
+		resolver: net.DefaultResolver,
+		shutdown: make(chan struct{}),
 	}
 
 	d.rt.Start()
@@ -175,11 +218,20 @@ func New(ln node.LocalNode, config config.SwarmConfig, service server.Service, p
 	//TODO: Return err if no bootstrap nodes were parsed.
 	d.bootstrapper = newRefresher(ln.PublicKey(), d.rt, d.disc, bn, logger)
 
+	if len(config.DNSSeeds) > 0 && config.DNSSeedRefresh > 0 {
+		go d.refreshSeeds(config.DNSSeedRefresh)
+	}
+
 	return d
 }
 
 // Shutdown stops the discovery service
 func (d *Discovery) Shutdown() {
+	d.shutdownOnce.Do(func() { close(d.shutdown) })
+	d.mdnsOnce.Do(func() {}) // mdns doesn't start after shutdown
+	if d.mdns != nil {
+		d.mdns.close()
+	}
 	d.rt.Stop()
 }
 
@@ -192,6 +244,27 @@ func (d *Discovery) IsLocalAddress(info *node.Info) bool {
 func (d *Discovery) SetLocalAddresses(tcp, udp int) {
 	d.disc.SetLocalAddresses(tcp, udp)
 	//TODO: lookup a protocol or just pass here our IP to the routing table
+	if d.config.MDNS {
+		// the node is announced once its ports are known
+		d.mdnsOnce.Do(func() {
+			m, err := startMDNS(d.local.PublicKey(), uint16(tcp), uint16(udp), d.rt, d.config.MDNSInterval, d.logger)
+			if err != nil {
+				d.logger.With().Error("failed to start mdns discovery", log.Err(err))
+				return
+			}
+			d.mdns = m
+		})
+	}
+}
+
+// Addresses returns a snapshot of the routing table.
+func (d *Discovery) Addresses() []AddressInfo {
+	return d.rt.Addresses()
+}
+
+// ExternalAddresses returns the addresses of the local node as reported by the peers that answered our pings.
+func (d *Discovery) ExternalAddresses() []ExternalAddress {
+	return d.disc.ExternalAddresses()
 }
 
 // Remove removes a record from the routing table
@@ -199,8 +272,15 @@ func (d *Discovery) Remove(key p2pcrypto.PublicKey) {
 	d.rt.RemoveAddress(key) // we don't care about address when we remove
 }
 
-// Bootstrap runs a refresh and tries to get a minimum number of nodes in the addrBook.
+// Bootstrap runs a refresh and tries to get a minimum number of nodes in the addrBook. The refresh is skipped if
+// the addrBook already knows enough nodes that the node connected to before, so a restarted node reconnects to them
+// instead of crawling the network from the bootstrap nodes.
 func (d *Discovery) Bootstrap(ctx context.Context) error {
 	d.logger.Debug("Starting node bootstrap")
+	if known := len(d.rt.BestAddresses(d.config.RandomConnections)); known > 0 && known >= d.config.RandomConnections {
+		d.logger.Info("Bootstrap: skipped, address book has %d previously connected peers", known)
+		return nil
+	}
+	d.resolveSeeds(ctx)
 	return d.refresh(ctx, d.config.RandomConnections)
 }
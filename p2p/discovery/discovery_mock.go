@@ -2,6 +2,7 @@ package discovery
 
 import (
 	"context"
+	"time"
 
 	"github.com/spacemeshos/go-spacemesh/p2p/node"
 	"github.com/spacemeshos/go-spacemesh/p2p/p2pcrypto"
@@ -20,9 +21,14 @@ type MockPeerStore struct {
 
 	IsLocalAddressFunc func(info *node.Info) bool
 
-	RemoveFunc  func(key p2pcrypto.PublicKey)
-	GoodFunc    func(key p2pcrypto.PublicKey)
-	AttemptFunc func(key p2pcrypto.PublicKey)
+	RemoveFunc     func(key p2pcrypto.PublicKey)
+	GoodFunc       func(key p2pcrypto.PublicKey)
+	AttemptFunc    func(key p2pcrypto.PublicKey)
+	FailedFunc     func(key p2pcrypto.PublicKey)
+	SetLatencyFunc func(key p2pcrypto.PublicKey, latency time.Duration)
+
+	AddressesFunc         func() []AddressInfo
+	ExternalAddressesFunc func() []ExternalAddress
 }
 
 // Remove mock
@@ -126,6 +132,36 @@ func (m *MockPeerStore) Attempt(key p2pcrypto.PublicKey) {
 	}
 }
 
+// Failed is a mock.
+func (m *MockPeerStore) Failed(key p2pcrypto.PublicKey) {
+	if m.FailedFunc != nil {
+		m.FailedFunc(key)
+	}
+}
+
+// SetLatency is a mock.
+func (m *MockPeerStore) SetLatency(key p2pcrypto.PublicKey, latency time.Duration) {
+	if m.SetLatencyFunc != nil {
+		m.SetLatencyFunc(key, latency)
+	}
+}
+
+// Addresses is a mock.
+func (m *MockPeerStore) Addresses() []AddressInfo {
+	if m.AddressesFunc != nil {
+		return m.AddressesFunc()
+	}
+	return nil
+}
+
+// ExternalAddresses is a mock.
+func (m *MockPeerStore) ExternalAddresses() []ExternalAddress {
+	if m.ExternalAddressesFunc != nil {
+		return m.ExternalAddressesFunc()
+	}
+	return nil
+}
+
 // mockAddrBook
 type mockAddrBook struct {
 	addAddressFunc func(n, src *node.Info)
@@ -137,9 +173,12 @@ type mockAddrBook struct {
 	GetAddressFunc func() *KnownAddress
 	GetAddressRes  *KnownAddress
 
+	BestAddressesFunc func(n int) []*node.Info
+
 	NeedNewAddressesFunc func() bool
 
 	AddressCacheFunc func() []*node.Info
+	AddressesFunc    func() []AddressInfo
 
 	GoodFunc    func(key p2pcrypto.PublicKey)
 	AttemptFunc func(key p2pcrypto.PublicKey)
@@ -185,6 +224,18 @@ func (m *mockAddrBook) Attempt(key p2pcrypto.PublicKey) {
 		m.AttemptFunc(key)
 	}
 }
+func (m *mockAddrBook) Failed(key p2pcrypto.PublicKey) {
+}
+func (m *mockAddrBook) SetLatency(key p2pcrypto.PublicKey, latency time.Duration) {
+}
+
+// BestAddresses mock
+func (m *mockAddrBook) BestAddresses(n int) []*node.Info {
+	if m.BestAddressesFunc != nil {
+		return m.BestAddressesFunc(n)
+	}
+	return nil
+}
 
 func (m *mockAddrBook) NeedNewAddresses() bool {
 	if m.NeedNewAddressesFunc != nil {
@@ -228,6 +279,14 @@ func (m *mockAddrBook) AddressCache() []*node.Info {
 	return nil
 }
 
+// Addresses mock
+func (m *mockAddrBook) Addresses() []AddressInfo {
+	if m.AddressesFunc != nil {
+		return m.AddressesFunc()
+	}
+	return nil
+}
+
 // Lookup mock
 func (m *mockAddrBook) Lookup(pubkey p2pcrypto.PublicKey) (*node.Info, error) {
 	if m.LookupFunc != nil {
@@ -255,6 +314,11 @@ func (m *mockAddrBook) NumAddresses() int {
 
 type refresherMock struct {
 	BootstrapFunc func(ctx context.Context, minPeers int) error
+	seedNodes     []*node.Info
+}
+
+func (r *refresherMock) setSeedNodes(nodes []*node.Info) {
+	r.seedNodes = nodes
 }
 
 func (r *refresherMock) Bootstrap(ctx context.Context, minPeers int) error {
@@ -283,3 +283,42 @@ func Test_Refresh(t *testing.T) {
 	require.Len(t, prz, 0)
 	require.Equal(t, requsted, 0)
 }
+
+func Test_SelectPeers_BestAddresses(t *testing.T) {
+	sim := service.NewSimulator()
+	bsnode, bsinfo := node.GenerateTestNode(t)
+	serv := sim.NewNodeFrom(bsinfo)
+
+	cfg := config.DefaultConfig().SwarmConfig
+	cfg.RandomConnections = 2
+	disc := New(bsnode, cfg, serv, "", log.NewDefault(""))
+	best := generateDiscNodes(2)
+	rt := &mockAddrBook{}
+	rt.BestAddressesFunc = func(n int) []*node.Info {
+		if n > len(best) {
+			return best
+		}
+		return best[:n]
+	}
+	rt.NeedNewAddressesFunc = func() bool {
+		return true
+	}
+	refreshed := 0
+	refresher := &refresherMock{}
+	refresher.BootstrapFunc = func(ctx context.Context, minPeers int) error {
+		refreshed++
+		return nil
+	}
+	disc.rt = rt
+	disc.bootstrapper = refresher
+
+	// peers connected to before are enough, so the network isn't crawled
+	require.NoError(t, disc.Bootstrap(context.TODO()))
+	require.Equal(t, 0, refreshed)
+	require.Equal(t, best, disc.SelectPeers(context.TODO(), 2))
+	require.Equal(t, 0, refreshed)
+
+	prz := disc.SelectPeers(context.TODO(), 3)
+	require.Equal(t, best, prz)
+	require.Equal(t, 1, refreshed)
+}
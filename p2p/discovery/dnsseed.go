@@ -0,0 +1,77 @@
+package discovery
+
+import (
+	"context"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/p2p/node"
+	"github.com/spacemeshos/go-spacemesh/p2p/p2pcrypto"
+)
+
+// dnsSeedTimeout bounds the time resolving all the DNS seeds takes
+const dnsSeedTimeout = 10 * time.Second
+
+// txtResolver resolves the TXT records of a DNS name, it's implemented by net.Resolver
+type txtResolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+// resolveSeeds returns the bootstrap nodes listed by the DNS seeds. Every TXT record of a seed is a node in the format
+// of the bootstrap nodes of the config. Seeds that fail to resolve and malformed records are skipped.
+func resolveSeeds(ctx context.Context, resolver txtResolver, seeds []string, logger log.Log) []*node.Info {
+	ctx, cancel := context.WithTimeout(ctx, dnsSeedTimeout)
+	defer cancel()
+
+	var nodes []*node.Info
+	seen := make(map[p2pcrypto.PublicKey]struct{})
+	for _, seed := range seeds {
+		records, err := resolver.LookupTXT(ctx, seed)
+		if err != nil {
+			logger.Warning("failed to resolve dns seed %v: %v", seed, err)
+			continue
+		}
+		for _, record := range records {
+			nd, err := node.ParseNode(record)
+			if err != nil || nd.IP == nil {
+				logger.Warning("dns seed %v returned invalid bootstrap node %v", seed, record)
+				continue
+			}
+			if _, ok := seen[nd.PublicKey()]; ok {
+				continue
+			}
+			seen[nd.PublicKey()] = struct{}{}
+			nodes = append(nodes, nd)
+		}
+	}
+	return nodes
+}
+
+// resolveSeeds updates the bootstrap nodes from the DNS seeds. The previous nodes are kept if none resolved.
+func (d *Discovery) resolveSeeds(ctx context.Context) {
+	if len(d.config.DNSSeeds) == 0 {
+		return
+	}
+	nodes := resolveSeeds(ctx, d.resolver, d.config.DNSSeeds, d.logger)
+	if len(nodes) == 0 {
+		d.logger.Warning("no bootstrap nodes resolved from dns seeds")
+		return
+	}
+	d.logger.Info("resolved %d bootstrap nodes from dns seeds", len(nodes))
+	d.bootstrapper.setSeedNodes(nodes)
+}
+
+// refreshSeeds resolves the DNS seeds every interval until the discovery shuts down, so changes of the bootstrap
+// infrastructure apply without restarting the node.
+func (d *Discovery) refreshSeeds(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-d.shutdown:
+			return
+		case <-ticker.C:
+			d.resolveSeeds(context.Background())
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/p2p/config"
+	"github.com/spacemeshos/go-spacemesh/p2p/node"
+	"github.com/spacemeshos/go-spacemesh/p2p/service"
+	"github.com/stretchr/testify/require"
+)
+
+type resolverMock struct {
+	mu      sync.Mutex
+	records map[string][]string
+	lookups int
+}
+
+func (r *resolverMock) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lookups++
+	records, ok := r.records[name]
+	if !ok {
+		return nil, errors.New("no such host")
+	}
+	return records, nil
+}
+
+func (r *resolverMock) set(name string, records ...string) {
+	r.mu.Lock()
+	r.records[name] = records
+	r.mu.Unlock()
+}
+
+func TestResolveSeeds(t *testing.T) {
+	nodes := generateDiscNodes(3)
+	resolver := &resolverMock{records: map[string][]string{
+		"seed1.example.com": {nodes[0].String(), nodes[1].String(), "not a node"},
+		"seed2.example.com": {nodes[1].String(), nodes[2].String(), "spacemesh://" + nodes[2].PublicKey().String()},
+	}}
+
+	resolved := resolveSeeds(context.TODO(), resolver, []string{"seed1.example.com", "missing.example.com", "seed2.example.com"}, log.NewDefault(t.Name()))
+	require.Len(t, resolved, 3)
+	for i, nd := range resolved {
+		require.Equal(t, nodes[i].PublicKey(), nd.PublicKey())
+		require.True(t, nodes[i].IP.Equal(nd.IP))
+		require.Equal(t, nodes[i].ProtocolPort, nd.ProtocolPort)
+	}
+	require.Equal(t, 3, resolver.lookups)
+}
+
+func TestRefresher_bootstrapNodes(t *testing.T) {
+	cfg := config.DefaultConfig()
+	boot := generateDiscNodes(2)
+	addrbk := newAddrBook(cfg.SwarmConfig, "", GetTestLogger("test.newRefresher.addrbook"))
+	ref := newRefresher(generateDiscNode().PublicKey(), addrbk, &mockDisc{}, boot, GetTestLogger("test.newRefresher"))
+	require.Equal(t, boot, ref.bootstrapNodes())
+
+	// seed nodes are added to the configured nodes, without duplicates
+	seeds := append(generateDiscNodes(1), boot[1])
+	ref.setSeedNodes(seeds)
+	require.Equal(t, append(boot, seeds[0]), ref.bootstrapNodes())
+}
+
+func TestDiscovery_DNSSeeds(t *testing.T) {
+	ln, ninfo := node.GenerateTestNode(t)
+	cfg := config.DefaultConfig()
+	cfg.SwarmConfig.DNSSeeds = []string{"seed.example.com"}
+	cfg.SwarmConfig.DNSSeedRefresh = 0 // refreshed explicitly below
+	sim := service.NewSimulator()
+	d := New(ln, cfg.SwarmConfig, sim.NewNodeFrom(ninfo), "", log.NewDefault(t.Name()))
+
+	nodes := generateDiscNodes(2)
+	resolver := &resolverMock{records: map[string][]string{"seed.example.com": {nodes[0].String()}}}
+	ref := &refresherMock{}
+	d.resolver = resolver
+	d.bootstrapper = ref
+
+	// seeds are resolved before bootstrapping
+	require.NoError(t, d.Bootstrap(context.TODO()))
+	require.Len(t, ref.seedNodes, 1)
+	require.Equal(t, nodes[0].PublicKey(), ref.seedNodes[0].PublicKey())
+
+	// the nodes are kept if the seeds stop resolving
+	resolver.set("seed.example.com")
+	require.NoError(t, d.Bootstrap(context.TODO()))
+	require.Len(t, ref.seedNodes, 1)
+
+	// the seeds are resolved periodically until the discovery shuts down
+	resolver.set("seed.example.com", nodes[1].String())
+	done := make(chan struct{})
+	go func() {
+		d.refreshSeeds(time.Millisecond)
+		close(done)
+	}()
+	require.Eventually(t, func() bool {
+		resolver.mu.Lock()
+		defer resolver.mu.Unlock()
+		return resolver.lookups > 3
+	}, time.Second, time.Millisecond)
+	d.Shutdown()
+	<-done
+	require.Equal(t, nodes[1].PublicKey(), ref.seedNodes[0].PublicKey())
+}
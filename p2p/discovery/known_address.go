@@ -17,6 +17,11 @@ type KnownAddress struct {
 	lastsuccess time.Time
 	tried       bool
 	refs        int // reference count of new buckets
+
+	// the connection history of the address, kept across restarts
+	successes int
+	failures  int
+	latency   time.Duration // moving average of the time it takes to connect
 }
 
 // NodeInfo returns the internal Info struct of this address.
@@ -55,6 +60,15 @@ func (ka *KnownAddress) chance() float64 {
 	return c
 }
 
+// quality ranks addresses that were connected to before, by the share of successful connections and the latency.
+func (ka *KnownAddress) quality() float64 {
+	q := float64(ka.successes+1) / float64(ka.successes+ka.failures+2)
+	if ka.latency > 0 {
+		q /= 1 + ka.latency.Seconds()
+	}
+	return q
+}
+
 // isBad returns true if the address in question has not been tried in the last
 // minute and meets one of the following criteria:
 // 1) It claims to be from the future
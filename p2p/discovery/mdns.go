@@ -0,0 +1,240 @@
+package discovery
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/p2p/node"
+	"github.com/spacemeshos/go-spacemesh/p2p/p2pcrypto"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// Nodes on the same LAN find each other with multicast DNS (RFC 6762): every node queries the PTR records of the
+// spacemesh service, and answers the queries of other nodes with the PTR and TXT records of its own instance. The
+// TXT record holds the ID and the ports of the node, and the address is the source address of the answer.
+const (
+	mdnsService = "_spacemesh._udp.local."
+	// mdnsTTL is the TTL in seconds of the records the node answers with
+	mdnsTTL = 120
+	// mdnsMaxMessage is the maximal size of mDNS messages, the size of a jumbo ethernet frame
+	mdnsMaxMessage = 9000
+)
+
+var mdnsGroup = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 5353}
+
+// mdns announces the node on the LAN and adds the nodes that it finds to the address book
+type mdns struct {
+	local    p2pcrypto.PublicKey
+	tcp, udp uint16
+	book     addressBook
+	conn     *net.UDPConn // receives the messages of the multicast group
+	send     *net.UDPConn // sends to the multicast group, the group address can't be the source of messages
+	logger   log.Log
+	shutdown chan struct{}
+}
+
+// startMDNS joins the mDNS multicast group and queries it every interval until closed
+func startMDNS(local p2pcrypto.PublicKey, tcp, udp uint16, book addressBook, interval time.Duration, logger log.Log) (*mdns, error) {
+	conn, err := net.ListenMulticastUDP("udp4", nil, mdnsGroup)
+	if err != nil {
+		return nil, err
+	}
+	send, err := net.ListenUDP("udp4", &net.UDPAddr{})
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	m := &mdns{
+		local:    local,
+		tcp:      tcp,
+		udp:      udp,
+		book:     book,
+		conn:     conn,
+		send:     send,
+		logger:   logger,
+		shutdown: make(chan struct{}),
+	}
+	go m.listen()
+	go m.queryLoop(interval)
+	return m, nil
+}
+
+func (m *mdns) close() {
+	close(m.shutdown)
+	m.conn.Close()
+	m.send.Close()
+}
+
+func (m *mdns) queryLoop(interval time.Duration) {
+	query, err := mdnsQuery()
+	if err != nil {
+		m.logger.Error("failed to build mdns query: %v", err)
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := m.send.WriteToUDP(query, mdnsGroup); err != nil {
+			m.logger.Warning("failed to send mdns query: %v", err)
+		}
+		select {
+		case <-m.shutdown:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *mdns) listen() {
+	buf := make([]byte, mdnsMaxMessage)
+	for {
+		size, from, err := m.conn.ReadFromUDP(buf)
+		if err != nil {
+			select {
+			case <-m.shutdown:
+			default:
+				m.logger.Warning("mdns listener stopped: %v", err)
+			}
+			return
+		}
+		msg := buf[:size]
+		if isMDNSQuery(msg) {
+			m.answer()
+			continue
+		}
+		for _, nd := range parseMDNSResponse(msg, from.IP) {
+			if nd.PublicKey() == m.local {
+				continue
+			}
+			m.logger.Debug("found %v on the local network", nd)
+			m.book.AddAddress(nd, nd)
+		}
+	}
+}
+
+func (m *mdns) answer() {
+	response, err := mdnsResponse(m.local, m.tcp, m.udp)
+	if err != nil {
+		m.logger.Error("failed to build mdns response: %v", err)
+		return
+	}
+	if _, err := m.send.WriteToUDP(response, mdnsGroup); err != nil {
+		m.logger.Warning("failed to send mdns response: %v", err)
+	}
+}
+
+func mdnsQuery() ([]byte, error) {
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{})
+	if err := b.StartQuestions(); err != nil {
+		return nil, err
+	}
+	if err := b.Question(dnsmessage.Question{
+		Name:  dnsmessage.MustNewName(mdnsService),
+		Type:  dnsmessage.TypePTR,
+		Class: dnsmessage.ClassINET,
+	}); err != nil {
+		return nil, err
+	}
+	return b.Finish()
+}
+
+func mdnsResponse(id p2pcrypto.PublicKey, tcp, udp uint16) ([]byte, error) {
+	service := dnsmessage.MustNewName(mdnsService)
+	instance, err := dnsmessage.NewName(id.String() + "." + mdnsService)
+	if err != nil {
+		return nil, err
+	}
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{Response: true, Authoritative: true})
+	if err := b.StartAnswers(); err != nil {
+		return nil, err
+	}
+	if err := b.PTRResource(dnsmessage.ResourceHeader{Name: service, Class: dnsmessage.ClassINET, TTL: mdnsTTL},
+		dnsmessage.PTRResource{PTR: instance}); err != nil {
+		return nil, err
+	}
+	txt := []string{"id=" + id.String(), "port=" + strconv.Itoa(int(tcp)), "disc=" + strconv.Itoa(int(udp))}
+	if err := b.TXTResource(dnsmessage.ResourceHeader{Name: instance, Class: dnsmessage.ClassINET, TTL: mdnsTTL},
+		dnsmessage.TXTResource{TXT: txt}); err != nil {
+		return nil, err
+	}
+	return b.Finish()
+}
+
+// isMDNSQuery returns true if the message is a query for the spacemesh service
+func isMDNSQuery(msg []byte) bool {
+	var p dnsmessage.Parser
+	header, err := p.Start(msg)
+	if err != nil || header.Response {
+		return false
+	}
+	questions, err := p.AllQuestions()
+	if err != nil {
+		return false
+	}
+	for _, q := range questions {
+		if strings.EqualFold(q.Name.String(), mdnsService) && (q.Type == dnsmessage.TypePTR || q.Type == dnsmessage.TypeALL) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseMDNSResponse returns the nodes in the TXT records of spacemesh instances in a response sent from ip.
+// Malformed records are skipped.
+func parseMDNSResponse(msg []byte, ip net.IP) []*node.Info {
+	var p dnsmessage.Parser
+	header, err := p.Start(msg)
+	if err != nil || !header.Response {
+		return nil
+	}
+	if err := p.SkipAllQuestions(); err != nil {
+		return nil
+	}
+	answers, err := p.AllAnswers()
+	if err != nil {
+		return nil
+	}
+	var nodes []*node.Info
+	for _, a := range answers {
+		txt, ok := a.Body.(*dnsmessage.TXTResource)
+		if !ok || !strings.HasSuffix(strings.ToLower(a.Header.Name.String()), mdnsService) {
+			continue
+		}
+		if nd := parseMDNSRecord(txt.TXT, ip); nd != nil {
+			nodes = append(nodes, nd)
+		}
+	}
+	return nodes
+}
+
+func parseMDNSRecord(txt []string, ip net.IP) *node.Info {
+	var (
+		id         p2pcrypto.PublicKey
+		port, disc uint64
+		err        error
+	)
+	for _, entry := range txt {
+		kv := strings.SplitN(entry, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case "id":
+			id, err = p2pcrypto.NewPublicKeyFromBase58(kv[1])
+		case "port":
+			port, err = strconv.ParseUint(kv[1], 10, 16)
+		case "disc":
+			disc, err = strconv.ParseUint(kv[1], 10, 16)
+		}
+		if err != nil {
+			return nil
+		}
+	}
+	if id == nil || port == 0 || disc == 0 {
+		return nil
+	}
+	return node.NewNode(id, ip, uint16(port), uint16(disc))
+}
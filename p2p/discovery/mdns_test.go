@@ -0,0 +1,86 @@
+package discovery
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/p2p/config"
+	"github.com/spacemeshos/go-spacemesh/p2p/node"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func TestMDNSMessages(t *testing.T) {
+	r := require.New(t)
+	nd := generateDiscNode()
+
+	query, err := mdnsQuery()
+	r.NoError(err)
+	r.True(isMDNSQuery(query))
+	r.Empty(parseMDNSResponse(query, net.IPv4(192, 168, 1, 7)))
+
+	response, err := mdnsResponse(nd.PublicKey(), 7513, 7514)
+	r.NoError(err)
+	r.False(isMDNSQuery(response))
+	nodes := parseMDNSResponse(response, net.IPv4(192, 168, 1, 7))
+	r.Len(nodes, 1)
+	r.Equal(nd.PublicKey(), nodes[0].PublicKey())
+	r.Equal("192.168.1.7", nodes[0].IP.String())
+	r.Equal(uint16(7513), nodes[0].ProtocolPort)
+	r.Equal(uint16(7514), nodes[0].DiscoveryPort)
+
+	// queries for other services are ignored
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{})
+	r.NoError(b.StartQuestions())
+	r.NoError(b.Question(dnsmessage.Question{Name: dnsmessage.MustNewName("_printer._tcp.local."), Type: dnsmessage.TypePTR, Class: dnsmessage.ClassINET}))
+	other, err := b.Finish()
+	r.NoError(err)
+	r.False(isMDNSQuery(other))
+}
+
+func TestParseMDNSRecord(t *testing.T) {
+	nd := generateDiscNode()
+	ip := net.IPv4(10, 0, 0, 1)
+	id := "id=" + nd.PublicKey().String()
+
+	require.NotNil(t, parseMDNSRecord([]string{id, "port=1", "disc=2", "other"}, ip))
+	require.Nil(t, parseMDNSRecord([]string{id, "port=1"}, ip))
+	require.Nil(t, parseMDNSRecord([]string{id, "port=70000", "disc=2"}, ip))
+	require.Nil(t, parseMDNSRecord([]string{"id=notakey", "port=1", "disc=2"}, ip))
+}
+
+func TestDiscovery_MDNS(t *testing.T) {
+	cfg := config.DefaultConfig().SwarmConfig
+	cfg.MDNS = true
+	cfg.MDNSInterval = 100 * time.Millisecond
+	cfg.PeersFile = ""
+
+	ln1, _ := node.GenerateTestNode(t)
+	book1 := newAddrBook(cfg, "", log.NewDefault(t.Name()+"_1"))
+	m1, err := startMDNS(ln1.PublicKey(), 7513, 7513, book1, cfg.MDNSInterval, log.NewDefault(t.Name()+"_1"))
+	if err != nil {
+		t.Skipf("multicast isn't available: %v", err)
+	}
+	defer m1.close()
+	ln2, _ := node.GenerateTestNode(t)
+	book2 := newAddrBook(cfg, "", log.NewDefault(t.Name()+"_2"))
+	m2, err := startMDNS(ln2.PublicKey(), 7514, 7514, book2, cfg.MDNSInterval, log.NewDefault(t.Name()+"_2"))
+	require.NoError(t, err)
+	defer m2.close()
+
+	found := func(book *addrBook, nd node.LocalNode) bool {
+		_, err := book.Lookup(nd.PublicKey())
+		return err == nil
+	}
+	deadline := time.Now().Add(3 * time.Second)
+	for !found(book1, ln2) || !found(book2, ln1) {
+		if time.Now().After(deadline) {
+			t.Skip("multicast messages aren't delivered on this host")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	// the node doesn't add itself
+	require.False(t, found(book1, ln1))
+}
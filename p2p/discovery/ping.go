@@ -3,6 +3,7 @@ package discovery
 import (
 	"bytes"
 	"errors"
+	xdr "github.com/nullstyle/go-xdr/xdr3"
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/log"
 	"net"
@@ -31,11 +32,17 @@ func (p *protocol) newPingRequestHandler() func(msg server.Message) []byte {
 
 		//pong
 		payload, err := types.InterfaceToBytes(p.local)
-		// TODO: include the resolved To address
 		if err != nil {
 			plogger.Error("Error marshaling response message (Ping)")
 			return nil
 		}
+		// the address we see the pinger at follows our node info. older nodes only decode the node info and ignore it.
+		observed, err := types.InterfaceToBytes(msg.Metadata().FromAddress.String())
+		if err != nil {
+			plogger.Error("Error marshaling observed address (Ping)")
+			return nil
+		}
+		payload = append(payload, observed...)
 
 		plogger.Debug("Sending pong message")
 		return payload
@@ -75,13 +82,24 @@ func (p *protocol) Ping(peer p2pcrypto.PublicKey) error {
 		defer close(ch)
 		plogger.Debug("handle response")
 		sender := &node.Info{}
-		err := types.BytesToInterface(msg, sender)
+		rd := bytes.NewReader(msg)
+		_, err := xdr.Unmarshal(rd, sender)
 
 		if err != nil {
 			plogger.Warning("got unreadable pong. err=%v", err)
 			return
 		}
 
+		// pongs of older nodes don't include our address
+		if rd.Len() > 0 {
+			var observed string
+			if _, err := xdr.Unmarshal(rd, &observed); err != nil {
+				plogger.Warning("got unreadable observed address in pong. err=%v", err)
+			} else {
+				p.addExternalAddress(observed)
+			}
+		}
+
 		// todo: if we pinged it we already have id so no need to update
 		// todo : but what if id or listen address has changed ?
 
@@ -7,6 +7,8 @@ import (
 	"github.com/spacemeshos/go-spacemesh/p2p/server"
 	"github.com/spacemeshos/go-spacemesh/p2p/service"
 	"net"
+	"sort"
+	"sync"
 	"time"
 )
 
@@ -22,6 +24,9 @@ type protocol struct {
 	table     protocolRoutingTable
 	logger    log.Log
 	msgServer *server.MessageServer
+
+	externalMtx sync.Mutex
+	external    map[string]*ExternalAddress
 }
 
 func (p *protocol) SetLocalAddresses(tcp, udp int) {
@@ -44,6 +49,17 @@ const PingPong = 0
 // GetAddresses is the findnode protocol ID
 const GetAddresses = 1
 
+// maxExternalAddresses is the number of different external addresses we keep, reports of other addresses are dropped
+const maxExternalAddresses = 16
+
+// ExternalAddress is an address of the local node as seen by the peers that answered our pings.
+type ExternalAddress struct {
+	Address string
+	// Reports is the number of pongs that reported this address.
+	Reports  int
+	LastSeen time.Time
+}
+
 // newProtocol is a constructor for a protocol protocol provider.
 func newProtocol(local p2pcrypto.PublicKey, rt protocolRoutingTable, svc server.Service, log log.Log) *protocol {
 	s := server.NewMsgServer(svc, Name, MessageTimeout, make(chan service.DirectMessage, MessageBufSize), log)
@@ -52,6 +68,7 @@ func newProtocol(local p2pcrypto.PublicKey, rt protocolRoutingTable, svc server.
 		table:     rt,
 		msgServer: s,
 		logger:    log,
+		external:  make(map[string]*ExternalAddress),
 	}
 
 	// XXX Reminder: for discovery protocol to work you must call SetLocalAddresses with updated ports from the socket.
@@ -65,3 +82,35 @@ func newProtocol(local p2pcrypto.PublicKey, rt protocolRoutingTable, svc server.
 func (p *protocol) Close() {
 	p.msgServer.Close()
 }
+
+func (p *protocol) addExternalAddress(addr string) {
+	p.externalMtx.Lock()
+	defer p.externalMtx.Unlock()
+	ext, ok := p.external[addr]
+	if !ok {
+		if len(p.external) >= maxExternalAddresses {
+			return
+		}
+		ext = &ExternalAddress{Address: addr}
+		p.external[addr] = ext
+	}
+	ext.Reports++
+	ext.LastSeen = time.Now()
+}
+
+// ExternalAddresses returns the addresses of the local node reported by peers, the most reported first.
+func (p *protocol) ExternalAddresses() []ExternalAddress {
+	p.externalMtx.Lock()
+	defer p.externalMtx.Unlock()
+	addrs := make([]ExternalAddress, 0, len(p.external))
+	for _, ext := range p.external {
+		addrs = append(addrs, *ext)
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		if addrs[i].Reports != addrs[j].Reports {
+			return addrs[i].Reports > addrs[j].Reports
+		}
+		return addrs[i].Address < addrs[j].Address
+	})
+	return addrs
+}
@@ -1,6 +1,7 @@
 package discovery
 
 import (
+	"fmt"
 	"github.com/spacemeshos/go-spacemesh/log"
 	"github.com/spacemeshos/go-spacemesh/p2p/node"
 	"github.com/spacemeshos/go-spacemesh/p2p/p2pcrypto"
@@ -64,6 +65,30 @@ func TestPing_Ping(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestPing_ExternalAddresses(t *testing.T) {
+	sim := service.NewSimulator()
+	p1 := newTestNode(sim)
+	p2 := newTestNode(sim)
+
+	require.Empty(t, p1.dscv.ExternalAddresses())
+
+	require.NoError(t, p1.dscv.Ping(p2.svc.PublicKey()))
+	require.NoError(t, p1.dscv.Ping(p2.svc.PublicKey()))
+
+	// the simulator reports the same address for all senders
+	addrs := p1.dscv.ExternalAddresses()
+	require.Len(t, addrs, 1)
+	require.Equal(t, "127.0.0.1:1234", addrs[0].Address)
+	require.Equal(t, 2, addrs[0].Reports)
+
+	for i := 0; i < maxExternalAddresses+1; i++ {
+		p1.dscv.addExternalAddress(fmt.Sprintf("10.0.0.%d:7513", i))
+	}
+	addrs = p1.dscv.ExternalAddresses()
+	require.Len(t, addrs, maxExternalAddresses)
+	require.Equal(t, "127.0.0.1:1234", addrs[0].Address)
+}
+
 func TestPing_Ping_Concurrency(t *testing.T) {
 	//TODO : bigger concurrency test
 	sim := service.NewSimulator()
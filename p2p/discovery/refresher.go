@@ -8,6 +8,7 @@ import (
 	"github.com/spacemeshos/go-spacemesh/p2p/node"
 	"github.com/spacemeshos/go-spacemesh/p2p/p2pcrypto"
 	"net"
+	"sync"
 	"time"
 )
 
@@ -43,6 +44,9 @@ type refresher struct {
 	book      addressBook
 	bootNodes []*node.Info
 
+	seedMu    sync.Mutex
+	seedNodes []*node.Info // bootstrap nodes resolved from the dns seeds
+
 	backoffFunc func(tries int) time.Duration
 
 	disc        pingerGetAddresser
@@ -75,11 +79,12 @@ func (r *refresher) Bootstrap(ctx context.Context, numpeers int) error {
 	// to let other nodes populate before flooding with queries.
 	size := r.book.NumAddresses()
 	if size == 0 {
-		r.book.AddAddresses(r.bootNodes, r.localAddress)
-		size = len(r.bootNodes)
+		bootNodes := r.bootstrapNodes()
+		r.book.AddAddresses(bootNodes, r.localAddress)
+		size = len(bootNodes)
 		defer func() {
 			// currently we only have  the discovery address of bootnodes in the configuration so let them pick their own neighbors.
-			for _, b := range r.bootNodes {
+			for _, b := range bootNodes {
 				r.book.RemoveAddress(b.PublicKey())
 			}
 		}()
@@ -124,6 +129,30 @@ loop:
 	return err
 }
 
+// setSeedNodes replaces the bootstrap nodes resolved from the dns seeds
+func (r *refresher) setSeedNodes(nodes []*node.Info) {
+	r.seedMu.Lock()
+	r.seedNodes = nodes
+	r.seedMu.Unlock()
+}
+
+// bootstrapNodes returns the bootstrap nodes of the config and the ones resolved from the dns seeds
+func (r *refresher) bootstrapNodes() []*node.Info {
+	r.seedMu.Lock()
+	defer r.seedMu.Unlock()
+	nodes := append([]*node.Info{}, r.bootNodes...)
+	seen := make(map[p2pcrypto.PublicKey]struct{}, len(nodes))
+	for _, nd := range nodes {
+		seen[nd.PublicKey()] = struct{}{}
+	}
+	for _, nd := range r.seedNodes {
+		if _, ok := seen[nd.PublicKey()]; !ok {
+			nodes = append(nodes, nd)
+		}
+	}
+	return nodes
+}
+
 func expire(m map[p2pcrypto.PublicKey]time.Time) {
 	t := time.Now()
 	c := 0
@@ -19,6 +19,9 @@ type serializedKnownAddress struct {
 	LastSeen    int64
 	LastAttempt int64
 	LastSuccess int64
+	Successes   int
+	Failures    int
+	Latency     time.Duration
 	// no refcount or tried, that is available from context.
 }
 
@@ -50,6 +53,9 @@ func (a *addrBook) savePeers(path string) {
 		ska.Attempts = v.attempts
 		ska.LastAttempt = v.lastattempt.Unix()
 		ska.LastSuccess = v.lastsuccess.Unix()
+		ska.Successes = v.successes
+		ska.Failures = v.failures
+		ska.Latency = v.latency
 		// Tried and refs are implicit in the rest of the structure
 		// and will be worked out from context on unserialisation.
 		sam.Addresses[i] = ska
@@ -145,8 +151,12 @@ func (a *addrBook) deserializePeers(filePath string) error {
 		}
 
 		ka.attempts = v.Attempts
+		ka.lastSeen = time.Unix(v.LastSeen, 0)
 		ka.lastattempt = time.Unix(v.LastAttempt, 0)
 		ka.lastsuccess = time.Unix(v.LastSuccess, 0)
+		ka.successes = v.Successes
+		ka.failures = v.Failures
+		ka.latency = v.Latency
 		a.addrIndex[ka.na.ID] = ka
 	}
 
@@ -184,10 +194,8 @@ func (a *addrBook) deserializePeers(filePath string) error {
 					"none in address list", val)
 			}
 
-			if ka.refs == 0 {
-				a.nTried++
-			}
-			ka.refs++
+			ka.tried = true
+			a.nTried++
 			a.addrTried[i][parsed.ID] = ka
 		}
 	}
@@ -5,9 +5,11 @@ import (
 	"github.com/spacemeshos/go-spacemesh/p2p/config"
 	"github.com/spacemeshos/go-spacemesh/p2p/node"
 	"github.com/spacemeshos/go-spacemesh/p2p/p2pcrypto"
+	"github.com/stretchr/testify/require"
 	"io/ioutil"
 	"os"
 	"testing"
+	"time"
 )
 
 // assertAddr ensures that the two addresses match. The timestamp is not
@@ -97,3 +99,37 @@ func TestAddrManagerSerialization(t *testing.T) {
 	addrMgr.loadPeers(filePath)
 	assertAddrs(t, addrMgr, expectedAddrs)
 }
+
+func TestAddrManagerSerialization_History(t *testing.T) {
+	r := require.New(t)
+	lg := log.New("addrbook_serialize_test", "", "")
+	cfg := config.DefaultConfig()
+
+	tempDir, err := ioutil.TempDir("", "addrbook")
+	r.NoError(err)
+	defer os.RemoveAll(tempDir)
+	filePath := tempDir + "/" + defaultPeersFileName
+
+	addrMgr := newAddrBook(cfg.SwarmConfig, "", lg)
+	addr := node.GenerateRandomNodeData()
+	addrMgr.AddAddress(addr, node.GenerateRandomNodeData())
+	addrMgr.Good(addr.PublicKey())
+	addrMgr.Good(addr.PublicKey())
+	addrMgr.Failed(addr.PublicKey())
+	addrMgr.SetLatency(addr.PublicKey(), 300*time.Millisecond)
+	addrMgr.lookup(addr.PublicKey()).lastattempt = time.Now().Add(-time.Hour)
+	addrMgr.savePeers(filePath)
+
+	// the history of the address survives a restart, so it is dialed first
+	addrMgr = newAddrBook(cfg.SwarmConfig, "", lg)
+	addrMgr.loadPeers(filePath)
+	addrs := addrMgr.Addresses()
+	r.Len(addrs, 1)
+	r.Equal(2, addrs[0].Successes)
+	r.Equal(1, addrs[0].Failures)
+	r.Equal(300*time.Millisecond, addrs[0].Latency)
+	r.False(addrs[0].LastSeen.IsZero())
+	best := addrMgr.BestAddresses(1)
+	r.Len(best, 1)
+	r.Equal(addr.ID, best[0].ID)
+}
@@ -0,0 +1,112 @@
+package gossip
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/p2p/metrics"
+)
+
+// CacheStats describes the occupancy and the effectiveness of the cache of seen gossip messages.
+type CacheStats struct {
+	Size      int           // the number of messages in the cache
+	Capacity  int           // the maximal number of messages in the cache
+	TTL       time.Duration // how long messages are remembered, 0 if only the capacity limits it
+	Hits      uint64        // messages that were already seen and dropped
+	Misses    uint64        // new messages
+	Evictions uint64        // messages forgotten to make room or because they expired
+}
+
+type cacheEntry struct {
+	hash  types.Hash12
+	added time.Time
+}
+
+// messageCache remembers the hashes of seen gossip messages, so messages aren't processed and relayed more than once.
+// Messages are forgotten when they're older than the ttl, or when the cache is full, the oldest first. A cache that's
+// too small for the network causes rebroadcast storms, a cache that remembers messages too long drops legitimate
+// messages that are sent again.
+type messageCache struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	entries  map[types.Hash12]*list.Element
+	order    *list.List // of *cacheEntry, the oldest first
+	now      func() time.Time
+
+	hits, misses, evictions uint64
+}
+
+func newMessageCache(capacity int, ttl time.Duration) *messageCache {
+	return &messageCache{
+		capacity: capacity,
+		ttl:      ttl,
+		entries:  make(map[types.Hash12]*list.Element, capacity),
+		order:    list.New(),
+		now:      time.Now,
+	}
+}
+
+// GetOrInsert checks if a message was seen, otherwise it remembers it. Returns true if the message was already seen.
+func (c *messageCache) GetOrInsert(hash types.Hash12) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	c.expire(now)
+	if _, ok := c.entries[hash]; ok {
+		c.hits++
+		metrics.GossipCacheHits.Add(1)
+		return true
+	}
+	c.misses++
+	metrics.GossipCacheMisses.Add(1)
+	if c.order.Len() >= c.capacity {
+		c.evict(false)
+	}
+	c.entries[hash] = c.order.PushBack(&cacheEntry{hash: hash, added: now})
+	metrics.GossipCacheSize.Set(float64(c.order.Len()))
+	return false
+}
+
+// expire forgets the messages that are older than the ttl
+func (c *messageCache) expire(now time.Time) {
+	if c.ttl <= 0 {
+		return
+	}
+	for front := c.order.Front(); front != nil && now.Sub(front.Value.(*cacheEntry).added) >= c.ttl; front = c.order.Front() {
+		c.evict(true)
+	}
+}
+
+// evict forgets the oldest message
+func (c *messageCache) evict(expired bool) {
+	front := c.order.Front()
+	if front == nil {
+		return
+	}
+	c.order.Remove(front)
+	delete(c.entries, front.Value.(*cacheEntry).hash)
+	c.evictions++
+	if expired {
+		metrics.GossipCacheExpiredEvictions.Add(1)
+	} else {
+		metrics.GossipCacheFullEvictions.Add(1)
+	}
+}
+
+// Stats returns the occupancy and the hit and eviction counts of the cache.
+func (c *messageCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.expire(c.now())
+	return CacheStats{
+		Size:      c.order.Len(),
+		Capacity:  c.capacity,
+		TTL:       c.ttl,
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+	}
+}
@@ -0,0 +1,42 @@
+package gossip
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/stretchr/testify/require"
+)
+
+func hash(i int) types.Hash12 {
+	return types.CalcMessageHash12([]byte{byte(i)}, "test")
+}
+
+func TestMessageCache_Capacity(t *testing.T) {
+	c := newMessageCache(2, 0)
+	require.False(t, c.GetOrInsert(hash(1)))
+	require.False(t, c.GetOrInsert(hash(2)))
+	require.True(t, c.GetOrInsert(hash(1)))
+
+	// the oldest message is forgotten to make room
+	require.False(t, c.GetOrInsert(hash(3)))
+	require.False(t, c.GetOrInsert(hash(1)))
+	require.True(t, c.GetOrInsert(hash(3)))
+	require.Equal(t, CacheStats{Size: 2, Capacity: 2, Hits: 2, Misses: 4, Evictions: 2}, c.Stats())
+}
+
+func TestMessageCache_TTL(t *testing.T) {
+	now := time.Now()
+	c := newMessageCache(10, time.Minute)
+	c.now = func() time.Time { return now }
+	require.False(t, c.GetOrInsert(hash(1)))
+	now = now.Add(30 * time.Second)
+	require.False(t, c.GetOrInsert(hash(2)))
+	require.True(t, c.GetOrInsert(hash(1)))
+
+	// messages are forgotten once they expire
+	now = now.Add(30 * time.Second)
+	require.Equal(t, CacheStats{Size: 1, Capacity: 10, TTL: time.Minute, Hits: 1, Misses: 2, Evictions: 1}, c.Stats())
+	require.False(t, c.GetOrInsert(hash(1)))
+	require.True(t, c.GetOrInsert(hash(2)))
+}
@@ -2,6 +2,8 @@
 package gossip
 
 import (
+	"fmt"
+	"math/rand"
 	"sync"
 
 	"github.com/spacemeshos/go-spacemesh/common/types"
@@ -15,9 +17,36 @@ import (
 	"github.com/spacemeshos/go-spacemesh/priorityq"
 )
 
-const oldMessageCacheSize = 10000
+const defaultMessageCacheSize = 20000  // used if the config doesn't set the size of the cache of seen messages
 const propagateHandleBufferSize = 5000 // number of MessageValidation that we allow buffering, above this number protocols will get stuck
 
+// minGossipFanout is the smallest number of peers messages can be propagated to, fewer peers don't reliably reach the
+// whole network. maxGossipWorkers bounds the number of messages forwarded and validated at the same time.
+const (
+	minGossipFanout  = 3
+	maxGossipWorkers = 64
+)
+
+// ValidateConfig checks that the gossip propagation parameters of the config are within their bounds.
+func ValidateConfig(conf config.SwarmConfig) error {
+	if conf.GossipFanout != 0 && conf.GossipFanout < minGossipFanout {
+		return fmt.Errorf("gossip fanout (%d) must be 0 for all peers or at least %d", conf.GossipFanout, minGossipFanout)
+	}
+	for _, workers := range []struct {
+		name  string
+		value int
+	}{
+		{"gossip forwarders", conf.GossipForwarders},
+		{"gossip validators", conf.GossipValidators},
+		{"gossip block validators", conf.GossipBlockValidators},
+	} {
+		if workers.value < 1 || workers.value > maxGossipWorkers {
+			return fmt.Errorf("%s (%d) must be between 1 and %d", workers.name, workers.value, maxGossipWorkers)
+		}
+	}
+	return nil
+}
+
 type peersManager interface {
 	GetPeers() []peers.Peer
 	PeerCount() uint64
@@ -48,7 +77,7 @@ type Protocol struct {
 
 	shutdown chan struct{}
 
-	oldMessageQ *types.DoubleCache
+	oldMessageQ *messageCache
 
 	propagateQ chan service.MessageValidation
 	pq         prioQ
@@ -58,6 +87,13 @@ type Protocol struct {
 // NewProtocol creates a new gossip protocol instance.
 func NewProtocol(config config.SwarmConfig, base baseNetwork, peersManager peersManager, localNodePubkey p2pcrypto.PublicKey, logger log.Log) *Protocol {
 	// intentionally not subscribing to peers events so that the channels won't block in case executing Start delays
+	cacheSize := config.GossipCacheSize
+	if cacheSize <= 0 {
+		cacheSize = defaultMessageCacheSize
+	}
+	if config.GossipForwarders <= 0 {
+		config.GossipForwarders = 1
+	}
 	return &Protocol{
 		Log:             logger,
 		config:          config,
@@ -65,7 +101,7 @@ func NewProtocol(config config.SwarmConfig, base baseNetwork, peersManager peers
 		localNodePubkey: localNodePubkey,
 		peers:           peersManager,
 		shutdown:        make(chan struct{}),
-		oldMessageQ:     types.NewDoubleCache(oldMessageCacheSize), // todo : remember to drain this
+		oldMessageQ:     newMessageCache(cacheSize, config.GossipCacheTTL),
 		propagateQ:      make(chan service.MessageValidation, propagateHandleBufferSize),
 		pq:              priorityq.New(propagateHandleBufferSize),
 		priorities:      make(map[string]priorityq.Priority),
@@ -74,7 +110,7 @@ func NewProtocol(config config.SwarmConfig, base baseNetwork, peersManager peers
 
 // Start a loop that process peers events
 func (p *Protocol) Start() {
-	go p.propagationEventLoop() // TODO consider running several consumers
+	go p.propagationEventLoop()
 }
 
 // Close stops all protocol routines.
@@ -99,6 +135,11 @@ func (p *Protocol) SetPriority(protoName string, priority priorityq.Priority) {
 	p.priorities[protoName] = priority
 }
 
+// CacheStats returns the occupancy and the effectiveness of the cache of seen messages.
+func (p *Protocol) CacheStats() CacheStats {
+	return p.oldMessageQ.Stats()
+}
+
 // markMessageAsOld adds the message's hash to the old messages queue so that the message won't be processed in case received again.
 // Returns true if message was already processed before
 func (p *Protocol) markMessageAsOld(h types.Hash12) bool {
@@ -126,11 +167,7 @@ func (p *Protocol) propagateMessage(payload []byte, h types.Hash12, nextProt str
 	//TODO soon : don't wait for mesaage to send and if we finished sending last message one of the peers send the next message to him.
 	// limit the number of simultaneous sends. *consider other messages (mainly sync)
 	var wg sync.WaitGroup
-peerLoop:
-	for _, peer := range p.peers.GetPeers() {
-		if exclude == peer {
-			continue peerLoop
-		}
+	for _, peer := range p.selectPeers(exclude) {
 		wg.Add(1)
 		go func(pubkey p2pcrypto.PublicKey) {
 			// TODO: replace peer ?
@@ -148,6 +185,22 @@ peerLoop:
 	wg.Wait()
 }
 
+// selectPeers returns the peers a message is propagated to, the fanout of the config picked at random, or all peers
+func (p *Protocol) selectPeers(exclude p2pcrypto.PublicKey) []peers.Peer {
+	all := p.peers.GetPeers()
+	selected := make([]peers.Peer, 0, len(all))
+	for _, peer := range all {
+		if peer != exclude {
+			selected = append(selected, peer)
+		}
+	}
+	if p.config.GossipFanout == 0 || len(selected) <= p.config.GossipFanout {
+		return selected
+	}
+	rand.Shuffle(len(selected), func(i, j int) { selected[i], selected[j] = selected[j], selected[i] })
+	return selected[:p.config.GossipFanout]
+}
+
 func (p *Protocol) handlePQ() {
 	for {
 		mi, err := p.pq.Read()
@@ -181,7 +234,10 @@ func (p *Protocol) getPriority(protoName string) priorityq.Priority {
 
 // pushes messages that passed validation into the priority queue
 func (p *Protocol) propagationEventLoop() {
-	go p.handlePQ()
+	// every forwarder propagates one message at a time
+	for i := 0; i < p.config.GossipForwarders; i++ {
+		go p.handlePQ()
+	}
 
 	for {
 		select {
@@ -120,3 +120,56 @@ func TestPropagationEventLoop(t *testing.T) {
 	assert.Equal(t, true, isClosed, "listener should be shut down")
 
 }
+
+func TestPropagateMessage_Fanout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	net := NewMockbaseNetwork(ctrl)
+	peersManager := NewMockpeersManager(ctrl)
+	protocol := NewProtocol(config.SwarmConfig{GossipFanout: 8}, net, peersManager, nil, logger)
+
+	peers := make([]p2ppeers.Peer, 30)
+	for i := range peers {
+		peers[i] = p2pcrypto.NewRandomPubkey()
+	}
+	exclude := peers[0]
+	peersManager.EXPECT().GetPeers().Return(peers)
+
+	peersMu := sync.Mutex{}
+	handledPeers := make(map[p2ppeers.Peer]bool)
+	net.EXPECT().
+		SendMessage(gomock.Any(), "test", []byte("test")).
+		Do(func(peer p2pcrypto.PublicKey, _ ...interface{}) {
+			peersMu.Lock()
+			handledPeers[peer] = true
+			peersMu.Unlock()
+		}).
+		Times(8)
+
+	protocol.propagateMessage([]byte("test"), types.CalcHash12([]byte("test")), "test", exclude)
+	assert.Len(t, handledPeers, 8)
+	assert.False(t, handledPeers[exclude], "peer should be excluded")
+}
+
+func TestValidateConfig(t *testing.T) {
+	conf := config.DefaultConfig().SwarmConfig
+	assert.NoError(t, ValidateConfig(conf))
+
+	conf.GossipFanout = 8
+	assert.NoError(t, ValidateConfig(conf))
+	conf.GossipFanout = 2
+	assert.EqualError(t, ValidateConfig(conf), "gossip fanout (2) must be 0 for all peers or at least 3")
+	conf.GossipFanout = -1
+	assert.Error(t, ValidateConfig(conf))
+
+	conf = config.DefaultConfig().SwarmConfig
+	conf.GossipForwarders = 0
+	assert.EqualError(t, ValidateConfig(conf), "gossip forwarders (0) must be between 1 and 64")
+	conf = config.DefaultConfig().SwarmConfig
+	conf.GossipValidators = 65
+	assert.Error(t, ValidateConfig(conf))
+	conf = config.DefaultConfig().SwarmConfig
+	conf.GossipBlockValidators = 0
+	assert.Error(t, ValidateConfig(conf))
+}
@@ -0,0 +1,229 @@
+package p2p
+
+import (
+	"context"
+	"errors"
+	inet "net"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/p2p/node"
+	"github.com/spacemeshos/go-spacemesh/p2p/p2pcrypto"
+	"github.com/spacemeshos/go-spacemesh/p2p/service"
+)
+
+// Peers behind NATs can't be dialed, but two such peers can connect by dialing each other at the same time, each
+// from the port it listens on. A relay, a peer both of them are connected to, tells each of them the address it sees
+// the other at:
+//
+//  1. A fails to dial B and asks its relays to connect it to B.
+//  2. A relay connected to B sends A the address of B, and then B the address of A.
+//  3. A and B dial each other. A runs the handshake as the dialing side, B as the accepting side.
+const (
+	holePunchProtocol = "/p2p/1.0/holepunch"
+	// holePunchCapability is advertised by nodes that can be connected to through hole punching
+	holePunchCapability = "holepunch"
+	// relayCapability is advertised by nodes that coordinate hole punching for their peers
+	relayCapability = "relay"
+	// holePunchTimeout is the time to wait for a relay and to connect after it answered
+	holePunchTimeout = 8 * time.Second
+)
+
+var errNoRelay = errors.New("no relay connected to the peer")
+
+// holePunchMessage asks a relay to connect the node to Peer when Address is empty. Otherwise, it's the answer of a
+// relay, and Address is the address the relay sees Peer at.
+type holePunchMessage struct {
+	Peer    []byte
+	Address string
+}
+
+// relays returns the connected peers that coordinate hole punching
+func (s *Switch) relays() []p2pcrypto.PublicKey {
+	var relays []p2pcrypto.PublicKey
+	for _, p := range s.allPeers() {
+		if s.peerAdvertises(p, relayCapability) {
+			relays = append(relays, p)
+		}
+	}
+	return relays
+}
+
+func (s *Switch) allPeers() []p2pcrypto.PublicKey {
+	s.outpeersMutex.RLock()
+	s.inpeersMutex.RLock()
+	defer s.outpeersMutex.RUnlock()
+	defer s.inpeersMutex.RUnlock()
+	peers := make([]p2pcrypto.PublicKey, 0, len(s.outpeers)+len(s.inpeers))
+	for p := range s.outpeers {
+		peers = append(peers, p)
+	}
+	for p := range s.inpeers {
+		peers = append(peers, p)
+	}
+	return peers
+}
+
+func (s *Switch) sendHolePunch(peer p2pcrypto.PublicKey, msg *holePunchMessage) error {
+	payload, err := types.InterfaceToBytes(msg)
+	if err != nil {
+		return err
+	}
+	return s.SendMessage(peer, holePunchProtocol, payload)
+}
+
+// holePunch connects to a peer that couldn't be dialed through one of the relays of the node
+func (s *Switch) holePunch(nd *node.Info) error {
+	relays := s.relays()
+	if len(relays) == 0 {
+		return errNoRelay
+	}
+
+	answer := make(chan string, 1)
+	s.punchMutex.Lock()
+	if _, ok := s.punches[nd.PublicKey().String()]; ok {
+		s.punchMutex.Unlock()
+		return errors.New("already punching")
+	}
+	s.punches[nd.PublicKey().String()] = answer
+	s.punchMutex.Unlock()
+	defer func() {
+		s.punchMutex.Lock()
+		delete(s.punches, nd.PublicKey().String())
+		s.punchMutex.Unlock()
+	}()
+
+	for _, r := range relays {
+		if err := s.sendHolePunch(r, &holePunchMessage{Peer: nd.PublicKey().Bytes()}); err != nil {
+			s.logger.Debug("failed to ask relay %v to connect %v, err: %v", r, nd.PublicKey(), err)
+		}
+	}
+
+	timer := time.NewTimer(holePunchTimeout)
+	defer timer.Stop()
+	var address string
+	select {
+	case address = <-answer:
+	case <-timer.C:
+		return errNoRelay
+	case <-s.shutdown:
+		return errors.New("shutting down")
+	}
+
+	addr, err := inet.ResolveTCPAddr("tcp", address)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, holePunchTimeout)
+	defer cancel()
+	conn, err := s.network.PunchDial(ctx, addr, nd.PublicKey())
+	if err != nil {
+		return err
+	}
+	s.logger.Debug("connected to %v through hole punching at %v", nd.PublicKey(), addr)
+	return s.cPool.OnDialedConnection(conn)
+}
+
+// relay sends two peers the addresses it sees each other at, so they can connect
+func (s *Switch) relay(requester, target p2pcrypto.PublicKey) {
+	if !s.peerAdvertises(target, holePunchCapability) {
+		return
+	}
+	requesterConn, err := s.cPool.GetConnectionIfExists(requester)
+	if err != nil {
+		return
+	}
+	targetConn, err := s.cPool.GetConnectionIfExists(target)
+	if err != nil {
+		return
+	}
+	// hole punching connects over TCP, the addresses of QUIC connections are of the peers' QUIC ports
+	if !isTCP(requesterConn.RemoteAddr()) || !isTCP(targetConn.RemoteAddr()) {
+		return
+	}
+	// the requester starts first, so it waits for the attempts of the target that reach its listener
+	if err := s.sendHolePunch(requester, &holePunchMessage{Peer: target.Bytes(), Address: targetConn.RemoteAddr().String()}); err != nil {
+		s.logger.Debug("failed to relay %v to %v, err: %v", target, requester, err)
+		return
+	}
+	if err := s.sendHolePunch(target, &holePunchMessage{Peer: requester.Bytes(), Address: requesterConn.RemoteAddr().String()}); err != nil {
+		s.logger.Debug("failed to relay %v to %v, err: %v", requester, target, err)
+	}
+}
+
+// acceptHolePunch connects to a peer that a relay connects to the node
+func (s *Switch) acceptHolePunch(peer p2pcrypto.PublicKey, address string) {
+	s.punchMutex.Lock()
+	if _, ok := s.punches[peer.String()]; ok {
+		s.punchMutex.Unlock()
+		return
+	}
+	s.punches[peer.String()] = nil
+	s.punchMutex.Unlock()
+	defer func() {
+		s.punchMutex.Lock()
+		delete(s.punches, peer.String())
+		s.punchMutex.Unlock()
+	}()
+
+	addr, err := inet.ResolveTCPAddr("tcp", address)
+	if err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, holePunchTimeout)
+	defer cancel()
+	// the connection is published as an inbound connection
+	if err := s.network.PunchAccept(ctx, addr); err != nil {
+		s.logger.Debug("failed to connect to %v through hole punching at %v, err: %v", peer, addr, err)
+	}
+}
+
+// holePunchLoop handles the hole punching requests of peers to relays and the answers of relays.
+func (s *Switch) holePunchLoop(messages chan service.DirectMessage) {
+	for {
+		select {
+		case msg := <-messages:
+			hp := &holePunchMessage{}
+			if err := types.BytesToInterface(msg.Bytes(), hp); err != nil {
+				s.logger.Warning("invalid hole punching message from %v, err: %v", msg.Sender(), err)
+				s.ReportPeer(msg.Sender(), service.InvalidMessage)
+				continue
+			}
+			peer, err := p2pcrypto.NewPubkeyFromBytes(hp.Peer)
+			if err != nil {
+				s.ReportPeer(msg.Sender(), service.InvalidMessage)
+				continue
+			}
+			if hp.Address == "" {
+				if s.config.Relay {
+					go s.relay(msg.Sender(), peer)
+				}
+				continue
+			}
+			// only relays the node asked, or the relays of peers, make the node dial
+			if !s.peerAdvertises(msg.Sender(), relayCapability) {
+				continue
+			}
+			s.punchMutex.Lock()
+			answer, requested := s.punches[peer.String()]
+			s.punchMutex.Unlock()
+			if requested {
+				if answer != nil {
+					select {
+					case answer <- hp.Address:
+					default: // another relay answered first
+					}
+				}
+				continue
+			}
+			go s.acceptHolePunch(peer, hp.Address)
+		case <-s.shutdown:
+			return
+		}
+	}
+}
+
+func isTCP(addr inet.Addr) bool {
+	_, ok := addr.(*inet.TCPAddr)
+	return ok
+}
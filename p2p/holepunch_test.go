@@ -0,0 +1,56 @@
+package p2p
+
+import (
+	inet "net"
+	"testing"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/p2p/net"
+	"github.com/spacemeshos/go-spacemesh/p2p/node"
+	"github.com/stretchr/testify/require"
+)
+
+func waitForCapabilities(t *testing.T, s1, s2 *Switch) {
+	deadline := time.Now().Add(5 * time.Second)
+	for s1.PeerProtocolVersion(s2.lNode.PublicKey()) != net.ProtocolVersion ||
+		s2.PeerProtocolVersion(s1.lNode.PublicKey()) != net.ProtocolVersion {
+		require.True(t, time.Now().Before(deadline), "capabilities weren't exchanged")
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSwarm_HolePunch(t *testing.T) {
+	relayCfg := configWithPort(0)
+	relayCfg.Relay = true
+	relay := p2pTestInstance(t, relayCfg)
+	defer relay.Shutdown()
+	alice := p2pTestInstance(t, configWithPort(0))
+	defer alice.Shutdown()
+	bob := p2pTestInstance(t, configWithPort(0))
+	defer bob.Shutdown()
+
+	// alice can't connect to bob without a relay
+	bobInfo := node.NewNode(bob.lNode.PublicKey(), []byte{127, 0, 0, 1}, uint16(bob.network.LocalAddr().(*inet.TCPAddr).Port), 0)
+	require.Equal(t, errNoRelay, alice.holePunch(bobInfo))
+
+	for _, s := range []*Switch{alice, bob} {
+		_, err := s.cPool.GetConnection(relay.network.LocalAddr(), relay.lNode.PublicKey())
+		require.NoError(t, err)
+		waitForCapabilities(t, s, relay)
+		s.outpeersMutex.Lock()
+		s.outpeers[relay.lNode.PublicKey()] = struct{}{}
+		s.outpeersMutex.Unlock()
+	}
+	require.Len(t, alice.relays(), 1)
+
+	newPeers, _ := bob.SubscribePeerEvents()
+	require.NoError(t, alice.holePunch(bobInfo))
+	_, err := alice.cPool.GetConnectionIfExists(bob.lNode.PublicKey())
+	require.NoError(t, err)
+	select {
+	case peer := <-newPeers:
+		require.Equal(t, alice.lNode.PublicKey().String(), peer.String())
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "bob didn't accept the connection")
+	}
+}
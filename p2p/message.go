@@ -33,6 +33,7 @@ type gossipProtocolMessage struct {
 	sender         p2pcrypto.PublicKey
 	data           service.Data
 	validationChan chan service.MessageValidation
+	scorer         service.PeerScorer // credits the sender for valid messages, nil if peers aren't scored
 }
 
 func (pm gossipProtocolMessage) Sender() p2pcrypto.PublicKey {
@@ -52,6 +53,9 @@ func (pm gossipProtocolMessage) ValidationCompletedChan() chan service.MessageVa
 }
 
 func (pm gossipProtocolMessage) ReportValidation(protocol string) {
+	if pm.scorer != nil {
+		pm.scorer.ReportPeer(pm.sender, service.ValidMessage)
+	}
 	if pm.validationChan != nil {
 		pm.validationChan <- service.NewMessageValidation(pm.sender, pm.Bytes(), protocol)
 	}
@@ -17,6 +17,9 @@ const (
 
 	typeLabel        = "type"
 	messageTypeLabel = "message_type"
+	resultLabel      = "result"
+	reasonLabel      = "reason"
+	directionLabel   = "direction"
 
 	// ProtocolLabel holds the name we use to add a protocol label value
 	ProtocolLabel = "protocol"
@@ -65,6 +68,66 @@ var (
 		Help:      "Number of bytes sent to a given peer.",
 	}, []string{PeerIDLabel})
 
+	peerMessages = prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: MetricsSubsystem,
+		Name:      "peer_messages_total",
+		Help:      "Number of messages received from or sent to a given peer.",
+	}, []string{PeerIDLabel, directionLabel})
+
+	// PeerRecvMessages is the num of messages received from peer
+	PeerRecvMessages = peerMessages.With(directionLabel, "in")
+	// PeerSendMessages is the num of messages sent to peer
+	PeerSendMessages = peerMessages.With(directionLabel, "out")
+
+	// PeerSendQueueLength is the number of messages waiting to be sent to a given peer
+	PeerSendQueueLength = prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: MetricsSubsystem,
+		Name:      "peer_send_queue_len",
+		Help:      "Number of messages waiting to be sent to a given peer.",
+	}, []string{PeerIDLabel})
+
+	protocolBytes = prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: MetricsSubsystem,
+		Name:      "protocol_bytes_total",
+		Help:      "Number of bytes of the messages of a given protocol received from or sent to peers.",
+	}, []string{ProtocolLabel, directionLabel})
+
+	// ProtocolRecv is the num of bytes of the messages of a protocol received from peers
+	ProtocolRecv = protocolBytes.With(directionLabel, "in")
+	// ProtocolSend is the num of bytes of the messages of a protocol sent to peers
+	ProtocolSend = protocolBytes.With(directionLabel, "out")
+
+	protocolMessages = prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: MetricsSubsystem,
+		Name:      "protocol_messages_total",
+		Help:      "Number of messages of a given protocol received from or sent to peers.",
+	}, []string{ProtocolLabel, directionLabel})
+
+	// ProtocolRecvMessages is the num of messages of a protocol received from peers
+	ProtocolRecvMessages = protocolMessages.With(directionLabel, "in")
+	// ProtocolSendMessages is the num of messages of a protocol sent to peers
+	ProtocolSendMessages = protocolMessages.With(directionLabel, "out")
+
+	droppedMessages = prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: MetricsSubsystem,
+		Name:      "dropped_messages_total",
+		Help:      "Number of messages received from peers and dropped before reaching a protocol",
+	}, []string{reasonLabel})
+
+	// DroppedTooBig is a metric for messages dropped because they exceed the size limit
+	DroppedTooBig = droppedMessages.With(reasonLabel, "too_big")
+	// DroppedOutOfSync is a metric for messages dropped because their timestamp drifted too much
+	DroppedOutOfSync = droppedMessages.With(reasonLabel, "out_of_sync")
+	// DroppedUnknownProtocol is a metric for messages dropped because no protocol handles them
+	DroppedUnknownProtocol = droppedMessages.With(reasonLabel, "unknown_protocol")
+	// DroppedInvalid is a metric for messages dropped because they couldn't be decrypted or decoded
+	DroppedInvalid = droppedMessages.With(reasonLabel, "invalid")
+
 	totalGossipMessages = prometheus.NewCounterFrom(stdprometheus.CounterOpts{
 		Namespace: Namespace,
 		Subsystem: MetricsSubsystem,
@@ -78,6 +141,40 @@ var (
 	OldGossipMessages = totalGossipMessages.With(messageTypeLabel, "old")
 	// InvalidGossipMessages is a metric for invalid messages received
 	InvalidGossipMessages = totalGossipMessages.With(messageTypeLabel, "invalid")
+	// DroppedGossipMessages is a metric for new messages dropped because the queues were full
+	DroppedGossipMessages = totalGossipMessages.With(messageTypeLabel, "dropped")
+
+	gossipCacheLookups = prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: MetricsSubsystem,
+		Name:      "gossip_cache_lookups_total",
+		Help:      "Number of gossip messages looked up in the cache of seen messages",
+	}, []string{resultLabel})
+
+	// GossipCacheHits is a metric for gossip messages that were already in the cache
+	GossipCacheHits = gossipCacheLookups.With(resultLabel, "hit")
+	// GossipCacheMisses is a metric for gossip messages that weren't in the cache
+	GossipCacheMisses = gossipCacheLookups.With(resultLabel, "miss")
+
+	gossipCacheEvictions = prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: MetricsSubsystem,
+		Name:      "gossip_cache_evictions_total",
+		Help:      "Number of gossip messages removed from the cache of seen messages",
+	}, []string{reasonLabel})
+
+	// GossipCacheFullEvictions is a metric for messages removed to make room in the cache
+	GossipCacheFullEvictions = gossipCacheEvictions.With(reasonLabel, "full")
+	// GossipCacheExpiredEvictions is a metric for messages removed from the cache because they expired
+	GossipCacheExpiredEvictions = gossipCacheEvictions.With(reasonLabel, "expired")
+
+	// GossipCacheSize is the current number of messages in the cache of seen gossip messages
+	GossipCacheSize = prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: MetricsSubsystem,
+		Name:      "gossip_cache_size",
+		Help:      "Number of gossip messages in the cache of seen messages",
+	}, []string{})
 
 	// AddrbookSize is the current size of the discovery
 	AddrbookSize = prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
@@ -0,0 +1,152 @@
+package net
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// throttleChunkSize is the largest write that waits for the rate limits at once, so connections sharing the global
+// limits take turns instead of waiting for each other's whole messages
+const throttleChunkSize = 16 * 1024
+
+// tokenBucket limits traffic to a rate in bytes per second, allowing bursts of up to a second of traffic. A nil
+// tokenBucket doesn't limit traffic.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate int) *tokenBucket {
+	if rate <= 0 {
+		return nil
+	}
+	return &tokenBucket{rate: float64(rate), tokens: float64(rate), last: time.Now()}
+}
+
+// reserve takes n tokens and returns how long the caller has to wait before using them. The tokens are taken even if
+// there aren't enough, so messages larger than the burst are delayed rather than starved.
+func (b *tokenBucket) reserve(n int) time.Duration {
+	if b == nil {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	b.tokens = math.Min(b.rate, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	b.tokens -= float64(n)
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// throttledConn limits the bandwidth of a connection with its own rate limits and the limits shared by all
+// connections. Receiving is throttled by delaying reads, which slows the sender down through TCP flow control.
+type throttledConn struct {
+	readWriteCloseAddresser
+	send, recv []*tokenBucket
+
+	// the write deadline relative to the start of the write, so time waiting for the rate limits doesn't count
+	writeTimeout int64 // nanoseconds, accessed atomically
+	closeOnce    sync.Once
+	closed       chan struct{}
+}
+
+// throttle limits the bandwidth of conn according to the config. conn is returned as is if bandwidth isn't limited.
+func (n *Net) throttle(conn readWriteCloseAddresser) readWriteCloseAddresser {
+	if n.sendLimit == nil && n.recvLimit == nil && n.config.PeerSendRate <= 0 && n.config.PeerRecvRate <= 0 {
+		return conn
+	}
+	send, recv := n.connLimits()
+	return &throttledConn{
+		readWriteCloseAddresser: conn,
+		send:                    send,
+		recv:                    recv,
+		closed:                  make(chan struct{}),
+	}
+}
+
+// connLimits returns the send and receive limits of a new connection, the shared limits and its own
+func (n *Net) connLimits() (send, recv []*tokenBucket) {
+	return []*tokenBucket{n.sendLimit, newTokenBucket(n.config.PeerSendRate)},
+		[]*tokenBucket{n.recvLimit, newTokenBucket(n.config.PeerRecvRate)}
+}
+
+// wait waits until n bytes may pass all the limits
+func (c *throttledConn) wait(limits []*tokenBucket, n int) error {
+	return waitLimits(limits, n, c.closed)
+}
+
+// waitLimits waits until n bytes may pass all the limits, or until closed is closed
+func waitLimits(limits []*tokenBucket, n int, closed chan struct{}) error {
+	var delay time.Duration
+	for _, limit := range limits {
+		if d := limit.reserve(n); d > delay {
+			delay = d
+		}
+	}
+	if delay == 0 {
+		return nil
+	}
+	tmr := time.NewTimer(delay)
+	defer tmr.Stop()
+	select {
+	case <-tmr.C:
+		return nil
+	case <-closed:
+		return ErrConnectionClosed
+	}
+}
+
+func (c *throttledConn) Read(p []byte) (int, error) {
+	n, err := c.readWriteCloseAddresser.Read(p)
+	if n > 0 {
+		if werr := c.wait(c.recv, n); werr != nil && err == nil {
+			err = werr
+		}
+	}
+	return n, err
+}
+
+func (c *throttledConn) Write(p []byte) (int, error) {
+	written := 0
+	for written < len(p) {
+		chunk := p[written:]
+		if len(chunk) > throttleChunkSize {
+			chunk = chunk[:throttleChunkSize]
+		}
+		if err := c.wait(c.send, len(chunk)); err != nil {
+			return written, err
+		}
+		if timeout := time.Duration(atomic.LoadInt64(&c.writeTimeout)); timeout > 0 {
+			if err := c.readWriteCloseAddresser.SetWriteDeadline(time.Now().Add(timeout)); err != nil {
+				return written, err
+			}
+		}
+		n, err := c.readWriteCloseAddresser.Write(chunk)
+		written += n
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+func (c *throttledConn) SetWriteDeadline(t time.Time) error {
+	var timeout time.Duration
+	if !t.IsZero() {
+		timeout = time.Until(t)
+	}
+	atomic.StoreInt64(&c.writeTimeout, int64(timeout))
+	return c.readWriteCloseAddresser.SetWriteDeadline(t)
+}
+
+func (c *throttledConn) Close() error {
+	c.closeOnce.Do(func() { close(c.closed) })
+	return c.readWriteCloseAddresser.Close()
+}
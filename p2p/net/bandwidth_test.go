@@ -0,0 +1,60 @@
+package net
+
+import (
+	"io"
+	"io/ioutil"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/p2p/config"
+	"github.com/spacemeshos/go-spacemesh/p2p/node"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenBucket(t *testing.T) {
+	var unlimited *tokenBucket
+	require.Equal(t, time.Duration(0), unlimited.reserve(1000000))
+	require.Nil(t, newTokenBucket(0))
+
+	b := newTokenBucket(1000)
+	require.Equal(t, time.Duration(0), b.reserve(600))
+	require.Equal(t, time.Duration(0), b.reserve(400))
+	// the burst is used up, the next bytes wait for the bucket to refill
+	delay := b.reserve(500)
+	require.True(t, delay > 400*time.Millisecond && delay <= 500*time.Millisecond, "delay %v", delay)
+}
+
+func TestNet_Throttle(t *testing.T) {
+	ln, err := node.NewNodeIdentity()
+	require.NoError(t, err)
+	cfg := config.DefaultConfig()
+	n, err := NewNet(cfg, ln, log.NewDefault(t.Name()))
+	require.NoError(t, err)
+
+	local, remote := net.Pipe()
+	defer remote.Close()
+	require.Equal(t, local, n.throttle(local))
+
+	cfg.PeerSendRate = 10000
+	n, err = NewNet(cfg, ln, log.NewDefault(t.Name()))
+	require.NoError(t, err)
+	conn := n.throttle(local)
+	go io.Copy(ioutil.Discard, remote)
+
+	// the first second of traffic passes right away, the rest waits for the limit
+	start := time.Now()
+	written, err := conn.Write(make([]byte, 15000))
+	require.NoError(t, err)
+	require.Equal(t, 15000, written)
+	require.True(t, time.Since(start) >= 400*time.Millisecond, "write took %v", time.Since(start))
+
+	// closing the connection interrupts a throttled write
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		conn.Close()
+	}()
+	_, err = conn.Write(make([]byte, 10000))
+	require.Equal(t, ErrConnectionClosed, err)
+}
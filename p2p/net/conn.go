@@ -13,6 +13,7 @@ import (
 	"io"
 	"net"
 	"sync"
+	"sync/atomic"
 
 	"github.com/spacemeshos/go-spacemesh/crypto"
 )
@@ -44,6 +45,7 @@ type Connection interface {
 	RemotePublicKey() p2pcrypto.PublicKey
 	SetRemotePublicKey(key p2pcrypto.PublicKey)
 	Created() time.Time
+	LastActivity() time.Time
 
 	RemoteAddr() net.Addr
 
@@ -55,9 +57,24 @@ type Connection interface {
 	Closed() bool
 }
 
+// activity records the last time a message was sent or received on a connection
+type activity struct {
+	last int64 // unix nanoseconds, accessed atomically
+}
+
+func (a *activity) touch() {
+	atomic.StoreInt64(&a.last, time.Now().UnixNano())
+}
+
+// LastActivity is the last time a message was sent or received on the connection
+func (a *activity) LastActivity() time.Time {
+	return time.Unix(0, atomic.LoadInt64(&a.last))
+}
+
 // FormattedConnection is an io.Writer and an io.Closer
 // A network connection supporting full-duplex messaging
 type FormattedConnection struct {
+	activity // first for the alignment of atomic accesses
 	// metadata for logging / debugging
 	logger      log.Log
 	id          string // uuid for logging
@@ -106,9 +123,32 @@ type formattedWriter interface {
 	WriteRecord([]byte) (int, error)
 }
 
+// messageConn is a connection that reads and writes whole messages
+type messageConn interface {
+	formattedReader
+	formattedWriter
+	deadliner
+	io.Closer
+	RemoteAddr() net.Addr
+}
+
+// delimitedConn delimits the messages of a stream connection with their length
+type delimitedConn struct {
+	*delimited.Reader
+	*delimited.Writer
+	readWriteCloseAddresser
+}
+
 // Create a new connection wrapping a net.Conn with a provided connection manager
 func newConnection(conn readWriteCloseAddresser, netw networker,
 	remotePub p2pcrypto.PublicKey, session NetworkSession, msgSizeLimit int, deadline time.Duration, log log.Log) *FormattedConnection {
+	mc := delimitedConn{Reader: delimited.NewReader(conn), Writer: delimited.NewWriter(conn), readWriteCloseAddresser: conn}
+	return newMessageConnection(mc, netw, remotePub, session, msgSizeLimit, deadline, log)
+}
+
+// Create a new connection over a connection that carries whole messages
+func newMessageConnection(conn messageConn, netw networker,
+	remotePub p2pcrypto.PublicKey, session NetworkSession, msgSizeLimit int, deadline time.Duration, log log.Log) *FormattedConnection {
 
 	// todo parametrize channel size - hard-coded for now
 	connection := &FormattedConnection{
@@ -117,8 +157,8 @@ func newConnection(conn readWriteCloseAddresser, netw networker,
 		created:      time.Now(),
 		remotePub:    remotePub,
 		remoteAddr:   conn.RemoteAddr(),
-		r:            delimited.NewReader(conn),
-		w:            delimited.NewWriter(conn),
+		r:            conn,
+		w:            conn,
 		close:        conn,
 		deadline:     deadline,
 		deadliner:    conn,
@@ -128,6 +168,7 @@ func newConnection(conn readWriteCloseAddresser, netw networker,
 		stopSending:  make(chan struct{}),
 		msgSizeLimit: msgSizeLimit,
 	}
+	connection.touch()
 	go connection.sendListener()
 	return connection
 }
@@ -208,6 +249,7 @@ func (c *FormattedConnection) sendListener() {
 	for {
 		select {
 		case buf := <-c.messages:
+			metrics.PeerSendQueueLength.With(metrics.PeerIDLabel, c.remotePub.String()).Set(float64(len(c.messages)))
 			//todo: we are hiding the error here...
 			err := c.SendSock(buf)
 			if err != nil {
@@ -229,6 +271,7 @@ func (c *FormattedConnection) Send(m []byte) error {
 	}
 	c.wmtx.Unlock()
 	c.messages <- m
+	metrics.PeerSendQueueLength.With(metrics.PeerIDLabel, c.remotePub.String()).Set(float64(len(c.messages)))
 	return nil
 }
 
@@ -254,7 +297,9 @@ func (c *FormattedConnection) SendSock(m []byte) error {
 		return err
 	}
 	c.wmtx.Unlock()
-	metrics.PeerRecv.With(metrics.PeerIDLabel, c.remotePub.String()).Add(float64(len(m)))
+	c.touch()
+	metrics.PeerSend.With(metrics.PeerIDLabel, c.remotePub.String()).Add(float64(len(m)))
+	metrics.PeerSendMessages.With(metrics.PeerIDLabel, c.remotePub.String()).Add(1)
 	return nil
 }
 
@@ -299,7 +344,17 @@ var (
 	ErrMsgExceededLimit = errors.New("message size exceeded limit")
 )
 
-func (c *FormattedConnection) setupIncoming(timeout time.Duration) error {
+// setupOutgoing sends the first handshake message of a dialed connection and returns the answer of the remote node
+func (c *FormattedConnection) setupOutgoing(timeout time.Duration, handshake []byte) ([]byte, error) {
+	if err := c.SendSock(handshake); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c.readHandshake(timeout)
+}
+
+// readHandshake reads a handshake message, it closes the connection if no valid message arrives in time
+func (c *FormattedConnection) readHandshake(timeout time.Duration) ([]byte, error) {
 	be := make(chan struct {
 		b []byte
 		e error
@@ -332,13 +387,22 @@ func (c *FormattedConnection) setupIncoming(timeout time.Duration) error {
 
 	if err != nil {
 		c.Close()
-		return err
+		return nil, err
 	}
 
 	if c.msgSizeLimit != config.UnlimitedMsgSize && len(msg) > c.msgSizeLimit {
-		c.logger.With().Error("setupIncoming: message is too big",
+		c.logger.With().Error("readHandshake: message is too big",
 			log.Int("limit", c.msgSizeLimit), log.Int("actual", len(msg)))
-		return ErrMsgExceededLimit
+		c.Close()
+		return nil, ErrMsgExceededLimit
+	}
+	return msg, nil
+}
+
+func (c *FormattedConnection) setupIncoming(timeout time.Duration) error {
+	msg, err := c.readHandshake(timeout)
+	if err != nil {
+		return err
 	}
 
 	if c.session != nil {
@@ -372,8 +436,11 @@ func (c *FormattedConnection) beginEventProcessing() {
 		}
 
 		if len(buf) > 0 {
+			c.touch()
 			newbuf := make([]byte, len(buf))
 			copy(newbuf, buf)
+			metrics.PeerRecv.With(metrics.PeerIDLabel, c.remotePub.String()).Add(float64(len(newbuf)))
+			metrics.PeerRecvMessages.With(metrics.PeerIDLabel, c.remotePub.String()).Add(1)
 			c.publish(newbuf)
 		}
 
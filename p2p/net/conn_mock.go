@@ -46,6 +46,11 @@ func (cm ConnectionMock) Created() time.Time {
 	return cm.created
 }
 
+// LastActivity mocks the connection interface.
+func (cm ConnectionMock) LastActivity() time.Time {
+	return cm.created
+}
+
 // SetCreated mutate the mock.
 func (cm ConnectionMock) SetCreated(time2 time.Time) {
 	cm.created = time2
@@ -33,6 +33,27 @@ func TestSendReceiveMessage(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestConn_LastActivity(t *testing.T) {
+	netw := NewNetworkMock()
+	rwcam := NewReadWriteCloseAddresserMock()
+	rPub := p2pcrypto.NewRandomPubkey()
+	conn := newConnection(rwcam, netw, rPub, &networkSessionImpl{}, msgSizeLimit, time.Second, netw.logger)
+	require.False(t, conn.LastActivity().Before(conn.Created()))
+
+	created := conn.LastActivity()
+	time.Sleep(10 * time.Millisecond)
+	require.NoError(t, conn.SendSock([]byte("hello")))
+	sent := conn.LastActivity()
+	require.True(t, sent.After(created))
+
+	time.Sleep(10 * time.Millisecond)
+	go conn.beginEventProcessing()
+	rwcam.SetReadResult(rwcam.WriteOut(), nil)
+	<-netw.IncomingMessages()[0]
+	require.True(t, conn.LastActivity().After(sent))
+	require.NoError(t, conn.Close())
+}
+
 func TestSendMessage(t *testing.T) {
 	netw := NewNetworkMock()
 	rwcam := NewReadWriteCloseAddresserMock()
@@ -1,8 +1,68 @@
 package net
 
-// HandshakeData is the handshake message struct
+import (
+	"errors"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+// ProtocolVersion is the version of the p2p protocol spoken by this node. Peers use the lower of their versions, so
+// nodes of different versions can coexist during network upgrades. Nodes that don't send a version speak version 0.
+const ProtocolVersion uint32 = 3
+
+// noiseVersion is the first protocol version that secures connections with the Noise handshake. Nodes of older
+// versions still connect with the legacy handshake until the next version drops it, but nodes of this version never
+// use it with each other, so they can't be downgraded to it.
+const noiseVersion uint32 = 3
+
+var (
+	// errNoHandshakeAnswer is returned when a dialed node closes the connection without answering the handshake
+	errNoHandshakeAnswer = errors.New("no answer to the handshake")
+	// errNotLegacyHandshake is returned when the first message of a connection isn't a legacy handshake
+	errNotLegacyHandshake = errors.New("not a legacy handshake")
+)
+
+// HandshakeData is the payload of the first handshake message of a connection
 type HandshakeData struct {
 	ClientVersion string
 	NetworkID     int32
 	Port          uint16
+	// fields added after the first version are appended, nodes of older versions ignore them
+	ProtocolVersion uint32
+	Capabilities    []string // optional features the node supports, e.g. new message formats
+	GenesisID       []byte   // the genesis of the network of the node, since version 2
+}
+
+// handshakeDataV1 is the handshake message of nodes of protocol version 1
+type handshakeDataV1 struct {
+	ClientVersion   string
+	NetworkID       int32
+	Port            uint16
+	ProtocolVersion uint32
+	Capabilities    []string
+}
+
+// legacyHandshakeData is the handshake message of nodes that don't negotiate a protocol version
+type legacyHandshakeData struct {
+	ClientVersion string
+	NetworkID     int32
+	Port          uint16
+}
+
+// decodeHandshake decodes the handshake of nodes of any protocol version
+func decodeHandshake(data []byte) (*HandshakeData, error) {
+	handshakeData := &HandshakeData{}
+	if err := types.BytesToInterface(data, handshakeData); err == nil {
+		return handshakeData, nil
+	}
+	v1 := &handshakeDataV1{}
+	if err := types.BytesToInterface(data, v1); err == nil {
+		return &HandshakeData{ClientVersion: v1.ClientVersion, NetworkID: v1.NetworkID, Port: v1.Port,
+			ProtocolVersion: v1.ProtocolVersion, Capabilities: v1.Capabilities}, nil
+	}
+	legacy := &legacyHandshakeData{}
+	if err := types.BytesToInterface(data, legacy); err != nil {
+		return nil, err
+	}
+	return &HandshakeData{ClientVersion: legacy.ClientVersion, NetworkID: legacy.NetworkID, Port: legacy.Port}, nil
 }
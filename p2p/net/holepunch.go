@@ -0,0 +1,154 @@
+package net
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/p2p/p2pcrypto"
+)
+
+// punchRetryInterval is the time between attempts to connect to a peer through its NAT
+const punchRetryInterval = 200 * time.Millisecond
+
+// ErrHolePunchDisabled is returned when hole punching is disabled in the config or isn't supported by the platform
+var ErrHolePunchDisabled = errors.New("hole punching is disabled")
+
+var errPunchedByPeer = errors.New("the peer connected to the listener")
+
+// Listen listens on the tcp address. With hole punching, the connections the node dials share the listening port, so
+// the port is checked to be free first, since sockets that share their port don't fail on ports in use.
+func Listen(address *net.TCPAddr, holePunch bool) (net.Listener, error) {
+	listener, err := net.ListenTCP("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	if !holePunch || !reusePortSupported {
+		return listener, nil
+	}
+	addr := listener.Addr().String()
+	if err := listener.Close(); err != nil {
+		return nil, err
+	}
+	lc := net.ListenConfig{Control: reusePort}
+	return lc.Listen(context.Background(), "tcp", addr)
+}
+
+func isAddrInUse(err error) bool {
+	return errors.Is(err, syscall.EADDRINUSE) || errors.Is(err, syscall.EADDRNOTAVAIL)
+}
+
+// punchDialer returns a dialer that dials from the listening port
+func (n *Net) punchDialer() *net.Dialer {
+	local := &net.TCPAddr{Port: n.listenAddress.Port}
+	if !n.listenAddress.IP.IsUnspecified() {
+		local.IP = n.listenAddress.IP
+	}
+	return &net.Dialer{LocalAddr: local, Control: reusePort}
+}
+
+// punch connects to a peer behind a NAT that connects to this node at the same time. Each NAT lets the connection
+// attempt of the other peer in, since it looks like the answer to the attempt of its own peer, and the attempts that
+// cross make a single connection. Both peers must dial from their listening ports, which their relay observed.
+// The attempt of the peer may also reach the listener instead, that connection is received on accepted if it's set.
+func (n *Net) punch(ctx context.Context, address net.Addr, accepted chan net.Conn) (net.Conn, error) {
+	dialer := n.punchDialer()
+	for {
+		netConn, err := dialer.DialContext(ctx, "tcp", address.String())
+		if err == nil {
+			tcpconn := netConn.(*net.TCPConn)
+			n.tcpSocketConfig(tcpconn)
+			return tcpconn, nil
+		}
+		if errors.Is(err, syscall.EADDRNOTAVAIL) && accepted == nil {
+			// the connection between the addresses exists, the attempt of the peer reached the listener
+			return nil, errPunchedByPeer
+		}
+		timer := time.NewTimer(punchRetryInterval)
+		select {
+		case netConn := <-accepted:
+			timer.Stop()
+			return netConn, nil
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("failed to punch %v: %v", address, err)
+		case <-timer.C:
+		}
+	}
+}
+
+// punched hands a connection the listener accepted to the PunchDial waiting for it, it returns false if no PunchDial
+// is waiting for the remote address of the connection.
+func (n *Net) punched(netConn net.Conn) bool {
+	n.punchMutex.Lock()
+	defer n.punchMutex.Unlock()
+	accepted, ok := n.punches[netConn.RemoteAddr().String()]
+	if !ok {
+		return false
+	}
+	delete(n.punches, netConn.RemoteAddr().String())
+	accepted <- netConn
+	return true
+}
+
+// PunchDial connects to a peer behind a NAT through hole punching and runs the handshake as the dialing side. The
+// peer must call PunchAccept with the address of this node at the same time.
+func (n *Net) PunchDial(ctx context.Context, address net.Addr, remotePubkey p2pcrypto.PublicKey) (Connection, error) {
+	if !n.holePunch || n.listenAddress == nil {
+		return nil, ErrHolePunchDisabled
+	}
+	accepted := make(chan net.Conn, 1)
+	n.punchMutex.Lock()
+	n.punches[address.String()] = accepted
+	n.punchMutex.Unlock()
+	defer func() {
+		n.punchMutex.Lock()
+		if n.punches[address.String()] == accepted {
+			delete(n.punches, address.String())
+		}
+		n.punchMutex.Unlock()
+		select {
+		case netConn := <-accepted:
+			// the listener accepted the connection after the dial succeeded
+			netConn.Close()
+		default:
+		}
+	}()
+
+	netConn, err := n.punch(ctx, address, accepted)
+	if err != nil {
+		return nil, err
+	}
+	c := newConnection(n.throttle(netConn), n, remotePubkey, nil, n.config.MsgSizeLimit, n.config.ResponseTimeout, n.logger)
+	conn, err := n.secureConnection(c, remotePubkey)
+	if err != nil {
+		return nil, err
+	}
+	go conn.beginEventProcessing()
+	return conn, nil
+}
+
+// PunchAccept connects to a peer behind a NAT that calls PunchDial with the address of this node at the same time,
+// and runs the handshake as the accepting side. The connection is published like connections the node accepts.
+func (n *Net) PunchAccept(ctx context.Context, address net.Addr) error {
+	if !n.holePunch || n.listenAddress == nil {
+		return ErrHolePunchDisabled
+	}
+	netConn, err := n.punch(ctx, address, nil)
+	if err == errPunchedByPeer {
+		// the listener accepted the connection, which is published once its handshake completes
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	c := newConnection(n.throttle(netConn), n, nil, nil, n.config.MsgSizeLimit, n.config.ResponseTimeout, n.logger)
+	if err := c.setupIncoming(n.config.SessionTimeout); err != nil {
+		return err
+	}
+	go c.beginEventProcessing()
+	return nil
+}
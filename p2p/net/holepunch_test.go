@@ -0,0 +1,113 @@
+package net
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/p2p/config"
+	"github.com/spacemeshos/go-spacemesh/p2p/node"
+	"github.com/stretchr/testify/require"
+)
+
+func punchNet(t *testing.T, name string, holePunch bool) (*Net, node.LocalNode) {
+	cfg := config.DefaultConfig()
+	cfg.SessionTimeout = time.Second
+	cfg.HolePunch = holePunch
+	ln, _ := node.GenerateTestNode(t)
+	n, err := NewNet(cfg, ln, log.NewDefault(t.Name()+"_"+name))
+	require.NoError(t, err)
+	listener, err := Listen(&net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)}, holePunch)
+	require.NoError(t, err)
+	n.Start(listener)
+	return n, ln
+}
+
+func TestListen_HolePunch(t *testing.T) {
+	if !reusePortSupported {
+		t.Skip("hole punching isn't supported on this platform")
+	}
+	r := require.New(t)
+	listener, err := Listen(&net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)}, true)
+	r.NoError(err)
+	defer listener.Close()
+
+	// sharing the port is only for dialing, the port is still checked to be free
+	_, err = Listen(listener.Addr().(*net.TCPAddr), true)
+	r.Error(err)
+}
+
+func TestNet_PunchDial(t *testing.T) {
+	if !reusePortSupported {
+		t.Skip("hole punching isn't supported on this platform")
+	}
+	r := require.New(t)
+	bobsNet, bobNode := punchNet(t, "bob", false)
+	defer bobsNet.Shutdown()
+	connected := make(chan NewConnectionEvent, 1)
+	bobsNet.SubscribeOnNewRemoteConnections(func(event NewConnectionEvent) {
+		connected <- event
+	})
+	alicesNet, aliceNode := punchNet(t, "alice", true)
+	defer alicesNet.Shutdown()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := alicesNet.PunchDial(ctx, bobsNet.LocalAddr(), bobNode.PublicKey())
+	r.NoError(err)
+	defer conn.Close()
+
+	event := <-connected
+	r.Equal(aliceNode.PublicKey().String(), event.Conn.RemotePublicKey().String())
+	// the connection is from the listening port, the address a relay tells the peers of the node
+	r.Equal(alicesNet.LocalAddr().String(), event.Conn.RemoteAddr().String())
+}
+
+func TestNet_PunchAccept(t *testing.T) {
+	if !reusePortSupported {
+		t.Skip("hole punching isn't supported on this platform")
+	}
+	r := require.New(t)
+	bobsNet, bobNode := punchNet(t, "bob", true)
+	defer bobsNet.Shutdown()
+	connected := make(chan NewConnectionEvent, 1)
+	bobsNet.SubscribeOnNewRemoteConnections(func(event NewConnectionEvent) {
+		connected <- event
+	})
+
+	// alice runs the handshake as the dialing side on the connection bob makes
+	alicesNet, aliceNode := punchNet(t, "alice", false)
+	defer alicesNet.Shutdown()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	r.NoError(err)
+	defer listener.Close()
+
+	accepted := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		accepted <- bobsNet.PunchAccept(ctx, listener.Addr())
+	}()
+	netConn, err := listener.Accept()
+	r.NoError(err)
+	c := newConnection(netConn, alicesNet, bobNode.PublicKey(), nil, alicesNet.config.MsgSizeLimit, alicesNet.config.ResponseTimeout, alicesNet.logger)
+	_, err = alicesNet.secureConnection(c, bobNode.PublicKey())
+	r.NoError(err)
+	defer c.Close()
+
+	r.NoError(<-accepted)
+	event := <-connected
+	r.Equal(aliceNode.PublicKey().String(), event.Conn.RemotePublicKey().String())
+}
+
+func TestNet_PunchDisabled(t *testing.T) {
+	r := require.New(t)
+	alicesNet, bobNode := punchNet(t, "alice", false)
+	defer alicesNet.Shutdown()
+
+	_, err := alicesNet.PunchDial(context.TODO(), alicesNet.LocalAddr(), bobNode.PublicKey())
+	r.Equal(ErrHolePunchDisabled, err)
+	r.Equal(ErrHolePunchDisabled, alicesNet.PunchAccept(context.TODO(), alicesNet.LocalAddr()))
+}
@@ -16,6 +16,7 @@ import (
 // A network connection supporting full-duplex messaging
 // It resembles the Connection interface but suits a packet oriented socket.
 type MsgConnection struct {
+	activity // first for the alignment of atomic accesses
 	// metadata for logging / debugging
 	logger      log.Log
 	id          string // uuid for logging
@@ -59,6 +60,7 @@ func newMsgConnection(conn readWriteCloseAddresser, netw networker,
 		stopSending:  make(chan struct{}),
 		msgSizeLimit: msgSizeLimit,
 	}
+	connection.touch()
 	go connection.sendListener()
 	return connection
 }
@@ -139,6 +141,7 @@ func (c *MsgConnection) sendListener() {
 	for {
 		select {
 		case buf := <-c.messages:
+			metrics.PeerSendQueueLength.With(metrics.PeerIDLabel, c.remotePub.String()).Set(float64(len(c.messages)))
 			//todo: we are hiding the error here...
 			err := c.SendSock(buf)
 			if err != nil {
@@ -160,6 +163,7 @@ func (c *MsgConnection) Send(m []byte) error {
 	}
 	c.wmtx.Unlock()
 	c.messages <- m
+	metrics.PeerSendQueueLength.With(metrics.PeerIDLabel, c.remotePub.String()).Set(float64(len(c.messages)))
 	return nil
 }
 
@@ -185,7 +189,9 @@ func (c *MsgConnection) SendSock(m []byte) error {
 		return err
 	}
 	c.wmtx.Unlock()
-	metrics.PeerRecv.With(metrics.PeerIDLabel, c.remotePub.String()).Add(float64(len(m)))
+	c.touch()
+	metrics.PeerSend.With(metrics.PeerIDLabel, c.remotePub.String()).Add(float64(len(m)))
+	metrics.PeerSendMessages.With(metrics.PeerIDLabel, c.remotePub.String()).Add(1)
 	return nil
 }
 
@@ -238,8 +244,11 @@ func (c *MsgConnection) beginEventProcessing() {
 		}
 
 		if len(buf) > 0 {
+			c.touch()
 			newbuf := make([]byte, size)
 			copy(newbuf, buf[:size])
+			metrics.PeerRecv.With(metrics.PeerIDLabel, c.remotePub.String()).Add(float64(len(newbuf)))
+			metrics.PeerRecvMessages.With(metrics.PeerIDLabel, c.remotePub.String()).Add(1)
 			c.publish(newbuf)
 		}
 
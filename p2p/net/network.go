@@ -2,15 +2,20 @@
 package net
 
 import (
+	"bytes"
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
+	"github.com/flynn/noise"
+	"github.com/quic-go/quic-go"
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/log"
 	"github.com/spacemeshos/go-spacemesh/p2p/config"
 	"github.com/spacemeshos/go-spacemesh/p2p/node"
 	"github.com/spacemeshos/go-spacemesh/p2p/p2pcrypto"
 	"github.com/spacemeshos/go-spacemesh/p2p/version"
+	"golang.org/x/net/proxy"
 	"net"
 	"strconv"
 	"sync"
@@ -44,6 +49,7 @@ type IncomingMessageEvent struct {
 // ManagedConnection in an interface extending Connection with some internal methods that are required for Net to manage Connections
 type ManagedConnection interface {
 	Connection
+	setupOutgoing(timeout time.Duration, handshake []byte) ([]byte, error)
 	beginEventProcessing()
 }
 
@@ -57,6 +63,17 @@ type Net struct {
 	localNode node.LocalNode
 	logger    log.Log
 
+	// the genesis of the network and the pre-shared key of the private network, peers of other networks or without
+	// the key can't connect
+	genesisID  []byte
+	networkKey []byte
+
+	// connections are dialed from the listening port, see HolePunch in the config
+	holePunch bool
+	// the channels of the PunchDial calls waiting for the listener to accept the connection of the peer, by address
+	punchMutex sync.Mutex
+	punches    map[string]chan net.Conn
+
 	listener      net.Listener
 	listenAddress *net.TCPAddr // Address to open connection: localhost:9999
 
@@ -72,12 +89,30 @@ type Net struct {
 	incomingMessagesQueue []chan IncomingMessageEvent
 
 	config config.Config
+	proxy  proxy.ContextDialer // nil if connections are dialed directly
+
+	// bandwidth limits shared by all connections, nil if unlimited
+	sendLimit, recvLimit *tokenBucket
+
+	// the optional features this node supports, sent in the handshake
+	capsMutex    sync.RWMutex
+	capabilities []string
+
+	// QUIC connections, see StartQUIC. quic is nil if they aren't used.
+	quicMutex    sync.Mutex
+	quic         *quic.Transport
+	quicListener *quic.EarlyListener
+	quicSessions tls.ClientSessionCache
+	quicPorts    map[string]int // the QUIC ports of peers, by public key
 }
 
 // NewConnectionEvent is a struct holding a new created connection and a node info.
 type NewConnectionEvent struct {
 	Conn Connection
 	Node *node.Info
+	// the p2p protocol version and the capabilities the remote node sent in its handshake
+	ProtocolVersion uint32
+	Capabilities    []string
 }
 
 // TODO Create a config for Net and only pass it in.
@@ -89,8 +124,19 @@ func NewNet(conf config.Config, localEntity node.LocalNode, logger log.Log) (*Ne
 	qcount := DefaultQueueCount      // todo : get from cfg
 	qsize := DefaultMessageQueueSize // todo : get from cfg
 
+	dialer, err := newProxyDialer(conf.Proxy)
+	if err != nil {
+		return nil, err
+	}
+	networkKey, err := conf.NetworkKeyBytes()
+	if err != nil {
+		return nil, err
+	}
+
 	n := &Net{
 		networkID:             conf.NetworkID,
+		genesisID:             conf.GenesisID,
+		networkKey:            networkKey,
 		localNode:             localEntity,
 		logger:                logger,
 		regNewRemoteConn:      make([]func(NewConnectionEvent), 0, 3),
@@ -98,6 +144,12 @@ func NewNet(conf config.Config, localEntity node.LocalNode, logger log.Log) (*Ne
 		queuesCount:           qcount,
 		incomingMessagesQueue: make([]chan IncomingMessageEvent, qcount),
 		config:                conf,
+		proxy:                 dialer,
+		holePunch:             conf.HolePunch && reusePortSupported && dialer == nil,
+		punches:               make(map[string]chan net.Conn),
+		sendLimit:             newTokenBucket(conf.SendRate),
+		recvLimit:             newTokenBucket(conf.RecvRate),
+		quicPorts:             make(map[string]int),
 	}
 
 	for imq := range n.incomingMessagesQueue {
@@ -176,9 +228,23 @@ func (n *Net) publishClosingConnection(connection ConnectionWithErr) {
 }
 
 func (n *Net) dial(ctx context.Context, address net.Addr) (net.Conn, error) {
+	if n.proxy != nil {
+		// the connection is to the proxy, its socket is configured by the proxy's own network stack
+		return n.proxy.DialContext(ctx, "tcp", address.String())
+	}
+
 	// connect via dialer so we can set tcp network params
 	dialer := &net.Dialer{}
+	if n.holePunch && n.listenAddress != nil {
+		// dial from the listening port, so peers see the address the NAT maps the port to, which is the address
+		// hole punching connects to
+		dialer = n.punchDialer()
+	}
 	netConn, err := dialer.DialContext(ctx, "tcp", address.String())
+	if err != nil && dialer.LocalAddr != nil && isAddrInUse(err) {
+		// the last connection from the listening port to the address may linger, e.g. in TIME_WAIT
+		netConn, err = (&net.Dialer{}).DialContext(ctx, "tcp", address.String())
+	}
 	if err == nil {
 		tcpconn := netConn.(*net.TCPConn)
 		n.tcpSocketConfig(tcpconn)
@@ -217,18 +283,40 @@ func (n *Net) createConnection(ctx context.Context, address net.Addr, remotePub
 	}
 
 	n.logger.Debug("Connected to %s...", address.String())
-	return newConnection(netConn, n, remotePub, session, n.config.MsgSizeLimit, n.config.ResponseTimeout, n.logger), nil
+	return newConnection(n.throttle(netConn), n, remotePub, session, n.config.MsgSizeLimit, n.config.ResponseTimeout, n.logger), nil
 }
 
 func (n *Net) createSecuredConnection(ctx context.Context, address net.Addr, remotePubkey p2pcrypto.PublicKey) (ManagedConnection, error) {
+	if port, ok := n.peerQUICPort(remotePubkey); ok {
+		conn, err := n.createQUICConnection(ctx, address, port, remotePubkey)
+		if err == nil {
+			return conn, nil
+		}
+		n.logger.Debug("failed to connect to %v over QUIC, dialing it over TCP: %v", remotePubkey.String(), err)
+	}
+	conn, err := n.createConnection(ctx, address, remotePubkey, nil)
+	if err != nil {
+		return nil, err
+	}
+	secured, err := n.secureConnection(conn, remotePubkey)
+	if err != errNoHandshakeAnswer {
+		return secured, err
+	}
+	// nodes of versions before the noise handshake close the connection without an answer. Nodes of this version
+	// refuse the legacy handshake of the node, so dropping the answer can't downgrade a connection between them.
+	n.logger.Debug("%v didn't answer the noise handshake, dialing it with the legacy handshake", remotePubkey.String())
+	return n.createLegacyConnection(ctx, address, remotePubkey)
+}
 
-	session := createSession(n.localNode.PrivateKey(), remotePubkey)
+// createLegacyConnection dials a node of a version before the noise handshake
+func (n *Net) createLegacyConnection(ctx context.Context, address net.Addr, remotePubkey p2pcrypto.PublicKey) (ManagedConnection, error) {
+	session := createSession(n.localNode.PrivateKey(), remotePubkey, n.networkKey)
 	conn, err := n.createConnection(ctx, address, remotePubkey, session)
 	if err != nil {
 		return nil, err
 	}
 
-	handshakeMessage, err := generateHandshakeMessage(session, n.networkID, n.listenAddress.Port, n.localNode.PublicKey())
+	handshakeMessage, err := generateLegacyHandshakeMessage(session, n.networkID, n.genesisID, n.listenAddress.Port, n.localNode.PublicKey(), n.Capabilities())
 	if err != nil {
 		conn.Close()
 		return nil, err
@@ -241,8 +329,38 @@ func (n *Net) createSecuredConnection(ctx context.Context, address net.Addr, rem
 	return conn, nil
 }
 
-func createSession(privkey p2pcrypto.PrivateKey, remotePubkey p2pcrypto.PublicKey) NetworkSession {
-	sharedSecret := p2pcrypto.GenerateSharedSecret(privkey, remotePubkey)
+// secureConnection runs the handshake of a dialed connection
+func (n *Net) secureConnection(conn ManagedConnection, remotePubkey p2pcrypto.PublicKey) (ManagedConnection, error) {
+	hs, err := newNoiseHandshake(n.localNode, n.networkID, n.networkKey, remotePubkey)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	handshakeMessage, err := generateHandshakeMessage(hs, n.networkID, n.genesisID, n.listenAddress.Port, n.Capabilities())
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	// the answer only decrypts if the remote node holds the private key of remotePubkey (and the network key)
+	answer, err := conn.setupOutgoing(n.config.SessionTimeout, handshakeMessage)
+	if err != nil {
+		n.logger.Debug("no answer to the handshake of %v: %v", remotePubkey.String(), err)
+		return nil, errNoHandshakeAnswer
+	}
+	_, cs1, cs2, err := hs.ReadMessage(nil, answer)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("handshake failed: %v", err)
+	}
+	conn.SetSession(newNoiseSession(remotePubkey, true, cs1, cs2))
+	return conn, nil
+}
+
+// createSession creates a session with a peer, keyed with the pre-shared key of the private network if there's one.
+// It's used for discovery datagrams, which can't run a handshake, and the connections of the legacy handshake.
+func createSession(privkey p2pcrypto.PrivateKey, remotePubkey p2pcrypto.PublicKey, networkKey []byte) NetworkSession {
+	sharedSecret := p2pcrypto.GenerateKeyedSharedSecret(privkey, remotePubkey, networkKey)
 	session := NewNetworkSession(sharedSecret, remotePubkey)
 	return session
 }
@@ -273,6 +391,7 @@ func (n *Net) Shutdown() {
 			n.logger.Error("Error closing listener err=%v", err)
 		}
 	}
+	n.shutdownQUIC()
 }
 
 func (n *Net) accept(listen net.Listener) {
@@ -303,7 +422,12 @@ func (n *Net) accept(listen net.Listener) {
 		n.logger.Debug("Got new connection... Remote Address: %s", netConn.RemoteAddr())
 		conn := netConn.(*net.TCPConn)
 		n.tcpSocketConfig(conn) // TODO maybe only set this after session handshake to prevent denial of service with big messages
-		c := newConnection(netConn, n, nil, nil, n.config.MsgSizeLimit, n.config.ResponseTimeout, n.logger)
+		if n.holePunch && n.punched(netConn) {
+			// the connection is from a peer this node is connecting to through hole punching, which runs the handshake
+			pending <- struct{}{}
+			continue
+		}
+		c := newConnection(n.throttle(netConn), n, nil, nil, n.config.MsgSizeLimit, n.config.ResponseTimeout, n.logger)
 		go func(con Connection) {
 			defer func() { pending <- struct{}{} }()
 			err := c.setupIncoming(n.config.SessionTimeout)
@@ -318,6 +442,25 @@ func (n *Net) accept(listen net.Listener) {
 	}
 }
 
+// AddCapability advertises an optional feature to the peers the node connects to from now on.
+func (n *Net) AddCapability(capability string) {
+	n.capsMutex.Lock()
+	defer n.capsMutex.Unlock()
+	for _, c := range n.capabilities {
+		if c == capability {
+			return
+		}
+	}
+	n.capabilities = append(n.capabilities, capability)
+}
+
+// Capabilities returns the optional features the node advertises in its handshake.
+func (n *Net) Capabilities() []string {
+	n.capsMutex.RLock()
+	defer n.capsMutex.RUnlock()
+	return append([]string(nil), n.capabilities...)
+}
+
 // SubscribeOnNewRemoteConnections registers a callback for a new connection event. all registered callbacks are called before moving.
 func (n *Net) SubscribeOnNewRemoteConnections(f func(event NewConnectionEvent)) {
 	n.regMutex.Lock()
@@ -325,40 +468,87 @@ func (n *Net) SubscribeOnNewRemoteConnections(f func(event NewConnectionEvent))
 	n.regMutex.Unlock()
 }
 
-func (n *Net) publishNewRemoteConnectionEvent(conn Connection, node *node.Info) {
+func (n *Net) publishNewRemoteConnectionEvent(conn Connection, node *node.Info, handshakeData *HandshakeData) {
 	n.regMutex.RLock()
 	for _, f := range n.regNewRemoteConn {
-		f(NewConnectionEvent{conn, node})
+		f(NewConnectionEvent{Conn: conn, Node: node, ProtocolVersion: handshakeData.ProtocolVersion, Capabilities: handshakeData.Capabilities})
 	}
 	n.regMutex.RUnlock()
 }
 
 // HandlePreSessionIncomingMessage establishes session with the remote peer and update the Connection with the new session
 func (n *Net) HandlePreSessionIncomingMessage(c Connection, message []byte) error {
-	message, remotePubkey, err := p2pcrypto.ExtractPubkey(message)
+	hs, err := newNoiseHandshake(n.localNode, n.networkID, n.networkKey, nil)
+	if err != nil {
+		return err
+	}
+	// it fails if the peer doesn't hold the key of the private network or speaks another handshake protocol
+	protoMessage, _, _, err := hs.ReadMessage(nil, message)
+	if err != nil {
+		if legacyErr := n.handleLegacyHandshake(c, message); legacyErr != errNotLegacyHandshake {
+			return legacyErr
+		}
+		return fmt.Errorf("handshake failed: %v", err)
+	}
+	remotePubkey, err := p2pcrypto.NewPubkeyFromBytes(hs.PeerStatic())
 	if err != nil {
 		return err
 	}
 	c.SetRemotePublicKey(remotePubkey)
-	session := createSession(n.localNode.PrivateKey(), remotePubkey)
-	c.SetSession(session)
 
-	// open message
-	protoMessage, err := session.OpenMessage(message)
+	handshakeData, err := decodeHandshake(protoMessage)
+	if err != nil {
+		return err
+	}
+
+	err = verifyNetworkIDAndClientVersion(n.networkID, n.genesisID, handshakeData)
 	if err != nil {
 		return err
 	}
 
-	handshakeData := &HandshakeData{}
-	err = types.BytesToInterface(protoMessage, handshakeData)
+	answer, cs1, cs2, err := hs.WriteMessage(nil, nil)
 	if err != nil {
 		return err
 	}
+	c.SetSession(newNoiseSession(remotePubkey, false, cs1, cs2))
+	if err := c.Send(answer); err != nil {
+		return err
+	}
+	return n.publishHandshake(c, handshakeData)
+}
+
+// handleLegacyHandshake establishes the session of a node of a version before the noise handshake. It returns
+// errNotLegacyHandshake if the message isn't a legacy handshake with the node.
+func (n *Net) handleLegacyHandshake(c Connection, message []byte) error {
+	message, remotePubkey, err := p2pcrypto.ExtractPubkey(message)
+	if err != nil {
+		return errNotLegacyHandshake
+	}
+	session := createSession(n.localNode.PrivateKey(), remotePubkey, n.networkKey)
+	// open message, it fails if the peer doesn't hold the key of the private network
+	protoMessage, err := session.OpenMessage(message)
+	if err != nil {
+		return errNotLegacyHandshake
+	}
 
-	err = verifyNetworkIDAndClientVersion(n.networkID, handshakeData)
+	handshakeData, err := decodeHandshake(protoMessage)
 	if err != nil {
 		return err
 	}
+	if handshakeData.ProtocolVersion >= noiseVersion {
+		return fmt.Errorf("legacy handshake of protocol version %d", handshakeData.ProtocolVersion)
+	}
+	err = verifyNetworkIDAndClientVersion(n.networkID, n.genesisID, handshakeData)
+	if err != nil {
+		return err
+	}
+	c.SetRemotePublicKey(remotePubkey)
+	c.SetSession(session)
+	return n.publishHandshake(c, handshakeData)
+}
+
+// publishHandshake publishes the new connection of the node that sent the handshake
+func (n *Net) publishHandshake(c Connection, handshakeData *HandshakeData) error {
 	// TODO: pass TO - IP:port and FROM - IP:port in handshake message.
 	remoteListeningPort := handshakeData.Port
 	remoteListeningAddress, err := replacePort(c.RemoteAddr().String(), remoteListeningPort)
@@ -367,11 +557,11 @@ func (n *Net) HandlePreSessionIncomingMessage(c Connection, message []byte) erro
 	}
 	anode := node.NewNode(c.RemotePublicKey(), net.ParseIP(remoteListeningAddress), remoteListeningPort, remoteListeningPort)
 
-	n.publishNewRemoteConnectionEvent(c, anode)
+	n.publishNewRemoteConnectionEvent(c, anode, handshakeData)
 	return nil
 }
 
-func verifyNetworkIDAndClientVersion(networkID int8, handshakeData *HandshakeData) error {
+func verifyNetworkIDAndClientVersion(networkID int8, genesisID []byte, handshakeData *HandshakeData) error {
 	// compare that version to the min client version in config
 	ok, err := version.CheckNodeVersion(handshakeData.ClientVersion, config.MinClientVersion)
 	if err == nil && !ok {
@@ -386,14 +576,41 @@ func verifyNetworkIDAndClientVersion(networkID int8, handshakeData *HandshakeDat
 		return fmt.Errorf("request net id (%d) is different than local net id (%d)", handshakeData.NetworkID, networkID)
 		//TODO : drop and blacklist this sender
 	}
+	// nodes that don't know the genesis, e.g. in tests, only have their network id checked
+	if len(genesisID) > 0 && len(handshakeData.GenesisID) > 0 && !bytes.Equal(handshakeData.GenesisID, genesisID) {
+		return fmt.Errorf("request genesis id (%x) is different than local genesis id (%x)", handshakeData.GenesisID, genesisID)
+	}
 	return nil
 }
 
-func generateHandshakeMessage(session NetworkSession, networkID int8, localIncomingPort int, localPubkey p2pcrypto.PublicKey) ([]byte, error) {
+// generateHandshakeMessage writes the first message of the handshake of a dialed connection
+func generateHandshakeMessage(hs *noise.HandshakeState, networkID int8, genesisID []byte, localIncomingPort int, capabilities []string) ([]byte, error) {
+	handshakeData := &HandshakeData{
+		ClientVersion:   config.ClientVersion,
+		NetworkID:       int32(networkID),
+		Port:            uint16(localIncomingPort),
+		ProtocolVersion: ProtocolVersion,
+		Capabilities:    capabilities,
+		GenesisID:       genesisID,
+	}
+	handshakeMessage, err := types.InterfaceToBytes(handshakeData)
+	if err != nil {
+		return nil, err
+	}
+	message, _, _, err := hs.WriteMessage(nil, handshakeMessage)
+	return message, err
+}
+
+// generateLegacyHandshakeMessage writes the handshake of a connection to a node of a version before the noise
+// handshake. It carries the version of the node, so nodes of this version refuse it.
+func generateLegacyHandshakeMessage(session NetworkSession, networkID int8, genesisID []byte, localIncomingPort int, localPubkey p2pcrypto.PublicKey, capabilities []string) ([]byte, error) {
 	handshakeData := &HandshakeData{
-		ClientVersion: config.ClientVersion,
-		NetworkID:     int32(networkID),
-		Port:          uint16(localIncomingPort),
+		ClientVersion:   config.ClientVersion,
+		NetworkID:       int32(networkID),
+		Port:            uint16(localIncomingPort),
+		ProtocolVersion: ProtocolVersion,
+		Capabilities:    capabilities,
+		GenesisID:       genesisID,
 	}
 	handshakeMessage, err := types.InterfaceToBytes(handshakeData)
 	if err != nil {
@@ -3,9 +3,11 @@ package net
 import (
 	"encoding/hex"
 	"fmt"
+	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/log"
 	"github.com/spacemeshos/go-spacemesh/p2p/config"
 	"github.com/spacemeshos/go-spacemesh/p2p/node"
+	"github.com/spacemeshos/go-spacemesh/p2p/p2pcrypto"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"math/rand"
@@ -147,6 +149,15 @@ func Test_Net_LimitedConnections(t *testing.T) {
 	require.Equal(t, atomic.LoadInt32(&listener.calledCount), int32(cfg.MaxPendingConnections)+1)
 }
 
+// aliceHandshake writes the first handshake message of alice dialing bob
+func aliceHandshake(t *testing.T, alice node.LocalNode, bob p2pcrypto.PublicKey, genesisID []byte, capabilities []string, networkKey []byte) []byte {
+	hs, err := newNoiseHandshake(alice, 1, networkKey, bob)
+	require.NoError(t, err)
+	handshake, err := generateHandshakeMessage(hs, 1, genesisID, 123, capabilities)
+	require.NoError(t, err)
+	return handshake
+}
+
 func TestHandlePreSessionIncomingMessage2(t *testing.T) {
 	r := require.New(t)
 	var wg sync.WaitGroup
@@ -154,7 +165,7 @@ func TestHandlePreSessionIncomingMessage2(t *testing.T) {
 	aliceNode, aliceNodeInfo := node.GenerateTestNode(t)
 	bobNode, _ := node.GenerateTestNode(t)
 
-	bobsAliceConn := NewConnectionMock(aliceNode.PublicKey())
+	bobsAliceConn := NewConnectionMock(nil)
 	bobsAliceConn.Addr = &net.TCPAddr{IP: aliceNodeInfo.IP, Port: int(aliceNodeInfo.ProtocolPort)}
 
 	bobsNet, err := NewNet(config.DefaultConfig(), bobNode, log.NewDefault(t.Name()))
@@ -164,23 +175,175 @@ func TestHandlePreSessionIncomingMessage2(t *testing.T) {
 		wg.Done()
 	})
 
-	aliceSessionWithBob := createSession(aliceNode.PrivateKey(), bobNode.PublicKey())
-	aliceHandshakeMessageToBob, err := generateHandshakeMessage(aliceSessionWithBob, 1, 123, aliceNode.PublicKey())
-	r.NoError(err)
-
 	wg.Add(1)
 
-	err = bobsNet.HandlePreSessionIncomingMessage(bobsAliceConn, aliceHandshakeMessageToBob)
+	// the key of alice is only learned from the handshake
+	err = bobsNet.HandlePreSessionIncomingMessage(bobsAliceConn, aliceHandshake(t, aliceNode, bobNode.PublicKey(), nil, nil, nil))
 	r.NoError(err)
-	r.Equal(int32(0), bobsAliceConn.SendCount())
+	r.Equal(aliceNode.PublicKey().String(), bobsAliceConn.RemotePublicKey().String())
+	r.Equal(int32(1), bobsAliceConn.SendCount())
 
 	wg.Wait()
 
-	wg.Add(1)
+	// handshakes for another node fail
+	otherNode, _ := node.GenerateTestNode(t)
+	r.Error(bobsNet.HandlePreSessionIncomingMessage(bobsAliceConn, aliceHandshake(t, aliceNode, otherNode.PublicKey(), nil, nil, nil)))
+	r.Equal(int32(1), bobsAliceConn.SendCount())
+}
+
+func TestHandlePreSessionIncomingMessage_Capabilities(t *testing.T) {
+	r := require.New(t)
+
+	aliceNode, aliceNodeInfo := node.GenerateTestNode(t)
+	bobNode, _ := node.GenerateTestNode(t)
+
+	bobsAliceConn := NewConnectionMock(aliceNode.PublicKey())
+	bobsAliceConn.Addr = &net.TCPAddr{IP: aliceNodeInfo.IP, Port: int(aliceNodeInfo.ProtocolPort)}
+
+	bobsNet, err := NewNet(config.DefaultConfig(), bobNode, log.NewDefault(t.Name()))
+	r.NoError(err)
+	events := make(chan NewConnectionEvent, 1)
+	bobsNet.SubscribeOnNewRemoteConnections(func(event NewConnectionEvent) {
+		events <- event
+	})
+
+	r.NoError(bobsNet.HandlePreSessionIncomingMessage(bobsAliceConn, aliceHandshake(t, aliceNode, bobNode.PublicKey(), nil, []string{"tx-batches"}, nil)))
+	event := <-events
+	r.Equal(ProtocolVersion, event.ProtocolVersion)
+	r.Equal([]string{"tx-batches"}, event.Capabilities)
+	r.Equal(uint16(123), event.Node.ProtocolPort)
+}
 
-	err = bobsNet.HandlePreSessionIncomingMessage(bobsAliceConn, aliceHandshakeMessageToBob)
+// legacyHandshake seals the handshake payload of a node of a version before the noise handshake
+func legacyHandshake(t *testing.T, alice node.LocalNode, bob p2pcrypto.PublicKey, data interface{}) []byte {
+	payload, err := types.InterfaceToBytes(data)
+	require.NoError(t, err)
+	session := createSession(alice.PrivateKey(), bob, nil)
+	return p2pcrypto.PrependPubkey(session.SealMessage(payload), alice.PublicKey())
+}
+
+func TestHandlePreSessionIncomingMessage_Legacy(t *testing.T) {
+	r := require.New(t)
+
+	aliceNode, aliceNodeInfo := node.GenerateTestNode(t)
+	bobNode, _ := node.GenerateTestNode(t)
+
+	bobsAliceConn := NewConnectionMock(nil)
+	bobsAliceConn.Addr = &net.TCPAddr{IP: aliceNodeInfo.IP, Port: int(aliceNodeInfo.ProtocolPort)}
+
+	bobsNet, err := NewNet(config.DefaultConfig(), bobNode, log.NewDefault(t.Name()))
 	r.NoError(err)
+	events := make(chan NewConnectionEvent, 1)
+	bobsNet.SubscribeOnNewRemoteConnections(func(event NewConnectionEvent) {
+		events <- event
+	})
+
+	// nodes of versions before the noise handshake are accepted with the legacy handshake
+	r.NoError(bobsNet.HandlePreSessionIncomingMessage(bobsAliceConn, legacyHandshake(t, aliceNode, bobNode.PublicKey(),
+		&HandshakeData{ClientVersion: config.ClientVersion, NetworkID: 1, Port: 123, ProtocolVersion: 2, Capabilities: []string{"tx-batches"}})))
+	event := <-events
+	r.Equal(uint32(2), event.ProtocolVersion)
+	r.Equal([]string{"tx-batches"}, event.Capabilities)
+	r.Equal(aliceNode.PublicKey().String(), bobsAliceConn.RemotePublicKey().String())
+	r.Equal(aliceNode.PublicKey().String(), bobsAliceConn.Session().ID().String())
 	r.Equal(int32(0), bobsAliceConn.SendCount())
 
-	wg.Wait()
+	// and so are nodes of version 1, which don't send a genesis id, and nodes that don't negotiate a version
+	r.NoError(bobsNet.HandlePreSessionIncomingMessage(bobsAliceConn, legacyHandshake(t, aliceNode, bobNode.PublicKey(),
+		&handshakeDataV1{ClientVersion: config.ClientVersion, NetworkID: 1, Port: 123, ProtocolVersion: 1})))
+	r.Equal(uint32(1), (<-events).ProtocolVersion)
+	r.NoError(bobsNet.HandlePreSessionIncomingMessage(bobsAliceConn, legacyHandshake(t, aliceNode, bobNode.PublicKey(),
+		&legacyHandshakeData{ClientVersion: config.ClientVersion, NetworkID: 1, Port: 123})))
+	event = <-events
+	r.Equal(uint32(0), event.ProtocolVersion)
+	r.Equal(uint16(123), event.Node.ProtocolPort)
+
+	// nodes that speak the noise handshake can't be downgraded to the legacy one
+	bobsAliceConn = NewConnectionMock(nil)
+	bobsAliceConn.Addr = &net.TCPAddr{IP: aliceNodeInfo.IP, Port: int(aliceNodeInfo.ProtocolPort)}
+	r.EqualError(bobsNet.HandlePreSessionIncomingMessage(bobsAliceConn, legacyHandshake(t, aliceNode, bobNode.PublicKey(),
+		&HandshakeData{ClientVersion: config.ClientVersion, NetworkID: 1, Port: 123, ProtocolVersion: ProtocolVersion})),
+		"legacy handshake of protocol version 3")
+	r.Nil(bobsAliceConn.Session())
+	r.Len(events, 0)
+
+	// neither can a handshake of another protocol or network, which changes the prologue
+	hs, err := newNoiseHandshake(aliceNode, 2, nil, bobNode.PublicKey())
+	r.NoError(err)
+	handshake, err := generateHandshakeMessage(hs, 1, nil, 123, nil)
+	r.NoError(err)
+	r.Error(bobsNet.HandlePreSessionIncomingMessage(bobsAliceConn, handshake))
+	r.Nil(bobsAliceConn.Session())
+}
+
+func TestNet_AddCapability(t *testing.T) {
+	ln, err := node.NewNodeIdentity()
+	require.NoError(t, err)
+	n, err := NewNet(config.DefaultConfig(), ln, log.NewDefault(t.Name()))
+	require.NoError(t, err)
+	n.AddCapability("a")
+	n.AddCapability("b")
+	n.AddCapability("a")
+	require.Equal(t, []string{"a", "b"}, n.Capabilities())
+}
+
+func TestHandlePreSessionIncomingMessage_GenesisID(t *testing.T) {
+	r := require.New(t)
+
+	aliceNode, aliceNodeInfo := node.GenerateTestNode(t)
+	bobNode, _ := node.GenerateTestNode(t)
+
+	bobsAliceConn := NewConnectionMock(aliceNode.PublicKey())
+	bobsAliceConn.Addr = &net.TCPAddr{IP: aliceNodeInfo.IP, Port: int(aliceNodeInfo.ProtocolPort)}
+
+	cfg := config.DefaultConfig()
+	cfg.GenesisID = []byte{1, 2, 3}
+	bobsNet, err := NewNet(cfg, bobNode, log.NewDefault(t.Name()))
+	r.NoError(err)
+	events := make(chan NewConnectionEvent, 1)
+	bobsNet.SubscribeOnNewRemoteConnections(func(event NewConnectionEvent) {
+		events <- event
+	})
+
+	r.EqualError(bobsNet.HandlePreSessionIncomingMessage(bobsAliceConn, aliceHandshake(t, aliceNode, bobNode.PublicKey(), []byte{1, 2, 4}, nil, nil)),
+		"request genesis id (010204) is different than local genesis id (010203)")
+	r.Len(events, 0)
+
+	r.NoError(bobsNet.HandlePreSessionIncomingMessage(bobsAliceConn, aliceHandshake(t, aliceNode, bobNode.PublicKey(), []byte{1, 2, 3}, nil, nil)))
+	<-events
+}
+
+func TestHandlePreSessionIncomingMessage_NetworkKey(t *testing.T) {
+	r := require.New(t)
+
+	aliceNode, aliceNodeInfo := node.GenerateTestNode(t)
+	bobNode, _ := node.GenerateTestNode(t)
+
+	bobsAliceConn := NewConnectionMock(aliceNode.PublicKey())
+	bobsAliceConn.Addr = &net.TCPAddr{IP: aliceNodeInfo.IP, Port: int(aliceNodeInfo.ProtocolPort)}
+
+	cfg := config.DefaultConfig()
+	cfg.NetworkKey = "zz"
+	_, err := NewNet(cfg, bobNode, log.NewDefault(t.Name()))
+	r.EqualError(err, "invalid network key: encoding/hex: invalid byte: U+007A 'z'")
+	cfg.NetworkKey = "0102"
+	_, err = NewNet(cfg, bobNode, log.NewDefault(t.Name()))
+	r.EqualError(err, "invalid network key size (got 2 instead of 32 bytes)")
+
+	key := make([]byte, config.NetworkKeySize)
+	key[0] = 1
+	cfg.NetworkKey = hex.EncodeToString(key)
+	bobsNet, err := NewNet(cfg, bobNode, log.NewDefault(t.Name()))
+	r.NoError(err)
+	events := make(chan NewConnectionEvent, 1)
+	bobsNet.SubscribeOnNewRemoteConnections(func(event NewConnectionEvent) {
+		events <- event
+	})
+
+	// peers without the network key can't open a session
+	r.Error(bobsNet.HandlePreSessionIncomingMessage(bobsAliceConn, aliceHandshake(t, aliceNode, bobNode.PublicKey(), nil, nil, nil)))
+	r.Len(events, 0)
+
+	r.NoError(bobsNet.HandlePreSessionIncomingMessage(bobsAliceConn, aliceHandshake(t, aliceNode, bobNode.PublicKey(), nil, nil, key)))
+	<-events
 }
@@ -0,0 +1,139 @@
+package net
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/flynn/noise"
+	"github.com/spacemeshos/go-spacemesh/p2p/node"
+	"github.com/spacemeshos/go-spacemesh/p2p/p2pcrypto"
+)
+
+// Connections are secured with the Noise IK handshake (https://noiseprotocol.org/noise.html). The dialer knows the
+// public key of the node it dials, so the handshake fails unless the remote node holds the matching private key, and
+// the dialer proves its own key in the first message. Node keys are curve25519 keys, so they're the static keys of the
+// handshake as they are. Nodes of private networks mix the pre-shared network key into the handshake (IKpsk2).
+var noiseSuite = noise.NewCipherSuite(noise.DH25519, noise.CipherChaChaPoly, noise.HashSHA256)
+
+// noiseProtocolName is hashed into the handshake along with the network id. It must change whenever the handshake or
+// the transport of connections change, so a peer can't be tricked into speaking an older protocol: both sides must
+// agree on it or the handshake fails.
+const noiseProtocolName = "spacemesh-p2p-noise/1"
+
+// noiseNonceSize is the size of the nonce prepended to the messages of a session
+const noiseNonceSize = 8
+
+// replayWindowSize is the number of recent nonces a session remembers to reject replayed messages
+const replayWindowSize = 1024
+
+var errShortMessage = errors.New("message is shorter than its nonce")
+
+func noisePrologue(networkID int8) []byte {
+	return append([]byte(noiseProtocolName), byte(networkID))
+}
+
+// newNoiseHandshake starts the handshake of a connection, remotePubkey is the pinned key of the dialed node and nil
+// for incoming connections
+func newNoiseHandshake(local node.LocalNode, networkID int8, networkKey []byte, remotePubkey p2pcrypto.PublicKey) (*noise.HandshakeState, error) {
+	cfg := noise.Config{
+		CipherSuite: noiseSuite,
+		Random:      rand.Reader,
+		Pattern:     noise.HandshakeIK,
+		Initiator:   remotePubkey != nil,
+		Prologue:    noisePrologue(networkID),
+		StaticKeypair: noise.DHKey{
+			Private: local.PrivateKey().Bytes(),
+			Public:  local.PublicKey().Bytes(),
+		},
+	}
+	if remotePubkey != nil {
+		cfg.PeerStatic = remotePubkey.Bytes()
+	}
+	if len(networkKey) > 0 {
+		cfg.PresharedKey = networkKey
+		cfg.PresharedKeyPlacement = 2
+	}
+	return noise.NewHandshakeState(cfg)
+}
+
+// noiseSession encrypts the messages of a connection with the keys of its handshake. Messages are sealed by
+// concurrent senders and may reach the socket in a different order than they were sealed, so each message carries its
+// nonce and the receiver rejects nonces it has already seen.
+type noiseSession struct {
+	peerPubkey p2pcrypto.PublicKey
+	send       noise.Cipher
+	recv       noise.Cipher
+	nonce      uint64
+
+	mu       sync.Mutex
+	highest  uint64
+	received [replayWindowSize]uint64 // nonce+1 of the last message received in each slot
+}
+
+var _ NetworkSession = (*noiseSession)(nil)
+
+// newNoiseSession creates the session of a completed handshake, the initiator sends with the first cipher state
+func newNoiseSession(peerPubkey p2pcrypto.PublicKey, initiator bool, cs1, cs2 *noise.CipherState) *noiseSession {
+	s := &noiseSession{peerPubkey: peerPubkey, send: cs1.Cipher(), recv: cs2.Cipher()}
+	if !initiator {
+		s.send, s.recv = s.recv, s.send
+	}
+	return s
+}
+
+// String returns the session's identifier string.
+func (s *noiseSession) String() string {
+	return s.peerPubkey.String()
+}
+
+// ID returns the session's unique id
+func (s *noiseSession) ID() p2pcrypto.PublicKey {
+	return s.peerPubkey
+}
+
+// SealMessage encrypts the message with the next nonce of the session and prepends the nonce.
+func (s *noiseSession) SealMessage(message []byte) []byte {
+	n := atomic.AddUint64(&s.nonce, 1) - 1
+	out := make([]byte, noiseNonceSize, noiseNonceSize+len(message)+16)
+	binary.BigEndian.PutUint64(out, n)
+	return s.send.Encrypt(out, n, nil, message)
+}
+
+// OpenMessage decrypts a message sealed by the peer, it fails if the message was tampered with or replayed.
+func (s *noiseSession) OpenMessage(boxedMessage []byte) ([]byte, error) {
+	if len(boxedMessage) < noiseNonceSize {
+		return nil, errShortMessage
+	}
+	n := binary.BigEndian.Uint64(boxedMessage)
+	message, err := s.recv.Decrypt(nil, n, nil, boxedMessage[noiseNonceSize:])
+	if err != nil {
+		return nil, err
+	}
+	if !s.accept(n) {
+		return nil, fmt.Errorf("replayed message nonce %d", n)
+	}
+	return message, nil
+}
+
+// accept records the nonce of a received message, it returns false if the nonce was received before or is too old
+// to tell
+func (s *noiseSession) accept(n uint64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if n+replayWindowSize <= s.highest {
+		return false
+	}
+	slot := n % replayWindowSize
+	if s.received[slot] == n+1 {
+		return false
+	}
+	s.received[slot] = n + 1
+	if n > s.highest {
+		s.highest = n
+	}
+	return true
+}
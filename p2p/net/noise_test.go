@@ -0,0 +1,193 @@
+package net
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/p2p/config"
+	"github.com/spacemeshos/go-spacemesh/p2p/net/wire/delimited"
+	"github.com/spacemeshos/go-spacemesh/p2p/node"
+	"github.com/spacemeshos/go-spacemesh/p2p/p2pcrypto"
+	"github.com/stretchr/testify/require"
+)
+
+func noiseSessions(t *testing.T) (*noiseSession, *noiseSession) {
+	alice, _ := node.GenerateTestNode(t)
+	bob, _ := node.GenerateTestNode(t)
+	aliceHs, err := newNoiseHandshake(alice, 1, nil, bob.PublicKey())
+	require.NoError(t, err)
+	bobHs, err := newNoiseHandshake(bob, 1, nil, nil)
+	require.NoError(t, err)
+
+	msg, _, _, err := aliceHs.WriteMessage(nil, nil)
+	require.NoError(t, err)
+	_, _, _, err = bobHs.ReadMessage(nil, msg)
+	require.NoError(t, err)
+	msg, bcs1, bcs2, err := bobHs.WriteMessage(nil, nil)
+	require.NoError(t, err)
+	_, acs1, acs2, err := aliceHs.ReadMessage(nil, msg)
+	require.NoError(t, err)
+	return newNoiseSession(bob.PublicKey(), true, acs1, acs2), newNoiseSession(alice.PublicKey(), false, bcs1, bcs2)
+}
+
+func TestNoiseSession(t *testing.T) {
+	r := require.New(t)
+	alice, bob := noiseSessions(t)
+
+	first := alice.SealMessage([]byte("first"))
+	second := alice.SealMessage([]byte("second"))
+
+	// messages may arrive in another order than they were sealed
+	msg, err := bob.OpenMessage(second)
+	r.NoError(err)
+	r.Equal([]byte("second"), msg)
+	msg, err = bob.OpenMessage(first)
+	r.NoError(err)
+	r.Equal([]byte("first"), msg)
+
+	_, err = bob.OpenMessage(first)
+	r.EqualError(err, "replayed message nonce 0")
+
+	tampered := alice.SealMessage([]byte("third"))
+	tampered[len(tampered)-1] ^= 1
+	_, err = bob.OpenMessage(tampered)
+	r.Error(err)
+	_, err = bob.OpenMessage([]byte{1, 2})
+	r.Equal(errShortMessage, err)
+
+	// each direction has its own keys
+	_, err = alice.OpenMessage(alice.SealMessage([]byte("echo")))
+	r.Error(err)
+	msg, err = alice.OpenMessage(bob.SealMessage([]byte("answer")))
+	r.NoError(err)
+	r.Equal([]byte("answer"), msg)
+}
+
+func TestNoiseSession_ReplayWindow(t *testing.T) {
+	r := require.New(t)
+	alice, bob := noiseSessions(t)
+
+	old := alice.SealMessage([]byte("old"))
+	for i := 0; i < replayWindowSize; i++ {
+		_, err := bob.OpenMessage(alice.SealMessage([]byte("msg")))
+		r.NoError(err)
+	}
+	// a message older than the window can't be told from a replay
+	_, err := bob.OpenMessage(old)
+	r.EqualError(err, "replayed message nonce 0")
+}
+
+func TestNet_DialNoise(t *testing.T) {
+	r := require.New(t)
+	cfg := config.DefaultConfig()
+	cfg.SessionTimeout = time.Second
+
+	bobNode, _ := node.GenerateTestNode(t)
+	bobsNet, err := NewNet(cfg, bobNode, log.NewDefault(t.Name()+"_bob"))
+	r.NoError(err)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	r.NoError(err)
+	bobsNet.Start(listener)
+	defer bobsNet.Shutdown()
+	connected := make(chan NewConnectionEvent, 1)
+	bobsNet.SubscribeOnNewRemoteConnections(func(event NewConnectionEvent) {
+		connected <- event
+	})
+
+	aliceNode, _ := node.GenerateTestNode(t)
+	alicesNet, err := NewNet(cfg, aliceNode, log.NewDefault(t.Name()+"_alice"))
+	r.NoError(err)
+	aliceListener, err := net.Listen("tcp", "127.0.0.1:0")
+	r.NoError(err)
+	alicesNet.Start(aliceListener)
+	defer alicesNet.Shutdown()
+
+	// the dialed key is pinned, a node holding another key can't answer the handshake, nor open the legacy handshake
+	// the dialer falls back to
+	wrong, err := alicesNet.Dial(context.TODO(), listener.Addr(), p2pcrypto.NewRandomPubkey())
+	r.NoError(err)
+	r.Eventually(wrong.Closed, 5*time.Second, 10*time.Millisecond)
+	r.Len(connected, 0)
+
+	conn, err := alicesNet.Dial(context.TODO(), listener.Addr(), bobNode.PublicKey())
+	r.NoError(err)
+	defer conn.Close()
+	event := <-connected
+	r.Equal(aliceNode.PublicKey().String(), event.Conn.RemotePublicKey().String())
+
+	r.NoError(conn.Send(conn.Session().SealMessage([]byte("hello"))))
+	select {
+	case in := <-bobsNet.IncomingMessages()[sumByteArray(aliceNode.PublicKey().Bytes())%bobsNet.queuesCount]:
+		msg, err := in.Conn.Session().OpenMessage(in.Message)
+		r.NoError(err)
+		r.Equal([]byte("hello"), msg)
+	case <-time.After(5 * time.Second):
+		r.Fail("message not received")
+	}
+}
+
+func TestNet_DialLegacy(t *testing.T) {
+	r := require.New(t)
+	cfg := config.DefaultConfig()
+	cfg.SessionTimeout = time.Second
+
+	// bob runs a version before the noise handshake: it closes connections whose handshake it can't open
+	bobNode, _ := node.GenerateTestNode(t)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	r.NoError(err)
+	defer listener.Close()
+	handshakes := make(chan *HandshakeData, 1)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			msg, err := delimited.NewReader(conn).Next()
+			if err != nil {
+				conn.Close()
+				continue
+			}
+			message, alice, err := p2pcrypto.ExtractPubkey(msg)
+			if err != nil {
+				conn.Close()
+				continue
+			}
+			payload, err := createSession(bobNode.PrivateKey(), alice, nil).OpenMessage(message)
+			if err != nil {
+				conn.Close()
+				continue
+			}
+			data, err := decodeHandshake(payload)
+			if err != nil {
+				conn.Close()
+				continue
+			}
+			handshakes <- data
+		}
+	}()
+
+	aliceNode, _ := node.GenerateTestNode(t)
+	alicesNet, err := NewNet(cfg, aliceNode, log.NewDefault(t.Name()+"_alice"))
+	r.NoError(err)
+	aliceListener, err := net.Listen("tcp", "127.0.0.1:0")
+	r.NoError(err)
+	alicesNet.Start(aliceListener)
+	defer alicesNet.Shutdown()
+
+	// alice falls back to the legacy handshake, which carries its version so nodes of its version refuse it
+	conn, err := alicesNet.Dial(context.TODO(), listener.Addr(), bobNode.PublicKey())
+	r.NoError(err)
+	defer conn.Close()
+	r.Equal(bobNode.PublicKey().String(), conn.Session().ID().String())
+	select {
+	case data := <-handshakes:
+		r.Equal(ProtocolVersion, data.ProtocolVersion)
+		r.Equal(int32(cfg.NetworkID), data.NetworkID)
+	case <-time.After(5 * time.Second):
+		r.Fail("legacy handshake not received")
+	}
+}
@@ -0,0 +1,29 @@
+package net
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+
+	"golang.org/x/net/proxy"
+)
+
+// newProxyDialer returns a dialer that connects through the SOCKS5 proxy at the given URL, e.g.
+// socks5://127.0.0.1:9050 for a local Tor client. It returns nil if the URL is empty.
+func newProxyDialer(proxyURL string) (proxy.ContextDialer, error) {
+	if proxyURL == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy url: %v", err)
+	}
+	if u.Scheme != "socks5" && u.Scheme != "socks5h" {
+		return nil, fmt.Errorf("unsupported proxy scheme %q, only socks5 proxies are supported", u.Scheme)
+	}
+	dialer, err := proxy.FromURL(u, &net.Dialer{})
+	if err != nil {
+		return nil, err
+	}
+	return dialer.(proxy.ContextDialer), nil
+}
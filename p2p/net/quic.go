@@ -0,0 +1,508 @@
+package net
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/p2p/config"
+	"github.com/spacemeshos/go-spacemesh/p2p/p2pcrypto"
+)
+
+const (
+	// QUICCapabilityPrefix prefixes the capability of nodes that accept QUIC connections, it's followed by their QUIC
+	// port
+	QUICCapabilityPrefix = "quic/1:"
+	// quicALPN is the application protocol negotiated in the TLS handshake of QUIC connections
+	quicALPN = "spacemesh-p2p/1"
+	// quicPeers is the number of peers whose QUIC port and session ticket are remembered for reconnecting to them
+	quicPeers = 1000
+	// quicMaxStreams is the number of messages a peer may send on a QUIC connection at the same time
+	quicMaxStreams = 1000
+	// quicIdleTimeout closes QUIC connections that didn't receive a packet, including keep-alives, for that long
+	quicIdleTimeout = 3 * TCPKeepAlivePeriod
+	// quicErrMessageTooBig is the code streams are canceled with when their message exceeds the size limit
+	quicErrMessageTooBig = quic.StreamErrorCode(1)
+)
+
+// QUICCapability returns the capability that advertises the QUIC port of the node
+func QUICCapability(port int) string {
+	return QUICCapabilityPrefix + strconv.Itoa(port)
+}
+
+// ParseQUICCapability returns the QUIC port a capability advertises, false if it doesn't advertise a QUIC port
+func ParseQUICCapability(capability string) (int, bool) {
+	if !strings.HasPrefix(capability, QUICCapabilityPrefix) {
+		return 0, false
+	}
+	port, err := strconv.Atoi(strings.TrimPrefix(capability, QUICCapabilityPrefix))
+	if err != nil || port <= 0 || port > 65535 {
+		return 0, false
+	}
+	return port, true
+}
+
+// StartQUIC accepts QUIC connections on conn. Connections to peers whose QUIC port is set with SetPeerQUICPort are
+// dialed over QUIC from conn after StartQUIC is called.
+func (n *Net) StartQUIC(conn net.PacketConn) error {
+	cert, err := quicCertificate()
+	if err != nil {
+		return err
+	}
+	transport := &quic.Transport{Conn: conn}
+	// peers are authenticated by the handshake of the connection, TLS only encrypts QUIC packets
+	listener, err := transport.ListenEarly(&tls.Config{
+		Certificates: []tls.Certificate{cert},
+		NextProtos:   []string{quicALPN},
+		MinVersion:   tls.VersionTLS13,
+	}, n.quicConfig())
+	if err != nil {
+		return err
+	}
+	n.quicMutex.Lock()
+	n.quic = transport
+	n.quicListener = listener
+	n.quicSessions = tls.NewLRUClientSessionCache(quicPeers)
+	n.quicMutex.Unlock()
+	n.logger.Info("Started QUIC server listening for connections on udp:%v", conn.LocalAddr().String())
+	go n.acceptQUIC(listener)
+	return nil
+}
+
+// QUICAddr returns the local address QUIC connections are accepted on, nil if StartQUIC wasn't called
+func (n *Net) QUICAddr() net.Addr {
+	n.quicMutex.Lock()
+	defer n.quicMutex.Unlock()
+	if n.quicListener == nil {
+		return nil
+	}
+	return n.quicListener.Addr()
+}
+
+// SetPeerQUICPort sets the port the peer accepts QUIC connections on, so it's dialed over QUIC
+func (n *Net) SetPeerQUICPort(peer p2pcrypto.PublicKey, port int) {
+	n.quicMutex.Lock()
+	defer n.quicMutex.Unlock()
+	if _, ok := n.quicPorts[peer.String()]; !ok && len(n.quicPorts) >= quicPeers {
+		for p := range n.quicPorts {
+			delete(n.quicPorts, p)
+			break
+		}
+	}
+	n.quicPorts[peer.String()] = port
+}
+
+// peerQUICPort returns the port to dial the peer over QUIC at, false if it isn't dialed over QUIC
+func (n *Net) peerQUICPort(peer p2pcrypto.PublicKey) (int, bool) {
+	n.quicMutex.Lock()
+	defer n.quicMutex.Unlock()
+	if n.quic == nil {
+		return 0, false
+	}
+	port, ok := n.quicPorts[peer.String()]
+	return port, ok
+}
+
+func (n *Net) quicConfig() *quic.Config {
+	return &quic.Config{
+		HandshakeIdleTimeout:  n.config.SessionTimeout,
+		MaxIdleTimeout:        quicIdleTimeout,
+		KeepAlivePeriod:       TCPKeepAlivePeriod,
+		MaxIncomingStreams:    1, // the stream of the handshake
+		MaxIncomingUniStreams: quicMaxStreams,
+		Allow0RTT:             true,
+	}
+}
+
+// quicCertificate creates the self signed certificate of the QUIC listener
+func quicCertificate() (tls.Certificate, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(10 * 365 * 24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, pub, priv)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: priv}, nil
+}
+
+// createQUICConnection dials a peer over QUIC and runs the handshake of the connection. The TLS session of the last
+// connection to the peer is resumed, so the handshake is sent in the first packet.
+func (n *Net) createQUICConnection(ctx context.Context, address net.Addr, port int, remotePubkey p2pcrypto.PublicKey) (ManagedConnection, error) {
+	if n.isShuttingDown {
+		return nil, fmt.Errorf("can't dial because the connection is shutting down")
+	}
+	host, _, err := net.SplitHostPort(address.String())
+	if err != nil {
+		return nil, err
+	}
+	udpAddr := &net.UDPAddr{IP: net.ParseIP(host), Port: port}
+	n.quicMutex.Lock()
+	transport, sessions := n.quic, n.quicSessions
+	n.quicMutex.Unlock()
+	if transport == nil {
+		return nil, errors.New("QUIC is shut down")
+	}
+
+	n.logger.Debug("Dialing %v @ %v over QUIC...", remotePubkey.String(), udpAddr.String())
+	qconn, err := transport.DialEarly(ctx, udpAddr, &tls.Config{
+		// the remote node is authenticated by the handshake of the connection, the certificate is self signed
+		InsecureSkipVerify: true,
+		ServerName:         remotePubkey.String(), // keys the session of the peer in the cache
+		ClientSessionCache: sessions,
+		NextProtos:         []string{quicALPN},
+		MinVersion:         tls.VersionTLS13,
+	}, n.quicConfig())
+	if err != nil {
+		return nil, err
+	}
+	stream, err := qconn.OpenStream()
+	if errors.Is(err, quic.Err0RTTRejected) {
+		stream, err = qconn.NextConnection().OpenStream()
+	}
+	if err != nil {
+		_ = qconn.CloseWithError(0, "")
+		return nil, err
+	}
+	n.logger.Debug("Connected to %s over QUIC...", udpAddr.String())
+	send, recv := n.connLimits()
+	mc := newQUICMessages(qconn, stream, true, n.config.MsgSizeLimit, send, recv)
+	conn := newMessageConnection(mc, n, remotePubkey, nil, n.config.MsgSizeLimit, n.config.ResponseTimeout, n.logger)
+	return n.secureConnection(conn, remotePubkey)
+}
+
+func (n *Net) acceptQUIC(listener *quic.EarlyListener) {
+	pending := make(chan struct{}, n.config.MaxPendingConnections)
+	for i := 0; i < n.config.MaxPendingConnections; i++ {
+		pending <- struct{}{}
+	}
+
+	for {
+		<-pending
+		qconn, err := listener.Accept(context.Background())
+		if err != nil {
+			if !n.isShuttingDown {
+				n.logger.Error("QUIC listener errored while accepting connections: err: %v", err)
+			}
+			return
+		}
+
+		n.logger.Debug("Got new QUIC connection... Remote Address: %s", qconn.RemoteAddr())
+		go func() {
+			defer func() { pending <- struct{}{} }()
+			ctx, cancel := context.WithTimeout(context.Background(), n.config.SessionTimeout)
+			stream, err := qconn.AcceptStream(ctx)
+			cancel()
+			if err != nil {
+				_ = qconn.CloseWithError(0, "")
+				n.logger.Event().Warning("conn_incoming_failed", log.String("remote", qconn.RemoteAddr().String()), log.Err(err))
+				return
+			}
+			send, recv := n.connLimits()
+			mc := newQUICMessages(qconn, stream, false, n.config.MsgSizeLimit, send, recv)
+			c := newMessageConnection(mc, n, nil, nil, n.config.MsgSizeLimit, n.config.ResponseTimeout, n.logger)
+			if err := c.setupIncoming(n.config.SessionTimeout); err != nil {
+				n.logger.Event().Warning("conn_incoming_failed", log.String("remote", c.remoteAddr.String()), log.Err(err))
+				return
+			}
+			go c.beginEventProcessing()
+		}()
+	}
+}
+
+// shutdownQUIC stops accepting and dialing QUIC connections
+func (n *Net) shutdownQUIC() {
+	n.quicMutex.Lock()
+	defer n.quicMutex.Unlock()
+	if n.quicListener == nil {
+		return
+	}
+	if err := n.quicListener.Close(); err != nil {
+		n.logger.Error("Error closing QUIC listener err=%v", err)
+	}
+	if err := n.quic.Close(); err != nil {
+		n.logger.Error("Error closing QUIC transport err=%v", err)
+	}
+	// the transport doesn't close the socket it was given
+	if err := n.quic.Conn.Close(); err != nil {
+		n.logger.Error("Error closing QUIC socket err=%v", err)
+	}
+	n.quic, n.quicListener = nil, nil
+}
+
+// quicMessages carries the messages of a QUIC connection. The handshake is exchanged on a bidirectional stream the
+// dialing side opens, every other message is sent on its own unidirectional stream. Messages are read in the order
+// they complete, so a lost packet only delays the message it belongs to.
+type quicMessages struct {
+	mu   sync.Mutex
+	conn quic.Connection
+	// the stream of the handshake, the first message each side writes and reads
+	first                 quic.Stream
+	wroteFirst, readFirst bool
+	outgoing              bool
+	handshake             []byte // the first message of the dialing side, resent if 0-RTT is rejected
+	readDeadline          time.Time
+	writeTimeout          time.Duration // the write deadline relative to the start of the write
+	limit                 int
+	send, recv            []*tokenBucket
+	incoming              chan []byte
+	errs                  chan error
+	closeOnce             sync.Once
+	closed                chan struct{}
+}
+
+func newQUICMessages(conn quic.Connection, first quic.Stream, outgoing bool, limit int, send, recv []*tokenBucket) *quicMessages {
+	return &quicMessages{
+		conn:     conn,
+		first:    first,
+		outgoing: outgoing,
+		limit:    limit,
+		send:     send,
+		recv:     recv,
+		incoming: make(chan []byte),
+		errs:     make(chan error, 1),
+		closed:   make(chan struct{}),
+	}
+}
+
+func (m *quicMessages) RemoteAddr() net.Addr {
+	return m.conn.RemoteAddr()
+}
+
+// Next returns the next message received on the connection
+func (m *quicMessages) Next() ([]byte, error) {
+	m.mu.Lock()
+	if !m.readFirst {
+		m.readFirst = true
+		first := m.first
+		m.mu.Unlock()
+		msg, err := m.readStream(first)
+		if err != nil && m.outgoing && errors.Is(err, quic.Err0RTTRejected) {
+			if first, err = m.resendHandshake(); err == nil {
+				msg, err = m.readStream(first)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		go m.acceptStreams()
+		return msg, nil
+	}
+	deadline := m.readDeadline
+	m.mu.Unlock()
+
+	var timeout <-chan time.Time
+	if !deadline.IsZero() {
+		tmr := time.NewTimer(time.Until(deadline))
+		defer tmr.Stop()
+		timeout = tmr.C
+	}
+	select {
+	case msg := <-m.incoming:
+		return msg, nil
+	case err := <-m.errs:
+		return nil, err
+	case <-timeout:
+		return nil, os.ErrDeadlineExceeded
+	}
+}
+
+// WriteRecord sends a message on the connection
+func (m *quicMessages) WriteRecord(msg []byte) (int, error) {
+	m.mu.Lock()
+	first := !m.wroteFirst
+	m.wroteFirst = true
+	if first && m.outgoing {
+		m.handshake = msg
+	}
+	stream, conn, timeout := m.first, m.conn, m.writeTimeout
+	m.mu.Unlock()
+	if first {
+		n, err := m.writeStream(stream, msg)
+		if err != nil && m.outgoing && errors.Is(err, quic.Err0RTTRejected) {
+			if _, err = m.resendHandshake(); err == nil {
+				n = len(msg)
+			}
+		}
+		return n, err
+	}
+
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	uni, err := conn.OpenUniStreamSync(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return m.writeStream(uni, msg)
+}
+
+// resendHandshake sends the handshake again after the handshake of QUIC, when the remote node rejected it as 0-RTT
+// data, e.g. after it restarted. It returns the new stream of the handshake.
+func (m *quicMessages) resendHandshake() (quic.Stream, error) {
+	m.mu.Lock()
+	early, ok := m.conn.(quic.EarlyConnection)
+	handshake, deadline := m.handshake, m.readDeadline
+	m.mu.Unlock()
+	if !ok {
+		return nil, quic.Err0RTTRejected
+	}
+	conn := early.NextConnection()
+	stream, err := conn.OpenStream()
+	if err != nil {
+		return nil, err
+	}
+	if err := stream.SetReadDeadline(deadline); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	m.conn, m.first = conn, stream
+	m.mu.Unlock()
+	if _, err := m.writeStream(stream, handshake); err != nil {
+		return nil, err
+	}
+	return stream, nil
+}
+
+// acceptStreams reads the messages the remote node sends on its own streams
+func (m *quicMessages) acceptStreams() {
+	m.mu.Lock()
+	conn := m.conn
+	m.mu.Unlock()
+	for {
+		stream, err := conn.AcceptUniStream(context.Background())
+		if err != nil {
+			m.fail(err)
+			return
+		}
+		go func() {
+			msg, err := m.readStream(stream)
+			if err != nil {
+				m.fail(err)
+				return
+			}
+			select {
+			case m.incoming <- msg:
+			case <-m.closed:
+			}
+		}()
+	}
+}
+
+// fail makes Next return err, it keeps the first error
+func (m *quicMessages) fail(err error) {
+	select {
+	case m.errs <- err:
+	default:
+	}
+}
+
+// readStream reads the message of a stream, until the remote node closes the stream
+func (m *quicMessages) readStream(stream quic.ReceiveStream) ([]byte, error) {
+	var msg []byte
+	buf := make([]byte, throttleChunkSize)
+	for {
+		n, err := stream.Read(buf)
+		msg = append(msg, buf[:n]...)
+		if m.limit != config.UnlimitedMsgSize && len(msg) > m.limit {
+			stream.CancelRead(quicErrMessageTooBig)
+			return nil, ErrMsgExceededLimit
+		}
+		if n > 0 {
+			if werr := waitLimits(m.recv, n, m.closed); werr != nil {
+				return nil, werr
+			}
+		}
+		if err == io.EOF {
+			return msg, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// writeStream writes a message to a stream and closes it
+func (m *quicMessages) writeStream(stream quic.SendStream, msg []byte) (int, error) {
+	m.mu.Lock()
+	timeout := m.writeTimeout
+	m.mu.Unlock()
+	written := 0
+	for written < len(msg) {
+		chunk := msg[written:]
+		if len(chunk) > throttleChunkSize {
+			chunk = chunk[:throttleChunkSize]
+		}
+		if err := waitLimits(m.send, len(chunk), m.closed); err != nil {
+			stream.CancelWrite(0)
+			return written, err
+		}
+		if timeout > 0 {
+			if err := stream.SetWriteDeadline(time.Now().Add(timeout)); err != nil {
+				return written, err
+			}
+		}
+		n, err := stream.Write(chunk)
+		written += n
+		if err != nil {
+			stream.CancelWrite(0)
+			return written, err
+		}
+	}
+	return written, stream.Close()
+}
+
+// SetReadDeadline sets the deadline of reading the next message
+func (m *quicMessages) SetReadDeadline(t time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.readDeadline = t
+	if !m.readFirst {
+		return m.first.SetReadDeadline(t)
+	}
+	return nil
+}
+
+// SetWriteDeadline sets the deadline of writing messages, not counting the time waiting for the bandwidth limits
+func (m *quicMessages) SetWriteDeadline(t time.Time) error {
+	var timeout time.Duration
+	if !t.IsZero() {
+		timeout = time.Until(t)
+	}
+	m.mu.Lock()
+	m.writeTimeout = timeout
+	m.mu.Unlock()
+	return nil
+}
+
+// Close closes the QUIC connection
+func (m *quicMessages) Close() error {
+	m.closeOnce.Do(func() { close(m.closed) })
+	m.mu.Lock()
+	conn := m.conn
+	m.mu.Unlock()
+	return conn.CloseWithError(0, "")
+}
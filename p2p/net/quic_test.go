@@ -0,0 +1,160 @@
+package net
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/p2p/config"
+	"github.com/spacemeshos/go-spacemesh/p2p/node"
+	"github.com/stretchr/testify/require"
+)
+
+func quicNet(t *testing.T, name string) (*Net, node.LocalNode) {
+	cfg := config.DefaultConfig()
+	cfg.SessionTimeout = time.Second
+	cfg.HolePunch = false
+	ln, _ := node.GenerateTestNode(t)
+	n, err := NewNet(cfg, ln, log.NewDefault(t.Name()+"_"+name))
+	require.NoError(t, err)
+	listener, err := Listen(&net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)}, false)
+	require.NoError(t, err)
+	n.Start(listener)
+	udp, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	require.NoError(t, n.StartQUIC(udp))
+	return n, ln
+}
+
+func nextMessage(t *testing.T, n *Net) IncomingMessageEvent {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		for _, q := range n.IncomingMessages() {
+			select {
+			case ime := <-q:
+				return ime
+			default:
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("no message")
+	return IncomingMessageEvent{}
+}
+
+func TestParseQUICCapability(t *testing.T) {
+	r := require.New(t)
+	port, ok := ParseQUICCapability(QUICCapability(7514))
+	r.True(ok)
+	r.Equal(7514, port)
+	for _, c := range []string{"relay", QUICCapabilityPrefix, QUICCapabilityPrefix + "x", QUICCapabilityPrefix + "70000"} {
+		_, ok := ParseQUICCapability(c)
+		r.False(ok, c)
+	}
+}
+
+func TestNet_QUIC(t *testing.T) {
+	r := require.New(t)
+	bobsNet, bobNode := quicNet(t, "bob")
+	defer bobsNet.Shutdown()
+	connected := make(chan NewConnectionEvent, 2)
+	bobsNet.SubscribeOnNewRemoteConnections(func(event NewConnectionEvent) {
+		connected <- event
+	})
+	alicesNet, aliceNode := quicNet(t, "alice")
+	defer alicesNet.Shutdown()
+	alicesNet.SetPeerQUICPort(bobNode.PublicKey(), bobsNet.QUICAddr().(*net.UDPAddr).Port)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := alicesNet.Dial(ctx, bobsNet.LocalAddr(), bobNode.PublicKey())
+	r.NoError(err)
+	r.Equal(bobsNet.QUICAddr().String(), conn.RemoteAddr().String())
+
+	event := <-connected
+	r.Equal(aliceNode.PublicKey().String(), event.Conn.RemotePublicKey().String())
+	r.Equal(alicesNet.QUICAddr().String(), event.Conn.RemoteAddr().String())
+
+	// each message is sent on its own stream, a large message doesn't hold back the messages sent after it
+	large := bytes.Repeat([]byte{1}, 4*1024*1024)
+	r.NoError(conn.Send(large))
+	r.NoError(conn.Send([]byte("hello")))
+	received := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		ime := nextMessage(t, bobsNet)
+		received[string(ime.Message[:5])] = true
+		if len(ime.Message) > 5 {
+			r.Equal(large, ime.Message)
+		}
+	}
+	r.True(received["hello"])
+
+	r.NoError(event.Conn.Send([]byte("world")))
+	r.Equal([]byte("world"), nextMessage(t, alicesNet).Message)
+	r.NoError(conn.Close())
+
+	// reconnecting resumes the session of the last connection, the handshake is sent in the first packet
+	conn, err = alicesNet.Dial(ctx, bobsNet.LocalAddr(), bobNode.PublicKey())
+	r.NoError(err)
+	defer conn.Close()
+	qconn := conn.(*FormattedConnection).r.(*quicMessages).conn
+	r.True(qconn.ConnectionState().Used0RTT)
+	event = <-connected
+	r.NoError(conn.Send([]byte("again")))
+	r.Equal([]byte("again"), nextMessage(t, bobsNet).Message)
+}
+
+func TestNet_QUIC_0RTTRejected(t *testing.T) {
+	r := require.New(t)
+	bobsNet, bobNode := quicNet(t, "bob")
+	defer bobsNet.Shutdown()
+	alicesNet, _ := quicNet(t, "alice")
+	defer alicesNet.Shutdown()
+	alicesNet.SetPeerQUICPort(bobNode.PublicKey(), bobsNet.QUICAddr().(*net.UDPAddr).Port)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := alicesNet.Dial(ctx, bobsNet.LocalAddr(), bobNode.PublicKey())
+	r.NoError(err)
+	r.NoError(conn.Close())
+
+	// bob restarts, it can't decrypt the session ticket it issued before
+	addr := bobsNet.QUICAddr().(*net.UDPAddr)
+	bobsNet.shutdownQUIC()
+	udp, err := net.ListenUDP("udp", addr)
+	r.NoError(err)
+	r.NoError(bobsNet.StartQUIC(udp))
+
+	conn, err = alicesNet.Dial(ctx, bobsNet.LocalAddr(), bobNode.PublicKey())
+	r.NoError(err)
+	defer conn.Close()
+	r.Equal(addr.String(), conn.RemoteAddr().String())
+	r.False(conn.(*FormattedConnection).r.(*quicMessages).conn.ConnectionState().Used0RTT)
+	r.NoError(conn.Send([]byte("hello")))
+	r.Equal([]byte("hello"), nextMessage(t, bobsNet).Message)
+}
+
+func TestNet_QUIC_FallbackToTCP(t *testing.T) {
+	r := require.New(t)
+	bobsNet, bobNode := quicNet(t, "bob")
+	defer bobsNet.Shutdown()
+	alicesNet, _ := quicNet(t, "alice")
+	defer alicesNet.Shutdown()
+
+	// nothing accepts QUIC connections on the port
+	udp, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	r.NoError(err)
+	port := udp.LocalAddr().(*net.UDPAddr).Port
+	r.NoError(udp.Close())
+	alicesNet.SetPeerQUICPort(bobNode.PublicKey(), port)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := alicesNet.Dial(ctx, bobsNet.LocalAddr(), bobNode.PublicKey())
+	r.NoError(err)
+	defer conn.Close()
+	r.Equal(bobsNet.LocalAddr().String(), conn.RemoteAddr().String())
+}
@@ -0,0 +1,12 @@
+package net
+
+import (
+	"syscall"
+)
+
+// reusePortSupported is false since windows sockets can't share the listening port, so hole punching is disabled
+const reusePortSupported = false
+
+func reusePort(network, address string, c syscall.RawConn) error {
+	return nil
+}
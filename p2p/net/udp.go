@@ -46,6 +46,8 @@ type UDPNet struct {
 	msgChan chan IncomingMessageEvent
 	conn    UDPListener
 	cache   *sessionCache
+	// the pre-shared key of the private network sessions are keyed with, nil if the network isn't private
+	networkKey []byte
 
 	regMutex         sync.RWMutex
 	regNewRemoteConn []func(NewConnectionEvent)
@@ -60,10 +62,15 @@ type UDPNet struct {
 
 // NewUDPNet creates a UDPNet
 func NewUDPNet(config config.Config, localEntity node.LocalNode, log log.Log) (*UDPNet, error) {
+	networkKey, err := config.NetworkKeyBytes()
+	if err != nil {
+		return nil, err
+	}
 	n := &UDPNet{
 		local:        localEntity,
 		logger:       log,
 		config:       config,
+		networkKey:   networkKey,
 		msgChan:      make(chan IncomingMessageEvent, config.BufferSize),
 		incomingConn: make(map[string]udpConn, maxUDPConn),
 		shutdown:     make(chan struct{}),
@@ -99,7 +106,7 @@ func (n *UDPNet) SubscribeOnNewRemoteConnections(f func(event NewConnectionEvent
 func (n *UDPNet) publishNewRemoteConnectionEvent(conn Connection, node *node.Info) {
 	n.regMutex.RLock()
 	for _, f := range n.regNewRemoteConn {
-		f(NewConnectionEvent{conn, node})
+		f(NewConnectionEvent{Conn: conn, Node: node})
 	}
 	n.regMutex.RUnlock()
 }
@@ -128,7 +135,7 @@ func (n *UDPNet) Shutdown() {
 var IPv4LoopbackAddress = net.IP{127, 0, 0, 1}
 
 func (n *UDPNet) initSession(remote p2pcrypto.PublicKey) NetworkSession {
-	session := createSession(n.local.PrivateKey(), remote)
+	session := createSession(n.local.PrivateKey(), remote, n.networkKey)
 	return session
 }
 
@@ -83,7 +83,7 @@ func TestUDPNet_Sanity(t *testing.T) {
 	other, otherinfo := node.GenerateTestNode(t)
 	addr2 := &net.UDPAddr{IP: otherinfo.IP, Port: int(otherinfo.DiscoveryPort)}
 
-	session := createSession(other.PrivateKey(), local.PublicKey())
+	session := createSession(other.PrivateKey(), local.PublicKey(), nil)
 
 	require.NoError(t, err)
 
@@ -3,7 +3,9 @@
 package p2pcrypto
 
 import (
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"errors"
 	"fmt"
 	"github.com/btcsuite/btcutil/base58"
@@ -120,6 +122,21 @@ func GenerateSharedSecret(privkey PrivateKey, peerPubkey PublicKey) SharedSecret
 	return sharedSecret
 }
 
+// GenerateKeyedSharedSecret creates a key derived from a private and a public key and a pre-shared key. Messages sealed
+// with it can only be opened by peers holding the same pre-shared key. Without a pre-shared key it's the key
+// GenerateSharedSecret creates.
+func GenerateKeyedSharedSecret(privkey PrivateKey, peerPubkey PublicKey, psk []byte) SharedSecret {
+	sharedSecret := GenerateSharedSecret(privkey, peerPubkey)
+	if len(psk) == 0 {
+		return sharedSecret
+	}
+	mac := hmac.New(sha256.New, psk)
+	mac.Write(sharedSecret.Bytes())
+	keyed := newKey()
+	copy(keyed.bytes[:], mac.Sum(nil))
+	return keyed
+}
+
 // PrependPubkey adds a public key at the beginning on a byte array.
 func PrependPubkey(message []byte, pubkey PublicKey) []byte {
 	return append(pubkey.Bytes(), message...)
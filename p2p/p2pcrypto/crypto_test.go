@@ -27,6 +27,32 @@ func TestBox(t *testing.T) {
 	r.Equal(string(secretMessage), string(opened))
 }
 
+func TestGenerateKeyedSharedSecret(t *testing.T) {
+	r := require.New(t)
+	alicePrivkey, alicePubkey, err := GenerateKeyPair()
+	r.NoError(err)
+	bobPrivkey, bobPubkey, err := GenerateKeyPair()
+	r.NoError(err)
+
+	psk := []byte("network key")
+	aliceSharedSecret := GenerateKeyedSharedSecret(alicePrivkey, bobPubkey, psk)
+	bobSharedSecret := GenerateKeyedSharedSecret(bobPrivkey, alicePubkey, psk)
+	r.Equal(aliceSharedSecret.Bytes(), bobSharedSecret.Bytes())
+
+	sealed := aliceSharedSecret.Seal([]byte("secret"))
+	opened, err := bobSharedSecret.Open(sealed)
+	r.NoError(err)
+	r.Equal("secret", string(opened))
+
+	// peers without the pre-shared key can't open the messages
+	_, err = GenerateSharedSecret(bobPrivkey, alicePubkey).Open(sealed)
+	r.Error(err)
+	_, err = GenerateKeyedSharedSecret(bobPrivkey, alicePubkey, []byte("other key")).Open(sealed)
+	r.Error(err)
+
+	r.Equal(GenerateSharedSecret(alicePrivkey, bobPubkey).Bytes(), GenerateKeyedSharedSecret(alicePrivkey, bobPubkey, nil).Bytes())
+}
+
 func TestPrependPubkey(t *testing.T) {
 	r := require.New(t)
 	pubkey := NewRandomPubkey()
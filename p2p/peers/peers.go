@@ -2,11 +2,13 @@ package peers
 
 import (
 	"math/rand"
+	"sort"
 	"sync/atomic"
 	"time"
 
 	"github.com/spacemeshos/go-spacemesh/log"
 	"github.com/spacemeshos/go-spacemesh/p2p/p2pcrypto"
+	"github.com/spacemeshos/go-spacemesh/p2p/service"
 )
 
 // Peer is represented by a p2p identity public key.
@@ -17,6 +19,7 @@ type Peers struct {
 	log.Log
 	snapshot *atomic.Value
 	exit     chan struct{}
+	scorer   service.PeerScorer // nil if peers aren't scored
 
 	rand *rand.Rand
 }
@@ -31,6 +34,9 @@ func NewPeers(s PeerSubscriptionProvider, lg log.Log) *Peers {
 	value := atomic.Value{}
 	value.Store(make([]Peer, 0, 20))
 	pi := NewPeersImpl(&value, make(chan struct{}), lg)
+	if scorer, ok := s.(service.PeerScorer); ok {
+		pi.scorer = scorer
+	}
 	newPeerC, expiredPeerC := s.SubscribePeerEvents()
 	go pi.listenToPeers(newPeerC, expiredPeerC)
 	return pi
@@ -51,7 +57,7 @@ func (p *Peers) Close() {
 	close(p.exit)
 }
 
-// GetPeers returns a snapshot of the connected peers shuffled.
+// GetPeers returns a snapshot of the connected peers shuffled. If peers are scored, the best scored peers come first.
 // Method is not concurrent-safe.
 func (p *Peers) GetPeers() []Peer {
 	peers := p.snapshot.Load().([]Peer)
@@ -59,6 +65,13 @@ func (p *Peers) GetPeers() []Peer {
 	copy(cpy, peers) // if we dont copy we will shuffle orig array
 	p.With().Info("now connected", log.Int("n_peers", len(cpy)))
 	p.rand.Shuffle(len(cpy), func(i, j int) { cpy[i], cpy[j] = cpy[j], cpy[i] }) // shuffle peers order
+	if p.scorer != nil {
+		scores := make(map[Peer]float64, len(cpy))
+		for _, peer := range cpy {
+			scores[peer] = p.scorer.PeerScore(peer)
+		}
+		sort.SliceStable(cpy, func(i, j int) bool { return scores[cpy[i]] > scores[cpy[j]] })
+	}
 	return cpy
 }
 
@@ -34,6 +34,30 @@ func TestPeers_GetPeers(t *testing.T) {
 	assert.True(t, peers[0] == a, "returned wrong peer")
 }
 
+type scorerMock map[p2pcrypto.PublicKey]float64
+
+func (s scorerMock) ReportPeer(peer p2pcrypto.PublicKey, event service.PeerEvent) {}
+
+func (s scorerMock) PeerScore(peer p2pcrypto.PublicKey) float64 {
+	return s[peer]
+}
+
+func TestPeers_GetPeersByScore(t *testing.T) {
+	pi, n, _ := getPeers(service.NewSimulator().NewNode())
+	defer pi.Close()
+	good, bad := p2pcrypto.NewRandomPubkey(), p2pcrypto.NewRandomPubkey()
+	others := []p2pcrypto.PublicKey{p2pcrypto.NewRandomPubkey(), p2pcrypto.NewRandomPubkey()}
+	pi.scorer = scorerMock{good: 10, bad: -10}
+	for _, p := range append(others, bad, good) {
+		n <- p
+	}
+	time.Sleep(10 * time.Millisecond) //allow context switch
+	peers := pi.GetPeers()
+	assert.Len(t, peers, 4)
+	assert.True(t, peers[0] == good, "the best scored peer should come first")
+	assert.True(t, peers[3] == bad, "the worst scored peer should come last")
+}
+
 func TestPeers_Close(t *testing.T) {
 	pi, n, _ := getPeers(service.NewSimulator().NewNode())
 	a := p2pcrypto.NewRandomPubkey()
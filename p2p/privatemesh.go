@@ -0,0 +1,44 @@
+package p2p
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spacemeshos/go-spacemesh/p2p/node"
+	"github.com/spacemeshos/go-spacemesh/p2p/p2pcrypto"
+)
+
+// ErrNotAllowed is returned for messages of peers outside the private mesh
+var ErrNotAllowed = errors.New("peer isn't allowed in the private mesh")
+
+// parseAllowedPeers returns the IDs of the peers of the private mesh, the allowed peers and the static peers. It
+// returns nil if the node isn't in a private mesh, which allows all peers.
+func parseAllowedPeers(private bool, allowed []string, static []*node.Info) (map[string]struct{}, error) {
+	if !private {
+		return nil, nil
+	}
+	ids := make(map[string]struct{}, len(allowed)+len(static))
+	for _, str := range allowed {
+		pk, err := parsePeerID(str)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowed peer %v: %v", str, err)
+		}
+		ids[pk.String()] = struct{}{}
+	}
+	for _, nd := range static {
+		ids[nd.PublicKey().String()] = struct{}{}
+	}
+	if len(ids) == 0 {
+		return nil, errors.New("a private mesh requires allowed or static peers")
+	}
+	return ids, nil
+}
+
+// isAllowed returns whether the node may connect to the peer or exchange discovery messages with it
+func (s *Switch) isAllowed(peer p2pcrypto.PublicKey) bool {
+	if s.allowedPeers == nil {
+		return true
+	}
+	_, ok := s.allowedPeers[peer.String()]
+	return ok
+}
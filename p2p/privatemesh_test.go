@@ -0,0 +1,79 @@
+package p2p
+
+import (
+	"context"
+	inet "net"
+	"testing"
+
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/p2p/discovery"
+	"github.com/spacemeshos/go-spacemesh/p2p/net"
+	"github.com/spacemeshos/go-spacemesh/p2p/node"
+	"github.com/spacemeshos/go-spacemesh/p2p/p2pcrypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAllowedPeers(t *testing.T) {
+	allowed := node.GenerateRandomNodeData()
+	static := node.GenerateRandomNodeData()
+
+	ids, err := parseAllowedPeers(false, []string{allowed.PublicKey().String()}, nil)
+	require.NoError(t, err)
+	require.Nil(t, ids)
+
+	ids, err = parseAllowedPeers(true, []string{allowed.PublicKey().String()}, []*node.Info{static})
+	require.NoError(t, err)
+	require.Len(t, ids, 2)
+	require.Contains(t, ids, allowed.PublicKey().String())
+	require.Contains(t, ids, static.PublicKey().String())
+
+	_, err = parseAllowedPeers(true, []string{"not a key"}, nil)
+	require.Error(t, err)
+	_, err = parseAllowedPeers(true, nil, nil)
+	require.Error(t, err)
+}
+
+func TestSwarm_PrivateMesh(t *testing.T) {
+	allowed := node.GenerateRandomNodeData()
+	stranger := node.GenerateRandomNodeData()
+	cfg := configWithPort(0)
+	cfg.SwarmConfig.Bootstrap = true
+	cfg.SwarmConfig.PrivateMesh = true
+	cfg.SwarmConfig.AllowedPeers = []string{allowed.String()}
+	p := p2pTestNoStart(t, cfg)
+	require.False(t, p.config.SwarmConfig.Bootstrap)
+
+	cpm := newCpoolMock()
+	dialed := make(chan p2pcrypto.PublicKey, 2)
+	cpm.f = func(address inet.Addr, pk p2pcrypto.PublicKey) (net.Connection, error) {
+		dialed <- pk
+		return net.NewConnectionMock(pk), nil
+	}
+	p.cPool = cpm
+
+	// connections of peers outside the mesh are rejected
+	p.onNewConnection(net.NewConnectionEvent{Conn: net.NewConnectionMock(stranger.PublicKey()), Node: stranger})
+	require.Equal(t, stranger.PublicKey(), <-cpm.keyRemoved)
+	require.False(t, p.hasIncomingPeer(stranger.PublicKey()))
+	p.onNewConnection(net.NewConnectionEvent{Conn: net.NewConnectionMock(allowed.PublicKey()), Node: allowed})
+	require.True(t, p.hasIncomingPeer(allowed.PublicKey()))
+
+	// peers outside the mesh aren't dialed
+	mdht := new(discovery.MockPeerStore)
+	mdht.SelectPeersFunc = func(ctx context.Context, qty int) []*node.Info {
+		return []*node.Info{stranger}
+	}
+	p.discover = mdht
+	require.Zero(t, p.getMorePeers(1))
+	require.Len(t, dialed, 0)
+
+	// discovery messages aren't sent to peers outside the mesh
+	require.Equal(t, ErrNotAllowed, p.udpServer.SendMessage(stranger.PublicKey(), "discovery", []byte("ping")))
+}
+
+func TestSwarm_PrivateMeshWithoutPeers(t *testing.T) {
+	cfg := configWithPort(0)
+	cfg.SwarmConfig.PrivateMesh = true
+	_, err := newSwarm(context.TODO(), cfg, log.NewDefault(t.Name()), "")
+	require.Error(t, err)
+}
@@ -0,0 +1,124 @@
+package p2p
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/p2p/p2pcrypto"
+	"github.com/spacemeshos/go-spacemesh/p2p/service"
+)
+
+// peerEventScores are the changes of the score of a peer for each behavior
+var peerEventScores = map[service.PeerEvent]float64{
+	service.ValidMessage:   1,
+	service.UsefulResponse: 2,
+	service.InvalidMessage: -20,
+	service.RequestTimeout: -5,
+}
+
+const (
+	// scores are kept within [-maxScore, maxScore], so a peer can't bank good behavior indefinitely
+	maxScore = 100
+	// scoreHalfLife is the time it takes the score of a peer to decay to half its value
+	scoreHalfLife = 30 * time.Minute
+	// peers whose score drops below scoreDisconnectThreshold are disconnected and refused until it decays above it
+	scoreDisconnectThreshold = -50
+	// maxScoredPeers bounds the number of peers whose scores are kept, including disconnected peers
+	maxScoredPeers = 1000
+)
+
+type peerScore struct {
+	value   float64
+	updated time.Time
+}
+
+// decayed returns the score at the given time
+func (ps peerScore) decayed(now time.Time) float64 {
+	return ps.value * math.Pow(0.5, float64(now.Sub(ps.updated))/float64(scoreHalfLife))
+}
+
+// peerScores tracks the reputation of peers. Scores are kept after peers disconnect, so peers can't reset their score
+// by reconnecting, until they decay or are evicted to make room for other peers.
+type peerScores struct {
+	mu     sync.Mutex
+	scores map[p2pcrypto.PublicKey]*peerScore
+	now    func() time.Time
+}
+
+func newPeerScores() *peerScores {
+	return &peerScores{scores: make(map[p2pcrypto.PublicKey]*peerScore), now: time.Now}
+}
+
+// report updates the score of the peer for the event and returns the new score
+func (ps *peerScores) report(peer p2pcrypto.PublicKey, event service.PeerEvent) float64 {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	now := ps.now()
+	score, ok := ps.scores[peer]
+	if !ok {
+		if len(ps.scores) >= maxScoredPeers {
+			ps.evict(now)
+		}
+		score = &peerScore{}
+		ps.scores[peer] = score
+	}
+	score.value = math.Max(-maxScore, math.Min(maxScore, score.decayed(now)+peerEventScores[event]))
+	score.updated = now
+	return score.value
+}
+
+// evict removes the score that decayed the most, assuming it's the least relevant
+func (ps *peerScores) evict(now time.Time) {
+	var oldest p2pcrypto.PublicKey
+	var oldestUpdate time.Time
+	for peer, score := range ps.scores {
+		if math.Abs(score.decayed(now)) < 1 {
+			delete(ps.scores, peer)
+			return
+		}
+		if oldest == nil || score.updated.Before(oldestUpdate) {
+			oldest, oldestUpdate = peer, score.updated
+		}
+	}
+	delete(ps.scores, oldest)
+}
+
+// score returns the current score of the peer, zero if it has none
+func (ps *peerScores) score(peer p2pcrypto.PublicKey) float64 {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	score, ok := ps.scores[peer]
+	if !ok {
+		return 0
+	}
+	return score.decayed(ps.now())
+}
+
+// ReportPeer updates the score of the peer for its behavior. Peers whose score drops below the threshold are
+// disconnected, unless they're protected.
+func (s *Switch) ReportPeer(peer p2pcrypto.PublicKey, event service.PeerEvent) {
+	if peer == s.lNode.PublicKey() {
+		// our own broadcasts
+		return
+	}
+	score := s.scores.report(peer, event)
+	if score >= scoreDisconnectThreshold || s.isProtected(peer) {
+		return
+	}
+	if s.hasIncomingPeer(peer) || s.hasOutgoingPeer(peer) {
+		s.logger.Warning("disconnecting peer %v with score %.1f", peer, score)
+		s.cPool.CloseConnection(peer)
+		s.Disconnect(peer)
+	}
+}
+
+// PeerScore returns the score of the peer, positive for useful peers and negative for misbehaving ones.
+func (s *Switch) PeerScore(peer p2pcrypto.PublicKey) float64 {
+	return s.scores.score(peer)
+}
+
+// lowScore returns whether the peer is refused because of its score
+func (s *Switch) lowScore(peer p2pcrypto.PublicKey) bool {
+	return s.scores.score(peer) < scoreDisconnectThreshold && !s.isProtected(peer)
+}
@@ -0,0 +1,123 @@
+package p2p
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/p2p/net"
+	"github.com/spacemeshos/go-spacemesh/p2p/node"
+	"github.com/spacemeshos/go-spacemesh/p2p/p2pcrypto"
+	"github.com/spacemeshos/go-spacemesh/p2p/service"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeerScores(t *testing.T) {
+	now := time.Now()
+	ps := newPeerScores()
+	ps.now = func() time.Time { return now }
+	peer := node.GenerateRandomNodeData().PublicKey()
+
+	require.Equal(t, float64(0), ps.score(peer))
+	require.Equal(t, float64(1), ps.report(peer, service.ValidMessage))
+	require.Equal(t, float64(3), ps.report(peer, service.UsefulResponse))
+	require.Equal(t, float64(-2), ps.report(peer, service.RequestTimeout))
+
+	// scores decay by half every half life
+	now = now.Add(scoreHalfLife)
+	require.InDelta(t, -1, ps.score(peer), 1e-9)
+
+	// scores are clamped
+	for i := 0; i < 10; i++ {
+		ps.report(peer, service.InvalidMessage)
+	}
+	require.Equal(t, float64(-maxScore), ps.score(peer))
+}
+
+func TestPeerScores_Evict(t *testing.T) {
+	now := time.Now()
+	ps := newPeerScores()
+	ps.now = func() time.Time { return now }
+
+	peers := make([]p2pcrypto.PublicKey, maxScoredPeers)
+	for i := range peers {
+		peers[i] = node.GenerateRandomNodeData().PublicKey()
+		ps.report(peers[i], service.InvalidMessage)
+		now = now.Add(time.Second)
+	}
+
+	// the oldest score is evicted to make room
+	ps.report(node.GenerateRandomNodeData().PublicKey(), service.InvalidMessage)
+	require.Len(t, ps.scores, maxScoredPeers)
+	require.Equal(t, float64(0), ps.score(peers[0]))
+	require.NotEqual(t, float64(0), ps.score(peers[1]))
+
+	// scores that decayed to nothing are evicted first
+	for ps.score(peers[1]) < 0 {
+		ps.report(peers[1], service.ValidMessage)
+	}
+	ps.report(node.GenerateRandomNodeData().PublicKey(), service.InvalidMessage)
+	require.Len(t, ps.scores, maxScoredPeers)
+	require.Equal(t, float64(0), ps.score(peers[1]))
+	require.NotEqual(t, float64(0), ps.score(peers[2]))
+}
+
+func TestSwarm_ReportPeer(t *testing.T) {
+	cfg := configWithPort(0)
+	cfg.SwarmConfig.Bootstrap = false
+	cfg.SwarmConfig.Gossip = false
+	p := p2pTestNoStart(t, cfg)
+	cpm := newCpoolMock()
+	p.cPool = cpm
+
+	bad := node.GenerateRandomNodeData().PublicKey()
+	require.NoError(t, p.addIncomingPeer(bad))
+	p.ReportPeer(bad, service.InvalidMessage)
+	p.ReportPeer(bad, service.InvalidMessage)
+	require.True(t, p.hasIncomingPeer(bad))
+	require.False(t, p.lowScore(bad))
+
+	// the peer is disconnected once its score drops below the threshold
+	p.ReportPeer(bad, service.InvalidMessage)
+	require.Equal(t, bad, <-cpm.keyRemoved)
+	require.False(t, p.hasIncomingPeer(bad))
+	require.True(t, p.lowScore(bad))
+
+	// and refused when it reconnects
+	p.onNewConnection(net.NewConnectionEvent{Conn: net.NewConnectionMock(bad), Node: node.NewNode(bad, nil, 0, 0)})
+	require.Equal(t, bad, <-cpm.keyRemoved)
+	require.False(t, p.hasIncomingPeer(bad))
+
+	// our own messages aren't scored
+	p.ReportPeer(p.lNode.PublicKey(), service.InvalidMessage)
+	require.Equal(t, float64(0), p.PeerScore(p.lNode.PublicKey()))
+}
+
+func TestSwarm_PruneLowScoredPeer(t *testing.T) {
+	cfg := configWithPort(0)
+	cfg.SwarmConfig.Bootstrap = false
+	cfg.SwarmConfig.Gossip = false
+	cfg.MaxInboundPeers = 2
+	p := p2pTestNoStart(t, cfg)
+	cpm := newCpoolMock()
+	p.cPool = cpm
+
+	good := node.GenerateRandomNodeData().PublicKey()
+	bad := node.GenerateRandomNodeData().PublicKey()
+	require.NoError(t, p.addIncomingPeer(good))
+	require.NoError(t, p.addIncomingPeer(bad))
+	p.ReportPeer(good, service.UsefulResponse)
+	p.ReportPeer(bad, service.RequestTimeout)
+
+	// a peer that misbehaved more than the new peer is pruned to make room
+	newPeer := node.GenerateRandomNodeData().PublicKey()
+	require.NoError(t, p.addIncomingPeer(newPeer))
+	require.Equal(t, bad, <-cpm.keyRemoved)
+	require.True(t, p.hasIncomingPeer(good))
+	require.True(t, p.hasIncomingPeer(newPeer))
+	require.False(t, p.hasIncomingPeer(bad))
+
+	// no peer is pruned for a peer with a lower score
+	p.ReportPeer(bad, service.InvalidMessage)
+	require.EqualError(t, p.addIncomingPeer(bad), "reached max connections")
+	require.Len(t, cpm.keyRemoved, 0)
+}
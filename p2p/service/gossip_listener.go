@@ -17,16 +17,21 @@ type Listener struct {
 	channels []chan GossipMessage
 	stoppers []chan struct{}
 	syncer   Syncer
+	workers  int
 	wg       sync.WaitGroup
 }
 
-// NewListener creates a new listener struct
-func NewListener(net Service, syncer Syncer, log log.Log) *Listener {
+// NewListener creates a new listener struct, the messages of every channel are handled by workers goroutines
+func NewListener(net Service, syncer Syncer, workers int, log log.Log) *Listener {
+	if workers < 1 {
+		workers = 1
+	}
 	return &Listener{
-		Log:    &log,
-		net:    net,
-		syncer: syncer,
-		wg:     sync.WaitGroup{},
+		Log:     &log,
+		net:     net,
+		syncer:  syncer,
+		workers: workers,
+		wg:      sync.WaitGroup{},
 	}
 }
 
@@ -44,8 +49,10 @@ func (l *Listener) AddListener(channel string, priority priorityq.Priority, data
 	stop := make(chan struct{})
 	l.channels = append(l.channels, ch)
 	l.stoppers = append(l.stoppers, stop)
-	l.wg.Add(1)
-	go l.listenToGossip(dataHandler, ch, stop)
+	l.wg.Add(l.workers)
+	for i := 0; i < l.workers; i++ {
+		go l.listenToGossip(dataHandler, ch, stop)
+	}
 }
 
 // Stop stops listening to all gossip channels
@@ -33,7 +33,7 @@ func (*syncMock) IsSynced() bool {
 func Test_AddListener(t *testing.T) {
 	net := NewSimulator()
 	n1 := net.NewNode()
-	l := NewListener(n1, &syncMock{true}, log.New(n1.Info.ID.String(), "", ""))
+	l := NewListener(n1, &syncMock{true}, 1, log.New(n1.Info.ID.String(), "", ""))
 
 	var channelCount, secondChannel int32
 	wg := sync.WaitGroup{}
@@ -65,7 +65,7 @@ func Test_AddListener(t *testing.T) {
 func Test_AddListener_notSynced(t *testing.T) {
 	net := NewSimulator()
 	n1 := net.NewNode()
-	l := NewListener(n1, &syncMock{false}, log.New(n1.Info.ID.String(), "", ""))
+	l := NewListener(n1, &syncMock{false}, 1, log.New(n1.Info.ID.String(), "", ""))
 
 	var channelCount, secondChannel int32
 
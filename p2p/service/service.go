@@ -65,6 +65,27 @@ type Service interface {
 	Shutdown()
 }
 
+// PeerEvent is a behavior of a peer that changes its score.
+type PeerEvent int
+
+// These are the behaviors that affect the score of a peer
+const (
+	// ValidMessage is a gossip message of the peer that passed validation
+	ValidMessage PeerEvent = iota
+	// UsefulResponse is a response of the peer to a request, received in time
+	UsefulResponse
+	// InvalidMessage is a malformed or invalid message of the peer
+	InvalidMessage
+	// RequestTimeout is a request the peer didn't respond to in time
+	RequestTimeout
+)
+
+// PeerScorer is implemented by services that score peers by their behavior. Peers with low scores are disconnected.
+type PeerScorer interface {
+	ReportPeer(peer p2pcrypto.PublicKey, event PeerEvent)
+	PeerScore(peer p2pcrypto.PublicKey) float64
+}
+
 // Data is a wrapper around a message that can hold either raw bytes message or a req-res wrapper.
 type Data interface {
 	Bytes() []byte
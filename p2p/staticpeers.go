@@ -0,0 +1,139 @@
+package p2p
+
+import (
+	"fmt"
+	inet "net"
+	"strings"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/p2p/metrics"
+	"github.com/spacemeshos/go-spacemesh/p2p/node"
+	"github.com/spacemeshos/go-spacemesh/p2p/p2pcrypto"
+)
+
+// the backoff between failed attempts to connect a static peer doubles from staticPeerMinBackoff up to
+// staticPeerMaxBackoff
+const (
+	staticPeerMinBackoff = time.Second
+	staticPeerMaxBackoff = 5 * time.Minute
+)
+
+// staticPeerCheckInterval is how often a connected static peer is checked, in case its disconnection was missed
+const staticPeerCheckInterval = time.Minute
+
+// parseStaticPeers parses the static peers and the protected peers of the config. Static peers are protected too.
+func parseStaticPeers(static, protected []string) ([]*node.Info, map[string]struct{}, error) {
+	nodes := make([]*node.Info, 0, len(static))
+	ids := make(map[string]struct{}, len(static)+len(protected))
+	for _, str := range static {
+		nd, err := node.ParseNode(str)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid static peer %v: %v", str, err)
+		}
+		if nd.IP == nil {
+			return nil, nil, fmt.Errorf("invalid static peer %v: the address of the peer is missing", str)
+		}
+		nodes = append(nodes, nd)
+		ids[nd.PublicKey().String()] = struct{}{}
+	}
+	for _, str := range protected {
+		pk, err := parsePeerID(str)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid protected peer %v: %v", str, err)
+		}
+		ids[pk.String()] = struct{}{}
+	}
+	return nodes, ids, nil
+}
+
+// parsePeerID parses a peer ID in base58, or the ID of a node in the format of the bootstrap nodes
+func parsePeerID(str string) (p2pcrypto.PublicKey, error) {
+	if strings.HasPrefix(str, node.Scheme+"://") {
+		nd, err := node.ParseNode(str)
+		if err != nil {
+			return nil, err
+		}
+		return nd.PublicKey(), nil
+	}
+	return p2pcrypto.NewPublicKeyFromBase58(str)
+}
+
+// isProtected returns whether connection management must never drop or refuse the peer
+func (s *Switch) isProtected(peer p2pcrypto.PublicKey) bool {
+	_, ok := s.protectedPeers[peer.String()]
+	return ok
+}
+
+// keepStaticPeer keeps the node connected to a static peer until the switch shuts down. When the peer isn't
+// connected it's dialed, with an exponential backoff between failed attempts.
+func (s *Switch) keepStaticPeer(nd *node.Info) {
+	_, disc := s.SubscribePeerEvents()
+	backoff := staticPeerMinBackoff
+	check := time.NewTicker(staticPeerCheckInterval)
+	defer check.Stop()
+	for {
+		if !s.hasIncomingPeer(nd.PublicKey()) && !s.hasOutgoingPeer(nd.PublicKey()) {
+			if err := s.connectStaticPeer(nd); err != nil {
+				s.logger.Warning("failed to connect static peer %v, retrying in %v: %v", nd.PublicKey(), backoff, err)
+				tmr := time.NewTimer(backoff)
+				select {
+				case <-s.shutdown:
+					tmr.Stop()
+					return
+				case <-tmr.C:
+				}
+				if backoff *= 2; backoff > staticPeerMaxBackoff {
+					backoff = staticPeerMaxBackoff
+				}
+				continue
+			}
+			backoff = staticPeerMinBackoff
+		}
+
+		// wait until the peer disconnects
+	wait:
+		for {
+			select {
+			case <-s.shutdown:
+				return
+			case peer, ok := <-disc:
+				if !ok {
+					return
+				}
+				if peer.String() == nd.PublicKey().String() {
+					break wait
+				}
+			case <-check.C:
+				break wait
+			}
+		}
+	}
+}
+
+// connectStaticPeer dials a static peer and adds it to the outbound peers
+func (s *Switch) connectStaticPeer(nd *node.Info) error {
+	if s.banList.banned(nd.PublicKey(), nd.IP) {
+		return fmt.Errorf("peer is banned")
+	}
+	addr := inet.TCPAddr{IP: nd.IP, Port: int(nd.ProtocolPort)}
+	if _, err := s.cPool.GetConnection(&addr, nd.PublicKey()); err != nil {
+		return err
+	}
+	if s.hasIncomingPeer(nd.PublicKey()) {
+		// the peer connected us while we were dialing
+		return nil
+	}
+	s.outpeersMutex.Lock()
+	if _, ok := s.outpeers[nd.PublicKey()]; ok {
+		s.outpeersMutex.Unlock()
+		return nil
+	}
+	s.outpeers[nd.PublicKey()] = struct{}{}
+	s.outpeersMutex.Unlock()
+	s.groups.add(nd.PublicKey(), nd.IP, false)
+
+	s.publishNewPeer(nd.PublicKey(), true)
+	metrics.OutboundPeers.Add(1)
+	s.logger.Info("Neighborhood: connected static peer %v", nd.PublicKey())
+	return nil
+}
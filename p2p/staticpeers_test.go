@@ -0,0 +1,129 @@
+package p2p
+
+import (
+	"errors"
+	inet "net"
+	"testing"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/p2p/net"
+	"github.com/spacemeshos/go-spacemesh/p2p/node"
+	"github.com/spacemeshos/go-spacemesh/p2p/p2pcrypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStaticPeers(t *testing.T) {
+	static := node.GenerateRandomNodeData()
+	protected := node.GenerateRandomNodeData()
+	protectedNode := node.GenerateRandomNodeData()
+
+	nodes, ids, err := parseStaticPeers([]string{static.String()}, []string{protected.PublicKey().String(), protectedNode.String()})
+	require.NoError(t, err)
+	require.Len(t, nodes, 1)
+	require.Equal(t, static.PublicKey(), nodes[0].PublicKey())
+	require.True(t, static.IP.Equal(nodes[0].IP))
+	require.Equal(t, static.ProtocolPort, nodes[0].ProtocolPort)
+	require.Len(t, ids, 3)
+	for _, nd := range []*node.Info{static, protected, protectedNode} {
+		require.Contains(t, ids, nd.PublicKey().String())
+	}
+
+	for _, tc := range []struct {
+		name              string
+		static, protected []string
+	}{
+		{"static peer without an address", []string{"spacemesh://" + static.PublicKey().String()}, nil},
+		{"malformed static peer", []string{"1.2.3.4:7513"}, nil},
+		{"malformed protected peer", nil, []string{"not a key"}},
+		{"malformed protected node", nil, []string{"spacemesh://x@1.2.3.4:7513"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, err := parseStaticPeers(tc.static, tc.protected)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestSwarm_ProtectedPeers(t *testing.T) {
+	protected := node.GenerateRandomNodeData()
+	cfg := configWithPort(0)
+	cfg.MaxInboundPeers = 1
+	cfg.SwarmConfig.ProtectedPeers = []string{protected.PublicKey().String()}
+	p := p2pTestNoStart(t, cfg)
+
+	require.NoError(t, p.addIncomingPeer(node.GenerateRandomNodeData().PublicKey()))
+	require.Error(t, p.addIncomingPeer(node.GenerateRandomNodeData().PublicKey()))
+	// protected peers are accepted over the limit of inbound peers
+	require.NoError(t, p.addIncomingPeer(protected.PublicKey()))
+	require.True(t, p.hasIncomingPeer(protected.PublicKey()))
+}
+
+func TestSwarm_StaticPeerBackoff(t *testing.T) {
+	static := node.GenerateRandomNodeData()
+	cfg := configWithPort(0)
+	cfg.SwarmConfig.StaticPeers = []string{static.String()}
+	p := p2pTestNoStart(t, cfg)
+	defer p.Shutdown()
+
+	// the first dial fails, the peer is dialed again after the backoff
+	dials := make(chan time.Time, 10)
+	failed := false
+	cpm := newCpoolMock()
+	cpm.f = func(address inet.Addr, pk p2pcrypto.PublicKey) (net.Connection, error) {
+		require.Equal(t, static.PublicKey(), pk)
+		require.Equal(t, (&inet.TCPAddr{IP: static.IP, Port: int(static.ProtocolPort)}).String(), address.String())
+		dials <- time.Now()
+		if !failed {
+			failed = true
+			return nil, errors.New("can't make connection")
+		}
+		return net.NewConnectionMock(pk), nil
+	}
+	p.cPool = cpm
+	conn, disc := p.SubscribePeerEvents()
+
+	go p.keepStaticPeer(p.staticPeers[0])
+	select {
+	case peer := <-conn:
+		require.Equal(t, static.PublicKey(), peer)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "static peer wasn't connected")
+	}
+	require.Len(t, dials, 2)
+	first, second := <-dials, <-dials
+	require.True(t, second.Sub(first) >= staticPeerMinBackoff)
+	require.True(t, p.hasOutgoingPeer(static.PublicKey()))
+
+	// the peer is dialed again right after it disconnects
+	p.Disconnect(static.PublicKey())
+	require.Equal(t, static.PublicKey(), <-disc)
+	select {
+	case peer := <-conn:
+		require.Equal(t, static.PublicKey(), peer)
+	case <-time.After(time.Second):
+		require.Fail(t, "static peer wasn't reconnected")
+	}
+	require.True(t, p.hasOutgoingPeer(static.PublicKey()))
+}
+
+func TestSwarm_StaticPeers(t *testing.T) {
+	cfg := configWithPort(0)
+	cfg.SwarmConfig.Bootstrap = false
+	cfg.SwarmConfig.RandomConnections = 0
+	p1 := p2pTestInstance(t, cfg)
+	defer p1.Shutdown()
+
+	cfg.SwarmConfig.StaticPeers = StringIdentifiers(p1)
+	p2 := p2pTestNoStart(t, cfg)
+	defer p2.Shutdown()
+	conn, _ := p2.SubscribePeerEvents()
+	require.NoError(t, p2.Start())
+
+	select {
+	case peer := <-conn:
+		require.Equal(t, p1.LocalNode().PublicKey(), peer)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "static peer wasn't connected")
+	}
+	require.True(t, p2.hasOutgoingPeer(p1.LocalNode().PublicKey()))
+}
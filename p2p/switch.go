@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/events"
 	"github.com/spacemeshos/go-spacemesh/log"
 	"github.com/spacemeshos/go-spacemesh/nattraversal"
 	"github.com/spacemeshos/go-spacemesh/p2p/config"
@@ -22,7 +23,11 @@ import (
 	"github.com/spacemeshos/go-spacemesh/priorityq"
 	"github.com/spacemeshos/go-spacemesh/timesync"
 
+	"math"
 	inet "net"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -37,10 +42,40 @@ const UPNPRetries = 20
 type cPool interface {
 	GetConnection(address inet.Addr, pk p2pcrypto.PublicKey) (net.Connection, error)
 	GetConnectionIfExists(pk p2pcrypto.PublicKey) (net.Connection, error)
+	OnDialedConnection(conn net.Connection) error
 	CloseConnection(key p2pcrypto.PublicKey)
 	Shutdown()
 }
 
+// NATStatus describes whether the node mapped its port on a NAT gateway using UPnP or NAT-PMP.
+type NATStatus int
+
+// These are the possible NAT statuses
+const (
+	// NATDisabled means that acquiring a port from a gateway is disabled in the config
+	NATDisabled NATStatus = iota
+	// NATNoGateway means that no UPnP or NAT-PMP gateway was discovered
+	NATNoGateway
+	// NATPortFailed means that the gateway didn't map the port
+	NATPortFailed
+	// NATPortMapped means that the gateway forwards the port to this node
+	NATPortMapped
+)
+
+func (n NATStatus) String() string {
+	switch n {
+	case NATDisabled:
+		return "disabled"
+	case NATNoGateway:
+		return "no gateway"
+	case NATPortFailed:
+		return "port mapping failed"
+	case NATPortMapped:
+		return "port mapped"
+	}
+	return fmt.Sprintf("unknown(%d)", int(n))
+}
+
 // Switch is the heart of the p2p package. it runs and orchestrates all services within it. It provides the external interface
 // for protocols to access peers or receive incoming messages.
 type Switch struct {
@@ -68,6 +103,11 @@ type Switch struct {
 	gossipProtocolHandlers map[string]chan service.GossipMessage
 	protocolHandlerMutex   sync.RWMutex
 
+	// gossip messages wait in gossipQ for their protocols by the priority of the protocols, so consensus messages
+	// aren't delayed behind bulk traffic
+	gossipQ          *priorityq.Queue
+	gossipPriorities map[string]priorityq.Priority
+
 	// Networking
 	network    *net.Net    // (tcp) networking service
 	udpnetwork *net.UDPNet // (udp) networking service
@@ -93,6 +133,31 @@ type Switch struct {
 	outpeers      map[p2pcrypto.PublicKey]struct{}
 	inpeers       map[p2pcrypto.PublicKey]struct{}
 
+	// the protocols of the messages received from every peer, for diagnostics
+	peerProtocolsMutex sync.Mutex
+	peerProtocols      map[string]map[string]struct{}
+
+	// the protocol versions and the capabilities of peers that negotiated them, by ID
+	peerCapsMutex sync.RWMutex
+	peerCaps      map[string]peerCapabilities
+
+	// the peers the node is connecting to through hole punching, with the channels of the dials waiting for a relay
+	punchMutex sync.Mutex
+	punches    map[string]chan string
+
+	// banned peer IDs and IP addresses, enforced on inbound and outbound connections
+	banList *banList
+	// the reputation of peers, peers with low scores are disconnected and refused
+	scores *peerScores
+	// the subnets of connected peers, inbound peers of each subnet are limited and outbound peers are diversified
+	groups *addrGroups
+
+	// peers the node always keeps connected, and peers connection management never drops, by ID
+	staticPeers    []*node.Info
+	protectedPeers map[string]struct{}
+	// the peers of the private mesh, nil unless the node is in a private mesh
+	allowedPeers map[string]struct{}
+
 	morePeersReq      chan struct{}
 	connectingTimeout time.Duration
 
@@ -102,6 +167,9 @@ type Switch struct {
 
 	// function to release upnp port when shutting down
 	releaseUpnp func()
+	natStatus   NATStatus
+	// mappedAddress is the external address of the port mapped on the gateway
+	mappedAddress string
 }
 
 func (s *Switch) waitForBoot() error {
@@ -152,6 +220,42 @@ func newSwarm(ctx context.Context, config config.Config, logger log.Log, datadir
 		}
 	}
 
+	staticPeers, protectedPeers, err := parseStaticPeers(config.SwarmConfig.StaticPeers, config.SwarmConfig.ProtectedPeers)
+	if err != nil {
+		return nil, err
+	}
+	allowedPeers, err := parseAllowedPeers(config.SwarmConfig.PrivateMesh, config.SwarmConfig.AllowedPeers, staticPeers)
+	if err != nil {
+		return nil, err
+	}
+	groups, err := newAddrGroups(config.IPv4GroupPrefix, config.IPv6GroupPrefix)
+	if err != nil {
+		return nil, err
+	}
+	if config.ReservedPeerSlots < 0 || config.ReservedPeerSlots > config.MaxInboundPeers ||
+		config.ReservedPeerSlots > config.MaxOutboundPeers {
+		return nil, fmt.Errorf("reserved peer slots (%d) must be within the inbound (%d) and outbound (%d) peer limits",
+			config.ReservedPeerSlots, config.MaxInboundPeers, config.MaxOutboundPeers)
+	}
+	if err := gossip.ValidateConfig(config.SwarmConfig); err != nil {
+		return nil, err
+	}
+	if allowedPeers != nil {
+		logger.Info("Private mesh of %d peers, not bootstrapping", len(allowedPeers))
+		config.SwarmConfig.Bootstrap = false
+	}
+	if err := config.ValidateTransport(); err != nil {
+		return nil, err
+	}
+	if config.Proxy != "" {
+		// discovery runs over UDP, which can't be proxied, and listening publicly or forwarding a port would reveal the
+		// address of the node
+		logger.Info("Dialing through proxy, listening on loopback only and not bootstrapping")
+		config.TCPInterface = "127.0.0.1"
+		config.AcquirePort = false
+		config.SwarmConfig.Bootstrap = false
+	}
+
 	// Create networking
 
 	n, err := net.NewNet(config, l, logger.WithName("tcpnet"))
@@ -183,12 +287,23 @@ func newSwarm(ctx context.Context, config config.Config, logger log.Log, datadir
 		morePeersReq:      make(chan struct{}, config.MaxInboundPeers+config.OutboundPeersTarget),
 		inpeers:           make(map[p2pcrypto.PublicKey]struct{}),
 		outpeers:          make(map[p2pcrypto.PublicKey]struct{}),
+		peerProtocols:     make(map[string]map[string]struct{}),
+		peerCaps:          make(map[string]peerCapabilities),
+		punches:           make(map[string]chan string),
+		banList:           newBanList(banListPath(datadir), logger),
+		scores:            newPeerScores(),
+		groups:            groups,
+		staticPeers:       staticPeers,
+		protectedPeers:    protectedPeers,
+		allowedPeers:      allowedPeers,
 		newPeerSub:        make([]chan p2pcrypto.PublicKey, 0, 10),
 		delPeerSub:        make([]chan p2pcrypto.PublicKey, 0, 10),
 		connectingTimeout: ConnectingTimeout,
 
 		directProtocolHandlers: make(map[string]chan service.DirectMessage),
 		gossipProtocolHandlers: make(map[string]chan service.GossipMessage),
+		gossipQ:                priorityq.New(config.BufferSize),
+		gossipPriorities:       make(map[string]priorityq.Priority),
 
 		network:    n,
 		udpnetwork: udpnet,
@@ -196,6 +311,9 @@ func newSwarm(ctx context.Context, config config.Config, logger log.Log, datadir
 
 	// Create the udp version of Switch
 	mux := NewUDPMux(s.lNode, s.lookupFunc, udpnet, s.config.NetworkID, s.logger)
+	if allowedPeers != nil {
+		mux.allowed = s.isAllowed
+	}
 	s.udpServer = mux
 
 	// todo : if discovery on
@@ -217,6 +335,15 @@ func newSwarm(ctx context.Context, config config.Config, logger log.Log, datadir
 
 	s.cPool = cpool
 
+	// peers may compress the messages they send us
+	s.network.AddCapability(compressionCapability)
+	if config.HolePunch {
+		s.network.AddCapability(holePunchCapability)
+	}
+	if config.Relay {
+		s.network.AddCapability(relayCapability)
+	}
+
 	s.gossip = gossip.NewProtocol(config.SwarmConfig, s, peers.NewPeers(s, s.logger), s.LocalNode().PublicKey(), s.logger)
 
 	s.logger.Debug("Created newSwarm with key %s", l.PublicKey())
@@ -228,13 +355,39 @@ func (s *Switch) lookupFunc(target p2pcrypto.PublicKey) (*node.Info, error) {
 }
 
 func (s *Switch) onNewConnection(nce net.NewConnectionEvent) {
+	if s.banList.banned(nce.Node.PublicKey(), addrIP(nce.Conn.RemoteAddr())) {
+		s.logger.Info("Rejecting connection from banned peer %v at %v", nce.Node.PublicKey(), nce.Conn.RemoteAddr())
+		s.cPool.CloseConnection(nce.Node.PublicKey())
+		return
+	}
+	if !s.isAllowed(nce.Node.PublicKey()) {
+		s.logger.Info("Rejecting connection from peer %v outside the private mesh", nce.Node.PublicKey())
+		s.cPool.CloseConnection(nce.Node.PublicKey())
+		return
+	}
+	if s.lowScore(nce.Node.PublicKey()) {
+		s.logger.Info("Rejecting connection from peer %v with a low score", nce.Node.PublicKey())
+		s.cPool.CloseConnection(nce.Node.PublicKey())
+		return
+	}
+	ip := addrIP(nce.Conn.RemoteAddr())
+	if s.config.MaxInboundPerGroup > 0 && s.groups.inboundCount(ip) >= s.config.MaxInboundPerGroup &&
+		!s.isProtected(nce.Node.PublicKey()) {
+		s.logger.Info("Rejecting connection from peer %v, reached max inbound connections from the subnet of %v",
+			nce.Node.PublicKey(), ip)
+		s.cPool.CloseConnection(nce.Node.PublicKey())
+		return
+	}
 	// todo: consider doing cpool actions from here instead of registering cpool as well.
 	err := s.addIncomingPeer(nce.Node.PublicKey())
 	if err != nil {
 		s.logger.Warning("Error adding new connection %v, err: %v", nce.Node.PublicKey(), err)
 		// todo: send rejection reason
 		s.cPool.CloseConnection(nce.Node.PublicKey())
+		return
 	}
+	s.groups.add(nce.Node.PublicKey(), ip, true)
+	s.acceptCapabilities(nce)
 }
 
 func (s *Switch) onClosedConnection(cwe net.ConnectionWithErr) {
@@ -256,11 +409,19 @@ func (s *Switch) Start() error {
 	atomic.StoreUint32(&s.started, 1)
 	s.logger.Debug("Starting the p2p layer")
 
-	tcpListener, udpListener, err := s.getListeners(getTCPListener, getUDPListener, discoverUPnPGateway)
+	listenTCP := func(tcpAddr *inet.TCPAddr) (inet.Listener, error) {
+		return net.Listen(tcpAddr, s.config.HolePunch)
+	}
+	tcpListener, udpListener, err := s.getListeners(listenTCP, getUDPListener, discoverGateway(s.config.NATPMP, discoverUPnPGateway, discoverNATPMPGateway))
 	if err != nil {
 		return fmt.Errorf("error getting port: %v", err)
 	}
 
+	if s.config.Transport == config.TransportQUIC && s.config.Proxy == "" {
+		if err := s.startQUIC(); err != nil {
+			return err
+		}
+	}
 	s.network.Start(tcpListener)
 	s.udpnetwork.Start(udpListener)
 
@@ -276,6 +437,9 @@ func (s *Switch) Start() error {
 
 	s.logger.Debug("Starting to listen for network messages")
 	s.listenToNetworkMessages() // fires up a goroutine for each queue of messages
+	go s.deliverGossipMessages()
+	go s.capabilitiesLoop(s.RegisterDirectProtocol(capabilitiesProtocol))
+	go s.holePunchLoop(s.RegisterDirectProtocol(holePunchProtocol))
 	s.logger.Debug("starting the udp server")
 
 	// TODO : insert new addresses to discovery
@@ -331,6 +495,185 @@ func (s *Switch) LocalNode() node.LocalNode {
 	return s.lNode
 }
 
+// PeerInfo describes a connected gossip peer.
+type PeerInfo struct {
+	ID       p2pcrypto.PublicKey
+	Address  string // empty if there's no open connection to the peer
+	Outbound bool
+	// Score is the probability of the peer's address to be selected from the routing table, 0 if it isn't there.
+	Score float64
+	// ConnectedSince and LastActivity are the times the connection to the peer was created and last sent or
+	// received a message, zero if there's no open connection.
+	ConnectedSince time.Time
+	LastActivity   time.Time
+	// Protocols are the protocols of the messages received from the peer, sorted.
+	Protocols []string
+	// Reputation is the score of the peer's behavior, positive for useful peers and negative for misbehaving ones.
+	Reputation float64
+}
+
+// NetworkInfo is a snapshot of the connectivity of the node.
+type NetworkInfo struct {
+	ID         p2pcrypto.PublicKey
+	TCPAddress string
+	UDPAddress string
+	// ExternalAddresses are our addresses as reported by peers that answered our pings.
+	ExternalAddresses []discovery.ExternalAddress
+	NAT               NATStatus
+	// MappedAddress is the external address the NAT gateway forwards to the node, empty if no port is mapped.
+	MappedAddress string
+	// GossipProtocols are the protocols we gossip, every gossip peer relays all of them.
+	GossipProtocols []string
+	// GossipCache is the occupancy of the cache of seen gossip messages.
+	GossipCache  gossip.CacheStats
+	Peers        []PeerInfo
+	RoutingTable []discovery.AddressInfo
+}
+
+// NetworkInfo returns the addresses, peers and routing table of the node.
+func (s *Switch) NetworkInfo() NetworkInfo {
+	info := NetworkInfo{
+		ID:                s.lNode.PublicKey(),
+		ExternalAddresses: s.discover.ExternalAddresses(),
+		NAT:               s.natStatus,
+		MappedAddress:     s.mappedAddress,
+		GossipCache:       s.gossip.CacheStats(),
+		RoutingTable:      s.discover.Addresses(),
+	}
+	if atomic.LoadUint32(&s.started) == 1 {
+		info.TCPAddress = s.network.LocalAddr().String()
+		info.UDPAddress = s.udpnetwork.LocalAddr().String()
+	}
+
+	s.protocolHandlerMutex.RLock()
+	for protocol := range s.gossipProtocolHandlers {
+		info.GossipProtocols = append(info.GossipProtocols, protocol)
+	}
+	s.protocolHandlerMutex.RUnlock()
+	sort.Strings(info.GossipProtocols)
+
+	scores := make(map[string]float64, len(info.RoutingTable))
+	for _, addr := range info.RoutingTable {
+		scores[addr.Info.PublicKey().String()] = addr.Score
+	}
+	addPeers := func(peers map[p2pcrypto.PublicKey]struct{}, outbound bool) {
+		for peer := range peers {
+			p := PeerInfo{ID: peer, Outbound: outbound, Score: scores[peer.String()], Protocols: s.receivedProtocols(peer),
+				Reputation: s.PeerScore(peer)}
+			if conn, err := s.cPool.GetConnectionIfExists(peer); err == nil {
+				p.Address = conn.RemoteAddr().String()
+				p.ConnectedSince = conn.Created()
+				p.LastActivity = conn.LastActivity()
+			}
+			info.Peers = append(info.Peers, p)
+		}
+	}
+	s.outpeersMutex.RLock()
+	addPeers(s.outpeers, true)
+	s.outpeersMutex.RUnlock()
+	s.inpeersMutex.RLock()
+	addPeers(s.inpeers, false)
+	s.inpeersMutex.RUnlock()
+
+	return info
+}
+
+// recordProtocol records that a message of protocol was received from peer
+func (s *Switch) recordProtocol(peer p2pcrypto.PublicKey, protocol string) {
+	s.peerProtocolsMutex.Lock()
+	defer s.peerProtocolsMutex.Unlock()
+	protocols, ok := s.peerProtocols[peer.String()]
+	if !ok {
+		protocols = make(map[string]struct{})
+		s.peerProtocols[peer.String()] = protocols
+	}
+	protocols[protocol] = struct{}{}
+}
+
+// receivedProtocols returns the sorted protocols of the messages received from peer
+func (s *Switch) receivedProtocols(peer p2pcrypto.PublicKey) []string {
+	s.peerProtocolsMutex.Lock()
+	defer s.peerProtocolsMutex.Unlock()
+	protocols := make([]string, 0, len(s.peerProtocols[peer.String()]))
+	for protocol := range s.peerProtocols[peer.String()] {
+		protocols = append(protocols, protocol)
+	}
+	sort.Strings(protocols)
+	return protocols
+}
+
+// banListPath returns the path of the banlist file in datadir, or an empty path if there's no datadir
+func banListPath(datadir string) string {
+	if datadir == "" {
+		return ""
+	}
+	return filepath.Join(datadir, config.P2PDirectoryPath, banListFileName)
+}
+
+// BanPeer bans a peer ID for the given duration, forever if it's 0, and closes the connection to the peer
+func (s *Switch) BanPeer(peer p2pcrypto.PublicKey, duration time.Duration) error {
+	if err := s.banList.add(newBan(peer, nil, duration)); err != nil {
+		return fmt.Errorf("failed to persist banlist: %v", err)
+	}
+	s.logger.Info("Banned peer %v", peer)
+	s.cPool.CloseConnection(peer)
+	return nil
+}
+
+// BanIP bans an IP address for the given duration, forever if it's 0, and closes the connections to peers at the address
+func (s *Switch) BanIP(ip inet.IP, duration time.Duration) error {
+	if err := s.banList.add(newBan(nil, ip, duration)); err != nil {
+		return fmt.Errorf("failed to persist banlist: %v", err)
+	}
+	s.logger.Info("Banned ip %v", ip)
+	for _, peer := range s.connectedPeers() {
+		conn, err := s.cPool.GetConnectionIfExists(peer)
+		if err == nil && ip.Equal(addrIP(conn.RemoteAddr())) {
+			s.cPool.CloseConnection(peer)
+		}
+	}
+	return nil
+}
+
+// UnbanPeer lifts the ban of a peer ID, it returns false if the peer wasn't banned
+func (s *Switch) UnbanPeer(peer p2pcrypto.PublicKey) (bool, error) {
+	return s.banList.remove(peer.String())
+}
+
+// UnbanIP lifts the ban of an IP address, it returns false if the address wasn't banned
+func (s *Switch) UnbanIP(ip inet.IP) (bool, error) {
+	return s.banList.remove(ip.String())
+}
+
+// Bans returns the banned peer IDs and IP addresses, the oldest ban first
+func (s *Switch) Bans() []Ban {
+	return s.banList.list()
+}
+
+func newBan(peer p2pcrypto.PublicKey, ip inet.IP, duration time.Duration) Ban {
+	ban := Ban{Peer: peer, IP: ip, Created: time.Now()}
+	if duration > 0 {
+		ban.Expires = ban.Created.Add(duration)
+	}
+	return ban
+}
+
+// connectedPeers returns the inbound and outbound peers
+func (s *Switch) connectedPeers() []p2pcrypto.PublicKey {
+	var res []p2pcrypto.PublicKey
+	s.inpeersMutex.RLock()
+	for peer := range s.inpeers {
+		res = append(res, peer)
+	}
+	s.inpeersMutex.RUnlock()
+	s.outpeersMutex.RLock()
+	for peer := range s.outpeers {
+		res = append(res, peer)
+	}
+	s.outpeersMutex.RUnlock()
+	return res
+}
+
 // SendWrappedMessage sends a wrapped message in order to differentiate between request response and sub protocol messages.
 // It is used by `MessageServer`.
 func (s *Switch) SendWrappedMessage(nodeID p2pcrypto.PublicKey, protocol string, payload *service.DataMsgWrapper) error {
@@ -360,6 +703,11 @@ func (s *Switch) sendMessageImpl(peerPubKey p2pcrypto.PublicKey, protocol string
 		return errors.New("this peer isn't a neighbor or connection lost")
 	}
 
+	return s.sendToConnection(conn, protocol, payload)
+}
+
+// sendToConnection seals a message with the session of the connection and sends it
+func (s *Switch) sendToConnection(conn net.Connection, protocol string, payload service.Data) error {
 	session := conn.Session()
 	if session == nil {
 		s.logger.Warning("failed to send message to %v, no valid session.", conn.RemotePublicKey().String())
@@ -384,6 +732,11 @@ func (s *Switch) sendMessageImpl(peerPubKey p2pcrypto.PublicKey, protocol string
 		return fmt.Errorf("failed to encode signed message err: %v", err)
 	}
 
+	if s.config.CompressThreshold > 0 && len(data) >= s.config.CompressThreshold &&
+		s.PeerSupports(conn.RemotePublicKey(), compressionCapability) {
+		data = compressMessage(data)
+	}
+
 	final := session.SealMessage(data)
 
 	if final == nil {
@@ -391,10 +744,15 @@ func (s *Switch) sendMessageImpl(peerPubKey p2pcrypto.PublicKey, protocol string
 	}
 
 	err = conn.Send(final)
+	if err != nil {
+		return err
+	}
+	metrics.ProtocolSend.With(metrics.ProtocolLabel, protocol).Add(float64(len(final)))
+	metrics.ProtocolSendMessages.With(metrics.ProtocolLabel, protocol).Add(1)
 
 	s.logger.Debug("DirectMessage sent successfully")
 
-	return err
+	return nil
 }
 
 // RegisterDirectProtocol registers an handler for a direct messaging based protocol.
@@ -411,6 +769,7 @@ func (s *Switch) RegisterGossipProtocol(protocol string, prio priorityq.Priority
 	mchan := make(chan service.GossipMessage, s.config.BufferSize)
 	s.protocolHandlerMutex.Lock()
 	s.gossip.SetPriority(protocol, prio)
+	s.gossipPriorities[protocol] = prio
 	s.gossipProtocolHandlers[protocol] = mchan
 	s.protocolHandlerMutex.Unlock()
 	return mchan
@@ -432,6 +791,7 @@ func (s *Switch) Shutdown() {
 			delete(s.directProtocolHandlers, i)
 			//close(prt) //todo: signal protocols to shutdown with closing chan. (this makes us send on closed chan. )
 		}
+		s.gossipQ.Close() // no more messages are queued once the handlers are removed
 		for i := range s.gossipProtocolHandlers {
 			delete(s.gossipProtocolHandlers, i)
 			//close(prt) //todo: signal protocols to shutdown with closing chan. (this makes us send on closed chan. )
@@ -459,13 +819,23 @@ func (s *Switch) processMessage(ime net.IncomingMessageEvent) {
 	if s.config.MsgSizeLimit != config.UnlimitedMsgSize && len(ime.Message) > s.config.MsgSizeLimit {
 		s.logger.With().Error("processMessage: message is too big",
 			log.Int("limit", s.config.MsgSizeLimit), log.Int("actual", len(ime.Message)))
+		metrics.DroppedTooBig.Add(1)
 		return
 	}
 
 	err := s.onRemoteClientMessage(ime)
 	if err != nil {
+		switch err {
+		case ErrOutOfSync:
+			metrics.DroppedOutOfSync.Add(1)
+		case ErrNoProtocol:
+			metrics.DroppedUnknownProtocol.Add(1)
+		default:
+			metrics.DroppedInvalid.Add(1)
+		}
 		// TODO: differentiate action on errors
 		s.logger.Error("Err reading message from %v, closing connection err=%v", ime.Conn.RemotePublicKey(), err)
+		s.scores.report(ime.Conn.RemotePublicKey(), service.InvalidMessage)
 		if err := ime.Conn.Close(); err == nil {
 			s.cPool.CloseConnection(ime.Conn.RemotePublicKey())
 			s.Disconnect(ime.Conn.RemotePublicKey())
@@ -544,6 +914,11 @@ func (s *Switch) onRemoteClientMessage(msg net.IncomingMessageEvent) error {
 		return ErrFailDecrypt
 	}
 
+	decPayload, err = decompressMessage(decPayload, s.config.MsgSizeLimit)
+	if err != nil {
+		return err
+	}
+
 	pm := &ProtocolMessage{}
 	err = types.BytesToInterface(decPayload, pm)
 	if err != nil {
@@ -573,6 +948,9 @@ func (s *Switch) onRemoteClientMessage(msg net.IncomingMessageEvent) error {
 	s.protocolHandlerMutex.RUnlock()
 
 	s.logger.Debug("Handle %v message from << %v", pm.Metadata.NextProtocol, msg.Conn.RemotePublicKey().String())
+	s.recordProtocol(msg.Conn.RemotePublicKey(), pm.Metadata.NextProtocol)
+	metrics.ProtocolRecv.With(metrics.ProtocolLabel, pm.Metadata.NextProtocol).Add(float64(len(msg.Message)))
+	metrics.ProtocolRecvMessages.With(metrics.ProtocolLabel, pm.Metadata.NextProtocol).Add(1)
 
 	if ok {
 		// if this message is tagged with a gossip protocol, relay it.
@@ -603,23 +981,65 @@ func (s *Switch) ProcessDirectProtocolMessage(sender p2pcrypto.PublicKey, protoc
 }
 
 // ProcessGossipProtocolMessage passes an already decrypted message to a protocol. It is expected that the protocol will send
-// the message syntactic validation result on the validationCompletedChan ASAP
+// the message syntactic validation result on the validationCompletedChan ASAP.
+// Messages are queued by the priority of their protocol, and dropped if the queue of the priority is full.
 func (s *Switch) ProcessGossipProtocolMessage(sender p2pcrypto.PublicKey, protocol string, data service.Data, validationCompletedChan chan service.MessageValidation) error {
 	// route authenticated message to the registered protocol
 	s.protocolHandlerMutex.RLock()
-	msgchan := s.gossipProtocolHandlers[protocol]
-	s.protocolHandlerMutex.RUnlock()
-	if msgchan == nil {
+	defer s.protocolHandlerMutex.RUnlock() // the queue is closed under the lock on shutdown
+	if s.gossipProtocolHandlers[protocol] == nil {
 		return ErrNoProtocol
 	}
-	s.logger.Debug("Forwarding message to %v protocol", protocol)
+	prio := s.gossipPriorities[protocol]
+	msg := queuedGossipMessage{protocol, prio, gossipProtocolMessage{sender, data, validationCompletedChan, s}}
+	if err := s.gossipQ.TryWrite(prio, msg); err != nil {
+		metrics.DroppedGossipMessages.With(metrics.ProtocolLabel, protocol).Add(1)
+		s.logger.With().Warning("dropping gossip message", log.String("protocol", protocol), log.Err(err))
+	}
+	return nil
+}
 
-	metrics.QueueLength.With(metrics.ProtocolLabel, protocol).Set(float64(len(msgchan)))
+// queuedGossipMessage is a gossip message waiting in the queue for its protocol
+type queuedGossipMessage struct {
+	protocol string
+	priority priorityq.Priority
+	msg      gossipProtocolMessage
+}
 
-	// TODO: check queue length
-	msgchan <- gossipProtocolMessage{sender, data, validationCompletedChan}
+// deliverGossipMessages passes the queued gossip messages to their protocols by priority. A protocol that doesn't
+// keep up loses messages instead of delaying the messages of other protocols, unless it has the highest priority.
+func (s *Switch) deliverGossipMessages() {
+	for {
+		mi, err := s.gossipQ.Read()
+		if err != nil {
+			return
+		}
+		m := mi.(queuedGossipMessage)
+		s.protocolHandlerMutex.RLock()
+		msgchan := s.gossipProtocolHandlers[m.protocol]
+		s.protocolHandlerMutex.RUnlock()
+		if msgchan == nil {
+			continue
+		}
+		s.logger.Debug("Forwarding message to %v protocol", m.protocol)
 
-	return nil
+		metrics.QueueLength.With(metrics.ProtocolLabel, m.protocol).Set(float64(len(msgchan)))
+
+		if m.priority == priorityq.High {
+			select {
+			case msgchan <- m.msg:
+			case <-s.shutdown:
+				return
+			}
+			continue
+		}
+		select {
+		case msgchan <- m.msg:
+		default:
+			metrics.DroppedGossipMessages.With(metrics.ProtocolLabel, m.protocol).Add(1)
+			s.logger.With().Warning("dropping gossip message, the protocol queue is full", log.String("protocol", m.protocol))
+		}
+	}
 }
 
 // Broadcast creates a gossip message signs it and disseminate it to neighbors.
@@ -633,7 +1053,8 @@ func (s *Switch) Broadcast(protocol string, payload []byte) error {
 // outgoing connections. protocols can use these peers to send direct messages or gossip.
 
 // tells protocols  we connected to a new peer.
-func (s *Switch) publishNewPeer(peer p2pcrypto.PublicKey) {
+func (s *Switch) publishNewPeer(peer p2pcrypto.PublicKey, outbound bool) {
+	s.publishPeerUpdate(peer, true, outbound)
 	s.peerLock.RLock()
 	for _, p := range s.newPeerSub {
 		select {
@@ -645,7 +1066,8 @@ func (s *Switch) publishNewPeer(peer p2pcrypto.PublicKey) {
 }
 
 // tells protocols  we disconnected a peer.
-func (s *Switch) publishDelPeer(peer p2pcrypto.PublicKey) {
+func (s *Switch) publishDelPeer(peer p2pcrypto.PublicKey, outbound bool) {
+	s.publishPeerUpdate(peer, false, outbound)
 	s.peerLock.RLock()
 	for _, p := range s.delPeerSub {
 		select {
@@ -656,6 +1078,29 @@ func (s *Switch) publishDelPeer(peer p2pcrypto.PublicKey) {
 	s.peerLock.RUnlock()
 }
 
+// publishPeerUpdate publishes a peer connecting or disconnecting, so operators can follow the connectivity of the node
+func (s *Switch) publishPeerUpdate(peer p2pcrypto.PublicKey, connected, outbound bool) {
+	update := events.PeerUpdate{
+		ID:         peer.Bytes(),
+		Connected:  connected,
+		Outbound:   outbound,
+		Reputation: s.PeerScore(peer),
+		Time:       time.Now(),
+	}
+	if connected {
+		if conn, err := s.cPool.GetConnectionIfExists(peer); err == nil && conn.RemoteAddr() != nil {
+			update.Address = conn.RemoteAddr().String()
+		}
+	}
+	s.inpeersMutex.RLock()
+	update.InboundPeers = uint64(len(s.inpeers))
+	s.inpeersMutex.RUnlock()
+	s.outpeersMutex.RLock()
+	update.OutboundPeers = uint64(len(s.outpeers))
+	s.outpeersMutex.RUnlock()
+	events.Publish(update)
+}
+
 // SubscribePeerEvents lets clients listen on events inside the Switch about peers. first chan is new peers, second is deleted peers.
 func (s *Switch) SubscribePeerEvents() (conn, disc chan p2pcrypto.PublicKey) {
 	in := make(chan p2pcrypto.PublicKey, 30) // todo : the size should be determined after #269
@@ -676,6 +1121,10 @@ func (s *Switch) startNeighborhood() error {
 	//TODO: Save and load persistent peers ?
 	s.logger.Info("Neighborhood service started")
 
+	for _, nd := range s.staticPeers {
+		go s.keepStaticPeer(nd)
+	}
+
 	// initial request for peers
 	go s.peersLoop()
 	s.morePeersReq <- struct{}{}
@@ -705,7 +1154,8 @@ func (s *Switch) askForMorePeers() {
 	s.outpeersMutex.RLock()
 	numpeers := len(s.outpeers)
 	s.outpeersMutex.RUnlock()
-	req := s.config.SwarmConfig.RandomConnections - numpeers
+	target := s.outboundTarget()
+	req := target - numpeers
 	if req <= 0 {
 		return
 	}
@@ -719,7 +1169,7 @@ func (s *Switch) askForMorePeers() {
 	s.outpeersMutex.RUnlock()
 	// announce if initial number of peers achieved
 	// todo: better way then going in this every time ?
-	if numpeers >= s.config.SwarmConfig.RandomConnections {
+	if numpeers >= target {
 		s.initOnce.Do(func() {
 			s.logger.Info("gossip; connected to initial required neighbors - %v", len(s.outpeers))
 			close(s.initial)
@@ -745,6 +1195,16 @@ func (s *Switch) askForMorePeers() {
 	}
 }
 
+// outboundTarget returns the number of outbound peers the node keeps, RandomConnections unless the outbound limit
+// without the reserved slots is lower
+func (s *Switch) outboundTarget() int {
+	target := s.config.SwarmConfig.RandomConnections
+	if limit := s.config.MaxOutboundPeers - s.config.ReservedPeerSlots; limit < target {
+		target = limit
+	}
+	return target
+}
+
 // getMorePeers tries to fill the `outpeers` slice with dialed outbound peers that we selected from the discovery.
 func (s *Switch) getMorePeers(numpeers int) int {
 
@@ -753,7 +1213,8 @@ func (s *Switch) getMorePeers(numpeers int) int {
 	}
 
 	// discovery should provide us with random peers to connect to
-	nds := s.discover.SelectPeers(s.ctx, numpeers)
+	// prefer peers of subnets the node has no outbound peers in, so a single network can't provide all of them
+	nds := s.groups.diverse(s.discover.SelectPeers(s.ctx, numpeers))
 	ndsLen := len(nds)
 	if ndsLen == 0 {
 		s.logger.Debug("Peer sampler returned nothing.")
@@ -776,9 +1237,38 @@ func (s *Switch) getMorePeers(numpeers int) int {
 				reportChan <- cnErr{nd, errors.New("connection to self")}
 				return
 			}
+			if s.banList.banned(nd.PublicKey(), nd.IP) {
+				reportChan <- cnErr{nd, errors.New("peer is banned")}
+				return
+			}
+			if !s.isAllowed(nd.PublicKey()) {
+				reportChan <- cnErr{nd, ErrNotAllowed}
+				return
+			}
+			if s.lowScore(nd.PublicKey()) {
+				reportChan <- cnErr{nd, errors.New("peer has a low score")}
+				return
+			}
 			s.discover.Attempt(nd.PublicKey())
 			addr := inet.TCPAddr{IP: inet.ParseIP(nd.IP.String()), Port: int(nd.ProtocolPort)}
+			_, notConnected := s.cPool.GetConnectionIfExists(nd.PublicKey())
+			dialed := time.Now()
 			_, err := s.cPool.GetConnection(&addr, nd.PublicKey())
+			latency := time.Since(dialed)
+			// the connection history lets a restarted node redial the peers that it connected to quickly
+			if err != nil {
+				s.discover.Failed(nd.PublicKey())
+			} else if notConnected != nil {
+				s.discover.SetLatency(nd.PublicKey(), latency)
+			}
+			if err != nil && s.config.HolePunch {
+				// the peer may be behind a NAT, try to connect to it through a relay
+				if punchErr := s.holePunch(nd); punchErr == nil {
+					err = nil
+				} else {
+					s.logger.Debug("failed to connect to %v through hole punching, err: %v", nd.PublicKey(), punchErr)
+				}
+			}
 			reportChan <- cnErr{nd, err}
 		}(nds[i], res)
 	}
@@ -816,11 +1306,20 @@ loop:
 				bad++
 				break
 			}
+			if len(s.outpeers) >= s.config.MaxOutboundPeers-s.config.ReservedPeerSlots {
+				// static peers connected while we were dialing
+				s.outpeersMutex.Unlock()
+				s.logger.Debug("reached max outbound connections, dropping peer %v", pk)
+				s.cPool.CloseConnection(pk)
+				bad++
+				break
+			}
 			s.outpeers[pk] = struct{}{}
 			s.outpeersMutex.Unlock()
+			s.groups.add(pk, cne.n.IP, false)
 
 			s.discover.Good(cne.n.PublicKey())
-			s.publishNewPeer(cne.n.PublicKey())
+			s.publishNewPeer(cne.n.PublicKey(), true)
 			metrics.OutboundPeers.Add(1)
 			s.logger.Debug("Neighborhood: Added peer to peer list %v", cne.n.PublicKey())
 		case <-tm.C:
@@ -839,11 +1338,21 @@ loop:
 
 // Disconnect removes a peer from the neighborhood. It requests more peers if our outbound peer count is less than configured
 func (s *Switch) Disconnect(peer p2pcrypto.PublicKey) {
+	s.peerProtocolsMutex.Lock()
+	delete(s.peerProtocols, peer.String())
+	s.peerProtocolsMutex.Unlock()
+
+	s.peerCapsMutex.Lock()
+	delete(s.peerCaps, peer.String())
+	s.peerCapsMutex.Unlock()
+
+	s.groups.remove(peer)
+
 	s.inpeersMutex.Lock()
 	if _, ok := s.inpeers[peer]; ok {
 		delete(s.inpeers, peer)
 		s.inpeersMutex.Unlock()
-		s.publishDelPeer(peer)
+		s.publishDelPeer(peer, false)
 		metrics.InboundPeers.Add(-1)
 		return
 	}
@@ -857,7 +1366,7 @@ func (s *Switch) Disconnect(peer p2pcrypto.PublicKey) {
 		return
 	}
 	s.outpeersMutex.Unlock()
-	s.publishDelPeer(peer)
+	s.publishDelPeer(peer, true)
 	metrics.OutboundPeers.Add(-1)
 
 	// todo: don't remove if we know this is a valid peer for later
@@ -867,28 +1376,54 @@ func (s *Switch) Disconnect(peer p2pcrypto.PublicKey) {
 }
 
 // addIncomingPeer inserts a peer to the neighborhood as a remote peer.
-// returns an error if we reached our maximum number of peers.
+// returns an error if we reached our maximum number of peers, unless the peer is protected. Protected peers use the
+// reserved slots, so unprotected peers are limited to the slots that aren't reserved.
 func (s *Switch) addIncomingPeer(n p2pcrypto.PublicKey) error {
 	s.inpeersMutex.RLock()
 	amnt := len(s.inpeers)
 	_, exist := s.inpeers[n]
 	s.inpeersMutex.RUnlock()
 
-	if amnt >= s.config.MaxInboundPeers {
-		// todo: close connection with CPOOL
-		return errors.New("reached max connections")
+	if amnt >= s.config.MaxInboundPeers-s.config.ReservedPeerSlots && !s.isProtected(n) {
+		// make room by pruning an inbound peer that misbehaved more than the new peer
+		victim := s.pruneCandidate(s.PeerScore(n))
+		if victim == nil {
+			// todo: close connection with CPOOL
+			return errors.New("reached max connections")
+		}
+		s.logger.Info("Pruning inbound peer %v with score %.1f for peer %v", victim, s.PeerScore(victim), n)
+		s.cPool.CloseConnection(victim)
+		s.Disconnect(victim)
 	}
 
 	s.inpeersMutex.Lock()
 	s.inpeers[n] = struct{}{}
 	s.inpeersMutex.Unlock()
 	if !exist {
-		s.publishNewPeer(n)
+		s.publishNewPeer(n, false)
 		metrics.InboundPeers.Add(1)
 	}
 	return nil
 }
 
+// pruneCandidate returns the unprotected inbound peer with the lowest negative score below the given score, nil if
+// there's none
+func (s *Switch) pruneCandidate(score float64) p2pcrypto.PublicKey {
+	var victim p2pcrypto.PublicKey
+	lowest := math.Min(score, 0)
+	s.inpeersMutex.RLock()
+	defer s.inpeersMutex.RUnlock()
+	for peer := range s.inpeers {
+		if s.isProtected(peer) {
+			continue
+		}
+		if ps := s.PeerScore(peer); ps < lowest {
+			victim, lowest = peer, ps
+		}
+	}
+	return victim
+}
+
 func (s *Switch) hasIncomingPeer(peer p2pcrypto.PublicKey) bool {
 	s.inpeersMutex.RLock()
 	_, ok := s.inpeers[peer]
@@ -915,12 +1450,13 @@ func (s *Switch) getListeners(
 	randomPort := port == 0
 	var gateway nattraversal.UPNPGateway
 	if s.config.AcquirePort {
-		s.logger.Info("Trying to acquire ports using UPnP, this might take a while..")
+		s.logger.Info("Trying to acquire ports using UPnP or NAT-PMP, this might take a while..")
 		var err error
 		gateway, err = discoverUpnpGateway()
 		if err != nil {
 			gateway = nil
-			s.logger.With().Warning("could not discover UPnP gateway", log.Err(err))
+			s.natStatus = NATNoGateway
+			s.logger.With().Warning("could not discover UPnP or NAT-PMP gateway", log.Err(err))
 		}
 	}
 
@@ -951,6 +1487,7 @@ func (s *Switch) getListeners(
 		if gateway != nil {
 			err := nattraversal.AcquirePortFromGateway(gateway, uint16(port))
 			if err != nil {
+				s.natStatus = NATPortFailed
 				if upnpFails >= UPNPRetries {
 					return tcpListener, udpListener, nil
 				}
@@ -967,6 +1504,13 @@ func (s *Switch) getListeners(
 				}
 				s.logger.Warning("failed to acquire requested port using UPnP: %v", err)
 			} else {
+				s.natStatus = NATPortMapped
+				if ip, err := gateway.ExternalIP(); err != nil {
+					s.logger.Warning("failed to get the external IP of the gateway: %v", err)
+				} else {
+					s.mappedAddress = inet.JoinHostPort(ip, strconv.Itoa(port))
+					s.logger.Info("Gateway forwards external address %v to this node", s.mappedAddress)
+				}
 				s.releaseUpnp = func() {
 					err := gateway.Clear(uint16(port))
 					if err != nil {
@@ -980,14 +1524,47 @@ func (s *Switch) getListeners(
 	}
 }
 
-func getUDPListener(udpAddr *inet.UDPAddr) (net.UDPListener, error) {
-	return inet.ListenUDP("udp", udpAddr)
+// startQUIC accepts QUIC connections on the QUIC port and advertises it to peers. The port isn't mapped on NAT
+// gateways, peers that can't reach it connect over TCP.
+func (s *Switch) startQUIC() error {
+	conn, err := inet.ListenUDP("udp", &inet.UDPAddr{IP: inet.ParseIP(s.config.TCPInterface), Port: s.config.QUICPort})
+	if err != nil {
+		return fmt.Errorf("failed to acquire requested quic port: %v", err)
+	}
+	if err := s.network.StartQUIC(conn); err != nil {
+		if err := conn.Close(); err != nil {
+			s.logger.With().Error("error closing quic listener", log.Err(err))
+		}
+		return err
+	}
+	s.network.AddCapability(net.QUICCapability(conn.LocalAddr().(*inet.UDPAddr).Port))
+	return nil
 }
 
-func getTCPListener(tcpAddr *inet.TCPAddr) (inet.Listener, error) {
-	return inet.Listen("tcp", tcpAddr.String())
+func getUDPListener(udpAddr *inet.UDPAddr) (net.UDPListener, error) {
+	return inet.ListenUDP("udp", udpAddr)
 }
 
 func discoverUPnPGateway() (igd nattraversal.UPNPGateway, err error) {
 	return nattraversal.DiscoverUPNPGateway()
 }
+
+func discoverNATPMPGateway() (igd nattraversal.UPNPGateway, err error) {
+	return nattraversal.DiscoverNATPMPGateway()
+}
+
+// discoverGateway returns a function that discovers a UPnP gateway and, if none is found and natpmp is set, falls back
+// to a NAT-PMP gateway.
+func discoverGateway(natpmp bool, discoverUpnp, discoverNATPMP func() (nattraversal.UPNPGateway, error)) func() (nattraversal.UPNPGateway, error) {
+	return func() (nattraversal.UPNPGateway, error) {
+		gateway, err := discoverUpnp()
+		if err == nil || !natpmp {
+			return gateway, err
+		}
+		gateway, pmpErr := discoverNATPMP()
+		if pmpErr != nil {
+			return nil, fmt.Errorf("%v, %v", err, pmpErr)
+		}
+		return gateway, nil
+	}
+}
@@ -1,8 +1,10 @@
 package p2p
 
 import (
+	"bytes"
 	"fmt"
 	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/events"
 	"github.com/spacemeshos/go-spacemesh/log"
 	"github.com/spacemeshos/go-spacemesh/nattraversal"
 	"github.com/spacemeshos/go-spacemesh/p2p/connectionpool"
@@ -20,6 +22,7 @@ import (
 	"github.com/spacemeshos/go-spacemesh/p2p/node"
 	"github.com/spacemeshos/go-spacemesh/p2p/p2pcrypto"
 	"github.com/spacemeshos/go-spacemesh/p2p/service"
+	"github.com/spacemeshos/go-spacemesh/priorityq"
 	"github.com/spacemeshos/go-spacemesh/rand"
 	"github.com/stretchr/testify/assert"
 	"sync"
@@ -56,6 +59,10 @@ func (cp *cpoolMock) GetConnectionIfExists(pk p2pcrypto.PublicKey) (net.Connecti
 	return net.NewConnectionMock(pk), nil
 }
 
+func (cp *cpoolMock) OnDialedConnection(conn net.Connection) error {
+	return nil
+}
+
 func (cp *cpoolMock) Shutdown() {
 
 }
@@ -144,6 +151,8 @@ func TestSwarm_processMessage(t *testing.T) {
 		outpeers:     make(map[p2pcrypto.PublicKey]struct{}),
 		delPeerSub:   make([]chan p2pcrypto.PublicKey, 0),
 		morePeersReq: make(chan struct{}, 1),
+		scores:       newPeerScores(),
+		groups:       &addrGroups{peers: make(map[p2pcrypto.PublicKey]groupedPeer)},
 	}
 	cpmock := newCpoolMock()
 	s.cPool = cpmock
@@ -980,6 +989,78 @@ func TestSwarm_AddIncomingPeer(t *testing.T) {
 	assert.False(t, ok)
 }
 
+func TestSwarm_PeerUpdates(t *testing.T) {
+	cfg := configWithPort(0)
+	cfg.SwarmConfig.Bootstrap = false
+	cfg.SwarmConfig.Gossip = false
+	p := p2pTestNoStart(t, cfg)
+	p.cPool = newCpoolMock()
+	updates := events.Subscribe(events.EventPeer)
+	defer updates.Close()
+
+	next := func(peer p2pcrypto.PublicKey) events.PeerUpdate {
+		for ev := range updates.Events() {
+			if update := ev.(events.PeerUpdate); bytes.Equal(update.ID, peer.Bytes()) {
+				return update
+			}
+		}
+		t.Fatal("subscription closed")
+		return events.PeerUpdate{}
+	}
+
+	rnd := node.GenerateRandomNodeData().PublicKey()
+	require.NoError(t, p.addIncomingPeer(rnd))
+	update := next(rnd)
+	require.True(t, update.Connected)
+	require.False(t, update.Outbound)
+	require.Equal(t, uint64(1), update.InboundPeers)
+
+	p.Disconnect(rnd)
+	update = next(rnd)
+	require.False(t, update.Connected)
+	require.False(t, update.Outbound)
+	require.Equal(t, uint64(0), update.InboundPeers)
+}
+
+func TestSwarm_ConnectionLimits(t *testing.T) {
+	cfg := configWithPort(0)
+	cfg.SwarmConfig.Bootstrap = false
+	cfg.SwarmConfig.Gossip = false
+	cfg.MaxInboundPeers = 3
+	cfg.MaxOutboundPeers = 3
+	cfg.ReservedPeerSlots = 4
+	_, err := newSwarm(context.TODO(), cfg, log.NewDefault(t.Name()), "")
+	require.EqualError(t, err, "reserved peer slots (4) must be within the inbound (3) and outbound (3) peer limits")
+
+	protected := node.GenerateRandomNodeData()
+	cfg.ReservedPeerSlots = 1
+	cfg.SwarmConfig.ProtectedPeers = []string{protected.PublicKey().String()}
+	cfg.SwarmConfig.RandomConnections = 5
+	p := p2pTestNoStart(t, cfg)
+
+	// unprotected inbound peers can't use the reserved slot
+	require.NoError(t, p.addIncomingPeer(node.GenerateRandomNodeData().PublicKey()))
+	require.NoError(t, p.addIncomingPeer(node.GenerateRandomNodeData().PublicKey()))
+	require.EqualError(t, p.addIncomingPeer(node.GenerateRandomNodeData().PublicKey()), "reached max connections")
+	require.NoError(t, p.addIncomingPeer(protected.PublicKey()))
+
+	// the node dials outbound peers up to the outbound limit without the reserved slot
+	require.Equal(t, 2, p.outboundTarget())
+	nds := node.GenerateRandomNodesData(3)
+	mdht := new(discovery.MockPeerStore)
+	mdht.SelectPeersFunc = func(ctx context.Context, qty int) []*node.Info {
+		return nds
+	}
+	p.discover = mdht
+	cpm := newCpoolMock()
+	p.cPool = cpm
+	require.Equal(t, 2, p.getMorePeers(3))
+	p.outpeersMutex.RLock()
+	require.Len(t, p.outpeers, 2)
+	p.outpeersMutex.RUnlock()
+	require.Len(t, cpm.keyRemoved, 1)
+}
+
 func TestSwarm_AskPeersSerial(t *testing.T) {
 	p := p2pTestNoStart(t, configWithPort(0))
 	dsc := &discovery.MockPeerStore{}
@@ -1308,6 +1389,164 @@ func TestSwarm_getListeners_upnpMoreCases(t *testing.T) {
 	r.NoError(testGetListenersScenario(t, port, tcpResponses, udpResponses, createDiscoverUpnpFunc(ErrPortUnavailable, 1337, ErrPortUnavailable), true))
 }
 
+func TestSwarm_getListeners_natStatus(t *testing.T) {
+	getTCP := func(addr *inet.TCPAddr) (inet.Listener, error) {
+		return tcpListenerMock{port: 1337}, nil
+	}
+	getUDP := func(addr *inet.UDPAddr) (net.UDPListener, error) {
+		return &UDPConnMock{}, nil
+	}
+	for _, tc := range []struct {
+		name        string
+		acquirePort bool
+		discover    func() (nattraversal.UPNPGateway, error)
+		status      NATStatus
+		mapped      string
+	}{
+		{"disabled", false, createDiscoverUpnpFunc(nil, 1337, nil), NATDisabled, ""},
+		{"no gateway", true, createDiscoverUpnpFunc(ErrPortUnavailable, 1337, nil), NATNoGateway, ""},
+		{"port failed", true, createDiscoverUpnpFunc(nil, 1337, ErrPortUnavailable), NATPortFailed, ""},
+		{"port mapped", true, createDiscoverUpnpFunc(nil, 1337, nil), NATPortMapped, "1.2.3.4:1337"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := configWithPort(1337)
+			cfg.AcquirePort = tc.acquirePort
+			swarm := p2pTestNoStart(t, cfg)
+			_, _, err := swarm.getListeners(getTCP, getUDP, tc.discover)
+			require.NoError(t, err)
+			require.Equal(t, tc.status, swarm.NetworkInfo().NAT)
+			require.Equal(t, tc.mapped, swarm.NetworkInfo().MappedAddress)
+		})
+	}
+}
+
+func TestDiscoverGateway(t *testing.T) {
+	upnp := &UpnpGatewayMock{}
+	natpmp := &UpnpGatewayMock{}
+	found := func(gw nattraversal.UPNPGateway) func() (nattraversal.UPNPGateway, error) {
+		return func() (nattraversal.UPNPGateway, error) { return gw, nil }
+	}
+	notFound := func(err string) func() (nattraversal.UPNPGateway, error) {
+		return func() (nattraversal.UPNPGateway, error) { return nil, errors.New(err) }
+	}
+
+	// UPnP is preferred
+	gw, err := discoverGateway(true, found(upnp), found(natpmp))()
+	require.NoError(t, err)
+	require.True(t, gw == upnp)
+
+	// NAT-PMP is used when no UPnP gateway is found, if it's enabled
+	gw, err = discoverGateway(true, notFound("no upnp"), found(natpmp))()
+	require.NoError(t, err)
+	require.True(t, gw == natpmp)
+	_, err = discoverGateway(false, notFound("no upnp"), found(natpmp))()
+	require.EqualError(t, err, "no upnp")
+	_, err = discoverGateway(true, notFound("no upnp"), notFound("no natpmp"))()
+	require.EqualError(t, err, "no upnp, no natpmp")
+}
+
+func TestSwarm_GossipPriorities(t *testing.T) {
+	cfg := configWithPort(0)
+	cfg.SwarmConfig.Bootstrap = false
+	cfg.SwarmConfig.Gossip = false
+	cfg.BufferSize = 2
+	p := p2pTestNoStart(t, cfg)
+	defer p.Shutdown()
+	consensus := p.RegisterGossipProtocol("consensus", priorityq.High)
+	bulk := p.RegisterGossipProtocol("bulk", priorityq.Bulk)
+	sender := node.GenerateRandomNodeData().PublicKey()
+	data := service.DataBytes{Payload: []byte("msg")}
+
+	// messages of a priority whose queue is full are dropped
+	for i := 0; i < 3; i++ {
+		require.NoError(t, p.ProcessGossipProtocolMessage(sender, "bulk", data, nil))
+	}
+	require.NoError(t, p.ProcessGossipProtocolMessage(sender, "consensus", data, nil))
+	require.Equal(t, ErrNoProtocol, p.ProcessGossipProtocolMessage(sender, "unknown", data, nil))
+
+	// higher priorities are delivered first
+	m, err := p.gossipQ.Read()
+	require.NoError(t, err)
+	require.Equal(t, "consensus", m.(queuedGossipMessage).protocol)
+
+	go p.deliverGossipMessages()
+	require.Eventually(t, func() bool { return len(bulk) == 2 }, time.Second, time.Millisecond)
+
+	// a protocol that doesn't keep up loses messages without holding back other protocols
+	require.NoError(t, p.ProcessGossipProtocolMessage(sender, "bulk", data, nil))
+	require.NoError(t, p.ProcessGossipProtocolMessage(sender, "consensus", data, nil))
+	select {
+	case msg := <-consensus:
+		require.Equal(t, sender, msg.Sender())
+	case <-time.After(time.Second):
+		require.Fail(t, "the consensus message wasn't delivered")
+	}
+	require.Len(t, bulk, 2)
+}
+
+func TestSwarm_NetworkInfo(t *testing.T) {
+	p := p2pTestInstance(t, configWithPort(0))
+	defer p.Shutdown()
+	p.RegisterGossipProtocol("b", priorityq.Low)
+	p.RegisterGossipProtocol("a", priorityq.Low)
+
+	out := node.GenerateRandomNodeData()
+	in := node.GenerateRandomNodeData()
+	p.outpeersMutex.Lock()
+	p.outpeers[out.PublicKey()] = struct{}{}
+	p.outpeersMutex.Unlock()
+	require.NoError(t, p.addIncomingPeer(in.PublicKey()))
+	p.recordProtocol(out.PublicKey(), "b")
+	p.recordProtocol(out.PublicKey(), "a")
+	p.recordProtocol(out.PublicKey(), "b")
+
+	cpm := newCpoolMock()
+	outConn := net.NewConnectionMock(out.PublicKey())
+	outConn.Addr = &inet.TCPAddr{IP: out.IP, Port: int(out.ProtocolPort)}
+	cpm.fExists = func(pk p2pcrypto.PublicKey) (net.Connection, error) {
+		if pk.String() != out.PublicKey().String() {
+			return nil, errors.New("no connection")
+		}
+		return outConn, nil
+	}
+	p.cPool = cpm
+	ext := []discovery.ExternalAddress{{Address: "1.2.3.4:7513", Reports: 3}}
+	rt := []discovery.AddressInfo{{Info: out, Tried: true, Score: 0.5}}
+	p.discover = &discovery.MockPeerStore{
+		AddressesFunc:         func() []discovery.AddressInfo { return rt },
+		ExternalAddressesFunc: func() []discovery.ExternalAddress { return ext },
+	}
+
+	info := p.NetworkInfo()
+	require.Equal(t, p.lNode.PublicKey(), info.ID)
+	require.Equal(t, p.network.LocalAddr().String(), info.TCPAddress)
+	require.Equal(t, p.udpnetwork.LocalAddr().String(), info.UDPAddress)
+	require.Equal(t, NATDisabled, info.NAT)
+	require.Equal(t, ext, info.ExternalAddresses)
+	require.Equal(t, rt, info.RoutingTable)
+	require.Equal(t, []string{"a", "b"}, info.GossipProtocols)
+	require.Len(t, info.Peers, 2)
+	for _, peer := range info.Peers {
+		if peer.Outbound {
+			require.Equal(t, out.PublicKey(), peer.ID)
+			require.Equal(t, (&inet.TCPAddr{IP: out.IP, Port: int(out.ProtocolPort)}).String(), peer.Address)
+			require.Equal(t, 0.5, peer.Score)
+			require.Equal(t, outConn.Created(), peer.ConnectedSince)
+			require.Equal(t, outConn.LastActivity(), peer.LastActivity)
+			require.Equal(t, []string{"a", "b"}, peer.Protocols)
+		} else {
+			require.Equal(t, in.PublicKey(), peer.ID)
+			require.Empty(t, peer.Address)
+			require.Zero(t, peer.Score)
+			require.True(t, peer.ConnectedSince.IsZero())
+			require.Empty(t, peer.Protocols)
+		}
+	}
+
+	p.Disconnect(out.PublicKey())
+	require.Empty(t, p.receivedProtocols(out.PublicKey()))
+}
+
 type UDPConnMock struct{}
 
 func (UDPConnMock) LocalAddr() inet.Addr                               { panic("implement me") }
@@ -1388,6 +1627,10 @@ func (u *UpnpGatewayMock) Clear(port uint16) error {
 	return nil
 }
 
+func (u *UpnpGatewayMock) ExternalIP() (string, error) {
+	return "1.2.3.4", nil
+}
+
 func createDiscoverUpnpFunc(funcErr error, port uint16, gatewayForwardErr error) func() (igd nattraversal.UPNPGateway, err error) {
 	return func() (igd nattraversal.UPNPGateway, err error) {
 		if funcErr != nil {
@@ -1396,3 +1639,175 @@ func createDiscoverUpnpFunc(funcErr error, port uint16, gatewayForwardErr error)
 		return &UpnpGatewayMock{errs: map[uint16][]error{port: {gatewayForwardErr}}}, nil
 	}
 }
+
+func TestSwarm_Ban(t *testing.T) {
+	cfg := configWithPort(0)
+	cfg.SwarmConfig.Bootstrap = false
+	cfg.SwarmConfig.Gossip = false
+	p := p2pTestNoStart(t, cfg)
+
+	banned := node.GenerateRandomNodeData()
+	atBannedIP := node.GenerateRandomNodeData()
+	atBannedIP.IP = inet.ParseIP("1.2.3.4")
+	good := node.GenerateRandomNodeData()
+	good.IP = inet.ParseIP("1.2.3.5")
+	conns := make(map[string]*net.ConnectionMock)
+	for _, nd := range []*node.Info{banned, atBannedIP, good} {
+		conn := net.NewConnectionMock(nd.PublicKey())
+		conn.Addr = &inet.TCPAddr{IP: nd.IP, Port: int(nd.ProtocolPort)}
+		conns[nd.PublicKey().String()] = conn
+	}
+	cpm := newCpoolMock()
+	cpm.fExists = func(pk p2pcrypto.PublicKey) (net.Connection, error) {
+		return conns[pk.String()], nil
+	}
+	p.cPool = cpm
+
+	// banning a peer closes the connection to it
+	require.NoError(t, p.BanPeer(banned.PublicKey(), 0))
+	require.Equal(t, banned.PublicKey(), <-cpm.keyRemoved)
+
+	// banning an ip closes the connections to the peers at the ip
+	require.NoError(t, p.addIncomingPeer(atBannedIP.PublicKey()))
+	require.NoError(t, p.addIncomingPeer(good.PublicKey()))
+	require.NoError(t, p.BanIP(atBannedIP.IP, time.Hour))
+	require.Equal(t, atBannedIP.PublicKey(), <-cpm.keyRemoved)
+	require.Len(t, cpm.keyRemoved, 0)
+
+	bans := p.Bans()
+	require.Len(t, bans, 2)
+	require.Equal(t, banned.PublicKey(), bans[0].Peer)
+	require.True(t, bans[0].Expires.IsZero())
+	require.True(t, atBannedIP.IP.Equal(bans[1].IP))
+	require.False(t, bans[1].Expires.IsZero())
+
+	// connections from banned peers are rejected
+	p.onNewConnection(net.NewConnectionEvent{Conn: conns[banned.PublicKey().String()], Node: banned})
+	require.Equal(t, banned.PublicKey(), <-cpm.keyRemoved)
+	require.False(t, p.hasIncomingPeer(banned.PublicKey()))
+
+	// banned peers aren't dialed
+	dialed := make(chan p2pcrypto.PublicKey, 3)
+	cpm.f = func(address inet.Addr, pk p2pcrypto.PublicKey) (net.Connection, error) {
+		dialed <- pk
+		return conns[pk.String()], nil
+	}
+	mdht := new(discovery.MockPeerStore)
+	mdht.SelectPeersFunc = func(ctx context.Context, qty int) []*node.Info {
+		return []*node.Info{banned, atBannedIP}
+	}
+	p.discover = mdht
+	require.Zero(t, p.getMorePeers(2))
+	require.Len(t, dialed, 0)
+
+	// lifting the bans allows the peers to connect
+	found, err := p.UnbanPeer(banned.PublicKey())
+	require.NoError(t, err)
+	require.True(t, found)
+	found, err = p.UnbanIP(atBannedIP.IP)
+	require.NoError(t, err)
+	require.True(t, found)
+	found, err = p.UnbanIP(atBannedIP.IP)
+	require.NoError(t, err)
+	require.False(t, found)
+	require.Empty(t, p.Bans())
+	p.onNewConnection(net.NewConnectionEvent{Conn: conns[banned.PublicKey().String()], Node: banned})
+	require.True(t, p.hasIncomingPeer(banned.PublicKey()))
+}
+
+func TestSwarm_Proxy(t *testing.T) {
+	cfg := configWithPort(0)
+	cfg.Proxy = "socks5://127.0.0.1:9050"
+	cfg.SwarmConfig.Bootstrap = true
+	p := p2pTestNoStart(t, cfg)
+	// the node only listens on loopback, doesn't forward its port and doesn't bootstrap
+	require.Equal(t, "127.0.0.1", p.config.TCPInterface)
+	require.False(t, p.config.AcquirePort)
+	require.False(t, p.config.SwarmConfig.Bootstrap)
+
+	cfg.Proxy = "http://127.0.0.1:8080"
+	_, err := newSwarm(context.TODO(), cfg, log.NewDefault(t.Name()), "")
+	require.Error(t, err)
+}
+
+func TestSwarm_InboundPerGroup(t *testing.T) {
+	cfg := configWithPort(0)
+	cfg.SwarmConfig.Bootstrap = false
+	cfg.SwarmConfig.Gossip = false
+	cfg.MaxInboundPerGroup = 2
+	protected := node.GenerateRandomNodeData()
+	cfg.SwarmConfig.ProtectedPeers = []string{protected.PublicKey().String()}
+	p := p2pTestNoStart(t, cfg)
+	cpm := newCpoolMock()
+	p.cPool = cpm
+
+	connect := func(nd *node.Info) {
+		conn := net.NewConnectionMock(nd.PublicKey())
+		conn.Addr = &inet.TCPAddr{IP: nd.IP, Port: int(nd.ProtocolPort)}
+		p.onNewConnection(net.NewConnectionEvent{Conn: conn, Node: nd})
+	}
+	nds := node.GenerateRandomNodesData(4)
+	for i, nd := range nds {
+		nd.IP = inet.ParseIP(fmt.Sprintf("1.2.3.%d", i+1))
+	}
+	nds[3].IP = inet.ParseIP("1.2.4.1")
+	protected.IP = inet.ParseIP("1.2.3.100")
+
+	connect(nds[0])
+	connect(nds[1])
+	require.True(t, p.hasIncomingPeer(nds[1].PublicKey()))
+
+	// the subnet is full
+	connect(nds[2])
+	require.Equal(t, nds[2].PublicKey(), <-cpm.keyRemoved)
+	require.False(t, p.hasIncomingPeer(nds[2].PublicKey()))
+
+	// other subnets and protected peers are accepted
+	connect(nds[3])
+	require.True(t, p.hasIncomingPeer(nds[3].PublicKey()))
+	connect(protected)
+	require.True(t, p.hasIncomingPeer(protected.PublicKey()))
+
+	// disconnecting frees a slot of the subnet
+	p.Disconnect(nds[0].PublicKey())
+	p.Disconnect(protected.PublicKey())
+	connect(nds[2])
+	require.True(t, p.hasIncomingPeer(nds[2].PublicKey()))
+}
+
+func TestSwarm_Compression(t *testing.T) {
+	cfg := configWithPort(0)
+	cfg.SwarmConfig.Bootstrap = false
+	cfg.SwarmConfig.Gossip = false
+	cfg.CompressThreshold = 400
+	p := p2pTestNoStart(t, cfg)
+	msgs := p.RegisterDirectProtocol(exampleProtocol)
+	peer := node.GenerateRandomNodeData().PublicKey()
+
+	var sealed [][]byte
+	session := net.NewSessionMock(peer)
+	session.SealMessageFunc = func(message []byte) []byte {
+		sealed = append(sealed, message)
+		return message
+	}
+	session.OpenMessageFunc = func(boxedMessage []byte) ([]byte, error) {
+		return boxedMessage, nil
+	}
+	conn := net.NewConnectionMock(peer)
+	conn.SetSession(session)
+
+	payload := bytes.Repeat([]byte("block"), 100)
+	require.NoError(t, p.sendToConnection(conn, exampleProtocol, service.DataBytes{Payload: payload}))
+	require.NotEqual(t, compressedMessageMarker, sealed[0][0], "peers that didn't negotiate compression get plain messages")
+
+	p.setPeerCapabilities(peer, net.ProtocolVersion, []string{compressionCapability})
+	require.NoError(t, p.sendToConnection(conn, exampleProtocol, service.DataBytes{Payload: payload[:50]}))
+	require.NotEqual(t, compressedMessageMarker, sealed[1][0], "small messages aren't compressed")
+	require.NoError(t, p.sendToConnection(conn, exampleProtocol, service.DataBytes{Payload: payload}))
+	require.Equal(t, compressedMessageMarker, sealed[2][0])
+	require.Less(t, len(sealed[2]), len(sealed[0]))
+
+	require.NoError(t, p.onRemoteClientMessage(net.IncomingMessageEvent{Conn: conn, Message: sealed[2]}))
+	msg := <-msgs
+	require.Equal(t, payload, msg.Bytes())
+}
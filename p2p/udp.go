@@ -42,6 +42,9 @@ type UDPMux struct {
 
 	messages map[string]chan service.DirectMessage
 	shutdown chan struct{}
+
+	// allowed filters the peers messages are exchanged with, all peers are allowed if it's nil
+	allowed func(p2pcrypto.PublicKey) bool
 }
 
 // NewUDPMux creates a new udp protocol server
@@ -149,6 +152,10 @@ func (mux *UDPMux) sendMessageImpl(peerPubkey p2pcrypto.PublicKey, protocol stri
 	var err error
 	var peer *node.Info
 
+	if mux.allowed != nil && !mux.allowed(peerPubkey) {
+		return ErrNotAllowed
+	}
+
 	peer, err = mux.lookuper(peerPubkey)
 
 	if err != nil {
@@ -242,6 +249,10 @@ func (mux *UDPMux) processUDPMessage(msg inet.IncomingMessageEvent) error {
 		return ErrNoSession
 	}
 
+	if mux.allowed != nil && !mux.allowed(msg.Conn.RemotePublicKey()) {
+		return ErrNotAllowed
+	}
+
 	rawmsg, _, err := p2pcrypto.ExtractPubkey(msg.Message)
 
 	if err != nil {
@@ -237,6 +237,11 @@ func TestUDPMux_ProcessUDP(t *testing.T) {
 		t.Fatal("didn't get msg")
 	}
 
+	// messages of peers outside a private mesh are rejected
+	m.allowed = func(p2pcrypto.PublicKey) bool { return false }
+	err = m.processUDPMessage(net.IncomingMessageEvent{Conn: connmock, Message: msgbuf})
+	require.Equal(t, ErrNotAllowed, err)
+	require.Len(t, c, 0)
 }
 
 func Test_RoundTrip(t *testing.T) {
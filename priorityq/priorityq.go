@@ -10,13 +10,16 @@ var (
 
 	// ErrQueueClosed indicates an attempt to read from a closed priority queue instance
 	ErrQueueClosed = errors.New("the queue is closed")
+
+	// ErrQueueFull indicates a message wasn't written because the queue of its priority is full
+	ErrQueueFull = errors.New("the queue is full")
 )
 
 // Priority is the type that indicates the priority of different queues
 type Priority int
 
 const (
-	prioritiesCount = 3 // the number of priorities
+	prioritiesCount = 4 // the number of priorities
 
 	// High indicates the highest priority
 	High = Priority(0)
@@ -24,8 +27,11 @@ const (
 	// Mid indicates the medium priority
 	Mid = Priority(1)
 
-	// Low indicates the lowest priority
+	// Low indicates the low priority
 	Low = Priority(2)
+
+	// Bulk indicates the lowest priority, for traffic that can wait behind everything else
+	Bulk = Priority(3)
 )
 
 // Queue is the priority queue
@@ -62,6 +68,23 @@ func (pq *Queue) Write(prio Priority, m interface{}) error {
 	return nil
 }
 
+// TryWrite writes a message m to the associated queue with the provided priority if the queue isn't full
+// Returns ErrQueueFull instead of blocking iff the queue is full
+// Note: writing to the pq after a call to close is forbidden and will result in a panic
+func (pq *Queue) TryWrite(prio Priority, m interface{}) error {
+	if int(prio) >= cap(pq.queues) {
+		return ErrUnknownPriority
+	}
+
+	select {
+	case pq.queues[prio] <- m:
+	default:
+		return ErrQueueFull
+	}
+	pq.waitCh <- struct{}{}
+	return nil
+}
+
 // Read returns the next message by priority
 // An error is set iff the priority queue has been closed
 func (pq *Queue) Read() (interface{}, error) {
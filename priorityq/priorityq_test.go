@@ -51,7 +51,25 @@ func TestPriorityQ_Write(t *testing.T) {
 func TestPriorityQ_WriteError(t *testing.T) {
 	r := require.New(t)
 	pq := New(defLen)
-	r.Equal(ErrUnknownPriority, pq.Write(3, 0))
+	r.Equal(ErrUnknownPriority, pq.Write(4, 0))
+}
+
+func TestPriorityQ_TryWrite(t *testing.T) {
+	r := require.New(t)
+	pq := New(2)
+	r.NoError(pq.TryWrite(Bulk, 0))
+	r.NoError(pq.TryWrite(Bulk, 1))
+	r.Equal(ErrQueueFull, pq.TryWrite(Bulk, 2))
+	r.Equal(ErrUnknownPriority, pq.TryWrite(4, 0))
+
+	// other priorities aren't affected by a full queue
+	r.NoError(pq.TryWrite(High, 3))
+	m, err := pq.Read()
+	r.NoError(err)
+	r.Equal(3, m)
+	m, err = pq.Read()
+	r.NoError(err)
+	r.Equal(0, m)
 }
 
 func TestPriorityQ_Read(t *testing.T) {
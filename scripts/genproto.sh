@@ -39,3 +39,11 @@ echo "Generating protobuf for api/pb"
 compile -I. -Idevtools/include -I$googleapis_path $freebsd_opts --go_out=plugins=grpc:. api/pb/api.proto
 compile -I. -Idevtools/include -I$googleapis_path $freebsd_opts --grpc-gateway_out=logtostderr=true:. api/pb/api.proto
 compile -I. -Idevtools/include -I$googleapis_path $freebsd_opts --swagger_out=logtostderr=true:. api/pb/api.proto
+
+echo "Generating protobuf for api/extpb"
+compile -I. -Idevtools/include -I$googleapis_path $freebsd_opts --go_out=plugins=grpc,paths=source_relative:. api/extpb/*.proto
+compile -I. -Idevtools/include -I$googleapis_path $freebsd_opts --grpc-gateway_out=logtostderr=true,paths=source_relative:. api/extpb/*.proto
+compile -I. -Idevtools/include -I$googleapis_path $freebsd_opts --swagger_out=logtostderr=true:. api/extpb/*.proto
+
+echo "Embedding swagger definitions for api/extpb"
+(cd api/extpb && go generate)
@@ -11,3 +11,9 @@ ECHO Generating protobuf for api/pb
 %USERPROFILE%\protoc-3.6.1\bin\protoc -I%CD%\api\pb -I %grpc_gateway_path%\third_party\googleapis --grpc-gateway_out=logtostderr=true:%CD%\api\pb %CD%\api\pb\api.proto
 %USERPROFILE%\protoc-3.6.1\bin\protoc -I%CD%\api\pb -I %grpc_gateway_path%\third_party\googleapis --swagger_out=logtostderr=true:%CD%\api\pb %CD%\api\pb\api.proto
 
+ECHO Generating protobuf for api/extpb
+FOR %%P IN (%CD%\api\extpb\*.proto) DO (
+  %USERPROFILE%\protoc-3.6.1\bin\protoc -I%CD% -I %grpc_gateway_path%\third_party\googleapis --go_out=plugins=grpc,paths=source_relative:%CD% %%P
+  %USERPROFILE%\protoc-3.6.1\bin\protoc -I%CD% -I %grpc_gateway_path%\third_party\googleapis --grpc-gateway_out=logtostderr=true,paths=source_relative:%CD% %%P
+  %USERPROFILE%\protoc-3.6.1\bin\protoc -I%CD% -I %grpc_gateway_path%\third_party\googleapis --swagger_out=logtostderr=true:%CD% %%P
+)
@@ -0,0 +1,84 @@
+package signing
+
+import (
+	"runtime"
+	"sync"
+
+	"github.com/spacemeshos/ed25519"
+)
+
+// minExtractChunk is the smallest number of signatures handed to a single worker, below it the overhead of the worker
+// outweighs the gain
+const minExtractChunk = 8
+
+// KeyExtractor collects signed messages to extract the public keys of their signers over the available CPUs. Blocks and
+// ATXs don't carry the keys of their signers, the keys are extracted from the signatures, and extraction can't be
+// batched like ed25519 verification is: batch verification checks a combination of signatures against keys that are
+// known in advance, while any well formed signature extracts to some key, and every key takes its own scalar
+// multiplication. The signatures are only spread in chunks over the CPUs, and each one gets its own result, so a bad
+// signature only fails the message it signs.
+type KeyExtractor struct {
+	msgs [][]byte
+	sigs [][]byte
+}
+
+// NewKeyExtractor returns an empty extractor with room for size signatures
+func NewKeyExtractor(size int) *KeyExtractor {
+	return &KeyExtractor{
+		msgs: make([][]byte, 0, size),
+		sigs: make([][]byte, 0, size),
+	}
+}
+
+// Add adds a message and its signature to the extractor
+func (e *KeyExtractor) Add(message, sig []byte) {
+	e.msgs = append(e.msgs, message)
+	e.sigs = append(e.sigs, sig)
+}
+
+// Len returns the number of signatures in the extractor
+func (e *KeyExtractor) Len() int {
+	return len(e.sigs)
+}
+
+// ExtractParallel extracts the public keys of the signers of the messages in parallel, in the order they
+// were added. If the public key can't be extracted from a signature, its key is nil and its error is set.
+func (e *KeyExtractor) ExtractParallel() ([]*PublicKey, []error) {
+	keys := make([]*PublicKey, len(e.sigs))
+	errs := make([]error, len(e.sigs))
+	workers := runtime.NumCPU()
+	if max := (len(e.sigs) + minExtractChunk - 1) / minExtractChunk; max < workers {
+		workers = max
+	}
+	if workers <= 1 {
+		e.extract(0, len(e.sigs), keys, errs)
+		return keys, errs
+	}
+
+	chunk := (len(e.sigs) + workers - 1) / workers
+	var wg sync.WaitGroup
+	for from := 0; from < len(e.sigs); from += chunk {
+		to := from + chunk
+		if to > len(e.sigs) {
+			to = len(e.sigs)
+		}
+		wg.Add(1)
+		go func(from, to int) {
+			defer wg.Done()
+			e.extract(from, to, keys, errs)
+		}(from, to)
+	}
+	wg.Wait()
+	return keys, errs
+}
+
+func (e *KeyExtractor) extract(from, to int, keys []*PublicKey, errs []error) {
+	for i := from; i < to; i++ {
+		pub, err := ed25519.ExtractPublicKey(e.msgs[i], e.sigs[i])
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		keys[i] = NewPublicKey(pub)
+	}
+}
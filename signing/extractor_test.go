@@ -0,0 +1,39 @@
+package signing
+
+import (
+	"testing"
+
+	"github.com/spacemeshos/go-spacemesh/rand"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyExtractor_Extract(t *testing.T) {
+	signers := []*EdSigner{NewEdSigner(), NewEdSigner(), NewEdSigner()}
+	extractor := NewKeyExtractor(100)
+	for i := 0; i < 100; i++ {
+		m := make([]byte, 32)
+		rand.Read(m)
+		extractor.Add(m, signers[i%len(signers)].Sign(m))
+	}
+	// a malformed signature only fails its own message
+	extractor.sigs[42] = extractor.sigs[42][:10]
+	require.Equal(t, 100, extractor.Len())
+
+	keys, errs := extractor.ExtractParallel()
+	for i := range keys {
+		if i == 42 {
+			require.Error(t, errs[i])
+			require.Nil(t, keys[i])
+			continue
+		}
+		require.NoError(t, errs[i])
+		require.True(t, keys[i].Equals(signers[i%len(signers)].PublicKey()))
+		require.True(t, Verify(keys[i], extractor.msgs[i], extractor.sigs[i]))
+	}
+}
+
+func TestKeyExtractor_Empty(t *testing.T) {
+	keys, errs := NewKeyExtractor(0).ExtractParallel()
+	require.Empty(t, keys)
+	require.Empty(t, errs)
+}
@@ -3,6 +3,7 @@ package state
 import (
 	"fmt"
 	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/crypto"
 	"github.com/spacemeshos/go-spacemesh/log"
 	"github.com/spacemeshos/go-spacemesh/rlp"
 	"github.com/spacemeshos/go-spacemesh/trie"
@@ -273,3 +274,84 @@ func (state *DB) IntermediateRoot(deleteEmptyObjects bool) types.Hash32 {
 func (state *DB) TrieDB() *trie.Database {
 	return state.db.TrieDB()
 }
+
+// proofList collects the encoded trie nodes of a merkle proof
+type proofList [][]byte
+
+func (n *proofList) Put(key []byte, value []byte) error {
+	*n = append(*n, value)
+	return nil
+}
+
+// GetProof returns a merkle proof of the state of an account in the committed global state with the given root. If
+// the account doesn't exist in that state, the proof shows its absence.
+func (state *DB) GetProof(root types.Hash32, addr types.Address) (*types.AccountProof, error) {
+	tr, err := state.db.OpenTrie(root)
+	if err != nil {
+		return nil, err
+	}
+	var nodes proofList
+	// accounts are keyed by the hash of their address in the secure trie
+	if err := tr.Prove(crypto.Keccak256(addr.Bytes()), 0, &nodes); err != nil {
+		return nil, err
+	}
+	proof := &types.AccountProof{Address: addr, Nodes: nodes}
+	data, err := readAccount(tr, addr)
+	if err != nil || data == nil {
+		return proof, err
+	}
+	proof.Exists = true
+	proof.Nonce = data.Nonce
+	proof.Balance = data.Balance.Uint64()
+	return proof, nil
+}
+
+// GetAccountAt returns the nonce and balance of an account in the committed global state with the given root
+func (state *DB) GetAccountAt(root types.Hash32, addr types.Address) (exists bool, nonce, balance uint64, err error) {
+	tr, err := state.db.OpenTrie(root)
+	if err != nil {
+		return false, 0, 0, err
+	}
+	data, err := readAccount(tr, addr)
+	if err != nil || data == nil {
+		return false, 0, 0, err
+	}
+	return true, data.Nonce, data.Balance.Uint64(), nil
+}
+
+// readAccount reads an account from a trie, it returns nil if the account doesn't exist
+func readAccount(tr Trie, addr types.Address) (*Account, error) {
+	enc, err := tr.TryGet(addr.Bytes())
+	if err != nil || len(enc) == 0 {
+		return nil, err
+	}
+	var data Account
+	if err := rlp.DecodeBytes(enc, &data); err != nil {
+		return nil, fmt.Errorf("failed to decode account %v: %v", addr.Short(), err)
+	}
+	return &data, nil
+}
+
+// IterateAccounts calls fn for every account in the committed global state with the given root, ordered by the hash
+// of their address. It stops at the first error returned by fn.
+func (state *DB) IterateAccounts(root types.Hash32, fn func(addr types.Address, nonce, balance uint64) error) error {
+	tr, err := state.db.OpenTrie(root)
+	if err != nil {
+		return err
+	}
+	it := trie.NewIterator(tr.NodeIterator(nil))
+	for it.Next() {
+		addr := tr.GetKey(it.Key)
+		if addr == nil {
+			return fmt.Errorf("missing address of account with key %x", it.Key)
+		}
+		var data Account
+		if err := rlp.DecodeBytes(it.Value, &data); err != nil {
+			return fmt.Errorf("failed to decode account with key %x: %v", it.Key, err)
+		}
+		if err := fn(types.BytesToAddress(addr), data.Nonce, data.Balance.Uint64()); err != nil {
+			return err
+		}
+	}
+	return it.Err
+}
@@ -46,6 +46,7 @@ type TransactionProcessor struct {
 	rootHash     types.Hash32
 	stateQueue   list.List
 	projector    Projector
+	receipts     *ReceiptStore
 	trie         *trie.Database
 	mu           sync.Mutex
 	rootMu       sync.RWMutex
@@ -142,10 +143,108 @@ func (tp *TransactionProcessor) ApplyTransactions(layer types.LayerID, txs []*ty
 	}
 
 	err = tp.addStateToHistory(layer, newHash)
+	if err == nil {
+		tp.reportAppliedTxs(layer, txs, remaining)
+	}
 
 	return remainingCount, err
 }
 
+// CommitLayer commits the changes made to the global state as the state after applying the given layer, without
+// applying any transactions. It's used to set up the state of a node that is restored from a checkpoint.
+func (tp *TransactionProcessor) CommitLayer(layer types.LayerID) (types.Hash32, error) {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	root, err := tp.Commit()
+	if err != nil {
+		return types.Hash32{}, fmt.Errorf("failed to commit global state: %v", err)
+	}
+	return root, tp.addStateToHistory(layer, root)
+}
+
+// SetReceiptStore sets the store in which the receipts of applied transactions are recorded. Receipts aren't recorded
+// until a store is set.
+func (tp *TransactionProcessor) SetReceiptStore(receipts *ReceiptStore) {
+	tp.receipts = receipts
+}
+
+// reportAppliedTxs records and publishes the outcome of applying a layer's transactions, remaining are the ones that
+// failed, and publishes the new state of the accounts they changed. It must be called after the state was committed.
+func (tp *TransactionProcessor) reportAppliedTxs(layer types.LayerID, txs, remaining []*types.Transaction) {
+	failed := make(map[types.TransactionID]struct{}, len(remaining))
+	for _, tx := range remaining {
+		failed[tx.ID()] = struct{}{}
+	}
+	var updated []types.Address
+	for _, tx := range txs {
+		receipt := &Receipt{ID: tx.ID(), Layer: layer, Result: events.TxResultApplied, GasUsed: tx.GasLimit, Fee: tx.Fee}
+		if _, isFailed := failed[tx.ID()]; isFailed {
+			receipt.Result = tp.failureResult(tx)
+			receipt.GasUsed = 0
+			receipt.Fee = 0
+			receipt.Error = failureError(receipt.Result)
+		} else {
+			updated = append(updated, tx.Origin(), tx.Recipient)
+		}
+		if tp.receipts != nil {
+			if err := tp.receipts.Put(receipt); err != nil {
+				tp.With().Error("failed to store transaction receipt", tx.ID(), log.Err(err))
+			}
+		}
+		events.Publish(events.TxApplied{
+			ID:      tx.ID(),
+			LayerID: layer,
+			Fee:     tx.Fee,
+			Result:  receipt.Result,
+			GasUsed: receipt.GasUsed,
+			Error:   receipt.Error,
+		})
+	}
+	tp.reportAccountUpdates(layer, updated)
+}
+
+// failureResult returns the reason a transaction failed to apply. Failed transactions are retried until no more
+// transactions can be applied, so the committed state is the one they failed against.
+func (tp *TransactionProcessor) failureResult(tx *types.Transaction) events.TxResult {
+	switch {
+	case !tp.Exist(tx.Origin()):
+		return events.TxResultUnknownOrigin
+	case tp.GetBalance(tx.Origin()) <= tx.Fee+tx.Amount:
+		return events.TxResultInsufficientFunds
+	default:
+		return events.TxResultBadNonce
+	}
+}
+
+// failureError returns the error ApplyTransaction returns for transactions that fail with the given result
+func failureError(result events.TxResult) string {
+	switch result {
+	case events.TxResultUnknownOrigin:
+		return errOrigin
+	case events.TxResultInsufficientFunds:
+		return errFunds
+	default:
+		return errNonce
+	}
+}
+
+// reportAccountUpdates publishes the current state of the given accounts, each account is reported once
+func (tp *TransactionProcessor) reportAccountUpdates(layer types.LayerID, accounts []types.Address) {
+	seen := make(map[types.Address]struct{}, len(accounts))
+	for _, addr := range accounts {
+		if _, ok := seen[addr]; ok {
+			continue
+		}
+		seen[addr] = struct{}{}
+		events.Publish(events.AccountUpdate{
+			Address: addr,
+			Nonce:   tp.GetNonce(addr),
+			Balance: tp.GetBalance(addr),
+			LayerID: layer,
+		})
+	}
+}
+
 func (tp *TransactionProcessor) addStateToHistory(layer types.LayerID, newHash types.Hash32) error {
 	tp.trie.Reference(newHash, types.Hash32{})
 	err := tp.trie.Commit(newHash, false)
@@ -174,16 +273,30 @@ func (tp *TransactionProcessor) addState(stateRoot types.Hash32, layer types.Lay
 	return nil
 }
 
-func (tp *TransactionProcessor) getLayerStateRoot(layer types.LayerID) (types.Hash32, error) {
+// GetLayerStateRoot returns the global state root after applying the given layer. The roots of all applied layers are
+// retained unless the node is pruned, it returns database.ErrNotFound for layers that weren't applied and
+// database.ErrPruned for layers that were pruned.
+func (tp *TransactionProcessor) GetLayerStateRoot(layer types.LayerID) (types.Hash32, error) {
 	bts, err := tp.processorDb.Get(getStateRootLayerKey(layer))
 	if err != nil {
 		return types.Hash32{}, err
 	}
+	if len(bts) == 0 {
+		return types.Hash32{}, database.ErrPruned
+	}
 	var x types.Hash32
 	x.SetBytes(bts)
 	return x, nil
 }
 
+// PruneLayerStateRoot deletes the global state root of a layer that fell out of the history a pruned node keeps
+func (tp *TransactionProcessor) PruneLayerStateRoot(layer types.LayerID) error {
+	if _, err := tp.processorDb.Get(getStateRootLayerKey(layer)); err == database.ErrNotFound {
+		return nil
+	}
+	return tp.processorDb.Put(getStateRootLayerKey(layer), nil)
+}
+
 // ApplyRewards applies reward reward to miners vector miners in for layer
 func (tp *TransactionProcessor) ApplyRewards(layer types.LayerID, miners []types.Address, reward *big.Int) {
 	for _, account := range miners {
@@ -193,7 +306,6 @@ func (tp *TransactionProcessor) ApplyRewards(layer types.LayerID, miners []types
 			layer,
 		)
 		tp.AddBalance(account, reward)
-		events.Publish(events.RewardReceived{Coinbase: account.String(), Amount: reward.Uint64()})
 	}
 	newHash, err := tp.Commit()
 
@@ -206,13 +318,14 @@ func (tp *TransactionProcessor) ApplyRewards(layer types.LayerID, miners []types
 	if err != nil {
 		tp.Log.Error("failed to add state to history: %v", err)
 	}
+	tp.reportAccountUpdates(layer, miners)
 }
 
 // LoadState loads the last state from persistent storage
 func (tp *TransactionProcessor) LoadState(layer types.LayerID) error {
 	tp.mu.Lock()
 	defer tp.mu.Unlock()
-	state, err := tp.getLayerStateRoot(layer)
+	state, err := tp.GetLayerStateRoot(layer)
 	if err != nil {
 		return err
 	}
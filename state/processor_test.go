@@ -5,6 +5,7 @@ import (
 	"github.com/spacemeshos/ed25519"
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/database"
+	"github.com/spacemeshos/go-spacemesh/events"
 	"github.com/spacemeshos/go-spacemesh/log"
 	"github.com/spacemeshos/go-spacemesh/mesh"
 	"github.com/spacemeshos/go-spacemesh/signing"
@@ -197,6 +198,57 @@ func (s *ProcessorStateSuite) TestTransactionProcessor_ApplyTransaction_Errors()
 	assert.Equal(s.T(), err.Error(), errOrigin)
 }
 
+func (s *ProcessorStateSuite) TestTransactionProcessor_ApplyTransactions_Events() {
+	signer1 := signing.NewEdSigner()
+	obj1 := createAccount(s.processor, SignerToAddr(signer1), 21, 0)
+	obj2 := createAccount(s.processor, toAddr([]byte{0x01, 02}), 1, 10)
+	s.processor.Commit()
+
+	sub := events.Subscribe(events.EventTxApplied)
+	defer sub.Close()
+	accountSub := events.Subscribe(events.EventAccountUpdate)
+	defer accountSub.Close()
+
+	applied := createTransaction(s.T(), obj1.Nonce(), obj2.address, 1, 5, signer1)
+	failed := createTransaction(s.T(), obj1.Nonce()+1, obj2.address, 100, 5, signer1)
+	badNonce := createTransaction(s.T(), obj1.Nonce()+5, obj2.address, 1, 5, signer1)
+	numFailed, err := s.processor.ApplyTransactions(1, []*types.Transaction{applied, failed, badNonce})
+	s.NoError(err)
+	s.Equal(2, numFailed)
+
+	s.Equal(events.TxApplied{ID: applied.ID(), LayerID: 1, Fee: 5, Result: events.TxResultApplied, GasUsed: 100}, <-sub.Events())
+	s.Equal(events.TxApplied{ID: failed.ID(), LayerID: 1, Fee: 5, Result: events.TxResultInsufficientFunds, Error: errFunds}, <-sub.Events())
+	s.Equal(events.TxApplied{ID: badNonce.ID(), LayerID: 1, Fee: 5, Result: events.TxResultBadNonce, Error: errNonce}, <-sub.Events())
+
+	s.Equal(events.AccountUpdate{Address: obj1.address, Nonce: 1, Balance: 15, LayerID: 1}, <-accountSub.Events())
+	s.Equal(events.AccountUpdate{Address: obj2.address, Nonce: 10, Balance: 2, LayerID: 1}, <-accountSub.Events())
+	s.Len(accountSub.Events(), 0)
+}
+
+func (s *ProcessorStateSuite) TestTransactionProcessor_ApplyTransactions_Receipts() {
+	receipts := NewReceiptStore(database.NewMemDatabase())
+	s.processor.SetReceiptStore(receipts)
+	signer1 := signing.NewEdSigner()
+	obj1 := createAccount(s.processor, SignerToAddr(signer1), 21, 0)
+	obj2 := createAccount(s.processor, toAddr([]byte{0x01, 02}), 1, 10)
+	s.processor.Commit()
+
+	applied := createTransaction(s.T(), obj1.Nonce(), obj2.address, 1, 5, signer1)
+	failed := createTransaction(s.T(), obj1.Nonce()+1, obj2.address, 100, 5, signer1)
+	_, err := s.processor.ApplyTransactions(3, []*types.Transaction{applied, failed})
+	s.NoError(err)
+
+	receipt, err := receipts.Get(applied.ID())
+	s.NoError(err)
+	s.Equal(&Receipt{ID: applied.ID(), Layer: 3, Result: events.TxResultApplied, GasUsed: 100, Fee: 5}, receipt)
+	receipt, err = receipts.Get(failed.ID())
+	s.NoError(err)
+	s.Equal(&Receipt{ID: failed.ID(), Layer: 3, Result: events.TxResultInsufficientFunds, Error: errFunds}, receipt)
+
+	_, err = receipts.Get(types.TransactionID{1})
+	s.Equal(database.ErrNotFound, err)
+}
+
 func (s *ProcessorStateSuite) TestTransactionProcessor_ApplyRewards() {
 	s.processor.ApplyRewards(1, []types.Address{types.HexToAddress("aaa"),
 		types.HexToAddress("bbb"),
@@ -565,7 +617,30 @@ func TestTransactionProcessor_ApplyTransactions(t *testing.T) {
 	_, err = processor.ApplyTransactions(3, []*types.Transaction{})
 	assert.NoError(t, err)
 
-	_, err = processor.getLayerStateRoot(3)
+	_, err = processor.GetLayerStateRoot(3)
 	assert.NoError(t, err)
 
 }
+
+func TestTransactionProcessor_PruneLayerStateRoot(t *testing.T) {
+	r := require.New(t)
+	lg := log.New("proc_logger", "", "")
+	db := database.NewMemDatabase()
+	processor := NewTransactionProcessor(db, db, &ProjectorMock{}, NewTxMemPool(), lg)
+
+	_, err := processor.ApplyTransactions(1, []*types.Transaction{})
+	r.NoError(err)
+	_, err = processor.ApplyTransactions(2, []*types.Transaction{})
+	r.NoError(err)
+
+	r.NoError(processor.PruneLayerStateRoot(1))
+	_, err = processor.GetLayerStateRoot(1)
+	r.Equal(database.ErrPruned, err)
+	_, err = processor.GetLayerStateRoot(2)
+	r.NoError(err)
+
+	// layers that weren't applied aren't marked as pruned
+	r.NoError(processor.PruneLayerStateRoot(3))
+	_, err = processor.GetLayerStateRoot(3)
+	r.Equal(database.ErrNotFound, err)
+}
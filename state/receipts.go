@@ -0,0 +1,57 @@
+package state
+
+import (
+	"fmt"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/database"
+	"github.com/spacemeshos/go-spacemesh/events"
+)
+
+// Receipt records the outcome of applying a transaction of a confirmed layer to the global state. Failed transactions
+// are dropped from the mesh, their receipt is the only trace of why they failed.
+type Receipt struct {
+	ID     types.TransactionID
+	Layer  types.LayerID
+	Result events.TxResult
+	// GasUsed is the gas charged for the transaction, simple coin transactions use their whole gas limit when they are
+	// applied and nothing when they fail
+	GasUsed uint64
+	// Fee is the fee paid by the origin of the transaction, it's zero for failed transactions
+	Fee uint64
+	// Error describes why the transaction failed, it's empty for applied transactions
+	Error string
+}
+
+// ReceiptStore persists the receipts of applied transactions. A receipt is overwritten if its transaction is applied
+// again, e.g. after the state was reverted.
+type ReceiptStore struct {
+	db database.Database
+}
+
+// NewReceiptStore returns a new ReceiptStore that keeps the receipts in db
+func NewReceiptStore(db database.Database) *ReceiptStore {
+	return &ReceiptStore{db: db}
+}
+
+// Put stores the receipt of a transaction
+func (s *ReceiptStore) Put(receipt *Receipt) error {
+	b, err := types.InterfaceToBytes(receipt)
+	if err != nil {
+		return fmt.Errorf("failed to serialize receipt: %v", err)
+	}
+	return s.db.Put(receipt.ID.Bytes(), b)
+}
+
+// Get returns the receipt of a transaction, or database.ErrNotFound if the transaction wasn't applied
+func (s *ReceiptStore) Get(id types.TransactionID) (*Receipt, error) {
+	b, err := s.db.Get(id.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	var receipt Receipt
+	if err := types.BytesToInterface(b, &receipt); err != nil {
+		return nil, fmt.Errorf("failed to deserialize receipt: %v", err)
+	}
+	return &receipt, nil
+}
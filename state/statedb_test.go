@@ -155,3 +155,122 @@ func TestCopyOfCopy(t *testing.T) {
 		t.Fatalf("2nd copy fail, expected 42, got %v", got)
 	}
 }
+
+func TestGetProof(t *testing.T) {
+	state, _ := New(types.Hash32{}, NewDatabase(database.NewMemDatabase()))
+	for i := byte(1); i < 100; i++ {
+		addr := types.BytesToAddress([]byte{i})
+		state.AddBalance(addr, big.NewInt(11*int64(i)))
+		state.SetNonce(addr, uint64(i))
+	}
+	root, err := state.Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addr := types.BytesToAddress([]byte{42})
+	proof, err := state.GetProof(root, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !proof.Exists || proof.Nonce != 42 || proof.Balance != 462 {
+		t.Fatalf("unexpected proven state %+v", proof)
+	}
+	if err := proof.Verify(root); err != nil {
+		t.Errorf("valid proof rejected: %v", err)
+	}
+	if err := proof.Verify(types.BytesToHash([]byte("other root"))); err == nil {
+		t.Error("proof accepted for another root")
+	}
+	proof.Balance++
+	if err := proof.Verify(root); err == nil {
+		t.Error("proof accepted for a wrong balance")
+	}
+
+	absent, err := state.GetProof(root, types.BytesToAddress([]byte{200}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if absent.Exists {
+		t.Fatal("absent account reported as existing")
+	}
+	if err := absent.Verify(root); err != nil {
+		t.Errorf("valid absence proof rejected: %v", err)
+	}
+	absent.Address = addr
+	if err := absent.Verify(root); err == nil {
+		t.Error("absence proof accepted for an existing account")
+	}
+
+	empty, err := state.GetProof(types.Hash32{}, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := empty.Verify(types.HexToHash32("56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")); err != nil {
+		t.Errorf("empty state proof rejected: %v", err)
+	}
+}
+
+func TestGetAccountAt(t *testing.T) {
+	state, _ := New(types.Hash32{}, NewDatabase(database.NewMemDatabase()))
+	addr := types.BytesToAddress([]byte{1})
+	state.AddBalance(addr, big.NewInt(100))
+	state.SetNonce(addr, 1)
+	oldRoot, err := state.Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	state.SubBalance(addr, big.NewInt(30))
+	state.SetNonce(addr, 2)
+	newRoot, err := state.Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exists, nonce, balance, err := state.GetAccountAt(oldRoot, addr)
+	if err != nil || !exists || nonce != 1 || balance != 100 {
+		t.Errorf("unexpected old state: exists %v nonce %v balance %v err %v", exists, nonce, balance, err)
+	}
+	exists, nonce, balance, err = state.GetAccountAt(newRoot, addr)
+	if err != nil || !exists || nonce != 2 || balance != 70 {
+		t.Errorf("unexpected new state: exists %v nonce %v balance %v err %v", exists, nonce, balance, err)
+	}
+	exists, _, _, err = state.GetAccountAt(newRoot, types.BytesToAddress([]byte{2}))
+	if err != nil || exists {
+		t.Errorf("unexpected state of a missing account: exists %v err %v", exists, err)
+	}
+	if _, _, _, err := state.GetAccountAt(types.BytesToHash([]byte("unknown")), addr); err == nil {
+		t.Error("expected an error for an unknown root")
+	}
+}
+
+func TestIterateAccounts(t *testing.T) {
+	db := NewDatabase(database.NewMemDatabase())
+	state, _ := New(types.Hash32{}, db)
+	for i := byte(1); i <= 50; i++ {
+		addr := types.BytesToAddress([]byte{i})
+		state.AddBalance(addr, big.NewInt(int64(i)))
+		state.SetNonce(addr, uint64(i))
+	}
+	root, err := state.Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the addresses are read from the committed preimages of a fresh state
+	fresh, _ := New(root, db)
+	seen := make(map[types.Address]bool)
+	err = fresh.IterateAccounts(root, func(addr types.Address, nonce, balance uint64) error {
+		if nonce != uint64(addr.Bytes()[types.AddressLength-1]) || balance != nonce {
+			t.Errorf("unexpected state of %v: nonce %v balance %v", addr.Short(), nonce, balance)
+		}
+		seen[addr] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != 50 {
+		t.Errorf("expected 50 accounts, iterated %v", len(seen))
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/events"
 	"github.com/spacemeshos/go-spacemesh/pendingtxs"
 	"github.com/spacemeshos/go-spacemesh/rand"
 	"sync"
@@ -37,6 +38,13 @@ func (t *TxMempool) Get(id types.TransactionID) (*types.Transaction, error) {
 	return nil, errors.New("transaction not found in mempool")
 }
 
+// Len returns the number of transactions in the mempool
+func (t *TxMempool) Len() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return len(t.txs)
+}
+
 // GetTxIdsByAddress returns all transactions from/to a specific address
 func (t *TxMempool) GetTxIdsByAddress(addr types.Address) []types.TransactionID {
 	var ids []types.TransactionID
@@ -49,9 +57,19 @@ func (t *TxMempool) GetTxIdsByAddress(addr types.Address) []types.TransactionID
 // GetTxsForBlock gets a specific number of random txs for a block. This function also receives a state calculation function
 // to allow returning only transactions that will probably be valid
 func (t *TxMempool) GetTxsForBlock(numOfTxs int, getState func(addr types.Address) (nonce, balance uint64, err error)) ([]types.TransactionID, []*types.Transaction, error) {
+	return t.SelectTxsForBlock(numOfTxs, getState, func() bool { return false })
+}
+
+// SelectTxsForBlock is like GetTxsForBlock, but it stops calculating the state of accounts once done returns true, and
+// selects the txs among the accounts it went through so far. It allows building a block against a deadline when
+// calculating the state is slow
+func (t *TxMempool) SelectTxsForBlock(numOfTxs int, getState func(addr types.Address) (nonce, balance uint64, err error), done func() bool) ([]types.TransactionID, []*types.Transaction, error) {
 	var txIds []types.TransactionID
 	t.mu.RLock()
 	for addr, account := range t.accounts {
+		if done() {
+			break
+		}
 		nonce, balance, err := getState(addr)
 		if err != nil {
 			t.mu.RUnlock()
@@ -100,6 +118,7 @@ func (t *TxMempool) Put(id types.TransactionID, tx *types.Transaction) {
 	t.addToAddr(tx.Origin(), id)
 	t.addToAddr(tx.Recipient, id)
 	t.mu.Unlock()
+	events.Publish(events.TxInMempool{Transaction: tx})
 }
 
 // Invalidate removes transaction from pool
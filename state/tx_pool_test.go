@@ -46,8 +46,10 @@ func TestNewTxPoolWithAccounts(t *testing.T) {
 	r.ElementsMatch([]types.TransactionID{tx1.ID(), tx2.ID()}, pool.GetTxIdsByAddress(origin))
 	r.ElementsMatch([]types.TransactionID{tx1.ID()}, pool.GetTxIdsByAddress(tx1.Recipient))
 	r.ElementsMatch([]types.TransactionID{tx2.ID()}, pool.GetTxIdsByAddress(tx2.Recipient))
+	r.Equal(2, pool.Len())
 
 	pool.Invalidate(tx1.ID())
+	r.Equal(1, pool.Len())
 	nonce, balance = pool.GetProjection(origin, prevNonce+1, prevBalance-50)
 	r.Equal(prevNonce+2, nonce)
 	r.Equal(prevBalance-50-150, balance)
@@ -181,3 +183,31 @@ func createBatch(t testing.TB, signer *signing.EdSigner) ([]*types.Transaction,
 	}
 	return txBatch, txIDBatch
 }
+
+func TestTxMempool_SelectTxsForBlock(t *testing.T) {
+	r := require.New(t)
+
+	pool := NewTxMemPool()
+	for i := 0; i < 3; i++ {
+		tx := newTx(t, 5, 50, signing.NewEdSigner())
+		pool.Put(tx.ID(), tx)
+	}
+
+	// nothing is selected once done
+	txs, _, err := pool.SelectTxsForBlock(10, getState, func() bool { return true })
+	r.NoError(err)
+	r.Empty(txs)
+
+	// txs are selected among the accounts gone through before done
+	calls := 0
+	txs, _, err = pool.SelectTxsForBlock(10, func(addr types.Address) (uint64, uint64, error) {
+		calls++
+		return getState(addr)
+	}, func() bool { return calls == 2 })
+	r.NoError(err)
+	r.Len(txs, 2)
+
+	txs, _, err = pool.SelectTxsForBlock(10, getState, func() bool { return false })
+	r.NoError(err)
+	r.Len(txs, 3)
+}
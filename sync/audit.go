@@ -0,0 +1,176 @@
+package sync
+
+import (
+	"sync"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/database"
+	"github.com/spacemeshos/go-spacemesh/log"
+)
+
+// The node may lose data it synced, e.g. when writes fail or the disk is corrupted, and it only notices when it serves
+// peers or computes something from the data. The audit periodically scans the recent validated layers for blocks, and
+// the transactions and ATXs they reference, that the node doesn't have, and fetches them from peers again. Layers being
+// synced aren't scanned, and neither are layers a pruned node pruned.
+
+// AuditReport describes an audit of the data of the recent layers of the mesh
+type AuditReport struct {
+	Started   time.Time
+	Duration  time.Duration
+	FromLayer types.LayerID
+	ToLayer   types.LayerID
+	Missing   map[ObjectKind]int // objects referenced by the scanned layers that the node didn't have
+	Refetched map[ObjectKind]int // missing objects that were fetched from peers and stored again
+}
+
+func newAuditReport(from, to types.LayerID) *AuditReport {
+	return &AuditReport{
+		Started:   time.Now(),
+		FromLayer: from,
+		ToLayer:   to,
+		Missing:   make(map[ObjectKind]int),
+		Refetched: make(map[ObjectKind]int),
+	}
+}
+
+func (r *AuditReport) missing(kind ObjectKind, refetched bool) {
+	r.Missing[kind]++
+	auditMissing.With("kind", kind.String()).Add(1)
+	if refetched {
+		r.Refetched[kind]++
+		auditRefetched.With("kind", kind.String()).Add(1)
+	}
+}
+
+type auditor struct {
+	mu     sync.Mutex // held while auditing, so audits don't run concurrently
+	lastMu sync.RWMutex
+	last   *AuditReport
+}
+
+// LastAudit returns the report of the latest audit, nil if the node didn't audit its data yet
+func (s *Syncer) LastAudit() *AuditReport {
+	s.audits.lastMu.RLock()
+	defer s.audits.lastMu.RUnlock()
+	return s.audits.last
+}
+
+// Audit scans the last AuditLayers validated layers for missing data and fetches it from peers
+func (s *Syncer) Audit() *AuditReport {
+	s.audits.mu.Lock()
+	defer s.audits.mu.Unlock()
+
+	to := s.ProcessedLayer()
+	from := types.GetEffectiveGenesis() + 1
+	if s.AuditLayers > 0 && to >= from+types.LayerID(s.AuditLayers) {
+		from = to - types.LayerID(s.AuditLayers) + 1
+	}
+	if pruned := s.PrunedLayer(); from < pruned {
+		from = pruned
+	}
+	report := newAuditReport(from, to)
+	for layer := from; layer <= to; layer++ {
+		if s.shutdown() {
+			break
+		}
+		s.auditLayer(layer, report)
+	}
+	report.Duration = time.Since(report.Started)
+	auditDuration.Set(report.Duration.Seconds())
+
+	s.With().Info("audited mesh data",
+		log.FieldNamed("from_layer", from),
+		log.FieldNamed("to_layer", to),
+		log.Int("missing_blocks", report.Missing[BlockObject]),
+		log.Int("missing_txs", report.Missing[TransactionObject]),
+		log.Int("missing_atxs", report.Missing[AtxObject]),
+		log.Int("refetched_blocks", report.Refetched[BlockObject]),
+		log.Int("refetched_txs", report.Refetched[TransactionObject]),
+		log.Int("refetched_atxs", report.Refetched[AtxObject]),
+		log.String("duration", report.Duration.String()))
+
+	s.audits.lastMu.Lock()
+	s.audits.last = report
+	s.audits.lastMu.Unlock()
+	return report
+}
+
+func (s *Syncer) auditLayer(layer types.LayerID, report *AuditReport) {
+	ids, err := s.LayerBlockIds(layer)
+	if err != nil {
+		if err != database.ErrNotFound {
+			s.With().Error("audit could not read the blocks of layer", layer, log.Err(err))
+		}
+		return
+	}
+	for _, id := range ids {
+		blk, err := s.GetBlock(id)
+		if err == database.ErrPruned {
+			continue
+		}
+		if err != nil {
+			s.With().Warning("audit found a missing block", layer, id)
+			report.missing(BlockObject, s.fetchBlock(id))
+			continue
+		}
+		s.auditBlockData(blk, report)
+	}
+}
+
+// auditBlockData fetches the missing transactions and ATXs that a stored block references
+func (s *Syncer) auditBlockData(blk *types.Block, report *AuditReport) {
+	if _, missing := s.GetTransactions(blk.TxIDs); len(missing) > 0 {
+		ids := make([]types.TransactionID, 0, len(missing))
+		for id := range missing {
+			ids = append(ids, id)
+		}
+		s.With().Warning("audit found missing transactions", blk.ID(), blk.LayerIndex, log.Int("tx_count", len(ids)))
+		txs, err := s.txQueue.HandleTxs(ids)
+		if err == nil {
+			err = s.AddBlockBody(blk, txs)
+		}
+		if err != nil {
+			s.With().Error("audit could not fetch the transactions of block", blk.ID(), log.Err(err))
+		}
+		_, stillMissing := s.GetTransactions(ids)
+		for _, id := range ids {
+			_, notFetched := stillMissing[id]
+			report.missing(TransactionObject, !notFetched)
+		}
+	}
+
+	atxs := []types.ATXID{blk.ATXID}
+	if blk.ActiveSet != nil {
+		atxs = append(atxs, *blk.ActiveSet...)
+	}
+	for _, id := range atxs {
+		if _, err := s.atxDb.GetFullAtx(id); err == nil {
+			continue
+		}
+		s.With().Warning("audit found a missing atx", blk.ID(), blk.LayerIndex, id)
+		_, err := s.fetchAtx(id)
+		if err != nil {
+			s.With().Error("audit could not fetch atx", id, log.Err(err))
+		}
+		report.missing(AtxObject, err == nil)
+	}
+}
+
+// auditLoop audits the data of the recent layers every AuditInterval
+func (s *Syncer) auditLoop() {
+	ticker := time.NewTicker(s.AuditInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.exit:
+			return
+		case <-ticker.C:
+			if !s.IsSynced() {
+				// layers are still being synced, their data is expected to be partial
+				continue
+			}
+			s.Audit()
+		}
+	}
+}
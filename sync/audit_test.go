@@ -0,0 +1,52 @@
+package sync
+
+import (
+	"testing"
+
+	"github.com/spacemeshos/go-spacemesh/activation"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	p2ppeers "github.com/spacemeshos/go-spacemesh/p2p/peers"
+	"github.com/spacemeshos/go-spacemesh/rand"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncer_Audit(t *testing.T) {
+	r := require.New(t)
+	syncs, nodes, _ := SyncMockFactory(2, conf, t.Name(), memoryDB, newMemPoetDb)
+	client, server := syncs[0], syncs[1]
+	defer client.Close()
+	defer server.Close()
+	client.peers = getPeersMock([]p2ppeers.Peer{nodes[1].PublicKey()})
+	atxPool := activation.NewAtxMemPool()
+	client.atxDb = atxPool
+	atx := atx("")
+	atxPool.Put(atx)
+	r.Nil(client.LastAudit())
+
+	block := types.NewExistingBlock(types.GetEffectiveGenesis()+1, []byte(rand.String(8)))
+	block.ActiveSet = &[]types.ATXID{atx.ID()}
+	block.ATXID = atx.ID()
+	block.TxIDs = []types.TransactionID{tx1.ID()}
+	block.Initialize()
+	r.NoError(server.AddBlockWithTxs(block, []*types.Transaction{tx1}, nil))
+
+	// the client stored the block but lost its transaction
+	r.NoError(client.AddBlockWithTxs(block, nil, nil))
+	client.SetProcessedLayer(block.Layer())
+	_, missing := client.GetTransactions(block.TxIDs)
+	r.Len(missing, 1)
+
+	report := client.Audit()
+	r.Equal(block.Layer(), report.FromLayer)
+	r.Equal(block.Layer(), report.ToLayer)
+	r.Equal(map[ObjectKind]int{TransactionObject: 1}, report.Missing)
+	r.Equal(map[ObjectKind]int{TransactionObject: 1}, report.Refetched)
+	r.Equal(report, client.LastAudit())
+	_, missing = client.GetTransactions(block.TxIDs)
+	r.Empty(missing)
+
+	// nothing is missing anymore
+	report = client.Audit()
+	r.Empty(report.Missing)
+	r.Empty(report.Refetched)
+}
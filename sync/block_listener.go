@@ -10,6 +10,9 @@ import (
 	"time"
 )
 
+// gossipBlockBatchSize is the maximal number of gossip blocks whose miner IDs are extracted together
+const gossipBlockBatchSize = 64
+
 // BlockListener Listens to blocks propagated in gossip
 type BlockListener struct {
 	*Syncer
@@ -47,7 +50,7 @@ func NewBlockListener(net service.Service, sync *Syncer, concurrency int, logger
 		Log:                  logger,
 		semaphore:            make(chan struct{}, concurrency),
 		exit:                 make(chan struct{}),
-		receivedGossipBlocks: net.RegisterGossipProtocol(config.NewBlockProtocol, priorityq.High),
+		receivedGossipBlocks: net.RegisterGossipProtocol(config.NewBlockProtocol, priorityq.Mid),
 	}
 	return &bl
 }
@@ -64,33 +67,80 @@ func (bl *BlockListener) listenToGossipBlocks() {
 				break
 			}
 
-			bl.wg.Add(1)
-			go func() {
-				defer bl.wg.Done()
-				if data == nil {
-					bl.Error("got empty message while listening to gossip blocks")
+			msgs, blocks := bl.decodeGossipBlocks(bl.collectGossipBlocks(data))
+			for i := range blocks {
+				msg, blk := msgs[i], blocks[i]
+				// at most concurrency blocks are validated at the same time
+				select {
+				case bl.semaphore <- struct{}{}:
+				case <-bl.exit:
+					bl.Log.Info("listening  stopped")
 					return
 				}
-				tmr := newMilliTimer(gossipBlockTime)
-				bl.handleBlock(data)
-				tmr.ObserveDuration()
-			}()
+				bl.wg.Add(1)
+				go func() {
+					defer func() { <-bl.semaphore }()
+					defer bl.wg.Done()
+					tmr := newMilliTimer(gossipBlockTime)
+					bl.handleBlock(msg, blk)
+					tmr.ObserveDuration()
+				}()
+			}
+		}
+	}
+}
 
+// collectGossipBlocks returns the given message along with the block messages already waiting in the queue, up to
+// gossipBlockBatchSize messages. It never waits for more messages, so under a light load a batch holds a single message.
+func (bl *BlockListener) collectGossipBlocks(first service.GossipMessage) []service.GossipMessage {
+	msgs := []service.GossipMessage{first}
+	for len(msgs) < gossipBlockBatchSize {
+		select {
+		case data := <-bl.receivedGossipBlocks:
+			msgs = append(msgs, data)
+		default:
+			return msgs
 		}
 	}
+	return msgs
 }
 
-func (bl *BlockListener) handleBlock(data service.GossipMessage) {
-	var blk types.Block
-	err := types.BytesToInterface(data.Bytes(), &blk)
-	if err != nil {
-		bl.Error("received invalid block %v", data.Bytes(), err)
-		return
+// decodeGossipBlocks decodes the blocks in the messages and initializes them, extracting the miner IDs from the
+// signatures in parallel. If any of them fails the blocks are initialized one by one, so that a single bad signature
+// only drops its own block. It returns the messages that hold valid blocks and their blocks.
+func (bl *BlockListener) decodeGossipBlocks(msgs []service.GossipMessage) ([]service.GossipMessage, []*types.Block) {
+	valid := make([]service.GossipMessage, 0, len(msgs))
+	blocks := make([]*types.Block, 0, len(msgs))
+	for _, data := range msgs {
+		if data == nil {
+			bl.Error("got empty message while listening to gossip blocks")
+			continue
+		}
+		var blk types.Block
+		if err := types.BytesToInterface(data.Bytes(), &blk); err != nil {
+			bl.Error("received invalid block %v", data.Bytes(), err)
+			continue
+		}
+		valid = append(valid, data)
+		blocks = append(blocks, &blk)
+	}
+	if err := types.InitializeBlocks(blocks); err == nil {
+		return valid, blocks
 	}
 
-	// set the block id when received
-	blk.Initialize()
+	initialized := 0
+	for i, blk := range blocks {
+		if err := types.InitializeBlocks([]*types.Block{blk}); err != nil {
+			bl.With().Error("received block with invalid signature", log.Err(err))
+			continue
+		}
+		valid[initialized], blocks[initialized] = valid[i], blk
+		initialized++
+	}
+	return valid[:initialized], blocks[:initialized]
+}
 
+func (bl *BlockListener) handleBlock(data service.GossipMessage, blk *types.Block) {
 	activeSet := 0
 	if blk.ActiveSet != nil {
 		activeSet = len(*blk.ActiveSet)
@@ -120,19 +170,19 @@ func (bl *BlockListener) handleBlock(data service.GossipMessage) {
 		bl.With().Info("we already know this block", blk.ID())
 		return
 	}
-	txs, atxs, err := bl.blockSyntacticValidation(&blk)
+	txs, atxs, err := bl.blockSyntacticValidation(blk)
 	if err != nil {
 		bl.With().Error("failed to validate block", blk.ID(), log.Err(err))
 		return
 	}
 	data.ReportValidation(config.NewBlockProtocol)
-	if err := bl.AddBlockWithTxs(&blk, txs, atxs); err != nil {
+	if err := bl.AddBlockWithTxs(blk, txs, atxs); err != nil {
 		bl.With().Error("failed to add block to database", blk.ID(), log.Err(err))
 		return
 	}
 
 	if blk.Layer() <= bl.ProcessedLayer() || blk.Layer() == bl.getValidatingLayer() {
-		bl.Syncer.HandleLateBlock(&blk)
+		bl.Syncer.HandleLateBlock(blk)
 	}
 	return
 }
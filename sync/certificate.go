@@ -0,0 +1,111 @@
+package sync
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/common/util"
+	"github.com/spacemeshos/go-spacemesh/database"
+	"github.com/spacemeshos/go-spacemesh/log"
+	p2ppeers "github.com/spacemeshos/go-spacemesh/p2p/peers"
+	"github.com/spacemeshos/go-spacemesh/p2p/service"
+)
+
+// Peers serve the certificates of the hare outputs of the layers they know, so a node that syncs a layer verifies its
+// hare output without having run the protocol. The syncer doesn't interpret certificates: it asks all of its peers for
+// the certificate of a layer it has none of, and passes them to the certificate handler until one of them is valid.
+// Certificates are fetched in the background once their layers are validated, one layer at a time, so they don't slow
+// down the sync.
+
+// certificateQueueSize is the max number of validated layers waiting for their certificates. The certificates of the
+// layers validated while the queue is full aren't fetched.
+const certificateQueueSize = 1000
+
+var errNoCertificate = errors.New("no peer serves a valid certificate")
+
+// certificateHandler validates and stores the encoded certificate of the hare output of a layer
+type certificateHandler interface {
+	HandleCertificate(layer types.LayerID, cert []byte) error
+}
+
+// SetCertificateHandler sets the handler of the certificates fetched for the synced layers. Certificates aren't
+// fetched until it's set. It must be called before Start.
+func (s *Syncer) SetCertificateHandler(handler certificateHandler) {
+	s.certificates = handler
+}
+
+func newCertificateRequestHandler(s *Syncer, logger log.Log) func(msg []byte) []byte {
+	return func(msg []byte) []byte {
+		layer := types.LayerID(util.BytesToUint64(msg))
+		logger.With().Debug("handle certificate request", layer)
+		cert, err := s.GetCertificate(layer)
+		if err != nil {
+			if err != database.ErrNotFound && err != database.ErrPruned {
+				logger.With().Error("cannot read certificate", layer, log.Err(err))
+			}
+			return nil
+		}
+		return cert
+	}
+}
+
+// queueCertificate queues the validated layer for the fetch of its certificate
+func (s *Syncer) queueCertificate(layer types.LayerID) {
+	if s.certificates == nil {
+		return
+	}
+	select {
+	case s.certLayers <- layer:
+	default:
+		s.With().Warning("certificate queue is full, not fetching certificate", layer)
+	}
+}
+
+// certificateLoop fetches the certificates of the queued layers until the syncer is closed
+func (s *Syncer) certificateLoop() {
+	for {
+		select {
+		case <-s.exit:
+			return
+		case layer := <-s.certLayers:
+			if err := s.fetchCertificate(layer); err != nil {
+				s.With().Info("could not get certificate from neighbors", layer, log.Err(err))
+			}
+		}
+	}
+}
+
+type peerCertificatePair struct {
+	peer p2ppeers.Peer
+	cert []byte
+}
+
+// fetchCertificate fetches the certificate of the hare output of the layer if the node has none, and the layer is
+// within the retention horizon
+func (s *Syncer) fetchCertificate(layer types.LayerID) error {
+	if s.certificates == nil {
+		return nil
+	}
+	if _, err := s.GetCertificate(layer); err == nil || err == database.ErrPruned {
+		return nil
+	}
+	wrk := newPeersWorker(s, s.GetPeers(), &sync.Once{}, certificateReqFactory(layer))
+	go wrk.Work()
+	var pairs []*peerCertificatePair
+	for out := range wrk.output {
+		if pair, ok := out.(*peerCertificatePair); ok && pair != nil {
+			pairs = append(pairs, pair)
+		}
+	}
+	for _, pair := range pairs {
+		if err := s.certificates.HandleCertificate(layer, pair.cert); err != nil {
+			s.With().Warning("peer served an invalid certificate", layer, log.String("peer", pair.peer.String()), log.Err(err))
+			s.ReportPeer(pair.peer, service.InvalidMessage)
+			continue
+		}
+		s.With().Info("fetched certificate", layer, log.String("peer", pair.peer.String()))
+		return nil
+	}
+	return errNoCertificate
+}
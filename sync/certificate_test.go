@@ -0,0 +1,42 @@
+package sync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/stretchr/testify/require"
+)
+
+type certificateHandlerMock struct {
+	s *Syncer
+}
+
+func (m certificateHandlerMock) HandleCertificate(layer types.LayerID, cert []byte) error {
+	return m.s.SaveCertificate(layer, cert)
+}
+
+func TestSyncer_FetchCertificates(t *testing.T) {
+	r := require.New(t)
+	syncs, _ := SyncMockFactoryManClock(2, conf, t.Name(), memoryDB, newMemPoetDb, &mockClock{Layer: 10})
+	server, client := syncs[0], syncs[1]
+	defer server.Close()
+	defer client.Close()
+	layer := types.GetEffectiveGenesis() + 1
+	r.NoError(server.SaveCertificate(layer, []byte("certificate")))
+	r.Eventually(func() bool { return len(client.GetPeers()) == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	// layers are queued without blocking the sync, the certificates of the layers queued once it's full are dropped
+	client.SetCertificateHandler(certificateHandlerMock{s: client})
+	for i := 0; i <= certificateQueueSize; i++ {
+		client.queueCertificate(layer)
+	}
+	r.Len(client.certLayers, certificateQueueSize)
+
+	// and fetched in the background
+	go client.certificateLoop()
+	r.Eventually(func() bool {
+		cert, err := client.GetCertificate(layer)
+		return err == nil && string(cert) == "certificate"
+	}, 5*time.Second, 10*time.Millisecond)
+}
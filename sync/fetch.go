@@ -0,0 +1,143 @@
+package sync
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	p2ppeers "github.com/spacemeshos/go-spacemesh/p2p/peers"
+)
+
+// ObjectKind is the kind of a mesh object that can be fetched on demand
+type ObjectKind int
+
+const (
+	// BlockObject is a block, which carries the votes of its miner
+	BlockObject ObjectKind = iota
+	// TransactionObject is a transaction
+	TransactionObject
+	// AtxObject is an activation transaction
+	AtxObject
+)
+
+func (k ObjectKind) String() string {
+	switch k {
+	case BlockObject:
+		return "block"
+	case TransactionObject:
+		return "transaction"
+	case AtxObject:
+		return "atx"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(k))
+	}
+}
+
+// FetchResult describes where a mesh object that was fetched on demand was found
+type FetchResult struct {
+	Local    bool            // the node already had the object, peers weren't asked
+	Peers    []p2ppeers.Peer // peers that served the object
+	Stored   bool            // the object was validated and stored
+	StoreErr error           // why an object that peers served wasn't stored
+	Layer    types.LayerID   // the layer of a block or the publication layer of an ATX
+}
+
+// Fetch requests a mesh object from all peers by its ID, to unblock a layer that waits for it. The object is validated
+// and stored like the objects sync fetches. A block ID is given as its 32 bytes hash.
+func (s *Syncer) Fetch(kind ObjectKind, id types.Hash32) (*FetchResult, error) {
+	var fetch batchRequestFactory
+	switch kind {
+	case BlockObject:
+		fetch = blockFetchReqFactory
+	case TransactionObject:
+		fetch = txFetchReqFactory
+	case AtxObject:
+		fetch = atxFetchReqFactory
+	default:
+		return nil, fmt.Errorf("can't fetch objects of kind %v", kind)
+	}
+
+	if layer, ok := s.hasObject(kind, id); ok {
+		return &FetchResult{Local: true, Layer: layer}, nil
+	}
+	peers := s.GetPeers()
+	if len(peers) == 0 {
+		return nil, fmt.Errorf("no peers")
+	}
+
+	res := &FetchResult{}
+	wrk := newPeersWorker(s, peers, &sync.Once{}, objectReqFactory(fetch, id))
+	go wrk.Work()
+	for out := range wrk.output {
+		if peer, ok := out.(p2ppeers.Peer); ok && peer != nil {
+			res.Peers = append(res.Peers, peer)
+		}
+	}
+	s.Info("on demand fetch of %v %v found it at %d of %d peers", kind, id.ShortString(), len(res.Peers), len(peers))
+	if len(res.Peers) == 0 {
+		return res, nil
+	}
+
+	switch kind {
+	case BlockObject:
+		if !s.fetchBlock(types.BlockID(id.ToHash20())) {
+			res.StoreErr = fmt.Errorf("block isn't valid or its data isn't available")
+		}
+	case TransactionObject:
+		_, res.StoreErr = s.txQueue.HandleTxs([]types.TransactionID{types.TransactionID(id)})
+	case AtxObject:
+		_, res.StoreErr = s.fetchAtx(types.ATXID(id))
+	}
+	if res.StoreErr != nil {
+		s.Warning("on demand fetch of %v %v wasn't stored: %v", kind, id.ShortString(), res.StoreErr)
+		return res, nil
+	}
+	res.Layer, res.Stored = s.hasObject(kind, id)
+	return res, nil
+}
+
+// hasObject returns true and the layer of the object if the node has it
+func (s *Syncer) hasObject(kind ObjectKind, id types.Hash32) (types.LayerID, bool) {
+	switch kind {
+	case BlockObject:
+		if blk, err := s.GetBlock(types.BlockID(id.ToHash20())); err == nil {
+			return blk.Layer(), true
+		}
+	case TransactionObject:
+		if _, err := s.txpool.Get(types.TransactionID(id)); err == nil {
+			return 0, true
+		}
+		if _, missing := s.GetTransactions([]types.TransactionID{types.TransactionID(id)}); len(missing) == 0 {
+			return 0, true
+		}
+	case AtxObject:
+		if atx, err := s.atxDb.GetFullAtx(types.ATXID(id)); err == nil {
+			return atx.PubLayerID, true
+		}
+	}
+	return 0, false
+}
+
+// objectReqFactory requests a single object from a peer, the peer is sent on the channel if it served the object
+func objectReqFactory(fetch batchRequestFactory, id types.Hash32) requestFactory {
+	return func(s networker, peer p2ppeers.Peer) (chan interface{}, error) {
+		items, err := fetch(s, peer, []types.Hash32{id})
+		if err != nil {
+			return nil, err
+		}
+		ch := make(chan interface{}, 1)
+		go func() {
+			defer close(ch)
+			select {
+			case res := <-items:
+				if len(res) > 0 {
+					ch <- peer
+				}
+			case <-time.After(s.GetTimeout()):
+			case <-s.GetExit():
+			}
+		}()
+		return ch, nil
+	}
+}
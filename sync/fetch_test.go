@@ -0,0 +1,59 @@
+package sync
+
+import (
+	"testing"
+
+	"github.com/spacemeshos/go-spacemesh/activation"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	p2ppeers "github.com/spacemeshos/go-spacemesh/p2p/peers"
+	"github.com/spacemeshos/go-spacemesh/rand"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncer_Fetch(t *testing.T) {
+	r := require.New(t)
+	syncs, nodes, _ := SyncMockFactory(2, conf, t.Name(), memoryDB, newMemPoetDb)
+	client, server := syncs[0], syncs[1]
+	defer client.Close()
+	defer server.Close()
+	client.peers = getPeersMock([]p2ppeers.Peer{nodes[1].PublicKey()})
+	atxPool := activation.NewAtxMemPool()
+	client.atxDb = atxPool
+	atx := atx("")
+	atxPool.Put(atx)
+
+	block := types.NewExistingBlock(types.GetEffectiveGenesis()+1, []byte(rand.String(8)))
+	block.ActiveSet = &[]types.ATXID{atx.ID()}
+	block.ATXID = atx.ID()
+	block.Initialize()
+
+	// the object isn't found if no peer has it
+	res, err := client.Fetch(BlockObject, block.ID().AsHash32())
+	r.NoError(err)
+	r.Equal(&FetchResult{}, res)
+
+	r.NoError(server.AddBlockWithTxs(block, []*types.Transaction{tx1}, nil))
+	res, err = client.Fetch(BlockObject, block.ID().AsHash32())
+	r.NoError(err)
+	r.Equal(&FetchResult{Peers: []p2ppeers.Peer{nodes[1].PublicKey()}, Stored: true, Layer: block.Layer()}, res)
+	_, err = client.GetBlock(block.ID())
+	r.NoError(err)
+
+	res, err = client.Fetch(TransactionObject, tx1.ID().Hash32())
+	r.NoError(err)
+	r.Equal(&FetchResult{Peers: []p2ppeers.Peer{nodes[1].PublicKey()}, Stored: true}, res)
+
+	// objects the node has aren't requested from peers
+	res, err = client.Fetch(BlockObject, block.ID().AsHash32())
+	r.NoError(err)
+	r.Equal(&FetchResult{Local: true, Layer: block.Layer()}, res)
+	res, err = client.Fetch(AtxObject, atx.ID().Hash32())
+	r.NoError(err)
+	r.Equal(&FetchResult{Local: true, Layer: atx.PubLayerID}, res)
+
+	client.peers = getPeersMock([]p2ppeers.Peer{})
+	_, err = client.Fetch(TransactionObject, tx2.ID().Hash32())
+	r.Error(err)
+	_, err = client.Fetch(ObjectKind(10), tx2.ID().Hash32())
+	r.Error(err)
+}
@@ -0,0 +1,81 @@
+package sync
+
+import (
+	"sort"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/mesh"
+)
+
+// The syncer persists its frontier, the last validated layer and the layers it is fetching, as it syncs. Layers fetched
+// ahead of validation may be stored partially when the node restarts, and since storing their blocks moves the latest
+// layer, the node would take itself for weakly synced and validate them from its database. A node that restarts with
+// layers in flight syncs them from its peers again instead.
+
+// loadFrontier loads the layers that were in flight when the node stopped
+func (s *Syncer) loadFrontier() {
+	frontier := s.SyncFrontier()
+	for _, layer := range frontier.InFlight {
+		if layer > s.ProcessedLayer() {
+			s.inFlight[layer] = struct{}{}
+			s.resumed[layer] = struct{}{}
+		}
+	}
+	if len(s.resumed) > 0 {
+		s.With().Info("resuming sync with layers in flight",
+			log.FieldNamed("validated_layer", frontier.Validated),
+			log.Int("in_flight_layers", len(s.resumed)))
+	}
+}
+
+// fetchingLayer records that the data of a layer is being fetched
+func (s *Syncer) fetchingLayer(layer types.LayerID) {
+	s.frontierMutex.Lock()
+	defer s.frontierMutex.Unlock()
+	s.inFlight[layer] = struct{}{}
+	s.persistFrontier()
+}
+
+// fetchedLayer records that a layer was fetched and validated
+func (s *Syncer) fetchedLayer(layer types.LayerID) {
+	s.frontierMutex.Lock()
+	defer s.frontierMutex.Unlock()
+	delete(s.inFlight, layer)
+	delete(s.resumed, layer)
+	s.persistFrontier()
+}
+
+// resuming returns true if layers that were in flight when the node stopped, or layers whose blocks are stored without
+// their transactions, weren't synced yet
+func (s *Syncer) resuming() bool {
+	s.frontierMutex.Lock()
+	defer s.frontierMutex.Unlock()
+	for layer := range s.resumed {
+		if layer > s.ProcessedLayer() {
+			return true
+		}
+	}
+	for layer := range s.deferred {
+		if layer > s.ProcessedLayer() {
+			return true
+		}
+	}
+	return false
+}
+
+// persistFrontier must be called with frontierMutex held
+func (s *Syncer) persistFrontier() {
+	frontier := mesh.SyncFrontier{Validated: s.ProcessedLayer()}
+	for layer := range s.inFlight {
+		if layer <= frontier.Validated {
+			delete(s.inFlight, layer)
+			continue
+		}
+		frontier.InFlight = append(frontier.InFlight, layer)
+	}
+	sort.Slice(frontier.InFlight, func(i, j int) bool { return frontier.InFlight[i] < frontier.InFlight[j] })
+	if err := s.SetSyncFrontier(frontier); err != nil {
+		s.With().Error("could not persist sync frontier", frontier.Validated, log.Err(err))
+	}
+}
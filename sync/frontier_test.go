@@ -0,0 +1,38 @@
+package sync
+
+import (
+	"testing"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/mesh"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncer_Frontier(t *testing.T) {
+	r := require.New(t)
+	syncs, _, _ := SyncMockFactory(1, conf, t.Name(), memoryDB, newMockPoetDb)
+	sync := syncs[0]
+	defer sync.Close()
+	gen := types.GetEffectiveGenesis()
+	r.False(sync.resuming())
+
+	sync.fetchingLayer(gen + 2)
+	sync.fetchingLayer(gen + 1)
+	r.Equal(mesh.SyncFrontier{Validated: sync.ProcessedLayer(), InFlight: []types.LayerID{gen + 1, gen + 2}}, sync.SyncFrontier())
+	// layers that fail to be fetched don't stop a running node from validating the layers it has
+	r.False(sync.resuming())
+	sync.fetchedLayer(gen + 1)
+	r.Equal([]types.LayerID{gen + 2}, sync.SyncFrontier().InFlight)
+
+	// a restarted node loads the layers in flight
+	sync.inFlight = make(map[types.LayerID]struct{})
+	sync.loadFrontier()
+	r.True(sync.resuming())
+
+	// and syncs them from peers even though it seems weakly synced
+	sync.ticker = &mockClock{Layer: gen + 1}
+	r.True(sync.weaklySynced(sync.GetCurrentLayer()))
+	sync.synchronise()
+	r.Equal(PhaseHeaders, sync.SyncProgress().Phase)
+	r.False(sync.ListenToGossip())
+}
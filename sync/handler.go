@@ -18,6 +18,10 @@ func newLayerHashRequestHandler(layers *mesh.Mesh, logger log.Log) func(msg []by
 				logger.With().Warning("hashes requested for unfamiliar layer ", lyrid)
 				return nil
 			}
+			if err == database.ErrPruned {
+				logger.With().Info("hashes requested for pruned layer", lyrid)
+				return nil
+			}
 			logger.With().Error("Error handling layer request message", lyrid, log.Err(err))
 			return nil
 		}
@@ -105,6 +109,10 @@ func newBlockRequestHandler(msh *mesh.Mesh, logger log.Log) func(msg []byte) []b
 					logger.With().Warning("unfamiliar block was requested (id: %s)", blockID, log.Err(err))
 					continue
 				}
+				if err == database.ErrPruned {
+					logger.With().Info("pruned block was requested", blockID)
+					continue
+				}
 				logger.With().Error("Error handling block request message", blockID, log.Err(err))
 				continue
 			}
@@ -0,0 +1,110 @@
+package sync
+
+import (
+	"fmt"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/database"
+	"github.com/spacemeshos/go-spacemesh/log"
+)
+
+// A node that is out of sync with HeadersFirst set syncs in two phases. First it fetches and validates the blocks of all
+// the layers it's missing, with the ATXs and the views they reference, but without their transactions. The blocks are
+// stored as they're validated, so the structure of the mesh is known early and a failed fetch doesn't lose the layers
+// that were already fetched. Then it fetches the transactions of the blocks, the bodies, of several layers concurrently,
+// and validates the layers in order as their bodies arrive.
+
+// syncHeaders fetches and validates the blocks of the layers from..to-1, deferring their transactions
+func (s *Syncer) syncHeaders(from, to types.LayerID) error {
+	s.progress.syncHeaders(s.ProcessedLayer())
+	fetcher := newLayerFetcher(from, s.FetchLayers, s.getLayerHeaders, func() types.LayerID { return to })
+	fetcher.started = func(layer types.LayerID) {
+		s.deferBodies(layer)
+		s.fetchingLayer(layer)
+	}
+	for layer := from; layer < to; layer++ {
+		if s.shutdown() {
+			return fmt.Errorf("interupt")
+		}
+		lyr, err := fetcher.get(layer)
+		if err != nil {
+			return fmt.Errorf("could not get blocks of layer %v: %v", layer, err)
+		}
+		if len(lyr.Blocks()) == 0 {
+			if err := s.SetZeroBlockLayer(layer); err != nil {
+				return err
+			}
+		}
+		s.With().Info("fetched blocks of layer", layer, log.Int("block_count", len(lyr.Blocks())))
+	}
+	return nil
+}
+
+// getLayerHeaders fetches the blocks of a layer, whose bodies are deferred
+func (s *Syncer) getLayerHeaders(layer types.LayerID) (*types.Layer, error) {
+	lyr, err := s.getLayerFromNeighbors(layer)
+	if err != nil {
+		// unless some blocks were stored before the fetch failed, the bodies of the layer are fetched with its blocks
+		// when it's synced again
+		if _, err := s.LayerBlockIds(layer); err == database.ErrNotFound {
+			s.frontierMutex.Lock()
+			delete(s.deferred, layer)
+			s.frontierMutex.Unlock()
+		}
+		return nil, err
+	}
+	return lyr, nil
+}
+
+// getLayerBodies fetches the transactions of the blocks of a layer whose blocks were fetched by syncHeaders
+func (s *Syncer) getLayerBodies(layer types.LayerID) (*types.Layer, error) {
+	// blocks of the layer that are fetched from now on come with their transactions
+	s.frontierMutex.Lock()
+	delete(s.deferred, layer)
+	s.frontierMutex.Unlock()
+
+	lyr, err := s.fetchLayerBodies(layer)
+	if err != nil {
+		s.deferBodies(layer)
+		return nil, err
+	}
+	return lyr, nil
+}
+
+func (s *Syncer) fetchLayerBodies(layer types.LayerID) (*types.Layer, error) {
+	blocks, err := s.LayerBlocks(layer)
+	if err == database.ErrNotFound {
+		return types.NewLayer(layer), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, blk := range blocks {
+		if len(blk.TxIDs) == 0 {
+			continue
+		}
+		txs, err := s.txQueue.HandleTxs(blk.TxIDs)
+		if err != nil {
+			return nil, fmt.Errorf("failed fetching block %v transactions %v", blk.ID(), err)
+		}
+		if err := s.AddBlockBody(blk, txs); err != nil {
+			return nil, err
+		}
+	}
+	return types.NewExistingLayer(layer, blocks), nil
+}
+
+// deferBodies records that blocks of the layer are stored without their transactions
+func (s *Syncer) deferBodies(layer types.LayerID) {
+	s.frontierMutex.Lock()
+	defer s.frontierMutex.Unlock()
+	s.deferred[layer] = struct{}{}
+}
+
+// bodyDeferred returns true if blocks of the layer are stored without their transactions
+func (s *Syncer) bodyDeferred(layer types.LayerID) bool {
+	s.frontierMutex.Lock()
+	defer s.frontierMutex.Unlock()
+	_, ok := s.deferred[layer]
+	return ok
+}
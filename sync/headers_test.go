@@ -0,0 +1,76 @@
+package sync
+
+import (
+	"testing"
+
+	"github.com/spacemeshos/go-spacemesh/activation"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	p2ppeers "github.com/spacemeshos/go-spacemesh/p2p/peers"
+	"github.com/spacemeshos/go-spacemesh/signing"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncer_HeadersFirst(t *testing.T) {
+	r := require.New(t)
+	signer := signing.NewEdSigner()
+	atx := atx(signer.PublicKey().String())
+	atx.PubLayerID = 1
+	atx.CalcAndSetID()
+	r.NoError(activation.SignAtx(signer, atx))
+	atxDb := activation.NewAtxMemPool()
+	atxDb.Put(atx)
+
+	gen := types.GetEffectiveGenesis()
+	syncs, nodes := SyncMockFactoryManClock(2, conf, t.Name(), memoryDB, newMockPoetDb, &mockClock{Layer: gen + 3})
+	client, server := syncs[0], syncs[1]
+	defer client.Close()
+	defer server.Close()
+	client.peers = getPeersMock([]p2ppeers.Peer{nodes[1].PublicKey()})
+	client.atxDb = atxDb
+
+	block1 := createBlock(*atx, signer)
+	block1.LayerIndex = gen + 1
+	block1.TxIDs = []types.TransactionID{tx1.ID(), tx2.ID()}
+	block1.Signature = signer.Sign(block1.Bytes())
+	block1.Initialize()
+	block2 := createBlock(*atx, signer)
+	block2.LayerIndex = gen + 2
+	block2.TxIDs = []types.TransactionID{tx3.ID()}
+	block2.Signature = signer.Sign(block2.Bytes())
+	block2.Initialize()
+	r.NoError(server.AddBlockWithTxs(block1, []*types.Transaction{tx1, tx2}, nil))
+	r.NoError(server.AddBlockWithTxs(block2, []*types.Transaction{tx3}, nil))
+
+	// the blocks of all layers are fetched first, without their transactions
+	r.NoError(client.syncHeaders(gen+1, gen+3))
+	for _, block := range []*types.Block{block1, block2} {
+		_, err := client.GetBlock(block.ID())
+		r.NoError(err)
+		_, missing := client.GetTransactions(block.TxIDs)
+		r.Len(missing, len(block.TxIDs))
+		r.True(client.bodyDeferred(block.Layer()))
+	}
+	r.Equal(PhaseHeaders, client.SyncProgress().Phase)
+	// a node that has blocks without transactions doesn't validate them from its database
+	r.True(client.resuming())
+
+	// then the bodies
+	lyr, err := client.getLayerBodies(gen + 1)
+	r.NoError(err)
+	r.Len(lyr.Blocks(), 1)
+	_, missing := client.GetTransactions(block1.TxIDs)
+	r.Empty(missing)
+	r.False(client.bodyDeferred(gen + 1))
+	r.True(client.bodyDeferred(gen + 2))
+
+	// a failed fetch of bodies is retried when the node syncs again
+	client.peers = getPeersMock([]p2ppeers.Peer{})
+	_, err = client.getLayerBodies(gen + 2)
+	r.Error(err)
+	r.True(client.bodyDeferred(gen + 2))
+	client.peers = getPeersMock([]p2ppeers.Peer{nodes[1].PublicKey()})
+	lyr, err = client.getLayerBodies(gen + 2)
+	r.NoError(err)
+	r.Len(lyr.Blocks(), 1)
+	r.False(client.bodyDeferred(gen + 2))
+}
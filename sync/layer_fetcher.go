@@ -0,0 +1,67 @@
+package sync
+
+import (
+	"github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+type layerFetch struct {
+	done  chan struct{}
+	layer *types.Layer
+	err   error
+}
+
+// layerFetcher fetches the layers a node that is out of sync is missing. Layers are validated one by one, in order,
+// but fetching a layer mostly waits for peers, so the fetcher fetches the next layers concurrently while the node
+// validates the current one. It's used by a single goroutine.
+type layerFetcher struct {
+	fetch   func(types.LayerID) (*types.Layer, error)
+	current func() types.LayerID // layers before the current layer can be fetched
+	ahead   int                  // number of layers fetched concurrently
+	started func(types.LayerID)  // called when a layer starts being fetched, if set
+
+	next    types.LayerID // next layer to start fetching
+	fetches map[types.LayerID]*layerFetch
+}
+
+func newLayerFetcher(from types.LayerID, ahead int, fetch func(types.LayerID) (*types.Layer, error), current func() types.LayerID) *layerFetcher {
+	if ahead < 1 {
+		ahead = 1
+	}
+	return &layerFetcher{
+		fetch:   fetch,
+		current: current,
+		ahead:   ahead,
+		next:    from,
+		fetches: make(map[types.LayerID]*layerFetch),
+	}
+}
+
+func (f *layerFetcher) start(layer types.LayerID) {
+	lf := &layerFetch{done: make(chan struct{})}
+	f.fetches[layer] = lf
+	if f.started != nil {
+		f.started(layer)
+	}
+	go func() {
+		defer close(lf.done)
+		lf.layer, lf.err = f.fetch(layer)
+	}()
+}
+
+// get returns the layer once it's fetched, and starts fetching the layers after it
+func (f *layerFetcher) get(layer types.LayerID) (*types.Layer, error) {
+	if f.next < layer {
+		f.next = layer
+	}
+	for ; f.next < layer+types.LayerID(f.ahead) && f.next < f.current(); f.next++ {
+		f.start(f.next)
+	}
+	lf, ok := f.fetches[layer]
+	if !ok {
+		f.start(layer)
+		lf = f.fetches[layer]
+	}
+	<-lf.done
+	delete(f.fetches, layer)
+	return lf.layer, lf.err
+}
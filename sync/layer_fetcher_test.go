@@ -0,0 +1,75 @@
+package sync
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLayerFetcher(t *testing.T) {
+	r := require.New(t)
+	var mu sync.Mutex
+	fetching, maxFetching := 0, 0
+	fetched := make(map[types.LayerID]int)
+	fetch := func(layer types.LayerID) (*types.Layer, error) {
+		mu.Lock()
+		fetching++
+		if fetching > maxFetching {
+			maxFetching = fetching
+		}
+		fetched[layer]++
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		fetching--
+		mu.Unlock()
+		if layer == 8 {
+			return nil, errors.New("no peer has the layer")
+		}
+		return types.NewLayer(layer), nil
+	}
+	current := func() types.LayerID { return 10 }
+
+	f := newLayerFetcher(2, 3, fetch, current)
+	for layer := types.LayerID(2); layer < 8; layer++ {
+		lyr, err := f.get(layer)
+		r.NoError(err)
+		r.Equal(layer, lyr.Index())
+	}
+	_, err := f.get(8)
+	r.Error(err)
+
+	// wait for the layers fetched ahead of the failed one
+	r.Eventually(func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(fetched) == 8 && fetching == 0
+	}, time.Second, 10*time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	r.Equal(3, maxFetching)
+	// the layers are fetched once, and no layer at or after the current one is fetched
+	for layer := types.LayerID(2); layer < 10; layer++ {
+		r.Equal(1, fetched[layer], "layer %v", layer)
+	}
+}
+
+func TestLayerFetcher_Sequential(t *testing.T) {
+	r := require.New(t)
+	var fetched []types.LayerID
+	fetch := func(layer types.LayerID) (*types.Layer, error) {
+		fetched = append(fetched, layer)
+		return types.NewLayer(layer), nil
+	}
+	f := newLayerFetcher(1, 0, fetch, func() types.LayerID { return 100 })
+	for layer := types.LayerID(1); layer < 4; layer++ {
+		_, err := f.get(layer)
+		r.NoError(err)
+		r.Equal(layer, fetched[len(fetched)-1])
+	}
+	r.Len(fetched, 3)
+}
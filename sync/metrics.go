@@ -16,6 +16,10 @@ func newCounter(name, help string, labels []string) metrics.Counter {
 	return prmkit.NewCounterFrom(prometheus.CounterOpts{Namespace: namespace, Subsystem: subsystem, Name: name, Help: help}, labels)
 }
 
+func newGauge(name, help string, labels []string) metrics.Gauge {
+	return prmkit.NewGaugeFrom(prometheus.GaugeOpts{Namespace: namespace, Subsystem: subsystem, Name: name, Help: help}, labels)
+}
+
 func newMilliTimer(sum prometheus.Summary) *prometheus.Timer {
 	return prometheus.NewTimer(prometheus.ObserverFunc(func(v float64) {
 		us := v * 1000 // make microseconds
@@ -37,6 +41,12 @@ func newFetchRequestTimer(msgtype server.MessageType) *prometheus.Timer {
 	return nil
 }
 
+var (
+	auditMissing   = newCounter("audit_missing", "number of objects referenced by the audited layers that were missing", []string{"kind"})
+	auditRefetched = newCounter("audit_refetched", "number of missing objects fetched again by audits", []string{"kind"})
+	auditDuration  = newGauge("audit_duration", "duration of the latest audit in seconds", nil)
+)
+
 var (
 	gossipBlockTime = prometheus.NewSummary(prometheus.SummaryOpts{Name: "gossip_block_request_durations",
 		Help:       "gossip block handle duration in milliseconds",
@@ -75,6 +75,10 @@ func (s mockState) LoadState(types.LayerID) error {
 	panic("implement me")
 }
 
+func (s mockState) PruneLayerStateRoot(types.LayerID) error {
+	return nil
+}
+
 func (s mockState) GetStateRoot() types.Hash32 {
 	return [32]byte{}
 }
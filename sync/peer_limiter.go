@@ -0,0 +1,57 @@
+package sync
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/p2p/p2pcrypto"
+)
+
+var errLimiterClosed = errors.New("closed while waiting for a request slot")
+
+// peerLimiter caps the number of sync requests in flight to each peer, so concurrent fetches are spread over peers
+// instead of piling up on the first ones. Peers don't answer requests they can't serve, so a slot is released when the
+// response arrives or when the request times out, whichever comes first.
+type peerLimiter struct {
+	limit   int
+	timeout time.Duration
+
+	mu    sync.Mutex
+	slots map[string]chan struct{}
+}
+
+func newPeerLimiter(limit int, timeout time.Duration) *peerLimiter {
+	return &peerLimiter{limit: limit, timeout: timeout, slots: make(map[string]chan struct{})}
+}
+
+// acquire blocks until a request can be sent to the peer, and returns the func that releases its slot. The release
+// func may be called more than once.
+func (l *peerLimiter) acquire(peer p2pcrypto.PublicKey, exit chan struct{}) (func(), error) {
+	l.mu.Lock()
+	slots, ok := l.slots[peer.String()]
+	if !ok {
+		slots = make(chan struct{}, l.limit)
+		l.slots[peer.String()] = slots
+	}
+	l.mu.Unlock()
+
+	select {
+	case slots <- struct{}{}:
+	case <-exit:
+		return nil, errLimiterClosed
+	}
+	once := sync.Once{}
+	release := func() {
+		once.Do(func() { <-slots })
+	}
+	time.AfterFunc(l.timeout, release)
+	return release, nil
+}
+
+// inFlight returns the number of requests in flight to the peer
+func (l *peerLimiter) inFlight(peer p2pcrypto.PublicKey) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.slots[peer.String()])
+}
@@ -0,0 +1,65 @@
+package sync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/p2p/p2pcrypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeerLimiter(t *testing.T) {
+	r := require.New(t)
+	l := newPeerLimiter(2, time.Hour)
+	exit := make(chan struct{})
+	peer1, peer2 := p2pcrypto.NewRandomPubkey(), p2pcrypto.NewRandomPubkey()
+
+	release1, err := l.acquire(peer1, exit)
+	r.NoError(err)
+	_, err = l.acquire(peer1, exit)
+	r.NoError(err)
+	r.Equal(2, l.inFlight(peer1))
+
+	// other peers have slots of their own
+	_, err = l.acquire(peer2, exit)
+	r.NoError(err)
+	r.Equal(1, l.inFlight(peer2))
+
+	acquired := make(chan struct{})
+	go func() {
+		_, err := l.acquire(peer1, exit)
+		r.NoError(err)
+		close(acquired)
+	}()
+	select {
+	case <-acquired:
+		r.FailNow("acquired a slot beyond the limit")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// releasing twice frees a single slot
+	release1()
+	release1()
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		r.FailNow("slot wasn't released")
+	}
+	r.Equal(2, l.inFlight(peer1))
+
+	close(exit)
+	_, err = l.acquire(peer1, exit)
+	r.Equal(errLimiterClosed, err)
+}
+
+func TestPeerLimiter_Timeout(t *testing.T) {
+	r := require.New(t)
+	l := newPeerLimiter(1, 50*time.Millisecond)
+	peer := p2pcrypto.NewRandomPubkey()
+	_, err := l.acquire(peer, make(chan struct{}))
+	r.NoError(err)
+
+	// peers don't answer requests they can't serve, the slot is released when the request times out
+	_, err = l.acquire(peer, make(chan struct{}))
+	r.NoError(err)
+}
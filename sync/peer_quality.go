@@ -0,0 +1,121 @@
+package sync
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/p2p/p2pcrypto"
+	p2ppeers "github.com/spacemeshos/go-spacemesh/p2p/peers"
+	"github.com/spacemeshos/go-spacemesh/rand"
+)
+
+const (
+	// qualityWeight is the weight of a new sample in the moving averages of the quality of a peer
+	qualityWeight = 0.2
+	// peerProbeRate is how often a peer other than the best one is asked first, so peers that performed poorly get
+	// a chance to show they got better, and new measurements keep coming for all of them
+	peerProbeRate = 0.1
+)
+
+// peerStats are the moving averages of the responses of a peer to sync requests
+type peerStats struct {
+	latency     float64 // seconds until the response arrived
+	throughput  float64 // bytes of the response per second
+	successRate float64 // ratio of requests that were answered with data
+}
+
+// score is the number of useful responses per second expected from the peer, 0 if it never responded with data
+func (ps *peerStats) score() float64 {
+	if ps.latency == 0 {
+		return 0
+	}
+	return ps.successRate / ps.latency
+}
+
+// peerQuality measures how well peers respond to sync requests, so requests that are sent to one peer after another
+// start with the peers that respond fast and reliably, instead of letting a slow peer drag a whole layer fetch. Peers
+// without measurements are asked first, to measure them.
+type peerQuality struct {
+	mu    sync.Mutex
+	stats map[string]*peerStats
+}
+
+func newPeerQuality() *peerQuality {
+	return &peerQuality{stats: make(map[string]*peerStats)}
+}
+
+func (q *peerQuality) get(peer p2pcrypto.PublicKey) *peerStats {
+	ps, ok := q.stats[peer.String()]
+	if !ok {
+		ps = &peerStats{successRate: 1}
+		q.stats[peer.String()] = ps
+	}
+	return ps
+}
+
+func average(avg, sample float64) float64 {
+	return (1-qualityWeight)*avg + qualityWeight*sample
+}
+
+// response records a response of the peer. Empty responses mean the peer couldn't serve the request.
+func (q *peerQuality) response(peer p2pcrypto.PublicKey, latency time.Duration, size int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	ps := q.get(peer)
+	if size == 0 {
+		ps.successRate = average(ps.successRate, 0)
+		return
+	}
+	seconds := latency.Seconds()
+	if ps.latency == 0 {
+		ps.latency = seconds
+	} else {
+		ps.latency = average(ps.latency, seconds)
+	}
+	if seconds > 0 {
+		ps.throughput = average(ps.throughput, float64(size)/seconds)
+	}
+	ps.successRate = average(ps.successRate, 1)
+}
+
+// failure records a request the peer didn't respond to in time
+func (q *peerQuality) failure(peer p2pcrypto.PublicKey) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	ps := q.get(peer)
+	ps.successRate = average(ps.successRate, 0)
+}
+
+// peerStats returns the measurements of the peer, and false if it wasn't measured
+func (q *peerQuality) peerStats(peer p2pcrypto.PublicKey) (peerStats, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	ps, ok := q.stats[peer.String()]
+	if !ok {
+		return peerStats{}, false
+	}
+	return *ps, true
+}
+
+// rank orders peers from the best to the worst, except that once in a while another peer is moved to the front.
+// Peers that weren't measured keep the order they were given in.
+func (q *peerQuality) rank(peers []p2ppeers.Peer) []p2ppeers.Peer {
+	scores := make(map[string]float64, len(peers))
+	q.mu.Lock()
+	for _, peer := range peers {
+		if ps, ok := q.stats[peer.String()]; ok {
+			scores[peer.String()] = ps.score()
+		} else {
+			scores[peer.String()] = math.Inf(1)
+		}
+	}
+	q.mu.Unlock()
+	sort.SliceStable(peers, func(i, j int) bool { return scores[peers[i].String()] > scores[peers[j].String()] })
+	if len(peers) > 1 && rand.Float64() < peerProbeRate {
+		i := 1 + rand.Intn(len(peers)-1)
+		peers[0], peers[i] = peers[i], peers[0]
+	}
+	return peers
+}
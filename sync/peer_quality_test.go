@@ -0,0 +1,65 @@
+package sync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/p2p/p2pcrypto"
+	p2ppeers "github.com/spacemeshos/go-spacemesh/p2p/peers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeerQuality_Rank(t *testing.T) {
+	r := require.New(t)
+	q := newPeerQuality()
+	fast, slow, failing, unknown := p2pcrypto.NewRandomPubkey(), p2pcrypto.NewRandomPubkey(), p2pcrypto.NewRandomPubkey(), p2pcrypto.NewRandomPubkey()
+	for i := 0; i < 10; i++ {
+		q.response(fast, 10*time.Millisecond, 1000)
+		q.response(slow, time.Second, 1000)
+		q.response(failing, 10*time.Millisecond, 1000)
+		q.failure(failing)
+		q.response(failing, 10*time.Millisecond, 0)
+	}
+
+	stats, ok := q.peerStats(fast)
+	r.True(ok)
+	r.InDelta(0.01, stats.latency, 0.001)
+	r.InDelta(100000, stats.throughput, 100000*0.2)
+	r.InDelta(1, stats.successRate, 0.001)
+	stats, ok = q.peerStats(failing)
+	r.True(ok)
+	r.True(stats.successRate < 0.5)
+	_, ok = q.peerStats(unknown)
+	r.False(ok)
+
+	// peers that weren't measured are asked first, then the best ones
+	first := make(map[string]int)
+	for i := 0; i < 1000; i++ {
+		ranked := q.rank([]p2ppeers.Peer{slow, failing, fast, unknown})
+		r.Len(ranked, 4)
+		first[ranked[0].String()]++
+		if ranked[0] == unknown {
+			r.Equal([]p2ppeers.Peer{unknown, fast, failing, slow}, ranked)
+		}
+	}
+	// but others are probed once in a while
+	r.True(first[unknown.String()] > 800)
+	r.True(first[fast.String()] > 0)
+	r.True(first[slow.String()] > 0)
+}
+
+func TestSyncer_PeerQuality(t *testing.T) {
+	r := require.New(t)
+	syncs, nodes, _ := SyncMockFactory(2, conf, t.Name(), memoryDB, newMockPoetDb)
+	client, server := syncs[0], syncs[1]
+	defer client.Close()
+	defer server.Close()
+	client.peers = getPeersMock([]p2ppeers.Peer{nodes[1].PublicKey()})
+
+	_, err := client.fetchLayerHashes(1)
+	r.Error(err) // the server has no blocks in the layer, and responds with nothing
+	stats, ok := client.quality.peerStats(nodes[1].PublicKey())
+	r.True(ok)
+	r.True(stats.successRate < 1)
+	r.Zero(stats.latency)
+}
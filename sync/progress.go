@@ -0,0 +1,151 @@
+package sync
+
+import (
+	"sync"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+// Phase is the phase of the sync of a node
+type Phase int
+
+const (
+	// PhaseStarting means the node didn't start syncing yet
+	PhaseStarting Phase = iota
+	// PhaseSnapshot means the node restores a snapshot of the state from its peers
+	PhaseSnapshot
+	// PhaseHeaders means the node fetches and validates the blocks of the layers it's missing, without their transactions
+	PhaseHeaders
+	// PhaseLayers means the node fetches and validates the layers it's missing from its peers
+	PhaseLayers
+	// PhaseGossip means the node listens to gossip for a full layer before it's synced
+	PhaseGossip
+	// PhaseSynced means the node is synced
+	PhaseSynced
+)
+
+func (p Phase) String() string {
+	switch p {
+	case PhaseStarting:
+		return "starting"
+	case PhaseSnapshot:
+		return "snapshot"
+	case PhaseHeaders:
+		return "headers"
+	case PhaseLayers:
+		return "layers"
+	case PhaseGossip:
+		return "gossip"
+	case PhaseSynced:
+		return "synced"
+	}
+	return "unknown"
+}
+
+// Progress describes how far the sync of a node got and how long it's expected to take
+type Progress struct {
+	Phase           Phase
+	ProcessedLayer  types.LayerID
+	CurrentLayer    types.LayerID
+	LayersCompleted uint64        // layers synced since the node started syncing layers
+	LayersRemaining uint64        // layers between the last processed layer and the current layer
+	LayersPerSecond float64       // layers synced per second since the node started syncing layers
+	BytesPerSecond  float64       // bytes received from peers per second since the node started syncing
+	ETA             time.Duration // estimated time until all layers are synced, zero if unknown
+}
+
+// progress tracks the progress of the sync. The rates are averaged over the current sync, which starts when the node
+// goes out of sync, so they don't jump around when a single layer takes long.
+type progress struct {
+	mu         sync.Mutex
+	phase      Phase
+	started    time.Time     // when the current sync started
+	bytes      uint64        // bytes received since the current sync started
+	layerStart types.LayerID // processed layer when the node started syncing layers
+	layersTime time.Time     // when the node started syncing layers
+}
+
+func (p *progress) setPhase(phase Phase) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.set(phase)
+}
+
+// syncLayers sets the phase to PhaseLayers, and counts the synced layers from the given processed layer
+func (p *progress) syncLayers(processed types.LayerID) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.setLayers(PhaseLayers, processed)
+}
+
+// syncHeaders sets the phase to PhaseHeaders, and counts the synced layers from the given processed layer
+func (p *progress) syncHeaders(processed types.LayerID) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.setLayers(PhaseHeaders, processed)
+}
+
+// setLayers keeps counting the synced layers while the node alternates between syncing headers and layers
+func (p *progress) setLayers(phase Phase, processed types.LayerID) {
+	if p.phase != PhaseHeaders && p.phase != PhaseLayers {
+		p.layerStart = processed
+		p.layersTime = time.Now()
+	}
+	p.set(phase)
+}
+
+func (p *progress) set(phase Phase) {
+	if phase == p.phase {
+		return
+	}
+	if p.phase == PhaseStarting || p.phase == PhaseSynced {
+		p.started = time.Now()
+		p.bytes = 0
+	}
+	p.phase = phase
+}
+
+func (p *progress) addBytes(n int) {
+	p.mu.Lock()
+	p.bytes += uint64(n)
+	p.mu.Unlock()
+}
+
+func (p *progress) get(processed, current types.LayerID) Progress {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	res := Progress{
+		Phase:          p.phase,
+		ProcessedLayer: processed,
+		CurrentLayer:   current,
+	}
+	if current > processed {
+		res.LayersRemaining = uint64(current - processed)
+	}
+	if p.phase == PhaseSynced || p.phase == PhaseStarting {
+		return res
+	}
+	now := time.Now()
+	if elapsed := now.Sub(p.started).Seconds(); elapsed > 0 {
+		res.BytesPerSecond = float64(p.bytes) / elapsed
+	}
+	if p.phase != PhaseHeaders && p.phase != PhaseLayers && p.phase != PhaseGossip {
+		return res
+	}
+	if processed > p.layerStart {
+		res.LayersCompleted = uint64(processed - p.layerStart)
+	}
+	if elapsed := now.Sub(p.layersTime).Seconds(); elapsed > 0 {
+		res.LayersPerSecond = float64(res.LayersCompleted) / elapsed
+	}
+	if res.LayersPerSecond > 0 {
+		res.ETA = time.Duration(float64(res.LayersRemaining) / res.LayersPerSecond * float64(time.Second))
+	}
+	return res
+}
+
+// SyncProgress returns the progress of the sync of the node
+func (s *Syncer) SyncProgress() Progress {
+	return s.progress.get(s.ProcessedLayer(), s.GetCurrentLayer())
+}
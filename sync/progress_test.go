@@ -0,0 +1,68 @@
+package sync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgress(t *testing.T) {
+	r := require.New(t)
+	p := &progress{}
+
+	res := p.get(0, 100)
+	r.Equal(PhaseStarting, res.Phase)
+	r.Equal(uint64(100), res.LayersRemaining)
+	r.Zero(res.ETA)
+
+	p.setPhase(PhaseSnapshot)
+	p.addBytes(1000)
+	p.syncLayers(50) // the snapshot isn't counted as synced layers
+	p.addBytes(1000)
+	p.started = p.started.Add(-10 * time.Second)
+	p.layersTime = p.layersTime.Add(-10 * time.Second)
+
+	res = p.get(70, 100)
+	r.Equal(PhaseLayers, res.Phase)
+	r.Equal(uint64(20), res.LayersCompleted)
+	r.Equal(uint64(30), res.LayersRemaining)
+	r.InDelta(2, res.LayersPerSecond, 0.01)
+	r.InDelta(200, res.BytesPerSecond, 1)
+	r.InDelta(15*time.Second, res.ETA, float64(100*time.Millisecond))
+
+	p.setPhase(PhaseSynced)
+	res = p.get(100, 100)
+	r.Equal(PhaseSynced, res.Phase)
+	r.Zero(res.LayersRemaining)
+	r.Zero(res.LayersPerSecond)
+	r.Zero(res.ETA)
+
+	// a new sync doesn't count the bytes of the previous one
+	p.syncLayers(100)
+	r.Zero(p.bytes)
+}
+
+func TestSyncer_SyncProgress(t *testing.T) {
+	r := require.New(t)
+	types.SetLayersPerEpoch(1)
+	syncs, _, _ := SyncMockFactory(1, conf, t.Name(), memoryDB, newMockPoetDb)
+	sync := syncs[0]
+	defer sync.Close()
+	r.Equal(PhaseStarting, sync.SyncProgress().Phase)
+
+	gen := types.GetEffectiveGenesis()
+	sync.ticker = &mockClock{Layer: gen}
+	sync.synchronise()
+	res := sync.SyncProgress()
+	r.Equal(PhaseSynced, res.Phase)
+	r.Equal(gen, res.CurrentLayer)
+
+	// no peers serve the missing layers
+	sync.ticker = &mockClock{Layer: gen + 5}
+	sync.synchronise()
+	res = sync.SyncProgress()
+	r.Equal(PhaseHeaders, res.Phase)
+	r.Equal(uint64(gen+5-res.ProcessedLayer), res.LayersRemaining)
+}
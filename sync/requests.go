@@ -115,6 +115,78 @@ func atxHashReqFactory(ep types.EpochID) requestFactory {
 
 }
 
+type peerManifestPair struct {
+	peer     p2ppeers.Peer
+	manifest *snapshotManifest
+}
+
+func snapshotManifestReqFactory(lyr types.LayerID) requestFactory {
+	return func(s networker, peer p2ppeers.Peer) (chan interface{}, error) {
+		ch := make(chan interface{}, 1)
+		foo := func(msg []byte) {
+			defer close(ch)
+			if len(msg) == 0 || msg == nil {
+				s.Warning("peer %v responded with nil to snapshot manifest request layer %v", peer, lyr)
+				return
+			}
+			var manifest snapshotManifest
+			if err := types.BytesToInterface(msg, &manifest); err != nil {
+				s.Error("could not unmarshal snapshot manifest response ", err)
+				return
+			}
+			if manifest.Layer != lyr || len(manifest.Chunks) == 0 {
+				s.Warning("peer %v responded with a manifest of another snapshot to layer %v request", peer, lyr)
+				return
+			}
+			ch <- &peerManifestPair{peer: peer, manifest: &manifest}
+		}
+		if err := s.SendRequest(snapshotMsg, lyr.Bytes(), peer, foo); err != nil {
+			return nil, err
+		}
+		return ch, nil
+	}
+}
+
+func snapshotChunkReqFactory(lyr types.LayerID, index uint32) requestFactory {
+	return func(s networker, peer p2ppeers.Peer) (chan interface{}, error) {
+		ch := make(chan interface{}, 1)
+		foo := func(msg []byte) {
+			defer close(ch)
+			if len(msg) == 0 || msg == nil {
+				s.Warning("peer %v responded with nil to snapshot chunk %d request layer %v", peer, index, lyr)
+				return
+			}
+			ch <- msg
+		}
+		payload, err := types.InterfaceToBytes(&snapshotChunkRequest{Layer: lyr, Index: index})
+		if err != nil {
+			return nil, err
+		}
+		if err := s.SendRequest(snapshotChunkMsg, payload, peer, foo); err != nil {
+			return nil, err
+		}
+		return ch, nil
+	}
+}
+
+func certificateReqFactory(lyr types.LayerID) requestFactory {
+	return func(s networker, peer p2ppeers.Peer) (chan interface{}, error) {
+		ch := make(chan interface{}, 1)
+		foo := func(msg []byte) {
+			defer close(ch)
+			if len(msg) == 0 || msg == nil {
+				s.Debug("peer %v has no certificate of layer %v", peer, lyr)
+				return
+			}
+			ch <- &peerCertificatePair{peer: peer, cert: msg}
+		}
+		if err := s.SendRequest(certificateMsg, lyr.Bytes(), peer, foo); err != nil {
+			return nil, err
+		}
+		return ch, nil
+	}
+}
+
 func newFetchReqFactory(msgtype server.MessageType, asItems func(msg []byte) ([]item, error)) batchRequestFactory {
 	//convert to chan
 	return func(infra networker, peer p2ppeers.Peer, ids []types.Hash32) (chan []item, error) {
@@ -157,8 +229,23 @@ func atxsAsItems(msg []byte) ([]item, error) {
 	}
 	atxs = calcAndSetIds(atxs)
 	items := make([]item, len(atxs))
+	ptrs := make([]*types.ActivationTx, len(atxs))
 	for i := range atxs {
 		items[i] = &atxs[i]
+		ptrs[i] = &atxs[i]
+	}
+	if err := types.ExtractSigners(ptrs); err == nil {
+		return items, nil
+	}
+
+	// a bad signature only drops its own atx
+	items = items[:0]
+	for _, atx := range ptrs {
+		if err := types.ExtractSigners([]*types.ActivationTx{atx}); err != nil {
+			log.Warning("dropped atx %v with invalid signature: %v", atx.ShortString(), err)
+			continue
+		}
+		items = append(items, atx)
 	}
 	return items, nil
 }
@@ -188,9 +275,23 @@ func blocksAsItems(msg []byte) ([]item, error) {
 		return nil, err
 	}
 	items := make([]item, len(blocks))
+	ptrs := make([]*types.Block, len(blocks))
 	for i := range blocks {
-		blocks[i].Initialize()
 		items[i] = &blocks[i]
+		ptrs[i] = &blocks[i]
+	}
+	if err := types.InitializeBlocks(ptrs); err == nil {
+		return items, nil
+	}
+
+	// a bad signature only drops its own block
+	items = items[:0]
+	for _, blk := range ptrs {
+		if err := types.InitializeBlocks([]*types.Block{blk}); err != nil {
+			log.Warning("dropped block with invalid signature: %v", err)
+			continue
+		}
+		items = append(items, blk)
 	}
 	return items, nil
 }
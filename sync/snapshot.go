@@ -0,0 +1,377 @@
+package sync
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/checkpoint"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/common/util"
+	"github.com/spacemeshos/go-spacemesh/database"
+	"github.com/spacemeshos/go-spacemesh/log"
+	p2ppeers "github.com/spacemeshos/go-spacemesh/p2p/peers"
+	"github.com/spacemeshos/go-spacemesh/p2p/service"
+	"github.com/spacemeshos/go-spacemesh/state"
+)
+
+// A new node can sync the global state from its peers instead of applying the transactions of every layer: peers serve
+// the checkpoint of a recent layer, split into chunks, and the node restores it and syncs the layers that follow it.
+// Peers only serve the snapshot of the layer that new nodes sync from now, the last layer of an epoch, and only serve a
+// limited number of snapshot requests per second. The node only restores a snapshot whose state root the operator
+// trusts: it asks all of its peers for the manifest of the snapshot, which lists the hashes of the chunks, and fetches
+// the chunks from the peers whose manifest has the trusted state root. The chunks are checked against the manifest,
+// and the restored accounts against the state root, before the snapshot is applied. If any of it fails, or the state
+// root of the layer isn't trusted, the node syncs all the layers.
+
+const (
+	// snapshotChunkSize is the size of the chunks snapshots are split into
+	snapshotChunkSize = 512 * 1024
+	// maxSnapshotRequests is the number of snapshot requests of all peers the node serves per second
+	maxSnapshotRequests = 20
+)
+
+var (
+	errNoSnapshot        = errors.New("no peer serves a snapshot")
+	errUntrustedSnapshot = errors.New("the state root of the snapshot layer isn't trusted")
+)
+
+// snapshotState is the global state that snapshots are created from and restored to
+type snapshotState interface {
+	GetLayerStateRoot(layer types.LayerID) (types.Hash32, error)
+	IterateAccounts(root types.Hash32, fn func(addr types.Address, nonce, balance uint64) error) error
+	SetNonce(addr types.Address, nonce uint64)
+	SetBalance(addr types.Address, amount *big.Int)
+	CommitLayer(layer types.LayerID) (types.Hash32, error)
+	LoadState(layer types.LayerID) error
+}
+
+// snapshotAtxs holds the ATXs that snapshots are created from and restored to
+type snapshotAtxs interface {
+	GetEpochAtxs(epochID types.EpochID) []types.ATXID
+	GetFullAtx(id types.ATXID) (*types.ActivationTx, error)
+	StoreAtx(ech types.EpochID, atx *types.ActivationTx) error
+}
+
+// snapshotManifest describes the snapshot of a layer, the hashes of the chunks of its encoded checkpoint
+type snapshotManifest struct {
+	Layer     types.LayerID
+	StateRoot types.Hash32
+	Chunks    []types.Hash32
+}
+
+func (m *snapshotManifest) hash() (types.Hash32, error) {
+	b, err := types.InterfaceToBytes(m)
+	if err != nil {
+		return types.Hash32{}, err
+	}
+	return types.CalcHash32(b), nil
+}
+
+type snapshotChunkRequest struct {
+	Layer types.LayerID
+	Index uint32
+}
+
+type snapshot struct {
+	manifest *snapshotManifest
+	chunks   [][]byte
+}
+
+// snapshots creates the snapshots the node serves and restores the snapshot it syncs from. The snapshots it created
+// are kept until the snapshot of the next epoch is created, since peers that sync at the same time ask for the
+// snapshot of the same layer.
+type snapshots struct {
+	state snapshotState
+	atxs  snapshotAtxs
+
+	mu       sync.Mutex
+	cache    map[types.LayerID]*snapshot
+	window   time.Time // the second the served requests are counted in
+	requests int
+}
+
+// allow returns false if the node already served maxSnapshotRequests snapshot requests in the current second
+func (ss *snapshots) allow(now time.Time) bool {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if now.Sub(ss.window) >= time.Second {
+		ss.window, ss.requests = now, 0
+	}
+	if ss.requests >= maxSnapshotRequests {
+		return false
+	}
+	ss.requests++
+	return true
+}
+
+func (ss *snapshots) snapshot(layer types.LayerID) (*snapshotManifest, [][]byte, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if snap, ok := ss.cache[layer]; ok {
+		return snap.manifest, snap.chunks, nil
+	}
+	cp, err := checkpoint.Generate(layer, ss.state, ss.atxs)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := cp.Encode()
+	if err != nil {
+		return nil, nil, err
+	}
+	manifest := &snapshotManifest{Layer: layer, StateRoot: cp.StateRoot}
+	var chunks [][]byte
+	for len(data) > 0 {
+		size := snapshotChunkSize
+		if len(data) < size {
+			size = len(data)
+		}
+		chunks = append(chunks, data[:size])
+		manifest.Chunks = append(manifest.Chunks, types.CalcHash32(data[:size]))
+		data = data[size:]
+	}
+	if ss.cache == nil {
+		ss.cache = make(map[types.LayerID]*snapshot)
+	}
+	for l := range ss.cache {
+		if l.GetEpoch()+1 < layer.GetEpoch() {
+			delete(ss.cache, l)
+		}
+	}
+	ss.cache[layer] = &snapshot{manifest: manifest, chunks: chunks}
+	return manifest, chunks, nil
+}
+
+// SetSnapshotSource sets the global state and ATXs that the node serves snapshots of to its peers, and restores the
+// snapshot it syncs from to. Snapshots aren't served until it's set. It must be called before Start.
+func (s *Syncer) SetSnapshotSource(state snapshotState, atxs snapshotAtxs) {
+	s.snapshots = &snapshots{state: state, atxs: atxs}
+}
+
+// servesSnapshot returns true if the node serves the snapshot of the layer to its peers: the layer new nodes sync a
+// snapshot of now, or a layer ago for peers whose clock lags
+func (s *Syncer) servesSnapshot(layer types.LayerID) bool {
+	curr := s.GetCurrentLayer()
+	if layer == 0 {
+		return false
+	}
+	return layer == s.snapshotLayer(curr) || (curr > 0 && layer == s.snapshotLayer(curr-1))
+}
+
+// snapshotRequest returns false if the snapshot request of the layer isn't served
+func (s *Syncer) snapshotRequest(layer types.LayerID, logger log.Log) bool {
+	if s.snapshots == nil {
+		return false
+	}
+	if !s.servesSnapshot(layer) {
+		logger.With().Debug("snapshot of layer isn't served", layer)
+		return false
+	}
+	if !s.snapshots.allow(time.Now()) {
+		logger.With().Debug("too many snapshot requests", layer)
+		return false
+	}
+	return true
+}
+
+func newSnapshotManifestRequestHandler(s *Syncer, logger log.Log) func(msg []byte) []byte {
+	return func(msg []byte) []byte {
+		layer := types.LayerID(util.BytesToUint64(msg))
+		if !s.snapshotRequest(layer, logger) {
+			return nil
+		}
+		logger.With().Info("handle snapshot manifest request", layer)
+		manifest, _, err := s.snapshots.snapshot(layer)
+		if err != nil {
+			logger.With().Warning("cannot create snapshot", layer, log.Err(err))
+			return nil
+		}
+		b, err := types.InterfaceToBytes(manifest)
+		if err != nil {
+			logger.With().Error("cannot encode snapshot manifest", layer, log.Err(err))
+			return nil
+		}
+		return b
+	}
+}
+
+func newSnapshotChunkRequestHandler(s *Syncer, logger log.Log) func(msg []byte) []byte {
+	return func(msg []byte) []byte {
+		var req snapshotChunkRequest
+		if err := types.BytesToInterface(msg, &req); err != nil {
+			logger.Error("malformed snapshot chunk request: %v", err)
+			return nil
+		}
+		if !s.snapshotRequest(req.Layer, logger) {
+			return nil
+		}
+		logger.With().Debug("handle snapshot chunk request", req.Layer, log.Uint32("index", req.Index))
+		_, chunks, err := s.snapshots.snapshot(req.Layer)
+		if err != nil {
+			logger.With().Warning("cannot create snapshot", req.Layer, log.Err(err))
+			return nil
+		}
+		if int(req.Index) >= len(chunks) {
+			return nil
+		}
+		return chunks[req.Index]
+	}
+}
+
+// snapshotLayer returns the layer of the snapshot a node syncs from when the current layer is curr: the last layer of
+// the epoch before the one of the last layer that all of the peers already applied
+func (s *Syncer) snapshotLayer(curr types.LayerID) types.LayerID {
+	if int(curr) <= s.Hdist {
+		return 0
+	}
+	epoch := (curr - types.LayerID(s.Hdist)).GetEpoch()
+	if epoch == 0 {
+		return 0
+	}
+	return epoch.FirstLayer() - 1
+}
+
+// syncSnapshot restores the snapshot of a recent layer from peers into the state of a node that didn't apply any
+// layer yet. It returns false if the node has to sync all the layers instead.
+func (s *Syncer) syncSnapshot(curr types.LayerID) bool {
+	layer := s.snapshotLayer(curr)
+	if layer <= s.ProcessedLayer() {
+		return false
+	}
+	s.With().Info("syncing snapshot", layer)
+	cp, err := s.fetchSnapshot(layer)
+	if err != nil {
+		s.With().Warning("cannot fetch snapshot, syncing all layers", layer, log.Err(err))
+		return false
+	}
+	if err := checkpoint.Restore(cp, s.snapshots.state, s.snapshots.atxs, s.Mesh); err != nil {
+		s.With().Error("cannot restore snapshot, syncing all layers", layer, log.Err(err))
+		if err := s.snapshots.state.LoadState(s.ProcessedLayer()); err != nil {
+			s.With().Error("cannot reload state", s.ProcessedLayer(), log.Err(err))
+		}
+		return false
+	}
+	s.With().Info("restored snapshot", layer,
+		log.Int("accounts", len(cp.Accounts)),
+		log.Int("atxs", len(cp.Atxs)))
+	return true
+}
+
+// fetchSnapshot fetches the snapshot of a layer with a trusted state root from the peers that serve it and verifies it
+func (s *Syncer) fetchSnapshot(layer types.LayerID) (*checkpoint.Checkpoint, error) {
+	trusted, ok := s.SnapshotRoots[layer]
+	if !ok {
+		return nil, errUntrustedSnapshot
+	}
+	manifest, peers, err := s.fetchSnapshotManifest(layer, trusted)
+	if err != nil {
+		return nil, err
+	}
+	var data []byte
+	for i, hash := range manifest.Chunks {
+		chunk, err := s.fetchSnapshotChunk(layer, uint32(i), hash, peers)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, chunk...)
+	}
+	cp, err := checkpoint.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	if cp.Layer != layer || cp.StateRoot != manifest.StateRoot {
+		return nil, fmt.Errorf("snapshot of layer %v doesn't match its manifest", cp.Layer)
+	}
+	root, err := accountsRoot(cp.Accounts)
+	if err != nil {
+		return nil, err
+	}
+	if root != cp.StateRoot {
+		return nil, checkpoint.ErrStateRootMismatch
+	}
+	return cp, nil
+}
+
+// accountsRoot returns the state root of the given accounts
+func accountsRoot(accounts []checkpoint.Account) (types.Hash32, error) {
+	st, err := state.New(types.Hash32{}, state.NewDatabase(database.NewMemDatabase()))
+	if err != nil {
+		return types.Hash32{}, err
+	}
+	for _, acc := range accounts {
+		st.SetNonce(acc.Address, acc.Nonce)
+		st.SetBalance(acc.Address, new(big.Int).SetUint64(acc.Balance))
+	}
+	return st.Commit()
+}
+
+// fetchSnapshotManifest returns the manifest of the snapshot of a layer with the trusted state root that most peers
+// agree on, and these peers. Peers that serve a manifest with another state root are reported.
+func (s *Syncer) fetchSnapshotManifest(layer types.LayerID, root types.Hash32) (*snapshotManifest, []p2ppeers.Peer, error) {
+	wrk := newPeersWorker(s, s.GetPeers(), &sync.Once{}, snapshotManifestReqFactory(layer))
+	go wrk.Work()
+	manifests := make(map[types.Hash32]*snapshotManifest)
+	peers := make(map[types.Hash32][]p2ppeers.Peer)
+	for out := range wrk.output {
+		pair, ok := out.(*peerManifestPair)
+		if !ok || pair == nil {
+			continue
+		}
+		if pair.manifest.StateRoot != root {
+			s.With().Warning("peer served a snapshot with an untrusted state root", layer,
+				log.String("peer", pair.peer.String()))
+			s.ReportPeer(pair.peer, service.InvalidMessage)
+			continue
+		}
+		h, err := pair.manifest.hash()
+		if err != nil {
+			continue
+		}
+		manifests[h] = pair.manifest
+		peers[h] = append(peers[h], pair.peer)
+	}
+	var best types.Hash32
+	for h := range manifests {
+		if len(peers[h]) > len(peers[best]) {
+			best = h
+		}
+	}
+	if len(peers[best]) == 0 {
+		return nil, nil, errNoSnapshot
+	}
+	if len(manifests) > 1 {
+		s.With().Warning("peers serve different chunks of the snapshot",
+			layer,
+			log.Int("snapshots", len(manifests)),
+			log.Int("peers", len(peers[best])))
+	}
+	return manifests[best], peers[best], nil
+}
+
+// fetchSnapshotChunk fetches a chunk from the first of the peers that returns a chunk with the expected hash
+func (s *Syncer) fetchSnapshotChunk(layer types.LayerID, index uint32, hash types.Hash32, peers []p2ppeers.Peer) ([]byte, error) {
+	for _, peer := range peers {
+		ch, err := snapshotChunkReqFactory(layer, index)(s, peer)
+		if err != nil {
+			return nil, err
+		}
+		select {
+		case <-s.GetExit():
+			return nil, fmt.Errorf("interupt")
+		case <-time.After(s.Configuration.RequestTimeout):
+			s.Warning("snapshot chunk %d request to %v timed out", index, peer)
+		case v := <-ch:
+			if v == nil {
+				continue
+			}
+			if chunk := v.([]byte); types.CalcHash32(chunk) == hash {
+				return chunk, nil
+			}
+			s.Warning("peer %v returned snapshot chunk %d that doesn't match the manifest", peer, index)
+			s.ReportPeer(peer, service.InvalidMessage)
+		}
+	}
+	return nil, fmt.Errorf("could not fetch snapshot chunk %d from any peer", index)
+}
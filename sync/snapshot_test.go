@@ -0,0 +1,235 @@
+package sync
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/checkpoint"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/database"
+	p2ppeers "github.com/spacemeshos/go-spacemesh/p2p/peers"
+	"github.com/spacemeshos/go-spacemesh/state"
+	"github.com/stretchr/testify/require"
+)
+
+type snapshotStateMock struct {
+	*state.DB
+	roots map[types.LayerID]types.Hash32
+	extra *types.Address // an account that is iterated but isn't in the state
+}
+
+func newSnapshotStateMock() *snapshotStateMock {
+	st, _ := state.New(types.Hash32{}, state.NewDatabase(database.NewMemDatabase()))
+	return &snapshotStateMock{DB: st, roots: make(map[types.LayerID]types.Hash32)}
+}
+
+func (m *snapshotStateMock) GetLayerStateRoot(layer types.LayerID) (types.Hash32, error) {
+	root, ok := m.roots[layer]
+	if !ok {
+		return types.Hash32{}, errors.New("state of layer isn't applied")
+	}
+	return root, nil
+}
+
+func (m *snapshotStateMock) IterateAccounts(root types.Hash32, fn func(addr types.Address, nonce, balance uint64) error) error {
+	if m.extra != nil {
+		if err := fn(*m.extra, 0, 1); err != nil {
+			return err
+		}
+	}
+	return m.DB.IterateAccounts(root, fn)
+}
+
+func (m *snapshotStateMock) CommitLayer(layer types.LayerID) (types.Hash32, error) {
+	root, err := m.Commit()
+	m.roots[layer] = root
+	return root, err
+}
+
+func (m *snapshotStateMock) LoadState(layer types.LayerID) error {
+	return nil
+}
+
+func (m *snapshotStateMock) PruneLayerStateRoot(layer types.LayerID) error {
+	return nil
+}
+
+type snapshotAtxsMock map[types.EpochID][]*types.ActivationTx
+
+func (m snapshotAtxsMock) GetEpochAtxs(epochID types.EpochID) []types.ATXID {
+	var ids []types.ATXID
+	for _, atx := range m[epochID] {
+		ids = append(ids, atx.ID())
+	}
+	return ids
+}
+
+func (m snapshotAtxsMock) GetFullAtx(id types.ATXID) (*types.ActivationTx, error) {
+	for _, atxs := range m {
+		for _, atx := range atxs {
+			if atx.ID() == id {
+				return atx, nil
+			}
+		}
+	}
+	return nil, errors.New("atx not found")
+}
+
+func (m snapshotAtxsMock) StoreAtx(ech types.EpochID, atx *types.ActivationTx) error {
+	m[ech] = append(m[ech], atx)
+	return nil
+}
+
+func TestSyncer_SyncSnapshot(t *testing.T) {
+	r := require.New(t)
+	types.SetLayersPerEpoch(4)
+	curr := types.LayerID(20)
+	syncs, nodes := SyncMockFactoryManClock(2, conf, "SyncSnapshot", memoryDB, newMemPoetDb, &mockClock{Layer: curr})
+	client, server := syncs[0], syncs[1]
+	defer client.Close()
+	defer server.Close()
+	client.peers = getPeersMock([]p2ppeers.Peer{nodes[1].PublicKey()})
+
+	layer := client.snapshotLayer(curr)
+	r.Equal(types.LayerID(11), layer)
+
+	serverState := newSnapshotStateMock()
+	addr := types.HexToAddress("1234")
+	serverState.SetNonce(addr, 3)
+	serverState.SetBalance(addr, big.NewInt(1000))
+	root, err := serverState.CommitLayer(layer)
+	r.NoError(err)
+	a := atx("")
+	serverAtxs := snapshotAtxsMock{layer.GetEpoch(): {a}}
+	server.SetSnapshotSource(serverState, serverAtxs)
+
+	clientState, clientAtxs := newSnapshotStateMock(), snapshotAtxsMock{}
+	client.SetSnapshotSource(clientState, clientAtxs)
+	// the snapshot isn't restored unless its state root is trusted
+	r.False(client.syncSnapshot(curr))
+	client.SnapshotRoots = map[types.LayerID]types.Hash32{layer: root}
+	r.True(client.syncSnapshot(curr))
+
+	r.Equal(uint64(3), clientState.GetNonce(addr))
+	r.Equal(uint64(1000), clientState.GetBalance(addr))
+	r.Equal([]types.ATXID{a.ID()}, clientAtxs.GetEpochAtxs(a.PubLayerID.GetEpoch()))
+	r.Equal(layer, client.ProcessedLayer())
+	r.Equal(layer, client.CheckpointLayer())
+
+	// the snapshot is only synced by nodes that didn't apply its layer yet
+	r.False(client.syncSnapshot(curr))
+}
+
+func TestSyncer_SyncSnapshot_Invalid(t *testing.T) {
+	r := require.New(t)
+	types.SetLayersPerEpoch(4)
+	curr := types.LayerID(20)
+	syncs, nodes := SyncMockFactoryManClock(2, conf, "SyncSnapshot_Invalid", memoryDB, newMemPoetDb, &mockClock{Layer: curr})
+	client, server := syncs[0], syncs[1]
+	defer client.Close()
+	defer server.Close()
+	client.peers = getPeersMock([]p2ppeers.Peer{nodes[1].PublicKey()})
+
+	layer := client.snapshotLayer(curr)
+	clientState := newSnapshotStateMock()
+	client.SetSnapshotSource(clientState, snapshotAtxsMock{})
+	client.SnapshotRoots = map[types.LayerID]types.Hash32{layer: {1}}
+
+	// no peer serves snapshots
+	_, err := client.fetchSnapshot(layer)
+	r.Equal(errNoSnapshot, err)
+	r.False(client.syncSnapshot(curr))
+
+	// the peer serves a snapshot with another state root
+	serverState := newSnapshotStateMock()
+	addr := types.HexToAddress("1234")
+	serverState.SetBalance(addr, big.NewInt(1000))
+	root, err := serverState.CommitLayer(layer)
+	r.NoError(err)
+	extra := types.HexToAddress("5678")
+	serverState.extra = &extra
+	server.SetSnapshotSource(serverState, snapshotAtxsMock{})
+	_, err = client.fetchSnapshot(layer)
+	r.Equal(errNoSnapshot, err)
+
+	// the accounts of the snapshot don't add up to its state root
+	client.SnapshotRoots[layer] = root
+	_, err = client.fetchSnapshot(layer)
+	r.Equal(checkpoint.ErrStateRootMismatch, err)
+	r.False(client.syncSnapshot(curr))
+
+	r.Equal(uint64(0), clientState.GetBalance(addr))
+	r.Equal(types.LayerID(0), client.ProcessedLayer())
+}
+
+func TestSnapshots_Chunks(t *testing.T) {
+	r := require.New(t)
+	st := newSnapshotStateMock()
+	for i := 0; i < 20000; i++ {
+		st.SetBalance(types.BytesToAddress(big.NewInt(int64(i+1)).Bytes()), big.NewInt(int64(i+1)))
+	}
+	_, err := st.CommitLayer(5)
+	r.NoError(err)
+	ss := &snapshots{state: st, atxs: snapshotAtxsMock{}}
+
+	manifest, chunks, err := ss.snapshot(5)
+	r.NoError(err)
+	r.True(len(chunks) > 1)
+	r.Len(manifest.Chunks, len(chunks))
+	var data []byte
+	for i, chunk := range chunks {
+		r.True(len(chunk) <= snapshotChunkSize)
+		r.Equal(manifest.Chunks[i], types.CalcHash32(chunk))
+		data = append(data, chunk...)
+	}
+	cp, err := checkpoint.Decode(data)
+	r.NoError(err)
+	r.Len(cp.Accounts, 20000)
+	root, err := st.GetLayerStateRoot(5)
+	r.NoError(err)
+	r.Equal(root, manifest.StateRoot)
+
+	// the snapshots are kept until the snapshot of the next epoch is created
+	cached, _, err := ss.snapshot(5)
+	r.NoError(err)
+	r.True(manifest == cached)
+	_, _, err = ss.snapshot(6)
+	r.Error(err)
+	layer := (types.LayerID(5).GetEpoch() + 2).FirstLayer()
+	_, err = st.CommitLayer(layer)
+	r.NoError(err)
+	_, _, err = ss.snapshot(layer)
+	r.NoError(err)
+	r.Len(ss.cache, 1)
+}
+
+func TestSyncer_ServesSnapshot(t *testing.T) {
+	r := require.New(t)
+	types.SetLayersPerEpoch(4)
+	curr := types.LayerID(20)
+	syncs, _ := SyncMockFactoryManClock(1, conf, t.Name(), memoryDB, newMemPoetDb, &mockClock{Layer: curr})
+	s := syncs[0]
+	defer s.Close()
+	st := newSnapshotStateMock()
+	for _, layer := range []types.LayerID{7, 11, 12} {
+		_, err := st.CommitLayer(layer)
+		r.NoError(err)
+	}
+	s.SetSnapshotSource(st, snapshotAtxsMock{})
+	manifests := newSnapshotManifestRequestHandler(s, s.Log)
+
+	// only the snapshot of the layer new nodes sync from is served
+	r.Equal(types.LayerID(11), s.snapshotLayer(curr))
+	r.NotNil(manifests(types.LayerID(11).Bytes()))
+	r.Nil(manifests(types.LayerID(12).Bytes()))
+	r.Nil(manifests(types.LayerID(7).Bytes()))
+
+	// the requests of all peers are limited
+	for i := 1; i < maxSnapshotRequests; i++ {
+		r.NotNil(manifests(types.LayerID(11).Bytes()))
+	}
+	r.Nil(manifests(types.LayerID(11).Bytes()))
+	r.True(s.snapshots.allow(time.Now().Add(time.Second)))
+}
@@ -9,9 +9,11 @@ import (
 
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/database"
+	"github.com/spacemeshos/go-spacemesh/events"
 	"github.com/spacemeshos/go-spacemesh/log"
 	"github.com/spacemeshos/go-spacemesh/mesh"
 	p2pconf "github.com/spacemeshos/go-spacemesh/p2p/config"
+	"github.com/spacemeshos/go-spacemesh/p2p/p2pcrypto"
 	p2ppeers "github.com/spacemeshos/go-spacemesh/p2p/peers"
 	"github.com/spacemeshos/go-spacemesh/p2p/server"
 	"github.com/spacemeshos/go-spacemesh/p2p/service"
@@ -54,7 +56,12 @@ type net struct {
 	peers
 	RequestTimeout time.Duration
 	*server.MessageServer
-	exit chan struct{}
+	exit     chan struct{}
+	scorer   service.PeerScorer // nil if the network doesn't score peers
+	received func(n int)        // called with the size of every response, if set
+	limiter  *peerLimiter       // nil if the requests in flight to a peer aren't capped
+	quality  *peerQuality
+	throttle *throttle
 }
 
 func (ms net) Close() {
@@ -62,6 +69,11 @@ func (ms net) Close() {
 	ms.peers.Close()
 }
 
+// GetPeers returns the peers ordered by how well they respond to sync requests
+func (ms net) GetPeers() []p2ppeers.Peer {
+	return ms.quality.rank(ms.peers.GetPeers())
+}
+
 func (ms net) GetTimeout() time.Duration {
 	return ms.RequestTimeout
 }
@@ -70,6 +82,54 @@ func (ms net) GetExit() chan struct{} {
 	return ms.exit
 }
 
+// SendRequest sends a request to a peer once it has fewer requests in flight than the limit, measures how the peer
+// responds and counts the bytes of the response
+func (ms net) SendRequest(msgType server.MessageType, payload []byte, address p2pcrypto.PublicKey, resHandler func(msg []byte)) error {
+	done, err := ms.throttle.acquire(ms.exit)
+	if err != nil {
+		return err
+	}
+	release := func() {}
+	if ms.limiter != nil {
+		r, err := ms.limiter.acquire(address, ms.exit)
+		if err != nil {
+			done(0)
+			return err
+		}
+		release = r
+	}
+	measured := sync.Once{}
+	start := time.Now()
+	timer := time.AfterFunc(ms.RequestTimeout, func() {
+		measured.Do(func() { ms.quality.failure(address) })
+	})
+	handler := func(msg []byte) {
+		done(len(msg))
+		release()
+		timer.Stop()
+		measured.Do(func() { ms.quality.response(address, time.Since(start), len(msg)) })
+		if ms.received != nil {
+			ms.received(len(msg))
+		}
+		resHandler(msg)
+	}
+	if err := ms.MessageServer.SendRequest(msgType, payload, address, handler); err != nil {
+		done(0)
+		release()
+		timer.Stop()
+		measured.Do(func() { ms.quality.failure(address) })
+		return err
+	}
+	return nil
+}
+
+// ReportPeer reports the behavior of a sync peer to the network, which prefers useful peers for later requests
+func (ms net) ReportPeer(peer p2ppeers.Peer, event service.PeerEvent) {
+	if ms.scorer != nil {
+		ms.scorer.ReportPeer(peer, event)
+	}
+}
+
 // Configuration represents all config params needed by syncer
 type Configuration struct {
 	LayersPerEpoch  uint16
@@ -80,6 +140,16 @@ type Configuration struct {
 	ValidationDelta time.Duration
 	AtxsLimit       int
 	Hdist           int
+	SnapshotSync    bool                           // a new node restores a snapshot of the state from peers instead of applying every layer
+	FetchLayers     int                            // number of layers fetched concurrently ahead of validation while out of sync
+	PeerRequests    int                            // max number of requests in flight to a single peer, 0 for no limit
+	Bandwidth       int                            // max bytes per second received by sync, 0 for no limit
+	Requests        int                            // max number of requests in flight to all peers, 0 for no limit
+	HeadersFirst    bool                           // a node that is out of sync fetches the blocks of all missing layers before their transactions
+	TrustedLayers   map[types.LayerID]types.Hash32 // hashes of the block IDs of layers whose history is known to be good
+	SnapshotRoots   map[types.LayerID]types.Hash32 // state roots of the layers a new node may restore a snapshot of
+	AuditInterval   time.Duration                  // interval between audits of the recent layers for missing data, 0 to disable
+	AuditLayers     int                            // number of recent validated layers scanned by an audit, 0 for all
 }
 
 var (
@@ -111,14 +181,17 @@ const (
 	inProgress status = 1
 	done       status = 2
 
-	blockMsg      server.MessageType = 1
-	layerHashMsg  server.MessageType = 2
-	layerIdsMsg   server.MessageType = 3
-	txMsg         server.MessageType = 4
-	atxMsg        server.MessageType = 5
-	poetMsg       server.MessageType = 6
-	atxIdsMsg     server.MessageType = 7
-	atxIdrHashMsg server.MessageType = 8
+	blockMsg         server.MessageType = 1
+	layerHashMsg     server.MessageType = 2
+	layerIdsMsg      server.MessageType = 3
+	txMsg            server.MessageType = 4
+	atxMsg           server.MessageType = 5
+	poetMsg          server.MessageType = 6
+	atxIdsMsg        server.MessageType = 7
+	atxIdrHashMsg    server.MessageType = 8
+	snapshotMsg      server.MessageType = 9
+	snapshotChunkMsg server.MessageType = 10
+	certificateMsg   server.MessageType = 11
 
 	syncProtocol                      = "/sync/1.0/"
 	validatingLayerNone types.LayerID = 0
@@ -150,9 +223,22 @@ type Syncer struct {
 	gossipSynced         status
 	awaitCh              chan struct{}
 
-	blockQueue *blockQueue
-	txQueue    *txQueue
-	atxQueue   *atxQueue
+	blockQueue   *blockQueue
+	txQueue      *txQueue
+	atxQueue     *atxQueue
+	snapshots    *snapshots
+	certificates certificateHandler // nil if certificates aren't fetched
+	certLayers   chan types.LayerID // validated layers whose certificates are fetched
+	progress     *progress
+	audits       auditor
+
+	frontierMutex sync.Mutex
+	inFlight      map[types.LayerID]struct{} // layers being fetched, whose data may be partial
+	resumed       map[types.LayerID]struct{} // layers that were in flight when the node stopped
+	deferred      map[types.LayerID]struct{} // layers whose blocks are stored without their transactions
+
+	trustedMutex sync.Mutex
+	trusted      map[types.LayerID]map[types.BlockID]struct{} // blocks of trusted layers that match the trusted hashes
 }
 
 // NewSync fires a sync every sm.SyncInterval or on force space from outside
@@ -165,6 +251,14 @@ func NewSync(srv service.Service, layers *mesh.Mesh, txpool txMemPool, atxDB atx
 		MessageServer:  server.NewMsgServer(srv.(server.Service), syncProtocol, conf.RequestTimeout, make(chan service.DirectMessage, p2pconf.Values.BufferSize), logger),
 		peers:          p2ppeers.NewPeers(srv, logger.WithName("peers")),
 		exit:           exit,
+		quality:        newPeerQuality(),
+		throttle:       newThrottle(Limits{Bandwidth: conf.Bandwidth, Requests: conf.Requests}, conf.RequestTimeout),
+	}
+	if scorer, ok := srv.(service.PeerScorer); ok {
+		srvr.scorer = scorer
+	}
+	if conf.PeerRequests > 0 {
+		srvr.limiter = newPeerLimiter(conf.PeerRequests, conf.RequestTimeout)
 	}
 
 	s := &Syncer{
@@ -185,7 +279,15 @@ func NewSync(srv service.Service, layers *mesh.Mesh, txpool txMemPool, atxDB atx
 		exit:                      exit,
 		gossipSynced:              pending,
 		awaitCh:                   make(chan struct{}),
+		progress:                  &progress{},
+		inFlight:                  make(map[types.LayerID]struct{}),
+		resumed:                   make(map[types.LayerID]struct{}),
+		deferred:                  make(map[types.LayerID]struct{}),
+		trusted:                   make(map[types.LayerID]map[types.BlockID]struct{}),
+		certLayers:                make(chan types.LayerID, certificateQueueSize),
 	}
+	s.loadFrontier()
+	srvr.received = s.progress.addBytes
 
 	s.blockQueue = newValidationQueue(srvr, conf, s)
 	s.txQueue = newTxQueue(s)
@@ -198,6 +300,9 @@ func NewSync(srv service.Service, layers *mesh.Mesh, txpool txMemPool, atxDB atx
 	srvr.RegisterBytesMsgHandler(poetMsg, newPoetRequestHandler(s, logger))
 	srvr.RegisterBytesMsgHandler(atxIdsMsg, newEpochAtxsRequestHandler(s, logger))
 	srvr.RegisterBytesMsgHandler(atxIdrHashMsg, newAtxHashRequestHandler(s, logger))
+	srvr.RegisterBytesMsgHandler(snapshotMsg, newSnapshotManifestRequestHandler(s, logger))
+	srvr.RegisterBytesMsgHandler(snapshotChunkMsg, newSnapshotChunkRequestHandler(s, logger))
+	srvr.RegisterBytesMsgHandler(certificateMsg, newCertificateRequestHandler(s, logger))
 
 	return s
 }
@@ -275,6 +380,17 @@ func (s *Syncer) setGossipBufferingStatus(status status) {
 	}
 	s.Info("setting gossip to '%s' ", status.String())
 	s.notifySubscribers(s.gossipSynced, status)
+	switch status {
+	case done:
+		s.progress.setPhase(PhaseSynced)
+	case inProgress:
+		s.progress.setPhase(PhaseGossip)
+	}
+	if status == done {
+		events.Publish(events.NodeEvent{Kind: events.NodeEventSyncCompleted})
+	} else if s.gossipSynced == done {
+		events.Publish(events.NodeEvent{Kind: events.NodeEventSyncStarted})
+	}
 	s.gossipSynced = status
 
 }
@@ -290,7 +406,14 @@ func (s *Syncer) IsSynced() bool {
 func (s *Syncer) Start() {
 	if s.startLock.TryLock() {
 		s.Info("start syncer")
+		events.Publish(events.NodeEvent{Kind: events.NodeEventSyncStarted})
 		go s.run()
+		if s.AuditInterval > 0 {
+			go s.auditLoop()
+		}
+		if s.certificates != nil {
+			go s.certificateLoop()
+		}
 		s.forceSync <- true
 		return
 	}
@@ -331,8 +454,14 @@ func (s *Syncer) synchronise() {
 		return
 	}
 
-	// we have all the data of the prev layers so we can simply validate
-	if s.weaklySynced(curr) {
+	// a new node that is far behind restores a snapshot of a recent layer, and syncs the layers that follow it
+	if s.SnapshotSync && s.snapshots != nil && !s.weaklySynced(curr) && s.ProcessedLayer() <= types.GetEffectiveGenesis() {
+		s.progress.setPhase(PhaseSnapshot)
+		s.syncSnapshot(curr)
+	}
+
+	// we have all the data of the prev layers so we can simply validate, unless the node stopped while fetching layers
+	if s.weaklySynced(curr) && !s.resuming() {
 		s.handleWeaklySynced()
 	} else {
 		s.handleNotSynced(s.ProcessedLayer() + 1)
@@ -379,6 +508,9 @@ func (s *Syncer) handleLayersTillCurrent() {
 	}
 
 	s.Info("handle layers %d to %d", s.ProcessedLayer()+1, s.GetCurrentLayer()-1)
+	if s.getGossipBufferingStatus() != done {
+		s.progress.syncLayers(s.ProcessedLayer())
+	}
 	for currentSyncLayer := s.ProcessedLayer() + 1; currentSyncLayer < s.GetCurrentLayer(); currentSyncLayer++ {
 		if s.shutdown() {
 			return
@@ -421,31 +553,52 @@ func (s *Syncer) handleCurrentLayer() error {
 func (s *Syncer) handleNotSynced(currentSyncLayer types.LayerID) {
 	s.Info("Node is out of sync setting gossip-synced to false and starting sync")
 	s.setGossipBufferingStatus(pending) // don't listen to gossip while not synced
-
-	// first, bring all the data of the prev layers
-	// Note: lastTicked() is not constant but updates as ticks are received
-	for ; currentSyncLayer < s.GetCurrentLayer(); currentSyncLayer++ {
-		s.With().Info("syncing layer", log.FieldNamed("current_sync_layer", currentSyncLayer),
-			log.FieldNamed("last_ticked_layer", s.GetCurrentLayer()))
-
-		if s.shutdown() {
-			return
+	s.progress.syncLayers(s.ProcessedLayer())
+
+	// first, bring all the data of the prev layers, fetching the next layers while validating the current one
+	// Note: lastTicked() is not constant but updates as ticks are received, the layers that ticked while the node
+	// synced are synced once it caught up with the previous ones
+	for currentSyncLayer < s.GetCurrentLayer() {
+		to := s.GetCurrentLayer()
+		fetch := s.getLayerFromNeighbors
+		if s.HeadersFirst {
+			if err := s.syncHeaders(currentSyncLayer, to); err != nil {
+				s.With().Info("could not get blocks from neighbors", log.Err(err))
+				return
+			}
+			s.progress.syncLayers(s.ProcessedLayer())
+			fetch = s.getLayerBodies
 		}
 
-		lyr, err := s.getLayerFromNeighbors(currentSyncLayer)
-		if err != nil {
-			s.With().Info("could not get layer from neighbors", currentSyncLayer, log.Err(err))
-			return
-		}
+		fetcher := newLayerFetcher(currentSyncLayer, s.FetchLayers, fetch, func() types.LayerID { return to })
+		fetcher.started = s.fetchingLayer
+		for ; currentSyncLayer < to; currentSyncLayer++ {
+			s.With().Info("syncing layer", log.FieldNamed("current_sync_layer", currentSyncLayer),
+				log.FieldNamed("last_ticked_layer", s.GetCurrentLayer()))
 
-		if len(lyr.Blocks()) == 0 {
-			if err := s.SetZeroBlockLayer(currentSyncLayer); err != nil {
-				s.With().Error("handleNotSynced failed ", currentSyncLayer, log.Err(err))
+			if s.shutdown() {
 				return
 			}
-		}
 
-		s.ValidateLayer(lyr) // wait for layer validation
+			lyr, err := fetcher.get(currentSyncLayer)
+			if err != nil {
+				s.With().Info("could not get layer from neighbors", currentSyncLayer, log.Err(err))
+				return
+			}
+
+			// empty layers synced headers first were set when their blocks were fetched
+			if len(lyr.Blocks()) == 0 && !s.HeadersFirst {
+				if err := s.SetZeroBlockLayer(currentSyncLayer); err != nil {
+					s.With().Error("handleNotSynced failed ", currentSyncLayer, log.Err(err))
+					return
+				}
+			}
+
+			s.ValidateLayer(lyr) // wait for layer validation
+			s.fetchedLayer(currentSyncLayer)
+			// the certificate only confirms the hare output, the layer is synced without it
+			s.queueCertificate(currentSyncLayer)
+		}
 	}
 
 	// if we are in the first epoch, we need to listen to gossip still
@@ -632,6 +785,11 @@ func (s *Syncer) fastValidation(block *types.Block) error {
 	if err := validateUniqueTxAtx(block); err != nil {
 		return err
 	}
+
+	// blocks of trusted layers must be part of their trusted history
+	if err := s.validateTrusted(block); err != nil {
+		return err
+	}
 	return nil
 
 }
@@ -902,6 +1060,10 @@ func (s *Syncer) fetchLayerBlockIds(m map[types.Hash32][]p2ppeers.Peer, lyr type
 
 					if h != res {
 						s.Warning("Peer: %v layer ids hash does not match request", peer)
+						if !s.matchesTrusted(lyr, res) {
+							s.ReportPeer(peer, service.InvalidMessage)
+							continue
+						}
 					}
 
 					for _, bid := range v.([]types.BlockID) {
@@ -921,6 +1083,13 @@ func (s *Syncer) fetchLayerBlockIds(m map[types.Hash32][]p2ppeers.Peer, lyr type
 		s.Info("could not get layer ids from any peer")
 	}
 
+	if trusted, ok := s.trustedHash(lyr); ok {
+		if types.CalcBlocksHash32(ids, nil) != trusted {
+			return nil, fmt.Errorf("could not get the trusted block ids of layer %v from any peer", lyr)
+		}
+		s.trustBlocks(lyr, ids)
+	}
+
 	return ids, nil
 }
 
@@ -989,6 +1158,11 @@ func (s *Syncer) fetchLayerHashes(lyr types.LayerID) (map[types.Hash32][]p2ppeer
 	for out := range wrk.output {
 		pair, ok := out.(*peerHashPair)
 		if pair != nil && ok { // do nothing on close channel
+			if !s.matchesTrusted(lyr, pair.hash) {
+				s.With().Warning("peer served a history that conflicts with the trusted layer", lyr, log.String("peer", pair.peer.String()))
+				s.ReportPeer(pair.peer, service.InvalidMessage)
+				continue
+			}
 			if pair.hash != emptyLayer {
 				layerHasBlocks = true
 				m[pair.hash] = append(m[pair.hash], pair.peer)
@@ -997,6 +1171,10 @@ func (s *Syncer) fetchLayerHashes(lyr types.LayerID) (map[types.Hash32][]p2ppeer
 		}
 	}
 
+	if trusted, ok := s.trustedHash(lyr); ok && trusted != emptyLayer && len(m) == 0 {
+		return nil, fmt.Errorf("no peer serves the trusted hash of layer %v", lyr)
+	}
+
 	if !layerHasBlocks {
 		s.Info("layer %d has no blocks", lyr)
 		return nil, errNoBlocksInLayer
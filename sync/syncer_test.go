@@ -33,7 +33,7 @@ import (
 	"github.com/spacemeshos/go-spacemesh/timesync"
 )
 
-var conf = Configuration{1000, 1, 300, 500 * time.Millisecond, 200 * time.Millisecond, 10 * time.Hour, 100, 5}
+var conf = Configuration{1000, 1, 300, 500 * time.Millisecond, 200 * time.Millisecond, 10 * time.Hour, 100, 5, false, 4, 2, 0, 0, true, nil, nil, 0, 0}
 
 func init() {
 	rand.Seed(time.Now().UnixNano())
@@ -1681,6 +1681,38 @@ func TestSyncer_fetchBlock(t *testing.T) {
 
 }
 
+func TestSyncer_fetchBlock_Checkpointed(t *testing.T) {
+	r := require.New(t)
+	syncs, _, _ := SyncMockFactory(2, conf, "fetchBlock_Checkpointed", memoryDB, newMemPoetDb)
+	s := syncs[0]
+	s.RestoreCheckpointLayer(10)
+
+	// blocks of layers covered by the checkpoint aren't validated, the blocks in their view are fetched down to hdist
+	// layers below the checkpoint
+	var blocks []*types.Block
+	var prev *types.Block
+	for layer := types.LayerID(3); layer <= 10; layer++ {
+		blk := types.NewExistingBlock(layer, []byte(rand.String(8)))
+		if prev != nil {
+			blk.ViewEdges = []types.BlockID{prev.ID()}
+		}
+		blk.Initialize()
+		r.NoError(syncs[1].AddBlock(blk))
+		blocks = append(blocks, blk)
+		prev = blk
+	}
+	r.True(s.fetchBlock(prev.ID()))
+	for _, blk := range blocks {
+		_, err := s.GetBlock(blk.ID())
+		if int(blk.Layer())+conf.Hdist < 10 {
+			r.Error(err, "block of layer %v", blk.Layer())
+		} else {
+			r.NoError(err, "block of layer %v", blk.Layer())
+		}
+	}
+	r.Equal(types.LayerID(10), s.LatestLayer())
+}
+
 func TestSyncer_AtxSetID(t *testing.T) {
 	a := atx("")
 	bbytes, _ := types.InterfaceToBytes(*a)
@@ -1730,6 +1762,7 @@ func TestSyncer_Await_LowLevel(t *testing.T) {
 		gossipSynced: pending,
 		awaitCh:      make(chan struct{}),
 		Log:          log.New("", "", ""),
+		progress:     &progress{},
 	}
 
 	ch := syncer.Await()
@@ -1772,3 +1805,26 @@ func closed(ch chan struct{}) bool {
 		return false
 	}
 }
+
+func TestBlocksAsItems_InvalidSignature(t *testing.T) {
+	r := require.New(t)
+	signer := signing.NewEdSigner()
+	blocks := make([]types.Block, 0, 3)
+	for i := 0; i < 3; i++ {
+		b := types.Block{MiniBlock: types.MiniBlock{BlockHeader: types.BlockHeader{LayerIndex: types.LayerID(i)}}}
+		b.Signature = signer.Sign(b.Bytes())
+		blocks = append(blocks, b)
+	}
+	// a malformed signature only drops its own block
+	blocks[1].Signature = blocks[1].Signature[:10]
+	msg, err := types.InterfaceToBytes(blocks)
+	r.NoError(err)
+	items, err := blocksAsItems(msg)
+	r.NoError(err)
+	r.Len(items, 2)
+	for i, it := range items {
+		blk := it.(*types.Block)
+		r.Equal(blocks[2*i].LayerIndex, blk.Layer())
+		r.Equal(signer.PublicKey().Bytes(), blk.MinerID().Bytes())
+	}
+}
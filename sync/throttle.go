@@ -0,0 +1,140 @@
+package sync
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/log"
+)
+
+var errThrottleClosed = errors.New("closed while waiting for the sync bandwidth")
+
+// Limits cap the bandwidth and the concurrency of sync, so a node that catches up in the background doesn't saturate
+// the connection it shares with its operator
+type Limits struct {
+	Bandwidth int // bytes per second received in responses to sync requests, 0 for no limit
+	Requests  int // max number of sync requests in flight to all peers, 0 for no limit
+}
+
+// throttle enforces the sync limits. The size of a response is only known once it arrives, so requests are sent as
+// long as the bandwidth budget isn't overdrawn, and every response is charged to the budget, which refills at the
+// bandwidth limit and holds at most a second of it. The limits can be changed while requests wait.
+type throttle struct {
+	timeout time.Duration
+
+	mu       sync.Mutex
+	limits   Limits
+	budget   float64   // bytes that can be received before requests wait, negative when overdrawn
+	refilled time.Time // when the budget was last refilled
+	inFlight int
+	changed  chan struct{} // closed when the limits change or a request completes
+}
+
+func newThrottle(limits Limits, timeout time.Duration) *throttle {
+	return &throttle{
+		timeout:  timeout,
+		limits:   limits,
+		budget:   float64(limits.Bandwidth),
+		refilled: time.Now(),
+		changed:  make(chan struct{}),
+	}
+}
+
+// notify wakes up the requests that wait, must be called with mu held
+func (t *throttle) notify() {
+	close(t.changed)
+	t.changed = make(chan struct{})
+}
+
+// refill must be called with mu held
+func (t *throttle) refill() {
+	now := time.Now()
+	if t.limits.Bandwidth > 0 {
+		t.budget += now.Sub(t.refilled).Seconds() * float64(t.limits.Bandwidth)
+		if t.budget > float64(t.limits.Bandwidth) {
+			t.budget = float64(t.limits.Bandwidth)
+		}
+	}
+	t.refilled = now
+}
+
+// acquire blocks until a request can be sent, and returns the func to call with the size of its response. A request
+// that isn't answered in time is released without a charge. The done func may be called more than once.
+func (t *throttle) acquire(exit chan struct{}) (func(n int), error) {
+	for {
+		t.mu.Lock()
+		t.refill()
+		requestsOK := t.limits.Requests == 0 || t.inFlight < t.limits.Requests
+		bandwidthOK := t.limits.Bandwidth == 0 || t.budget >= 0
+		if requestsOK && bandwidthOK {
+			t.inFlight++
+			t.mu.Unlock()
+			break
+		}
+		// requests over the concurrency limit wait for one to complete, others for the budget to refill
+		wait := t.timeout
+		if requestsOK {
+			wait = time.Duration(-t.budget / float64(t.limits.Bandwidth) * float64(time.Second))
+		}
+		changed := t.changed
+		t.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-changed:
+		case <-exit:
+			timer.Stop()
+			return nil, errThrottleClosed
+		}
+		timer.Stop()
+	}
+
+	once := sync.Once{}
+	done := func(n int) {
+		once.Do(func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.refill()
+			t.inFlight--
+			if t.limits.Bandwidth > 0 {
+				t.budget -= float64(n)
+			}
+			t.notify()
+		})
+	}
+	time.AfterFunc(t.timeout, func() { done(0) })
+	return done, nil
+}
+
+// setLimits changes the limits, requests that wait are sent when the new limits allow them
+func (t *throttle) setLimits(limits Limits) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.refill()
+	if limits.Bandwidth == 0 || t.budget > float64(limits.Bandwidth) {
+		t.budget = float64(limits.Bandwidth)
+	}
+	t.limits = limits
+	t.notify()
+}
+
+func (t *throttle) getLimits() Limits {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.limits
+}
+
+// SetSyncLimits changes the bandwidth and concurrency limits of sync while the node runs
+func (s *Syncer) SetSyncLimits(limits Limits) {
+	s.With().Info("sync limits changed",
+		log.Int("bandwidth", limits.Bandwidth),
+		log.Int("requests", limits.Requests))
+	s.throttle.setLimits(limits)
+}
+
+// SyncLimits returns the bandwidth and concurrency limits of sync
+func (s *Syncer) SyncLimits() Limits {
+	return s.throttle.getLimits()
+}
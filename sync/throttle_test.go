@@ -0,0 +1,89 @@
+package sync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestThrottle_Requests(t *testing.T) {
+	r := require.New(t)
+	th := newThrottle(Limits{Requests: 2}, time.Minute)
+	exit := make(chan struct{})
+
+	done1, err := th.acquire(exit)
+	r.NoError(err)
+	_, err = th.acquire(exit)
+	r.NoError(err)
+
+	acquired := make(chan struct{})
+	go func() {
+		_, err := th.acquire(exit)
+		r.NoError(err)
+		close(acquired)
+	}()
+	select {
+	case <-acquired:
+		r.Fail("acquired over the limit")
+	case <-time.After(50 * time.Millisecond):
+	}
+	done1(100)
+	done1(100) // released once
+	<-acquired
+
+	// lifting the limit lets waiting requests through
+	go func() {
+		_, err := th.acquire(exit)
+		r.NoError(err)
+		close(exit)
+	}()
+	th.setLimits(Limits{})
+	<-exit
+	r.Equal(Limits{}, th.getLimits())
+}
+
+func TestThrottle_Bandwidth(t *testing.T) {
+	r := require.New(t)
+	th := newThrottle(Limits{Bandwidth: 10000}, time.Minute)
+	exit := make(chan struct{})
+
+	// a response larger than the budget overdraws it, and the next request waits until it's refilled
+	done, err := th.acquire(exit)
+	r.NoError(err)
+	done(15000)
+	start := time.Now()
+	done, err = th.acquire(exit)
+	r.NoError(err)
+	r.True(time.Since(start) > 400*time.Millisecond)
+	done(100000)
+
+	// requests that wait for the budget are sent when the limit is lifted
+	acquired := make(chan struct{})
+	go func() {
+		_, err := th.acquire(exit)
+		r.NoError(err)
+		close(acquired)
+	}()
+	select {
+	case <-acquired:
+		r.Fail("acquired over the bandwidth")
+	case <-time.After(50 * time.Millisecond):
+	}
+	th.setLimits(Limits{Bandwidth: 0})
+	<-acquired
+
+	// and unanswered requests are released without a charge
+	th = newThrottle(Limits{Bandwidth: 10000, Requests: 1}, 50*time.Millisecond)
+	_, err = th.acquire(exit)
+	r.NoError(err)
+	_, err = th.acquire(exit)
+	r.NoError(err)
+
+	th = newThrottle(Limits{Requests: 1}, time.Minute)
+	_, err = th.acquire(exit)
+	r.NoError(err)
+	close(exit)
+	_, err = th.acquire(exit)
+	r.Equal(errThrottleClosed, err)
+}
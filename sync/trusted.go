@@ -0,0 +1,79 @@
+package sync
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+// A new node that syncs from genesis can't tell the history of the network from a fake history built with old keys, so
+// the operator may give it trusted layers, the hashes of their block IDs taken from a source it trusts. Sync only accepts
+// the blocks of a trusted layer whose IDs hash to the trusted hash, and reports peers that serve another history of it.
+
+var errUntrustedBlock = errors.New("block isn't part of the trusted history of its layer")
+
+// ParseTrustedLayers parses trusted layers given as layer:hash, where hash is the hex encoded hash of the block IDs of
+// the layer, as peers serve it
+func ParseTrustedLayers(layers []string) (map[types.LayerID]types.Hash32, error) {
+	trusted := make(map[types.LayerID]types.Hash32, len(layers))
+	for _, l := range layers {
+		parts := strings.Split(l, ":")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("trusted layer must be layer:hash, got %q", l)
+		}
+		layer, err := strconv.ParseUint(parts[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid layer of trusted layer %q: %v", l, err)
+		}
+		hash, err := hex.DecodeString(strings.TrimPrefix(parts[1], "0x"))
+		if err != nil || len(hash) != types.Hash32Length {
+			return nil, fmt.Errorf("hash of trusted layer %q must be %d bytes in hex", l, types.Hash32Length)
+		}
+		if h, ok := trusted[types.LayerID(layer)]; ok && h != types.BytesToHash(hash) {
+			return nil, fmt.Errorf("conflicting hashes for trusted layer %d", layer)
+		}
+		trusted[types.LayerID(layer)] = types.BytesToHash(hash)
+	}
+	return trusted, nil
+}
+
+// trustedHash returns the trusted hash of the block IDs of the layer, if it's trusted
+func (s *Syncer) trustedHash(layer types.LayerID) (types.Hash32, bool) {
+	h, ok := s.TrustedLayers[layer]
+	return h, ok
+}
+
+// trustBlocks records the blocks of a trusted layer, once their IDs were found to match the trusted hash
+func (s *Syncer) trustBlocks(layer types.LayerID, ids []types.BlockID) {
+	s.trustedMutex.Lock()
+	defer s.trustedMutex.Unlock()
+	set := make(map[types.BlockID]struct{}, len(ids))
+	for _, id := range ids {
+		set[id] = struct{}{}
+	}
+	s.trusted[layer] = set
+}
+
+// validateTrusted returns an error if the block is of a trusted layer but isn't one of the blocks it was synced with
+func (s *Syncer) validateTrusted(block *types.Block) error {
+	if _, ok := s.trustedHash(block.Layer()); !ok {
+		return nil
+	}
+	s.trustedMutex.Lock()
+	defer s.trustedMutex.Unlock()
+	if _, ok := s.trusted[block.Layer()][block.ID()]; !ok {
+		s.With().Warning("rejected block of trusted layer", block.ID(), block.Layer())
+		return errUntrustedBlock
+	}
+	return nil
+}
+
+// matchesTrusted returns false if the layer is trusted and the hash isn't its trusted hash
+func (s *Syncer) matchesTrusted(layer types.LayerID, hash types.Hash32) bool {
+	trusted, ok := s.trustedHash(layer)
+	return !ok || trusted == hash
+}
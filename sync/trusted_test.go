@@ -0,0 +1,71 @@
+package sync
+
+import (
+	"testing"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/common/util"
+	p2ppeers "github.com/spacemeshos/go-spacemesh/p2p/peers"
+	"github.com/spacemeshos/go-spacemesh/rand"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTrustedLayers(t *testing.T) {
+	r := require.New(t)
+	hash := types.CalcHash32([]byte("layer"))
+
+	trusted, err := ParseTrustedLayers([]string{"5:" + hash.Hex(), "7:" + util.Bytes2Hex(hash.Bytes())})
+	r.NoError(err)
+	r.Equal(map[types.LayerID]types.Hash32{5: hash, 7: hash}, trusted)
+
+	trusted, err = ParseTrustedLayers(nil)
+	r.NoError(err)
+	r.Empty(trusted)
+
+	for _, invalid := range [][]string{
+		{"5"},
+		{"x:" + hash.Hex()},
+		{"5:0x1234"},
+		{"5:" + hash.Hex(), "5:" + types.CalcHash32([]byte("other")).Hex()},
+	} {
+		_, err := ParseTrustedLayers(invalid)
+		r.Error(err, invalid)
+	}
+}
+
+func TestSyncer_TrustedLayers(t *testing.T) {
+	r := require.New(t)
+	syncs, nodes, _ := SyncMockFactory(3, conf, t.Name(), memoryDB, newMockPoetDb)
+	honest, fake, client := syncs[0], syncs[1], syncs[2]
+	defer honest.Close()
+	defer fake.Close()
+	defer client.Close()
+	client.peers = getPeersMock([]p2ppeers.Peer{nodes[0].PublicKey(), nodes[1].PublicKey()})
+
+	gen := types.GetEffectiveGenesis()
+	block1 := types.NewExistingBlock(gen+1, []byte(rand.String(8)))
+	block2 := types.NewExistingBlock(gen+1, []byte(rand.String(8)))
+	r.NoError(honest.AddBlock(block1))
+	r.NoError(fake.AddBlock(block2))
+	hash := types.CalcBlocksHash32([]types.BlockID{block1.ID()}, nil)
+	client.TrustedLayers = map[types.LayerID]types.Hash32{gen + 1: hash, gen + 2: types.CalcHash32([]byte("missing"))}
+
+	// only the peer that serves the trusted history of the layer is asked for its blocks
+	m, err := client.fetchLayerHashes(gen + 1)
+	r.NoError(err)
+	r.Equal(map[types.Hash32][]p2ppeers.Peer{hash: {nodes[0].PublicKey()}}, m)
+	ids, err := client.fetchLayerBlockIds(map[types.Hash32][]p2ppeers.Peer{hash: {nodes[1].PublicKey(), nodes[0].PublicKey()}}, gen+1)
+	r.NoError(err)
+	r.Equal([]types.BlockID{block1.ID()}, ids)
+
+	// blocks of the layer that aren't part of its trusted history are rejected
+	r.NoError(client.validateTrusted(block1))
+	r.Equal(errUntrustedBlock, client.validateTrusted(block2))
+	r.NoError(client.validateTrusted(types.NewExistingBlock(gen+3, []byte(rand.String(8)))))
+
+	// the layer isn't synced if no peer serves its trusted history
+	_, err = client.fetchLayerHashes(gen + 2)
+	r.Error(err)
+	_, err = client.fetchLayerBlockIds(map[types.Hash32][]p2ppeers.Peer{hash: {nodes[1].PublicKey()}}, gen+1)
+	r.Error(err)
+}
@@ -15,7 +15,10 @@ type syncer interface {
 	ForBlockInView(view map[types.BlockID]struct{}, layer types.LayerID, blockHandler func(block *types.Block) (bool, error)) error
 	HandleLateBlock(bl *types.Block)
 	ProcessedLayer() types.LayerID
+	CheckpointLayer() types.LayerID
+	AddCheckpointedBlock(blk *types.Block) error
 	dataAvailability(blk *types.Block) ([]*types.Transaction, []*types.ActivationTx, error)
+	bodyDeferred(layer types.LayerID) bool
 	getValidatingLayer() types.LayerID
 	fastValidation(block *types.Block) error
 	blockCheckLocal(blockIds []types.Hash32) (map[types.Hash32]item, map[types.Hash32]item, []types.Hash32)
@@ -87,6 +90,10 @@ func (vq *blockQueue) handleBlocks(bjb fetchJob) {
 
 func (vq *blockQueue) handleBlock(id types.Hash32, block *types.Block) {
 	vq.With().Info("start handling", block.ID(), block.MinerID())
+	if block.Layer() <= vq.CheckpointLayer() {
+		vq.handleCheckpointedBlock(id, block)
+		return
+	}
 	if err := vq.fetchBlockDataForValidation(block); err != nil {
 		vq.Error("block fetching data failed", block.ID(), log.Err(err))
 		vq.updateDependencies(id, false)
@@ -101,6 +108,30 @@ func (vq *blockQueue) handleBlock(id types.Hash32, block *types.Block) {
 	vq.handleBlockDependencies(block)
 }
 
+// handles a block of a layer covered by the checkpoint the mesh was restored from. the block isn't validated, the
+// checkpoint is trusted, but it's stored along with the blocks in its view that the blocks following the checkpoint
+// can still vote on, so that their votes can be validated
+func (vq *blockQueue) handleCheckpointedBlock(id types.Hash32, block *types.Block) {
+	if err := vq.AddCheckpointedBlock(block); err != nil {
+		vq.With().Error("failed to store checkpointed block", block.ID(), log.Err(err))
+		vq.updateDependencies(id, false)
+		return
+	}
+	if int(block.Layer())+vq.Hdist <= int(vq.CheckpointLayer()) {
+		vq.updateDependencies(id, true)
+		return
+	}
+	res, err := vq.addDependencies(block.ID(), block.ViewEdges, func(res bool) error { return nil })
+	if err != nil {
+		vq.updateDependencies(id, false)
+		vq.With().Error("failed to add dependencies", block.ID(), log.Err(err))
+		return
+	}
+	if res == false {
+		vq.updateDependencies(id, true)
+	}
+}
+
 // handles new block dependencies
 // if there are unknown blocks in the view they are added to the fetch queue
 func (vq *blockQueue) handleBlockDependencies(blk *types.Block) {
@@ -127,10 +158,15 @@ func (vq *blockQueue) finishBlockCallback(block *types.Block) func(res bool) err
 			return nil
 		}
 
-		// data availability
-		txs, atxs, err := vq.dataAvailability(block)
-		if err != nil {
-			return fmt.Errorf("DataAvailabilty failed for block: %v errmsg: %v", block.ID().String(), err)
+		// data availability, the transactions of blocks synced headers first are fetched later
+		var txs []*types.Transaction
+		var atxs []*types.ActivationTx
+		var err error
+		if !vq.bodyDeferred(block.Layer()) {
+			txs, atxs, err = vq.dataAvailability(block)
+			if err != nil {
+				return fmt.Errorf("DataAvailabilty failed for block: %v errmsg: %v", block.ID().String(), err)
+			}
 		}
 
 		// validate block's votes
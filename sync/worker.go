@@ -10,6 +10,7 @@ import (
 	"github.com/spacemeshos/go-spacemesh/p2p/p2pcrypto"
 	p2ppeers "github.com/spacemeshos/go-spacemesh/p2p/peers"
 	"github.com/spacemeshos/go-spacemesh/p2p/server"
+	"github.com/spacemeshos/go-spacemesh/p2p/service"
 )
 
 type requestFactory func(com networker, peer p2ppeers.Peer) (chan interface{}, error)
@@ -20,6 +21,7 @@ type networker interface {
 	SendRequest(msgType server.MessageType, payload []byte, address p2pcrypto.PublicKey, resHandler func(msg []byte)) error
 	GetTimeout() time.Duration
 	GetExit() chan struct{}
+	ReportPeer(peer p2ppeers.Peer, event service.PeerEvent)
 	log.Logger
 }
 
@@ -77,10 +79,12 @@ func newPeersWorker(s networker, peers []p2ppeers.Peer, mu *sync.Once, reqFactor
 				return
 			case <-timeout:
 				lg.Error("request to %v timed out", peer)
+				s.ReportPeer(peer, service.RequestTimeout)
 				return
 			case v := <-ch:
 				if v != nil {
 					lg.Debug("Peer: %v responded", peer)
+					s.ReportPeer(peer, service.UsefulResponse)
 					output <- v
 				}
 			}
@@ -118,9 +122,11 @@ func newNeighborhoodWorker(s networker, count int, reqFactory requestFactory) wo
 				return
 			case <-timeout:
 				lg.Error("request to %v timed out", peer)
+				s.ReportPeer(peer, service.RequestTimeout)
 			case v := <-ch:
 				if v != nil {
 					lg.Info("Peer: %v responded ", peer)
+					s.ReportPeer(peer, service.UsefulResponse)
 					lg.Debug("Peer: %v response was  %v", v)
 					output <- v
 					return
@@ -166,12 +172,14 @@ func newFetchWorker(s networker, count int, reqFactory batchRequestFactory, idsC
 						log.String("type", name),
 						peer.Field("peer_id"),
 						log.String("ids", idsStr))
+					s.ReportPeer(peer, service.RequestTimeout)
 				case v := <-ch:
 					if v != nil && len(v) > 0 {
 						lg.With().Info("peer responded to fetch request",
 							log.String("type", name),
 							peer.Field("peer_id"),
 							log.String("ids", idsStr))
+						s.ReportPeer(peer, service.UsefulResponse)
 						// 	remove ids from leftToFetch add to fetched
 						for _, itm := range v {
 							fetched = append(fetched, itm)
@@ -11,7 +11,9 @@ import (
 
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/p2p/p2pcrypto"
 	p2ppeers "github.com/spacemeshos/go-spacemesh/p2p/peers"
+	"github.com/spacemeshos/go-spacemesh/p2p/service"
 	"github.com/spacemeshos/go-spacemesh/rand"
 )
 
@@ -61,7 +63,45 @@ func TestNewNeighborhoodWorker(t *testing.T) {
 	r.NoError(err)
 }
 
-var longConf = Configuration{1000, 1, 300, 5 * time.Minute, 1 * time.Second, 10 * time.Hour, 100, 5}
+type scorerMock struct {
+	mu     sync.Mutex
+	events map[p2pcrypto.PublicKey][]service.PeerEvent
+}
+
+func (s *scorerMock) ReportPeer(peer p2pcrypto.PublicKey, event service.PeerEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events[peer] = append(s.events[peer], event)
+}
+
+func (s *scorerMock) PeerScore(peer p2pcrypto.PublicKey) float64 {
+	return 0
+}
+
+func (s *scorerMock) peerEvents(peer p2pcrypto.PublicKey) []service.PeerEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.events[peer]
+}
+
+func TestPeerWorker_ReportsPeers(t *testing.T) {
+	syncs, nodes, _ := SyncMockFactory(2, conf, "TestPeerWorker_ReportsPeers", memoryDB, newMockPoetDb)
+	syncObj1 := syncs[0]
+	defer syncObj1.Close()
+	syncObj2 := syncs[1]
+	defer syncObj2.Close()
+	scorer := &scorerMock{events: make(map[p2pcrypto.PublicKey][]service.PeerEvent)}
+	syncObj2.scorer = scorer
+	bl1 := types.NewExistingBlock(types.GetEffectiveGenesis()+1, []byte(rand.String(8)))
+	require.NoError(t, syncObj1.AddBlock(bl1))
+
+	wrk := newPeersWorker(syncObj2, []p2ppeers.Peer{nodes[0].PublicKey()}, &sync.Once{}, layerIdsReqFactory(types.GetEffectiveGenesis()+1))
+	go wrk.Work()
+	require.NotNil(t, <-wrk.output)
+	require.Equal(t, []service.PeerEvent{service.UsefulResponse}, scorer.peerEvents(nodes[0].PublicKey()))
+}
+
+var longConf = Configuration{1000, 1, 300, 5 * time.Minute, 1 * time.Second, 10 * time.Hour, 100, 5, false, 1, 0, 0, 0, false, nil, nil, 0, 0}
 
 func TestNeighborhoodWorkerClose(t *testing.T) {
 	r := require.New(t)
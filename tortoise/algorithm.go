@@ -1,6 +1,9 @@
 package tortoise
 
 import (
+	"fmt"
+	"sync"
+
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/log"
 	"github.com/spacemeshos/go-spacemesh/mesh"
@@ -12,21 +15,75 @@ type Tortoise interface {
 	HandleIncomingLayer(ll *types.Layer) (types.LayerID, types.LayerID)
 	LatestComplete() types.LayerID
 	Persist() error
+	LayerOpinion(layer types.LayerID) map[types.BlockID]BlockOpinion
+	Rerun() (bool, error)
+	Snapshot() error
+}
+
+// Opinion is the vote of the tortoise on the contextual validity of a block
+type Opinion int
+
+// These are the possible votes of the tortoise
+const (
+	// Abstain means that the votes for the block didn't pass the threshold in either direction
+	Abstain Opinion = iota
+	// Support means that the block is contextually valid
+	Support
+	// Against means that the block is contextually invalid
+	Against
+)
+
+// BlockOpinion is the opinion of the tortoise on a block along with the tally of votes it's based on
+type BlockOpinion struct {
+	Opinion Opinion
+	// Support and Against are the weighted votes for and against the block
+	Support int
+	Against int
 }
 
+// snapshotInterval is the number of layers between snapshots of the state of the tortoise. Its opinion is saved on every
+// layer, and the layers processed after the latest snapshot are processed again when the node restarts.
+const snapshotInterval = 10
+
 type tortoise struct {
+	mutex sync.Mutex
 	*ninjaTortoise
+	snapshotLayer types.LayerID // the last layer processed when the state was snapshotted
+	lateBlocks    bool          // whether late blocks were processed since the latest snapshot
+	reported      types.LayerID // the pbase reported to the mesh before a rerun switched the opinion, 0 if none did
+}
+
+// ValidateWindow checks that the memory window of the tortoise covers the layers that blocks vote on explicitly and the
+// layers it counts votes on, 0 doesn't bound the memory
+func ValidateWindow(memWindow, hdist int) error {
+	if memWindow == 0 {
+		return nil
+	}
+	if memWindow <= hdist || memWindow <= window {
+		return fmt.Errorf("tortoise window (%d) must be greater than hdist (%d) and %d", memWindow, hdist, window)
+	}
+	return nil
 }
 
-// NewTortoise returns a new Tortoise instance
-func NewTortoise(layerSize int, mdb *mesh.DB, hdist int, lg log.Log) Tortoise {
-	alg := &tortoise{ninjaTortoise: newNinjaTortoise(layerSize, mdb, hdist, lg)}
+// ValidateReorgWindow checks that the reorg window covers the layers the tortoise revises as it verifies new layers
+func ValidateReorgWindow(reorgWindow, hdist int) error {
+	if reorgWindow < hdist {
+		return fmt.Errorf("reorg window (%d) must be at least hdist (%d)", reorgWindow, hdist)
+	}
+	return nil
+}
+
+// NewTortoise returns a new Tortoise instance that keeps at most window layers in memory, 0 for no bound
+func NewTortoise(layerSize int, mdb *mesh.DB, hdist int, window int, lg log.Log) Tortoise {
+	ni := newNinjaTortoise(layerSize, mdb, hdist, lg)
+	ni.memWindow = types.LayerID(window)
+	alg := &tortoise{ninjaTortoise: ni}
 	alg.HandleIncomingLayer(mesh.GenesisLayer())
 	return alg
 }
 
 // NewRecoveredTortoise recovers a previously persisted tortoise copy from mesh.DB
-func NewRecoveredTortoise(mdb *mesh.DB, lg log.Log) Tortoise {
+func NewRecoveredTortoise(mdb *mesh.DB, window int, lg log.Log) Tortoise {
 	tmp, err := RecoverTortoise(mdb)
 	if err != nil {
 		lg.Panic("could not recover tortoise state from disc ", err)
@@ -37,8 +94,19 @@ func NewRecoveredTortoise(mdb *mesh.DB, lg log.Log) Tortoise {
 	lg.Info("recovered tortoise from disc")
 	trtl.db = mdb
 	trtl.logger = lg
+	trtl.memWindow = types.LayerID(window)
+	snapshotLayer := trtl.Last
+
+	// process again the layers that were processed after the snapshot
+	if processed, err := mdb.RecoverProcessedLayer(); err == nil && processed > trtl.Last {
+		lg.With().Info("processing layers processed after the tortoise snapshot",
+			log.FieldNamed("from", trtl.Last+1), log.FieldNamed("to", processed))
+		if _, err := trtl.feedLayers(trtl.Last+1, processed); err != nil {
+			lg.With().Error("could not process layers processed after the tortoise snapshot", log.Err(err))
+		}
+	}
 
-	return &tortoise{ninjaTortoise: trtl}
+	return &tortoise{ninjaTortoise: trtl, snapshotLayer: snapshotLayer}
 }
 
 // HandleLateBlock processes a late blocks votes (for late block definition see white paper)
@@ -48,16 +116,43 @@ func (trtl *tortoise) HandleLateBlock(b *types.Block) (types.LayerID, types.Laye
 	l := types.NewLayer(b.Layer())
 	l.AddBlock(b)
 	oldPbase, newPbase := trtl.HandleIncomingLayer(l)
+	trtl.mutex.Lock()
+	trtl.lateBlocks = true
+	trtl.mutex.Unlock()
 	log.With().Info("late block", b.Layer(), b.ID())
 	return oldPbase, newPbase
 }
 
-// Persist saves a copy of the current tortoise state to the database
+// Persist saves the opinion of the tortoise to the database, and a snapshot of its state every snapshotInterval layers
+// or after late blocks, which aren't processed again on restart
 func (trtl *tortoise) Persist() error {
 	trtl.mutex.Lock()
 	defer trtl.mutex.Unlock()
-	log.Info("persist tortoise ")
-	return trtl.ninjaTortoise.persist()
+	if err := trtl.saveOpinion(); err != nil {
+		return err
+	}
+	if !trtl.lateBlocks && trtl.Last < trtl.snapshotLayer+snapshotInterval {
+		return nil
+	}
+	return trtl.snapshot()
+}
+
+// Snapshot saves the state of the tortoise to the database, so that a restarted node doesn't process the layers again
+func (trtl *tortoise) Snapshot() error {
+	trtl.mutex.Lock()
+	defer trtl.mutex.Unlock()
+	return trtl.snapshot()
+}
+
+// snapshot saves the state of the tortoise, trtl.mutex must be held
+func (trtl *tortoise) snapshot() error {
+	if err := trtl.db.Persist(mesh.TORTOISE, trtl.ninjaTortoise); err != nil {
+		return err
+	}
+	trtl.logger.With().Info("persisted tortoise state", log.FieldNamed("last_layer", trtl.Last), log.FieldNamed("pbase", trtl.latestComplete()))
+	trtl.snapshotLayer = trtl.Last
+	trtl.lateBlocks = false
+	return nil
 }
 
 // HandleIncomingLayer processes all layer block votes
@@ -66,17 +161,102 @@ func (trtl *tortoise) HandleIncomingLayer(ll *types.Layer) (types.LayerID, types
 	trtl.mutex.Lock()
 	defer trtl.mutex.Unlock()
 	oldPbase := trtl.latestComplete()
+	if trtl.reported != 0 {
+		// the mesh applied the layers up to the pbase it was reported, not up to the pbase of the switched opinion
+		oldPbase = trtl.reported
+	}
 	trtl.ninjaTortoise.handleIncomingLayer(ll)
 	newPbase := trtl.latestComplete()
+	if trtl.reported != 0 {
+		if newPbase < trtl.reported {
+			newPbase = trtl.reported
+		} else {
+			trtl.reported = 0
+		}
+	}
 	updateMetrics(trtl, ll)
 	return oldPbase, newPbase
 }
 
 // LatestComplete returns the latest complete (a.k.a irreversible) layer
 func (trtl *tortoise) LatestComplete() types.LayerID {
+	trtl.mutex.Lock()
+	defer trtl.mutex.Unlock()
 	return trtl.latestComplete()
 }
 
+// LayerOpinion returns the opinion on the blocks of a layer according to the latest good pattern that voted on it.
+// Layers that aren't covered by a good pattern, because they are too recent or were evicted, have no opinion.
+func (trtl *tortoise) LayerOpinion(layer types.LayerID) map[types.BlockID]BlockOpinion {
+	trtl.mutex.Lock()
+	defer trtl.mutex.Unlock()
+	opinions := make(map[types.BlockID]BlockOpinion)
+	if trtl.PBase == zeroPattern || layer >= trtl.PBase.Layer() {
+		return opinions
+	}
+	if layer < trtl.Evict {
+		return trtl.evictedLayerOpinion(layer)
+	}
+	// patterns vote on the layers between the complete pattern preceding them and their own layer
+	p := trtl.PBase
+	for l := trtl.PBase.Layer() - 1; l > layer; l-- {
+		if trtl.votesOnLayer(p, layer) {
+			break
+		}
+		good, found := trtl.TGood[l]
+		if !found {
+			break
+		}
+		p = good
+	}
+	for blt, vote := range trtl.TVote[p] {
+		if blt.layer() != layer {
+			continue
+		}
+		tally := trtl.TTally[p][blt]
+		opinion := BlockOpinion{Opinion: Abstain, Support: tally[0], Against: tally[1]}
+		switch vote {
+		case support:
+			opinion.Opinion = Support
+		case against:
+			opinion.Opinion = Against
+		}
+		opinions[blt.id()] = opinion
+	}
+	return opinions
+}
+
+// evictedLayerOpinion returns the opinion on the blocks of a layer that was evicted from memory, as persisted in their
+// contextual validity. The tally of votes isn't kept.
+func (trtl *tortoise) evictedLayerOpinion(layer types.LayerID) map[types.BlockID]BlockOpinion {
+	opinions := make(map[types.BlockID]BlockOpinion)
+	ids, err := trtl.db.LayerBlockIds(layer)
+	if err != nil {
+		return opinions
+	}
+	for _, id := range ids {
+		valid, err := trtl.db.ContextualValidity(id)
+		if err != nil {
+			continue
+		}
+		opinion := BlockOpinion{Opinion: Against}
+		if valid {
+			opinion.Opinion = Support
+		}
+		opinions[id] = opinion
+	}
+	return opinions
+}
+
+func (trtl *tortoise) votesOnLayer(p votingPattern, layer types.LayerID) bool {
+	for blt := range trtl.TVote[p] {
+		if blt.layer() == layer {
+			return true
+		}
+	}
+	return false
+}
+
 func updateMetrics(alg *tortoise, ll *types.Layer) {
 	pbaseCount.Set(float64(alg.latestComplete()))
 	processedCount.Set(float64(ll.Index()))
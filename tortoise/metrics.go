@@ -11,6 +11,10 @@ const (
 	subsystem = "consensus"
 )
 
+func newCounter(name, help string, labels []string) metrics.Counter {
+	return prmkit.NewCounterFrom(prometheus.CounterOpts{Namespace: namespace, Subsystem: subsystem, Name: name, Help: help}, labels)
+}
+
 func newGauge(name, help string, labels []string) metrics.Gauge {
 	return prmkit.NewGaugeFrom(prometheus.GaugeOpts{Namespace: namespace, Subsystem: subsystem, Name: name, Help: help}, labels)
 }
@@ -21,4 +25,8 @@ var (
 	blockVotes     = newGauge("block_votes", "block validity", []string{"validity"})
 	validBlocks    = blockVotes.With("validity", "valid")
 	invalidBlocks  = blockVotes.With("validity", "invalid")
+	rerunCount     = newCounter("tortoise_reruns", "tortoise reruns by result", []string{"result"})
+	rerunDuration  = newGauge("tortoise_rerun_seconds", "duration of the latest tortoise rerun", []string{})
+
+	refusedRevisions = newCounter("tortoise_refused_revisions", "opinions on blocks that weren't saved since they revise their validity beyond the reorg window or after automatic reorgs halted", []string{})
 )
@@ -78,6 +78,8 @@ type database interface {
 	LayerBlockIds(id types.LayerID) ([]types.BlockID, error)
 	ForBlockInView(view map[types.BlockID]struct{}, layer types.LayerID, foo func(block *types.Block) (bool, error)) error
 	SaveContextualValidity(id types.BlockID, valid bool) error
+	ContextualValidity(id types.BlockID) (bool, error)
+	FinalizedLayer() types.LayerID
 	Persist(key []byte, v interface{}) error
 	Retrieve(key []byte, v interface{}) (interface{}, error)
 }
@@ -85,7 +87,8 @@ type database interface {
 type ninjaTortoise struct {
 	db           database // block cache
 	logger       log.Log
-	mutex        sync.Mutex
+	memWindow    types.LayerID // the number of layers kept in memory below the last layer, 0 for no bound
+	reorgHalted  bool          // whether a revision beyond the reorg window was refused, which stops revising validity
 	Last         types.LayerID
 	Hdist        types.LayerID
 	Evict        types.LayerID
@@ -136,9 +139,19 @@ func newNinjaTortoise(layerSize int, blocks database, hdist int, log log.Log) *n
 	return trtl
 }
 
+// saveOpinion persists the opinion on the blocks below the pbase as their contextual validity. Revising the validity of
+// the blocks of finalized layers, which are beyond the reorg window, is refused, and stops revising the validity of any
+// block until the node restarts.
 func (ni *ninjaTortoise) saveOpinion() error {
+	finalized := ni.db.FinalizedLayer()
 	for b, vec := range ni.TVote[ni.PBase] {
 		valid := vec == support
+		if ni.reorgHalted || b.layer() <= finalized {
+			if saved, err := ni.db.ContextualValidity(b.id()); err == nil && saved != valid {
+				ni.refuseRevision(b, saved, finalized)
+				continue
+			}
+		}
 		if err := ni.db.SaveContextualValidity(b.id(), valid); err != nil {
 			return err
 		}
@@ -151,12 +164,24 @@ func (ni *ninjaTortoise) saveOpinion() error {
 	return nil
 }
 
-// Persist saves the current tortoise state to the database
-func (ni *ninjaTortoise) persist() error {
-	if err := ni.saveOpinion(); err != nil {
-		return err
+// refuseRevision keeps the saved validity of a block. The first refusal halts automatic reorgs and raises a critical
+// event, the operator has to find out which history the node should follow.
+func (ni *ninjaTortoise) refuseRevision(b blockIDLayerTuple, saved bool, finalized types.LayerID) {
+	refusedRevisions.Add(1)
+	if ni.reorgHalted {
+		ni.logger.With().Warning("automatic reorgs are halted, not revising the validity of block",
+			b.id(), b.layer(), log.Bool("valid", saved))
+		return
 	}
-	return ni.db.Persist(mesh.TORTOISE, ni)
+	ni.reorgHalted = true
+	ni.logger.With().Error("refusing to revise the validity of a block beyond the reorg window, halting automatic reorgs",
+		b.id(), b.layer(), log.Bool("valid", saved), log.FieldNamed("finalized_layer", finalized))
+	events.Publish(events.NodeEvent{
+		Kind:  events.NodeEventReorgRejected,
+		Layer: b.layer(),
+		Details: fmt.Sprintf("the tortoise revises the validity of block %v of finalized layer %v, automatic reorgs are halted until the node restarts",
+			b.id(), b.layer()),
+	})
 }
 
 // RecoverTortoise retrieve latest saved tortoise from the database
@@ -164,18 +189,36 @@ func RecoverTortoise(mdb database) (interface{}, error) {
 	return mdb.Retrieve(mesh.TORTOISE, &ninjaTortoise{})
 }
 
+// evictOutOfPbase drops the bookkeeping of the layers more than hdist below the pbase, and of the layers more than the
+// memory window below the last layer if the pbase lags behind. Only layers below the pbase are evicted: their opinions
+// are kept in the votes of the pbase and persisted as the contextual validity of their blocks, while the layers above it
+// are needed to advance it.
 func (ni *ninjaTortoise) evictOutOfPbase() {
 	wg := sync.WaitGroup{}
-	if ni.PBase == zeroPattern || ni.PBase.Layer() <= ni.Hdist {
+	var window types.LayerID
+	if ni.PBase != zeroPattern && ni.PBase.Layer() > ni.Hdist {
+		window = ni.PBase.Layer() - ni.Hdist
+	}
+	if ni.memWindow > 0 && ni.Last > ni.memWindow && ni.Last-ni.memWindow > window {
+		window = ni.Last - ni.memWindow
+		if window > ni.PBase.Layer() {
+			ni.logger.With().Warning("pbase is behind the memory window, keeping the layers above it",
+				log.FieldNamed("pbase", ni.PBase.Layer()), log.FieldNamed("last_layer", ni.Last))
+			window = ni.PBase.Layer()
+		}
+	}
+	if window <= ni.Evict {
 		return
 	}
 
-	window := ni.PBase.Layer() - ni.Hdist
 	for lyr := ni.Evict; lyr < window; lyr++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
 			for p := range ni.Patterns[lyr] {
+				if p == ni.PBase {
+					continue // the base of the tally of newer patterns
+				}
 				delete(ni.TSupport, p)
 				delete(ni.TComplete, p)
 				delete(ni.TEffectiveToBlocks, p)
@@ -204,9 +247,29 @@ func (ni *ninjaTortoise) evictOutOfPbase() {
 		}()
 		wg.Wait()
 	}
+	ni.evictUnindexed(window)
 	ni.Evict = window
 }
 
+// evictUnindexed drops the bookkeeping below the window that isn't indexed by layer: the support of patterns that
+// blocks vote for implicitly, and the blocks that abstain from voting on all layers
+func (ni *ninjaTortoise) evictUnindexed(window types.LayerID) {
+	for p := range ni.TSupport {
+		if p.Layer() < window && p != ni.PBase {
+			delete(ni.TSupport, p)
+		}
+	}
+	if abstaining, found := ni.TEffectiveToBlocks[zeroPattern]; found {
+		kept := abstaining[:0]
+		for _, blt := range abstaining {
+			if blt.layer() >= window {
+				kept = append(kept, blt)
+			}
+		}
+		ni.TEffectiveToBlocks[zeroPattern] = kept
+	}
+}
+
 func (ni *ninjaTortoise) processBlock(b *types.Block) {
 
 	ni.logger.Debug("process block: %s layer: %s  ", b.ID(), b.Layer())
@@ -3,10 +3,12 @@ package tortoise
 import (
 	"fmt"
 	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/events"
 	"github.com/spacemeshos/go-spacemesh/log"
 	"github.com/spacemeshos/go-spacemesh/mesh"
 	"github.com/spacemeshos/go-spacemesh/rand"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"math"
 	"os"
 	"runtime"
@@ -997,23 +999,23 @@ func TestNinjaTortoise_Recovery(t *testing.T) {
 	lg := log.New(t.Name(), "", "")
 
 	mdb := getPersistentMash()
-	alg := NewTortoise(3, mdb, 5, lg)
+	alg := NewTortoise(3, mdb, 5, 0, lg)
 	l := mesh.GenesisLayer()
 	AddLayer(mdb, l)
 
 	alg.HandleIncomingLayer(l)
-	alg.Persist()
+	alg.Snapshot()
 
 	l1 := createLayer(1, []*types.Layer{l}, 3)
 	AddLayer(mdb, l1)
 
 	alg.HandleIncomingLayer(l1)
-	alg.Persist()
+	alg.Snapshot()
 
 	l2 := createLayer(2, []*types.Layer{l1, l}, 3)
 	AddLayer(mdb, l2)
 	alg.HandleIncomingLayer(l2)
-	alg.Persist()
+	alg.Snapshot()
 
 	l31 := createLayer(3, []*types.Layer{l1, l}, 4)
 	l32 := createLayer(3, []*types.Layer{l31}, 5)
@@ -1022,19 +1024,19 @@ func TestNinjaTortoise_Recovery(t *testing.T) {
 		if r := recover(); r != nil {
 			t.Log("Recovered from", r)
 		}
-		alg := NewRecoveredTortoise(mdb, lg)
+		alg := NewRecoveredTortoise(mdb, 0, lg)
 
 		alg.HandleIncomingLayer(l2)
 
 		l3 := createLayer(3, []*types.Layer{l2, l}, 3)
 		AddLayer(mdb, l3)
 		alg.HandleIncomingLayer(l3)
-		alg.Persist()
+		alg.Snapshot()
 
 		l4 := createLayer(4, []*types.Layer{l3, l2}, 3)
 		AddLayer(mdb, l4)
 		alg.HandleIncomingLayer(l4)
-		alg.Persist()
+		alg.Snapshot()
 
 		assert.True(t, alg.LatestComplete() == 3)
 		return
@@ -1042,3 +1044,327 @@ func TestNinjaTortoise_Recovery(t *testing.T) {
 
 	alg.HandleIncomingLayer(l32) //crash
 }
+
+func TestTortoise_LayerOpinion(t *testing.T) {
+	lg := log.New(t.Name(), "", "")
+
+	mdb := getInMemMesh()
+	alg := NewTortoise(3, mdb, 5, 0, lg)
+	l := mesh.GenesisLayer()
+	AddLayer(mdb, l)
+
+	prev := l
+	for i := 1; i <= 4; i++ {
+		lyr := createLayer(types.LayerID(i), []*types.Layer{prev, l}, 3)
+		AddLayer(mdb, lyr)
+		alg.HandleIncomingLayer(lyr)
+		prev = lyr
+	}
+	assert.Equal(t, types.LayerID(3), alg.LatestComplete())
+
+	for _, layer := range []types.LayerID{1, 2} {
+		ids, err := mdb.LayerBlockIds(layer)
+		assert.NoError(t, err)
+		opinions := alg.LayerOpinion(layer)
+		assert.Len(t, opinions, len(ids))
+		for _, id := range ids {
+			opinion, ok := opinions[id]
+			assert.True(t, ok)
+			assert.Equal(t, Support, opinion.Opinion)
+			assert.True(t, opinion.Support > opinion.Against)
+		}
+	}
+
+	// the latest complete layer and the layers above it have no opinion yet
+	assert.Empty(t, alg.LayerOpinion(3))
+	assert.Empty(t, alg.LayerOpinion(4))
+}
+
+func TestTortoise_Rerun(t *testing.T) {
+	r := require.New(t)
+	lg := log.New(t.Name(), "", "")
+
+	mdb := &finalizedMeshDB{DB: getInMemMesh()}
+	alg := &tortoise{ninjaTortoise: newNinjaTortoise(3, mdb, 5, lg)}
+	l := mesh.GenesisLayer()
+	AddLayer(mdb.DB, l)
+	alg.HandleIncomingLayer(l)
+
+	prev := l
+	for i := 1; i <= 4; i++ {
+		lyr := createLayer(types.LayerID(i), []*types.Layer{prev, l}, 3)
+		AddLayer(mdb.DB, lyr)
+		alg.HandleIncomingLayer(lyr)
+		prev = lyr
+	}
+	r.Equal(types.LayerID(3), alg.LatestComplete())
+
+	switched, err := alg.Rerun()
+	r.NoError(err)
+	r.False(switched)
+
+	// corrupt the opinion of the incremental tortoise on a block above the genesis
+	var corrupted blockIDLayerTuple
+	for blt := range alg.TVote[alg.PBase] {
+		if blt.layer() > 0 {
+			corrupted = blt
+			break
+		}
+	}
+	alg.TVote[alg.PBase][corrupted] = against
+	r.Equal(Against, alg.LayerOpinion(corrupted.layer())[corrupted.id()].Opinion)
+
+	sub := events.Subscribe(events.EventNode)
+	defer sub.Close()
+	nextEvent := func() events.NodeEvent {
+		select {
+		case ev := <-sub.Events():
+			return ev.(events.NodeEvent)
+		case <-time.After(time.Second):
+			r.FailNow("no node event")
+		}
+		return events.NodeEvent{}
+	}
+
+	// the layer isn't final yet, the tortoise switches to the opinion of the rerun
+	switched, err = alg.Rerun()
+	r.NoError(err)
+	r.True(switched)
+	r.Equal(events.NodeEventTortoiseDiverged, nextEvent().Kind)
+	r.Equal(types.LayerID(3), alg.LatestComplete())
+	r.Equal(support, alg.TVote[alg.PBase][corrupted])
+	r.Equal(Support, alg.LayerOpinion(corrupted.layer())[corrupted.id()].Opinion)
+	valid, err := mdb.ContextualValidity(corrupted.id())
+	r.NoError(err)
+	r.True(valid)
+
+	// the mesh applies the layers from the pbase it was reported before the switch
+	lyr := createLayer(5, []*types.Layer{prev, l}, 3)
+	AddLayer(mdb.DB, lyr)
+	oldPbase, newPbase := alg.HandleIncomingLayer(lyr)
+	r.Equal(types.LayerID(3), oldPbase)
+	r.Equal(types.LayerID(4), newPbase)
+
+	// a rerun that diverges on a finalized layer is rejected and halts automatic reorgs
+	mdb.finalized = corrupted.layer()
+	alg.TVote[alg.PBase][corrupted] = against
+	switched, err = alg.Rerun()
+	r.Equal(errReorgHalted, err)
+	r.False(switched)
+	r.Equal(events.NodeEventTortoiseDiverged, nextEvent().Kind)
+	ev := nextEvent()
+	r.Equal(events.NodeEventReorgRejected, ev.Kind)
+	r.Equal(corrupted.layer(), ev.Layer)
+	r.True(alg.reorgHalted)
+	r.Equal(Against, alg.LayerOpinion(corrupted.layer())[corrupted.id()].Opinion)
+
+	_, err = alg.Rerun()
+	r.Equal(errReorgHalted, err)
+}
+
+type finalizedMeshDB struct {
+	*mesh.DB
+	finalized types.LayerID
+}
+
+func (db *finalizedMeshDB) FinalizedLayer() types.LayerID {
+	return db.finalized
+}
+
+func TestNinjaTortoise_FinalizedValidity(t *testing.T) {
+	r := require.New(t)
+	lg := log.New(t.Name(), "", "")
+
+	mdb := &finalizedMeshDB{DB: getInMemMesh()}
+	alg := newNinjaTortoise(3, mdb, 5, lg)
+	l := mesh.GenesisLayer()
+	AddLayer(mdb.DB, l)
+	alg.handleIncomingLayer(l)
+
+	prev := l
+	for i := 1; i <= 4; i++ {
+		lyr := createLayer(types.LayerID(i), []*types.Layer{prev, l}, 3)
+		AddLayer(mdb.DB, lyr)
+		alg.handleIncomingLayer(lyr)
+		prev = lyr
+	}
+	r.Equal(types.LayerID(3), alg.latestComplete())
+	r.NoError(alg.saveOpinion())
+
+	// the validity of blocks of layers that aren't final yet can be revised
+	var flipped blockIDLayerTuple
+	for blt := range alg.TVote[alg.PBase] {
+		flipped = blt
+		break
+	}
+	alg.TVote[alg.PBase][flipped] = against
+	mdb.finalized = flipped.layer() - 1
+	r.NoError(alg.saveOpinion())
+	valid, err := mdb.ContextualValidity(flipped.id())
+	r.NoError(err)
+	r.False(valid)
+
+	sub := events.Subscribe(events.EventNode)
+	defer sub.Close()
+
+	// revising a block of a final layer is refused and halts automatic reorgs
+	alg.TVote[alg.PBase][flipped] = support
+	mdb.finalized = flipped.layer()
+	r.NoError(alg.saveOpinion())
+	valid, err = mdb.ContextualValidity(flipped.id())
+	r.NoError(err)
+	r.False(valid)
+	r.True(alg.reorgHalted)
+	select {
+	case ev := <-sub.Events():
+		r.Equal(events.NodeEventReorgRejected, ev.(events.NodeEvent).Kind)
+		r.Equal(flipped.layer(), ev.(events.NodeEvent).Layer)
+	case <-time.After(time.Second):
+		r.Fail("no reorg rejected event")
+	}
+
+	// once halted, the validity of blocks of layers that aren't final isn't revised either
+	mdb.finalized = flipped.layer() - 1
+	r.NoError(alg.saveOpinion())
+	valid, err = mdb.ContextualValidity(flipped.id())
+	r.NoError(err)
+	r.False(valid)
+}
+
+func TestValidateReorgWindow(t *testing.T) {
+	r := require.New(t)
+	r.NoError(ValidateReorgWindow(5, 5))
+	r.NoError(ValidateReorgWindow(10, 5))
+	r.Error(ValidateReorgWindow(4, 5))
+}
+
+func TestValidateWindow(t *testing.T) {
+	r := require.New(t)
+	r.NoError(ValidateWindow(0, 5))
+	r.NoError(ValidateWindow(100, 5))
+	r.Error(ValidateWindow(5, 5))
+	r.Error(ValidateWindow(window, 5))
+}
+
+func TestNinjaTortoise_MemoryWindow(t *testing.T) {
+	r := require.New(t)
+	mdb := getInMemMesh()
+	alg := newNinjaTortoise(3, mdb, 5, log.New(t.Name(), "", ""))
+	alg.memWindow = 20
+	l := mesh.GenesisLayer()
+	AddLayer(mdb, l)
+	alg.handleIncomingLayer(l)
+
+	// blocks that don't vote never make a layer complete
+	for i := 1; i <= 60; i++ {
+		lyr := createLayer(types.LayerID(i), nil, 3)
+		AddLayer(mdb, lyr)
+		alg.handleIncomingLayer(lyr)
+	}
+	// the layers above the pbase are needed to advance it, they aren't evicted
+	r.Equal(zeroPattern, alg.PBase)
+	r.Equal(types.LayerID(0), alg.Evict)
+	r.Len(alg.TEffective, 60*3)
+	r.Len(alg.TExplicit, 60*3+len(l.Blocks()))
+}
+
+func TestNinjaTortoise_MemoryWindowStall(t *testing.T) {
+	r := require.New(t)
+	mdb := getInMemMesh()
+	alg := newNinjaTortoise(3, mdb, 5, log.New(t.Name(), "", ""))
+	alg.memWindow = 6
+	l := mesh.GenesisLayer()
+	AddLayer(mdb, l)
+	alg.handleIncomingLayer(l)
+
+	layers := []*types.Layer{l}
+	for i := 1; i <= 30; i++ {
+		var lyr *types.Layer
+		prev := layers[len(layers)-1]
+		switch {
+		case i <= 5:
+			lyr = createLayer(types.LayerID(i), []*types.Layer{prev, l}, 3)
+		case i <= 8:
+			// layers too small to verify the layers they vote on stall the pbase
+			lyr = createLayer(types.LayerID(i), []*types.Layer{prev, l}, 1)
+		default:
+			// the blocks that follow vote on all the layers within hdist
+			lyr = createLayer(types.LayerID(i), append(append([]*types.Layer{prev}, layers[i-5:i-1]...), l), 3)
+		}
+		AddLayer(mdb, lyr)
+		alg.handleIncomingLayer(lyr)
+		layers = append(layers, lyr)
+		r.True(alg.Evict <= alg.PBase.Layer())
+		if i == 14 {
+			// the pbase stalled longer than the memory window
+			r.Equal(types.LayerID(4), alg.PBase.Layer())
+		}
+	}
+	r.Equal(types.LayerID(29), alg.PBase.Layer())
+	r.Equal(types.LayerID(29-5), alg.Evict)
+}
+
+func TestTortoise_LayerOpinion_Evicted(t *testing.T) {
+	r := require.New(t)
+	lg := log.New(t.Name(), "", "")
+
+	mdb := getInMemMesh()
+	alg := NewTortoise(3, mdb, 5, 0, lg)
+	l := mesh.GenesisLayer()
+	AddLayer(mdb, l)
+
+	prev := l
+	for i := 1; i <= 20; i++ {
+		lyr := createLayer(types.LayerID(i), []*types.Layer{prev, l}, 3)
+		AddLayer(mdb, lyr)
+		alg.HandleIncomingLayer(lyr)
+		r.NoError(alg.Persist())
+		prev = lyr
+	}
+	trtl := alg.(*tortoise)
+	r.True(trtl.Evict > 2)
+
+	ids, err := mdb.LayerBlockIds(2)
+	r.NoError(err)
+	opinions := alg.LayerOpinion(2)
+	r.Len(opinions, len(ids))
+	for _, id := range ids {
+		r.Equal(Support, opinions[id].Opinion)
+	}
+}
+
+func TestTortoise_Snapshot(t *testing.T) {
+	r := require.New(t)
+	lg := log.New(t.Name(), "", "")
+
+	mdb := getInMemMesh()
+	alg := NewTortoise(3, mdb, 5, 0, lg)
+	l := mesh.GenesisLayer()
+	AddLayer(mdb, l)
+
+	// the opinion is saved on every layer, the state every snapshotInterval layers
+	prev := l
+	for i := 1; i <= snapshotInterval+3; i++ {
+		lyr := createLayer(types.LayerID(i), []*types.Layer{prev, l}, 3)
+		AddLayer(mdb, lyr)
+		alg.HandleIncomingLayer(lyr)
+		r.NoError(alg.Persist())
+		r.NoError(mdb.PersistProcessedLayer(lyr.Index()))
+		prev = lyr
+	}
+	snapshot, err := RecoverTortoise(mdb)
+	r.NoError(err)
+	r.Equal(types.LayerID(snapshotInterval), snapshot.(*ninjaTortoise).Last)
+
+	// the layers processed after the snapshot are processed again on restart
+	recovered := NewRecoveredTortoise(mdb, 0, lg)
+	r.Equal(types.LayerID(snapshotInterval+3), recovered.(*tortoise).Last)
+	r.Equal(alg.LatestComplete(), recovered.LatestComplete())
+	r.Equal(alg.LayerOpinion(snapshotInterval), recovered.LayerOpinion(snapshotInterval))
+
+	// a snapshot is taken on demand, e.g. on shutdown
+	r.NoError(alg.Snapshot())
+	snapshot, err = RecoverTortoise(mdb)
+	r.NoError(err)
+	r.Equal(types.LayerID(snapshotInterval+3), snapshot.(*ninjaTortoise).Last)
+}
@@ -0,0 +1,174 @@
+package tortoise
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	dbs "github.com/spacemeshos/go-spacemesh/database"
+	"github.com/spacemeshos/go-spacemesh/events"
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/mesh"
+)
+
+// The tortoise counts votes incrementally, layer by layer and late block by late block, so an opinion that went wrong,
+// e.g. because blocks arrived late or out of order, is carried forward. A rerun recomputes the opinion from genesis,
+// from the blocks in the mesh, and the tortoise switches to it if it diverges from the incremental one, like it revises
+// its opinion when late blocks arrive. The switch is bounded by the reorg window: a rerun that diverges on a finalized
+// layer is rejected, the node keeps its opinion, raises a critical event and halts automatic reorgs until it restarts.
+// Reruns need the whole history of the mesh: pruned nodes and nodes restored from a checkpoint can't run them.
+
+// Results of a rerun, as reported to the metrics
+const (
+	rerunAgreed   = "agreed"
+	rerunDiverged = "diverged"
+	rerunFailed   = "failed"
+	rerunRejected = "rejected"
+)
+
+var errReorgHalted = errors.New("automatic reorgs are halted")
+
+// Rerun recomputes the opinion of the tortoise from genesis and switches to it if it diverges from the current one.
+// Most of the work is done without blocking the incremental tortoise. It returns true if the opinion was switched.
+func (trtl *tortoise) Rerun() (bool, error) {
+	start := time.Now()
+	switched, err := trtl.rerun()
+	result := rerunAgreed
+	if err == errReorgHalted {
+		result = rerunRejected
+	} else if err != nil {
+		result = rerunFailed
+	} else if switched {
+		result = rerunDiverged
+	}
+	rerunCount.With("result", result).Add(1)
+	rerunDuration.Set(time.Since(start).Seconds())
+	return switched, err
+}
+
+func (trtl *tortoise) rerun() (bool, error) {
+	trtl.mutex.Lock()
+	if trtl.reorgHalted {
+		trtl.mutex.Unlock()
+		return false, errReorgHalted
+	}
+	last, layerSize, hdist, memWindow, logger := trtl.Last, trtl.AvgLayerSize, trtl.Hdist, trtl.memWindow, trtl.logger
+	trtl.mutex.Unlock()
+
+	fresh := newNinjaTortoise(layerSize, trtl.db, int(hdist), logger.WithName("rerun").WithOptions(log.Nop))
+	fresh.memWindow = memWindow
+	fresh.handleIncomingLayer(mesh.GenesisLayer())
+	next, err := fresh.feedLayers(types.GetEffectiveGenesis()+1, last)
+	if err != nil {
+		return false, err
+	}
+
+	trtl.mutex.Lock()
+	defer trtl.mutex.Unlock()
+	// catch up with the layers the incremental tortoise handled meanwhile
+	if _, err := fresh.feedLayers(next, trtl.Last); err != nil {
+		return false, err
+	}
+	if !trtl.diverges(fresh) {
+		logger.With().Info("tortoise rerun agrees with the current opinion", log.FieldNamed("pbase", trtl.latestComplete()))
+		return false, nil
+	}
+	events.Publish(events.NodeEvent{
+		Kind:  events.NodeEventTortoiseDiverged,
+		Layer: trtl.latestComplete(),
+		Details: fmt.Sprintf("the tortoise rerun from genesis verified layer %v and diverges from the opinion of the node",
+			fresh.latestComplete()),
+	})
+	if diverged, finalized := trtl.divergedLayer(fresh), trtl.db.FinalizedLayer(); diverged <= finalized {
+		trtl.reorgHalted = true
+		logger.With().Error("tortoise rerun diverges on a finalized layer, rejecting it and halting automatic reorgs",
+			log.FieldNamed("diverged_layer", diverged),
+			log.FieldNamed("finalized_layer", finalized))
+		events.Publish(events.NodeEvent{
+			Kind:  events.NodeEventReorgRejected,
+			Layer: diverged,
+			Details: fmt.Sprintf("the tortoise rerun from genesis revises finalized layer %v, automatic reorgs are halted until the node restarts",
+				diverged),
+		})
+		return false, errReorgHalted
+	}
+	logger.With().Warning("tortoise rerun diverges from the current opinion, switching to it",
+		log.FieldNamed("old_pbase", trtl.latestComplete()),
+		log.FieldNamed("new_pbase", fresh.latestComplete()))
+	if trtl.reported == 0 {
+		trtl.reported = trtl.latestComplete()
+	}
+	fresh.logger = logger
+	trtl.ninjaTortoise = fresh
+	if err := trtl.saveOpinion(); err != nil {
+		return true, err
+	}
+	// the state of the switched tortoise isn't processed again on restart
+	return true, trtl.snapshot()
+}
+
+// feedLayers handles the blocks of the layers in the range in order, skipping layers without blocks like the mesh does.
+// It returns the layer that follows the range.
+func (ni *ninjaTortoise) feedLayers(from, to types.LayerID) (types.LayerID, error) {
+	for layer := from; layer <= to; layer++ {
+		ids, err := ni.db.LayerBlockIds(layer)
+		if err == dbs.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return layer, fmt.Errorf("failed to read the blocks of layer %v: %v", layer, err)
+		}
+		if len(ids) == 0 {
+			continue
+		}
+		blocks := make([]*types.Block, 0, len(ids))
+		for _, id := range ids {
+			block, err := ni.db.GetBlock(id)
+			if err != nil {
+				return layer, fmt.Errorf("failed to read block %v of layer %v: %v", id, layer, err)
+			}
+			blocks = append(blocks, block)
+		}
+		ni.handleIncomingLayer(types.NewExistingLayer(layer, blocks))
+	}
+	return to + 1, nil
+}
+
+// divergedLayer returns the lowest layer that the other tortoise votes on differently, or that only one of them
+// verified if their pbases differ
+func (ni *ninjaTortoise) divergedLayer(other *ninjaTortoise) types.LayerID {
+	diverged := ni.PBase.Layer()
+	if other.PBase.Layer() < diverged {
+		diverged = other.PBase.Layer()
+	}
+	votes, otherVotes := ni.TVote[ni.PBase], other.TVote[other.PBase]
+	for blt, vote := range votes {
+		if otherVote, found := otherVotes[blt]; (!found || otherVote != vote) && blt.layer() < diverged {
+			diverged = blt.layer()
+		}
+	}
+	for blt := range otherVotes {
+		if _, found := votes[blt]; !found && blt.layer() < diverged {
+			diverged = blt.layer()
+		}
+	}
+	return diverged
+}
+
+// diverges returns true if the pbase of the other tortoise or its opinion on the blocks below it differ
+func (ni *ninjaTortoise) diverges(other *ninjaTortoise) bool {
+	if ni.PBase.Layer() != other.PBase.Layer() {
+		return true
+	}
+	votes, otherVotes := ni.TVote[ni.PBase], other.TVote[other.PBase]
+	if len(votes) != len(otherVotes) {
+		return true
+	}
+	for blt, vote := range votes {
+		if otherVote, found := otherVotes[blt]; !found || otherVote != vote {
+			return true
+		}
+	}
+	return false
+}